@@ -0,0 +1,220 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Keyboard operation and screen reader labels. Every shortcut is registered with both Cmd and
+// Ctrl like the File/Find ones, so the same keys work on macOS, Linux and Windows. Chart
+// navigation keeps a focused chart section (Cmd/Ctrl+↓/↑), marked with ▶ in its title, which
+// the export, detach and info shortcuts act on. Charts announce their title, batch range and the
+// crosshair readout to VoiceOver (fyne.Accessible; build with -tags accessibility on macOS).
+
+// keyboardShortcut is one entry of the Keyboard Shortcuts dialog.
+type keyboardShortcut struct {
+	keys, action string
+}
+
+// viewerShortcuts lists the main-window shortcuts; "Cmd/Ctrl" is either modifier.
+var viewerShortcuts = []keyboardShortcut{
+	{"Cmd/Ctrl+O", "Open a results file"},
+	{"Cmd/Ctrl+R", "Reload"},
+	{"Cmd/Ctrl+W", "Close the window"},
+	{"Cmd/Ctrl+D", "Diagnostics for the selected batch"},
+	{"Cmd/Ctrl+F", "Find a chart; Cmd/Ctrl+G / Shift+Cmd/Ctrl+G next / previous match"},
+	{"Cmd/Ctrl+1 / 2 / 3", "Batches / BatchAvg Charts / Detailed Batch Charts tab"},
+	{"Cmd/Ctrl+↓ / ↑", "Focus the next / previous visible chart (BatchAvg Charts)"},
+	{"Cmd/Ctrl+E", "Export the focused chart as PNG"},
+	{"Cmd/Ctrl+Return", "Open the focused chart in its own window (PgUp/PgDn, Esc there)"},
+	{"Cmd/Ctrl+I", "Info for the focused chart"},
+	{"Shift+Cmd/Ctrl+1 / 2 / 3", "Show or hide the Overall / IPv4 / IPv6 series"},
+	{"Cmd/Ctrl+/", "This list"},
+}
+
+// addShortcut registers fn for key with Cmd and with Ctrl, plus extra modifiers.
+func addShortcut(canv fyne.Canvas, key fyne.KeyName, extra fyne.KeyModifier, fn func()) {
+	for _, mod := range []fyne.KeyModifier{fyne.KeyModifierSuper, fyne.KeyModifierControl} {
+		canv.AddShortcut(&desktop.CustomShortcut{KeyName: key, Modifier: mod | extra}, func(fyne.Shortcut) { fn() })
+	}
+}
+
+// registerNavigationShortcuts adds the tab, chart and series shortcuts to the main window.
+func registerNavigationShortcuts(state *uiState, canv fyne.Canvas) {
+	for i, key := range []fyne.KeyName{fyne.Key1, fyne.Key2, fyne.Key3} {
+		i := i
+		addShortcut(canv, key, 0, func() {
+			if state.tabs != nil && i < len(state.tabs.Items) {
+				state.tabs.SelectIndex(i)
+			}
+		})
+		addShortcut(canv, key, fyne.KeyModifierShift, func() { toggleSeries(state, i) })
+	}
+	addShortcut(canv, fyne.KeyDown, 0, func() { stepChartFocus(state, 1) })
+	addShortcut(canv, fyne.KeyUp, 0, func() { stepChartFocus(state, -1) })
+	addShortcut(canv, fyne.KeyE, 0, func() {
+		if i := focusedChartIndex(state); i >= 0 {
+			img, _ := sectionChart(state.chartRefs[i].section)
+			exportChartPNG(state, img, chartTitleToID(state.chartRefs[i].title)+"_chart.png")
+		}
+	})
+	addShortcut(canv, fyne.KeyReturn, 0, func() {
+		if i := focusedChartIndex(state); i >= 0 {
+			openDetachedChart(state, i)
+		}
+	})
+	addShortcut(canv, fyne.KeyI, 0, func() {
+		if i := focusedChartIndex(state); i >= 0 {
+			ref := state.chartRefs[i]
+			showChartInfoWindow(state, tr(ref.title)+" – "+tr("Info"), ref.help)
+		}
+	})
+	addShortcut(canv, fyne.KeySlash, 0, func() { showKeyboardShortcuts(state) })
+}
+
+// toggleSeries flips the Overall (0), IPv4 (1) or IPv6 (2) toolbar checkbox; its OnChanged
+// redraws and saves as a click would.
+func toggleSeries(state *uiState, i int) {
+	if i < 0 || i >= len(state.seriesChecks) || state.seriesChecks[i] == nil {
+		return
+	}
+	chk := state.seriesChecks[i]
+	chk.SetChecked(!chk.Checked)
+}
+
+// focusedChartIndex is the chartRefs index of the focused chart section, -1 when none (or it
+// is hidden now).
+func focusedChartIndex(state *uiState) int {
+	if state == nil || state.focusedChart == nil {
+		return -1
+	}
+	for i, ref := range state.chartRefs {
+		if ref.section == state.focusedChart && ref.section.Visible() {
+			return i
+		}
+	}
+	return -1
+}
+
+// nextVisibleChart returns the index of the next visible section after from in direction dir
+// (wrapping around), -1 when no section is visible. from -1 starts at the first (dir>0) or
+// last (dir<0) section.
+func nextVisibleChart(visible []bool, from, dir int) int {
+	n := len(visible)
+	if n == 0 {
+		return -1
+	}
+	if dir >= 0 {
+		dir = 1
+	} else {
+		dir = -1
+	}
+	i := from
+	if from < 0 {
+		i = -1
+		if dir < 0 {
+			i = n
+		}
+	}
+	for k := 0; k < n; k++ {
+		i = ((i+dir)%n + n) % n
+		if visible[i] {
+			return i
+		}
+	}
+	return -1
+}
+
+// stepChartFocus moves the chart focus and scrolls the section into view, showing the
+// BatchAvg Charts tab first.
+func stepChartFocus(state *uiState, dir int) {
+	if state == nil || len(state.chartRefs) == 0 {
+		return
+	}
+	visible := make([]bool, len(state.chartRefs))
+	for i, ref := range state.chartRefs {
+		visible[i] = ref.section != nil && ref.section.Visible()
+	}
+	i := nextVisibleChart(visible, focusedChartIndex(state), dir)
+	if i < 0 {
+		return
+	}
+	if state.tabs != nil && state.tabs.SelectedIndex() != 1 {
+		state.tabs.SelectIndex(1)
+	}
+	setChartFocus(state, i)
+	scrollToChartSection(state, i)
+}
+
+// setChartFocus marks section i as focused in its title ("▶ Title").
+func setChartFocus(state *uiState, i int) {
+	for _, ref := range state.chartRefs {
+		if ref.section == state.focusedChart && ref.label != nil {
+			ref.label.SetText(tr(ref.title))
+		}
+	}
+	ref := state.chartRefs[i]
+	state.focusedChart = ref.section
+	if ref.label != nil {
+		ref.label.SetText("▶ " + tr(ref.title))
+	}
+}
+
+// showKeyboardShortcuts lists viewerShortcuts.
+func showKeyboardShortcuts(state *uiState) {
+	if state == nil || state.window == nil {
+		return
+	}
+	var b strings.Builder
+	for _, s := range viewerShortcuts {
+		b.WriteString(fmt.Sprintf("%-26s %s\n", s.keys, s.action))
+	}
+	grid := widget.NewTextGrid()
+	grid.SetText(strings.TrimRight(b.String(), "\n"))
+	dialog.ShowCustom("Keyboard Shortcuts", "Close", grid, state.window)
+}
+
+// AccessibilityLabel describes the chart under the overlay for screen readers: its title, the
+// batches it covers and, while the crosshair is on a batch, the readout.
+func (c *crosshairOverlay) AccessibilityLabel() string {
+	title := c.a11yTitle
+	if title == "" {
+		title = c.mode
+	}
+	parts := []string{title + " chart"}
+	if c.state != nil {
+		if rows := filteredSummaries(c.state); len(rows) > 0 {
+			parts = append(parts, fmt.Sprintf("%d batches, %s to %s", len(rows), rows[0].RunTag, rows[len(rows)-1].RunTag))
+		} else {
+			parts = append(parts, "no batches")
+		}
+	}
+	if c.hovering && len(c.readout) > 0 {
+		parts = append(parts, strings.Join(c.readout, ", "))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// AccessibilityRole reads a chart as static text.
+func (c *crosshairOverlay) AccessibilityRole() fyne.AccessibleRole { return fyne.AccessibleRoleText }
+
+// labeledIconButton is an icon-only button with a spoken label (widget.Button would announce
+// the icon's resource name).
+type labeledIconButton struct {
+	widget.Button
+	label string
+}
+
+func newLabeledIconButton(label string, icon fyne.Resource, tapped func()) *labeledIconButton {
+	b := &labeledIconButton{label: label}
+	b.Icon, b.OnTapped = icon, tapped
+	b.ExtendBaseWidget(b)
+	return b
+}
+
+func (b *labeledIconButton) AccessibilityLabel() string { return b.label }
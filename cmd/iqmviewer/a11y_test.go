@@ -0,0 +1,35 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/iafilius/InternetQualityMonitor/src/analysis"
+)
+
+func TestNextVisibleChart(t *testing.T) {
+	visible := []bool{true, false, true, true, false}
+	steps := []struct{ from, dir, want int }{
+		{-1, 1, 0}, {-1, -1, 3}, {0, 1, 2}, {3, 1, 0}, {0, -1, 3}, {2, -1, 0},
+	}
+	for _, s := range steps {
+		if got := nextVisibleChart(visible, s.from, s.dir); got != s.want {
+			t.Errorf("next(%d, %d) = %d, want %d", s.from, s.dir, got, s.want)
+		}
+	}
+	if nextVisibleChart([]bool{false, false}, -1, 1) != -1 || nextVisibleChart(nil, 0, 1) != -1 {
+		t.Fatalf("no visible chart means no focus")
+	}
+}
+
+func TestCrosshairOverlayAccessibilityLabel(t *testing.T) {
+	s := &uiState{summaries: []analysis.BatchSummary{{RunTag: "20261001_100000"}, {RunTag: "20261002_100000"}}, situation: "All"}
+	c := &crosshairOverlay{state: s, mode: "speed", a11yTitle: "Speed – Average"}
+	if got := c.AccessibilityLabel(); got != "Speed – Average chart; 2 batches, 20261001_100000 to 20261002_100000" {
+		t.Fatalf("label = %q", got)
+	}
+	c.hovering, c.readout = true, []string{"RunTag: 20261002_100000", "Overall: 48.2 Mbps"}
+	if got := c.AccessibilityLabel(); !strings.HasSuffix(got, "; RunTag: 20261002_100000, Overall: 48.2 Mbps") {
+		t.Fatalf("hover label = %q", got)
+	}
+}
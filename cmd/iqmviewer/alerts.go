@@ -0,0 +1,317 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/iafilius/InternetQualityMonitor/src/analysis"
+)
+
+// alertRule fires a desktop notification when Metric compared with Threshold holds for the
+// newest Consecutive batches. Rules are edge-triggered: after firing they stay quiet until the
+// condition clears again.
+type alertRule struct {
+	Enabled     bool    `json:"enabled"`
+	Metric      string  `json:"metric"` // key into alertMetrics
+	Op          string  `json:"op"`     // ">" or "<"
+	Threshold   float64 `json:"threshold"`
+	Consecutive int     `json:"consecutive"`
+}
+
+type alertMetric struct {
+	key   string
+	label string
+	get   func(analysis.BatchSummary) (float64, bool)
+}
+
+// alertMetrics are the batch values a rule can watch; speeds are in kbps regardless of the
+// display unit so saved rules do not change meaning with Settings → Speed Unit.
+var alertMetrics = []alertMetric{
+	{"avg_speed_kbps", "Avg speed (kbps)", func(b analysis.BatchSummary) (float64, bool) { return b.AvgSpeed, b.AvgSpeed > 0 }},
+	{"p50_speed_kbps", "P50 speed (kbps)", func(b analysis.BatchSummary) (float64, bool) { v := analysis.BatchP50Speed(b); return v, v > 0 }},
+	{"avg_ttfb_ms", "Avg TTFB (ms)", func(b analysis.BatchSummary) (float64, bool) { return b.AvgTTFB, b.AvgTTFB > 0 }},
+	{"p95_ttfb_ms", "P95 TTFB (ms)", func(b analysis.BatchSummary) (float64, bool) { return b.AvgP95TTFBMs, b.AvgP95TTFBMs > 0 }},
+	{"error_rate_pct", "Error rate (%)", func(b analysis.BatchSummary) (float64, bool) {
+		if b.Lines == 0 {
+			return 0, false
+		}
+		return float64(b.ErrorLines) / float64(b.Lines) * 100, true
+	}},
+	{"stall_rate_pct", "Stall rate (%)", func(b analysis.BatchSummary) (float64, bool) { return b.StallRatePct, b.Lines > 0 }},
+	{"partial_body_rate_pct", "Partial body rate (%)", func(b analysis.BatchSummary) (float64, bool) { return b.PartialBodyRatePct, b.Lines > 0 }},
+	{"udp_blocked_rate_pct", "UDP blocked rate (%)", func(b analysis.BatchSummary) (float64, bool) { return b.UDPBlockedRatePct, b.QUICProbeLines > 0 }},
+	{"rate_limited_rate_pct", "Rate-limited rate (%)", func(b analysis.BatchSummary) (float64, bool) { return b.RateLimitedRatePct, b.Lines > 0 }},
+	{"content_corruption_rate_pct", "Content corruption rate (%)", func(b analysis.BatchSummary) (float64, bool) {
+		return b.ContentCorruptionRatePct, b.IntegrityCheckedLines > 0
+	}},
+}
+
+func alertMetricByKey(key string) (alertMetric, bool) {
+	for _, m := range alertMetrics {
+		if m.key == key {
+			return m, true
+		}
+	}
+	return alertMetric{}, false
+}
+
+// describe renders the rule like "P95 TTFB (ms) > 300 for 3 batches".
+func (r alertRule) describe() string {
+	label := r.Metric
+	if m, ok := alertMetricByKey(r.Metric); ok {
+		label = m.label
+	}
+	n := r.Consecutive
+	if n < 1 {
+		n = 1
+	}
+	unit := "batches"
+	if n == 1 {
+		unit = "batch"
+	}
+	return fmt.Sprintf("%s %s %s for %d %s", label, r.Op, strconv.FormatFloat(r.Threshold, 'f', -1, 64), n, unit)
+}
+
+// tripped reports whether the newest Consecutive batches of rows (oldest first) all meet the
+// rule; a batch without a value for the metric breaks the run. It returns the newest value.
+func (r alertRule) tripped(rows []analysis.BatchSummary) (bool, float64) {
+	m, ok := alertMetricByKey(r.Metric)
+	n := r.Consecutive
+	if n < 1 {
+		n = 1
+	}
+	if !ok || len(rows) < n {
+		return false, 0
+	}
+	var newest float64
+	for i := len(rows) - 1; i >= len(rows)-n; i-- {
+		v, has := m.get(rows[i])
+		if !has {
+			return false, 0
+		}
+		if i == len(rows)-1 {
+			newest = v
+		}
+		switch r.Op {
+		case ">":
+			if !(v > r.Threshold) {
+				return false, 0
+			}
+		case "<":
+			if !(v < r.Threshold) {
+				return false, 0
+			}
+		default:
+			return false, 0
+		}
+	}
+	return true, newest
+}
+
+// loadAlertRules reads the rules saved under the "alertRules" preference.
+func loadAlertRules(state *uiState) {
+	state.alertRules = nil
+	if state.app == nil {
+		return
+	}
+	if s := state.app.Preferences().String("alertRules"); s != "" {
+		if err := json.Unmarshal([]byte(s), &state.alertRules); err != nil {
+			logf(slog.LevelWarn, "viewer", "alert rules: %v", err)
+		}
+	}
+}
+
+func saveAlertRules(state *uiState) {
+	if state.app == nil {
+		return
+	}
+	b, _ := json.Marshal(state.alertRules)
+	state.app.Preferences().SetString("alertRules", string(b))
+}
+
+// checkAlerts evaluates the rules against the loaded batches after a Follow-mode reload and
+// notifies for rules that newly tripped.
+func checkAlerts(state *uiState) {
+	if state.alertTripped == nil {
+		state.alertTripped = map[string]bool{}
+	}
+	for _, msg := range evaluateAlerts(state.alertRules, state.summaries, state.alertTripped) {
+		logf(slog.LevelInfo, "viewer", "alert: %s", msg)
+		if state.app != nil {
+			state.app.SendNotification(fyne.NewNotification("Internet Quality alert", msg))
+		}
+	}
+}
+
+// evaluateAlerts returns one message per enabled rule that trips now but did not at the
+// previous evaluation; tripped carries that state between calls (keyed by the rule text).
+func evaluateAlerts(rules []alertRule, rows []analysis.BatchSummary, tripped map[string]bool) []string {
+	var out []string
+	seen := map[string]bool{}
+	for _, r := range rules {
+		if !r.Enabled {
+			continue
+		}
+		key := r.describe()
+		seen[key] = true
+		on, v := r.tripped(rows)
+		if on && !tripped[key] {
+			msg := fmt.Sprintf("%s (now %.1f", key, v)
+			if len(rows) > 0 {
+				msg += ", " + rows[len(rows)-1].RunTag
+			}
+			out = append(out, msg+")")
+		}
+		tripped[key] = on
+	}
+	for k := range tripped {
+		if !seen[k] {
+			delete(tripped, k)
+		}
+	}
+	return out
+}
+
+// setFollow starts or stops Follow mode: the results file is polled every followInterval and
+// reloaded when its size or modification time changed; alert rules are checked after each
+// reload. Starting it primes the alert state so batches already on disk do not notify.
+func setFollow(state *uiState, on bool, fileLabel *widget.Label) {
+	if state.followStop != nil {
+		close(state.followStop)
+		state.followStop = nil
+	}
+	state.follow = on
+	if state.app != nil {
+		state.app.Preferences().SetBool("follow", on)
+	}
+	if !on {
+		return
+	}
+	state.alertTripped = map[string]bool{}
+	evaluateAlerts(state.alertRules, state.summaries, state.alertTripped)
+	stop := make(chan struct{})
+	state.followStop = stop
+	var lastSize int64
+	var lastMod time.Time
+	if fi, err := os.Stat(state.filePath); err == nil {
+		lastSize, lastMod = fi.Size(), fi.ModTime()
+	}
+	go func() {
+		t := time.NewTicker(followInterval)
+		defer t.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-t.C:
+			}
+			fi, err := os.Stat(state.filePath)
+			if err != nil || (fi.Size() == lastSize && fi.ModTime().Equal(lastMod)) {
+				continue
+			}
+			lastSize, lastMod = fi.Size(), fi.ModTime()
+			fyne.Do(func() {
+				if !state.follow {
+					return
+				}
+				loadAllThen(state, fileLabel, func() { checkAlerts(state) })
+			})
+		}
+	}()
+}
+
+const followInterval = 5 * time.Second
+
+// openAlertsDialog edits the alert rules: one row per rule (enabled, metric, comparator,
+// threshold, consecutive batches), with Add, Test notification and Save.
+func openAlertsDialog(state *uiState) {
+	rules := append([]alertRule(nil), state.alertRules...)
+	labels := make([]string, len(alertMetrics))
+	for i, m := range alertMetrics {
+		labels[i] = m.label
+	}
+	rowsBox := container.NewVBox()
+	var rebuild func()
+	rebuild = func() {
+		rowsBox.Objects = nil
+		if len(rules) == 0 {
+			rowsBox.Add(widget.NewLabel("No rules yet — press Add rule."))
+		}
+		for i := range rules {
+			r := &rules[i]
+			en := widget.NewCheck("", func(b bool) { r.Enabled = b })
+			en.SetChecked(r.Enabled)
+			metric := widget.NewSelect(labels, func(v string) {
+				for _, m := range alertMetrics {
+					if m.label == v {
+						r.Metric = m.key
+					}
+				}
+			})
+			if m, ok := alertMetricByKey(r.Metric); ok {
+				metric.SetSelected(m.label)
+			}
+			op := widget.NewSelect([]string{">", "<"}, func(v string) { r.Op = v })
+			op.SetSelected(r.Op)
+			thr := widget.NewEntry()
+			thr.SetText(strconv.FormatFloat(r.Threshold, 'f', -1, 64))
+			thr.OnChanged = func(s string) {
+				if v, err := strconv.ParseFloat(strings.TrimSpace(s), 64); err == nil {
+					r.Threshold = v
+				}
+			}
+			cons := widget.NewEntry()
+			cons.SetText(strconv.Itoa(r.Consecutive))
+			cons.OnChanged = func(s string) {
+				if v, err := strconv.Atoi(strings.TrimSpace(s)); err == nil && v >= 1 && v <= 100 {
+					r.Consecutive = v
+				}
+			}
+			idx := i
+			del := widget.NewButton("Remove", func() {
+				rules = append(rules[:idx], rules[idx+1:]...)
+				rebuild()
+			})
+			rowsBox.Add(container.NewHBox(en, metric, op, container.NewGridWrap(fyne.NewSize(90, 36), thr),
+				widget.NewLabel("for"), container.NewGridWrap(fyne.NewSize(56, 36), cons), widget.NewLabel("batches"), del))
+		}
+		rowsBox.Refresh()
+	}
+	rebuild()
+	addBtn := widget.NewButton("Add rule", func() {
+		rules = append(rules, alertRule{Enabled: true, Metric: "p95_ttfb_ms", Op: ">", Threshold: float64(state.slaTTFBThresholdMs), Consecutive: 3})
+		rebuild()
+	})
+	testBtn := widget.NewButton("Test notification", func() {
+		if state.app != nil {
+			state.app.SendNotification(fyne.NewNotification("Internet Quality alert", "Test notification from iqmviewer"))
+		}
+	})
+	note := widget.NewLabel("Rules are checked after each Follow-mode reload (File → Follow); speeds are in kbps.")
+	note.Wrapping = fyne.TextWrapWord
+	content := container.NewBorder(note, container.NewHBox(addBtn, testBtn), nil, nil, container.NewVScroll(rowsBox))
+	d := dialog.NewCustomConfirm("Alerts", "Save", "Cancel", content, func(ok bool) {
+		if !ok {
+			return
+		}
+		state.alertRules = rules
+		saveAlertRules(state)
+		if state.follow {
+			// re-prime so editing a rule does not notify for the data already shown
+			state.alertTripped = map[string]bool{}
+			evaluateAlerts(state.alertRules, state.summaries, state.alertTripped)
+		}
+	}, state.window)
+	d.Resize(fyne.NewSize(760, 420))
+	d.Show()
+}
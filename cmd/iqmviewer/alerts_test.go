@@ -0,0 +1,47 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/iafilius/InternetQualityMonitor/src/analysis"
+)
+
+func TestAlertRuleTripped(t *testing.T) {
+	rows := []analysis.BatchSummary{{RunTag: "a", AvgP95TTFBMs: 500}, {RunTag: "b", AvgP95TTFBMs: 100}, {RunTag: "c", AvgP95TTFBMs: 400}, {RunTag: "d", AvgP95TTFBMs: 450}}
+	r := alertRule{Enabled: true, Metric: "p95_ttfb_ms", Op: ">", Threshold: 300, Consecutive: 2}
+	if on, v := r.tripped(rows); !on || v != 450 {
+		t.Fatalf("want tripped with newest 450, got %v %v", on, v)
+	}
+	r.Consecutive = 3
+	if on, _ := r.tripped(rows); on {
+		t.Fatalf("run of 3 is broken by batch b")
+	}
+	r = alertRule{Enabled: true, Metric: "avg_speed_kbps", Op: "<", Threshold: 1000, Consecutive: 1}
+	if on, _ := r.tripped(rows); on {
+		t.Fatalf("batches without a speed value must not trip a '<' rule")
+	}
+	if got := (alertRule{Metric: "error_rate_pct", Op: ">", Threshold: 2.5, Consecutive: 1}).describe(); got != "Error rate (%) > 2.5 for 1 batch" {
+		t.Fatalf("describe: %q", got)
+	}
+}
+
+func TestEvaluateAlerts_EdgeTriggered(t *testing.T) {
+	rules := []alertRule{{Enabled: true, Metric: "stall_rate_pct", Op: ">", Threshold: 5, Consecutive: 1}, {Enabled: false, Metric: "stall_rate_pct", Op: ">", Threshold: 0, Consecutive: 1}}
+	tripped := map[string]bool{}
+	bad := []analysis.BatchSummary{{RunTag: "r1", Lines: 10, StallRatePct: 10}}
+	msgs := evaluateAlerts(rules, bad, tripped)
+	if len(msgs) != 1 || !strings.Contains(msgs[0], "r1") {
+		t.Fatalf("want one notification naming the batch, got %v", msgs)
+	}
+	if msgs := evaluateAlerts(rules, append(bad, analysis.BatchSummary{RunTag: "r2", Lines: 10, StallRatePct: 12}), tripped); len(msgs) != 0 {
+		t.Fatalf("a rule that stays tripped must not notify again: %v", msgs)
+	}
+	ok := []analysis.BatchSummary{{RunTag: "r3", Lines: 10, StallRatePct: 1}}
+	if msgs := evaluateAlerts(rules, ok, tripped); len(msgs) != 0 {
+		t.Fatalf("cleared rule must not notify: %v", msgs)
+	}
+	if msgs := evaluateAlerts(rules, bad, tripped); len(msgs) != 1 {
+		t.Fatalf("rule must re-arm after clearing, got %v", msgs)
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/iafilius/InternetQualityMonitor/src/analysis"
+)
+
+// anonymizedFileName suggests the export name next to the loaded results file.
+func anonymizedFileName(path string) string {
+	base := filepath.Base(path)
+	for _, ext := range []string{".gz", ".zst", ".jsonl"} {
+		base = strings.TrimSuffix(base, ext)
+	}
+	if base == "" || base == "." || base == string(filepath.Separator) {
+		base = "monitor_results"
+	}
+	return base + ".anonymized.jsonl"
+}
+
+// exportAnonymizedResults writes a copy of the loaded results file with URLs, hosts, IPs, SSIDs
+// and proxy/VPN names pseudonymized (analysis.Anonymizer). The optional salt keeps pseudonyms
+// consistent between exports; without one they differ every time.
+func exportAnonymizedResults(state *uiState) {
+	if state == nil || state.window == nil {
+		return
+	}
+	if strings.TrimSpace(state.filePath) == "" {
+		dialog.ShowInformation("Export Anonymized Results", "Open a results file first.", state.window)
+		return
+	}
+	salt := widget.NewPasswordEntry()
+	salt.SetPlaceHolder("optional; empty = random")
+	items := []*widget.FormItem{widget.NewFormItem("Salt", salt)}
+	dialog.ShowForm("Export Anonymized Results", "Choose file…", "Cancel", items, func(ok bool) {
+		if !ok {
+			return
+		}
+		key := salt.Text
+		path := state.filePath
+		fs := dialog.NewFileSave(func(wc fyne.URIWriteCloser, err error) {
+			if err != nil || wc == nil {
+				return
+			}
+			go func() {
+				defer wc.Close()
+				var rep *analysis.AnonymizeReport
+				f, err := analysis.OpenResults(path)
+				if err == nil {
+					rep, err = analysis.AnonymizeResults(f, wc, key)
+					f.Close()
+				}
+				fyne.Do(func() {
+					if err != nil {
+						dialog.ShowError(err, state.window)
+						return
+					}
+					p := wc.URI().Path()
+					if strings.TrimSpace(p) == "" {
+						p = wc.URI().String()
+					}
+					msg := fmt.Sprintf("Saved to:\n%s\n\n%d lines written", p, rep.Written)
+					if rep.Dropped > 0 {
+						msg += fmt.Sprintf(", %d unparsable lines left out", rep.Dropped)
+					}
+					dialog.ShowInformation("Export complete", msg, state.window)
+				})
+			}()
+		}, state.window)
+		fs.SetFileName(anonymizedFileName(path))
+		fs.SetFilter(storage.NewExtensionFileFilter([]string{".jsonl"}))
+		fs.Show()
+	}, state.window)
+}
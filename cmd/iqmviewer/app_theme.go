@@ -0,0 +1,128 @@
+package main
+
+import (
+	"image/color"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/theme"
+)
+
+// App theme (Settings → App Theme): the look of the viewer's own widgets, separate from the
+// Screenshot Theme of the charts. "system" takes the dark/light variant the OS reports through
+// Fyne (XDG desktop portal on Linux, the registry on Windows, AppKit on macOS) and follows it
+// live when the OS switches; "dark" and "light" pin it. The accent replaces Fyne's primary
+// color. When the Screenshot Theme is Auto, an OS switch also re-themes the charts.
+
+// appThemeModes are the App Theme choices in menu order.
+var appThemeModes = []string{"system", "dark", "light"}
+
+// appAccents are the accent choices besides "" (Fyne's default primary color).
+var appAccents = []struct {
+	name string
+	col  color.NRGBA
+}{
+	{"blue", color.NRGBA{R: 0x29, G: 0x6f, B: 0xf6, A: 0xff}},
+	{"purple", color.NRGBA{R: 0x9c, G: 0x27, B: 0xb0, A: 0xff}},
+	{"green", color.NRGBA{R: 0x2e, G: 0x9e, B: 0x44, A: 0xff}},
+	{"orange", color.NRGBA{R: 0xf2, G: 0x8c, B: 0x1c, A: 0xff}},
+	{"red", color.NRGBA{R: 0xe5, G: 0x39, B: 0x35, A: 0xff}},
+	{"gray", color.NRGBA{R: 0x8a, G: 0x8a, B: 0x8a, A: 0xff}},
+}
+
+// appTheme is the fyne.Theme of the viewer window.
+type appTheme struct {
+	mode   string // system, dark or light
+	accent string // "" or one of appAccents
+}
+
+// viewerTheme is global like chartLook: menus and the settings listener change it in place.
+var viewerTheme = &appTheme{mode: "system"}
+
+func normalizeAppThemeMode(m string) string {
+	for _, v := range appThemeModes {
+		if m == v {
+			return m
+		}
+	}
+	return "system"
+}
+
+func normalizeAppAccent(a string) string {
+	for _, v := range appAccents {
+		if a == v.name {
+			return a
+		}
+	}
+	return ""
+}
+
+func accentColor(name string) (color.NRGBA, bool) {
+	for _, v := range appAccents {
+		if v.name == name {
+			return v.col, true
+		}
+	}
+	return color.NRGBA{}, false
+}
+
+// variant pins the variant Fyne asks for unless the mode follows the system.
+func (t *appTheme) variant(v fyne.ThemeVariant) fyne.ThemeVariant {
+	switch t.mode {
+	case "dark":
+		return theme.VariantDark
+	case "light":
+		return theme.VariantLight
+	}
+	return v
+}
+
+func (t *appTheme) Color(name fyne.ThemeColorName, variant fyne.ThemeVariant) color.Color {
+	if c, ok := accentColor(t.accent); ok {
+		// the derived colors keep Fyne's translucency
+		switch name {
+		case theme.ColorNamePrimary, theme.ColorNameHyperlink:
+			return c
+		case theme.ColorNameFocus:
+			c.A = 0x7f
+			return c
+		case theme.ColorNameSelection:
+			c.A = 0x3f
+			return c
+		}
+	}
+	return theme.DefaultTheme().Color(name, t.variant(variant))
+}
+
+func (t *appTheme) Font(style fyne.TextStyle) fyne.Resource { return theme.DefaultTheme().Font(style) }
+func (t *appTheme) Icon(name fyne.ThemeIconName) fyne.Resource {
+	return theme.DefaultTheme().Icon(name)
+}
+func (t *appTheme) Size(name fyne.ThemeSizeName) float32 { return theme.DefaultTheme().Size(name) }
+
+// setAppTheme stores and applies a new mode and accent.
+func setAppTheme(state *uiState, mode, accent string) {
+	viewerTheme.mode, viewerTheme.accent = normalizeAppThemeMode(mode), normalizeAppAccent(accent)
+	if state == nil || state.app == nil {
+		return
+	}
+	state.app.Preferences().SetString("appThemeMode", viewerTheme.mode)
+	state.app.Preferences().SetString("appAccent", viewerTheme.accent)
+	state.app.Settings().SetTheme(viewerTheme)
+}
+
+// followSystemTheme re-resolves the chart theme when the OS variant changes; Fyne itself
+// re-applies viewerTheme with the new variant.
+func followSystemTheme(state *uiState) {
+	if state == nil || state.app == nil {
+		return
+	}
+	state.app.Settings().AddListener(func(fyne.Settings) {
+		if screenshotThemeMode != "auto" {
+			return
+		}
+		if t := resolveTheme(screenshotThemeMode, state.app); t != screenshotThemeGlobal {
+			screenshotThemeGlobal = t
+			scheduleRedraw(state)
+		}
+	})
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"image/color"
+	"testing"
+
+	"fyne.io/fyne/v2/test"
+	"fyne.io/fyne/v2/theme"
+)
+
+func TestAppTheme_ModeAndAccent(t *testing.T) {
+	test.NewTempApp(t) // the default theme reads the app settings
+	bg := theme.ColorNameBackground
+	def := theme.DefaultTheme()
+	sys := &appTheme{mode: "system"}
+	if sys.Color(bg, theme.VariantLight) != def.Color(bg, theme.VariantLight) || sys.Color(bg, theme.VariantDark) != def.Color(bg, theme.VariantDark) {
+		t.Fatalf("system mode must follow the variant Fyne asks for")
+	}
+	dark := &appTheme{mode: "dark"}
+	if dark.Color(bg, theme.VariantLight) != def.Color(bg, theme.VariantDark) {
+		t.Fatalf("dark mode must pin the dark variant")
+	}
+	if (&appTheme{mode: "light"}).Color(bg, theme.VariantDark) != def.Color(bg, theme.VariantLight) {
+		t.Fatalf("light mode must pin the light variant")
+	}
+	if dark.Color(theme.ColorNamePrimary, theme.VariantDark) != def.Color(theme.ColorNamePrimary, theme.VariantDark) {
+		t.Fatalf("no accent keeps Fyne's primary color")
+	}
+	green := &appTheme{mode: "system", accent: "green"}
+	want, _ := accentColor("green")
+	if green.Color(theme.ColorNamePrimary, theme.VariantLight) != want {
+		t.Fatalf("accent not applied to primary")
+	}
+	if c := green.Color(theme.ColorNameSelection, theme.VariantLight).(color.NRGBA); c.A != 0x3f || c.G != want.G {
+		t.Fatalf("selection = %v", c)
+	}
+	if normalizeAppThemeMode("neon") != "system" || normalizeAppAccent("neon") != "" || normalizeAppAccent("red") != "red" {
+		t.Fatalf("normalize")
+	}
+}
@@ -0,0 +1,308 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	chart "github.com/wcharczuk/go-chart/v2"
+	"github.com/wcharczuk/go-chart/v2/drawing"
+)
+
+// chartAppearance is the Settings → Chart Appearance configuration. Renderers keep using the
+// stock go-chart colors (Overall gray, IPv4 blue, IPv6 green, ...); applyChartAppearance maps
+// them to the chosen palette and scales dots, lines and fonts in renderChart, so on-screen
+// charts, exports and screenshots all pick it up.
+type chartAppearance struct {
+	Palette string // "default" or "colorblind" (Okabe–Ito)
+	// Per-family overrides as "#rrggbb"; "" keeps the palette color.
+	Overall, IPv4, IPv6 string
+	// Multipliers; 1 leaves the renderer's sizes unchanged.
+	DotScale, LineScale, FontScale float64
+}
+
+// chartLook is global like chartDecimationEnabled; renderers read it through renderSettingsFor.
+var chartLook = defaultChartAppearance()
+
+func defaultChartAppearance() chartAppearance {
+	return chartAppearance{Palette: "default", DotScale: 1, LineScale: 1, FontScale: 1}
+}
+
+// colorblindPalette replaces the stock go-chart colors with Okabe–Ito colors that stay
+// distinguishable with the common color vision deficiencies. IPv6 moves from green to orange
+// so the IPv4/IPv6 pair does not rely on red/green contrast.
+var colorblindPalette = map[drawing.Color]drawing.Color{
+	chart.ColorBlue:          drawing.ColorFromHex("0072B2"),
+	chart.ColorGreen:         drawing.ColorFromHex("E69F00"),
+	chart.ColorRed:           drawing.ColorFromHex("D55E00"),
+	chart.ColorOrange:        drawing.ColorFromHex("CC79A7"),
+	chart.ColorYellow:        drawing.ColorFromHex("F0E442"),
+	chart.ColorCyan:          drawing.ColorFromHex("56B4E9"),
+	chart.ColorAlternateGray: drawing.ColorFromHex("7F7F7F"),
+}
+
+// parseChartAppearance reads the compact form stored in preferences and accepted by
+// --chart-appearance, e.g. "palette=colorblind,ipv4=#0072b2,dot=1.5,line=2,font=1.2".
+// Missing keys keep their defaults; scales are clamped to 0.5–3.
+func parseChartAppearance(s string) (chartAppearance, error) {
+	a := defaultChartAppearance()
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(part, "=")
+		k, v = strings.ToLower(strings.TrimSpace(k)), strings.TrimSpace(v)
+		if !ok {
+			return a, fmt.Errorf("%q: want key=value", part)
+		}
+		switch k {
+		case "palette":
+			v = strings.ToLower(v)
+			if v != "default" && v != "colorblind" {
+				return a, fmt.Errorf("palette %q: want default or colorblind", v)
+			}
+			a.Palette = v
+		case "overall", "ipv4", "ipv6":
+			if v != "" && !validHexColor(v) {
+				return a, fmt.Errorf("%s color %q: want #rrggbb", k, v)
+			}
+			switch k {
+			case "overall":
+				a.Overall = v
+			case "ipv4":
+				a.IPv4 = v
+			default:
+				a.IPv6 = v
+			}
+		case "dot", "line", "font":
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return a, fmt.Errorf("%s scale %q: %v", k, v, err)
+			}
+			f = clampScale(f)
+			switch k {
+			case "dot":
+				a.DotScale = f
+			case "line":
+				a.LineScale = f
+			default:
+				a.FontScale = f
+			}
+		default:
+			return a, fmt.Errorf("unknown chart appearance key %q", k)
+		}
+	}
+	return a, nil
+}
+
+// String is the inverse of parseChartAppearance; defaults are omitted ("" for all defaults).
+func (a chartAppearance) String() string {
+	var parts []string
+	if a.Palette != "" && a.Palette != "default" {
+		parts = append(parts, "palette="+a.Palette)
+	}
+	for _, c := range []struct{ k, v string }{{"overall", a.Overall}, {"ipv4", a.IPv4}, {"ipv6", a.IPv6}} {
+		if c.v != "" {
+			parts = append(parts, c.k+"="+c.v)
+		}
+	}
+	for _, sc := range []struct {
+		k string
+		v float64
+	}{{"dot", a.DotScale}, {"line", a.LineScale}, {"font", a.FontScale}} {
+		if sc.v != 1 && sc.v != 0 {
+			parts = append(parts, sc.k+"="+strconv.FormatFloat(sc.v, 'g', 3, 64))
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+func validHexColor(s string) bool {
+	s = strings.TrimPrefix(s, "#")
+	if len(s) != 6 {
+		return false
+	}
+	_, err := strconv.ParseUint(s, 16, 32)
+	return err == nil
+}
+
+func clampScale(f float64) float64 {
+	return min(max(f, 0.5), 3)
+}
+
+// colorMap returns the palette's source → replacement colors; nil for the default palette.
+func (a chartAppearance) colorMap() map[drawing.Color]drawing.Color {
+	if a.Palette == "colorblind" {
+		return colorblindPalette
+	}
+	return nil
+}
+
+// familyColor returns the override color for a series of the Overall, IPv4 or IPv6 family: the
+// family name itself or followed by a space ("IPv4 Median", "IPv6 trend (…)"), but not a
+// comparison naming both families ("IPv6 vs IPv4 %"). Other series keep their colors even when
+// they share the family's stock color.
+func (a chartAppearance) familyColor(name string) (drawing.Color, bool) {
+	for _, o := range []struct{ family, hex string }{{"Overall", a.Overall}, {"IPv4", a.IPv4}, {"IPv6", a.IPv6}} {
+		if o.hex == "" || (name != o.family && !strings.HasPrefix(name, o.family+" ")) {
+			continue
+		}
+		if rest := name[len(o.family):]; strings.Contains(rest, "IPv4") || strings.Contains(rest, "IPv6") {
+			return drawing.Color{}, false
+		}
+		return drawing.ColorFromHex(o.hex), true
+	}
+	return drawing.Color{}, false
+}
+
+// remapColor swaps c for its replacement, matching on RGB so the translucent variants
+// renderers derive with WithAlpha (median, min/max, bands) follow the base color.
+func remapColor(m map[drawing.Color]drawing.Color, c drawing.Color) drawing.Color {
+	if m == nil || c.IsZero() {
+		return c
+	}
+	if r, ok := m[drawing.Color{R: c.R, G: c.G, B: c.B, A: 255}]; ok {
+		r.A = c.A
+		return r
+	}
+	return c
+}
+
+// styleSeries applies the palette and, for the Overall/IPv4/IPv6 families, the per-family color
+// (keeping each color's alpha) to the style of the series called name, then the size scales.
+func (a chartAppearance) styleSeries(m map[drawing.Color]drawing.Color, name string, st chart.Style) chart.Style {
+	st.StrokeColor = remapColor(m, st.StrokeColor)
+	st.DotColor = remapColor(m, st.DotColor)
+	st.FillColor = remapColor(m, st.FillColor)
+	if fc, ok := a.familyColor(name); ok {
+		for _, c := range []*drawing.Color{&st.StrokeColor, &st.DotColor, &st.FillColor} {
+			if !c.IsZero() {
+				*c = fc.WithAlpha(c.A)
+			}
+		}
+	}
+	if p := st.DotColorProvider; p != nil && m != nil {
+		// per-dot colors (significance dots) follow the palette like the series colors
+		st.DotColorProvider = func(xr, yr chart.Range, i int, x, y float64) drawing.Color {
+			return remapColor(m, p(xr, yr, i, x, y))
+		}
+	}
+	if a.DotScale > 0 && st.DotWidth > 0 {
+		st.DotWidth *= a.DotScale
+	}
+	if a.LineScale > 0 && st.StrokeWidth > 0 {
+		st.StrokeWidth *= a.LineScale
+	}
+	return st
+}
+
+func scaleFont(size, fallback, scale float64) float64 {
+	if size == 0 {
+		size = fallback
+	}
+	return size * scale
+}
+
+// applyChartAppearance restyles c in place. renderChart calls it once per render; the series
+// slice is replaced like decimateChartSeries does, so callers' series are untouched.
+func applyChartAppearance(c *chart.Chart, a chartAppearance) {
+	if c == nil || a == defaultChartAppearance() {
+		return
+	}
+	m := a.colorMap()
+	series := append([]chart.Series(nil), c.Series...)
+	for i, s := range series {
+		switch ser := s.(type) {
+		case chart.ContinuousSeries:
+			ser.Style = a.styleSeries(m, ser.Name, ser.Style)
+			series[i] = ser
+		case chart.TimeSeries:
+			ser.Style = a.styleSeries(m, ser.Name, ser.Style)
+			series[i] = ser
+		case forecastBand:
+			ser.style = a.styleSeries(m, ser.family, ser.style)
+			series[i] = ser
+		}
+	}
+	c.Series = series
+	if f := a.FontScale; f > 0 && f != 1 {
+		c.TitleStyle.FontSize = scaleFont(c.TitleStyle.FontSize, chart.DefaultTitleFontSize, f)
+		for _, st := range []*chart.Style{&c.XAxis.Style, &c.XAxis.NameStyle, &c.YAxis.Style, &c.YAxis.NameStyle, &c.YAxisSecondary.Style, &c.YAxisSecondary.NameStyle} {
+			st.FontSize = scaleFont(st.FontSize, chart.DefaultAxisFontSize, f)
+		}
+	}
+}
+
+// chartLegend is chart.Legend with the legend text scaled by the appearance font scale of state's
+// renderSettings.
+func chartLegend(state *uiState, c *chart.Chart) chart.Renderable {
+	if f := renderSettingsFor(state).look.FontScale; f > 0 && f != 1 {
+		return chart.Legend(c, chart.Style{FontSize: 8 * f})
+	}
+	return chart.Legend(c)
+}
+
+// openChartAppearanceDialog edits chartLook; Save persists it ("chartAppearance") and redraws.
+func openChartAppearanceDialog(state *uiState) {
+	cur := chartLook
+	palettes := []string{"Default", "Colorblind-safe (Okabe–Ito)"}
+	palette := widget.NewSelect(palettes, nil)
+	palette.SetSelected(palettes[0])
+	if cur.Palette == "colorblind" {
+		palette.SetSelected(palettes[1])
+	}
+	colorEntry := func(v string) *widget.Entry {
+		e := widget.NewEntry()
+		e.SetPlaceHolder("palette color (#rrggbb)")
+		e.SetText(v)
+		return e
+	}
+	overall, v4, v6 := colorEntry(cur.Overall), colorEntry(cur.IPv4), colorEntry(cur.IPv6)
+	scaleEntry := func(v float64) *widget.Entry {
+		e := widget.NewEntry()
+		e.SetText(strconv.FormatFloat(v, 'g', 3, 64))
+		return e
+	}
+	dot, line, font := scaleEntry(cur.DotScale), scaleEntry(cur.LineScale), scaleEntry(cur.FontScale)
+	form := &widget.Form{Items: []*widget.FormItem{
+		{Text: "Palette", Widget: palette},
+		{Text: "Overall color", Widget: overall},
+		{Text: "IPv4 color", Widget: v4},
+		{Text: "IPv6 color", Widget: v6},
+		{Text: "Dot size (×)", Widget: dot},
+		{Text: "Line width (×)", Widget: line},
+		{Text: "Font scale (×)", Widget: font},
+	}}
+	d := dialog.NewCustomConfirm("Chart Appearance", "Save", "Cancel", form, func(ok bool) {
+		if !ok {
+			return
+		}
+		spec := []string{"palette=default"}
+		if palette.Selected == palettes[1] {
+			spec[0] = "palette=colorblind"
+		}
+		for _, c := range []struct {
+			k string
+			e *widget.Entry
+		}{{"overall", overall}, {"ipv4", v4}, {"ipv6", v6}, {"dot", dot}, {"line", line}, {"font", font}} {
+			if v := strings.TrimSpace(c.e.Text); v != "" {
+				spec = append(spec, c.k+"="+v)
+			}
+		}
+		a, err := parseChartAppearance(strings.Join(spec, ","))
+		if err != nil {
+			dialog.ShowError(err, state.window)
+			return
+		}
+		chartLook = a
+		savePrefs(state)
+		scheduleRedraw(state)
+	}, state.window)
+	d.Resize(fyne.NewSize(420, 380))
+	d.Show()
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"testing"
+
+	chart "github.com/wcharczuk/go-chart/v2"
+	"github.com/wcharczuk/go-chart/v2/drawing"
+)
+
+func TestParseChartAppearance_RoundTrip(t *testing.T) {
+	a, err := parseChartAppearance("palette=colorblind, ipv4=#112233, dot=1.5, font=9")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Palette != "colorblind" || a.IPv4 != "#112233" || a.DotScale != 1.5 || a.FontScale != 3 || a.LineScale != 1 {
+		t.Fatalf("parsed %+v", a)
+	}
+	b, err := parseChartAppearance(a.String())
+	if err != nil || b != a {
+		t.Fatalf("round trip %q -> %+v (%v)", a.String(), b, err)
+	}
+	if s := defaultChartAppearance().String(); s != "" {
+		t.Fatalf("defaults should format empty, got %q", s)
+	}
+	for _, bad := range []string{"palette=neon", "ipv6=green", "dot=big", "size=2", "font"} {
+		if _, err := parseChartAppearance(bad); err == nil {
+			t.Fatalf("%q: expected error", bad)
+		}
+	}
+}
+
+func TestApplyChartAppearance_RemapsAndScales(t *testing.T) {
+	look, _ := parseChartAppearance("palette=colorblind,overall=#000080,dot=2,line=2,font=1.5")
+	orig := []chart.Series{
+		chart.ContinuousSeries{Name: "IPv4", Style: chart.Style{DotWidth: 4, DotColor: chart.ColorBlue}},
+		chart.ContinuousSeries{Name: "IPv6 Median", Style: chart.Style{DotWidth: 4, DotColor: chart.ColorGreen.WithAlpha(210)}},
+		chart.ContinuousSeries{Name: "Overall", Style: chart.Style{StrokeWidth: 1.5, StrokeColor: chart.ColorAlternateGray}},
+	}
+	ch := chart.Chart{Series: orig}
+	applyChartAppearance(&ch, look)
+	s0 := ch.Series[0].(chart.ContinuousSeries).Style
+	if s0.DotColor != drawing.ColorFromHex("0072B2") || s0.DotWidth != 8 {
+		t.Fatalf("IPv4 style %+v", s0)
+	}
+	if s1 := ch.Series[1].(chart.ContinuousSeries).Style; s1.DotColor != drawing.ColorFromHex("E69F00").WithAlpha(210) {
+		t.Fatalf("IPv6 median should keep its alpha: %+v", s1.DotColor)
+	}
+	if s2 := ch.Series[2].(chart.ContinuousSeries).Style; s2.StrokeColor != drawing.ColorFromHex("000080") || s2.StrokeWidth != 3 {
+		t.Fatalf("Overall override %+v", s2)
+	}
+	if ch.YAxis.Style.FontSize != chart.DefaultAxisFontSize*1.5 {
+		t.Fatalf("axis font %v", ch.YAxis.Style.FontSize)
+	}
+	if orig[0].(chart.ContinuousSeries).Style.DotColor != chart.ColorBlue {
+		t.Fatal("caller's series were modified")
+	}
+	// family overrides follow the series name, not the stock color
+	look = chartAppearance{Palette: "default", IPv4: "#123456", DotScale: 1, LineScale: 1, FontScale: 1}
+	ch = chart.Chart{Series: []chart.Series{
+		chart.ContinuousSeries{Name: "IPv4 Median", Style: chart.Style{DotWidth: 4, DotColor: chart.ColorBlue.WithAlpha(210)}},
+		chart.ContinuousSeries{Name: "HTTP/2", Style: chart.Style{DotWidth: 4, DotColor: chart.ColorBlue}},
+		chart.ContinuousSeries{Name: "IPv6 vs IPv4 %", Style: chart.Style{DotWidth: 4, DotColor: chart.ColorRed}},
+	}}
+	applyChartAppearance(&ch, look)
+	if c := ch.Series[0].(chart.ContinuousSeries).Style.DotColor; c != drawing.ColorFromHex("123456").WithAlpha(210) {
+		t.Fatalf("IPv4 Median %+v", c)
+	}
+	if c := ch.Series[1].(chart.ContinuousSeries).Style.DotColor; c != chart.ColorBlue {
+		t.Fatalf("a non-family blue series must keep its color: %+v", c)
+	}
+	if c := ch.Series[2].(chart.ContinuousSeries).Style.DotColor; c != chart.ColorRed {
+		t.Fatalf("a family comparison must keep its color: %+v", c)
+	}
+}
@@ -0,0 +1,303 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	helpers "github.com/iafilius/InternetQualityMonitor/cmd/iqmviewer/uihelpers"
+	"github.com/iafilius/InternetQualityMonitor/src/analysis"
+	"github.com/iafilius/InternetQualityMonitor/src/monitor"
+)
+
+// batchLine is one raw per-request record of a batch as shown in the "View lines…" drill-down.
+type batchLine struct {
+	n      int // position within the batch (file order)
+	url    string
+	family string
+	ip     string
+	status int
+	speed  float64 // kbps
+	ttfb   float64 // ms
+	bytes  int64
+	err    string
+	// series is the transfer's speed over time: the monitor's speed_series (--speed-series), else
+	// derived from transfer_speed_samples; nil for lines without a transfer
+	series *monitor.SpeedSeries
+	// waterfall is the request's DNS → connect → TLS → wait → download split (waterfall.go)
+	waterfall []waterfallPhase
+	raw       json.RawMessage
+}
+
+var batchLineColumns = []string{"#", "URL", "Family", "IP", "Status", "Speed", "TTFB (ms)", "Bytes", "Error"}
+
+// readBatchLines scans a results file and returns the lines of runTag. Only the matching
+// lines keep their raw JSON, so even large files stay cheap to drill into.
+func readBatchLines(r io.Reader, runTag string) ([]batchLine, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 512*1024), 8*1024*1024)
+	tagKey := []byte(strconv.Quote(runTag))
+	var out []batchLine
+	for sc.Scan() {
+		line := sc.Bytes()
+		if !bytes.Contains(line, tagKey) { // cheap pre-filter before decoding
+			continue
+		}
+		var env monitor.ResultEnvelope
+		if err := json.Unmarshal(line, &env); err != nil || env.Meta == nil || env.SiteResult == nil || env.Meta.RunTag != runTag {
+			continue
+		}
+		sr := env.SiteResult
+		bl := batchLine{n: len(out) + 1, url: sr.URL, family: sr.IPFamily, ip: sr.ResolvedIP, status: sr.HeadStatus,
+			speed: sr.TransferSpeedKbps, ttfb: float64(sr.TraceTTFBMs), bytes: sr.TransferSizeBytes, raw: append(json.RawMessage(nil), line...)}
+		if bl.ip == "" {
+			bl.ip = sr.IP
+		}
+		if bl.status == 0 {
+			bl.status = sr.SecondGetStatus
+		}
+		for _, e := range []string{sr.TCPError, sr.SSLError, sr.HeadError, sr.HTTPError, sr.SecondGetError} {
+			if e != "" {
+				bl.err = e
+				break
+			}
+		}
+		if bl.err == "" && sr.TransferStalled {
+			bl.err = "transfer stalled"
+		}
+		bl.waterfall = lineWaterfall(sr)
+		bl.series = sr.SpeedSeries
+		if bl.series == nil && len(sr.TransferSpeedSamples) > 0 {
+			samples := append(append([]monitor.SpeedSample(nil), sr.TransferSpeedSamples...), monitor.SpeedSample{TimeMs: sr.TransferTimeMs, Bytes: sr.TransferSizeBytes})
+			bl.series = monitor.BuildSpeedSeries(samples, monitor.DefaultSpeedSeriesMax)
+		}
+		out = append(out, bl)
+	}
+	return out, sc.Err()
+}
+
+// lineSparkW/H size the drill-down's speed sparkline, lineWaterfallH the waterfall bar above it.
+const (
+	lineSparkW     = 420
+	lineSparkH     = 48
+	lineWaterfallH = 18
+)
+
+// speedSeriesCaption describes a line's series: interval, points and the min/median/max rate.
+func speedSeriesCaption(s *monitor.SpeedSeries, unitName string, factor float64) string {
+	if s == nil || len(s.Kbps) == 0 {
+		return "No speed samples for this line"
+	}
+	sorted := append([]float64(nil), s.Kbps...)
+	sort.Float64s(sorted)
+	return fmt.Sprintf("Speed over %d × %s: min %.1f, median %.1f, max %.1f %s", len(sorted), msDur(s.IntervalMs),
+		sorted[0]*factor, sorted[len(sorted)/2]*factor, sorted[len(sorted)-1]*factor, unitName)
+}
+
+// sortBatchLines orders lines by column col (index into batchLineColumns); ties keep file order.
+func sortBatchLines(lines []batchLine, col int, desc bool) {
+	less := func(a, b batchLine) bool {
+		switch col {
+		case 1:
+			return a.url < b.url
+		case 2:
+			return a.family < b.family
+		case 3:
+			return a.ip < b.ip
+		case 4:
+			return a.status < b.status
+		case 5:
+			return a.speed < b.speed
+		case 6:
+			return a.ttfb < b.ttfb
+		case 7:
+			return a.bytes < b.bytes
+		case 8:
+			return a.err < b.err
+		}
+		return a.n < b.n
+	}
+	sort.SliceStable(lines, func(i, j int) bool {
+		if desc {
+			return less(lines[j], lines[i])
+		}
+		return less(lines[i], lines[j])
+	})
+}
+
+// showBatchLinesForSelection opens the raw line drill-down of the selected table row.
+func showBatchLinesForSelection(state *uiState) {
+	rows := filteredSummaries(state)
+	if state == nil || state.app == nil || len(rows) == 0 {
+		return
+	}
+	rix := state.selectedRow
+	if rix < 0 || rix >= len(rows) {
+		rix = 0
+	}
+	showBatchLines(state, rows[rix].RunTag)
+}
+
+// showBatchLines opens a window listing every request of runTag in a sortable table (click a
+// column header; click again to reverse) with the selected line's request waterfall, speed
+// sparkline and JSON on the right. Lines are read in the background when the window opens.
+func showBatchLines(state *uiState, runTag string) {
+	w := state.app.NewWindow("Lines – " + runTag)
+	unitName, factor := speedUnitFor(state)
+	var lines []batchLine
+	sortCol, sortDesc := 0, false
+	status := widget.NewLabel("Loading lines…")
+	detail := widget.NewRichText()
+	detail.Wrapping = fyne.TextWrapBreak
+	selectedJSON := ""
+	copyBtn := widget.NewButton("Copy JSON", func() { state.app.Clipboard().SetContent(selectedJSON) })
+	copyBtn.Disable()
+	spark := canvas.NewImageFromImage(image.NewRGBA(image.Rect(0, 0, lineSparkW, lineSparkH)))
+	spark.FillMode = canvas.ImageFillOriginal
+	spark.SetMinSize(fyne.NewSize(lineSparkW, lineSparkH))
+	sparkCaption := widget.NewLabel("Select a line for its speed over time")
+	waterfall := canvas.NewImageFromImage(image.NewRGBA(image.Rect(0, 0, lineSparkW, lineWaterfallH)))
+	waterfall.FillMode = canvas.ImageFillOriginal
+	waterfall.SetMinSize(fyne.NewSize(lineSparkW, lineWaterfallH))
+	waterfallCap := widget.NewLabel("Select a line for its request waterfall")
+	waterfallCap.Wrapping = fyne.TextWrapWord
+	legend := container.NewHBox()
+	for _, name := range []string{"DNS", "Connect", "TLS", "Wait", "Download"} {
+		sw := canvas.NewRectangle(waterfallPhaseColors[name])
+		sw.SetMinSize(fyne.NewSize(12, 12))
+		legend.Add(container.NewCenter(sw))
+		legend.Add(widget.NewLabel(name))
+	}
+	cell := func(bl batchLine, col int) string {
+		switch col {
+		case 0:
+			return strconv.Itoa(bl.n)
+		case 1:
+			return bl.url
+		case 2:
+			return bl.family
+		case 3:
+			return bl.ip
+		case 4:
+			if bl.status == 0 {
+				return ""
+			}
+			return strconv.Itoa(bl.status)
+		case 5:
+			return fmt.Sprintf("%.1f %s", bl.speed*factor, unitName)
+		case 6:
+			return fmt.Sprintf("%.0f", bl.ttfb)
+		case 7:
+			return strconv.FormatInt(bl.bytes, 10)
+		case 8:
+			return bl.err
+		}
+		return ""
+	}
+	var table *widget.Table
+	table = widget.NewTable(
+		func() (int, int) { return len(lines), len(batchLineColumns) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.TableCellID, o fyne.CanvasObject) {
+			if id.Row < len(lines) {
+				o.(*widget.Label).SetText(cell(lines[id.Row], id.Col))
+			}
+		})
+	table.ShowHeaderRow = true
+	table.CreateHeader = func() fyne.CanvasObject { return widget.NewButton("", nil) }
+	table.UpdateHeader = func(id widget.TableCellID, o fyne.CanvasObject) {
+		b := o.(*widget.Button)
+		label := batchLineColumns[id.Col]
+		if id.Col == sortCol {
+			if sortDesc {
+				label += " ▼"
+			} else {
+				label += " ▲"
+			}
+		}
+		b.SetText(label)
+		col := id.Col
+		b.OnTapped = func() {
+			if sortCol == col {
+				sortDesc = !sortDesc
+			} else {
+				// numbers are most useful worst-first: slowest speed, highest TTFB
+				sortCol, sortDesc = col, col == 6 || col == 7 || col == 4
+			}
+			sortBatchLines(lines, sortCol, sortDesc)
+			table.UnselectAll()
+			table.Refresh()
+		}
+	}
+	for i, wd := range []float32{44, 360, 56, 150, 60, 110, 80, 100, 320} {
+		table.SetColumnWidth(i, wd)
+	}
+	table.OnSelected = func(id widget.TableCellID) {
+		if id.Row < 0 || id.Row >= len(lines) {
+			return
+		}
+		var pretty bytes.Buffer
+		if json.Indent(&pretty, lines[id.Row].raw, "", "  ") != nil {
+			pretty.Reset()
+			pretty.Write(lines[id.Row].raw)
+		}
+		selectedJSON = pretty.String()
+		detail.Segments = []widget.RichTextSegment{&widget.TextSegment{Text: selectedJSON, Style: widget.RichTextStyleCodeBlock}}
+		detail.Refresh()
+		copyBtn.Enable()
+		var vals []float64
+		if s := lines[id.Row].series; s != nil {
+			vals = s.Kbps
+		}
+		spark.Image = helpers.Sparkline(vals, lineSparkW, lineSparkH, sparkColor)
+		spark.Refresh()
+		sparkCaption.SetText(speedSeriesCaption(lines[id.Row].series, unitName, factor))
+		waterfall.Image = waterfallImage(lines[id.Row].waterfall, lineSparkW, lineWaterfallH)
+		waterfall.Refresh()
+		waterfallCap.SetText(waterfallCaption(lines[id.Row].waterfall))
+	}
+	charts := container.NewVBox(waterfallCap, waterfall, legend, sparkCaption, spark)
+	split := container.NewHSplit(table, container.NewBorder(charts, copyBtn, nil, nil, container.NewVScroll(detail)))
+	split.Offset = 0.62
+	w.SetContent(container.NewBorder(status, nil, nil, nil, split))
+	w.Resize(fyne.NewSize(1400, 720))
+	w.Show()
+	path := state.filePath
+	go func() {
+		var loaded []batchLine
+		f, err := analysis.OpenResults(path)
+		if err == nil {
+			loaded, err = readBatchLines(f, runTag)
+			f.Close()
+		}
+		fyne.Do(func() {
+			lines = loaded
+			switch {
+			case err != nil:
+				status.SetText("Could not read lines: " + err.Error())
+			case len(lines) == 0:
+				status.SetText("No lines for " + runTag + " in " + path)
+			default:
+				errs := 0
+				for _, bl := range lines {
+					if strings.TrimSpace(bl.err) != "" {
+						errs++
+					}
+				}
+				status.SetText(fmt.Sprintf("%d lines, %d with errors — click a header to sort, a row for its JSON", len(lines), errs))
+			}
+			table.Refresh()
+		})
+	}()
+}
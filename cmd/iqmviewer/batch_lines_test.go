@@ -0,0 +1,58 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadBatchLines_FiltersRunTagAndPicksFirstError(t *testing.T) {
+	in := `{"meta":{"run_tag":"A","schema_version":3},"site_result":{"url":"https://a/1","ip_family":"ipv4","resolved_ip":"1.2.3.4","head_status":200,"transfer_speed_kbps":900,"trace_ttfb_ms":40}}
+{"meta":{"run_tag":"B","schema_version":3},"site_result":{"url":"https://b/1"}}
+not json "A"
+{"meta":{"run_tag":"A","schema_version":3},"site_result":{"url":"https://a/2","ip":"::1","ip_family":"ipv6","ssl_error":"handshake timeout","http_error":"later"}}
+{"meta":{"run_tag":"A","schema_version":3},"site_result":{"url":"https://a/3","transfer_speed_kbps":120,"trace_ttfb_ms":700,"transfer_stalled":true}}
+`
+	lines, err := readBatchLines(strings.NewReader(in), "A")
+	if err != nil || len(lines) != 3 {
+		t.Fatalf("want 3 lines of A, got %d (%v)", len(lines), err)
+	}
+	if lines[1].ip != "::1" || lines[1].err != "handshake timeout" || lines[2].err != "transfer stalled" {
+		t.Fatalf("unexpected lines: %+v", lines)
+	}
+	sortBatchLines(lines, 5, false) // slowest first
+	if lines[0].url != "https://a/2" || lines[1].url != "https://a/3" {
+		t.Fatalf("speed sort: %s %s", lines[0].url, lines[1].url)
+	}
+	sortBatchLines(lines, 6, true)
+	if lines[0].url != "https://a/3" {
+		t.Fatalf("ttfb desc sort: %s", lines[0].url)
+	}
+	sortBatchLines(lines, 0, false)
+	if lines[0].n != 1 || lines[2].n != 3 {
+		t.Fatalf("file order restore failed")
+	}
+}
+
+func TestReadBatchLines_SpeedSeries(t *testing.T) {
+	in := `{"meta":{"run_tag":"A","schema_version":3},"site_result":{"url":"https://a/1","speed_series":{"interval_ms":1000,"kbps":[10,0,30]}}}
+{"meta":{"run_tag":"A","schema_version":3},"site_result":{"url":"https://a/2","transfer_time_ms":2000,"transfer_size_bytes":3072,"transfer_speed_samples":[{"time_ms":1000,"bytes":1024,"speed_kbps":1}]}}
+{"meta":{"run_tag":"A","schema_version":3},"site_result":{"url":"https://a/3","tcp_error":"refused"}}
+`
+	lines, err := readBatchLines(strings.NewReader(in), "A")
+	if err != nil || len(lines) != 3 {
+		t.Fatalf("want 3 lines, got %d (%v)", len(lines), err)
+	}
+	if s := lines[0].series; s == nil || len(s.Kbps) != 3 || s.Kbps[2] != 30 {
+		t.Fatalf("recorded series: %+v", s)
+	}
+	// older lines: derived from the cumulative samples up to the transfer end
+	if s := lines[1].series; s == nil || len(s.Kbps) != 2 || s.Kbps[0] != 1 || s.Kbps[1] != 2 {
+		t.Fatalf("derived series: %+v", s)
+	}
+	if lines[2].series != nil || speedSeriesCaption(nil, "kbps", 1) != "No speed samples for this line" {
+		t.Fatalf("no transfer: no series")
+	}
+	if got := speedSeriesCaption(lines[0].series, "kbps", 1); got != "Speed over 3 × 1s: min 0.0, median 10.0, max 30.0 kbps" {
+		t.Fatalf("caption: %q", got)
+	}
+}
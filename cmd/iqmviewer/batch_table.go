@@ -0,0 +1,418 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	helpers "github.com/iafilius/InternetQualityMonitor/cmd/iqmviewer/uihelpers"
+	"github.com/iafilius/InternetQualityMonitor/src/analysis"
+)
+
+// batchColumn is one column the Batches table can show. family ties a column to an existing
+// visibility toggle (Overall/IPv4/IPv6 series, Qual column) that zeroes its width.
+type batchColumn struct {
+	key    string
+	label  string // chooser label
+	header func(state *uiState) string
+	width  float32
+	family string // ""|overall|ipv4|ipv6|qual
+	cell   func(state *uiState, bs analysis.BatchSummary) string
+	sort   func(state *uiState, bs analysis.BatchSummary) helpers.SortKey
+}
+
+// defaultBatchColumns is the layout before any customization (the original fixed table); their
+// responsive widths come from helpers.ComputeTableColumnWidths in this order.
+var defaultBatchColumns = []string{"run_tag", "lines", "avg_speed", "avg_ttfb", "errors", "v4_speed", "v4_ttfb", "v6_speed", "v6_ttfb", "qual", "v6_ready", "score"}
+
+func fixedHeader(h string) func(*uiState) string { return func(*uiState) string { return h } }
+
+func speedHeader(prefix string) func(*uiState) string {
+	return func(state *uiState) string {
+		unit, _ := speedUnitFor(state)
+		return prefix + "(" + unit + ")"
+	}
+}
+
+func numKey(v float64) helpers.SortKey { return helpers.SortKey{Num: v} }
+
+// speedColumn shows a kbps field in the chosen speed unit; zero means no data.
+func speedColumn(key, label, prefix string, width float32, family string, get func(analysis.BatchSummary) (float64, bool)) batchColumn {
+	return batchColumn{key: key, label: label, header: speedHeader(prefix), width: width, family: family,
+		cell: func(state *uiState, bs analysis.BatchSummary) string {
+			v, ok := get(bs)
+			if !ok {
+				return "-"
+			}
+			_, factor := speedUnitFor(state)
+			return fmt.Sprintf("%.1f", v*factor)
+		},
+		sort: func(_ *uiState, bs analysis.BatchSummary) helpers.SortKey {
+			v, ok := get(bs)
+			return helpers.SortKey{Num: v, Missing: !ok}
+		},
+	}
+}
+
+// valueColumn formats a plain number; ok=false renders "-".
+func valueColumn(key, label, header string, width float32, family, format string, get func(analysis.BatchSummary) (float64, bool)) batchColumn {
+	return batchColumn{key: key, label: label, header: fixedHeader(header), width: width, family: family,
+		cell: func(_ *uiState, bs analysis.BatchSummary) string {
+			v, ok := get(bs)
+			if !ok {
+				return "-"
+			}
+			return fmt.Sprintf(format, v)
+		},
+		sort: func(_ *uiState, bs analysis.BatchSummary) helpers.SortKey {
+			v, ok := get(bs)
+			return helpers.SortKey{Num: v, Missing: !ok}
+		},
+	}
+}
+
+func positive(v float64) (float64, bool) { return v, v > 0 }
+
+func batchTagsText(tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, k+"="+tags[k])
+	}
+	return strings.Join(parts, ",")
+}
+
+func textColumn(key, label string, width float32, get func(analysis.BatchSummary) string) batchColumn {
+	return batchColumn{key: key, label: label, header: fixedHeader(label), width: width,
+		cell: func(_ *uiState, bs analysis.BatchSummary) string {
+			if v := get(bs); v != "" {
+				return v
+			}
+			return "-"
+		},
+		sort: func(_ *uiState, bs analysis.BatchSummary) helpers.SortKey {
+			v := get(bs)
+			return helpers.SortKey{Text: v, Missing: v == ""}
+		},
+	}
+}
+
+var batchColumns = []batchColumn{
+	{key: "run_tag", label: "RunTag", header: fixedHeader("RunTag"), width: 220,
+		cell: func(_ *uiState, bs analysis.BatchSummary) string {
+			tag := bs.RunTag
+			if bs.SamplingProfile != "" {
+				tag += " (" + bs.SamplingProfile + ")"
+			}
+			if bs.Canceled {
+				tag += " (canceled)"
+			}
+			return tag
+		},
+		sort: func(_ *uiState, bs analysis.BatchSummary) helpers.SortKey { return helpers.SortKey{Text: bs.RunTag} },
+	},
+	valueColumn("lines", "Lines", "Lines", 70, "", "%.0f", func(bs analysis.BatchSummary) (float64, bool) { return float64(bs.Lines), true }),
+	speedColumn("avg_speed", "Average speed", "Avg", 130, "overall", func(bs analysis.BatchSummary) (float64, bool) { return bs.AvgSpeed, true }),
+	valueColumn("avg_ttfb", "Average TTFB", "AvgTTFB(ms)", 100, "overall", "%.0f", func(bs analysis.BatchSummary) (float64, bool) { return bs.AvgTTFB, true }),
+	valueColumn("errors", "Errors", "Errors", 70, "", "%.0f", func(bs analysis.BatchSummary) (float64, bool) { return float64(bs.ErrorLines), true }),
+	speedColumn("v4_speed", "IPv4 speed", "v4", 120, "ipv4", func(bs analysis.BatchSummary) (float64, bool) {
+		if bs.IPv4 == nil {
+			return 0, false
+		}
+		return bs.IPv4.AvgSpeed, true
+	}),
+	valueColumn("v4_ttfb", "IPv4 TTFB", "v4TTFB", 110, "ipv4", "%.0f", func(bs analysis.BatchSummary) (float64, bool) {
+		if bs.IPv4 == nil {
+			return 0, false
+		}
+		return bs.IPv4.AvgTTFB, true
+	}),
+	speedColumn("v6_speed", "IPv6 speed", "v6", 120, "ipv6", func(bs analysis.BatchSummary) (float64, bool) {
+		if bs.IPv6 == nil {
+			return 0, false
+		}
+		return bs.IPv6.AvgSpeed, true
+	}),
+	valueColumn("v6_ttfb", "IPv6 TTFB", "v6TTFB", 110, "ipv6", "%.0f", func(bs analysis.BatchSummary) (float64, bool) {
+		if bs.IPv6 == nil {
+			return 0, false
+		}
+		return bs.IPv6.AvgTTFB, true
+	}),
+	// Quality indicator: ✓ for quality_good; ✗ if known and not good; - if unknown
+	{key: "qual", label: "Measurement quality (Qual)", header: fixedHeader("Qual"), width: 60, family: "qual",
+		cell: func(_ *uiState, bs analysis.BatchSummary) string {
+			switch {
+			case bs.SampleCount <= 0:
+				return "-"
+			case bs.QualityGood:
+				return "✓"
+			}
+			return "✗"
+		},
+		sort: func(_ *uiState, bs analysis.BatchSummary) helpers.SortKey {
+			if bs.SampleCount <= 0 {
+				return helpers.SortKey{Missing: true}
+			}
+			if bs.QualityGood {
+				return numKey(1)
+			}
+			return numKey(0)
+		},
+	},
+	valueColumn("v6_ready", "IPv6 readiness", "v6Ready", 70, "ipv6", "%.0f", func(bs analysis.BatchSummary) (float64, bool) {
+		if bs.IPv6Readiness == nil {
+			return 0, false
+		}
+		return bs.IPv6Readiness.Score, true
+	}),
+	valueColumn("score", "Quality score", "Score", 60, "", "%.0f", func(bs analysis.BatchSummary) (float64, bool) {
+		if bs.QualityScore == nil {
+			return 0, false
+		}
+		return bs.QualityScore.Score, true
+	}),
+	// optional columns
+	textColumn("started", "Started", 150, func(bs analysis.BatchSummary) string {
+		if t := bs.StartTime(); !t.IsZero() {
+			return t.In(timeAxisLoc).Format("2006-01-02 15:04")
+		}
+		return ""
+	}),
+	textColumn("situation", "Situation", 110, func(bs analysis.BatchSummary) string { return bs.Situation }),
+	textColumn("interface", "Interface", 110, func(bs analysis.BatchSummary) string { return bs.BindLabel() }),
+	textColumn("tags", "Tags", 180, func(bs analysis.BatchSummary) string { return batchTagsText(bs.Tags) }),
+	speedColumn("median_speed", "Median speed", "Median", 120, "overall", func(bs analysis.BatchSummary) (float64, bool) { return positive(bs.MedianSpeed) }),
+	speedColumn("p50_speed", "P50 speed", "P50", 110, "overall", func(bs analysis.BatchSummary) (float64, bool) { return positive(bs.AvgP50Speed) }),
+	speedColumn("p90_speed", "P90 speed", "P90", 110, "overall", func(bs analysis.BatchSummary) (float64, bool) { return positive(bs.AvgP90Speed) }),
+	speedColumn("p95_speed", "P95 speed", "P95", 110, "overall", func(bs analysis.BatchSummary) (float64, bool) { return positive(bs.AvgP95Speed) }),
+	speedColumn("p99_speed", "P99 speed", "P99", 110, "overall", func(bs analysis.BatchSummary) (float64, bool) { return positive(bs.AvgP99Speed) }),
+	valueColumn("p95_ttfb", "P95 TTFB", "P95TTFB(ms)", 100, "overall", "%.0f", func(bs analysis.BatchSummary) (float64, bool) { return positive(bs.AvgP95TTFBMs) }),
+	valueColumn("error_rate", "Error rate", "Err%", 70, "", "%.1f", func(bs analysis.BatchSummary) (float64, bool) {
+		if bs.Lines == 0 {
+			return 0, false
+		}
+		return float64(bs.ErrorLines) / float64(bs.Lines) * 100, true
+	}),
+	valueColumn("stall_rate", "Stall rate", "Stall%", 70, "", "%.1f", func(bs analysis.BatchSummary) (float64, bool) { return bs.StallRatePct, true }),
+	valueColumn("dns_ms", "DNS lookup", "DNS(ms)", 80, "", "%.0f", func(bs analysis.BatchSummary) (float64, bool) { return positive(bs.AvgDNSMs) }),
+	valueColumn("duration", "Batch duration", "Dur(s)", 70, "", "%.0f", func(bs analysis.BatchSummary) (float64, bool) {
+		return float64(bs.BatchDurationMs) / 1000, bs.BatchDurationMs > 0
+	}),
+}
+
+func batchColumnByKey(key string) (batchColumn, bool) {
+	for _, c := range batchColumns {
+		if c.key == key {
+			return c, true
+		}
+	}
+	return batchColumn{}, false
+}
+
+// visibleBatchColumns resolves the chosen layout; RunTag is always the first column.
+func visibleBatchColumns(state *uiState) []batchColumn {
+	keys := defaultBatchColumns
+	if state != nil && len(state.tableColumns) > 0 {
+		keys = state.tableColumns
+	}
+	out := []batchColumn{batchColumns[0]}
+	for _, k := range keys {
+		if c, ok := batchColumnByKey(k); ok && k != "run_tag" {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// parseBatchColumnsPref reads the persisted comma-separated layout, dropping unknown keys.
+func parseBatchColumnsPref(s string) []string {
+	var out []string
+	for _, k := range strings.Split(s, ",") {
+		if _, ok := batchColumnByKey(strings.TrimSpace(k)); ok {
+			out = append(out, strings.TrimSpace(k))
+		}
+	}
+	return out
+}
+
+// batchTableOrder maps table rows to indexes in filteredSummaries (which stays chronological
+// for the charts and state.selectedRow). Cached until the filtered batches or the sort change.
+func batchTableOrder(state *uiState) []int {
+	rows := filteredSummaries(state)
+	col, ok := batchColumnByKey(state.tableSortKey)
+	if !ok {
+		order := make([]int, len(rows))
+		for i := range order {
+			order[i] = i
+		}
+		return order
+	}
+	h := fnv.New64a()
+	for _, bs := range rows {
+		h.Write([]byte(bs.RunTag))
+	}
+	sig := fmt.Sprintf("%s|%v|%d|%x", col.key, state.tableSortDesc, len(rows), h.Sum64())
+	if sig == state.tableOrderSig && len(state.tableOrder) == len(rows) {
+		return state.tableOrder
+	}
+	keys := make([]helpers.SortKey, len(rows))
+	for i, bs := range rows {
+		keys[i] = col.sort(state, bs)
+	}
+	state.tableOrder = helpers.SortedRowOrder(keys, state.tableSortDesc)
+	state.tableOrderSig = sig
+	return state.tableOrder
+}
+
+// batchRowIndex converts a table row (1-based data rows after the header) to a filteredSummaries
+// index, -1 when out of range.
+func batchRowIndex(state *uiState, row int) int {
+	order := batchTableOrder(state)
+	if row < 1 || row > len(order) {
+		return -1
+	}
+	return order[row-1]
+}
+
+// toggleBatchSort sorts by the clicked header: ascending, then descending, then back to the
+// chronological order.
+func toggleBatchSort(state *uiState, col int) {
+	cols := visibleBatchColumns(state)
+	if col < 0 || col >= len(cols) {
+		return
+	}
+	key := cols[col].key
+	switch {
+	case state.tableSortKey != key:
+		state.tableSortKey, state.tableSortDesc = key, false
+	case !state.tableSortDesc:
+		state.tableSortDesc = true
+	default:
+		state.tableSortKey, state.tableSortDesc = "", false
+	}
+	state.tableOrderSig = ""
+	savePrefs(state)
+	if state.table != nil {
+		state.table.Refresh()
+	}
+}
+
+func batchHeaderText(state *uiState, c batchColumn) string {
+	h := c.header(state)
+	if state.tableSortKey == c.key {
+		if state.tableSortDesc {
+			return h + " ▼"
+		}
+		return h + " ▲"
+	}
+	return h
+}
+
+// batchColumnHidden reports whether a series toggle hides the column.
+func batchColumnHidden(state *uiState, c batchColumn) bool {
+	switch c.family {
+	case "overall":
+		return !state.showOverall
+	case "ipv4":
+		return !state.showIPv4
+	case "ipv6":
+		return !state.showIPv6
+	case "qual":
+		return !state.showQualColumn
+	}
+	return false
+}
+
+// applyBatchTableColumns sets the column widths for the current window width, layout and
+// series toggles. Default columns use the responsive widths; the others scale with the RunTag
+// column.
+func applyBatchTableColumns(state *uiState, winW float32) {
+	if state == nil || state.table == nil {
+		return
+	}
+	responsive := helpers.ComputeTableColumnWidths(winW)
+	scale := float32(responsive[0]) / 220
+	for i, c := range visibleBatchColumns(state) {
+		width := c.width * scale
+		for j, k := range defaultBatchColumns {
+			if k == c.key {
+				width = float32(responsive[j])
+			}
+		}
+		if batchColumnHidden(state, c) {
+			width = 0
+		}
+		state.table.SetColumnWidth(i, width)
+	}
+	state.table.Refresh()
+}
+
+// showBatchColumnChooser lets the user pick and order the optional Batches table columns.
+func showBatchColumnChooser(state *uiState) {
+	if state == nil || state.window == nil {
+		return
+	}
+	chosen := map[string]bool{}
+	for _, c := range visibleBatchColumns(state) {
+		chosen[c.key] = true
+	}
+	box := container.NewVBox()
+	checks := map[string]*widget.Check{}
+	for _, c := range batchColumns[1:] {
+		chk := widget.NewCheck(c.label, nil)
+		chk.SetChecked(chosen[c.key])
+		checks[c.key] = chk
+		box.Add(chk)
+	}
+	content := container.NewBorder(widget.NewLabel("RunTag is always shown. Click a header to sort."), nil, nil, nil, container.NewVScroll(box))
+	d := dialog.NewCustomConfirm("Batches Table Columns", "Apply", "Cancel", content, func(ok bool) {
+		if !ok {
+			return
+		}
+		keys := []string{"run_tag"}
+		for _, c := range batchColumns[1:] {
+			if checks[c.key].Checked {
+				keys = append(keys, c.key)
+			}
+		}
+		state.tableColumns = keys
+		if _, ok := batchColumnByKey(state.tableSortKey); ok && !chosenKey(keys, state.tableSortKey) {
+			state.tableSortKey, state.tableSortDesc = "", false
+		}
+		state.tableOrderSig = ""
+		savePrefs(state)
+		applyBatchTableColumns(state, state.window.Canvas().Size().Width)
+	}, state.window)
+	d.Resize(fyne.NewSize(360, 520))
+	d.Show()
+}
+
+func chosenKey(keys []string, key string) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// resetBatchColumns restores the default layout and chronological order.
+func resetBatchColumns(state *uiState) {
+	state.tableColumns = nil
+	state.tableSortKey, state.tableSortDesc, state.tableOrderSig = "", false, ""
+	savePrefs(state)
+	if state.window != nil {
+		applyBatchTableColumns(state, state.window.Canvas().Size().Width)
+	}
+}
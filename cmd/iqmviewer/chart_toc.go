@@ -0,0 +1,183 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Chart contents (BatchAvg Charts tab): a collapsible sidebar lists the visible charts of
+// chartRefs in column order; clicking one scrolls to it. A sticky header above the charts names
+// the chart at the top of the view, which the sidebar highlights as the charts scroll.
+
+const (
+	chartTOCWidth = 230
+	// chartTOCLead counts a chart as current once its title is this close to the top of the view
+	chartTOCLead = 40
+)
+
+// chartTOC is the sidebar and sticky header of the charts column.
+type chartTOC struct {
+	box     *fyne.Container // the sidebar, hidden when collapsed
+	header  *fyne.Container
+	list    *widget.List
+	current *widget.Label
+	toggle  *widget.Button
+	entries []int // chartRefs indices of the listed charts
+	active  int   // entries index of the current chart, -1 when none
+}
+
+func newChartTOC(state *uiState, fileLabel *widget.Label) *chartTOC {
+	t := &chartTOC{active: -1}
+	t.list = widget.NewList(
+		func() int { return len(t.entries) },
+		func() fyne.CanvasObject {
+			l := widget.NewLabel("")
+			l.Truncation = fyne.TextTruncateEllipsis
+			return l
+		},
+		func(id widget.ListItemID, o fyne.CanvasObject) {
+			l := o.(*widget.Label)
+			if id < 0 || id >= len(t.entries) || t.entries[id] >= len(state.chartRefs) {
+				l.SetText("")
+				return
+			}
+			title := tr(state.chartRefs[t.entries[id]].title)
+			l.TextStyle.Bold = id == t.active
+			if id == t.active {
+				title = "▸ " + title
+			}
+			l.SetText(title)
+		},
+	)
+	// Selection is only a click: scroll there and drop it again so the same chart can be
+	// clicked twice; the highlight follows the scroll position instead.
+	t.list.OnSelected = func(id widget.ListItemID) {
+		t.list.UnselectAll()
+		if id >= 0 && id < len(t.entries) {
+			setChartFocus(state, t.entries[id])
+			scrollToChartSection(state, t.entries[id])
+		}
+	}
+	// a transparent strut gives the sidebar its width; the labels truncate to it
+	strut := canvas.NewRectangle(color.Transparent)
+	strut.SetMinSize(fyne.NewSize(chartTOCWidth, 0))
+	t.box = container.NewBorder(nil, nil, nil, widget.NewSeparator(), container.NewStack(strut, t.list))
+
+	t.current = widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	t.current.Truncation = fyne.TextTruncateEllipsis
+	t.toggle = widget.NewButtonWithIcon(tr("Contents"), theme.MenuIcon(), func() { toggleChartTOC(state, fileLabel) })
+	t.toggle.Importance = widget.LowImportance
+	t.header = container.NewBorder(nil, widget.NewSeparator(), t.toggle, nil, t.current)
+	if !state.showChartTOC {
+		t.box.Hide()
+	}
+	return t
+}
+
+// toggleChartTOC shows or collapses the sidebar and saves the choice.
+func toggleChartTOC(state *uiState, fileLabel *widget.Label) {
+	state.showChartTOC = !state.showChartTOC
+	applyChartTOCVisibility(state)
+	savePrefs(state)
+	scheduleMenuRebuild(state, fileLabel)
+}
+
+func applyChartTOCVisibility(state *uiState) {
+	if state == nil || state.chartTOC == nil {
+		return
+	}
+	if state.showChartTOC {
+		state.chartTOC.box.Show()
+	} else {
+		state.chartTOC.box.Hide()
+	}
+}
+
+// refreshChartTOC re-lists the visible charts (visibility, auto-hide and dashboard order change
+// them) and updates the highlight.
+func refreshChartTOC(state *uiState) {
+	if state == nil || state.chartTOC == nil {
+		return
+	}
+	t := state.chartTOC
+	t.entries = t.entries[:0]
+	for i, ref := range state.chartRefs {
+		if chartSectionShown(state, ref) {
+			t.entries = append(t.entries, i)
+		}
+	}
+	t.active = -1
+	t.list.Refresh()
+	updateChartTOCPosition(state)
+}
+
+// chartSectionShown reports whether a section is laid out in the charts column; the Pre‑TTFB
+// section sits in its own wrapper block.
+func chartSectionShown(state *uiState, ref chartRef) bool {
+	if ref.section == nil || !ref.section.Visible() {
+		return false
+	}
+	if ref.section == state.pretffbSection && state.pretffbBlock != nil {
+		return state.pretffbBlock.Visible()
+	}
+	return true
+}
+
+// chartSectionTop is the Y of a section within the charts column.
+func chartSectionTop(state *uiState, ref chartRef) float32 {
+	if ref.section == state.pretffbSection && state.pretffbBlock != nil {
+		return state.pretffbBlock.Position().Y + ref.section.Position().Y
+	}
+	return ref.section.Position().Y
+}
+
+// updateChartTOCPosition moves the highlight and the sticky header to the chart at the top of
+// the view; called on every scroll.
+func updateChartTOCPosition(state *uiState) {
+	if state == nil || state.chartTOC == nil || state.chartsScroll == nil {
+		return
+	}
+	t := state.chartTOC
+	tops := make([]float32, len(t.entries))
+	for i, idx := range t.entries {
+		tops[i] = chartSectionTop(state, state.chartRefs[idx])
+	}
+	active := currentChartEntry(tops, state.chartsScroll.Offset.Y)
+	if active != t.active {
+		t.active = active
+		t.list.Refresh()
+		if active >= 0 {
+			t.list.ScrollTo(active)
+		}
+	}
+	t.current.SetText(chartTOCHeader(state, t.entries, active))
+}
+
+// currentChartEntry is the index of the last section whose top (in column order) is within
+// chartTOCLead of the scroll offset, 0 above the first one and -1 without sections.
+func currentChartEntry(tops []float32, offsetY float32) int {
+	if len(tops) == 0 {
+		return -1
+	}
+	cur := 0
+	for i, top := range tops {
+		if top <= offsetY+chartTOCLead {
+			cur = i
+		}
+	}
+	return cur
+}
+
+// chartTOCHeader is the sticky header text, e.g. "TLS Handshake (4/52)".
+func chartTOCHeader(state *uiState, entries []int, active int) string {
+	if active < 0 || active >= len(entries) {
+		return tr("No charts shown")
+	}
+	return fmt.Sprintf("%s (%d/%d)", tr(state.chartRefs[entries[active]].title), active+1, len(entries))
+}
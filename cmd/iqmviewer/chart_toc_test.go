@@ -0,0 +1,35 @@
+package main
+
+import "testing"
+
+func TestCurrentChartEntry(t *testing.T) {
+	tops := []float32{0, 400, 800, 1200}
+	cases := []struct {
+		offset float32
+		want   int
+	}{
+		{0, 0},
+		{300, 0},
+		{400 - chartTOCLead, 1}, // title just reached the top of the view
+		{790, 2},
+		{5000, 3},
+	}
+	for _, c := range cases {
+		if got := currentChartEntry(tops, c.offset); got != c.want {
+			t.Errorf("offset %v: got %d want %d", c.offset, got, c.want)
+		}
+	}
+	if got := currentChartEntry(nil, 0); got != -1 {
+		t.Fatalf("no sections: %d", got)
+	}
+}
+
+func TestChartTOCHeader(t *testing.T) {
+	s := &uiState{chartRefs: []chartRef{{title: "DNS Lookup Time"}, {title: "TCP Connect Time"}, {title: "TLS Handshake Time"}}}
+	if got := chartTOCHeader(s, []int{0, 2}, 1); got != "TLS Handshake Time (2/2)" {
+		t.Fatalf("header: %q", got)
+	}
+	if got := chartTOCHeader(s, nil, -1); got != "No charts shown" {
+		t.Fatalf("empty: %q", got)
+	}
+}
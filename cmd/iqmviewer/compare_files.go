@@ -0,0 +1,399 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+	chart "github.com/wcharczuk/go-chart/v2"
+
+	helpers "github.com/iafilius/InternetQualityMonitor/cmd/iqmviewer/uihelpers"
+	"github.com/iafilius/InternetQualityMonitor/src/analysis"
+)
+
+// File → Compare With… loads a second results file next to the one on screen, e.g. the batches
+// after a router swap against those before it, pairs their batches (analysis.PairBatches) and
+// shows one metric of both files per pair as a chart, the per-metric differences and the pairs
+// as a table. The file on screen keeps its filters; the compared file is taken whole.
+
+// defaultCompareToleranceMin is the pairing tolerance the dialog starts with, in minutes.
+const defaultCompareToleranceMin = 30
+
+// compareModes are the dialog's pairing choices in display order.
+var compareModes = []struct{ label, mode string }{
+	{"By batch index", analysis.PairByIndex},
+	{"By start time", analysis.PairByTime},
+	{"By time of day", analysis.PairByTimeOfDay},
+}
+
+// compareValue is one metric of a batch for display: speeds in the viewer's unit.
+func compareValue(m analysis.CompareMetric, s analysis.BatchSummary, speedFactor float64) (float64, bool) {
+	v, ok := m.Get(s)
+	if ok && m.Unit == "kbps" {
+		v *= speedFactor
+	}
+	return v, ok
+}
+
+// compareUnit is the display unit of m.
+func compareUnit(m analysis.CompareMetric, speedUnit string) string {
+	if m.Unit == "kbps" {
+		return speedUnit
+	}
+	return m.Unit
+}
+
+// renderCompareChart draws metric m of both files per pair, A solid and B dashed, numbered by pair.
+func renderCompareChart(pairs []analysis.BatchPair, m analysis.CompareMetric, nameA, nameB, speedUnit string, speedFactor float64, w, h int) image.Image {
+	if len(pairs) == 0 {
+		return drawHint(blank(w, h), "No batches paired: widen the tolerance or pair by batch index.")
+	}
+	var xa, ya, xb, yb []float64
+	maxY := 0.0
+	for i, p := range pairs {
+		if v, ok := compareValue(m, p.A, speedFactor); ok {
+			xa, ya = append(xa, float64(i+1)), append(ya, v)
+			maxY = math.Max(maxY, v)
+		}
+		if v, ok := compareValue(m, p.B, speedFactor); ok {
+			xb, yb = append(xb, float64(i+1)), append(yb, v)
+			maxY = math.Max(maxY, v)
+		}
+	}
+	if len(ya) == 0 && len(yb) == 0 {
+		return drawHint(blank(w, h), "Neither file has this metric in the paired batches.")
+	}
+	series := func(name string, xs, ys []float64, st chart.Style) chart.Series {
+		if len(ys) == 1 {
+			xs, ys = append(xs, xs[0]+0.01), append(ys, ys[0])
+		}
+		return chart.ContinuousSeries{Name: name, XValues: xs, YValues: ys, Style: st}
+	}
+	stA := pointStyle(chart.ColorBlue)
+	stA.StrokeColor, stA.StrokeWidth = chart.ColorBlue, 1.5
+	stB := pointStyle(chart.ColorOrange)
+	stB.StrokeColor, stB.StrokeWidth, stB.StrokeDashArray = chart.ColorOrange, 1.5, []float64{6, 3}
+	var ss []chart.Series
+	if len(ya) > 0 {
+		ss = append(ss, series("A: "+nameA, xa, ya, stA))
+	}
+	if len(yb) > 0 {
+		ss = append(ss, series("B: "+nameB, xb, yb, stB))
+	}
+	hi := maxY * 1.15
+	if m.Unit == "%" || m.Key == "quality_score" {
+		hi = math.Max(hi, 10)
+	}
+	vals := helpers.BuildNumericTicks(0, math.Max(hi, 1), 6)
+	if len(vals) < 2 {
+		vals = []float64{0, 100}
+	}
+	yTicks := make([]chart.Tick, len(vals))
+	for i, v := range vals {
+		yTicks[i] = chart.Tick{Value: v, Label: helpers.FormatNumericTick(v)}
+	}
+	title := tr(m.Label)
+	if u := compareUnit(m, speedUnit); u != "" {
+		title += " (" + u + ")"
+	}
+	ch := chart.Chart{
+		Title:      title,
+		Background: chart.Style{Padding: chart.Box{Top: 14, Left: 16, Right: 12, Bottom: 28}},
+		XAxis:      chart.XAxis{Name: "Pair", Range: &chart.ContinuousRange{Min: 0.5, Max: float64(len(pairs)) + 0.5}},
+		YAxis:      chart.YAxis{Name: compareUnit(m, speedUnit), Range: &chart.ContinuousRange{Min: vals[0], Max: vals[len(vals)-1]}, Ticks: yTicks},
+		Series:     ss,
+	}
+	themeChart(&ch)
+	ch.Width, ch.Height = w, h
+	attachLegend(nil, &ch)
+	var buf bytes.Buffer
+	if err := renderChart(nil, &ch, &buf); err != nil {
+		return blank(w, h)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		return blank(w, h)
+	}
+	return img
+}
+
+// compareSummaryText is the per-metric table: both means, the difference and, for tested
+// metrics, whether it is significant.
+func compareSummaryText(cmp []analysis.MetricComparison, speedUnit string, speedFactor float64) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%-14s %6s %12s %12s %12s %8s  %s\n", "Metric", "Pairs", "A", "B", "B − A", "Change", "Verdict")
+	for _, c := range cmp {
+		f, unit := 1.0, c.Unit
+		if c.Unit == "kbps" {
+			f, unit = speedFactor, speedUnit
+		}
+		verdict := "worse"
+		switch {
+		case c.Delta == 0:
+			verdict = "same"
+		case c.Improved:
+			verdict = "better"
+		}
+		if t := c.Test; t != nil {
+			kind := "significant"
+			if !t.Significant {
+				kind = "not significant"
+			}
+			if t.Paired {
+				kind += ", paired"
+			}
+			verdict += fmt.Sprintf(" (%s, p=%.3f)", kind, t.PValue)
+		}
+		fmt.Fprintf(&b, "%-14s %6d %12s %12s %12s %+7.1f%%  %s\n", c.Label, c.Pairs, compareNum(c.MeanA*f, unit), compareNum(c.MeanB*f, unit),
+			compareDelta(c.Delta*f, unit), c.DeltaPct, verdict)
+	}
+	return b.String()
+}
+
+// compareNum formats a value with its unit, e.g. "85.2 Mbps", "120 ms", "1.5%".
+func compareNum(v float64, unit string) string {
+	switch unit {
+	case "ms":
+		return strconv.FormatFloat(v, 'f', 0, 64) + " ms"
+	case "%":
+		return strconv.FormatFloat(v, 'f', 1, 64) + "%"
+	case "":
+		return strconv.FormatFloat(v, 'f', 1, 64)
+	}
+	return strconv.FormatFloat(v, 'f', 1, 64) + " " + unit
+}
+
+// compareDelta is compareNum with the sign of a difference, e.g. "+40.0 Mbps".
+func compareDelta(v float64, unit string) string {
+	if v > 0 {
+		return "+" + compareNum(v, unit)
+	}
+	return compareNum(v, unit)
+}
+
+// comparePairsText lists the pairs with metric m of both batches.
+func comparePairsText(pairs []analysis.BatchPair, m analysis.CompareMetric, speedUnit string, speedFactor float64) string {
+	var b strings.Builder
+	unit := compareUnit(m, speedUnit)
+	fmt.Fprintf(&b, "%4s  %-30s %-30s %10s %12s %12s\n", "Pair", "A batch", "B batch", "Offset", "A", "B")
+	for i, p := range pairs {
+		val := func(s analysis.BatchSummary) string {
+			if v, ok := compareValue(m, s, speedFactor); ok {
+				return compareNum(v, unit)
+			}
+			return "-"
+		}
+		fmt.Fprintf(&b, "%4d  %-30s %-30s %10s %12s %12s\n", i+1, compareBatchLabel(p.A), compareBatchLabel(p.B), compareOffset(p.Offset), val(p.A), val(p.B))
+	}
+	return b.String()
+}
+
+// compareBatchLabel is the run tag of a batch, with its start time when it has one.
+func compareBatchLabel(s analysis.BatchSummary) string {
+	if t := s.StartTime(); !t.IsZero() {
+		return t.In(timeAxisLoc).Format("2006-01-02 15:04") + " " + s.RunTag
+	}
+	return s.RunTag
+}
+
+// compareOffset is e.g. "+7d 10m" for a week and ten minutes, "-5m" for five minutes earlier.
+func compareOffset(d time.Duration) string {
+	sign := "+"
+	if d < 0 {
+		sign, d = "-", -d
+	}
+	d = d.Round(time.Minute)
+	days := d / (24 * time.Hour)
+	d -= days * 24 * time.Hour
+	s := strings.TrimSuffix(d.String(), "0s")
+	if s == "" {
+		s = "0m"
+	}
+	if days > 0 {
+		return fmt.Sprintf("%s%dd %s", sign, int(days), s)
+	}
+	return sign + s
+}
+
+// comparePairsCSV is every pair with all CompareMetrics of both batches, in kbps and ms.
+func comparePairsCSV(pairs []analysis.BatchPair) string {
+	var b strings.Builder
+	b.WriteString("pair,a_run_tag,a_start_utc,b_run_tag,b_start_utc,offset_s")
+	for _, m := range analysis.CompareMetrics {
+		b.WriteString(",a_" + m.Key + ",b_" + m.Key)
+	}
+	b.WriteString("\n")
+	start := func(s analysis.BatchSummary) string {
+		if t := s.StartTime(); !t.IsZero() {
+			return t.UTC().Format(time.RFC3339)
+		}
+		return ""
+	}
+	for i, p := range pairs {
+		fmt.Fprintf(&b, "%d,%s,%s,%s,%s,%.0f", i+1, p.A.RunTag, start(p.A), p.B.RunTag, start(p.B), p.Offset.Seconds())
+		for _, m := range analysis.CompareMetrics {
+			for _, s := range []analysis.BatchSummary{p.A, p.B} {
+				b.WriteString(",")
+				if v, ok := m.Get(s); ok {
+					b.WriteString(strconv.FormatFloat(v, 'f', 2, 64))
+				}
+			}
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// openCompareWith asks for a second results file and compares it with the batches on screen.
+func openCompareWith(state *uiState) {
+	if state == nil || state.window == nil {
+		return
+	}
+	if len(filteredSummaries(state)) == 0 {
+		dialog.ShowInformation("Compare With", "Open a results file first; the file picked next is compared with it.", state.window)
+		return
+	}
+	d := dialog.NewFileOpen(func(rc fyne.URIReadCloser, err error) {
+		if err != nil || rc == nil {
+			return
+		}
+		path := rc.URI().Path()
+		rc.Close()
+		loadCompareFile(state, path)
+	}, state.window)
+	d.Show()
+}
+
+// loadCompareFile analyzes path in the background, with the load progress dialog, and opens the
+// comparison; the batches on screen are not touched.
+func loadCompareFile(state *uiState, path string) {
+	ctx, cancel := context.WithCancel(context.Background())
+	prog := newLoadProgress(state, path, cancel)
+	opts := viewerAnalyzeOptions(state)
+	opts.Progress = prog.update
+	go func() {
+		res, err := analysis.Analyze(ctx, path, opts)
+		fyne.Do(func() {
+			prog.close()
+			cancel()
+			switch {
+			case errors.Is(err, context.Canceled):
+				return
+			case err != nil:
+				dialog.ShowError(err, state.window)
+			case len(res.Batches) == 0:
+				dialog.ShowInformation("Compare With", "No batches in "+filepath.Base(path)+".", state.window)
+			default:
+				openCompareView(state, path, res.Batches)
+			}
+		})
+	}()
+}
+
+// openCompareView shows the comparison of the filtered batches on screen (A) with batches (B).
+func openCompareView(state *uiState, pathB string, batches []analysis.BatchSummary) {
+	rowsA := filteredSummaries(state)
+	pathA := state.loadedPath
+	if pathA == "" {
+		pathA = state.filePath
+	}
+	nameA, nameB := filepath.Base(pathA), filepath.Base(pathB)
+	if nameA == nameB {
+		nameA, nameB = truncatePath(pathA, 40), truncatePath(pathB, 40)
+	}
+	speedUnit, speedFactor := speedUnitFor(state)
+	modeLabels := make([]string, len(compareModes))
+	for i, m := range compareModes {
+		modeLabels[i] = m.label
+	}
+	modeSel := widget.NewSelect(modeLabels, nil)
+	tolerance := widget.NewEntry()
+	tolerance.SetText(strconv.Itoa(defaultCompareToleranceMin))
+	metricLabels := make([]string, len(analysis.CompareMetrics))
+	for i, m := range analysis.CompareMetrics {
+		metricLabels[i] = m.Label
+	}
+	metricSel := widget.NewSelect(metricLabels, nil)
+	img := canvas.NewImageFromImage(blank(760, 260))
+	img.FillMode = canvas.ImageFillContain
+	img.SetMinSize(fyne.NewSize(760, 260))
+	grid := widget.NewTextGrid()
+	var csv string
+	refresh := func() {
+		mode := analysis.PairByIndex
+		for _, m := range compareModes {
+			if m.label == modeSel.Selected {
+				mode = m.mode
+			}
+		}
+		tol := defaultCompareToleranceMin
+		if v, err := strconv.Atoi(strings.TrimSpace(tolerance.Text)); err == nil && v >= 0 {
+			tol = v
+		}
+		metric := analysis.CompareMetrics[0]
+		for _, m := range analysis.CompareMetrics {
+			if m.Label == metricSel.Selected {
+				metric = m
+			}
+		}
+		pairs, err := analysis.PairBatches(rowsA, batches, mode, time.Duration(tol)*time.Minute)
+		if err != nil {
+			grid.SetText(err.Error())
+			return
+		}
+		head := fmt.Sprintf("A: %s — %d batches (current filters, Situation: %s)\nB: %s — %d batches\n%d pairs\n\n",
+			nameA, len(rowsA), activeSituationLabel(state), nameB, len(batches), len(pairs))
+		grid.SetText(head + compareSummaryText(analysis.ComparePairs(pairs, nameA, nameB), speedUnit, speedFactor) + "\n" +
+			comparePairsText(pairs, metric, speedUnit, speedFactor))
+		csv = comparePairsCSV(pairs)
+		img.Image = renderCompareChart(pairs, metric, nameA, nameB, speedUnit, speedFactor, 760, 260)
+		img.Refresh()
+	}
+	modeSel.OnChanged = func(string) {
+		if modeSel.Selected == compareModes[0].label {
+			tolerance.Disable()
+		} else {
+			tolerance.Enable()
+		}
+		refresh()
+	}
+	tolerance.OnChanged = func(string) { refresh() }
+	metricSel.OnChanged = func(string) { refresh() }
+	metricSel.SetSelected(metricLabels[0])
+	modeSel.SetSelected(modeLabels[0])
+	copyBtn := widget.NewButton("Copy CSV", func() { state.app.Clipboard().SetContent(csv) })
+	saveBtn := widget.NewButton("Save CSV…", func() {
+		fs := dialog.NewFileSave(func(wc fyne.URIWriteCloser, err error) {
+			if err != nil || wc == nil {
+				return
+			}
+			defer wc.Close()
+			if _, err := wc.Write([]byte(csv)); err != nil {
+				dialog.ShowError(err, state.window)
+			}
+		}, state.window)
+		fs.SetFileName("compare_pairs.csv")
+		fs.SetFilter(storage.NewExtensionFileFilter([]string{".csv"}))
+		fs.Show()
+	})
+	controls := container.NewHBox(widget.NewLabel("Match"), modeSel, widget.NewLabel("Tolerance (min)"),
+		container.NewGridWrap(fyne.NewSize(70, tolerance.MinSize().Height), tolerance), widget.NewLabel("Chart"), metricSel)
+	content := container.NewBorder(container.NewVBox(controls, img), container.NewHBox(copyBtn, saveBtn), nil, nil, container.NewScroll(grid))
+	d := dialog.NewCustom("Compare With "+nameB, "Close", content, state.window)
+	d.Resize(fyne.NewSize(900, 700))
+	d.Show()
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iafilius/InternetQualityMonitor/src/analysis"
+)
+
+func TestCompareOffset(t *testing.T) {
+	for d, want := range map[time.Duration]string{
+		0:                               "+0m",
+		-5 * time.Minute:                "-5m",
+		90 * time.Minute:                "+1h30m",
+		7*24*time.Hour + 10*time.Minute: "+7d 10m",
+		-(2*24*time.Hour + 3*time.Hour): "-2d 3h0m",
+		7*24*time.Hour + 29*time.Second: "+7d 0m",
+	} {
+		if got := compareOffset(d); got != want {
+			t.Errorf("compareOffset(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
+
+func TestCompareSummaryAndCSV(t *testing.T) {
+	start := time.Date(2025, 6, 2, 8, 0, 0, 0, time.UTC)
+	pairs := []analysis.BatchPair{{
+		A:      analysis.BatchSummary{RunTag: "before", StartedUTC: start.Format(time.RFC3339), Lines: 10, ErrorLines: 1, AvgSpeed: 40000, MedianSpeed: 40000, AvgTTFB: 120},
+		B:      analysis.BatchSummary{RunTag: "after", StartedUTC: start.AddDate(0, 0, 7).Format(time.RFC3339), Lines: 10, AvgSpeed: 80000, MedianSpeed: 80000, AvgTTFB: 90},
+		Offset: 7 * 24 * time.Hour,
+	}}
+	text := compareSummaryText(analysis.ComparePairs(pairs, "before.jsonl", "after.jsonl"), "Mbps", 1.0/1000)
+	for _, l := range strings.Split(text, "\n") {
+		if strings.HasPrefix(l, "Avg speed") && strings.Join(strings.Fields(l), " ") != "Avg speed 1 40.0 Mbps 80.0 Mbps +40.0 Mbps +100.0% better" {
+			t.Fatalf("avg speed: %q", l)
+		}
+		if strings.HasPrefix(l, "Avg TTFB") && !strings.HasSuffix(l, "better") {
+			t.Fatalf("lower TTFB should read better: %q", l)
+		}
+		if strings.HasPrefix(l, "Error rate") && !strings.Contains(l, "-100.0%") {
+			t.Fatalf("error rate change: %q", l)
+		}
+	}
+	lines := strings.Split(strings.TrimSpace(comparePairsCSV(pairs)), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "pair,a_run_tag,a_start_utc,b_run_tag,b_start_utc,offset_s,a_avg_speed,b_avg_speed,") {
+		t.Fatalf("csv header: %q", lines)
+	}
+	if !strings.HasPrefix(lines[1], "1,before,2025-06-02T08:00:00Z,after,2025-06-09T08:00:00Z,604800,40000.00,80000.00,") {
+		t.Fatalf("csv row: %q", lines[1])
+	}
+}
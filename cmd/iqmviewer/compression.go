@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	chart "github.com/wcharczuk/go-chart/v2"
+
+	helpers "github.com/iafilius/InternetQualityMonitor/cmd/iqmviewer/uihelpers"
+	"github.com/iafilius/InternetQualityMonitor/src/analysis"
+)
+
+// renderCompressionRatioChart draws BatchSummary.Compression per batch: the effective ratio of
+// decoded to transferred body bytes, the average ratio of the compressed lines and, as large red
+// dots, batches in which compressible bodies arrived uncompressed. Batches without body samples
+// are omitted.
+func renderCompressionRatioChart(state *uiState) image.Image {
+	cw, chh := chartSize(state)
+	rows := filteredSummaries(state)
+	if len(rows) == 0 {
+		return blank(cw, chh)
+	}
+	timeMode, times, xs, xAxis := buildXAxis(rows, state.xAxisMode)
+	type pts struct {
+		x  []float64
+		t  []time.Time
+		ys []float64
+	}
+	var effective, compressed, stripped pts
+	add := func(p *pts, i int, y float64) {
+		if timeMode {
+			p.t = append(p.t, times[i])
+		} else {
+			p.x = append(p.x, xs[i])
+		}
+		p.ys = append(p.ys, y)
+	}
+	maxY := 0.0
+	for i, r := range rows {
+		cs := r.Compression
+		if cs == nil {
+			continue
+		}
+		if cs.EffectiveRatio > 0 {
+			add(&effective, i, cs.EffectiveRatio)
+		}
+		if cs.AvgCompressedRatio > 0 {
+			add(&compressed, i, cs.AvgCompressedRatio)
+		}
+		if cs.UncompressedCompressible > 0 {
+			add(&stripped, i, math.Max(cs.EffectiveRatio, 1))
+		}
+		maxY = math.Max(maxY, math.Max(cs.EffectiveRatio, cs.AvgCompressedRatio))
+	}
+	if len(effective.ys) == 0 && len(compressed.ys) == 0 {
+		return drawHint(blank(cw, chh), "No body samples: the monitor records them unless --accept-encoding is empty.")
+	}
+	var series []chart.Series
+	addSeries := func(name string, p pts, st chart.Style) {
+		if len(p.ys) == 0 {
+			return
+		}
+		if len(p.ys) == 1 {
+			p.ys = append(p.ys, p.ys[0])
+			if timeMode {
+				p.t = append(p.t, p.t[0].Add(1*time.Second))
+			} else {
+				p.x = append(p.x, p.x[0]+1)
+			}
+		}
+		if timeMode {
+			series = append(series, chart.TimeSeries{Name: name, XValues: p.t, YValues: p.ys, Style: st})
+		} else {
+			series = append(series, chart.ContinuousSeries{Name: name, XValues: p.x, YValues: p.ys, Style: st})
+		}
+	}
+	addSeries("Effective", effective, pointStyle(chart.ColorAlternateGray))
+	addSeries("Compressed lines", compressed, pointStyle(chart.ColorBlue))
+	if len(stripped.ys) == 1 {
+		// a lone marker is padded in place
+		stripped.x, stripped.t, stripped.ys = append(stripped.x, stripped.x...), append(stripped.t, stripped.t...), append(stripped.ys, stripped.ys...)
+	}
+	addSeries("Uncompressed text", stripped, chart.Style{StrokeWidth: 0, DotWidth: 9, DotColor: chart.ColorRed.WithAlpha(110)})
+	vals := helpers.BuildNumericTicks(0, math.Max(maxY*1.15, 2), 6)
+	if len(vals) < 2 {
+		vals = []float64{0, 4}
+	}
+	yTicks := make([]chart.Tick, len(vals))
+	for i, v := range vals {
+		yTicks[i] = chart.Tick{Value: v, Label: helpers.FormatNumericTick(v)}
+	}
+	padBottom := 28
+	switch state.xAxisMode {
+	case "run_tag":
+		padBottom = 90
+	case "time":
+		padBottom = 48
+	}
+	if state.showHints {
+		padBottom += 18
+	}
+	ch := chart.Chart{
+		Title:      "Compression Ratio",
+		Background: chart.Style{Padding: chart.Box{Top: 14, Left: 16, Right: 12, Bottom: padBottom}},
+		XAxis:      xAxis,
+		YAxis:      chart.YAxis{Name: "decoded / transferred", Range: &chart.ContinuousRange{Min: vals[0], Max: vals[len(vals)-1]}, Ticks: yTicks},
+		Series:     series,
+	}
+	themeChart(&ch)
+	ch.Title += allGroupsNote(state)
+	ch.Width, ch.Height = cw, chh
+	attachLegend(state, &ch)
+	var buf bytes.Buffer
+	if err := renderChart(state, &ch, &buf); err != nil {
+		return blank(cw, chh)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		return blank(cw, chh)
+	}
+	if state.showHints {
+		img = drawHint(img, "Hint: an effective ratio dropping to 1 while the sites stayed the same means compression is being stripped on the way.")
+	}
+	return drawWatermark(img, "Situation: "+activeSituationLabel(state))
+}
+
+// compressionLines is the crosshair readout of the Compression Ratio chart for one batch.
+func compressionLines(cs *analysis.CompressionStats) []string {
+	if cs == nil {
+		return []string{"No body samples"}
+	}
+	lines := []string{fmt.Sprintf("Effective ratio: %.2f× (compressed lines %.2f×)", cs.EffectiveRatio, cs.AvgCompressedRatio),
+		fmt.Sprintf("Compressed: %d of %d lines (%.1f%%): %s", cs.CompressedLines, cs.Lines, cs.CompressedPct, encodingMix(cs.Encodings))}
+	if cs.UncompressedCompressible > 0 {
+		lines = append(lines, fmt.Sprintf("Compressible but uncompressed: %d lines", cs.UncompressedCompressible))
+	}
+	if cs.SavedBytes > 0 {
+		lines = append(lines, fmt.Sprintf("Saved on the wire: %.1f MB", float64(cs.SavedBytes)/1e6))
+	}
+	return lines
+}
+
+// encodingMix lists the line count per Content-Encoding, most used first: "gzip 12, identity 3".
+func encodingMix(encodings map[string]int) string {
+	names := make([]string, 0, len(encodings))
+	for n := range encodings {
+		names = append(names, n)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if encodings[names[i]] != encodings[names[j]] {
+			return encodings[names[i]] > encodings[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	parts := make([]string, len(names))
+	for i, n := range names {
+		parts[i] = fmt.Sprintf("%s %d", n, encodings[n])
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	chart "github.com/wcharczuk/go-chart/v2"
+	"github.com/wcharczuk/go-chart/v2/drawing"
+
+	"github.com/iafilius/InternetQualityMonitor/src/analysis"
+)
+
+// Config change markers (Chart Options → "Config Change Markers"): batches whose monitor
+// configuration differs from the previous batch (BatchSummary.ConfigChanges: sites list,
+// --parallel, timeouts, interval) get a dashed vertical line with a small flag on every batch
+// chart, and the crosshair readout there names the changes.
+
+// configMarker is one marked batch in the positions buildXAxis gives it.
+type configMarker struct {
+	Batch   float64 // 1-based position on the Batch/RunTag axes
+	TimeX   float64 // position on the time axis (chart.TimeToFloat64)
+	Changes []string
+}
+
+// chartConfigMarkers is global like chartTrend: renderChart, which adds the marker series, has no
+// uiState. updateConfigMarkers sets it from the filtered batches before charts are rendered.
+var (
+	chartConfigMarkersMu sync.Mutex
+	chartConfigMarkers   []configMarker
+)
+
+var configMarkerColor = drawing.Color{R: 148, G: 103, B: 189, A: 200}
+
+// configMarkersFor returns the markers for rows; the time positions follow buildXAxis so they
+// line up with the time-axis points.
+func configMarkersFor(rows []analysis.BatchSummary) []configMarker {
+	var out []configMarker
+	var times []float64
+	for i, r := range rows {
+		if len(r.ConfigChanges) == 0 {
+			continue
+		}
+		if times == nil {
+			_, ts, _, _ := buildXAxis(rows, "time")
+			times = make([]float64, len(ts))
+			for j, t := range ts {
+				times[j] = chart.TimeToFloat64(t)
+			}
+		}
+		out = append(out, configMarker{Batch: float64(i + 1), TimeX: times[i], Changes: r.ConfigChanges})
+	}
+	return out
+}
+
+// updateConfigMarkers refreshes chartConfigMarkers for the state's filtered batches.
+func updateConfigMarkers(state *uiState) {
+	var m []configMarker
+	if state != nil && state.showConfigMarkers {
+		m = configMarkersFor(filteredSummaries(state))
+	}
+	chartConfigMarkersMu.Lock()
+	chartConfigMarkers = m
+	chartConfigMarkersMu.Unlock()
+}
+
+// applyConfigMarkers appends the marker series to a batch chart (see batchAxisNames).
+func applyConfigMarkers(c *chart.Chart) {
+	chartConfigMarkersMu.Lock()
+	markers := chartConfigMarkers
+	chartConfigMarkersMu.Unlock()
+	if c == nil || len(markers) == 0 {
+		return
+	}
+	timeAxis := c.XAxis.Name == timeAxisName()
+	if !timeAxis && !batchAxisNames[c.XAxis.Name] {
+		return
+	}
+	s := configMarkerSeries{Name: "Config change", Style: chart.Style{StrokeColor: configMarkerColor, StrokeWidth: 1.5, StrokeDashArray: []float64{4, 3}, FillColor: configMarkerColor}}
+	for _, m := range markers {
+		if timeAxis {
+			s.XValues = append(s.XValues, m.TimeX)
+		} else {
+			s.XValues = append(s.XValues, m.Batch)
+		}
+	}
+	c.Series = append(append([]chart.Series(nil), c.Series...), s)
+}
+
+// configMarkerSeries draws a full-height vertical line with a flag at the top per x value. It
+// provides no values, so it does not influence the axis ranges.
+type configMarkerSeries struct {
+	Name    string
+	Style   chart.Style
+	XValues []float64
+}
+
+func (s configMarkerSeries) GetName() string           { return s.Name }
+func (s configMarkerSeries) GetStyle() chart.Style     { return s.Style }
+func (s configMarkerSeries) GetYAxis() chart.YAxisType { return chart.YAxisPrimary }
+func (s configMarkerSeries) Validate() error           { return nil }
+
+func (s configMarkerSeries) Render(r chart.Renderer, canvasBox chart.Box, xrange, yrange chart.Range, defaults chart.Style) {
+	st := s.Style.InheritFrom(defaults)
+	for _, x := range s.XValues {
+		if x < xrange.GetMin() || x > xrange.GetMax() {
+			continue
+		}
+		px := canvasBox.Left + xrange.Translate(x)
+		r.SetStrokeColor(st.StrokeColor)
+		r.SetStrokeWidth(st.StrokeWidth)
+		r.SetStrokeDashArray(st.StrokeDashArray)
+		r.MoveTo(px, canvasBox.Top)
+		r.LineTo(px, canvasBox.Bottom)
+		r.Stroke()
+		r.SetStrokeDashArray(nil)
+		r.SetFillColor(st.FillColor)
+		r.MoveTo(px, canvasBox.Top)
+		r.LineTo(px+8, canvasBox.Top+3)
+		r.LineTo(px, canvasBox.Top+6)
+		r.Close()
+		r.Fill()
+	}
+}
+
+// msDur formats a meta.config millisecond setting ("2m0s").
+func msDur(ms int64) string { return (time.Duration(ms) * time.Millisecond).String() }
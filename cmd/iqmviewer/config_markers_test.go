@@ -0,0 +1,40 @@
+package main
+
+import (
+	"testing"
+
+	chart "github.com/wcharczuk/go-chart/v2"
+
+	"github.com/iafilius/InternetQualityMonitor/src/analysis"
+)
+
+func TestConfigMarkersOnBatchCharts(t *testing.T) {
+	rows := []analysis.BatchSummary{
+		{RunTag: "20261001_100000_i1"},
+		{RunTag: "20261001_100000_i2", ConfigChanges: []string{"parallel 1→2"}},
+		{RunTag: "20261001_100000_i3"},
+	}
+	s := &uiState{summaries: rows, situation: "All", showConfigMarkers: true}
+	updateConfigMarkers(s)
+	defer func() { chartConfigMarkers = nil }()
+	_, times, _, _ := buildXAxis(rows, "time")
+	if len(chartConfigMarkers) != 1 || chartConfigMarkers[0].Batch != 2 || chartConfigMarkers[0].TimeX != chart.TimeToFloat64(times[1]) {
+		t.Fatalf("markers = %+v", chartConfigMarkers)
+	}
+	c := &chart.Chart{XAxis: chart.XAxis{Name: "Batch"}}
+	applyConfigMarkers(c)
+	if len(c.Series) != 1 || c.Series[0].(configMarkerSeries).XValues[0] != 2 {
+		t.Fatalf("batch chart series = %+v", c.Series)
+	}
+	// per-transfer charts (time (s) axis) stay unmarked
+	d := &chart.Chart{XAxis: chart.XAxis{Name: "time (s)"}}
+	applyConfigMarkers(d)
+	if len(d.Series) != 0 {
+		t.Fatalf("detailed chart got markers")
+	}
+	s.showConfigMarkers = false
+	updateConfigMarkers(s)
+	if len(chartConfigMarkers) != 0 {
+		t.Fatalf("toggle off keeps markers")
+	}
+}
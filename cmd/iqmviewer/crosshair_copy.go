@@ -0,0 +1,134 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+)
+
+// crosshairReadoutRow is one value of the crosshair readout, split for pasting into tickets.
+type crosshairReadoutRow struct {
+	series string
+	value  string
+	unit   string
+}
+
+// parseCrosshairReadout splits readout lines like "IPv4: 12.3 Mbps", "Avg: 1.0 ms  P95: 2.5 ms",
+// "IPv4: 12 / 14 ms" or "TCP: avg 90 kbps, median 88 kbps" into one series/value/unit row per
+// value. Words before a value extend the series name ("TCP avg"); values joined by " / " share
+// the unit of the last one. Parts without any number are kept whole as the series with an empty
+// value, so nothing shown is lost.
+func parseCrosshairReadout(lines []string) []crosshairReadoutRow {
+	var out []crosshairReadoutRow
+	for _, ln := range lines {
+		for _, part := range strings.Split(ln, "  ") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			name, rest, ok := strings.Cut(part, ": ")
+			if !ok {
+				name, rest = "", part
+			}
+			rows := parseReadoutValues(name, rest)
+			if rows == nil {
+				out = append(out, crosshairReadoutRow{series: part})
+				continue
+			}
+			out = append(out, rows...)
+		}
+	}
+	return out
+}
+
+// parseReadoutValues splits the text after a readout's "Name: " on ", ", " (" and " / " into
+// rows; nil when no piece holds a number.
+func parseReadoutValues(name, rest string) []crosshairReadoutRow {
+	var out []crosshairReadoutRow
+	found := false
+	for _, clause := range strings.FieldsFunc(strings.ReplaceAll(rest, " (", ", "), func(r rune) bool { return r == ',' }) {
+		var group []crosshairReadoutRow
+		for _, seg := range strings.Split(clause, " / ") {
+			seg = strings.TrimSpace(strings.TrimRight(strings.TrimSpace(seg), ")"))
+			if seg == "" {
+				continue
+			}
+			row, ok := parseReadoutValue(name, seg)
+			found = found || ok
+			group = append(group, row)
+		}
+		// "12 / 14 ms": the values before the last share its unit
+		if n := len(group); n > 1 && group[n-1].value != "" {
+			for i := range group[:n-1] {
+				if group[i].value != "" && group[i].unit == "" {
+					group[i].unit = group[n-1].unit
+				}
+			}
+		}
+		out = append(out, group...)
+	}
+	if !found {
+		return nil
+	}
+	return out
+}
+
+// parseReadoutValue parses one "[label] number [unit]" piece; ok is false when it holds no
+// number, and the row then carries the piece as its series.
+func parseReadoutValue(name, seg string) (crosshairReadoutRow, bool) {
+	series := func(label []string) string {
+		return strings.TrimSpace(name + " " + strings.Join(label, " "))
+	}
+	fields := strings.Fields(seg)
+	for i, tok := range fields {
+		num := strings.TrimRight(tok, "%")
+		if _, err := strconv.ParseFloat(num, 64); err != nil {
+			continue
+		}
+		unit := strings.Join(fields[i+1:], " ")
+		if strings.HasSuffix(tok, "%") {
+			unit = strings.TrimSpace("% " + unit)
+		}
+		return crosshairReadoutRow{series: series(fields[:i]), value: num, unit: unit}, true
+	}
+	return crosshairReadoutRow{series: series(fields)}, false
+}
+
+// crosshairClipboardText renders a readout as tab-separated rows with a header
+// (run_tag, metric, series, value, unit), which pastes as a table into tickets and spreadsheets.
+func crosshairClipboardText(runTag, metric string, lines []string) string {
+	var b strings.Builder
+	b.WriteString("run_tag\tmetric\tseries\tvalue\tunit\n")
+	for _, r := range parseCrosshairReadout(lines) {
+		b.WriteString(strings.Join([]string{runTag, metric, r.series, r.value, r.unit}, "\t"))
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// chartTitle is the title of the chart section this overlay (or a detached copy with the same
+// mode) belongs to; the mode itself when no section matches.
+func (c *crosshairOverlay) chartTitle() string {
+	if c.state != nil {
+		for _, ref := range c.state.chartRefs {
+			if _, ov := sectionChart(ref.section); ov != nil && ov.mode == c.mode {
+				return ref.title
+			}
+		}
+	}
+	return c.mode
+}
+
+// Tapped copies the values under the crosshair to the clipboard; the readout says so until the
+// mouse moves on.
+func (c *crosshairOverlay) Tapped(_ *fyne.PointEvent) {
+	if !c.enabled || !c.hovering || len(c.readout) == 0 || c.state == nil || c.state.app == nil {
+		return
+	}
+	c.state.app.Clipboard().SetContent(crosshairClipboardText(c.readoutTag, c.chartTitle(), c.readout))
+	c.copied = true
+	c.Refresh()
+}
+
+var _ fyne.Tappable = (*crosshairOverlay)(nil)
@@ -0,0 +1,46 @@
+package main
+
+import "testing"
+
+func TestCrosshairClipboardText(t *testing.T) {
+	lines := []string{"Overall: 12.3 Mbps", "IPv6: 4.50%", "Avg: 1.0 ms  P95: 2.5 ms", "No IPv4 data"}
+	got := crosshairClipboardText("20260101_120000", "Speed – Average", lines)
+	want := "run_tag\tmetric\tseries\tvalue\tunit\n" +
+		"20260101_120000\tSpeed – Average\tOverall\t12.3\tMbps\n" +
+		"20260101_120000\tSpeed – Average\tIPv6\t4.50\t%\n" +
+		"20260101_120000\tSpeed – Average\tAvg\t1.0\tms\n" +
+		"20260101_120000\tSpeed – Average\tP95\t2.5\tms\n" +
+		"20260101_120000\tSpeed – Average\tNo IPv4 data\t\t\n"
+	if got != want {
+		t.Fatalf("clipboard text:\n%q\nwant\n%q", got, want)
+	}
+	rows := parseCrosshairReadout([]string{"IPv6 lost: 12.5% of 4 races", "Batch: 1.5 MB down, 0.2 MB up"})
+	if len(rows) != 3 || rows[0].value != "12.5" || rows[0].unit != "% of 4 races" || rows[1] != (crosshairReadoutRow{"Batch", "1.5", "MB down"}) || rows[2] != (crosshairReadoutRow{"Batch", "0.2", "MB up"}) {
+		t.Fatalf("rows: %+v", rows)
+	}
+}
+
+func TestParseCrosshairReadout_SeveralValues(t *testing.T) {
+	rows := parseCrosshairReadout([]string{
+		"A 12 ms / B 14 ms",
+		"IPv4: 12 / 14 ms",
+		"TCP: avg 90000 kbps, median 88000 kbps, 7 retransmits",
+		"A lookup: 12 ms (P95 20, 1.5% failed)",
+		"Status: unknown",
+	})
+	want := []crosshairReadoutRow{
+		{"A", "12", "ms"}, {"B", "14", "ms"},
+		{"IPv4", "12", "ms"}, {"IPv4", "14", "ms"},
+		{"TCP avg", "90000", "kbps"}, {"TCP median", "88000", "kbps"}, {"TCP", "7", "retransmits"},
+		{"A lookup", "12", "ms"}, {"A lookup P95", "20", ""}, {"A lookup", "1.5", "% failed"},
+		{"Status: unknown", "", ""},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("rows: %+v", rows)
+	}
+	for i := range want {
+		if rows[i] != want[i] {
+			t.Fatalf("row %d: %+v, want %+v", i, rows[i], want[i])
+		}
+	}
+}
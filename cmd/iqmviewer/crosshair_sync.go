@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/iafilius/InternetQualityMonitor/src/analysis"
+)
+
+// Synchronized crosshair: the crosshair snaps to the nearest batch, and that batch is highlighted
+// on every other batch chart at once (a band at its X position, without readout) and named in
+// the status bar under the BatchAvg charts. Hovering a TTFB spike thus shows at a glance what
+// the error, stall or proxy charts did in the same batch. Detailed (per-batch) charts and
+// detached windows keep their own crosshair.
+
+// hoverStatusIdle is the status bar text while no batch chart is hovered.
+const hoverStatusIdle = "Hover a chart to highlight its nearest batch on every chart."
+
+// syncable reports whether the overlay follows the batch hovered on another chart.
+func (c *crosshairOverlay) syncable() bool {
+	return c.mode != "" && c.img == nil && !strings.HasPrefix(c.mode, "detailed_")
+}
+
+// registerCrosshairOverlay adds c to the overlays that follow the hovered batch.
+func registerCrosshairOverlay(state *uiState, c *crosshairOverlay) {
+	if state != nil && c.syncable() {
+		state.crosshairOverlays = append(state.crosshairOverlays, c)
+	}
+}
+
+// setHoverBatch records the batch under source's crosshair ("" once the pointer leaves) and,
+// when it changed, redraws the other overlays and the status bar.
+func setHoverBatch(state *uiState, source *crosshairOverlay, runTag string) {
+	if state == nil || state.hoverRunTag == runTag || (source != nil && !source.syncable()) {
+		return
+	}
+	state.hoverRunTag = runTag
+	for _, o := range state.crosshairOverlays {
+		if o != source && o.enabled {
+			o.Refresh()
+		}
+	}
+	if state.hoverStatus != nil {
+		state.hoverStatus.SetText(hoverStatusText(state, runTag))
+	}
+}
+
+// hoverStatusText names the hovered batch: run tag, start time, position and situation.
+func hoverStatusText(state *uiState, runTag string) string {
+	if runTag == "" {
+		return hoverStatusIdle
+	}
+	rows := filteredSummaries(state)
+	i := batchIndexByRunTag(rows, runTag)
+	if i < 0 {
+		return hoverStatusIdle
+	}
+	bs := rows[i]
+	parts := []string{"Batch " + bs.RunTag}
+	if t := bs.StartTime(); !t.IsZero() {
+		parts = append(parts, t.In(timeAxisLoc).Format("2006-01-02 15:04:05 MST"))
+	}
+	parts = append(parts, fmt.Sprintf("%d of %d", i+1, len(rows)))
+	if bs.Situation != "" {
+		parts = append(parts, "Situation: "+bs.Situation)
+	}
+	if bs.Canceled {
+		parts = append(parts, "canceled")
+	}
+	return strings.Join(parts, " — ")
+}
+
+// batchIndexByRunTag is the index of runTag in rows, -1 when absent.
+func batchIndexByRunTag(rows []analysis.BatchSummary, runTag string) int {
+	for i := range rows {
+		if rows[i].RunTag == runTag {
+			return i
+		}
+	}
+	return -1
+}
+
+// newHoverStatusBar is the status line under the BatchAvg charts.
+func newHoverStatusBar(state *uiState) *widget.Label {
+	l := widget.NewLabel(hoverStatusIdle)
+	l.Truncation = fyne.TextTruncateEllipsis
+	l.Importance = widget.LowImportance
+	state.hoverStatus = l
+	return l
+}
+
+// hoverBandColor is the translucent highlight of the hovered batch.
+func hoverBandColor() color.Color {
+	r, g, b, _ := theme.Color(theme.ColorNamePrimary).RGBA()
+	return color.NRGBA{R: uint8(r >> 8), G: uint8(g >> 8), B: uint8(b >> 8), A: 60}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"fyne.io/fyne/v2/test"
+
+	"github.com/iafilius/InternetQualityMonitor/src/analysis"
+)
+
+func TestSetHoverBatch_SyncsOverlaysAndStatus(t *testing.T) {
+	test.NewTempApp(t) // the status label and the synced overlays refresh
+	s := &uiState{crosshairEnabled: true}
+	s.summaries = []analysis.BatchSummary{{RunTag: "20260301_120000", Situation: "home"}, {RunTag: "20260301_130000", Situation: "home"}}
+	speed := newCrosshairOverlay(s, "speed")
+	ttfb := newCrosshairOverlay(s, "ttfb")
+	detailed := newCrosshairOverlay(s, "detailed_speed_over_time")
+	if len(s.crosshairOverlays) != 2 || detailed.syncable() {
+		t.Fatalf("only batch charts follow the hovered batch: %d registered", len(s.crosshairOverlays))
+	}
+	newHoverStatusBar(s)
+	setHoverBatch(s, speed, "20260301_130000")
+	if s.hoverRunTag != "20260301_130000" || !strings.Contains(s.hoverStatus.Text, "Batch 20260301_130000") || !strings.Contains(s.hoverStatus.Text, "2 of 2") {
+		t.Fatalf("hover %q, status %q", s.hoverRunTag, s.hoverStatus.Text)
+	}
+	// a hover on a chart that does not sync changes nothing
+	setHoverBatch(s, detailed, "20260301_120000")
+	if s.hoverRunTag != "20260301_130000" {
+		t.Fatalf("detailed chart moved the shared hover to %q", s.hoverRunTag)
+	}
+	setHoverBatch(s, ttfb, "")
+	if s.hoverRunTag != "" || s.hoverStatus.Text != hoverStatusIdle {
+		t.Fatalf("leaving the chart should clear the hover: %q %q", s.hoverRunTag, s.hoverStatus.Text)
+	}
+	if batchIndexByRunTag(s.summaries, "missing") != -1 {
+		t.Fatalf("unknown run tag must not be found")
+	}
+}
@@ -0,0 +1,398 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Dashboards are the custom visibility presets plus a section order: a preset's Order lists the
+// chart IDs top to bottom and applying it rearranges the charts column. They are picked from the
+// toolbar "Dashboard" dropdown or Settings → Visibility Presets, persist in
+// customVisibilityPresetsJSON and travel between machines as a JSON array of presets.
+
+// chartSectionKey is the stable chart ID of a column entry, the title for charts without one.
+func chartSectionKey(title string) string {
+	if id := chartTitleToID(title); id != "" {
+		return id
+	}
+	return title
+}
+
+// orderChartKeys returns current rearranged so the keys listed in order come first, in that
+// order; keys order does not mention (charts added since the preset was saved) keep their
+// relative position after them. Keys in order that no longer exist are ignored.
+func orderChartKeys(current, order []string) []string {
+	present := make(map[string]bool, len(current))
+	for _, k := range current {
+		present[k] = true
+	}
+	out := make([]string, 0, len(current))
+	used := map[string]bool{}
+	for _, k := range order {
+		if present[k] && !used[k] {
+			used[k] = true
+			out = append(out, k)
+		}
+	}
+	for _, k := range current {
+		if !used[k] {
+			out = append(out, k)
+		}
+	}
+	return out
+}
+
+// columnUnit is one chart of the charts column with the separator drawn above it.
+type columnUnit struct {
+	key string
+	sep fyne.CanvasObject
+	obj fyne.CanvasObject
+}
+
+// chartColumnUnits splits the charts column into units, keyed by the chart each section shows.
+func chartColumnUnits(state *uiState) []columnUnit {
+	if state == nil || state.chartsColumn == nil {
+		return nil
+	}
+	titles := map[fyne.CanvasObject]string{}
+	for _, r := range state.chartRefs {
+		titles[r.section] = r.title
+	}
+	if state.pretffbBlock != nil {
+		titles[state.pretffbBlock] = "Pre‑TTFB Stall Rate"
+	}
+	var units []columnUnit
+	var sep fyne.CanvasObject
+	for _, o := range state.chartsColumn.Objects {
+		if _, ok := o.(*widget.Separator); ok {
+			sep = o
+			continue
+		}
+		units = append(units, columnUnit{key: chartSectionKey(titles[o]), sep: sep, obj: o})
+		sep = nil
+	}
+	return units
+}
+
+// currentChartOrder lists the chart keys of the charts column top to bottom.
+func currentChartOrder(state *uiState) []string {
+	units := chartColumnUnits(state)
+	keys := make([]string, 0, len(units))
+	for _, u := range units {
+		keys = append(keys, u.key)
+	}
+	return keys
+}
+
+// applyChartOrder rearranges the charts column (and the Find order) to follow order; an empty
+// order restores the built-in one.
+func applyChartOrder(state *uiState, order []string) {
+	units := chartColumnUnits(state)
+	if len(units) == 0 {
+		return
+	}
+	if len(order) == 0 {
+		order = state.defaultChartOrder
+	}
+	byKey := make(map[string]columnUnit, len(units))
+	keys := make([]string, 0, len(units))
+	for _, u := range units {
+		byKey[u.key] = u
+		keys = append(keys, u.key)
+	}
+	objs := make([]fyne.CanvasObject, 0, len(state.chartsColumn.Objects))
+	pos := map[fyne.CanvasObject]int{}
+	for i, k := range orderChartKeys(keys, order) {
+		u := byKey[k]
+		// the Pre‑TTFB block carries its own separator
+		if i > 0 && u.obj != state.pretffbBlock {
+			sep := u.sep
+			if sep == nil {
+				sep = widget.NewSeparator()
+			}
+			objs = append(objs, sep)
+		}
+		pos[u.obj] = len(pos)
+		objs = append(objs, u.obj)
+	}
+	state.chartsColumn.Objects = objs
+	state.chartsColumn.Refresh()
+	sort.SliceStable(state.chartRefs, func(i, j int) bool {
+		return refColumnPos(state, pos, state.chartRefs[i]) < refColumnPos(state, pos, state.chartRefs[j])
+	})
+	refreshChartTOC(state)
+}
+
+func refColumnPos(state *uiState, pos map[fyne.CanvasObject]int, r chartRef) int {
+	if p, ok := pos[r.section]; ok {
+		return p
+	}
+	if r.section == state.pretffbSection {
+		return pos[state.pretffbBlock]
+	}
+	return len(pos)
+}
+
+// applyDashboard applies a custom preset: its visible charts and, when it has one, its order.
+func applyDashboard(state *uiState, fileLabel *widget.Label, name string) {
+	for _, p := range state.customPresets {
+		if p.Name != name {
+			continue
+		}
+		applyCustomPreset(state, name)
+		if len(p.Order) > 0 {
+			state.chartOrder = append([]string(nil), p.Order...)
+			applyChartOrder(state, state.chartOrder)
+		}
+		savePrefs(state)
+		state.applyChartVisibilityFromPrefs()
+		scheduleRedraw(state)
+		updateFindMatches(state)
+		scheduleMenuRebuild(state, fileLabel)
+		return
+	}
+}
+
+// refreshDashboardSelect syncs the toolbar dropdown with the presets and the active one.
+func refreshDashboardSelect(state *uiState) {
+	sel := state.dashboardSelect
+	if sel == nil {
+		return
+	}
+	names := make([]string, 0, len(state.customPresets))
+	for _, p := range state.customPresets {
+		names = append(names, p.Name)
+	}
+	sel.Options = names
+	// assigned directly: SetSelected would fire OnChanged and re-apply the preset
+	sel.Selected = activePresetName(state)
+	if len(names) == 0 {
+		sel.PlaceHolder = "(none saved)"
+		sel.Disable()
+	} else {
+		sel.PlaceHolder = "(custom)"
+		sel.Enable()
+	}
+	sel.Refresh()
+}
+
+// upsertPreset replaces the preset with the same name or appends p.
+func upsertPreset(presets []visibilityPreset, p visibilityPreset) ([]visibilityPreset, bool) {
+	for i := range presets {
+		if presets[i].Name == p.Name {
+			presets[i] = p
+			return presets, true
+		}
+	}
+	return append(presets, p), false
+}
+
+// parseDashboards reads an exported preset file: a JSON array of {name, ids, order}.
+func parseDashboards(data []byte) ([]visibilityPreset, error) {
+	var in []visibilityPreset
+	if err := json.Unmarshal(data, &in); err != nil {
+		return nil, fmt.Errorf("not a preset file: %w", err)
+	}
+	out := make([]visibilityPreset, 0, len(in))
+	for _, p := range in {
+		p.Name = strings.TrimSpace(p.Name)
+		if p.Name == "" || len(p.IDs) == 0 {
+			continue
+		}
+		out = append(out, p)
+	}
+	if len(out) == 0 {
+		return nil, errors.New("the file contains no named presets with charts")
+	}
+	return out, nil
+}
+
+// exportDashboards saves all custom presets as JSON.
+func exportDashboards(state *uiState) {
+	if len(state.customPresets) == 0 {
+		dialog.ShowInformation("Export presets", "There are no custom presets to export yet.", state.window)
+		return
+	}
+	data, err := json.MarshalIndent(state.customPresets, "", "  ")
+	if err != nil {
+		dialog.ShowError(err, state.window)
+		return
+	}
+	fs := dialog.NewFileSave(func(wc fyne.URIWriteCloser, err error) {
+		if err != nil || wc == nil {
+			return
+		}
+		defer wc.Close()
+		if _, err := wc.Write(append(data, '\n')); err != nil {
+			dialog.ShowError(err, state.window)
+		}
+	}, state.window)
+	fs.SetFileName("iqmviewer_presets.json")
+	fs.SetFilter(storage.NewExtensionFileFilter([]string{".json"}))
+	fs.Show()
+}
+
+// importDashboards merges presets from a file; presets with an existing name are replaced.
+func importDashboards(state *uiState, fileLabel *widget.Label) {
+	d := dialog.NewFileOpen(func(rc fyne.URIReadCloser, err error) {
+		if err != nil || rc == nil {
+			return
+		}
+		defer rc.Close()
+		data, err := io.ReadAll(rc)
+		if err != nil {
+			dialog.ShowError(err, state.window)
+			return
+		}
+		in, err := parseDashboards(data)
+		if err != nil {
+			dialog.ShowError(err, state.window)
+			return
+		}
+		added, replaced := 0, 0
+		for _, p := range in {
+			var dup bool
+			state.customPresets, dup = upsertPreset(state.customPresets, p)
+			if dup {
+				replaced++
+			} else {
+				added++
+			}
+		}
+		savePrefs(state)
+		scheduleMenuRebuild(state, fileLabel)
+		dialog.ShowInformation("Import presets", fmt.Sprintf("Imported %d preset(s): %d new, %d replaced.", len(in), added, replaced), state.window)
+	}, state.window)
+	d.SetFilter(storage.NewExtensionFileFilter([]string{".json"}))
+	d.Show()
+}
+
+// openDashboardEditor edits which charts a preset shows and their order; a new preset starts
+// from the current layout.
+func openDashboardEditor(state *uiState, fileLabel *widget.Label, name string) {
+	if state == nil || state.window == nil {
+		return
+	}
+	titles := map[string]string{}
+	for _, r := range state.chartRefs {
+		titles[chartSectionKey(r.title)] = r.title
+	}
+	visible := map[string]bool{}
+	order := currentChartOrder(state)
+	for _, id := range currentVisibleChartIDs(state) {
+		visible[id] = true
+	}
+	for _, p := range state.customPresets {
+		if p.Name == name {
+			visible = map[string]bool{}
+			for _, id := range p.IDs {
+				visible[id] = true
+			}
+			order = orderChartKeys(order, p.Order)
+		}
+	}
+	// only charts with a stable ID can be stored in a preset
+	keys := make([]string, 0, len(order))
+	for _, k := range order {
+		if chartTitleToID(titles[k]) != "" {
+			keys = append(keys, k)
+		}
+	}
+	nameEntry := widget.NewEntry()
+	nameEntry.SetText(name)
+	nameEntry.SetPlaceHolder("Dashboard name, e.g. Latency focus")
+	rows := container.NewVBox()
+	var rebuild func()
+	move := func(i, delta int) {
+		j := i + delta
+		if j < 0 || j >= len(keys) {
+			return
+		}
+		keys[i], keys[j] = keys[j], keys[i]
+		rebuild()
+	}
+	rebuild = func() {
+		rows.Objects = rows.Objects[:0]
+		for i, k := range keys {
+			i, k := i, k
+			chk := widget.NewCheck(titles[k], func(on bool) { visible[k] = on })
+			chk.SetChecked(visible[k])
+			up := newLabeledIconButton("Move "+titles[k]+" up", theme.MoveUpIcon(), func() { move(i, -1) })
+			down := newLabeledIconButton("Move "+titles[k]+" down", theme.MoveDownIcon(), func() { move(i, 1) })
+			if i == 0 {
+				up.Disable()
+			}
+			if i == len(keys)-1 {
+				down.Disable()
+			}
+			rows.Add(container.NewHBox(chk, layout.NewSpacer(), up, down))
+		}
+		rows.Refresh()
+	}
+	rebuild()
+	all := widget.NewButton("Show all", func() {
+		for _, k := range keys {
+			visible[k] = true
+		}
+		rebuild()
+	})
+	none := widget.NewButton("Hide all", func() {
+		for _, k := range keys {
+			visible[k] = false
+		}
+		rebuild()
+	})
+	top := container.NewVBox(widget.NewForm(widget.NewFormItem("Name", nameEntry)), container.NewHBox(all, none))
+	content := container.NewBorder(top, nil, nil, nil, container.NewVScroll(rows))
+	title := "New Dashboard"
+	if name != "" {
+		title = "Edit Dashboard – " + name
+	}
+	d := dialog.NewCustomConfirm(title, "Save & Apply", "Cancel", content, func(ok bool) {
+		if !ok {
+			return
+		}
+		newName := strings.TrimSpace(nameEntry.Text)
+		if newName == "" {
+			dialog.ShowInformation("Invalid name", "Please enter a dashboard name.", state.window)
+			return
+		}
+		p := visibilityPreset{Name: newName, Order: append([]string(nil), keys...)}
+		for _, k := range keys {
+			if visible[k] {
+				p.IDs = append(p.IDs, k)
+			}
+		}
+		if len(p.IDs) == 0 {
+			dialog.ShowInformation("Empty dashboard", "Select at least one chart to show.", state.window)
+			return
+		}
+		sort.Strings(p.IDs)
+		if name != "" && newName != name {
+			// renamed: drop the old entry
+			kept := state.customPresets[:0]
+			for _, cp := range state.customPresets {
+				if cp.Name != name {
+					kept = append(kept, cp)
+				}
+			}
+			state.customPresets = kept
+		}
+		state.customPresets, _ = upsertPreset(state.customPresets, p)
+		applyDashboard(state, fileLabel, newName)
+	}, state.window)
+	d.Resize(fyne.NewSize(560, 640))
+	d.Show()
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestOrderChartKeys(t *testing.T) {
+	current := []string{"quality_score", "setup_dns", "setup_tls", "speed_avg", "error_rate"}
+	// a preset saved before error_rate existed, mentioning a chart that has since been removed
+	got := orderChartKeys(current, []string{"speed_avg", "gone_chart", "setup_dns", "speed_avg"})
+	want := "speed_avg,setup_dns,quality_score,setup_tls,error_rate"
+	if strings.Join(got, ",") != want {
+		t.Fatalf("order = %v, want %s", got, want)
+	}
+	if strings.Join(orderChartKeys(current, nil), ",") != strings.Join(current, ",") {
+		t.Fatalf("empty order must keep the current one")
+	}
+}
+
+func TestParseDashboardsAndUpsert(t *testing.T) {
+	data := []byte(`[{"name":" Latency focus ","ids":["ttfb_avg","setup_dns"],"order":["setup_dns","ttfb_avg"]},{"name":"","ids":["x"]},{"name":"Empty","ids":[]}]`)
+	in, err := parseDashboards(data)
+	if err != nil || len(in) != 1 || in[0].Name != "Latency focus" || len(in[0].Order) != 2 {
+		t.Fatalf("parse: %v %+v", err, in)
+	}
+	if _, err := parseDashboards([]byte(`{"name":"x"}`)); err == nil {
+		t.Fatalf("an object is not a preset file")
+	}
+	presets := []visibilityPreset{{Name: "Latency focus", IDs: []string{"speed_avg"}}}
+	presets, replaced := upsertPreset(presets, in[0])
+	if !replaced || len(presets) != 1 || presets[0].IDs[0] != "ttfb_avg" {
+		t.Fatalf("replace: %v %+v", replaced, presets)
+	}
+	presets, replaced = upsertPreset(presets, visibilityPreset{Name: "Protocol debugging", IDs: []string{"alpn_mix"}})
+	if replaced || len(presets) != 2 {
+		t.Fatalf("append: %v %+v", replaced, presets)
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"time"
+
+	chart "github.com/wcharczuk/go-chart/v2"
+
+	helpers "github.com/iafilius/InternetQualityMonitor/cmd/iqmviewer/uihelpers"
+)
+
+// chartDecimationEnabled mirrors the Settings toggle "Decimate long histories" (on by default).
+// Renderers see it through renderSettingsFor, which render snapshots capture on the UI thread.
+var chartDecimationEnabled = true
+
+// decimateChartSeries thins line/point series that have more points than the chart has pixels
+// (see helpers.DecimateIndices: min/max per bucket plus LTTB). Rendering many thousands of batch
+// points is slow and overdraws into a solid band; the bucket envelope keeps spikes visible.
+// Series are replaced by decimated copies, so the caller's slices are untouched.
+func decimateChartSeries(c *chart.Chart) {
+	if c == nil {
+		return
+	}
+	w := c.Width
+	if w <= 0 {
+		w = chart.DefaultChartWidth
+	}
+	buckets := w / 3
+	series := append([]chart.Series(nil), c.Series...)
+	for i, s := range series {
+		switch ser := s.(type) {
+		case chart.ContinuousSeries:
+			if len(ser.XValues) != len(ser.YValues) {
+				continue
+			}
+			idx := helpers.DecimateIndices(ser.XValues, ser.YValues, buckets)
+			if idx == nil {
+				continue
+			}
+			xs, ys := make([]float64, len(idx)), make([]float64, len(idx))
+			for k, j := range idx {
+				xs[k], ys[k] = ser.XValues[j], ser.YValues[j]
+			}
+			ser.XValues, ser.YValues = xs, ys
+			series[i] = ser
+		case chart.TimeSeries:
+			if len(ser.XValues) != len(ser.YValues) {
+				continue
+			}
+			fx := make([]float64, len(ser.XValues))
+			for k, t := range ser.XValues {
+				fx[k] = float64(t.UnixNano())
+			}
+			idx := helpers.DecimateIndices(fx, ser.YValues, buckets)
+			if idx == nil {
+				continue
+			}
+			xs, ys := make([]time.Time, len(idx)), make([]float64, len(idx))
+			for k, j := range idx {
+				xs[k], ys[k] = ser.XValues[j], ser.YValues[j]
+			}
+			ser.XValues, ser.YValues = xs, ys
+			series[i] = ser
+		}
+	}
+	c.Series = series
+}
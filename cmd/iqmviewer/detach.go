@@ -0,0 +1,271 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/png"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+)
+
+// detachedChart is a chart opened in its own window (double-click a chart or its Detach
+// button). It renders at the window's width with its own crosshair, and PageUp/PageDown step
+// through the other chart sections in main-window order.
+type detachedChart struct {
+	state   *uiState
+	win     fyne.Window
+	idx     int           // index into state.chartRefs
+	src     *canvas.Image // main-window canvas of the chart (identifies its renderer)
+	render  func(*uiState) image.Image
+	img     *canvas.Image
+	overlay *crosshairOverlay
+	title   *widget.Label
+	pos     *widget.Label
+	lastW   int
+	timer   *time.Timer
+}
+
+// sectionChart finds the chart image and crosshair overlay inside a chart section.
+func sectionChart(obj fyne.CanvasObject) (*canvas.Image, *crosshairOverlay) {
+	switch o := obj.(type) {
+	case *canvas.Image:
+		return o, nil
+	case *crosshairOverlay:
+		return nil, o
+	case *fyne.Container:
+		var img *canvas.Image
+		var ov *crosshairOverlay
+		for _, child := range o.Objects {
+			ci, co := sectionChart(child)
+			if img == nil {
+				img = ci
+			}
+			if ov == nil {
+				ov = co
+			}
+			if img != nil && ov != nil {
+				break
+			}
+		}
+		return img, ov
+	}
+	return nil, nil
+}
+
+// detachableAt reports whether chart section i can be shown detached (visible and re-renderable).
+func detachableAt(state *uiState, i int) bool {
+	if i < 0 || i >= len(state.chartRefs) {
+		return false
+	}
+	ref := state.chartRefs[i]
+	if ref.section == nil || !ref.section.Visible() {
+		return false
+	}
+	img, _ := sectionChart(ref.section)
+	return img != nil && rendererForImage(state, img) != nil
+}
+
+// openDetachedChartForSection opens the chart of sec in its own window.
+func openDetachedChartForSection(state *uiState, sec *fyne.Container) {
+	if state == nil {
+		return
+	}
+	for i, ref := range state.chartRefs {
+		if ref.section == sec {
+			openDetachedChart(state, i)
+			return
+		}
+	}
+}
+
+func openDetachedChart(state *uiState, idx int) {
+	if state == nil || state.app == nil || !detachableAt(state, idx) {
+		return
+	}
+	d := &detachedChart{state: state, idx: -1}
+	d.win = state.app.NewWindow("Chart")
+	d.img = canvas.NewImageFromImage(image.NewRGBA(image.Rect(0, 0, 100, 60)))
+	d.img.FillMode = canvas.ImageFillContain
+	d.title = widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	d.pos = widget.NewLabel("")
+	prevBtn := widget.NewButton("◀ PgUp", func() { d.step(-1) })
+	nextBtn := widget.NewButton("PgDn ▶", func() { d.step(1) })
+	crossChk := widget.NewCheck("Crosshair", func(b bool) {
+		if d.overlay != nil {
+			d.overlay.enabled = b
+			d.overlay.Refresh()
+		}
+	})
+	crossChk.SetChecked(true)
+	exportBtn := widget.NewButton("Export PNG…", func() {
+		exportChartPNG(state, d.src, chartTitleToID(state.chartRefs[d.idx].title)+"_chart.png")
+	})
+	exportWinBtn := widget.NewButton("Export as shown…", func() { d.exportAsShown() })
+	bar := container.NewHBox(prevBtn, nextBtn, d.title, layout.NewSpacer(), d.pos, crossChk, exportBtn, exportWinBtn)
+	d.overlay = newCrosshairOverlay(state, "")
+	d.overlay.enabled = true
+	d.overlay.img = d.img
+	d.win.SetContent(container.NewBorder(bar, nil, nil, nil, container.NewStack(d.img, d.overlay)))
+	d.win.Canvas().SetOnTypedKey(func(e *fyne.KeyEvent) {
+		switch e.Name {
+		case fyne.KeyPageUp:
+			d.step(-1)
+		case fyne.KeyPageDown:
+			d.step(1)
+		case fyne.KeyEscape:
+			d.win.Close()
+		}
+	})
+	d.win.SetOnClosed(func() {
+		if d.timer != nil {
+			d.timer.Stop()
+		}
+		sz := d.win.Canvas().Size()
+		state.app.Preferences().SetInt("detachedChartW", int(sz.Width))
+		state.app.Preferences().SetInt("detachedChartH", int(sz.Height))
+		for i, o := range state.detachedCharts {
+			if o == d {
+				state.detachedCharts = append(state.detachedCharts[:i], state.detachedCharts[i+1:]...)
+				break
+			}
+		}
+	})
+	w := state.app.Preferences().IntWithFallback("detachedChartW", 1400)
+	h := state.app.Preferences().IntWithFallback("detachedChartH", 640)
+	d.win.Resize(fyne.NewSize(float32(w), float32(h)))
+	state.detachedCharts = append(state.detachedCharts, d)
+	d.show(idx)
+	d.win.Show()
+	d.watchResize()
+}
+
+// show switches the window to chart section idx and renders it.
+func (d *detachedChart) show(idx int) {
+	ref := d.state.chartRefs[idx]
+	img, ov := sectionChart(ref.section)
+	d.idx = idx
+	d.src = img
+	d.render = rendererForImage(d.state, img)
+	d.overlay.mode = ""
+	if ov != nil {
+		d.overlay.mode = ov.mode
+	}
+	d.title.SetText(tr(ref.title))
+	d.overlay.a11yTitle = tr(ref.title)
+	d.win.SetTitle(tr(ref.title))
+	n, k := 0, 0
+	for i := range d.state.chartRefs {
+		if detachableAt(d.state, i) {
+			n++
+			if i <= idx {
+				k = n
+			}
+		}
+	}
+	d.pos.SetText(fmt.Sprintf("%d / %d", k, n))
+	d.lastW = 0
+	d.refresh()
+}
+
+// step moves to the previous/next detachable chart, wrapping around.
+func (d *detachedChart) step(dir int) {
+	n := len(d.state.chartRefs)
+	for i, j := 0, d.idx; i < n; i++ {
+		j = (j + dir + n) % n
+		if detachableAt(d.state, j) {
+			d.show(j)
+			return
+		}
+	}
+}
+
+// refresh re-renders the chart at the window's width (the chart height follows chartSize's
+// aspect rules); the image is then fitted into the window.
+func (d *detachedChart) refresh() {
+	if d.render == nil || d.win == nil {
+		return
+	}
+	w, h := widthOverrideChartSize(int(d.win.Canvas().Size().Width))
+	im := d.render(renderSnapshot(d.state, w, h))
+	d.lastW = w
+	d.img.Image = im
+	d.img.Refresh()
+	d.overlay.Refresh()
+}
+
+// watchResize re-renders after the window width settled, like the main window's resize debounce.
+func (d *detachedChart) watchResize() {
+	d.timer = time.AfterFunc(400*time.Millisecond, func() {
+		fyne.Do(func() {
+			if d.win == nil || d.win.Canvas() == nil {
+				return
+			}
+			if w := int(d.win.Canvas().Size().Width); d.lastW > 0 && (w > d.lastW+8 || w < d.lastW-8) && w >= 800 {
+				d.refresh()
+			}
+			for _, o := range d.state.detachedCharts {
+				if o == d {
+					d.watchResize()
+					return
+				}
+			}
+		})
+	})
+}
+
+// exportAsShown saves the image rendered for this window's size.
+func (d *detachedChart) exportAsShown() {
+	if d.img.Image == nil {
+		return
+	}
+	name := chartTitleToID(d.state.chartRefs[d.idx].title) + "_chart.png"
+	fs := dialog.NewFileSave(func(wc fyne.URIWriteCloser, err error) {
+		if err != nil || wc == nil {
+			return
+		}
+		defer wc.Close()
+		if encErr := png.Encode(wc, d.img.Image); encErr != nil {
+			dialog.ShowError(encErr, d.win)
+			return
+		}
+		p := wc.URI().Path()
+		if strings.TrimSpace(p) == "" {
+			p = wc.URI().String()
+		}
+		dialog.ShowInformation("Export complete", fmt.Sprintf("Saved to:\n%s", p), d.win)
+	}, d.win)
+	fs.SetFileName(name)
+	fs.SetFilter(storage.NewExtensionFileFilter([]string{".png"}))
+	fs.Show()
+}
+
+// refreshDetachedCharts re-renders open detached windows after the main charts were redrawn
+// (filters, data reload, theme), so they never show stale data.
+func refreshDetachedCharts(state *uiState) {
+	for _, d := range state.detachedCharts {
+		d.refresh()
+	}
+}
+
+// DoubleTapped opens the chart under the crosshair in its own window.
+func (c *crosshairOverlay) DoubleTapped(_ *fyne.PointEvent) {
+	if c.img != nil || c.state == nil {
+		return // already detached
+	}
+	for i, ref := range c.state.chartRefs {
+		if _, ov := sectionChart(ref.section); ov == c {
+			openDetachedChart(c.state, i)
+			return
+		}
+	}
+}
+
+var _ fyne.DoubleTappable = (*crosshairOverlay)(nil)
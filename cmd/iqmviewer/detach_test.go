@@ -0,0 +1,31 @@
+package main
+
+import (
+	"image"
+	"testing"
+
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+func TestSectionChart_FindsImageAndOverlay(t *testing.T) {
+	s := &uiState{}
+	s.speedImgCanvas = canvas.NewImageFromImage(image.NewRGBA(image.Rect(0, 0, 4, 4)))
+	s.speedOverlay = newCrosshairOverlay(s, "speed")
+	sec := makeChartSection(s, "Speed – Average", "help", container.NewStack(s.speedImgCanvas, s.speedOverlay))
+	img, ov := sectionChart(sec)
+	if img != s.speedImgCanvas || ov != s.speedOverlay {
+		t.Fatalf("sectionChart did not find the chart's canvas/overlay: %v %v", img, ov)
+	}
+	if !detachableAt(s, 0) {
+		t.Fatalf("a visible chart with a renderer must be detachable")
+	}
+	sec.Hide()
+	if detachableAt(s, 0) || detachableAt(s, 1) {
+		t.Fatalf("hidden or out-of-range sections must not be detachable")
+	}
+	if img, _ := sectionChart(container.NewVBox(widget.NewLabel("x"))); img != nil {
+		t.Fatalf("section without chart image should yield nil")
+	}
+}
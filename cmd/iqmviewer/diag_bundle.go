@@ -0,0 +1,179 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+
+	"github.com/iafilius/InternetQualityMonitor/src/analysis"
+	"github.com/iafilius/InternetQualityMonitor/src/monitor"
+)
+
+// diagBundleCharts are the key charts rendered into a diagnostics bundle, as currently filtered.
+var diagBundleCharts = []struct {
+	name   string
+	render func(*uiState) image.Image
+}{
+	{"speed_avg", renderSpeedChart},
+	{"ttfb_avg", renderTTFBChart},
+	{"error_rate", renderErrorRateChart},
+	{"jitter", renderJitterChart},
+	{"stall_rate", renderStallRateChart},
+	{"dns_lookup", renderDNSLookupChart},
+}
+
+// bundleEnvironment is environment.json of a diagnostics bundle.
+type bundleEnvironment struct {
+	CreatedUTC    string            `json:"created_utc"`
+	OS            string            `json:"os"`
+	Arch          string            `json:"arch"`
+	NumCPU        int               `json:"num_cpu"`
+	GoVersion     string            `json:"go_version"`
+	AppVersion    string            `json:"app_version,omitempty"` // module version, "(devel)" for local builds
+	Revision      string            `json:"vcs_revision,omitempty"`
+	Modified      bool              `json:"vcs_modified,omitempty"`
+	SchemaVersion int               `json:"schema_version"`
+	ResultsFile   string            `json:"results_file,omitempty"`
+	Filters       map[string]string `json:"filters,omitempty"`
+}
+
+// currentBundleEnvironment describes this viewer build and the loaded file.
+func currentBundleEnvironment(state *uiState) bundleEnvironment {
+	env := bundleEnvironment{
+		CreatedUTC:    time.Now().UTC().Format(time.RFC3339),
+		OS:            runtime.GOOS,
+		Arch:          runtime.GOARCH,
+		NumCPU:        runtime.NumCPU(),
+		GoVersion:     runtime.Version(),
+		SchemaVersion: monitor.SchemaVersion,
+	}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		env.AppVersion = bi.Main.Version
+		for _, s := range bi.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				env.Revision = s.Value
+			case "vcs.modified":
+				env.Modified = s.Value == "true"
+			}
+		}
+	}
+	if state != nil {
+		env.ResultsFile = state.filePath
+		env.Filters = map[string]string{"situation": state.situation, "vpn": state.vpnFilter, "interface": state.bindFilter, "tag": state.tagFilter, "group": state.groupFilter}
+	}
+	return env
+}
+
+// writeDiagnosticsBundle writes the ZIP: diagnostics.json (the full batch summary),
+// diagnostics.txt (the dialog text), lines.jsonl (the batch's raw lines as stored),
+// charts/<name>.png and environment.json. Nil charts are left out.
+func writeDiagnosticsBundle(w io.Writer, bs analysis.BatchSummary, tolPct int, lines []batchLine, charts map[string]image.Image, env bundleEnvironment) error {
+	zw := zip.NewWriter(w)
+	add := func(name string, data []byte) error {
+		f, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: time.Now()})
+		if err != nil {
+			return err
+		}
+		_, err = f.Write(data)
+		return err
+	}
+	summary, err := json.MarshalIndent(bs, "", "  ")
+	if err != nil {
+		return err
+	}
+	envJSON, err := json.MarshalIndent(env, "", "  ")
+	if err != nil {
+		return err
+	}
+	var raw bytes.Buffer
+	for _, bl := range lines {
+		raw.Write(bl.raw)
+		raw.WriteByte('\n')
+	}
+	files := []struct {
+		name string
+		data []byte
+	}{
+		{"diagnostics.json", summary},
+		{"diagnostics.txt", []byte(buildDiagnosticsText(bs, tolPct))},
+		{"lines.jsonl", raw.Bytes()},
+		{"environment.json", envJSON},
+	}
+	for _, f := range files {
+		if err := add(f.name, f.data); err != nil {
+			return err
+		}
+	}
+	for _, c := range diagBundleCharts {
+		img := charts[c.name]
+		if img == nil {
+			continue
+		}
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err != nil {
+			return fmt.Errorf("chart %s: %w", c.name, err)
+		}
+		if err := add("charts/"+c.name+".png", buf.Bytes()); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// exportDiagnosticsBundle asks for a .zip path and writes the bundle of bs. Charts are rendered
+// on the UI goroutine; reading the batch's lines and writing the archive run in the background.
+func exportDiagnosticsBundle(state *uiState, bs analysis.BatchSummary, parent fyne.Window) {
+	fs := dialog.NewFileSave(func(wc fyne.URIWriteCloser, err error) {
+		if err != nil || wc == nil {
+			return
+		}
+		charts := map[string]image.Image{}
+		for _, c := range diagBundleCharts {
+			if img := c.render(state); img != nil {
+				charts[c.name] = img
+			}
+		}
+		env := currentBundleEnvironment(state)
+		tolPct := state.calibTolerancePct
+		path := state.filePath
+		go func() {
+			defer wc.Close()
+			var lines []batchLine
+			f, err := analysis.OpenResults(path)
+			if err == nil {
+				lines, err = readBatchLines(f, bs.RunTag)
+				f.Close()
+			}
+			if err == nil {
+				err = writeDiagnosticsBundle(wc, bs, tolPct, lines, charts, env)
+			}
+			fyne.Do(func() {
+				if err != nil {
+					dialog.ShowError(err, parent)
+					return
+				}
+				p := wc.URI().Path()
+				if strings.TrimSpace(p) == "" {
+					p = wc.URI().String()
+				}
+				dialog.ShowInformation("Export complete", fmt.Sprintf("Saved to:\n%s\n\n%d lines, %d charts", p, len(lines), len(charts)), parent)
+			})
+		}()
+	}, parent)
+	fs.SetFileName("iqm_diagnostics_" + bs.RunTag + ".zip")
+	fs.SetFilter(storage.NewExtensionFileFilter([]string{".zip"}))
+	fs.Show()
+}
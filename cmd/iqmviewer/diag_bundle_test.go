@@ -0,0 +1,59 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"image"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/iafilius/InternetQualityMonitor/src/analysis"
+)
+
+func TestWriteDiagnosticsBundle(t *testing.T) {
+	in := `{"meta":{"run_tag":"A","schema_version":3},"site_result":{"url":"https://a/1","transfer_speed_kbps":900}}
+{"meta":{"run_tag":"B","schema_version":3},"site_result":{"url":"https://b/1"}}
+{"meta":{"run_tag":"A","schema_version":3},"site_result":{"url":"https://a/2","http_error":"stall_abort"}}
+`
+	lines, err := readBatchLines(strings.NewReader(in), "A")
+	if err != nil {
+		t.Fatal(err)
+	}
+	charts := map[string]image.Image{"speed_avg": image.NewRGBA(image.Rect(0, 0, 4, 3))}
+	env := bundleEnvironment{OS: "linux", AppVersion: "(devel)", SchemaVersion: 3}
+	var buf bytes.Buffer
+	if err := writeDiagnosticsBundle(&buf, analysis.BatchSummary{RunTag: "A", Lines: 2}, 10, lines, charts, env); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("zip: %v", err)
+	}
+	got := map[string]string{}
+	for _, f := range zr.File {
+		rc, _ := f.Open()
+		b, _ := io.ReadAll(rc)
+		rc.Close()
+		got[f.Name] = string(b)
+	}
+	for _, name := range []string{"diagnostics.json", "diagnostics.txt", "lines.jsonl", "environment.json", "charts/speed_avg.png"} {
+		if _, ok := got[name]; !ok {
+			t.Fatalf("missing %s (have %d files)", name, len(got))
+		}
+	}
+	if _, ok := got["charts/ttfb_avg.png"]; ok {
+		t.Fatal("unrendered chart written")
+	}
+	if n := strings.Count(got["lines.jsonl"], "\n"); n != 2 || strings.Contains(got["lines.jsonl"], "https://b/1") {
+		t.Fatalf("lines.jsonl should hold the 2 lines of A: %q", got["lines.jsonl"])
+	}
+	var bs analysis.BatchSummary
+	if err := json.Unmarshal([]byte(got["diagnostics.json"]), &bs); err != nil || bs.RunTag != "A" || bs.Lines != 2 {
+		t.Fatalf("diagnostics.json: %v %+v", err, bs.RunTag)
+	}
+	if !strings.Contains(got["environment.json"], `"app_version": "(devel)"`) || !strings.HasPrefix(got["diagnostics.txt"], "RunTag: A") {
+		t.Fatalf("environment/text: %s / %s", got["environment.json"], got["diagnostics.txt"])
+	}
+}
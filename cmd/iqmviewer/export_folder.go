@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Export All Charts (Folder): writes every chart of a default screenshot run as its own PNG under
+// the screenshot-mode file names (speed_avg.png, stall_rate.png, …), so the docs images can be
+// refreshed from the GUI with the current filters, unit and theme.
+
+// folderExportSize is a resolution choice of the folder export; w 0 is the current chart size.
+type folderExportSize struct {
+	label string
+	w, h  int
+}
+
+var folderExportSizes = []folderExportSize{
+	{"Screenshot mode (1100 × 340)", 1100, 340},
+	{"1600 × 520", 1600, 520},
+	{"1920 × 600", 1920, 600},
+	{"2560 × 800", 2560, 800},
+	{"Current window", 0, 0},
+}
+
+// writeChartFolder writes the rendered charts into dir under their names and returns how many
+// were written; charts without an image are skipped, the first error stops the export.
+func writeChartFolder(dir string, charts []screenshotChart, shots []renderedShot) (int, error) {
+	n := 0
+	for i, shot := range shots {
+		if shot.err != nil {
+			return n, shot.err
+		}
+		if shot.png == nil {
+			continue
+		}
+		p := filepath.Join(dir, charts[i].name)
+		if err := os.WriteFile(p, shot.png, 0o644); err != nil {
+			return n, fmt.Errorf("write %s: %w", p, err)
+		}
+		n++
+	}
+	return n, nil
+}
+
+// exportAllChartsToFolder asks for a resolution and a folder, then renders the charts off the UI
+// thread and writes them there.
+func exportAllChartsToFolder(state *uiState) {
+	if state == nil || state.window == nil {
+		return
+	}
+	if len(state.summaries) == 0 {
+		dialog.ShowInformation("Export All Charts (Folder)", "Open a results file first.", state.window)
+		return
+	}
+	labels := make([]string, len(folderExportSizes))
+	for i, s := range folderExportSizes {
+		labels[i] = s.label
+	}
+	sizeSel := widget.NewSelect(labels, nil)
+	sizeSel.SetSelectedIndex(0)
+	items := []*widget.FormItem{widget.NewFormItem("Resolution", sizeSel)}
+	dialog.ShowForm("Export All Charts (Folder)", "Choose folder…", "Cancel", items, func(ok bool) {
+		if !ok {
+			return
+		}
+		size := folderExportSizes[0]
+		if i := sizeSel.SelectedIndex(); i >= 0 {
+			size = folderExportSizes[i]
+		}
+		if size.w == 0 {
+			size.w, size.h = chartSize(state)
+		}
+		dialog.ShowFolderOpen(func(dir fyne.ListableURI, err error) {
+			if err != nil || dir == nil {
+				return
+			}
+			path := dir.Path()
+			snap := renderSnapshot(state, size.w, size.h)
+			charts := allScreenshotCharts()
+			progress := dialog.NewCustomWithoutButtons("Exporting charts…", widget.NewProgressBarInfinite(), state.window)
+			progress.Show()
+			go func() {
+				n, err := writeChartFolder(path, charts, renderScreenshots(snap, charts, renderWorkers(len(charts))))
+				fyne.Do(func() {
+					progress.Hide()
+					if err != nil {
+						dialog.ShowError(err, state.window)
+						return
+					}
+					if strings.TrimSpace(path) == "" {
+						path = dir.String()
+					}
+					dialog.ShowInformation("Export complete", fmt.Sprintf("%d charts (%d × %d) saved to:\n%s", n, size.w, size.h, path), state.window)
+				})
+			}()
+		}, state.window)
+	}, state.window)
+}
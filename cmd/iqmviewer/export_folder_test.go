@@ -0,0 +1,39 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAllScreenshotChartsNames(t *testing.T) {
+	seen := map[string]bool{}
+	for _, c := range allScreenshotCharts() {
+		if !strings.HasSuffix(c.name, ".png") || seen[c.name] || c.fn == nil {
+			t.Fatalf("bad or duplicate chart %q", c.name)
+		}
+		seen[c.name] = true
+	}
+	for _, n := range []string{"speed_avg.png", "local_throughput_selftest.png", "pretffb_stall_rate.png", "ttfb_avg_relative.png"} {
+		if !seen[n] {
+			t.Fatalf("missing %s", n)
+		}
+	}
+}
+
+func TestWriteChartFolder(t *testing.T) {
+	dir := t.TempDir()
+	charts := []screenshotChart{{name: "speed_avg.png"}, {name: "stall_rate.png"}, {name: "jitter.png"}}
+	shots := []renderedShot{{png: []byte("a")}, {}, {png: []byte("c")}}
+	n, err := writeChartFolder(dir, charts, shots)
+	if err != nil || n != 2 {
+		t.Fatalf("wrote %d: %v", n, err)
+	}
+	if b, err := os.ReadFile(filepath.Join(dir, "jitter.png")); err != nil || string(b) != "c" {
+		t.Fatalf("jitter.png: %q %v", b, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "stall_rate.png")); !os.IsNotExist(err) {
+		t.Fatalf("chart without image was written: %v", err)
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/iafilius/InternetQualityMonitor/src/analysis"
+)
+
+// updateGroupSelect refreshes the Group filter with one entry per target group seen in the loaded
+// batches (BatchSummary.Groups). It stays hidden when no site has a group.
+func updateGroupSelect(state *uiState) {
+	if state.groupSelect == nil || state.groupRow == nil {
+		return
+	}
+	set := map[string]struct{}{}
+	for _, r := range state.summaries {
+		for g := range r.Groups {
+			set[g] = struct{}{}
+		}
+	}
+	groups := make([]string, 0, len(set))
+	for g := range set {
+		groups = append(groups, g)
+	}
+	sort.Strings(groups)
+	if _, ok := set[state.groupFilter]; !ok {
+		state.groupFilter = "All"
+	}
+	state.groupSelect.Options = append([]string{"All"}, groups...)
+	prevInit := state.initializing
+	state.initializing = true
+	state.groupSelect.SetSelected(state.groupFilter)
+	state.initializing = prevInit
+	if len(groups) > 0 {
+		state.groupRow.Show()
+	} else {
+		state.groupRow.Hide()
+	}
+}
+
+// groupView returns batch s as seen through target group g: every metric the group breakdown has
+// (analysis.FamilySummary mirrors those BatchSummary fields by name) is replaced by the group's
+// value, and the IPv4/IPv6 subsets are dropped because they cover the whole batch. Breakdowns the
+// group does not carry (protocols, errors by reason, probes, ...) keep their batch-wide values;
+// the charts built on them say so with allGroupsNote. ok is false when the batch has no line of that group.
+func groupView(s analysis.BatchSummary, g string) (analysis.BatchSummary, bool) {
+	fs := s.Groups[g]
+	if fs == nil {
+		return s, false
+	}
+	dst := reflect.ValueOf(&s).Elem()
+	src := reflect.ValueOf(fs).Elem()
+	for i := 0; i < src.NumField(); i++ {
+		if f := dst.FieldByName(src.Type().Field(i).Name); f.IsValid() && f.Type() == src.Field(i).Type() {
+			f.Set(src.Field(i))
+		}
+	}
+	s.IPv4, s.IPv6 = nil, nil
+	return s, true
+}
+
+// allGroupsNote is appended to the titles of the charts whose breakdowns groupView cannot narrow
+// to one group, so that under a group filter they read as covering all groups.
+func allGroupsNote(state *uiState) string {
+	if state == nil {
+		return ""
+	}
+	if g := state.groupFilter; g == "" || g == "All" {
+		return ""
+	}
+	return " " + tr("(all groups)")
+}
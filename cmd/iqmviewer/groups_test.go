@@ -0,0 +1,33 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/iafilius/InternetQualityMonitor/src/analysis"
+)
+
+func TestGroupView(t *testing.T) {
+	s := analysis.BatchSummary{RunTag: "A", Lines: 4, AvgSpeed: 30000, AvgTTFB: 40, IPv4: &analysis.FamilySummary{Lines: 4},
+		Groups: map[string]*analysis.FamilySummary{"Intranet": {Lines: 2, AvgSpeed: 60000, AvgTTFB: 6}}}
+	g, ok := groupView(s, "Intranet")
+	if !ok || g.RunTag != "A" || g.Lines != 2 || g.AvgSpeed != 60000 || g.AvgTTFB != 6 || g.IPv4 != nil {
+		t.Fatalf("group view: ok=%v %+v", ok, g)
+	}
+	if s.AvgSpeed != 30000 || s.IPv4 == nil {
+		t.Fatal("groupView must not modify the batch it was given")
+	}
+	if _, ok := groupView(s, "CDN"); ok {
+		t.Fatal("batch without the group should be filtered out")
+	}
+	state := &uiState{summaries: []analysis.BatchSummary{s, {RunTag: "B", Lines: 1, AvgSpeed: 1000}}, groupFilter: "Intranet"}
+	if rows := filteredSummaries(state); len(rows) != 1 || rows[0].AvgSpeed != 60000 {
+		t.Fatalf("filtered by group: %+v", rows)
+	}
+	if got := allGroupsNote(state); got != " (all groups)" {
+		t.Fatalf("note under a group filter: %q", got)
+	}
+	state.groupFilter = "All"
+	if got := allGroupsNote(state); got != "" {
+		t.Fatalf("note without a group filter: %q", got)
+	}
+}
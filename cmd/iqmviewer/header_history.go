@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/iafilius/InternetQualityMonitor/src/analysis"
+)
+
+// headerTargets returns the URLs with captured response headers in rows, sorted.
+func headerTargets(rows []analysis.BatchSummary) []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, r := range rows {
+		for _, fp := range r.HeaderFingerprints {
+			if !seen[fp.URL] {
+				seen[fp.URL] = true
+				out = append(out, fp.URL)
+			}
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// buildHeaderHistoryText lists a target's fingerprint per batch, spelling out what changed
+// against the previous batch and collapsing unchanged stretches to one line each.
+func buildHeaderHistoryText(url string, entries []analysis.HeaderTimelineEntry) string {
+	if len(entries) == 0 {
+		return "No captured headers for " + url + " in these batches (monitor --capture-headers)."
+	}
+	var b strings.Builder
+	changes := 0
+	for i, e := range entries {
+		variants := ""
+		if e.Site.Variants > 1 {
+			variants = fmt.Sprintf(" (%d variants across %d lines)", e.Site.Variants, e.Site.Lines)
+		}
+		switch {
+		case i == 0:
+			fmt.Fprintf(&b, "%s  first seen%s\n    %s\n", e.RunTag, variants, e.Site.Fingerprint)
+		case len(e.Changes) > 0:
+			changes++
+			fmt.Fprintf(&b, "%s  CHANGED%s\n", e.RunTag, variants)
+			for _, c := range e.Changes {
+				from, to := c.From, c.To
+				if from == "" {
+					from = "(absent)"
+				}
+				if to == "" {
+					to = "(absent)"
+				}
+				fmt.Fprintf(&b, "    %s: %s → %s\n", c.Header, from, to)
+			}
+		default:
+			fmt.Fprintf(&b, "%s  unchanged%s\n", e.RunTag, variants)
+		}
+	}
+	return fmt.Sprintf("%s\n%d batches, %d with header changes\n\n", url, len(entries), changes) + b.String()
+}
+
+// showHeaderHistory opens a window that diffs the header fingerprint of a target across the
+// filtered batches; preferred selects the initial target when it has headers.
+func showHeaderHistory(state *uiState, preferred string) {
+	rows := filteredSummaries(state)
+	targets := headerTargets(rows)
+	w := state.app.NewWindow("Header History")
+	text := widget.NewLabel("")
+	text.Wrapping = fyne.TextWrapWord
+	text.TextStyle = fyne.TextStyle{Monospace: true}
+	current := ""
+	show := func(url string) {
+		current = buildHeaderHistoryText(url, analysis.HeaderTimeline(rows, url))
+		text.SetText(current)
+	}
+	sel := widget.NewSelect(targets, show)
+	copyBtn := widget.NewButton("Copy", func() { state.app.Clipboard().SetContent(current) })
+	if len(targets) == 0 {
+		text.SetText("No captured response headers in these batches. Run the monitor with --capture-headers=default (Server, Via, X-Cache, CF-Ray, Age) or a list of header names.")
+		sel.Disable()
+		copyBtn.Disable()
+	} else {
+		initial := targets[0]
+		for _, t := range targets {
+			if t == preferred {
+				initial = t
+			}
+		}
+		sel.SetSelected(initial)
+	}
+	top := container.NewBorder(nil, nil, widget.NewLabel("Target:"), copyBtn, sel)
+	w.SetContent(container.NewBorder(top, nil, nil, nil, container.NewVScroll(text)))
+	w.Resize(fyne.NewSize(820, 560))
+	w.Show()
+}
+
+// showHeaderHistoryForSelection opens the header history on the first target of the selected batch.
+func showHeaderHistoryForSelection(state *uiState) {
+	rows := filteredSummaries(state)
+	if state == nil || state.app == nil || len(rows) == 0 {
+		return
+	}
+	rix := state.selectedRow
+	if rix < 0 || rix >= len(rows) {
+		rix = 0
+	}
+	preferred := ""
+	if fps := rows[rix].HeaderFingerprints; len(fps) > 0 {
+		preferred = fps[0].URL
+	}
+	showHeaderHistory(state, preferred)
+}
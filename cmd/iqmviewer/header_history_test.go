@@ -0,0 +1,31 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/iafilius/InternetQualityMonitor/src/analysis"
+)
+
+func TestBuildHeaderHistoryText(t *testing.T) {
+	fp := func(server string) analysis.SiteHeaderFingerprint {
+		return analysis.SiteHeaderFingerprint{URL: "https://a/", Headers: map[string]string{"Server": server}, Fingerprint: "Server: " + server, Lines: 2}
+	}
+	rows := []analysis.BatchSummary{
+		{RunTag: "b1", HeaderFingerprints: []analysis.SiteHeaderFingerprint{fp("cloudflare")}},
+		{RunTag: "b2", HeaderFingerprints: []analysis.SiteHeaderFingerprint{fp("cloudflare")}},
+		{RunTag: "b3", HeaderFingerprints: []analysis.SiteHeaderFingerprint{fp("AkamaiGHost")}},
+	}
+	if got := headerTargets(rows); len(got) != 1 || got[0] != "https://a/" {
+		t.Fatalf("targets: %v", got)
+	}
+	txt := buildHeaderHistoryText("https://a/", analysis.HeaderTimeline(rows, "https://a/"))
+	for _, want := range []string{"3 batches, 1 with header changes", "b2  unchanged", "b3  CHANGED", "Server: cloudflare → AkamaiGHost"} {
+		if !strings.Contains(txt, want) {
+			t.Fatalf("missing %q in:\n%s", want, txt)
+		}
+	}
+	if txt := buildHeaderHistoryText("https://x/", nil); !strings.Contains(txt, "--capture-headers") {
+		t.Fatalf("empty text: %q", txt)
+	}
+}
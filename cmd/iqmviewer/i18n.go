@@ -0,0 +1,118 @@
+package main
+
+import (
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/iafilius/InternetQualityMonitor/cmd/iqmviewer/i18n"
+)
+
+// UI language (Settings → Language, --lang): menus, tab names, chart section titles and the
+// titles drawn into the chart images come from the catalogs of package i18n. Chart titles stay
+// English inside the viewer (visibility, order and export names are keyed by them) and are
+// translated where they are shown.
+
+// viewerTabNames are the English tab names, in tab order.
+var viewerTabNames = []string{"Batches", "BatchAvg Charts", "Detailed Batch Charts"}
+
+// tr translates s into the UI language.
+func tr(s string) string { return i18n.T(s) }
+
+// trLabel translates a menu label. The toggle mark " ✓" is kept, and a computed
+// "Name – Value" label ("Trend Lines – Linear") is translated part by part.
+func trLabel(s string) string {
+	base, mark := s, ""
+	if b, ok := strings.CutSuffix(s, " ✓"); ok {
+		base, mark = b, " ✓"
+	}
+	if t := tr(base); t != base {
+		return t + mark
+	}
+	if name, value, ok := strings.Cut(base, " – "); ok {
+		return tr(name) + " – " + tr(value) + mark
+	}
+	return s
+}
+
+// translateMenu translates the labels of m and its submenus in place.
+func translateMenu(m *fyne.Menu) {
+	if m == nil {
+		return
+	}
+	m.Label = trLabel(m.Label)
+	for _, it := range m.Items {
+		if it == nil || it.IsSeparator {
+			continue
+		}
+		it.Label = trLabel(it.Label)
+		translateMenu(it.ChildMenu)
+	}
+}
+
+// languageMenuItem is the Settings → Language submenu: System (from the locale environment)
+// and every shipped catalog in its own name.
+func languageMenuItem(state *uiState, fileLabel *widget.Label) *fyne.MenuItem {
+	pick := func(code string) func() {
+		return func() { setUILanguage(state, fileLabel, code) }
+	}
+	mark := func(lbl string, on bool) string {
+		if on {
+			return lbl + " ✓"
+		}
+		return lbl
+	}
+	items := []*fyne.MenuItem{fyne.NewMenuItem(mark("System", state.uiLanguage == ""), pick("")), fyne.NewMenuItemSeparator()}
+	for _, l := range i18n.Languages() {
+		items = append(items, fyne.NewMenuItem(mark(l.Name, state.uiLanguage == l.Code), pick(l.Code)))
+	}
+	item := fyne.NewMenuItem("Language", nil)
+	item.ChildMenu = fyne.NewMenu("Language", items...)
+	return item
+}
+
+// setUILanguage switches the language ("" follows the system), saves it and relabels the open
+// window.
+func setUILanguage(state *uiState, fileLabel *widget.Label, code string) {
+	state.uiLanguage = code
+	i18n.SetLanguage(code)
+	savePrefs(state)
+	applyUILanguage(state)
+	scheduleMenuRebuild(state, fileLabel)
+}
+
+// applyUILanguage relabels what was built before the language changed: section titles and their
+// buttons, the tabs and the contents sidebar; the chart images follow through the redraw (the
+// language is part of their render fingerprint).
+func applyUILanguage(state *uiState) {
+	if state == nil {
+		return
+	}
+	for _, ref := range state.chartRefs {
+		if ref.label != nil {
+			title := tr(ref.title)
+			if ref.section != nil && ref.section == state.focusedChart {
+				title = "▶ " + title
+			}
+			ref.label.SetText(title)
+		}
+		if len(ref.buttons) == 2 {
+			ref.buttons[0].SetText(tr("Detach"))
+			ref.buttons[1].SetText(tr("Info"))
+		}
+	}
+	if state.tabs != nil {
+		for i, it := range state.tabs.Items {
+			if i < len(viewerTabNames) {
+				it.Text = tr(viewerTabNames[i])
+			}
+		}
+		state.tabs.Refresh()
+	}
+	if state.chartTOC != nil {
+		state.chartTOC.toggle.SetText(tr("Contents"))
+	}
+	refreshChartTOC(state)
+	scheduleRedraw(state)
+}
@@ -0,0 +1,142 @@
+// Package i18n translates the viewer's menus, tab names and chart titles.
+//
+// Catalogs are JSON objects in locales/<code>.json that map the English text, exactly as written
+// in the source, to its translation; "_name" is the language's own name for the Settings menu.
+// locales/en.json is the template: it lists every translatable string mapped to itself. To add a
+// language, copy it to locales/<code>.json, translate the values and keep the fmt verbs (%s, %d,
+// %.1f, %%) in their order. Strings missing from a catalog stay English.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+// Template is the code of the English catalog every other catalog is checked against.
+const Template = "en"
+
+// Language is one selectable UI language.
+type Language struct {
+	Code string // e.g. "nl"
+	Name string // its own name, e.g. "Nederlands"
+}
+
+var (
+	mu       sync.RWMutex
+	catalogs map[string]map[string]string
+	current  = Template
+	active   map[string]string // catalog of current; nil for English
+)
+
+// loadCatalogs parses the embedded locales once (call with mu held); a broken file panics at
+// first use, and the tests make sure none ships.
+func loadCatalogs() map[string]map[string]string {
+	if catalogs != nil {
+		return catalogs
+	}
+	catalogs = map[string]map[string]string{}
+	entries, _ := localeFiles.ReadDir("locales")
+	for _, e := range entries {
+		code := strings.TrimSuffix(e.Name(), ".json")
+		b, err := localeFiles.ReadFile(path.Join("locales", e.Name()))
+		if err != nil {
+			panic(err)
+		}
+		cat := map[string]string{}
+		if err := json.Unmarshal(b, &cat); err != nil {
+			panic("i18n: locales/" + e.Name() + ": " + err.Error())
+		}
+		catalogs[code] = cat
+	}
+	return catalogs
+}
+
+// Languages lists the shipped languages, English first, then by code.
+func Languages() []Language {
+	mu.Lock()
+	defer mu.Unlock()
+	cats := loadCatalogs()
+	out := make([]Language, 0, len(cats))
+	for code, cat := range cats {
+		name := cat["_name"]
+		if name == "" {
+			name = code
+		}
+		out = append(out, Language{Code: code, Name: name})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if (out[i].Code == Template) != (out[j].Code == Template) {
+			return out[i].Code == Template
+		}
+		return out[i].Code < out[j].Code
+	})
+	return out
+}
+
+// SetLanguage switches the language and returns the code in use: "" (or "system") picks the
+// system language, and codes without a catalog ("fr", "de_AT" without "de") fall back to
+// English. Region suffixes are dropped ("nl_BE" → "nl").
+func SetLanguage(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	if code == "" || code == "system" {
+		code = SystemLanguage()
+	}
+	if i := strings.IndexAny(code, "_-.@"); i >= 0 {
+		code = code[:i]
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	cat, ok := loadCatalogs()[code]
+	if !ok {
+		code = Template
+	}
+	current = code
+	active = nil
+	if code != Template {
+		active = cat
+	}
+	return current
+}
+
+// Current returns the code of the language in use.
+func Current() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// SystemLanguage derives the language from LC_ALL, LC_MESSAGES or LANG ("nl_NL.UTF-8" → "nl");
+// "en" when none is set or it is C/POSIX.
+func SystemLanguage() string {
+	for _, v := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		l := strings.ToLower(os.Getenv(v))
+		if i := strings.IndexAny(l, "_-.@"); i >= 0 {
+			l = l[:i]
+		}
+		if l != "" && l != "c" && l != "posix" {
+			return l
+		}
+	}
+	return Template
+}
+
+// T returns the translation of s in the current language, s itself when there is none.
+func T(s string) string {
+	mu.RLock()
+	defer mu.RUnlock()
+	if active == nil {
+		return s
+	}
+	if t := active[s]; t != "" {
+		return t
+	}
+	return s
+}
@@ -0,0 +1,110 @@
+package i18n
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// verbs lists the fmt verbs of s in order.
+func verbs(s string) []string {
+	return regexp.MustCompile(`%[-+# 0]*[0-9]*(\.[0-9]+)?[a-zA-Z%]`).FindAllString(s, -1)
+}
+
+func TestCatalogsMatchTemplate(t *testing.T) {
+	mu.Lock()
+	cats := loadCatalogs()
+	mu.Unlock()
+	tmpl := cats[Template]
+	if len(tmpl) < 100 {
+		t.Fatalf("template has %d entries", len(tmpl))
+	}
+	for k, v := range tmpl {
+		if k != "_name" && k != v {
+			t.Errorf("template maps %q to %q; it lists the English strings as themselves", k, v)
+		}
+	}
+	for code, cat := range cats {
+		if cat["_name"] == "" {
+			t.Errorf("%s: no _name", code)
+		}
+		for k, v := range cat {
+			if _, ok := tmpl[k]; !ok {
+				t.Errorf("%s: %q is not in the template (stale key?)", code, k)
+			}
+			if strings.Join(verbs(k), " ") != strings.Join(verbs(v), " ") {
+				t.Errorf("%s: %q changes the format verbs of %q", code, v, k)
+			}
+		}
+		for k := range tmpl {
+			if _, ok := cat[k]; !ok {
+				t.Errorf("%s: missing %q", code, k)
+			}
+		}
+	}
+}
+
+// The template must list the labels and titles the viewer source passes through translation.
+func TestTemplateCoversViewerSource(t *testing.T) {
+	files, err := filepath.Glob("../*.go")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("viewer sources: %v", err)
+	}
+	patterns := []*regexp.Regexp{
+		regexp.MustCompile(`NewMenuItem\("([^"]+)"[,)]`),
+		regexp.MustCompile(`NewMenu\("([^"]+)"[,)]`),
+		regexp.MustCompile(`makeChartSection\(state, "([^"]+)",`),
+		regexp.MustCompile(`Title: *"([^"]+)",`),
+		regexp.MustCompile(`withNote\("([^"]+)",`),
+		regexp.MustCompile(`titleUnknownHidden\(state, "([^"]+)"\)`),
+		regexp.MustCompile(`\btr\("([^"]+)"\)`),
+	}
+	mu.Lock()
+	tmpl := loadCatalogs()[Template]
+	mu.Unlock()
+	for _, f := range files {
+		if strings.HasSuffix(f, "_test.go") {
+			continue
+		}
+		b, err := os.ReadFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, re := range patterns {
+			for _, m := range re.FindAllStringSubmatch(string(b), -1) {
+				if _, ok := tmpl[m[1]]; !ok {
+					t.Errorf("%s: %q is not in locales/%s.json", filepath.Base(f), m[1], Template)
+				}
+			}
+		}
+	}
+}
+
+func TestSetLanguage(t *testing.T) {
+	defer SetLanguage(Template)
+	if got := SetLanguage("nl_BE.UTF-8"); got != "nl" || T("Settings") != "Instellingen" {
+		t.Fatalf("nl: got %q, Settings → %q", got, T("Settings"))
+	}
+	if T("not in any catalog") != "not in any catalog" {
+		t.Fatalf("unknown strings must stay as they are")
+	}
+	if got := SetLanguage("fr"); got != Template || T("Settings") != "Settings" {
+		t.Fatalf("fr: got %q, Settings → %q", got, T("Settings"))
+	}
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LC_MESSAGES", "")
+	t.Setenv("LANG", "nl_NL.UTF-8")
+	if got := SetLanguage(""); got != "nl" {
+		t.Fatalf("system: got %q", got)
+	}
+	t.Setenv("LANG", "C")
+	if SystemLanguage() != Template {
+		t.Fatalf("C locale: %q", SystemLanguage())
+	}
+	langs := Languages()
+	if len(langs) < 2 || langs[0].Code != Template || langs[0].Name != "English" {
+		t.Fatalf("languages: %+v", langs)
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"math"
+	"time"
+
+	chart "github.com/wcharczuk/go-chart/v2"
+	"github.com/wcharczuk/go-chart/v2/drawing"
+
+	helpers "github.com/iafilius/InternetQualityMonitor/cmd/iqmviewer/uihelpers"
+	"github.com/iafilius/InternetQualityMonitor/src/analysis"
+)
+
+// renderIPerf3Chart draws the iperf3 probe's capacity per batch (TCP, and UDP when tested) next
+// to the batch's HTTP average speed, in the selected speed unit. Batches without iperf3 lines
+// are omitted.
+func renderIPerf3Chart(state *uiState) image.Image {
+	cw, chh := chartSize(state)
+	rows := filteredSummaries(state)
+	if len(rows) == 0 {
+		return blank(cw, chh)
+	}
+	unitName, factor := speedUnitFor(state)
+	timeMode, times, xs, xAxis := buildXAxis(rows, state.xAxisMode)
+	var series []chart.Series
+	maxY := 0.0
+	add := func(name string, sel func(analysis.BatchSummary) float64, color drawing.Color) {
+		var px []float64
+		var pt []time.Time
+		var ys []float64
+		for i, r := range rows {
+			v := sel(r)
+			if r.IPerf3Lines == 0 || v <= 0 {
+				continue
+			}
+			if timeMode {
+				pt = append(pt, times[i])
+			} else {
+				px = append(px, xs[i])
+			}
+			ys = append(ys, v*factor)
+			maxY = math.Max(maxY, v*factor)
+		}
+		if len(ys) == 0 {
+			return
+		}
+		st := pointStyle(color)
+		if len(ys) == 1 {
+			st.DotWidth = 6
+			ys = append(ys, ys[0])
+			if timeMode {
+				pt = append(pt, pt[0].Add(1*time.Second))
+			} else {
+				px = append(px, px[0]+1)
+			}
+		}
+		if timeMode {
+			series = append(series, chart.TimeSeries{Name: name, XValues: pt, YValues: ys, Style: st})
+		} else {
+			series = append(series, chart.ContinuousSeries{Name: name, XValues: px, YValues: ys, Style: st})
+		}
+	}
+	add("iPerf3 TCP", func(b analysis.BatchSummary) float64 { return b.AvgIPerf3TCPKbps }, chart.ColorBlue)
+	add("iPerf3 UDP", func(b analysis.BatchSummary) float64 { return b.AvgIPerf3UDPKbps }, chart.ColorCyan)
+	add("HTTP avg", func(b analysis.BatchSummary) float64 { return b.AvgSpeed }, chart.ColorOrange)
+	if len(series) == 0 {
+		return drawHint(blank(cw, chh), "No iperf3 probe lines in these batches (add a site with \"probe\": \"iperf3\").")
+	}
+	vals := helpers.BuildNumericTicks(0, math.Max(maxY*1.15, 1), 6)
+	if len(vals) < 2 {
+		vals = []float64{0, 1}
+	}
+	yTicks := make([]chart.Tick, len(vals))
+	for i, v := range vals {
+		yTicks[i] = chart.Tick{Value: v, Label: helpers.FormatNumericTick(v)}
+	}
+	padBottom := 28
+	switch state.xAxisMode {
+	case "run_tag":
+		padBottom = 90
+	case "time":
+		padBottom = 48
+	}
+	if state.showHints {
+		padBottom += 18
+	}
+	ch := chart.Chart{
+		Title:      fmt.Sprintf(tr("iPerf3 Capacity vs HTTP Speed (%s)"), unitName),
+		Background: chart.Style{Padding: chart.Box{Top: 14, Left: 16, Right: 12, Bottom: padBottom}},
+		XAxis:      xAxis,
+		YAxis:      chart.YAxis{Name: unitName, Range: &chart.ContinuousRange{Min: vals[0], Max: vals[len(vals)-1]}, Ticks: yTicks},
+		Series:     series,
+	}
+	themeChart(&ch)
+	ch.Title += allGroupsNote(state)
+	ch.Width, ch.Height = cw, chh
+	attachLegend(state, &ch)
+	var buf bytes.Buffer
+	if err := renderChart(state, &ch, &buf); err != nil {
+		return blank(cw, chh)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		return blank(cw, chh)
+	}
+	if state.showHints {
+		img = drawHint(img, "Hint: HTTP near the TCP capacity: the path is the limit; far below it: TLS, proxies or the origins.")
+	}
+	return drawWatermark(img, "Situation: "+activeSituationLabel(state))
+}
+
+// iperf3CapacityLines is the crosshair and Diagnostics readout of the iperf3 probe for one batch.
+func iperf3CapacityLines(bs analysis.BatchSummary) []string {
+	if bs.IPerf3Lines == 0 {
+		return []string{"No iperf3 lines"}
+	}
+	lines := []string{fmt.Sprintf("iPerf3: %d line(s), errors %.1f%%", bs.IPerf3Lines, bs.IPerf3ErrorRatePct)}
+	if bs.AvgIPerf3TCPKbps > 0 {
+		lines = append(lines, fmt.Sprintf("TCP: avg %.0f kbps, median %.0f kbps, %d retransmits", bs.AvgIPerf3TCPKbps, bs.MedianIPerf3TCPKbps, bs.IPerf3Retransmits))
+	}
+	if bs.IPerf3UDPLines > 0 {
+		lines = append(lines, fmt.Sprintf("UDP: %.0f kbps, jitter %.2f ms, loss %.2f%%", bs.AvgIPerf3UDPKbps, bs.AvgIPerf3UDPJitterMs, bs.IPerf3UDPLossPct))
+	}
+	if bs.IPerf3HTTPSpeedPct > 0 {
+		lines = append(lines, fmt.Sprintf("HTTP avg %.0f kbps = %.0f%% of the TCP capacity", bs.AvgSpeed, bs.IPerf3HTTPSpeedPct))
+	}
+	return lines
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/iafilius/InternetQualityMonitor/src/analysis"
+)
+
+func TestIPerf3CapacityLines(t *testing.T) {
+	if got := iperf3CapacityLines(analysis.BatchSummary{}); len(got) != 1 || got[0] != "No iperf3 lines" {
+		t.Fatalf("no lines: %q", got)
+	}
+	bs := analysis.BatchSummary{AvgSpeed: 45000, IPerf3Lines: 2, AvgIPerf3TCPKbps: 90000, MedianIPerf3TCPKbps: 90000, IPerf3Retransmits: 7, IPerf3HTTPSpeedPct: 50}
+	got := iperf3CapacityLines(bs)
+	if len(got) != 3 || got[1] != "TCP: avg 90000 kbps, median 90000 kbps, 7 retransmits" || got[2] != "HTTP avg 45000 kbps = 50% of the TCP capacity" {
+		t.Fatalf("tcp: %q", got)
+	}
+	bs.IPerf3UDPLines, bs.AvgIPerf3UDPKbps, bs.AvgIPerf3UDPJitterMs, bs.IPerf3UDPLossPct = 1, 49000, 0.35, 1.5
+	if got = iperf3CapacityLines(bs); len(got) != 4 || got[2] != "UDP: 49000 kbps, jitter 0.35 ms, loss 1.50%" {
+		t.Fatalf("udp: %q", got)
+	}
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+	"github.com/wcharczuk/go-chart/v2"
+	"github.com/wcharczuk/go-chart/v2/drawing"
+
+	helpers "github.com/iafilius/InternetQualityMonitor/cmd/iqmviewer/uihelpers"
+	"github.com/iafilius/InternetQualityMonitor/src/analysis"
+)
+
+// ISP plan benchmark: the user enters the subscribed rates (Settings → "ISP Plan…", stored in Mbps
+// as planDownMbps/planUpMbps/planMinDownMbps); the "Plan Attainment (%)" chart shows each batch's
+// median speed against it and File → "Plan Attainment Report…" summarizes it per month.
+
+func loadISPPlan(p fyne.Preferences) analysis.ISPPlan {
+	return analysis.ISPPlan{
+		DownKbps:    p.Float("planDownMbps") * 1000,
+		UpKbps:      p.Float("planUpMbps") * 1000,
+		MinDownKbps: p.Float("planMinDownMbps") * 1000,
+	}
+}
+
+func saveISPPlan(p fyne.Preferences, plan analysis.ISPPlan) {
+	p.SetFloat("planDownMbps", plan.DownKbps/1000)
+	p.SetFloat("planUpMbps", plan.UpKbps/1000)
+	p.SetFloat("planMinDownMbps", plan.MinDownKbps/1000)
+}
+
+// planSummary is e.g. "100 / 20 Mbps (min 50)".
+func planSummary(plan analysis.ISPPlan) string {
+	mbps := func(kbps float64) string { return strconv.FormatFloat(kbps/1000, 'f', -1, 64) }
+	s := mbps(plan.DownKbps)
+	if plan.UpKbps > 0 {
+		s += " / " + mbps(plan.UpKbps)
+	}
+	s += " Mbps"
+	if plan.MinDownKbps > 0 {
+		s += " (min " + mbps(plan.MinDownKbps) + ")"
+	}
+	return s
+}
+
+// openISPPlanDialog edits the subscribed rates; an empty download rate turns the benchmark off.
+func openISPPlanDialog(state *uiState) {
+	if state == nil || state.window == nil {
+		return
+	}
+	entry := func(kbps float64) *widget.Entry {
+		e := widget.NewEntry()
+		if kbps > 0 {
+			e.SetText(strconv.FormatFloat(kbps/1000, 'f', -1, 64))
+		}
+		return e
+	}
+	down, up, minDown := entry(state.ispPlan.DownKbps), entry(state.ispPlan.UpKbps), entry(state.ispPlan.MinDownKbps)
+	down.SetPlaceHolder("e.g. 100")
+	form := &widget.Form{Items: []*widget.FormItem{
+		{Text: "Download (Mbps)", Widget: down, HintText: "subscribed rate; empty turns the benchmark off"},
+		{Text: "Upload (Mbps)", Widget: up, HintText: "reported only; the monitor measures downloads"},
+		{Text: "Minimum download (Mbps)", Widget: minDown, HintText: "contractual minimum, if the plan states one"},
+	}}
+	d := dialog.NewCustomConfirm("ISP Plan", "Save", "Cancel", form, func(ok bool) {
+		if !ok {
+			return
+		}
+		var vals [3]float64
+		for i, e := range []*widget.Entry{down, up, minDown} {
+			t := strings.TrimSpace(strings.ReplaceAll(e.Text, ",", "."))
+			if t == "" {
+				continue
+			}
+			v, err := strconv.ParseFloat(t, 64)
+			if err != nil || v < 0 {
+				dialog.ShowError(fmt.Errorf("invalid rate %q: want Mbps, e.g. 100", e.Text), state.window)
+				return
+			}
+			vals[i] = v * 1000
+		}
+		plan := analysis.ISPPlan{DownKbps: vals[0], UpKbps: vals[1], MinDownKbps: vals[2]}
+		if plan.MinDownKbps > plan.DownKbps && plan.Enabled() {
+			dialog.ShowError(fmt.Errorf("the minimum download rate is above the subscribed rate"), state.window)
+			return
+		}
+		state.ispPlan = plan
+		if state.app != nil {
+			saveISPPlan(state.app.Preferences(), plan)
+		}
+		scheduleRedraw(state)
+	}, state.window)
+	d.Resize(fyne.NewSize(480, 260))
+	d.Show()
+}
+
+// renderPlanAttainmentChart draws each batch's median speed as a percentage of the subscribed
+// download rate, with the plan (100%), the 90% attainment line and the contractual minimum.
+func renderPlanAttainmentChart(state *uiState) image.Image {
+	cw, chh := chartSize(state)
+	if !state.ispPlan.Enabled() {
+		return drawHint(blank(cw, chh), "Enter your subscribed rates in Settings → ISP Plan… to chart the % of plan achieved.")
+	}
+	rows := filteredSummaries(state)
+	if len(rows) == 0 {
+		return blank(cw, chh)
+	}
+	timeMode, times, xs, xAxis := buildXAxis(rows, state.xAxisMode)
+	var px []float64
+	var pt []time.Time
+	var ys []float64
+	maxY := 100.0
+	for i, r := range rows {
+		pct, ok := analysis.PlanAttainment(r, state.ispPlan)
+		if !ok {
+			continue
+		}
+		ys = append(ys, pct)
+		maxY = math.Max(maxY, pct)
+		if timeMode {
+			pt = append(pt, times[i])
+		} else {
+			px = append(px, xs[i])
+		}
+	}
+	if len(ys) == 0 {
+		return drawHint(blank(cw, chh), "No successful transfers in these batches.")
+	}
+	if timeMode && len(pt) == 1 {
+		pt, ys = append(pt, pt[0].Add(1*time.Second)), append(ys, ys[0])
+	} else if !timeMode && len(px) == 1 {
+		px, ys = append(px, px[0]+1), append(ys, ys[0])
+	}
+	line := func(name string, y float64, c drawing.Color) chart.Series {
+		st := chart.Style{StrokeColor: c, StrokeWidth: 1, StrokeDashArray: []float64{4, 3}}
+		if timeMode {
+			return chart.TimeSeries{Name: name, XValues: []time.Time{pt[0], pt[len(pt)-1]}, YValues: []float64{y, y}, Style: st}
+		}
+		return chart.ContinuousSeries{Name: name, XValues: []float64{px[0], px[len(px)-1]}, YValues: []float64{y, y}, Style: st}
+	}
+	series := []chart.Series{line("Plan (100%)", 100, chart.ColorGreen), line(fmt.Sprintf("%.0f%% of plan", analysis.PlanReachedPct), analysis.PlanReachedPct, chart.ColorOrange)}
+	if p := state.ispPlan; p.MinDownKbps > 0 {
+		series = append(series, line("Minimum", p.MinDownKbps/p.DownKbps*100, chart.ColorRed))
+	}
+	st := pointStyle(chart.ColorBlue)
+	if timeMode {
+		series = append(series, chart.TimeSeries{Name: "Median speed", XValues: pt, YValues: ys, Style: st})
+	} else {
+		series = append(series, chart.ContinuousSeries{Name: "Median speed", XValues: px, YValues: ys, Style: st})
+	}
+	vals := helpers.BuildNumericTicks(0, maxY*1.05, 6)
+	if len(vals) < 2 {
+		vals = []float64{0, 100}
+	}
+	yTicks := make([]chart.Tick, len(vals))
+	for i, v := range vals {
+		yTicks[i] = chart.Tick{Value: v, Label: helpers.FormatNumericTick(v)}
+	}
+	padBottom := 28
+	switch state.xAxisMode {
+	case "run_tag":
+		padBottom = 90
+	case "time":
+		padBottom = 48
+	}
+	if state.showHints {
+		padBottom += 18
+	}
+	ch := chart.Chart{
+		Title:      fmt.Sprintf(tr("Plan Attainment (%% of %s)"), planSummary(state.ispPlan)),
+		Background: chart.Style{Padding: chart.Box{Top: 14, Left: 16, Right: 12, Bottom: padBottom}},
+		XAxis:      xAxis,
+		YAxis:      chart.YAxis{Name: "% of plan", Range: &chart.ContinuousRange{Min: vals[0], Max: vals[len(vals)-1]}, Ticks: yTicks},
+		Series:     series,
+	}
+	themeChart(&ch)
+	ch.Width, ch.Height = cw, chh
+	attachLegend(state, &ch)
+	var buf bytes.Buffer
+	if err := renderChart(state, &ch, &buf); err != nil {
+		return blank(cw, chh)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		return blank(cw, chh)
+	}
+	if state.showHints {
+		img = drawHint(img, "Hint: single HTTP transfers rarely fill a fast line; compare months (File → Plan Attainment Report…) rather than single batches.")
+	}
+	return drawWatermark(img, "Situation: "+activeSituationLabel(state))
+}
+
+// planMonthlyCSV is the monthly attainment summary as CSV, one row per month.
+func planMonthlyCSV(months []analysis.PlanMonth, plan analysis.ISPPlan) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# plan download %.0f kbps, upload %.0f kbps, minimum %.0f kbps; attainment = batch median speed / plan download\n", plan.DownKbps, plan.UpKbps, plan.MinDownKbps)
+	b.WriteString("month,batches,first_batch,last_batch,median_pct,p10_pct,worst_pct,reached_90_pct,below_min_batches,below_min_pct\n")
+	for _, m := range months {
+		fmt.Fprintf(&b, "%s,%d,%s,%s,%.1f,%.1f,%.1f,%.1f,%d,%.1f\n", m.Month.Format("2006-01"), m.Batches,
+			m.First.Format(time.RFC3339), m.Last.Format(time.RFC3339), m.MedianPct, m.P10Pct, m.WorstPct, m.ReachedPct, m.BelowMin, m.BelowMinPct)
+	}
+	return b.String()
+}
+
+// openPlanReport shows the monthly attainment summary of the filtered batches, with the CSV to
+// copy or save for a complaint to the ISP.
+func openPlanReport(state *uiState) {
+	if state == nil || state.window == nil {
+		return
+	}
+	if !state.ispPlan.Enabled() {
+		dialog.ShowInformation("Plan Attainment Report", "Enter your subscribed rates in Settings → ISP Plan… first.", state.window)
+		return
+	}
+	plan := state.ispPlan
+	months := analysis.BuildPlanMonthly(filteredSummaries(state), plan, timeAxisLoc)
+	if len(months) == 0 {
+		dialog.ShowInformation("Plan Attainment Report", "No batches with a start time and a successful transfer.", state.window)
+		return
+	}
+	var txt strings.Builder
+	fmt.Fprintf(&txt, "Plan: %s — Situation: %s\n", planSummary(plan), activeSituationLabel(state))
+	fmt.Fprintf(&txt, "Attainment = batch median speed / subscribed download; reached = at least %.0f%% of plan.\n\n", analysis.PlanReachedPct)
+	fmt.Fprintf(&txt, "%-8s %7s %8s %8s %8s %8s", "Month", "Batches", "Median", "P10", "Worst", "Reached")
+	if plan.MinDownKbps > 0 {
+		fmt.Fprintf(&txt, " %10s", "Below min")
+	}
+	txt.WriteString("\n")
+	for _, m := range months {
+		fmt.Fprintf(&txt, "%-8s %7d %7.0f%% %7.0f%% %7.0f%% %7.0f%%", m.Month.Format("2006-01"), m.Batches, m.MedianPct, m.P10Pct, m.WorstPct, m.ReachedPct)
+		if plan.MinDownKbps > 0 {
+			fmt.Fprintf(&txt, " %4d (%2.0f%%)", m.BelowMin, m.BelowMinPct)
+		}
+		txt.WriteString("\n")
+	}
+	if plan.UpKbps > 0 {
+		txt.WriteString("\nUpload is not measured by the monitor; only the download rate is benchmarked.\n")
+	}
+	grid := widget.NewTextGridFromString(txt.String())
+	csv := planMonthlyCSV(months, plan)
+	copyBtn := widget.NewButton("Copy CSV", func() { state.app.Clipboard().SetContent(csv) })
+	saveBtn := widget.NewButton("Save CSV…", func() {
+		fs := dialog.NewFileSave(func(wc fyne.URIWriteCloser, err error) {
+			if err != nil || wc == nil {
+				return
+			}
+			defer wc.Close()
+			if _, err := wc.Write([]byte(csv)); err != nil {
+				dialog.ShowError(err, state.window)
+			}
+		}, state.window)
+		fs.SetFileName("plan_attainment_monthly.csv")
+		fs.SetFilter(storage.NewExtensionFileFilter([]string{".csv"}))
+		fs.Show()
+	})
+	content := container.NewBorder(nil, container.NewHBox(copyBtn, saveBtn), nil, nil, container.NewScroll(grid))
+	d := dialog.NewCustom("Plan Attainment Report", "Close", content, state.window)
+	d.Resize(fyne.NewSize(720, 420))
+	d.Show()
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iafilius/InternetQualityMonitor/src/analysis"
+)
+
+func TestPlanSummaryAndCSV(t *testing.T) {
+	plan := analysis.ISPPlan{DownKbps: 100000, UpKbps: 20000, MinDownKbps: 50000}
+	if got := planSummary(plan); got != "100 / 20 Mbps (min 50)" {
+		t.Fatalf("summary: %q", got)
+	}
+	if got := planSummary(analysis.ISPPlan{DownKbps: 250000}); got != "250 Mbps" {
+		t.Fatalf("summary without upload/minimum: %q", got)
+	}
+	at := time.Date(2026, 3, 2, 20, 0, 0, 0, time.UTC)
+	csv := planMonthlyCSV([]analysis.PlanMonth{{Month: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), Batches: 4, MedianPct: 86, P10Pct: 40, WorstPct: 40, ReachedPct: 50, BelowMin: 1, BelowMinPct: 25, First: at, Last: at}}, plan)
+	lines := strings.Split(strings.TrimSpace(csv), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[1], "month,batches,") {
+		t.Fatalf("csv layout: %q", csv)
+	}
+	if lines[2] != "2026-03,4,2026-03-02T20:00:00Z,2026-03-02T20:00:00Z,86.0,40.0,40.0,50.0,1,25.0" {
+		t.Fatalf("csv row: %q", lines[2])
+	}
+}
@@ -15,7 +15,7 @@ func TestLegendStyleUniform(t *testing.T) {
 	// Build two synthetic charts invoking attachLegend directly (faster & deterministic).
 	for i := 0; i < 2; i++ {
 		c := chart.Chart{Title: "Synthetic"}
-		attachLegend(&c)
+		attachLegend(nil, &c)
 	}
 
 	if len(lastLegendSpecs) == 0 {
@@ -42,7 +42,7 @@ func TestLegendStyleUniform(t *testing.T) {
 
 		// Also assert that a synthetic chart includes a first series named with the prefix.
 		c := chart.Chart{Title: "Synthetic2"}
-		attachLegend(&c)
+		attachLegend(nil, &c)
 		if len(c.Series) == 0 || c.Series[0].GetName() != base.Prefix {
 			t.Fatalf("expected first series legend prefix %q, got %q", base.Prefix, func() string {
 				if len(c.Series) == 0 {
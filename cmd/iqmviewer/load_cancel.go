@@ -0,0 +1,221 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/iafilius/InternetQualityMonitor/src/analysis"
+)
+
+// Loads run in the background so a huge results file does not freeze the window. A load that
+// takes longer than loadProgressDelay shows a dialog with the bytes and lines read so far and a
+// Cancel button; canceling keeps the batches already on screen (and their file). Quick loads,
+// such as Follow mode reloads, never show the dialog. Starting a load cancels a running one.
+
+// loadProgressDelay is how long a load runs before its progress dialog appears.
+const loadProgressDelay = 700 * time.Millisecond
+
+// startLoad analyzes state.filePath in a goroutine and, on the UI thread, shows the result via
+// applyLoad and calls done. Errors are shown in a dialog; the previous batches stay.
+func startLoad(state *uiState, fileLabel *widget.Label, done func()) {
+	if state.loadCancel != nil {
+		state.loadCancel()
+	}
+	state.loadGen++
+	gen := state.loadGen
+	ctx, cancel := context.WithCancel(context.Background())
+	state.loadCancel = cancel
+	path := state.filePath
+	opts := viewerAnalyzeOptions(state)
+	prog := newLoadProgress(state, path, cancel)
+	opts.Progress = prog.update
+	go func() {
+		res, err := analysis.Analyze(ctx, path, opts)
+		fyne.Do(func() {
+			prog.close()
+			if gen != state.loadGen {
+				return // superseded by a newer load
+			}
+			state.loadCancel = nil
+			cancel()
+			if errors.Is(err, context.Canceled) {
+				restoreLoadedPath(state, fileLabel)
+				logf(slog.LevelInfo, "viewer", "load of %s canceled; keeping %d batches", path, len(state.summaries))
+				return
+			}
+			if err != nil {
+				dialog.ShowError(err, state.window)
+				return
+			}
+			state.loadStats, state.loadMigration = res.Stats, res.Migration
+			state.loadedPath = path
+			updateLoadWarning(state)
+			applyLoad(state, res.Batches)
+			if done != nil {
+				done()
+			}
+		})
+	}()
+}
+
+// viewerAnalyzeOptions are the analysis options of the viewer's settings. The viewer's 0 turns
+// a detection off; in analysis.Options that is a negative value.
+func viewerAnalyzeOptions(state *uiState) analysis.Options {
+	opts := analysis.Options{MaxBatches: state.batchesN, LowSpeedThresholdKbps: float64(state.lowSpeedThresholdKbps),
+		MicroStallMinGapMs: int64(state.microStallGapMs), Log: os.Stdout}
+	if opts.LowSpeedThresholdKbps <= 0 {
+		opts.LowSpeedThresholdKbps = -1
+	}
+	if opts.MicroStallMinGapMs <= 0 {
+		opts.MicroStallMinGapMs = -1
+	}
+	return opts
+}
+
+// restoreLoadedPath points the viewer back at the file of the batches on screen after a
+// canceled load of another file.
+func restoreLoadedPath(state *uiState, fileLabel *widget.Label) {
+	if state.loadedPath == "" || state.loadedPath == state.filePath {
+		return
+	}
+	state.filePath = state.loadedPath
+	if fileLabel != nil {
+		fileLabel.SetText(truncatePath(state.filePath, 60))
+	}
+	savePrefs(state)
+}
+
+// loadProgress is the progress dialog of one load, created hidden and shown after
+// loadProgressDelay unless the load finished first.
+type loadProgress struct {
+	state  *uiState
+	size   int64 // file size; 0 when compressed (the bytes read are decompressed) or unknown
+	dlg    dialog.Dialog
+	bar    *widget.ProgressBar
+	detail *widget.Label
+	timer  *time.Timer
+
+	mu      sync.Mutex
+	last    analysis.LoadProgress
+	pending bool
+	closed  bool
+}
+
+func newLoadProgress(state *uiState, path string, cancel context.CancelFunc) *loadProgress {
+	p := &loadProgress{state: state}
+	if fi, err := os.Stat(path); err == nil && !isCompressedResults(path) {
+		p.size = fi.Size()
+	}
+	if state.window == nil {
+		p.closed = true
+		return p
+	}
+	p.bar = widget.NewProgressBar()
+	p.detail = widget.NewLabel("Starting…")
+	var bar fyne.CanvasObject = p.bar
+	if p.size == 0 {
+		bar = widget.NewProgressBarInfinite()
+	}
+	cancelBtn := widget.NewButton("Cancel", func() {
+		p.detail.SetText("Canceling…")
+		cancel()
+	})
+	content := container.NewVBox(widget.NewLabel(truncatePath(path, 70)), bar, p.detail, container.NewCenter(cancelBtn))
+	p.dlg = dialog.NewCustomWithoutButtons("Loading results…", content, state.window)
+	p.timer = time.AfterFunc(loadProgressDelay, func() {
+		fyne.Do(func() {
+			p.mu.Lock()
+			closed := p.closed
+			p.mu.Unlock()
+			if !closed {
+				p.dlg.Show()
+			}
+		})
+	})
+	return p
+}
+
+// update records progress from the analysis goroutine; the dialog catches up on the UI thread.
+func (p *loadProgress) update(lp analysis.LoadProgress) {
+	p.mu.Lock()
+	p.last = lp
+	schedule := !p.pending && !p.closed
+	p.pending = true
+	p.mu.Unlock()
+	if !schedule {
+		return
+	}
+	fyne.Do(func() {
+		p.mu.Lock()
+		lp, closed := p.last, p.closed
+		p.pending = false
+		p.mu.Unlock()
+		if closed {
+			return
+		}
+		p.detail.SetText(loadProgressText(lp, p.size))
+		if p.size > 0 {
+			p.bar.SetValue(math.Min(float64(lp.Bytes)/float64(p.size), 1))
+		}
+	})
+}
+
+// close hides the dialog; on the UI thread.
+func (p *loadProgress) close() {
+	p.mu.Lock()
+	p.closed = true
+	p.mu.Unlock()
+	if p.timer != nil {
+		p.timer.Stop()
+	}
+	if p.dlg != nil {
+		p.dlg.Hide()
+	}
+}
+
+// loadProgressText is e.g. "312.4 of 1024.0 MB, 1,204,331 lines".
+func loadProgressText(lp analysis.LoadProgress, size int64) string {
+	read := fmt.Sprintf("%.1f MB", float64(lp.Bytes)/1e6)
+	if size > 0 {
+		read = fmt.Sprintf("%.1f of %.1f MB", float64(lp.Bytes)/1e6, float64(size)/1e6)
+	}
+	return read + ", " + thousands(lp.Lines) + " lines"
+}
+
+// thousands formats n with comma separators.
+func thousands(n int) string {
+	s := fmt.Sprint(n)
+	if n < 0 {
+		return "-" + thousands(-n)
+	}
+	var b strings.Builder
+	for i, c := range s {
+		if i > 0 && (len(s)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// isCompressedResults reports whether path names a gzip or zstd results archive.
+func isCompressedResults(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".gz", ".zst":
+		return true
+	}
+	return false
+}
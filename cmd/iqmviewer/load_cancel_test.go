@@ -0,0 +1,23 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/iafilius/InternetQualityMonitor/src/analysis"
+)
+
+func TestLoadProgressText(t *testing.T) {
+	if got := loadProgressText(analysis.LoadProgress{Bytes: 312_400_000, Lines: 1204331}, 1_024_000_000); got != "312.4 of 1024.0 MB, 1,204,331 lines" {
+		t.Fatalf("with size: %q", got)
+	}
+	if got := loadProgressText(analysis.LoadProgress{Bytes: 1_500_000, Lines: 999}, 0); got != "1.5 MB, 999 lines" {
+		t.Fatalf("compressed: %q", got)
+	}
+	if !isCompressedResults("a/results.jsonl.GZ") || isCompressedResults("results.jsonl") {
+		t.Fatal("compressed results detection")
+	}
+	s := &uiState{batchesN: 25, lowSpeedThresholdKbps: 0, microStallGapMs: 500}
+	if o := viewerAnalyzeOptions(s); o.MaxBatches != 25 || o.LowSpeedThresholdKbps >= 0 || o.MicroStallMinGapMs != 500 {
+		t.Fatalf("options: %+v", o)
+	}
+}
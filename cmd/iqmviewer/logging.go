@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Viewer logging: one leveled slog logger (--log-level/--log-format, defaulting to
+// $IQM_LOG_LEVEL/$IQM_LOG_FORMAT like the monitor) writing text or JSON to stderr. Every record,
+// debug included whatever the level, is also kept in logRing for File → Debug Console, so a
+// failed or odd load can be examined without a terminal or a restart with --log-level debug.
+
+// logRingSize is how many recent entries the Debug Console keeps.
+const logRingSize = 500
+
+var (
+	viewerLogLevel = new(slog.LevelVar) // Info unless configured
+	logRing        = &logBuffer{max: logRingSize}
+	viewerLog      = slog.New(&ringHandler{out: slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: viewerLogLevel}), ring: logRing})
+)
+
+// logEntry is one record as shown in the Debug Console.
+type logEntry struct {
+	Time      time.Time
+	Level     slog.Level
+	Component string
+	Msg       string
+}
+
+func (e logEntry) String() string {
+	return fmt.Sprintf("%s %-5s [%s] %s", e.Time.Format("15:04:05.000"), e.Level, e.Component, e.Msg)
+}
+
+// logBuffer keeps the last max entries.
+type logBuffer struct {
+	mu      sync.Mutex
+	max     int
+	entries []logEntry
+}
+
+func (b *logBuffer) add(e logEntry) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.entries = append(b.entries, e)
+	if over := len(b.entries) - b.max; over > 0 {
+		b.entries = append(b.entries[:0], b.entries[over:]...)
+	}
+}
+
+// snapshot returns the entries at or above min, oldest first.
+func (b *logBuffer) snapshot(min slog.Level) []logEntry {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	out := make([]logEntry, 0, len(b.entries))
+	for _, e := range b.entries {
+		if e.Level >= min {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+func (b *logBuffer) clear() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.entries = nil
+}
+
+// ringHandler copies every record into ring and passes those out accepts on to it.
+type ringHandler struct {
+	out  slog.Handler
+	ring *logBuffer
+}
+
+func (h *ringHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h *ringHandler) Handle(ctx context.Context, r slog.Record) error {
+	e := logEntry{Time: r.Time, Level: r.Level, Msg: r.Message}
+	r.Attrs(func(a slog.Attr) bool {
+		if a.Key == "component" {
+			e.Component = a.Value.String()
+		}
+		return true
+	})
+	h.ring.add(e)
+	if !h.out.Enabled(ctx, r.Level) {
+		return nil
+	}
+	return h.out.Handle(ctx, r)
+}
+
+func (h *ringHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &ringHandler{out: h.out.WithAttrs(attrs), ring: h.ring}
+}
+
+func (h *ringHandler) WithGroup(name string) slog.Handler {
+	return &ringHandler{out: h.out.WithGroup(name), ring: h.ring}
+}
+
+// configureViewerLogging applies --log-level and --log-format; debug also turns on the verbose
+// resize/render tracing (debugLoggingEnabled).
+func configureViewerLogging(w io.Writer, level, format string) error {
+	var l slog.Level
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "debug":
+		l = slog.LevelDebug
+	case "", "info":
+		l = slog.LevelInfo
+	case "warn", "warning":
+		l = slog.LevelWarn
+	case "error":
+		l = slog.LevelError
+	default:
+		return fmt.Errorf("log level must be one of debug|info|warn|error (got %q)", level)
+	}
+	opts := &slog.HandlerOptions{Level: viewerLogLevel}
+	var out slog.Handler
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "", "text":
+		out = slog.NewTextHandler(w, opts)
+	case "json":
+		out = slog.NewJSONHandler(w, opts)
+	default:
+		return fmt.Errorf("log format must be text or json (got %q)", format)
+	}
+	viewerLogLevel.Set(l)
+	viewerLog = slog.New(&ringHandler{out: out, ring: logRing})
+	debugLoggingEnabled = debugLoggingEnabled || l <= slog.LevelDebug
+	return nil
+}
+
+// logf logs a formatted message for component ("viewer", "selftest", "detailed", …).
+func logf(level slog.Level, component, format string, args ...any) {
+	msg := format
+	if len(args) > 0 {
+		msg = fmt.Sprintf(format, args...)
+	}
+	viewerLog.LogAttrs(context.Background(), level, msg, slog.String("component", component))
+}
+
+// openDebugConsole shows the recent log entries with a level filter, Copy and Clear.
+func openDebugConsole(state *uiState) {
+	if state == nil || state.app == nil {
+		return
+	}
+	w := state.app.NewWindow("Debug Console")
+	grid := widget.NewTextGrid()
+	min := slog.LevelDebug
+	countLbl := widget.NewLabel("")
+	text := func() string {
+		var b strings.Builder
+		for _, e := range logRing.snapshot(min) {
+			b.WriteString(e.String())
+			b.WriteByte('\n')
+		}
+		return b.String()
+	}
+	refresh := func() {
+		t := text()
+		grid.SetText(t)
+		countLbl.SetText(fmt.Sprintf("%d entries (last %d of all levels kept; stderr gets %s and up)", strings.Count(t, "\n"), logRingSize, viewerLogLevel.Level()))
+	}
+	levels := map[string]slog.Level{"Debug": slog.LevelDebug, "Info": slog.LevelInfo, "Warn": slog.LevelWarn, "Error": slog.LevelError}
+	levelSel := widget.NewSelect([]string{"Debug", "Info", "Warn", "Error"}, func(v string) {
+		min = levels[v]
+		refresh()
+	})
+	levelSel.SetSelected("Debug")
+	bar := container.NewHBox(
+		widget.NewLabel("Show:"), levelSel,
+		widget.NewButton("Refresh", refresh),
+		widget.NewButton("Copy", func() { state.app.Clipboard().SetContent(text()) }),
+		widget.NewButton("Clear", func() { logRing.clear(); refresh() }),
+		countLbl,
+	)
+	w.SetContent(container.NewBorder(bar, nil, nil, nil, container.NewScroll(grid)))
+	w.Resize(fyne.NewSize(900, 480))
+	refresh()
+	w.Show()
+}
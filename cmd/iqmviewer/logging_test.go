@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestViewerLoggingLevelsJSONAndRing(t *testing.T) {
+	prevLog, prevLevel, prevDebug := viewerLog, viewerLogLevel.Level(), debugLoggingEnabled
+	defer func() {
+		viewerLog, debugLoggingEnabled = prevLog, prevDebug
+		viewerLogLevel.Set(prevLevel)
+		logRing.clear()
+	}()
+	if err := configureViewerLogging(&bytes.Buffer{}, "loud", "text"); err == nil {
+		t.Fatalf("unknown level accepted")
+	}
+	var buf bytes.Buffer
+	if err := configureViewerLogging(&buf, "warn", "json"); err != nil {
+		t.Fatalf("configure: %v", err)
+	}
+	logRing.clear()
+	logf(slog.LevelDebug, "viewer", "prefs: lastSituation=%q", "Home")
+	logf(slog.LevelWarn, "viewer", "speed chart render error: %v; showing blank fallback", "boom")
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("stderr should only get warn and up: %q", buf.String())
+	}
+	var rec map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &rec); err != nil || rec["level"] != "WARN" || rec["component"] != "viewer" {
+		t.Fatalf("json record: %v (%v)", rec, err)
+	}
+	// the Debug Console keeps debug entries whatever the output level
+	all := logRing.snapshot(slog.LevelDebug)
+	if len(all) != 2 || all[0].Msg != `prefs: lastSituation="Home"` || all[1].Component != "viewer" {
+		t.Fatalf("ring: %+v", all)
+	}
+	if warn := logRing.snapshot(slog.LevelWarn); len(warn) != 1 {
+		t.Fatalf("level filter: %+v", warn)
+	}
+	for i := 0; i < logRingSize+10; i++ {
+		logf(slog.LevelInfo, "viewer", "line %d", i)
+	}
+	if got := logRing.snapshot(slog.LevelDebug); len(got) != logRingSize || got[len(got)-1].Msg != "line 509" {
+		t.Fatalf("ring bound: %d entries, last %q", len(got), got[len(got)-1].Msg)
+	}
+}
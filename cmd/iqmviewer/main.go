@@ -3,6 +3,7 @@ package main
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
 	"flag"
 	"fmt"
@@ -10,6 +11,7 @@ import (
 	"image/color"
 	"image/draw"
 	"image/png"
+	"log/slog"
 	"math"
 	"net/url"
 	"os"
@@ -19,6 +21,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"golang.org/x/image/font"
@@ -40,6 +43,7 @@ import (
 	chart "github.com/wcharczuk/go-chart/v2"
 	"github.com/wcharczuk/go-chart/v2/drawing"
 
+	"github.com/iafilius/InternetQualityMonitor/cmd/iqmviewer/i18n"
 	helpers "github.com/iafilius/InternetQualityMonitor/cmd/iqmviewer/uihelpers"
 	"github.com/iafilius/InternetQualityMonitor/src/analysis"
 	"github.com/iafilius/InternetQualityMonitor/src/monitor"
@@ -55,6 +59,7 @@ type legendSpec struct {
 }
 
 var lastLegendSpecs []legendSpec
+var lastLegendSpecsMu sync.Mutex // charts may be pre-rendered concurrently (render_cache.go)
 
 // screenshotThemeGlobal holds the effective theme used for charts/screenshots.
 // Values: "dark" or "light".
@@ -72,6 +77,10 @@ var screenshotWidthOverride = 0
 // Always reset back to 0 after export to avoid affecting on-screen rendering.
 var renderWidthOverride = 0
 
+// timeAxisLoc is the zone batch times are shown and bucketed in: time.Local, or time.UTC with
+// Settings → Axes & Units → Time Zone → UTC (state.timeAxisUTC). Run tags are UTC either way.
+var timeAxisLoc = time.Local
+
 // debugLoggingEnabled enables verbose resize/chart dimension tracing.
 // Toggle via a hidden CLI flag or future settings if needed.
 var debugLoggingEnabled = false
@@ -85,7 +94,14 @@ func resolveTheme(mode string, app fyne.App) string {
 	if m == "light" {
 		return "light"
 	}
-	// auto or any other value: follow system preference when available
+	// auto or any other value: follow system preference when available. The running app knows
+	// the OS variant (and tracks changes); headless screenshots fall back to asking directly.
+	if app != nil {
+		if app.Settings().ThemeVariant() == theme.VariantDark {
+			return "dark"
+		}
+		return "light"
+	}
 	if isSystemDark() {
 		return "dark"
 	}
@@ -121,6 +137,38 @@ func emptyDash(s string) string {
 	return s
 }
 
+// asnLabel formats an ASN as "AS64500 (Org)", or "-" when unknown.
+func asnLabel(asn uint, org string) string {
+	if asn == 0 {
+		return "-"
+	}
+	if org != "" {
+		return fmt.Sprintf("AS%d (%s)", asn, org)
+	}
+	return fmt.Sprintf("AS%d", asn)
+}
+
+// routeDetail renders a traced AS path and hop count, e.g. ", path AS1 → AS2, 9 hops".
+func routeDetail(path []uint, hops int) string {
+	if len(path) == 0 && hops == 0 {
+		return ""
+	}
+	var b strings.Builder
+	if len(path) > 0 {
+		b.WriteString(", path")
+		for i, a := range path {
+			if i > 0 {
+				b.WriteString(" →")
+			}
+			b.WriteString(fmt.Sprintf(" AS%d", a))
+		}
+	}
+	if hops > 0 {
+		b.WriteString(fmt.Sprintf(", %d hops", hops))
+	}
+	return b.String()
+}
+
 // topK returns the key with the highest value from a map[string]float64.
 // If the map is empty, it returns ("", 0, false).
 func topK(m map[string]float64) (string, float64, bool) {
@@ -149,8 +197,125 @@ func buildDiagnosticsText(bs analysis.BatchSummary, tolPct int) string {
 	tlsVer, _, _ := topK(bs.TLSVersionRatePct)
 	alpn, _, _ := topK(bs.ALPNRatePct)
 	var b strings.Builder
-	b.WriteString(fmt.Sprintf("RunTag: %s\n\n", bs.RunTag))
+	b.WriteString(fmt.Sprintf("RunTag: %s\n", bs.RunTag))
+	if t := bs.StartTime(); !t.IsZero() {
+		started := "Started: " + t.UTC().Format("2006-01-02 15:04:05") + " UTC"
+		// the monitor's wall clock, which can differ from this machine's (remote agents, DST)
+		if loc := bs.RecordedLocation(); loc != nil {
+			started += " — monitor local " + t.In(loc).Format("2006-01-02 15:04:05 MST (-07:00)")
+		}
+		b.WriteString(started + "\n")
+	}
+	b.WriteString("\n")
+	if bs.Canceled {
+		// stopped mid-batch: fewer lines than usual, and the sites that ran were not failing
+		b.WriteString(fmt.Sprintf("Canceled batch: the monitor was stopped while measuring; %d line(s) cover only the sites reached before the stop\n\n", bs.Lines))
+	}
 	b.WriteString(fmt.Sprintf("DNS server: %s\nDNS network: %s\n\n", emptyDash(bs.DNSServer), emptyDash(bs.DNSServerNetwork)))
+	if bs.BindInterface != "" || bs.BindSourceIP != "" {
+		b.WriteString(fmt.Sprintf("Bound to: interface %s, source %s (monitor --interface/--source-ip)\n\n", emptyDash(bs.BindInterface), emptyDash(bs.BindSourceIP)))
+	}
+	if c := bs.Config; c != nil {
+		b.WriteString(fmt.Sprintf("Monitor config %s: %d sites (list %s), parallel %d, http/stall/site/dns timeout %s/%s/%s/%s\n", c.Hash, c.Sites, c.SitesHash, c.Parallel,
+			msDur(c.HTTPTimeoutMs), msDur(c.StallTimeoutMs), msDur(c.SiteTimeoutMs), msDur(c.DNSTimeoutMs)))
+		if len(bs.ConfigChanges) > 0 {
+			b.WriteString("  Changed since the previous batch: " + strings.Join(bs.ConfigChanges, ", ") + "\n")
+		}
+		b.WriteString("\n")
+	}
+	if bs.DNSHijackChecked {
+		b.WriteString("DNS hijack check\n")
+		if bs.DNSHijackSuspected {
+			// a rewriting resolver also answers typos and blocked names, so DNS timings and
+			// lookup errors of this batch are not what a clean resolver would give
+			b.WriteString(fmt.Sprintf("  SUSPECTED: resolver %s answered nonexistent names with %s instead of NXDOMAIN\n", emptyDash(bs.DNSHijackResolver), strings.Join(bs.DNSHijackAnswers, ", ")))
+		} else {
+			b.WriteString(fmt.Sprintf("  Clean: nonexistent names got NXDOMAIN (resolver %s)\n", emptyDash(bs.DNSHijackResolver)))
+		}
+		b.WriteString("\n")
+	}
+	if bs.DNSCacheLines > 0 {
+		b.WriteString("DNS cache (monitor --dns-cache-check)\n")
+		if bs.MedianDNSTTLSeconds > 0 {
+			b.WriteString(fmt.Sprintf("  Median answer TTL: %.0f s\n", bs.MedianDNSTTLSeconds))
+		}
+		if bs.DNSWithinTTLLookups > 0 {
+			b.WriteString(fmt.Sprintf("  Lookups within the TTL: %d, %.0f%% cache hits (avg %.0f ms)", bs.DNSWithinTTLLookups, bs.DNSCacheHitRatePct, bs.AvgDNSCacheHitMs))
+			if bs.DNSCacheHitRatePct < 100 {
+				// slow lookups of a still-valid answer: the resolver does not cache or evicts early
+				b.WriteString(fmt.Sprintf(", re-queried avg %.0f ms", bs.AvgDNSRequeryMs))
+			}
+			b.WriteString("\n")
+		} else {
+			b.WriteString("  No lookup fell within the TTL of an earlier answer\n")
+		}
+		if bs.DNSTTLQueryErrorLines > 0 {
+			b.WriteString(fmt.Sprintf("  TTL query failed for %d line(s)\n", bs.DNSTTLQueryErrorLines))
+		}
+		b.WriteString("\n")
+	}
+	if h := bs.HealthCheck; h != nil {
+		b.WriteString(fmt.Sprintf("Target health check (%s): %d checked, %d down, %d excluded, %d ms\n", h.Mode, h.Checked, h.Unhealthy, h.Excluded, h.DurationMs))
+		if h.AllUnhealthy {
+			b.WriteString("  All targets were down, so none was excluded (more likely a local outage)\n")
+		}
+		for _, t := range h.Targets {
+			status := "measured anyway"
+			if t.Status == monitor.HealthExcludedUnhealthy {
+				status = "excluded"
+			}
+			b.WriteString(fmt.Sprintf("  %s: %s (%s)\n", t.Name, t.Reason, status))
+		}
+		b.WriteString("\n")
+	}
+	if bs.ForcedProtocolLines > 0 {
+		b.WriteString(fmt.Sprintf("Forced HTTP versions: %d lines", bs.ForcedProtocolLines))
+		if c := bs.Config; c != nil && len(c.ProtocolExperiment) > 0 {
+			b.WriteString(" (protocol experiment " + strings.Join(c.ProtocolExperiment, ", ") + ")")
+		}
+		b.WriteString("\n")
+		if bs.ForcedProtocolFallbacks > 0 {
+			b.WriteString(fmt.Sprintf("  %d lines forced to HTTP/2 were answered in HTTP/1.x (no HTTP/2 on the server); they count as HTTP/1.x\n", bs.ForcedProtocolFallbacks))
+		}
+		b.WriteString("\n")
+	}
+	if bs.FamilySpeedTest != nil || bs.FamilyTTFBTest != nil || bs.ProtocolSpeedTest != nil {
+		b.WriteString("Group comparisons (Mann–Whitney U; 95% bootstrap CI of the median difference)\n")
+		kbps := func(v float64) string { return fmt.Sprintf("%.0f kbps", v) }
+		ms := func(v float64) string { return fmt.Sprintf("%.0f ms", v) }
+		if t := bs.FamilySpeedTest; t != nil {
+			b.WriteString(significanceLine("Speed", t, kbps))
+		}
+		if t := bs.FamilyTTFBTest; t != nil {
+			b.WriteString(significanceLine("TTFB", t, ms))
+		}
+		if t := bs.ProtocolSpeedTest; t != nil {
+			b.WriteString(significanceLine("Speed", t, kbps))
+		}
+		b.WriteString("\n")
+	}
+	if bs.PreHook != nil || bs.PostHook != nil {
+		// hooks change the environment on purpose (VPN, Wi-Fi band); a failed one means the batch
+		// may not have run in the setup it was meant to measure
+		b.WriteString("Batch hooks\n")
+		for _, h := range []*monitor.HookResult{bs.PreHook, bs.PostHook} {
+			if h == nil {
+				continue
+			}
+			status := "ok"
+			if !h.OK() {
+				status = fmt.Sprintf("FAILED exit=%d", h.ExitCode)
+				if h.Error != "" {
+					status += " (" + h.Error + ")"
+				}
+			}
+			b.WriteString(fmt.Sprintf("  %s: %s — %s, %d ms\n", h.Phase, h.Command, status, h.DurationMs))
+			if h.Output != "" && !h.OK() {
+				b.WriteString("    output: " + h.Output + "\n")
+			}
+		}
+		b.WriteString("\n")
+	}
 	b.WriteString(fmt.Sprintf("Next hop: %s\nSource: %s\n\n", emptyDash(bs.NextHop), emptyDash(bs.NextHopSource)))
 	if bs.AvgDNSMs > 0 || bs.AvgConnectMs > 0 || bs.AvgTLSHandshake > 0 {
 		b.WriteString("Setup timing (means)\n")
@@ -270,6 +435,108 @@ func buildDiagnosticsText(bs analysis.BatchSummary, tolPct int) string {
 		}
 		b.WriteString("\n")
 	}
+	if len(bs.ProbeLines) > 0 {
+		b.WriteString("Probes (lines)\n")
+		names := make([]string, 0, len(bs.ProbeLines))
+		for k := range bs.ProbeLines {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+		for _, k := range names {
+			b.WriteString(fmt.Sprintf("  %s: %d\n", k, bs.ProbeLines[k]))
+		}
+		if bs.PingLines > 0 {
+			b.WriteString(fmt.Sprintf("  Ping RTT: avg %.1f ms, p50 %.1f ms, p95 %.1f ms, loss %.1f%%\n", bs.AvgPingRTTMs, bs.P50PingRTTMs, bs.P95PingRTTMs, bs.PingLossPct))
+			if bs.PingJitterLines > 0 {
+				b.WriteString(fmt.Sprintf("  Ping jitter (RFC 3550): avg %.1f ms, p95 %.1f ms over %d line(s)\n", bs.AvgPingJitterMs, bs.P95PingJitterMs, bs.PingJitterLines))
+			}
+		}
+		if bs.DNSProbeLines > 0 {
+			b.WriteString(fmt.Sprintf("  DNS probe: avg %.1f ms, errors %.1f%%\n", bs.AvgDNSProbeMs, bs.DNSProbeErrorRatePct))
+		}
+		if bs.SoakSeconds > 0 {
+			b.WriteString(fmt.Sprintf("  Soak: %.0f s, avg %.0f kbps, median %.0f kbps, p5 %.0f kbps, CoV %.1f%%\n", bs.SoakSeconds, bs.AvgSoakKbps, bs.MedianSoakKbps, bs.P5SoakKbps, bs.SoakCoVPct))
+			b.WriteString(fmt.Sprintf("  Soak drops: %d (%.1f/h), longest %.0f s, %.1f%% of time; interruptions %d\n", bs.SoakDropEvents, bs.SoakDropsPerHour, bs.SoakLongestDropS, bs.SoakTimeInDropPct, bs.SoakInterruptions))
+		}
+		if bs.IPerf3Lines > 0 {
+			for _, l := range iperf3CapacityLines(bs) {
+				b.WriteString("  " + l + "\n")
+			}
+		}
+		b.WriteString("\n")
+	}
+	if bs.Metered || bs.ReducedMode {
+		b.WriteString("Metered network\n")
+		b.WriteString(fmt.Sprintf("  Detected by: %s\n", emptyDash(bs.MeteredSource)))
+		if bs.ReducedMode {
+			// capped transfers are slower by construction; compare only with other reduced batches
+			b.WriteString(fmt.Sprintf("  Reduced mode: %d line(s), %d transfer(s) capped; speeds are not comparable with full batches\n", bs.ReducedModeLines, bs.CappedTransferLines))
+		}
+		b.WriteString("\n")
+	}
+	if bs.SamplingProfile != "" {
+		// lite batches measure fewer targets with capped transfers, like reduced mode
+		b.WriteString(fmt.Sprintf("Sampling profile: %s (fewer targets, capped transfers, no per-sample speeds; compare only with other %s batches)\n\n", bs.SamplingProfile, bs.SamplingProfile))
+	}
+	if len(bs.RouteComparisons) > 0 || bs.EgressIPv4ASN != 0 || bs.EgressIPv6ASN != 0 {
+		// Different egress or transit ASNs per family are the usual cause of a persistent gap
+		// in the IPv4/IPv6 delta charts.
+		b.WriteString("IPv4 vs IPv6 routes\n")
+		if bs.EgressIPv4ASN != 0 || bs.EgressIPv6ASN != 0 {
+			b.WriteString(fmt.Sprintf("  Egress: IPv4 %s, IPv6 %s\n", asnLabel(bs.EgressIPv4ASN, bs.EgressIPv4ASNOrg), asnLabel(bs.EgressIPv6ASN, bs.EgressIPv6ASNOrg)))
+			if bs.EgressIPv4ASN != 0 && bs.EgressIPv6ASN != 0 && bs.EgressIPv4ASN != bs.EgressIPv6ASN {
+				b.WriteString("  Egress ASNs differ (tunnel, VPN or separate IPv6 transit?)\n")
+			}
+		}
+		if len(bs.RouteComparisons) > 0 {
+			b.WriteString(fmt.Sprintf("  Sites with different paths: %d/%d\n", bs.RouteDifferSites, len(bs.RouteComparisons)))
+		}
+		for _, rc := range bs.RouteComparisons {
+			verdict := "same"
+			switch {
+			case rc.Basis == "":
+				verdict = "unknown (no ASN data)"
+			case rc.Differs:
+				verdict = "differs"
+			}
+			b.WriteString(fmt.Sprintf("  %s: %s\n", rc.URL, verdict))
+			b.WriteString(fmt.Sprintf("    IPv4 %s %s%s\n", rc.IPv4, asnLabel(rc.IPv4ASN, rc.IPv4ASNOrg), routeDetail(rc.IPv4ASPath, rc.IPv4Hops)))
+			b.WriteString(fmt.Sprintf("    IPv6 %s %s%s\n", rc.IPv6, asnLabel(rc.IPv6ASN, rc.IPv6ASNOrg), routeDetail(rc.IPv6ASPath, rc.IPv6Hops)))
+		}
+		b.WriteString("\n")
+	}
+	if rh := bs.ReturnHops; rh != nil {
+		b.WriteString("Return path (monitor --response-ttl)\n")
+		b.WriteString(fmt.Sprintf("  Estimated hops: %.1f (IPv4 %.1f, IPv6 %.1f) over %d lines\n", rh.AvgHops, rh.AvgHopsIPv4, rh.AvgHopsIPv6, rh.Lines))
+		if rh.AsymmetricLines > 0 {
+			b.WriteString(fmt.Sprintf("  Asymmetric: %d line(s) return over %d+ hops more or fewer than the traced forward path\n", rh.AsymmetricLines, monitor.AsymmetricHopDelta))
+		}
+		for _, c := range rh.Changes {
+			b.WriteString(fmt.Sprintf("  Rerouted: %s %d → %d hops\n", c.Target, c.From, c.To))
+		}
+		b.WriteString("\n")
+	}
+	if cs := bs.Compression; cs != nil {
+		b.WriteString("Response compression (monitor --accept-encoding)\n")
+		for _, l := range compressionLines(cs) {
+			b.WriteString("  " + l + "\n")
+		}
+		b.WriteString("\n")
+	}
+	if st := bs.ServerTiming; st != nil {
+		b.WriteString("Server-Timing (origin-reported processing)\n")
+		for _, l := range serverTimingLines(st) {
+			b.WriteString("  " + l + "\n")
+		}
+		b.WriteString("\n")
+	}
+	if ts := bs.TCPStats; ts != nil {
+		b.WriteString("TCP retransmissions (kernel TCP_INFO, monitor --tcp-stats)\n")
+		for _, l := range tcpRetransLines(ts) {
+			b.WriteString("  " + l + "\n")
+		}
+		b.WriteString("\n")
+	}
 	if bs.StallRatePct > 0 || bs.MicroStallRatePct > 0 || bs.LowSpeedTimeSharePct > 0 || bs.PreTTFBStallRatePct > 0 {
 		b.WriteString("Stability highlights\n")
 		if bs.StallRatePct > 0 {
@@ -412,6 +679,10 @@ func showDiagnosticsForSelection(state *uiState) {
 	bs := rows[rix]
 	// Build content with copy helpers, including traceroute command when available
 	text := buildDiagnosticsText(bs, state.calibTolerancePct)
+	// the heuristic summary leads, against the earlier batches shown with the same filters
+	if e := analysis.ExplainBatch(rows, bs.RunTag); e != nil {
+		text = "Explanation: " + e.String() + "\n\n" + text
+	}
 	jsonStr := buildDiagnosticsJSON(bs, state.calibTolerancePct)
 	traceCmd := buildTracerouteCommand(bs)
 	pingCmd := buildPingCommand(bs)
@@ -438,7 +709,8 @@ func showDiagnosticsForSelection(state *uiState) {
 	if curlCmd == "" {
 		copyCurlBtn.Disable()
 	}
-	content := container.NewBorder(nil, container.NewHBox(copyBtn, copyJSONBtn, copyTraceBtn, copyPingBtn, copyMTRBtn, copyCurlBtn), nil, nil, scroll)
+	bundleBtn := widget.NewButton("Export bundle…", func() { exportDiagnosticsBundle(state, bs, state.window) })
+	content := container.NewBorder(nil, container.NewHBox(copyBtn, copyJSONBtn, copyTraceBtn, copyPingBtn, copyMTRBtn, copyCurlBtn, bundleBtn), nil, nil, scroll)
 	d := dialog.NewCustom("Diagnostics", "Close", content, state.window)
 	d.Resize(fyne.NewSize(560, 460))
 	d.Show()
@@ -470,10 +742,17 @@ func (l *tableCellLabel) TappedSecondary(pe *fyne.PointEvent) {
 		return
 	}
 	// Set the selected row and show menu
-	l.state.selectedRow = l.row - 1
+	rix := batchRowIndex(l.state, l.row)
+	if rix < 0 {
+		return
+	}
+	l.state.selectedRow = rix
 	diagItem := fyne.NewMenuItem("Diagnostics…", func() { showDiagnosticsForSelection(l.state) })
+	linesItem := fyne.NewMenuItem("View lines…", func() { showBatchLinesForSelection(l.state) })
+	headersItem := fyne.NewMenuItem("Header history…", func() { showHeaderHistoryForSelection(l.state) })
 	// Disable when out of range
-	menu := fyne.NewMenu("", diagItem)
+	menu := fyne.NewMenu("", diagItem, linesItem, headersItem)
+	translateMenu(menu)
 	w := l.state.window
 	if w == nil {
 		return
@@ -509,14 +788,15 @@ func (l *tableCellLabel) maybeShowQualTooltip(ev *desktop.MouseEvent) {
 		return
 	}
 	// Only for Qual column cells (exclude header)
-	if l.col != 9 || l.row <= 0 {
+	cols := visibleBatchColumns(l.state)
+	if l.col < 0 || l.col >= len(cols) || cols[l.col].key != "qual" || l.row <= 0 {
 		if l.tip != nil {
 			l.tip.Hide()
 		}
 		return
 	}
 	rows := filteredSummaries(l.state)
-	rix := l.row - 1
+	rix := batchRowIndex(l.state, l.row)
 	if rix < 0 || rix >= len(rows) {
 		return
 	}
@@ -584,6 +864,13 @@ type uiState struct {
 	showOnlyQualityGood bool // when enabled, only include batches with QualityGood=true
 	// table columns visibility
 	showQualColumn bool // show the Qual (quality_good) column in the table
+	// Batches table layout: chosen column keys (nil = defaultBatchColumns) and sort column
+	// ("" = chronological); tableOrder caches the sorted row order (batch_table.go)
+	tableColumns  []string
+	tableSortKey  string
+	tableSortDesc bool
+	tableOrder    []int
+	tableOrderSig string
 
 	// widgets
 	table        *widget.Table
@@ -596,6 +883,54 @@ type uiState struct {
 
 	// situation selector (populated after data load)
 	situationSelect *widget.Select
+	// agent filter (collector files with lines from several remote agents); "" or "All" = no filter
+	agent       string
+	agentSelect *widget.Select
+	agentRow    *fyne.Container
+	// charts opened in their own windows (detach.go)
+	detachedCharts []*detachedChart
+	// keyboard navigation (a11y.go): focused chart section and the Overall/IPv4/IPv6 checkboxes
+	focusedChart *fyne.Container
+	seriesChecks []*widget.Check
+	// VPN filter ("All", "VPN on", "VPN off" or "VPN: <name>"); shown when any batch ran on a VPN
+	vpnFilter string
+	vpnSelect *widget.Select
+	vpnRow    *fyne.Container
+	// interface filter ("All" or a binding from the monitor's --interface/--source-ip); shown when
+	// batches ran on more than one binding
+	bindFilter string
+	bindSelect *widget.Select
+	bindRow    *fyne.Container
+	// tag filter ("All" or "key=value" from the monitor's --tags); shown when any batch has tags
+	tagFilter string
+	tagSelect *widget.Select
+	tagRow    *fyne.Container
+	// target group ("All" or a site "group" such as CDN or Intranet); when set, every batch is
+	// shown with that group's metrics (groups.go). Shown when any batch has groups
+	groupFilter string
+	groupSelect *widget.Select
+	groupRow    *fyne.Container
+	// Follow mode (auto-reload on file change) and alert rules (alerts.go)
+	follow       bool
+	followStop   chan struct{}
+	alertRules   []alertRule
+	alertTripped map[string]bool
+	// mini dashboard window and menu bar/tray status (mini_status.go); the tray stays once started
+	miniWin     fyne.Window
+	miniView    *miniView
+	trayStatus  bool
+	trayStarted bool
+	// "Run batch now" via the monitor daemon's control API or a monitor binary (run_batch.go)
+	monitor       monitorSettings
+	ispPlan       analysis.ISPPlan // subscribed rates for Plan Attainment; zero = off
+	monitorDaemon bool             // the control API answered on the last probe
+	runBatchBtn   *widget.Button
+	batchRunning  bool
+	// rolling 24h/7d aggregates above the BatchAvg charts (summary_strip.go)
+	summaryStrip *summaryStrip
+	// "Auto" speed unit resolved for the current data/filters (speedUnitFor)
+	autoSpeedUnit    string
+	autoSpeedUnitKey string
 	// Speed/TTFB split charts
 	speedImgCanvas           *canvas.Image // Speed – Average
 	speedMedianImgCanvas     *canvas.Image // Speed – Median
@@ -611,6 +946,7 @@ type uiState struct {
 	tpctlIPv6Img             *canvas.Image
 	errImgCanvas             *canvas.Image
 	jitterImgCanvas          *canvas.Image
+	pingJitterImgCanvas      *canvas.Image // Ping Jitter (ms): RFC 3550 jitter of the ping probe RTTs
 	covImgCanvas             *canvas.Image
 	plCountImgCanvas         *canvas.Image
 	plLongestImgCanvas       *canvas.Image
@@ -623,6 +959,7 @@ type uiState struct {
 	// transport/protocol charts
 	protocolMixImgCanvas        *canvas.Image // HTTP protocol mix (%)
 	protocolAvgSpeedImgCanvas   *canvas.Image // Avg speed by HTTP protocol
+	protocolTTFBImgCanvas       *canvas.Image // TTFB (avg + P95) by HTTP protocol
 	protocolStallRateImgCanvas  *canvas.Image // Stall rate by HTTP protocol (%)
 	protocolErrorRateImgCanvas  *canvas.Image // Error rate by HTTP protocol (%)
 	protocolErrorShareImgCanvas *canvas.Image // Error share by HTTP protocol (%) – sums to ~100%
@@ -654,8 +991,11 @@ type uiState struct {
 	detailedBytesTopSessionsCanvas *canvas.Image
 	// New: Host/IP Timing Breakdown chart (detailed)
 	detailedHostIPTimingImgCanvas *canvas.Image
+	// Speed Distribution (histogram + CDF) of the selected batch (detailed)
+	detailedDistImgCanvas *canvas.Image
 	// Detailed visibility toggles (persisted)
 	showDetailedPercentiles      bool
+	showDetailedDistribution     bool
 	showDetailedSpeedOverTime    bool
 	showDetailedBytesOverTime    bool
 	showDetailedTopSessionsSpeed bool
@@ -685,6 +1025,25 @@ type uiState struct {
 	tlsVersionMixImgCanvas        *canvas.Image // TLS version mix (%)
 	alpnMixImgCanvas              *canvas.Image // ALPN mix (%)
 	chunkedRateImgCanvas          *canvas.Image // Chunked transfer rate (%)
+	compressionImgCanvas          *canvas.Image // Effective body compression ratio, stripped encodings marked
+	serverTimingImgCanvas         *canvas.Image // TTFB split into network and Server-Timing processing time
+	tcpRetransImgCanvas           *canvas.Image // TCP retransmission and out-of-order rates (tcp_stats)
+	iperf3ImgCanvas               *canvas.Image // iperf3 probe capacity next to the HTTP speed
+	qualityScoreImgCanvas         *canvas.Image // Quality Score (0–100) per batch: the headline scorecard
+	planAttainmentImgCanvas       *canvas.Image // Plan Attainment (%) per batch against the subscribed ISP plan
+	stabilityImgCanvas            *canvas.Image // Throughput Stability (%): P10/P20 vs median, share within ±20%
+	ipv6ReadinessImgCanvas        *canvas.Image // IPv6 Readiness Score (0–100) per batch
+	heLostImgCanvas               *canvas.Image // Happy Eyeballs – IPv6 Lost Races (%)
+	udpBlockedImgCanvas           *canvas.Image // UDP Blocked Rate (%) from the QUIC probe
+	returnHopsImgCanvas           *canvas.Image // Estimated Hop Count from response TTLs, route changes marked
+	coldWarmTTFBImgCanvas         *canvas.Image // Cold vs Warm Connection TTFB from the reuse experiment
+	contentCorruptionImgCanvas    *canvas.Image // Content Corruption Rate (%) from sha256 integrity checks
+	dataUsageImgCanvas            *canvas.Image // Data Usage (MB) per batch and day from wire byte counts
+	stallTimelineImgCanvas        *canvas.Image // Stall Timeline heat strip (where within transfers transient stalls fall)
+	speedHeatmapImgCanvas         *canvas.Image // Speed Heatmap: median speed by local day × hour of day
+	ttfbHeatmapImgCanvas          *canvas.Image // TTFB Heatmap: P95 TTFB by local day × hour of day
+	wifiRSSIImgCanvas             *canvas.Image // Wi-Fi RSSI (dBm) with throughput overlay
+	wifiPHYImgCanvas              *canvas.Image // Wi-Fi PHY rate (Mbps) with throughput overlay
 
 	// Local throughput self-test chart
 	selfTestImgCanvas *canvas.Image // Local loopback throughput baseline (kbps -> chosen unit)
@@ -727,6 +1086,7 @@ type uiState struct {
 	detailedTopSessionsBytesOverlay *crosshairOverlay
 	detailedErrorsByURLOverlay      *crosshairOverlay
 	detailedPercentilesOverlay      *crosshairOverlay
+	detailedDistributionOverlay     *crosshairOverlay
 	hostIPTimingOverlay             *crosshairOverlay // overlay for Host/IP timing breakdown
 	lowSpeedImgCanvas               *canvas.Image     // Low-Speed Time Share (%)
 	stallRateImgCanvas              *canvas.Image     // Stall Rate (%)
@@ -754,6 +1114,7 @@ type uiState struct {
 	// overlays for additional charts
 	errOverlay             *crosshairOverlay
 	jitterOverlay          *crosshairOverlay
+	pingJitterOverlay      *crosshairOverlay
 	covOverlay             *crosshairOverlay
 	plCountOverlay         *crosshairOverlay
 	plLongestOverlay       *crosshairOverlay
@@ -765,6 +1126,7 @@ type uiState struct {
 	// overlays for transport/protocol charts
 	protocolMixOverlay        *crosshairOverlay
 	protocolAvgSpeedOverlay   *crosshairOverlay
+	protocolTTFBOverlay       *crosshairOverlay
 	protocolStallRateOverlay  *crosshairOverlay
 	protocolErrorRateOverlay  *crosshairOverlay
 	protocolErrorShareOverlay *crosshairOverlay
@@ -778,6 +1140,22 @@ type uiState struct {
 	tlsVersionMixOverlay        *crosshairOverlay
 	alpnMixOverlay              *crosshairOverlay
 	chunkedRateOverlay          *crosshairOverlay
+	compressionOverlay          *crosshairOverlay
+	serverTimingOverlay         *crosshairOverlay
+	tcpRetransOverlay           *crosshairOverlay
+	iperf3Overlay               *crosshairOverlay
+	qualityScoreOverlay         *crosshairOverlay
+	planAttainmentOverlay       *crosshairOverlay
+	stabilityOverlay            *crosshairOverlay
+	ipv6ReadinessOverlay        *crosshairOverlay
+	heLostOverlay               *crosshairOverlay
+	udpBlockedOverlay           *crosshairOverlay
+	returnHopsOverlay           *crosshairOverlay
+	coldWarmTTFBOverlay         *crosshairOverlay
+	contentCorruptionOverlay    *crosshairOverlay
+	dataUsageOverlay            *crosshairOverlay
+	wifiRSSIOverlay             *crosshairOverlay
+	wifiPHYOverlay              *crosshairOverlay
 	// overlays for new charts
 	tailRatioOverlay     *crosshairOverlay
 	ttfbTailRatioOverlay *crosshairOverlay
@@ -819,6 +1197,8 @@ type uiState struct {
 
 	// Low-speed threshold for Low-Speed Time Share metric (kbps)
 	lowSpeedThresholdKbps int // default 1000
+	// Minimum pause counted as a transient stall (ms)
+	microStallGapMs int // default 500
 
 	// containers
 	pctlGrid *fyne.Container
@@ -843,6 +1223,14 @@ type uiState struct {
 
 	// option to overlay legacy pre-resolve DNS timing (dns_time_ms) on DNS chart
 	showDNSLegacy bool
+	// decimateCharts thins series with more points than pixels (min/max envelope + LTTB); see decimate.go
+	decimateCharts bool
+	// situationOverlay draws one series per Situation on the headline charts; see situation_overlay.go
+	situationOverlay bool
+	// interfaceOverlay splits the same charts by source binding instead (Interface filter "All")
+	interfaceOverlay bool
+	// showConfigMarkers marks batches whose monitor configuration changed; see config_markers.go
+	showConfigMarkers bool
 
 	// data cleanup toggles
 	// When enabled, hide generic 'other' buckets from error reason charts to reduce clutter
@@ -851,7 +1239,13 @@ type uiState struct {
 	hideUnknownProtocols bool
 
 	// prefs
-	speedUnit string // "kbps", "kBps", "Mbps", "MBps", "Gbps", "GBps"
+	speedUnit string // "Auto", "kbps", "kBps", "Mbps", "MBps", "Gbps", "GBps"
+
+	// Speed percentiles: "pooled" (all transfer samples, default), "per_line" (average of each
+	// line's own percentiles; the pre-pooling method) or "compare" (both)
+	speedPercentileMethod string
+	// show times in UTC instead of local time (see timeAxisLoc)
+	timeAxisUTC bool
 
 	// rolling overlays
 	showRolling     bool // show rolling mean line on Speed/TTFB
@@ -868,11 +1262,32 @@ type uiState struct {
 	// charts registry and search
 	chartsScroll *container.Scroll
 	chartRefs    []chartRef
+	chartTOC     *chartTOC // contents sidebar + sticky header (chart_toc.go)
+	// synchronized crosshair (crosshair_sync.go): the batch hovered on any batch chart, the
+	// overlays that highlight it and the status bar naming it
+	hoverRunTag       string
+	crosshairOverlays []*crosshairOverlay
+	hoverStatus       *widget.Label
+
+	showChartTOC bool   // sidebar expanded (persisted)
+	uiLanguage   string // Settings → Language code, "" follows the system (i18n.go)
 	findEntry    *widget.Entry
 	findCountLbl *widget.Label
 	findIndex    int
 	findMatches  []int
 
+	// load warning banner (shown when the results file had skipped lines)
+	loadStats      analysis.LoadStats
+	loadMigration  analysis.MigrationReport // lines upgraded from older schema versions on the last load
+	loadWarningLbl *widget.Label
+	loadWarningRow *fyne.Container
+
+	// background load (load_cancel.go): cancels the running load, its generation (a newer load
+	// supersedes older ones), and the file the shown batches came from
+	loadCancel context.CancelFunc
+	loadGen    int
+	loadedPath string
+
 	// Calibration tolerance (percent) for pass/fail in diagnostics
 	calibTolerancePct int // default 10
 
@@ -884,18 +1299,37 @@ type uiState struct {
 
 	// custom visibility presets persisted by name
 	customPresets []visibilityPreset
+	// chart section order (dashboards.go): charts column, built-in and persisted order, toolbar picker
+	chartsColumn      *fyne.Container
+	defaultChartOrder []string
+	chartOrder        []string
+	dashboardSelect   *widget.Select
+
+	// chart render cache + async redraw pipeline (render_cache.go)
+	renderCache    *chartRenderCache
+	redrawInFlight bool
+	redrawQueued   bool
+	// fixed chart size for render snapshots on worker goroutines (avoids touching the window)
+	snapshotChartW, snapshotChartH int
+	// viewer-wide chart options captured by renderSnapshot; nil on the live state
+	snapshotSettings *renderSettings
 }
 
 // visibilityPreset stores a named set of chart IDs to show
 type visibilityPreset struct {
 	Name string   `json:"name"`
 	IDs  []string `json:"ids"`
+	// Order lists chart IDs top to bottom (dashboards.go); empty keeps the current order
+	Order []string `json:"order,omitempty"`
 }
 
 // chartRef tracks a chart section for search/navigation
 type chartRef struct {
-	title   string
+	title   string // English; the key of visibility, order and export names, shown via tr
 	section *fyne.Container
+	label   *widget.Label // section title (▶ marks keyboard focus, a11y.go)
+	help    string
+	buttons []*widget.Button // Detach and Info, relabeled on a language change
 }
 
 // isChartVisible reports whether the named chart is currently intended to be visible
@@ -983,6 +1417,7 @@ func (s *uiState) applyChartVisibilityFromPrefs() {
 			s.pretffbBlock.Hide()
 		}
 	}
+	refreshChartTOC(s)
 }
 
 // chartTitleToID maps human-readable titles to stable IDs (do not change IDs once published)
@@ -998,6 +1433,8 @@ func chartTitleToID(title string) string {
 		return "http_protocol_mix"
 	case "Avg Speed by HTTP Protocol":
 		return "proto_avg_speed"
+	case "TTFB by HTTP Protocol":
+		return "proto_ttfb"
 	case "Stall Rate by HTTP Protocol (%)":
 		return "proto_stall_rate"
 	case "Stall Share by HTTP Protocol (%)":
@@ -1022,6 +1459,44 @@ func chartTitleToID(title string) string {
 		return "alpn_mix"
 	case "Chunked Transfer Rate (%)":
 		return "chunked_rate"
+	case "Compression Ratio":
+		return "compression_ratio"
+	case "TTFB: Network vs Server (ms)":
+		return "server_timing"
+	case "TCP Retransmissions (%)":
+		return "tcp_retrans"
+	case "iPerf3 Capacity vs HTTP Speed":
+		return "iperf3_capacity"
+	case "Quality Score":
+		return "quality_score"
+	case "Plan Attainment (%)":
+		return "plan_attainment"
+	case "Throughput Stability (%)":
+		return "throughput_stability"
+	case "IPv6 Readiness Score":
+		return "ipv6_readiness"
+	case "Happy Eyeballs – IPv6 Lost Races (%)":
+		return "happy_eyeballs_ipv6_lost"
+	case "UDP Blocked Rate (%)":
+		return "udp_blocked_rate"
+	case "Estimated Hop Count":
+		return "return_hops"
+	case "Cold vs Warm Connection TTFB (ms)":
+		return "cold_warm_ttfb"
+	case "Content Corruption Rate (%)":
+		return "content_corruption_rate"
+	case "Data Usage (MB)":
+		return "data_usage"
+	case "Stall Timeline (position in transfer)":
+		return "stall_timeline"
+	case "Speed Heatmap (day × hour)":
+		return "heatmap_speed"
+	case "TTFB Heatmap (day × hour)":
+		return "heatmap_ttfb"
+	case "Wi‑Fi RSSI vs Throughput":
+		return "wifi_rssi"
+	case "Wi‑Fi PHY Rate vs Throughput":
+		return "wifi_phy_rate"
 	case "Speed – Average":
 		return "speed_avg"
 	case "Speed – Median":
@@ -1066,6 +1541,8 @@ func chartTitleToID(title string) string {
 		return "error_rate"
 	case "Jitter":
 		return "jitter"
+	case "Ping Jitter (ms)":
+		return "ping_jitter"
 	case "Coefficient of Variation":
 		return "cov"
 	case "Low Speed Share":
@@ -1137,6 +1614,8 @@ func chartHasData(state *uiState, title string) bool {
 		return state.protocolMixImgCanvas != nil && state.protocolMixImgCanvas.Image != nil
 	case "Avg Speed by HTTP Protocol":
 		return state.protocolAvgSpeedImgCanvas != nil && state.protocolAvgSpeedImgCanvas.Image != nil
+	case "TTFB by HTTP Protocol":
+		return state.protocolTTFBImgCanvas != nil && state.protocolTTFBImgCanvas.Image != nil
 	case "Stall Rate by HTTP Protocol (%)":
 		return state.protocolStallRateImgCanvas != nil && state.protocolStallRateImgCanvas.Image != nil
 	case "Stall Share by HTTP Protocol (%)":
@@ -1161,6 +1640,44 @@ func chartHasData(state *uiState, title string) bool {
 		return state.alpnMixImgCanvas != nil && state.alpnMixImgCanvas.Image != nil
 	case "Chunked Transfer Rate (%)":
 		return state.chunkedRateImgCanvas != nil && state.chunkedRateImgCanvas.Image != nil
+	case "Compression Ratio":
+		return state.compressionImgCanvas != nil && state.compressionImgCanvas.Image != nil
+	case "TTFB: Network vs Server (ms)":
+		return state.serverTimingImgCanvas != nil && state.serverTimingImgCanvas.Image != nil
+	case "TCP Retransmissions (%)":
+		return state.tcpRetransImgCanvas != nil && state.tcpRetransImgCanvas.Image != nil
+	case "iPerf3 Capacity vs HTTP Speed":
+		return state.iperf3ImgCanvas != nil && state.iperf3ImgCanvas.Image != nil
+	case "Quality Score":
+		return state.qualityScoreImgCanvas != nil && state.qualityScoreImgCanvas.Image != nil
+	case "Plan Attainment (%)":
+		return state.planAttainmentImgCanvas != nil && state.planAttainmentImgCanvas.Image != nil
+	case "Throughput Stability (%)":
+		return state.stabilityImgCanvas != nil && state.stabilityImgCanvas.Image != nil
+	case "IPv6 Readiness Score":
+		return state.ipv6ReadinessImgCanvas != nil && state.ipv6ReadinessImgCanvas.Image != nil
+	case "Happy Eyeballs – IPv6 Lost Races (%)":
+		return state.heLostImgCanvas != nil && state.heLostImgCanvas.Image != nil
+	case "UDP Blocked Rate (%)":
+		return state.udpBlockedImgCanvas != nil && state.udpBlockedImgCanvas.Image != nil
+	case "Estimated Hop Count":
+		return state.returnHopsImgCanvas != nil && state.returnHopsImgCanvas.Image != nil
+	case "Cold vs Warm Connection TTFB (ms)":
+		return state.coldWarmTTFBImgCanvas != nil && state.coldWarmTTFBImgCanvas.Image != nil
+	case "Content Corruption Rate (%)":
+		return state.contentCorruptionImgCanvas != nil && state.contentCorruptionImgCanvas.Image != nil
+	case "Data Usage (MB)":
+		return state.dataUsageImgCanvas != nil && state.dataUsageImgCanvas.Image != nil
+	case "Stall Timeline (position in transfer)":
+		return state.stallTimelineImgCanvas != nil && state.stallTimelineImgCanvas.Image != nil
+	case "Speed Heatmap (day × hour)":
+		return state.speedHeatmapImgCanvas != nil && state.speedHeatmapImgCanvas.Image != nil
+	case "TTFB Heatmap (day × hour)":
+		return state.ttfbHeatmapImgCanvas != nil && state.ttfbHeatmapImgCanvas.Image != nil
+	case "Wi‑Fi RSSI vs Throughput":
+		return state.wifiRSSIImgCanvas != nil && state.wifiRSSIImgCanvas.Image != nil
+	case "Wi‑Fi PHY Rate vs Throughput":
+		return state.wifiPHYImgCanvas != nil && state.wifiPHYImgCanvas.Image != nil
 	case "Speed – Average":
 		return state.speedImgCanvas != nil && state.speedImgCanvas.Image != nil
 	case "Speed – Median":
@@ -1205,6 +1722,8 @@ func chartHasData(state *uiState, title string) bool {
 		return state.errImgCanvas != nil && state.errImgCanvas.Image != nil
 	case "Jitter":
 		return state.jitterImgCanvas != nil && state.jitterImgCanvas.Image != nil
+	case "Ping Jitter (ms)":
+		return state.pingJitterImgCanvas != nil && state.pingJitterImgCanvas.Image != nil
 	case "Coefficient of Variation":
 		return state.covImgCanvas != nil && state.covImgCanvas.Image != nil
 	case "Low-Speed Time Share":
@@ -1335,17 +1854,24 @@ func activePresetName(state *uiState) string {
 
 // makeChartSection composes a header row (title + info button) and the stacked image+overlay
 func makeChartSection(state *uiState, title string, help string, stack *fyne.Container) *fyne.Container {
-	titleLbl := widget.NewLabelWithStyle(title, fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	titleLbl := widget.NewLabelWithStyle(tr(title), fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
 	// Accessibility: give the Info button a visible label so screen readers announce it clearly
-	infoBtn := widget.NewButtonWithIcon("Info", theme.InfoIcon(), func() {
+	infoBtn := widget.NewButtonWithIcon(tr("Info"), theme.InfoIcon(), func() {
 		// Open in a resizable child window with a minimum size and persistent sizing
-		showChartInfoWindow(state, title+" – Info", help)
+		showChartInfoWindow(state, tr(title)+" – "+tr("Info"), help)
 	})
 	infoBtn.Importance = widget.LowImportance
-	header := container.New(layout.NewHBoxLayout(), titleLbl, layout.NewSpacer(), infoBtn)
-	sec := container.NewVBox(header, stack)
+	var sec *fyne.Container
+	// Detach opens the chart in its own window (same as double-clicking the chart)
+	detachBtn := widget.NewButtonWithIcon(tr("Detach"), theme.ViewFullScreenIcon(), func() { openDetachedChartForSection(state, sec) })
+	detachBtn.Importance = widget.LowImportance
+	header := container.New(layout.NewHBoxLayout(), titleLbl, layout.NewSpacer(), detachBtn, infoBtn)
+	sec = container.NewVBox(header, stack)
+	if _, ov := sectionChart(stack); ov != nil {
+		ov.a11yTitle = tr(title)
+	}
 	if state != nil {
-		state.chartRefs = append(state.chartRefs, chartRef{title: title, section: sec})
+		state.chartRefs = append(state.chartRefs, chartRef{title: title, section: sec, label: titleLbl, help: help, buttons: []*widget.Button{detachBtn, infoBtn}})
 	}
 	return sec
 }
@@ -1463,7 +1989,7 @@ func updateFindMatches(state *uiState) {
 		if !state.isChartVisible(r.title) {
 			continue
 		}
-		if strings.Contains(strings.ToLower(r.title), query) {
+		if strings.Contains(strings.ToLower(r.title), query) || strings.Contains(strings.ToLower(tr(r.title)), query) {
 			state.findMatches = append(state.findMatches, i)
 		}
 	}
@@ -1486,8 +2012,12 @@ func findScrollToCurrent(state *uiState) {
 	if state == nil || state.chartsScroll == nil || len(state.findMatches) == 0 || state.findIndex < 0 || state.findIndex >= len(state.findMatches) {
 		return
 	}
-	idx := state.findMatches[state.findIndex]
-	if idx < 0 || idx >= len(state.chartRefs) {
+	scrollToChartSection(state, state.findMatches[state.findIndex])
+}
+
+// scrollToChartSection scrolls the BatchAvg charts so section idx of chartRefs is in view.
+func scrollToChartSection(state *uiState, idx int) {
+	if state == nil || state.chartsScroll == nil || idx < 0 || idx >= len(state.chartRefs) {
 		return
 	}
 	ref := state.chartRefs[idx]
@@ -1565,17 +2095,62 @@ func speedUnitNameAndFactor(unit string) (string, float64) {
 	}
 }
 
-// dark theme wrapper
-type darkTheme struct{}
+// autoSpeedUnit picks the unit for the "Auto" setting from the median batch average speed:
+// kbps below 1 Mbps, Gbps from 1 Gbps, Mbps in between. The median (not the max) keeps one
+// fast outlier batch from pushing a slow link's charts into fractions.
+func autoSpeedUnit(rows []analysis.BatchSummary) string {
+	vals := make([]float64, 0, len(rows))
+	for _, r := range rows {
+		if r.AvgSpeed > 0 {
+			vals = append(vals, r.AvgSpeed)
+		}
+	}
+	if len(vals) == 0 {
+		return "Mbps"
+	}
+	sort.Float64s(vals)
+	med := vals[len(vals)/2]
+	switch {
+	case med < 1000:
+		return "kbps"
+	case med >= 1_000_000:
+		return "Gbps"
+	}
+	return "Mbps"
+}
 
-func (d *darkTheme) Color(name fyne.ThemeColorName, variant fyne.ThemeVariant) color.Color {
-	return theme.DefaultTheme().Color(name, theme.VariantDark)
+// speedUnitFor resolves the configured speed unit, including "Auto", to a name and factor.
+// Auto uses the unit refreshAutoSpeedUnit resolved for the filtered batches, so every chart and
+// the table share a unit; renderers only read it.
+func speedUnitFor(state *uiState) (string, float64) {
+	if state == nil {
+		return speedUnitNameAndFactor("")
+	}
+	if !strings.EqualFold(state.speedUnit, "Auto") {
+		return speedUnitNameAndFactor(state.speedUnit)
+	}
+	if state.autoSpeedUnit == "" {
+		// not resolved yet: derive it without storing (this may run on a render worker)
+		return speedUnitNameAndFactor(autoSpeedUnit(filteredSummaries(state)))
+	}
+	return speedUnitNameAndFactor(state.autoSpeedUnit)
 }
-func (d *darkTheme) Font(style fyne.TextStyle) fyne.Resource { return theme.DefaultTheme().Font(style) }
-func (d *darkTheme) Icon(name fyne.ThemeIconName) fyne.Resource {
-	return theme.DefaultTheme().Icon(name)
+
+// refreshAutoSpeedUnit re-resolves the "Auto" unit when the loaded data or a batch filter
+// changed. Call on the UI thread before a render pass.
+func refreshAutoSpeedUnit(state *uiState) {
+	if state == nil || !strings.EqualFold(state.speedUnit, "Auto") {
+		return
+	}
+	key := fmt.Sprintf("%d|%s|%s|%s|%s|%s|%s|%t", len(state.summaries), state.situation, state.agent, state.vpnFilter, state.bindFilter, state.tagFilter, state.groupFilter, state.showOnlyQualityGood)
+	if n := len(state.summaries); n > 0 {
+		key += "|" + state.summaries[0].RunTag + "|" + state.summaries[n-1].RunTag
+	}
+	if key != state.autoSpeedUnitKey || state.autoSpeedUnit == "" {
+		state.autoSpeedUnitKey = key
+		state.autoSpeedUnit = autoSpeedUnit(filteredSummaries(state))
+	}
 }
-func (d *darkTheme) Size(name fyne.ThemeSizeName) float32 { return theme.DefaultTheme().Size(name) }
 
 // tinyWrapper is a container that forces a very small MinSize so the window can be shrunk
 // beyond the natural minimum implied by its child (e.g. wide table columns). It simply
@@ -1629,6 +2204,13 @@ func main() {
 	var shotsShowIQR bool
 	var selfTest bool
 	var showPretffbCLI string
+	var serveAddr string
+	var serveBatches int
+	var serveToken string
+	var chartAppearanceFlag string
+	var trendFlag string
+	var forecastFlag int
+	var langFlag string
 	flag.StringVar(&fileFlag, "file", "", "Path to monitor results JSONL file")
 	flag.BoolVar(&shots, "screenshot", false, "Run in headless screenshot mode and save sample charts to --screenshot-outdir")
 	flag.StringVar(&shotsOut, "screenshot-outdir", "docs/images", "Directory to write screenshots into (created if missing)")
@@ -1636,9 +2218,31 @@ func main() {
 	flag.IntVar(&shotsRollingWindow, "screenshot-rolling-window", 7, "Rolling window N for overlays")
 	flag.BoolVar(&shotsBand, "screenshot-rolling-band", true, "Whether to show the ±1σ band in screenshots")
 	flag.IntVar(&shotsLowSpeedThreshKbps, "screenshot-low-speed-threshold-kbps", 1000, "Low-Speed Threshold (kbps) used for Low-Speed Time Share in screenshots")
+	flag.IntVar(&screenshotMicroStallGapMs, "screenshot-micro-stall-gap-ms", analysis.DefaultMicroStallGapMs, "Transient Stall Gap (ms) used for the transient stall charts in screenshots")
 	flag.IntVar(&shotsBatches, "screenshot-batches", 50, "How many recent batches to include in screenshots")
 	flag.StringVar(&shotsTheme, "screenshot-theme", "auto", "Screenshot theme: 'auto', 'dark', or 'light'")
 	flag.StringVar(&shotsVariants, "screenshot-variants", "averages", "Which extra variants to render: 'none' or 'averages'")
+	var shotsOnly string
+	flag.StringVar(&shotsOnly, "screenshot-only", "", "Comma-separated chart names to render in --screenshot mode instead of the whole set (file names without .png, e.g. speed_avg,ttfb_p95_p50_gap,stall_rate)")
+	flag.StringVar(&screenshotOutFile, "out", "", "With --screenshot and a single --screenshot-only chart: write it to this PNG path instead of --screenshot-outdir")
+	var shotsFrom, shotsTo string
+	flag.StringVar(&shotsFrom, "from", "", "With --screenshot: only batches that started at or after this time (RFC 3339, '2006-01-02 15:04' or 2006-01-02; local time unless a zone is given)")
+	flag.StringVar(&shotsTo, "to", "", "With --screenshot: only batches that started at or before this time (a date alone includes that whole day)")
+	flag.Func("tag", "With --screenshot: only batches whose meta.tags carry key=value (repeatable or comma-separated; all must match)", func(v string) error {
+		tags, err := monitor.ParseTags(v)
+		if err != nil {
+			return err
+		}
+		if screenshotSlice.tags == nil {
+			screenshotSlice.tags = map[string]string{}
+		}
+		for k, val := range tags {
+			screenshotSlice.tags[k] = val
+		}
+		return nil
+	})
+	flag.StringVar(&screenshotFormat, "screenshot-format", "png", "Screenshot output: 'png', or 'svg' to also write a vector .svg next to each PNG")
+	flag.IntVar(&screenshotJobs, "screenshot-jobs", 0, "Charts to render in parallel in --screenshot mode (0 = one per CPU, 1 = sequential)")
 	flag.BoolVar(&shotsDNSLegacy, "screenshot-dns-legacy", false, "If true, overlay legacy dns_time_ms as dashed line on DNS chart in screenshots")
 	flag.BoolVar(&shotsSelfTest, "screenshot-selftest", true, "Include the Local Throughput Self-Test chart in screenshots")
 	flag.BoolVar(&shotsIncludePreTTFB, "screenshot-pretffb", true, "Include Pre‑TTFB Stall Rate chart if data is present")
@@ -1650,31 +2254,100 @@ func main() {
 	flag.BoolVar(&shotsShowIQR, "screenshot-show-iqr", false, "Show IQR band (P25–P75) on averages charts in screenshots")
 	flag.BoolVar(&selfTest, "selftest-speed", true, "Run a quick local throughput self-test on startup (loopback)")
 	flag.StringVar(&showPretffbCLI, "show-pretffb", "", "Show Pre‑TTFB chart on launch (true|false); persists preference")
+	flag.StringVar(&serveAddr, "serve", "", "Serve a browser dashboard on this address instead of opening a window; no display needed. A bare :8080 binds 127.0.0.1; other interfaces need --serve-token")
+	flag.StringVar(&serveToken, "serve-token", "", "Token the --serve dashboard requires (Authorization: Bearer, or open the page once with ?token=)")
+	flag.IntVar(&serveBatches, "serve-batches", 50, "How many recent batches the --serve dashboard analyzes")
+	flag.StringVar(&chartAppearanceFlag, "chart-appearance", "", "Chart appearance for --screenshot and --serve, e.g. 'palette=colorblind,ipv4=#0072b2,dot=1.5,line=2,font=1.2' (the window uses Settings → Chart Appearance)")
+	flag.StringVar(&trendFlag, "trend", "", "Trend lines on the batch charts for --screenshot and --serve: off, linear or loess")
+	flag.IntVar(&forecastFlag, "forecast-batches", defaultForecastBatches, "Forecast band length in batches for --trend (0 = trend line only)")
+	flag.StringVar(&langFlag, "lang", "", "Language of menus and chart titles, e.g. en or nl; saved as Settings → Language; --screenshot and --serve default to English")
+	logLevel := flag.String("log-level", monitor.LogEnvDefault("IQM_LOG_LEVEL", "info"), "Log level (debug|info|warn|error; default: $IQM_LOG_LEVEL or info); debug also traces resize/render")
+	logFormat := flag.String("log-format", monitor.LogEnvDefault("IQM_LOG_FORMAT", "text"), "Log output on stderr: text or json (default: $IQM_LOG_FORMAT or text)")
 	flag.Parse()
+	if err := configureViewerLogging(os.Stderr, *logLevel, *logFormat); err != nil {
+		fmt.Fprintf(os.Stderr, "--log-level/--log-format: %v\n", err)
+		os.Exit(2)
+	}
+	if trendFlag != "" && (shots || serveAddr != "") {
+		m, err := parseTrendMethod(trendFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "--trend: %v\n", err)
+			os.Exit(2)
+		}
+		chartTrend = trendOptions{Method: m, Horizon: min(max(forecastFlag, 0), 100)}
+	}
+	// headless renders stay English unless asked, so docs images do not depend on the locale
+	if langFlag != "" && (shots || serveAddr != "") {
+		if got := i18n.SetLanguage(langFlag); got == i18n.Template && !strings.HasPrefix(strings.ToLower(langFlag), i18n.Template) {
+			logf(slog.LevelWarn, "viewer", "--lang %s: no catalog, using English", langFlag)
+		}
+	}
+	if chartAppearanceFlag != "" && (shots || serveAddr != "") {
+		look, err := parseChartAppearance(chartAppearanceFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "--chart-appearance: %v\n", err)
+			os.Exit(2)
+		}
+		chartLook = look
+	}
 
 	if selfTest {
 		kbps, err := monitor.LocalMaxSpeedProbe(300 * time.Millisecond)
 		if err != nil {
-			fmt.Println("[selftest] local throughput probe error:", err)
+			logf(slog.LevelWarn, "selftest", "local throughput probe error: %v", err)
 		} else {
 			_, factor := speedUnitNameAndFactor("Mbps")
-			fmt.Printf("[selftest] local throughput: %.1f Mbps (%.0f kbps)\n", kbps*factor, kbps)
+			logf(slog.LevelInfo, "selftest", "local throughput: %.1f Mbps (%.0f kbps)", kbps*factor, kbps)
 			monitor.SetLocalSelfTestKbps(kbps)
 		}
 	}
 
 	// Headless screenshots mode: no UI, just render and write images.
 	if shots {
+		if shotsOnly != "" {
+			screenshotOnly = strings.Split(shotsOnly, ",")
+		}
+		var err error
+		if shotsFrom != "" {
+			if screenshotSlice.from, err = parseScreenshotTime(shotsFrom, false); err != nil {
+				fmt.Fprintf(os.Stderr, "--from: %v\n", err)
+				os.Exit(2)
+			}
+		}
+		if shotsTo != "" {
+			if screenshotSlice.to, err = parseScreenshotTime(shotsTo, true); err != nil {
+				fmt.Fprintf(os.Stderr, "--to: %v\n", err)
+				os.Exit(2)
+			}
+		}
+		if !screenshotSlice.from.IsZero() && !screenshotSlice.to.IsZero() && screenshotSlice.to.Before(screenshotSlice.from) {
+			fmt.Fprintf(os.Stderr, "--to is before --from\n")
+			os.Exit(2)
+		}
 		if err := RunScreenshotsMode(fileFlag, shotsOut, shotsSituation, shotsRollingWindow, shotsBand, shotsBatches, shotsLowSpeedThreshKbps, shotsVariants, shotsTheme, shotsDNSLegacy, shotsSelfTest, shotsIncludePreTTFB, shotsShowAvg, shotsShowMedian, shotsShowMin, shotsShowMax, shotsShowIQR); err != nil {
 			fmt.Fprintf(os.Stderr, "screenshot mode error: %v\n", err)
 			os.Exit(1)
 		}
-		fmt.Println("[viewer] screenshots written to:", shotsOut)
+		if screenshotOutFile != "" {
+			shotsOut = screenshotOutFile
+		}
+		logf(slog.LevelInfo, "viewer", "screenshots written to: %s", shotsOut)
+		return
+	}
+
+	// Web dashboard mode: like screenshots, charts are rendered headlessly, per request.
+	if serveAddr != "" {
+		if err := RunServeMode(serveAddr, fileFlag, serveBatches, serveToken); err != nil {
+			fmt.Fprintf(os.Stderr, "serve mode error: %v\n", err)
+			os.Exit(1)
+		}
 		return
 	}
 
 	a := app.NewWithID("com.iqm.viewer")
-	a.Settings().SetTheme(&darkTheme{})
+	viewerTheme.mode = normalizeAppThemeMode(a.Preferences().StringWithFallback("appThemeMode", "system"))
+	viewerTheme.accent = normalizeAppAccent(a.Preferences().String("appAccent"))
+	a.Settings().SetTheme(viewerTheme)
 	w := a.NewWindow("IQM Viewer")
 	// Main window sizing: greatly reduced minimum so user can shrink window far more.
 	// Real minimum visual usability will be enforced by responsive table column adjustments below.
@@ -1704,8 +2377,11 @@ func main() {
 		showIPv4:    true,
 		showIPv6:    true,
 		speedUnit:   "kbps",
+		// Pooled percentiles are the true batch-level values; see speedPercentileValue
+		speedPercentileMethod: "pooled",
 		// Detailed charts defaults (first run) – will be overridden by prefs if present
 		showDetailedPercentiles:      true,
+		showDetailedDistribution:     true,
 		showDetailedSpeedOverTime:    true, // overlays for detailed charts created lazily
 		showDetailedBytesOverTime:    true,
 		showDetailedTopSessionsSpeed: true,
@@ -1729,6 +2405,9 @@ func main() {
 	// Sensible corporate defaults for SLA thresholds
 	state.slaSpeedThresholdKbps = 10000 // 10 Mbps P50 speed target
 	state.slaTTFBThresholdMs = 200      // 200 ms P95 TTFB target
+	// Stall analysis defaults: 1 Mbps low-speed threshold, 500 ms transient stall gap
+	state.lowSpeedThresholdKbps = analysis.DefaultLowSpeedThresholdKbps
+	state.microStallGapMs = analysis.DefaultMicroStallGapMs
 	// Calibration tolerance default (10%)
 	state.calibTolerancePct = 10
 	// Ensure crosshair preference is loaded before creating overlays/controls.
@@ -1738,12 +2417,31 @@ func main() {
 	state.showHints = a.Preferences().BoolWithFallback("showHints", false)
 	// Initialize DNS legacy overlay preference early (used by menus)
 	state.showDNSLegacy = a.Preferences().BoolWithFallback("showDNSLegacy", false)
+	state.decimateCharts = a.Preferences().BoolWithFallback("decimateCharts", true)
+	chartDecimationEnabled = state.decimateCharts
+	state.showConfigMarkers = a.Preferences().BoolWithFallback("showConfigMarkers", true)
+	state.showChartTOC = a.Preferences().BoolWithFallback("showChartTOC", true)
+	// before any label is built; --lang overrides the saved choice and is kept like a Settings pick
+	state.uiLanguage = a.Preferences().String("uiLanguage")
+	if langFlag != "" {
+		state.uiLanguage = langFlag
+	}
+	i18n.SetLanguage(state.uiLanguage)
+	state.trayStatus = a.Preferences().Bool("trayStatus")
+	if look, err := parseChartAppearance(a.Preferences().String("chartAppearance")); err == nil {
+		chartLook = look
+	}
+	if m, err := parseTrendMethod(a.Preferences().String("trendMethod")); err == nil {
+		chartTrend.Method = m
+	}
+	chartTrend.Horizon = min(max(a.Preferences().IntWithFallback("forecastBatches", defaultForecastBatches), 0), 100)
 	// Initialize theme mode from preferences (default: auto). Resolve effective theme for charts.
 	screenshotThemeMode = strings.ToLower(strings.TrimSpace(a.Preferences().StringWithFallback("screenshotThemeMode", "auto")))
 	if screenshotThemeMode != "auto" && screenshotThemeMode != "light" && screenshotThemeMode != "dark" {
 		screenshotThemeMode = "auto"
 	}
 	screenshotThemeGlobal = resolveTheme(screenshotThemeMode, a)
+	followSystemTheme(state)
 	// Load Pre‑TTFB chart visibility preference (default: true)
 	state.showPreTTFB = a.Preferences().BoolWithFallback("showPreTTFB", true)
 	// Auto-hide Pre‑TTFB when metric is all zero (default: false)
@@ -1770,6 +2468,7 @@ func main() {
 	overallChk := widget.NewCheck("Overall", nil)
 	ipv4Chk := widget.NewCheck("IPv4", nil)
 	ipv6Chk := widget.NewCheck("IPv6", nil)
+	state.seriesChecks = []*widget.Check{overallChk, ipv4Chk, ipv6Chk}
 	// (Crosshair checkbox removed from toolbar; use Settings → Crosshair)
 
 	// (X-Axis and Y-Scale moved to Settings menu)
@@ -1789,12 +2488,12 @@ func main() {
 			state.situation = v
 		}
 		// small debug to verify selection behavior and filtered counts
-		fmt.Printf("[viewer] situation changed to: %q; filtered batches=%d\n", v, len(filteredSummaries(state)))
+		logf(slog.LevelDebug, "viewer", "situation changed to: %q; filtered batches=%d", v, len(filteredSummaries(state)))
 		savePrefs(state)
 		if state.table != nil {
 			state.table.Refresh()
 		}
-		redrawCharts(state)
+		scheduleRedraw(state)
 		// ensure overlays re-evaluate filtered data immediately
 		if state.speedOverlay != nil {
 			state.speedOverlay.Refresh()
@@ -1806,17 +2505,97 @@ func main() {
 	sitSelect.PlaceHolder = "All"
 	state.situationSelect = sitSelect
 
+	// Agent selector; only shown when the loaded file has lines from more than one agent
+	state.agentSelect = widget.NewSelect([]string{"All"}, func(v string) {
+		if state.initializing {
+			return
+		}
+		state.agent = v
+		logf(slog.LevelDebug, "viewer", "agent changed to: %q; filtered batches=%d", v, len(filteredSummaries(state)))
+		if state.table != nil {
+			state.table.Refresh()
+		}
+		scheduleRedraw(state)
+	})
+	state.agentSelect.PlaceHolder = "All"
+	state.agentRow = container.NewHBox(widget.NewLabel("Agent:"), state.agentSelect)
+	state.agentRow.Hide()
+
+	// VPN selector; splits batches by the monitor's per-batch VPN detection (meta.vpn_active)
+	state.vpnSelect = widget.NewSelect([]string{"All"}, func(v string) {
+		if state.initializing {
+			return
+		}
+		state.vpnFilter = v
+		logf(slog.LevelDebug, "viewer", "vpn filter changed to: %q; filtered batches=%d", v, len(filteredSummaries(state)))
+		if state.table != nil {
+			state.table.Refresh()
+		}
+		scheduleRedraw(state)
+	})
+	state.vpnSelect.PlaceHolder = "All"
+	state.vpnRow = container.NewHBox(widget.NewLabel("VPN:"), state.vpnSelect)
+	state.vpnRow.Hide()
+
+	// Interface selector; splits batches by the monitor's source binding (--interface/--source-ip)
+	state.bindSelect = widget.NewSelect([]string{"All"}, func(v string) {
+		if state.initializing {
+			return
+		}
+		state.bindFilter = v
+		logf(slog.LevelDebug, "viewer", "interface filter changed to: %q; filtered batches=%d", v, len(filteredSummaries(state)))
+		if state.table != nil {
+			state.table.Refresh()
+		}
+		scheduleRedraw(state)
+	})
+	state.bindSelect.PlaceHolder = "All"
+	state.bindRow = container.NewHBox(widget.NewLabel("Interface:"), state.bindSelect)
+	state.bindRow.Hide()
+
+	// Tag selector; filters batches by one key=value tag attached with the monitor's --tags
+	state.tagSelect = widget.NewSelect([]string{"All"}, func(v string) {
+		if state.initializing {
+			return
+		}
+		state.tagFilter = v
+		logf(slog.LevelDebug, "viewer", "tag filter changed to: %q; filtered batches=%d", v, len(filteredSummaries(state)))
+		if state.table != nil {
+			state.table.Refresh()
+		}
+		scheduleRedraw(state)
+	})
+	state.tagSelect.PlaceHolder = "All"
+	state.tagRow = container.NewHBox(widget.NewLabel("Tag:"), state.tagSelect)
+	state.tagRow.Hide()
+
+	// Group selector; narrows every chart and the table to one target group (internal vs internet paths)
+	state.groupSelect = widget.NewSelect([]string{"All"}, func(v string) {
+		if state.initializing {
+			return
+		}
+		state.groupFilter = v
+		logf(slog.LevelDebug, "viewer", "group filter changed to: %q; filtered batches=%d", v, len(filteredSummaries(state)))
+		if state.table != nil {
+			state.table.Refresh()
+		}
+		scheduleRedraw(state)
+	})
+	state.groupSelect.PlaceHolder = "All"
+	state.groupRow = container.NewHBox(widget.NewLabel("Group:"), state.groupSelect)
+	state.groupRow.Hide()
+
 	// (Batches control moved to Settings menu)
 
-	// Data table (batches overview)
+	// Data table (batches overview); columns are chosen and sorted via batch_table.go
 	state.table = widget.NewTable(
-		// size provider: 1 header row + data rows; 10 columns (added Qual)
+		// size provider: 1 header row + data rows
 		func() (int, int) {
 			rows := len(filteredSummaries(state)) + 1
 			if rows < 1 {
 				rows = 1
 			}
-			return rows, 10
+			return rows, len(visibleBatchColumns(state))
 		},
 		// template object
 		func() fyne.CanvasObject { return newTableCellLabel(state) },
@@ -1825,109 +2604,28 @@ func main() {
 			lbl := o.(*tableCellLabel)
 			lbl.row = id.Row
 			lbl.col = id.Col
-			rows := filteredSummaries(state)
-			// columns: 0 RunTag, 1 Lines, 2 AvgSpeed, 3 AvgTTFB, 4 Errors, 5 v4 speed, 6 v4 ttfb, 7 v6 speed, 8 v6 ttfb, 9 Qual
-			if id.Row == 0 { // header row labels
-				unitName, _ := speedUnitNameAndFactor(state.speedUnit)
-				switch id.Col {
-				case 0:
-					lbl.SetText("RunTag")
-				case 1:
-					lbl.SetText("Lines")
-				case 2:
-					lbl.SetText("Avg(" + unitName + ")")
-				case 3:
-					lbl.SetText("AvgTTFB(ms)")
-				case 4:
-					lbl.SetText("Errors")
-				case 5:
-					lbl.SetText("v4(" + unitName + ")")
-				case 6:
-					lbl.SetText("v4TTFB")
-				case 7:
-					lbl.SetText("v6(" + unitName + ")")
-				case 8:
-					lbl.SetText("v6TTFB")
-				case 9:
-					lbl.SetText("Qual")
-				}
+			cols := visibleBatchColumns(state)
+			if id.Col < 0 || id.Col >= len(cols) {
+				lbl.SetText("")
+				return
+			}
+			if id.Row == 0 { // header row labels (click to sort)
+				lbl.SetText(batchHeaderText(state, cols[id.Col]))
 				return
 			}
-			rix := id.Row - 1
+			rows := filteredSummaries(state)
+			rix := batchRowIndex(state, id.Row)
 			if rix < 0 || rix >= len(rows) {
 				lbl.SetText("")
 				return
 			}
-			_, factor := speedUnitNameAndFactor(state.speedUnit)
-			bs := rows[rix]
-			switch id.Col {
-			case 0:
-				lbl.SetText(bs.RunTag)
-			case 1:
-				lbl.SetText(fmt.Sprintf("%d", bs.Lines))
-			case 2:
-				lbl.SetText(fmt.Sprintf("%.1f", bs.AvgSpeed*factor))
-			case 3:
-				lbl.SetText(fmt.Sprintf("%.0f", bs.AvgTTFB))
-			case 4:
-				lbl.SetText(fmt.Sprintf("%d", bs.ErrorLines))
-			case 5:
-				if bs.IPv4 != nil {
-					lbl.SetText(fmt.Sprintf("%.1f", bs.IPv4.AvgSpeed*factor))
-				} else {
-					lbl.SetText("-")
-				}
-			case 6:
-				if bs.IPv4 != nil {
-					lbl.SetText(fmt.Sprintf("%.0f", bs.IPv4.AvgTTFB))
-				} else {
-					lbl.SetText("-")
-				}
-			case 7:
-				if bs.IPv6 != nil {
-					lbl.SetText(fmt.Sprintf("%.1f", bs.IPv6.AvgSpeed*factor))
-				} else {
-					lbl.SetText("-")
-				}
-			case 8:
-				if bs.IPv6 != nil {
-					lbl.SetText(fmt.Sprintf("%.0f", bs.IPv6.AvgTTFB))
-				} else {
-					lbl.SetText("-")
-				}
-			case 9:
-				// Quality indicator: ✓ for quality_good; ✗ if known and not good; - if unknown
-				if bs.SampleCount > 0 {
-					if bs.QualityGood {
-						lbl.SetText("✓")
-					} else {
-						lbl.SetText("✗")
-					}
-				} else {
-					lbl.SetText("-")
-				}
-			}
+			lbl.SetText(cols[id.Col].cell(state, rows[rix]))
 		},
 	)
-	// initial column widths
-	state.table.SetColumnWidth(0, 220)
-	state.table.SetColumnWidth(1, 70)
-	state.table.SetColumnWidth(2, 130)
-	state.table.SetColumnWidth(3, 100)
-	state.table.SetColumnWidth(4, 70)
-	state.table.SetColumnWidth(5, 120)
-	state.table.SetColumnWidth(6, 110)
-	state.table.SetColumnWidth(7, 120)
-	state.table.SetColumnWidth(8, 110)
-	state.table.SetColumnWidth(9, 60)
 
 	// Responsive table column sizing via pure helper
 	applyResponsiveTable := func() {
-		cols := helpers.ComputeTableColumnWidths(w.Canvas().Size().Width)
-		for i, width := range cols {
-			state.table.SetColumnWidth(i, float32(width))
-		}
-		state.table.Refresh()
+		applyBatchTableColumns(state, w.Canvas().Size().Width)
 	}
 	// Initial application
 	applyResponsiveTable()
@@ -1946,7 +2644,7 @@ func main() {
 					applyResponsiveTable()
 					if widthChanged && cur.Width != lastRedrawW {
 						lastRedrawW = cur.Width
-						redrawCharts(state)
+						scheduleRedraw(state)
 					}
 				})
 			}
@@ -1956,10 +2654,12 @@ func main() {
 	// open diagnostics details on row selection (single-click for now)
 	state.table.OnSelected = func(id widget.TableCellID) {
 		if id.Row == 0 {
+			toggleBatchSort(state, id.Col)
+			state.table.UnselectAll()
 			return
 		}
 		rows := filteredSummaries(state)
-		rix := id.Row - 1
+		rix := batchRowIndex(state, id.Row)
 		if rix < 0 || rix >= len(rows) {
 			return
 		}
@@ -2021,12 +2721,26 @@ func main() {
 	}
 	state.findEntry.OnSubmitted = func(string) { findNext(state) }
 
+	state.runBatchBtn = widget.NewButton("Run batch now", func() { runBatchNow(state, fileLabel) })
+	state.dashboardSelect = widget.NewSelect(nil, func(name string) {
+		if name != "" {
+			applyDashboard(state, fileLabel, name)
+		}
+	})
+	state.runBatchBtn.Hide()
 	top := container.NewHBox(
 		widget.NewButton("Open…", func() { openFileDialog(state, fileLabel) }),
 		widget.NewButton("Reload", func() { loadAll(state, fileLabel) }),
+		state.runBatchBtn,
 		// (X-Axis and Y-Scale moved to Settings menu)
 		// (SLA, Low-Speed Threshold, Rolling Window moved to Settings menu)
 		widget.NewLabel("Situation:"), sitSelect,
+		widget.NewLabel("Dashboard:"), state.dashboardSelect,
+		state.agentRow,
+		state.vpnRow,
+		state.bindRow,
+		state.tagRow,
+		state.groupRow,
 		// (Batches moved to Settings menu)
 		overallChk, ipv4Chk, ipv6Chk,
 		layout.NewSpacer(),
@@ -2094,6 +2808,10 @@ func main() {
 	state.jitterImgCanvas.FillMode = canvas.ImageFillStretch
 	state.jitterImgCanvas.SetMinSize(fyne.NewSize(0, float32(ih)))
 	state.jitterOverlay = newCrosshairOverlay(state, "jitter")
+	state.pingJitterImgCanvas = canvas.NewImageFromImage(image.NewRGBA(image.Rect(0, 0, 100, 60)))
+	state.pingJitterImgCanvas.FillMode = canvas.ImageFillStretch
+	state.pingJitterImgCanvas.SetMinSize(fyne.NewSize(0, float32(ih)))
+	state.pingJitterOverlay = newCrosshairOverlay(state, "ping_jitter")
 	state.covImgCanvas = canvas.NewImageFromImage(image.NewRGBA(image.Rect(0, 0, 100, 60)))
 	state.covImgCanvas.FillMode = canvas.ImageFillStretch
 	state.covImgCanvas.SetMinSize(fyne.NewSize(0, float32(ih)))
@@ -2164,6 +2882,10 @@ func main() {
 	// transport/protocol overlays
 	state.protocolMixOverlay = newCrosshairOverlay(state, "protocol_mix")
 	state.protocolAvgSpeedOverlay = newCrosshairOverlay(state, "protocol_avg_speed")
+	state.protocolTTFBImgCanvas = canvas.NewImageFromImage(image.NewRGBA(image.Rect(0, 0, 100, 60)))
+	state.protocolTTFBImgCanvas.FillMode = canvas.ImageFillStretch
+	state.protocolTTFBImgCanvas.SetMinSize(fyne.NewSize(0, float32(ih)))
+	state.protocolTTFBOverlay = newCrosshairOverlay(state, "protocol_ttfb")
 	state.protocolStallRateOverlay = newCrosshairOverlay(state, "protocol_stall_rate")
 	state.protocolErrorRateOverlay = newCrosshairOverlay(state, "protocol_error_rate")
 	state.protocolErrorShareOverlay = newCrosshairOverlay(state, "protocol_error_share")
@@ -2177,6 +2899,79 @@ func main() {
 	state.tlsVersionMixOverlay = newCrosshairOverlay(state, "tls_version_mix")
 	state.alpnMixOverlay = newCrosshairOverlay(state, "alpn_mix")
 	state.chunkedRateOverlay = newCrosshairOverlay(state, "chunked_rate")
+	state.qualityScoreImgCanvas = canvas.NewImageFromImage(image.NewRGBA(image.Rect(0, 0, 100, 60)))
+	state.qualityScoreImgCanvas.FillMode = canvas.ImageFillStretch
+	state.qualityScoreImgCanvas.SetMinSize(fyne.NewSize(0, float32(ih)))
+	state.qualityScoreOverlay = newCrosshairOverlay(state, "quality_score")
+	state.planAttainmentImgCanvas = canvas.NewImageFromImage(image.NewRGBA(image.Rect(0, 0, 100, 60)))
+	state.planAttainmentImgCanvas.FillMode = canvas.ImageFillStretch
+	state.planAttainmentImgCanvas.SetMinSize(fyne.NewSize(0, float32(ih)))
+	state.planAttainmentOverlay = newCrosshairOverlay(state, "plan_attainment")
+	state.stabilityImgCanvas = canvas.NewImageFromImage(image.NewRGBA(image.Rect(0, 0, 100, 60)))
+	state.stabilityImgCanvas.FillMode = canvas.ImageFillStretch
+	state.stabilityImgCanvas.SetMinSize(fyne.NewSize(0, float32(ih)))
+	state.stabilityOverlay = newCrosshairOverlay(state, "throughput_stability")
+	state.ipv6ReadinessImgCanvas = canvas.NewImageFromImage(image.NewRGBA(image.Rect(0, 0, 100, 60)))
+	state.ipv6ReadinessImgCanvas.FillMode = canvas.ImageFillStretch
+	state.ipv6ReadinessImgCanvas.SetMinSize(fyne.NewSize(0, float32(ih)))
+	state.ipv6ReadinessOverlay = newCrosshairOverlay(state, "ipv6_readiness")
+	state.heLostImgCanvas = canvas.NewImageFromImage(image.NewRGBA(image.Rect(0, 0, 100, 60)))
+	state.heLostImgCanvas.FillMode = canvas.ImageFillStretch
+	state.heLostImgCanvas.SetMinSize(fyne.NewSize(0, float32(ih)))
+	state.heLostOverlay = newCrosshairOverlay(state, "happy_eyeballs_ipv6_lost")
+	state.udpBlockedImgCanvas = canvas.NewImageFromImage(image.NewRGBA(image.Rect(0, 0, 100, 60)))
+	state.udpBlockedImgCanvas.FillMode = canvas.ImageFillStretch
+	state.udpBlockedImgCanvas.SetMinSize(fyne.NewSize(0, float32(ih)))
+	state.udpBlockedOverlay = newCrosshairOverlay(state, "udp_blocked_rate")
+	state.returnHopsImgCanvas = canvas.NewImageFromImage(image.NewRGBA(image.Rect(0, 0, 100, 60)))
+	state.returnHopsImgCanvas.FillMode = canvas.ImageFillStretch
+	state.returnHopsImgCanvas.SetMinSize(fyne.NewSize(0, float32(ih)))
+	state.returnHopsOverlay = newCrosshairOverlay(state, "return_hops")
+	state.compressionImgCanvas = canvas.NewImageFromImage(image.NewRGBA(image.Rect(0, 0, 100, 60)))
+	state.compressionImgCanvas.FillMode = canvas.ImageFillStretch
+	state.compressionImgCanvas.SetMinSize(fyne.NewSize(0, float32(ih)))
+	state.compressionOverlay = newCrosshairOverlay(state, "compression_ratio")
+	state.serverTimingImgCanvas = canvas.NewImageFromImage(image.NewRGBA(image.Rect(0, 0, 100, 60)))
+	state.serverTimingImgCanvas.FillMode = canvas.ImageFillStretch
+	state.serverTimingImgCanvas.SetMinSize(fyne.NewSize(0, float32(ih)))
+	state.serverTimingOverlay = newCrosshairOverlay(state, "server_timing")
+	state.tcpRetransImgCanvas = canvas.NewImageFromImage(image.NewRGBA(image.Rect(0, 0, 100, 60)))
+	state.tcpRetransImgCanvas.FillMode = canvas.ImageFillStretch
+	state.tcpRetransImgCanvas.SetMinSize(fyne.NewSize(0, float32(ih)))
+	state.tcpRetransOverlay = newCrosshairOverlay(state, "tcp_retrans")
+	state.iperf3ImgCanvas = canvas.NewImageFromImage(image.NewRGBA(image.Rect(0, 0, 100, 60)))
+	state.iperf3ImgCanvas.FillMode = canvas.ImageFillStretch
+	state.iperf3ImgCanvas.SetMinSize(fyne.NewSize(0, float32(ih)))
+	state.iperf3Overlay = newCrosshairOverlay(state, "iperf3_capacity")
+	state.coldWarmTTFBImgCanvas = canvas.NewImageFromImage(image.NewRGBA(image.Rect(0, 0, 100, 60)))
+	state.coldWarmTTFBImgCanvas.FillMode = canvas.ImageFillStretch
+	state.coldWarmTTFBImgCanvas.SetMinSize(fyne.NewSize(0, float32(ih)))
+	state.coldWarmTTFBOverlay = newCrosshairOverlay(state, "cold_warm_ttfb")
+	state.contentCorruptionImgCanvas = canvas.NewImageFromImage(image.NewRGBA(image.Rect(0, 0, 100, 60)))
+	state.contentCorruptionImgCanvas.FillMode = canvas.ImageFillStretch
+	state.contentCorruptionImgCanvas.SetMinSize(fyne.NewSize(0, float32(ih)))
+	state.contentCorruptionOverlay = newCrosshairOverlay(state, "content_corruption_rate")
+	state.dataUsageImgCanvas = canvas.NewImageFromImage(image.NewRGBA(image.Rect(0, 0, 100, 60)))
+	state.dataUsageImgCanvas.FillMode = canvas.ImageFillStretch
+	state.dataUsageImgCanvas.SetMinSize(fyne.NewSize(0, float32(ih)))
+	state.dataUsageOverlay = newCrosshairOverlay(state, "data_usage")
+	state.stallTimelineImgCanvas = canvas.NewImageFromImage(image.NewRGBA(image.Rect(0, 0, 100, 60)))
+	state.stallTimelineImgCanvas.FillMode = canvas.ImageFillStretch
+	state.stallTimelineImgCanvas.SetMinSize(fyne.NewSize(0, float32(ih)))
+	state.speedHeatmapImgCanvas = canvas.NewImageFromImage(image.NewRGBA(image.Rect(0, 0, 100, 60)))
+	state.speedHeatmapImgCanvas.FillMode = canvas.ImageFillStretch
+	state.speedHeatmapImgCanvas.SetMinSize(fyne.NewSize(0, float32(ih)))
+	state.ttfbHeatmapImgCanvas = canvas.NewImageFromImage(image.NewRGBA(image.Rect(0, 0, 100, 60)))
+	state.ttfbHeatmapImgCanvas.FillMode = canvas.ImageFillStretch
+	state.ttfbHeatmapImgCanvas.SetMinSize(fyne.NewSize(0, float32(ih)))
+	state.wifiRSSIImgCanvas = canvas.NewImageFromImage(image.NewRGBA(image.Rect(0, 0, 100, 60)))
+	state.wifiRSSIImgCanvas.FillMode = canvas.ImageFillStretch
+	state.wifiRSSIImgCanvas.SetMinSize(fyne.NewSize(0, float32(ih)))
+	state.wifiRSSIOverlay = newCrosshairOverlay(state, "wifi_rssi")
+	state.wifiPHYImgCanvas = canvas.NewImageFromImage(image.NewRGBA(image.Rect(0, 0, 100, 60)))
+	state.wifiPHYImgCanvas.FillMode = canvas.ImageFillStretch
+	state.wifiPHYImgCanvas.SetMinSize(fyne.NewSize(0, float32(ih)))
+	state.wifiPHYOverlay = newCrosshairOverlay(state, "wifi_phy_rate")
 
 	// Self-test chart placeholder
 	state.selfTestImgCanvas = canvas.NewImageFromImage(image.NewRGBA(image.Rect(0, 0, 100, 60)))
@@ -2322,6 +3117,12 @@ Additional research: BBR congestion control — ACM Queue (2016): https://queue.
 	- Expect P99 ≥ P95 ≥ P90 ≥ P50 by definition; bigger gaps mean heavier tail latency (spikes/outliers).
 	- Investigate large P99 when the average looks fine; tail latency hurts user experience and systems throughput.
 	References: https://en.wikipedia.org/wiki/Percentile , https://research.google/pubs/pub40801/` + axesTip
+	helpSpeedHeatmap := `Speed Heatmap: one column per local calendar day, one row per hour of day (top = 00:00). Cell color is the median of the batch median speeds that started in that hour, green = fastest cell, red = slowest; grey cells had no batch.
+	- Evening congestion shows up as a red band across the same rows on most days; a red column is a bad day (outage, maintenance).
+	- The caption names the hour with the lowest median across all days. Best read with an hourly or more frequent schedule over a week or more.`
+	helpTTFBHeatmap := `TTFB Heatmap: the day × hour calendar of the Speed Heatmap with the batch P95 TTFB (ms) as cell value, green = lowest, red = highest.
+	- Latency that climbs in the same hours every day points at loaded peering or a busy access network rather than the targets.
+	- The caption names the hour with the highest median P95 across all days.`
 	helpSpeedPct := `Percentiles of throughput (per batch): P50 (median), P90, P95, P99 in the selected speed unit.
 	- Shows distribution and variability of achieved speed beyond the average.
 	- Use alongside Avg Speed to spot unstable networks (wide gaps between P50 and P95/P99).
@@ -2329,8 +3130,12 @@ Additional research: BBR congestion control — ACM Queue (2016): https://queue.
 	helpErr := `Error Rate per batch (Overall/IPv4/IPv6) as a percentage of lines with errors (TCP/HTTP failures).
 - Sustained increases correlate with reliability issues or upstream/network faults.` + axesTip + "\nReferences: https://developer.mozilla.org/en-US/docs/Web/HTTP/Status , https://en.wikipedia.org/wiki/List_of_HTTP_status_codes"
 	helpJitter := `Jitter (%): mean absolute relative variation between consecutive sampled speeds within a transfer.
-- Higher jitter means more erratic throughput (bursts, stalls), often due to contention or queueing.` + axesTip + "\nReferences: https://en.wikipedia.org/wiki/Jitter" +
+- Higher jitter means more erratic throughput (bursts, stalls), often due to contention or queueing.
+- This is throughput jitter; latency jitter (what VoIP and meetings feel) is the Ping Jitter chart.` + axesTip + "\nReferences: https://en.wikipedia.org/wiki/Jitter" +
 		"\nAdditional research: Bufferbloat — ACM Queue (2012): https://queue.acm.org/detail.cfm?id=2063196"
+	helpPingJitter := `Ping Jitter (ms): latency jitter of the ping probe sites ("probe": "ping"), the RFC 3550 interarrival jitter of each line's consecutive connect RTTs (J += (|D| − J)/16), averaged over the batch's ping lines; the dashed line is the P95 over those lines.
+- VoIP and video calls start to suffer above roughly 30 ms; steady RTTs give values near 0.
+- The estimator starts at 0 and settles after about 16 RTTs, so use --ping-count 20 or more for comparable values.` + axesTip + "\nReferences: RFC 3550 §6.4.1 https://www.rfc-editor.org/rfc/rfc3550#section-6.4.1"
 	helpCoV := `Coefficient of Variation (%): standard deviation / mean of speeds.
 - Another variability measure; higher values indicate less consistent throughput across samples.` + axesTip + "\nReferences: https://en.wikipedia.org/wiki/Coefficient_of_variation"
 	helpCache := `Cache Hit Rate (%): fraction of requests likely served from intermediary caches (heuristics).
@@ -2372,7 +3177,7 @@ Computation: sample-based using intra-transfer speed samples and the selected th
 	helpPartialBody := `Partial Body Rate (%): fraction of requests that finished with an incomplete body (Content-Length mismatch or early EOF).
 - Helpful to spot flaky networks, proxies, or servers that terminate transfers prematurely.` + axesTip + "\nReferences: https://www.rfc-editor.org/rfc/rfc9112 , https://en.wikipedia.org/wiki/Chunked_transfer_encoding"
 	// Micro-stalls help
-	helpMicroStallRate := `Transient Stall Rate (%): share of lines with ≥1 short stall (≥500 ms by default) while transfer continued.
+	helpMicroStallRate := `Transient Stall Rate (%): share of lines with ≥1 short stall (≥ the Transient Stall Gap, 500 ms by default; Settings → Thresholds) while transfer continued.
 - Derived offline from intra-transfer speed samples. Not the same as hard stall-timeout aborts.` + axesTip + "\nReferences: https://www.rfc-editor.org/rfc/rfc6298 , https://en.wikipedia.org/wiki/Bufferbloat" +
 		"\nAdditional research: CoDel — Controlling Queue Delay — ACM Queue (2012): https://queue.acm.org/detail.cfm?id=2209336"
 	helpMicroStallTime := `Avg Transient Stall Time (ms): average total duration of micro-stalls per line (among lines with any micro-stall).` + axesTip + "\nReferences: https://www.rfc-editor.org/rfc/rfc6298" +
@@ -2384,6 +3189,7 @@ Computation: sample-based using intra-transfer speed samples and the selected th
 	helpDNS := `DNS Lookup Time (ms): average time to resolve the hostname.
  - Preferred source is httptrace (trace_dns_ms). When unavailable, legacy dns_time_ms is used.
  - Toggle Settings → "Overlay legacy DNS (dns_time_ms)" to overlay the legacy series (dashed) for comparison.
+ - Dotted "A lookup" / "AAAA lookup" series time the IPv4 and IPv6 record queries separately (dns_family); a slow or failing AAAA resolver shows up here long before it is visible in the overall average.
 - Elevated values can indicate resolver or network issues.` + axesTip + "\nReferences: https://www.rfc-editor.org/rfc/rfc1034 , https://www.rfc-editor.org/rfc/rfc1035" +
 		"\nAdditional research: CoDNS — Improving DNS Performance via Cooperative Lookups (NSDI 2004): https://www.usenix.org/legacy/events/nsdi04/tech/andersen/andersen_html/"
 	helpConn := `TCP Connect Time (ms): average time to establish the TCP connection (SYN→ACK and socket connect).
@@ -2437,13 +3243,18 @@ Set thresholds in Settings → SLA Thresholds (defaults: P50 ≥ 10,000 kbps; P9
 	if state != nil {
 		state.chartRefs = state.chartRefs[:0]
 	}
-	// Requested order: DNS, TCP Connect, TLS Handshake at the top, then the rest.
+	// Requested order: the Quality Score headline, then DNS, TCP Connect, TLS Handshake, then the rest.
 	helpPreTTFB := `Pre‑TTFB Stall Rate (%): fraction of requests canceled due to a pre‑TTFB stall (no first byte within stall timeout).\n- Requires monitor runs with --pre-ttfb-stall.\n- Useful to spot early server/network stalls before any response bytes.` + axesTip
 	// Build Pre‑TTFB section block separately so we can hide/show it dynamically
 	state.pretffbSection = makeChartSection(state, "Pre‑TTFB Stall Rate", helpPreTTFB, container.NewStack(state.pretffbImgCanvas, state.pretffbOverlay))
 	state.pretffbBlock = container.NewVBox(widget.NewSeparator(), state.pretffbSection)
 
 	chartsColumn := container.NewVBox(
+		makeChartSection(state, "Quality Score", "Quality Score (0–100): the network \"weather\" of a batch in one number. It is the weighted mean of five components, each 0–100: speed (median speed relative to a reference, 25 Mbps by default, capped at 100), TTFB (a 200 ms reference relative to the average TTFB), jitter, stalled transfers and failed lines (100 minus a penalty per percent). Default weights are speed 30, TTFB 25, jitter, stalls and errors 15 each; change them in Settings → Quality Score… or with the monitor's --quality-score. The crosshair lists the components; compare days with each other rather than reading the absolute value."+axesTip, container.NewStack(state.qualityScoreImgCanvas, state.qualityScoreOverlay)),
+		widget.NewSeparator(),
+		makeChartSection(state, "Plan Attainment (%)", "Plan Attainment (%): each batch's median speed as a percentage of the download rate you subscribed to (Settings → ISP Plan…). Dashed lines mark the plan (100%), 90% of it (the share regulators commonly treat as normally available) and the contractual minimum when entered. A single HTTP transfer may not fill a fast line, so use the monthly summary (File → Plan Attainment Report…) to document a persistent shortfall rather than single batches."+axesTip, container.NewStack(state.planAttainmentImgCanvas, state.planAttainmentOverlay)),
+		makeChartSection(state, "Throughput Stability (%)", "Throughput Stability (%): how consistent each batch's transfer speeds were, in the terms regulators use. P10 / median is the speed 90% of transfers reached (BEREC's \"normally available\" speed), P20 / median the FCC Measuring Broadband America \"80/80\" consistent speed, both as a percentage of the batch median; Within ±20% is the share of transfers close to the median. The index averages P10 / median and Within ±20%: 100 means every transfer ran at the median speed. Batches with fewer than 5 successful transfers are left out."+axesTip, container.NewStack(state.stabilityImgCanvas, state.stabilityOverlay)),
+		widget.NewSeparator(),
 		makeChartSection(state, "DNS Lookup Time (ms)", helpDNS, container.NewStack(state.setupDNSImgCanvas, state.setupDNSOverlay)),
 		widget.NewSeparator(),
 		makeChartSection(state, "TCP Connect Time (ms)", helpConn, container.NewStack(state.setupConnImgCanvas, state.setupConnOverlay)),
@@ -2456,6 +3267,8 @@ Set thresholds in Settings → SLA Thresholds (defaults: P50 ≥ 10,000 kbps; P9
 		widget.NewSeparator(),
 		makeChartSection(state, "Avg Speed by HTTP Protocol", "Average speed per HTTP protocol. Helps compare protocol performance.\nReferences: https://www.rfc-editor.org/rfc/rfc9110\nAdditional research: QUIC — Design and Internet-scale Deployment (SIGCOMM 2017): https://research.google/pubs/pub43884/"+axesTip, container.NewStack(state.protocolAvgSpeedImgCanvas, state.protocolAvgSpeedOverlay)),
 		widget.NewSeparator(),
+		makeChartSection(state, "TTFB by HTTP Protocol", "Average time to first byte per negotiated HTTP protocol (solid) with the P95 per protocol (dashed). Compares first-byte latency of h2 vs HTTP/1.1 on your path; a protocol with a much higher P95 points at head-of-line blocking, a different server pool or proxy handling. Hover shows avg, P50 and P95.\nReferences: https://www.rfc-editor.org/rfc/rfc9113"+axesTip, container.NewStack(state.protocolTTFBImgCanvas, state.protocolTTFBOverlay)),
+		widget.NewSeparator(),
 		makeChartSection(state, "Stall Rate by HTTP Protocol (%)", "Per‑protocol stall prevalence: for each HTTP protocol, the fraction of that protocol's requests that stalled. Note: These values do not add up to 100% because each bar is normalized by its own protocol's volume, not across protocols. See 'Stall Share by HTTP Protocol' for a breakdown that typically sums to ~100%.\nReferences: https://www.rfc-editor.org/rfc/rfc9110\nAdditional research: A QUIC look at HTTP/3 performance (IMC 2020): https://dl.acm.org/doi/10.1145/3419394.3423639"+axesTip, container.NewStack(state.protocolStallRateImgCanvas, state.protocolStallRateOverlay)),
 		makeChartSection(state, "Stall Share by HTTP Protocol (%)", "Share of total stalled requests by protocol. Bars typically sum to about 100% (across protocols with stalls). Complements ‘Stall Rate by HTTP Protocol’, which normalizes by each protocol’s request volume and therefore does not sum to 100%.\nReferences: https://www.rfc-editor.org/rfc/rfc9110\nAdditional research: A QUIC look at HTTP/3 performance (IMC 2020): https://dl.acm.org/doi/10.1145/3419394.3423639"+axesTip, container.NewStack(state.protocolStallShareImgCanvas, state.protocolStallShareOverlay)),
 		widget.NewSeparator(),
@@ -2464,7 +3277,7 @@ Set thresholds in Settings → SLA Thresholds (defaults: P50 ≥ 10,000 kbps; P9
 		widget.NewSeparator(),
 		makeChartSection(state, "Error Rate by HTTP Protocol (%)", "Per‑protocol error prevalence: for each HTTP protocol, the fraction of that protocol’s requests that errored.\n\nNote: These values do not add up to 100% because each bar is normalized by its own protocol’s volume, not the total errors across all protocols. Missing percentage is therefore expected. (Unknown protocol is counted as ‘(unknown)’ if present).\nReferences: https://developer.mozilla.org/en-US/docs/Web/HTTP/Status"+axesTip, container.NewStack(state.protocolErrorRateImgCanvas, state.protocolErrorRateOverlay)),
 		makeChartSection(state, "Error Share by HTTP Protocol (%)", "Share of total errors attributed to each HTTP protocol. Bars typically sum to about 100% (across protocols with errors). This complements ‘Error Rate by HTTP Protocol’, which normalizes by each protocol’s request volume and therefore does not sum to 100%.\nReferences: https://developer.mozilla.org/en-US/docs/Web/HTTP/Status"+axesTip, container.NewStack(state.protocolErrorShareImgCanvas, state.protocolErrorShareOverlay)),
-		makeChartSection(state, "Error Types (%)", "Share of total errors by error type (DNS, TCP, TLS, HEAD, HTTP, Range). Stacks typically sum to about 100% per batch.", container.NewStack(state.errorTypesImgCanvas, state.errorTypesOverlay)),
+		makeChartSection(state, "Error Types (%)", "Share of total errors by error type (DNS, TCP, TLS, HEAD, HTTP, Rate limit, Range). Stacks typically sum to about 100% per batch.\n- RATE LIMIT: the measured request got 429 Too Many Requests (or 503 with Retry-After). That is the server throttling this client, not a network fault; hover shows the rate-limited lines and the average Retry-After.", container.NewStack(state.errorTypesImgCanvas, state.errorTypesOverlay)),
 		makeChartSection(state, "Error Reasons (%)", "Share of total errors by normalized reason (e.g., timeout, conn_refused, conn_reset, tls_cert, stall_pre_ttfb, stall_abort, http_4xx, http_5xx, partial_body, dns_failure). Stacks typically sum to about 100% per batch.", container.NewStack(state.errorReasonsImgCanvas, state.errorReasonsOverlay)),
 		makeChartSection(state, "Error Reasons (detailed) (%)", "Share of total errors by detailed reason (e.g., http_404, http_503, tls_cert_expired, tls_cert_untrusted, timeout_connect, timeout_ttfb, timeout_read, conn_reset, dns_no_such_host, other_…). Stacks typically sum to about 100% per batch.", container.NewStack(state.errorReasonsDetailedImgCanvas, state.errorReasonsDetailedOverlay)),
 		makeChartSection(state, "Errors by URL (Top 12)", "Top URLs by error count in the selected batch (pick a row in the table). Helps identify problematic endpoints quickly.", container.NewStack(state.errorsByURLImgCanvas)),
@@ -2474,13 +3287,19 @@ Set thresholds in Settings → SLA Thresholds (defaults: P50 ≥ 10,000 kbps; P9
 		makeChartSection(state, "ALPN Mix (%)", "Share of requests by negotiated ALPN (e.g., h2, http/1.1). Bars typically sum to about 100% across ALPN values per batch (including '(unknown)' when present).\nReferences: https://www.iana.org/assignments/tls-extensiontype-values/tls-extensiontype-values.xhtml#alpn-protocol-ids"+axesTip, container.NewStack(state.alpnMixImgCanvas, state.alpnMixOverlay)),
 		widget.NewSeparator(),
 		makeChartSection(state, "Chunked Transfer Rate (%)", "Percentage of responses using chunked transfer encoding.\nReferences: https://www.rfc-editor.org/rfc/rfc9112"+axesTip, container.NewStack(state.chunkedRateImgCanvas, state.chunkedRateOverlay)),
+		makeChartSection(state, "Compression Ratio", "Response bodies decoded over the bytes transferred (the monitor offers --accept-encoding, gzip and deflate by default, and decodes the body itself). Effective is all decoded over all transferred bytes per batch, uncompressed bodies included; Compressed lines is the average ratio of the gzip/deflate bodies. Red dots mark batches in which text, JSON, JavaScript or similar bodies of 1 KB or more arrived without Content-Encoding: a proxy that strips compression, or an origin without it. The same content then costs the ratio times the bytes to transfer. Brotli or zstd bodies are counted but cannot be decoded, so they stay out of the ratios."+axesTip, container.NewStack(state.compressionImgCanvas, state.compressionOverlay)),
 		widget.NewSeparator(),
 		makeChartSection(state, "Speed – Average", helpSpeed, container.NewStack(state.speedImgCanvas, state.speedOverlay)),
 		makeChartSection(state, "Speed – Median", "Median throughput per batch (Overall/IPv4/IPv6). Pair with IQR band to gauge variability."+axesTip, container.NewStack(state.speedMedianImgCanvas, state.speedMedianOverlay)),
 		makeChartSection(state, "Speed – Min/Max", "Batch minima and maxima for throughput. Useful for spotting outliers; typically noisier."+axesTip, container.NewStack(state.speedMinMaxImgCanvas, state.speedMinMaxOverlay)),
+		makeChartSection(state, "iPerf3 Capacity vs HTTP Speed", "Raw capacity from the iperf3 probe sites (\"probe\": \"iperf3\", the system iperf3 client against a server you run or choose): the average TCP rate received per batch and, with --iperf3-udp-bitrate, the UDP rate, next to the batch's HTTP average speed. HTTP close to the TCP capacity means the path is the bottleneck; HTTP far below it points at TLS, proxies, the HTTP stack or the origins. A UDP rate below its offered rate, or UDP loss, with TCP fine hints at UDP shaping. The crosshair adds the retransmits, UDP jitter and loss and HTTP as a share of the capacity. Batches without iperf3 lines are left out.\nReferences: https://software.es.net/iperf/"+axesTip, container.NewStack(state.iperf3ImgCanvas, state.iperf3Overlay)),
 		widget.NewSeparator(),
 		makeChartSection(state, "Local Throughput Self-Test", "Local loopback throughput measured on startup. Useful as a device + OS baseline to compare against network speeds."+axesTip, container.NewStack(state.selfTestImgCanvas, state.selfTestOverlay)),
 		widget.NewSeparator(),
+		makeChartSection(state, "Wi‑Fi RSSI vs Throughput", "Average Wi‑Fi signal strength (RSSI, dBm) per batch from meta.wifi (airport/iw/netsh), with average throughput on the right axis. Throughput dips that follow RSSI point at the radio link rather than the ISP."+axesTip, container.NewStack(state.wifiRSSIImgCanvas, state.wifiRSSIOverlay)),
+		widget.NewSeparator(),
+		makeChartSection(state, "Wi‑Fi PHY Rate vs Throughput", "Average negotiated Wi‑Fi PHY (link) rate per batch from meta.wifi, with average throughput on the right axis. Throughput close to the PHY rate means the radio link is the ceiling."+axesTip, container.NewStack(state.wifiPHYImgCanvas, state.wifiPHYOverlay)),
+		widget.NewSeparator(),
 		// Place Speed Percentiles directly under Avg Speed
 		makeChartSection(state, "Speed Percentiles", helpSpeedPct, speedPctlGrid),
 		widget.NewSeparator(),
@@ -2490,6 +3309,9 @@ Set thresholds in Settings → SLA Thresholds (defaults: P50 ≥ 10,000 kbps; P9
 		widget.NewSeparator(),
 		makeChartSection(state, "TTFB Percentiles", helpTTFBPct, ttfbPctlGrid),
 		widget.NewSeparator(),
+		makeChartSection(state, "Speed Heatmap (day × hour)", helpSpeedHeatmap, container.NewStack(state.speedHeatmapImgCanvas)),
+		makeChartSection(state, "TTFB Heatmap (day × hour)", helpTTFBHeatmap, container.NewStack(state.ttfbHeatmapImgCanvas)),
+		widget.NewSeparator(),
 		makeChartSection(state, "Tail Heaviness (P99/P50 Speed)", helpTail, container.NewStack(state.tailRatioImgCanvas, state.tailRatioOverlay)),
 		widget.NewSeparator(),
 		makeChartSection(state, "TTFB Tail Heaviness (P95/P50)", helpTTFBTail, container.NewStack(state.ttfbTailRatioImgCanvas, state.ttfbTailRatioOverlay)),
@@ -2502,6 +3324,18 @@ Set thresholds in Settings → SLA Thresholds (defaults: P50 ≥ 10,000 kbps; P9
 		widget.NewSeparator(),
 		makeChartSection(state, "Family Delta – TTFB % (IPv6 vs IPv4)", helpDeltaPct, container.NewStack(state.ttfbDeltaPctImgCanvas, state.ttfbDeltaPctOverlay)),
 		widget.NewSeparator(),
+		makeChartSection(state, "IPv6 Readiness Score", "IPv6 Readiness Score (0–100): one number per batch for how usable IPv6 is from this network. It weighs the share of targets publishing AAAA records (25%), the share of IPv6 requests without errors (30%), IPv6 speed and TTFB relative to IPv4 (15% each, capped when IPv6 is faster) and, with --quic-probe, UDP reachability over IPv6 (15%). Components without data are left out and the rest re-weighted; the crosshair lists them. 100 means IPv6 works as well as IPv4; an IPv4-only network scores at most the AAAA share."+axesTip, container.NewStack(state.ipv6ReadinessImgCanvas, state.ipv6ReadinessOverlay)),
+		widget.NewSeparator(),
+		makeChartSection(state, "Happy Eyeballs – IPv6 Lost Races (%)", "Share of dual-stack happy-eyeballs races (IPv6 first, IPv4 after the fallback delay) where IPv6 was attempted but IPv4 connected first. Rising values point at a slow or broken IPv6 path that browsers mask by falling back; it often explains odd IPv6 vs IPv4 deltas.\nReferences: https://www.rfc-editor.org/rfc/rfc8305", container.NewStack(state.heLostImgCanvas, state.heLostOverlay)),
+		widget.NewSeparator(),
+		makeChartSection(state, "UDP Blocked Rate (%)", "Share of QUIC/UDP reachability probes (--quic-probe) that got no reply: a QUIC packet with an unknown version is sent to UDP/443 of each target IP and every QUIC server must answer with Version Negotiation. No answer after all attempts means UDP is dropped on the path (common on corporate networks and some guest Wi-Fi), so HTTP/3 cannot work there even though TCP-based HTTP does. Hover shows how many blocked probes hit sites that advertise h3 via Alt-Svc.\nReferences: https://www.rfc-editor.org/rfc/rfc9000#section-6", container.NewStack(state.udpBlockedImgCanvas, state.udpBlockedOverlay)),
+		widget.NewSeparator(),
+		makeChartSection(state, "Estimated Hop Count", "Routers on the way back from the targets, estimated from the TTL of one echo reply per line (--response-ttl): servers send with an initial TTL of 64, 128 or 255, and every router lowers it by one. Lines are averaged per family; red dots mark batches in which a target's hop count moved by 2 or more since its previous batch, a route change on the return path (the crosshair names the targets). With --route-trace the return count is also compared with the traced forward path: a difference of 3 or more counts as asymmetric routing. Firewalls that drop ICMP leave a target out."+axesTip, container.NewStack(state.returnHopsImgCanvas, state.returnHopsOverlay)),
+		widget.NewSeparator(),
+		makeChartSection(state, "Cold vs Warm Connection TTFB (ms)", "Per batch, the monitor's reuse experiment (--reuse-experiment) times one small request on a brand-new connection (cold: TCP connect + TLS handshake + server time) and the same request on the warm connection left by the measurement (reused). Cold minus warm (dashed gray) is the pure connection setup cost on this path; warm pairs that did not actually reuse are excluded. Helps quantify what keep-alive and connection pooling save for short requests.\nReferences: https://www.rfc-editor.org/rfc/rfc9112#section-9.3"+axesTip, container.NewStack(state.coldWarmTTFBImgCanvas, state.coldWarmTTFBOverlay)),
+		makeChartSection(state, "TTFB: Network vs Server (ms)", "For targets whose origin sends Server-Timing response headers (W3C Server Timing), the average TTFB of those lines split into the processing time the server reports and the rest: network round trips, TLS and any proxy or queue in front of the server. The server time is the \"total\" metric when sent, otherwise the longest reported metric, capped at the line's TTFB. A high Network share puts slowness on the path; a high Server share on the backend. Targets without the header are left out, so the TTFB here can differ from TTFB – Average.\nReferences: https://www.w3.org/TR/server-timing/"+axesTip, container.NewStack(state.serverTimingImgCanvas, state.serverTimingOverlay)),
+		makeChartSection(state, "TCP Retransmissions (%)", "Kernel TCP counters of the measured connections (monitor --tcp-stats, TCP_INFO on Linux and macOS), read when each connection closes. Sent retransmitted: the share of the segments the client had to send again. Received out of order: the share of the received segments that arrived out of order, which on a download mostly means the server retransmitted a lost segment (Linux 5.4 or later; macOS estimates it from bytes). Loss makes TCP back off, so rising rates explain stalls, plateaus and low speed that the speed charts only show; the crosshair lists the RTT variation and how many stalled lines saw loss. Through a proxy the counters describe the path to the proxy. Batches without counters (other systems, older results) are left out.\nReferences: https://www.rfc-editor.org/rfc/rfc6298 https://www.rfc-editor.org/rfc/rfc5681"+axesTip, container.NewStack(state.tcpRetransImgCanvas, state.tcpRetransOverlay)),
+		widget.NewSeparator(),
 		makeChartSection(state, "SLA Compliance – Speed", helpSLA, container.NewStack(state.slaSpeedImgCanvas, state.slaSpeedOverlay)),
 		widget.NewSeparator(),
 		makeChartSection(state, "SLA Compliance – TTFB", helpSLA, container.NewStack(state.slaTTFBImgCanvas, state.slaTTFBOverlay)),
@@ -2516,6 +3350,8 @@ Set thresholds in Settings → SLA Thresholds (defaults: P50 ≥ 10,000 kbps; P9
 		widget.NewSeparator(),
 		makeChartSection(state, "Jitter", helpJitter, container.NewStack(state.jitterImgCanvas, state.jitterOverlay)),
 		widget.NewSeparator(),
+		makeChartSection(state, "Ping Jitter (ms)", helpPingJitter, container.NewStack(state.pingJitterImgCanvas, state.pingJitterOverlay)),
+		widget.NewSeparator(),
 		makeChartSection(state, "Coefficient of Variation", helpCoV, container.NewStack(state.covImgCanvas, state.covOverlay)),
 		widget.NewSeparator(),
 		// Stability & quality section
@@ -2529,12 +3365,18 @@ Set thresholds in Settings → SLA Thresholds (defaults: P50 ≥ 10,000 kbps; P9
 		widget.NewSeparator(),
 		makeChartSection(state, "Partial Body Rate", helpPartialBody, container.NewStack(state.partialBodyImgCanvas, state.partialBodyOverlay)),
 		widget.NewSeparator(),
+		makeChartSection(state, "Content Corruption Rate (%)", "Content Corruption Rate (%): share of complete response bodies whose SHA-256 differed from the digest configured for the site (\"sha256\" in the sites file). Only sites with a digest are checked, and truncated bodies count as Partial Body instead, so any value above 0% means content was altered in transit — typically an intercepting proxy, captive portal or ISP injecting or recompressing content.\nReferences: https://www.rfc-editor.org/rfc/rfc6234"+axesTip, container.NewStack(state.contentCorruptionImgCanvas, state.contentCorruptionOverlay)),
+		widget.NewSeparator(),
+		makeChartSection(state, "Data Usage (MB)", "Data Usage (MB): bytes the monitor moved on its HTTP connections per batch (download and upload, including headers and TLS overhead, from wire_rx_bytes/wire_tx_bytes), plus the running total of the day the batch ended in. On LTE or other metered links, set --monthly-budget in the monitor to reduce or skip batches before the plan's cap is reached; the budget and its action show in the crosshair."+axesTip, container.NewStack(state.dataUsageImgCanvas, state.dataUsageOverlay)),
+		widget.NewSeparator(),
 		makeChartSection(state, "Stalled Requests Count", helpStallCount, container.NewStack(state.stallCountImgCanvas, state.stallCountOverlay)),
 		widget.NewSeparator(),
 		makeChartSection(state, "Avg Stall Time", helpStallTime, container.NewStack(state.stallTimeImgCanvas, state.stallTimeOverlay)),
 		widget.NewSeparator(),
 		makeChartSection(state, "Avg Transient Stall Count", helpMicroStallCount, container.NewStack(state.microStallCountImgCanvas, state.microStallCountOverlay)),
 		widget.NewSeparator(),
+		makeChartSection(state, "Stall Timeline (position in transfer)", "Stall Timeline: one column per batch, one row per tenth of the transfer duration (top = first byte, bottom = end of transfer). Cell color is the share of that slice of transfer time spent in a transient stall (no byte progress for at least the micro-stall threshold), derived from the intra-transfer speed samples.\n- A hot top row means stalls right after the transfer starts (slow start, server warm-up, shaping kicking in); hot middle rows mean stalls during the transfer (congestion, Wi‑Fi roaming, buffer stalls).\n- The caption lists the events, how many started in the first tenth, and their average offset and duration.", container.NewStack(state.stallTimelineImgCanvas)),
+		widget.NewSeparator(),
 		makeChartSection(state, "Avg Transient Stall Time", helpMicroStallTime, container.NewStack(state.microStallTimeImgCanvas, state.microStallTimeOverlay)),
 		widget.NewSeparator(),
 		makeChartSection(state, "Cache Hit Rate", helpCache, container.NewStack(state.cacheImgCanvas, state.cacheOverlay)),
@@ -2552,6 +3394,8 @@ Set thresholds in Settings → SLA Thresholds (defaults: P50 ≥ 10,000 kbps; P9
 		widget.NewSeparator(),
 		makeChartSection(state, "Plateau Stable Rate", helpPlStable, container.NewStack(state.plStableImgCanvas, state.plStableOverlay)),
 	)
+	state.chartsColumn = chartsColumn
+	state.defaultChartOrder = currentChartOrder(state)
 	// Always show stacked percentiles
 	speedPctlGrid.Show()
 	ttfbPctlGrid.Show()
@@ -2559,6 +3403,9 @@ Set thresholds in Settings → SLA Thresholds (defaults: P50 ≥ 10,000 kbps; P9
 	// Remove wide minimums to allow shrinking the window freely
 	chartsScroll.SetMinSize(fyne.NewSize(0, 0))
 	state.chartsScroll = chartsScroll
+	state.summaryStrip = newSummaryStrip(state)
+	state.chartTOC = newChartTOC(state, fileLabel)
+	chartsScroll.OnScrolled = func(fyne.Position) { updateChartTOCPosition(state) }
 	// Build Detailed Batch Charts tab
 	// Selector: list available RunTags from filtered summaries
 	buildDetailedTab := func() *container.TabItem {
@@ -2722,10 +3569,11 @@ Set thresholds in Settings → SLA Thresholds (defaults: P50 ≥ 10,000 kbps; P9
 These charts drill into an individual batch (RunTag) with per-request time series and distribution detail:
 
 1. Percentiles: Latency/speed distribution percentiles across samples in the batch. Shows how typical vs worst/best measurements behave.
-2. Speed over Time: Per‑request instantaneous or sampled transfer speed (dots ~100 ms sampling). Thin colored series = individual HTTP sessions (capped by Detailed Max Series). Red vertical line = TTFB (Time To First Byte) for that session; it marks when the first response byte arrived. Orange translucent band = stall period (transfer stalled near end of session).
-3. Bytes over Time: Cumulative bytes transferred over time for each request.
-4. Top Sessions (Speed / Bytes): Small multiples of the heaviest sessions, helpful to compare different hosts/paths and protocols.
-5. Errors by URL: Aggregated error counts for URLs (optionally grouped by host) to spot failing endpoints.
+2. Speed Distribution: Histogram of the batch's speed samples on a log speed axis with the cumulative share (orange). Two humps mean the batch alternated between a fast and a slow path; dashed lines mark the detected modes.
+3. Speed over Time: Per‑request instantaneous or sampled transfer speed (dots ~100 ms sampling). Thin colored series = individual HTTP sessions (capped by Detailed Max Series). Red vertical line = TTFB (Time To First Byte) for that session; it marks when the first response byte arrived. Orange translucent band = stall period (transfer stalled near end of session).
+4. Bytes over Time: Cumulative bytes transferred over time for each request.
+5. Top Sessions (Speed / Bytes): Small multiples of the heaviest sessions, helpful to compare different hosts/paths and protocols.
+6. Errors by URL: Aggregated error counts for URLs (optionally grouped by host) to spot failing endpoints.
 
 Vertical TTFB Line
 The vertical red line spans the plot height to remain visible even when values are small, so it can appear to dip into the padding area (this is expected). You can disable/enable it via: Menu → Settings → Detailed Charts → "Show TTFB Markers".
@@ -2755,13 +3603,13 @@ Tips
 		// Simplified bar now includes info button
 		barInner := container.New(layout.NewHBoxLayout(), batchLbl, state.detailedSelect, infoBtn, layout.NewSpacer(), widget.NewLabel("Host:"), hostSelect, groupErrs, layout.NewSpacer(), compareBtn)
 		wrap := container.NewBorder(barInner, nil, nil, nil, container.NewVScroll(state.detailedChartsBox))
-		return container.NewTabItem("Detailed Batch Charts", wrap)
+		return container.NewTabItem(tr(viewerTabNames[2]), wrap)
 	}
 
 	// tabs: Batches | BatchAvg Charts | Detailed Batch Charts
 	tabs := container.NewAppTabs(
-		container.NewTabItem("Batches", state.table),
-		container.NewTabItem("BatchAvg Charts", chartsScroll),
+		container.NewTabItem(tr(viewerTabNames[0]), state.table),
+		container.NewTabItem(tr(viewerTabNames[1]), container.NewBorder(container.NewVBox(state.summaryStrip.box, state.chartTOC.header), newHoverStatusBar(state), state.chartTOC.box, nil, chartsScroll)),
 		buildDetailedTab(),
 	)
 	tabs.SetTabLocation(container.TabLocationTop)
@@ -2775,7 +3623,7 @@ Tips
 		// If user navigates to Detailed tab (index 2), ensure charts are rebuilt (in case data/filters changed while not visible)
 		if tabs.SelectedIndex() == 2 {
 			if state.firstDataLoadDone {
-				fmt.Println("[detailed] tab selected -> rebuilding charts")
+				logf(slog.LevelDebug, "detailed", "tab selected -> rebuilding charts")
 				if state.firstDataLoadDone {
 					scheduleDetailedRebuild(state)
 				} else {
@@ -2784,8 +3632,14 @@ Tips
 			}
 		}
 	}
+	// Warning banner under the toolbar; hidden unless the last load skipped lines
+	state.loadWarningLbl = widget.NewLabel("")
+	state.loadWarningLbl.Wrapping = fyne.TextWrapWord
+	state.loadWarningLbl.Importance = widget.WarningImportance
+	state.loadWarningRow = container.NewBorder(nil, nil, widget.NewIcon(theme.WarningIcon()), widget.NewButton("Dismiss", func() { state.loadWarningRow.Hide() }), state.loadWarningLbl)
+	state.loadWarningRow.Hide()
 	// Use the horizontally scrollable toolbar at the top
-	content := container.NewBorder(topScroll, nil, nil, nil, tabs)
+	content := container.NewBorder(container.NewVBox(topScroll, state.loadWarningRow), nil, nil, nil, tabs)
 	w.SetContent(newTinyWrapper(content))
 	// Initialize find matches now that chartRefs are registered
 	updateFindMatches(state)
@@ -2825,7 +3679,7 @@ Tips
 				state.app.Preferences().SetInt("mainWindowW", curW)
 				state.app.Preferences().SetInt("mainWindowH", curH)
 				if widthChanged {
-					redrawCharts(state)
+					scheduleRedraw(state)
 				}
 			}
 		}
@@ -2850,13 +3704,23 @@ Tips
 		state.showOverall = b
 		savePrefs(state)
 		updateColumnVisibility(state)
-		redrawCharts(state)
+		scheduleRedraw(state)
 	}
 
 	// (X-Axis and Y-Scale callbacks moved to Settings menu)
 	// (removed: pctlFamily/change and compare handlers)
-	ipv4Chk.OnChanged = func(b bool) { state.showIPv4 = b; savePrefs(state); updateColumnVisibility(state); redrawCharts(state) }
-	ipv6Chk.OnChanged = func(b bool) { state.showIPv6 = b; savePrefs(state); updateColumnVisibility(state); redrawCharts(state) }
+	ipv4Chk.OnChanged = func(b bool) {
+		state.showIPv4 = b
+		savePrefs(state)
+		updateColumnVisibility(state)
+		scheduleRedraw(state)
+	}
+	ipv6Chk.OnChanged = func(b bool) {
+		state.showIPv6 = b
+		savePrefs(state)
+		updateColumnVisibility(state)
+		scheduleRedraw(state)
+	}
 	// (crosshair toggle moved to Settings menu)
 
 	// (removed duplicate wiring block)
@@ -2931,6 +3795,10 @@ Tips
 		state.jitterOverlay.enabled = state.crosshairEnabled
 		state.jitterOverlay.Refresh()
 	}
+	if state.pingJitterOverlay != nil {
+		state.pingJitterOverlay.enabled = state.crosshairEnabled
+		state.pingJitterOverlay.Refresh()
+	}
 	if state.covOverlay != nil {
 		state.covOverlay.enabled = state.crosshairEnabled
 		state.covOverlay.Refresh()
@@ -2963,6 +3831,10 @@ Tips
 		state.protocolAvgSpeedOverlay.enabled = state.crosshairEnabled
 		state.protocolAvgSpeedOverlay.Refresh()
 	}
+	if state.protocolTTFBOverlay != nil {
+		state.protocolTTFBOverlay.enabled = state.crosshairEnabled
+		state.protocolTTFBOverlay.Refresh()
+	}
 	if state.protocolStallRateOverlay != nil {
 		state.protocolStallRateOverlay.enabled = state.crosshairEnabled
 		state.protocolStallRateOverlay.Refresh()
@@ -3003,6 +3875,70 @@ Tips
 		state.chunkedRateOverlay.enabled = state.crosshairEnabled
 		state.chunkedRateOverlay.Refresh()
 	}
+	if state.compressionOverlay != nil {
+		state.compressionOverlay.enabled = state.crosshairEnabled
+		state.compressionOverlay.Refresh()
+	}
+	if state.serverTimingOverlay != nil {
+		state.serverTimingOverlay.enabled = state.crosshairEnabled
+		state.serverTimingOverlay.Refresh()
+	}
+	if state.tcpRetransOverlay != nil {
+		state.tcpRetransOverlay.enabled = state.crosshairEnabled
+		state.tcpRetransOverlay.Refresh()
+	}
+	if state.iperf3Overlay != nil {
+		state.iperf3Overlay.enabled = state.crosshairEnabled
+		state.iperf3Overlay.Refresh()
+	}
+	if state.qualityScoreOverlay != nil {
+		state.qualityScoreOverlay.enabled = state.crosshairEnabled
+		state.qualityScoreOverlay.Refresh()
+	}
+	if state.planAttainmentOverlay != nil {
+		state.planAttainmentOverlay.enabled = state.crosshairEnabled
+		state.planAttainmentOverlay.Refresh()
+	}
+	if state.stabilityOverlay != nil {
+		state.stabilityOverlay.enabled = state.crosshairEnabled
+		state.stabilityOverlay.Refresh()
+	}
+	if state.ipv6ReadinessOverlay != nil {
+		state.ipv6ReadinessOverlay.enabled = state.crosshairEnabled
+		state.ipv6ReadinessOverlay.Refresh()
+	}
+	if state.heLostOverlay != nil {
+		state.heLostOverlay.enabled = state.crosshairEnabled
+		state.heLostOverlay.Refresh()
+	}
+	if state.udpBlockedOverlay != nil {
+		state.udpBlockedOverlay.enabled = state.crosshairEnabled
+		state.udpBlockedOverlay.Refresh()
+	}
+	if state.returnHopsOverlay != nil {
+		state.returnHopsOverlay.enabled = state.crosshairEnabled
+		state.returnHopsOverlay.Refresh()
+	}
+	if state.coldWarmTTFBOverlay != nil {
+		state.coldWarmTTFBOverlay.enabled = state.crosshairEnabled
+		state.coldWarmTTFBOverlay.Refresh()
+	}
+	if state.contentCorruptionOverlay != nil {
+		state.contentCorruptionOverlay.enabled = state.crosshairEnabled
+		state.contentCorruptionOverlay.Refresh()
+	}
+	if state.dataUsageOverlay != nil {
+		state.dataUsageOverlay.enabled = state.crosshairEnabled
+		state.dataUsageOverlay.Refresh()
+	}
+	if state.wifiRSSIOverlay != nil {
+		state.wifiRSSIOverlay.enabled = state.crosshairEnabled
+		state.wifiRSSIOverlay.Refresh()
+	}
+	if state.wifiPHYOverlay != nil {
+		state.wifiPHYOverlay.enabled = state.crosshairEnabled
+		state.wifiPHYOverlay.Refresh()
+	}
 	if state.tailRatioOverlay != nil {
 		state.tailRatioOverlay.enabled = state.crosshairEnabled
 		state.tailRatioOverlay.Refresh()
@@ -3063,8 +3999,24 @@ Tips
 		state.partialBodyOverlay.enabled = state.crosshairEnabled
 		state.partialBodyOverlay.Refresh()
 	}
-	// Always load data once at startup (will fallback to monitor_results.jsonl if available)
-	loadAll(state, fileLabel)
+	applyQualityScorePref(a.Preferences())
+	// Always load data once at startup (will fallback to monitor_results.jsonl if available).
+	// The Getting Started wizard opens once the file turns out to hold no batches.
+	loadAllThen(state, fileLabel, func() {
+		if onboardingNeeded(a.Preferences(), state) {
+			openOnboardingWizard(state, fileLabel)
+		}
+	})
+	loadAlertRules(state)
+	if a.Preferences().Bool("follow") {
+		setFollow(state, true, fileLabel)
+	}
+	state.monitor = loadMonitorSettings(a.Preferences())
+	state.ispPlan = loadISPPlan(a.Preferences())
+	startMonitorProbe(state)
+	if state.filePath == "" && onboardingNeeded(a.Preferences(), state) {
+		openOnboardingWizard(state, fileLabel)
+	}
 
 	// (removed: compare view initial toggle; percentiles always shown in stack now)
 
@@ -3133,9 +4085,11 @@ func buildMenus(state *uiState, fileLabel *widget.Label) {
 	// New: per-URL errors
 	exportErrorsByURL := fyne.NewMenuItem("Export Errors by URL…", func() { exportChartPNG(state, state.errorsByURLImgCanvas, "errors_by_url_chart.png") })
 	exportJitter := fyne.NewMenuItem("Export Jitter Chart…", func() { exportChartPNG(state, state.jitterImgCanvas, "jitter_chart.png") })
+	exportPingJitter := fyne.NewMenuItem("Export Ping Jitter Chart…", func() { exportChartPNG(state, state.pingJitterImgCanvas, "ping_jitter_chart.png") })
 	exportCoV := fyne.NewMenuItem("Export CoV Chart…", func() { exportChartPNG(state, state.covImgCanvas, "cov_chart.png") })
 	// Self-test export
 	exportSelfTest := fyne.NewMenuItem("Export Local Throughput Self-Test…", func() { exportChartPNG(state, state.selfTestImgCanvas, "local_throughput_selftest_chart.png") })
+	exportIPerf3 := fyne.NewMenuItem("Export iPerf3 Capacity vs HTTP Speed…", func() { exportChartPNG(state, state.iperf3ImgCanvas, "iperf3_capacity_chart.png") })
 	// Connection setup breakdown exports
 	exportDNS := fyne.NewMenuItem("Export DNS Lookup Time Chart…", func() { exportChartPNG(state, state.setupDNSImgCanvas, "dns_lookup_time_chart.png") })
 	exportConn := fyne.NewMenuItem("Export TCP Connect Time Chart…", func() { exportChartPNG(state, state.setupConnImgCanvas, "tcp_connect_time_chart.png") })
@@ -3143,6 +4097,7 @@ func buildMenus(state *uiState, fileLabel *widget.Label) {
 	// Transport/Protocol exports
 	exportProtocolMix := fyne.NewMenuItem("Export HTTP Protocol Mix…", func() { exportChartPNG(state, state.protocolMixImgCanvas, "http_protocol_mix_chart.png") })
 	exportProtocolAvgSpeed := fyne.NewMenuItem("Export Avg Speed by HTTP Protocol…", func() { exportChartPNG(state, state.protocolAvgSpeedImgCanvas, "avg_speed_by_http_protocol_chart.png") })
+	exportProtocolTTFB := fyne.NewMenuItem("Export TTFB by HTTP Protocol…", func() { exportChartPNG(state, state.protocolTTFBImgCanvas, "ttfb_by_http_protocol_chart.png") })
 	exportProtocolStallRate := fyne.NewMenuItem("Export Stall Rate by HTTP Protocol…", func() {
 		exportChartPNG(state, state.protocolStallRateImgCanvas, "stall_rate_by_http_protocol_chart.png")
 	})
@@ -3164,6 +4119,24 @@ func buildMenus(state *uiState, fileLabel *widget.Label) {
 	exportTLSMix := fyne.NewMenuItem("Export TLS Version Mix…", func() { exportChartPNG(state, state.tlsVersionMixImgCanvas, "tls_version_mix_chart.png") })
 	exportALPNMix := fyne.NewMenuItem("Export ALPN Mix…", func() { exportChartPNG(state, state.alpnMixImgCanvas, "alpn_mix_chart.png") })
 	exportChunkedRate := fyne.NewMenuItem("Export Chunked Transfer Rate…", func() { exportChartPNG(state, state.chunkedRateImgCanvas, "chunked_transfer_rate_chart.png") })
+	exportCompression := fyne.NewMenuItem("Export Compression Ratio…", func() { exportChartPNG(state, state.compressionImgCanvas, "compression_ratio_chart.png") })
+	exportServerTiming := fyne.NewMenuItem("Export TTFB: Network vs Server…", func() { exportChartPNG(state, state.serverTimingImgCanvas, "server_timing_chart.png") })
+	exportTCPRetrans := fyne.NewMenuItem("Export TCP Retransmissions…", func() { exportChartPNG(state, state.tcpRetransImgCanvas, "tcp_retrans_chart.png") })
+	exportQualityScore := fyne.NewMenuItem("Export Quality Score…", func() { exportChartPNG(state, state.qualityScoreImgCanvas, "quality_score_chart.png") })
+	exportPlanAttainment := fyne.NewMenuItem("Export Plan Attainment…", func() { exportChartPNG(state, state.planAttainmentImgCanvas, "plan_attainment_chart.png") })
+	exportStability := fyne.NewMenuItem("Export Throughput Stability…", func() { exportChartPNG(state, state.stabilityImgCanvas, "throughput_stability_chart.png") })
+	exportIPv6Readiness := fyne.NewMenuItem("Export IPv6 Readiness Score…", func() { exportChartPNG(state, state.ipv6ReadinessImgCanvas, "ipv6_readiness_chart.png") })
+	exportHeLost := fyne.NewMenuItem("Export Happy Eyeballs – IPv6 Lost Races…", func() { exportChartPNG(state, state.heLostImgCanvas, "happy_eyeballs_ipv6_lost_chart.png") })
+	exportUdpBlocked := fyne.NewMenuItem("Export UDP Blocked Rate…", func() { exportChartPNG(state, state.udpBlockedImgCanvas, "udp_blocked_rate_chart.png") })
+	exportReturnHops := fyne.NewMenuItem("Export Estimated Hop Count…", func() { exportChartPNG(state, state.returnHopsImgCanvas, "return_hops_chart.png") })
+	exportColdWarmTTFB := fyne.NewMenuItem("Export Cold vs Warm TTFB…", func() { exportChartPNG(state, state.coldWarmTTFBImgCanvas, "cold_warm_ttfb_chart.png") })
+	exportContentCorruption := fyne.NewMenuItem("Export Content Corruption Rate…", func() { exportChartPNG(state, state.contentCorruptionImgCanvas, "content_corruption_rate_chart.png") })
+	exportDataUsage := fyne.NewMenuItem("Export Data Usage…", func() { exportChartPNG(state, state.dataUsageImgCanvas, "data_usage_chart.png") })
+	exportSpeedHeatmap := fyne.NewMenuItem("Export Speed Heatmap (day × hour)…", func() { exportChartPNG(state, state.speedHeatmapImgCanvas, "speed_heatmap_chart.png") })
+	exportTTFBHeatmap := fyne.NewMenuItem("Export TTFB Heatmap (day × hour)…", func() { exportChartPNG(state, state.ttfbHeatmapImgCanvas, "ttfb_heatmap_chart.png") })
+	exportStallTimeline := fyne.NewMenuItem("Export Stall Timeline…", func() { exportChartPNG(state, state.stallTimelineImgCanvas, "stall_timeline_chart.png") })
+	exportWifiRSSI := fyne.NewMenuItem("Export Wi‑Fi RSSI vs Throughput…", func() { exportChartPNG(state, state.wifiRSSIImgCanvas, "wifi_rssi_vs_throughput_chart.png") })
+	exportWifiPHY := fyne.NewMenuItem("Export Wi‑Fi PHY Rate vs Throughput…", func() { exportChartPNG(state, state.wifiPHYImgCanvas, "wifi_phy_rate_vs_throughput_chart.png") })
 	// Setup Timings submenu (exports only; DNS legacy overlay toggle moved to Settings)
 	setupSub := fyne.NewMenu("Setup Timings",
 		exportDNS,
@@ -3176,6 +4149,7 @@ func buildMenus(state *uiState, fileLabel *widget.Label) {
 	transportSub := fyne.NewMenu("Transport",
 		exportProtocolMix,
 		exportProtocolAvgSpeed,
+		exportProtocolTTFB,
 		exportProtocolStallRate,
 		exportProtocolStallShare,
 		exportProtocolPartialRate,
@@ -3186,6 +4160,9 @@ func buildMenus(state *uiState, fileLabel *widget.Label) {
 		exportTLSMix,
 		exportALPNMix,
 		exportChunkedRate,
+		exportCompression,
+		exportServerTiming,
+		exportTCPRetrans,
 	)
 	transportSubItem := fyne.NewMenuItem("Transport", nil)
 	transportSubItem.ChildMenu = transportSub
@@ -3205,11 +4182,13 @@ func buildMenus(state *uiState, fileLabel *widget.Label) {
 	exportPlLongest := fyne.NewMenuItem("Export Longest Plateau Chart…", func() { exportChartPNG(state, state.plLongestImgCanvas, "plateau_longest_chart.png") })
 	exportPlStable := fyne.NewMenuItem("Export Plateau Stable Rate Chart…", func() { exportChartPNG(state, state.plStableImgCanvas, "plateau_stable_rate_chart.png") })
 	exportAll := fyne.NewMenuItem("Export All BatchAvg Charts (One Image)…", func() { exportAllChartsCombined(state) })
+	exportFolder := fyne.NewMenuItem("Export All Charts (Folder)…", func() { exportAllChartsToFolder(state) })
 	// Create logical submenus to reduce clutter
 	avgSub := fyne.NewMenu("Averages & Percentiles",
 		exportSpeedAvg,
 		exportSpeedMedian,
 		exportSpeedMinMax,
+		exportIPerf3,
 		exportPctlOverall,
 		exportPctlIPv4,
 		exportPctlIPv6,
@@ -3220,6 +4199,9 @@ func buildMenus(state *uiState, fileLabel *widget.Label) {
 		exportTPctlOverall,
 		exportTPctlIPv4,
 		exportTPctlIPv6,
+		fyne.NewMenuItemSeparator(),
+		exportSpeedHeatmap,
+		exportTTFBHeatmap,
 	)
 	avgSubItem := fyne.NewMenuItem("Averages & Percentiles", nil)
 	avgSubItem.ChildMenu = avgSub
@@ -3229,6 +4211,8 @@ func buildMenus(state *uiState, fileLabel *widget.Label) {
 		exportTTFBTailRatio,
 		exportTTFBGap,
 		exportSelfTest,
+		exportWifiRSSI,
+		exportWifiPHY,
 	)
 	diagSubItem := fyne.NewMenuItem("Diagnostics", nil)
 	diagSubItem.ChildMenu = diagSub
@@ -3238,6 +4222,11 @@ func buildMenus(state *uiState, fileLabel *widget.Label) {
 		exportTTFBDelta,
 		exportSpeedDeltaPct,
 		exportTTFBDeltaPct,
+		exportIPv6Readiness,
+		exportHeLost,
+		exportUdpBlocked,
+		exportReturnHops,
+		exportColdWarmTTFB,
 	)
 	deltasSubItem := fyne.NewMenuItem("Family Deltas", nil)
 	deltasSubItem.ChildMenu = deltasSub
@@ -3255,6 +4244,7 @@ func buildMenus(state *uiState, fileLabel *widget.Label) {
 		exportErrors,
 		exportErrorsByURL,
 		exportJitter,
+		exportPingJitter,
 		exportCoV,
 	)
 	errorsSubItem := fyne.NewMenuItem("Errors & Variability", nil)
@@ -3265,8 +4255,11 @@ func buildMenus(state *uiState, fileLabel *widget.Label) {
 		exportStallRate,
 		exportPreTTFB,
 		exportPartialBody,
+		exportContentCorruption,
+		exportDataUsage,
 		exportStallCount,
 		exportStallTime,
+		exportStallTimeline,
 		fyne.NewMenuItemSeparator(),
 		fyne.NewMenuItem("Export Transient Stall Rate…", func() { exportChartPNG(state, state.microStallRateImgCanvas, "transient_stall_rate_chart.png") }),
 		fyne.NewMenuItem("Export Avg Transient Stall Time…", func() { exportChartPNG(state, state.microStallTimeImgCanvas, "avg_transient_stall_time_chart.png") }),
@@ -3295,6 +4288,7 @@ func buildMenus(state *uiState, fileLabel *widget.Label) {
 	// Group all export submenus under a single "Export Charts" submenu
 	// Detailed chart export items (operate on currently selected batch in Detailed tab)
 	exportDetailedPctl := fyne.NewMenuItem("Export Detailed – Speed Percentiles…", func() { exportChartPNG(state, state.detailedPctlImgCanvas, "detailed_speed_percentiles.png") })
+	exportDetailedDist := fyne.NewMenuItem("Export Detailed – Speed Distribution…", func() { exportChartPNG(state, state.detailedDistImgCanvas, "detailed_speed_distribution.png") })
 	exportDetailedSpeed := fyne.NewMenuItem("Export Detailed – Speed over Time…", func() { exportChartPNG(state, state.detailedSpeedOverTimeImgCanvas, "detailed_speed_over_time.png") })
 	exportDetailedTop := fyne.NewMenuItem("Export Detailed – Top Sessions…", func() { exportChartPNG(state, state.detailedTopSessionsImgCanvas, "detailed_top_sessions.png") })
 	exportDetailedErrURL := fyne.NewMenuItem("Export Detailed – Errors by URL…", func() { exportChartPNG(state, state.detailedErrorsByURLImgCanvas, "detailed_errors_by_url.png") })
@@ -3305,6 +4299,9 @@ func buildMenus(state *uiState, fileLabel *widget.Label) {
 	exportAllDetailed := fyne.NewMenuItem("Export All Detailed (Selected Batch)…", func() { exportAllDetailedChartsCombined(state) })
 
 	exportChartsSub := fyne.NewMenu("Export Charts",
+		exportQualityScore,
+		exportPlanAttainment,
+		exportStability,
 		setupSubItem,
 		transportSubItem,
 		avgSubItem,
@@ -3317,8 +4314,10 @@ func buildMenus(state *uiState, fileLabel *widget.Label) {
 		platSubItem,
 		fyne.NewMenuItemSeparator(),
 		exportAll,
+		exportFolder,
 		fyne.NewMenuItemSeparator(),
 		exportDetailedPctl,
+		exportDetailedDist,
 		exportDetailedSpeed,
 		exportDetailedTop,
 		exportDetailedErrURL,
@@ -3330,11 +4329,39 @@ func buildMenus(state *uiState, fileLabel *widget.Label) {
 	exportChartsItem := fyne.NewMenuItem("Export Charts", nil)
 	exportChartsItem.ChildMenu = exportChartsSub
 
-	fileMenu := fyne.NewMenu("File",
+	followLabel := "Follow (auto-reload)"
+	if state.follow {
+		followLabel += " ✓"
+	}
+	trayLabel := "Menu Bar Status"
+	if state.trayStatus {
+		trayLabel += " ✓"
+	}
+	fileMenu := fyne.NewMenu("File",
 		fyne.NewMenuItem("Open…", func() { openFileDialog(state, fileLabel) }),
 		fyne.NewMenuItem("Reload", func() { loadAll(state, fileLabel) }),
+		fyne.NewMenuItem("Compare With…", func() { openCompareWith(state) }),
+		fyne.NewMenuItem("Getting Started…", func() { openOnboardingWizard(state, fileLabel) }),
+		fyne.NewMenuItem(followLabel, func() {
+			setFollow(state, !state.follow, fileLabel)
+			scheduleMenuRebuild(state, fileLabel)
+		}),
+		fyne.NewMenuItem("Mini Dashboard", func() { openMiniDashboard(state) }),
+		fyne.NewMenuItem(trayLabel, func() {
+			state.trayStatus = !state.trayStatus
+			savePrefs(state)
+			refreshMiniStatus(state)
+			scheduleMenuRebuild(state, fileLabel)
+		}),
 		fyne.NewMenuItemSeparator(),
 		exportChartsItem,
+		fyne.NewMenuItem("Export Anonymized Results…", func() { exportAnonymizedResults(state) }),
+		fyne.NewMenuItem("Prune Old Batches…", func() { pruneOldBatches(state, fileLabel) }),
+		fyne.NewMenuItem("Plan Attainment Report…", func() { openPlanReport(state) }),
+		fyne.NewMenuItem("SLA Compliance Report…", func() { openSLAMonthlyReport(state) }),
+		fyne.NewMenuItem("Target SLO Compliance…", func() { openTargetSLOReport(state) }),
+		fyne.NewMenuItemSeparator(),
+		fyne.NewMenuItem("Debug Console…", func() { openDebugConsole(state) }),
 		fyne.NewMenuItemSeparator(),
 		fyne.NewMenuItem("Quit", func() { state.window.Close() }),
 	)
@@ -3366,7 +4393,7 @@ func buildMenus(state *uiState, fileLabel *widget.Label) {
 		// Resolve current effective theme and apply
 		screenshotThemeGlobal = resolveTheme(screenshotThemeMode, state.app)
 		// Immediate redraw; prior sleep delay no longer needed
-		redrawCharts(state)
+		scheduleRedraw(state)
 		scheduleMenuRebuild(state, fileLabel)
 	})
 	darkItem := fyne.NewMenuItem(themeLabelFor("Dark"), func() {
@@ -3377,7 +4404,7 @@ func buildMenus(state *uiState, fileLabel *widget.Label) {
 		state.app.Preferences().SetString("screenshotThemeMode", screenshotThemeMode)
 		screenshotThemeGlobal = resolveTheme(screenshotThemeMode, state.app)
 		// Redraw charts to reflect watermark/hint/background contrast if applicable
-		redrawCharts(state)
+		scheduleRedraw(state)
 		scheduleMenuRebuild(state, fileLabel)
 	})
 	lightItem := fyne.NewMenuItem(themeLabelFor("Light"), func() {
@@ -3387,7 +4414,7 @@ func buildMenus(state *uiState, fileLabel *widget.Label) {
 		screenshotThemeMode = "light"
 		state.app.Preferences().SetString("screenshotThemeMode", screenshotThemeMode)
 		screenshotThemeGlobal = resolveTheme(screenshotThemeMode, state.app)
-		redrawCharts(state)
+		scheduleRedraw(state)
 		scheduleMenuRebuild(state, fileLabel)
 	})
 	// Crosshair toggle menu item
@@ -3430,6 +4457,10 @@ func buildMenus(state *uiState, fileLabel *widget.Label) {
 			state.jitterOverlay.enabled = b
 			state.jitterOverlay.Refresh()
 		}
+		if state.pingJitterOverlay != nil {
+			state.pingJitterOverlay.enabled = b
+			state.pingJitterOverlay.Refresh()
+		}
 		if state.covOverlay != nil {
 			state.covOverlay.enabled = b
 			state.covOverlay.Refresh()
@@ -3474,6 +4505,10 @@ func buildMenus(state *uiState, fileLabel *widget.Label) {
 			state.protocolAvgSpeedOverlay.enabled = b
 			state.protocolAvgSpeedOverlay.Refresh()
 		}
+		if state.protocolTTFBOverlay != nil {
+			state.protocolTTFBOverlay.enabled = b
+			state.protocolTTFBOverlay.Refresh()
+		}
 		if state.protocolStallRateOverlay != nil {
 			state.protocolStallRateOverlay.enabled = b
 			state.protocolStallRateOverlay.Refresh()
@@ -3498,6 +4533,70 @@ func buildMenus(state *uiState, fileLabel *widget.Label) {
 			state.chunkedRateOverlay.enabled = b
 			state.chunkedRateOverlay.Refresh()
 		}
+		if state.compressionOverlay != nil {
+			state.compressionOverlay.enabled = b
+			state.compressionOverlay.Refresh()
+		}
+		if state.serverTimingOverlay != nil {
+			state.serverTimingOverlay.enabled = b
+			state.serverTimingOverlay.Refresh()
+		}
+		if state.tcpRetransOverlay != nil {
+			state.tcpRetransOverlay.enabled = b
+			state.tcpRetransOverlay.Refresh()
+		}
+		if state.iperf3Overlay != nil {
+			state.iperf3Overlay.enabled = b
+			state.iperf3Overlay.Refresh()
+		}
+		if state.qualityScoreOverlay != nil {
+			state.qualityScoreOverlay.enabled = b
+			state.qualityScoreOverlay.Refresh()
+		}
+		if state.planAttainmentOverlay != nil {
+			state.planAttainmentOverlay.enabled = b
+			state.planAttainmentOverlay.Refresh()
+		}
+		if state.stabilityOverlay != nil {
+			state.stabilityOverlay.enabled = b
+			state.stabilityOverlay.Refresh()
+		}
+		if state.ipv6ReadinessOverlay != nil {
+			state.ipv6ReadinessOverlay.enabled = b
+			state.ipv6ReadinessOverlay.Refresh()
+		}
+		if state.heLostOverlay != nil {
+			state.heLostOverlay.enabled = b
+			state.heLostOverlay.Refresh()
+		}
+		if state.udpBlockedOverlay != nil {
+			state.udpBlockedOverlay.enabled = b
+			state.udpBlockedOverlay.Refresh()
+		}
+		if state.returnHopsOverlay != nil {
+			state.returnHopsOverlay.enabled = b
+			state.returnHopsOverlay.Refresh()
+		}
+		if state.coldWarmTTFBOverlay != nil {
+			state.coldWarmTTFBOverlay.enabled = b
+			state.coldWarmTTFBOverlay.Refresh()
+		}
+		if state.contentCorruptionOverlay != nil {
+			state.contentCorruptionOverlay.enabled = b
+			state.contentCorruptionOverlay.Refresh()
+		}
+		if state.dataUsageOverlay != nil {
+			state.dataUsageOverlay.enabled = b
+			state.dataUsageOverlay.Refresh()
+		}
+		if state.wifiRSSIOverlay != nil {
+			state.wifiRSSIOverlay.enabled = b
+			state.wifiRSSIOverlay.Refresh()
+		}
+		if state.wifiPHYOverlay != nil {
+			state.wifiPHYOverlay.enabled = b
+			state.wifiPHYOverlay.Refresh()
+		}
 		if state.setupDNSOverlay != nil {
 			state.setupDNSOverlay.enabled = b
 			state.setupDNSOverlay.Refresh()
@@ -3563,6 +4662,10 @@ func buildMenus(state *uiState, fileLabel *widget.Label) {
 			state.detailedPercentilesOverlay.enabled = b
 			state.detailedPercentilesOverlay.Refresh()
 		}
+		if state.detailedDistributionOverlay != nil {
+			state.detailedDistributionOverlay.enabled = b
+			state.detailedDistributionOverlay.Refresh()
+		}
 		if state.detailedSpeedOverTimeOverlay != nil {
 			state.detailedSpeedOverTimeOverlay.enabled = b
 			state.detailedSpeedOverTimeOverlay.Refresh()
@@ -3597,7 +4700,7 @@ func buildMenus(state *uiState, fileLabel *widget.Label) {
 		state.autoHidePreTTFB = !state.autoHidePreTTFB
 		savePrefs(state)
 		// Re-render to apply visibility based on current data
-		redrawCharts(state)
+		scheduleRedraw(state)
 		// Update menu label
 		scheduleMenuRebuild(state, fileLabel)
 	})
@@ -3612,7 +4715,7 @@ func buildMenus(state *uiState, fileLabel *widget.Label) {
 	hintsToggle := fyne.NewMenuItem(hintsLabel(), func() {
 		state.showHints = !state.showHints
 		savePrefs(state)
-		redrawCharts(state)
+		scheduleRedraw(state)
 		scheduleMenuRebuild(state, fileLabel)
 	})
 
@@ -3626,7 +4729,7 @@ func buildMenus(state *uiState, fileLabel *widget.Label) {
 	rollingToggle := fyne.NewMenuItem(rollingLabel(), func() {
 		state.showRolling = !state.showRolling
 		savePrefs(state)
-		redrawCharts(state)
+		scheduleRedraw(state)
 		scheduleMenuRebuild(state, fileLabel)
 	})
 	bandLabel := func() string {
@@ -3638,10 +4741,48 @@ func buildMenus(state *uiState, fileLabel *widget.Label) {
 	bandToggle := fyne.NewMenuItem(bandLabel(), func() {
 		state.showRollingBand = !state.showRollingBand
 		savePrefs(state)
-		redrawCharts(state)
+		scheduleRedraw(state)
 		scheduleMenuRebuild(state, fileLabel)
 	})
 
+	// Trend lines submenu: method radio items plus the forecast horizon
+	trendItem := func(method string) *fyne.MenuItem {
+		label := trendMethodLabel(method)
+		if chartTrend.Method == method {
+			label += " ✓"
+		}
+		return fyne.NewMenuItem(label, func() {
+			chartTrend.Method = method
+			savePrefs(state)
+			scheduleRedraw(state)
+			scheduleMenuRebuild(state, fileLabel)
+		})
+	}
+	openForecastDialog := func() {
+		entry := widget.NewEntry()
+		entry.SetText(strconv.Itoa(chartTrend.Horizon))
+		form := &widget.Form{Items: []*widget.FormItem{{Text: "Forecast batches (0 = trend only)", Widget: entry}}, OnSubmit: func() {
+			if iv, err := strconv.Atoi(strings.TrimSpace(entry.Text)); err == nil {
+				chartTrend.Horizon = min(max(iv, 0), 100)
+				savePrefs(state)
+				scheduleRedraw(state)
+			}
+		}}
+		d := dialog.NewCustomConfirm("Forecast Horizon", "Save", "Cancel", form, func(ok bool) {
+			if ok {
+				form.OnSubmit()
+			}
+		}, state.window)
+		d.Resize(fyne.NewSize(360, 160))
+		d.Show()
+	}
+	trendSubItem := fyne.NewMenuItem("Trend Lines – "+trendMethodLabel(chartTrend.Method), nil)
+	trendSubItem.ChildMenu = fyne.NewMenu("Trend Lines",
+		trendItem(""), trendItem(analysis.TrendLinear), trendItem(analysis.TrendLoess),
+		fyne.NewMenuItemSeparator(),
+		fyne.NewMenuItem(fmt.Sprintf("Forecast Horizon… (%d batches)", chartTrend.Horizon), func() { openForecastDialog() }),
+	)
+
 	// Quality filter toggle
 	qualityOnlyLabel := func() string {
 		if state.showOnlyQualityGood {
@@ -3656,7 +4797,7 @@ func buildMenus(state *uiState, fileLabel *widget.Label) {
 		if state.table != nil {
 			state.table.Refresh()
 		}
-		redrawCharts(state)
+		scheduleRedraw(state)
 		// Rebuild menus to update checkmark
 		scheduleMenuRebuild(state, fileLabel)
 	})
@@ -3672,14 +4813,7 @@ func buildMenus(state *uiState, fileLabel *widget.Label) {
 		state.showQualColumn = !state.showQualColumn
 		savePrefs(state)
 		// Apply column width
-		if state.table != nil {
-			if state.showQualColumn {
-				state.table.SetColumnWidth(9, 60)
-			} else {
-				state.table.SetColumnWidth(9, 0)
-			}
-			state.table.Refresh()
-		}
+		updateColumnVisibility(state)
 		// Rebuild menus to update label
 		scheduleMenuRebuild(state, fileLabel)
 	})
@@ -3718,31 +4852,31 @@ func buildMenus(state *uiState, fileLabel *widget.Label) {
 	avgToggle := fyne.NewMenuItem(avgLabel(), func() {
 		state.showAvg = !state.showAvg
 		savePrefs(state)
-		redrawCharts(state)
+		scheduleRedraw(state)
 		scheduleMenuRebuild(state, fileLabel)
 	})
 	medToggle := fyne.NewMenuItem(medLabel(), func() {
 		state.showMedian = !state.showMedian
 		savePrefs(state)
-		redrawCharts(state)
+		scheduleRedraw(state)
 		scheduleMenuRebuild(state, fileLabel)
 	})
 	minToggle := fyne.NewMenuItem(minLabel(), func() {
 		state.showMin = !state.showMin
 		savePrefs(state)
-		redrawCharts(state)
+		scheduleRedraw(state)
 		scheduleMenuRebuild(state, fileLabel)
 	})
 	maxToggle := fyne.NewMenuItem(maxLabel(), func() {
 		state.showMax = !state.showMax
 		savePrefs(state)
-		redrawCharts(state)
+		scheduleRedraw(state)
 		scheduleMenuRebuild(state, fileLabel)
 	})
 	iqrToggle := fyne.NewMenuItem(iqrLabel(), func() {
 		state.showIQR = !state.showIQR
 		savePrefs(state)
-		redrawCharts(state)
+		scheduleRedraw(state)
 		scheduleMenuRebuild(state, fileLabel)
 	})
 
@@ -3756,7 +4890,62 @@ func buildMenus(state *uiState, fileLabel *widget.Label) {
 	dnsToggle := fyne.NewMenuItem(dnsLabel(), func() {
 		state.showDNSLegacy = !state.showDNSLegacy
 		savePrefs(state)
-		redrawCharts(state)
+		scheduleRedraw(state)
+		scheduleMenuRebuild(state, fileLabel)
+	})
+
+	// Decimation for very long histories (keeps per-bucket min/max so spikes stay visible)
+	decimateLabel := func() string {
+		if state.decimateCharts {
+			return "Decimate long histories ✓"
+		}
+		return "Decimate long histories"
+	}
+	decimateToggle := fyne.NewMenuItem(decimateLabel(), func() {
+		state.decimateCharts = !state.decimateCharts
+		chartDecimationEnabled = state.decimateCharts
+		savePrefs(state)
+		scheduleRedraw(state)
+		scheduleMenuRebuild(state, fileLabel)
+	})
+
+	// One series per Situation on the headline charts (Situation filter "All")
+	overlayLabel := func() string {
+		if state.situationOverlay {
+			return "Overlay Situations ✓"
+		}
+		return "Overlay Situations"
+	}
+	overlayToggle := fyne.NewMenuItem(overlayLabel(), func() {
+		state.situationOverlay = !state.situationOverlay
+		savePrefs(state)
+		scheduleRedraw(state)
+		scheduleMenuRebuild(state, fileLabel)
+	})
+	// Same per source binding (monitor --interface/--source-ip; Interface filter "All")
+	ifaceOverlayLabel := func() string {
+		if state.interfaceOverlay {
+			return "Overlay Interfaces ✓"
+		}
+		return "Overlay Interfaces"
+	}
+	ifaceOverlayToggle := fyne.NewMenuItem(ifaceOverlayLabel(), func() {
+		state.interfaceOverlay = !state.interfaceOverlay
+		savePrefs(state)
+		scheduleRedraw(state)
+		scheduleMenuRebuild(state, fileLabel)
+	})
+	// Markers at batches whose monitor configuration changed (meta.config)
+	configMarkersLabel := func() string {
+		if state.showConfigMarkers {
+			return "Config Change Markers ✓"
+		}
+		return "Config Change Markers"
+	}
+	configMarkersToggle := fyne.NewMenuItem(configMarkersLabel(), func() {
+		state.showConfigMarkers = !state.showConfigMarkers
+		savePrefs(state)
+		scheduleRedraw(state)
 		scheduleMenuRebuild(state, fileLabel)
 	})
 
@@ -3764,6 +4953,34 @@ func buildMenus(state *uiState, fileLabel *widget.Label) {
 	themeSub := fyne.NewMenu("Screenshot Theme", autoItem, darkItem, lightItem)
 	themeSubItem := fyne.NewMenuItem("Screenshot Theme", nil)
 	themeSubItem.ChildMenu = themeSub
+	// App Theme submenu: the viewer's widgets, independent of the chart theme above
+	checked := func(lbl string, on bool) string {
+		if on {
+			return lbl + " ✓"
+		}
+		return lbl
+	}
+	var appThemeItems []*fyne.MenuItem
+	for _, m := range appThemeModes {
+		m := m
+		appThemeItems = append(appThemeItems, fyne.NewMenuItem(checked(strings.ToUpper(m[:1])+m[1:], viewerTheme.mode == m), func() {
+			setAppTheme(state, m, viewerTheme.accent)
+			scheduleMenuRebuild(state, fileLabel)
+		}))
+	}
+	appThemeItems = append(appThemeItems, fyne.NewMenuItemSeparator(), fyne.NewMenuItem(checked("Default Accent", viewerTheme.accent == ""), func() {
+		setAppTheme(state, viewerTheme.mode, "")
+		scheduleMenuRebuild(state, fileLabel)
+	}))
+	for _, ac := range appAccents {
+		name := ac.name
+		appThemeItems = append(appThemeItems, fyne.NewMenuItem(checked(strings.ToUpper(name[:1])+name[1:]+" Accent", viewerTheme.accent == name), func() {
+			setAppTheme(state, viewerTheme.mode, name)
+			scheduleMenuRebuild(state, fileLabel)
+		}))
+	}
+	appThemeSubItem := fyne.NewMenuItem("App Theme", nil)
+	appThemeSubItem.ChildMenu = fyne.NewMenu("App Theme", appThemeItems...)
 
 	// Speed Unit submenu under Settings
 	speedUnitLabelFor := func(u string) string {
@@ -3781,9 +4998,10 @@ func buildMenus(state *uiState, fileLabel *widget.Label) {
 		if state.table != nil {
 			state.table.Refresh()
 		}
-		redrawCharts(state)
+		scheduleRedraw(state)
 		scheduleMenuRebuild(state, fileLabel)
 	}
+	suAuto := fyne.NewMenuItem(speedUnitLabelFor("Auto"), func() { setSpeedUnit("Auto") })
 	suKbps := fyne.NewMenuItem(speedUnitLabelFor("kbps"), func() { setSpeedUnit("kbps") })
 	suKBps := fyne.NewMenuItem(speedUnitLabelFor("kBps"), func() { setSpeedUnit("kBps") })
 	suMbps := fyne.NewMenuItem(speedUnitLabelFor("Mbps"), func() { setSpeedUnit("Mbps") })
@@ -3791,11 +5009,53 @@ func buildMenus(state *uiState, fileLabel *widget.Label) {
 	suGbps := fyne.NewMenuItem(speedUnitLabelFor("Gbps"), func() { setSpeedUnit("Gbps") })
 	suGBps := fyne.NewMenuItem(speedUnitLabelFor("GBps"), func() { setSpeedUnit("GBps") })
 	speedUnitSub := fyne.NewMenu("Speed Unit",
+		suAuto, fyne.NewMenuItemSeparator(),
 		suKbps, suKBps, suMbps, suMBps, suGbps, suGBps,
 	)
 	speedUnitSubItem := fyne.NewMenuItem("Speed Unit", nil)
 	speedUnitSubItem.ChildMenu = speedUnitSub
 
+	// Speed Percentiles method submenu under Settings
+	pctlMethodLabelFor := func(lbl, m string) string {
+		if normalizeSpeedPercentileMethod(state.speedPercentileMethod) == m {
+			return lbl + " ✓"
+		}
+		return lbl
+	}
+	setPctlMethod := func(m string) {
+		state.speedPercentileMethod = m
+		savePrefs(state)
+		scheduleRedraw(state)
+		scheduleMenuRebuild(state, fileLabel)
+	}
+	pctlMethodSub := fyne.NewMenu("Speed Percentiles",
+		fyne.NewMenuItem(pctlMethodLabelFor("Pooled samples (batch-level)", "pooled"), func() { setPctlMethod("pooled") }),
+		fyne.NewMenuItem(pctlMethodLabelFor("Average of per-line percentiles", "per_line"), func() { setPctlMethod("per_line") }),
+		fyne.NewMenuItem(pctlMethodLabelFor("Compare both", "compare"), func() { setPctlMethod("compare") }),
+	)
+	pctlMethodSubItem := fyne.NewMenuItem("Speed Percentiles", nil)
+	pctlMethodSubItem.ChildMenu = pctlMethodSub
+
+	// Time Zone submenu: the zone of the Time X-axis, heatmaps, monthly report and Started column
+	tzLabelFor := func(lbl string, utc bool) string {
+		if state.timeAxisUTC == utc {
+			return lbl + " ✓"
+		}
+		return lbl
+	}
+	setTimeZone := func(utc bool) {
+		setTimeAxisUTC(state, utc)
+		savePrefs(state)
+		scheduleRedraw(state)
+		scheduleMenuRebuild(state, fileLabel)
+	}
+	timeZoneSub := fyne.NewMenu("Time Zone",
+		fyne.NewMenuItem(tzLabelFor("Local ("+time.Now().Format("MST, -07:00")+")", false), func() { setTimeZone(false) }),
+		fyne.NewMenuItem(tzLabelFor("UTC", true), func() { setTimeZone(true) }),
+	)
+	timeZoneSubItem := fyne.NewMenuItem("Time Zone", nil)
+	timeZoneSubItem.ChildMenu = timeZoneSub
+
 	// X-Axis submenu under Settings
 	xAxisLabelFor := func(lbl, mode string) string {
 		if strings.EqualFold(state.xAxisMode, mode) {
@@ -3809,7 +5069,7 @@ func buildMenus(state *uiState, fileLabel *widget.Label) {
 		}
 		state.xAxisMode = mode
 		savePrefs(state)
-		redrawCharts(state)
+		scheduleRedraw(state)
 		scheduleMenuRebuild(state, fileLabel)
 	}
 	xaBatch := fyne.NewMenuItem(xAxisLabelFor("Batch", "batch"), func() { setXAxis("batch") })
@@ -3833,7 +5093,7 @@ func buildMenus(state *uiState, fileLabel *widget.Label) {
 		state.yScaleMode = mode
 		state.useRelative = strings.EqualFold(mode, "relative")
 		savePrefs(state)
-		redrawCharts(state)
+		scheduleRedraw(state)
 		scheduleMenuRebuild(state, fileLabel)
 	}
 	ysAbs := fyne.NewMenuItem(yScaleLabelFor("Absolute", "absolute"), func() { setYScale("absolute") })
@@ -3907,7 +5167,7 @@ func buildMenus(state *uiState, fileLabel *widget.Label) {
 					state.slaTTFBThresholdMs = iv
 				}
 				savePrefs(state)
-				redrawCharts(state)
+				scheduleRedraw(state)
 				scheduleMenuRebuild(state, fileLabel)
 			},
 		}
@@ -3947,6 +5207,34 @@ func buildMenus(state *uiState, fileLabel *widget.Label) {
 		d.Resize(fyne.NewSize(380, 160))
 		d.Show()
 	}
+	openMicroStallGapDialog := func() {
+		entry := widget.NewEntry()
+		entry.SetPlaceHolder("Transient Stall Gap (ms)")
+		if state.microStallGapMs <= 0 {
+			state.microStallGapMs = analysis.DefaultMicroStallGapMs
+		}
+		entry.SetText(strconv.Itoa(state.microStallGapMs))
+		form := &widget.Form{Items: []*widget.FormItem{{Text: "Transient Stall Gap (ms)", Widget: entry}}, OnSubmit: func() {
+			if iv, err := strconv.Atoi(strings.TrimSpace(entry.Text)); err == nil {
+				if iv < 50 {
+					iv = 50
+				}
+				if iv > 60_000 {
+					iv = 60_000
+				}
+				state.microStallGapMs = iv
+				savePrefs(state)
+				loadAll(state, fileLabel) // re-analyze summaries
+			}
+		}}
+		d := dialog.NewCustomConfirm("Transient Stall Gap", "Save", "Cancel", form, func(ok bool) {
+			if ok {
+				form.OnSubmit()
+			}
+		}, state.window)
+		d.Resize(fyne.NewSize(380, 160))
+		d.Show()
+	}
 
 	// Detailed settings dialogs
 	openDetailedSeriesDialog := func() {
@@ -4032,7 +5320,7 @@ func buildMenus(state *uiState, fileLabel *widget.Label) {
 				}
 				state.rollingWindow = iv
 				savePrefs(state)
-				redrawCharts(state)
+				scheduleRedraw(state)
 			}
 		}}
 		d := dialog.NewCustomConfirm("Rolling Window", "Save", "Cancel", form, func(ok bool) {
@@ -4090,7 +5378,7 @@ func buildMenus(state *uiState, fileLabel *widget.Label) {
 			}
 			savePrefs(state)
 			state.applyChartVisibilityFromPrefs()
-			redrawCharts(state)
+			scheduleRedraw(state)
 			updateFindMatches(state)
 			scheduleMenuRebuild(state, fileLabel)
 		}),
@@ -4103,7 +5391,7 @@ func buildMenus(state *uiState, fileLabel *widget.Label) {
 			}
 			savePrefs(state)
 			state.applyChartVisibilityFromPrefs()
-			redrawCharts(state)
+			scheduleRedraw(state)
 			updateFindMatches(state)
 			scheduleMenuRebuild(state, fileLabel)
 		}),
@@ -4123,7 +5411,7 @@ func buildMenus(state *uiState, fileLabel *widget.Label) {
 			state.setChartVisible(t, newVis)
 			savePrefs(state)
 			// Redraw to account for layout changes and data-driven auto-hide
-			redrawCharts(state)
+			scheduleRedraw(state)
 			updateFindMatches(state)
 			scheduleMenuRebuild(state, fileLabel)
 		})
@@ -4145,7 +5433,7 @@ func buildMenus(state *uiState, fileLabel *widget.Label) {
 		}(), func() {
 			state.hideOtherCategories = !state.hideOtherCategories
 			savePrefs(state)
-			redrawCharts(state)
+			scheduleRedraw(state)
 			scheduleMenuRebuild(state, fileLabel)
 		}),
 		fyne.NewMenuItem(func() string {
@@ -4156,7 +5444,7 @@ func buildMenus(state *uiState, fileLabel *widget.Label) {
 		}(), func() {
 			state.hideUnknownProtocols = !state.hideUnknownProtocols
 			savePrefs(state)
-			redrawCharts(state)
+			scheduleRedraw(state)
 			scheduleMenuRebuild(state, fileLabel)
 		}),
 		fyne.NewMenuItemSeparator(),
@@ -4173,11 +5461,13 @@ func buildMenus(state *uiState, fileLabel *widget.Label) {
 		fyne.NewMenuItemSeparator(),
 		avgToggle, medToggle, minToggle, maxToggle, iqrToggle,
 		fyne.NewMenuItemSeparator(),
-		rollingToggle, bandToggle,
+		rollingToggle, bandToggle, trendSubItem,
 		fyne.NewMenuItemSeparator(),
 		qualityOnlyToggle, qualColToggle,
+		fyne.NewMenuItem("Table Columns…", func() { showBatchColumnChooser(state) }),
+		fyne.NewMenuItem("Reset Table Layout", func() { resetBatchColumns(state) }),
 		fyne.NewMenuItemSeparator(),
-		dnsToggle,
+		dnsToggle, decimateToggle, overlayToggle, ifaceOverlayToggle, configMarkersToggle,
 	)
 	chartOptionsItem := fyne.NewMenuItem("Chart Options", nil)
 	chartOptionsItem.ChildMenu = chartOptionsMenu
@@ -4192,7 +5482,7 @@ func buildMenus(state *uiState, fileLabel *widget.Label) {
 			applyVisibilityPreset(state, set, onlyWithData)
 			savePrefs(state)
 			state.applyChartVisibilityFromPrefs()
-			redrawCharts(state)
+			scheduleRedraw(state)
 			updateFindMatches(state)
 			scheduleMenuRebuild(state, fileLabel)
 		})
@@ -4203,10 +5493,10 @@ func buildMenus(state *uiState, fileLabel *widget.Label) {
 		vpMenuTitle = fmt.Sprintf("Visibility Presets – %s", ap)
 	}
 	visibilityPresetsMenu := fyne.NewMenu(vpMenuTitle,
-		preset("Everything (show all)", []string{"setup_dns", "setup_connect", "setup_tls", "http_protocol_mix", "proto_avg_speed", "proto_stall_rate", "proto_stall_share", "proto_partial_rate", "proto_partial_share", "proto_error_rate", "proto_error_share", "tls_version_mix", "alpn_mix", "chunked_rate", "speed_avg", "speed_median", "speed_minmax", "speed_percentiles", "self_test", "ttfb_avg", "ttfb_median", "ttfb_minmax", "ttfb_percentiles", "tail_speed_ratio", "tail_ttfb_ratio", "delta_speed_abs", "delta_ttfb_abs", "delta_speed_pct", "delta_ttfb_pct", "sla_speed", "sla_ttfb", "sla_speed_delta", "sla_ttfb_delta", "ttfb_p95_p50_gap", "error_rate", "jitter", "cov", "low_speed_share", "stall_rate", "pre_ttfb_stall", "partial_body_rate", "stall_count", "stall_time", "micro_stall_rate", "micro_stall_count", "micro_stall_time", "cache_hit_rate", "enterprise_proxy_rate", "server_proxy_rate", "warm_cache_rate", "plateau_count", "plateau_longest", "plateau_stable_rate", "error_types", "error_reasons", "error_reasons_detailed"}, false),
-		preset("Stability Focus", []string{"low_speed_share", "stall_rate", "pre_ttfb_stall", "partial_body_rate", "stall_count", "stall_time", "micro_stall_rate", "micro_stall_count", "micro_stall_time"}, false),
-		preset("Transport Focus", []string{"http_protocol_mix", "proto_avg_speed", "proto_stall_rate", "proto_stall_share", "proto_partial_rate", "proto_partial_share", "proto_error_rate", "proto_error_share", "tls_version_mix", "alpn_mix", "chunked_rate"}, false),
-		preset("Setup Timings", []string{"setup_dns", "setup_connect", "setup_tls"}, false),
+		preset("Everything (show all)", []string{"quality_score", "plan_attainment", "throughput_stability", "setup_dns", "setup_connect", "setup_tls", "http_protocol_mix", "proto_avg_speed", "proto_ttfb", "proto_stall_rate", "proto_stall_share", "proto_partial_rate", "proto_partial_share", "proto_error_rate", "proto_error_share", "tls_version_mix", "alpn_mix", "chunked_rate", "compression_ratio", "ipv6_readiness", "happy_eyeballs_ipv6_lost", "udp_blocked_rate", "return_hops", "cold_warm_ttfb", "server_timing", "tcp_retrans", "wifi_rssi", "wifi_phy_rate", "speed_avg", "speed_median", "speed_minmax", "iperf3_capacity", "speed_percentiles", "self_test", "ttfb_avg", "ttfb_median", "ttfb_minmax", "ttfb_percentiles", "heatmap_speed", "heatmap_ttfb", "tail_speed_ratio", "tail_ttfb_ratio", "delta_speed_abs", "delta_ttfb_abs", "delta_speed_pct", "delta_ttfb_pct", "sla_speed", "sla_ttfb", "sla_speed_delta", "sla_ttfb_delta", "ttfb_p95_p50_gap", "error_rate", "jitter", "ping_jitter", "cov", "low_speed_share", "stall_rate", "pre_ttfb_stall", "partial_body_rate", "content_corruption_rate", "data_usage", "stall_count", "stall_time", "micro_stall_rate", "micro_stall_count", "micro_stall_time", "stall_timeline", "cache_hit_rate", "enterprise_proxy_rate", "server_proxy_rate", "warm_cache_rate", "plateau_count", "plateau_longest", "plateau_stable_rate", "error_types", "error_reasons", "error_reasons_detailed"}, false),
+		preset("Stability Focus", []string{"low_speed_share", "stall_rate", "pre_ttfb_stall", "partial_body_rate", "content_corruption_rate", "stall_count", "stall_time", "micro_stall_rate", "micro_stall_count", "micro_stall_time", "stall_timeline", "tcp_retrans"}, false),
+		preset("Transport Focus", []string{"http_protocol_mix", "proto_avg_speed", "proto_ttfb", "proto_stall_rate", "proto_stall_share", "proto_partial_rate", "proto_partial_share", "proto_error_rate", "proto_error_share", "tls_version_mix", "alpn_mix", "chunked_rate", "compression_ratio", "udp_blocked_rate", "tcp_retrans"}, false),
+		preset("Setup Timings", []string{"setup_dns", "setup_connect", "setup_tls", "cold_warm_ttfb", "server_timing"}, false),
 		preset("Errors Focus", []string{"error_rate", "error_types", "error_reasons", "error_reasons_detailed"}, false),
 		preset("Percentiles & Tail", []string{"speed_percentiles", "ttfb_percentiles", "tail_speed_ratio", "tail_ttfb_ratio", "ttfb_p95_p50_gap"}, false),
 		preset("Show only charts with data", []string{"speed_avg"}, true), // 'ids' ignored when onlyWithData=true
@@ -4224,41 +5514,35 @@ func buildMenus(state *uiState, fileLabel *widget.Label) {
 						dialog.ShowInformation("Invalid name", "Please enter a preset name.", state.window)
 						return
 					}
-					ids := currentVisibleChartIDs(state)
 					// Replace if name exists
-					replaced := false
-					for i := range state.customPresets {
-						if state.customPresets[i].Name == name {
-							state.customPresets[i].IDs = ids
-							replaced = true
-							break
-						}
-					}
-					if !replaced {
-						state.customPresets = append(state.customPresets, visibilityPreset{Name: name, IDs: ids})
-					}
+					state.customPresets, _ = upsertPreset(state.customPresets, visibilityPreset{Name: name, IDs: currentVisibleChartIDs(state), Order: currentChartOrder(state)})
 					savePrefs(state)
 					scheduleMenuRebuild(state, fileLabel)
 				}, state.window)
 			d.Show()
 		}),
+		fyne.NewMenuItem("New Dashboard…", func() { openDashboardEditor(state, fileLabel, "") }),
+		fyne.NewMenuItem("Reset Chart Order", func() {
+			state.chartOrder = nil
+			applyChartOrder(state, nil)
+			savePrefs(state)
+			updateFindMatches(state)
+		}),
+		fyne.NewMenuItemSeparator(),
+		fyne.NewMenuItem("Export Presets (JSON)…", func() { exportDashboards(state) }),
+		fyne.NewMenuItem("Import Presets (JSON)…", func() { importDashboards(state, fileLabel) }),
 	)
 
 	// If custom presets exist, add submenus to apply or delete them
 	if len(state.customPresets) > 0 {
 		applyMenu := fyne.NewMenu("Apply Custom Preset")
+		editMenu := fyne.NewMenu("Edit Dashboard")
 		deleteMenu := fyne.NewMenu("Delete Custom Preset")
 		renameMenu := fyne.NewMenu("Rename Custom Preset")
 		for _, p := range state.customPresets {
 			pname := p.Name
-			applyMenu.Items = append(applyMenu.Items, fyne.NewMenuItem(pname, func() {
-				applyCustomPreset(state, pname)
-				savePrefs(state)
-				state.applyChartVisibilityFromPrefs()
-				redrawCharts(state)
-				updateFindMatches(state)
-				scheduleMenuRebuild(state, fileLabel)
-			}))
+			applyMenu.Items = append(applyMenu.Items, fyne.NewMenuItem(pname, func() { applyDashboard(state, fileLabel, pname) }))
+			editMenu.Items = append(editMenu.Items, fyne.NewMenuItem(pname+"…", func() { openDashboardEditor(state, fileLabel, pname) }))
 			renameMenu.Items = append(renameMenu.Items, fyne.NewMenuItem(pname+"…", func() {
 				nameEntry := widget.NewEntry()
 				nameEntry.SetText(pname)
@@ -4326,6 +5610,8 @@ func buildMenus(state *uiState, fileLabel *widget.Label) {
 		visibilityPresetsMenu.Items = append(visibilityPresetsMenu.Items, fyne.NewMenuItemSeparator())
 		visibilityPresetsMenu.Items = append(visibilityPresetsMenu.Items, fyne.NewMenuItem("Apply Custom Preset", nil))
 		visibilityPresetsMenu.Items[len(visibilityPresetsMenu.Items)-1].ChildMenu = applyMenu
+		visibilityPresetsMenu.Items = append(visibilityPresetsMenu.Items, fyne.NewMenuItem("Edit Dashboard", nil))
+		visibilityPresetsMenu.Items[len(visibilityPresetsMenu.Items)-1].ChildMenu = editMenu
 		visibilityPresetsMenu.Items = append(visibilityPresetsMenu.Items, fyne.NewMenuItem("Rename Custom Preset", nil))
 		visibilityPresetsMenu.Items[len(visibilityPresetsMenu.Items)-1].ChildMenu = renameMenu
 		visibilityPresetsMenu.Items = append(visibilityPresetsMenu.Items, fyne.NewMenuItem("Delete Custom Preset", nil))
@@ -4335,17 +5621,19 @@ func buildMenus(state *uiState, fileLabel *widget.Label) {
 	visibilityPresetsItem.ChildMenu = visibilityPresetsMenu
 
 	// Axes & Units submenu: X-Axis, Y-Scale, Speed Unit
-	axesUnitsMenu := fyne.NewMenu("Axes & Units", xAxisSubItem, yScaleSubItem, speedUnitSubItem)
+	axesUnitsMenu := fyne.NewMenu("Axes & Units", xAxisSubItem, yScaleSubItem, timeZoneSubItem, speedUnitSubItem, pctlMethodSubItem)
 	axesUnitsItem := fyne.NewMenuItem("Axes & Units", nil)
 	axesUnitsItem.ChildMenu = axesUnitsMenu
 
-	// Thresholds submenu: SLA, Low-Speed, Rolling Window, Calibration tolerance
+	// Thresholds submenu: SLA, Low-Speed, Transient Stall Gap, Rolling Window, Calibration tolerance
 	thresholdsMenu := fyne.NewMenu("Thresholds",
 		fyne.NewMenuItem("SLA Thresholds…", func() { openSLADialog() }),
 		fyne.NewMenuItem("Low-Speed Threshold…", func() { openLowSpeedDialog() }),
+		fyne.NewMenuItem("Transient Stall Gap…", func() { openMicroStallGapDialog() }),
 		fyne.NewMenuItem("Rolling Window…", func() { openRollingDialog() }),
 		fyne.NewMenuItem("Calibration tolerance…", func() { openCalibTolDialog() }),
 	)
+	alertsItem := fyne.NewMenuItem("Alerts…", func() { openAlertsDialog(state) })
 	thresholdsItem := fyne.NewMenuItem("Thresholds", nil)
 	thresholdsItem.ChildMenu = thresholdsMenu
 
@@ -4367,6 +5655,7 @@ func buildMenus(state *uiState, fileLabel *widget.Label) {
 		// Bulk actions
 		items = append(items, fyne.NewMenuItem("Show All", func() {
 			state.showDetailedPercentiles = true
+			state.showDetailedDistribution = true
 			state.showDetailedSpeedOverTime = true
 			state.showDetailedBytesOverTime = true
 			state.showDetailedTopSessionsSpeed = true
@@ -4382,6 +5671,7 @@ func buildMenus(state *uiState, fileLabel *widget.Label) {
 		}))
 		items = append(items, fyne.NewMenuItem("Hide All", func() {
 			state.showDetailedPercentiles = false
+			state.showDetailedDistribution = false
 			state.showDetailedSpeedOverTime = false
 			state.showDetailedBytesOverTime = false
 			state.showDetailedTopSessionsSpeed = false
@@ -4407,6 +5697,16 @@ func buildMenus(state *uiState, fileLabel *widget.Label) {
 			}
 			scheduleMenuRebuild(state, fileLabel)
 		}))
+		items = append(items, fyne.NewMenuItem(checkLabel("Show Speed Distribution", state.showDetailedDistribution), func() {
+			state.showDetailedDistribution = !state.showDetailedDistribution
+			savePrefs(state)
+			if state.firstDataLoadDone {
+				scheduleDetailedRebuild(state)
+			} else {
+				state.pendingDetailedRebuild = true
+			}
+			scheduleMenuRebuild(state, fileLabel)
+		}))
 		items = append(items, fyne.NewMenuItem(checkLabel("Show Speed over Time", state.showDetailedSpeedOverTime), func() {
 			state.showDetailedSpeedOverTime = !state.showDetailedSpeedOverTime
 			savePrefs(state)
@@ -4512,7 +5812,7 @@ func buildMenus(state *uiState, fileLabel *widget.Label) {
 			savePrefs(state)
 			state.applyChartVisibilityFromPrefs()
 			updateColumnVisibility(state)
-			redrawCharts(state)
+			scheduleRedraw(state)
 			scheduleMenuRebuild(state, fileLabel)
 		}, state.window)
 		confirm.Show()
@@ -4524,14 +5824,28 @@ func buildMenus(state *uiState, fileLabel *widget.Label) {
 		chartOptionsItem,
 		axesUnitsItem,
 		thresholdsItem,
+		alertsItem,
+		fyne.NewMenuItem("Quality Score…", func() { openQualityScoreDialog(state, fileLabel) }),
+		fyne.NewMenuItem("ISP Plan…", func() { openISPPlanDialog(state) }),
+		fyne.NewMenuItem("Monitor Connection…", func() { openMonitorConnectionDialog(state) }),
 		dataScopeItem,
 		detailedSettingsItem,
 		autoOpenDetailedToggle,
 		resetAll,
 		fyne.NewMenuItemSeparator(),
+		appThemeSubItem,
 		themeSubItem,
+		languageMenuItem(state, fileLabel),
+		fyne.NewMenuItem("Chart Appearance…", func() { openChartAppearanceDialog(state) }),
 	)
 
+	// Contents sidebar of the BatchAvg charts (chart_toc.go)
+	chartTOCLabel := "Chart Contents Sidebar"
+	if state.showChartTOC {
+		chartTOCLabel += " ✓"
+	}
+	chartTOCToggle := fyne.NewMenuItem(chartTOCLabel, func() { toggleChartTOC(state, fileLabel) })
+
 	// Find menu for quick navigation across charts
 	findMenu := fyne.NewMenu("Find",
 		fyne.NewMenuItem("Find…", func() {
@@ -4543,10 +5857,19 @@ func buildMenus(state *uiState, fileLabel *widget.Label) {
 		}),
 		fyne.NewMenuItem("Find Next", func() { findNext(state) }),
 		fyne.NewMenuItem("Find Previous", func() { findPrev(state) }),
+		fyne.NewMenuItemSeparator(),
+		fyne.NewMenuItem("Next Chart", func() { stepChartFocus(state, 1) }),
+		fyne.NewMenuItem("Previous Chart", func() { stepChartFocus(state, -1) }),
+		chartTOCToggle,
+		fyne.NewMenuItem("Keyboard Shortcuts…", func() { showKeyboardShortcuts(state) }),
 	)
 
 	mainMenu := fyne.NewMainMenu(fileMenu, recentMenu, settingsMenu, findMenu)
+	for _, m := range mainMenu.Items {
+		translateMenu(m)
+	}
 	state.window.SetMainMenu(mainMenu)
+	refreshDashboardSelect(state)
 
 	canv := state.window.Canvas()
 	if canv != nil {
@@ -4578,6 +5901,7 @@ func buildMenus(state *uiState, fileLabel *widget.Label) {
 		canv.AddShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyG, Modifier: fyne.KeyModifierControl}, func(fyne.Shortcut) { findNext(state) })
 		canv.AddShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyG, Modifier: fyne.KeyModifierShift | fyne.KeyModifierSuper}, func(fyne.Shortcut) { findPrev(state) })
 		canv.AddShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyG, Modifier: fyne.KeyModifierShift | fyne.KeyModifierControl}, func(fyne.Shortcut) { findPrev(state) })
+		registerNavigationShortcuts(state, canv)
 	}
 }
 
@@ -4598,7 +5922,59 @@ func openFileDialog(state *uiState, fileLabel *widget.Label) {
 }
 
 // load data and render
+// updateLoadWarning shows or hides the banner for lines the last load had to skip or upgrade.
+// Schema-version mismatches are only mentioned alongside corrupt lines; files mixing schema
+// versions after an upgrade are normal, so upgraded lines alone only get an informational note.
+func updateLoadWarning(state *uiState) {
+	if state.loadWarningRow == nil || state.loadWarningLbl == nil {
+		return
+	}
+	st := state.loadStats
+	note := ""
+	if state.loadMigration.Lines > 0 {
+		note = fmt.Sprintf("Schema: %s in %s.", state.loadMigration.Summary(), filepath.Base(state.filePath))
+	}
+	if st.Duplicates > 0 {
+		// not an error: the copies are dropped, so charts are unaffected
+		dup := fmt.Sprintf("Ignored %d duplicate lines in %s (appended twice?).", st.Duplicates, filepath.Base(state.filePath))
+		note = strings.TrimSpace(note + " " + dup)
+	}
+	if st.Corrupt == 0 && st.MissingRunTag == 0 {
+		if note == "" {
+			state.loadWarningRow.Hide()
+			return
+		}
+		state.loadWarningLbl.SetText(note)
+		state.loadWarningRow.Show()
+		return
+	}
+	msg := fmt.Sprintf("%d of %d lines in %s were skipped", st.Skipped(), st.Lines, filepath.Base(state.filePath))
+	var parts []string
+	if st.Corrupt > 0 {
+		parts = append(parts, fmt.Sprintf("%d corrupt/truncated", st.Corrupt))
+	}
+	if st.MissingRunTag > 0 {
+		parts = append(parts, fmt.Sprintf("%d without run_tag", st.MissingRunTag))
+	}
+	if st.SchemaMismatch > 0 {
+		parts = append(parts, fmt.Sprintf("%d other schema version", st.SchemaMismatch))
+	}
+	msg += " (" + strings.Join(parts, ", ") + "). Charts may be missing data; run the monitor with --fsck --input <file> for details or --fsck-repair <out> for a cleaned copy."
+	if note != "" {
+		msg += " " + note
+	}
+	state.loadWarningLbl.SetText(msg)
+	state.loadWarningRow.Show()
+}
+
+// loadAll (re)loads state.filePath in the background (load_cancel.go) and shows its batches.
 func loadAll(state *uiState, fileLabel *widget.Label) {
+	loadAllThen(state, fileLabel, nil)
+}
+
+// loadAllThen is loadAll calling done once the new batches are shown (not after an error or
+// a canceled load).
+func loadAllThen(state *uiState, fileLabel *widget.Label, done func()) {
 	if state.filePath == "" {
 		if _, err := os.Stat("monitor_results.jsonl"); err == nil {
 			state.filePath = "monitor_results.jsonl"
@@ -4609,13 +5985,12 @@ func loadAll(state *uiState, fileLabel *widget.Label) {
 			return
 		}
 	}
-	// Use options so low-speed threshold and micro-stall detection are applied
-	ops := analysis.AnalyzeOptions{SituationFilter: "", LowSpeedThresholdKbps: float64(state.lowSpeedThresholdKbps), MicroStallMinGapMs: 500}
-	summaries, err := analysis.AnalyzeRecentResultsFullWithOptions(state.filePath, monitor.SchemaVersion, state.batchesN, ops)
-	if err != nil {
-		dialog.ShowError(err, state.window)
-		return
-	}
+	startLoad(state, fileLabel, done)
+}
+
+// applyLoad shows freshly loaded batches: situations and the other selectors, the table, and
+// the charts.
+func applyLoad(state *uiState, summaries []analysis.BatchSummary) {
 	state.summaries = summaries
 	state.firstDataLoadDone = true
 	// If any detailed rebuilds were requested before data was available, coalesce them now
@@ -4652,7 +6027,7 @@ func loadAll(state *uiState, fileLabel *widget.Label) {
 		if counts["(none)"] > 0 {
 			parts = append(parts, fmt.Sprintf("(none)=%d", counts["(none)"]))
 		}
-		fmt.Printf("[viewer] loaded %d batches. Situation counts: %s\n", len(state.summaries), strings.Join(parts, ", "))
+		logf(slog.LevelInfo, "viewer", "loaded %d batches. Situation counts: %s", len(state.summaries), strings.Join(parts, ", "))
 	}
 	// Do not auto-select a specific situation; keep default as All
 	// update situation selector
@@ -4661,14 +6036,14 @@ func loadAll(state *uiState, fileLabel *widget.Label) {
 		opts = append(opts, "All")
 		opts = append(opts, state.situations...)
 		state.situationSelect.Options = opts
-		fmt.Printf("[viewer] situations available: %v\n", opts)
+		logf(slog.LevelDebug, "viewer", "situations available: %v", opts)
 		// Default to All unless a specific situation was previously chosen
 		if strings.TrimSpace(state.situation) == "" || strings.EqualFold(state.situation, "All") {
 			state.situation = "All"
 			state.initializing = true
 			state.situationSelect.SetSelected("All")
 			state.initializing = false
-			fmt.Printf("[viewer] selecting situation: %q (default)\n", "All")
+			logf(slog.LevelDebug, "viewer", "selecting situation: %q (default)", "All")
 		} else {
 			// If saved situation is no longer present in dataset, fall back to All
 			found := false
@@ -4684,13 +6059,13 @@ func loadAll(state *uiState, fileLabel *widget.Label) {
 				state.initializing = true
 				state.situationSelect.SetSelected(state.situation)
 				state.initializing = false
-				fmt.Printf("[viewer] selecting situation: %q (restored)\n", state.situation)
+				logf(slog.LevelDebug, "viewer", "selecting situation: %q (restored)", state.situation)
 			} else {
 				state.situation = "All"
 				state.initializing = true
 				state.situationSelect.SetSelected("All")
 				state.initializing = false
-				fmt.Printf("[viewer] saved situation not found; selecting %q\n", "All")
+				logf(slog.LevelDebug, "viewer", "saved situation not found; selecting %q", "All")
 			}
 		}
 		// Ensure placeholder reflects actual selection
@@ -4699,6 +6074,11 @@ func loadAll(state *uiState, fileLabel *widget.Label) {
 		// Persist the resolved selection so it sticks next launch
 		savePrefs(state)
 	}
+	updateAgentSelect(state)
+	updateVPNSelect(state)
+	updateBindSelect(state)
+	updateTagSelect(state)
+	updateGroupSelect(state)
 	if state.table != nil {
 		// Restore previously selected RunTag for this session if available
 		if tag := strings.TrimSpace(state.selectedRunTag); tag != "" {
@@ -4801,6 +6181,187 @@ func uniqueSituationsFromSummaries(rows []analysis.BatchSummary) []string {
 	return out
 }
 
+// updateAgentSelect refreshes the agent filter from the loaded batches. The selector is hidden
+// for single-agent files; a selected agent that is no longer present falls back to All.
+func updateAgentSelect(state *uiState) {
+	if state.agentSelect == nil || state.agentRow == nil {
+		return
+	}
+	set := map[string]struct{}{}
+	for _, r := range state.summaries {
+		if a := strings.TrimSpace(r.Agent); a != "" {
+			set[a] = struct{}{}
+		}
+	}
+	agents := make([]string, 0, len(set))
+	for a := range set {
+		agents = append(agents, a)
+	}
+	sort.Strings(agents)
+	found := false
+	for _, a := range agents {
+		if a == state.agent {
+			found = true
+		}
+	}
+	if !found {
+		state.agent = "All"
+	}
+	state.agentSelect.Options = append([]string{"All"}, agents...)
+	prevInit := state.initializing
+	state.initializing = true
+	state.agentSelect.SetSelected(state.agent)
+	state.initializing = prevInit
+	if len(agents) > 1 {
+		state.agentRow.Show()
+	} else {
+		state.agentRow.Hide()
+	}
+}
+
+// updateVPNSelect refreshes the VPN filter: All / VPN on / VPN off plus one entry per detected
+// VPN name. It stays hidden until at least one batch ran with a VPN active.
+func updateVPNSelect(state *uiState) {
+	if state.vpnSelect == nil || state.vpnRow == nil {
+		return
+	}
+	set := map[string]struct{}{}
+	anyVPN := false
+	for _, r := range state.summaries {
+		if !r.VPNActive {
+			continue
+		}
+		anyVPN = true
+		if n := strings.TrimSpace(r.VPNName); n != "" {
+			set[n] = struct{}{}
+		}
+	}
+	opts := []string{"All", "VPN on", "VPN off"}
+	names := make([]string, 0, len(set))
+	for n := range set {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	if len(names) > 1 {
+		for _, n := range names {
+			opts = append(opts, "VPN: "+n)
+		}
+	}
+	found := false
+	for _, o := range opts {
+		if o == state.vpnFilter {
+			found = true
+		}
+	}
+	if !found {
+		state.vpnFilter = "All"
+	}
+	state.vpnSelect.Options = opts
+	prevInit := state.initializing
+	state.initializing = true
+	state.vpnSelect.SetSelected(state.vpnFilter)
+	state.initializing = prevInit
+	if anyVPN {
+		state.vpnRow.Show()
+	} else {
+		state.vpnRow.Hide()
+	}
+}
+
+// updateBindSelect refreshes the Interface filter with one entry per source binding; "Default"
+// stands for batches the monitor ran without one. Hidden unless there are at least two.
+func updateBindSelect(state *uiState) {
+	if state.bindSelect == nil || state.bindRow == nil {
+		return
+	}
+	set := map[string]struct{}{}
+	for _, r := range state.summaries {
+		set[batchInterface(r)] = struct{}{}
+	}
+	names := make([]string, 0, len(set))
+	for n := range set {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	found := false
+	for _, n := range names {
+		if n == state.bindFilter {
+			found = true
+		}
+	}
+	if !found {
+		state.bindFilter = "All"
+	}
+	state.bindSelect.Options = append([]string{"All"}, names...)
+	prevInit := state.initializing
+	state.initializing = true
+	state.bindSelect.SetSelected(state.bindFilter)
+	state.initializing = prevInit
+	if len(names) > 1 {
+		state.bindRow.Show()
+	} else {
+		state.bindRow.Hide()
+	}
+}
+
+// updateTagSelect refreshes the tag filter with one "key=value" entry per distinct batch tag.
+// It stays hidden when no loaded batch carries tags.
+func updateTagSelect(state *uiState) {
+	if state.tagSelect == nil || state.tagRow == nil {
+		return
+	}
+	set := map[string]struct{}{}
+	for _, r := range state.summaries {
+		for k, v := range r.Tags {
+			set[k+"="+v] = struct{}{}
+		}
+	}
+	tags := make([]string, 0, len(set))
+	for t := range set {
+		tags = append(tags, t)
+	}
+	sort.Strings(tags)
+	opts := append([]string{"All"}, tags...)
+	if _, ok := set[state.tagFilter]; !ok {
+		state.tagFilter = "All"
+	}
+	state.tagSelect.Options = opts
+	prevInit := state.initializing
+	state.initializing = true
+	state.tagSelect.SetSelected(state.tagFilter)
+	state.initializing = prevInit
+	if len(tags) > 0 {
+		state.tagRow.Show()
+	} else {
+		state.tagRow.Hide()
+	}
+}
+
+// tagFilterMatches reports whether batch s carries the "key=value" tag f.
+func tagFilterMatches(s analysis.BatchSummary, f string) bool {
+	if f == "" || f == "All" {
+		return true
+	}
+	k, v, _ := strings.Cut(f, "=")
+	got, ok := s.Tags[k]
+	return ok && got == v
+}
+
+// vpnFilterMatches reports whether batch s passes the VPN filter value f.
+func vpnFilterMatches(s analysis.BatchSummary, f string) bool {
+	switch {
+	case f == "" || f == "All":
+		return true
+	case f == "VPN on":
+		return s.VPNActive
+	case f == "VPN off":
+		return !s.VPNActive
+	case strings.HasPrefix(f, "VPN: "):
+		return s.VPNActive && s.VPNName == strings.TrimPrefix(f, "VPN: ")
+	}
+	return true
+}
+
 func filteredSummaries(state *uiState) []analysis.BatchSummary {
 	if state == nil {
 		return nil
@@ -4821,46 +6382,94 @@ func filteredSummaries(state *uiState) []analysis.BatchSummary {
 		}
 		base = tmp
 	}
-	// Optionally filter to only quality-good batches
-	if state.showOnlyQualityGood {
+	if a := strings.TrimSpace(state.agent); a != "" && !strings.EqualFold(a, "All") {
 		tmp := make([]analysis.BatchSummary, 0, len(base))
 		for _, s := range base {
-			if s.QualityGood {
+			if s.Agent == a {
 				tmp = append(tmp, s)
 			}
 		}
 		base = tmp
 	}
-	return base
-}
-
-// (removed: batch filter label/update controls)
-
-func redrawCharts(state *uiState) {
-	// Speed split charts (respect Settings toggles)
-	if state.showAvg {
-		if img := renderSpeedChartVariant(state, "avg"); img != nil && state.speedImgCanvas != nil {
-			state.speedImgCanvas.Image = img
-			cw, chh := chartSize(state)
-			// Ensure MinSize width matches chart width so layout can expand; previously width 0 prevented growth.
-			state.speedImgCanvas.SetMinSize(fyne.NewSize(float32(cw), float32(chh)))
-			state.speedImgCanvas.Refresh()
-			if state.speedOverlay != nil {
-				state.speedOverlay.Refresh()
+	if f := state.vpnFilter; f != "" && f != "All" {
+		tmp := make([]analysis.BatchSummary, 0, len(base))
+		for _, s := range base {
+			if vpnFilterMatches(s, f) {
+				tmp = append(tmp, s)
 			}
 		}
-	} else if state.speedImgCanvas != nil {
-		// Clear image to a blank placeholder to reduce visual clutter when hidden
-		w, h := chartSize(state)
-		state.speedImgCanvas.Image = blank(w, h)
-		state.speedImgCanvas.SetMinSize(fyne.NewSize(float32(w), float32(h)))
-		state.speedImgCanvas.Refresh()
+		base = tmp
 	}
-	if state.showMedian {
-		if img := renderSpeedChartVariant(state, "median"); img != nil && state.speedMedianImgCanvas != nil {
-			state.speedMedianImgCanvas.Image = img
-			cw, chh := chartSize(state)
-			state.speedMedianImgCanvas.SetMinSize(fyne.NewSize(float32(cw), float32(chh)))
+	if f := state.bindFilter; f != "" && f != "All" {
+		tmp := make([]analysis.BatchSummary, 0, len(base))
+		for _, s := range base {
+			if batchInterface(s) == f {
+				tmp = append(tmp, s)
+			}
+		}
+		base = tmp
+	}
+	if f := state.tagFilter; f != "" && f != "All" {
+		tmp := make([]analysis.BatchSummary, 0, len(base))
+		for _, s := range base {
+			if tagFilterMatches(s, f) {
+				tmp = append(tmp, s)
+			}
+		}
+		base = tmp
+	}
+	if g := state.groupFilter; g != "" && g != "All" {
+		tmp := make([]analysis.BatchSummary, 0, len(base))
+		for _, s := range base {
+			if gs, ok := groupView(s, g); ok {
+				tmp = append(tmp, gs)
+			}
+		}
+		base = tmp
+	}
+	// Optionally filter to only quality-good batches
+	if state.showOnlyQualityGood {
+		tmp := make([]analysis.BatchSummary, 0, len(base))
+		for _, s := range base {
+			if s.QualityGood {
+				tmp = append(tmp, s)
+			}
+		}
+		base = tmp
+	}
+	return base
+}
+
+// (removed: batch filter label/update controls)
+
+func redrawCharts(state *uiState) {
+	renderCacheFor(state).beginPass()
+	updateConfigMarkers(state)
+	refreshAutoSpeedUnit(state)
+	// Speed split charts (respect Settings toggles)
+	if state.showAvg {
+		if img := cachedRender(state, "renderSpeedChartVariant/avg", func(s *uiState) image.Image { return renderSpeedChartVariant(s, "avg") }); img != nil && state.speedImgCanvas != nil && chartImageChanged(state.speedImgCanvas, img) {
+			state.speedImgCanvas.Image = img
+			cw, chh := chartSize(state)
+			// Ensure MinSize width matches chart width so layout can expand; previously width 0 prevented growth.
+			state.speedImgCanvas.SetMinSize(fyne.NewSize(float32(cw), float32(chh)))
+			state.speedImgCanvas.Refresh()
+			if state.speedOverlay != nil {
+				state.speedOverlay.Refresh()
+			}
+		}
+	} else if state.speedImgCanvas != nil {
+		// Clear image to a blank placeholder to reduce visual clutter when hidden
+		w, h := chartSize(state)
+		state.speedImgCanvas.Image = blank(w, h)
+		state.speedImgCanvas.SetMinSize(fyne.NewSize(float32(w), float32(h)))
+		state.speedImgCanvas.Refresh()
+	}
+	if state.showMedian {
+		if img := cachedRender(state, "renderSpeedChartVariant/median", func(s *uiState) image.Image { return renderSpeedChartVariant(s, "median") }); img != nil && state.speedMedianImgCanvas != nil && chartImageChanged(state.speedMedianImgCanvas, img) {
+			state.speedMedianImgCanvas.Image = img
+			cw, chh := chartSize(state)
+			state.speedMedianImgCanvas.SetMinSize(fyne.NewSize(float32(cw), float32(chh)))
 			state.speedMedianImgCanvas.Refresh()
 			if state.speedMedianOverlay != nil {
 				state.speedMedianOverlay.Refresh()
@@ -4872,7 +6481,7 @@ func redrawCharts(state *uiState) {
 		state.speedMedianImgCanvas.SetMinSize(fyne.NewSize(float32(w), float32(h)))
 		state.speedMedianImgCanvas.Refresh()
 	}
-	if img := renderSpeedChartVariant(state, "minmax"); img != nil && state.speedMinMaxImgCanvas != nil {
+	if img := cachedRender(state, "renderSpeedChartVariant/minmax", func(s *uiState) image.Image { return renderSpeedChartVariant(s, "minmax") }); img != nil && state.speedMinMaxImgCanvas != nil && chartImageChanged(state.speedMinMaxImgCanvas, img) {
 		state.speedMinMaxImgCanvas.Image = img
 		cw, chh := chartSize(state)
 		state.speedMinMaxImgCanvas.SetMinSize(fyne.NewSize(float32(cw), float32(chh)))
@@ -4883,7 +6492,7 @@ func redrawCharts(state *uiState) {
 	}
 	// TTFB split charts
 	if state.showAvg {
-		if img := renderTTFBChartVariant(state, "avg"); img != nil && state.ttfbImgCanvas != nil {
+		if img := cachedRender(state, "renderTTFBChartVariant/avg", func(s *uiState) image.Image { return renderTTFBChartVariant(s, "avg") }); img != nil && state.ttfbImgCanvas != nil && chartImageChanged(state.ttfbImgCanvas, img) {
 			state.ttfbImgCanvas.Image = img
 			cw, chh := chartSize(state)
 			state.ttfbImgCanvas.SetMinSize(fyne.NewSize(float32(cw), float32(chh)))
@@ -4899,7 +6508,7 @@ func redrawCharts(state *uiState) {
 		state.ttfbImgCanvas.Refresh()
 	}
 	if state.showMedian {
-		if img := renderTTFBChartVariant(state, "median"); img != nil && state.ttfbMedianImgCanvas != nil {
+		if img := cachedRender(state, "renderTTFBChartVariant/median", func(s *uiState) image.Image { return renderTTFBChartVariant(s, "median") }); img != nil && state.ttfbMedianImgCanvas != nil && chartImageChanged(state.ttfbMedianImgCanvas, img) {
 			state.ttfbMedianImgCanvas.Image = img
 			cw, chh := chartSize(state)
 			state.ttfbMedianImgCanvas.SetMinSize(fyne.NewSize(float32(cw), float32(chh)))
@@ -4914,7 +6523,7 @@ func redrawCharts(state *uiState) {
 		state.ttfbMedianImgCanvas.SetMinSize(fyne.NewSize(float32(w), float32(h)))
 		state.ttfbMedianImgCanvas.Refresh()
 	}
-	if img := renderTTFBChartVariant(state, "minmax"); img != nil && state.ttfbMinMaxImgCanvas != nil {
+	if img := cachedRender(state, "renderTTFBChartVariant/minmax", func(s *uiState) image.Image { return renderTTFBChartVariant(s, "minmax") }); img != nil && state.ttfbMinMaxImgCanvas != nil && chartImageChanged(state.ttfbMinMaxImgCanvas, img) {
 		state.ttfbMinMaxImgCanvas.Image = img
 		_, chh := chartSize(state)
 		state.ttfbMinMaxImgCanvas.SetMinSize(fyne.NewSize(0, float32(chh)))
@@ -4925,9 +6534,9 @@ func redrawCharts(state *uiState) {
 	}
 	// Percentiles chart(s) stacked: Overall, IPv4, IPv6; visibility via checkboxes
 	// Local self-test chart (single series)
-	stImg := renderSelfTestChart(state)
+	stImg := cachedRender(state, "renderSelfTestChart", renderSelfTestChart)
 	if stImg != nil {
-		if state.selfTestImgCanvas != nil {
+		if state.selfTestImgCanvas != nil && chartImageChanged(state.selfTestImgCanvas, stImg) {
 			state.selfTestImgCanvas.Image = stImg
 			_, chh := chartSize(state)
 			state.selfTestImgCanvas.SetMinSize(fyne.NewSize(0, float32(chh)))
@@ -4940,12 +6549,14 @@ func redrawCharts(state *uiState) {
 
 	if state.pctlOverallImg != nil {
 		if state.showOverall {
-			img := renderPercentilesChartWithFamily(state, "overall")
+			img := cachedRender(state, "renderPercentilesChartWithFamily/overall", func(s *uiState) image.Image { return renderPercentilesChartWithFamily(s, "overall") })
 			if img != nil {
+				state.pctlOverallImg.Show()
+			}
+			if img != nil && chartImageChanged(state.pctlOverallImg, img) {
 				state.pctlOverallImg.Image = img
 				_, chh := chartSize(state)
 				state.pctlOverallImg.SetMinSize(fyne.NewSize(0, float32(chh)))
-				state.pctlOverallImg.Show()
 				state.pctlOverallImg.Refresh()
 				if state.pctlOverallOverlay != nil {
 					state.pctlOverallOverlay.Refresh()
@@ -4957,12 +6568,14 @@ func redrawCharts(state *uiState) {
 	}
 	if state.pctlIPv4Img != nil {
 		if state.showIPv4 {
-			img := renderPercentilesChartWithFamily(state, "ipv4")
+			img := cachedRender(state, "renderPercentilesChartWithFamily/ipv4", func(s *uiState) image.Image { return renderPercentilesChartWithFamily(s, "ipv4") })
 			if img != nil {
+				state.pctlIPv4Img.Show()
+			}
+			if img != nil && chartImageChanged(state.pctlIPv4Img, img) {
 				state.pctlIPv4Img.Image = img
 				_, chh := chartSize(state)
 				state.pctlIPv4Img.SetMinSize(fyne.NewSize(0, float32(chh)))
-				state.pctlIPv4Img.Show()
 				state.pctlIPv4Img.Refresh()
 				if state.pctlIPv4Overlay != nil {
 					state.pctlIPv4Overlay.Refresh()
@@ -4974,12 +6587,14 @@ func redrawCharts(state *uiState) {
 	}
 	if state.pctlIPv6Img != nil {
 		if state.showIPv6 {
-			img := renderPercentilesChartWithFamily(state, "ipv6")
+			img := cachedRender(state, "renderPercentilesChartWithFamily/ipv6", func(s *uiState) image.Image { return renderPercentilesChartWithFamily(s, "ipv6") })
 			if img != nil {
+				state.pctlIPv6Img.Show()
+			}
+			if img != nil && chartImageChanged(state.pctlIPv6Img, img) {
 				state.pctlIPv6Img.Image = img
 				_, chh := chartSize(state)
 				state.pctlIPv6Img.SetMinSize(fyne.NewSize(0, float32(chh)))
-				state.pctlIPv6Img.Show()
 				state.pctlIPv6Img.Refresh()
 				if state.pctlIPv6Overlay != nil {
 					state.pctlIPv6Overlay.Refresh()
@@ -4995,12 +6610,14 @@ func redrawCharts(state *uiState) {
 	// TTFB Percentiles chart(s): Overall, IPv4, IPv6
 	if state.tpctlOverallImg != nil {
 		if state.showOverall {
-			img := renderTTFBPercentilesChartWithFamily(state, "overall")
+			img := cachedRender(state, "renderTTFBPercentilesChartWithFamily/overall", func(s *uiState) image.Image { return renderTTFBPercentilesChartWithFamily(s, "overall") })
 			if img != nil {
+				state.tpctlOverallImg.Show()
+			}
+			if img != nil && chartImageChanged(state.tpctlOverallImg, img) {
 				state.tpctlOverallImg.Image = img
 				_, chh := chartSize(state)
 				state.tpctlOverallImg.SetMinSize(fyne.NewSize(0, float32(chh)))
-				state.tpctlOverallImg.Show()
 				state.tpctlOverallImg.Refresh()
 				if state.tpctlOverallOverlay != nil {
 					state.tpctlOverallOverlay.Refresh()
@@ -5012,12 +6629,14 @@ func redrawCharts(state *uiState) {
 	}
 	if state.tpctlIPv4Img != nil {
 		if state.showIPv4 {
-			img := renderTTFBPercentilesChartWithFamily(state, "ipv4")
+			img := cachedRender(state, "renderTTFBPercentilesChartWithFamily/ipv4", func(s *uiState) image.Image { return renderTTFBPercentilesChartWithFamily(s, "ipv4") })
 			if img != nil {
+				state.tpctlIPv4Img.Show()
+			}
+			if img != nil && chartImageChanged(state.tpctlIPv4Img, img) {
 				state.tpctlIPv4Img.Image = img
 				_, chh := chartSize(state)
 				state.tpctlIPv4Img.SetMinSize(fyne.NewSize(0, float32(chh)))
-				state.tpctlIPv4Img.Show()
 				state.tpctlIPv4Img.Refresh()
 				if state.tpctlIPv4Overlay != nil {
 					state.tpctlIPv4Overlay.Refresh()
@@ -5029,12 +6648,14 @@ func redrawCharts(state *uiState) {
 	}
 	if state.tpctlIPv6Img != nil {
 		if state.showIPv6 {
-			img := renderTTFBPercentilesChartWithFamily(state, "ipv6")
+			img := cachedRender(state, "renderTTFBPercentilesChartWithFamily/ipv6", func(s *uiState) image.Image { return renderTTFBPercentilesChartWithFamily(s, "ipv6") })
 			if img != nil {
+				state.tpctlIPv6Img.Show()
+			}
+			if img != nil && chartImageChanged(state.tpctlIPv6Img, img) {
 				state.tpctlIPv6Img.Image = img
 				_, chh := chartSize(state)
 				state.tpctlIPv6Img.SetMinSize(fyne.NewSize(0, float32(chh)))
-				state.tpctlIPv6Img.Show()
 				state.tpctlIPv6Img.Refresh()
 				if state.tpctlIPv6Overlay != nil {
 					state.tpctlIPv6Overlay.Refresh()
@@ -5045,9 +6666,9 @@ func redrawCharts(state *uiState) {
 		}
 	}
 	// Tail Heaviness (P99/P50 Speed)
-	trImg := renderTailHeavinessChart(state)
+	trImg := cachedRender(state, "renderTailHeavinessChart", renderTailHeavinessChart)
 	if trImg != nil {
-		if state.tailRatioImgCanvas != nil {
+		if state.tailRatioImgCanvas != nil && chartImageChanged(state.tailRatioImgCanvas, trImg) {
 			state.tailRatioImgCanvas.Image = trImg
 			_, chh := chartSize(state)
 			state.tailRatioImgCanvas.SetMinSize(fyne.NewSize(0, float32(chh)))
@@ -5061,9 +6682,9 @@ func redrawCharts(state *uiState) {
 		updateFindMatches(state)
 	}
 	// TTFB Tail Heaviness (P95/P50)
-	ttrImg := renderTTFBTailHeavinessChart(state)
+	ttrImg := cachedRender(state, "renderTTFBTailHeavinessChart", renderTTFBTailHeavinessChart)
 	if ttrImg != nil {
-		if state.ttfbTailRatioImgCanvas != nil {
+		if state.ttfbTailRatioImgCanvas != nil && chartImageChanged(state.ttfbTailRatioImgCanvas, ttrImg) {
 			state.ttfbTailRatioImgCanvas.Image = ttrImg
 			_, chh := chartSize(state)
 			state.ttfbTailRatioImgCanvas.SetMinSize(fyne.NewSize(0, float32(chh)))
@@ -5074,9 +6695,9 @@ func redrawCharts(state *uiState) {
 		}
 	}
 	// Family Delta – Speed
-	sdImg := renderFamilyDeltaSpeedChart(state)
+	sdImg := cachedRender(state, "renderFamilyDeltaSpeedChart", renderFamilyDeltaSpeedChart)
 	if sdImg != nil {
-		if state.speedDeltaImgCanvas != nil {
+		if state.speedDeltaImgCanvas != nil && chartImageChanged(state.speedDeltaImgCanvas, sdImg) {
 			state.speedDeltaImgCanvas.Image = sdImg
 			_, chh := chartSize(state)
 			state.speedDeltaImgCanvas.SetMinSize(fyne.NewSize(0, float32(chh)))
@@ -5087,9 +6708,9 @@ func redrawCharts(state *uiState) {
 		}
 	}
 	// Family Delta – TTFB
-	tdImg := renderFamilyDeltaTTFBChart(state)
+	tdImg := cachedRender(state, "renderFamilyDeltaTTFBChart", renderFamilyDeltaTTFBChart)
 	if tdImg != nil {
-		if state.ttfbDeltaImgCanvas != nil {
+		if state.ttfbDeltaImgCanvas != nil && chartImageChanged(state.ttfbDeltaImgCanvas, tdImg) {
 			state.ttfbDeltaImgCanvas.Image = tdImg
 			_, chh := chartSize(state)
 			state.ttfbDeltaImgCanvas.SetMinSize(fyne.NewSize(0, float32(chh)))
@@ -5100,9 +6721,9 @@ func redrawCharts(state *uiState) {
 		}
 	}
 	// Family Delta – Speed %
-	sdpImg := renderFamilyDeltaSpeedPctChart(state)
+	sdpImg := cachedRender(state, "renderFamilyDeltaSpeedPctChart", renderFamilyDeltaSpeedPctChart)
 	if sdpImg != nil {
-		if state.speedDeltaPctImgCanvas != nil {
+		if state.speedDeltaPctImgCanvas != nil && chartImageChanged(state.speedDeltaPctImgCanvas, sdpImg) {
 			state.speedDeltaPctImgCanvas.Image = sdpImg
 			_, chh := chartSize(state)
 			state.speedDeltaPctImgCanvas.SetMinSize(fyne.NewSize(0, float32(chh)))
@@ -5113,9 +6734,9 @@ func redrawCharts(state *uiState) {
 		}
 	}
 	// Family Delta – TTFB %
-	tdpImg := renderFamilyDeltaTTFBPctChart(state)
+	tdpImg := cachedRender(state, "renderFamilyDeltaTTFBPctChart", renderFamilyDeltaTTFBPctChart)
 	if tdpImg != nil {
-		if state.ttfbDeltaPctImgCanvas != nil {
+		if state.ttfbDeltaPctImgCanvas != nil && chartImageChanged(state.ttfbDeltaPctImgCanvas, tdpImg) {
 			state.ttfbDeltaPctImgCanvas.Image = tdpImg
 			_, chh := chartSize(state)
 			state.ttfbDeltaPctImgCanvas.SetMinSize(fyne.NewSize(0, float32(chh)))
@@ -5126,9 +6747,9 @@ func redrawCharts(state *uiState) {
 		}
 	}
 	// SLA Compliance – Speed
-	slasImg := renderSLASpeedChart(state)
+	slasImg := cachedRender(state, "renderSLASpeedChart", renderSLASpeedChart)
 	if slasImg != nil {
-		if state.slaSpeedImgCanvas != nil {
+		if state.slaSpeedImgCanvas != nil && chartImageChanged(state.slaSpeedImgCanvas, slasImg) {
 			state.slaSpeedImgCanvas.Image = slasImg
 			_, chh := chartSize(state)
 			state.slaSpeedImgCanvas.SetMinSize(fyne.NewSize(0, float32(chh)))
@@ -5139,9 +6760,9 @@ func redrawCharts(state *uiState) {
 		}
 	}
 	// SLA Compliance – TTFB
-	slatImg := renderSLATTFBChart(state)
+	slatImg := cachedRender(state, "renderSLATTFBChart", renderSLATTFBChart)
 	if slatImg != nil {
-		if state.slaTTFBImgCanvas != nil {
+		if state.slaTTFBImgCanvas != nil && chartImageChanged(state.slaTTFBImgCanvas, slatImg) {
 			state.slaTTFBImgCanvas.Image = slatImg
 			_, chh := chartSize(state)
 			state.slaTTFBImgCanvas.SetMinSize(fyne.NewSize(0, float32(chh)))
@@ -5152,9 +6773,9 @@ func redrawCharts(state *uiState) {
 		}
 	}
 	// SLA Compliance Delta – Speed
-	slaSpdDelta := renderSLASpeedDeltaChart(state)
+	slaSpdDelta := cachedRender(state, "renderSLASpeedDeltaChart", renderSLASpeedDeltaChart)
 	if slaSpdDelta != nil {
-		if state.slaSpeedDeltaImgCanvas != nil {
+		if state.slaSpeedDeltaImgCanvas != nil && chartImageChanged(state.slaSpeedDeltaImgCanvas, slaSpdDelta) {
 			state.slaSpeedDeltaImgCanvas.Image = slaSpdDelta
 			_, chh := chartSize(state)
 			state.slaSpeedDeltaImgCanvas.SetMinSize(fyne.NewSize(0, float32(chh)))
@@ -5165,9 +6786,9 @@ func redrawCharts(state *uiState) {
 		}
 	}
 	// SLA Compliance Delta – TTFB
-	slaTtfbDelta := renderSLATTFBDeltaChart(state)
+	slaTtfbDelta := cachedRender(state, "renderSLATTFBDeltaChart", renderSLATTFBDeltaChart)
 	if slaTtfbDelta != nil {
-		if state.slaTTFBDeltaImgCanvas != nil {
+		if state.slaTTFBDeltaImgCanvas != nil && chartImageChanged(state.slaTTFBDeltaImgCanvas, slaTtfbDelta) {
 			state.slaTTFBDeltaImgCanvas.Image = slaTtfbDelta
 			_, chh := chartSize(state)
 			state.slaTTFBDeltaImgCanvas.SetMinSize(fyne.NewSize(0, float32(chh)))
@@ -5178,9 +6799,9 @@ func redrawCharts(state *uiState) {
 		}
 	}
 	// TTFB P95−P50 Gap (ms)
-	gapImg := renderTTFBP95GapChart(state)
+	gapImg := cachedRender(state, "renderTTFBP95GapChart", renderTTFBP95GapChart)
 	if gapImg != nil {
-		if state.tpctlP95GapImgCanvas != nil {
+		if state.tpctlP95GapImgCanvas != nil && chartImageChanged(state.tpctlP95GapImgCanvas, gapImg) {
 			state.tpctlP95GapImgCanvas.Image = gapImg
 			_, chh := chartSize(state)
 			state.tpctlP95GapImgCanvas.SetMinSize(fyne.NewSize(0, float32(chh)))
@@ -5191,9 +6812,9 @@ func redrawCharts(state *uiState) {
 		}
 	}
 	// Error Rate chart
-	erImg := renderErrorRateChart(state)
+	erImg := cachedRender(state, "renderErrorRateChart", renderErrorRateChart)
 	if erImg != nil {
-		if state.errImgCanvas != nil {
+		if state.errImgCanvas != nil && chartImageChanged(state.errImgCanvas, erImg) {
 			state.errImgCanvas.Image = erImg
 		}
 		_, chh := chartSize(state)
@@ -5206,9 +6827,9 @@ func redrawCharts(state *uiState) {
 		}
 	}
 	// Jitter chart
-	jitImg := renderJitterChart(state)
+	jitImg := cachedRender(state, "renderJitterChart", renderJitterChart)
 	if jitImg != nil {
-		if state.jitterImgCanvas != nil {
+		if state.jitterImgCanvas != nil && chartImageChanged(state.jitterImgCanvas, jitImg) {
 			state.jitterImgCanvas.Image = jitImg
 		}
 		_, chh := chartSize(state)
@@ -5220,10 +6841,35 @@ func redrawCharts(state *uiState) {
 			state.jitterOverlay.Refresh()
 		}
 	}
+	for _, hm := range []struct {
+		key    string
+		render func(*uiState) image.Image
+		c      *canvas.Image
+	}{
+		{"renderSpeedHeatmapChart", renderSpeedHeatmapChart, state.speedHeatmapImgCanvas},
+		{"renderTTFBHeatmapChart", renderTTFBHeatmapChart, state.ttfbHeatmapImgCanvas},
+	} {
+		if img := cachedRender(state, hm.key, hm.render); img != nil && hm.c != nil && chartImageChanged(hm.c, img) {
+			hm.c.Image = img
+			_, chh := chartSize(state)
+			hm.c.SetMinSize(fyne.NewSize(0, float32(chh)))
+			hm.c.Refresh()
+		}
+	}
+	pingJitterImg := cachedRender(state, "renderPingJitterChart", renderPingJitterChart)
+	if pingJitterImg != nil && chartImageChanged(state.pingJitterImgCanvas, pingJitterImg) {
+		state.pingJitterImgCanvas.Image = pingJitterImg
+		_, chh := chartSize(state)
+		state.pingJitterImgCanvas.SetMinSize(fyne.NewSize(0, float32(chh)))
+		state.pingJitterImgCanvas.Refresh()
+		if state.pingJitterOverlay != nil {
+			state.pingJitterOverlay.Refresh()
+		}
+	}
 	// Coefficient of Variation chart
-	covImg := renderCoVChart(state)
+	covImg := cachedRender(state, "renderCoVChart", renderCoVChart)
 	if covImg != nil {
-		if state.covImgCanvas != nil {
+		if state.covImgCanvas != nil && chartImageChanged(state.covImgCanvas, covImg) {
 			state.covImgCanvas.Image = covImg
 		}
 		_, chh := chartSize(state)
@@ -5235,27 +6881,27 @@ func redrawCharts(state *uiState) {
 			state.covOverlay.Refresh()
 		}
 		// Connection setup breakdown charts (DNS, TCP connect, TLS handshake)
-		dnsImg := renderDNSLookupChart(state)
+		dnsImg := cachedRender(state, "renderDNSLookupChart", renderDNSLookupChart)
 		if dnsImg != nil {
-			if state.setupDNSImgCanvas != nil {
+			if state.setupDNSImgCanvas != nil && chartImageChanged(state.setupDNSImgCanvas, dnsImg) {
 				state.setupDNSImgCanvas.Image = dnsImg
 				_, chh := chartSize(state)
 				state.setupDNSImgCanvas.SetMinSize(fyne.NewSize(0, float32(chh)))
 				state.setupDNSImgCanvas.Refresh()
 			}
 		}
-		connImg := renderTCPConnectChart(state)
+		connImg := cachedRender(state, "renderTCPConnectChart", renderTCPConnectChart)
 		if connImg != nil {
-			if state.setupConnImgCanvas != nil {
+			if state.setupConnImgCanvas != nil && chartImageChanged(state.setupConnImgCanvas, connImg) {
 				state.setupConnImgCanvas.Image = connImg
 				_, chh := chartSize(state)
 				state.setupConnImgCanvas.SetMinSize(fyne.NewSize(0, float32(chh)))
 				state.setupConnImgCanvas.Refresh()
 			}
 		}
-		tlsImg := renderTLSHandshakeChart(state)
+		tlsImg := cachedRender(state, "renderTLSHandshakeChart", renderTLSHandshakeChart)
 		if tlsImg != nil {
-			if state.setupTLSImgCanvas != nil {
+			if state.setupTLSImgCanvas != nil && chartImageChanged(state.setupTLSImgCanvas, tlsImg) {
 				state.setupTLSImgCanvas.Image = tlsImg
 				_, chh := chartSize(state)
 				state.setupTLSImgCanvas.SetMinSize(fyne.NewSize(0, float32(chh)))
@@ -5263,9 +6909,9 @@ func redrawCharts(state *uiState) {
 			}
 		}
 		// Batch Host/IP Timing Avg chart
-		hipAvgImg := renderHostIPTimingAvgChart(state)
+		hipAvgImg := cachedRender(state, "renderHostIPTimingAvgChart", renderHostIPTimingAvgChart)
 		if hipAvgImg != nil {
-			if state.hostIPTimingAvgImgCanvas != nil {
+			if state.hostIPTimingAvgImgCanvas != nil && chartImageChanged(state.hostIPTimingAvgImgCanvas, hipAvgImg) {
 				state.hostIPTimingAvgImgCanvas.Image = hipAvgImg
 				_, chh := chartSize(state)
 				state.hostIPTimingAvgImgCanvas.SetMinSize(fyne.NewSize(0, float32(chh)))
@@ -5276,8 +6922,8 @@ func redrawCharts(state *uiState) {
 			}
 		}
 		// Transport/Protocol charts
-		pmImg := renderHTTPProtocolMixChart(state)
-		if pmImg != nil {
+		pmImg := cachedRender(state, "renderHTTPProtocolMixChart", renderHTTPProtocolMixChart)
+		if pmImg != nil && chartImageChanged(state.protocolMixImgCanvas, pmImg) {
 			state.protocolMixImgCanvas.Image = pmImg
 			_, chh := chartSize(state)
 			state.protocolMixImgCanvas.SetMinSize(fyne.NewSize(0, float32(chh)))
@@ -5286,8 +6932,8 @@ func redrawCharts(state *uiState) {
 				state.protocolMixOverlay.Refresh()
 			}
 		}
-		pasImg := renderAvgSpeedByHTTPProtocolChart(state)
-		if pasImg != nil {
+		pasImg := cachedRender(state, "renderAvgSpeedByHTTPProtocolChart", renderAvgSpeedByHTTPProtocolChart)
+		if pasImg != nil && chartImageChanged(state.protocolAvgSpeedImgCanvas, pasImg) {
 			state.protocolAvgSpeedImgCanvas.Image = pasImg
 			_, chh := chartSize(state)
 			state.protocolAvgSpeedImgCanvas.SetMinSize(fyne.NewSize(0, float32(chh)))
@@ -5296,8 +6942,18 @@ func redrawCharts(state *uiState) {
 				state.protocolAvgSpeedOverlay.Refresh()
 			}
 		}
-		psrImg := renderStallRateByHTTPProtocolChart(state)
-		if psrImg != nil {
+		protocolTTFBImg := cachedRender(state, "renderTTFBByHTTPProtocolChart", renderTTFBByHTTPProtocolChart)
+		if protocolTTFBImg != nil && chartImageChanged(state.protocolTTFBImgCanvas, protocolTTFBImg) {
+			state.protocolTTFBImgCanvas.Image = protocolTTFBImg
+			_, chh := chartSize(state)
+			state.protocolTTFBImgCanvas.SetMinSize(fyne.NewSize(0, float32(chh)))
+			state.protocolTTFBImgCanvas.Refresh()
+			if state.protocolTTFBOverlay != nil {
+				state.protocolTTFBOverlay.Refresh()
+			}
+		}
+		psrImg := cachedRender(state, "renderStallRateByHTTPProtocolChart", renderStallRateByHTTPProtocolChart)
+		if psrImg != nil && chartImageChanged(state.protocolStallRateImgCanvas, psrImg) {
 			state.protocolStallRateImgCanvas.Image = psrImg
 			_, chh := chartSize(state)
 			state.protocolStallRateImgCanvas.SetMinSize(fyne.NewSize(0, float32(chh)))
@@ -5307,8 +6963,8 @@ func redrawCharts(state *uiState) {
 			}
 		}
 		// Stall Share by HTTP Protocol
-		pssImg := renderStallShareByHTTPProtocolChart(state)
-		if pssImg != nil {
+		pssImg := cachedRender(state, "renderStallShareByHTTPProtocolChart", renderStallShareByHTTPProtocolChart)
+		if pssImg != nil && chartImageChanged(state.protocolStallShareImgCanvas, pssImg) {
 			state.protocolStallShareImgCanvas.Image = pssImg
 			_, chh := chartSize(state)
 			state.protocolStallShareImgCanvas.SetMinSize(fyne.NewSize(0, float32(chh)))
@@ -5317,8 +6973,8 @@ func redrawCharts(state *uiState) {
 				state.protocolStallShareOverlay.Refresh()
 			}
 		}
-		perImg := renderErrorRateByHTTPProtocolChart(state)
-		if perImg != nil {
+		perImg := cachedRender(state, "renderErrorRateByHTTPProtocolChart", renderErrorRateByHTTPProtocolChart)
+		if perImg != nil && chartImageChanged(state.protocolErrorRateImgCanvas, perImg) {
 			state.protocolErrorRateImgCanvas.Image = perImg
 			_, chh := chartSize(state)
 			state.protocolErrorRateImgCanvas.SetMinSize(fyne.NewSize(0, float32(chh)))
@@ -5328,8 +6984,8 @@ func redrawCharts(state *uiState) {
 			}
 		}
 		// Error Share by HTTP Protocol
-		pesImg := renderErrorShareByHTTPProtocolChart(state)
-		if pesImg != nil {
+		pesImg := cachedRender(state, "renderErrorShareByHTTPProtocolChart", renderErrorShareByHTTPProtocolChart)
+		if pesImg != nil && chartImageChanged(state.protocolErrorShareImgCanvas, pesImg) {
 			state.protocolErrorShareImgCanvas.Image = pesImg
 			_, chh := chartSize(state)
 			state.protocolErrorShareImgCanvas.SetMinSize(fyne.NewSize(0, float32(chh)))
@@ -5339,38 +6995,38 @@ func redrawCharts(state *uiState) {
 			}
 		}
 		// Error Types composition chart
-		etImg := renderErrorTypesChart(state)
-		if etImg != nil {
+		etImg := cachedRender(state, "renderErrorTypesChart", renderErrorTypesChart)
+		if etImg != nil && chartImageChanged(state.errorTypesImgCanvas, etImg) {
 			state.errorTypesImgCanvas.Image = etImg
 			_, chh := chartSize(state)
 			state.errorTypesImgCanvas.SetMinSize(fyne.NewSize(0, float32(chh)))
 			state.errorTypesImgCanvas.Refresh()
 		}
 		// Error Reasons composition chart
-		erImg := renderErrorReasonsChart(state)
-		if erImg != nil {
+		erImg := cachedRender(state, "renderErrorReasonsChart", renderErrorReasonsChart)
+		if erImg != nil && chartImageChanged(state.errorReasonsImgCanvas, erImg) {
 			state.errorReasonsImgCanvas.Image = erImg
 			_, chh := chartSize(state)
 			state.errorReasonsImgCanvas.SetMinSize(fyne.NewSize(0, float32(chh)))
 			state.errorReasonsImgCanvas.Refresh()
 		}
 		// Error Reasons (detailed) composition chart
-		erdImg := renderErrorReasonsDetailedChart(state)
-		if erdImg != nil {
+		erdImg := cachedRender(state, "renderErrorReasonsDetailedChart", renderErrorReasonsDetailedChart)
+		if erdImg != nil && chartImageChanged(state.errorReasonsDetailedImgCanvas, erdImg) {
 			state.errorReasonsDetailedImgCanvas.Image = erdImg
 			_, chh := chartSize(state)
 			state.errorReasonsDetailedImgCanvas.SetMinSize(fyne.NewSize(0, float32(chh)))
 			state.errorReasonsDetailedImgCanvas.Refresh()
 		}
 		// Errors by URL (Top 12) – selected batch only
-		if img := renderErrorsByURLChart(state); img != nil {
+		if img := cachedRender(state, "renderErrorsByURLChart", renderErrorsByURLChart); img != nil && chartImageChanged(state.errorsByURLImgCanvas, img) {
 			state.errorsByURLImgCanvas.Image = img
 			_, chh := chartSize(state)
 			state.errorsByURLImgCanvas.SetMinSize(fyne.NewSize(0, float32(chh)))
 			state.errorsByURLImgCanvas.Refresh()
 		}
-		ppImg := renderPartialBodyRateByHTTPProtocolChart(state)
-		if ppImg != nil {
+		ppImg := cachedRender(state, "renderPartialBodyRateByHTTPProtocolChart", renderPartialBodyRateByHTTPProtocolChart)
+		if ppImg != nil && chartImageChanged(state.protocolPartialRateImgCanvas, ppImg) {
 			state.protocolPartialRateImgCanvas.Image = ppImg
 			_, chh := chartSize(state)
 			state.protocolPartialRateImgCanvas.SetMinSize(fyne.NewSize(0, float32(chh)))
@@ -5380,8 +7036,8 @@ func redrawCharts(state *uiState) {
 			}
 		}
 		// Partial Share by HTTP Protocol
-		ppsImg := renderPartialShareByHTTPProtocolChart(state)
-		if ppsImg != nil {
+		ppsImg := cachedRender(state, "renderPartialShareByHTTPProtocolChart", renderPartialShareByHTTPProtocolChart)
+		if ppsImg != nil && chartImageChanged(state.protocolPartialShareImgCanvas, ppsImg) {
 			state.protocolPartialShareImgCanvas.Image = ppsImg
 			_, chh := chartSize(state)
 			state.protocolPartialShareImgCanvas.SetMinSize(fyne.NewSize(0, float32(chh)))
@@ -5390,8 +7046,8 @@ func redrawCharts(state *uiState) {
 				state.protocolPartialShareOverlay.Refresh()
 			}
 		}
-		tlsMixImg := renderTLSVersionMixChart(state)
-		if tlsMixImg != nil {
+		tlsMixImg := cachedRender(state, "renderTLSVersionMixChart", renderTLSVersionMixChart)
+		if tlsMixImg != nil && chartImageChanged(state.tlsVersionMixImgCanvas, tlsMixImg) {
 			state.tlsVersionMixImgCanvas.Image = tlsMixImg
 			_, chh := chartSize(state)
 			state.tlsVersionMixImgCanvas.SetMinSize(fyne.NewSize(0, float32(chh)))
@@ -5400,8 +7056,8 @@ func redrawCharts(state *uiState) {
 				state.tlsVersionMixOverlay.Refresh()
 			}
 		}
-		alpnImg := renderALPNMixChart(state)
-		if alpnImg != nil {
+		alpnImg := cachedRender(state, "renderALPNMixChart", renderALPNMixChart)
+		if alpnImg != nil && chartImageChanged(state.alpnMixImgCanvas, alpnImg) {
 			state.alpnMixImgCanvas.Image = alpnImg
 			_, chh := chartSize(state)
 			state.alpnMixImgCanvas.SetMinSize(fyne.NewSize(0, float32(chh)))
@@ -5410,8 +7066,8 @@ func redrawCharts(state *uiState) {
 				state.alpnMixOverlay.Refresh()
 			}
 		}
-		chunkedImg := renderChunkedTransferRateChart(state)
-		if chunkedImg != nil {
+		chunkedImg := cachedRender(state, "renderChunkedTransferRateChart", renderChunkedTransferRateChart)
+		if chunkedImg != nil && chartImageChanged(state.chunkedRateImgCanvas, chunkedImg) {
 			state.chunkedRateImgCanvas.Image = chunkedImg
 			_, chh := chartSize(state)
 			state.chunkedRateImgCanvas.SetMinSize(fyne.NewSize(0, float32(chh)))
@@ -5420,10 +7076,150 @@ func redrawCharts(state *uiState) {
 				state.chunkedRateOverlay.Refresh()
 			}
 		}
+		compressionImg := cachedRender(state, "renderCompressionRatioChart", renderCompressionRatioChart)
+		if compressionImg != nil && chartImageChanged(state.compressionImgCanvas, compressionImg) {
+			state.compressionImgCanvas.Image = compressionImg
+			_, chh := chartSize(state)
+			state.compressionImgCanvas.SetMinSize(fyne.NewSize(0, float32(chh)))
+			state.compressionImgCanvas.Refresh()
+			if state.compressionOverlay != nil {
+				state.compressionOverlay.Refresh()
+			}
+		}
+		serverTimingImg := cachedRender(state, "renderServerTimingChart", renderServerTimingChart)
+		if serverTimingImg != nil && chartImageChanged(state.serverTimingImgCanvas, serverTimingImg) {
+			state.serverTimingImgCanvas.Image = serverTimingImg
+			_, chh := chartSize(state)
+			state.serverTimingImgCanvas.SetMinSize(fyne.NewSize(0, float32(chh)))
+			state.serverTimingImgCanvas.Refresh()
+			if state.serverTimingOverlay != nil {
+				state.serverTimingOverlay.Refresh()
+			}
+		}
+		tcpRetransImg := cachedRender(state, "renderTCPRetransChart", renderTCPRetransChart)
+		if tcpRetransImg != nil && chartImageChanged(state.tcpRetransImgCanvas, tcpRetransImg) {
+			state.tcpRetransImgCanvas.Image = tcpRetransImg
+			_, chh := chartSize(state)
+			state.tcpRetransImgCanvas.SetMinSize(fyne.NewSize(0, float32(chh)))
+			state.tcpRetransImgCanvas.Refresh()
+			if state.tcpRetransOverlay != nil {
+				state.tcpRetransOverlay.Refresh()
+			}
+		}
+		iperf3Img := cachedRender(state, "renderIPerf3Chart", renderIPerf3Chart)
+		if iperf3Img != nil && chartImageChanged(state.iperf3ImgCanvas, iperf3Img) {
+			state.iperf3ImgCanvas.Image = iperf3Img
+			_, chh := chartSize(state)
+			state.iperf3ImgCanvas.SetMinSize(fyne.NewSize(0, float32(chh)))
+			state.iperf3ImgCanvas.Refresh()
+			if state.iperf3Overlay != nil {
+				state.iperf3Overlay.Refresh()
+			}
+		}
+		qualityScoreImg := cachedRender(state, "renderQualityScoreChart", renderQualityScoreChart)
+		if qualityScoreImg != nil && chartImageChanged(state.qualityScoreImgCanvas, qualityScoreImg) {
+			state.qualityScoreImgCanvas.Image = qualityScoreImg
+			_, chh := chartSize(state)
+			state.qualityScoreImgCanvas.SetMinSize(fyne.NewSize(0, float32(chh)))
+			state.qualityScoreImgCanvas.Refresh()
+			if state.qualityScoreOverlay != nil {
+				state.qualityScoreOverlay.Refresh()
+			}
+		}
+		planAttainmentImg := cachedRender(state, "renderPlanAttainmentChart", renderPlanAttainmentChart)
+		if planAttainmentImg != nil && chartImageChanged(state.planAttainmentImgCanvas, planAttainmentImg) {
+			state.planAttainmentImgCanvas.Image = planAttainmentImg
+			_, chh := chartSize(state)
+			state.planAttainmentImgCanvas.SetMinSize(fyne.NewSize(0, float32(chh)))
+			state.planAttainmentImgCanvas.Refresh()
+			if state.planAttainmentOverlay != nil {
+				state.planAttainmentOverlay.Refresh()
+			}
+		}
+		stabilityImg := cachedRender(state, "renderThroughputStabilityChart", renderThroughputStabilityChart)
+		if stabilityImg != nil && chartImageChanged(state.stabilityImgCanvas, stabilityImg) {
+			state.stabilityImgCanvas.Image = stabilityImg
+			_, chh := chartSize(state)
+			state.stabilityImgCanvas.SetMinSize(fyne.NewSize(0, float32(chh)))
+			state.stabilityImgCanvas.Refresh()
+			if state.stabilityOverlay != nil {
+				state.stabilityOverlay.Refresh()
+			}
+		}
+		ipv6ReadinessImg := cachedRender(state, "renderIPv6ReadinessChart", renderIPv6ReadinessChart)
+		if ipv6ReadinessImg != nil && chartImageChanged(state.ipv6ReadinessImgCanvas, ipv6ReadinessImg) {
+			state.ipv6ReadinessImgCanvas.Image = ipv6ReadinessImg
+			_, chh := chartSize(state)
+			state.ipv6ReadinessImgCanvas.SetMinSize(fyne.NewSize(0, float32(chh)))
+			state.ipv6ReadinessImgCanvas.Refresh()
+			if state.ipv6ReadinessOverlay != nil {
+				state.ipv6ReadinessOverlay.Refresh()
+			}
+		}
+		heLostImg := cachedRender(state, "renderHappyEyeballsIPv6LostChart", renderHappyEyeballsIPv6LostChart)
+		if heLostImg != nil && chartImageChanged(state.heLostImgCanvas, heLostImg) {
+			state.heLostImgCanvas.Image = heLostImg
+			_, chh := chartSize(state)
+			state.heLostImgCanvas.SetMinSize(fyne.NewSize(0, float32(chh)))
+			state.heLostImgCanvas.Refresh()
+			if state.heLostOverlay != nil {
+				state.heLostOverlay.Refresh()
+			}
+		}
+		udpBlockedImg := cachedRender(state, "renderUDPBlockedRateChart", renderUDPBlockedRateChart)
+		if udpBlockedImg != nil && chartImageChanged(state.udpBlockedImgCanvas, udpBlockedImg) {
+			state.udpBlockedImgCanvas.Image = udpBlockedImg
+			_, chh := chartSize(state)
+			state.udpBlockedImgCanvas.SetMinSize(fyne.NewSize(0, float32(chh)))
+			state.udpBlockedImgCanvas.Refresh()
+			if state.udpBlockedOverlay != nil {
+				state.udpBlockedOverlay.Refresh()
+			}
+		}
+		returnHopsImg := cachedRender(state, "renderReturnHopsChart", renderReturnHopsChart)
+		if returnHopsImg != nil && chartImageChanged(state.returnHopsImgCanvas, returnHopsImg) {
+			state.returnHopsImgCanvas.Image = returnHopsImg
+			_, chh := chartSize(state)
+			state.returnHopsImgCanvas.SetMinSize(fyne.NewSize(0, float32(chh)))
+			state.returnHopsImgCanvas.Refresh()
+			if state.returnHopsOverlay != nil {
+				state.returnHopsOverlay.Refresh()
+			}
+		}
+		coldWarmTTFBImg := cachedRender(state, "renderColdWarmTTFBChart", renderColdWarmTTFBChart)
+		if coldWarmTTFBImg != nil && chartImageChanged(state.coldWarmTTFBImgCanvas, coldWarmTTFBImg) {
+			state.coldWarmTTFBImgCanvas.Image = coldWarmTTFBImg
+			_, chh := chartSize(state)
+			state.coldWarmTTFBImgCanvas.SetMinSize(fyne.NewSize(0, float32(chh)))
+			state.coldWarmTTFBImgCanvas.Refresh()
+			if state.coldWarmTTFBOverlay != nil {
+				state.coldWarmTTFBOverlay.Refresh()
+			}
+		}
+		wifiRSSIImg := cachedRender(state, "renderWiFiRSSIChart", renderWiFiRSSIChart)
+		if wifiRSSIImg != nil && chartImageChanged(state.wifiRSSIImgCanvas, wifiRSSIImg) {
+			state.wifiRSSIImgCanvas.Image = wifiRSSIImg
+			_, chh := chartSize(state)
+			state.wifiRSSIImgCanvas.SetMinSize(fyne.NewSize(0, float32(chh)))
+			state.wifiRSSIImgCanvas.Refresh()
+			if state.wifiRSSIOverlay != nil {
+				state.wifiRSSIOverlay.Refresh()
+			}
+		}
+		wifiPHYImg := cachedRender(state, "renderWiFiPHYRateChart", renderWiFiPHYRateChart)
+		if wifiPHYImg != nil && chartImageChanged(state.wifiPHYImgCanvas, wifiPHYImg) {
+			state.wifiPHYImgCanvas.Image = wifiPHYImg
+			_, chh := chartSize(state)
+			state.wifiPHYImgCanvas.SetMinSize(fyne.NewSize(0, float32(chh)))
+			state.wifiPHYImgCanvas.Refresh()
+			if state.wifiPHYOverlay != nil {
+				state.wifiPHYOverlay.Refresh()
+			}
+		}
 		// Cache Hit Rate chart
-		cacheImg := renderCacheHitRateChart(state)
+		cacheImg := cachedRender(state, "renderCacheHitRateChart", renderCacheHitRateChart)
 		if cacheImg != nil {
-			if state.cacheImgCanvas != nil {
+			if state.cacheImgCanvas != nil && chartImageChanged(state.cacheImgCanvas, cacheImg) {
 				state.cacheImgCanvas.Image = cacheImg
 			}
 			_, chh := chartSize(state)
@@ -5436,9 +7232,9 @@ func redrawCharts(state *uiState) {
 			}
 		}
 		// Enterprise Proxy Rate chart
-		entProxyImg := renderEnterpriseProxyRateChart(state)
+		entProxyImg := cachedRender(state, "renderEnterpriseProxyRateChart", renderEnterpriseProxyRateChart)
 		if entProxyImg != nil {
-			if state.enterpriseProxyImgCanvas != nil {
+			if state.enterpriseProxyImgCanvas != nil && chartImageChanged(state.enterpriseProxyImgCanvas, entProxyImg) {
 				state.enterpriseProxyImgCanvas.Image = entProxyImg
 			}
 			_, chh := chartSize(state)
@@ -5448,9 +7244,9 @@ func redrawCharts(state *uiState) {
 			}
 		}
 		// Server-side Proxy Rate chart
-		srvProxyImg := renderServerProxyRateChart(state)
+		srvProxyImg := cachedRender(state, "renderServerProxyRateChart", renderServerProxyRateChart)
 		if srvProxyImg != nil {
-			if state.serverProxyImgCanvas != nil {
+			if state.serverProxyImgCanvas != nil && chartImageChanged(state.serverProxyImgCanvas, srvProxyImg) {
 				state.serverProxyImgCanvas.Image = srvProxyImg
 			}
 			_, chh := chartSize(state)
@@ -5460,9 +7256,9 @@ func redrawCharts(state *uiState) {
 			}
 		}
 		// Warm Cache Suspected Rate chart
-		warmImg := renderWarmCacheSuspectedRateChart(state)
+		warmImg := cachedRender(state, "renderWarmCacheSuspectedRateChart", renderWarmCacheSuspectedRateChart)
 		if warmImg != nil {
-			if state.warmCacheImgCanvas != nil {
+			if state.warmCacheImgCanvas != nil && chartImageChanged(state.warmCacheImgCanvas, warmImg) {
 				state.warmCacheImgCanvas.Image = warmImg
 			}
 			_, chh := chartSize(state)
@@ -5475,9 +7271,9 @@ func redrawCharts(state *uiState) {
 			}
 		}
 		// Low-Speed Time Share chart
-		lssImg := renderLowSpeedShareChart(state)
+		lssImg := cachedRender(state, "renderLowSpeedShareChart", renderLowSpeedShareChart)
 		if lssImg != nil {
-			if state.lowSpeedImgCanvas != nil {
+			if state.lowSpeedImgCanvas != nil && chartImageChanged(state.lowSpeedImgCanvas, lssImg) {
 				state.lowSpeedImgCanvas.Image = lssImg
 			}
 			_, chh := chartSize(state)
@@ -5490,9 +7286,9 @@ func redrawCharts(state *uiState) {
 			}
 		}
 		// Stall Rate chart
-		srImg := renderStallRateChart(state)
+		srImg := cachedRender(state, "renderStallRateChart", renderStallRateChart)
 		if srImg != nil {
-			if state.stallRateImgCanvas != nil {
+			if state.stallRateImgCanvas != nil && chartImageChanged(state.stallRateImgCanvas, srImg) {
 				state.stallRateImgCanvas.Image = srImg
 			}
 			_, chh := chartSize(state)
@@ -5505,9 +7301,9 @@ func redrawCharts(state *uiState) {
 			}
 		}
 		// Pre‑TTFB Stall Rate chart
-		pretffbImg := renderPreTTFBStallRateChart(state)
+		pretffbImg := cachedRender(state, "renderPreTTFBStallRateChart", renderPreTTFBStallRateChart)
 		if pretffbImg != nil {
-			if state.pretffbImgCanvas != nil {
+			if state.pretffbImgCanvas != nil && chartImageChanged(state.pretffbImgCanvas, pretffbImg) {
 				state.pretffbImgCanvas.Image = pretffbImg
 			}
 			_, chh := chartSize(state)
@@ -5544,9 +7340,9 @@ func redrawCharts(state *uiState) {
 			}
 		}
 		// Avg Stall Time chart
-		stImg := renderStallTimeChart(state)
+		stImg := cachedRender(state, "renderStallTimeChart", renderStallTimeChart)
 		if stImg != nil {
-			if state.stallTimeImgCanvas != nil {
+			if state.stallTimeImgCanvas != nil && chartImageChanged(state.stallTimeImgCanvas, stImg) {
 				state.stallTimeImgCanvas.Image = stImg
 			}
 			_, chh := chartSize(state)
@@ -5559,9 +7355,9 @@ func redrawCharts(state *uiState) {
 			}
 		}
 		// Partial Body Rate chart
-		pbrImg := renderPartialBodyRateChart(state)
+		pbrImg := cachedRender(state, "renderPartialBodyRateChart", renderPartialBodyRateChart)
 		if pbrImg != nil {
-			if state.partialBodyImgCanvas != nil {
+			if state.partialBodyImgCanvas != nil && chartImageChanged(state.partialBodyImgCanvas, pbrImg) {
 				state.partialBodyImgCanvas.Image = pbrImg
 			}
 			_, chh := chartSize(state)
@@ -5573,10 +7369,30 @@ func redrawCharts(state *uiState) {
 				state.partialBodyOverlay.Refresh()
 			}
 		}
+		contentCorruptionImg := cachedRender(state, "renderContentCorruptionRateChart", renderContentCorruptionRateChart)
+		if contentCorruptionImg != nil && chartImageChanged(state.contentCorruptionImgCanvas, contentCorruptionImg) {
+			state.contentCorruptionImgCanvas.Image = contentCorruptionImg
+			_, chh := chartSize(state)
+			state.contentCorruptionImgCanvas.SetMinSize(fyne.NewSize(0, float32(chh)))
+			state.contentCorruptionImgCanvas.Refresh()
+			if state.contentCorruptionOverlay != nil {
+				state.contentCorruptionOverlay.Refresh()
+			}
+		}
+		dataUsageImg := cachedRender(state, "renderDataUsageChart", renderDataUsageChart)
+		if dataUsageImg != nil && chartImageChanged(state.dataUsageImgCanvas, dataUsageImg) {
+			state.dataUsageImgCanvas.Image = dataUsageImg
+			_, chh := chartSize(state)
+			state.dataUsageImgCanvas.SetMinSize(fyne.NewSize(0, float32(chh)))
+			state.dataUsageImgCanvas.Refresh()
+			if state.dataUsageOverlay != nil {
+				state.dataUsageOverlay.Refresh()
+			}
+		}
 		// Stalled Requests Count (interim) chart
-		scImg := renderStallCountChart(state)
+		scImg := cachedRender(state, "renderStallCountChart", renderStallCountChart)
 		if scImg != nil {
-			if state.stallCountImgCanvas != nil {
+			if state.stallCountImgCanvas != nil && chartImageChanged(state.stallCountImgCanvas, scImg) {
 				state.stallCountImgCanvas.Image = scImg
 			}
 			_, chh := chartSize(state)
@@ -5589,9 +7405,9 @@ func redrawCharts(state *uiState) {
 			}
 		}
 		// Transient/Micro‑Stalls charts
-		msrImg := renderMicroStallRateChart(state)
+		msrImg := cachedRender(state, "renderMicroStallRateChart", renderMicroStallRateChart)
 		if msrImg != nil {
-			if state.microStallRateImgCanvas != nil {
+			if state.microStallRateImgCanvas != nil && chartImageChanged(state.microStallRateImgCanvas, msrImg) {
 				state.microStallRateImgCanvas.Image = msrImg
 			}
 			_, chh := chartSize(state)
@@ -5603,9 +7419,9 @@ func redrawCharts(state *uiState) {
 				state.microStallRateOverlay.Refresh()
 			}
 		}
-		mstImg := renderMicroStallTimeChart(state)
+		mstImg := cachedRender(state, "renderMicroStallTimeChart", renderMicroStallTimeChart)
 		if mstImg != nil {
-			if state.microStallTimeImgCanvas != nil {
+			if state.microStallTimeImgCanvas != nil && chartImageChanged(state.microStallTimeImgCanvas, mstImg) {
 				state.microStallTimeImgCanvas.Image = mstImg
 			}
 			_, chh := chartSize(state)
@@ -5617,9 +7433,9 @@ func redrawCharts(state *uiState) {
 				state.microStallTimeOverlay.Refresh()
 			}
 		}
-		mscImg := renderMicroStallCountChart(state)
+		mscImg := cachedRender(state, "renderMicroStallCountChart", renderMicroStallCountChart)
 		if mscImg != nil {
-			if state.microStallCountImgCanvas != nil {
+			if state.microStallCountImgCanvas != nil && chartImageChanged(state.microStallCountImgCanvas, mscImg) {
 				state.microStallCountImgCanvas.Image = mscImg
 			}
 			_, chh := chartSize(state)
@@ -5631,10 +7447,17 @@ func redrawCharts(state *uiState) {
 				state.microStallCountOverlay.Refresh()
 			}
 		}
+		stallTimelineImg := cachedRender(state, "renderStallTimelineChart", renderStallTimelineChart)
+		if stallTimelineImg != nil && chartImageChanged(state.stallTimelineImgCanvas, stallTimelineImg) {
+			state.stallTimelineImgCanvas.Image = stallTimelineImg
+			_, chh := chartSize(state)
+			state.stallTimelineImgCanvas.SetMinSize(fyne.NewSize(0, float32(chh)))
+			state.stallTimelineImgCanvas.Refresh()
+		}
 		// Plateau Count chart
-		plcImg := renderPlateauCountChart(state)
+		plcImg := cachedRender(state, "renderPlateauCountChart", renderPlateauCountChart)
 		if plcImg != nil {
-			if state.plCountImgCanvas != nil {
+			if state.plCountImgCanvas != nil && chartImageChanged(state.plCountImgCanvas, plcImg) {
 				state.plCountImgCanvas.Image = plcImg
 			}
 			_, chh := chartSize(state)
@@ -5647,9 +7470,9 @@ func redrawCharts(state *uiState) {
 			}
 		}
 		// Longest Plateau chart
-		pllImg := renderPlateauLongestChart(state)
+		pllImg := cachedRender(state, "renderPlateauLongestChart", renderPlateauLongestChart)
 		if pllImg != nil {
-			if state.plLongestImgCanvas != nil {
+			if state.plLongestImgCanvas != nil && chartImageChanged(state.plLongestImgCanvas, pllImg) {
 				state.plLongestImgCanvas.Image = pllImg
 			}
 			_, chh := chartSize(state)
@@ -5662,9 +7485,9 @@ func redrawCharts(state *uiState) {
 			}
 		}
 		// Plateau Stable Rate chart
-		plsImg := renderPlateauStableChart(state)
+		plsImg := cachedRender(state, "renderPlateauStableChart", renderPlateauStableChart)
 		if plsImg != nil {
-			if state.plStableImgCanvas != nil {
+			if state.plStableImgCanvas != nil && chartImageChanged(state.plStableImgCanvas, plsImg) {
 				state.plStableImgCanvas.Image = plsImg
 			}
 			_, chh := chartSize(state)
@@ -5682,6 +7505,10 @@ func redrawCharts(state *uiState) {
 	// despite Image/Refresh calls (likely a repaint/caching edge when dimensions don't change).
 	// As a low-impact safeguard, nudge chart canvases' MinSize by +1px and back to force a repaint.
 	forceRepaintOnSingleBatch(state)
+	refreshDetachedCharts(state)
+	refreshSummaryStrip(state)
+	refreshMiniStatus(state)
+	refreshChartTOC(state)
 }
 
 // chartImageCanvases returns all chart image canvases we render into. Used for repaint nudging.
@@ -5721,6 +7548,7 @@ func chartImageCanvases(state *uiState) []*canvas.Image {
 		// Error / Variability
 		state.errImgCanvas,
 		state.jitterImgCanvas,
+		state.pingJitterImgCanvas,
 		state.covImgCanvas,
 		// Setup breakdown
 		state.setupDNSImgCanvas,
@@ -5729,6 +7557,7 @@ func chartImageCanvases(state *uiState) []*canvas.Image {
 		// Protocol charts
 		state.protocolMixImgCanvas,
 		state.protocolAvgSpeedImgCanvas,
+		state.protocolTTFBImgCanvas,
 		state.protocolStallRateImgCanvas,
 		state.protocolStallShareImgCanvas,
 		state.protocolErrorRateImgCanvas,
@@ -5743,6 +7572,20 @@ func chartImageCanvases(state *uiState) []*canvas.Image {
 		state.errorReasonsDetailedImgCanvas,
 		// Transfer/other
 		state.chunkedRateImgCanvas,
+		state.compressionImgCanvas,
+		state.serverTimingImgCanvas,
+		state.tcpRetransImgCanvas,
+		state.iperf3ImgCanvas,
+		state.qualityScoreImgCanvas,
+		state.planAttainmentImgCanvas,
+		state.stabilityImgCanvas,
+		state.ipv6ReadinessImgCanvas,
+		state.heLostImgCanvas,
+		state.udpBlockedImgCanvas,
+		state.returnHopsImgCanvas,
+		state.coldWarmTTFBImgCanvas,
+		state.wifiRSSIImgCanvas,
+		state.wifiPHYImgCanvas,
 		state.cacheImgCanvas,
 		state.enterpriseProxyImgCanvas,
 		state.serverProxyImgCanvas,
@@ -5751,12 +7594,17 @@ func chartImageCanvases(state *uiState) []*canvas.Image {
 		state.lowSpeedImgCanvas,
 		state.stallRateImgCanvas,
 		state.pretffbImgCanvas,
+		state.contentCorruptionImgCanvas,
+		state.dataUsageImgCanvas,
 		state.stallTimeImgCanvas,
 		state.stallCountImgCanvas,
 		// Micro‑stalls
 		state.microStallRateImgCanvas,
 		state.microStallTimeImgCanvas,
 		state.microStallCountImgCanvas,
+		state.stallTimelineImgCanvas,
+		state.speedHeatmapImgCanvas,
+		state.ttfbHeatmapImgCanvas,
 		// Plateaus
 		state.plCountImgCanvas,
 		state.plLongestImgCanvas,
@@ -5934,7 +7782,7 @@ func renderTTFBPercentilesChartWithFamily(state *uiState, fam string) image.Imag
 		titlePrefix = "Overall "
 	}
 	ch := chart.Chart{
-		Title:      fmt.Sprintf("%sTTFB Percentiles (ms)", titlePrefix),
+		Title:      fmt.Sprintf(tr("%sTTFB Percentiles (ms)"), titlePrefix),
 		Background: chart.Style{Padding: chart.Box{Top: 14, Left: 16, Right: 12, Bottom: padBottom}},
 		XAxis:      xAxis,
 		YAxis:      chart.YAxis{Name: "ms", Range: yAxisRange, Ticks: yTicks},
@@ -5944,10 +7792,10 @@ func renderTTFBPercentilesChartWithFamily(state *uiState, fam string) image.Imag
 	cw, chh := chartSize(state)
 	ch.Width = cw
 	ch.Height = chh
-	attachLegend(&ch)
+	attachLegend(state, &ch)
 	var buf bytes.Buffer
-	if err := ch.Render(chart.PNG, &buf); err != nil {
-		fmt.Printf("[viewer] renderStallRateChart: render error: %v\n", err)
+	if err := renderChart(state, &ch, &buf); err != nil {
+		logf(slog.LevelWarn, "viewer", "renderStallRateChart: render error: %v", err)
 		return blank(cw, chh)
 	}
 	img, err := png.Decode(&buf)
@@ -6051,10 +7899,10 @@ func renderTTFBTailHeavinessChart(state *uiState) image.Image {
 	themeChart(&ch)
 	cw, chh := chartSize(state)
 	ch.Width, ch.Height = cw, chh
-	attachLegend(&ch)
+	attachLegend(state, &ch)
 	var buf bytes.Buffer
-	if err := ch.Render(chart.PNG, &buf); err != nil {
-		fmt.Printf("[viewer] renderStallTimeChart: render error: %v\n", err)
+	if err := renderChart(state, &ch, &buf); err != nil {
+		logf(slog.LevelWarn, "viewer", "renderStallTimeChart: render error: %v", err)
 		return blank(cw, chh)
 	}
 	img, err := png.Decode(&buf)
@@ -6166,10 +8014,10 @@ func renderTTFBP95GapChart(state *uiState) image.Image {
 	themeChart(&ch)
 	cw, chh := chartSize(state)
 	ch.Width, ch.Height = cw, chh
-	attachLegend(&ch)
+	attachLegend(state, &ch)
 	var buf bytes.Buffer
-	if err := ch.Render(chart.PNG, &buf); err != nil {
-		fmt.Printf("[viewer] renderStallCountChart: render error: %v\n", err)
+	if err := renderChart(state, &ch, &buf); err != nil {
+		logf(slog.LevelWarn, "viewer", "renderStallCountChart: render error: %v", err)
 		return blank(cw, chh)
 	}
 	img, err := png.Decode(&buf)
@@ -6267,17 +8115,17 @@ func renderCacheHitRateChart(state *uiState) image.Image {
 	themeChart(&ch)
 	cw, chh := chartSize(state)
 	ch.Width, ch.Height = cw, chh
-	attachLegend(&ch)
+	attachLegend(state, &ch)
 	var buf bytes.Buffer
-	if err := ch.Render(chart.PNG, &buf); err != nil {
+	if err := renderChart(state, &ch, &buf); err != nil {
 		cw, chh := chartSize(state)
-		fmt.Printf("[viewer] cache-hit render error: %v; blank fallback\n", err)
+		logf(slog.LevelWarn, "viewer", "cache-hit render error: %v; blank fallback", err)
 		return blank(cw, chh)
 	}
 	img, err := png.Decode(&buf)
 	if err != nil {
 		cw, chh := chartSize(state)
-		fmt.Printf("[viewer] cache-hit decode error: %v; blank fallback\n", err)
+		logf(slog.LevelWarn, "viewer", "cache-hit decode error: %v; blank fallback", err)
 		return blank(cw, chh)
 	}
 	if state.showHints {
@@ -6398,17 +8246,17 @@ func renderEnterpriseProxyRateChart(state *uiState) image.Image {
 	themeChart(&ch)
 	cw, chh := chartSize(state)
 	ch.Width, ch.Height = cw, chh
-	attachLegend(&ch)
+	attachLegend(state, &ch)
 	var buf bytes.Buffer
-	if err := ch.Render(chart.PNG, &buf); err != nil {
+	if err := renderChart(state, &ch, &buf); err != nil {
 		cw, chh := chartSize(state)
-		fmt.Printf("[viewer] enterprise-proxy render error: %v; blank fallback\n", err)
+		logf(slog.LevelWarn, "viewer", "enterprise-proxy render error: %v; blank fallback", err)
 		return blank(cw, chh)
 	}
 	img, err := png.Decode(&buf)
 	if err != nil {
 		cw, chh := chartSize(state)
-		fmt.Printf("[viewer] enterprise-proxy decode error: %v; blank fallback\n", err)
+		logf(slog.LevelWarn, "viewer", "enterprise-proxy decode error: %v; blank fallback", err)
 		return blank(cw, chh)
 	}
 	if state.showHints {
@@ -6527,17 +8375,17 @@ func renderServerProxyRateChart(state *uiState) image.Image {
 	themeChart(&ch)
 	cw, chh := chartSize(state)
 	ch.Width, ch.Height = cw, chh
-	attachLegend(&ch)
+	attachLegend(state, &ch)
 	var buf bytes.Buffer
-	if err := ch.Render(chart.PNG, &buf); err != nil {
+	if err := renderChart(state, &ch, &buf); err != nil {
 		cw, chh := chartSize(state)
-		fmt.Printf("[viewer] server-proxy render error: %v; blank fallback\n", err)
+		logf(slog.LevelWarn, "viewer", "server-proxy render error: %v; blank fallback", err)
 		return blank(cw, chh)
 	}
 	img, err := png.Decode(&buf)
 	if err != nil {
 		cw, chh := chartSize(state)
-		fmt.Printf("[viewer] server-proxy decode error: %v; blank fallback\n", err)
+		logf(slog.LevelWarn, "viewer", "server-proxy decode error: %v; blank fallback", err)
 		return blank(cw, chh)
 	}
 	if state.showHints {
@@ -6656,17 +8504,17 @@ func renderWarmCacheSuspectedRateChart(state *uiState) image.Image {
 	themeChart(&ch)
 	cw, chh := chartSize(state)
 	ch.Width, ch.Height = cw, chh
-	attachLegend(&ch)
+	attachLegend(state, &ch)
 	var buf bytes.Buffer
-	if err := ch.Render(chart.PNG, &buf); err != nil {
+	if err := renderChart(state, &ch, &buf); err != nil {
 		cw, chh := chartSize(state)
-		fmt.Printf("[viewer] warm-cache render error: %v; blank fallback\n", err)
+		logf(slog.LevelWarn, "viewer", "warm-cache render error: %v; blank fallback", err)
 		return blank(cw, chh)
 	}
 	img, err := png.Decode(&buf)
 	if err != nil {
 		cw, chh := chartSize(state)
-		fmt.Printf("[viewer] warm-cache decode error: %v; blank fallback\n", err)
+		logf(slog.LevelWarn, "viewer", "warm-cache decode error: %v; blank fallback", err)
 		return blank(cw, chh)
 	}
 	if state.showHints {
@@ -6781,13 +8629,13 @@ func renderLowSpeedShareChart(state *uiState) image.Image {
 	if state.showHints {
 		padBottom += 18
 	}
-	ch := chart.Chart{Title: "Low-Speed Time Share (%)", Background: chart.Style{Padding: chart.Box{Top: 14, Left: 16, Right: 12, Bottom: padBottom}}, XAxis: xAxis, YAxis: chart.YAxis{Name: "%", Range: yAxisRange, Ticks: yTicks}, Series: series}
+	ch := chart.Chart{Title: withNote("Low-Speed Time Share (%)", lowSpeedNote(filteredSummaries(state))), Background: chart.Style{Padding: chart.Box{Top: 14, Left: 16, Right: 12, Bottom: padBottom}}, XAxis: xAxis, YAxis: chart.YAxis{Name: "%", Range: yAxisRange, Ticks: yTicks}, Series: series}
 	themeChart(&ch)
 	cw, chh := chartSize(state)
 	ch.Width, ch.Height = cw, chh
-	attachLegend(&ch)
+	attachLegend(state, &ch)
 	var buf bytes.Buffer
-	if err := ch.Render(chart.PNG, &buf); err != nil {
+	if err := renderChart(state, &ch, &buf); err != nil {
 		return blank(cw, chh)
 	}
 	img, err := png.Decode(&buf)
@@ -6802,6 +8650,9 @@ func renderLowSpeedShareChart(state *uiState) image.Image {
 
 // renderStallRateChart draws Stall Rate (%) per batch (overall/IPv4/IPv6).
 func renderStallRateChart(state *uiState) image.Image {
+	if img, ok := renderSituationOverlay(state, "stall_rate"); ok {
+		return img
+	}
 	rows := filteredSummaries(state)
 	if len(rows) == 0 {
 		w, h := chartSize(state)
@@ -6907,13 +8758,13 @@ func renderStallRateChart(state *uiState) image.Image {
 	if state.showHints {
 		padBottom += 18
 	}
-	ch := chart.Chart{Title: "Stall Rate (%)", Background: chart.Style{Padding: chart.Box{Top: 14, Left: 16, Right: 12, Bottom: padBottom}}, XAxis: xAxis, YAxis: chart.YAxis{Name: "%", Range: yAxisRange, Ticks: yTicks}, Series: series}
+	ch := chart.Chart{Title: withNote("Stall Rate (%)", stallTimeoutNote(filteredSummaries(state))), Background: chart.Style{Padding: chart.Box{Top: 14, Left: 16, Right: 12, Bottom: padBottom}}, XAxis: xAxis, YAxis: chart.YAxis{Name: "%", Range: yAxisRange, Ticks: yTicks}, Series: series}
 	themeChart(&ch)
 	cw, chh := chartSize(state)
 	ch.Width, ch.Height = cw, chh
-	attachLegend(&ch)
+	attachLegend(state, &ch)
 	var buf bytes.Buffer
-	if err := ch.Render(chart.PNG, &buf); err != nil {
+	if err := renderChart(state, &ch, &buf); err != nil {
 		return blank(cw, chh)
 	}
 	img, err := png.Decode(&buf)
@@ -7033,13 +8884,13 @@ func renderMicroStallRateChart(state *uiState) image.Image {
 	if state.showHints {
 		padBottom += 18
 	}
-	ch := chart.Chart{Title: "Transient Stall Rate (%)", Background: chart.Style{Padding: chart.Box{Top: 14, Left: 16, Right: 12, Bottom: padBottom}}, XAxis: xAxis, YAxis: chart.YAxis{Name: "%", Range: yAxisRange, Ticks: yTicks}, Series: series}
+	ch := chart.Chart{Title: withNote("Transient Stall Rate (%)", microStallGapNote(filteredSummaries(state))), Background: chart.Style{Padding: chart.Box{Top: 14, Left: 16, Right: 12, Bottom: padBottom}}, XAxis: xAxis, YAxis: chart.YAxis{Name: "%", Range: yAxisRange, Ticks: yTicks}, Series: series}
 	themeChart(&ch)
 	cw, chh := chartSize(state)
 	ch.Width, ch.Height = cw, chh
-	attachLegend(&ch)
+	attachLegend(state, &ch)
 	var buf bytes.Buffer
-	if err := ch.Render(chart.PNG, &buf); err != nil {
+	if err := renderChart(state, &ch, &buf); err != nil {
 		return blank(cw, chh)
 	}
 	img, err := png.Decode(&buf)
@@ -7132,13 +8983,13 @@ func renderMicroStallTimeChart(state *uiState) image.Image {
 	if state.showHints {
 		padBottom += 18
 	}
-	ch := chart.Chart{Title: "Avg Transient Stall Time (ms)", Background: chart.Style{Padding: chart.Box{Top: 14, Left: 16, Right: 12, Bottom: padBottom}}, XAxis: xAxis, YAxis: chart.YAxis{Name: "ms", Range: yAxisRange, Ticks: yTicks}, Series: series}
+	ch := chart.Chart{Title: withNote("Avg Transient Stall Time (ms)", microStallGapNote(filteredSummaries(state))), Background: chart.Style{Padding: chart.Box{Top: 14, Left: 16, Right: 12, Bottom: padBottom}}, XAxis: xAxis, YAxis: chart.YAxis{Name: "ms", Range: yAxisRange, Ticks: yTicks}, Series: series}
 	themeChart(&ch)
 	cw, chh := chartSize(state)
 	ch.Width, ch.Height = cw, chh
-	attachLegend(&ch)
+	attachLegend(state, &ch)
 	var buf bytes.Buffer
-	if err := ch.Render(chart.PNG, &buf); err != nil {
+	if err := renderChart(state, &ch, &buf); err != nil {
 		return blank(cw, chh)
 	}
 	img, err := png.Decode(&buf)
@@ -7231,13 +9082,13 @@ func renderMicroStallCountChart(state *uiState) image.Image {
 	if state.showHints {
 		padBottom += 18
 	}
-	ch := chart.Chart{Title: "Avg Transient Stall Count", Background: chart.Style{Padding: chart.Box{Top: 14, Left: 16, Right: 12, Bottom: padBottom}}, XAxis: xAxis, YAxis: chart.YAxis{Name: "count", Range: yAxisRange, Ticks: yTicks}, Series: series}
+	ch := chart.Chart{Title: withNote("Avg Transient Stall Count", microStallGapNote(filteredSummaries(state))), Background: chart.Style{Padding: chart.Box{Top: 14, Left: 16, Right: 12, Bottom: padBottom}}, XAxis: xAxis, YAxis: chart.YAxis{Name: "count", Range: yAxisRange, Ticks: yTicks}, Series: series}
 	themeChart(&ch)
 	cw, chh := chartSize(state)
 	ch.Width, ch.Height = cw, chh
-	attachLegend(&ch)
+	attachLegend(state, &ch)
 	var buf bytes.Buffer
-	if err := ch.Render(chart.PNG, &buf); err != nil {
+	if err := renderChart(state, &ch, &buf); err != nil {
 		return blank(cw, chh)
 	}
 	img, err := png.Decode(&buf)
@@ -7250,8 +9101,138 @@ func renderMicroStallCountChart(state *uiState) image.Image {
 	return drawWatermark(img, "Situation: "+activeSituationLabel(state))
 }
 
-// renderPartialBodyRateChart draws Partial Body Rate (%) per batch (overall/IPv4/IPv6).
-func renderPartialBodyRateChart(state *uiState) image.Image {
+// renderStallTimelineChart draws a heat strip of where within transfers the transient stalls
+// fell: one column per batch, one row per slice of the transfer duration (top = start).
+func renderStallTimelineChart(state *uiState) image.Image {
+	rows := filteredSummaries(state)
+	cw, chh := chartSize(state)
+	if len(rows) == 0 {
+		return blank(cw, chh)
+	}
+	bins := analysis.StallTimelineBins
+	maxPct := 0.0
+	have := 0
+	events, early := 0, 0.0
+	offsetSum, durSum := 0.0, 0.0
+	for _, r := range rows {
+		if len(r.StallTimelinePct) != bins {
+			continue
+		}
+		have++
+		for _, v := range r.StallTimelinePct {
+			maxPct = math.Max(maxPct, v)
+		}
+		events += r.StallEvents
+		early += r.StallEventsEarlyPct / 100 * float64(r.StallEvents)
+		offsetSum += r.AvgStallEventOffsetMs * float64(r.StallEvents)
+		durSum += r.AvgStallEventMs * float64(r.StallEvents)
+	}
+	img := image.NewRGBA(image.Rect(0, 0, cw, chh))
+	isLight := strings.EqualFold(screenshotThemeGlobal, "light")
+	bg := color.RGBA{18, 18, 18, 255}
+	var textCol color.Color = color.RGBA{235, 235, 235, 255}
+	var faintText color.Color = color.RGBA{170, 170, 170, 255}
+	empty := color.RGBA{40, 40, 40, 255}
+	if isLight {
+		bg = color.RGBA{250, 250, 250, 255}
+		textCol = color.Black
+		faintText = color.RGBA{60, 60, 60, 255}
+		empty = color.RGBA{225, 225, 225, 255}
+	}
+	hot := color.RGBA{0xcc, 0x33, 0x33, 255}
+	draw.Draw(img, img.Bounds(), &image.Uniform{bg}, image.Point{}, draw.Src)
+	face := basicfont.Face7x13
+	addLabel(img, 16, 18, "Stall Timeline (% of transfer time stalled, by position in transfer)", textCol, face)
+	if have == 0 {
+		addLabel(img, 16, chh/2, "No stall timeline: the loaded lines carry no intra-transfer speed samples.", faintText, face)
+		return drawWatermark(img, "Situation: "+activeSituationLabel(state))
+	}
+	left, right, top, bottom := 84, 96, 34, 46
+	plotW, plotH := cw-left-right, chh-top-bottom
+	if plotW < 100 || plotH < 50 {
+		return blank(cw, chh)
+	}
+	cellW := float64(plotW) / float64(len(rows))
+	cellH := float64(plotH) / float64(bins)
+	// shade: blend from the background towards red by the cell's share of the hottest cell
+	shade := func(v float64) color.RGBA {
+		t := 0.0
+		if maxPct > 0 {
+			t = math.Min(1, v/maxPct)
+		}
+		mix := func(a, b uint8) uint8 { return uint8(float64(a) + (float64(b)-float64(a))*t) }
+		return color.RGBA{mix(empty.R, hot.R), mix(empty.G, hot.G), mix(empty.B, hot.B), 255}
+	}
+	for i, r := range rows {
+		x0, x1 := left+int(float64(i)*cellW), left+int(float64(i+1)*cellW)
+		for b := 0; b < bins; b++ {
+			y0, y1 := top+int(float64(b)*cellH), top+int(float64(b+1)*cellH)
+			c := bg
+			if len(r.StallTimelinePct) == bins {
+				c = shade(r.StallTimelinePct[b])
+			}
+			draw.Draw(img, image.Rect(x0, y0, x1, y1), &image.Uniform{c}, image.Point{}, draw.Src)
+		}
+	}
+	gridCol := color.RGBA{255, 255, 255, 40}
+	if isLight {
+		gridCol = color.RGBA{0, 0, 0, 40}
+	}
+	drawBorder(img, image.Rect(left, top, left+plotW, top+plotH), gridCol)
+	// Y axis: position within the transfer
+	addLabel(img, 8, top+10, "start 0%", faintText, face)
+	addLabel(img, 8, top+plotH/2+4, "50%", faintText, face)
+	addLabel(img, 8, top+plotH, "end 100%", faintText, face)
+	// X axis: thin out labels so they do not overlap
+	label := func(i int, r analysis.BatchSummary) string {
+		switch state.xAxisMode {
+		case "run_tag":
+			return r.RunTag
+		case "time":
+			if t := parseRunTagTime(r.RunTag); !t.IsZero() {
+				return t.Format("01-02 15:04")
+			}
+			return r.RunTag
+		}
+		return strconv.Itoa(i + 1)
+	}
+	widest := 0
+	for i, r := range rows {
+		if l := len(label(i, r)); l > widest {
+			widest = l
+		}
+	}
+	step := int(math.Ceil(float64(widest*7+10) / cellW))
+	if step < 1 {
+		step = 1
+	}
+	for i := 0; i < len(rows); i += step {
+		l := label(i, rows[i])
+		x := left + int((float64(i)+0.5)*cellW) - len(l)*7/2
+		addLabel(img, x, top+plotH+14, l, faintText, face)
+	}
+	// Color scale
+	sx := left + plotW + 16
+	for y := 0; y < plotH; y++ {
+		draw.Draw(img, image.Rect(sx, top+y, sx+12, top+y+1), &image.Uniform{shade(maxPct * float64(plotH-y) / float64(plotH))}, image.Point{}, draw.Src)
+	}
+	addLabel(img, sx+16, top+10, fmt.Sprintf("%.1f%%", maxPct), faintText, face)
+	addLabel(img, sx+16, top+plotH, "0%", faintText, face)
+	caption := "No transient stall events in these batches."
+	if events > 0 {
+		caption = fmt.Sprintf("%d stall events; %.0f%% started in the first tenth of the transfer; avg offset %.0f ms, avg duration %.0f ms.", events, early/float64(events)*100, offsetSum/float64(events), durSum/float64(events))
+	}
+	addLabel(img, left, top+plotH+32, caption, textCol, face)
+	var out image.Image = img
+	if state.showHints {
+		out = drawHint(out, "Hint: Hot top rows = stalls at transfer start; hot middle rows = stalls mid-transfer.")
+	}
+	return drawWatermark(out, "Situation: "+activeSituationLabel(state))
+}
+
+// renderContentCorruptionRateChart draws the share of integrity-checked bodies whose sha256
+// did not match the site's expected digest per batch (overall/IPv4/IPv6).
+func renderContentCorruptionRateChart(state *uiState) image.Image {
 	rows := filteredSummaries(state)
 	if len(rows) == 0 {
 		w, h := chartSize(state)
@@ -7259,89 +9240,274 @@ func renderPartialBodyRateChart(state *uiState) image.Image {
 	}
 	timeMode, times, xs, xAxis := buildXAxis(rows, state.xAxisMode)
 	series := []chart.Series{}
-	minY, maxY := math.MaxFloat64, -math.MaxFloat64
-	add := func(name string, sel func(analysis.BatchSummary) float64, col drawing.Color) {
+	add := func(name string, sel func(analysis.BatchSummary) (float64, bool), color drawing.Color) {
 		ys := make([]float64, len(rows))
 		valid := 0
 		for i, r := range rows {
-			v := sel(r)
-			if v < 0 || math.IsNaN(v) {
+			v, ok := sel(r)
+			if !ok {
 				ys[i] = math.NaN()
 				continue
 			}
 			ys[i] = v
-			if v < minY {
-				minY = v
-			}
-			if v > maxY {
-				maxY = v
-			}
 			valid++
 		}
-		st := pointStyle(col)
+		if valid == 0 {
+			return
+		}
+		st := pointStyle(color)
 		if valid == 1 {
 			st.DotWidth = 6
 		}
 		if timeMode {
 			if len(times) == 1 {
-				t2 := times[0].Add(1 * time.Second)
-				ys = append([]float64{ys[0]}, ys[0])
-				series = append(series, chart.TimeSeries{Name: name, XValues: []time.Time{times[0], t2}, YValues: ys, Style: st})
+				series = append(series, chart.TimeSeries{Name: name, XValues: []time.Time{times[0], times[0].Add(1 * time.Second)}, YValues: []float64{ys[0], ys[0]}, Style: st})
 			} else {
 				series = append(series, chart.TimeSeries{Name: name, XValues: times, YValues: ys, Style: st})
 			}
 		} else {
 			if len(xs) == 1 {
-				x2 := xs[0] + 1
-				ys = append([]float64{ys[0]}, ys[0])
-				series = append(series, chart.ContinuousSeries{Name: name, XValues: []float64{xs[0], x2}, YValues: ys, Style: st})
+				series = append(series, chart.ContinuousSeries{Name: name, XValues: []float64{xs[0], xs[0] + 1}, YValues: []float64{ys[0], ys[0]}, Style: st})
 			} else {
 				series = append(series, chart.ContinuousSeries{Name: name, XValues: xs, YValues: ys, Style: st})
 			}
 		}
 	}
 	if state.showOverall {
-		add("Overall", func(b analysis.BatchSummary) float64 { return b.PartialBodyRatePct }, chart.ColorAlternateGray)
+		add("Overall", func(b analysis.BatchSummary) (float64, bool) {
+			return b.ContentCorruptionRatePct, b.IntegrityCheckedLines > 0
+		}, chart.ColorAlternateGray)
 	}
 	if state.showIPv4 {
-		add("IPv4", func(b analysis.BatchSummary) float64 {
+		add("IPv4", func(b analysis.BatchSummary) (float64, bool) {
 			if b.IPv4 == nil {
-				return 0
+				return 0, false
 			}
-			return b.IPv4.PartialBodyRatePct
+			return b.IPv4.ContentCorruptionRatePct, b.IPv4.IntegrityCheckedLines > 0
 		}, chart.ColorBlue)
 	}
 	if state.showIPv6 {
-		add("IPv6", func(b analysis.BatchSummary) float64 {
+		add("IPv6", func(b analysis.BatchSummary) (float64, bool) {
 			if b.IPv6 == nil {
-				return 0
+				return 0, false
 			}
-			return b.IPv6.PartialBodyRatePct
+			return b.IPv6.ContentCorruptionRatePct, b.IPv6.IntegrityCheckedLines > 0
 		}, chart.ColorGreen)
 	}
-	var yAxisRange chart.Range
-	var yTicks []chart.Tick
-	haveY := (minY != math.MaxFloat64 && maxY != -math.MaxFloat64)
-	if state.useRelative && haveY {
-		if maxY <= minY {
-			maxY = minY + 1
-		}
-		vals5 := helpers.BuildNumericTicks(minY, maxY, 6)
-		if len(vals5) < 2 {
-			vals5 = []float64{minY, maxY}
-		}
-		rMin, rMax := vals5[0], vals5[len(vals5)-1]
-		yAxisRange = &chart.ContinuousRange{Min: rMin, Max: rMax}
-		yTicks = yTicks[:0]
-		for _, v5 := range vals5 {
-			yTicks = append(yTicks, chart.Tick{Value: v5, Label: helpers.FormatNumericTick(v5)})
-		}
-	} else if !state.useRelative && haveY {
-		if maxY < 1 {
-			maxY = 1
-		}
-		if maxY > 100 {
-			maxY = 100
+	if len(series) == 0 {
+		w, h := chartSize(state)
+		return drawHint(blank(w, h), "No integrity checks in these batches (add \"sha256\" to a site in the sites file).")
+	}
+	padBottom := 28
+	switch state.xAxisMode {
+	case "run_tag":
+		padBottom = 90
+	case "time":
+		padBottom = 48
+	}
+	if state.showHints {
+		padBottom += 18
+	}
+	yTicks := []chart.Tick{{Value: 0, Label: "0"}, {Value: 25, Label: "25"}, {Value: 50, Label: "50"}, {Value: 75, Label: "75"}, {Value: 100, Label: "100"}}
+	ch := chart.Chart{Title: "Content Corruption Rate (%)", Background: chart.Style{Padding: chart.Box{Top: 14, Left: 16, Right: 12, Bottom: padBottom}}, XAxis: xAxis, YAxis: chart.YAxis{Name: "%", Range: &chart.ContinuousRange{Min: 0, Max: 100}, Ticks: yTicks}, Series: series}
+	themeChart(&ch)
+	cw, chh := chartSize(state)
+	ch.Width, ch.Height = cw, chh
+	attachLegend(state, &ch)
+	var buf bytes.Buffer
+	if err := renderChart(state, &ch, &buf); err != nil {
+		return blank(cw, chh)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		return blank(cw, chh)
+	}
+	if state.showHints {
+		img = drawHint(img, "Hint: Any corruption on complete bodies points at something rewriting content in transit (proxy, captive portal, ad injection).")
+	}
+	return drawWatermark(img, "Situation: "+activeSituationLabel(state))
+}
+
+// renderDataUsageChart draws the megabytes downloaded and uploaded per batch from the wire byte
+// counts, with the running day total at each batch's end.
+func renderDataUsageChart(state *uiState) image.Image {
+	rows := filteredSummaries(state)
+	if len(rows) == 0 {
+		w, h := chartSize(state)
+		return blank(w, h)
+	}
+	timeMode, times, xs, xAxis := buildXAxis(rows, state.xAxisMode)
+	series := []chart.Series{}
+	minY, maxY := math.MaxFloat64, -math.MaxFloat64
+	add := func(name string, sel func(analysis.BatchSummary) int64, color drawing.Color) {
+		ys := make([]float64, len(rows))
+		valid := 0
+		for i, r := range rows {
+			v := sel(r)
+			if v <= 0 {
+				ys[i] = math.NaN()
+				continue
+			}
+			ys[i] = float64(v) / 1e6
+			minY, maxY = math.Min(minY, ys[i]), math.Max(maxY, ys[i])
+			valid++
+		}
+		if valid == 0 {
+			return
+		}
+		st := pointStyle(color)
+		if valid == 1 {
+			st.DotWidth = 6
+		}
+		if timeMode {
+			if len(times) == 1 {
+				series = append(series, chart.TimeSeries{Name: name, XValues: []time.Time{times[0], times[0].Add(1 * time.Second)}, YValues: []float64{ys[0], ys[0]}, Style: st})
+			} else {
+				series = append(series, chart.TimeSeries{Name: name, XValues: times, YValues: ys, Style: st})
+			}
+		} else {
+			if len(xs) == 1 {
+				series = append(series, chart.ContinuousSeries{Name: name, XValues: []float64{xs[0], xs[0] + 1}, YValues: []float64{ys[0], ys[0]}, Style: st})
+			} else {
+				series = append(series, chart.ContinuousSeries{Name: name, XValues: xs, YValues: ys, Style: st})
+			}
+		}
+	}
+	add("Down (batch)", func(b analysis.BatchSummary) int64 { return b.WireRxBytes }, chart.ColorBlue)
+	add("Up (batch)", func(b analysis.BatchSummary) int64 { return b.WireTxBytes }, chart.ColorGreen)
+	add("Day total", func(b analysis.BatchSummary) int64 { return b.DayRxBytes + b.DayTxBytes }, chart.ColorAlternateGray)
+	if len(series) == 0 {
+		w, h := chartSize(state)
+		return drawHint(blank(w, h), "No data usage recorded (needs a monitor that writes wire_rx_bytes/wire_tx_bytes).")
+	}
+	yAxisRange, yTicks := computeYAxisRange(minY, maxY, state.useRelative, false)
+	padBottom := 28
+	switch state.xAxisMode {
+	case "run_tag":
+		padBottom = 90
+	case "time":
+		padBottom = 48
+	}
+	if state.showHints {
+		padBottom += 18
+	}
+	ch := chart.Chart{Title: "Data Usage (MB)", Background: chart.Style{Padding: chart.Box{Top: 14, Left: 16, Right: 12, Bottom: padBottom}}, XAxis: xAxis, YAxis: chart.YAxis{Name: "MB", Range: yAxisRange, Ticks: yTicks}, Series: series}
+	themeChart(&ch)
+	ch.Title += allGroupsNote(state)
+	cw, chh := chartSize(state)
+	ch.Width, ch.Height = cw, chh
+	attachLegend(state, &ch)
+	var buf bytes.Buffer
+	if err := renderChart(state, &ch, &buf); err != nil {
+		return blank(cw, chh)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		return blank(cw, chh)
+	}
+	if state.showHints {
+		hint := "Hint: Each batch downloads every site in full; fewer sites, smaller files or reduced mode cut usage on metered links."
+		if last := rows[len(rows)-1]; last.BudgetBytes > 0 {
+			used := last.CycleRxBytes + last.CycleTxBytes
+			hint = fmt.Sprintf("Budget: %.2f of %.2f GB used this cycle (%.0f%%).", float64(used)/1e9, float64(last.BudgetBytes)/1e9, 100*float64(used)/float64(last.BudgetBytes))
+		}
+		img = drawHint(img, hint)
+	}
+	return drawWatermark(img, "Situation: "+activeSituationLabel(state))
+}
+
+// renderPartialBodyRateChart draws Partial Body Rate (%) per batch (overall/IPv4/IPv6).
+func renderPartialBodyRateChart(state *uiState) image.Image {
+	rows := filteredSummaries(state)
+	if len(rows) == 0 {
+		w, h := chartSize(state)
+		return blank(w, h)
+	}
+	timeMode, times, xs, xAxis := buildXAxis(rows, state.xAxisMode)
+	series := []chart.Series{}
+	minY, maxY := math.MaxFloat64, -math.MaxFloat64
+	add := func(name string, sel func(analysis.BatchSummary) float64, col drawing.Color) {
+		ys := make([]float64, len(rows))
+		valid := 0
+		for i, r := range rows {
+			v := sel(r)
+			if v < 0 || math.IsNaN(v) {
+				ys[i] = math.NaN()
+				continue
+			}
+			ys[i] = v
+			if v < minY {
+				minY = v
+			}
+			if v > maxY {
+				maxY = v
+			}
+			valid++
+		}
+		st := pointStyle(col)
+		if valid == 1 {
+			st.DotWidth = 6
+		}
+		if timeMode {
+			if len(times) == 1 {
+				t2 := times[0].Add(1 * time.Second)
+				ys = append([]float64{ys[0]}, ys[0])
+				series = append(series, chart.TimeSeries{Name: name, XValues: []time.Time{times[0], t2}, YValues: ys, Style: st})
+			} else {
+				series = append(series, chart.TimeSeries{Name: name, XValues: times, YValues: ys, Style: st})
+			}
+		} else {
+			if len(xs) == 1 {
+				x2 := xs[0] + 1
+				ys = append([]float64{ys[0]}, ys[0])
+				series = append(series, chart.ContinuousSeries{Name: name, XValues: []float64{xs[0], x2}, YValues: ys, Style: st})
+			} else {
+				series = append(series, chart.ContinuousSeries{Name: name, XValues: xs, YValues: ys, Style: st})
+			}
+		}
+	}
+	if state.showOverall {
+		add("Overall", func(b analysis.BatchSummary) float64 { return b.PartialBodyRatePct }, chart.ColorAlternateGray)
+	}
+	if state.showIPv4 {
+		add("IPv4", func(b analysis.BatchSummary) float64 {
+			if b.IPv4 == nil {
+				return 0
+			}
+			return b.IPv4.PartialBodyRatePct
+		}, chart.ColorBlue)
+	}
+	if state.showIPv6 {
+		add("IPv6", func(b analysis.BatchSummary) float64 {
+			if b.IPv6 == nil {
+				return 0
+			}
+			return b.IPv6.PartialBodyRatePct
+		}, chart.ColorGreen)
+	}
+	var yAxisRange chart.Range
+	var yTicks []chart.Tick
+	haveY := (minY != math.MaxFloat64 && maxY != -math.MaxFloat64)
+	if state.useRelative && haveY {
+		if maxY <= minY {
+			maxY = minY + 1
+		}
+		vals5 := helpers.BuildNumericTicks(minY, maxY, 6)
+		if len(vals5) < 2 {
+			vals5 = []float64{minY, maxY}
+		}
+		rMin, rMax := vals5[0], vals5[len(vals5)-1]
+		yAxisRange = &chart.ContinuousRange{Min: rMin, Max: rMax}
+		yTicks = yTicks[:0]
+		for _, v5 := range vals5 {
+			yTicks = append(yTicks, chart.Tick{Value: v5, Label: helpers.FormatNumericTick(v5)})
+		}
+	} else if !state.useRelative && haveY {
+		if maxY < 1 {
+			maxY = 1
+		}
+		if maxY > 100 {
+			maxY = 100
 		}
 		yAxisRange = &chart.ContinuousRange{Min: 0, Max: 100}
 		yTicks = []chart.Tick{{Value: 0, Label: "0"}, {Value: 25, Label: "25"}, {Value: 50, Label: "50"}, {Value: 75, Label: "75"}, {Value: 100, Label: "100"}}
@@ -7360,9 +9526,9 @@ func renderPartialBodyRateChart(state *uiState) image.Image {
 	themeChart(&ch)
 	cw, chh := chartSize(state)
 	ch.Width, ch.Height = cw, chh
-	attachLegend(&ch)
+	attachLegend(state, &ch)
 	var buf bytes.Buffer
-	if err := ch.Render(chart.PNG, &buf); err != nil {
+	if err := renderChart(state, &ch, &buf); err != nil {
 		return blank(cw, chh)
 	}
 	img, err := png.Decode(&buf)
@@ -7481,13 +9647,13 @@ func renderPreTTFBStallRateChart(state *uiState) image.Image {
 	if state.showHints {
 		padBottom += 18
 	}
-	ch := chart.Chart{Title: "Pre‑TTFB Stall Rate (%)", Background: chart.Style{Padding: chart.Box{Top: 14, Left: 16, Right: 12, Bottom: padBottom}}, XAxis: xAxis, YAxis: chart.YAxis{Name: "%", Range: yAxisRange, Ticks: yTicks}, Series: series}
+	ch := chart.Chart{Title: withNote("Pre‑TTFB Stall Rate (%)", stallTimeoutNote(filteredSummaries(state))), Background: chart.Style{Padding: chart.Box{Top: 14, Left: 16, Right: 12, Bottom: padBottom}}, XAxis: xAxis, YAxis: chart.YAxis{Name: "%", Range: yAxisRange, Ticks: yTicks}, Series: series}
 	themeChart(&ch)
 	cw, chh := chartSize(state)
 	ch.Width, ch.Height = cw, chh
-	attachLegend(&ch)
+	attachLegend(state, &ch)
 	var buf bytes.Buffer
-	if err := ch.Render(chart.PNG, &buf); err != nil {
+	if err := renderChart(state, &ch, &buf); err != nil {
 		return blank(cw, chh)
 	}
 	img, err := png.Decode(&buf)
@@ -7585,9 +9751,9 @@ func renderStallTimeChart(state *uiState) image.Image {
 	themeChart(&ch)
 	cw, chh := chartSize(state)
 	ch.Width, ch.Height = cw, chh
-	attachLegend(&ch)
+	attachLegend(state, &ch)
 	var buf bytes.Buffer
-	if err := ch.Render(chart.PNG, &buf); err != nil {
+	if err := renderChart(state, &ch, &buf); err != nil {
 		return blank(cw, chh)
 	}
 	img, err := png.Decode(&buf)
@@ -7602,20 +9768,13 @@ func renderStallTimeChart(state *uiState) image.Image {
 
 // chartSize computes a chart size based on the current window width so charts use more X-axis space.
 func chartSize(state *uiState) (int, int) {
-	// If a one-shot render width override is set (used by export), honor it first.
+	// Render snapshot (worker goroutines, detached windows): size was captured on the UI thread.
+	if state != nil && state.snapshotChartW > 0 {
+		return state.snapshotChartW, state.snapshotChartH
+	}
+	// If a one-shot render width override is set (used by export), honor it next.
 	if renderWidthOverride > 0 {
-		w := renderWidthOverride
-		if w < 800 {
-			w = 800
-		}
-		h := int(float32(w) * 0.33)
-		if h < 280 {
-			h = 280
-		}
-		if h > 520 {
-			h = 520
-		}
-		return w, h
+		return widthOverrideChartSize(renderWidthOverride)
 	}
 	// Headless/screenshot mode: allow tests to override width for exact checks.
 	if state == nil || state.window == nil || state.window.Canvas() == nil {
@@ -7629,8 +9788,24 @@ func chartSize(state *uiState) (int, int) {
 	return helpers.ComputeChartDimensions(int(sz.Width))
 }
 
+// widthOverrideChartSize is the chart size for an export or detached window w pixels wide: at
+// least 800 wide, height a third of the width within 280–520.
+func widthOverrideChartSize(w int) (int, int) {
+	if w < 800 {
+		w = 800
+	}
+	h := int(float32(w) * 0.33)
+	if h < 280 {
+		h = 280
+	}
+	if h > 520 {
+		h = 520
+	}
+	return w, h
+}
+
 func renderSpeedChart(state *uiState) image.Image {
-	unitName, factor := speedUnitNameAndFactor(state.speedUnit)
+	unitName, factor := speedUnitFor(state)
 	rows := filteredSummaries(state)
 	if len(rows) == 0 {
 		w, h := chartSize(state)
@@ -8070,7 +10245,7 @@ func renderSpeedChart(state *uiState) image.Image {
 		padBottom += 18
 	}
 	ch := chart.Chart{
-		Title:      fmt.Sprintf("Speed (Avg/Median/Min/Max%s) (%s)", ternary(state.showIQR, "+IQR", ""), unitName),
+		Title:      fmt.Sprintf(tr("Speed (Avg/Median/Min/Max%s) (%s)"), ternary(state.showIQR, "+IQR", ""), unitName),
 		Background: chart.Style{Padding: chart.Box{Top: 14, Left: 16, Right: 12, Bottom: padBottom}},
 		XAxis:      xAxis,
 		YAxis:      chart.YAxis{Name: unitName, Range: yAxisRange, Ticks: yTicks},
@@ -8222,11 +10397,11 @@ func renderSpeedChart(state *uiState) image.Image {
 		for i, s := range series {
 			switch ss := s.(type) {
 			case chart.TimeSeries:
-				fmt.Printf("[viewer] speed series[%d] TimeSeries X=%d Y=%d\n", i, len(ss.XValues), len(ss.YValues))
+				logf(slog.LevelDebug, "viewer", "speed series[%d] TimeSeries X=%d Y=%d", i, len(ss.XValues), len(ss.YValues))
 			case chart.ContinuousSeries:
-				fmt.Printf("[viewer] speed series[%d] Continuous X=%d Y=%d\n", i, len(ss.XValues), len(ss.YValues))
+				logf(slog.LevelDebug, "viewer", "speed series[%d] Continuous X=%d Y=%d", i, len(ss.XValues), len(ss.YValues))
 			default:
-				fmt.Printf("[viewer] speed series[%d] type=%T\n", i, s)
+				logf(slog.LevelDebug, "viewer", "speed series[%d] type=%T", i, s)
 			}
 		}
 	}
@@ -8234,19 +10409,19 @@ func renderSpeedChart(state *uiState) image.Image {
 	cw, chh := chartSize(state)
 	ch.Width = cw
 	ch.Height = chh
-	attachLegend(&ch)
+	attachLegend(state, &ch)
 
 	var buf bytes.Buffer
-	if err := ch.Render(chart.PNG, &buf); err != nil {
+	if err := renderChart(state, &ch, &buf); err != nil {
 		// Fallback to a blank image so the UI visibly updates even on render errors (e.g., single-point edge cases)
 		cw, chh := chartSize(state)
-		fmt.Printf("[viewer] speed chart render error: %v; showing blank fallback\n", err)
+		logf(slog.LevelWarn, "viewer", "speed chart render error: %v; showing blank fallback", err)
 		return blank(cw, chh)
 	}
 	img, err := png.Decode(&buf)
 	if err != nil {
 		cw, chh := chartSize(state)
-		fmt.Printf("[viewer] speed chart decode error: %v; showing blank fallback\n", err)
+		logf(slog.LevelWarn, "viewer", "speed chart decode error: %v; showing blank fallback", err)
 		return blank(cw, chh)
 	}
 	if state.showHints {
@@ -8490,7 +10665,7 @@ func renderSelfTestChart(state *uiState) image.Image {
 		w, h := chartSize(state)
 		return blank(w, h)
 	}
-	unitName, factor := speedUnitNameAndFactor(state.speedUnit)
+	unitName, factor := speedUnitFor(state)
 	timeMode, times, xs, xAxis := buildXAxis(rows, state.xAxisMode)
 
 	ys := make([]float64, len(rows))
@@ -8548,18 +10723,19 @@ func renderSelfTestChart(state *uiState) image.Image {
 		padBottom += 18
 	}
 	ch := chart.Chart{
-		Title:      fmt.Sprintf("Local Throughput Self-Test (%s)", unitName),
+		Title:      fmt.Sprintf(tr("Local Throughput Self-Test (%s)"), unitName),
 		Background: chart.Style{Padding: chart.Box{Top: 14, Left: 16, Right: 12, Bottom: padBottom}},
 		XAxis:      xAxis,
 		YAxis:      chart.YAxis{Name: unitName, Range: yAxisRange, Ticks: yTicks},
 		Series:     []chart.Series{series},
 	}
 	themeChart(&ch)
+	ch.Title += allGroupsNote(state)
 	cw, chh := chartSize(state)
 	ch.Width, ch.Height = cw, chh
-	attachLegend(&ch)
+	attachLegend(state, &ch)
 	var buf bytes.Buffer
-	if err := ch.Render(chart.PNG, &buf); err != nil {
+	if err := renderChart(state, &ch, &buf); err != nil {
 		return blank(cw, chh)
 	}
 	img, err := png.Decode(&buf)
@@ -8572,74 +10748,216 @@ func renderSelfTestChart(state *uiState) image.Image {
 	return drawWatermark(img, "Situation: "+activeSituationLabel(state))
 }
 
-func renderTTFBChart(state *uiState) image.Image {
+// renderWiFiRSSIChart overlays the batch-average Wi-Fi signal strength (dBm) with average throughput.
+func renderWiFiRSSIChart(state *uiState) image.Image { return renderWiFiLinkChart(state, "rssi") }
+
+// renderWiFiPHYRateChart overlays the batch-average Wi-Fi PHY (link) rate with average throughput.
+func renderWiFiPHYRateChart(state *uiState) image.Image { return renderWiFiLinkChart(state, "phy") }
+
+// renderWiFiLinkChart draws a Wi-Fi link metric (from meta.wifi) on the left axis and the batch
+// average throughput on the right axis. A speed drop while RSSI/PHY rate holds steady points at the
+// ISP/path; a drop that tracks the link metric points at the local radio link.
+func renderWiFiLinkChart(state *uiState, metric string) image.Image {
 	rows := filteredSummaries(state)
 	if len(rows) == 0 {
 		w, h := chartSize(state)
 		return blank(w, h)
 	}
-	// Build X axis according to mode
+	unitName, factor := speedUnitFor(state)
 	timeMode, times, xs, xAxis := buildXAxis(rows, state.xAxisMode)
-	// Collect series and y-bounds
-	series := []chart.Series{}
-	minY := math.MaxFloat64
-	maxY := -math.MaxFloat64
-	statStyle := func(base drawing.Color, role string, emphasize bool) chart.Style {
-		// role: avg, median, min, max
-		col := base
-		width := 4
-		switch role {
-		case "avg":
-			if emphasize {
-				width = 6
-			}
-		case "median":
-			col = base.WithAlpha(210)
-			width = 4
-		case "min", "max":
-			col = base.WithAlpha(160)
-			width = 3
-		}
-		return chart.Style{StrokeWidth: 0, DotWidth: float64(width), DotColor: col}
-	}
-	addSeries := func(name string, vals []float64, base drawing.Color, role string, timeMode bool) {
-		// track y bounds and build appropriate series type
-		valid := 0
-		ys := make([]float64, len(vals))
-		copy(ys, vals)
-		for _, v := range ys {
-			if !math.IsNaN(v) {
-				if v < minY {
-					minY = v
-				}
-				if v > maxY {
-					maxY = v
-				}
-				valid++
-			}
+	title, axisName, linkName := "Wi‑Fi RSSI vs Throughput", "dBm", "RSSI"
+	if metric == "phy" {
+		title, axisName, linkName = "Wi‑Fi PHY Rate vs Throughput", "Mbps (PHY)", "PHY Rate"
+	}
+	// Keep only batches that carry a value: NaN gaps stall go-chart's line stroker, and the two
+	// series legitimately cover different batches (wired batches have no Wi‑Fi data).
+	var linkX, speedX []float64
+	var linkT, speedT []time.Time
+	var link, speed []float64
+	minY, maxY := math.MaxFloat64, -math.MaxFloat64
+	minS, maxS := math.MaxFloat64, -math.MaxFloat64
+	for i, r := range rows {
+		v := r.AvgWiFiRSSIDBm
+		if metric == "phy" {
+			v = r.AvgWiFiPHYRateMbps
 		}
-		st := statStyle(base, role, valid == 1 && role == "avg")
-		if timeMode {
-			if len(times) == 1 {
-				t2 := times[0].Add(1 * time.Second)
-				ys = append([]float64{ys[0]}, ys[0])
-				series = append(series, chart.TimeSeries{Name: name, XValues: []time.Time{times[0], t2}, YValues: ys, Style: st})
+		if v != 0 && !math.IsNaN(v) {
+			link = append(link, v)
+			if timeMode {
+				linkT = append(linkT, times[i])
 			} else {
-				series = append(series, chart.TimeSeries{Name: name, XValues: times, YValues: ys, Style: st})
+				linkX = append(linkX, xs[i])
 			}
-		} else {
-			if len(xs) == 1 {
-				x2 := xs[0] + 1
-				ys = append([]float64{ys[0]}, ys[0])
-				series = append(series, chart.ContinuousSeries{Name: name, XValues: []float64{xs[0], x2}, YValues: ys, Style: st})
+			minY, maxY = math.Min(minY, v), math.Max(maxY, v)
+		}
+		if sp := r.AvgSpeed * factor; sp > 0 {
+			speed = append(speed, sp)
+			if timeMode {
+				speedT = append(speedT, times[i])
 			} else {
-				series = append(series, chart.ContinuousSeries{Name: name, XValues: xs, YValues: ys, Style: st})
+				speedX = append(speedX, xs[i])
 			}
+			minS, maxS = math.Min(minS, sp), math.Max(maxS, sp)
 		}
 	}
-
-	// Hold P25/P75 for optional IQR bands per family
-	var ovP25, ovP75 []float64
+	valid := len(link)
+	linkStyle := chart.Style{StrokeColor: chart.ColorBlue, StrokeWidth: 2, DotColor: chart.ColorBlue, DotWidth: 4}
+	speedStyle := chart.Style{StrokeColor: chart.ColorAlternateGray, StrokeWidth: 1.5, StrokeDashArray: []float64{4, 3}, DotColor: chart.ColorAlternateGray, DotWidth: 3}
+	var series []chart.Series
+	// go-chart needs at least two points per series; duplicate a lone point one step to the right.
+	if timeMode {
+		if len(linkT) == 1 {
+			linkT, link = append(linkT, linkT[0].Add(1*time.Second)), append(link, link[0])
+		}
+		if len(speedT) == 1 {
+			speedT, speed = append(speedT, speedT[0].Add(1*time.Second)), append(speed, speed[0])
+		}
+		if len(link) > 0 {
+			series = append(series, chart.TimeSeries{Name: linkName, XValues: linkT, YValues: link, Style: linkStyle})
+		}
+		if len(speed) > 0 {
+			series = append(series, chart.TimeSeries{Name: "Throughput", XValues: speedT, YValues: speed, Style: speedStyle, YAxis: chart.YAxisSecondary})
+		}
+	} else {
+		if len(linkX) == 1 {
+			linkX, link = append(linkX, linkX[0]+1), append(link, link[0])
+		}
+		if len(speedX) == 1 {
+			speedX, speed = append(speedX, speedX[0]+1), append(speed, speed[0])
+		}
+		if len(link) > 0 {
+			series = append(series, chart.ContinuousSeries{Name: linkName, XValues: linkX, YValues: link, Style: linkStyle})
+		}
+		if len(speed) > 0 {
+			series = append(series, chart.ContinuousSeries{Name: "Throughput", XValues: speedX, YValues: speed, Style: speedStyle, YAxis: chart.YAxisSecondary})
+		}
+	}
+	if len(series) == 0 {
+		w, h := chartSize(state)
+		return drawHint(blank(w, h), "No Wi‑Fi link or throughput data in these batches.")
+	}
+	var yRange chart.Range
+	var yTicks []chart.Tick
+	if metric == "phy" {
+		yRange, yTicks = computeYAxisRange(minY, maxY, state.useRelative, false)
+	} else {
+		// RSSI is negative; fit the observed band rather than anchoring at zero
+		yRange, yTicks = computeYAxisRangeSigned(minY, maxY, true)
+		if yRange == nil {
+			yRange = &chart.ContinuousRange{Min: -90, Max: -30}
+		}
+	}
+	sRange, sTicks := computeYAxisRange(minS, maxS, state.useRelative, false)
+	padBottom := 28
+	switch state.xAxisMode {
+	case "run_tag":
+		padBottom = 90
+	case "time":
+		padBottom = 48
+	}
+	if state.showHints {
+		padBottom += 18
+	}
+	ch := chart.Chart{
+		Title:          title,
+		Background:     chart.Style{Padding: chart.Box{Top: 14, Left: 16, Right: 12, Bottom: padBottom}},
+		XAxis:          xAxis,
+		YAxis:          chart.YAxis{Name: axisName, Range: yRange, Ticks: yTicks},
+		YAxisSecondary: chart.YAxis{Name: unitName, Range: sRange, Ticks: sTicks},
+		Series:         series,
+	}
+	themeChart(&ch)
+	// themeChart styles the primary axis only; mirror it onto the throughput axis.
+	ch.YAxisSecondary.Style = ch.YAxis.Style
+	ch.YAxisSecondary.NameStyle = ch.YAxis.NameStyle
+	ch.YAxisSecondary.TickStyle = ch.YAxis.TickStyle
+	cw, chh := chartSize(state)
+	ch.Width, ch.Height = cw, chh
+	attachLegend(state, &ch)
+	var buf bytes.Buffer
+	if err := renderChart(state, &ch, &buf); err != nil {
+		return blank(cw, chh)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		return blank(cw, chh)
+	}
+	if valid == 0 {
+		img = drawHint(img, "No Wi‑Fi link data in these batches (wired link, or airport/iw/netsh unavailable).")
+	} else if state.showHints {
+		img = drawHint(img, "Hint: Throughput dips that track "+linkName+" suggest a Wi‑Fi problem; dips with a steady link suggest ISP/path issues.")
+	}
+	return drawWatermark(img, "Situation: "+activeSituationLabel(state))
+}
+
+func renderTTFBChart(state *uiState) image.Image {
+	rows := filteredSummaries(state)
+	if len(rows) == 0 {
+		w, h := chartSize(state)
+		return blank(w, h)
+	}
+	// Build X axis according to mode
+	timeMode, times, xs, xAxis := buildXAxis(rows, state.xAxisMode)
+	// Collect series and y-bounds
+	series := []chart.Series{}
+	minY := math.MaxFloat64
+	maxY := -math.MaxFloat64
+	statStyle := func(base drawing.Color, role string, emphasize bool) chart.Style {
+		// role: avg, median, min, max
+		col := base
+		width := 4
+		switch role {
+		case "avg":
+			if emphasize {
+				width = 6
+			}
+		case "median":
+			col = base.WithAlpha(210)
+			width = 4
+		case "min", "max":
+			col = base.WithAlpha(160)
+			width = 3
+		}
+		return chart.Style{StrokeWidth: 0, DotWidth: float64(width), DotColor: col}
+	}
+	addSeries := func(name string, vals []float64, base drawing.Color, role string, timeMode bool) {
+		// track y bounds and build appropriate series type
+		valid := 0
+		ys := make([]float64, len(vals))
+		copy(ys, vals)
+		for _, v := range ys {
+			if !math.IsNaN(v) {
+				if v < minY {
+					minY = v
+				}
+				if v > maxY {
+					maxY = v
+				}
+				valid++
+			}
+		}
+		st := statStyle(base, role, valid == 1 && role == "avg")
+		if timeMode {
+			if len(times) == 1 {
+				t2 := times[0].Add(1 * time.Second)
+				ys = append([]float64{ys[0]}, ys[0])
+				series = append(series, chart.TimeSeries{Name: name, XValues: []time.Time{times[0], t2}, YValues: ys, Style: st})
+			} else {
+				series = append(series, chart.TimeSeries{Name: name, XValues: times, YValues: ys, Style: st})
+			}
+		} else {
+			if len(xs) == 1 {
+				x2 := xs[0] + 1
+				ys = append([]float64{ys[0]}, ys[0])
+				series = append(series, chart.ContinuousSeries{Name: name, XValues: []float64{xs[0], x2}, YValues: ys, Style: st})
+			} else {
+				series = append(series, chart.ContinuousSeries{Name: name, XValues: xs, YValues: ys, Style: st})
+			}
+		}
+	}
+
+	// Hold P25/P75 for optional IQR bands per family
+	var ovP25, ovP75 []float64
 	var v4P25, v4P75 []float64
 	var v6P25, v6P75 []float64
 	// Track family-specific maxima for median and P75 to enforce single-family occupancy
@@ -8980,7 +11298,7 @@ func renderTTFBChart(state *uiState) image.Image {
 		padBottom += 18
 	}
 	ch := chart.Chart{
-		Title:      fmt.Sprintf("TTFB (Avg/Median/Min/Max%s) (ms)", ternary(state.showIQR, "+IQR", "")),
+		Title:      fmt.Sprintf(tr("TTFB (Avg/Median/Min/Max%s) (ms)"), ternary(state.showIQR, "+IQR", "")),
 		Background: chart.Style{Padding: chart.Box{Top: 14, Left: 16, Right: 12, Bottom: padBottom}},
 		XAxis:      xAxis,
 		YAxis:      chart.YAxis{Name: "ms", Range: yAxisRange, Ticks: yTicks},
@@ -9126,29 +11444,29 @@ func renderTTFBChart(state *uiState) image.Image {
 		for i, s := range series {
 			switch ss := s.(type) {
 			case chart.TimeSeries:
-				fmt.Printf("[viewer] ttfb series[%d] TimeSeries X=%d Y=%d\n", i, len(ss.XValues), len(ss.YValues))
+				logf(slog.LevelDebug, "viewer", "ttfb series[%d] TimeSeries X=%d Y=%d", i, len(ss.XValues), len(ss.YValues))
 			case chart.ContinuousSeries:
-				fmt.Printf("[viewer] ttfb series[%d] Continuous X=%d Y=%d\n", i, len(ss.XValues), len(ss.YValues))
+				logf(slog.LevelDebug, "viewer", "ttfb series[%d] Continuous X=%d Y=%d", i, len(ss.XValues), len(ss.YValues))
 			default:
-				fmt.Printf("[viewer] ttfb series[%d] type=%T\n", i, s)
+				logf(slog.LevelDebug, "viewer", "ttfb series[%d] type=%T", i, s)
 			}
 		}
 	}
 	cw, chh := chartSize(state)
 	ch.Width = cw
 	ch.Height = chh
-	attachLegend(&ch)
+	attachLegend(state, &ch)
 
 	var buf bytes.Buffer
-	if err := ch.Render(chart.PNG, &buf); err != nil {
+	if err := renderChart(state, &ch, &buf); err != nil {
 		cw, chh := chartSize(state)
-		fmt.Printf("[viewer] ttfb chart render error: %v; showing blank fallback\n", err)
+		logf(slog.LevelWarn, "viewer", "ttfb chart render error: %v; showing blank fallback", err)
 		return blank(cw, chh)
 	}
 	img, err := png.Decode(&buf)
 	if err != nil {
 		cw, chh := chartSize(state)
-		fmt.Printf("[viewer] ttfb chart decode error: %v; showing blank fallback\n", err)
+		logf(slog.LevelWarn, "viewer", "ttfb chart decode error: %v; showing blank fallback", err)
 		return blank(cw, chh)
 	}
 	if state.showHints {
@@ -9165,6 +11483,9 @@ func renderSpeedChartVariant(state *uiState, mode string) image.Image {
 		w, h := chartSize(state)
 		return blank(w, h)
 	}
+	if img, ok := renderSituationOverlay(state, "speed_"+strings.ToLower(mode)); ok {
+		return img
+	}
 	// Save current toggles
 	sa, smed, smin, smax := state.showAvg, state.showMedian, state.showMin, state.showMax
 	// Configure for requested variant
@@ -9192,6 +11513,9 @@ func renderTTFBChartVariant(state *uiState, mode string) image.Image {
 		w, h := chartSize(state)
 		return blank(w, h)
 	}
+	if img, ok := renderSituationOverlay(state, "ttfb_"+strings.ToLower(mode)); ok {
+		return img
+	}
 	sa, smed, smin, smax := state.showAvg, state.showMedian, state.showMin, state.showMax
 	state.showAvg, state.showMedian, state.showMin, state.showMax = false, false, false, false
 	switch strings.ToLower(mode) {
@@ -9506,13 +11830,13 @@ func renderStallCountChart(state *uiState) image.Image {
 	if state.showHints {
 		padBottom += 18
 	}
-	ch := chart.Chart{Title: "Stalled Requests Count", Background: chart.Style{Padding: chart.Box{Top: 14, Left: 16, Right: 12, Bottom: padBottom}}, XAxis: xAxis, YAxis: chart.YAxis{Name: "count", Range: yAxisRange, Ticks: yTicks}, Series: series}
+	ch := chart.Chart{Title: withNote("Stalled Requests Count", stallTimeoutNote(filteredSummaries(state))), Background: chart.Style{Padding: chart.Box{Top: 14, Left: 16, Right: 12, Bottom: padBottom}}, XAxis: xAxis, YAxis: chart.YAxis{Name: "count", Range: yAxisRange, Ticks: yTicks}, Series: series}
 	themeChart(&ch)
 	cw, chh := chartSize(state)
 	ch.Width, ch.Height = cw, chh
-	attachLegend(&ch)
+	attachLegend(state, &ch)
 	var buf bytes.Buffer
-	if err := ch.Render(chart.PNG, &buf); err != nil {
+	if err := renderChart(state, &ch, &buf); err != nil {
 		return blank(cw, chh)
 	}
 	img, err := png.Decode(&buf)
@@ -9527,6 +11851,9 @@ func renderStallCountChart(state *uiState) image.Image {
 
 // renderErrorRateChart draws error percentage per batch for overall, IPv4, IPv6.
 func renderErrorRateChart(state *uiState) image.Image {
+	if img, ok := renderSituationOverlay(state, "error_rate"); ok {
+		return img
+	}
 	rows := filteredSummaries(state)
 	if len(rows) == 0 {
 		cw, chh := chartSize(state)
@@ -9649,18 +11976,18 @@ func renderErrorRateChart(state *uiState) image.Image {
 	cw, chh := chartSize(state)
 	ch.Width = cw
 	ch.Height = chh
-	attachLegend(&ch)
+	attachLegend(state, &ch)
 
 	var buf bytes.Buffer
-	if err := ch.Render(chart.PNG, &buf); err != nil {
+	if err := renderChart(state, &ch, &buf); err != nil {
 		cw, chh := chartSize(state)
-		fmt.Printf("[viewer] error chart render error: %v; showing blank fallback\n", err)
+		logf(slog.LevelWarn, "viewer", "error chart render error: %v; showing blank fallback", err)
 		return blank(cw, chh)
 	}
 	img, err := png.Decode(&buf)
 	if err != nil {
 		cw, chh := chartSize(state)
-		fmt.Printf("[viewer] error chart decode error: %v; showing blank fallback\n", err)
+		logf(slog.LevelWarn, "viewer", "error chart decode error: %v; showing blank fallback", err)
 		return blank(cw, chh)
 	}
 	if state.showHints {
@@ -9669,8 +11996,91 @@ func renderErrorRateChart(state *uiState) image.Image {
 	return drawWatermark(img, "Situation: "+activeSituationLabel(state))
 }
 
+// renderPingJitterChart draws the RFC 3550 latency jitter of the ping probe lines per batch
+// (average and P95 over the batch's ping lines); batches without ping lines are gaps.
+func renderPingJitterChart(state *uiState) image.Image {
+	rows := filteredSummaries(state)
+	if len(rows) == 0 {
+		w, h := chartSize(state)
+		return blank(w, h)
+	}
+	timeMode, times, xs, xAxis := buildXAxis(rows, state.xAxisMode)
+	series := []chart.Series{}
+	maxY := 0.0
+	add := func(name string, sel func(analysis.BatchSummary) float64, color drawing.Color) {
+		ys := make([]float64, len(rows))
+		valid := 0
+		for i, r := range rows {
+			if r.PingJitterLines == 0 {
+				ys[i] = math.NaN()
+				continue
+			}
+			ys[i] = sel(r)
+			maxY = math.Max(maxY, ys[i])
+			valid++
+		}
+		if valid == 0 {
+			return
+		}
+		st := pointStyle(color)
+		if valid == 1 {
+			st.DotWidth = 6
+		}
+		if timeMode {
+			if len(times) == 1 {
+				series = append(series, chart.TimeSeries{Name: name, XValues: []time.Time{times[0], times[0].Add(1 * time.Second)}, YValues: []float64{ys[0], ys[0]}, Style: st})
+			} else {
+				series = append(series, chart.TimeSeries{Name: name, XValues: times, YValues: ys, Style: st})
+			}
+		} else {
+			if len(xs) == 1 {
+				series = append(series, chart.ContinuousSeries{Name: name, XValues: []float64{xs[0], xs[0] + 1}, YValues: []float64{ys[0], ys[0]}, Style: st})
+			} else {
+				series = append(series, chart.ContinuousSeries{Name: name, XValues: xs, YValues: ys, Style: st})
+			}
+		}
+	}
+	add("Avg", func(b analysis.BatchSummary) float64 { return b.AvgPingJitterMs }, chart.ColorBlue)
+	add("P95", func(b analysis.BatchSummary) float64 { return b.P95PingJitterMs }, chart.ColorOrange)
+	if len(series) == 0 {
+		w, h := chartSize(state)
+		return drawHint(blank(w, h), "No ping probe lines in these batches (add a site with \"probe\": \"ping\").")
+	}
+	padBottom := 28
+	switch state.xAxisMode {
+	case "run_tag":
+		padBottom = 90
+	case "time":
+		padBottom = 48
+	}
+	if state.showHints {
+		padBottom += 18
+	}
+	ch := chart.Chart{Title: "Ping Jitter (ms)", Background: chart.Style{Padding: chart.Box{Top: 14, Left: 16, Right: 12, Bottom: padBottom}}, XAxis: xAxis, YAxis: chart.YAxis{Name: "ms", Range: &chart.ContinuousRange{Min: 0, Max: math.Max(5, maxY*1.15)}}, Series: series}
+	themeChart(&ch)
+	ch.Title += allGroupsNote(state)
+	cw, chh := chartSize(state)
+	ch.Width, ch.Height = cw, chh
+	attachLegend(state, &ch)
+	var buf bytes.Buffer
+	if err := renderChart(state, &ch, &buf); err != nil {
+		return blank(cw, chh)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		return blank(cw, chh)
+	}
+	if state.showHints {
+		img = drawHint(img, "Hint: Above ~30 ms calls get choppy even when speed looks fine; compare with Stall Rate and TTFB.")
+	}
+	return drawWatermark(img, "Situation: "+activeSituationLabel(state))
+}
+
 // renderJitterChart draws AvgJitterPct per batch for overall, IPv4, IPv6.
 func renderJitterChart(state *uiState) image.Image {
+	if img, ok := renderSituationOverlay(state, "jitter"); ok {
+		return img
+	}
 	rows := filteredSummaries(state)
 	if len(rows) == 0 {
 		cw, chh := chartSize(state)
@@ -9779,17 +12189,17 @@ func renderJitterChart(state *uiState) image.Image {
 	themeChart(&ch)
 	cw, chh := chartSize(state)
 	ch.Width, ch.Height = cw, chh
-	attachLegend(&ch)
+	attachLegend(state, &ch)
 	var buf bytes.Buffer
-	if err := ch.Render(chart.PNG, &buf); err != nil {
+	if err := renderChart(state, &ch, &buf); err != nil {
 		cw, chh := chartSize(state)
-		fmt.Printf("[viewer] jitter chart render error: %v; showing blank fallback\n", err)
+		logf(slog.LevelWarn, "viewer", "jitter chart render error: %v; showing blank fallback", err)
 		return blank(cw, chh)
 	}
 	img, err := png.Decode(&buf)
 	if err != nil {
 		cw, chh := chartSize(state)
-		fmt.Printf("[viewer] jitter chart decode error: %v; showing blank fallback\n", err)
+		logf(slog.LevelWarn, "viewer", "jitter chart decode error: %v; showing blank fallback", err)
 		return blank(cw, chh)
 	}
 	if state.showHints {
@@ -9808,7 +12218,7 @@ func renderDNSLookupChart(state *uiState) image.Image {
 	timeMode, times, xs, xAxis := buildXAxis(rows, state.xAxisMode)
 	series := []chart.Series{}
 	minY, maxY := math.MaxFloat64, -math.MaxFloat64
-	add := func(name string, sel func(analysis.BatchSummary) float64, color drawing.Color) {
+	addStyled := func(name string, sel func(analysis.BatchSummary) float64, st chart.Style) {
 		ys := make([]float64, len(rows))
 		valid := 0
 		for i, r := range rows {
@@ -9826,7 +12236,9 @@ func renderDNSLookupChart(state *uiState) image.Image {
 			}
 			valid++
 		}
-		st := pointStyle(color)
+		if valid == 0 {
+			return
+		}
 		if valid == 1 {
 			st.DotWidth = 6
 		}
@@ -9848,6 +12260,9 @@ func renderDNSLookupChart(state *uiState) image.Image {
 			}
 		}
 	}
+	add := func(name string, sel func(analysis.BatchSummary) float64, color drawing.Color) {
+		addStyled(name, sel, pointStyle(color))
+	}
 	if state.showOverall {
 		add("Overall", func(b analysis.BatchSummary) float64 { return b.AvgDNSMs }, chart.ColorAlternateGray)
 	}
@@ -9867,6 +12282,18 @@ func renderDNSLookupChart(state *uiState) image.Image {
 			return b.IPv6.AvgDNSMs
 		}, chart.ColorGreen)
 	}
+	// A and AAAA lookups timed separately (monitor --dns-family-timing): dotted, in the family colors
+	dotted := func(c drawing.Color) chart.Style {
+		st := pointStyle(c)
+		st.StrokeDashArray = []float64{1, 3}
+		return st
+	}
+	if state.showIPv4 {
+		addStyled("A lookup", func(b analysis.BatchSummary) float64 { return b.AvgDNSAMs }, dotted(chart.ColorBlue))
+	}
+	if state.showIPv6 {
+		addStyled("AAAA lookup", func(b analysis.BatchSummary) float64 { return b.AvgDNSAAAAMs }, dotted(chart.ColorGreen))
+	}
 	// Optional legacy overlay from dns_time_ms as dashed series
 	if state.showDNSLegacy {
 		addDashed := func(name string, sel func(analysis.BatchSummary) float64, col drawing.Color) {
@@ -9942,9 +12369,9 @@ func renderDNSLookupChart(state *uiState) image.Image {
 	themeChart(&ch)
 	cw, chh := chartSize(state)
 	ch.Width, ch.Height = cw, chh
-	attachLegend(&ch)
+	attachLegend(state, &ch)
 	var buf bytes.Buffer
-	if err := ch.Render(chart.PNG, &buf); err != nil {
+	if err := renderChart(state, &ch, &buf); err != nil {
 		return blank(cw, chh)
 	}
 	img, err := png.Decode(&buf)
@@ -10041,9 +12468,9 @@ func renderTCPConnectChart(state *uiState) image.Image {
 	themeChart(&ch)
 	cw, chh := chartSize(state)
 	ch.Width, ch.Height = cw, chh
-	attachLegend(&ch)
+	attachLegend(state, &ch)
 	var buf bytes.Buffer
-	if err := ch.Render(chart.PNG, &buf); err != nil {
+	if err := renderChart(state, &ch, &buf); err != nil {
 		return blank(cw, chh)
 	}
 	img, err := png.Decode(&buf)
@@ -10140,9 +12567,9 @@ func renderTLSHandshakeChart(state *uiState) image.Image {
 	themeChart(&ch)
 	cw, chh := chartSize(state)
 	ch.Width, ch.Height = cw, chh
-	attachLegend(&ch)
+	attachLegend(state, &ch)
 	var buf bytes.Buffer
-	if err := ch.Render(chart.PNG, &buf); err != nil {
+	if err := renderChart(state, &ch, &buf); err != nil {
 		return blank(cw, chh)
 	}
 	img, err := png.Decode(&buf)
@@ -10232,11 +12659,12 @@ func renderHTTPProtocolMixChart(state *uiState) image.Image {
 	yTicks := []chart.Tick{{Value: 0, Label: "0"}, {Value: 25, Label: "25"}, {Value: 50, Label: "50"}, {Value: 75, Label: "75"}, {Value: 100, Label: "100"}}
 	ch := chart.Chart{Title: titleUnknownHidden(state, "HTTP Protocol Mix (%)"), Background: chart.Style{Padding: chart.Box{Top: 14, Left: 16, Right: 12, Bottom: padBottom}}, XAxis: xAxis, YAxis: chart.YAxis{Name: "%", Range: &chart.ContinuousRange{Min: 0, Max: 100}, Ticks: yTicks}, Series: series}
 	themeChart(&ch)
+	ch.Title += allGroupsNote(state)
 	cw, chh := chartSize(state)
 	ch.Width, ch.Height = cw, chh
-	attachLegend(&ch)
+	attachLegend(state, &ch)
 	var buf bytes.Buffer
-	if err := ch.Render(chart.PNG, &buf); err != nil {
+	if err := renderChart(state, &ch, &buf); err != nil {
 		return blank(cw, chh)
 	}
 	img, err := png.Decode(&buf)
@@ -10251,7 +12679,7 @@ func renderHTTPProtocolMixChart(state *uiState) image.Image {
 
 // renderAvgSpeedByHTTPProtocolChart draws average speed by protocol.
 func renderAvgSpeedByHTTPProtocolChart(state *uiState) image.Image {
-	unitName, factor := speedUnitNameAndFactor(state.speedUnit)
+	unitName, factor := speedUnitFor(state)
 	rows := filteredSummaries(state)
 	if len(rows) == 0 {
 		cw, chh := chartSize(state)
@@ -10338,11 +12766,12 @@ func renderAvgSpeedByHTTPProtocolChart(state *uiState) image.Image {
 	}
 	ch := chart.Chart{Title: titleUnknownHidden(state, fmt.Sprintf("Avg Speed by HTTP Protocol (%s)", unitName)), Background: chart.Style{Padding: chart.Box{Top: 14, Left: 16, Right: 12, Bottom: padBottom}}, XAxis: xAxis, YAxis: chart.YAxis{Name: unitName, Range: yAxisRange, Ticks: yTicks}, Series: series}
 	themeChart(&ch)
+	ch.Title += allGroupsNote(state)
 	cw, chh := chartSize(state)
 	ch.Width, ch.Height = cw, chh
-	attachLegend(&ch)
+	attachLegend(state, &ch)
 	var buf bytes.Buffer
-	if err := ch.Render(chart.PNG, &buf); err != nil {
+	if err := renderChart(state, &ch, &buf); err != nil {
 		return blank(cw, chh)
 	}
 	img, err := png.Decode(&buf)
@@ -10355,7 +12784,9 @@ func renderAvgSpeedByHTTPProtocolChart(state *uiState) image.Image {
 	return drawWatermark(img, noteUnknownHidden(state, "Situation: "+activeSituationLabel(state)))
 }
 
-func renderStallRateByHTTPProtocolChart(state *uiState) image.Image {
+// renderTTFBByHTTPProtocolChart draws average TTFB per HTTP protocol (solid) with the P95 as a
+// dashed line in the same colour, so h2 vs HTTP/1.1 first-byte latency can be compared.
+func renderTTFBByHTTPProtocolChart(state *uiState) image.Image {
 	rows := filteredSummaries(state)
 	if len(rows) == 0 {
 		cw, chh := chartSize(state)
@@ -10363,7 +12794,7 @@ func renderStallRateByHTTPProtocolChart(state *uiState) image.Image {
 	}
 	keySet := map[string]struct{}{}
 	for _, r := range rows {
-		for k := range r.StallRateByHTTPProtocolPct {
+		for k := range r.AvgTTFBByHTTPProtocolMs {
 			if state.hideUnknownProtocols && k == "(unknown)" {
 				continue
 			}
@@ -10381,39 +12812,65 @@ func renderStallRateByHTTPProtocolChart(state *uiState) image.Image {
 	sort.Strings(keys)
 	timeMode, times, xs, xAxis := buildXAxis(rows, state.xAxisMode)
 	var series []chart.Series
+	minY := math.MaxFloat64
+	maxY := -math.MaxFloat64
 	palette := []drawing.Color{chart.ColorBlue, chart.ColorGreen, chart.ColorRed, chart.ColorAlternateGray, chart.ColorBlack, chart.ColorYellow, chart.ColorOrange}
-	for i, k := range keys {
-		ys := make([]float64, len(rows))
+	// addLine plots only the batches that have a value (no NaN gaps) and widens a lone point.
+	addLine := func(name string, st chart.Style, val func(analysis.BatchSummary) float64) {
+		var px []float64
+		var pt []time.Time
+		var py []float64
 		for j, r := range rows {
-			ys[j] = r.StallRateByHTTPProtocolPct[k]
-			if ys[j] < 0 {
-				ys[j] = math.NaN()
+			v := val(r)
+			if v <= 0 {
+				continue
 			}
-		}
-		st := pointStyle(palette[i%len(palette)])
-		name := k
-		if timeMode {
-			if len(times) == 1 {
-				t2 := times[0].Add(1 * time.Second)
-				ys = append([]float64{ys[0]}, ys[0])
-				series = append(series, chart.TimeSeries{Name: name, XValues: []time.Time{times[0], t2}, YValues: ys, Style: st})
+			if v < minY {
+				minY = v
+			}
+			if v > maxY {
+				maxY = v
+			}
+			py = append(py, v)
+			if timeMode {
+				pt = append(pt, times[j])
 			} else {
-				series = append(series, chart.TimeSeries{Name: name, XValues: times, YValues: ys, Style: st})
+				px = append(px, xs[j])
 			}
-		} else {
-			if len(xs) == 1 {
-				x2 := xs[0] + 1
-				ys = append([]float64{ys[0]}, ys[0])
-				series = append(series, chart.ContinuousSeries{Name: name, XValues: []float64{xs[0], x2}, YValues: ys, Style: st})
+		}
+		if len(py) == 0 {
+			return
+		}
+		if len(py) == 1 {
+			st.DotWidth = 6
+			py = append(py, py[0])
+			if timeMode {
+				pt = append(pt, pt[0].Add(1*time.Second))
 			} else {
-				series = append(series, chart.ContinuousSeries{Name: name, XValues: xs, YValues: ys, Style: st})
+				px = append(px, px[0]+1)
 			}
 		}
+		if timeMode {
+			series = append(series, chart.TimeSeries{Name: name, XValues: pt, YValues: py, Style: st})
+		} else {
+			series = append(series, chart.ContinuousSeries{Name: name, XValues: px, YValues: py, Style: st})
+		}
+	}
+	for i, k := range keys {
+		col := palette[i%len(palette)]
+		addLine(k+" avg", pointStyle(col), func(r analysis.BatchSummary) float64 { return r.AvgTTFBByHTTPProtocolMs[k] })
+		p95 := chart.Style{StrokeColor: col, StrokeWidth: 1.0, StrokeDashArray: []float64{4, 3}, DotWidth: 2, DotColor: col}
+		addLine(k+" P95", p95, func(r analysis.BatchSummary) float64 { return r.P95TTFBByHTTPProtocolMs[k] })
+	}
+	if len(series) == 0 {
+		cw, chh := chartSize(state)
+		return drawWatermark(blank(cw, chh), noteUnknownHidden(state, "Situation: "+activeSituationLabel(state)))
 	}
 	// Append legend cue for hidden unknowns
 	if s := legendUnknownHiddenSeries(state); s != nil {
 		series = append(series, s)
 	}
+	yAxisRange, yTicks := computeYAxisRange(minY, maxY, state.useRelative, false)
 	padBottom := 28
 	switch state.xAxisMode {
 	case "run_tag":
@@ -10424,14 +12881,14 @@ func renderStallRateByHTTPProtocolChart(state *uiState) image.Image {
 	if state.showHints {
 		padBottom += 18
 	}
-	yTicks := []chart.Tick{{Value: 0, Label: "0"}, {Value: 25, Label: "25"}, {Value: 50, Label: "50"}, {Value: 75, Label: "75"}, {Value: 100, Label: "100"}}
-	ch := chart.Chart{Title: titleUnknownHidden(state, "Stall Rate by HTTP Protocol (%)"), Background: chart.Style{Padding: chart.Box{Top: 14, Left: 16, Right: 12, Bottom: padBottom}}, XAxis: xAxis, YAxis: chart.YAxis{Name: "%", Range: &chart.ContinuousRange{Min: 0, Max: 100}, Ticks: yTicks}, Series: series}
+	ch := chart.Chart{Title: titleUnknownHidden(state, "TTFB by HTTP Protocol (ms)"), Background: chart.Style{Padding: chart.Box{Top: 14, Left: 16, Right: 12, Bottom: padBottom}}, XAxis: xAxis, YAxis: chart.YAxis{Name: "ms", Range: yAxisRange, Ticks: yTicks}, Series: series}
 	themeChart(&ch)
+	ch.Title += allGroupsNote(state)
 	cw, chh := chartSize(state)
 	ch.Width, ch.Height = cw, chh
-	attachLegend(&ch)
+	attachLegend(state, &ch)
 	var buf bytes.Buffer
-	if err := ch.Render(chart.PNG, &buf); err != nil {
+	if err := renderChart(state, &ch, &buf); err != nil {
 		return blank(cw, chh)
 	}
 	img, err := png.Decode(&buf)
@@ -10439,12 +12896,12 @@ func renderStallRateByHTTPProtocolChart(state *uiState) image.Image {
 		return blank(cw, chh)
 	}
 	if state.showHints {
-		img = drawHint(img, "Hint: Percentage of stalled requests per protocol.")
+		img = drawHint(img, "Hint: Solid = average TTFB, dashed = P95 per negotiated protocol. A protocol with a higher tail hints at head-of-line blocking or a different server path.")
 	}
 	return drawWatermark(img, noteUnknownHidden(state, "Situation: "+activeSituationLabel(state)))
 }
 
-func renderErrorRateByHTTPProtocolChart(state *uiState) image.Image {
+func renderStallRateByHTTPProtocolChart(state *uiState) image.Image {
 	rows := filteredSummaries(state)
 	if len(rows) == 0 {
 		cw, chh := chartSize(state)
@@ -10452,7 +12909,7 @@ func renderErrorRateByHTTPProtocolChart(state *uiState) image.Image {
 	}
 	keySet := map[string]struct{}{}
 	for _, r := range rows {
-		for k := range r.ErrorRateByHTTPProtocolPct {
+		for k := range r.StallRateByHTTPProtocolPct {
 			if state.hideUnknownProtocols && k == "(unknown)" {
 				continue
 			}
@@ -10474,7 +12931,7 @@ func renderErrorRateByHTTPProtocolChart(state *uiState) image.Image {
 	for i, k := range keys {
 		ys := make([]float64, len(rows))
 		for j, r := range rows {
-			ys[j] = r.ErrorRateByHTTPProtocolPct[k]
+			ys[j] = r.StallRateByHTTPProtocolPct[k]
 			if ys[j] < 0 {
 				ys[j] = math.NaN()
 			}
@@ -10514,13 +12971,14 @@ func renderErrorRateByHTTPProtocolChart(state *uiState) image.Image {
 		padBottom += 18
 	}
 	yTicks := []chart.Tick{{Value: 0, Label: "0"}, {Value: 25, Label: "25"}, {Value: 50, Label: "50"}, {Value: 75, Label: "75"}, {Value: 100, Label: "100"}}
-	ch := chart.Chart{Title: titleUnknownHidden(state, "Error Rate by HTTP Protocol (%)"), Background: chart.Style{Padding: chart.Box{Top: 14, Left: 16, Right: 12, Bottom: padBottom}}, XAxis: xAxis, YAxis: chart.YAxis{Name: "%", Range: &chart.ContinuousRange{Min: 0, Max: 100}, Ticks: yTicks}, Series: series}
+	ch := chart.Chart{Title: titleUnknownHidden(state, "Stall Rate by HTTP Protocol (%)"), Background: chart.Style{Padding: chart.Box{Top: 14, Left: 16, Right: 12, Bottom: padBottom}}, XAxis: xAxis, YAxis: chart.YAxis{Name: "%", Range: &chart.ContinuousRange{Min: 0, Max: 100}, Ticks: yTicks}, Series: series}
 	themeChart(&ch)
+	ch.Title += allGroupsNote(state)
 	cw, chh := chartSize(state)
 	ch.Width, ch.Height = cw, chh
-	attachLegend(&ch)
+	attachLegend(state, &ch)
 	var buf bytes.Buffer
-	if err := ch.Render(chart.PNG, &buf); err != nil {
+	if err := renderChart(state, &ch, &buf); err != nil {
 		return blank(cw, chh)
 	}
 	img, err := png.Decode(&buf)
@@ -10528,13 +12986,12 @@ func renderErrorRateByHTTPProtocolChart(state *uiState) image.Image {
 		return blank(cw, chh)
 	}
 	if state.showHints {
-		img = drawHint(img, "Hint: Error percentage per protocol. Spikes may indicate protocol-specific issues.")
+		img = drawHint(img, "Hint: Percentage of stalled requests per protocol.")
 	}
 	return drawWatermark(img, noteUnknownHidden(state, "Situation: "+activeSituationLabel(state)))
 }
 
-// renderErrorShareByHTTPProtocolChart draws share of total errors by HTTP protocol.
-func renderErrorShareByHTTPProtocolChart(state *uiState) image.Image {
+func renderErrorRateByHTTPProtocolChart(state *uiState) image.Image {
 	rows := filteredSummaries(state)
 	if len(rows) == 0 {
 		cw, chh := chartSize(state)
@@ -10542,7 +12999,98 @@ func renderErrorShareByHTTPProtocolChart(state *uiState) image.Image {
 	}
 	keySet := map[string]struct{}{}
 	for _, r := range rows {
-		for k := range r.ErrorShareByHTTPProtocolPct {
+		for k := range r.ErrorRateByHTTPProtocolPct {
+			if state.hideUnknownProtocols && k == "(unknown)" {
+				continue
+			}
+			keySet[k] = struct{}{}
+		}
+	}
+	if len(keySet) == 0 {
+		cw, chh := chartSize(state)
+		return drawWatermark(blank(cw, chh), noteUnknownHidden(state, "Situation: "+activeSituationLabel(state)))
+	}
+	keys := make([]string, 0, len(keySet))
+	for k := range keySet {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	timeMode, times, xs, xAxis := buildXAxis(rows, state.xAxisMode)
+	var series []chart.Series
+	palette := []drawing.Color{chart.ColorBlue, chart.ColorGreen, chart.ColorRed, chart.ColorAlternateGray, chart.ColorBlack, chart.ColorYellow, chart.ColorOrange}
+	for i, k := range keys {
+		ys := make([]float64, len(rows))
+		for j, r := range rows {
+			ys[j] = r.ErrorRateByHTTPProtocolPct[k]
+			if ys[j] < 0 {
+				ys[j] = math.NaN()
+			}
+		}
+		st := pointStyle(palette[i%len(palette)])
+		name := k
+		if timeMode {
+			if len(times) == 1 {
+				t2 := times[0].Add(1 * time.Second)
+				ys = append([]float64{ys[0]}, ys[0])
+				series = append(series, chart.TimeSeries{Name: name, XValues: []time.Time{times[0], t2}, YValues: ys, Style: st})
+			} else {
+				series = append(series, chart.TimeSeries{Name: name, XValues: times, YValues: ys, Style: st})
+			}
+		} else {
+			if len(xs) == 1 {
+				x2 := xs[0] + 1
+				ys = append([]float64{ys[0]}, ys[0])
+				series = append(series, chart.ContinuousSeries{Name: name, XValues: []float64{xs[0], x2}, YValues: ys, Style: st})
+			} else {
+				series = append(series, chart.ContinuousSeries{Name: name, XValues: xs, YValues: ys, Style: st})
+			}
+		}
+	}
+	// Append legend cue for hidden unknowns
+	if s := legendUnknownHiddenSeries(state); s != nil {
+		series = append(series, s)
+	}
+	padBottom := 28
+	switch state.xAxisMode {
+	case "run_tag":
+		padBottom = 90
+	case "time":
+		padBottom = 48
+	}
+	if state.showHints {
+		padBottom += 18
+	}
+	yTicks := []chart.Tick{{Value: 0, Label: "0"}, {Value: 25, Label: "25"}, {Value: 50, Label: "50"}, {Value: 75, Label: "75"}, {Value: 100, Label: "100"}}
+	ch := chart.Chart{Title: titleUnknownHidden(state, "Error Rate by HTTP Protocol (%)"), Background: chart.Style{Padding: chart.Box{Top: 14, Left: 16, Right: 12, Bottom: padBottom}}, XAxis: xAxis, YAxis: chart.YAxis{Name: "%", Range: &chart.ContinuousRange{Min: 0, Max: 100}, Ticks: yTicks}, Series: series}
+	themeChart(&ch)
+	ch.Title += allGroupsNote(state)
+	cw, chh := chartSize(state)
+	ch.Width, ch.Height = cw, chh
+	attachLegend(state, &ch)
+	var buf bytes.Buffer
+	if err := renderChart(state, &ch, &buf); err != nil {
+		return blank(cw, chh)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		return blank(cw, chh)
+	}
+	if state.showHints {
+		img = drawHint(img, "Hint: Error percentage per protocol. Spikes may indicate protocol-specific issues.")
+	}
+	return drawWatermark(img, noteUnknownHidden(state, "Situation: "+activeSituationLabel(state)))
+}
+
+// renderErrorShareByHTTPProtocolChart draws share of total errors by HTTP protocol.
+func renderErrorShareByHTTPProtocolChart(state *uiState) image.Image {
+	rows := filteredSummaries(state)
+	if len(rows) == 0 {
+		cw, chh := chartSize(state)
+		return blank(cw, chh)
+	}
+	keySet := map[string]struct{}{}
+	for _, r := range rows {
+		for k := range r.ErrorShareByHTTPProtocolPct {
 			if state.hideUnknownProtocols && k == "(unknown)" {
 				continue
 			}
@@ -10606,11 +13154,12 @@ func renderErrorShareByHTTPProtocolChart(state *uiState) image.Image {
 	yTicks := []chart.Tick{{Value: 0, Label: "0"}, {Value: 25, Label: "25"}, {Value: 50, Label: "50"}, {Value: 75, Label: "75"}, {Value: 100, Label: "100"}}
 	ch := chart.Chart{Title: titleUnknownHidden(state, "Error Share by HTTP Protocol (%)"), Background: chart.Style{Padding: chart.Box{Top: 14, Left: 16, Right: 12, Bottom: padBottom}}, XAxis: xAxis, YAxis: chart.YAxis{Name: "%", Range: &chart.ContinuousRange{Min: 0, Max: 100}, Ticks: yTicks}, Series: series}
 	themeChart(&ch)
+	ch.Title += allGroupsNote(state)
 	cw, chh := chartSize(state)
 	ch.Width, ch.Height = cw, chh
-	attachLegend(&ch)
+	attachLegend(state, &ch)
 	var buf bytes.Buffer
-	if err := ch.Render(chart.PNG, &buf); err != nil {
+	if err := renderChart(state, &ch, &buf); err != nil {
 		return blank(cw, chh)
 	}
 	img, err := png.Decode(&buf)
@@ -10643,7 +13192,7 @@ func renderErrorTypesChart(state *uiState) image.Image {
 	}
 	keys := make([]string, 0, len(keySet))
 	// Stable ordering by known set
-	order := []string{"dns", "tcp", "tls", "head", "http", "range"}
+	order := []string{"dns", "tcp", "tls", "head", "http", "rate_limit", "range"}
 	for _, k := range order {
 		if _, ok := keySet[k]; ok {
 			keys = append(keys, k)
@@ -10672,7 +13221,7 @@ func renderErrorTypesChart(state *uiState) image.Image {
 			}
 		}
 		st := pointStyle(palette[i%len(palette)])
-		name := strings.ToUpper(k)
+		name := strings.ToUpper(strings.ReplaceAll(k, "_", " "))
 		if timeMode {
 			if len(times) == 1 {
 				t2 := times[0].Add(1 * time.Second)
@@ -10704,11 +13253,12 @@ func renderErrorTypesChart(state *uiState) image.Image {
 	yTicks := []chart.Tick{{Value: 0, Label: "0"}, {Value: 25, Label: "25"}, {Value: 50, Label: "50"}, {Value: 75, Label: "75"}, {Value: 100, Label: "100"}}
 	ch := chart.Chart{Title: "Error Types (share of errors, %) ", Background: chart.Style{Padding: chart.Box{Top: 14, Left: 16, Right: 12, Bottom: padBottom}}, XAxis: xAxis, YAxis: chart.YAxis{Name: "%", Range: &chart.ContinuousRange{Min: 0, Max: 100}, Ticks: yTicks}, Series: series}
 	themeChart(&ch)
+	ch.Title += allGroupsNote(state)
 	cw, chh := chartSize(state)
 	ch.Width, ch.Height = cw, chh
-	attachLegend(&ch)
+	attachLegend(state, &ch)
 	var buf bytes.Buffer
-	if err := ch.Render(chart.PNG, &buf); err != nil {
+	if err := renderChart(state, &ch, &buf); err != nil {
 		return blank(cw, chh)
 	}
 	img, err := png.Decode(&buf)
@@ -10747,7 +13297,7 @@ func renderErrorReasonsChart(state *uiState) image.Image {
 	}
 	keys := make([]string, 0, len(keySet))
 	// Prefer a stable, meaningful order; then append extras
-	preferred := []string{"timeout", "conn_refused", "conn_reset", "tls_cert", "tls_handshake", "stall_pre_ttfb", "stall_abort", "partial_body", "http_4xx", "http_5xx", "dns_failure", "proxy", "unreachable", "stall", "other"}
+	preferred := []string{"timeout", "conn_refused", "conn_reset", "tls_cert", "tls_handshake", "stall_pre_ttfb", "stall_abort", "partial_body", "content_mismatch", "rate_limited", "http_4xx", "http_5xx", "dns_failure", "proxy", "unreachable", "stall", "other"}
 	for _, k := range preferred {
 		if _, ok := keySet[k]; ok {
 			if state.hideOtherCategories && k == "other" {
@@ -10809,11 +13359,12 @@ func renderErrorReasonsChart(state *uiState) image.Image {
 	yTicks := []chart.Tick{{Value: 0, Label: "0"}, {Value: 25, Label: "25"}, {Value: 50, Label: "50"}, {Value: 75, Label: "75"}, {Value: 100, Label: "100"}}
 	ch := chart.Chart{Title: "Error Reasons (share of errors, %)", Background: chart.Style{Padding: chart.Box{Top: 14, Left: 16, Right: 12, Bottom: padBottom}}, XAxis: xAxis, YAxis: chart.YAxis{Name: "%", Range: &chart.ContinuousRange{Min: 0, Max: 100}, Ticks: yTicks}, Series: series}
 	themeChart(&ch)
+	ch.Title += allGroupsNote(state)
 	cw, chh := chartSize(state)
 	ch.Width, ch.Height = cw, chh
-	attachLegend(&ch)
+	attachLegend(state, &ch)
 	var buf bytes.Buffer
-	if err := ch.Render(chart.PNG, &buf); err != nil {
+	if err := renderChart(state, &ch, &buf); err != nil {
 		return blank(cw, chh)
 	}
 	img, err := png.Decode(&buf)
@@ -10937,11 +13488,12 @@ func renderErrorReasonsDetailedChart(state *uiState) image.Image {
 	yTicks := []chart.Tick{{Value: 0, Label: "0"}, {Value: 25, Label: "25"}, {Value: 50, Label: "50"}, {Value: 75, Label: "75"}, {Value: 100, Label: "100"}}
 	ch := chart.Chart{Title: "Error Reasons (detailed share of errors, %)", Background: chart.Style{Padding: chart.Box{Top: 14, Left: 16, Right: 12, Bottom: padBottom}}, XAxis: xAxis, YAxis: chart.YAxis{Name: "%", Range: &chart.ContinuousRange{Min: 0, Max: 100}, Ticks: yTicks}, Series: series}
 	themeChart(&ch)
+	ch.Title += allGroupsNote(state)
 	cw, chh := chartSize(state)
 	ch.Width, ch.Height = cw, chh
-	attachLegend(&ch)
+	attachLegend(state, &ch)
 	var buf bytes.Buffer
-	if err := ch.Render(chart.PNG, &buf); err != nil {
+	if err := renderChart(state, &ch, &buf); err != nil {
 		return blank(cw, chh)
 	}
 	img, err := png.Decode(&buf)
@@ -11027,11 +13579,12 @@ func renderStallShareByHTTPProtocolChart(state *uiState) image.Image {
 	yTicks := []chart.Tick{{Value: 0, Label: "0"}, {Value: 25, Label: "25"}, {Value: 50, Label: "50"}, {Value: 75, Label: "75"}, {Value: 100, Label: "100"}}
 	ch := chart.Chart{Title: titleUnknownHidden(state, "Stall Share by HTTP Protocol (%)"), Background: chart.Style{Padding: chart.Box{Top: 14, Left: 16, Right: 12, Bottom: padBottom}}, XAxis: xAxis, YAxis: chart.YAxis{Name: "%", Range: &chart.ContinuousRange{Min: 0, Max: 100}, Ticks: yTicks}, Series: series}
 	themeChart(&ch)
+	ch.Title += allGroupsNote(state)
 	cw, chh := chartSize(state)
 	ch.Width, ch.Height = cw, chh
-	attachLegend(&ch)
+	attachLegend(state, &ch)
 	var buf bytes.Buffer
-	if err := ch.Render(chart.PNG, &buf); err != nil {
+	if err := renderChart(state, &ch, &buf); err != nil {
 		return blank(cw, chh)
 	}
 	img, err := png.Decode(&buf)
@@ -11117,11 +13670,12 @@ func renderPartialShareByHTTPProtocolChart(state *uiState) image.Image {
 	yTicks := []chart.Tick{{Value: 0, Label: "0"}, {Value: 25, Label: "25"}, {Value: 50, Label: "50"}, {Value: 75, Label: "75"}, {Value: 100, Label: "100"}}
 	ch := chart.Chart{Title: titleUnknownHidden(state, "Partial Share by HTTP Protocol (%)"), Background: chart.Style{Padding: chart.Box{Top: 14, Left: 16, Right: 12, Bottom: padBottom}}, XAxis: xAxis, YAxis: chart.YAxis{Name: "%", Range: &chart.ContinuousRange{Min: 0, Max: 100}, Ticks: yTicks}, Series: series}
 	themeChart(&ch)
+	ch.Title += allGroupsNote(state)
 	cw, chh := chartSize(state)
 	ch.Width, ch.Height = cw, chh
-	attachLegend(&ch)
+	attachLegend(state, &ch)
 	var buf bytes.Buffer
-	if err := ch.Render(chart.PNG, &buf); err != nil {
+	if err := renderChart(state, &ch, &buf); err != nil {
 		return blank(cw, chh)
 	}
 	img, err := png.Decode(&buf)
@@ -11270,7 +13824,7 @@ func renderErrorsByURLChart(state *uiState) image.Image {
 	bc.XAxis = chart.Style{}
 	// Render with custom value labels under bars (use ticks drawn by library based on labels on values)
 	var buf bytes.Buffer
-	if err := bc.Render(chart.PNG, &buf); err != nil {
+	if err := renderChart(state, &bc, &buf); err != nil {
 		return blank(cw, chh)
 	}
 	img, err := png.Decode(&buf)
@@ -11299,7 +13853,7 @@ func renderHostIPTimingBreakdownChart(state *uiState) image.Image {
 		ix = 0
 	}
 	runTag := rows[ix].RunTag
-	f, err := os.Open(state.filePath)
+	f, err := analysis.OpenResults(state.filePath)
 	if err != nil {
 		cw, chh := chartSize(state)
 		return blank(cw, chh)
@@ -11585,7 +14139,7 @@ func renderHostIPTimingAvgChart(state *uiState) image.Image {
 	// Preload whole file lines to reuse (avoid multi-open parse cost if large maybe heavy; fallback simple streaming per batch)
 	// Simpler: stream per batch separately (maybe slower but fine for moderate size).
 	for _, rsum := range rows { // each batch
-		f, err := os.Open(state.filePath)
+		f, err := analysis.OpenResults(state.filePath)
 		if err != nil {
 			continue
 		}
@@ -12028,7 +14582,7 @@ func renderSpeedPercentilesDetailedChart(state *uiState) image.Image {
 	}
 	bs := rows[ix]
 
-	unitName, factor := speedUnitNameAndFactor(state.speedUnit)
+	unitName, factor := speedUnitFor(state)
 	// Collect percentiles in fixed order
 	type pv struct {
 		name string
@@ -12058,7 +14612,7 @@ func renderSpeedPercentilesDetailedChart(state *uiState) image.Image {
 	}
 	// Build bar chart
 	bc := chart.BarChart{
-		Title:      fmt.Sprintf("Speed Percentiles (%s)", unitName),
+		Title:      fmt.Sprintf(tr("Speed Percentiles (%s)"), unitName),
 		Height:     0,
 		Background: chart.Style{Padding: chart.Box{Top: 14, Left: 16, Right: 12, Bottom: 60}},
 		YAxis:      chart.YAxis{},
@@ -12070,7 +14624,7 @@ func renderSpeedPercentilesDetailedChart(state *uiState) image.Image {
 	bc.Width = cw
 	bc.Height = chh
 	var buf bytes.Buffer
-	if err := bc.Render(chart.PNG, &buf); err != nil {
+	if err := renderChart(state, &bc, &buf); err != nil {
 		return blank(cw, chh)
 	}
 	img, err := png.Decode(&buf)
@@ -12091,7 +14645,7 @@ func loadPerRequestSpeedSamplesForRunTag(state *uiState, runTag string, maxSerie
 	if state == nil || strings.TrimSpace(state.filePath) == "" || strings.TrimSpace(runTag) == "" || maxSeries <= 0 {
 		return nil
 	}
-	f, err := os.Open(state.filePath)
+	f, err := analysis.OpenResults(state.filePath)
 	if err != nil {
 		return nil
 	}
@@ -12142,7 +14696,7 @@ func loadPerRequestSessionsMetaForRunTag(state *uiState, runTag string, maxSerie
 	if state == nil || strings.TrimSpace(state.filePath) == "" || strings.TrimSpace(runTag) == "" || maxSeries <= 0 {
 		return nil
 	}
-	f, err := os.Open(state.filePath)
+	f, err := analysis.OpenResults(state.filePath)
 	if err != nil {
 		return nil
 	}
@@ -12204,7 +14758,7 @@ func renderSpeedOverTimeDetailedChart(state *uiState) image.Image {
 		ix = 0
 	}
 	sel := rows[ix]
-	unitName, factor := speedUnitNameAndFactor(state.speedUnit)
+	unitName, factor := speedUnitFor(state)
 	maxSeries := state.detailedMaxSeries
 	if maxSeries <= 0 {
 		maxSeries = 8
@@ -12299,7 +14853,7 @@ func renderSpeedOverTimeDetailedChart(state *uiState) image.Image {
 	cw, chh := chartSize(state)
 	ch.Width, ch.Height = cw, chh
 	var buf bytes.Buffer
-	if err := ch.Render(chart.PNG, &buf); err != nil {
+	if err := renderChart(state, &ch, &buf); err != nil {
 		return blank(cw, chh)
 	}
 	img, err := png.Decode(&buf)
@@ -12353,7 +14907,7 @@ func loadPerRequestSessionsForRunTag(state *uiState, runTag string, maxSessions
 	if state == nil || strings.TrimSpace(state.filePath) == "" || strings.TrimSpace(runTag) == "" || maxSessions <= 0 {
 		return nil
 	}
-	f, err := os.Open(state.filePath)
+	f, err := analysis.OpenResults(state.filePath)
 	if err != nil {
 		return nil
 	}
@@ -12427,7 +14981,7 @@ func renderSpeedOverTimeTopSessionsChart(state *uiState) image.Image {
 		ix = 0
 	}
 	sel := rows[ix]
-	unitName, factor := speedUnitNameAndFactor(state.speedUnit)
+	unitName, factor := speedUnitFor(state)
 	maxSessions := state.detailedTopSessionsN
 	if maxSessions <= 0 {
 		maxSessions = 4
@@ -12490,7 +15044,7 @@ func renderSpeedOverTimeTopSessionsChart(state *uiState) image.Image {
 		ch.Width = fullW
 		ch.Height = miniH
 		var buf bytes.Buffer
-		if err := ch.Render(chart.PNG, &buf); err != nil {
+		if err := renderChart(state, &ch, &buf); err != nil {
 			continue
 		}
 		img, err := png.Decode(&buf)
@@ -12625,7 +15179,7 @@ func renderBytesOverTimeDetailedChart(state *uiState) image.Image {
 	cw, chh := chartSize(state)
 	ch.Width, ch.Height = cw, chh
 	var buf bytes.Buffer
-	if err := ch.Render(chart.PNG, &buf); err != nil {
+	if err := renderChart(state, &ch, &buf); err != nil {
 		return blank(cw, chh)
 	}
 	img, err := png.Decode(&buf)
@@ -12714,7 +15268,7 @@ func renderBytesOverTimeTopSessionsChart(state *uiState) image.Image {
 		ch.Width = fullW
 		ch.Height = miniH
 		var buf bytes.Buffer
-		if err := ch.Render(chart.PNG, &buf); err != nil {
+		if err := renderChart(state, &ch, &buf); err != nil {
 			continue
 		}
 		img, err := png.Decode(&buf)
@@ -12816,11 +15370,12 @@ func renderPartialBodyRateByHTTPProtocolChart(state *uiState) image.Image {
 	yTicks := []chart.Tick{{Value: 0, Label: "0"}, {Value: 25, Label: "25"}, {Value: 50, Label: "50"}, {Value: 75, Label: "75"}, {Value: 100, Label: "100"}}
 	ch := chart.Chart{Title: titleUnknownHidden(state, "Partial Body Rate by HTTP Protocol (%)"), Background: chart.Style{Padding: chart.Box{Top: 14, Left: 16, Right: 12, Bottom: padBottom}}, XAxis: xAxis, YAxis: chart.YAxis{Name: "%", Range: &chart.ContinuousRange{Min: 0, Max: 100}, Ticks: yTicks}, Series: series}
 	themeChart(&ch)
+	ch.Title += allGroupsNote(state)
 	cw, chh := chartSize(state)
 	ch.Width, ch.Height = cw, chh
-	attachLegend(&ch)
+	attachLegend(state, &ch)
 	var buf bytes.Buffer
-	if err := ch.Render(chart.PNG, &buf); err != nil {
+	if err := renderChart(state, &ch, &buf); err != nil {
 		return blank(cw, chh)
 	}
 	img, err := png.Decode(&buf)
@@ -12905,11 +15460,12 @@ func renderTLSVersionMixChart(state *uiState) image.Image {
 	yTicks := []chart.Tick{{Value: 0, Label: "0"}, {Value: 25, Label: "25"}, {Value: 50, Label: "50"}, {Value: 75, Label: "75"}, {Value: 100, Label: "100"}}
 	ch := chart.Chart{Title: titleUnknownHidden(state, "TLS Version Mix (%)"), Background: chart.Style{Padding: chart.Box{Top: 14, Left: 16, Right: 12, Bottom: padBottom}}, XAxis: xAxis, YAxis: chart.YAxis{Name: "%", Range: &chart.ContinuousRange{Min: 0, Max: 100}, Ticks: yTicks}, Series: series}
 	themeChart(&ch)
+	ch.Title += allGroupsNote(state)
 	cw, chh := chartSize(state)
 	ch.Width, ch.Height = cw, chh
-	attachLegend(&ch)
+	attachLegend(state, &ch)
 	var buf bytes.Buffer
-	if err := ch.Render(chart.PNG, &buf); err != nil {
+	if err := renderChart(state, &ch, &buf); err != nil {
 		return blank(cw, chh)
 	}
 	img, err := png.Decode(&buf)
@@ -12994,11 +15550,12 @@ func renderALPNMixChart(state *uiState) image.Image {
 	yTicks := []chart.Tick{{Value: 0, Label: "0"}, {Value: 25, Label: "25"}, {Value: 50, Label: "50"}, {Value: 75, Label: "75"}, {Value: 100, Label: "100"}}
 	ch := chart.Chart{Title: titleUnknownHidden(state, "ALPN Mix (%)"), Background: chart.Style{Padding: chart.Box{Top: 14, Left: 16, Right: 12, Bottom: padBottom}}, XAxis: xAxis, YAxis: chart.YAxis{Name: "%", Range: &chart.ContinuousRange{Min: 0, Max: 100}, Ticks: yTicks}, Series: series}
 	themeChart(&ch)
+	ch.Title += allGroupsNote(state)
 	cw, chh := chartSize(state)
 	ch.Width, ch.Height = cw, chh
-	attachLegend(&ch)
+	attachLegend(state, &ch)
 	var buf bytes.Buffer
-	if err := ch.Render(chart.PNG, &buf); err != nil {
+	if err := renderChart(state, &ch, &buf); err != nil {
 		return blank(cw, chh)
 	}
 	img, err := png.Decode(&buf)
@@ -13032,15 +15589,446 @@ func renderChunkedTransferRateChart(state *uiState) image.Image {
 		} else {
 			series = chart.TimeSeries{Name: "Chunked", XValues: times, YValues: ys, Style: st}
 		}
-	} else {
-		if len(xs) == 1 {
-			x2 := xs[0] + 1
-			ys = append([]float64{ys[0]}, ys[0])
-			series = chart.ContinuousSeries{Name: "Chunked", XValues: []float64{xs[0], x2}, YValues: ys, Style: st}
+	} else {
+		if len(xs) == 1 {
+			x2 := xs[0] + 1
+			ys = append([]float64{ys[0]}, ys[0])
+			series = chart.ContinuousSeries{Name: "Chunked", XValues: []float64{xs[0], x2}, YValues: ys, Style: st}
+		} else {
+			series = chart.ContinuousSeries{Name: "Chunked", XValues: xs, YValues: ys, Style: st}
+		}
+	}
+	padBottom := 28
+	switch state.xAxisMode {
+	case "run_tag":
+		padBottom = 90
+	case "time":
+		padBottom = 48
+	}
+	if state.showHints {
+		padBottom += 18
+	}
+	yTicks := []chart.Tick{{Value: 0, Label: "0"}, {Value: 25, Label: "25"}, {Value: 50, Label: "50"}, {Value: 75, Label: "75"}, {Value: 100, Label: "100"}}
+	ch := chart.Chart{Title: "Chunked Transfer Rate (%)", Background: chart.Style{Padding: chart.Box{Top: 14, Left: 16, Right: 12, Bottom: padBottom}}, XAxis: xAxis, YAxis: chart.YAxis{Name: "%", Range: &chart.ContinuousRange{Min: 0, Max: 100}, Ticks: yTicks}, Series: []chart.Series{series}}
+	themeChart(&ch)
+	ch.Title += allGroupsNote(state)
+	cw, chh := chartSize(state)
+	ch.Width, ch.Height = cw, chh
+	attachLegend(state, &ch)
+	var buf bytes.Buffer
+	if err := renderChart(state, &ch, &buf); err != nil {
+		return blank(cw, chh)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		return blank(cw, chh)
+	}
+	if state.showHints {
+		img = drawHint(img, "Hint: Percentage of responses using chunked transfer encoding.")
+	}
+	return drawWatermark(img, "Situation: "+activeSituationLabel(state))
+}
+
+// renderQualityScoreChart draws the composite quality score (0–100) per batch with bands for
+// good (≥80), fair (≥50) and poor scores; batches without HTTP lines are left out.
+func renderQualityScoreChart(state *uiState) image.Image {
+	if img, ok := renderSituationOverlay(state, "quality_score"); ok {
+		return img
+	}
+	rows := filteredSummaries(state)
+	if len(rows) == 0 {
+		w, h := chartSize(state)
+		return blank(w, h)
+	}
+	timeMode, times, xs, xAxis := buildXAxis(rows, state.xAxisMode)
+	var px []float64
+	var pt []time.Time
+	var ys []float64
+	for i, r := range rows {
+		if r.QualityScore == nil {
+			continue
+		}
+		ys = append(ys, r.QualityScore.Score)
+		if timeMode {
+			pt = append(pt, times[i])
+		} else {
+			px = append(px, xs[i])
+		}
+	}
+	if len(ys) == 0 {
+		w, h := chartSize(state)
+		return drawHint(blank(w, h), "No quality score in these batches (no HTTP measurements).")
+	}
+	st := pointStyle(chart.ColorBlue)
+	var series chart.Series
+	band := func(name string, y float64, c drawing.Color) chart.Series {
+		bs := chart.Style{StrokeColor: c, StrokeWidth: 1, StrokeDashArray: []float64{4, 3}}
+		if timeMode {
+			return chart.TimeSeries{Name: name, XValues: []time.Time{pt[0], pt[len(pt)-1]}, YValues: []float64{y, y}, Style: bs}
+		}
+		return chart.ContinuousSeries{Name: name, XValues: []float64{px[0], px[len(px)-1]}, YValues: []float64{y, y}, Style: bs}
+	}
+	if timeMode {
+		if len(pt) == 1 {
+			pt, ys = append(pt, pt[0].Add(1*time.Second)), append(ys, ys[0])
+		}
+		series = chart.TimeSeries{Name: "Score", XValues: pt, YValues: ys, Style: st}
+	} else {
+		if len(px) == 1 {
+			px, ys = append(px, px[0]+1), append(ys, ys[0])
+		}
+		series = chart.ContinuousSeries{Name: "Score", XValues: px, YValues: ys, Style: st}
+	}
+	padBottom := 28
+	switch state.xAxisMode {
+	case "run_tag":
+		padBottom = 90
+	case "time":
+		padBottom = 48
+	}
+	if state.showHints {
+		padBottom += 18
+	}
+	yTicks := []chart.Tick{{Value: 0, Label: "0"}, {Value: 25, Label: "25"}, {Value: 50, Label: "50"}, {Value: 75, Label: "75"}, {Value: 100, Label: "100"}}
+	ch := chart.Chart{Title: "Quality Score", Background: chart.Style{Padding: chart.Box{Top: 14, Left: 16, Right: 12, Bottom: padBottom}}, XAxis: xAxis, YAxis: chart.YAxis{Name: "score", Range: &chart.ContinuousRange{Min: 0, Max: 100}, Ticks: yTicks}, Series: []chart.Series{band("Good (80)", qualityGoodScore, chart.ColorGreen), band("Fair (50)", qualityFairScore, chart.ColorOrange), series}}
+	themeChart(&ch)
+	ch.Title += allGroupsNote(state)
+	cw, chh := chartSize(state)
+	ch.Width, ch.Height = cw, chh
+	attachLegend(state, &ch)
+	var buf bytes.Buffer
+	if err := renderChart(state, &ch, &buf); err != nil {
+		return blank(cw, chh)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		return blank(cw, chh)
+	}
+	if state.showHints {
+		img = drawHint(img, "Hint: hover a batch for the components; the lowest one is what dragged the score down.")
+	}
+	return drawWatermark(img, "Situation: "+activeSituationLabel(state))
+}
+
+// renderIPv6ReadinessChart draws the composite IPv6 readiness score (0–100) per batch; batches
+// without any IPv6 information are left out.
+func renderIPv6ReadinessChart(state *uiState) image.Image {
+	rows := filteredSummaries(state)
+	if len(rows) == 0 {
+		w, h := chartSize(state)
+		return blank(w, h)
+	}
+	timeMode, times, xs, xAxis := buildXAxis(rows, state.xAxisMode)
+	var px []float64
+	var pt []time.Time
+	var ys []float64
+	for i, r := range rows {
+		if r.IPv6Readiness == nil {
+			continue
+		}
+		ys = append(ys, r.IPv6Readiness.Score)
+		if timeMode {
+			pt = append(pt, times[i])
+		} else {
+			px = append(px, xs[i])
+		}
+	}
+	if len(ys) == 0 {
+		w, h := chartSize(state)
+		return drawHint(blank(w, h), "No IPv6 information in these batches (no AAAA data and no IPv6 measurements).")
+	}
+	st := pointStyle(chart.ColorGreen)
+	var series chart.Series
+	if timeMode {
+		if len(pt) == 1 {
+			pt, ys = append(pt, pt[0].Add(1*time.Second)), append(ys, ys[0])
+		}
+		series = chart.TimeSeries{Name: "Readiness", XValues: pt, YValues: ys, Style: st}
+	} else {
+		if len(px) == 1 {
+			px, ys = append(px, px[0]+1), append(ys, ys[0])
+		}
+		series = chart.ContinuousSeries{Name: "Readiness", XValues: px, YValues: ys, Style: st}
+	}
+	padBottom := 28
+	switch state.xAxisMode {
+	case "run_tag":
+		padBottom = 90
+	case "time":
+		padBottom = 48
+	}
+	if state.showHints {
+		padBottom += 18
+	}
+	yTicks := []chart.Tick{{Value: 0, Label: "0"}, {Value: 25, Label: "25"}, {Value: 50, Label: "50"}, {Value: 75, Label: "75"}, {Value: 100, Label: "100"}}
+	ch := chart.Chart{Title: "IPv6 Readiness Score", Background: chart.Style{Padding: chart.Box{Top: 14, Left: 16, Right: 12, Bottom: padBottom}}, XAxis: xAxis, YAxis: chart.YAxis{Name: "score", Range: &chart.ContinuousRange{Min: 0, Max: 100}, Ticks: yTicks}, Series: []chart.Series{series}}
+	themeChart(&ch)
+	ch.Title += allGroupsNote(state)
+	cw, chh := chartSize(state)
+	ch.Width, ch.Height = cw, chh
+	attachLegend(state, &ch)
+	var buf bytes.Buffer
+	if err := renderChart(state, &ch, &buf); err != nil {
+		return blank(cw, chh)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		return blank(cw, chh)
+	}
+	if state.showHints {
+		img = drawHint(img, "Hint: 100 = IPv6 as good as IPv4; a drop with steady AAAA share points at the IPv6 path, not the targets.")
+	}
+	return drawWatermark(img, "Situation: "+activeSituationLabel(state))
+}
+
+// renderHappyEyeballsIPv6LostChart draws the share of dual-stack races where IPv6 was attempted
+// but IPv4 won (HappyEyeballsIPv6LostPct). Batches without races are omitted rather than drawn as 0.
+func renderHappyEyeballsIPv6LostChart(state *uiState) image.Image {
+	rows := filteredSummaries(state)
+	if len(rows) == 0 {
+		w, h := chartSize(state)
+		return blank(w, h)
+	}
+	timeMode, times, xs, xAxis := buildXAxis(rows, state.xAxisMode)
+	var px []float64
+	var pt []time.Time
+	var ys []float64
+	for i, r := range rows {
+		if r.HappyEyeballsRaces == 0 {
+			continue
+		}
+		ys = append(ys, r.HappyEyeballsIPv6LostPct)
+		if timeMode {
+			pt = append(pt, times[i])
+		} else {
+			px = append(px, xs[i])
+		}
+	}
+	if len(ys) == 0 {
+		w, h := chartSize(state)
+		return drawHint(blank(w, h), "No happy-eyeballs races in these batches (no dual-stack targets, or --happy-eyeballs=false).")
+	}
+	st := pointStyle(chart.ColorOrange)
+	var series chart.Series
+	if timeMode {
+		if len(pt) == 1 {
+			pt, ys = append(pt, pt[0].Add(1*time.Second)), append(ys, ys[0])
+		}
+		series = chart.TimeSeries{Name: "IPv6 lost", XValues: pt, YValues: ys, Style: st}
+	} else {
+		if len(px) == 1 {
+			px, ys = append(px, px[0]+1), append(ys, ys[0])
+		}
+		series = chart.ContinuousSeries{Name: "IPv6 lost", XValues: px, YValues: ys, Style: st}
+	}
+	padBottom := 28
+	switch state.xAxisMode {
+	case "run_tag":
+		padBottom = 90
+	case "time":
+		padBottom = 48
+	}
+	if state.showHints {
+		padBottom += 18
+	}
+	yTicks := []chart.Tick{{Value: 0, Label: "0"}, {Value: 25, Label: "25"}, {Value: 50, Label: "50"}, {Value: 75, Label: "75"}, {Value: 100, Label: "100"}}
+	ch := chart.Chart{Title: "Happy Eyeballs – IPv6 Lost Races (%)", Background: chart.Style{Padding: chart.Box{Top: 14, Left: 16, Right: 12, Bottom: padBottom}}, XAxis: xAxis, YAxis: chart.YAxis{Name: "%", Range: &chart.ContinuousRange{Min: 0, Max: 100}, Ticks: yTicks}, Series: []chart.Series{series}}
+	themeChart(&ch)
+	ch.Title += allGroupsNote(state)
+	cw, chh := chartSize(state)
+	ch.Width, ch.Height = cw, chh
+	attachLegend(state, &ch)
+	var buf bytes.Buffer
+	if err := renderChart(state, &ch, &buf); err != nil {
+		return blank(cw, chh)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		return blank(cw, chh)
+	}
+	if state.showHints {
+		img = drawHint(img, "Hint: High values mean IPv6 is slow or broken and browsers silently fall back to IPv4; explains IPv6-only deltas.")
+	}
+	return drawWatermark(img, "Situation: "+activeSituationLabel(state))
+}
+
+// renderUDPBlockedRateChart draws the share of QUIC/UDP probes that got no reply per batch
+// (overall/IPv4/IPv6). Batches without probes are gaps, so 0% means UDP worked.
+func renderUDPBlockedRateChart(state *uiState) image.Image {
+	rows := filteredSummaries(state)
+	if len(rows) == 0 {
+		w, h := chartSize(state)
+		return blank(w, h)
+	}
+	timeMode, times, xs, xAxis := buildXAxis(rows, state.xAxisMode)
+	series := []chart.Series{}
+	add := func(name string, sel func(analysis.BatchSummary) (float64, bool), color drawing.Color) {
+		ys := make([]float64, len(rows))
+		valid := 0
+		for i, r := range rows {
+			v, ok := sel(r)
+			if !ok {
+				ys[i] = math.NaN()
+				continue
+			}
+			ys[i] = v
+			valid++
+		}
+		if valid == 0 {
+			return
+		}
+		st := pointStyle(color)
+		if valid == 1 {
+			st.DotWidth = 6
+		}
+		if timeMode {
+			if len(times) == 1 {
+				series = append(series, chart.TimeSeries{Name: name, XValues: []time.Time{times[0], times[0].Add(1 * time.Second)}, YValues: []float64{ys[0], ys[0]}, Style: st})
+			} else {
+				series = append(series, chart.TimeSeries{Name: name, XValues: times, YValues: ys, Style: st})
+			}
+		} else {
+			if len(xs) == 1 {
+				series = append(series, chart.ContinuousSeries{Name: name, XValues: []float64{xs[0], xs[0] + 1}, YValues: []float64{ys[0], ys[0]}, Style: st})
+			} else {
+				series = append(series, chart.ContinuousSeries{Name: name, XValues: xs, YValues: ys, Style: st})
+			}
+		}
+	}
+	if state.showOverall {
+		add("Overall", func(b analysis.BatchSummary) (float64, bool) { return b.UDPBlockedRatePct, b.QUICProbeLines > 0 }, chart.ColorAlternateGray)
+	}
+	if state.showIPv4 {
+		add("IPv4", func(b analysis.BatchSummary) (float64, bool) {
+			if b.IPv4 == nil {
+				return 0, false
+			}
+			return b.IPv4.UDPBlockedRatePct, b.IPv4.QUICProbeLines > 0
+		}, chart.ColorBlue)
+	}
+	if state.showIPv6 {
+		add("IPv6", func(b analysis.BatchSummary) (float64, bool) {
+			if b.IPv6 == nil {
+				return 0, false
+			}
+			return b.IPv6.UDPBlockedRatePct, b.IPv6.QUICProbeLines > 0
+		}, chart.ColorGreen)
+	}
+	if len(series) == 0 {
+		w, h := chartSize(state)
+		return drawHint(blank(w, h), "No QUIC/UDP probes in these batches (run the monitor with --quic-probe).")
+	}
+	padBottom := 28
+	switch state.xAxisMode {
+	case "run_tag":
+		padBottom = 90
+	case "time":
+		padBottom = 48
+	}
+	if state.showHints {
+		padBottom += 18
+	}
+	yTicks := []chart.Tick{{Value: 0, Label: "0"}, {Value: 25, Label: "25"}, {Value: 50, Label: "50"}, {Value: 75, Label: "75"}, {Value: 100, Label: "100"}}
+	ch := chart.Chart{Title: "UDP Blocked Rate (%)", Background: chart.Style{Padding: chart.Box{Top: 14, Left: 16, Right: 12, Bottom: padBottom}}, XAxis: xAxis, YAxis: chart.YAxis{Name: "%", Range: &chart.ContinuousRange{Min: 0, Max: 100}, Ticks: yTicks}, Series: series}
+	themeChart(&ch)
+	cw, chh := chartSize(state)
+	ch.Width, ch.Height = cw, chh
+	attachLegend(state, &ch)
+	var buf bytes.Buffer
+	if err := renderChart(state, &ch, &buf); err != nil {
+		return blank(cw, chh)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		return blank(cw, chh)
+	}
+	if state.showHints {
+		img = drawHint(img, "Hint: 100% while TCP works means UDP/443 is filtered (typical for corporate firewalls); HTTP/3 then always falls back.")
+	}
+	return drawWatermark(img, "Situation: "+activeSituationLabel(state))
+}
+
+// renderColdWarmTTFBChart compares the reuse experiment's TTFB on a fresh connection with the
+// TTFB on the warm (reused) connection per batch; the dashed gray line is their difference,
+// i.e. what TCP+TLS setup costs on this path.
+func renderColdWarmTTFBChart(state *uiState) image.Image {
+	rows := filteredSummaries(state)
+	if len(rows) == 0 {
+		w, h := chartSize(state)
+		return blank(w, h)
+	}
+	timeMode, times, xs, xAxis := buildXAxis(rows, state.xAxisMode)
+	series := []chart.Series{}
+	minY, maxY := math.MaxFloat64, -math.MaxFloat64
+	add := func(name string, sel func(analysis.BatchSummary) (float64, bool), st chart.Style) {
+		ys := make([]float64, len(rows))
+		valid := 0
+		for i, r := range rows {
+			v, ok := sel(r)
+			if !ok {
+				ys[i] = math.NaN()
+				continue
+			}
+			ys[i] = v
+			valid++
+			minY, maxY = math.Min(minY, v), math.Max(maxY, v)
+		}
+		if valid == 0 {
+			return
+		}
+		if valid == 1 {
+			st.DotWidth = 6
+		}
+		if timeMode {
+			if len(times) == 1 {
+				series = append(series, chart.TimeSeries{Name: name, XValues: []time.Time{times[0], times[0].Add(1 * time.Second)}, YValues: []float64{ys[0], ys[0]}, Style: st})
+			} else {
+				series = append(series, chart.TimeSeries{Name: name, XValues: times, YValues: ys, Style: st})
+			}
 		} else {
-			series = chart.ContinuousSeries{Name: "Chunked", XValues: xs, YValues: ys, Style: st}
+			if len(xs) == 1 {
+				series = append(series, chart.ContinuousSeries{Name: name, XValues: []float64{xs[0], xs[0] + 1}, YValues: []float64{ys[0], ys[0]}, Style: st})
+			} else {
+				series = append(series, chart.ContinuousSeries{Name: name, XValues: xs, YValues: ys, Style: st})
+			}
+		}
+	}
+	dashed := func(c drawing.Color) chart.Style {
+		st := pointStyle(c)
+		st.StrokeDashArray = []float64{5, 3}
+		return st
+	}
+	if state.showOverall {
+		add("Cold", func(b analysis.BatchSummary) (float64, bool) { return b.AvgColdTTFBMs, b.ReuseExperimentLines > 0 }, pointStyle(chart.ColorRed))
+		add("Warm", func(b analysis.BatchSummary) (float64, bool) { return b.AvgWarmTTFBMs, b.ReuseExperimentLines > 0 }, pointStyle(chart.ColorAlternateGray))
+		add("Setup cost", func(b analysis.BatchSummary) (float64, bool) { return b.AvgSetupCostMs, b.ReuseExperimentLines > 0 }, dashed(chart.ColorAlternateGray))
+	}
+	fam := func(get func(analysis.BatchSummary) *analysis.FamilySummary, cold bool) func(analysis.BatchSummary) (float64, bool) {
+		return func(b analysis.BatchSummary) (float64, bool) {
+			f := get(b)
+			if f == nil || f.AvgColdTTFBMs <= 0 {
+				return 0, false
+			}
+			if cold {
+				return f.AvgColdTTFBMs, true
+			}
+			return f.AvgWarmTTFBMs, true
 		}
 	}
+	if state.showIPv4 {
+		v4 := func(b analysis.BatchSummary) *analysis.FamilySummary { return b.IPv4 }
+		add("IPv4 cold", fam(v4, true), pointStyle(chart.ColorBlue))
+		add("IPv4 warm", fam(v4, false), dashed(chart.ColorBlue))
+	}
+	if state.showIPv6 {
+		v6 := func(b analysis.BatchSummary) *analysis.FamilySummary { return b.IPv6 }
+		add("IPv6 cold", fam(v6, true), pointStyle(chart.ColorGreen))
+		add("IPv6 warm", fam(v6, false), dashed(chart.ColorGreen))
+	}
+	if len(series) == 0 {
+		w, h := chartSize(state)
+		return drawHint(blank(w, h), "No cold/warm connection data in these batches (run the monitor with --reuse-experiment).")
+	}
 	padBottom := 28
 	switch state.xAxisMode {
 	case "run_tag":
@@ -13051,14 +16039,14 @@ func renderChunkedTransferRateChart(state *uiState) image.Image {
 	if state.showHints {
 		padBottom += 18
 	}
-	yTicks := []chart.Tick{{Value: 0, Label: "0"}, {Value: 25, Label: "25"}, {Value: 50, Label: "50"}, {Value: 75, Label: "75"}, {Value: 100, Label: "100"}}
-	ch := chart.Chart{Title: "Chunked Transfer Rate (%)", Background: chart.Style{Padding: chart.Box{Top: 14, Left: 16, Right: 12, Bottom: padBottom}}, XAxis: xAxis, YAxis: chart.YAxis{Name: "%", Range: &chart.ContinuousRange{Min: 0, Max: 100}, Ticks: yTicks}, Series: []chart.Series{series}}
+	yAxisRange, yTicks := computeYAxisRangeSigned(minY, maxY, state.useRelative)
+	ch := chart.Chart{Title: "Cold vs Warm Connection TTFB (ms)", Background: chart.Style{Padding: chart.Box{Top: 14, Left: 16, Right: 12, Bottom: padBottom}}, XAxis: xAxis, YAxis: chart.YAxis{Name: "ms", Range: yAxisRange, Ticks: yTicks}, Series: series}
 	themeChart(&ch)
 	cw, chh := chartSize(state)
 	ch.Width, ch.Height = cw, chh
-	attachLegend(&ch)
+	attachLegend(state, &ch)
 	var buf bytes.Buffer
-	if err := ch.Render(chart.PNG, &buf); err != nil {
+	if err := renderChart(state, &ch, &buf); err != nil {
 		return blank(cw, chh)
 	}
 	img, err := png.Decode(&buf)
@@ -13066,7 +16054,7 @@ func renderChunkedTransferRateChart(state *uiState) image.Image {
 		return blank(cw, chh)
 	}
 	if state.showHints {
-		img = drawHint(img, "Hint: Percentage of responses using chunked transfer encoding.")
+		img = drawHint(img, "Hint: a large cold–warm gap means handshakes dominate short requests; keep-alive/HTTP/2 reuse pays off most there.")
 	}
 	return drawWatermark(img, "Situation: "+activeSituationLabel(state))
 }
@@ -13180,17 +16168,17 @@ func renderCoVChart(state *uiState) image.Image {
 	themeChart(&ch)
 	cw, chh := chartSize(state)
 	ch.Width, ch.Height = cw, chh
-	attachLegend(&ch)
+	attachLegend(state, &ch)
 	var buf bytes.Buffer
-	if err := ch.Render(chart.PNG, &buf); err != nil {
+	if err := renderChart(state, &ch, &buf); err != nil {
 		cw, chh := chartSize(state)
-		fmt.Printf("[viewer] cov chart render error: %v; showing blank fallback\n", err)
+		logf(slog.LevelWarn, "viewer", "cov chart render error: %v; showing blank fallback", err)
 		return blank(cw, chh)
 	}
 	img, err := png.Decode(&buf)
 	if err != nil {
 		cw, chh := chartSize(state)
-		fmt.Printf("[viewer] cov chart decode error: %v; showing blank fallback\n", err)
+		logf(slog.LevelWarn, "viewer", "cov chart decode error: %v; showing blank fallback", err)
 		return blank(cw, chh)
 	}
 	if state.showHints {
@@ -13283,17 +16271,17 @@ func renderPlateauCountChart(state *uiState) image.Image {
 	themeChart(&ch)
 	cw, chh := chartSize(state)
 	ch.Width, ch.Height = cw, chh
-	attachLegend(&ch)
+	attachLegend(state, &ch)
 	var buf bytes.Buffer
-	if err := ch.Render(chart.PNG, &buf); err != nil {
+	if err := renderChart(state, &ch, &buf); err != nil {
 		cw, chh := chartSize(state)
-		fmt.Printf("[viewer] plateau-count render error: %v; blank fallback\n", err)
+		logf(slog.LevelWarn, "viewer", "plateau-count render error: %v; blank fallback", err)
 		return blank(cw, chh)
 	}
 	img, err := png.Decode(&buf)
 	if err != nil {
 		cw, chh := chartSize(state)
-		fmt.Printf("[viewer] plateau-count decode error: %v; blank fallback\n", err)
+		logf(slog.LevelWarn, "viewer", "plateau-count decode error: %v; blank fallback", err)
 		return blank(cw, chh)
 	}
 	if state.showHints {
@@ -13386,17 +16374,17 @@ func renderPlateauLongestChart(state *uiState) image.Image {
 	themeChart(&ch)
 	cw, chh := chartSize(state)
 	ch.Width, ch.Height = cw, chh
-	attachLegend(&ch)
+	attachLegend(state, &ch)
 	var buf bytes.Buffer
-	if err := ch.Render(chart.PNG, &buf); err != nil {
+	if err := renderChart(state, &ch, &buf); err != nil {
 		cw, chh := chartSize(state)
-		fmt.Printf("[viewer] plateau-longest render error: %v; blank fallback\n", err)
+		logf(slog.LevelWarn, "viewer", "plateau-longest render error: %v; blank fallback", err)
 		return blank(cw, chh)
 	}
 	img, err := png.Decode(&buf)
 	if err != nil {
 		cw, chh := chartSize(state)
-		fmt.Printf("[viewer] plateau-longest decode error: %v; blank fallback\n", err)
+		logf(slog.LevelWarn, "viewer", "plateau-longest decode error: %v; blank fallback", err)
 		return blank(cw, chh)
 	}
 	if state.showHints {
@@ -13489,17 +16477,17 @@ func renderPlateauStableChart(state *uiState) image.Image {
 	themeChart(&ch)
 	cw, chh := chartSize(state)
 	ch.Width, ch.Height = cw, chh
-	attachLegend(&ch)
+	attachLegend(state, &ch)
 	var buf bytes.Buffer
-	if err := ch.Render(chart.PNG, &buf); err != nil {
+	if err := renderChart(state, &ch, &buf); err != nil {
 		cw, chh := chartSize(state)
-		fmt.Printf("[viewer] plateau-stable render error: %v; blank fallback\n", err)
+		logf(slog.LevelWarn, "viewer", "plateau-stable render error: %v; blank fallback", err)
 		return blank(cw, chh)
 	}
 	img, err := png.Decode(&buf)
 	if err != nil {
 		cw, chh := chartSize(state)
-		fmt.Printf("[viewer] plateau-stable decode error: %v; blank fallback\n", err)
+		logf(slog.LevelWarn, "viewer", "plateau-stable decode error: %v; blank fallback", err)
 		return blank(cw, chh)
 	}
 	if state.showHints {
@@ -13592,9 +16580,9 @@ func renderTailHeavinessChart(state *uiState) image.Image {
 	themeChart(&ch)
 	cw, chh := chartSize(state)
 	ch.Width, ch.Height = cw, chh
-	attachLegend(&ch)
+	attachLegend(state, &ch)
 	var buf bytes.Buffer
-	if err := ch.Render(chart.PNG, &buf); err != nil {
+	if err := renderChart(state, &ch, &buf); err != nil {
 		return blank(cw, chh)
 	}
 	img, err := png.Decode(&buf)
@@ -13609,7 +16597,7 @@ func renderTailHeavinessChart(state *uiState) image.Image {
 
 // renderFamilyDeltaSpeedChart plots IPv6−IPv4 AvgSpeed delta in selected unit.
 func renderFamilyDeltaSpeedChart(state *uiState) image.Image {
-	unitName, factor := speedUnitNameAndFactor(state.speedUnit)
+	unitName, factor := speedUnitFor(state)
 	rows := filteredSummaries(state)
 	if len(rows) == 0 {
 		cw, chh := chartSize(state)
@@ -13633,6 +16621,7 @@ func renderFamilyDeltaSpeedChart(state *uiState) image.Image {
 		}
 	}
 	st := pointStyle(chart.ColorRed)
+	significanceDots(&st, chart.ColorRed, rows, timeMode, times, xs, familySpeedTest)
 	var series chart.Series
 	if timeMode {
 		if len(times) == 1 {
@@ -13663,13 +16652,16 @@ func renderFamilyDeltaSpeedChart(state *uiState) image.Image {
 	if state.showHints {
 		padBottom += 18
 	}
-	ch := chart.Chart{Title: fmt.Sprintf("Family Delta – Speed (IPv6−IPv4) (%s)", unitName), Background: chart.Style{Padding: chart.Box{Top: 14, Left: 16, Right: 12, Bottom: padBottom}}, XAxis: xAxis, YAxis: chart.YAxis{Name: unitName, Range: yAxisRange, Ticks: yTicks}, Series: []chart.Series{series}}
+	ch := chart.Chart{Title: fmt.Sprintf(tr("Family Delta – Speed (IPv6−IPv4) (%s)"), unitName), Background: chart.Style{Padding: chart.Box{Top: 14, Left: 16, Right: 12, Bottom: padBottom}}, XAxis: xAxis, YAxis: chart.YAxis{Name: unitName, Range: yAxisRange, Ticks: yTicks}, Series: []chart.Series{series}}
+	if l := significanceLegend(rows, familySpeedTest); l != nil {
+		ch.Series = append(ch.Series, l)
+	}
 	themeChart(&ch)
 	cw, chh := chartSize(state)
 	ch.Width, ch.Height = cw, chh
-	attachLegend(&ch)
+	attachLegend(state, &ch)
 	var buf bytes.Buffer
-	if err := ch.Render(chart.PNG, &buf); err != nil {
+	if err := renderChart(state, &ch, &buf); err != nil {
 		return blank(cw, chh)
 	}
 	img, err := png.Decode(&buf)
@@ -13707,6 +16699,7 @@ func renderFamilyDeltaTTFBChart(state *uiState) image.Image {
 		}
 	}
 	st := pointStyle(chart.ColorBlue)
+	significanceDots(&st, chart.ColorBlue, rows, timeMode, times, xs, familyTTFBTest)
 	var series chart.Series
 	if timeMode {
 		if len(times) == 1 {
@@ -13738,12 +16731,15 @@ func renderFamilyDeltaTTFBChart(state *uiState) image.Image {
 		padBottom += 18
 	}
 	ch := chart.Chart{Title: "Family Delta – TTFB (IPv4−IPv6) (ms)", Background: chart.Style{Padding: chart.Box{Top: 14, Left: 16, Right: 12, Bottom: padBottom}}, XAxis: xAxis, YAxis: chart.YAxis{Name: "ms", Range: yAxisRange, Ticks: yTicks}, Series: []chart.Series{series}}
+	if l := significanceLegend(rows, familyTTFBTest); l != nil {
+		ch.Series = append(ch.Series, l)
+	}
 	themeChart(&ch)
 	cw, chh := chartSize(state)
 	ch.Width, ch.Height = cw, chh
-	attachLegend(&ch)
+	attachLegend(state, &ch)
 	var buf bytes.Buffer
-	if err := ch.Render(chart.PNG, &buf); err != nil {
+	if err := renderChart(state, &ch, &buf); err != nil {
 		return blank(cw, chh)
 	}
 	img, err := png.Decode(&buf)
@@ -13781,6 +16777,7 @@ func renderFamilyDeltaSpeedPctChart(state *uiState) image.Image {
 		}
 	}
 	st := pointStyle(chart.ColorRed)
+	significanceDots(&st, chart.ColorRed, rows, timeMode, times, xs, familySpeedTest)
 	var series chart.Series
 	if timeMode {
 		if len(times) == 1 {
@@ -13812,12 +16809,15 @@ func renderFamilyDeltaSpeedPctChart(state *uiState) image.Image {
 		padBottom += 18
 	}
 	ch := chart.Chart{Title: "Family Delta – Speed % (IPv6 vs IPv4)", Background: chart.Style{Padding: chart.Box{Top: 14, Left: 16, Right: 12, Bottom: padBottom}}, XAxis: xAxis, YAxis: chart.YAxis{Name: "%", Range: yAxisRange, Ticks: yTicks}, Series: []chart.Series{series}}
+	if l := significanceLegend(rows, familySpeedTest); l != nil {
+		ch.Series = append(ch.Series, l)
+	}
 	themeChart(&ch)
 	cw, chh := chartSize(state)
 	ch.Width, ch.Height = cw, chh
-	attachLegend(&ch)
+	attachLegend(state, &ch)
 	var buf bytes.Buffer
-	if err := ch.Render(chart.PNG, &buf); err != nil {
+	if err := renderChart(state, &ch, &buf); err != nil {
 		return blank(cw, chh)
 	}
 	img, err := png.Decode(&buf)
@@ -13855,6 +16855,7 @@ func renderFamilyDeltaTTFBPctChart(state *uiState) image.Image {
 		}
 	}
 	st := pointStyle(chart.ColorBlue)
+	significanceDots(&st, chart.ColorBlue, rows, timeMode, times, xs, familyTTFBTest)
 	var series chart.Series
 	if timeMode {
 		if len(times) == 1 {
@@ -13886,12 +16887,15 @@ func renderFamilyDeltaTTFBPctChart(state *uiState) image.Image {
 		padBottom += 18
 	}
 	ch := chart.Chart{Title: "Family Delta – TTFB % (IPv6 vs IPv4)", Background: chart.Style{Padding: chart.Box{Top: 14, Left: 16, Right: 12, Bottom: padBottom}}, XAxis: xAxis, YAxis: chart.YAxis{Name: "%", Range: yAxisRange, Ticks: yTicks}, Series: []chart.Series{series}}
+	if l := significanceLegend(rows, familyTTFBTest); l != nil {
+		ch.Series = append(ch.Series, l)
+	}
 	themeChart(&ch)
 	cw, chh := chartSize(state)
 	ch.Width, ch.Height = cw, chh
-	attachLegend(&ch)
+	attachLegend(state, &ch)
 	var buf bytes.Buffer
-	if err := ch.Render(chart.PNG, &buf); err != nil {
+	if err := renderChart(state, &ch, &buf); err != nil {
 		return blank(cw, chh)
 	}
 	img, err := png.Decode(&buf)
@@ -13964,9 +16968,9 @@ func renderSLASpeedDeltaChart(state *uiState) image.Image {
 	themeChart(&ch)
 	cw, chh := chartSize(state)
 	ch.Width, ch.Height = cw, chh
-	attachLegend(&ch)
+	attachLegend(state, &ch)
 	var buf bytes.Buffer
-	if err := ch.Render(chart.PNG, &buf); err != nil {
+	if err := renderChart(state, &ch, &buf); err != nil {
 		return blank(cw, chh)
 	}
 	img, err := png.Decode(&buf)
@@ -14039,9 +17043,9 @@ func renderSLATTFBDeltaChart(state *uiState) image.Image {
 	themeChart(&ch)
 	cw, chh := chartSize(state)
 	ch.Width, ch.Height = cw, chh
-	attachLegend(&ch)
+	attachLegend(state, &ch)
 	var buf bytes.Buffer
-	if err := ch.Render(chart.PNG, &buf); err != nil {
+	if err := renderChart(state, &ch, &buf); err != nil {
 		return blank(cw, chh)
 	}
 	img, err := png.Decode(&buf)
@@ -14095,7 +17099,7 @@ func estimateCompliance(percentiles map[int]float64, threshold float64, greaterB
 
 // renderSLASpeedChart renders estimated compliance % for speed threshold using percentiles (Overall/IPv4/IPv6).
 func renderSLASpeedChart(state *uiState) image.Image {
-	unitName, factor := speedUnitNameAndFactor(state.speedUnit)
+	unitName, factor := speedUnitFor(state)
 	rows := filteredSummaries(state)
 	if len(rows) == 0 {
 		cw, chh := chartSize(state)
@@ -14175,13 +17179,13 @@ func renderSLASpeedChart(state *uiState) image.Image {
 	if state.showHints {
 		padBottom += 18
 	}
-	ch := chart.Chart{Title: fmt.Sprintf("SLA Compliance – Speed (≥ %.1f %s P50 est)", float64(state.slaSpeedThresholdKbps)*factor, unitName), Background: chart.Style{Padding: chart.Box{Top: 14, Left: 16, Right: 12, Bottom: padBottom}}, XAxis: xAxis, YAxis: chart.YAxis{Name: "%", Range: yAxisRange, Ticks: yTicks}, Series: series}
+	ch := chart.Chart{Title: fmt.Sprintf(tr("SLA Compliance – Speed (≥ %.1f %s P50 est)"), float64(state.slaSpeedThresholdKbps)*factor, unitName), Background: chart.Style{Padding: chart.Box{Top: 14, Left: 16, Right: 12, Bottom: padBottom}}, XAxis: xAxis, YAxis: chart.YAxis{Name: "%", Range: yAxisRange, Ticks: yTicks}, Series: series}
 	themeChart(&ch)
 	cw, chh := chartSize(state)
 	ch.Width, ch.Height = cw, chh
-	attachLegend(&ch)
+	attachLegend(state, &ch)
 	var buf bytes.Buffer
-	if err := ch.Render(chart.PNG, &buf); err != nil {
+	if err := renderChart(state, &ch, &buf); err != nil {
 		return blank(cw, chh)
 	}
 	img, err := png.Decode(&buf)
@@ -14266,13 +17270,13 @@ func renderSLATTFBChart(state *uiState) image.Image {
 	if state.showHints {
 		padBottom += 18
 	}
-	ch := chart.Chart{Title: fmt.Sprintf("SLA Compliance – TTFB (≤ %d ms P95 est)", state.slaTTFBThresholdMs), Background: chart.Style{Padding: chart.Box{Top: 14, Left: 16, Right: 12, Bottom: padBottom}}, XAxis: xAxis, YAxis: chart.YAxis{Name: "%", Range: yAxisRange, Ticks: yTicks}, Series: series}
+	ch := chart.Chart{Title: fmt.Sprintf(tr("SLA Compliance – TTFB (≤ %d ms P95 est)"), state.slaTTFBThresholdMs), Background: chart.Style{Padding: chart.Box{Top: 14, Left: 16, Right: 12, Bottom: padBottom}}, XAxis: xAxis, YAxis: chart.YAxis{Name: "%", Range: yAxisRange, Ticks: yTicks}, Series: series}
 	themeChart(&ch)
 	cw, chh := chartSize(state)
 	ch.Width, ch.Height = cw, chh
-	attachLegend(&ch)
+	attachLegend(state, &ch)
 	var buf bytes.Buffer
-	if err := ch.Render(chart.PNG, &buf); err != nil {
+	if err := renderChart(state, &ch, &buf); err != nil {
 		return blank(cw, chh)
 	}
 	img, err := png.Decode(&buf)
@@ -14309,7 +17313,7 @@ func buildXAxis(rows []analysis.BatchSummary, mode string) (bool, []time.Time, [
 		}
 		// Build nice rounded ticks across the time span
 		if len(ts) == 0 {
-			return true, ts, nil, chart.XAxis{Name: "Time"}
+			return true, ts, nil, chart.XAxis{Name: timeAxisName()}
 		}
 		minT := ts[0]
 		maxT := ts[0]
@@ -14325,7 +17329,7 @@ func buildXAxis(rows []analysis.BatchSummary, mode string) (bool, []time.Time, [
 		ticks := makeNiceTimeTicks(minT, maxT, step, labFmt)
 		if len(ts) == 1 && len(ticks) < 2 {
 			// add a second tick one step later to keep axis happy
-			ticks = append(ticks, chart.Tick{Value: float64(chart.TimeToFloat64(minT.Add(step))), Label: minT.Add(step).Local().Format(labFmt)})
+			ticks = append(ticks, chart.Tick{Value: float64(chart.TimeToFloat64(minT.Add(step))), Label: minT.Add(step).In(timeAxisLoc).Format(labFmt)})
 		}
 		// Ensure non-zero X range even when there's only one timestamp
 		minF := float64(chart.TimeToFloat64(minT))
@@ -14336,9 +17340,9 @@ func buildXAxis(rows []analysis.BatchSummary, mode string) (bool, []time.Time, [
 				maxF = minF + 1
 			}
 		}
-		xa := chart.XAxis{Name: "Time", Ticks: ticks, Range: &chart.ContinuousRange{Min: minF, Max: maxF}}
+		xa := chart.XAxis{Name: timeAxisName(), Ticks: ticks, Range: &chart.ContinuousRange{Min: minF, Max: maxF}}
 		if len(ts) == 1 {
-			fmt.Printf("[viewer] time axis padded: min=%v max=%v ticks=%d\n", minT, maxT, len(ticks))
+			logf(slog.LevelDebug, "viewer", "time axis padded: min=%v max=%v ticks=%d", minT, maxT, len(ticks))
 		}
 		return true, ts, nil, xa
 	case "run_tag":
@@ -14429,13 +17433,32 @@ func parseRunTagTime(runTag string) time.Time {
 	parts := strings.Split(runTag, "_")
 	if len(parts) >= 2 && len(parts[0]) == 8 && len(parts[1]) >= 6 {
 		base := parts[0] + "_" + parts[1][:6]
-		if t, err := time.ParseInLocation("20060102_150405", base, time.Local); err == nil {
-			return t
+		// the monitor writes run tags in UTC
+		if t, err := time.ParseInLocation("20060102_150405", base, time.UTC); err == nil {
+			return t.In(timeAxisLoc)
 		}
 	}
 	return time.Time{}
 }
 
+// setTimeAxisUTC switches the display zone (timeAxisLoc) between UTC and local time.
+func setTimeAxisUTC(state *uiState, utc bool) {
+	state.timeAxisUTC = utc
+	if utc {
+		timeAxisLoc = time.UTC
+	} else {
+		timeAxisLoc = time.Local
+	}
+}
+
+// timeAxisName labels the Time X-axis with the zone its ticks are in.
+func timeAxisName() string {
+	if timeAxisLoc == time.UTC {
+		return "Time (UTC)"
+	}
+	return "Time (local)"
+}
+
 // (legacy niceAxisBounds removed—range now derived from BuildNumericTicks output)
 
 // (legacy niceTicks/formatTick implementation removed after migration to uihelpers.BuildNumericTicks & uihelpers.FormatNumericTick)
@@ -14661,10 +17684,10 @@ func labelForIndex(rows []analysis.BatchSummary, xAxisMode string, idx int) stri
 		rt := rows[idx].RunTag
 		// Accept both YYYYmmdd_HHMMSS and RFC3339
 		if t, err := time.Parse("20060102_150405", rt); err == nil {
-			return t.Format("01-02 15:04:05")
+			return t.In(timeAxisLoc).Format("01-02 15:04:05")
 		}
 		if t, err := time.Parse(time.RFC3339, rt); err == nil {
-			return t.Format("01-02 15:04:05")
+			return t.In(timeAxisLoc).Format("01-02 15:04:05")
 		}
 		return rt
 	default:
@@ -14999,9 +18022,9 @@ func noteUnknownHidden(state *uiState, base string) string {
 // titleUnknownHidden appends a short suffix to chart titles when '(unknown)' protocols are hidden.
 func titleUnknownHidden(state *uiState, base string) string {
 	if state != nil && state.hideUnknownProtocols {
-		return base + " — (unknown hidden)"
+		return tr(base) + " — " + tr("(unknown hidden)")
 	}
-	return base
+	return tr(base)
 }
 
 // scheduleDetailedRebuild debounces detailed chart rebuild requests.
@@ -15095,7 +18118,7 @@ func rebuildDetailedCharts(state *uiState) {
 		}
 	}()
 	// Quick visibility guard
-	if !(state.showDetailedPercentiles || state.showDetailedSpeedOverTime || state.showDetailedBytesOverTime || state.showDetailedTopSessionsSpeed || state.showDetailedTopSessionsBytes || state.showDetailedErrorsByURL || state.showDetailedHostIPTiming) {
+	if !(state.showDetailedPercentiles || state.showDetailedDistribution || state.showDetailedSpeedOverTime || state.showDetailedBytesOverTime || state.showDetailedTopSessionsSpeed || state.showDetailedTopSessionsBytes || state.showDetailedErrorsByURL || state.showDetailedHostIPTiming) {
 		state.detailedChartsBox.Objects = nil
 		state.detailedChartsBox.Add(widget.NewLabel("All detailed chart toggles are off. Enable one or more checkboxes above to view charts."))
 		state.detailedChartsBox.Refresh()
@@ -15150,7 +18173,7 @@ func rebuildDetailedCharts(state *uiState) {
 		}
 		tags = valid
 	}
-	fmt.Printf("[detailed] rebuild start #%d @%s: tags=%v toggles={pctl:%v speed:%v bytes:%v topSpeed:%v topBytes:%v errs:%v hostIP:%v} hostFilter=%q groupErrs=%v\n",
+	logf(slog.LevelDebug, "detailed", "rebuild start #%d @%s: tags=%v toggles={pctl:%v speed:%v bytes:%v topSpeed:%v topBytes:%v errs:%v hostIP:%v} hostFilter=%q groupErrs=%v",
 		state.detailedRebuildCount+1, time.Now().Format("15:04:05.000"), tags, state.showDetailedPercentiles, state.showDetailedSpeedOverTime, state.showDetailedBytesOverTime, state.showDetailedTopSessionsSpeed, state.showDetailedTopSessionsBytes, state.showDetailedErrorsByURL, state.showDetailedHostIPTiming, state.detailedHostFilter, state.detailedErrorsGroupByHost)
 	state.detailedChartsBox.Objects = nil
 	if len(tags) == 0 || len(rows) == 0 {
@@ -15226,6 +18249,7 @@ func rebuildDetailedCharts(state *uiState) {
 		helpBytesOverTime := "Bytes over Time (cumulative)\n\nShows cumulative bytes transferred for each HTTP session over time. Slopes reflect throughput; plateaus indicate idle or stalled intervals.\n\nMarkers & Overlays (when enabled):\n• Red vertical line = session TTFB (toggle in Settings).\n• Orange band = detected stall period near tail.\n\nUse to compare ramp-up behavior, early slow starts (e.g., TLS warm-up), or mid-transfer stalls.\n\n" + refTTFB
 		helpTopSessionsSpeed := "Top Sessions (Speed)\n\nSmall multiples focusing on the top sessions by transfer size or ranking metric (speed view). Each panel is a miniature \"Speed over Time\" with identical interpretations: red TTFB line (toggleable), orange stall band, sampled dots.\n\nCompare protocol (ALPN) differences, host/path impact, and initial latency patterns side-by-side.\n\n" + refTTFB + "\n" + refHTTP
 		helpTopSessionsBytes := "Top Sessions (Bytes)\n\nSmall multiples of cumulative bytes per top session. Useful for spotting which flows dominate bandwidth and whether any suffer from late stalls (orange bands) or delayed starts (late TTFB lines).\n\n" + refTTFB
+		helpDistribution := "Speed Distribution (per batch)\n\nHistogram of every intra-transfer speed sample in this batch, bucketed on a log speed axis (10 buckets per decade), with the cumulative share on the right axis. The batch's exponential histogram is stored by the analysis (speed_histogram); older results fall back to the speed sketch.\n\nReading Tips:\n• One hump = a single steady path.\n• Two humps (dashed mode lines) = bimodal: transfers alternated between a fast and a slow path (e.g. Wi-Fi roaming, CDN edges, a throttled flow); the average then sits between them and describes neither.\n• Samples at 0 are stalled intervals and are reported in the title.\n\n" + refPercentiles
 		helpErrors := "Errors by URL (Top 12)\n\nBar chart of error occurrence counts for this batch, optionally grouped by host. Helps identify failing endpoints or disproportionate error contributors.\n\nUsage:\n• Apply Host filter or group by host for aggregation.\n• Investigate spikes by correlating with latency or stall charts.\n\nReferences:\n" + refHTTP
 		// Percentiles
 		if state.showDetailedPercentiles {
@@ -15247,6 +18271,26 @@ func rebuildDetailedCharts(state *uiState) {
 			}
 		}
 
+		// Speed distribution
+		if state.showDetailedDistribution {
+			if img := renderSpeedDistributionDetailedChart(state); img != nil {
+				img = drawNoteTopLeft(img, "Batch: "+tag)
+				canv := canvas.NewImageFromImage(img)
+				canv.FillMode = canvas.ImageFillContain
+				canv.SetMinSize(fyne.NewSize(float32(canv.Image.Bounds().Dx()), float32(canv.Image.Bounds().Dy())))
+				header := makeDetailHeader("Speed Distribution", helpDistribution)
+				legend := []string{"Legend:", "Blue: share of samples", "Orange: cumulative %", "Dashed: modes"}
+				wrapped := wrapDetailed(canv, "detailed_distribution", legend, &state.detailedDistributionOverlay)
+				state.detailedChartsBox.Add(container.NewVBox(header, wrapped))
+				if len(tags) == 1 {
+					state.detailedDistImgCanvas = canv
+				}
+			} else {
+				header := makeDetailHeader("Speed Distribution", helpDistribution)
+				state.detailedChartsBox.Add(container.NewVBox(header, widget.NewLabel("No speed samples for this batch.")))
+			}
+		}
+
 		// Speed over time
 		if state.showDetailedSpeedOverTime {
 			if img := renderSpeedOverTimeDetailedChart(state); img != nil {
@@ -15434,10 +18478,27 @@ func legendUnknownHiddenSeries(state *uiState) chart.Series {
 
 // activeSituationLabel returns the visible label for the current situation (or "All").
 func activeSituationLabel(state *uiState) string {
-	if state == nil || strings.TrimSpace(state.situation) == "" || strings.EqualFold(state.situation, "All") {
-		return "All"
+	label := "All"
+	if state == nil {
+		return label
+	}
+	if !(strings.TrimSpace(state.situation) == "" || strings.EqualFold(state.situation, "All")) {
+		label = state.situation
+	}
+	// VPN split is part of the context a screenshot was taken in
+	if f := state.vpnFilter; f != "" && f != "All" {
+		label += " · " + f
+	}
+	if f := state.bindFilter; f != "" && f != "All" {
+		label += " · " + f
 	}
-	return state.situation
+	if f := state.tagFilter; f != "" && f != "All" {
+		label += " · " + f
+	}
+	if g := state.groupFilter; g != "" && g != "All" {
+		label += " · group " + g
+	}
+	return label
 }
 
 // (titles intentionally do not include the situation; see watermark for context)
@@ -15447,7 +18508,7 @@ func activeSituationLabel(state *uiState) string {
 
 // renderPercentilesChartWithFamily draws a compact percentiles chart for the given family (overall/ipv4/ipv6).
 func renderPercentilesChartWithFamily(state *uiState, fam string) image.Image {
-	unitName, factor := speedUnitNameAndFactor(state.speedUnit)
+	unitName, factor := speedUnitFor(state)
 	rows := filteredSummaries(state)
 	if len(rows) == 0 {
 		w, h := chartSize(state)
@@ -15458,7 +18519,7 @@ func renderPercentilesChartWithFamily(state *uiState, fam string) image.Image {
 	minY := math.MaxFloat64
 	maxY := -math.MaxFloat64
 
-	add := func(name string, sel func(analysis.BatchSummary) float64, color drawing.Color) {
+	add := func(name string, sel func(analysis.BatchSummary) float64, color drawing.Color, dotWidth float64) {
 		ys := make([]float64, len(rows))
 		valid := 0
 		for i, r := range rows {
@@ -15477,6 +18538,7 @@ func renderPercentilesChartWithFamily(state *uiState, fam string) image.Image {
 			valid++
 		}
 		st := pointStyle(color)
+		st.DotWidth = dotWidth
 		if valid == 1 {
 			st.DotWidth = 6
 		}
@@ -15500,62 +18562,20 @@ func renderPercentilesChartWithFamily(state *uiState, fam string) image.Image {
 	}
 
 	fam = strings.ToLower(strings.TrimSpace(fam))
-	switch fam {
-	case "ipv4":
-		add("P50", func(b analysis.BatchSummary) float64 {
-			if b.IPv4 == nil {
-				return 0
-			}
-			return b.IPv4.AvgP50Speed
-		}, chart.ColorBlue)
-		add("P90", func(b analysis.BatchSummary) float64 {
-			if b.IPv4 == nil {
-				return 0
-			}
-			return b.IPv4.AvgP90Speed
-		}, chart.ColorGreen)
-		add("P95", func(b analysis.BatchSummary) float64 {
-			if b.IPv4 == nil {
-				return 0
-			}
-			return b.IPv4.AvgP95Speed
-		}, chart.ColorAlternateGray)
-		add("P99", func(b analysis.BatchSummary) float64 {
-			if b.IPv4 == nil {
-				return 0
-			}
-			return b.IPv4.AvgP99Speed
-		}, chart.ColorRed)
-	case "ipv6":
-		add("P50", func(b analysis.BatchSummary) float64 {
-			if b.IPv6 == nil {
-				return 0
-			}
-			return b.IPv6.AvgP50Speed
-		}, chart.ColorBlue)
-		add("P90", func(b analysis.BatchSummary) float64 {
-			if b.IPv6 == nil {
-				return 0
-			}
-			return b.IPv6.AvgP90Speed
-		}, chart.ColorGreen)
-		add("P95", func(b analysis.BatchSummary) float64 {
-			if b.IPv6 == nil {
-				return 0
-			}
-			return b.IPv6.AvgP95Speed
-		}, chart.ColorAlternateGray)
-		add("P99", func(b analysis.BatchSummary) float64 {
-			if b.IPv6 == nil {
-				return 0
-			}
-			return b.IPv6.AvgP99Speed
-		}, chart.ColorRed)
-	default:
-		add("P50", func(b analysis.BatchSummary) float64 { return b.AvgP50Speed }, colorForSeries("P50"))
-		add("P90", func(b analysis.BatchSummary) float64 { return b.AvgP90Speed }, colorForSeries("P90"))
-		add("P95", func(b analysis.BatchSummary) float64 { return b.AvgP95Speed }, colorForSeries("P95"))
-		add("P99", func(b analysis.BatchSummary) float64 { return b.AvgP99Speed }, colorForSeries("P99"))
+	method := normalizeSpeedPercentileMethod(state.speedPercentileMethod)
+	for _, p := range []int{50, 90, 95, 99} {
+		p := p
+		name := fmt.Sprintf("P%d", p)
+		col := colorForSeries(name)
+		switch method {
+		case "per_line":
+			add(name, func(b analysis.BatchSummary) float64 { return speedPercentileValue(b, fam, p, false) }, col, 4)
+		case "compare":
+			add(name+" pooled", func(b analysis.BatchSummary) float64 { return speedPercentileValue(b, fam, p, true) }, col, 4)
+			add(name+" per-line avg", func(b analysis.BatchSummary) float64 { return speedPercentileValue(b, fam, p, false) }, col.WithAlpha(110), 2.5)
+		default:
+			add(name, func(b analysis.BatchSummary) float64 { return speedPercentileValue(b, fam, p, true) }, col, 4)
+		}
 	}
 
 	var yAxisRange chart.Range
@@ -15606,7 +18626,7 @@ func renderPercentilesChartWithFamily(state *uiState, fam string) image.Image {
 		titlePrefix = "Overall "
 	}
 	ch := chart.Chart{
-		Title:      fmt.Sprintf("%sSpeed Percentiles (%s)", titlePrefix, unitName),
+		Title:      fmt.Sprintf(tr("%sSpeed Percentiles (%s)"), titlePrefix, unitName),
 		Background: chart.Style{Padding: chart.Box{Top: 14, Left: 16, Right: 12, Bottom: padBottom}},
 		XAxis:      xAxis,
 		YAxis:      chart.YAxis{Name: unitName, Range: yAxisRange, Ticks: yTicks},
@@ -15617,15 +18637,15 @@ func renderPercentilesChartWithFamily(state *uiState, fam string) image.Image {
 	cw, chh := chartSize(state)
 	ch.Width = cw
 	ch.Height = chh
-	ch.Elements = []chart.Renderable{chart.Legend(&ch)}
+	ch.Elements = []chart.Renderable{chartLegend(state, &ch)}
 	var buf bytes.Buffer
-	if err := ch.Render(chart.PNG, &buf); err != nil {
-		fmt.Printf("[viewer] percentiles(compare) render error: %v; blank fallback\n", err)
+	if err := renderChart(state, &ch, &buf); err != nil {
+		logf(slog.LevelWarn, "viewer", "percentiles(compare) render error: %v; blank fallback", err)
 		return blank(cw, chh)
 	}
 	img, err := png.Decode(&buf)
 	if err != nil {
-		fmt.Printf("[viewer] percentiles(compare) decode error: %v; blank fallback\n", err)
+		logf(slog.LevelWarn, "viewer", "percentiles(compare) decode error: %v; blank fallback", err)
 		return blank(cw, chh)
 	}
 	if state.showHints {
@@ -15634,6 +18654,64 @@ func renderPercentilesChartWithFamily(state *uiState, fam string) image.Image {
 	return drawWatermark(img, "Situation: "+activeSituationLabel(state))
 }
 
+// normalizeSpeedPercentileMethod maps the stored preference to "pooled" (default), "per_line" or "compare".
+func normalizeSpeedPercentileMethod(m string) string {
+	switch m = strings.ToLower(strings.TrimSpace(m)); m {
+	case "per_line", "compare":
+		return m
+	default:
+		return "pooled"
+	}
+}
+
+// speedPercentileValue returns the Speed percentile p (50/90/95/99) of a batch for fam ("" overall,
+// "ipv4", "ipv6"). pooled selects the percentile over all transfer samples of the batch and falls
+// back to the average of per-line percentiles for batches recorded without samples.
+func speedPercentileValue(b analysis.BatchSummary, fam string, p int, pooled bool) float64 {
+	var perLine, pool [4]float64
+	switch fam {
+	case "ipv4", "ipv6":
+		f := b.IPv4
+		if fam == "ipv6" {
+			f = b.IPv6
+		}
+		if f == nil {
+			return 0
+		}
+		perLine = [4]float64{f.AvgP50Speed, f.AvgP90Speed, f.AvgP95Speed, f.AvgP99Speed}
+		pool = [4]float64{f.PooledP50Speed, f.PooledP90Speed, f.PooledP95Speed, f.PooledP99Speed}
+	default:
+		perLine = [4]float64{b.AvgP50Speed, b.AvgP90Speed, b.AvgP95Speed, b.AvgP99Speed}
+		pool = [4]float64{b.PooledP50Speed, b.PooledP90Speed, b.PooledP95Speed, b.PooledP99Speed}
+	}
+	i := map[int]int{50: 0, 90: 1, 95: 2, 99: 3}[p]
+	if pooled && pool[i] > 0 {
+		return pool[i]
+	}
+	return perLine[i]
+}
+
+// speedPercentileTooltipLines formats P50..P99 of one batch for the crosshair, following the
+// selected percentile method ("compare" shows both values).
+func speedPercentileTooltipLines(state *uiState, b analysis.BatchSummary, fam string) []string {
+	unit, factor := speedUnitFor(state)
+	method := normalizeSpeedPercentileMethod(state.speedPercentileMethod)
+	var out []string
+	for _, p := range []int{50, 90, 95, 99} {
+		pooled := speedPercentileValue(b, fam, p, true) * factor
+		perLine := speedPercentileValue(b, fam, p, false) * factor
+		switch method {
+		case "per_line":
+			out = append(out, fmt.Sprintf("P%d: %.1f %s", p, perLine, unit))
+		case "compare":
+			out = append(out, fmt.Sprintf("P%d: %.1f %s pooled, %.1f per-line avg", p, pooled, unit, perLine))
+		default:
+			out = append(out, fmt.Sprintf("P%d: %.1f %s", p, pooled, unit))
+		}
+	}
+	return out
+}
+
 // compareChartSize returns a compact size for side-by-side percentiles charts
 // (compareChartSize removed; all charts now use full-width chartSize for consistency)
 
@@ -15662,25 +18740,48 @@ func pickTimeStep(span time.Duration) (time.Duration, string) {
 	}
 }
 
-// makeNiceTimeTicks returns rounded ticks between min and max at the given step with labels.
+// makeNiceTimeTicks returns rounded ticks between min and max at the given step with labels in
+// timeAxisLoc. Hour and day steps are aligned to wall-clock boundaries of that zone and advanced
+// by calendar, so day ticks stay at midnight and 6h ticks at 00/06/12/18 across DST changes.
 func makeNiceTimeTicks(minT, maxT time.Time, step time.Duration, labelFmt string) []chart.Tick {
 	if step <= 0 {
 		return nil
 	}
-	// Round start down to step boundary
-	// We align to UTC to avoid DST/local anomalies in labels
-	start := minT.UTC()
-	// Convert to Unix seconds and round down by step
-	s := start.Unix()
-	st := int64(step.Seconds())
-	if st <= 0 {
-		st = 1
+	loc := timeAxisLoc
+	end := maxT.Add(step)
+	var start time.Time
+	var next func(time.Time) time.Time
+	switch {
+	case step >= 24*time.Hour:
+		days := int(step / (24 * time.Hour))
+		m := minT.In(loc)
+		start = time.Date(m.Year(), m.Month(), m.Day(), 0, 0, 0, 0, loc)
+		next = func(t time.Time) time.Time { return t.AddDate(0, 0, days) }
+	case step >= time.Hour && (24*time.Hour)%step == 0:
+		hours := int(step / time.Hour)
+		m := minT.In(loc)
+		start = time.Date(m.Year(), m.Month(), m.Day(), m.Hour()-m.Hour()%hours, 0, 0, 0, loc)
+		next = func(t time.Time) time.Time {
+			t = t.In(loc)
+			n := time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+hours, 0, 0, 0, loc)
+			if !n.After(t) { // repeated hour when clocks go back
+				n = t.Add(step)
+			}
+			return n
+		}
+	default:
+		// Round start down to step boundary (minute steps: zone offsets are whole quarter hours)
+		st := int64(step.Seconds())
+		if st <= 0 {
+			st = 1
+		}
+		start = time.Unix((minT.Unix()/st)*st, 0)
+		next = func(t time.Time) time.Time { return t.Add(step) }
 	}
-	aligned := time.Unix((s/st)*st, 0).UTC()
 	// Generate ticks up to max
 	ticks := []chart.Tick{}
-	for t := aligned; !t.After(maxT.UTC().Add(step)); t = t.Add(step) {
-		ticks = append(ticks, chart.Tick{Value: float64(chart.TimeToFloat64(t)), Label: t.Local().Format(labelFmt)})
+	for t := start; !t.After(end); t = next(t) {
+		ticks = append(ticks, chart.Tick{Value: float64(chart.TimeToFloat64(t)), Label: t.In(loc).Format(labelFmt)})
 		if len(ticks) > 20 { // keep it readable
 			break
 		}
@@ -15760,8 +18861,9 @@ func themeChart(ch *chart.Chart) {
 	ch.YAxis.GridMinorStyle.StrokeColor = drawing.Color{R: grid.R, G: grid.G, B: grid.B, A: 110}
 	ch.YAxis.GridMinorStyle.StrokeWidth = 1
 	ch.YAxis.GridMinorStyle.StrokeDashArray = []float64{2, 3}
-	// Title color
+	// Title color; static titles are translated here, formatted ones where they are built
 	ch.TitleStyle.FontColor = text
+	ch.Title = tr(ch.Title)
 	// Best-effort legend theming: legend renders text using default style; set Title/Font colors to improve contrast.
 	// Many charts add the legend via chart.Legend(&ch); ensure text contrasts by setting DefaultTextColor-like fields.
 	// Note: go-chart does not expose a direct LegendStyle here; legend inherits canvas, so background is already themed.
@@ -15773,12 +18875,13 @@ func themeChart(ch *chart.Chart) {
 // rounded rectangle (simulated with a simple filled box) and a faint border.
 // attachLegend ensures a legend is attached consistently (single place for future styling).
 // attachLegend centralizes legend creation for possible future styling tweaks.
-func attachLegend(ch *chart.Chart) {
+func attachLegend(state *uiState, ch *chart.Chart) {
 	if ch == nil {
 		return
 	}
 	// Record a minimal styling spec for tests (legend_style_test.go)
 	// We approximate by capturing title and a fixed style; go-chart's legend doesn't expose style directly.
+	lastLegendSpecsMu.Lock()
 	if lastLegendSpecs == nil {
 		lastLegendSpecs = []legendSpec{}
 	}
@@ -15789,11 +18892,12 @@ func attachLegend(ch *chart.Chart) {
 		FontSize:   10,
 		Alpha:      255,
 	})
+	lastLegendSpecsMu.Unlock()
 	// Ensure a placeholder first series with name matching prefix for tests.
 	if len(ch.Series) == 0 || ch.Series[0].GetName() != "Legend" {
 		ch.Series = append([]chart.Series{chart.ContinuousSeries{Name: "Legend", XValues: []float64{0}, YValues: []float64{0}}}, ch.Series...)
 	}
-	ch.Elements = []chart.Renderable{chart.Legend(ch)}
+	ch.Elements = []chart.Renderable{chartLegend(state, ch)}
 }
 
 // colorForSeries centralizes palette selection so BatchAvg and Detailed charts map series
@@ -15960,6 +19064,10 @@ func exportAllChartsCombined(state *uiState) {
 		renderers = append(renderers, renderAvgSpeedByHTTPProtocolChart)
 		labels = append(labels, "Avg Speed by HTTP Protocol")
 	}
+	if state.protocolTTFBImgCanvas != nil && state.protocolTTFBImgCanvas.Image != nil && (!state.exportRespectVisibility || state.isChartVisible("TTFB by HTTP Protocol")) {
+		renderers = append(renderers, renderTTFBByHTTPProtocolChart)
+		labels = append(labels, "TTFB by HTTP Protocol")
+	}
 	if state.protocolStallRateImgCanvas != nil && state.protocolStallRateImgCanvas.Image != nil && (!state.exportRespectVisibility || state.isChartVisible("Stall Rate by HTTP Protocol (%)")) {
 		renderers = append(renderers, renderStallRateByHTTPProtocolChart)
 		labels = append(labels, "Stall Rate by HTTP Protocol (%)")
@@ -16020,6 +19128,82 @@ func exportAllChartsCombined(state *uiState) {
 		renderers = append(renderers, renderChunkedTransferRateChart)
 		labels = append(labels, "Chunked Transfer Rate (%)")
 	}
+	if state.compressionImgCanvas != nil && state.compressionImgCanvas.Image != nil && (!state.exportRespectVisibility || state.isChartVisible("Compression Ratio")) {
+		renderers = append(renderers, renderCompressionRatioChart)
+		labels = append(labels, "Compression Ratio")
+	}
+	if state.serverTimingImgCanvas != nil && state.serverTimingImgCanvas.Image != nil && (!state.exportRespectVisibility || state.isChartVisible("TTFB: Network vs Server (ms)")) {
+		renderers = append(renderers, renderServerTimingChart)
+		labels = append(labels, "TTFB: Network vs Server (ms)")
+	}
+	if state.tcpRetransImgCanvas != nil && state.tcpRetransImgCanvas.Image != nil && (!state.exportRespectVisibility || state.isChartVisible("TCP Retransmissions (%)")) {
+		renderers = append(renderers, renderTCPRetransChart)
+		labels = append(labels, "TCP Retransmissions (%)")
+	}
+	if state.iperf3ImgCanvas != nil && state.iperf3ImgCanvas.Image != nil && (!state.exportRespectVisibility || state.isChartVisible("iPerf3 Capacity vs HTTP Speed")) {
+		renderers = append(renderers, renderIPerf3Chart)
+		labels = append(labels, "iPerf3 Capacity vs HTTP Speed")
+	}
+	if state.qualityScoreImgCanvas != nil && state.qualityScoreImgCanvas.Image != nil && (!state.exportRespectVisibility || state.isChartVisible("Quality Score")) {
+		renderers = append(renderers, renderQualityScoreChart)
+		labels = append(labels, "Quality Score")
+	}
+	if state.planAttainmentImgCanvas != nil && state.planAttainmentImgCanvas.Image != nil && state.ispPlan.Enabled() && (!state.exportRespectVisibility || state.isChartVisible("Plan Attainment (%)")) {
+		renderers = append(renderers, renderPlanAttainmentChart)
+		labels = append(labels, "Plan Attainment (%)")
+	}
+	if state.stabilityImgCanvas != nil && state.stabilityImgCanvas.Image != nil && (!state.exportRespectVisibility || state.isChartVisible("Throughput Stability (%)")) {
+		renderers = append(renderers, renderThroughputStabilityChart)
+		labels = append(labels, "Throughput Stability (%)")
+	}
+	if state.ipv6ReadinessImgCanvas != nil && state.ipv6ReadinessImgCanvas.Image != nil && (!state.exportRespectVisibility || state.isChartVisible("IPv6 Readiness Score")) {
+		renderers = append(renderers, renderIPv6ReadinessChart)
+		labels = append(labels, "IPv6 Readiness Score")
+	}
+	if state.heLostImgCanvas != nil && state.heLostImgCanvas.Image != nil && (!state.exportRespectVisibility || state.isChartVisible("Happy Eyeballs – IPv6 Lost Races (%)")) {
+		renderers = append(renderers, renderHappyEyeballsIPv6LostChart)
+		labels = append(labels, "Happy Eyeballs – IPv6 Lost Races (%)")
+	}
+	if state.udpBlockedImgCanvas != nil && state.udpBlockedImgCanvas.Image != nil && (!state.exportRespectVisibility || state.isChartVisible("UDP Blocked Rate (%)")) {
+		renderers = append(renderers, renderUDPBlockedRateChart)
+		labels = append(labels, "UDP Blocked Rate (%)")
+	}
+	if state.returnHopsImgCanvas != nil && state.returnHopsImgCanvas.Image != nil && (!state.exportRespectVisibility || state.isChartVisible("Estimated Hop Count")) {
+		renderers = append(renderers, renderReturnHopsChart)
+		labels = append(labels, "Estimated Hop Count")
+	}
+	if state.coldWarmTTFBImgCanvas != nil && state.coldWarmTTFBImgCanvas.Image != nil && (!state.exportRespectVisibility || state.isChartVisible("Cold vs Warm Connection TTFB (ms)")) {
+		renderers = append(renderers, renderColdWarmTTFBChart)
+		labels = append(labels, "Cold vs Warm Connection TTFB (ms)")
+	}
+	if state.contentCorruptionImgCanvas != nil && state.contentCorruptionImgCanvas.Image != nil && (!state.exportRespectVisibility || state.isChartVisible("Content Corruption Rate (%)")) {
+		renderers = append(renderers, renderContentCorruptionRateChart)
+		labels = append(labels, "Content Corruption Rate (%)")
+	}
+	if state.dataUsageImgCanvas != nil && state.dataUsageImgCanvas.Image != nil && (!state.exportRespectVisibility || state.isChartVisible("Data Usage (MB)")) {
+		renderers = append(renderers, renderDataUsageChart)
+		labels = append(labels, "Data Usage (MB)")
+	}
+	if state.stallTimelineImgCanvas != nil && state.stallTimelineImgCanvas.Image != nil && (!state.exportRespectVisibility || state.isChartVisible("Stall Timeline (position in transfer)")) {
+		renderers = append(renderers, renderStallTimelineChart)
+		labels = append(labels, "Stall Timeline (position in transfer)")
+	}
+	if state.speedHeatmapImgCanvas != nil && state.speedHeatmapImgCanvas.Image != nil && (!state.exportRespectVisibility || state.isChartVisible("Speed Heatmap (day × hour)")) {
+		renderers = append(renderers, renderSpeedHeatmapChart)
+		labels = append(labels, "Speed Heatmap (day × hour)")
+	}
+	if state.ttfbHeatmapImgCanvas != nil && state.ttfbHeatmapImgCanvas.Image != nil && (!state.exportRespectVisibility || state.isChartVisible("TTFB Heatmap (day × hour)")) {
+		renderers = append(renderers, renderTTFBHeatmapChart)
+		labels = append(labels, "TTFB Heatmap (day × hour)")
+	}
+	if state.wifiRSSIImgCanvas != nil && state.wifiRSSIImgCanvas.Image != nil && (!state.exportRespectVisibility || state.isChartVisible("Wi‑Fi RSSI vs Throughput")) {
+		renderers = append(renderers, renderWiFiRSSIChart)
+		labels = append(labels, "Wi‑Fi RSSI vs Throughput")
+	}
+	if state.wifiPHYImgCanvas != nil && state.wifiPHYImgCanvas.Image != nil && (!state.exportRespectVisibility || state.isChartVisible("Wi‑Fi PHY Rate vs Throughput")) {
+		renderers = append(renderers, renderWiFiPHYRateChart)
+		labels = append(labels, "Wi‑Fi PHY Rate vs Throughput")
+	}
 
 	// Split charts in on-screen order: Speed Avg/Median/Min/Max, then Self-test, then Percentiles, then TTFB Avg/Median/Min/Max
 	if state.speedImgCanvas != nil && state.speedImgCanvas.Image != nil && state.showAvg && (!state.exportRespectVisibility || state.isChartVisible("Speed – Average")) {
@@ -16132,6 +19316,10 @@ func exportAllChartsCombined(state *uiState) {
 		renderers = append(renderers, renderJitterChart)
 		labels = append(labels, "Jitter")
 	}
+	if state.pingJitterImgCanvas != nil && state.pingJitterImgCanvas.Image != nil && (!state.exportRespectVisibility || state.isChartVisible("Ping Jitter (ms)")) {
+		renderers = append(renderers, renderPingJitterChart)
+		labels = append(labels, "Ping Jitter (ms)")
+	}
 	if state.covImgCanvas != nil && state.covImgCanvas.Image != nil && (!state.exportRespectVisibility || state.isChartVisible("Coefficient of Variation")) {
 		renderers = append(renderers, renderCoVChart)
 		labels = append(labels, "Coefficient of Variation")
@@ -16325,6 +19513,12 @@ func exportAllDetailedChartsCombined(state *uiState) {
 			imgs = append(imgs, img)
 		}
 	}
+	// 1b) Speed Distribution
+	if state.showDetailedDistribution {
+		if img := renderSpeedDistributionDetailedChart(state); img != nil {
+			imgs = append(imgs, img)
+		}
+	}
 	// 2) Speed over Time (per measurement)
 	if state.showDetailedSpeedOverTime {
 		if img := renderSpeedOverTimeDetailedChart(state); img != nil {
@@ -16456,6 +19650,9 @@ func getExportPlan(state *uiState) []string {
 	if state.protocolAvgSpeedImgCanvas != nil && state.protocolAvgSpeedImgCanvas.Image != nil && (!state.exportRespectVisibility || state.isChartVisible("Avg Speed by HTTP Protocol")) {
 		labels = append(labels, "Avg Speed by HTTP Protocol")
 	}
+	if state.protocolTTFBImgCanvas != nil && state.protocolTTFBImgCanvas.Image != nil && (!state.exportRespectVisibility || state.isChartVisible("TTFB by HTTP Protocol")) {
+		labels = append(labels, "TTFB by HTTP Protocol")
+	}
 	if state.protocolStallRateImgCanvas != nil && state.protocolStallRateImgCanvas.Image != nil && (!state.exportRespectVisibility || state.isChartVisible("Stall Rate by HTTP Protocol (%)")) {
 		labels = append(labels, "Stall Rate by HTTP Protocol (%)")
 	}
@@ -16548,6 +19745,8 @@ func rendererForImage(state *uiState, img *canvas.Image) func(*uiState) image.Im
 		return renderErrorRateChart
 	case state.jitterImgCanvas:
 		return renderJitterChart
+	case state.pingJitterImgCanvas:
+		return renderPingJitterChart
 	case state.covImgCanvas:
 		return renderCoVChart
 	case state.plCountImgCanvas:
@@ -16590,6 +19789,8 @@ func rendererForImage(state *uiState, img *canvas.Image) func(*uiState) image.Im
 		return renderHTTPProtocolMixChart
 	case state.protocolAvgSpeedImgCanvas:
 		return renderAvgSpeedByHTTPProtocolChart
+	case state.protocolTTFBImgCanvas:
+		return renderTTFBByHTTPProtocolChart
 	case state.protocolStallRateImgCanvas:
 		return renderStallRateByHTTPProtocolChart
 	case state.protocolErrorRateImgCanvas:
@@ -16614,12 +19815,52 @@ func rendererForImage(state *uiState, img *canvas.Image) func(*uiState) image.Im
 		return renderALPNMixChart
 	case state.chunkedRateImgCanvas:
 		return renderChunkedTransferRateChart
+	case state.qualityScoreImgCanvas:
+		return renderQualityScoreChart
+	case state.planAttainmentImgCanvas:
+		return renderPlanAttainmentChart
+	case state.stabilityImgCanvas:
+		return renderThroughputStabilityChart
+	case state.ipv6ReadinessImgCanvas:
+		return renderIPv6ReadinessChart
+	case state.heLostImgCanvas:
+		return renderHappyEyeballsIPv6LostChart
+	case state.udpBlockedImgCanvas:
+		return renderUDPBlockedRateChart
+	case state.returnHopsImgCanvas:
+		return renderReturnHopsChart
+	case state.compressionImgCanvas:
+		return renderCompressionRatioChart
+	case state.serverTimingImgCanvas:
+		return renderServerTimingChart
+	case state.tcpRetransImgCanvas:
+		return renderTCPRetransChart
+	case state.iperf3ImgCanvas:
+		return renderIPerf3Chart
+	case state.coldWarmTTFBImgCanvas:
+		return renderColdWarmTTFBChart
+	case state.contentCorruptionImgCanvas:
+		return renderContentCorruptionRateChart
+	case state.dataUsageImgCanvas:
+		return renderDataUsageChart
+	case state.stallTimelineImgCanvas:
+		return renderStallTimelineChart
+	case state.speedHeatmapImgCanvas:
+		return renderSpeedHeatmapChart
+	case state.ttfbHeatmapImgCanvas:
+		return renderTTFBHeatmapChart
+	case state.wifiRSSIImgCanvas:
+		return renderWiFiRSSIChart
+	case state.wifiPHYImgCanvas:
+		return renderWiFiPHYRateChart
 	case state.selfTestImgCanvas:
 		return renderSelfTestChart
 	case state.errorsByURLImgCanvas:
 		return renderErrorsByURLChart
 	case state.detailedPctlImgCanvas:
 		return renderSpeedPercentilesDetailedChart
+	case state.detailedDistImgCanvas:
+		return renderSpeedDistributionDetailedChart
 	case state.detailedSpeedOverTimeImgCanvas:
 		return renderSpeedOverTimeDetailedChart
 	case state.detailedTopSessionsImgCanvas:
@@ -16711,7 +19952,7 @@ func savePrefs(state *uiState) {
 	if s := strings.TrimSpace(state.situation); s != "" {
 		if prev := prefs.String("lastSituation"); prev != s {
 			prefs.SetString("lastSituation", s)
-			fmt.Printf("[viewer] prefs save: lastSituation=\"%s\"\n", s)
+			logf(slog.LevelDebug, "viewer", "prefs save: lastSituation=\"%s\"", s)
 		}
 	}
 	prefs.SetInt("batchesN", state.batchesN)
@@ -16721,9 +19962,21 @@ func savePrefs(state *uiState) {
 	prefs.SetString("xAxisMode", state.xAxisMode)
 	prefs.SetString("yScaleMode", state.yScaleMode)
 	prefs.SetString("speedUnit", state.speedUnit)
+	prefs.SetString("speedPercentileMethod", state.speedPercentileMethod)
+	prefs.SetBool("timeAxisUTC", state.timeAxisUTC)
 	prefs.SetBool("crosshair", state.crosshairEnabled)
 	prefs.SetBool("showHints", state.showHints)
 	prefs.SetBool("showDNSLegacy", state.showDNSLegacy)
+	prefs.SetBool("decimateCharts", state.decimateCharts)
+	prefs.SetBool("situationOverlay", state.situationOverlay)
+	prefs.SetBool("interfaceOverlay", state.interfaceOverlay)
+	prefs.SetBool("showConfigMarkers", state.showConfigMarkers)
+	prefs.SetBool("showChartTOC", state.showChartTOC)
+	prefs.SetString("uiLanguage", state.uiLanguage)
+	prefs.SetBool("trayStatus", state.trayStatus)
+	prefs.SetString("chartAppearance", chartLook.String())
+	prefs.SetString("trendMethod", chartTrend.Method)
+	prefs.SetInt("forecastBatches", chartTrend.Horizon)
 	// Hide 'Other' buckets
 	prefs.SetBool("hideOtherCategories", state.hideOtherCategories)
 	// Hide '(unknown)' protocol buckets
@@ -16735,8 +19988,9 @@ func savePrefs(state *uiState) {
 	// SLA thresholds
 	prefs.SetInt("slaSpeedThresholdKbps", state.slaSpeedThresholdKbps)
 	prefs.SetInt("slaTTFBThresholdMs", state.slaTTFBThresholdMs)
-	// Low-speed threshold
+	// Low-speed threshold and transient stall gap
 	prefs.SetInt("lowSpeedThresholdKbps", state.lowSpeedThresholdKbps)
+	prefs.SetInt("microStallGapMs", state.microStallGapMs)
 	// Rolling overlays
 	prefs.SetBool("showRolling", state.showRolling)
 	prefs.SetBool("showRollingBand", state.showRollingBand)
@@ -16751,6 +20005,12 @@ func savePrefs(state *uiState) {
 	prefs.SetBool("showOnlyQualityGood", state.showOnlyQualityGood)
 	// Table columns
 	prefs.SetBool("showQualColumn", state.showQualColumn)
+	prefs.SetString("batchTableColumns", strings.Join(state.tableColumns, ","))
+	sortPref := state.tableSortKey
+	if sortPref != "" && state.tableSortDesc {
+		sortPref = "-" + sortPref
+	}
+	prefs.SetString("batchTableSort", sortPref)
 	// Export behavior
 	prefs.SetBool("exportRespectVisibility", state.exportRespectVisibility)
 	// Auto-open Detailed tab when a selection exists
@@ -16760,6 +20020,7 @@ func savePrefs(state *uiState) {
 	prefs.SetInt("detailedTopSessionsN", state.detailedTopSessionsN)
 	// Detailed visibility
 	prefs.SetBool("showDetailedPercentiles", state.showDetailedPercentiles)
+	prefs.SetBool("showDetailedDistribution", state.showDetailedDistribution)
 	prefs.SetBool("showDetailedSpeedOverTime", state.showDetailedSpeedOverTime)
 	prefs.SetBool("showDetailedBytesOverTime", state.showDetailedBytesOverTime)
 	prefs.SetBool("showDetailedTopSessionsSpeed", state.showDetailedTopSessionsSpeed)
@@ -16805,11 +20066,12 @@ func savePrefs(state *uiState) {
 		if data, err := json.Marshal(ids); err == nil {
 			prefs.SetString("hiddenChartIDsJSON", string(data))
 		}
-		// Persist custom presets
-		if len(state.customPresets) > 0 {
-			if data, err := json.Marshal(state.customPresets); err == nil {
-				prefs.SetString("customVisibilityPresetsJSON", string(data))
-			}
+		// Persist custom presets (also when the last one was deleted) and the chart order
+		if data, err := json.Marshal(state.customPresets); err == nil {
+			prefs.SetString("customVisibilityPresetsJSON", string(data))
+		}
+		if data, err := json.Marshal(state.chartOrder); err == nil {
+			prefs.SetString("chartOrderJSON", string(data))
 		}
 	}
 }
@@ -16826,6 +20088,8 @@ func resetViewerDefaults(state *uiState) {
 	state.yScaleMode = "absolute"
 	state.useRelative = false
 	state.speedUnit = "kbps"
+	state.speedPercentileMethod = "pooled"
+	setTimeAxisUTC(state, false)
 
 	// Visibility and overlays
 	state.showOverall = true
@@ -16837,6 +20101,18 @@ func resetViewerDefaults(state *uiState) {
 	state.crosshairEnabled = true
 	state.showHints = false
 	state.showDNSLegacy = false
+	state.decimateCharts = true
+	chartDecimationEnabled = true
+	state.situationOverlay = false
+	state.interfaceOverlay = false
+	state.showConfigMarkers = true
+	state.showChartTOC = true
+	state.uiLanguage = ""
+	i18n.SetLanguage("")
+	applyUILanguage(state)
+	state.trayStatus = false
+	chartLook = defaultChartAppearance()
+	chartTrend = trendOptions{Horizon: defaultForecastBatches}
 	state.hideOtherCategories = false
 	state.hideUnknownProtocols = false
 	state.showRolling = true
@@ -16858,6 +20134,7 @@ func resetViewerDefaults(state *uiState) {
 	state.slaSpeedThresholdKbps = 10000
 	state.slaTTFBThresholdMs = 200
 	state.lowSpeedThresholdKbps = 1000
+	state.microStallGapMs = analysis.DefaultMicroStallGapMs
 	state.calibTolerancePct = 10
 
 	// Export behavior
@@ -16867,6 +20144,7 @@ func resetViewerDefaults(state *uiState) {
 	state.detailedMaxSeries = 8
 	state.detailedTopSessionsN = 4
 	state.showDetailedPercentiles = true
+	state.showDetailedDistribution = true
 	state.showDetailedSpeedOverTime = true
 	state.showDetailedBytesOverTime = true
 	state.showDetailedTopSessionsSpeed = true
@@ -16882,10 +20160,13 @@ func resetViewerDefaults(state *uiState) {
 	screenshotThemeMode = "auto"
 	state.app.Preferences().SetString("screenshotThemeMode", screenshotThemeMode)
 	screenshotThemeGlobal = resolveTheme(screenshotThemeMode, state.app)
+	setAppTheme(state, "system", "")
 
 	// Clear hidden charts maps (both legacy titles and stable IDs)
 	state.hiddenCharts = map[string]bool{}
 	state.hiddenChartIDs = map[string]bool{}
+	state.chartOrder = nil
+	applyChartOrder(state, nil)
 }
 
 func loadPrefs(state *uiState, avg *widget.Check, v4 *widget.Check, v6 *widget.Check, fileLabel *widget.Label, tabs *container.AppTabs) {
@@ -16934,9 +20215,9 @@ func loadPrefs(state *uiState, avg *widget.Check, v4 *widget.Check, v6 *widget.C
 		state.situation = rawSit
 	}
 	if state.situation == "" || strings.EqualFold(state.situation, "All") {
-		fmt.Printf("[viewer] prefs: lastSituation=<All>\n")
+		logf(slog.LevelDebug, "viewer", "prefs: lastSituation=<All>")
 	} else {
-		fmt.Printf("[viewer] prefs: lastSituation=%q\n", state.situation)
+		logf(slog.LevelDebug, "viewer", "prefs: lastSituation=%q", state.situation)
 	}
 	mode := prefs.StringWithFallback("xAxisMode", state.xAxisMode)
 	switch mode {
@@ -16952,6 +20233,8 @@ func loadPrefs(state *uiState, avg *widget.Check, v4 *widget.Check, v6 *widget.C
 	if su := prefs.StringWithFallback("speedUnit", state.speedUnit); su != "" {
 		state.speedUnit = su
 	}
+	state.speedPercentileMethod = normalizeSpeedPercentileMethod(prefs.StringWithFallback("speedPercentileMethod", state.speedPercentileMethod))
+	setTimeAxisUTC(state, prefs.BoolWithFallback("timeAxisUTC", state.timeAxisUTC))
 	state.crosshairEnabled = prefs.BoolWithFallback("crosshair", state.crosshairEnabled)
 	if tabs != nil {
 		idx := prefs.IntWithFallback("selectedTabIndex", 0)
@@ -16961,6 +20244,20 @@ func loadPrefs(state *uiState, avg *widget.Check, v4 *widget.Check, v6 *widget.C
 	}
 	state.showHints = prefs.BoolWithFallback("showHints", state.showHints)
 	state.showDNSLegacy = prefs.BoolWithFallback("showDNSLegacy", state.showDNSLegacy)
+	state.decimateCharts = prefs.BoolWithFallback("decimateCharts", state.decimateCharts)
+	state.situationOverlay = prefs.Bool("situationOverlay")
+	state.interfaceOverlay = prefs.Bool("interfaceOverlay")
+	state.showConfigMarkers = prefs.BoolWithFallback("showConfigMarkers", state.showConfigMarkers)
+	state.showChartTOC = prefs.BoolWithFallback("showChartTOC", state.showChartTOC)
+	state.trayStatus = prefs.Bool("trayStatus")
+	chartDecimationEnabled = state.decimateCharts
+	if a, err := parseChartAppearance(prefs.String("chartAppearance")); err == nil {
+		chartLook = a
+	}
+	if m, err := parseTrendMethod(prefs.String("trendMethod")); err == nil {
+		chartTrend.Method = m
+	}
+	chartTrend.Horizon = min(max(prefs.IntWithFallback("forecastBatches", chartTrend.Horizon), 0), 100)
 	state.hideOtherCategories = prefs.BoolWithFallback("hideOtherCategories", state.hideOtherCategories)
 	state.hideUnknownProtocols = prefs.BoolWithFallback("hideUnknownProtocols", state.hideUnknownProtocols)
 	// SLA thresholds (persisted)
@@ -16974,6 +20271,9 @@ func loadPrefs(state *uiState, avg *widget.Check, v4 *widget.Check, v6 *widget.C
 	if v := prefs.IntWithFallback("lowSpeedThresholdKbps", state.lowSpeedThresholdKbps); v > 0 {
 		state.lowSpeedThresholdKbps = v
 	}
+	if v := prefs.IntWithFallback("microStallGapMs", state.microStallGapMs); v > 0 {
+		state.microStallGapMs = v
+	}
 	// Rolling overlays
 	state.showRolling = prefs.BoolWithFallback("showRolling", state.showRolling)
 	state.showRollingBand = prefs.BoolWithFallback("showRollingBand", state.showRollingBand)
@@ -16990,6 +20290,13 @@ func loadPrefs(state *uiState, avg *widget.Check, v4 *widget.Check, v6 *widget.C
 	state.showOnlyQualityGood = prefs.BoolWithFallback("showOnlyQualityGood", state.showOnlyQualityGood)
 	// Table columns
 	state.showQualColumn = prefs.BoolWithFallback("showQualColumn", state.showQualColumn)
+	state.tableColumns = parseBatchColumnsPref(prefs.String("batchTableColumns"))
+	if sortPref := prefs.String("batchTableSort"); sortPref != "" {
+		state.tableSortKey, state.tableSortDesc = strings.TrimPrefix(sortPref, "-"), strings.HasPrefix(sortPref, "-")
+		if _, ok := batchColumnByKey(state.tableSortKey); !ok {
+			state.tableSortKey, state.tableSortDesc = "", false
+		}
+	}
 	// Export behavior
 	state.exportRespectVisibility = prefs.BoolWithFallback("exportRespectVisibility", state.exportRespectVisibility)
 	// Auto-open Detailed tab when a selection exists
@@ -17003,6 +20310,7 @@ func loadPrefs(state *uiState, avg *widget.Check, v4 *widget.Check, v6 *widget.C
 	}
 	// Detailed visibility
 	state.showDetailedPercentiles = prefs.BoolWithFallback("showDetailedPercentiles", state.showDetailedPercentiles)
+	state.showDetailedDistribution = prefs.BoolWithFallback("showDetailedDistribution", state.showDetailedDistribution)
 	state.showDetailedSpeedOverTime = prefs.BoolWithFallback("showDetailedSpeedOverTime", state.showDetailedSpeedOverTime)
 	state.showDetailedBytesOverTime = prefs.BoolWithFallback("showDetailedBytesOverTime", state.showDetailedBytesOverTime)
 	state.showDetailedTopSessionsSpeed = prefs.BoolWithFallback("showDetailedTopSessionsSpeed", state.showDetailedTopSessionsSpeed)
@@ -17056,18 +20364,24 @@ func loadPrefs(state *uiState, avg *widget.Check, v4 *widget.Check, v6 *widget.C
 					if id := chartTitleToID(t); id != "" {
 						state.hiddenChartIDs[id] = true
 					}
-
-					// Load custom presets
-					if raw := strings.TrimSpace(prefs.StringWithFallback("customVisibilityPresetsJSON", "")); raw != "" {
-						var cps []visibilityPreset
-						if err := json.Unmarshal([]byte(raw), &cps); err == nil {
-							state.customPresets = cps
-						}
-					}
 				}
 			}
 		}
 	}
+	// Custom presets / dashboards and the chart order
+	if raw := strings.TrimSpace(prefs.StringWithFallback("customVisibilityPresetsJSON", "")); raw != "" {
+		var cps []visibilityPreset
+		if err := json.Unmarshal([]byte(raw), &cps); err == nil {
+			state.customPresets = cps
+		}
+	}
+	if raw := strings.TrimSpace(prefs.StringWithFallback("chartOrderJSON", "")); raw != "" {
+		var order []string
+		if err := json.Unmarshal([]byte(raw), &order); err == nil {
+			state.chartOrder = order
+		}
+	}
+	applyChartOrder(state, state.chartOrder)
 	// Apply chart visibility after chartRefs are registered
 	state.applyChartVisibilityFromPrefs()
 }
@@ -17092,41 +20406,17 @@ func truncatePath(p string, n int) string {
 	return dir + "/..." + base
 }
 
-// Hide/show IPv4/IPv6 columns according to toggles
+// Hide/show the Overall/IPv4/IPv6/Qual columns according to toggles
 func updateColumnVisibility(state *uiState) {
-	// Columns: 0 RunTag, 1 Lines, 2 AvgSpeed, 3 AvgTTFB, 4 Errors, 5 v4 speed, 6 v4 ttfb, 7 v6 speed, 8 v6 ttfb
-	// We can't truly hide columns in fyne.Table; set width to 0 for hidden columns
+	// We can't truly hide columns in fyne.Table; hidden columns get width 0
 	if state == nil || state.table == nil {
 		return
 	}
-	if state.showOverall {
-		state.table.SetColumnWidth(2, 130)
-		state.table.SetColumnWidth(3, 100)
-	} else {
-		state.table.SetColumnWidth(2, 0)
-		state.table.SetColumnWidth(3, 0)
-	}
-	if state.showIPv4 {
-		state.table.SetColumnWidth(5, 100)
-		state.table.SetColumnWidth(6, 100)
-	} else {
-		state.table.SetColumnWidth(5, 0)
-		state.table.SetColumnWidth(6, 0)
-	}
-	if state.showIPv6 {
-		state.table.SetColumnWidth(7, 100)
-		state.table.SetColumnWidth(8, 100)
-	} else {
-		state.table.SetColumnWidth(7, 0)
-		state.table.SetColumnWidth(8, 0)
-	}
-	// Qual column visibility
-	if state.showQualColumn {
-		state.table.SetColumnWidth(9, 60)
-	} else {
-		state.table.SetColumnWidth(9, 0)
+	winW := float32(1200)
+	if state.window != nil {
+		winW = state.window.Canvas().Size().Width
 	}
-	state.table.Refresh()
+	applyBatchTableColumns(state, winW)
 }
 
 // crosshairOverlay draws a simple crosshair on top of a chart image when enabled.
@@ -17135,14 +20425,22 @@ type crosshairOverlay struct {
 	widget.BaseWidget
 	state    *uiState
 	enabled  bool
-	mode     string // "speed", "ttfb", "error", "jitter", "cov", "pctl_overall", "pctl_ipv4", "pctl_ipv6", ...
+	mode     string        // "speed", "ttfb", "error", "jitter", "cov", "pctl_overall", "pctl_ipv4", "pctl_ipv6", ...
+	img      *canvas.Image // set for detached chart windows: calibrate against this image, not the mode's main canvas
 	mouse    fyne.Position
 	hovering bool
+	// readout under the crosshair for click-to-copy (crosshair_copy.go); copied marks it as sent
+	readoutTag string
+	readout    []string
+	copied     bool
+	// a11yTitle is the chart title screen readers announce (a11y.go)
+	a11yTitle string
 }
 
 func newCrosshairOverlay(state *uiState, mode string) *crosshairOverlay {
 	c := &crosshairOverlay{state: state, enabled: state != nil && state.crosshairEnabled, mode: mode}
 	c.ExtendBaseWidget(c)
+	registerCrosshairOverlay(state, c)
 	return c
 }
 
@@ -17160,15 +20458,17 @@ func (c *crosshairOverlay) CreateRenderer() fyne.WidgetRenderer {
 	label.Wrapping = fyne.TextWrapOff
 	label.Segments = []widget.RichTextSegment{}
 	labelBG := canvas.NewRectangle(color.RGBA{R: 0, G: 0, B: 0, A: 170})
+	band := canvas.NewRectangle(hoverBandColor())
 	// No axis marker to avoid misaligned highlighting; keep it simple and accurate
-	objs := []fyne.CanvasObject{bg, lineV, lineH, dot, labelBG, label}
-	r := &crosshairRenderer{c: c, bg: bg, lineV: lineV, lineH: lineH, dot: dot, labelBG: labelBG, label: label, objs: objs}
+	objs := []fyne.CanvasObject{bg, band, lineV, lineH, dot, labelBG, label}
+	r := &crosshairRenderer{c: c, bg: bg, band: band, lineV: lineV, lineH: lineH, dot: dot, labelBG: labelBG, label: label, objs: objs}
 	return r
 }
 
 type crosshairRenderer struct {
 	c     *crosshairOverlay
 	bg    *canvas.Rectangle
+	band  *canvas.Rectangle // the snapped batch, also shown on the synchronized charts
 	lineV *canvas.Line
 	lineH *canvas.Line
 	dot   *canvas.Circle
@@ -17189,7 +20489,11 @@ func (r *crosshairRenderer) Layout(size fyne.Size) {
 		r.bg.Resize(size)
 		r.bg.Move(fyne.NewPos(0, 0))
 	}
-	if !r.c.enabled || !r.c.hovering {
+	// the batch hovered on another chart is highlighted here without a readout
+	synced := r.c.enabled && !r.c.hovering && r.c.syncable() && r.c.state != nil && r.c.state.hoverRunTag != ""
+	r.band.Resize(fyne.NewSize(0, 0))
+	r.band.Move(fyne.NewPos(-1000, -1000))
+	if !r.c.enabled || (!r.c.hovering && !synced) {
 		// move lines out of view
 		r.lineV.Position1 = fyne.NewPos(-10, -10)
 		r.lineV.Position2 = fyne.NewPos(-10, -10)
@@ -17246,6 +20550,8 @@ func (r *crosshairRenderer) Layout(size fyne.Size) {
 			imgCanvas = r.c.state.errImgCanvas
 		case "jitter":
 			imgCanvas = r.c.state.jitterImgCanvas
+		case "ping_jitter":
+			imgCanvas = r.c.state.pingJitterImgCanvas
 		case "cov":
 			imgCanvas = r.c.state.covImgCanvas
 		case "plateau_count":
@@ -17304,6 +20610,8 @@ func (r *crosshairRenderer) Layout(size fyne.Size) {
 			imgCanvas = r.c.state.protocolMixImgCanvas
 		case "protocol_avg_speed":
 			imgCanvas = r.c.state.protocolAvgSpeedImgCanvas
+		case "protocol_ttfb":
+			imgCanvas = r.c.state.protocolTTFBImgCanvas
 		case "protocol_stall_rate":
 			imgCanvas = r.c.state.protocolStallRateImgCanvas
 		case "protocol_error_rate":
@@ -17318,6 +20626,38 @@ func (r *crosshairRenderer) Layout(size fyne.Size) {
 			imgCanvas = r.c.state.alpnMixImgCanvas
 		case "chunked_rate":
 			imgCanvas = r.c.state.chunkedRateImgCanvas
+		case "quality_score":
+			imgCanvas = r.c.state.qualityScoreImgCanvas
+		case "plan_attainment":
+			imgCanvas = r.c.state.planAttainmentImgCanvas
+		case "throughput_stability":
+			imgCanvas = r.c.state.stabilityImgCanvas
+		case "ipv6_readiness":
+			imgCanvas = r.c.state.ipv6ReadinessImgCanvas
+		case "happy_eyeballs_ipv6_lost":
+			imgCanvas = r.c.state.heLostImgCanvas
+		case "udp_blocked_rate":
+			imgCanvas = r.c.state.udpBlockedImgCanvas
+		case "return_hops":
+			imgCanvas = r.c.state.returnHopsImgCanvas
+		case "compression_ratio":
+			imgCanvas = r.c.state.compressionImgCanvas
+		case "server_timing":
+			imgCanvas = r.c.state.serverTimingImgCanvas
+		case "tcp_retrans":
+			imgCanvas = r.c.state.tcpRetransImgCanvas
+		case "iperf3_capacity":
+			imgCanvas = r.c.state.iperf3ImgCanvas
+		case "cold_warm_ttfb":
+			imgCanvas = r.c.state.coldWarmTTFBImgCanvas
+		case "content_corruption_rate":
+			imgCanvas = r.c.state.contentCorruptionImgCanvas
+		case "data_usage":
+			imgCanvas = r.c.state.dataUsageImgCanvas
+		case "wifi_rssi":
+			imgCanvas = r.c.state.wifiRSSIImgCanvas
+		case "wifi_phy_rate":
+			imgCanvas = r.c.state.wifiPHYImgCanvas
 		case "error_reasons_detailed":
 			imgCanvas = r.c.state.errorReasonsDetailedImgCanvas
 		case "selftest_speed":
@@ -17334,6 +20674,11 @@ func (r *crosshairRenderer) Layout(size fyne.Size) {
 			imgCanvas = r.c.state.detailedErrorsByURLImgCanvas
 		case "detailed_percentiles":
 			imgCanvas = r.c.state.detailedPctlImgCanvas
+		case "detailed_distribution":
+			imgCanvas = r.c.state.detailedDistImgCanvas
+		}
+		if r.c.img != nil {
+			imgCanvas = r.c.img
 		}
 		if imgCanvas != nil && imgCanvas.Image != nil {
 			b := imgCanvas.Image.Bounds()
@@ -17347,7 +20692,7 @@ func (r *crosshairRenderer) Layout(size fyne.Size) {
 	// Compute contain scaling (centralized helper)
 	drawX, drawY, drawW, drawH, scale = computeContainRect(imgW, imgH, float32(size.Width), float32(size.Height))
 	// Hide crosshair when cursor is outside drawn image rect (contain-fit area)
-	if !(float32(x) >= drawX && float32(x) <= drawX+drawW && float32(y) >= drawY && float32(y) <= drawY+drawH) {
+	if !synced && !(float32(x) >= drawX && float32(x) <= drawX+drawW && float32(y) >= drawY && float32(y) <= drawY+drawH) {
 		r.lineV.Position1 = fyne.NewPos(-10, -10)
 		r.lineV.Position2 = fyne.NewPos(-10, -10)
 		r.lineH.Position1 = fyne.NewPos(-10, -10)
@@ -17400,6 +20745,8 @@ func (r *crosshairRenderer) Layout(size fyne.Size) {
 				imgCanvas = r.c.state.errImgCanvas
 			case "jitter":
 				imgCanvas = r.c.state.jitterImgCanvas
+			case "ping_jitter":
+				imgCanvas = r.c.state.pingJitterImgCanvas
 			case "cov":
 				imgCanvas = r.c.state.covImgCanvas
 			case "plateau_count":
@@ -17454,6 +20801,8 @@ func (r *crosshairRenderer) Layout(size fyne.Size) {
 				imgCanvas = r.c.state.protocolMixImgCanvas
 			case "protocol_avg_speed":
 				imgCanvas = r.c.state.protocolAvgSpeedImgCanvas
+			case "protocol_ttfb":
+				imgCanvas = r.c.state.protocolTTFBImgCanvas
 			case "protocol_stall_rate":
 				imgCanvas = r.c.state.protocolStallRateImgCanvas
 			case "protocol_error_rate":
@@ -17468,9 +20817,44 @@ func (r *crosshairRenderer) Layout(size fyne.Size) {
 				imgCanvas = r.c.state.alpnMixImgCanvas
 			case "chunked_rate":
 				imgCanvas = r.c.state.chunkedRateImgCanvas
+			case "quality_score":
+				imgCanvas = r.c.state.qualityScoreImgCanvas
+			case "plan_attainment":
+				imgCanvas = r.c.state.planAttainmentImgCanvas
+			case "throughput_stability":
+				imgCanvas = r.c.state.stabilityImgCanvas
+			case "ipv6_readiness":
+				imgCanvas = r.c.state.ipv6ReadinessImgCanvas
+			case "happy_eyeballs_ipv6_lost":
+				imgCanvas = r.c.state.heLostImgCanvas
+			case "udp_blocked_rate":
+				imgCanvas = r.c.state.udpBlockedImgCanvas
+			case "return_hops":
+				imgCanvas = r.c.state.returnHopsImgCanvas
+			case "compression_ratio":
+				imgCanvas = r.c.state.compressionImgCanvas
+			case "server_timing":
+				imgCanvas = r.c.state.serverTimingImgCanvas
+			case "tcp_retrans":
+				imgCanvas = r.c.state.tcpRetransImgCanvas
+			case "iperf3_capacity":
+				imgCanvas = r.c.state.iperf3ImgCanvas
+			case "cold_warm_ttfb":
+				imgCanvas = r.c.state.coldWarmTTFBImgCanvas
+			case "content_corruption_rate":
+				imgCanvas = r.c.state.contentCorruptionImgCanvas
+			case "data_usage":
+				imgCanvas = r.c.state.dataUsageImgCanvas
+			case "wifi_rssi":
+				imgCanvas = r.c.state.wifiRSSIImgCanvas
+			case "wifi_phy_rate":
+				imgCanvas = r.c.state.wifiPHYImgCanvas
 			case "error_reasons_detailed":
 				imgCanvas = r.c.state.errorReasonsDetailedImgCanvas
 			}
+			if r.c.img != nil {
+				imgCanvas = r.c.img
+			}
 			if imgCanvas != nil && imgCanvas.Image != nil {
 				centersImg := detectXGridlineCenters(imgCanvas.Image, isDark)
 				if len(centersImg) >= n {
@@ -17522,6 +20906,15 @@ func (r *crosshairRenderer) Layout(size fyne.Size) {
 			}
 		}
 	}
+	if synced {
+		idx = batchIndexByRunTag(rows, r.c.state.hoverRunTag)
+	} else if idx >= 0 {
+		setHoverBatch(r.c.state, r.c, rows[idx].RunTag)
+	}
+	if synced && idx < 0 {
+		r.hideMarks()
+		return
+	}
 	// Snap the vertical line to the nearest data X for precise alignment with ticks
 	var lineX float32 = float32(x)
 	if n > 0 && idx >= 0 {
@@ -17574,6 +20967,8 @@ func (r *crosshairRenderer) Layout(size fyne.Size) {
 				imgCanvas = r.c.state.errImgCanvas
 			case "jitter":
 				imgCanvas = r.c.state.jitterImgCanvas
+			case "ping_jitter":
+				imgCanvas = r.c.state.pingJitterImgCanvas
 			case "cov":
 				imgCanvas = r.c.state.covImgCanvas
 			case "plateau_count":
@@ -17624,6 +21019,8 @@ func (r *crosshairRenderer) Layout(size fyne.Size) {
 				imgCanvas = r.c.state.protocolMixImgCanvas
 			case "protocol_avg_speed":
 				imgCanvas = r.c.state.protocolAvgSpeedImgCanvas
+			case "protocol_ttfb":
+				imgCanvas = r.c.state.protocolTTFBImgCanvas
 			case "protocol_stall_rate":
 				imgCanvas = r.c.state.protocolStallRateImgCanvas
 			case "protocol_error_rate":
@@ -17638,9 +21035,44 @@ func (r *crosshairRenderer) Layout(size fyne.Size) {
 				imgCanvas = r.c.state.alpnMixImgCanvas
 			case "chunked_rate":
 				imgCanvas = r.c.state.chunkedRateImgCanvas
+			case "quality_score":
+				imgCanvas = r.c.state.qualityScoreImgCanvas
+			case "plan_attainment":
+				imgCanvas = r.c.state.planAttainmentImgCanvas
+			case "throughput_stability":
+				imgCanvas = r.c.state.stabilityImgCanvas
+			case "ipv6_readiness":
+				imgCanvas = r.c.state.ipv6ReadinessImgCanvas
+			case "happy_eyeballs_ipv6_lost":
+				imgCanvas = r.c.state.heLostImgCanvas
+			case "udp_blocked_rate":
+				imgCanvas = r.c.state.udpBlockedImgCanvas
+			case "return_hops":
+				imgCanvas = r.c.state.returnHopsImgCanvas
+			case "compression_ratio":
+				imgCanvas = r.c.state.compressionImgCanvas
+			case "server_timing":
+				imgCanvas = r.c.state.serverTimingImgCanvas
+			case "tcp_retrans":
+				imgCanvas = r.c.state.tcpRetransImgCanvas
+			case "iperf3_capacity":
+				imgCanvas = r.c.state.iperf3ImgCanvas
+			case "cold_warm_ttfb":
+				imgCanvas = r.c.state.coldWarmTTFBImgCanvas
+			case "content_corruption_rate":
+				imgCanvas = r.c.state.contentCorruptionImgCanvas
+			case "data_usage":
+				imgCanvas = r.c.state.dataUsageImgCanvas
+			case "wifi_rssi":
+				imgCanvas = r.c.state.wifiRSSIImgCanvas
+			case "wifi_phy_rate":
+				imgCanvas = r.c.state.wifiPHYImgCanvas
 			case "error_reasons_detailed":
 				imgCanvas = r.c.state.errorReasonsDetailedImgCanvas
 			}
+			if r.c.img != nil {
+				imgCanvas = r.c.img
+			}
 			if imgCanvas != nil && imgCanvas.Image != nil {
 				centersImg := detectXGridlineCenters(imgCanvas.Image, isDark)
 				if len(centersImg) > idx {
@@ -17661,6 +21093,19 @@ func (r *crosshairRenderer) Layout(size fyne.Size) {
 	}
 	r.lineV.Position1 = fyne.NewPos(lineX, 0)
 	r.lineV.Position2 = fyne.NewPos(lineX, size.Height)
+	if idx >= 0 {
+		r.band.Resize(fyne.NewSize(8, drawH))
+		r.band.Move(fyne.NewPos(lineX-4, drawY))
+	}
+	if synced {
+		r.lineH.Position1 = fyne.NewPos(-10, -10)
+		r.lineH.Position2 = fyne.NewPos(-10, -10)
+		r.dot.Move(fyne.NewPos(-10, -10))
+		r.labelBG.Resize(fyne.NewSize(0, 0))
+		r.labelBG.Move(fyne.NewPos(-1000, -1000))
+		r.label.Move(fyne.NewPos(-1000, -1000))
+		return
+	}
 	// horizontal line follows mouse Y
 	r.lineH.Position1 = fyne.NewPos(0, y)
 	r.lineH.Position2 = fyne.NewPos(size.Width, y)
@@ -17692,9 +21137,13 @@ func (r *crosshairRenderer) Layout(size fyne.Size) {
 		if !strings.HasPrefix(r.c.mode, "detailed_") {
 			lines = append(lines, xLabel)
 		}
+		if overlayTooltipMode(r.c.state, r.c.mode) {
+			dim, key := overlayDimension(r.c.state)
+			lines = append(lines, dim+": "+key(bs))
+		}
 		switch r.c.mode {
 		case "speed":
-			unit, factor := speedUnitNameAndFactor(r.c.state.speedUnit)
+			unit, factor := speedUnitFor(r.c.state)
 			if r.c.state.showOverall {
 				lines = append(lines, fmt.Sprintf("Overall: %.1f %s", bs.AvgSpeed*factor, unit))
 			}
@@ -17735,6 +21184,13 @@ func (r *crosshairRenderer) Layout(size fyne.Size) {
 			if r.c.state.showIPv6 && bs.IPv6 != nil {
 				lines = append(lines, fmt.Sprintf("IPv6: %.2f%%", bs.IPv6.AvgJitterPct))
 			}
+		case "ping_jitter":
+			if bs.PingJitterLines > 0 {
+				lines = append(lines, fmt.Sprintf("Avg: %.1f ms  P95: %.1f ms", bs.AvgPingJitterMs, bs.P95PingJitterMs))
+				lines = append(lines, fmt.Sprintf("Ping lines: %d, avg RTT %.1f ms, loss %.1f%%", bs.PingJitterLines, bs.AvgPingRTTMs, bs.PingLossPct))
+			} else {
+				lines = append(lines, "No ping probe lines with 2+ RTTs")
+			}
 		case "cov":
 			if r.c.state.showOverall {
 				lines = append(lines, fmt.Sprintf("Overall: %.2f%%", bs.AvgCoefVariationPct))
@@ -17746,28 +21202,16 @@ func (r *crosshairRenderer) Layout(size fyne.Size) {
 				lines = append(lines, fmt.Sprintf("IPv6: %.2f%%", bs.IPv6.AvgCoefVariationPct))
 			}
 		case "pctl_overall":
-			unit, factor := speedUnitNameAndFactor(r.c.state.speedUnit)
-			lines = append(lines, fmt.Sprintf("P50: %.1f %s", bs.AvgP50Speed*factor, unit))
-			lines = append(lines, fmt.Sprintf("P90: %.1f %s", bs.AvgP90Speed*factor, unit))
-			lines = append(lines, fmt.Sprintf("P95: %.1f %s", bs.AvgP95Speed*factor, unit))
-			lines = append(lines, fmt.Sprintf("P99: %.1f %s", bs.AvgP99Speed*factor, unit))
+			lines = append(lines, speedPercentileTooltipLines(r.c.state, bs, "")...)
 		case "pctl_ipv4":
-			unit, factor := speedUnitNameAndFactor(r.c.state.speedUnit)
 			if bs.IPv4 != nil {
-				lines = append(lines, fmt.Sprintf("P50: %.1f %s", bs.IPv4.AvgP50Speed*factor, unit))
-				lines = append(lines, fmt.Sprintf("P90: %.1f %s", bs.IPv4.AvgP90Speed*factor, unit))
-				lines = append(lines, fmt.Sprintf("P95: %.1f %s", bs.IPv4.AvgP95Speed*factor, unit))
-				lines = append(lines, fmt.Sprintf("P99: %.1f %s", bs.IPv4.AvgP99Speed*factor, unit))
+				lines = append(lines, speedPercentileTooltipLines(r.c.state, bs, "ipv4")...)
 			} else {
 				lines = append(lines, "No IPv4 data")
 			}
 		case "pctl_ipv6":
-			unit, factor := speedUnitNameAndFactor(r.c.state.speedUnit)
 			if bs.IPv6 != nil {
-				lines = append(lines, fmt.Sprintf("P50: %.1f %s", bs.IPv6.AvgP50Speed*factor, unit))
-				lines = append(lines, fmt.Sprintf("P90: %.1f %s", bs.IPv6.AvgP90Speed*factor, unit))
-				lines = append(lines, fmt.Sprintf("P95: %.1f %s", bs.IPv6.AvgP95Speed*factor, unit))
-				lines = append(lines, fmt.Sprintf("P99: %.1f %s", bs.IPv6.AvgP99Speed*factor, unit))
+				lines = append(lines, speedPercentileTooltipLines(r.c.state, bs, "ipv6")...)
 			} else {
 				lines = append(lines, "No IPv6 data")
 			}
@@ -17918,7 +21362,7 @@ func (r *crosshairRenderer) Layout(size fyne.Size) {
 				lines = append(lines, fmt.Sprintf("IPv6: %.2f", bs.IPv6.AvgP99P50Ratio))
 			}
 		case "speed_delta":
-			unit, factor := speedUnitNameAndFactor(r.c.state.speedUnit)
+			unit, factor := speedUnitFor(r.c.state)
 			if bs.IPv4 != nil && bs.IPv6 != nil {
 				lines = append(lines, fmt.Sprintf("IPv6−IPv4: %.2f %s", (bs.IPv6.AvgSpeed-bs.IPv4.AvgSpeed)*factor, unit))
 			} else {
@@ -17996,7 +21440,7 @@ func (r *crosshairRenderer) Layout(size fyne.Size) {
 		case "detailed_speed_over_time":
 			// Single-series focus: pick series whose value at this time is closest to mouse Y position.
 			if r.c.state != nil && r.c.state.detailedSpeedXMaxSec > 0 && len(r.c.state.detailedSpeedSeriesData) > 0 && r.c.state.detailedSpeedYMax > 0 {
-				unit, _ := speedUnitNameAndFactor(r.c.state.speedUnit)
+				unit, _ := speedUnitFor(r.c.state)
 				sec := projectLineXToDomain(lineX, drawX, drawW, r.c.state.detailedSpeedXMaxSec)
 				lines = append(lines, fmt.Sprintf("t=%.2fs", sec))
 				// Map mouse Y -> domain (invert because screen Y grows downward)
@@ -18109,6 +21553,13 @@ func (r *crosshairRenderer) Layout(size fyne.Size) {
 			if r.c.state.showIPv6 && bs.IPv6 != nil {
 				lines = append(lines, fmt.Sprintf("IPv6: %.0f ms", bs.IPv6.AvgDNSMs))
 			}
+			if bs.DNSFamilyLines > 0 {
+				lines = append(lines, fmt.Sprintf("A lookup: %.0f ms (P95 %.0f, %.1f%% failed)", bs.AvgDNSAMs, bs.P95DNSAMs, bs.DNSAErrorRatePct))
+				lines = append(lines, fmt.Sprintf("AAAA lookup: %.0f ms (P95 %.0f, %.1f%% failed)", bs.AvgDNSAAAAMs, bs.P95DNSAAAAMs, bs.DNSAAAAErrorRatePct))
+			}
+			if bs.DNSWithinTTLLookups > 0 {
+				lines = append(lines, fmt.Sprintf("Cache hits within TTL: %.0f%% (re-queried avg %.0f ms)", bs.DNSCacheHitRatePct, bs.AvgDNSRequeryMs))
+			}
 		case "setup_conn":
 			if r.c.state.showOverall {
 				lines = append(lines, fmt.Sprintf("Overall: %.0f ms", bs.AvgConnectMs))
@@ -18144,12 +21595,28 @@ func (r *crosshairRenderer) Layout(size fyne.Size) {
 				v := bs.HTTPProtocolRatePct[k]
 				lines = append(lines, fmt.Sprintf("%s: %.1f%%", k, v))
 			}
+		case "protocol_ttfb":
+			if len(bs.AvgTTFBByHTTPProtocolMs) == 0 {
+				lines = append(lines, "No protocol TTFB data")
+				break
+			}
+			keys := make([]string, 0, len(bs.AvgTTFBByHTTPProtocolMs))
+			for k := range bs.AvgTTFBByHTTPProtocolMs {
+				if r.c.state.hideUnknownProtocols && k == "(unknown)" {
+					continue
+				}
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				lines = append(lines, fmt.Sprintf("%s: avg %.0f ms, P50 %.0f ms, P95 %.0f ms", k, bs.AvgTTFBByHTTPProtocolMs[k], bs.P50TTFBByHTTPProtocolMs[k], bs.P95TTFBByHTTPProtocolMs[k]))
+			}
 		case "protocol_avg_speed":
 			if len(bs.AvgSpeedByHTTPProtocolKbps) == 0 {
 				lines = append(lines, "No protocol data")
 				break
 			}
-			unit, factor := speedUnitNameAndFactor(r.c.state.speedUnit)
+			unit, factor := speedUnitFor(r.c.state)
 			keys := make([]string, 0, len(bs.AvgSpeedByHTTPProtocolKbps))
 			for k := range bs.AvgSpeedByHTTPProtocolKbps {
 				keys = append(keys, k)
@@ -18200,6 +21667,9 @@ func (r *crosshairRenderer) Layout(size fyne.Size) {
 			for _, k := range keys {
 				lines = append(lines, fmt.Sprintf("%s: %.1f%%", k, bs.ErrorShareByTypePct[k]))
 			}
+			if bs.RateLimitedLines > 0 {
+				lines = append(lines, fmt.Sprintf("Rate limited: %d lines (%.1f%%), avg Retry-After %.0f s", bs.RateLimitedLines, bs.RateLimitedRatePct, bs.AvgRetryAfterSec))
+			}
 		case "error_reasons":
 			if len(bs.ErrorShareByReasonPct) == 0 {
 				lines = append(lines, "No error data")
@@ -18252,18 +21722,169 @@ func (r *crosshairRenderer) Layout(size fyne.Size) {
 			for _, k := range keys {
 				lines = append(lines, fmt.Sprintf("%s: %.1f%%", k, bs.ALPNRatePct[k]))
 			}
+		case "wifi_rssi":
+			unit, factor := speedUnitFor(r.c.state)
+			if bs.AvgWiFiRSSIDBm != 0 {
+				lines = append(lines, fmt.Sprintf("RSSI: %.0f dBm", bs.AvgWiFiRSSIDBm))
+			} else {
+				lines = append(lines, "RSSI: n/a")
+			}
+			if bs.WiFiSSID != "" {
+				lines = append(lines, fmt.Sprintf("SSID: %s (ch %d)", bs.WiFiSSID, bs.WiFiChannel))
+			}
+			lines = append(lines, fmt.Sprintf("Throughput: %.1f %s", bs.AvgSpeed*factor, unit))
+		case "wifi_phy_rate":
+			unit, factor := speedUnitFor(r.c.state)
+			if bs.AvgWiFiPHYRateMbps > 0 {
+				lines = append(lines, fmt.Sprintf("PHY rate: %.0f Mbps", bs.AvgWiFiPHYRateMbps))
+			} else {
+				lines = append(lines, "PHY rate: n/a")
+			}
+			lines = append(lines, fmt.Sprintf("Throughput: %.1f %s", bs.AvgSpeed*factor, unit))
+		case "content_corruption_rate":
+			if bs.IntegrityCheckedLines > 0 {
+				lines = append(lines, fmt.Sprintf("Overall: %.1f%% of %d checked", bs.ContentCorruptionRatePct, bs.IntegrityCheckedLines))
+				if r.c.state.showIPv4 && bs.IPv4 != nil && bs.IPv4.IntegrityCheckedLines > 0 {
+					lines = append(lines, fmt.Sprintf("IPv4: %.1f%% of %d", bs.IPv4.ContentCorruptionRatePct, bs.IPv4.IntegrityCheckedLines))
+				}
+				if r.c.state.showIPv6 && bs.IPv6 != nil && bs.IPv6.IntegrityCheckedLines > 0 {
+					lines = append(lines, fmt.Sprintf("IPv6: %.1f%% of %d", bs.IPv6.ContentCorruptionRatePct, bs.IPv6.IntegrityCheckedLines))
+				}
+			} else {
+				lines = append(lines, "No integrity checks (no sha256 configured)")
+			}
+		case "data_usage":
+			if bs.WireRxBytes+bs.WireTxBytes > 0 {
+				lines = append(lines, fmt.Sprintf("Batch: %.1f MB down, %.1f MB up", float64(bs.WireRxBytes)/1e6, float64(bs.WireTxBytes)/1e6))
+			} else {
+				lines = append(lines, "Batch: no byte counts (older monitor)")
+			}
+			if bs.UsageDay != "" {
+				lines = append(lines, fmt.Sprintf("Day %s: %.1f MB", bs.UsageDay, float64(bs.DayRxBytes+bs.DayTxBytes)/1e6))
+			}
+			if bs.BudgetBytes > 0 {
+				used := bs.CycleRxBytes + bs.CycleTxBytes
+				line := fmt.Sprintf("Budget: %.2f of %.2f GB (%.0f%%)", float64(used)/1e9, float64(bs.BudgetBytes)/1e9, 100*float64(used)/float64(bs.BudgetBytes))
+				if bs.BudgetAction != "" {
+					line += ", " + bs.BudgetAction
+				}
+				lines = append(lines, line)
+			}
+		case "cold_warm_ttfb":
+			if bs.ReuseExperimentLines > 0 {
+				lines = append(lines, fmt.Sprintf("Cold: %.0f ms", bs.AvgColdTTFBMs), fmt.Sprintf("Warm: %.0f ms", bs.AvgWarmTTFBMs), fmt.Sprintf("Setup cost: avg %.0f ms, P50 %.0f ms (%d pairs)", bs.AvgSetupCostMs, bs.P50SetupCostMs, bs.ReuseExperimentLines))
+				if bs.IPv4 != nil && bs.IPv4.AvgColdTTFBMs > 0 {
+					lines = append(lines, fmt.Sprintf("IPv4: %.0f / %.0f ms", bs.IPv4.AvgColdTTFBMs, bs.IPv4.AvgWarmTTFBMs))
+				}
+				if bs.IPv6 != nil && bs.IPv6.AvgColdTTFBMs > 0 {
+					lines = append(lines, fmt.Sprintf("IPv6: %.0f / %.0f ms", bs.IPv6.AvgColdTTFBMs, bs.IPv6.AvgWarmTTFBMs))
+				}
+			} else {
+				lines = append(lines, "No reuse experiment (--reuse-experiment off)")
+			}
+		case "return_hops":
+			lines = append(lines, returnHopsLines(bs.ReturnHops)...)
+		case "compression_ratio":
+			lines = append(lines, compressionLines(bs.Compression)...)
+		case "server_timing":
+			lines = append(lines, serverTimingLines(bs.ServerTiming)...)
+		case "tcp_retrans":
+			lines = append(lines, tcpRetransLines(bs.TCPStats)...)
+		case "iperf3_capacity":
+			lines = append(lines, iperf3CapacityLines(bs)...)
+		case "udp_blocked_rate":
+			if bs.QUICProbeLines > 0 || bs.QUICNoListenerLines > 0 {
+				lines = append(lines, fmt.Sprintf("UDP blocked: %.1f%% of %d probes of h3-advertising sites", bs.UDPBlockedRatePct, bs.QUICProbeLines))
+				if bs.QUICNoListenerLines > 0 {
+					lines = append(lines, fmt.Sprintf("Unanswered at sites without h3 (not counted): %d", bs.QUICNoListenerLines))
+				}
+				if bs.IPv4 != nil && bs.IPv4.QUICProbeLines > 0 {
+					lines = append(lines, fmt.Sprintf("IPv4: %.1f%%", bs.IPv4.UDPBlockedRatePct))
+				}
+				if bs.IPv6 != nil && bs.IPv6.QUICProbeLines > 0 {
+					lines = append(lines, fmt.Sprintf("IPv6: %.1f%%", bs.IPv6.UDPBlockedRatePct))
+				}
+			} else {
+				lines = append(lines, "No QUIC probes (--quic-probe off)")
+			}
+		case "quality_score":
+			if q := bs.QualityScore; q != nil {
+				lines = append(lines, fmt.Sprintf("Score: %.0f / 100", q.Score))
+				lines = append(lines, fmt.Sprintf("Speed: %.0f  TTFB: %.0f", q.SpeedPct, q.TTFBPct))
+				if q.TTFBPct > 0 {
+					lines = append(lines, fmt.Sprintf("Jitter: %.0f  Stalls: %.0f  Errors: %.0f", q.JitterPct, q.StallPct, q.ErrorPct))
+				} else {
+					lines = append(lines, fmt.Sprintf("Stalls: %.0f  Errors: %.0f (no successful transfer)", q.StallPct, q.ErrorPct))
+				}
+			} else {
+				lines = append(lines, "No HTTP lines")
+			}
+		case "plan_attainment":
+			plan := r.c.state.ispPlan
+			if pct, ok := analysis.PlanAttainment(bs, plan); ok {
+				lines = append(lines, fmt.Sprintf("Median: %.1f Mbps = %.0f%% of %s", pct/100*plan.DownKbps/1000, pct, planSummary(plan)))
+				if plan.MinDownKbps > 0 && pct < plan.MinDownKbps/plan.DownKbps*100 {
+					lines = append(lines, "Below the contractual minimum")
+				}
+			} else if !plan.Enabled() {
+				lines = append(lines, "No plan set (Settings → ISP Plan…)")
+			} else {
+				lines = append(lines, "No successful transfer")
+			}
+		case "throughput_stability":
+			if st := bs.Stability; st != nil {
+				lines = append(lines, fmt.Sprintf("Index: %.0f / 100", st.Index))
+				lines = append(lines, fmt.Sprintf("P10: %.0f%%  P20: %.0f%% of median %.1f Mbps", st.P10MedianPct, st.P20MedianPct, st.MedianKbps/1000))
+				lines = append(lines, fmt.Sprintf("Within ±20%%: %.0f%% of %d transfers", st.Within20Pct, st.Tests))
+			} else {
+				lines = append(lines, fmt.Sprintf("Fewer than %d successful transfers", analysis.StabilityMinTests))
+			}
+		case "ipv6_readiness":
+			if rd := bs.IPv6Readiness; rd != nil {
+				lines = append(lines, fmt.Sprintf("Score: %.0f / 100", rd.Score))
+				lines = append(lines, fmt.Sprintf("AAAA: %.0f%%  IPv6 success: %.0f%%", rd.AAAAPct, rd.SuccessPct))
+				lines = append(lines, fmt.Sprintf("Speed vs IPv4: %.0f%%  TTFB vs IPv4: %.0f%%", rd.SpeedPct, rd.TTFBPct))
+				if rd.UDPPct >= 0 {
+					lines = append(lines, fmt.Sprintf("UDP over IPv6: %.0f%%", rd.UDPPct))
+				}
+			} else {
+				lines = append(lines, "No IPv6 information")
+			}
+		case "happy_eyeballs_ipv6_lost":
+			if bs.HappyEyeballsRaces > 0 {
+				lines = append(lines, fmt.Sprintf("IPv6 lost: %.1f%% of %d races", bs.HappyEyeballsIPv6LostPct, bs.HappyEyeballsRaces))
+				if bs.AvgHappyEyeballsWinnerMs > 0 {
+					lines = append(lines, fmt.Sprintf("Avg winner connect: %.0f ms", bs.AvgHappyEyeballsWinnerMs))
+				}
+				if bs.AvgHappyEyeballsIPv6LoserMs > 0 {
+					lines = append(lines, fmt.Sprintf("Avg losing IPv6 attempt: %.0f ms", bs.AvgHappyEyeballsIPv6LoserMs))
+				}
+			} else {
+				lines = append(lines, "No races (single-family targets)")
+			}
 		case "chunked_rate":
 			lines = append(lines, fmt.Sprintf("Chunked: %.1f%%", bs.ChunkedRatePct))
 		case "selftest_speed":
-			unit, factor := speedUnitNameAndFactor(r.c.state.speedUnit)
+			unit, factor := speedUnitFor(r.c.state)
 			if bs.LocalSelfTestKbps > 0 {
 				lines = append(lines, fmt.Sprintf("Baseline: %.1f %s", bs.LocalSelfTestKbps*factor, unit))
 			} else {
 				lines = append(lines, "Baseline: n/a")
 			}
 		}
+		if r.c.state.showConfigMarkers && len(bs.ConfigChanges) > 0 && !strings.HasPrefix(r.c.mode, "detailed_") {
+			lines = append(lines, "Config changed: "+strings.Join(bs.ConfigChanges, ", "))
+		}
+		r.c.readoutTag, r.c.readout = bs.RunTag, lines
+		if !strings.HasPrefix(r.c.mode, "detailed_") && len(lines) > 0 {
+			r.c.readout = lines[1:]
+		}
+		if r.c.copied {
+			lines = append(lines, "(copied to clipboard)")
+		}
 		r.label.Segments = []widget.RichTextSegment{&widget.TextSegment{Text: strings.Join(lines, "\n")}}
 	} else {
+		r.c.readoutTag, r.c.readout = "", nil
 		r.label.Segments = nil
 	}
 	r.label.Refresh()
@@ -18289,6 +21910,16 @@ func (r *crosshairRenderer) Layout(size fyne.Size) {
 		r.label.Move(fyne.NewPos(tx+pad, ty+pad))
 	}
 }
+
+// hideMarks moves every mark out of view.
+func (r *crosshairRenderer) hideMarks() {
+	r.lineV.Position1, r.lineV.Position2 = fyne.NewPos(-10, -10), fyne.NewPos(-10, -10)
+	r.lineH.Position1, r.lineH.Position2 = fyne.NewPos(-10, -10), fyne.NewPos(-10, -10)
+	r.dot.Move(fyne.NewPos(-10, -10))
+	r.labelBG.Resize(fyne.NewSize(0, 0))
+	r.labelBG.Move(fyne.NewPos(-1000, -1000))
+	r.label.Move(fyne.NewPos(-1000, -1000))
+}
 func (r *crosshairRenderer) MinSize() fyne.Size           { return fyne.NewSize(10, 10) }
 func (r *crosshairRenderer) Objects() []fyne.CanvasObject { return r.objs }
 func (r *crosshairRenderer) Refresh() {
@@ -18298,6 +21929,8 @@ func (r *crosshairRenderer) Refresh() {
 	if r.bg != nil {
 		r.bg.Refresh()
 	}
+	r.band.FillColor = hoverBandColor()
+	r.band.Refresh()
 	// Update colors to match theme each refresh
 	r.lineV.StrokeColor = theme.Color(theme.ColorNameDisabled)
 	r.lineV.StrokeWidth = 1
@@ -18354,10 +21987,15 @@ func (c *crosshairOverlay) MouseMoved(ev *desktop.MouseEvent) {
 	}
 	c.hovering = true
 	c.mouse = ev.Position
+	c.copied = false
 	c.Refresh()
 }
 func (c *crosshairOverlay) MouseIn(ev *desktop.MouseEvent) { c.hovering = true; c.Refresh() }
-func (c *crosshairOverlay) MouseOut()                      { c.hovering = false; c.Refresh() }
+func (c *crosshairOverlay) MouseOut() {
+	c.hovering = false
+	setHoverBatch(c.state, c, "")
+	c.Refresh()
+}
 
 // Assert that crosshairOverlay implements desktop.Hoverable
 var _ desktop.Hoverable = (*crosshairOverlay)(nil)
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/iafilius/InternetQualityMonitor/src/analysis"
+)
+
+// Mini dashboard (File → "Mini Dashboard") and menu bar status (File → "Menu Bar Status"): the
+// newest filtered batch's median speed, TTFB and stall rate in a small always-on-top window and
+// in the system tray menu, with a green/amber/red state from its quality score (the Quality
+// Score chart's bands). Both refresh with every redraw, so in Follow mode they track the monitor
+// while the main window stays in the background.
+
+// Quality score bands, as drawn on the Quality Score chart.
+const (
+	qualityGoodScore = 80
+	qualityFairScore = 50
+)
+
+// miniStatus is the headline of one batch.
+type miniStatus struct {
+	RunTag string
+	Speed  string
+	TTFB   string
+	Stall  string
+	Score  string
+	Level  string // good, fair, poor or unknown (no quality score)
+}
+
+// miniStatusFor summarizes the newest of rows; speeds are converted with factor into unitName.
+// ok is false without batches.
+func miniStatusFor(rows []analysis.BatchSummary, unitName string, factor float64) (miniStatus, bool) {
+	if len(rows) == 0 {
+		return miniStatus{}, false
+	}
+	bs := rows[len(rows)-1]
+	ms := miniStatus{RunTag: bs.RunTag, Speed: "—", TTFB: "—", Stall: fmt.Sprintf("%.1f%%", bs.StallRatePct), Score: "—", Level: "unknown"}
+	if bs.MedianSpeed > 0 {
+		ms.Speed = fmt.Sprintf("%.1f %s", bs.MedianSpeed*factor, unitName)
+	}
+	ttfb := bs.AvgP50TTFBMs
+	if ttfb <= 0 {
+		ttfb = bs.AvgTTFB
+	}
+	if ttfb > 0 {
+		ms.TTFB = fmt.Sprintf("%.0f ms", ttfb)
+	}
+	if q := bs.QualityScore; q != nil {
+		ms.Score = fmt.Sprintf("%.0f", q.Score)
+		switch {
+		case q.Score >= qualityGoodScore:
+			ms.Level = "good"
+		case q.Score >= qualityFairScore:
+			ms.Level = "fair"
+		default:
+			ms.Level = "poor"
+		}
+	}
+	return ms, true
+}
+
+func miniLevelColor(level string) color.NRGBA {
+	switch level {
+	case "good":
+		return color.NRGBA{R: 46, G: 160, B: 67, A: 255}
+	case "fair":
+		return color.NRGBA{R: 230, G: 160, B: 20, A: 255}
+	case "poor":
+		return color.NRGBA{R: 215, G: 50, B: 40, A: 255}
+	}
+	return color.NRGBA{R: 140, G: 140, B: 140, A: 255}
+}
+
+// miniView is the content of the mini window.
+type miniView struct {
+	dot                *canvas.Circle
+	speed, ttfb, stall *canvas.Text
+	caption            *widget.Label
+}
+
+// openMiniDashboard shows the mini window, or brings it to the front when it is already open.
+func openMiniDashboard(state *uiState) {
+	if state == nil || state.app == nil {
+		return
+	}
+	if state.miniWin != nil {
+		state.miniWin.RequestFocus()
+		return
+	}
+	w := state.app.NewWindow("IQM Status")
+	big := func() *canvas.Text {
+		t := canvas.NewText("—", nil)
+		t.TextSize = 18
+		t.TextStyle = fyne.TextStyle{Bold: true}
+		return t
+	}
+	v := &miniView{dot: canvas.NewCircle(miniLevelColor("unknown")), speed: big(), ttfb: big(), stall: big(), caption: widget.NewLabel("")}
+	dot := container.NewGridWrap(fyne.NewSize(18, 18), v.dot)
+	row := func(name string, t *canvas.Text) fyne.CanvasObject {
+		return container.NewHBox(widget.NewLabel(name), t)
+	}
+	open := widget.NewButton("Open Viewer", func() {
+		state.window.Show()
+		state.window.RequestFocus()
+	})
+	w.SetContent(container.NewVBox(
+		container.NewHBox(container.NewCenter(dot), v.caption),
+		row("Speed", v.speed), row("TTFB", v.ttfb), row("Stalls", v.stall),
+		open,
+	))
+	if dw, ok := w.(desktop.Window); ok {
+		dw.RequestAlwaysOnTop()
+	}
+	w.SetFixedSize(true)
+	w.SetOnClosed(func() { state.miniWin, state.miniView = nil, nil })
+	state.miniWin, state.miniView = w, v
+	refreshMiniStatus(state)
+	w.Show()
+}
+
+// refreshMiniStatus updates the mini window and the tray menu, whichever is on.
+func refreshMiniStatus(state *uiState) {
+	if state == nil || (state.miniView == nil && !state.trayStatus && !state.trayStarted) {
+		return
+	}
+	unitName, factor := speedUnitFor(state)
+	ms, ok := miniStatusFor(filteredSummaries(state), unitName, factor)
+	if v := state.miniView; v != nil {
+		v.dot.FillColor = miniLevelColor(ms.Level)
+		v.dot.Refresh()
+		v.speed.Text, v.ttfb.Text, v.stall.Text = ms.Speed, ms.TTFB, ms.Stall
+		for _, t := range []*canvas.Text{v.speed, v.ttfb, v.stall} {
+			t.Refresh()
+		}
+		if ok {
+			v.caption.SetText(fmt.Sprintf("Score %s · %s", ms.Score, ms.RunTag))
+		} else {
+			v.caption.SetText("No batches loaded")
+		}
+	}
+	updateTrayStatus(state, ms, ok)
+}
+
+// updateTrayStatus puts the status into the system tray menu and colors its icon. The tray cannot
+// be removed again at runtime, so after switching it off it only says so until the next start.
+func updateTrayStatus(state *uiState, ms miniStatus, ok bool) {
+	desk, isDesk := state.app.(desktop.App)
+	if !isDesk || (!state.trayStatus && !state.trayStarted) {
+		return
+	}
+	state.trayStarted = true
+	if !state.trayStatus {
+		desk.SetSystemTrayMenu(fyne.NewMenu("IQM", &fyne.MenuItem{Label: "Menu bar status off (gone after restart)", Disabled: true}))
+		return
+	}
+	info := func(label string) *fyne.MenuItem { return &fyne.MenuItem{Label: label, Disabled: true} }
+	var items []*fyne.MenuItem
+	if ok {
+		items = append(items, info("Speed: "+ms.Speed), info("TTFB: "+ms.TTFB), info("Stalls: "+ms.Stall), info("Score: "+ms.Score+" ("+ms.Level+") · "+ms.RunTag))
+	} else {
+		items = append(items, info("No batches loaded"))
+	}
+	items = append(items, fyne.NewMenuItemSeparator(),
+		fyne.NewMenuItem("Show Viewer", func() { state.window.Show(); state.window.RequestFocus() }),
+		fyne.NewMenuItem("Mini Dashboard", func() { openMiniDashboard(state) }),
+	)
+	desk.SetSystemTrayMenu(fyne.NewMenu("IQM", items...))
+	desk.SetSystemTrayIcon(trayIcon(ms.Level))
+}
+
+// trayIcons caches one icon per level.
+var trayIcons = map[string]fyne.Resource{}
+
+// trayIcon is a filled circle in the level's color.
+func trayIcon(level string) fyne.Resource {
+	if r, ok := trayIcons[level]; ok {
+		return r
+	}
+	const size = 32
+	img := image.NewNRGBA(image.Rect(0, 0, size, size))
+	c := miniLevelColor(level)
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			dx, dy := float64(x)-size/2+0.5, float64(y)-size/2+0.5
+			if dx*dx+dy*dy <= (size/2-2)*(size/2-2) {
+				img.SetNRGBA(x, y, c)
+			}
+		}
+	}
+	var buf bytes.Buffer
+	_ = png.Encode(&buf, img)
+	r := fyne.NewStaticResource("iqm-status-"+level+".png", buf.Bytes())
+	trayIcons[level] = r
+	return r
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/iafilius/InternetQualityMonitor/src/analysis"
+)
+
+func TestMiniStatusFor(t *testing.T) {
+	if _, ok := miniStatusFor(nil, "Mbps", 1.0/1000.0); ok {
+		t.Fatalf("no batches: not ok")
+	}
+	rows := []analysis.BatchSummary{
+		{RunTag: "r1", MedianSpeed: 90000, AvgTTFB: 100, QualityScore: &analysis.QualityScore{Score: 90}},
+		{RunTag: "r2", MedianSpeed: 45500, AvgTTFB: 240, AvgP50TTFBMs: 180, StallRatePct: 2.5, QualityScore: &analysis.QualityScore{Score: 62}},
+	}
+	ms, ok := miniStatusFor(rows, "Mbps", 1.0/1000.0)
+	if !ok || ms.RunTag != "r2" || ms.Speed != "45.5 Mbps" || ms.TTFB != "180 ms" || ms.Stall != "2.5%" || ms.Score != "62" || ms.Level != "fair" {
+		t.Fatalf("newest batch: %+v", ms)
+	}
+	for score, want := range map[float64]string{80: "good", 49.9: "poor"} {
+		rows[1].QualityScore.Score = score
+		if ms, _ := miniStatusFor(rows, "Mbps", 1); ms.Level != want {
+			t.Fatalf("score %.1f: level %s, want %s", score, ms.Level, want)
+		}
+	}
+	rows[1].QualityScore, rows[1].MedianSpeed = nil, 0
+	if ms, _ := miniStatusFor(rows, "Mbps", 1); ms.Level != "unknown" || ms.Speed != "—" {
+		t.Fatalf("no score/speed: %+v", ms)
+	}
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Getting Started wizard: on a first start without data the viewer would otherwise open on empty
+// charts. Three steps pick or create a results file, optionally run a first monitor batch into
+// it, and explain Situations and the thresholds the charts judge batches by. It shows once
+// (preference "onboardingDone") and stays available under File → Getting Started….
+
+const defaultFirstSituation = "Home"
+
+// onboardingNeeded reports whether the wizard opens by itself at startup.
+func onboardingNeeded(p fyne.Preferences, state *uiState) bool {
+	return !p.Bool("onboardingDone") && len(state.summaries) == 0
+}
+
+// firstBatchArgs are the monitor arguments of the wizard's first batch: one batch into outFile,
+// labeled with situation.
+func firstBatchArgs(m monitorSettings, outFile, situation string) []string {
+	args := monitorBatchArgs(m, outFile)
+	if s := strings.TrimSpace(situation); s != "" {
+		args = append(args, "--situation", s)
+	}
+	return args
+}
+
+// onboardingThresholdsText explains Situations and the current thresholds.
+func onboardingThresholdsText(state *uiState) string {
+	var b strings.Builder
+	b.WriteString("Situations: every batch carries the --situation label it was measured in (Home, Office, VPN, Travel…). ")
+	b.WriteString("The Situation selector above the charts shows one of them or All, so measurements from different networks are not mixed up. ")
+	b.WriteString("Run the monitor with a different --situation whenever you change networks.\n\n")
+	b.WriteString("Thresholds decide when a batch counts as good; change them under Settings → Thresholds:\n")
+	fmt.Fprintf(&b, "  • SLA: median speed ≥ %d kbps and P95 TTFB ≤ %d ms (SLA charts and the compliance report)\n", state.slaSpeedThresholdKbps, state.slaTTFBThresholdMs)
+	fmt.Fprintf(&b, "  • Low speed: below %d kbps counts as low-speed time\n", state.lowSpeedThresholdKbps)
+	b.WriteString("Settings → ISP Plan… compares the measured speeds with what you pay for.")
+	return b.String()
+}
+
+// openOnboardingWizard shows the Getting Started wizard.
+func openOnboardingWizard(state *uiState, fileLabel *widget.Label) {
+	if state == nil || state.window == nil {
+		return
+	}
+	prefs := state.app.Preferences()
+	var d *dialog.CustomDialog
+
+	// Step 1: results file
+	fileStatus := widget.NewLabel("")
+	fileStatus.Wrapping = fyne.TextWrapWord
+	showFile := func() {
+		if state.filePath == "" {
+			fileStatus.SetText("No results file yet.")
+			return
+		}
+		fileStatus.SetText(fmt.Sprintf("Results file: %s (%d batches)", truncatePath(state.filePath, 60), len(state.summaries)))
+	}
+	useFile := func(path string) {
+		state.filePath = path
+		fileLabel.SetText(truncatePath(state.filePath, 60))
+		addRecentFile(state, state.filePath)
+		savePrefs(state)
+		showFile()
+		loadAllThen(state, fileLabel, showFile)
+	}
+	openBtn := widget.NewButton("Open Existing…", func() {
+		dialog.ShowFileOpen(func(rc fyne.URIReadCloser, err error) {
+			if err != nil || rc == nil {
+				return
+			}
+			rc.Close()
+			useFile(rc.URI().Path())
+		}, state.window)
+	})
+	createBtn := widget.NewButton("Create New…", func() {
+		fs := dialog.NewFileSave(func(wc fyne.URIWriteCloser, err error) {
+			if err != nil || wc == nil {
+				return
+			}
+			if err := wc.Close(); err != nil {
+				dialog.ShowError(err, state.window)
+				return
+			}
+			useFile(wc.URI().Path())
+		}, state.window)
+		fs.SetFileName("monitor_results.jsonl")
+		fs.SetFilter(storage.NewExtensionFileFilter([]string{".jsonl"}))
+		fs.Show()
+	})
+	fileIntro := widget.NewLabel("The monitor appends one JSON line per measured site and IP to a results file (monitor_results.jsonl by default); the viewer charts its batches. Open an existing file, or create an empty one for a first batch.")
+	fileIntro.Wrapping = fyne.TextWrapWord
+	step1 := container.NewVBox(fileIntro, container.NewHBox(openBtn, createBtn), fileStatus)
+
+	// Step 2: first batch
+	binEntry := widget.NewEntry()
+	binEntry.SetPlaceHolder("/usr/local/bin/iqm")
+	binEntry.SetText(state.monitor.binary)
+	sitesEntry := widget.NewEntry()
+	sitesEntry.SetPlaceHolder("./sites.jsonc")
+	sitesEntry.SetText(state.monitor.sites)
+	situationEntry := widget.NewEntry()
+	situationEntry.SetText(defaultFirstSituation)
+	batchStatus := widget.NewLabel("")
+	batchStatus.Wrapping = fyne.TextWrapWord
+	runBtn := widget.NewButton("Run First Batch", nil)
+	runBtn.OnTapped = func() {
+		if state.batchRunning {
+			return
+		}
+		if state.monitorDaemon {
+			batchStatus.SetText("Asked the monitor daemon for a batch; the charts reload when it is done.")
+			runBatchNow(state, fileLabel)
+			return
+		}
+		m := state.monitor
+		m.binary, m.sites = strings.TrimSpace(binEntry.Text), strings.TrimSpace(sitesEntry.Text)
+		if m.binary == "" {
+			batchStatus.SetText("Enter the path of the monitor binary first.")
+			return
+		}
+		if state.filePath == "" {
+			batchStatus.SetText("Pick or create a results file in the first step; the batch is appended to it.")
+			return
+		}
+		state.monitor = m
+		m.save(prefs)
+		state.batchRunning = true
+		updateRunBatchButton(state)
+		batchStatus.SetText("Batch running… this takes a minute or two; the charts load when it is done.")
+		go runMonitorCommand(state, fileLabel, m.binary, firstBatchArgs(m, state.filePath, situationEntry.Text))
+	}
+	batchIntro := widget.NewLabel("Optionally measure right away: the monitor runs one batch with its defaults over the sites file and appends it to the results file. A monitor daemon (--control-listen) is used instead when one answers. Skip this step if you collect results elsewhere.")
+	batchIntro.Wrapping = fyne.TextWrapWord
+	batchForm := &widget.Form{Items: []*widget.FormItem{
+		{Text: "Monitor binary", Widget: binEntry},
+		{Text: "Sites file", Widget: sitesEntry, HintText: "empty: the monitor's ./sites.jsonc"},
+		{Text: "Situation", Widget: situationEntry, HintText: "where you measure, e.g. Home or Office"},
+	}}
+	step2 := container.NewVBox(batchIntro, batchForm, runBtn, batchStatus)
+
+	// Step 3: Situations and thresholds
+	explain := widget.NewLabel("")
+	explain.Wrapping = fyne.TextWrapWord
+	step3 := container.NewVBox(explain)
+
+	steps := []fyne.CanvasObject{step1, step2, step3}
+	titles := []string{"1 of 3: Results File", "2 of 3: First Batch", "3 of 3: Situations and Thresholds"}
+	stepTitle := widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	body := container.NewStack(steps...)
+	cur := 0
+	backBtn := widget.NewButton("Back", nil)
+	nextBtn := widget.NewButton("Next", nil)
+	nextBtn.Importance = widget.HighImportance
+	show := func(i int) {
+		cur = i
+		for j, s := range steps {
+			if j == i {
+				s.Show()
+			} else {
+				s.Hide()
+			}
+		}
+		stepTitle.SetText(titles[i])
+		if i == 0 {
+			backBtn.Disable()
+		} else {
+			backBtn.Enable()
+		}
+		if i == len(steps)-1 {
+			explain.SetText(onboardingThresholdsText(state))
+			nextBtn.SetText("Finish")
+		} else {
+			nextBtn.SetText("Next")
+		}
+	}
+	backBtn.OnTapped = func() { show(cur - 1) }
+	nextBtn.OnTapped = func() {
+		if cur == len(steps)-1 {
+			d.Hide()
+			return
+		}
+		show(cur + 1)
+	}
+	skipBtn := widget.NewButton("Skip", func() { d.Hide() })
+	showFile()
+	show(0)
+	content := container.NewBorder(stepTitle, container.NewHBox(skipBtn, layout.NewSpacer(), backBtn, nextBtn), nil, nil, body)
+	d = dialog.NewCustomWithoutButtons("Getting Started", content, state.window)
+	d.SetOnClosed(func() { prefs.SetBool("onboardingDone", true) })
+	d.Resize(fyne.NewSize(640, 440))
+	d.Show()
+}
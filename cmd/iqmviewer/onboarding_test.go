@@ -0,0 +1,37 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"fyne.io/fyne/v2/test"
+
+	"github.com/iafilius/InternetQualityMonitor/src/analysis"
+)
+
+func TestOnboarding(t *testing.T) {
+	a := test.NewTempApp(t)
+	s := &uiState{slaSpeedThresholdKbps: 10000, slaTTFBThresholdMs: 300, lowSpeedThresholdKbps: 1000}
+	if !onboardingNeeded(a.Preferences(), s) {
+		t.Fatal("first start without batches should open the wizard")
+	}
+	s.summaries = []analysis.BatchSummary{{RunTag: "20260301_120000"}}
+	if onboardingNeeded(a.Preferences(), s) {
+		t.Fatal("a loaded file needs no wizard")
+	}
+	s.summaries = nil
+	a.Preferences().SetBool("onboardingDone", true)
+	if onboardingNeeded(a.Preferences(), s) {
+		t.Fatal("the wizard shows only once")
+	}
+	args := strings.Join(firstBatchArgs(monitorSettings{sites: "my.jsonc"}, "out.jsonl", " Office "), " ")
+	if args != "--iterations 1 --out out.jsonl --sites my.jsonc --situation Office" {
+		t.Fatalf("first batch args: %s", args)
+	}
+	if args := firstBatchArgs(monitorSettings{}, "out.jsonl", ""); len(args) != 4 {
+		t.Fatalf("no situation, no flag: %v", args)
+	}
+	if txt := onboardingThresholdsText(s); !strings.Contains(txt, "10000 kbps") || !strings.Contains(txt, "300 ms") {
+		t.Fatalf("thresholds text: %s", txt)
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/iafilius/InternetQualityMonitor/src/analysis"
+)
+
+// pruneOldBatches applies a retention period to the loaded results file (monitor --prune-keep):
+// a dry run shows what would go, and only after confirming are the older batches moved to a
+// gzip backup next to the file and the view reloaded.
+func pruneOldBatches(state *uiState, fileLabel *widget.Label) {
+	if state == nil || state.window == nil {
+		return
+	}
+	path := strings.TrimSpace(state.filePath)
+	if path == "" {
+		dialog.ShowInformation("Prune Old Batches", "Open a results file first.", state.window)
+		return
+	}
+	keep := widget.NewEntry()
+	keep.SetText("90d")
+	keep.SetPlaceHolder("e.g. 90d, 12w, 36h")
+	keep.Validator = func(s string) error {
+		_, err := analysis.ParseRetention(s)
+		return err
+	}
+	items := []*widget.FormItem{widget.NewFormItem("Keep", keep)}
+	dialog.ShowForm("Prune Old Batches", "Preview…", "Cancel", items, func(ok bool) {
+		if !ok {
+			return
+		}
+		d, err := analysis.ParseRetention(keep.Text)
+		if err != nil {
+			dialog.ShowError(err, state.window)
+			return
+		}
+		cutoff := time.Now().Add(-d)
+		go func() {
+			rep, err := analysis.PruneResultsFile(path, cutoff, analysis.PruneOptions{DryRun: true})
+			fyne.Do(func() {
+				if err != nil {
+					dialog.ShowError(err, state.window)
+					return
+				}
+				if rep.PrunedBatches == 0 {
+					dialog.ShowInformation("Prune Old Batches", "No batches before "+cutoff.Format("2006-01-02 15:04")+"; nothing to remove.", state.window)
+					return
+				}
+				msg := strings.TrimSpace(strings.ReplaceAll(rep.String(), "[prune] ", "")) +
+					"\n\nThe removed lines are backed up to a .pruned-….jsonl.gz file next to the results file. Prune now?"
+				dialog.ShowConfirm("Prune Old Batches", msg, func(yes bool) {
+					if yes {
+						runPrune(state, fileLabel, path, cutoff)
+					}
+				}, state.window)
+			})
+		}()
+	}, state.window)
+}
+
+func runPrune(state *uiState, fileLabel *widget.Label, path string, cutoff time.Time) {
+	go func() {
+		rep, err := analysis.PruneResultsFile(path, cutoff, analysis.PruneOptions{})
+		fyne.Do(func() {
+			if err != nil {
+				dialog.ShowError(errors.New("Prune failed, the results file is unchanged:\n"+err.Error()), state.window)
+				return
+			}
+			loadAll(state, fileLabel)
+			dialog.ShowInformation("Prune complete", strings.TrimSpace(strings.ReplaceAll(rep.String(), "[prune] ", "")), state.window)
+		})
+	}()
+}
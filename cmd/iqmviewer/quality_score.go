@@ -0,0 +1,89 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/iafilius/InternetQualityMonitor/src/analysis"
+)
+
+// The Quality Score weights and references are kept as an analysis.ParseQualityScoreConfig spec
+// in the "qualityScoreSpec" preference; an empty spec means the defaults.
+
+// applyQualityScorePref sets the analysis quality score config from the saved spec; a spec that
+// no longer parses falls back to the defaults.
+func applyQualityScorePref(p fyne.Preferences) {
+	c, err := analysis.ParseQualityScoreConfig(p.String("qualityScoreSpec"))
+	if err != nil {
+		c = analysis.DefaultQualityScoreConfig
+	}
+	analysis.SetQualityScoreConfig(c)
+}
+
+// qualityScoreFields are the dialog rows: spec key, label and the config value shown.
+var qualityScoreFields = []struct {
+	key, label string
+	get        func(analysis.QualityScoreConfig) float64
+}{
+	{"speed", "Speed weight", func(c analysis.QualityScoreConfig) float64 { return c.SpeedWeight }},
+	{"ttfb", "TTFB weight", func(c analysis.QualityScoreConfig) float64 { return c.TTFBWeight }},
+	{"jitter", "Jitter weight", func(c analysis.QualityScoreConfig) float64 { return c.JitterWeight }},
+	{"stall", "Stall weight", func(c analysis.QualityScoreConfig) float64 { return c.StallWeight }},
+	{"errors", "Error weight", func(c analysis.QualityScoreConfig) float64 { return c.ErrorWeight }},
+	{"speed_ref_kbps", "Full-mark speed (kbps)", func(c analysis.QualityScoreConfig) float64 { return c.SpeedRefKbps }},
+	{"ttfb_ref_ms", "Full-mark TTFB (ms)", func(c analysis.QualityScoreConfig) float64 { return c.TTFBRefMs }},
+	{"jitter_penalty", "Points per % jitter", func(c analysis.QualityScoreConfig) float64 { return c.JitterPenalty }},
+	{"stall_penalty", "Points per % stalls", func(c analysis.QualityScoreConfig) float64 { return c.StallPenalty }},
+	{"error_penalty", "Points per % errors", func(c analysis.QualityScoreConfig) float64 { return c.ErrorPenalty }},
+}
+
+// openQualityScoreDialog edits the score weights; saving re-analyzes the open file.
+func openQualityScoreDialog(state *uiState, fileLabel *widget.Label) {
+	if state == nil || state.window == nil || state.app == nil {
+		return
+	}
+	cur, err := analysis.ParseQualityScoreConfig(state.app.Preferences().String("qualityScoreSpec"))
+	if err != nil {
+		cur = analysis.DefaultQualityScoreConfig
+	}
+	entries := make([]*widget.Entry, len(qualityScoreFields))
+	form := &widget.Form{}
+	setValues := func(c analysis.QualityScoreConfig) {
+		for i, f := range qualityScoreFields {
+			entries[i].SetText(strconv.FormatFloat(f.get(c), 'g', -1, 64))
+		}
+	}
+	for i, f := range qualityScoreFields {
+		entries[i] = widget.NewEntry()
+		form.Append(f.label, entries[i])
+	}
+	setValues(cur)
+	form.Append("", widget.NewButton("Reset to defaults", func() { setValues(analysis.DefaultQualityScoreConfig) }))
+	d := dialog.NewCustomConfirm("Quality Score", "Apply", "Cancel", form, func(ok bool) {
+		if !ok {
+			return
+		}
+		parts := make([]string, len(qualityScoreFields))
+		for i, f := range qualityScoreFields {
+			parts[i] = f.key + "=" + strings.TrimSpace(entries[i].Text)
+		}
+		c, err := analysis.ParseQualityScoreConfig(strings.Join(parts, ","))
+		if err != nil {
+			dialog.ShowError(err, state.window)
+			return
+		}
+		spec := c.String()
+		if c == analysis.DefaultQualityScoreConfig {
+			spec = ""
+		}
+		state.app.Preferences().SetString("qualityScoreSpec", spec)
+		analysis.SetQualityScoreConfig(c)
+		loadAll(state, fileLabel)
+	}, state.window)
+	d.Resize(fyne.NewSize(420, 520))
+	d.Show()
+}
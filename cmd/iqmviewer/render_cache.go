@@ -0,0 +1,439 @@
+package main
+
+import (
+	"fmt"
+	"hash"
+	"hash/fnv"
+	"image"
+	"log/slog"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+
+	"github.com/iafilius/InternetQualityMonitor/cmd/iqmviewer/i18n"
+)
+
+// Chart render cache and async redraw pipeline.
+//
+// redrawCharts used to re-render every chart synchronously on each toggle/resize. Charts are now
+// memoized per chart key together with a fingerprint of their inputs (loaded data, chart size,
+// theme and all option toggles on uiState). Interactive handlers call scheduleRedraw, which
+// coalesces bursts, renders the charts used in the previous pass on a small worker pool into the
+// cache, and then runs redrawCharts on the UI thread where every chart is a cache hit. Canvases
+// whose image did not change are not refreshed.
+
+type renderCacheEntry struct {
+	fp   uint64
+	img  image.Image
+	fn   func(*uiState) image.Image
+	pass int // last redraw pass that used this chart; only these are pre-rendered
+}
+
+type chartRenderCache struct {
+	mu           sync.Mutex
+	entries      map[string]*renderCacheEntry
+	pass         int
+	hits, misses int
+}
+
+func newChartRenderCache() *chartRenderCache {
+	return &chartRenderCache{entries: map[string]*renderCacheEntry{}}
+}
+
+// renderCacheFor returns the state's cache, creating it on first use. Call on the UI thread.
+func renderCacheFor(state *uiState) *chartRenderCache {
+	if state.renderCache == nil {
+		state.renderCache = newChartRenderCache()
+	}
+	return state.renderCache
+}
+
+func (c *chartRenderCache) beginPass() {
+	c.mu.Lock()
+	c.pass++
+	c.hits, c.misses = 0, 0
+	c.mu.Unlock()
+}
+
+func (c *chartRenderCache) lookup(key string, fp uint64, fn func(*uiState) image.Image) (image.Image, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e := c.entries[key]
+	if e == nil {
+		e = &renderCacheEntry{}
+		c.entries[key] = e
+	}
+	e.fn = fn
+	e.pass = c.pass
+	if e.img != nil && e.fp == fp {
+		c.hits++
+		return e.img, true
+	}
+	c.misses++
+	return nil, false
+}
+
+func (c *chartRenderCache) store(key string, fp uint64, img image.Image) {
+	if img == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e := c.entries[key]
+	if e == nil {
+		e = &renderCacheEntry{pass: c.pass}
+		c.entries[key] = e
+	}
+	e.fp, e.img = fp, img
+}
+
+// cachedRender returns the cached image for key when the render inputs are unchanged, otherwise
+// renders via fn and caches the result. Export re-renders (renderWidthOverride, render snapshots)
+// bypass the cache so they don't evict on-screen images.
+func cachedRender(state *uiState, key string, fn func(*uiState) image.Image) image.Image {
+	if state == nil || renderWidthOverride > 0 || state.snapshotSettings != nil {
+		return fn(state)
+	}
+	c := renderCacheFor(state)
+	fp := renderFingerprint(state, key)
+	if img, ok := c.lookup(key, fp, fn); ok {
+		return img
+	}
+	img := fn(state)
+	c.store(key, fp, img)
+	return img
+}
+
+// chartImageChanged reports whether assigning img to c would change what is shown; nil canvases
+// report true so the caller's own nil handling still runs.
+func chartImageChanged(c *canvas.Image, img image.Image) bool {
+	return c == nil || c.Image != img
+}
+
+// fingerprintSkipFields lists uiState fields that never change how a batch chart looks:
+// bookkeeping plus chart visibility (hiding a section must not invalidate the others).
+var fingerprintSkipFields = map[string]bool{
+	"initializing":                 true,
+	"loadingPrefs":                 true,
+	"firstDataLoadDone":            true,
+	"pendingDetailedRebuild":       true,
+	"buildingDetailedCharts":       true,
+	"buildingDetailedPending":      true,
+	"detailedRebuildScheduled":     true,
+	"detailedRebuildCount":         true,
+	"detailedCooldownActive":       true,
+	"detailedQueuedDuringCooldown": true,
+	"redrawInFlight":               true,
+	"redrawQueued":                 true,
+	"snapshotChartW":               true,
+	"snapshotChartH":               true,
+	"snapshotSettings":             true,
+	"hiddenCharts":                 true,
+	"hiddenChartIDs":               true,
+	"customPresets":                true,
+	"exportRespectVisibility":      true,
+	"selectedRow":                  true,
+	"selectedRunTag":               true,
+	"hoverRunTag":                  true,
+	"tableColumns":                 true,
+	"tableSortKey":                 true,
+	"tableSortDesc":                true,
+	"tableOrder":                   true,
+	"tableOrderSig":                true,
+	"chartOrder":                   true,
+	"defaultChartOrder":            true,
+	"trayStatus":                   true,
+	"trayStarted":                  true,
+	"showChartTOC":                 true,
+	"follow":                       true,
+	"alertTripped":                 true,
+	"loadGen":                      true,
+	"loadedPath":                   true,
+	"monitorDaemon":                true,
+	"batchRunning":                 true,
+	"autoSpeedUnit":                true,
+	"autoSpeedUnitKey":             true,
+	"findIndex":                    true,
+	"findMatches":                  true,
+}
+
+// renderSettings are the viewer-wide chart options renderChart applies: trend lines (chartTrend),
+// decimation (chartDecimationEnabled) and the chart appearance (chartLook). They live in globals
+// that menus change on the UI thread; a render snapshot carries a copy, so renderers on worker
+// goroutines never read the globals.
+type renderSettings struct {
+	trend    trendOptions
+	decimate bool
+	look     chartAppearance
+}
+
+// renderSettingsFor returns the settings captured in a render snapshot, else the live globals
+// (UI thread only).
+func renderSettingsFor(state *uiState) renderSettings {
+	if state != nil && state.snapshotSettings != nil {
+		return *state.snapshotSettings
+	}
+	return renderSettings{trend: chartTrend, decimate: chartDecimationEnabled, look: chartLook}
+}
+
+// renderSnapshot copies state for a render of w×h pixels that must not see later UI changes:
+// pre-renders and folder exports on worker goroutines, detached windows at their own width.
+// Scalars are copied by value, the slices and maps the renderers read get fresh copies and the
+// viewer-wide options are captured as renderSettings. The loaded batches are shared: a reload
+// replaces state.summaries, nothing modifies the batches in place. Renderers flip toggles on the
+// state they get, so give each concurrent render its own shallow copy of the snapshot. Call on
+// the UI thread.
+func renderSnapshot(state *uiState, w, h int) *uiState {
+	snap := *state
+	snap.snapshotChartW, snap.snapshotChartH = w, h
+	rs := renderSettingsFor(state)
+	snap.snapshotSettings = &rs
+	snap.situations = append([]string(nil), state.situations...)
+	snap.runTagSituation = copyMap(state.runTagSituation)
+	snap.alertRules = append([]alertRule(nil), state.alertRules...)
+	snap.alertTripped = copyMap(state.alertTripped)
+	snap.findMatches = append([]int(nil), state.findMatches...)
+	snap.hiddenCharts = copyMap(state.hiddenCharts)
+	snap.hiddenChartIDs = copyMap(state.hiddenChartIDs)
+	snap.tableColumns = append([]string(nil), state.tableColumns...)
+	snap.tableOrder = append([]int(nil), state.tableOrder...)
+	snap.chartOrder = append([]string(nil), state.chartOrder...)
+	snap.defaultChartOrder = append([]string(nil), state.defaultChartOrder...)
+	snap.detailedCompareRunTags = append([]string(nil), state.detailedCompareRunTags...)
+	return &snap
+}
+
+func copyMap[K comparable, V any](m map[K]V) map[K]V {
+	if m == nil {
+		return nil
+	}
+	out := make(map[K]V, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
+
+// Per-chart adjustments keyed by renderer name (the part of the cache key before any "/").
+var (
+	// renderIgnoredFields: toggles a renderer overrides itself, so they are not inputs.
+	renderIgnoredFields = map[string][]string{
+		"renderSpeedChartVariant": {"showAvg", "showMedian", "showMin", "showMax"},
+		"renderTTFBChartVariant":  {"showAvg", "showMedian", "showMin", "showMax"},
+	}
+	// renderExtraInputs: inputs excluded globally that this renderer does depend on.
+	renderExtraInputs = map[string]func(*uiState) string{
+		"renderErrorsByURLChart":    func(s *uiState) string { return s.selectedRunTag },
+		"renderPlanAttainmentChart": func(s *uiState) string { return fmt.Sprint(s.ispPlan) },
+	}
+)
+
+// renderFingerprint hashes everything the chart for key depends on: the identity of the loaded
+// summaries, the chart size, the theme, the chart appearance, the trend options and every
+// scalar/string option on uiState (walked by reflection so new toggles are covered without
+// registering them here). Detailed-tab fields are skipped; those charts are rebuilt separately.
+func renderFingerprint(state *uiState, key string) uint64 {
+	base, _, _ := strings.Cut(key, "/")
+	ignored := renderIgnoredFields[base]
+	h := fnv.New64a()
+	w, ht := chartSize(state)
+	rs := renderSettingsFor(state)
+	fmt.Fprintf(h, "%d|%d|%s|%s|%s|%t|%s|", w, ht, screenshotThemeGlobal, rs.look, rs.trend, rs.decimate, i18n.Current())
+	if extra := renderExtraInputs[base]; extra != nil {
+		fmt.Fprintf(h, "%s|", extra(state))
+	}
+	v := reflect.ValueOf(state).Elem()
+	t := v.Type()
+fields:
+	for i := 0; i < t.NumField(); i++ {
+		name := t.Field(i).Name
+		if fingerprintSkipFields[name] || strings.HasPrefix(name, "detailed") {
+			continue
+		}
+		for _, ig := range ignored {
+			if ig == name {
+				continue fields
+			}
+		}
+		hashOptionValue(h, name, v.Field(i))
+	}
+	return h.Sum64()
+}
+
+func hashOptionValue(h hash.Hash64, name string, f reflect.Value) {
+	switch f.Kind() {
+	case reflect.Bool:
+		fmt.Fprintf(h, "%s=%t;", name, f.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		fmt.Fprintf(h, "%s=%d;", name, f.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		fmt.Fprintf(h, "%s=%d;", name, f.Uint())
+	case reflect.Float32, reflect.Float64:
+		fmt.Fprintf(h, "%s=%g;", name, f.Float())
+	case reflect.String:
+		fmt.Fprintf(h, "%s=%q;", name, f.String())
+	case reflect.Slice:
+		switch f.Type().Elem().Kind() {
+		case reflect.String, reflect.Int, reflect.Bool, reflect.Float64:
+			fmt.Fprintf(h, "%s=[", name)
+			for j := 0; j < f.Len(); j++ {
+				fmt.Fprintf(h, "%v,", scalarString(f.Index(j)))
+			}
+			fmt.Fprint(h, "];")
+		default:
+			// Large data slices (summaries): identity is enough, a reload allocates a new slice.
+			if f.Len() > 0 {
+				fmt.Fprintf(h, "%s=%x/%d;", name, f.Pointer(), f.Len())
+			} else {
+				fmt.Fprintf(h, "%s=0;", name)
+			}
+		}
+	case reflect.Map:
+		if f.Type().Key().Kind() != reflect.String {
+			return
+		}
+		keys := make([]string, 0, f.Len())
+		for _, k := range f.MapKeys() {
+			keys = append(keys, k.String())
+		}
+		sort.Strings(keys)
+		fmt.Fprintf(h, "%s={", name)
+		for _, k := range keys {
+			fmt.Fprintf(h, "%s:%s,", k, scalarString(f.MapIndex(reflect.ValueOf(k))))
+		}
+		fmt.Fprint(h, "};")
+	}
+}
+
+func scalarString(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.Bool:
+		return fmt.Sprint(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return fmt.Sprint(v.Int())
+	case reflect.Float32, reflect.Float64:
+		return fmt.Sprint(v.Float())
+	case reflect.String:
+		return v.String()
+	default:
+		return v.Kind().String()
+	}
+}
+
+// renderJob is one chart to pre-render on a worker. Each job renders from its own shallow copy of
+// uiState because some renderers temporarily flip toggles (e.g. the speed/TTFB variants).
+type renderJob struct {
+	key  string
+	fp   uint64
+	fn   func(*uiState) image.Image
+	snap *uiState
+}
+
+// pendingRenderJobs returns jobs for the charts used in the last redraw pass whose cached image
+// is stale. Call on the UI thread.
+func pendingRenderJobs(state *uiState) []renderJob {
+	c := renderCacheFor(state)
+	w, h := chartSize(state)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var jobs []renderJob
+	var base *uiState
+	for key, e := range c.entries {
+		if e.fn == nil || e.pass != c.pass {
+			continue
+		}
+		fp := renderFingerprint(state, key)
+		if e.img != nil && e.fp == fp {
+			continue
+		}
+		if base == nil {
+			base = renderSnapshot(state, w, h)
+		}
+		snap := *base
+		jobs = append(jobs, renderJob{key: key, fp: fp, fn: e.fn, snap: &snap})
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].key < jobs[j].key })
+	return jobs
+}
+
+// renderWorkers bounds pre-render parallelism; go-chart rendering is CPU bound.
+func renderWorkers(n int) int {
+	w := runtime.NumCPU()
+	if w > 4 {
+		w = 4
+	}
+	if w > n {
+		w = n
+	}
+	if w < 1 {
+		w = 1
+	}
+	return w
+}
+
+// prerenderCharts renders jobs concurrently into the cache. A job that panics is dropped; the
+// following redrawCharts renders that chart synchronously instead.
+func prerenderCharts(c *chartRenderCache, jobs []renderJob) {
+	queue := make(chan renderJob)
+	var wg sync.WaitGroup
+	for i := 0; i < renderWorkers(len(jobs)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range queue {
+				func() {
+					defer func() {
+						if r := recover(); r != nil && debugLoggingEnabled {
+							logf(slog.LevelDebug, "viewer", "pre-render %s failed: %v", j.key, r)
+						}
+					}()
+					c.store(j.key, j.fp, j.fn(j.snap))
+				}()
+			}
+		}()
+	}
+	for _, j := range jobs {
+		queue <- j
+	}
+	close(queue)
+	wg.Wait()
+}
+
+// scheduleRedraw is the asynchronous counterpart of redrawCharts for interactive changes
+// (toggles, theme, resize). Requests arriving while a pass is rendering are coalesced into one
+// follow-up pass. Must be called on the UI thread.
+func scheduleRedraw(state *uiState) {
+	if state == nil {
+		return
+	}
+	if state.redrawInFlight {
+		state.redrawQueued = true
+		return
+	}
+	state.redrawInFlight = true
+	c := renderCacheFor(state)
+	updateConfigMarkers(state)
+	refreshAutoSpeedUnit(state)
+	jobs := pendingRenderJobs(state)
+	go func() {
+		start := time.Now()
+		prerenderCharts(c, jobs)
+		fyne.Do(func() {
+			redrawCharts(state)
+			if debugLoggingEnabled {
+				logf(slog.LevelDebug, "viewer", "async redraw: %d charts pre-rendered in %s", len(jobs), time.Since(start).Round(time.Millisecond))
+			}
+			state.redrawInFlight = false
+			if state.redrawQueued {
+				state.redrawQueued = false
+				scheduleRedraw(state)
+			}
+		})
+	}()
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"image"
+	"testing"
+
+	"github.com/iafilius/InternetQualityMonitor/src/analysis"
+)
+
+func TestCachedRender_ReusesImageUntilInputsChange(t *testing.T) {
+	s := &uiState{summaries: []analysis.BatchSummary{{RunTag: "a"}}, xAxisMode: "batch"}
+	calls := 0
+	fn := func(st *uiState) image.Image {
+		calls++
+		return image.NewRGBA(image.Rect(0, 0, 4, 4))
+	}
+	first := cachedRender(s, "test", fn)
+	if again := cachedRender(s, "test", fn); again != first || calls != 1 {
+		t.Fatalf("expected cache hit with same image; calls=%d", calls)
+	}
+	// Visibility changes must not invalidate the cached image.
+	s.hiddenChartIDs = map[string]bool{"dns_lookup": true}
+	if again := cachedRender(s, "test", fn); again != first || calls != 1 {
+		t.Fatalf("visibility change should not re-render; calls=%d", calls)
+	}
+	// Option and data changes must.
+	s.xAxisMode = "time"
+	if again := cachedRender(s, "test", fn); again == first || calls != 2 {
+		t.Fatalf("option change should re-render; calls=%d", calls)
+	}
+	s.summaries = []analysis.BatchSummary{{RunTag: "a"}, {RunTag: "b"}}
+	cachedRender(s, "test", fn)
+	if calls != 3 {
+		t.Fatalf("data reload should re-render; calls=%d", calls)
+	}
+}
+
+func TestRenderFingerprint_PerChartInputs(t *testing.T) {
+	s := &uiState{showAvg: true}
+	avg := renderFingerprint(s, "renderSpeedChartVariant/avg")
+	url := renderFingerprint(s, "renderErrorsByURLChart")
+	s.showAvg = false
+	if renderFingerprint(s, "renderSpeedChartVariant/avg") != avg {
+		t.Fatalf("speed variants override showAvg; it must not be part of their fingerprint")
+	}
+	s.selectedRunTag = "batch-2"
+	if renderFingerprint(s, "renderErrorsByURLChart") == url {
+		t.Fatalf("errors-by-URL depends on the selected batch")
+	}
+}
+
+func TestPrerenderCharts_FillsCacheForLastPass(t *testing.T) {
+	s := &uiState{}
+	renderCacheFor(s).beginPass()
+	fn := func(st *uiState) image.Image {
+		w, h := chartSize(st)
+		return image.NewRGBA(image.Rect(0, 0, w, h))
+	}
+	cachedRender(s, "one", fn)
+	cachedRender(s, "two", fn)
+	s.useRelative = true // invalidate both
+	jobs := pendingRenderJobs(s)
+	if len(jobs) != 2 {
+		t.Fatalf("expected 2 stale jobs, got %d", len(jobs))
+	}
+	prerenderCharts(s.renderCache, jobs)
+	if len(pendingRenderJobs(s)) != 0 {
+		t.Fatalf("expected cache to be warm after pre-render")
+	}
+	w, h := chartSize(s)
+	if img := cachedRender(s, "one", fn); img.Bounds().Dx() != w || img.Bounds().Dy() != h {
+		t.Fatalf("pre-rendered image size %v, want %dx%d", img.Bounds(), w, h)
+	}
+}
+
+func TestRenderSnapshot_IsolatedFromUIChanges(t *testing.T) {
+	prevDecimate, prevTrend := chartDecimationEnabled, chartTrend
+	defer func() { chartDecimationEnabled, chartTrend = prevDecimate, prevTrend }()
+	chartDecimationEnabled, chartTrend = true, trendOptions{Method: "linear", Horizon: 3}
+	s := &uiState{runTagSituation: map[string]string{"a": "Home"}, findMatches: []int{1}}
+	snap := renderSnapshot(s, 900, 300)
+	s.runTagSituation["a"] = "Office"
+	s.findMatches[0] = 2
+	chartDecimationEnabled, chartTrend = false, trendOptions{}
+	if snap.runTagSituation["a"] != "Home" || snap.findMatches[0] != 1 {
+		t.Fatalf("snapshot shares maps or slices with the live state: %v %v", snap.runTagSituation, snap.findMatches)
+	}
+	if rs := renderSettingsFor(snap); !rs.decimate || rs.trend.Method != "linear" {
+		t.Fatalf("snapshot must keep the settings it captured: %+v", rs)
+	}
+	if rs := renderSettingsFor(s); rs.decimate || rs.trend.Method != "" {
+		t.Fatalf("live state reads the globals: %+v", rs)
+	}
+	if w, h := chartSize(snap); w != 900 || h != 300 {
+		t.Fatalf("snapshot size %dx%d", w, h)
+	}
+}
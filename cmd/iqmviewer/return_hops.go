@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"math"
+	"time"
+
+	chart "github.com/wcharczuk/go-chart/v2"
+
+	helpers "github.com/iafilius/InternetQualityMonitor/cmd/iqmviewer/uihelpers"
+	"github.com/iafilius/InternetQualityMonitor/src/analysis"
+)
+
+// renderReturnHopsChart draws BatchSummary.ReturnHops per batch: the average estimated return hop
+// count per family and, as large red dots, the batches in which a target was rerouted. Batches
+// without response TTLs (monitor --response-ttl) are omitted.
+func renderReturnHopsChart(state *uiState) image.Image {
+	cw, chh := chartSize(state)
+	rows := filteredSummaries(state)
+	if len(rows) == 0 {
+		return blank(cw, chh)
+	}
+	timeMode, times, xs, xAxis := buildXAxis(rows, state.xAxisMode)
+	type pts struct {
+		x  []float64
+		t  []time.Time
+		ys []float64
+	}
+	var overall, v4, v6, changed pts
+	add := func(p *pts, i int, y float64) {
+		if timeMode {
+			p.t = append(p.t, times[i])
+		} else {
+			p.x = append(p.x, xs[i])
+		}
+		p.ys = append(p.ys, y)
+	}
+	maxY := 0.0
+	for i, r := range rows {
+		rh := r.ReturnHops
+		if rh == nil {
+			continue
+		}
+		add(&overall, i, rh.AvgHops)
+		if rh.AvgHopsIPv4 > 0 {
+			add(&v4, i, rh.AvgHopsIPv4)
+		}
+		if rh.AvgHopsIPv6 > 0 {
+			add(&v6, i, rh.AvgHopsIPv6)
+		}
+		if len(rh.Changes) > 0 {
+			add(&changed, i, rh.AvgHops)
+		}
+		maxY = math.Max(maxY, math.Max(rh.AvgHops, math.Max(rh.AvgHopsIPv4, rh.AvgHopsIPv6)))
+	}
+	if len(overall.ys) == 0 {
+		return drawHint(blank(cw, chh), "No response TTLs: run the monitor with --response-ttl.")
+	}
+	var series []chart.Series
+	addSeries := func(name string, p pts, st chart.Style) {
+		if len(p.ys) == 0 {
+			return
+		}
+		if len(p.ys) == 1 {
+			p.ys = append(p.ys, p.ys[0])
+			if timeMode {
+				p.t = append(p.t, p.t[0].Add(1*time.Second))
+			} else {
+				p.x = append(p.x, p.x[0]+1)
+			}
+		}
+		if timeMode {
+			series = append(series, chart.TimeSeries{Name: name, XValues: p.t, YValues: p.ys, Style: st})
+		} else {
+			series = append(series, chart.ContinuousSeries{Name: name, XValues: p.x, YValues: p.ys, Style: st})
+		}
+	}
+	if state.showOverall {
+		addSeries("Overall", overall, pointStyle(chart.ColorAlternateGray))
+	}
+	if state.showIPv4 {
+		addSeries("IPv4", v4, pointStyle(chart.ColorBlue))
+	}
+	if state.showIPv6 {
+		addSeries("IPv6", v6, pointStyle(chart.ColorGreen))
+	}
+	if len(changed.ys) == 1 {
+		// a lone marker is padded in place: rerouted batches are not a line
+		changed.x, changed.t, changed.ys = append(changed.x, changed.x...), append(changed.t, changed.t...), append(changed.ys, changed.ys...)
+	}
+	addSeries("Route change", changed, chart.Style{StrokeWidth: 0, DotWidth: 9, DotColor: chart.ColorRed.WithAlpha(110)})
+	if len(series) == 0 {
+		return drawHint(blank(cw, chh), "Enable Overall, IPv4 or IPv6 to see the hop counts.")
+	}
+	vals := helpers.BuildNumericTicks(0, math.Max(maxY*1.15, 4), 6)
+	if len(vals) < 2 {
+		vals = []float64{0, 32}
+	}
+	yTicks := make([]chart.Tick, len(vals))
+	for i, v := range vals {
+		yTicks[i] = chart.Tick{Value: v, Label: helpers.FormatNumericTick(v)}
+	}
+	padBottom := 28
+	switch state.xAxisMode {
+	case "run_tag":
+		padBottom = 90
+	case "time":
+		padBottom = 48
+	}
+	if state.showHints {
+		padBottom += 18
+	}
+	ch := chart.Chart{
+		Title:      "Estimated Hop Count",
+		Background: chart.Style{Padding: chart.Box{Top: 14, Left: 16, Right: 12, Bottom: padBottom}},
+		XAxis:      xAxis,
+		YAxis:      chart.YAxis{Name: "hops", Range: &chart.ContinuousRange{Min: vals[0], Max: vals[len(vals)-1]}, Ticks: yTicks},
+		Series:     series,
+	}
+	themeChart(&ch)
+	ch.Title += allGroupsNote(state)
+	ch.Width, ch.Height = cw, chh
+	attachLegend(state, &ch)
+	var buf bytes.Buffer
+	if err := renderChart(state, &ch, &buf); err != nil {
+		return blank(cw, chh)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		return blank(cw, chh)
+	}
+	if state.showHints {
+		img = drawHint(img, "Hint: a step in the hop count is a route change on the way back; compare with Next Hop and the route traces in Diagnostics.")
+	}
+	return drawWatermark(img, "Situation: "+activeSituationLabel(state))
+}
+
+// returnHopsLines is the crosshair readout of the Estimated Hop Count chart for one batch.
+func returnHopsLines(rh *analysis.ReturnHops) []string {
+	if rh == nil {
+		return []string{"No response TTLs"}
+	}
+	lines := []string{fmt.Sprintf("Hops: %.1f (IPv4 %.1f, IPv6 %.1f) over %d lines", rh.AvgHops, rh.AvgHopsIPv4, rh.AvgHopsIPv6, rh.Lines)}
+	if rh.AsymmetricLines > 0 {
+		lines = append(lines, fmt.Sprintf("Asymmetric vs route trace: %d lines", rh.AsymmetricLines))
+	}
+	for i, c := range rh.Changes {
+		if i == 3 {
+			lines = append(lines, fmt.Sprintf("… %d more rerouted", len(rh.Changes)-i))
+			break
+		}
+		lines = append(lines, fmt.Sprintf("Rerouted: %s %d → %d hops", c.Target, c.From, c.To))
+	}
+	return lines
+}
@@ -0,0 +1,313 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/iafilius/InternetQualityMonitor/src/control"
+)
+
+// "Run batch now": when a monitor daemon answers on its control API (monitor --control-listen)
+// the toolbar button asks it for a batch and reloads once the daemon reports that batch done.
+// Without a daemon, a configured monitor binary is run for one batch appending to the open file,
+// and the view reloads when it exits. The button stays hidden when neither is available.
+
+const (
+	defaultMonitorControlURL = "http://127.0.0.1:8098"
+	monitorProbeInterval     = 30 * time.Second
+	batchPollInterval        = 2 * time.Second
+	batchRunTimeout          = 2 * time.Hour
+)
+
+type monitorSettings struct {
+	controlURL string // control API base URL; empty disables daemon detection
+	token      string // typed in this session; never saved to the preferences
+	tokenFile  string // read per request when token is empty (monitor --control-token-file)
+	binary     string // monitor executable for one-off batches
+	sites      string // --sites for the binary (empty: the monitor's default)
+}
+
+func loadMonitorSettings(p fyne.Preferences) monitorSettings {
+	m := monitorSettings{
+		controlURL: p.StringWithFallback("monitorControlURL", defaultMonitorControlURL),
+		tokenFile:  p.StringWithFallback("monitorControlTokenFile", control.DefaultTokenFile()),
+		binary:     p.String("monitorBinary"),
+		sites:      p.String("monitorSites"),
+	}
+	// earlier versions stored the token itself in the preferences file: use it for this
+	// session and remove it from there
+	if tok := p.String("monitorControlToken"); tok != "" {
+		m.token = tok
+		p.SetString("monitorControlToken", "")
+	}
+	return m
+}
+
+func (m monitorSettings) save(p fyne.Preferences) {
+	p.SetString("monitorControlURL", m.controlURL)
+	p.SetString("monitorControlTokenFile", m.tokenFile)
+	p.SetString("monitorBinary", m.binary)
+	p.SetString("monitorSites", m.sites)
+}
+
+// bearer is the token to send: the one typed this session, else the token file's.
+func (m monitorSettings) bearer() string {
+	if m.token != "" || m.tokenFile == "" {
+		return m.token
+	}
+	tok, _ := control.ReadTokenFile(m.tokenFile)
+	return tok
+}
+
+// controlRequest calls the daemon's control API; path is e.g. "/v1/status".
+func controlRequest(ctx context.Context, m monitorSettings, method, path string, out any) error {
+	base := strings.TrimRight(strings.TrimSpace(m.controlURL), "/")
+	if base == "" {
+		return fmt.Errorf("no control API URL configured")
+	}
+	if !strings.Contains(base, "://") {
+		base = "http://" + base
+	}
+	req, err := http.NewRequestWithContext(ctx, method, base+path, nil)
+	if err != nil {
+		return err
+	}
+	if tok := m.bearer(); tok != "" {
+		req.Header.Set("Authorization", "Bearer "+tok)
+	}
+	if method != http.MethodGet {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("%s %s: %s", method, path, resp.Status)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func fetchMonitorStatus(m monitorSettings) (*control.Status, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	var st control.Status
+	if err := controlRequest(ctx, m, http.MethodGet, "/v1/status", &st); err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+// batchCompleted reports whether the batch requested at status before has finished: the daemon
+// started a later iteration (after a running one, if any) and is no longer measuring.
+func batchCompleted(before, now control.Status) bool {
+	return now.Iteration > before.Iteration && now.State != control.StateMeasuring
+}
+
+// monitorBatchArgs are the arguments for a one-off batch appending to outFile.
+func monitorBatchArgs(m monitorSettings, outFile string) []string {
+	args := []string{"--iterations", "1", "--out", outFile}
+	if s := strings.TrimSpace(m.sites); s != "" {
+		args = append(args, "--sites", s)
+	}
+	return args
+}
+
+// startMonitorProbe looks for a daemon at startup and every monitorProbeInterval.
+func startMonitorProbe(state *uiState) {
+	go func() {
+		for {
+			probeMonitor(state)
+			time.Sleep(monitorProbeInterval)
+		}
+	}()
+}
+
+func probeMonitor(state *uiState) {
+	var m monitorSettings
+	fyne.DoAndWait(func() { m = state.monitor })
+	_, err := fetchMonitorStatus(m)
+	fyne.Do(func() {
+		state.monitorDaemon = err == nil
+		updateRunBatchButton(state)
+	})
+}
+
+func updateRunBatchButton(state *uiState) {
+	btn := state.runBatchBtn
+	if btn == nil {
+		return
+	}
+	if !state.monitorDaemon && strings.TrimSpace(state.monitor.binary) == "" {
+		btn.Hide()
+		return
+	}
+	btn.Show()
+	switch {
+	case state.batchRunning:
+		btn.SetText("Batch running…")
+		btn.Disable()
+	default:
+		btn.SetText("Run batch now")
+		btn.Enable()
+	}
+}
+
+// runBatchNow starts a batch through the daemon or, without one, the monitor binary. The daemon
+// check runs off the UI thread; the button stays disabled meanwhile.
+func runBatchNow(state *uiState, fileLabel *widget.Label) {
+	if state.batchRunning {
+		return
+	}
+	m := state.monitor
+	state.batchRunning = true
+	updateRunBatchButton(state)
+	go func() {
+		st, err := fetchMonitorStatus(m)
+		fyne.Do(func() {
+			if err == nil {
+				go waitDaemonBatch(state, fileLabel, m, *st)
+				return
+			}
+			state.batchRunning = false
+			startMonitorBinary(state, fileLabel, m)
+		})
+	}()
+}
+
+// startMonitorBinary runs a one-off batch with the monitor binary after the daemon did not answer.
+func startMonitorBinary(state *uiState, fileLabel *widget.Label, m monitorSettings) {
+	state.monitorDaemon = false
+	if strings.TrimSpace(m.binary) == "" {
+		updateRunBatchButton(state)
+		dialog.ShowInformation("Run batch now", "The monitor daemon did not answer at "+m.controlURL+".\nStart it with --control-listen, or set a monitor binary in Settings → Monitor Connection….", state.window)
+		return
+	}
+	if state.filePath == "" {
+		updateRunBatchButton(state)
+		dialog.ShowInformation("Run batch now", "Open a results file first; the batch is appended to it.", state.window)
+		return
+	}
+	state.batchRunning = true
+	updateRunBatchButton(state)
+	go runMonitorBinary(state, fileLabel, m, state.filePath)
+}
+
+func waitDaemonBatch(state *uiState, fileLabel *widget.Label, m monitorSettings, before control.Status) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	err := controlRequest(ctx, m, http.MethodPost, "/v1/batch", nil)
+	cancel()
+	deadline := time.Now().Add(batchRunTimeout)
+	failures := 0
+	for err == nil && time.Now().Before(deadline) {
+		time.Sleep(batchPollInterval)
+		st, perr := fetchMonitorStatus(m)
+		if perr != nil {
+			// tolerate a short hiccup; a daemon that went away ends the wait
+			if failures++; failures >= 5 {
+				err = perr
+			}
+			continue
+		}
+		failures = 0
+		if batchCompleted(before, *st) {
+			break
+		}
+	}
+	finishBatchRun(state, fileLabel, err)
+}
+
+func runMonitorBinary(state *uiState, fileLabel *widget.Label, m monitorSettings, outFile string) {
+	runMonitorCommand(state, fileLabel, m.binary, monitorBatchArgs(m, outFile))
+}
+
+// runMonitorCommand runs the monitor binary with args and reloads once it exits.
+func runMonitorCommand(state *uiState, fileLabel *widget.Label, binary string, args []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), batchRunTimeout)
+	defer cancel()
+	var out bytes.Buffer
+	cmd := exec.CommandContext(ctx, binary, args...)
+	cmd.Stdout, cmd.Stderr = &out, &out
+	err := cmd.Run()
+	if err != nil {
+		err = fmt.Errorf("%v\n\n%s", err, lastLines(out.String(), 12))
+	}
+	finishBatchRun(state, fileLabel, err)
+}
+
+func finishBatchRun(state *uiState, fileLabel *widget.Label, err error) {
+	fyne.Do(func() {
+		state.batchRunning = false
+		updateRunBatchButton(state)
+		loadAll(state, fileLabel)
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("run batch: %w", err), state.window)
+		}
+	})
+}
+
+func lastLines(s string, n int) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// openMonitorConnectionDialog edits where the viewer finds the monitor.
+func openMonitorConnectionDialog(state *uiState) {
+	urlEntry := widget.NewEntry()
+	urlEntry.SetPlaceHolder(defaultMonitorControlURL)
+	urlEntry.SetText(state.monitor.controlURL)
+	tokenEntry := widget.NewPasswordEntry()
+	tokenEntry.SetText(state.monitor.token)
+	tokenFileEntry := widget.NewEntry()
+	tokenFileEntry.SetPlaceHolder(control.DefaultTokenFile())
+	tokenFileEntry.SetText(state.monitor.tokenFile)
+	binEntry := widget.NewEntry()
+	binEntry.SetPlaceHolder("/usr/local/bin/iqm (optional)")
+	binEntry.SetText(state.monitor.binary)
+	sitesEntry := widget.NewEntry()
+	sitesEntry.SetPlaceHolder("./sites.jsonc")
+	sitesEntry.SetText(state.monitor.sites)
+	form := &widget.Form{Items: []*widget.FormItem{
+		{Text: "Control API URL", Widget: urlEntry, HintText: "monitor --control-listen address"},
+		{Text: "Control token", Widget: tokenEntry, HintText: "this session only; empty uses the token file"},
+		{Text: "Token file", Widget: tokenFileEntry, HintText: "monitor --control-token-file"},
+		{Text: "Monitor binary", Widget: binEntry, HintText: "used when no daemon answers"},
+		{Text: "Sites file", Widget: sitesEntry},
+	}}
+	d := dialog.NewCustomConfirm("Monitor Connection", "Save", "Cancel", form, func(ok bool) {
+		if !ok {
+			return
+		}
+		state.monitor = monitorSettings{
+			controlURL: strings.TrimSpace(urlEntry.Text),
+			token:      strings.TrimSpace(tokenEntry.Text),
+			tokenFile:  strings.TrimSpace(tokenFileEntry.Text),
+			binary:     strings.TrimSpace(binEntry.Text),
+			sites:      strings.TrimSpace(sitesEntry.Text),
+		}
+		if state.app != nil {
+			state.monitor.save(state.app.Preferences())
+		}
+		updateRunBatchButton(state)
+		go probeMonitor(state)
+	}, state.window)
+	d.Resize(fyne.NewSize(460, 340))
+	d.Show()
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/iafilius/InternetQualityMonitor/src/control"
+)
+
+func TestBatchCompleted(t *testing.T) {
+	waiting := control.Status{State: control.StateWaiting, Iteration: 3}
+	if batchCompleted(waiting, waiting) {
+		t.Fatalf("nothing ran yet")
+	}
+	if batchCompleted(waiting, control.Status{State: control.StateMeasuring, Iteration: 4}) {
+		t.Fatalf("triggered batch still measuring")
+	}
+	if !batchCompleted(waiting, control.Status{State: control.StateWaiting, Iteration: 4}) {
+		t.Fatalf("triggered batch done")
+	}
+	// requested during batch 3: batch 3 ending is not enough
+	running := control.Status{State: control.StateMeasuring, Iteration: 3}
+	if batchCompleted(running, control.Status{State: control.StateWaiting, Iteration: 3}) {
+		t.Fatalf("the running batch is not the requested one")
+	}
+}
+
+func TestDaemonControl(t *testing.T) {
+	c := control.New("sites.jsonc", "out.jsonl", 2, 0, time.Hour)
+	c.Token = "tok"
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+	m := monitorSettings{controlURL: srv.URL + "/", token: "tok"}
+	st, err := fetchMonitorStatus(m)
+	if err != nil || st.Sites != 2 || st.State != control.StateWaiting {
+		t.Fatalf("status: %+v %v", st, err)
+	}
+	m.token = ""
+	if _, err := fetchMonitorStatus(m); err == nil {
+		t.Fatalf("missing token must fail")
+	}
+	m.tokenFile = filepath.Join(t.TempDir(), "control-token")
+	os.WriteFile(m.tokenFile, []byte("tok\n"), 0o600)
+	if _, err := fetchMonitorStatus(m); err != nil {
+		t.Fatalf("token from file: %v", err)
+	}
+	if got := monitorBatchArgs(monitorSettings{sites: " s.jsonc "}, "/data/r.jsonl"); !reflect.DeepEqual(got, []string{"--iterations", "1", "--out", "/data/r.jsonl", "--sites", "s.jsonc"}) {
+		t.Fatalf("args: %v", got)
+	}
+}
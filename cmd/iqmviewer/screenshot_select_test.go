@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iafilius/InternetQualityMonitor/src/analysis"
+)
+
+func TestSelectScreenshotCharts(t *testing.T) {
+	fn := func(*uiState) image.Image { return nil }
+	all := []screenshotChart{{"speed_avg.png", fn}, {"ttfb_avg.png", fn}, {"stall_rate.png", fn}, {"pretffb_stall_rate.png", fn}}
+	defaults := all[:3]
+	got, err := selectScreenshotCharts(all, defaults, nil)
+	if err != nil || len(got) != 3 {
+		t.Fatalf("defaults: %v (n=%d)", err, len(got))
+	}
+	// requested order wins, ".png" is optional, and charts outside the defaults can be named
+	got, err = selectScreenshotCharts(all, defaults, []string{" stall_rate", "pretffb_stall_rate.png", "speed_avg"})
+	if err != nil || len(got) != 3 || got[0].name != "stall_rate.png" || got[1].name != "pretffb_stall_rate.png" || got[2].name != "speed_avg.png" {
+		t.Fatalf("selection: %v %+v", err, got)
+	}
+	if _, err := selectScreenshotCharts(all, defaults, []string{"speed_avg", "nope"}); err == nil || !strings.Contains(err.Error(), "nope") || !strings.Contains(err.Error(), "ttfb_avg") {
+		t.Fatalf("unknown name error: %v", err)
+	}
+	if _, err := selectScreenshotCharts(all, defaults, []string{" ", ""}); err == nil {
+		t.Fatalf("empty selection should error")
+	}
+}
+
+func TestRenderScreenshotsParallelKeepsOrder(t *testing.T) {
+	var charts []screenshotChart
+	for i := 0; i < 12; i++ {
+		w := i + 1
+		charts = append(charts, screenshotChart{name: fmt.Sprintf("c%02d.png", i), fn: func(s *uiState) image.Image {
+			s.xAxisMode = "time" // variants flip toggles on their own copy
+			return image.NewRGBA(image.Rect(0, 0, w, 1))
+		}})
+	}
+	charts[5].fn = func(*uiState) image.Image { panic("boom") }
+	charts[7].fn = func(*uiState) image.Image { return nil }
+	st := &uiState{xAxisMode: "batch"}
+	shots := renderScreenshots(st, charts, screenshotWorkers(4, len(charts)))
+	for i, sh := range shots {
+		switch i {
+		case 5:
+			if sh.err == nil || !strings.Contains(sh.err.Error(), "c05.png") {
+				t.Fatalf("panic not reported for %s: %v", charts[i].name, sh.err)
+			}
+		case 7:
+			if sh.png != nil || sh.err != nil {
+				t.Fatalf("nil image must give no file: %+v", sh)
+			}
+		default:
+			img, err := png.Decode(bytes.NewReader(sh.png))
+			if err != nil || img.Bounds().Dx() != i+1 {
+				t.Fatalf("%s: got %v (err %v), want width %d", charts[i].name, img, err, i+1)
+			}
+		}
+	}
+	if st.xAxisMode != "batch" {
+		t.Fatalf("shared state was modified: %q", st.xAxisMode)
+	}
+	if screenshotWorkers(0, 3) < 1 || screenshotWorkers(8, 3) != 3 || screenshotWorkers(-1, 0) != 1 {
+		t.Fatalf("worker bounds")
+	}
+}
+
+func TestSliceScreenshotBatches(t *testing.T) {
+	sums := []analysis.BatchSummary{
+		{RunTag: "20261001_100000", Tags: map[string]string{"isp": "Acme"}},
+		{RunTag: "20261001_120000", Tags: map[string]string{"isp": "Acme", "router_fw": "1.2"}},
+		{RunTag: "20261002_080000", Tags: map[string]string{"isp": "Other"}},
+		{RunTag: "untimed"},
+	}
+	from, _ := parseScreenshotTime("2026-10-01T11:00:00Z", false)
+	to, _ := parseScreenshotTime("2026-10-02T00:00:00Z", true)
+	if got := sliceScreenshotBatches(sums, from, to, nil); len(got) != 1 || got[0].RunTag != "20261001_120000" {
+		t.Fatalf("time window: %+v", got)
+	}
+	if got := sliceScreenshotBatches(sums, time.Time{}, time.Time{}, map[string]string{"isp": "Acme"}); len(got) != 2 {
+		t.Fatalf("tag isp=Acme: %d batches", len(got))
+	}
+	if got := sliceScreenshotBatches(sums, time.Time{}, time.Time{}, map[string]string{"isp": "Acme", "router_fw": "1.2"}); len(got) != 1 {
+		t.Fatalf("all tags must match: %d batches", len(got))
+	}
+	if got := sliceScreenshotBatches(sums, time.Time{}, time.Time{}, nil); len(got) != len(sums) {
+		t.Fatalf("no slice keeps everything: %d", len(got))
+	}
+}
+
+func TestParseScreenshotTime(t *testing.T) {
+	end, err := parseScreenshotTime("2026-10-01", true)
+	if err != nil || end.Day() != 1 || end.Hour() != 23 || end.Location() != time.Local {
+		t.Fatalf("date as end: %v %v", end, err)
+	}
+	start, _ := parseScreenshotTime("2026-10-01", false)
+	if start.Hour() != 0 || !start.Before(end) {
+		t.Fatalf("date as start: %v", start)
+	}
+	if got, err := parseScreenshotTime("2026-10-01 14:30", false); err != nil || got.Hour() != 14 || got.Minute() != 30 {
+		t.Fatalf("wall time: %v %v", got, err)
+	}
+	if got, err := parseScreenshotTime("2026-10-01T14:30:00+02:00", false); err != nil || !got.Equal(time.Date(2026, 10, 1, 12, 30, 0, 0, time.UTC)) {
+		t.Fatalf("RFC 3339: %v %v", got, err)
+	}
+	if _, err := parseScreenshotTime("yesterday", false); err == nil {
+		t.Fatalf("want an error for an unparsable time")
+	}
+}
@@ -2,12 +2,19 @@ package main
 
 import (
 	"bytes"
+	"encoding/xml"
 	"fmt"
 	"image"
 	"image/png"
+	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"regexp"
+	"runtime"
 	"strings"
+	"sync"
+	"time"
 
 	chart "github.com/wcharczuk/go-chart/v2"
 
@@ -15,6 +22,377 @@ import (
 	"github.com/iafilius/InternetQualityMonitor/src/monitor"
 )
 
+// screenshotFormat selects headless screenshot output: "png" (default) or "svg" (each PNG plus
+// a vector .svg of the same chart). Set from --screenshot-format before RunScreenshotsMode.
+var screenshotFormat = "png"
+
+// screenshotOnly restricts screenshot mode to these charts, named by file name without ".png"
+// (e.g. speed_avg, stall_rate); screenshotOutFile writes the single selected chart to that path
+// instead of into the out dir. Set from --screenshot-only and --out before RunScreenshotsMode.
+var (
+	screenshotOnly    []string
+	screenshotOutFile string
+)
+
+// screenshotSlice narrows screenshot mode to the batches that started within [from, to] (zero
+// = open) and carry every tag (meta.tags key=value). Set from --from, --to and --tag before
+// RunScreenshotsMode; with any of them set, up to screenshotSliceMaxBatches batches are read so
+// an older incident window is still found, and --screenshot-batches applies to the slice.
+var screenshotSlice struct {
+	from, to time.Time
+	tags     map[string]string
+}
+
+// screenshotSliceMaxBatches bounds the batches read for a slice: a month of 5-minute batches.
+const screenshotSliceMaxBatches = 10000
+
+// parseScreenshotTime parses a --from/--to value: RFC 3339 ("2026-10-01T14:00:00Z"), or a
+// "2006-01-02 15:04[:05]" / "2006-01-02T15:04[:05]" / "2006-01-02" wall time in the local zone.
+// end makes a date-only value the end of that day, so --to 2026-10-01 includes the whole day.
+func parseScreenshotTime(v string, end bool) (time.Time, error) {
+	v = strings.TrimSpace(v)
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02 15:04", "2006-01-02T15:04"} {
+		if t, err := time.ParseInLocation(layout, v, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	t, err := time.ParseInLocation("2006-01-02", v, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q: want RFC 3339, \"2006-01-02 15:04\" or 2006-01-02", v)
+	}
+	if end {
+		t = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+	return t, nil
+}
+
+// sliceScreenshotBatches keeps the batches of sums inside the slice, oldest first as given;
+// batches without a start time are dropped when a time bound is set.
+func sliceScreenshotBatches(sums []analysis.BatchSummary, from, to time.Time, tags map[string]string) []analysis.BatchSummary {
+	out := make([]analysis.BatchSummary, 0, len(sums))
+	for _, s := range sums {
+		if !from.IsZero() || !to.IsZero() {
+			st := s.StartTime()
+			if st.IsZero() || (!from.IsZero() && st.Before(from)) || (!to.IsZero() && st.After(to)) {
+				continue
+			}
+		}
+		ok := true
+		for k, v := range tags {
+			ok = ok && tagFilterMatches(s, k+"="+v)
+		}
+		if ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// screenshotMicroStallGapMs is the transient stall gap of the screenshots; set from
+// --screenshot-micro-stall-gap-ms before RunScreenshotsMode.
+var screenshotMicroStallGapMs = analysis.DefaultMicroStallGapMs
+
+// screenshotJobs bounds how many charts screenshot mode renders at once; 0 means one per CPU.
+// Set from --screenshot-jobs before RunScreenshotsMode.
+var screenshotJobs = 0
+
+// svgCapture collects SVG renderings while screenshots run in SVG format: renderChart appends
+// one entry per go-chart render and RunScreenshotsMode drains it after each image.
+var svgCapture struct {
+	enabled bool
+	charts  [][]byte
+}
+
+// renderChart renders c as PNG into w (all chart renderers go through here), after adding trend
+// lines, thinning overlong series (decimateChartSeries) and applying the chart appearance, with
+// the options of state's renderSettings (nil: the live ones). While svgCapture is enabled the
+// same chart is also rendered with go-chart's SVG renderer.
+func renderChart(state *uiState, c interface {
+	Render(chart.RendererProvider, io.Writer) error
+}, w io.Writer) error {
+	if cc, ok := c.(*chart.Chart); ok {
+		rs := renderSettingsFor(state)
+		applyChartTrends(cc, rs.trend, rs.look)
+		applyConfigMarkers(cc)
+		if rs.decimate {
+			decimateChartSeries(cc)
+		}
+		applyChartAppearance(cc, rs.look)
+	}
+	if err := c.Render(chart.PNG, w); err != nil {
+		return err
+	}
+	if svgCapture.enabled {
+		var sb bytes.Buffer
+		if err := c.Render(chart.SVG, &sb); err == nil {
+			svgCapture.charts = append(svgCapture.charts, sb.Bytes())
+		}
+	}
+	return nil
+}
+
+// go-chart's SVG renderer sizes the document via viewBox only.
+var svgSizeRe = regexp.MustCompile(`<svg[^>]*\sviewBox="0 0 (\d+) (\d+)"`)
+
+// addSVGWatermark adds the Situation watermark bottom-right (as drawWatermark does for PNGs) as
+// an outlined SVG text element.
+func addSVGWatermark(svg []byte, text string) []byte {
+	end := bytes.LastIndex(svg, []byte("</svg>"))
+	m := svgSizeRe.FindSubmatch(svg)
+	if end < 0 || m == nil || strings.TrimSpace(text) == "" {
+		return svg
+	}
+	var esc bytes.Buffer
+	_ = xml.EscapeText(&esc, []byte(text))
+	w, h := string(m[1]), string(m[2])
+	mark := fmt.Sprintf(`<g font-family="sans-serif" font-size="13"><text x="%s" y="%s" dx="-14" dy="-12" text-anchor="end" fill="#ffffff" stroke="#000000" stroke-opacity="0.85" stroke-width="3" paint-order="stroke">%s</text></g>`, w, h, esc.String())
+	out := append([]byte(nil), svg[:end]...)
+	out = append(out, mark...)
+	return append(out, svg[end:]...)
+}
+
+// screenshotChart is one headless chart render: the file name it is written as and its renderer.
+type screenshotChart struct {
+	name string
+	fn   func(*uiState) image.Image
+}
+
+// screenshotCharts is the expanded set rendered for documentation (and served by --serve).
+func screenshotCharts() []screenshotChart {
+	return []screenshotChart{
+		// Averages
+		{"speed_avg.png", renderSpeedChart},
+		{"ttfb_avg.png", renderTTFBChart},
+		// Stability & quality
+		{"low_speed_share.png", renderLowSpeedShareChart},
+		{"stall_rate.png", renderStallRateChart},
+		{"stall_time.png", renderStallTimeChart},
+		{"partial_body_rate.png", renderPartialBodyRateChart},
+		{"content_corruption_rate.png", renderContentCorruptionRateChart},
+		{"data_usage.png", renderDataUsageChart},
+		{"stall_count.png", renderStallCountChart},
+		{"transient_stall_rate.png", renderMicroStallRateChart},
+		{"transient_stall_time.png", renderMicroStallTimeChart},
+		{"transient_stall_count.png", renderMicroStallCountChart},
+		{"stall_timeline.png", renderStallTimelineChart},
+		{"speed_heatmap.png", renderSpeedHeatmapChart},
+		{"ttfb_heatmap.png", renderTTFBHeatmapChart},
+		{"jitter.png", renderJitterChart},
+		{"ping_jitter.png", renderPingJitterChart},
+		{"cov.png", renderCoVChart},
+		{"plateau_count.png", renderPlateauCountChart},
+		{"plateau_longest.png", renderPlateauLongestChart},
+		{"plateau_stable.png", renderPlateauStableChart},
+		// Setup breakdown (connection setup timings)
+		{"dns_lookup_time.png", renderDNSLookupChart},
+		{"tcp_connect_time.png", renderTCPConnectChart},
+		{"tls_handshake_time.png", renderTLSHandshakeChart},
+		// Percentiles (Speed)
+		{"speed_percentiles_overall.png", func(s *uiState) image.Image { return renderPercentilesChartWithFamily(s, "overall") }},
+		{"speed_percentiles_ipv4.png", func(s *uiState) image.Image { return renderPercentilesChartWithFamily(s, "ipv4") }},
+		{"speed_percentiles_ipv6.png", func(s *uiState) image.Image { return renderPercentilesChartWithFamily(s, "ipv6") }},
+		// Percentiles (TTFB)
+		{"ttfb_percentiles_overall.png", func(s *uiState) image.Image { return renderTTFBPercentilesChartWithFamily(s, "overall") }},
+		{"ttfb_percentiles_ipv4.png", func(s *uiState) image.Image { return renderTTFBPercentilesChartWithFamily(s, "ipv4") }},
+		{"ttfb_percentiles_ipv6.png", func(s *uiState) image.Image { return renderTTFBPercentilesChartWithFamily(s, "ipv6") }},
+		// Tail & gaps
+		{"tail_heaviness_speed.png", renderTailHeavinessChart},
+		{"tail_heaviness_ttfb.png", renderTTFBTailHeavinessChart},
+		{"ttfb_p95_p50_gap.png", renderTTFBP95GapChart},
+		// Family deltas
+		{"delta_speed_abs.png", renderFamilyDeltaSpeedChart},
+		{"delta_ttfb_abs.png", renderFamilyDeltaTTFBChart},
+		{"delta_speed_pct.png", renderFamilyDeltaSpeedPctChart},
+		{"delta_ttfb_pct.png", renderFamilyDeltaTTFBPctChart},
+		{"quality_score.png", renderQualityScoreChart},
+		{"plan_attainment.png", renderPlanAttainmentChart},
+		{"throughput_stability.png", renderThroughputStabilityChart},
+		{"ipv6_readiness.png", renderIPv6ReadinessChart},
+		{"happy_eyeballs_ipv6_lost.png", renderHappyEyeballsIPv6LostChart},
+		// SLA & SLA deltas
+		{"sla_speed.png", renderSLASpeedChart},
+		{"sla_ttfb.png", renderSLATTFBChart},
+		{"sla_speed_delta.png", renderSLASpeedDeltaChart},
+		{"sla_ttfb_delta.png", renderSLATTFBDeltaChart},
+		// Signals
+		{"cache_hit_rate.png", renderCacheHitRateChart},
+		{"enterprise_proxy_rate.png", renderEnterpriseProxyRateChart},
+		{"server_proxy_rate.png", renderServerProxyRateChart},
+		{"warm_cache_suspected_rate.png", renderWarmCacheSuspectedRateChart},
+		// Errors
+		{"error_rate.png", renderErrorRateChart},
+		{"error_share_by_http_protocol.png", renderErrorShareByHTTPProtocolChart},
+		{"stall_share_by_http_protocol.png", renderStallShareByHTTPProtocolChart},
+		{"partial_share_by_http_protocol.png", renderPartialShareByHTTPProtocolChart},
+		{"ttfb_by_http_protocol.png", renderTTFBByHTTPProtocolChart},
+		// Per-URL errors (selected batch top-N)
+		{"errors_by_url.png", renderErrorsByURLChart},
+		{"cold_warm_ttfb.png", renderColdWarmTTFBChart},
+		{"udp_blocked_rate.png", renderUDPBlockedRateChart},
+		{"return_hops.png", renderReturnHopsChart},
+		{"compression_ratio.png", renderCompressionRatioChart},
+		{"server_timing.png", renderServerTimingChart},
+		{"tcp_retrans.png", renderTCPRetransChart},
+		{"iperf3_capacity.png", renderIPerf3Chart},
+		{"wifi_rssi_vs_throughput.png", renderWiFiRSSIChart},
+		{"wifi_phy_rate_vs_throughput.png", renderWiFiPHYRateChart},
+	}
+}
+
+// screenshotOptionalCharts are the charts screenshot mode adds to screenshotCharts by default:
+// the Local Throughput Self-Test (--screenshot-selftest), Pre‑TTFB Stall Rate
+// (--screenshot-pretffb) and the "averages" action variants with the time axis and relative scale.
+func screenshotOptionalCharts() (selfTest, preTTFB screenshotChart, variants []screenshotChart) {
+	selfTest = screenshotChart{name: "local_throughput_selftest.png", fn: renderSelfTestChart}
+	preTTFB = screenshotChart{name: "pretffb_stall_rate.png", fn: renderPreTTFBStallRateChart}
+	variants = []screenshotChart{
+		{"speed_avg_time.png", screenshotVariant("time", "", renderSpeedChart)},
+		{"ttfb_avg_time.png", screenshotVariant("time", "", renderTTFBChart)},
+		{"speed_avg_relative.png", screenshotVariant("", "relative", renderSpeedChart)},
+		{"ttfb_avg_relative.png", screenshotVariant("", "relative", renderTTFBChart)},
+	}
+	return selfTest, preTTFB, variants
+}
+
+// allScreenshotCharts is every chart screenshot mode can write, in the order of a default run.
+func allScreenshotCharts() []screenshotChart {
+	selfTest, preTTFB, variants := screenshotOptionalCharts()
+	return append(append(screenshotCharts(), selfTest, preTTFB), variants...)
+}
+
+// screenshotVariant renders fn with the X axis or Y scale temporarily switched (the "averages"
+// action variants).
+func screenshotVariant(xAxis, yScale string, fn func(*uiState) image.Image) func(*uiState) image.Image {
+	return func(s *uiState) image.Image {
+		prevX, prevY := s.xAxisMode, s.yScaleMode
+		defer func() { s.xAxisMode, s.yScaleMode = prevX, prevY }()
+		if xAxis != "" {
+			s.xAxisMode = xAxis
+		}
+		if yScale != "" {
+			s.yScaleMode = yScale
+		}
+		return fn(s)
+	}
+}
+
+// screenshotWorkers resolves --screenshot-jobs for n charts: jobs <= 0 uses one per CPU.
+func screenshotWorkers(jobs, n int) int {
+	if jobs <= 0 {
+		jobs = runtime.NumCPU()
+	}
+	if jobs > n {
+		jobs = n
+	}
+	if jobs < 1 {
+		jobs = 1
+	}
+	return jobs
+}
+
+// renderedShot is a screenshot chart encoded by a worker; err covers a failed encode or a
+// renderer panic so one broken chart does not take the whole run down.
+type renderedShot struct {
+	png []byte
+	svg [][]byte
+	err error
+}
+
+// renderScreenshots renders and PNG-encodes charts on up to workers goroutines, each from its own
+// shallow copy of st (variants flip the axis toggles), and returns the results in chart order so
+// files are written in the same order with the same names however many workers ran. A nil image
+// yields a result without PNG. SVG capture goes through one global buffer, so with it enabled
+// the charts render one at a time.
+func renderScreenshots(st *uiState, charts []screenshotChart, workers int) []renderedShot {
+	out := make([]renderedShot, len(charts))
+	render := func(i int) {
+		defer func() {
+			if r := recover(); r != nil {
+				out[i] = renderedShot{err: fmt.Errorf("render %s: %v", charts[i].name, r)}
+			}
+		}()
+		snap := *st
+		img := charts[i].fn(&snap)
+		res := renderedShot{}
+		if svgCapture.enabled {
+			res.svg, svgCapture.charts = svgCapture.charts, nil
+		}
+		if img != nil {
+			var buf bytes.Buffer
+			if err := png.Encode(&buf, img); err != nil {
+				res.err = fmt.Errorf("png encode %s: %w", charts[i].name, err)
+			}
+			res.png = buf.Bytes()
+		}
+		out[i] = res
+	}
+	if svgCapture.enabled {
+		workers = 1
+	}
+	if workers <= 1 {
+		for i := range charts {
+			render(i)
+		}
+		return out
+	}
+	queue := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				render(i)
+			}
+		}()
+	}
+	for i := range charts {
+		queue <- i
+	}
+	close(queue)
+	wg.Wait()
+	return out
+}
+
+// selectScreenshotCharts picks the charts named in only (in that order, names without ".png")
+// from all; with no names it returns defaults. Unknown names are an error listing the valid ones.
+func selectScreenshotCharts(all, defaults []screenshotChart, only []string) ([]screenshotChart, error) {
+	if len(only) == 0 {
+		return defaults, nil
+	}
+	byName := map[string]screenshotChart{}
+	for _, c := range all {
+		byName[strings.TrimSuffix(c.name, ".png")] = c
+	}
+	var out []screenshotChart
+	var unknown []string
+	for _, n := range only {
+		n = strings.TrimSuffix(strings.TrimSpace(n), ".png")
+		if n == "" {
+			continue
+		}
+		if c, ok := byName[n]; ok {
+			out = append(out, c)
+		} else {
+			unknown = append(unknown, n)
+		}
+	}
+	if len(unknown) > 0 {
+		names := make([]string, 0, len(all))
+		for _, c := range all {
+			names = append(names, strings.TrimSuffix(c.name, ".png"))
+		}
+		return nil, fmt.Errorf("unknown chart(s) %s; available: %s", strings.Join(unknown, ", "), strings.Join(names, ", "))
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("no chart names given")
+	}
+	return out, nil
+}
+
 // RunScreenshotsMode renders a curated set of charts and writes them as PNGs under outDir.
 // It runs headlessly without creating a UI window.
 // variants: "none" or "averages" (controls extra action variants for averages)
@@ -22,10 +400,16 @@ import (
 // showDNSLegacy: when true, include dashed legacy dns_time_ms overlay on the DNS chart
 // includeSelfTest: when true, include the Local Throughput Self-Test chart
 // avg/median/min/max/iqr: metric visibility toggles for averages charts
+// screenshotOnly/screenshotOutFile narrow the run to named charts (any of the above, regardless
+// of the include toggles) and, for a single chart, an explicit output file.
+// screenshotSlice narrows the batches to a time window and tags.
 func RunScreenshotsMode(filePath, outDir, situation string, rollingWindow int, showBand bool, batches int, lowSpeedThresholdKbps int, variants string, theme string, showDNSLegacy bool, includeSelfTest bool, includePreTTFB bool, showAvg, showMedian, showMin, showMax, showIQR bool) error {
 	if filePath == "" {
 		filePath = "monitor_results.jsonl"
 	}
+	if screenshotOutFile != "" {
+		outDir = filepath.Dir(screenshotOutFile)
+	}
 	if err := os.MkdirAll(outDir, 0o755); err != nil {
 		return fmt.Errorf("create out dir: %w", err)
 	}
@@ -48,10 +432,30 @@ func RunScreenshotsMode(filePath, outDir, situation string, rollingWindow int, s
 	if lowSpeedThresholdKbps <= 0 {
 		lowSpeedThresholdKbps = 1000
 	}
-	sums, err := analysis.AnalyzeRecentResultsFullWithOptions(filePath, monitor.SchemaVersion, batches, analysis.AnalyzeOptions{SituationFilter: sitFilter, LowSpeedThresholdKbps: float64(lowSpeedThresholdKbps), MicroStallMinGapMs: 500})
+	if screenshotMicroStallGapMs <= 0 {
+		screenshotMicroStallGapMs = analysis.DefaultMicroStallGapMs
+	}
+	sl := screenshotSlice
+	sliced := !sl.from.IsZero() || !sl.to.IsZero() || len(sl.tags) > 0
+	readBatches := batches
+	if sliced {
+		readBatches = screenshotSliceMaxBatches
+	}
+	sums, err := analysis.AnalyzeRecentResultsFullWithOptions(filePath, monitor.SchemaVersion, readBatches, analysis.AnalyzeOptions{SituationFilter: sitFilter, LowSpeedThresholdKbps: float64(lowSpeedThresholdKbps), MicroStallMinGapMs: int64(screenshotMicroStallGapMs)})
 	if err != nil {
 		return err
 	}
+	if sliced {
+		read := len(sums)
+		sums = sliceScreenshotBatches(sums, sl.from, sl.to, sl.tags)
+		if len(sums) == 0 {
+			return fmt.Errorf("no batches match --from/--to/--tag (of %d read)", read)
+		}
+		if len(sums) > batches {
+			sums = sums[len(sums)-batches:]
+		}
+		logf(slog.LevelInfo, "viewer", "screenshot slice: %d of %d batches", len(sums), read)
+	}
 	st := &uiState{
 		filePath:        filePath,
 		batchesN:        batches,
@@ -83,133 +487,93 @@ func RunScreenshotsMode(filePath, outDir, situation string, rollingWindow int, s
 		}
 	}
 	st.situation = strings.TrimSpace(situation)
+	st.showConfigMarkers = true
+	updateConfigMarkers(st)
 
-	// Expanded set for richer documentation and more visual action.
-	baseSet := []struct {
-		name string
-		fn   func(*uiState) image.Image
-	}{
-		// Averages
-		{"speed_avg.png", renderSpeedChart},
-		{"ttfb_avg.png", renderTTFBChart},
-		// Stability & quality
-		{"low_speed_share.png", renderLowSpeedShareChart},
-		{"stall_rate.png", renderStallRateChart},
-		{"stall_time.png", renderStallTimeChart},
-		{"partial_body_rate.png", renderPartialBodyRateChart},
-		{"stall_count.png", renderStallCountChart},
-		{"transient_stall_rate.png", renderMicroStallRateChart},
-		{"transient_stall_time.png", renderMicroStallTimeChart},
-		{"transient_stall_count.png", renderMicroStallCountChart},
-		{"jitter.png", renderJitterChart},
-		{"cov.png", renderCoVChart},
-		{"plateau_count.png", renderPlateauCountChart},
-		{"plateau_longest.png", renderPlateauLongestChart},
-		{"plateau_stable.png", renderPlateauStableChart},
-		// Setup breakdown (connection setup timings)
-		{"dns_lookup_time.png", renderDNSLookupChart},
-		{"tcp_connect_time.png", renderTCPConnectChart},
-		{"tls_handshake_time.png", renderTLSHandshakeChart},
-		// Percentiles (Speed)
-		{"speed_percentiles_overall.png", func(s *uiState) image.Image { return renderPercentilesChartWithFamily(s, "overall") }},
-		{"speed_percentiles_ipv4.png", func(s *uiState) image.Image { return renderPercentilesChartWithFamily(s, "ipv4") }},
-		{"speed_percentiles_ipv6.png", func(s *uiState) image.Image { return renderPercentilesChartWithFamily(s, "ipv6") }},
-		// Percentiles (TTFB)
-		{"ttfb_percentiles_overall.png", func(s *uiState) image.Image { return renderTTFBPercentilesChartWithFamily(s, "overall") }},
-		{"ttfb_percentiles_ipv4.png", func(s *uiState) image.Image { return renderTTFBPercentilesChartWithFamily(s, "ipv4") }},
-		{"ttfb_percentiles_ipv6.png", func(s *uiState) image.Image { return renderTTFBPercentilesChartWithFamily(s, "ipv6") }},
-		// Tail & gaps
-		{"tail_heaviness_speed.png", renderTailHeavinessChart},
-		{"tail_heaviness_ttfb.png", renderTTFBTailHeavinessChart},
-		{"ttfb_p95_p50_gap.png", renderTTFBP95GapChart},
-		// Family deltas
-		{"delta_speed_abs.png", renderFamilyDeltaSpeedChart},
-		{"delta_ttfb_abs.png", renderFamilyDeltaTTFBChart},
-		{"delta_speed_pct.png", renderFamilyDeltaSpeedPctChart},
-		{"delta_ttfb_pct.png", renderFamilyDeltaTTFBPctChart},
-		// SLA & SLA deltas
-		{"sla_speed.png", renderSLASpeedChart},
-		{"sla_ttfb.png", renderSLATTFBChart},
-		{"sla_speed_delta.png", renderSLASpeedDeltaChart},
-		{"sla_ttfb_delta.png", renderSLATTFBDeltaChart},
-		// Signals
-		{"cache_hit_rate.png", renderCacheHitRateChart},
-		{"enterprise_proxy_rate.png", renderEnterpriseProxyRateChart},
-		{"server_proxy_rate.png", renderServerProxyRateChart},
-		{"warm_cache_suspected_rate.png", renderWarmCacheSuspectedRateChart},
-		// Errors
-		{"error_rate.png", renderErrorRateChart},
-		{"error_share_by_http_protocol.png", renderErrorShareByHTTPProtocolChart},
-		{"stall_share_by_http_protocol.png", renderStallShareByHTTPProtocolChart},
-		{"partial_share_by_http_protocol.png", renderPartialShareByHTTPProtocolChart},
-		// Per-URL errors (selected batch top-N)
-		{"errors_by_url.png", renderErrorsByURLChart},
-	}
+	baseSet := screenshotCharts()
+	selfTestChart, preTTFBChart, variantSet := screenshotOptionalCharts()
+	all := allScreenshotCharts()
 
 	// Optionally include the Local Throughput Self-Test chart
 	if includeSelfTest {
-		baseSet = append(baseSet, struct {
-			name string
-			fn   func(*uiState) image.Image
-		}{name: "local_throughput_selftest.png", fn: renderSelfTestChart})
+		baseSet = append(baseSet, selfTestChart)
 	}
 
 	// Optionally include Pre‑TTFB stall rate if requested
 	if includePreTTFB {
-		baseSet = append(baseSet, struct {
-			name string
-			fn   func(*uiState) image.Image
-		}{name: "pretffb_stall_rate.png", fn: renderPreTTFBStallRateChart})
+		baseSet = append(baseSet, preTTFBChart)
+	}
+	if !strings.EqualFold(strings.TrimSpace(variants), "none") {
+		baseSet = append(baseSet, variantSet...)
+	}
+	baseSet, err = selectScreenshotCharts(all, baseSet, screenshotOnly)
+	if err != nil {
+		return err
+	}
+	if screenshotOutFile != "" && len(baseSet) != 1 {
+		return fmt.Errorf("--out writes a single chart; --screenshot-only selects %d (use --screenshot-outdir for several)", len(baseSet))
 	}
 
 	// Use default chart size from chartSize when state.window is nil.
 	_ = chart.ColorBlack // silence unused import if chart not referenced elsewhere
 
-	// Helper to write PNGs
-	encodeWrite := func(name string, img image.Image) error {
-		if img == nil {
-			return nil
+	// SVG output: capture the go-chart rendering behind each PNG. Charts that are not a single
+	// go-chart render (blank placeholders, composites) are written as PNG only.
+	withSVG := false
+	switch f := strings.ToLower(strings.TrimSpace(screenshotFormat)); f {
+	case "", "png":
+	case "svg":
+		withSVG = true
+	default:
+		return fmt.Errorf("unknown screenshot format %q (use png or svg)", f)
+	}
+	svgCapture.enabled, svgCapture.charts = withSVG, nil
+	defer func() { svgCapture.enabled, svgCapture.charts = false, nil }()
+	svgSkipped := 0
+	watermark := "Situation: " + activeSituationLabel(st)
+
+	// Helper to write PNGs (and the captured SVG)
+	write := func(name string, shot renderedShot) error {
+		if shot.err != nil {
+			return shot.err
 		}
-		var buf bytes.Buffer
-		if err := png.Encode(&buf, img); err != nil {
-			return fmt.Errorf("png encode %s: %w", name, err)
+		if shot.png == nil {
+			return nil
 		}
 		outPath := filepath.Join(outDir, name)
-		if err := os.WriteFile(outPath, buf.Bytes(), 0o644); err != nil {
+		if screenshotOutFile != "" {
+			outPath = screenshotOutFile
+		}
+		if err := os.WriteFile(outPath, shot.png, 0o644); err != nil {
 			return fmt.Errorf("write %s: %w", outPath, err)
 		}
-		return nil
-	}
-
-	// Render base set in current axis/scale settings
-	for _, item := range baseSet {
-		if err := encodeWrite(item.name, item.fn(st)); err != nil {
-			return err
+		if !withSVG {
+			return nil
 		}
-	}
-
-	// Action variants: time axis and relative scale for averages (more visual dynamics)
-	if !strings.EqualFold(strings.TrimSpace(variants), "none") {
-		prevXAxis := st.xAxisMode
-		st.xAxisMode = "time"
-		if err := encodeWrite("speed_avg_time.png", renderSpeedChart(st)); err != nil {
-			return err
+		if len(shot.svg) != 1 {
+			svgSkipped++
+			return nil
 		}
-		if err := encodeWrite("ttfb_avg_time.png", renderTTFBChart(st)); err != nil {
-			return err
+		svgPath := strings.TrimSuffix(outPath, filepath.Ext(outPath)) + ".svg"
+		if err := os.WriteFile(svgPath, addSVGWatermark(shot.svg[0], watermark), 0o644); err != nil {
+			return fmt.Errorf("write %s: %w", svgPath, err)
 		}
-		st.xAxisMode = prevXAxis
+		return nil
+	}
 
-		prevYScale := st.yScaleMode
-		st.yScaleMode = "relative"
-		if err := encodeWrite("speed_avg_relative.png", renderSpeedChart(st)); err != nil {
-			return err
-		}
-		if err := encodeWrite("ttfb_avg_relative.png", renderTTFBChart(st)); err != nil {
+	// Render the selected set (variants switch axis/scale only for their own render)
+	workers := screenshotWorkers(screenshotJobs, len(baseSet))
+	if withSVG && workers > 1 {
+		logf(slog.LevelInfo, "viewer", "screenshots: --screenshot-format svg renders one chart at a time (--screenshot-jobs ignored)")
+	}
+	for i, shot := range renderScreenshots(st, baseSet, workers) {
+		if err := write(baseSet[i].name, shot); err != nil {
 			return err
 		}
-		st.yScaleMode = prevYScale
 	}
 
+	if svgSkipped > 0 {
+		logf(slog.LevelInfo, "viewer", "screenshots: %d chart(s) have no SVG rendering (no data or composite image); PNG only", svgSkipped)
+	}
 	return nil
 }
@@ -9,6 +9,7 @@ import (
 	_ "image/png" // register PNG decoder
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -238,3 +239,33 @@ func TestScreenshots_IncludesErrorsByURL(t *testing.T) {
 		t.Fatalf("missing errors by url screenshot: %v", err)
 	}
 }
+
+// TestScreenshots_SVGFormat ensures --screenshot-format svg writes a vector copy next to the PNG.
+func TestScreenshots_SVGFormat(t *testing.T) {
+	screenshotWidthOverride = 800
+	screenshotFormat = "svg"
+	defer func() { screenshotFormat = "png" }()
+	tmpResults, err := os.CreateTemp(t.TempDir(), "results-*.jsonl")
+	if err != nil {
+		t.Fatalf("create temp results: %v", err)
+	}
+	writeResultLine(t, tmpResults, "20250101_000000", 1200, 80)
+	writeResultLine(t, tmpResults, "20250102_000000", 900, 90)
+	if err := tmpResults.Close(); err != nil {
+		t.Fatalf("close results: %v", err)
+	}
+	outDir := t.TempDir()
+	if err := RunScreenshotsMode(tmpResults.Name(), outDir, "All", 5, false, 10, 1000, "none", "light", false, false, false, false, true, true, true, true); err != nil {
+		t.Fatalf("RunScreenshotsMode: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(outDir, "speed_avg.png")); err != nil {
+		t.Fatalf("PNG must still be written: %v", err)
+	}
+	b, err := os.ReadFile(filepath.Join(outDir, "speed_avg.svg"))
+	if err != nil {
+		t.Fatalf("missing speed_avg.svg: %v", err)
+	}
+	if s := string(b); !strings.HasPrefix(strings.TrimSpace(s), "<svg") || !strings.Contains(s, "Situation: All") {
+		t.Fatalf("unexpected SVG content: %.200s", s)
+	}
+}
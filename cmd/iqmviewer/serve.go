@@ -0,0 +1,354 @@
+package main
+
+import (
+	"bytes"
+	"crypto/subtle"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/iafilius/InternetQualityMonitor/src/analysis"
+	"github.com/iafilius/InternetQualityMonitor/src/control"
+	"github.com/iafilius/InternetQualityMonitor/src/monitor"
+)
+
+//go:embed web/index.html
+var webIndexHTML []byte
+
+// webServer backs --serve: a browser dashboard for hosts without a display. Charts are the
+// headless screenshot renders, so they look exactly like the desktop viewer's.
+type webServer struct {
+	filePath string
+	batches  int
+	// token, when set, is required as a bearer token, a ?token= parameter or the cookie the
+	// latter sets; listenHost is the host of the listen address, accepted as Host.
+	token      string
+	listenHost string
+
+	// mu guards the loaded data and serializes rendering (the render helpers share package
+	// globals such as the theme).
+	mu       sync.Mutex
+	sums     []analysis.BatchSummary
+	stats    analysis.LoadStats
+	loadErr  error
+	modTime  time.Time
+	size     int64
+	loadedAt time.Time
+	charts   map[string]screenshotChart
+	order    []string
+}
+
+func newWebServer(filePath string, batches int) *webServer {
+	if filePath == "" {
+		filePath = monitor.DefaultResultsFile
+	}
+	if batches <= 0 {
+		batches = 50
+	}
+	s := &webServer{filePath: filePath, batches: batches, charts: map[string]screenshotChart{}}
+	for _, c := range append(screenshotCharts(), screenshotChart{"local_throughput_selftest.png", renderSelfTestChart}, screenshotChart{"pretffb_stall_rate.png", renderPreTTFBStallRateChart}) {
+		id := strings.TrimSuffix(c.name, ".png")
+		s.charts[id] = c
+		s.order = append(s.order, id)
+	}
+	return s
+}
+
+// serveTokenCookie carries the --serve-token for the page's own requests once it was opened
+// with ?token=.
+const serveTokenCookie = "iqm_serve_token"
+
+// RunServeMode serves the dashboard on addr (e.g. ":8080", which binds 127.0.0.1) until the
+// listener fails. Other interfaces need an explicit host and a token. The results file is
+// re-analyzed whenever its size or modification time changes.
+func RunServeMode(addr, filePath string, batches int, token string) error {
+	addr = serveListenAddr(addr)
+	token = strings.TrimSpace(token)
+	if token == "" && !control.LoopbackAddr(addr) {
+		return fmt.Errorf("--serve %s is reachable from other hosts: set --serve-token or bind a loopback address", addr)
+	}
+	s := newWebServer(filePath, batches)
+	s.token = token
+	s.listenHost, _, _ = net.SplitHostPort(addr)
+	s.mu.Lock()
+	err := s.refreshLocked()
+	s.mu.Unlock()
+	if err != nil {
+		logf(slog.LevelWarn, "viewer", "serve: %v (will retry on each request)", err)
+	}
+	display := "http://" + addr + "/"
+	if token != "" {
+		display += "?token=…"
+	}
+	logf(slog.LevelInfo, "viewer", "serving %s on %s", s.filePath, display)
+	// renders queue behind s.mu, so the write timeout leaves room for a few of them
+	hs := &http.Server{Addr: addr, Handler: s.handler(), ReadHeaderTimeout: 10 * time.Second, WriteTimeout: 2 * time.Minute, IdleTimeout: 2 * time.Minute}
+	if err := hs.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// serveListenAddr binds a bare ":port" to the loopback interface only.
+func serveListenAddr(addr string) string {
+	if strings.HasPrefix(addr, ":") {
+		return "127.0.0.1" + addr
+	}
+	return addr
+}
+
+func (s *webServer) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(webIndexHTML)
+	})
+	mux.HandleFunc("/api/summary", s.handleSummary)
+	mux.HandleFunc("/api/charts", s.handleCharts)
+	mux.HandleFunc("/chart/", s.handleChart)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.token == "" && !s.ownHostHeader(r.Host) {
+			http.Error(w, "foreign Host", http.StatusForbidden)
+			return
+		}
+		if !s.authorized(w, r) {
+			http.Error(w, "invalid or missing token", http.StatusUnauthorized)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// authorized checks the --serve-token. A valid ?token= parameter also sets a cookie, so the
+// page's own fetches and chart images pass without it.
+func (s *webServer) authorized(w http.ResponseWriter, r *http.Request) bool {
+	if s.token == "" {
+		return true
+	}
+	if s.tokenMatches(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")) {
+		return true
+	}
+	if c, err := r.Cookie(serveTokenCookie); err == nil && s.tokenMatches(c.Value) {
+		return true
+	}
+	if q := r.URL.Query().Get("token"); q != "" && s.tokenMatches(q) {
+		http.SetCookie(w, &http.Cookie{Name: serveTokenCookie, Value: q, Path: "/", HttpOnly: true, SameSite: http.SameSiteStrictMode})
+		return true
+	}
+	return false
+}
+
+func (s *webServer) tokenMatches(got string) bool {
+	return subtle.ConstantTimeCompare([]byte(got), []byte(s.token)) == 1
+}
+
+// ownHostHeader rejects a Host that is a DNS name other than localhost or the listen host, so a
+// page elsewhere cannot read the dashboard through DNS rebinding.
+func (s *webServer) ownHostHeader(hostport string) bool {
+	host := hostport
+	if h, _, err := net.SplitHostPort(hostport); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(strings.Trim(host, "[]"), ".")
+	if host == "" {
+		return false
+	}
+	return net.ParseIP(host) != nil || strings.EqualFold(host, "localhost") || strings.EqualFold(host, s.listenHost)
+}
+
+// refreshLocked re-analyzes the file when it changed since the last load; s.mu must be held.
+func (s *webServer) refreshLocked() error {
+	fi, err := os.Stat(s.filePath)
+	if err != nil {
+		s.loadErr = err
+		return err
+	}
+	if s.loadErr == nil && !s.loadedAt.IsZero() && fi.Size() == s.size && fi.ModTime().Equal(s.modTime) {
+		return nil
+	}
+	var st analysis.LoadStats
+	sums, err := analysis.AnalyzeRecentResultsFullWithOptions(s.filePath, monitor.SchemaVersion, s.batches, analysis.AnalyzeOptions{MicroStallMinGapMs: analysis.DefaultMicroStallGapMs, LowSpeedThresholdKbps: analysis.DefaultLowSpeedThresholdKbps, Stats: &st})
+	s.modTime, s.size, s.loadErr = fi.ModTime(), fi.Size(), err
+	if err != nil {
+		return err
+	}
+	s.sums, s.stats, s.loadedAt = sums, st, time.Now()
+	return nil
+}
+
+// stateFor builds a headless uiState for one request from the query parameters situation,
+// x (batch|run_tag|time), unit (speed unit), w (chart width) and theme (dark|light).
+func (s *webServer) stateFor(q url.Values) *uiState {
+	st := &uiState{
+		filePath:              s.filePath,
+		batchesN:              s.batches,
+		summaries:             s.sums,
+		xAxisMode:             "batch",
+		yScaleMode:            "absolute",
+		showOverall:           true,
+		showIPv4:              true,
+		showIPv6:              true,
+		speedUnit:             "Auto",
+		showAvg:               true,
+		showMedian:            true,
+		showHints:             false,
+		showConfigMarkers:     true,
+		slaSpeedThresholdKbps: 10000,
+		slaTTFBThresholdMs:    200,
+		runTagSituation:       map[string]string{},
+	}
+	for _, r := range s.sums {
+		if r.RunTag != "" {
+			st.runTagSituation[r.RunTag] = strings.TrimSpace(r.Situation)
+		}
+	}
+	st.situation = strings.TrimSpace(q.Get("situation"))
+	switch x := q.Get("x"); x {
+	case "batch", "run_tag", "time":
+		st.xAxisMode = x
+	}
+	if u := q.Get("unit"); u != "" {
+		st.speedUnit = u
+	}
+	if w, err := strconv.Atoi(q.Get("w")); err == nil && w > 0 {
+		if w < 480 {
+			w = 480
+		}
+		if w > 2400 {
+			w = 2400
+		}
+		st.snapshotChartW = w
+		st.snapshotChartH = w * 34 / 110
+		if st.snapshotChartH < 280 {
+			st.snapshotChartH = 280
+		}
+	}
+	return st
+}
+
+type webSummary struct {
+	File       string                  `json:"file"`
+	LoadedAt   time.Time               `json:"loaded_at"`
+	Version    int64                   `json:"version"` // changes whenever the file is re-analyzed
+	Error      string                  `json:"error,omitempty"`
+	Stats      analysis.LoadStats      `json:"stats"`
+	Situations []string                `json:"situations"`
+	Batches    []analysis.BatchSummary `json:"batches"`
+}
+
+func (s *webServer) handleSummary(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	err := s.refreshLocked()
+	st := s.stateFor(r.URL.Query())
+	out := webSummary{File: s.filePath, LoadedAt: s.loadedAt, Version: s.modTime.UnixNano(), Stats: s.stats, Batches: filteredSummaries(st)}
+	seen := map[string]bool{}
+	for _, b := range s.sums {
+		if sit := strings.TrimSpace(b.Situation); sit != "" && !seen[sit] {
+			seen[sit] = true
+			out.Situations = append(out.Situations, sit)
+		}
+	}
+	s.mu.Unlock()
+	sort.Strings(out.Situations)
+	if err != nil {
+		out.Error = err.Error()
+	}
+	writeJSON(w, out)
+}
+
+func (s *webServer) handleCharts(w http.ResponseWriter, r *http.Request) {
+	type chartInfo struct {
+		ID    string `json:"id"`
+		Title string `json:"title"`
+	}
+	out := make([]chartInfo, 0, len(s.order))
+	for _, id := range s.order {
+		out = append(out, chartInfo{ID: id, Title: webChartTitle(id)})
+	}
+	writeJSON(w, out)
+}
+
+func (s *webServer) handleChart(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/chart/"), ".png")
+	c, ok := s.charts[id]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	img, err := s.render(c, r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	var buf bytes.Buffer
+	if img == nil || png.Encode(&buf, img) != nil {
+		http.Error(w, "render failed", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Write(buf.Bytes())
+}
+
+// render renders c for the query's options under s.mu. The unlock and the theme restore are
+// deferred: net/http recovers a panicking renderer, which must not leave the lock held or the
+// requested theme in place.
+func (s *webServer) render(c screenshotChart, q url.Values) (image.Image, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.refreshLocked(); err != nil && len(s.sums) == 0 {
+		return nil, err
+	}
+	prevTheme := screenshotThemeGlobal
+	defer func() { screenshotThemeGlobal = prevTheme }()
+	if t := q.Get("theme"); t == "dark" || t == "light" {
+		screenshotThemeGlobal = t
+	}
+	st := s.stateFor(q)
+	updateConfigMarkers(st)
+	return c.fn(st), nil
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", " ")
+	if err := enc.Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// webChartTitle turns a chart id such as "ttfb_p95_p50_gap" into "TTFB P95 P50 Gap".
+func webChartTitle(id string) string {
+	upper := map[string]string{"ttfb": "TTFB", "sla": "SLA", "cov": "CoV", "ipv4": "IPv4", "ipv6": "IPv6", "http": "HTTP", "udp": "UDP", "tcp": "TCP", "tls": "TLS", "dns": "DNS", "url": "URL", "phy": "PHY", "rssi": "RSSI", "pretffb": "Pre‑TTFB", "selftest": "Self-Test", "abs": "(abs)", "pct": "(%)", "avg": "Average", "vs": "vs"}
+	parts := strings.Split(id, "_")
+	for i, p := range parts {
+		if u, ok := upper[p]; ok {
+			parts[i] = u
+			continue
+		}
+		if len(p) > 1 && p[0] == 'p' && p[1] >= '0' && p[1] <= '9' {
+			parts[i] = strings.ToUpper(p)
+			continue
+		}
+		parts[i] = strings.ToUpper(p[:1]) + p[1:]
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,66 @@
+//go:build integration
+// +build integration
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestWebServer_Endpoints(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "results-*.jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeResultLine(t, f, "20250101_100000", 5000, 40)
+	writeResultLine(t, f, "20250101_110000", 7000, 30)
+	f.Close()
+	ts := httptest.NewServer(newWebServer(f.Name(), 10).handler())
+	defer ts.Close()
+
+	var sum webSummary
+	getJSON(t, ts.URL+"/api/summary", &sum)
+	if len(sum.Batches) != 2 || sum.Version == 0 || sum.Error != "" {
+		t.Fatalf("summary: %+v", sum)
+	}
+	var charts []struct{ ID, Title string }
+	getJSON(t, ts.URL+"/api/charts", &charts)
+	if len(charts) == 0 || charts[0].ID != "speed_avg" {
+		t.Fatalf("charts: %+v", charts)
+	}
+	res, err := http.Get(ts.URL + "/chart/speed_avg.png?w=900&theme=light")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	buf.ReadFrom(res.Body)
+	res.Body.Close()
+	img, err := png.Decode(&buf)
+	if err != nil || img.Bounds().Dx() != 900 {
+		t.Fatalf("chart png: err=%v", err)
+	}
+	if res, _ := http.Get(ts.URL + "/chart/nope.png"); res.StatusCode != http.StatusNotFound {
+		t.Fatalf("unknown chart status %d", res.StatusCode)
+	}
+	if res, _ := http.Get(ts.URL + "/"); res.StatusCode != http.StatusOK || res.Header.Get("Content-Type") != "text/html; charset=utf-8" {
+		t.Fatalf("index status %d", res.StatusCode)
+	}
+}
+
+func getJSON(t *testing.T, u string, v any) {
+	t.Helper()
+	res, err := http.Get(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if err := json.NewDecoder(res.Body).Decode(v); err != nil {
+		t.Fatalf("%s: %v", u, err)
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestWebChartTitle(t *testing.T) {
+	cases := map[string]string{
+		"ttfb_p95_p50_gap":          "TTFB P95 P50 Gap",
+		"speed_percentiles_ipv6":    "Speed Percentiles IPv6",
+		"delta_speed_pct":           "Delta Speed (%)",
+		"udp_blocked_rate":          "UDP Blocked Rate",
+		"wifi_rssi_vs_throughput":   "Wifi RSSI vs Throughput",
+		"local_throughput_selftest": "Local Throughput Self-Test",
+	}
+	for id, want := range cases {
+		if got := webChartTitle(id); got != want {
+			t.Errorf("webChartTitle(%q)=%q want %q", id, got, want)
+		}
+	}
+}
+
+func TestWebServerStateFor_QueryParams(t *testing.T) {
+	s := newWebServer("results.jsonl", 0)
+	if s.batches != 50 || len(s.charts) == 0 || len(s.order) != len(s.charts) {
+		t.Fatalf("defaults: batches=%d charts=%d order=%d", s.batches, len(s.charts), len(s.order))
+	}
+	st := s.stateFor(url.Values{"situation": {"Home"}, "x": {"time"}, "unit": {"Mbps"}, "w": {"100"}})
+	if st.situation != "Home" || st.xAxisMode != "time" || st.speedUnit != "Mbps" || st.snapshotChartW != 480 {
+		t.Fatalf("state not taken from query: %+v", st)
+	}
+	if w, h := chartSize(st); w != 480 || h != 280 {
+		t.Fatalf("chart size %dx%d", w, h)
+	}
+	st = s.stateFor(url.Values{"x": {"bogus"}})
+	if st.xAxisMode != "batch" || st.speedUnit != "Auto" || st.snapshotChartW != 0 {
+		t.Fatalf("invalid params must keep defaults: %+v", st)
+	}
+}
+
+func TestServeListenAddr_BareBindsLoopback(t *testing.T) {
+	cases := map[string]string{":8080": "127.0.0.1:8080", "0.0.0.0:8080": "0.0.0.0:8080", "host.lan:80": "host.lan:80"}
+	for in, want := range cases {
+		if got := serveListenAddr(in); got != want {
+			t.Errorf("serveListenAddr(%q)=%q want %q", in, got, want)
+		}
+	}
+	if err := RunServeMode("0.0.0.0:0", "results.jsonl", 1, ""); err == nil {
+		t.Fatal("a non-loopback --serve without a token must be refused")
+	}
+}
+
+func TestWebServer_TokenAndHost(t *testing.T) {
+	s := newWebServer("results.jsonl", 1)
+	s.listenHost = "127.0.0.1"
+	h := s.handler()
+	get := func(host, target string, hdr map[string]string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, target, nil)
+		r.Host = host
+		for k, v := range hdr {
+			r.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+	if w := get("127.0.0.1:8080", "/api/charts", nil); w.Code != http.StatusOK {
+		t.Fatalf("loopback without token: %d", w.Code)
+	}
+	if w := get("evil.example:8080", "/api/charts", nil); w.Code != http.StatusForbidden {
+		t.Fatalf("foreign Host: %d", w.Code)
+	}
+	s.token = "secret"
+	if w := get("host.lan:8080", "/api/charts", nil); w.Code != http.StatusUnauthorized {
+		t.Fatalf("missing token: %d", w.Code)
+	}
+	if w := get("host.lan:8080", "/api/charts", map[string]string{"Authorization": "Bearer secret"}); w.Code != http.StatusOK {
+		t.Fatalf("bearer token: %d", w.Code)
+	}
+	w := get("host.lan:8080", "/?token=secret", nil)
+	cookies := w.Result().Cookies()
+	if w.Code != http.StatusOK || len(cookies) != 1 || cookies[0].Name != serveTokenCookie {
+		t.Fatalf("query token: %d cookies=%v", w.Code, cookies)
+	}
+	if w := get("host.lan:8080", "/api/charts", map[string]string{"Cookie": serveTokenCookie + "=secret"}); w.Code != http.StatusOK {
+		t.Fatalf("token cookie: %d", w.Code)
+	}
+	if w := get("host.lan:8080", "/api/charts?token=wrong", nil); w.Code != http.StatusUnauthorized {
+		t.Fatalf("wrong token: %d", w.Code)
+	}
+}
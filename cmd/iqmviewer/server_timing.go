@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"math"
+	"strings"
+	"time"
+
+	chart "github.com/wcharczuk/go-chart/v2"
+
+	helpers "github.com/iafilius/InternetQualityMonitor/cmd/iqmviewer/uihelpers"
+	"github.com/iafilius/InternetQualityMonitor/src/analysis"
+)
+
+// renderServerTimingChart draws BatchSummary.ServerTiming per batch: the average TTFB of the lines
+// whose origin sent Server-Timing, split into the server-reported processing time and the rest
+// (network). Batches without such lines are omitted.
+func renderServerTimingChart(state *uiState) image.Image {
+	cw, chh := chartSize(state)
+	rows := filteredSummaries(state)
+	if len(rows) == 0 {
+		return blank(cw, chh)
+	}
+	timeMode, times, xs, xAxis := buildXAxis(rows, state.xAxisMode)
+	type pts struct {
+		x  []float64
+		t  []time.Time
+		ys []float64
+	}
+	var ttfb, network, server pts
+	add := func(p *pts, i int, y float64) {
+		if timeMode {
+			p.t = append(p.t, times[i])
+		} else {
+			p.x = append(p.x, xs[i])
+		}
+		p.ys = append(p.ys, y)
+	}
+	maxY := 0.0
+	for i, r := range rows {
+		st := r.ServerTiming
+		if st == nil {
+			continue
+		}
+		add(&ttfb, i, st.AvgTTFBMs)
+		add(&network, i, st.AvgNetworkMs)
+		add(&server, i, st.AvgServerMs)
+		maxY = math.Max(maxY, st.AvgTTFBMs)
+	}
+	if len(ttfb.ys) == 0 {
+		return drawHint(blank(cw, chh), "No Server-Timing headers: none of the targets reports its processing time.")
+	}
+	var series []chart.Series
+	addSeries := func(name string, p pts, st chart.Style) {
+		if len(p.ys) == 1 {
+			p.ys = append(p.ys, p.ys[0])
+			if timeMode {
+				p.t = append(p.t, p.t[0].Add(1*time.Second))
+			} else {
+				p.x = append(p.x, p.x[0]+1)
+			}
+		}
+		if timeMode {
+			series = append(series, chart.TimeSeries{Name: name, XValues: p.t, YValues: p.ys, Style: st})
+		} else {
+			series = append(series, chart.ContinuousSeries{Name: name, XValues: p.x, YValues: p.ys, Style: st})
+		}
+	}
+	addSeries("TTFB", ttfb, pointStyle(chart.ColorAlternateGray))
+	addSeries("Network", network, pointStyle(chart.ColorBlue))
+	addSeries("Server-reported", server, pointStyle(chart.ColorOrange))
+	vals := helpers.BuildNumericTicks(0, math.Max(maxY*1.15, 10), 6)
+	if len(vals) < 2 {
+		vals = []float64{0, 100}
+	}
+	yTicks := make([]chart.Tick, len(vals))
+	for i, v := range vals {
+		yTicks[i] = chart.Tick{Value: v, Label: helpers.FormatNumericTick(v)}
+	}
+	padBottom := 28
+	switch state.xAxisMode {
+	case "run_tag":
+		padBottom = 90
+	case "time":
+		padBottom = 48
+	}
+	if state.showHints {
+		padBottom += 18
+	}
+	ch := chart.Chart{
+		Title:      "TTFB: Network vs Server (ms)",
+		Background: chart.Style{Padding: chart.Box{Top: 14, Left: 16, Right: 12, Bottom: padBottom}},
+		XAxis:      xAxis,
+		YAxis:      chart.YAxis{Name: "ms", Range: &chart.ContinuousRange{Min: vals[0], Max: vals[len(vals)-1]}, Ticks: yTicks},
+		Series:     series,
+	}
+	themeChart(&ch)
+	ch.Title += allGroupsNote(state)
+	ch.Width, ch.Height = cw, chh
+	attachLegend(state, &ch)
+	var buf bytes.Buffer
+	if err := renderChart(state, &ch, &buf); err != nil {
+		return blank(cw, chh)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		return blank(cw, chh)
+	}
+	if state.showHints {
+		img = drawHint(img, "Hint: TTFB rising with Network while Server stays flat points at the path, not the backend.")
+	}
+	return drawWatermark(img, "Situation: "+activeSituationLabel(state))
+}
+
+// serverTimingLines is the crosshair readout of the TTFB: Network vs Server chart for one batch.
+func serverTimingLines(st *analysis.ServerTimingStats) []string {
+	if st == nil {
+		return []string{"No Server-Timing headers"}
+	}
+	lines := []string{fmt.Sprintf("TTFB %.0f ms = network %.0f ms + server %.0f ms (%.0f%%), %d lines", st.AvgTTFBMs, st.AvgNetworkMs, st.AvgServerMs, st.ServerSharePct, st.Lines)}
+	if len(st.Metrics) > 0 {
+		parts := make([]string, 0, len(st.Metrics))
+		for _, m := range st.Metrics {
+			if m.AvgDurMs > 0 {
+				parts = append(parts, fmt.Sprintf("%s %.1f ms", m.Name, m.AvgDurMs))
+			} else {
+				parts = append(parts, m.Name)
+			}
+		}
+		lines = append(lines, "Metrics: "+strings.Join(parts, ", "))
+	}
+	return lines
+}
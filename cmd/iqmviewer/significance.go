@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/iafilius/InternetQualityMonitor/src/analysis"
+	chart "github.com/wcharczuk/go-chart/v2"
+	"github.com/wcharczuk/go-chart/v2/drawing"
+)
+
+// Significance markers on the Family Delta charts: a batch's dot keeps the series color when its
+// IPv6/IPv4 difference is significant (BatchSummary.FamilySpeedTest/FamilyTTFBTest) and turns
+// gray when it is not, or when a family had too few lines to tell.
+
+var notSignificantColor = drawing.Color{R: 150, G: 150, B: 150, A: 255}
+
+// significanceDots colors the dots of a delta series over rows. Dots are matched to batches by
+// X value, which survives decimation; the extra point of a single-batch chart is that batch. The
+// Chart Appearance remaps the provider's colors like DotColor (chartAppearance.styleSeries).
+func significanceDots(st *chart.Style, col drawing.Color, rows []analysis.BatchSummary, timeMode bool, times []time.Time, xs []float64, test func(analysis.BatchSummary) *analysis.SignificanceTest) {
+	if len(rows) == 0 {
+		return
+	}
+	byX := make(map[float64]int, len(rows))
+	for i := range rows {
+		switch {
+		case timeMode && i < len(times):
+			byX[chart.TimeToFloat64(times[i])] = i
+		case !timeMode && i < len(xs):
+			byX[xs[i]] = i
+		}
+	}
+	st.DotColorProvider = func(_, _ chart.Range, _ int, x, _ float64) drawing.Color {
+		i, ok := byX[x]
+		if !ok && len(rows) == 1 {
+			i, ok = 0, true
+		}
+		if t := test(rows[i]); ok && t != nil && t.Significant {
+			return col
+		}
+		return notSignificantColor
+	}
+}
+
+// significanceLegend is the legend entry for the gray dots; nil when no batch was tested.
+func significanceLegend(rows []analysis.BatchSummary, test func(analysis.BatchSummary) *analysis.SignificanceTest) chart.Series {
+	for _, r := range rows {
+		if test(r) != nil {
+			return chart.ContinuousSeries{Name: fmt.Sprintf("gray: not significant (p ≥ %g)", analysis.SignificanceAlpha), XValues: []float64{}, YValues: []float64{}, Style: pointStyle(notSignificantColor)}
+		}
+	}
+	return nil
+}
+
+func familySpeedTest(r analysis.BatchSummary) *analysis.SignificanceTest { return r.FamilySpeedTest }
+func familyTTFBTest(r analysis.BatchSummary) *analysis.SignificanceTest  { return r.FamilyTTFBTest }
+
+// significanceLine describes a test for the Diagnostics dialog, fmtV formatting medians and
+// differences.
+func significanceLine(label string, t *analysis.SignificanceTest, fmtV func(float64) string) string {
+	verdict := "not significant"
+	if t.Significant {
+		verdict = "significant"
+	}
+	return fmt.Sprintf("  %s: %s %s (n=%d) vs %s %s (n=%d), difference %s (95%% CI %s … %s), p=%.3f — %s\n",
+		label, t.A, fmtV(t.MedianA), t.NA, t.B, fmtV(t.MedianB), t.NB, fmtV(t.Delta), fmtV(t.CILow), fmtV(t.CIHigh), t.PValue, verdict)
+}
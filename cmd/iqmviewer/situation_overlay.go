@@ -0,0 +1,234 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"math"
+	"strings"
+
+	"github.com/wcharczuk/go-chart/v2"
+	"github.com/wcharczuk/go-chart/v2/drawing"
+
+	helpers "github.com/iafilius/InternetQualityMonitor/cmd/iqmviewer/uihelpers"
+	"github.com/iafilius/InternetQualityMonitor/src/analysis"
+)
+
+// Situation overlay (Settings → Chart Options → "Overlay Situations"): with the Situation filter
+// on "All", the headline time-series charts draw one series per Situation instead of the
+// Overall/IPv4/IPv6 families, so environments (e.g. Home-WiFi vs Home-Ethernet) can be compared
+// on one axis. Batches keep their place on the shared X axis; a Situation's series is empty where
+// other Situations' batches ran. "Overlay Interfaces" does the same per source binding (monitor
+// --interface/--source-ip) and takes precedence when both are on.
+
+type situationOverlayMetric struct {
+	title   string // chart title; speed titles get the unit appended
+	yName   string // Y axis name; empty for speed (unit)
+	percent bool   // 0–100 axis in absolute mode
+	speed   bool   // kbps value shown in the chosen speed unit
+	get     func(bs analysis.BatchSummary) (float64, bool)
+}
+
+// situationOverlayMetrics are keyed by the chart's render mode.
+var situationOverlayMetrics = map[string]situationOverlayMetric{
+	"speed_avg":    {title: "Speed – Average by Situation", speed: true, get: func(bs analysis.BatchSummary) (float64, bool) { return positive(bs.AvgSpeed) }},
+	"speed_median": {title: "Speed – Median by Situation", speed: true, get: func(bs analysis.BatchSummary) (float64, bool) { return positive(bs.MedianSpeed) }},
+	"ttfb_avg":     {title: "TTFB – Average by Situation (ms)", yName: "ms", get: func(bs analysis.BatchSummary) (float64, bool) { return positive(bs.AvgTTFB) }},
+	"ttfb_median":  {title: "TTFB – Median by Situation (ms)", yName: "ms", get: func(bs analysis.BatchSummary) (float64, bool) { return positive(bs.AvgP50TTFBMs) }},
+	"error_rate": {title: "Error Rate by Situation (%)", yName: "%", percent: true, get: func(bs analysis.BatchSummary) (float64, bool) {
+		if bs.Lines <= 0 {
+			return 0, false
+		}
+		return float64(bs.ErrorLines) / float64(bs.Lines) * 100, true
+	}},
+	"jitter":     {title: "Jitter by Situation (%)", yName: "%", percent: true, get: func(bs analysis.BatchSummary) (float64, bool) { return bs.AvgJitterPct, bs.Lines > 0 }},
+	"stall_rate": {title: "Stall Rate by Situation (%)", yName: "%", percent: true, get: func(bs analysis.BatchSummary) (float64, bool) { return bs.StallRatePct, bs.Lines > 0 }},
+	"quality_score": {title: "Quality Score by Situation", yName: "score", percent: true, get: func(bs analysis.BatchSummary) (float64, bool) {
+		if bs.QualityScore == nil {
+			return 0, false
+		}
+		return bs.QualityScore.Score, true
+	}},
+}
+
+// situationOverlayColors are stock colors so the colorblind palette still maps them.
+var situationOverlayColors = []drawing.Color{chart.ColorBlue, chart.ColorOrange, chart.ColorGreen, chart.ColorRed, chart.ColorCyan, chart.ColorYellow, chart.ColorAlternateGray, chart.ColorBlack}
+
+// batchSituation is the Situation of a batch, falling back to the per-RunTag map.
+func batchSituation(state *uiState, bs analysis.BatchSummary) string {
+	if s := strings.TrimSpace(bs.Situation); s != "" {
+		return s
+	}
+	if state != nil {
+		if s, ok := state.runTagSituation[bs.RunTag]; ok && strings.TrimSpace(s) != "" {
+			return s
+		}
+	}
+	return "Unknown"
+}
+
+// overlaySituations lists the Situations of rows in first-seen order.
+func overlaySituations(state *uiState, rows []analysis.BatchSummary) []string {
+	return overlayGroups(rows, func(bs analysis.BatchSummary) string { return batchSituation(state, bs) })
+}
+
+// batchInterface is the source binding of a batch, "Default" when the system chose.
+func batchInterface(bs analysis.BatchSummary) string {
+	if l := bs.BindLabel(); l != "" {
+		return l
+	}
+	return "Default"
+}
+
+// overlayDimension returns what overlay mode splits by ("Interface" or "Situation") and the
+// grouping of a batch; dim is empty when neither overlay is on with its filter on "All".
+func overlayDimension(state *uiState) (dim string, key func(analysis.BatchSummary) string) {
+	if state == nil {
+		return "", nil
+	}
+	if state.interfaceOverlay && (state.bindFilter == "" || state.bindFilter == "All") {
+		return "Interface", batchInterface
+	}
+	if s := strings.TrimSpace(state.situation); state.situationOverlay && (s == "" || strings.EqualFold(s, "All")) {
+		return "Situation", func(bs analysis.BatchSummary) string { return batchSituation(state, bs) }
+	}
+	return "", nil
+}
+
+// overlayGroups lists the groups of rows in first-seen order.
+func overlayGroups(rows []analysis.BatchSummary, key func(analysis.BatchSummary) string) []string {
+	var out []string
+	seen := map[string]bool{}
+	for _, r := range rows {
+		if g := key(r); !seen[g] {
+			seen[g] = true
+			out = append(out, g)
+		}
+	}
+	return out
+}
+
+// situationOverlayActive reports whether overlay mode applies: an overlay is on, its filter is
+// "All" and the filtered batches span at least two of its groups.
+func situationOverlayActive(state *uiState) bool {
+	dim, key := overlayDimension(state)
+	if dim == "" {
+		return false
+	}
+	return len(overlayGroups(filteredSummaries(state), key)) >= 2
+}
+
+// renderSituationOverlay draws chart mode with one series per Situation (or Interface); ok is
+// false when the overlay does not apply to mode or is not active, and the caller renders the
+// normal chart.
+func renderSituationOverlay(state *uiState, mode string) (img image.Image, ok bool) {
+	m, known := situationOverlayMetrics[mode]
+	if !known || !situationOverlayActive(state) {
+		return nil, false
+	}
+	rows := filteredSummaries(state)
+	dim, key := overlayDimension(state)
+	sits := overlayGroups(rows, key)
+	timeMode, times, xs, xAxis := buildXAxis(rows, state.xAxisMode)
+	unitName, factor := speedUnitFor(state)
+	title, yName := strings.Replace(m.title, "by Situation", "by "+dim, 1), m.yName
+	if m.speed {
+		title, yName = fmt.Sprintf("%s (%s)", title, unitName), unitName
+	} else {
+		factor = 1
+	}
+	minY, maxY := math.MaxFloat64, -math.MaxFloat64
+	var series []chart.Series
+	for si, sit := range sits {
+		ys := make([]float64, len(rows))
+		valid := 0
+		for i, r := range rows {
+			v, has := m.get(r)
+			if !has || key(r) != sit {
+				ys[i] = math.NaN()
+				continue
+			}
+			ys[i] = v * factor
+			minY, maxY = math.Min(minY, ys[i]), math.Max(maxY, ys[i])
+			valid++
+		}
+		if valid == 0 {
+			continue
+		}
+		st := pointStyle(situationOverlayColors[si%len(situationOverlayColors)])
+		if valid == 1 {
+			st.DotWidth = 6
+		}
+		if timeMode {
+			series = append(series, chart.TimeSeries{Name: sit, XValues: times, YValues: ys, Style: st})
+		} else {
+			series = append(series, chart.ContinuousSeries{Name: sit, XValues: xs, YValues: ys, Style: st})
+		}
+	}
+	cw, chh := chartSize(state)
+	if len(series) == 0 {
+		return drawHint(blank(cw, chh), "No data for this chart in the filtered batches."), true
+	}
+	var yRange chart.Range
+	var yTicks []chart.Tick
+	if m.percent && !state.useRelative {
+		yRange = &chart.ContinuousRange{Min: 0, Max: 100}
+		for _, v := range []float64{0, 25, 50, 75, 100} {
+			yTicks = append(yTicks, chart.Tick{Value: v, Label: helpers.FormatNumericTick(v)})
+		}
+	} else {
+		lo := 0.0
+		if state.useRelative {
+			lo = minY
+		}
+		hi := maxY
+		if hi <= lo {
+			hi = lo + 1
+		}
+		vals := helpers.BuildNumericTicks(lo, hi, 6)
+		if len(vals) < 2 {
+			vals = []float64{lo, hi}
+		}
+		yRange = &chart.ContinuousRange{Min: vals[0], Max: vals[len(vals)-1]}
+		for _, v := range vals {
+			yTicks = append(yTicks, chart.Tick{Value: v, Label: helpers.FormatNumericTick(v)})
+		}
+	}
+	padBottom := 28
+	switch state.xAxisMode {
+	case "run_tag":
+		padBottom = 90
+	case "time":
+		padBottom = 48
+	}
+	if state.showHints {
+		padBottom += 18
+	}
+	ch := chart.Chart{Title: title, Background: chart.Style{Padding: chart.Box{Top: 14, Left: 16, Right: 12, Bottom: padBottom}}, XAxis: xAxis, YAxis: chart.YAxis{Name: yName, Range: yRange, Ticks: yTicks}, Series: series}
+	themeChart(&ch)
+	ch.Width, ch.Height = cw, chh
+	attachLegend(state, &ch)
+	var buf bytes.Buffer
+	if err := renderChart(state, &ch, &buf); err != nil {
+		return blank(cw, chh), true
+	}
+	out, err := png.Decode(&buf)
+	if err != nil {
+		return blank(cw, chh), true
+	}
+	if state.showHints {
+		out = drawHint(out, fmt.Sprintf("Hint: one color per %s; compare the clouds, not single batches.", dim))
+	}
+	return drawWatermark(out, fmt.Sprintf("%ss: overlay of %d", dim, len(sits))), true
+}
+
+// overlayTooltipMode reports whether crosshair mode belongs to a chart that overlay mode
+// splits, so the readout names the hovered batch's group.
+func overlayTooltipMode(state *uiState, mode string) bool {
+	switch mode {
+	case "speed", "ttfb", "error", "jitter", "stall_rate", "quality_score":
+		return situationOverlayActive(state)
+	}
+	return false
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/iafilius/InternetQualityMonitor/src/analysis"
+)
+
+func TestSituationOverlayActive(t *testing.T) {
+	s := &uiState{
+		summaries: []analysis.BatchSummary{
+			{RunTag: "a", Situation: "Home-WiFi", Lines: 2},
+			{RunTag: "b", Lines: 2},
+			{RunTag: "c", Situation: "Home-WiFi", Lines: 2},
+		},
+		runTagSituation: map[string]string{"b": "Home-Ethernet"},
+		situation:       "All",
+		xAxisMode:       "batch",
+	}
+	if situationOverlayActive(s) {
+		t.Fatalf("overlay must stay off until enabled")
+	}
+	s.situationOverlay = true
+	if got := overlaySituations(s, s.summaries); len(got) != 2 || got[0] != "Home-WiFi" || got[1] != "Home-Ethernet" {
+		t.Fatalf("situations in first-seen order: %v", got)
+	}
+	if !situationOverlayActive(s) || !overlayTooltipMode(s, "speed") || overlayTooltipMode(s, "dns") {
+		t.Fatalf("overlay should apply to two situations under All")
+	}
+	s.situation = "Home-WiFi"
+	if situationOverlayActive(s) {
+		t.Fatalf("a single Situation filter disables the overlay")
+	}
+	s.situation = "All"
+	s.summaries = s.summaries[:1]
+	if situationOverlayActive(s) {
+		t.Fatalf("one Situation has nothing to overlay")
+	}
+	if _, ok := renderSituationOverlay(s, "error_rate"); ok {
+		t.Fatalf("inactive overlay must fall back to the normal chart")
+	}
+}
+
+func TestInterfaceOverlayTakesPrecedence(t *testing.T) {
+	s := &uiState{
+		summaries: []analysis.BatchSummary{
+			{RunTag: "a_eth0", Situation: "Home", BindInterface: "eth0", Lines: 2},
+			{RunTag: "a_wlan0", Situation: "Home", BindInterface: "wlan0", Lines: 2},
+			{RunTag: "b", Situation: "Home", Lines: 2},
+		},
+		situation:        "All",
+		xAxisMode:        "batch",
+		situationOverlay: true,
+	}
+	if situationOverlayActive(s) {
+		t.Fatalf("a single Situation has nothing to overlay")
+	}
+	s.interfaceOverlay = true
+	dim, key := overlayDimension(s)
+	if !situationOverlayActive(s) || dim != "Interface" {
+		t.Fatalf("interface overlay should apply: dim=%q", dim)
+	}
+	if got := overlayGroups(s.summaries, key); len(got) != 3 || got[2] != "Default" {
+		t.Fatalf("interfaces: %v", got)
+	}
+	s.bindFilter = "eth0"
+	if dim, _ := overlayDimension(s); dim != "Situation" || len(filteredSummaries(s)) != 1 {
+		t.Fatalf("an Interface filter hands over to the Situation overlay: dim=%q rows=%d", dim, len(filteredSummaries(s)))
+	}
+}
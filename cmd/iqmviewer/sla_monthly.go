@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+	"github.com/wcharczuk/go-chart/v2"
+
+	"github.com/iafilius/InternetQualityMonitor/src/analysis"
+)
+
+// defaultSLATargetPct is the monthly compliance target the report starts with.
+const defaultSLATargetPct = 99.0
+
+// renderSLAMonthlyChart draws one bar per month, green when the month met the target and red
+// when it did not.
+func renderSLAMonthlyChart(months []analysis.SLAMonth, targetPct float64, w, h int) image.Image {
+	if len(months) == 0 {
+		return blank(w, h)
+	}
+	bars := make([]chart.Value, 0, len(months))
+	for _, m := range months {
+		col := chart.ColorGreen
+		if !m.Met {
+			col = chart.ColorRed
+		}
+		bars = append(bars, chart.Value{Value: m.CompliancePct, Label: fmt.Sprintf("%s %.1f%%", m.Month.Format("2006-01"), m.CompliancePct),
+			Style: chart.Style{FillColor: col.WithAlpha(200), StrokeColor: col}})
+	}
+	bc := chart.BarChart{
+		Title:      fmt.Sprintf(tr("SLA Compliance by Month (target %.4g%%)"), targetPct),
+		Background: chart.Style{Padding: chart.Box{Top: 30, Left: 16, Right: 12, Bottom: 20}},
+		YAxis:      chart.YAxis{Range: &chart.ContinuousRange{Min: 0, Max: 100}},
+		BarWidth:   60,
+		Bars:       bars,
+	}
+	themeBarChart(&bc)
+	bc.Width, bc.Height = w, h
+	var buf bytes.Buffer
+	if err := renderChart(nil, &bc, &buf); err != nil {
+		return blank(w, h)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		return blank(w, h)
+	}
+	return img
+}
+
+// slaMonthlyText is the monthly compliance table shown in the report.
+func slaMonthlyText(months []analysis.SLAMonth) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%-8s %7s %10s %7s %7s %7s %-16s %9s\n", "Month", "Batches", "Compliance", "Speed", "TTFB", "Failed", "Worst day", "Coverage")
+	for _, m := range months {
+		verdict := "met"
+		if !m.Met {
+			verdict = "MISSED"
+		}
+		fmt.Fprintf(&b, "%-8s %7d %9.1f%% %6.1f%% %6.1f%% %7d %-16s %8.0f%%  %s\n", m.Month.Format("2006-01"), m.Batches, m.CompliancePct,
+			m.SpeedPct, m.TTFBPct, m.FailedBatches, fmt.Sprintf("%s %.0f%%", m.WorstDay.Format("01-02"), m.WorstDayPct), m.CoveragePct(), verdict)
+	}
+	return b.String()
+}
+
+// slaMonthlyCSV is the monthly compliance as CSV, one row per month.
+func slaMonthlyCSV(months []analysis.SLAMonth, sla analysis.SLAThresholds, hours analysis.BusinessHours, targetPct float64) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# SLA per batch: P50 speed >= %.0f kbps and P95 TTFB <= %.0f ms; hours: %s; monthly target %.4g%%\n", sla.SpeedKbps, sla.TTFBMs, hours, targetPct)
+	b.WriteString("month,batches,compliance_pct,speed_pct,ttfb_pct,failed_batches,worst_day,worst_day_pct,covered_hours,window_hours,met\n")
+	for _, m := range months {
+		fmt.Fprintf(&b, "%s,%d,%.2f,%.2f,%.2f,%d,%s,%.1f,%d,%d,%t\n", m.Month.Format("2006-01"), m.Batches, m.CompliancePct, m.SpeedPct, m.TTFBPct,
+			m.FailedBatches, m.WorstDay.Format(time.DateOnly), m.WorstDayPct, m.CoveredHours, m.WindowHours, m.Met)
+	}
+	return b.String()
+}
+
+// openSLAMonthlyReport shows the monthly SLA compliance of the filtered batches against the
+// Settings → SLA Thresholds, optionally within business hours only, as a bar chart and a table
+// with the CSV to copy or save.
+func openSLAMonthlyReport(state *uiState) {
+	if state == nil || state.window == nil {
+		return
+	}
+	sla := analysis.SLAThresholds{SpeedKbps: float64(state.slaSpeedThresholdKbps), TTFBMs: float64(state.slaTTFBThresholdMs)}
+	rows := filteredSummaries(state)
+	if len(rows) == 0 {
+		dialog.ShowInformation("SLA Compliance Report", "No batches to report on.", state.window)
+		return
+	}
+	target := widget.NewEntry()
+	target.SetText(strconv.FormatFloat(defaultSLATargetPct, 'g', -1, 64))
+	bizHours := analysis.DefaultBusinessHours()
+	biz := widget.NewCheck("Business hours only ("+bizHours.String()+")", nil)
+	img := canvas.NewImageFromImage(blank(680, 240))
+	img.FillMode = canvas.ImageFillContain
+	img.SetMinSize(fyne.NewSize(680, 240))
+	grid := widget.NewTextGrid()
+	var csv string
+	refresh := func() {
+		t, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(target.Text, "%")), 64)
+		if err != nil || t <= 0 || t > 100 {
+			t = defaultSLATargetPct
+		}
+		hours := analysis.BusinessHours{}
+		if biz.Checked {
+			hours = bizHours
+		}
+		months := analysis.BuildSLAMonthly(rows, sla, hours, t, timeAxisLoc)
+		head := fmt.Sprintf("SLA per batch: P50 speed ≥ %d kbps and P95 TTFB ≤ %d ms (Settings → SLA Thresholds) — Situation: %s — %s\n\n",
+			state.slaSpeedThresholdKbps, state.slaTTFBThresholdMs, activeSituationLabel(state), hours)
+		if len(months) == 0 {
+			grid.SetText(head + "No batches with a start time and speed or TTFB in these hours.")
+		} else {
+			grid.SetText(head + slaMonthlyText(months))
+		}
+		csv = slaMonthlyCSV(months, sla, hours, t)
+		img.Image = renderSLAMonthlyChart(months, t, 680, 240)
+		img.Refresh()
+	}
+	biz.OnChanged = func(bool) { refresh() }
+	target.OnChanged = func(string) { refresh() }
+	refresh()
+	copyBtn := widget.NewButton("Copy CSV", func() { state.app.Clipboard().SetContent(csv) })
+	saveBtn := widget.NewButton("Save CSV…", func() {
+		fs := dialog.NewFileSave(func(wc fyne.URIWriteCloser, err error) {
+			if err != nil || wc == nil {
+				return
+			}
+			defer wc.Close()
+			if _, err := wc.Write([]byte(csv)); err != nil {
+				dialog.ShowError(err, state.window)
+			}
+		}, state.window)
+		fs.SetFileName("sla_compliance_monthly.csv")
+		fs.SetFilter(storage.NewExtensionFileFilter([]string{".csv"}))
+		fs.Show()
+	})
+	controls := container.NewHBox(widget.NewLabel("Monthly target (%)"), container.NewGridWrap(fyne.NewSize(80, target.MinSize().Height), target), biz)
+	content := container.NewBorder(container.NewVBox(controls, img), container.NewHBox(copyBtn, saveBtn), nil, nil, container.NewScroll(grid))
+	d := dialog.NewCustom("SLA Compliance Report", "Close", content, state.window)
+	d.Resize(fyne.NewSize(760, 620))
+	d.Show()
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"math"
+	"strings"
+
+	"github.com/wcharczuk/go-chart/v2"
+
+	helpers "github.com/iafilius/InternetQualityMonitor/cmd/iqmviewer/uihelpers"
+	"github.com/iafilius/InternetQualityMonitor/src/analysis"
+	"github.com/iafilius/InternetQualityMonitor/src/monitor"
+)
+
+// batchSpeedHistogram is the batch's stored speed_histogram, or one built from its sketch for
+// results analyzed before histograms were kept.
+func batchSpeedHistogram(bs analysis.BatchSummary) *monitor.ExpHistogram {
+	if bs.SpeedHistogram != nil {
+		return bs.SpeedHistogram
+	}
+	return bs.SpeedSketch.Histogram(monitor.DefaultHistogramPerDecade)
+}
+
+// logAxisTicks returns 1-2-5 ticks between 10^lo and 10^hi, placed at their log10 positions.
+func logAxisTicks(lo, hi float64) []chart.Tick {
+	var ticks []chart.Tick
+	for d := math.Floor(lo); d <= math.Ceil(hi); d++ {
+		for _, m := range []float64{1, 2, 5} {
+			x := d + math.Log10(m)
+			if x < lo-1e-9 || x > hi+1e-9 {
+				continue
+			}
+			ticks = append(ticks, chart.Tick{Value: x, Label: helpers.FormatNumericTick(math.Pow(10, x))})
+		}
+	}
+	return ticks
+}
+
+// renderSpeedDistributionDetailedChart draws the selected batch's speed samples as a histogram
+// on a log speed axis (share of samples per bucket) with the cumulative distribution on the
+// right axis, so a bimodal batch shows two humps where its average sits in the empty middle.
+// It returns nil when the batch has no speed samples.
+func renderSpeedDistributionDetailedChart(state *uiState) image.Image {
+	rows := filteredSummaries(state)
+	if len(rows) == 0 {
+		cw, chh := chartSize(state)
+		return blank(cw, chh)
+	}
+	ix := state.selectedRow
+	if ix < 0 || ix >= len(rows) {
+		ix = 0
+	}
+	bs := rows[ix]
+	h := batchSpeedHistogram(bs)
+	total := h.Total()
+	if total == 0 || len(h.Counts) == 0 {
+		return nil
+	}
+	unitName, factor := speedUnitFor(state)
+	x := func(kbps float64) float64 { return math.Log10(kbps * factor) }
+	var hx, hy, cx, cy []float64
+	cum := float64(h.Zero)
+	lo0, _ := h.Bounds(0)
+	cx, cy = append(cx, x(lo0)), append(cy, cum/float64(total)*100)
+	for i, c := range h.Counts {
+		lo, hi := h.Bounds(i)
+		share := float64(c) / float64(total) * 100
+		// one step per bucket so the bars touch like a histogram
+		hx = append(hx, x(lo), x(lo), x(hi), x(hi))
+		hy = append(hy, 0, share, share, 0)
+		cum += float64(c)
+		cx, cy = append(cx, x(hi)), append(cy, cum/float64(total)*100)
+	}
+	maxShare := 0.0
+	for _, v := range hy {
+		maxShare = math.Max(maxShare, v)
+	}
+	histStyle := chart.Style{StrokeColor: chart.ColorBlue, StrokeWidth: 1.5, FillColor: chart.ColorBlue.WithAlpha(90)}
+	cdfStyle := chart.Style{StrokeColor: chart.ColorOrange, StrokeWidth: 2}
+	series := []chart.Series{
+		chart.ContinuousSeries{Name: "Share of samples (%)", XValues: hx, YValues: hy, Style: histStyle},
+		chart.ContinuousSeries{Name: "Cumulative (%)", XValues: cx, YValues: cy, Style: cdfStyle, YAxis: chart.YAxisSecondary},
+	}
+	modes := bs.SpeedModesKbps
+	if len(modes) == 0 {
+		modes = analysis.HistogramModes(h)
+	}
+	yMax := math.Max(5, maxShare*1.15)
+	_, hi0 := h.Bounds(len(h.Counts) - 1)
+	xMin, xMax := x(lo0), x(hi0)
+	yTicks := []chart.Tick{}
+	for _, v := range helpers.BuildNumericTicks(0, yMax, 5) {
+		yTicks = append(yTicks, chart.Tick{Value: v, Label: helpers.FormatNumericTick(v)})
+	}
+	if len(yTicks) > 1 {
+		yMax = yTicks[len(yTicks)-1].Value
+	}
+	for _, m := range modes {
+		series = append(series, chart.ContinuousSeries{Name: fmt.Sprintf("Mode %s", formatSpeedValue(m*factor)), XValues: []float64{x(m), x(m)}, YValues: []float64{0, yMax},
+			Style: chart.Style{StrokeColor: chart.ColorAlternateGray, StrokeWidth: 1, StrokeDashArray: []float64{4, 3}}})
+	}
+	var notes []string
+	if len(modes) > 1 {
+		parts := make([]string, len(modes))
+		for i, m := range modes {
+			parts[i] = formatSpeedValue(m * factor)
+		}
+		notes = append(notes, fmt.Sprintf("%d modes: %s %s (avg %s)", len(modes), strings.Join(parts, " / "), unitName, formatSpeedValue(bs.AvgSpeed*factor)))
+	}
+	if h.Zero > 0 {
+		notes = append(notes, fmt.Sprintf("%.1f%% samples at 0 (stalled)", float64(h.Zero)/float64(total)*100))
+	}
+	title := fmt.Sprintf("Speed Distribution (%s, log scale)", unitName)
+	if len(notes) > 0 {
+		// in the title: the Detailed tab stamps the batch name top-left
+		title += " — " + strings.Join(notes, " · ")
+	}
+	ch := chart.Chart{
+		Title:          title,
+		Background:     chart.Style{Padding: chart.Box{Top: 14, Left: 16, Right: 12, Bottom: 48}},
+		XAxis:          chart.XAxis{Name: unitName, Range: &chart.ContinuousRange{Min: xMin, Max: xMax}, Ticks: logAxisTicks(xMin, xMax)},
+		YAxis:          chart.YAxis{Name: "% of samples", Range: &chart.ContinuousRange{Min: 0, Max: yMax}, Ticks: yTicks},
+		YAxisSecondary: chart.YAxis{Name: "cumulative %", Range: &chart.ContinuousRange{Min: 0, Max: 100}, Ticks: []chart.Tick{{Value: 0, Label: "0"}, {Value: 50, Label: "50"}, {Value: 100, Label: "100"}}},
+		Series:         series,
+	}
+	themeChart(&ch)
+	cw, chh := chartSize(state)
+	ch.Width, ch.Height = cw, chh
+	attachLegend(state, &ch)
+	var buf bytes.Buffer
+	if err := renderChart(state, &ch, &buf); err != nil {
+		return blank(cw, chh)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		return blank(cw, chh)
+	}
+	if state.showHints {
+		img = drawHint(img, "Hint: two humps mean the batch switched between a fast and a slow path; the average hides that.")
+	}
+	return drawWatermark(img, "Situation: "+activeSituationLabel(state))
+}
+
+// formatSpeedValue prints a speed with precision that suits its magnitude.
+func formatSpeedValue(v float64) string {
+	switch {
+	case v >= 100:
+		return fmt.Sprintf("%.0f", v)
+	case v >= 10:
+		return fmt.Sprintf("%.1f", v)
+	}
+	return fmt.Sprintf("%.2f", v)
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/iafilius/InternetQualityMonitor/src/analysis"
+)
+
+func TestAutoSpeedUnit(t *testing.T) {
+	rows := func(v ...float64) []analysis.BatchSummary {
+		out := make([]analysis.BatchSummary, len(v))
+		for i, x := range v {
+			out[i].RunTag = "r"
+			out[i].AvgSpeed = x
+		}
+		return out
+	}
+	cases := []struct {
+		rows []analysis.BatchSummary
+		want string
+	}{
+		{rows(300, 600, 900), "kbps"},
+		{rows(300, 900, 50_000), "kbps"}, // median decides, not the outlier
+		{rows(20_000, 80_000), "Mbps"},
+		{rows(900_000, 1_200_000, 2_000_000), "Gbps"},
+		{nil, "Mbps"},
+	}
+	for i, c := range cases {
+		if got := autoSpeedUnit(c.rows); got != c.want {
+			t.Fatalf("case %d: got %s want %s", i, got, c.want)
+		}
+	}
+	s := &uiState{speedUnit: "Auto", summaries: rows(2_000_000, 3_000_000)}
+	if name, f := speedUnitFor(s); name != "Gbps" || f != 1.0/1_000_000.0 || s.autoSpeedUnit != "" {
+		t.Fatalf("unresolved Auto should derive Gbps without storing it, got %s %v (%q)", name, f, s.autoSpeedUnit)
+	}
+	refreshAutoSpeedUnit(s)
+	s.summaries = rows(500)
+	if name, _ := speedUnitFor(s); name != "Gbps" {
+		t.Fatalf("rendering must not re-resolve the unit, got %s", name)
+	}
+	refreshAutoSpeedUnit(s)
+	if name, _ := speedUnitFor(s); name != "kbps" {
+		t.Fatalf("Auto must re-resolve when the data changes, got %s", name)
+	}
+	s.summaries = rows(500, 2_000_000, 3_000_000)
+	s.summaries[0].QualityGood = true
+	refreshAutoSpeedUnit(s)
+	s.showOnlyQualityGood = true
+	refreshAutoSpeedUnit(s)
+	if name, _ := speedUnitFor(s); name != "kbps" {
+		t.Fatalf("Auto must follow the quality-good filter, got %s", name)
+	}
+}
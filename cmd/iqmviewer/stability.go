@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"math"
+	"time"
+
+	chart "github.com/wcharczuk/go-chart/v2"
+	"github.com/wcharczuk/go-chart/v2/drawing"
+
+	helpers "github.com/iafilius/InternetQualityMonitor/cmd/iqmviewer/uihelpers"
+)
+
+// renderThroughputStabilityChart draws BatchSummary.Stability per batch: the stability index, P10
+// and P20 speed as a percentage of the median and the share of transfers within ±20% of it.
+// Batches with too few successful transfers (analysis.StabilityMinTests) are omitted.
+func renderThroughputStabilityChart(state *uiState) image.Image {
+	cw, chh := chartSize(state)
+	rows := filteredSummaries(state)
+	if len(rows) == 0 {
+		return blank(cw, chh)
+	}
+	timeMode, times, xs, xAxis := buildXAxis(rows, state.xAxisMode)
+	var px []float64
+	var pt []time.Time
+	var idx, p10, p20, within []float64
+	maxY := 100.0
+	for i, r := range rows {
+		s := r.Stability
+		if s == nil {
+			continue
+		}
+		idx, p10, p20, within = append(idx, s.Index), append(p10, s.P10MedianPct), append(p20, s.P20MedianPct), append(within, s.Within20Pct)
+		maxY = math.Max(maxY, s.P20MedianPct)
+		if timeMode {
+			pt = append(pt, times[i])
+		} else {
+			px = append(px, xs[i])
+		}
+	}
+	if len(idx) == 0 {
+		return drawHint(blank(cw, chh), "Too few successful transfers per batch for stability metrics.")
+	}
+	if timeMode && len(pt) == 1 {
+		pt = append(pt, pt[0].Add(1*time.Second))
+	} else if !timeMode && len(px) == 1 {
+		px = append(px, px[0]+1)
+	}
+	var series []chart.Series
+	add := func(name string, ys []float64, c drawing.Color, dashed bool) {
+		if len(ys) == 1 {
+			ys = append(ys, ys[0])
+		}
+		st := pointStyle(c)
+		if dashed {
+			st.StrokeDashArray = []float64{4, 3}
+		}
+		if timeMode {
+			series = append(series, chart.TimeSeries{Name: name, XValues: pt, YValues: ys, Style: st})
+		} else {
+			series = append(series, chart.ContinuousSeries{Name: name, XValues: px, YValues: ys, Style: st})
+		}
+	}
+	add("Stability index", idx, chart.ColorBlue, false)
+	add("P10 / median", p10, chart.ColorRed, true)
+	add("P20 / median", p20, chart.ColorOrange, true)
+	add("Within ±20% of median", within, chart.ColorGreen, true)
+	vals := helpers.BuildNumericTicks(0, maxY*1.05, 6)
+	if len(vals) < 2 {
+		vals = []float64{0, 100}
+	}
+	yTicks := make([]chart.Tick, len(vals))
+	for i, v := range vals {
+		yTicks[i] = chart.Tick{Value: v, Label: helpers.FormatNumericTick(v)}
+	}
+	padBottom := 28
+	switch state.xAxisMode {
+	case "run_tag":
+		padBottom = 90
+	case "time":
+		padBottom = 48
+	}
+	if state.showHints {
+		padBottom += 18
+	}
+	ch := chart.Chart{
+		Title:      "Throughput Stability (%)",
+		Background: chart.Style{Padding: chart.Box{Top: 14, Left: 16, Right: 12, Bottom: padBottom}},
+		XAxis:      xAxis,
+		YAxis:      chart.YAxis{Name: "%", Range: &chart.ContinuousRange{Min: vals[0], Max: vals[len(vals)-1]}, Ticks: yTicks},
+		Series:     series,
+	}
+	themeChart(&ch)
+	ch.Title += allGroupsNote(state)
+	ch.Width, ch.Height = cw, chh
+	attachLegend(state, &ch)
+	var buf bytes.Buffer
+	if err := renderChart(state, &ch, &buf); err != nil {
+		return blank(cw, chh)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		return blank(cw, chh)
+	}
+	if state.showHints {
+		img = drawHint(img, "Hint: P10 / median well below 100 means the slow tail, not the typical transfer, is what users notice; compare with Low-Speed Time Share.")
+	}
+	return drawWatermark(img, "Situation: "+activeSituationLabel(state))
+}
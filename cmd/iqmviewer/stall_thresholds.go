@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/iafilius/InternetQualityMonitor/src/analysis"
+)
+
+// Threshold subtitles: the stall, transient stall and low-speed charts name the thresholds their
+// numbers were derived with (BatchSummary.Thresholds), so a saved screenshot stays readable after
+// the settings changed. Batches that differ show the range.
+
+// stallTimeoutNote is "stall timeout 20s", or a range; "" when no batch recorded it.
+func stallTimeoutNote(rows []analysis.BatchSummary) string {
+	lo, hi := thresholdRange(rows, func(t *analysis.StallThresholds) float64 { return float64(t.StallTimeoutMs) })
+	if hi == 0 {
+		return ""
+	}
+	d := func(ms float64) string { return (time.Duration(ms) * time.Millisecond).String() }
+	if lo == hi {
+		return "stall timeout " + d(hi)
+	}
+	return fmt.Sprintf("stall timeout %s–%s", d(lo), d(hi))
+}
+
+// microStallGapNote is "gaps ≥ 500 ms", or a range; "" when not computed.
+func microStallGapNote(rows []analysis.BatchSummary) string {
+	lo, hi := thresholdRange(rows, func(t *analysis.StallThresholds) float64 { return float64(t.MicroStallGapMs) })
+	if hi == 0 {
+		return ""
+	}
+	if lo == hi {
+		return fmt.Sprintf("gaps ≥ %.0f ms", hi)
+	}
+	return fmt.Sprintf("gaps ≥ %.0f–%.0f ms", lo, hi)
+}
+
+// lowSpeedNote is "< 1000 kbps", or a range; "" when not computed.
+func lowSpeedNote(rows []analysis.BatchSummary) string {
+	lo, hi := thresholdRange(rows, func(t *analysis.StallThresholds) float64 { return t.LowSpeedThresholdKbps })
+	if hi == 0 {
+		return ""
+	}
+	if lo == hi {
+		return fmt.Sprintf("< %g kbps", hi)
+	}
+	return fmt.Sprintf("< %g–%g kbps", lo, hi)
+}
+
+// thresholdRange is the smallest and largest non-zero value over the batches.
+func thresholdRange(rows []analysis.BatchSummary, v func(*analysis.StallThresholds) float64) (lo, hi float64) {
+	for _, r := range rows {
+		if r.Thresholds == nil {
+			continue
+		}
+		x := v(r.Thresholds)
+		if x <= 0 {
+			continue
+		}
+		if hi == 0 || x < lo {
+			lo = x
+		}
+		if x > hi {
+			hi = x
+		}
+	}
+	return lo, hi
+}
+
+// withNote appends a threshold note to a chart title.
+func withNote(title, note string) string {
+	if note == "" {
+		return tr(title)
+	}
+	return tr(title) + " – " + note
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/iafilius/InternetQualityMonitor/src/analysis"
+)
+
+func TestThresholdNotes(t *testing.T) {
+	rows := []analysis.BatchSummary{
+		{RunTag: "old"}, // before thresholds were recorded
+		{RunTag: "a", Thresholds: &analysis.StallThresholds{StallTimeoutMs: 20000, MicroStallGapMs: 500, LowSpeedThresholdKbps: 1000}},
+		{RunTag: "b", Thresholds: &analysis.StallThresholds{StallTimeoutMs: 15000, MicroStallGapMs: 500, LowSpeedThresholdKbps: 1000}},
+	}
+	if got := stallTimeoutNote(rows); got != "stall timeout 15s–20s" {
+		t.Fatalf("stall timeout: %q", got)
+	}
+	if got := microStallGapNote(rows); got != "gaps ≥ 500 ms" {
+		t.Fatalf("gap: %q", got)
+	}
+	if got := withNote("Low-Speed Time Share (%)", lowSpeedNote(rows)); got != "Low-Speed Time Share (%) – < 1000 kbps" {
+		t.Fatalf("low speed: %q", got)
+	}
+	if got := withNote("Stall Rate (%)", stallTimeoutNote(rows[:1])); got != "Stall Rate (%)" {
+		t.Fatalf("unrecorded: %q", got)
+	}
+}
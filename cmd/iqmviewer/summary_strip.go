@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	helpers "github.com/iafilius/InternetQualityMonitor/cmd/iqmviewer/uihelpers"
+	"github.com/iafilius/InternetQualityMonitor/src/analysis"
+)
+
+// summaryStrip is the header above the BatchAvg charts with rolling aggregates of the loaded
+// (filtered) batches over the last 24h or 7d, each with a trend sparkline.
+type summaryStrip struct {
+	box    *fyne.Container
+	window *widget.Select
+	span   *widget.Label
+	cards  []*summaryCard
+}
+
+type summaryCard struct {
+	title *widget.Label
+	value *widget.Label
+	spark *canvas.Image
+}
+
+// stripCard is the computed content of one card.
+type stripCard struct {
+	title string
+	value string
+	trend []float64 // NaN for empty buckets
+}
+
+const (
+	sparkW = 110
+	sparkH = 22
+)
+
+var sparkColor = color.RGBA{R: 90, G: 170, B: 255, A: 255}
+
+// rollingWindow maps the strip's window choice to its length and trend bucket count
+// (hourly for 24h, 6-hourly for 7d).
+func rollingWindow(choice string) (time.Duration, int) {
+	if choice == "7d" {
+		return 7 * 24 * time.Hour, 28
+	}
+	return 24 * time.Hour, 24
+}
+
+// rollingStripCards turns an aggregate into card texts and trend series; speeds are converted
+// with factor into unitName.
+func rollingStripCards(agg analysis.RollingAggregate, unitName string, factor float64) []stripCard {
+	trend := func(get func(analysis.RollingPoint) float64) []float64 {
+		out := make([]float64, len(agg.Trend))
+		for i, p := range agg.Trend {
+			out[i] = math.NaN()
+			if p.HasData {
+				out[i] = get(p)
+			}
+		}
+		return out
+	}
+	t := agg.Total
+	na := func(ok bool, s string) string {
+		if !ok {
+			return "—"
+		}
+		return s
+	}
+	return []stripCard{
+		{"Mean speed", na(t.MeanSpeedKbps > 0, fmt.Sprintf("%.1f %s", t.MeanSpeedKbps*factor, unitName)), trend(func(p analysis.RollingPoint) float64 { return p.MeanSpeedKbps * factor })},
+		{"P95 TTFB", na(t.P95TTFBMs > 0, fmt.Sprintf("%.0f ms", t.P95TTFBMs)), trend(func(p analysis.RollingPoint) float64 { return p.P95TTFBMs })},
+		{"Stall rate", na(t.HasData, fmt.Sprintf("%.2f%%", t.StallRatePct)), trend(func(p analysis.RollingPoint) float64 { return p.StallRatePct })},
+		{"Error rate", na(t.HasData, fmt.Sprintf("%.2f%%", t.ErrorRatePct)), trend(func(p analysis.RollingPoint) float64 { return p.ErrorRatePct })},
+		{"SLA compliance", na(t.SLABatches > 0, fmt.Sprintf("%.0f%% of %d", t.SLABothPct, t.SLABatches)), trend(func(p analysis.RollingPoint) float64 {
+			if p.SLABatches == 0 {
+				return math.NaN()
+			}
+			return p.SLABothPct
+		})},
+	}
+}
+
+// newSummaryStrip builds the strip; it is filled by refreshSummaryStrip.
+func newSummaryStrip(state *uiState) *summaryStrip {
+	s := &summaryStrip{span: widget.NewLabel("")}
+	s.window = widget.NewSelect([]string{"24h", "7d"}, func(v string) {
+		if state.app != nil {
+			state.app.Preferences().SetString("rollingSummaryWindow", v)
+		}
+		refreshSummaryStrip(state)
+	})
+	objs := []fyne.CanvasObject{widget.NewLabel("Rolling:"), s.window}
+	for i := 0; i < 5; i++ {
+		c := &summaryCard{
+			title: widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Italic: true}),
+			value: widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			spark: canvas.NewImageFromImage(image.NewRGBA(image.Rect(0, 0, sparkW, sparkH))),
+		}
+		c.spark.FillMode = canvas.ImageFillOriginal
+		c.spark.SetMinSize(fyne.NewSize(sparkW, sparkH))
+		s.cards = append(s.cards, c)
+		objs = append(objs, widget.NewSeparator(), container.NewVBox(container.NewHBox(c.title, c.value), c.spark))
+	}
+	objs = append(objs, widget.NewSeparator(), s.span)
+	s.box = container.NewVBox(container.NewHScroll(container.NewHBox(objs...)), widget.NewSeparator())
+	choice := "24h"
+	if state.app != nil {
+		choice = state.app.Preferences().StringWithFallback("rollingSummaryWindow", choice)
+	}
+	s.window.Selected = choice // no callback before the data is loaded
+	return s
+}
+
+// refreshSummaryStrip recomputes the aggregates from the filtered batches (called after each
+// chart redraw, so filters, SLA thresholds and the speed unit apply).
+func refreshSummaryStrip(state *uiState) {
+	s := state.summaryStrip
+	if s == nil {
+		return
+	}
+	win, buckets := rollingWindow(s.window.Selected)
+	agg := analysis.RollingSummary(filteredSummaries(state), win, buckets, analysis.SLAThresholds{
+		SpeedKbps: float64(state.slaSpeedThresholdKbps),
+		TTFBMs:    float64(state.slaTTFBThresholdMs),
+	})
+	unitName, factor := speedUnitFor(state)
+	for i, c := range rollingStripCards(agg, unitName, factor) {
+		if i >= len(s.cards) {
+			break
+		}
+		s.cards[i].title.SetText(c.title)
+		s.cards[i].value.SetText(c.value)
+		s.cards[i].spark.Image = helpers.Sparkline(c.trend, sparkW, sparkH, sparkColor)
+		s.cards[i].spark.Refresh()
+	}
+	if agg.Total.Batches == 0 {
+		s.span.SetText("no batches with a start time")
+		return
+	}
+	s.span.SetText(fmt.Sprintf("%d batches, %d lines · %s – %s", agg.Total.Batches, agg.Total.Lines,
+		agg.From.In(timeAxisLoc).Format("Jan 2 15:04"), agg.To.In(timeAxisLoc).Format("Jan 2 15:04")))
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/iafilius/InternetQualityMonitor/src/analysis"
+)
+
+func TestRollingStripCards(t *testing.T) {
+	agg := analysis.RollingAggregate{
+		Total: analysis.RollingPoint{Batches: 3, Lines: 30, HasData: true, MeanSpeedKbps: 20000, P95TTFBMs: 180, StallRatePct: 1.5, ErrorRatePct: 2, SLABothPct: 66.7, SLABatches: 3},
+		Trend: []analysis.RollingPoint{{HasData: true, MeanSpeedKbps: 10000, SLABatches: 1, SLABothPct: 100}, {}},
+	}
+	cards := rollingStripCards(agg, "Mbps", 1.0/1000.0)
+	if len(cards) != 5 {
+		t.Fatalf("want 5 cards, got %d", len(cards))
+	}
+	if cards[0].value != "20.0 Mbps" || cards[1].value != "180 ms" || cards[4].value != "67% of 3" {
+		t.Fatalf("unexpected values: %+v", cards)
+	}
+	if cards[0].trend[0] != 10 || !math.IsNaN(cards[0].trend[1]) {
+		t.Fatalf("trend must be unit-converted with gaps for empty buckets: %v", cards[0].trend)
+	}
+	empty := rollingStripCards(analysis.RollingAggregate{}, "kbps", 1)
+	if empty[0].value != "—" || empty[4].value != "—" {
+		t.Fatalf("empty aggregate should show placeholders: %+v", empty)
+	}
+	if w, n := rollingWindow("7d"); w != 7*24*time.Hour || n != 28 {
+		t.Fatalf("7d window: %v %d", w, n)
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/iafilius/InternetQualityMonitor/src/analysis"
+)
+
+// targetSLOLabel describes one target's SLO, e.g. "TTFB ≤ 50 ms, ≥ 20000 kbps".
+func targetSLOLabel(t analysis.TargetSLOCompliance) string {
+	var parts []string
+	if t.TTFBMs > 0 {
+		parts = append(parts, fmt.Sprintf("TTFB ≤ %.0f ms", t.TTFBMs))
+	}
+	if t.SpeedKbps > 0 {
+		parts = append(parts, fmt.Sprintf("≥ %.0f kbps", t.SpeedKbps))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// targetSLOText is the per-target compliance table shown in the report, worst target first.
+func targetSLOText(rows []analysis.TargetSLOCompliance) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%-28s %-12s %-26s %7s %10s %6s %6s %6s %9s %9s %10s\n", "Target", "Group", "SLO", "Lines", "Compliance",
+		"TTFB✗", "Speed✗", "Errors", "Avg TTFB", "P95 TTFB", "Avg speed")
+	for _, t := range rows {
+		fmt.Fprintf(&b, "%-28s %-12s %-26s %7d %9.1f%% %6d %6d %6d %7.0fms %7.0fms %6.0fkbps\n", t.Target, t.Group, targetSLOLabel(t), t.Lines,
+			t.CompliancePct, t.TTFBBreaches, t.SpeedBreaches, t.ErrorLines, t.AvgTTFBMs, t.P95TTFBMs, t.AvgSpeedKbps)
+	}
+	return b.String()
+}
+
+// targetSLOCSV is the per-target compliance as CSV, one row per target.
+func targetSLOCSV(rows []analysis.TargetSLOCompliance) string {
+	var b strings.Builder
+	b.WriteString("target,group,slo_ttfb_ms,slo_speed_kbps,batches,lines,compliant_lines,compliance_pct,ttfb_breaches,speed_breaches,error_lines,avg_ttfb_ms,p95_ttfb_ms,avg_speed_kbps\n")
+	for _, t := range rows {
+		fmt.Fprintf(&b, "%s,%s,%.0f,%.0f,%d,%d,%d,%.2f,%d,%d,%d,%.1f,%.1f,%.1f\n", targetSLOCSVField(t.Target), targetSLOCSVField(t.Group), t.TTFBMs, t.SpeedKbps,
+			t.Batches, t.Lines, t.CompliantLines, t.CompliancePct, t.TTFBBreaches, t.SpeedBreaches, t.ErrorLines, t.AvgTTFBMs, t.P95TTFBMs, t.AvgSpeedKbps)
+	}
+	return b.String()
+}
+
+// targetSLOCSVField quotes a target or group name that contains a comma or quote.
+func targetSLOCSVField(s string) string {
+	if !strings.ContainsAny(s, ",\"\n") {
+		return s
+	}
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+// targetSLORows aggregates the per-target compliance of the filtered batches, limited to the
+// selected target group, sorted worst compliance first.
+func targetSLORows(state *uiState) []analysis.TargetSLOCompliance {
+	rows := analysis.AggregateTargetSLOs(filteredSummaries(state))
+	if g := state.groupFilter; g != "" && g != "All" {
+		kept := rows[:0]
+		for _, t := range rows {
+			if t.Group == g {
+				kept = append(kept, t)
+			}
+		}
+		rows = kept
+	}
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].CompliancePct < rows[j].CompliancePct })
+	return rows
+}
+
+// openTargetSLOReport shows how well each target met its own SLO (sites file "slo") over the
+// filtered batches, with the CSV to copy or save.
+func openTargetSLOReport(state *uiState) {
+	if state == nil || state.window == nil {
+		return
+	}
+	rows := targetSLORows(state)
+	if len(rows) == 0 {
+		dialog.ShowInformation("Target SLO Compliance", "No target has an SLO: add \"slo\": {\"ttfb_ms\": 50} to sites in the sites file.", state.window)
+		return
+	}
+	grid := widget.NewTextGrid()
+	grid.SetText(fmt.Sprintf("Each line is judged by the SLO its target had when measured — Situation: %s\n\n", activeSituationLabel(state)) + targetSLOText(rows))
+	csv := targetSLOCSV(rows)
+	copyBtn := widget.NewButton("Copy CSV", func() { state.app.Clipboard().SetContent(csv) })
+	saveBtn := widget.NewButton("Save CSV…", func() {
+		fs := dialog.NewFileSave(func(wc fyne.URIWriteCloser, err error) {
+			if err != nil || wc == nil {
+				return
+			}
+			defer wc.Close()
+			if _, err := wc.Write([]byte(csv)); err != nil {
+				dialog.ShowError(err, state.window)
+			}
+		}, state.window)
+		fs.SetFileName("target_slo_compliance.csv")
+		fs.SetFilter(storage.NewExtensionFileFilter([]string{".csv"}))
+		fs.Show()
+	})
+	content := container.NewBorder(nil, container.NewHBox(copyBtn, saveBtn), nil, nil, container.NewScroll(grid))
+	d := dialog.NewCustom("Target SLO Compliance", "Close", content, state.window)
+	d.Resize(fyne.NewSize(980, 520))
+	d.Show()
+}
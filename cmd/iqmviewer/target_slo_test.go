@@ -0,0 +1,29 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/iafilius/InternetQualityMonitor/src/analysis"
+)
+
+func TestTargetSLOTextAndCSV(t *testing.T) {
+	rows := []analysis.TargetSLOCompliance{
+		{Target: "intranet, eu", Group: "Intranet", TTFBMs: 50, Lines: 4, CompliantLines: 3, CompliancePct: 75, TTFBBreaches: 1, AvgTTFBMs: 40, P95TTFBMs: 70, Batches: 2},
+		{Target: "cdn", TTFBMs: 200, SpeedKbps: 20000, Lines: 2, CompliantLines: 2, CompliancePct: 100, AvgSpeedKbps: 30000, Batches: 1},
+	}
+	if got := targetSLOLabel(rows[1]); got != "TTFB ≤ 200 ms, ≥ 20000 kbps" {
+		t.Fatalf("label: %q", got)
+	}
+	text := targetSLOText(rows)
+	if !strings.Contains(text, "intranet, eu") || !strings.Contains(text, "75.0%") {
+		t.Fatalf("text: %q", text)
+	}
+	lines := strings.Split(strings.TrimSpace(targetSLOCSV(rows)), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "target,group,slo_ttfb_ms,") {
+		t.Fatalf("csv layout: %q", lines)
+	}
+	if lines[1] != `"intranet, eu",Intranet,50,0,2,4,3,75.00,1,0,0,40.0,70.0,0.0` {
+		t.Fatalf("csv row: %q", lines[1])
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"math"
+	"time"
+
+	chart "github.com/wcharczuk/go-chart/v2"
+
+	helpers "github.com/iafilius/InternetQualityMonitor/cmd/iqmviewer/uihelpers"
+	"github.com/iafilius/InternetQualityMonitor/src/analysis"
+)
+
+// renderTCPRetransChart draws BatchSummary.TCPStats per batch: the share of the sent segments the
+// client retransmitted and the share of the received segments that arrived out of order (on a
+// download mostly the server's retransmissions). Batches without kernel TCP counters are omitted.
+func renderTCPRetransChart(state *uiState) image.Image {
+	cw, chh := chartSize(state)
+	rows := filteredSummaries(state)
+	if len(rows) == 0 {
+		return blank(cw, chh)
+	}
+	timeMode, times, xs, xAxis := buildXAxis(rows, state.xAxisMode)
+	type pts struct {
+		x  []float64
+		t  []time.Time
+		ys []float64
+	}
+	var sent, recv pts
+	add := func(p *pts, i int, y float64) {
+		if timeMode {
+			p.t = append(p.t, times[i])
+		} else {
+			p.x = append(p.x, xs[i])
+		}
+		p.ys = append(p.ys, y)
+	}
+	maxY := 0.0
+	for i, r := range rows {
+		ts := r.TCPStats
+		if ts == nil {
+			continue
+		}
+		add(&sent, i, ts.RetransRatePct)
+		add(&recv, i, ts.RecvOOORatePct)
+		maxY = math.Max(maxY, math.Max(ts.RetransRatePct, ts.RecvOOORatePct))
+	}
+	if len(sent.ys) == 0 {
+		return drawHint(blank(cw, chh), "No TCP counters: recorded by the monitor on Linux and macOS (--tcp-stats).")
+	}
+	var series []chart.Series
+	addSeries := func(name string, p pts, st chart.Style) {
+		if len(p.ys) == 1 {
+			p.ys = append(p.ys, p.ys[0])
+			if timeMode {
+				p.t = append(p.t, p.t[0].Add(1*time.Second))
+			} else {
+				p.x = append(p.x, p.x[0]+1)
+			}
+		}
+		if timeMode {
+			series = append(series, chart.TimeSeries{Name: name, XValues: p.t, YValues: p.ys, Style: st})
+		} else {
+			series = append(series, chart.ContinuousSeries{Name: name, XValues: p.x, YValues: p.ys, Style: st})
+		}
+	}
+	addSeries("Sent retransmitted", sent, pointStyle(chart.ColorRed))
+	addSeries("Received out of order", recv, pointStyle(chart.ColorOrange))
+	vals := helpers.BuildNumericTicks(0, math.Max(maxY*1.15, 1), 6)
+	if len(vals) < 2 {
+		vals = []float64{0, 1}
+	}
+	yTicks := make([]chart.Tick, len(vals))
+	for i, v := range vals {
+		yTicks[i] = chart.Tick{Value: v, Label: helpers.FormatNumericTick(v)}
+	}
+	padBottom := 28
+	switch state.xAxisMode {
+	case "run_tag":
+		padBottom = 90
+	case "time":
+		padBottom = 48
+	}
+	if state.showHints {
+		padBottom += 18
+	}
+	ch := chart.Chart{
+		Title:      "TCP Retransmissions (%)",
+		Background: chart.Style{Padding: chart.Box{Top: 14, Left: 16, Right: 12, Bottom: padBottom}},
+		XAxis:      xAxis,
+		YAxis:      chart.YAxis{Name: "%", Range: &chart.ContinuousRange{Min: vals[0], Max: vals[len(vals)-1]}, Ticks: yTicks},
+		Series:     series,
+	}
+	themeChart(&ch)
+	ch.Title += allGroupsNote(state)
+	ch.Width, ch.Height = cw, chh
+	attachLegend(state, &ch)
+	var buf bytes.Buffer
+	if err := renderChart(state, &ch, &buf); err != nil {
+		return blank(cw, chh)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		return blank(cw, chh)
+	}
+	if state.showHints {
+		img = drawHint(img, "Hint: loss above ~1% makes TCP back off; compare with the stall and plateau charts.")
+	}
+	return drawWatermark(img, "Situation: "+activeSituationLabel(state))
+}
+
+// tcpRetransLines is the crosshair readout of the TCP Retransmissions chart for one batch.
+func tcpRetransLines(ts *analysis.TCPRetransStats) []string {
+	if ts == nil {
+		return []string{"No TCP counters"}
+	}
+	lines := []string{
+		fmt.Sprintf("Sent retransmitted %.2f%% (%d of %d segments)", ts.RetransRatePct, ts.Retransmits, ts.SegsOut),
+		fmt.Sprintf("Received out of order %.2f%% (%d of %d segments)", ts.RecvOOORatePct, ts.RecvOutOfOrder, ts.SegsIn),
+		fmt.Sprintf("Lines with loss %d of %d (%d connections)", ts.LossLines, ts.Lines, ts.Conns),
+	}
+	if ts.StalledLines > 0 {
+		lines = append(lines, fmt.Sprintf("Stalled lines with loss %d of %d", ts.StalledWithLossLines, ts.StalledLines))
+	}
+	if ts.AvgRTTMs > 0 {
+		lines = append(lines, fmt.Sprintf("RTT %.1f ms ± %.1f ms", ts.AvgRTTMs, ts.AvgRTTVarMs))
+	}
+	return lines
+}
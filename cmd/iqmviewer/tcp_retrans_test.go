@@ -0,0 +1,24 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/iafilius/InternetQualityMonitor/src/analysis"
+)
+
+func TestTCPRetransLines(t *testing.T) {
+	if got := tcpRetransLines(nil); len(got) != 1 || got[0] != "No TCP counters" {
+		t.Fatalf("nil: %q", got)
+	}
+	ts := &analysis.TCPRetransStats{Lines: 4, Conns: 5, SegsOut: 400, SegsIn: 2000, Retransmits: 2, RecvOutOfOrder: 30, RetransRatePct: 0.5, RecvOOORatePct: 1.5, LossLines: 2}
+	got := tcpRetransLines(ts)
+	if len(got) != 3 || got[0] != "Sent retransmitted 0.50% (2 of 400 segments)" || got[2] != "Lines with loss 2 of 4 (5 connections)" {
+		t.Fatalf("lines: %q", got)
+	}
+	ts.StalledLines, ts.StalledWithLossLines, ts.AvgRTTMs, ts.AvgRTTVarMs = 2, 1, 24.5, 6
+	got = tcpRetransLines(ts)
+	if len(got) != 5 || got[3] != "Stalled lines with loss 1 of 2" || !strings.HasPrefix(got[4], "RTT 24.5 ms") {
+		t.Fatalf("stalled and rtt: %q", got)
+	}
+}
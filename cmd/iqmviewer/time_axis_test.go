@@ -0,0 +1,48 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRunTagTimeIsUTC(t *testing.T) {
+	prev := timeAxisLoc
+	defer func() { timeAxisLoc = prev }()
+	timeAxisLoc = time.FixedZone("CEST", 7200)
+	got := parseRunTagTime("20260329_100000_i1")
+	if !got.Equal(time.Date(2026, 3, 29, 10, 0, 0, 0, time.UTC)) || got.Hour() != 12 {
+		t.Fatalf("run tag = %v, want 10:00 UTC shown as 12:00", got)
+	}
+}
+
+// Day ticks stay on local midnight and 6h ticks on 00/06/12/18 across the spring-forward change.
+func TestMakeNiceTimeTicksDST(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Amsterdam")
+	if err != nil {
+		t.Skip("no tzdata:", err)
+	}
+	prev := timeAxisLoc
+	defer func() { timeAxisLoc = prev }()
+	timeAxisLoc = loc
+	minT := time.Date(2026, 3, 27, 15, 0, 0, 0, loc)
+	maxT := time.Date(2026, 4, 2, 9, 0, 0, 0, loc)
+	ticks := makeNiceTimeTicks(minT, maxT, 24*time.Hour, "Jan 2 15:04")
+	if len(ticks) < 7 {
+		t.Fatalf("ticks: %d", len(ticks))
+	}
+	for _, tk := range ticks {
+		if tk.Label[len(tk.Label)-5:] != "00:00" {
+			t.Fatalf("day tick off midnight: %q", tk.Label)
+		}
+	}
+	if ticks[0].Label != "Mar 27 00:00" || ticks[3].Label != "Mar 30 00:00" {
+		t.Fatalf("day ticks: %q .. %q", ticks[0].Label, ticks[3].Label)
+	}
+	six := makeNiceTimeTicks(time.Date(2026, 3, 28, 20, 0, 0, 0, loc), time.Date(2026, 3, 29, 13, 0, 0, 0, loc), 6*time.Hour, "15:04")
+	want := []string{"18:00", "00:00", "06:00", "12:00", "18:00"}
+	for i, w := range want {
+		if i >= len(six) || six[i].Label != w {
+			t.Fatalf("6h ticks: %v, want %v", six, want)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"strings"
+
+	"golang.org/x/image/font/basicfont"
+
+	"github.com/iafilius/InternetQualityMonitor/src/analysis"
+)
+
+// heatmapBadness maps a cell value to 0 (best of the range) … 1 (worst): low speed and high
+// TTFB are bad.
+func heatmapBadness(m analysis.TimeHeatmap, v float64) float64 {
+	if m.Max <= m.Min {
+		return 0
+	}
+	t := (v - m.Min) / (m.Max - m.Min)
+	if m.Metric != analysis.HeatmapP95TTFB {
+		t = 1 - t
+	}
+	return math.Max(0, math.Min(1, t))
+}
+
+// heatmapColor blends green (good) through amber to red (bad).
+func heatmapColor(t float64) color.RGBA {
+	good, mid, bad := color.RGBA{0x2e, 0x9e, 0x4f, 255}, color.RGBA{0xe6, 0xb4, 0x22, 255}, color.RGBA{0xcc, 0x33, 0x33, 255}
+	from, to := good, mid
+	if t > 0.5 {
+		from, to, t = mid, bad, t-0.5
+	}
+	t *= 2
+	mix := func(a, b uint8) uint8 { return uint8(float64(a) + (float64(b)-float64(a))*t) }
+	return color.RGBA{mix(from.R, to.R), mix(from.G, to.G), mix(from.B, to.B), 255}
+}
+
+func renderSpeedHeatmapChart(state *uiState) image.Image {
+	return renderTimeHeatmapChart(state, analysis.HeatmapMedianSpeed)
+}
+
+func renderTTFBHeatmapChart(state *uiState) image.Image {
+	return renderTimeHeatmapChart(state, analysis.HeatmapP95TTFB)
+}
+
+// renderTimeHeatmapChart draws the batches as a calendar: one column per local day, one row per
+// hour of day (top = midnight), cell color = median of the batches' median speed or P95 TTFB in
+// that hour, relative to the range of all cells.
+func renderTimeHeatmapChart(state *uiState, metric string) image.Image {
+	rows := filteredSummaries(state)
+	cw, chh := chartSize(state)
+	if len(rows) == 0 {
+		return blank(cw, chh)
+	}
+	m := analysis.BuildTimeHeatmap(rows, metric, timeAxisLoc)
+	unit, factor := "ms", 1.0
+	title := "TTFB Heatmap (P95 by day × hour of day, ms)"
+	if metric != analysis.HeatmapP95TTFB {
+		unit, factor = speedUnitFor(state)
+		title = fmt.Sprintf("Speed Heatmap (median by day × hour of day, %s)", unit)
+	}
+	format := func(v float64) string {
+		v *= factor
+		if v >= 100 {
+			return fmt.Sprintf("%.0f %s", v, unit)
+		}
+		return fmt.Sprintf("%.1f %s", v, unit)
+	}
+	img := image.NewRGBA(image.Rect(0, 0, cw, chh))
+	isLight := strings.EqualFold(screenshotThemeGlobal, "light")
+	bg := color.RGBA{18, 18, 18, 255}
+	var textCol color.Color = color.RGBA{235, 235, 235, 255}
+	var faintText color.Color = color.RGBA{170, 170, 170, 255}
+	empty := color.RGBA{40, 40, 40, 255}
+	if isLight {
+		bg = color.RGBA{250, 250, 250, 255}
+		textCol = color.Black
+		faintText = color.RGBA{60, 60, 60, 255}
+		empty = color.RGBA{225, 225, 225, 255}
+	}
+	draw.Draw(img, img.Bounds(), &image.Uniform{bg}, image.Point{}, draw.Src)
+	face := basicfont.Face7x13
+	addLabel(img, 16, 18, title, textCol, face)
+	if len(m.Days) == 0 {
+		addLabel(img, 16, chh/2, "No batches with a start time and data for this metric.", faintText, face)
+		return drawWatermark(img, "Situation: "+activeSituationLabel(state))
+	}
+	left, right, top, bottom := 56, 110, 34, 46
+	plotW, plotH := cw-left-right, chh-top-bottom
+	if plotW < 100 || plotH < 48 {
+		return blank(cw, chh)
+	}
+	cellW := float64(plotW) / float64(len(m.Days))
+	cellH := float64(plotH) / 24
+	for d := range m.Days {
+		x0, x1 := left+int(float64(d)*cellW), left+int(float64(d+1)*cellW)
+		for h := 0; h < 24; h++ {
+			y0, y1 := top+int(float64(h)*cellH), top+int(float64(h+1)*cellH)
+			c := empty
+			if cell := m.Cells[d][h]; cell.Batches > 0 {
+				c = heatmapColor(heatmapBadness(m, cell.Value))
+			}
+			draw.Draw(img, image.Rect(x0, y0, x1, y1), &image.Uniform{c}, image.Point{}, draw.Src)
+		}
+	}
+	gridCol := color.RGBA{255, 255, 255, 40}
+	if isLight {
+		gridCol = color.RGBA{0, 0, 0, 40}
+	}
+	drawBorder(img, image.Rect(left, top, left+plotW, top+plotH), gridCol)
+	// Y axis: hour of day, hourly when the rows are tall enough for a label each
+	hourStep := 3
+	if cellH >= 14 {
+		hourStep = 1
+	} else if cellH*2 >= 14 {
+		hourStep = 2
+	}
+	for h := 0; h < 24; h += hourStep {
+		addLabel(img, 10, top+int((float64(h)+0.5)*cellH)+4, fmt.Sprintf("%02d:00", h), faintText, face)
+	}
+	// X axis: day labels, thinned so they do not overlap
+	dayLabel := "Mon 01-02"
+	step := int(math.Ceil(float64(len(dayLabel)*7+10) / cellW))
+	if step < 1 {
+		step = 1
+	}
+	for d := 0; d < len(m.Days); d += step {
+		l := m.Days[d].Format("Mon 01-02")
+		x := left + int((float64(d)+0.5)*cellW) - len(l)*7/2
+		addLabel(img, x, top+plotH+14, l, faintText, face)
+	}
+	// Color scale: best at the top
+	sx := left + plotW + 16
+	for y := 0; y < plotH; y++ {
+		draw.Draw(img, image.Rect(sx, top+y, sx+12, top+y+1), &image.Uniform{heatmapColor(float64(y) / float64(plotH))}, image.Point{}, draw.Src)
+	}
+	best, worst := m.Max, m.Min
+	if metric == analysis.HeatmapP95TTFB {
+		best, worst = m.Min, m.Max
+	}
+	addLabel(img, sx+16, top+10, format(best), faintText, face)
+	addLabel(img, sx+16, top+plotH, format(worst), faintText, face)
+	caption := fmt.Sprintf("%d batches over %d days (local time).", m.Batches, len(m.Days))
+	if h := m.WorstHour(); h >= 0 {
+		c := m.Hours[h]
+		caption += fmt.Sprintf(" Worst hour %02d:00–%02d:00: median %s over %d batches.", h, (h+1)%24, format(c.Value), c.Batches)
+	}
+	addLabel(img, left, top+plotH+32, caption, textCol, face)
+	var out image.Image = img
+	if state.showHints {
+		out = drawHint(out, "Hint: A red band across the same evening hours on most days points at peak-time congestion, not a one-off outage.")
+	}
+	return drawWatermark(out, "Situation: "+activeSituationLabel(state))
+}
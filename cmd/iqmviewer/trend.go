@@ -0,0 +1,200 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	chart "github.com/wcharczuk/go-chart/v2"
+
+	"github.com/iafilius/InternetQualityMonitor/src/analysis"
+)
+
+// trendOptions is Chart Options → Trend Lines: a fitted trend per plotted series of the batch
+// charts (analysis.FitTrend) and a forecast band for the next Horizon batches.
+type trendOptions struct {
+	Method  string // "" (off), analysis.TrendLinear or analysis.TrendLoess
+	Horizon int    // forecast batches; 0 draws the trend only
+}
+
+const defaultForecastBatches = 5
+
+// chartTrend is global like chartLook; renderChart reads it through renderSettingsFor.
+var chartTrend = trendOptions{Horizon: defaultForecastBatches}
+
+func (o trendOptions) String() string { return o.Method + "/" + strconv.Itoa(o.Horizon) }
+
+// parseTrendMethod accepts off/none/"" and the analysis methods (case-insensitive).
+func parseTrendMethod(s string) (string, error) {
+	switch m := strings.ToLower(strings.TrimSpace(s)); m {
+	case "", "off", "none":
+		return "", nil
+	case analysis.TrendLinear, analysis.TrendLoess:
+		return m, nil
+	}
+	return "", fmt.Errorf("trend %q: want off, linear or loess", s)
+}
+
+func trendMethodLabel(m string) string {
+	switch m {
+	case analysis.TrendLinear:
+		return "Linear"
+	case analysis.TrendLoess:
+		return "LOESS"
+	}
+	return "Off"
+}
+
+// batchAxisNames are the x-axis names buildXAxis gives the per-batch charts; only those get
+// trends (the per-transfer sample charts have time (s) axes).
+var batchAxisNames = map[string]bool{"Time": true, "RunTag": true, "Batch": true}
+
+// applyChartTrends appends a dashed trend line, and with a horizon a forecast line and band, for
+// each point series of a batch chart (the Overall/IPv4/IPv6 or percentile dots; rolling lines and
+// bands are skipped). The x range is widened to show the forecast and values are clamped to an
+// explicit y range. Like decimation it replaces c.Series, so callers' series are untouched.
+func applyChartTrends(c *chart.Chart, opt trendOptions, look chartAppearance) {
+	if c == nil || opt.Method == "" || !batchAxisNames[c.XAxis.Name] {
+		return
+	}
+	timeAxis := c.XAxis.Name == "Time"
+	yLo, yHi := math.Inf(-1), math.Inf(1)
+	if r := c.YAxis.Range; r != nil && !r.IsZero() {
+		yLo, yHi = r.GetMin(), r.GetMax()
+	}
+	clamp := func(v float64) float64 { return min(max(v, yLo), yHi) }
+	series := append([]chart.Series(nil), c.Series...)
+	maxX := math.Inf(-1)
+	bandNamed := false
+	for _, s := range c.Series {
+		var name string
+		var st chart.Style
+		var xs, ys []float64
+		switch ser := s.(type) {
+		case chart.ContinuousSeries:
+			name, st, xs, ys = ser.Name, ser.Style, ser.XValues, ser.YValues
+		case chart.TimeSeries:
+			name, st, ys = ser.Name, ser.Style, ser.YValues
+			xs = make([]float64, len(ser.XValues))
+			for i, t := range ser.XValues {
+				xs[i] = chart.TimeToFloat64(t)
+			}
+		default:
+			continue
+		}
+		if name == "" || st.Hidden || st.DotWidth <= 0 || st.DotColor.IsZero() || st.StrokeWidth > 0 || !st.FillColor.IsZero() || len(xs) != len(ys) {
+			continue
+		}
+		// the forecast steps one batch: the median spacing in time mode, 1 on index axes
+		step := 0.0
+		if !timeAxis {
+			step = 1
+		}
+		tr, ok := analysis.FitTrend(xs, ys, opt.Method, opt.Horizon, step)
+		if !ok {
+			continue
+		}
+		col := st.DotColor
+		// the unnamed forecast line and band take a family color override from their series
+		if fc, ok := look.familyColor(name); ok {
+			col = fc
+		}
+		lastX := math.Inf(-1)
+		var fx, fy []float64
+		for i, x := range xs {
+			if math.IsNaN(ys[i]) || math.IsNaN(tr.Fitted[i]) {
+				continue
+			}
+			fx = append(fx, x)
+			fy = append(fy, clamp(tr.Fitted[i]))
+			lastX = math.Max(lastX, x)
+		}
+		perUnit, unit := 1.0, "batch"
+		if timeAxis {
+			perUnit, unit = float64(24*time.Hour), "day"
+		}
+		label := fmt.Sprintf("%s trend (%+.1f%%/%s)", name, tr.SlopePct(lastX)*perUnit, unit)
+		series = append(series, trendSeries(timeAxis, label, fx, fy, chart.Style{StrokeColor: col.WithAlpha(230), StrokeWidth: 1.5, StrokeDashArray: []float64{6, 3}}))
+		if len(tr.Forecast) == 0 {
+			continue
+		}
+		// forecast starts at the last fitted point so it continues the trend line
+		start := tr.Intercept + tr.Slope*lastX
+		px, py, lo, hi := []float64{lastX}, []float64{clamp(start)}, []float64{clamp(start)}, []float64{clamp(start)}
+		for _, f := range tr.Forecast {
+			px = append(px, f.X)
+			py = append(py, clamp(f.Y))
+			lo = append(lo, clamp(f.Lo))
+			hi = append(hi, clamp(f.Hi))
+			maxX = math.Max(maxX, f.X)
+		}
+		bandName := ""
+		if !bandNamed {
+			bandName = fmt.Sprintf("Forecast ±95%% (%d batches)", opt.Horizon)
+			bandNamed = true
+		}
+		// the band fills only between its bounds, so other series' bands and lines drawn
+		// earlier stay visible through it
+		series = append(series,
+			forecastBand{name: bandName, family: name, xs: px, lo: lo, hi: hi, style: chart.Style{FillColor: col.WithAlpha(40), StrokeColor: col.WithAlpha(40), StrokeWidth: 0.5}},
+			trendSeries(timeAxis, "", px, py, chart.Style{StrokeColor: col.WithAlpha(160), StrokeWidth: 1.5, StrokeDashArray: []float64{2, 3}}),
+		)
+	}
+	c.Series = series
+	if r, ok := c.XAxis.Range.(*chart.ContinuousRange); ok && r != nil && !math.IsInf(maxX, -1) {
+		pad := 0.5
+		if timeAxis {
+			pad = 0
+		}
+		if maxX+pad > r.Max {
+			c.XAxis.Range = &chart.ContinuousRange{Min: r.Min, Max: maxX + pad, Domain: r.Domain, Descending: r.Descending}
+		}
+	}
+}
+
+func trendSeries(timeAxis bool, name string, xs, ys []float64, st chart.Style) chart.Series {
+	if timeAxis {
+		ts := make([]time.Time, len(xs))
+		for i, x := range xs {
+			ts[i] = chart.TimeFromFloat64(x)
+		}
+		return chart.TimeSeries{Name: name, XValues: ts, YValues: ys, Style: st}
+	}
+	return chart.ContinuousSeries{Name: name, XValues: xs, YValues: ys, Style: st}
+}
+
+// forecastBand is a translucent fill between lo and hi (go-chart's bounded series drawing, as
+// used by its Bollinger bands). Unlike a fill under hi with the area under lo cut out in the
+// background color, it does not paint over series drawn before it. xs are float x values (for
+// time axes chart.TimeToFloat64).
+type forecastBand struct {
+	name       string
+	family     string // the series the forecast belongs to, for the appearance's family colors
+	style      chart.Style
+	xs, lo, hi []float64
+}
+
+func (b forecastBand) GetName() string           { return b.name }
+func (b forecastBand) GetStyle() chart.Style     { return b.style }
+func (b forecastBand) GetYAxis() chart.YAxisType { return chart.YAxisPrimary }
+func (b forecastBand) Len() int                  { return len(b.xs) }
+
+func (b forecastBand) GetBoundedValues(i int) (x, y1, y2 float64) {
+	return b.xs[i], b.hi[i], b.lo[i]
+}
+
+func (b forecastBand) Validate() error {
+	if len(b.lo) != len(b.xs) || len(b.hi) != len(b.xs) {
+		return fmt.Errorf("forecast band %q: %d x values, %d lower and %d upper bounds", b.name, len(b.xs), len(b.lo), len(b.hi))
+	}
+	return nil
+}
+
+func (b forecastBand) Render(r chart.Renderer, canvasBox chart.Box, xrange, yrange chart.Range, _ chart.Style) {
+	if len(b.xs) < 2 {
+		return
+	}
+	chart.Draw.BoundedSeries(r, canvasBox, xrange, yrange, b.style, b)
+}
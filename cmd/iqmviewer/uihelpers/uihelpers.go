@@ -1,8 +1,12 @@
 package uihelpers
 
 import (
+	"image"
+	"image/color"
 	"math"
+	"sort"
 	"strconv"
+	"strings"
 )
 
 // ComputeChartDimensions applies width/height clamp rules used for charts.
@@ -22,21 +26,21 @@ func ComputeChartDimensions(rawW int) (int, int) {
 	return w, h
 }
 
-// ComputeTableColumnWidths returns the 10 column widths for the summary table given a window width.
-// Order: RunTag, Count, AvgSpeed, AvgTTFB, Errs, IPv4Speed, IPv4TTFB, IPv6Speed, IPv6TTFB, Quality
-func ComputeTableColumnWidths(winW float32) [10]int {
+// ComputeTableColumnWidths returns the 12 column widths for the summary table given a window width.
+// Order: RunTag, Count, AvgSpeed, AvgTTFB, Errs, IPv4Speed, IPv4TTFB, IPv6Speed, IPv6TTFB, Quality, IPv6Readiness, Score
+func ComputeTableColumnWidths(winW float32) [12]int {
 	const compactBreakpoint = 900
 	const ultraCompactBreakpoint = 520
 	if winW < ultraCompactBreakpoint {
-		return [10]int{110, 0, 70, 0, 0, 0, 0, 0, 0, 24}
+		return [12]int{110, 0, 70, 0, 0, 0, 0, 0, 0, 24, 0, 40}
 	}
 	if winW < compactBreakpoint {
 		if winW < 760 {
-			return [10]int{140, 55, 90, 70, 55, 0, 0, 0, 0, 32}
+			return [12]int{140, 55, 90, 70, 55, 0, 0, 0, 0, 32, 0, 50}
 		}
-		return [10]int{140, 55, 90, 70, 55, 90, 70, 90, 70, 32}
+		return [12]int{140, 55, 90, 70, 55, 90, 70, 90, 70, 32, 55, 50}
 	}
-	return [10]int{220, 70, 130, 100, 70, 120, 110, 120, 110, 60}
+	return [12]int{220, 70, 130, 100, 70, 120, 110, 120, 110, 60, 70, 60}
 }
 
 // ComputeMiniChartHeight derives a reasonable mini-chart height (used for stacked detailed
@@ -166,3 +170,192 @@ func FormatNumericTick(v float64) string {
 		return strconv.FormatFloat(v, 'f', 4, 64)
 	}
 }
+
+// DecimateIndices picks the points to draw when a series has far more points than pixels.
+// The interior is split into buckets; each bucket keeps its minimum and maximum (so spikes and
+// dips survive) plus the largest-triangle (LTTB) point relative to the previous pick and the
+// next bucket's average. First and last points are always kept; a bucket with NaN values keeps
+// its first NaN so gaps stay visible instead of being bridged by the line. xs may be nil (index positions). It returns nil when the series is
+// short enough (n <= 3*buckets) and should be drawn unchanged.
+func DecimateIndices(xs, ys []float64, buckets int) []int {
+	n := len(ys)
+	if buckets <= 0 || n <= 3*buckets || n < 3 {
+		return nil
+	}
+	x := func(i int) float64 {
+		if xs != nil {
+			return xs[i]
+		}
+		return float64(i)
+	}
+	bounds := func(b int) (int, int) { // [lo, hi) of bucket b over indices 1..n-2
+		span := float64(n-2) / float64(buckets)
+		return 1 + int(float64(b)*span), 1 + int(float64(b+1)*span)
+	}
+	out := make([]int, 0, 3*buckets+2)
+	out = append(out, 0)
+	prev := 0
+	for b := 0; b < buckets; b++ {
+		lo, hi := bounds(b)
+		if lo >= hi {
+			continue
+		}
+		// average of the next bucket (or the last point) as the LTTB anchor
+		nlo, nhi := n-1, n
+		if b+1 < buckets {
+			nlo, nhi = bounds(b + 1)
+		}
+		var ax, ay float64
+		cnt := 0
+		for i := nlo; i < nhi; i++ {
+			if !math.IsNaN(ys[i]) {
+				ax += x(i)
+				ay += ys[i]
+				cnt++
+			}
+		}
+		if cnt > 0 {
+			ax /= float64(cnt)
+			ay /= float64(cnt)
+		}
+		minI, maxI, bestI, nanI := -1, -1, -1, -1
+		bestArea := -1.0
+		for i := lo; i < hi; i++ {
+			v := ys[i]
+			if math.IsNaN(v) {
+				if nanI < 0 {
+					nanI = i
+				}
+				continue
+			}
+			if minI < 0 || v < ys[minI] {
+				minI = i
+			}
+			if maxI < 0 || v > ys[maxI] {
+				maxI = i
+			}
+			if cnt > 0 && !math.IsNaN(ys[prev]) {
+				area := math.Abs((x(prev)-ax)*(v-ys[prev]) - (x(prev)-x(i))*(ay-ys[prev]))
+				if area > bestArea {
+					bestArea, bestI = area, i
+				}
+			}
+		}
+		if minI < 0 { // all NaN: keep the gap
+			out = append(out, lo)
+			prev = lo
+			continue
+		}
+		picks := []int{minI, maxI, bestI, nanI}
+		sort.Ints(picks)
+		for _, i := range picks {
+			if i >= 0 && i != out[len(out)-1] {
+				out = append(out, i)
+			}
+		}
+		prev = out[len(out)-1]
+	}
+	if out[len(out)-1] != n-1 {
+		out = append(out, n-1)
+	}
+	return out
+}
+
+// Sparkline draws vals as a small line on a transparent w×h image, scaled to the values' own
+// min..max (a flat series is drawn mid-height). NaN values are gaps; a single valid point
+// between gaps is drawn as a dot. The last valid point gets a 3×3 marker.
+func Sparkline(vals []float64, w, h int, col color.RGBA) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	if w < 2 || h < 2 || len(vals) == 0 {
+		return img
+	}
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, v := range vals {
+		if !math.IsNaN(v) {
+			lo, hi = math.Min(lo, v), math.Max(hi, v)
+		}
+	}
+	if math.IsInf(lo, 1) {
+		return img
+	}
+	const pad = 2
+	px := func(i int) float64 {
+		if len(vals) == 1 {
+			return float64(w) / 2
+		}
+		return pad + float64(i)*float64(w-1-2*pad)/float64(len(vals)-1)
+	}
+	py := func(v float64) float64 {
+		if hi == lo {
+			return float64(h) / 2
+		}
+		return float64(h-1-pad) - (v-lo)/(hi-lo)*float64(h-1-2*pad)
+	}
+	set := func(x, y int) {
+		if x >= 0 && y >= 0 && x < w && y < h {
+			img.SetRGBA(x, y, col)
+		}
+	}
+	prev, last := -1, -1
+	for i, v := range vals {
+		if math.IsNaN(v) {
+			prev = -1
+			continue
+		}
+		x1, y1 := px(i), py(v)
+		if prev >= 0 {
+			x0, y0 := px(prev), py(vals[prev])
+			steps := int(math.Max(math.Abs(x1-x0), math.Abs(y1-y0))) + 1
+			for s := 0; s <= steps; s++ {
+				t := float64(s) / float64(steps)
+				set(int(math.Round(x0+(x1-x0)*t)), int(math.Round(y0+(y1-y0)*t)))
+			}
+		} else {
+			set(int(math.Round(x1)), int(math.Round(y1)))
+		}
+		prev, last = i, i
+	}
+	lx, ly := int(math.Round(px(last))), int(math.Round(py(vals[last])))
+	for dx := -1; dx <= 1; dx++ {
+		for dy := -1; dy <= 1; dy++ {
+			set(lx+dx, ly+dy)
+		}
+	}
+	return img
+}
+
+// SortKey is one table cell's sort value: numeric when Text is empty, otherwise compared as
+// text (case-insensitive). Missing cells ("-") always sort after the others.
+type SortKey struct {
+	Num     float64
+	Text    string
+	Missing bool
+}
+
+// SortedRowOrder returns row indexes ordered by keys (ascending, or descending when desc). The
+// sort is stable, so rows with equal keys keep their original (chronological) order.
+func SortedRowOrder(keys []SortKey, desc bool) []int {
+	order := make([]int, len(keys))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		ka, kb := keys[order[a]], keys[order[b]]
+		if ka.Missing || kb.Missing {
+			return !ka.Missing && kb.Missing
+		}
+		var c int
+		if ka.Text != "" || kb.Text != "" {
+			c = strings.Compare(strings.ToLower(ka.Text), strings.ToLower(kb.Text))
+		} else if ka.Num < kb.Num {
+			c = -1
+		} else if ka.Num > kb.Num {
+			c = 1
+		}
+		if desc {
+			return c > 0
+		}
+		return c < 0
+	})
+	return order
+}
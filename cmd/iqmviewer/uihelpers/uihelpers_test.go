@@ -1,6 +1,8 @@
 package uihelpers
 
 import (
+	"fmt"
+	"image/color"
 	"math"
 	"testing"
 )
@@ -28,19 +30,22 @@ func TestComputeChartDimensions(t *testing.T) {
 
 func TestComputeTableColumnWidths(t *testing.T) {
 	ultra := ComputeTableColumnWidths(400)
-	if ultra != [10]int{110, 0, 70, 0, 0, 0, 0, 0, 0, 24} {
+	if ultra != [12]int{110, 0, 70, 0, 0, 0, 0, 0, 0, 24, 0, 40} {
 		t.Fatalf("ultra widths mismatch: %#v", ultra)
 	}
 	compactHide := ComputeTableColumnWidths(700)
-	if compactHide[5] != 0 || compactHide[6] != 0 || compactHide[7] != 0 || compactHide[8] != 0 {
+	if compactHide[5] != 0 || compactHide[6] != 0 || compactHide[7] != 0 || compactHide[8] != 0 || compactHide[10] != 0 {
 		t.Fatalf("expected ipv4/ipv6 hidden at 700: %#v", compactHide)
 	}
+	if compactHide[11] == 0 {
+		t.Fatalf("expected score visible at 700: %#v", compactHide)
+	}
 	compactFull := ComputeTableColumnWidths(850)
-	if compactFull[5] == 0 || compactFull[7] == 0 {
+	if compactFull[5] == 0 || compactFull[7] == 0 || compactFull[10] == 0 {
 		t.Fatalf("expected ipv4/ipv6 visible at 850: %#v", compactFull)
 	}
 	full := ComputeTableColumnWidths(1200)
-	expectedFull := [10]int{220, 70, 130, 100, 70, 120, 110, 120, 110, 60}
+	expectedFull := [12]int{220, 70, 130, 100, 70, 120, 110, 120, 110, 60, 70, 60}
 	if full != expectedFull {
 		t.Fatalf("full widths mismatch got %#v want %#v", full, expectedFull)
 	}
@@ -154,3 +159,97 @@ func TestBuildNumericTicksAndFormat(t *testing.T) {
 		t.Fatalf("format 0.001234 => %q want 0.0012", got)
 	}
 }
+
+func TestDecimateIndices_KeepsSpikesAndEnds(t *testing.T) {
+	n := 10000
+	ys := make([]float64, n)
+	for i := range ys {
+		ys[i] = 100 + float64(i%7)
+	}
+	ys[4321] = 9000 // spike
+	ys[7777] = -50  // dip
+	for i := 5000; i < 5100; i++ {
+		ys[i] = math.NaN() // gap
+	}
+	idx := DecimateIndices(nil, ys, 200)
+	if len(idx) == 0 || len(idx) > 3*200+2 {
+		t.Fatalf("decimated to %d points", len(idx))
+	}
+	has := map[int]bool{}
+	for k, i := range idx {
+		if k > 0 && i <= idx[k-1] {
+			t.Fatalf("indices not strictly increasing at %d", k)
+		}
+		has[i] = true
+	}
+	if !has[0] || !has[n-1] || !has[4321] || !has[7777] {
+		t.Fatalf("first/last/spike/dip missing")
+	}
+	gap := false
+	for _, i := range idx {
+		if math.IsNaN(ys[i]) {
+			gap = true
+		}
+	}
+	if !gap {
+		t.Fatalf("NaN gap lost")
+	}
+	// a gap shorter than a bucket must break the line too
+	ys[2000] = math.NaN()
+	short := false
+	for _, i := range DecimateIndices(nil, ys, 200) {
+		short = short || i == 2000
+	}
+	if !short {
+		t.Fatalf("single NaN bridged")
+	}
+	if DecimateIndices(nil, ys[:500], 200) != nil {
+		t.Fatalf("short series must not be decimated")
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	col := color.RGBA{200, 0, 0, 255}
+	img := Sparkline([]float64{1, 2, math.NaN(), 4, 3}, 60, 20, col)
+	if b := img.Bounds(); b.Dx() != 60 || b.Dy() != 20 {
+		t.Fatalf("bounds %v", b)
+	}
+	painted := func(x0, x1 int) int {
+		n := 0
+		for x := x0; x < x1; x++ {
+			for y := 0; y < 20; y++ {
+				if img.RGBAAt(x, y) == col {
+					n++
+				}
+			}
+		}
+		return n
+	}
+	if painted(0, 60) == 0 {
+		t.Fatalf("nothing drawn")
+	}
+	// the NaN sits at x≈30; the segment 2→4 must not be bridged across it
+	if painted(24, 27) != 0 {
+		t.Fatalf("gap was bridged")
+	}
+	if Sparkline([]float64{math.NaN()}, 10, 10, col).RGBAAt(5, 5) == col {
+		t.Fatalf("all-NaN series must stay empty")
+	}
+}
+
+func TestSortedRowOrder(t *testing.T) {
+	keys := []SortKey{{Num: 3}, {Missing: true}, {Num: 1}, {Num: 3}, {Num: 2}}
+	if got := fmt.Sprint(SortedRowOrder(keys, false)); got != "[2 4 0 3 1]" {
+		t.Fatalf("ascending: %s", got)
+	}
+	if got := fmt.Sprint(SortedRowOrder(keys, true)); got != "[0 3 4 2 1]" {
+		t.Fatalf("descending keeps ties stable and missing last: %s", got)
+	}
+	text := []SortKey{{Text: "office"}, {Text: "Home"}, {Text: "hotspot"}}
+	if got := fmt.Sprint(SortedRowOrder(text, false)); got != "[1 2 0]" {
+		t.Fatalf("text: %s", got)
+	}
+	if len(SortedRowOrder(nil, true)) != 0 {
+		t.Fatalf("empty")
+	}
+}
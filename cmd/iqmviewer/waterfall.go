@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"strings"
+
+	"github.com/iafilius/InternetQualityMonitor/src/monitor"
+)
+
+// Request waterfall of the "View lines…" drill-down: one horizontal stacked bar per selected line
+// with the phases of its GET in order, so a slow sample shows at a glance whether the time went
+// into setup (DNS, connect, TLS), the server (wait for the first byte) or the transfer.
+
+// waterfallPhase is one segment of the bar.
+type waterfallPhase struct {
+	name string
+	ms   int64
+	col  color.RGBA
+}
+
+// waterfallPhaseColors follow the browser devtools palette.
+var waterfallPhaseColors = map[string]color.RGBA{
+	"DNS":      {R: 0x1f, G: 0x9e, B: 0x89, A: 0xff},
+	"Connect":  {R: 0xf2, G: 0x8c, B: 0x1c, A: 0xff},
+	"TLS":      {R: 0x9c, G: 0x27, B: 0xb0, A: 0xff},
+	"Wait":     {R: 0x2e, G: 0x9e, B: 0x44, A: 0xff},
+	"Download": {R: 0x29, G: 0x6f, B: 0xf6, A: 0xff},
+}
+
+// lineWaterfall splits a line's timings into DNS → Connect → TLS → Wait → Download. The httptrace
+// phases of the GET are preferred, falling back to the separately timed DNS lookup, TCP connect
+// and TLS handshake. Wait is the TTFB after the connection was ready; the TTFB clock starts at the
+// request, so it does not include the separate DNS lookup. nil when the line has no timings.
+func lineWaterfall(sr *monitor.SiteResult) []waterfallPhase {
+	if sr == nil {
+		return nil
+	}
+	pick := func(trace, fallback int64) int64 {
+		if trace > 0 {
+			return trace
+		}
+		return fallback
+	}
+	dns := pick(sr.TraceDNSMs, sr.DNSTimeMs)
+	conn := pick(sr.TraceConnectMs, sr.TCPTimeMs)
+	tls := pick(sr.TraceTLSMs, sr.SSLHandshakeTimeMs)
+	ready := sr.TraceTimeToConnMs
+	if ready <= 0 {
+		ready = sr.TraceDNSMs + sr.TraceConnectMs + sr.TraceTLSMs
+	}
+	var wait int64
+	if sr.TraceTTFBMs > 0 {
+		wait = sr.TraceTTFBMs - ready
+		if wait < 0 {
+			wait = 0
+		}
+	}
+	phases := []waterfallPhase{{"DNS", dns, waterfallPhaseColors["DNS"]}, {"Connect", conn, waterfallPhaseColors["Connect"]},
+		{"TLS", tls, waterfallPhaseColors["TLS"]}, {"Wait", wait, waterfallPhaseColors["Wait"]}, {"Download", sr.TransferTimeMs, waterfallPhaseColors["Download"]}}
+	if waterfallTotal(phases) == 0 {
+		return nil
+	}
+	return phases
+}
+
+func waterfallTotal(phases []waterfallPhase) int64 {
+	var t int64
+	for _, p := range phases {
+		t += p.ms
+	}
+	return t
+}
+
+// waterfallCaption lists the phases in ms with the shares of setup (DNS, connect, TLS) and
+// download in the total.
+func waterfallCaption(phases []waterfallPhase) string {
+	total := waterfallTotal(phases)
+	if total == 0 {
+		return "No request timings for this line"
+	}
+	var parts []string
+	var setup int64
+	for _, p := range phases {
+		if p.ms == 0 {
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%s %d ms", p.name, p.ms))
+		if p.name == "DNS" || p.name == "Connect" || p.name == "TLS" {
+			setup += p.ms
+		}
+	}
+	pct := func(ms int64) float64 { return float64(ms) * 100 / float64(total) }
+	return fmt.Sprintf("%s — total %d ms, setup %.0f%%, download %.0f%%", strings.Join(parts, " · "), total, pct(setup), pct(phases[len(phases)-1].ms))
+}
+
+// waterfallImage draws the phases as a stacked bar across w×h. Every non-zero phase is at least
+// 2 px wide so a 1 ms DNS lookup next to a 10 s download stays visible.
+func waterfallImage(phases []waterfallPhase, w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	total := waterfallTotal(phases)
+	if total == 0 || w < 2 || h < 1 {
+		return img
+	}
+	const minW = 2
+	nonZero := 0
+	for _, p := range phases {
+		if p.ms > 0 {
+			nonZero++
+		}
+	}
+	avail := w - nonZero*minW
+	if avail < 0 {
+		avail = 0
+	}
+	x, k := 0, 0
+	var done int64
+	for _, p := range phases {
+		if p.ms == 0 {
+			continue
+		}
+		done += p.ms
+		k++
+		// cumulative rounding, so the last segment ends exactly at w
+		end := int(int64(avail)*done/total) + k*minW
+		if end > w {
+			end = w
+		}
+		draw.Draw(img, image.Rect(x, 0, end, h), &image.Uniform{C: p.col}, image.Point{}, draw.Src)
+		x = end
+	}
+	return img
+}
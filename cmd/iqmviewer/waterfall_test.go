@@ -0,0 +1,37 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/iafilius/InternetQualityMonitor/src/monitor"
+)
+
+func TestLineWaterfall(t *testing.T) {
+	// trace phases win over the separate timings; wait is the TTFB after the connection was ready
+	sr := &monitor.SiteResult{DNSTimeMs: 12, TCPTimeMs: 99, TraceConnectMs: 30, TraceTLSMs: 45, TraceTimeToConnMs: 80, TraceTTFBMs: 200, TransferTimeMs: 713}
+	ph := lineWaterfall(sr)
+	want := []int64{12, 30, 45, 120, 713}
+	if len(ph) != len(want) {
+		t.Fatalf("phases: %+v", ph)
+	}
+	for i, p := range ph {
+		if p.ms != want[i] {
+			t.Fatalf("%s: %d want %d", p.name, p.ms, want[i])
+		}
+	}
+	if got := waterfallCaption(ph); got != "DNS 12 ms · Connect 30 ms · TLS 45 ms · Wait 120 ms · Download 713 ms — total 920 ms, setup 9%, download 78%" {
+		t.Fatalf("caption: %q", got)
+	}
+	if lineWaterfall(&monitor.SiteResult{TCPError: "refused"}) != nil || waterfallCaption(nil) != "No request timings for this line" {
+		t.Fatalf("no timings: no waterfall")
+	}
+}
+
+func TestWaterfallImage(t *testing.T) {
+	ph := []waterfallPhase{{"DNS", 1, waterfallPhaseColors["DNS"]}, {"Wait", 0, waterfallPhaseColors["Wait"]}, {"Download", 10000, waterfallPhaseColors["Download"]}}
+	img := waterfallImage(ph, 100, 4)
+	// the 1 ms lookup keeps its minimum width, the download fills the rest up to the edge
+	if img.RGBAAt(1, 0) != waterfallPhaseColors["DNS"] || img.RGBAAt(2, 0) != waterfallPhaseColors["Download"] || img.RGBAAt(99, 3) != waterfallPhaseColors["Download"] {
+		t.Fatalf("segments: %v %v %v", img.RGBAAt(1, 0), img.RGBAAt(2, 0), img.RGBAAt(99, 3))
+	}
+}
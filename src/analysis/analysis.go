@@ -1,9 +1,8 @@
 package analysis
 
 import (
-	"bufio"
+	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io"
 	"math"
@@ -13,6 +12,7 @@ import (
 	"time"
 
 	"github.com/iafilius/InternetQualityMonitor/src/monitor"
+	"github.com/iafilius/InternetQualityMonitor/src/types"
 )
 
 // isEnterpriseProxy returns true if the proxy name is recognized as an enterprise/security proxy
@@ -38,6 +38,9 @@ func isEnterpriseProxy(name string) bool {
 type BatchSummary struct {
 	RunTag      string  `json:"run_tag"`
 	Situation   string  `json:"situation,omitempty"`
+	Agent       string  `json:"agent,omitempty"`      // meta.agent of the batch (remote agent / collector files)
+	VPNActive   bool    `json:"vpn_active,omitempty"` // any line of the batch had meta.vpn_active
+	VPNName     string  `json:"vpn_name,omitempty"`
 	Lines       int     `json:"lines"`
 	AvgSpeed    float64 `json:"avg_speed_kbps"`
 	MedianSpeed float64 `json:"median_speed_kbps"`
@@ -58,6 +61,10 @@ type BatchSummary struct {
 	AvgLongestPlateau  float64 `json:"avg_longest_plateau_ms"`
 	AvgJitterPct       float64 `json:"avg_jitter_mean_abs_pct"`
 	BatchDurationMs    int64   `json:"batch_duration_ms,omitempty"`
+	StartedUTC         string  `json:"started_utc,omitempty"` // earliest line timestamp (RFC3339Nano); see StartTime
+	// Monitor's local UTC offset and zone abbreviation at StartedUTC (meta.utc_offset/meta.tz); see StartLocal
+	UTCOffset string `json:"utc_offset,omitempty"`
+	TimeZone  string `json:"tz,omitempty"`
 	// New: connection setup breakdown averages (ms)
 	AvgDNSMs        float64 `json:"avg_dns_ms,omitempty"`
 	AvgConnectMs    float64 `json:"avg_connect_ms,omitempty"`
@@ -65,10 +72,46 @@ type BatchSummary struct {
 	// Legacy-only averages to enable comparison overlays in the UI
 	// For DNS, this captures the legacy pre-resolve field dns_time_ms when present
 	AvgDNSLegacyMs float64 `json:"avg_dns_legacy_ms,omitempty"`
+	// A (IPv4) and AAAA (IPv6) lookups timed separately (monitor dns_family); a slow or failing
+	// AAAA inflates the combined DNS time without showing up in it as such
+	DNSFamilyLines      int     `json:"dns_family_lines,omitempty"`
+	AvgDNSAMs           float64 `json:"avg_dns_a_ms,omitempty"`
+	AvgDNSAAAAMs        float64 `json:"avg_dns_aaaa_ms,omitempty"`
+	P95DNSAMs           float64 `json:"p95_dns_a_ms,omitempty"`
+	P95DNSAAAAMs        float64 `json:"p95_dns_aaaa_ms,omitempty"`
+	DNSAErrorRatePct    float64 `json:"dns_a_error_rate_pct,omitempty"`
+	DNSAAAAErrorRatePct float64 `json:"dns_aaaa_error_rate_pct,omitempty"`
+	// Resolver cache behaviour (monitor dns_cache): lookups within the TTL of an earlier answer
+	// should be cache hits, re-queried ones are the sporadic slow lookups. DNSCacheHitRatePct is
+	// over DNSWithinTTLLookups; the averages are the lookup times of hits and re-queries.
+	DNSCacheLines         int     `json:"dns_cache_lines,omitempty"`
+	DNSWithinTTLLookups   int     `json:"dns_within_ttl_lookups,omitempty"`
+	DNSCacheHitRatePct    float64 `json:"dns_cache_hit_rate_pct,omitempty"`
+	AvgDNSCacheHitMs      float64 `json:"avg_dns_cache_hit_ms,omitempty"`
+	AvgDNSRequeryMs       float64 `json:"avg_dns_requery_ms,omitempty"`
+	MedianDNSTTLSeconds   float64 `json:"median_dns_ttl_s,omitempty"`
+	DNSTTLQueryErrorLines int     `json:"dns_ttl_query_error_lines,omitempty"`
 	// Extended aggregated metrics (averages or rates over successful lines)
 	AvgP90Speed float64 `json:"avg_p90_kbps,omitempty"`
 	AvgP95Speed float64 `json:"avg_p95_kbps,omitempty"`
 	AvgP99Speed float64 `json:"avg_p99_kbps,omitempty"`
+	// Pooled Speed percentiles over every transfer speed sample of the batch (true batch-level
+	// percentiles, read from the merged per-line sketches, ±1%). AvgP50Speed..AvgP99Speed average
+	// each line's own percentile instead; both are kept so the two methods can be compared. Zero
+	// when no line carries samples.
+	PooledP50Speed     float64 `json:"pooled_p50_kbps,omitempty"`
+	PooledP90Speed     float64 `json:"pooled_p90_kbps,omitempty"`
+	PooledP95Speed     float64 `json:"pooled_p95_kbps,omitempty"`
+	PooledP99Speed     float64 `json:"pooled_p99_kbps,omitempty"`
+	PooledSpeedSamples int     `json:"pooled_speed_samples,omitempty"`
+	// Mergeable quantile sketches of this batch's speed samples and per-line TTFBs; see
+	// MergeBatchSketches for percentiles over several batches.
+	SpeedSketch *monitor.Sketch `json:"speed_sketch,omitempty"`
+	TTFBSketch  *monitor.Sketch `json:"ttfb_sketch,omitempty"`
+	// SpeedHistogram is the compact log-bucketed histogram of the speed samples and SpeedModesKbps
+	// the centers of its modes when the distribution has more than one (see speed_histogram.go).
+	SpeedHistogram *monitor.ExpHistogram `json:"speed_histogram,omitempty"`
+	SpeedModesKbps []float64             `json:"speed_modes_kbps,omitempty"`
 	// Cross-line Speed percentiles
 	AvgP25Speed           float64 `json:"avg_p25_kbps,omitempty"`
 	AvgP75Speed           float64 `json:"avg_p75_kbps,omitempty"`
@@ -96,6 +139,13 @@ type BatchSummary struct {
 	AvgMicroStallMs    float64 `json:"avg_micro_stall_ms,omitempty"`    // average total ms per line among lines with at least one micro-stall
 	// Optional: rate of requests aborted before the first byte due to pre-TTFB stall watchdog
 	PreTTFBStallRatePct float64 `json:"pretffb_stall_rate_pct,omitempty"`
+	// Stall timeline: where within transfers the micro-stalls fell. StallTimelinePct has
+	// StallTimelineBins entries, one per slice of the transfer duration (first = transfer start).
+	StallTimelinePct      []float64 `json:"stall_timeline_pct,omitempty"`        // % of the time in this slice spent stalled
+	StallEvents           int       `json:"stall_events,omitempty"`              // micro-stall events in the batch
+	StallEventsEarlyPct   float64   `json:"stall_events_early_pct,omitempty"`    // events starting in the first slice
+	AvgStallEventOffsetMs float64   `json:"avg_stall_event_offset_ms,omitempty"` // mean start offset from transfer start
+	AvgStallEventMs       float64   `json:"avg_stall_event_ms,omitempty"`
 	// Measurement quality (unknown true speed) derived from intra-transfer samples (latest line in batch)
 	SampleCount                 int     `json:"sample_count,omitempty"`
 	CI95RelMoEPct               float64 `json:"ci95_rel_moe_pct,omitempty"`
@@ -131,6 +181,178 @@ type BatchSummary struct {
 	NextHopSource    string `json:"next_hop_source,omitempty"`
 	// Representative URL from this batch (most recent non-empty); useful for tooling like curl copy in the viewer
 	SampleURL string `json:"sample_url,omitempty"`
+	// Wi-Fi link (from meta.wifi; averages over lines carrying it, identity from the latest line)
+	AvgWiFiRSSIDBm     float64 `json:"avg_wifi_rssi_dbm,omitempty"`
+	AvgWiFiPHYRateMbps float64 `json:"avg_wifi_phy_rate_mbps,omitempty"`
+	WiFiSSID           string  `json:"wifi_ssid,omitempty"`
+	WiFiBSSID          string  `json:"wifi_bssid,omitempty"`
+	WiFiChannel        int     `json:"wifi_channel,omitempty"`
+	// Happy-eyeballs (dual-stack race) rollup over lines that carry a race
+	HappyEyeballsRaces          int     `json:"happy_eyeballs_races,omitempty"`         // distinct races (once per site and port), not lines
+	HappyEyeballsIPv6LostPct    float64 `json:"happy_eyeballs_ipv6_lost_pct,omitempty"` // IPv6 attempted but IPv4 won (or both failed)
+	AvgHappyEyeballsWinnerMs    float64 `json:"avg_happy_eyeballs_winner_ms,omitempty"`
+	AvgHappyEyeballsIPv6LoserMs float64 `json:"avg_happy_eyeballs_ipv6_loser_ms,omitempty"` // how long the losing IPv6 attempt ran
+	// QUIC/UDP reachability (lines carrying a quic_probe; --quic-probe). Only sites that advertise
+	// h3 via Alt-Svc must answer, so only their lines count towards the blocked rate.
+	QUICProbeLines      int     `json:"quic_probe_lines,omitempty"` // probed lines of h3-advertising sites
+	UDPBlockedLines     int     `json:"udp_blocked_lines,omitempty"`
+	UDPBlockedRatePct   float64 `json:"udp_blocked_rate_pct,omitempty"`
+	QUICNoListenerLines int     `json:"quic_no_listener_lines,omitempty"` // unanswered probes of sites without h3: most likely no QUIC server
+	// Cold vs warm connection experiment (lines with a successful reuse_experiment; --reuse-experiment)
+	ReuseExperimentLines int     `json:"reuse_experiment_lines,omitempty"`
+	AvgColdTTFBMs        float64 `json:"avg_cold_ttfb_ms,omitempty"`
+	AvgWarmTTFBMs        float64 `json:"avg_warm_ttfb_ms,omitempty"`
+	AvgSetupCostMs       float64 `json:"avg_setup_cost_ms,omitempty"` // cold minus warm TTFB: handshake cost of a fresh connection
+	P50SetupCostMs       float64 `json:"p50_setup_cost_ms,omitempty"`
+	// Content integrity (sites with an expected sha256): complete bodies checked and the share
+	// whose digest differed, e.g. an intercepting proxy rewriting or injecting content
+	IntegrityCheckedLines    int     `json:"integrity_checked_lines,omitempty"`
+	ContentCorruptionRatePct float64 `json:"content_corruption_rate_pct,omitempty"`
+	// Server throttling: lines where any request got 429 (or 503 with Retry-After)
+	RateLimitedLines   int     `json:"rate_limited_lines,omitempty"`
+	RateLimitedRatePct float64 `json:"rate_limited_rate_pct,omitempty"`
+	AvgRetryAfterSec   float64 `json:"avg_retry_after_s,omitempty"` // over throttled lines that sent a positive Retry-After
+	// Composite IPv6 readiness of the batch (AAAA availability, IPv6 success, IPv6 vs IPv4
+	// speed/TTFB, UDP reachability); nil for batches without any IPv6 information
+	IPv6Readiness *IPv6Readiness `json:"ipv6_readiness,omitempty"`
+	// Composite network quality ("weather") of the batch from speed, TTFB, jitter, stalls and
+	// errors (SetQualityScoreConfig); nil for batches without HTTP lines
+	QualityScore *QualityScore `json:"quality_score,omitempty"`
+	// Regulator-style consistency of the batch's transfer speeds (P10 and P20 against the median,
+	// share within ±20% of it); nil below StabilityMinTests successful transfers
+	Stability *ThroughputStability `json:"throughput_stability,omitempty"`
+	// meta.tags of the batch (monitor --tags); merged over its lines, first value per key wins
+	Tags map[string]string `json:"tags,omitempty"`
+	// IPv4 vs IPv6 paths of the dual-stack sites (see SiteRouteComparison) and the client's
+	// public egress ASN per family from meta; RouteDifferSites counts comparisons that differ
+	RouteComparisons []SiteRouteComparison `json:"route_comparisons,omitempty"`
+	RouteDifferSites int                   `json:"route_differ_sites,omitempty"`
+	// Return-path hop counts from the response TTLs (monitor --response-ttl) with the targets
+	// that were rerouted since the previous batch; nil when no line has one
+	ReturnHops *ReturnHops `json:"return_hops,omitempty"`
+	// Response body compression (monitor --accept-encoding): encodings, ratios and compressible
+	// bodies that arrived uncompressed; nil when no line sampled its body
+	Compression *CompressionStats `json:"compression,omitempty"`
+	// TTFB split into server-reported processing (Server-Timing headers) and network time, over
+	// the lines whose origin sent them; nil when none did
+	ServerTiming *ServerTimingStats `json:"server_timing,omitempty"`
+	// Retransmissions and out-of-order segments of the lines' TCP connections (monitor
+	// tcp_stats); nil when no line has them
+	TCPStats         *TCPRetransStats `json:"tcp_stats,omitempty"`
+	EgressIPv4ASN    uint             `json:"egress_ipv4_asn,omitempty"`
+	EgressIPv4ASNOrg string           `json:"egress_ipv4_asn_org,omitempty"`
+	EgressIPv6ASN    uint             `json:"egress_ipv6_asn,omitempty"`
+	EgressIPv6ASNOrg string           `json:"egress_ipv6_asn_org,omitempty"`
+	// Response header fingerprint per target (monitor --capture-headers), see HeaderTimeline
+	HeaderFingerprints []SiteHeaderFingerprint `json:"header_fingerprints,omitempty"`
+	// Per-target compliance with the SLOs of the sites file (site "slo"), by target name
+	TargetSLOs []TargetSLOCompliance `json:"target_slos,omitempty"`
+	// Non-HTTP probe lines (sites with "probe": ping, dns, ...); every metric above covers HTTP
+	// lines only. ProbeLines counts lines per probe_type including http, set when a batch has
+	// probe lines. Ping RTTs are pooled over all successful connects; ping jitter is the RFC 3550
+	// interarrival jitter of each ping line (>= 2 RTTs), averaged and P95 over those lines.
+	ProbeLines           map[string]int `json:"probe_lines,omitempty"`
+	PingLines            int            `json:"ping_lines,omitempty"`
+	AvgPingRTTMs         float64        `json:"avg_ping_rtt_ms,omitempty"`
+	P50PingRTTMs         float64        `json:"p50_ping_rtt_ms,omitempty"`
+	P95PingRTTMs         float64        `json:"p95_ping_rtt_ms,omitempty"`
+	PingLossPct          float64        `json:"ping_loss_pct,omitempty"`
+	PingJitterLines      int            `json:"ping_jitter_lines,omitempty"`
+	AvgPingJitterMs      float64        `json:"avg_ping_jitter_ms,omitempty"`
+	P95PingJitterMs      float64        `json:"p95_ping_jitter_ms,omitempty"`
+	DNSProbeLines        int            `json:"dns_probe_lines,omitempty"`
+	AvgDNSProbeMs        float64        `json:"avg_dns_probe_ms,omitempty"`
+	DNSProbeErrorRatePct float64        `json:"dns_probe_error_rate_pct,omitempty"`
+	// Soak probe (continuous stream): speed samples pooled over all soak lines (Avg/Median/P5),
+	// SoakCoVPct the mean per-line coefficient of variation. A drop is a run of samples below
+	// half the line's median lasting >= 2s; SoakTimeInDropPct is the share of sampled time in
+	// drops. Interruptions are mid-stream failures the probe reconnected after.
+	SoakLines         int     `json:"soak_lines,omitempty"`
+	SoakSeconds       float64 `json:"soak_seconds,omitempty"`
+	AvgSoakKbps       float64 `json:"avg_soak_kbps,omitempty"`
+	MedianSoakKbps    float64 `json:"median_soak_kbps,omitempty"`
+	P5SoakKbps        float64 `json:"p5_soak_kbps,omitempty"`
+	SoakCoVPct        float64 `json:"soak_cov_pct,omitempty"`
+	SoakDropEvents    int     `json:"soak_drop_events,omitempty"`
+	SoakDropsPerHour  float64 `json:"soak_drops_per_hour,omitempty"`
+	SoakLongestDropS  float64 `json:"soak_longest_drop_s,omitempty"`
+	SoakTimeInDropPct float64 `json:"soak_time_in_drop_pct,omitempty"`
+	SoakInterruptions int     `json:"soak_interruptions,omitempty"`
+	// iperf3 probe (raw capacity, monitor IPerf3Measurer): TCP received rates per line
+	// (Avg/Median over the successful lines) with the senders' retransmits and, for lines with a
+	// UDP test, its received rate, jitter and pooled loss. IPerf3HTTPSpeedPct is the batch's HTTP
+	// AvgSpeed as a share of AvgIPerf3TCPKbps: near 100 the path is the limit, far below it the
+	// HTTP stack, proxies or origins are.
+	IPerf3Lines          int     `json:"iperf3_lines,omitempty"`
+	IPerf3ErrorRatePct   float64 `json:"iperf3_error_rate_pct,omitempty"`
+	AvgIPerf3TCPKbps     float64 `json:"avg_iperf3_tcp_kbps,omitempty"`
+	MedianIPerf3TCPKbps  float64 `json:"median_iperf3_tcp_kbps,omitempty"`
+	IPerf3Retransmits    int64   `json:"iperf3_retransmits,omitempty"`
+	IPerf3UDPLines       int     `json:"iperf3_udp_lines,omitempty"`
+	AvgIPerf3UDPKbps     float64 `json:"avg_iperf3_udp_kbps,omitempty"`
+	AvgIPerf3UDPJitterMs float64 `json:"avg_iperf3_udp_jitter_ms,omitempty"`
+	IPerf3UDPLossPct     float64 `json:"iperf3_udp_loss_pct,omitempty"`
+	IPerf3HTTPSpeedPct   float64 `json:"iperf3_http_speed_pct,omitempty"`
+	// Metered networks (monitor --metered-policy): ReducedMode is set when lines ran with capped
+	// transfers, so their lower speeds are not read as a regression; Metered/MeteredSource come
+	// from meta.metered. CappedTransferLines counts transfers that stopped at the cap.
+	ReducedMode         bool   `json:"reduced_mode,omitempty"`
+	ReducedModeLines    int    `json:"reduced_mode_lines,omitempty"`
+	CappedTransferLines int    `json:"capped_transfer_lines,omitempty"`
+	Metered             bool   `json:"metered,omitempty"`
+	MeteredSource       string `json:"metered_source,omitempty"`
+	// SamplingProfile is meta.sampling_profile ("lite" for monitor --sampling-profile lite: fewer
+	// targets, capped transfers, no per-sample speeds); empty for full batches.
+	SamplingProfile string `json:"sampling_profile,omitempty"`
+	// DNS hijack check (monitor --dns-hijack-check): random names that cannot exist were resolved
+	// once per batch. DNSHijackSuspected is set when the resolver answered any of them instead of
+	// NXDOMAIN; DNSHijackAnswers are the addresses it rewrote them to.
+	DNSHijackChecked   bool     `json:"dns_hijack_checked,omitempty"`
+	DNSHijackSuspected bool     `json:"dns_hijack_suspected,omitempty"`
+	DNSHijackAnswers   []string `json:"dns_hijack_answers,omitempty"`
+	DNSHijackResolver  string   `json:"dns_hijack_resolver,omitempty"`
+	// Source binding (monitor --interface/--source-ip): the interface and local address the
+	// batch's connections were bound to; empty when the system chose.
+	BindInterface string `json:"bind_interface,omitempty"`
+	BindSourceIP  string `json:"bind_source_ip,omitempty"`
+	// Batch hooks (monitor --pre-batch-hook/--post-batch-hook). PreHook ran before the batch;
+	// PostHook ran after it and is taken from the next batch's meta.hooks.post_prev, so the newest
+	// batch never has one yet.
+	PreHook  *monitor.HookResult `json:"pre_hook,omitempty"`
+	PostHook *monitor.HookResult `json:"post_hook,omitempty"`
+	// Run configuration (monitor meta.config) and what changed against the previous batch: the
+	// monitor's config.changed, else the difference to the preceding batch in the file (a restart
+	// with other flags or sites).
+	Config        *monitor.RunConfig `json:"config,omitempty"`
+	ConfigChanges []string           `json:"config_changes,omitempty"`
+	// Group comparisons within the batch (see CompareGroups): IPv6 against IPv4 line speeds and
+	// TTFBs, HTTP/2 against HTTP/1.1 line speeds; nil when a group has too few lines.
+	FamilySpeedTest   *SignificanceTest `json:"family_speed_test,omitempty"`
+	FamilyTTFBTest    *SignificanceTest `json:"family_ttfb_test,omitempty"`
+	ProtocolSpeedTest *SignificanceTest `json:"protocol_speed_test,omitempty"`
+	// Thresholds are the stall timeout, micro-stall gap and low-speed threshold the stall and
+	// low-speed metrics were derived with.
+	Thresholds *StallThresholds `json:"thresholds,omitempty"`
+	// Target pre-check (monitor --health-check): ExcludedUnhealthy counts the hard-down targets
+	// (NXDOMAIN, connection refused) left out of the batch, so they never entered its error rate;
+	// HealthCheck lists them, and in report mode the down targets that were measured anyway.
+	ExcludedUnhealthy int                      `json:"excluded_unhealthy,omitempty"`
+	HealthCheck       *monitor.HealthCheckInfo `json:"health_check,omitempty"`
+	// Data usage (monitor meta.data_usage): WireRx/TxBytes sum the lines' connection bytes; the
+	// day and billing-cycle totals are the running totals at the batch's last line. BudgetBytes and
+	// BudgetAction are set when the monitor ran with --monthly-budget.
+	WireRxBytes  int64  `json:"wire_rx_bytes,omitempty"`
+	WireTxBytes  int64  `json:"wire_tx_bytes,omitempty"`
+	UsageDay     string `json:"usage_day,omitempty"`
+	DayRxBytes   int64  `json:"day_rx_bytes,omitempty"`
+	DayTxBytes   int64  `json:"day_tx_bytes,omitempty"`
+	CycleRxBytes int64  `json:"cycle_rx_bytes,omitempty"`
+	CycleTxBytes int64  `json:"cycle_tx_bytes,omitempty"`
+	BudgetBytes  int64  `json:"budget_bytes,omitempty"`
+	BudgetAction string `json:"budget_action,omitempty"`
+	// Canceled: the monitor was stopped mid-batch (SIGINT/SIGTERM); the batch covers only the
+	// sites measured until then, so it is neither a failing batch nor comparable with full ones.
+	Canceled bool `json:"canceled,omitempty"`
 	// Raw count fields (not serialized) retained to enable higher-level aggregation (overall across batches)
 	CacheHitLines           int `json:"-"`
 	ProxySuspectedLines     int `json:"-"`
@@ -144,6 +366,9 @@ type BatchSummary struct {
 	// Per-IP-family breakdown (same metrics as above but limited to that family)
 	IPv4 *FamilySummary `json:"ipv4,omitempty"`
 	IPv6 *FamilySummary `json:"ipv6,omitempty"`
+	// Per target group breakdown (sites' "group", e.g. CDN, Intranet, SaaS), keyed by group name;
+	// lines of ungrouped sites only count in the batch totals
+	Groups map[string]*FamilySummary `json:"groups,omitempty"`
 	// Proxy aggregation (counts / rates)
 	ProxyUsedLines         int                `json:"proxy_used_lines,omitempty"`
 	ProxyUsingEnvLines     int                `json:"proxy_using_env_lines,omitempty"`
@@ -155,6 +380,10 @@ type BatchSummary struct {
 	HTTPProtocolCounts         map[string]int     `json:"http_protocol_counts,omitempty"`
 	HTTPProtocolRatePct        map[string]float64 `json:"http_protocol_rate_pct,omitempty"`
 	AvgSpeedByHTTPProtocolKbps map[string]float64 `json:"avg_speed_by_http_protocol_kbps,omitempty"`
+	// First-byte latency per HTTP protocol (ms, lines with TTFB > 0)
+	AvgTTFBByHTTPProtocolMs    map[string]float64 `json:"avg_ttfb_by_http_protocol_ms,omitempty"`
+	P50TTFBByHTTPProtocolMs    map[string]float64 `json:"p50_ttfb_by_http_protocol_ms,omitempty"`
+	P95TTFBByHTTPProtocolMs    map[string]float64 `json:"p95_ttfb_by_http_protocol_ms,omitempty"`
 	StallRateByHTTPProtocolPct map[string]float64 `json:"stall_rate_by_http_protocol_pct,omitempty"`
 	ErrorRateByHTTPProtocolPct map[string]float64 `json:"error_rate_by_http_protocol_pct,omitempty"`
 	// Share of all errors attributed to each HTTP protocol (sums to ~100% when there are errors)
@@ -164,11 +393,21 @@ type BatchSummary struct {
 	// Share of all partial body results attributed to each HTTP protocol (sums to ~100% when there are partials)
 	PartialShareByHTTPProtocolPct    map[string]float64 `json:"partial_share_by_http_protocol_pct,omitempty"`
 	PartialBodyRateByHTTPProtocolPct map[string]float64 `json:"partial_body_rate_by_http_protocol_pct,omitempty"`
-	TLSVersionCounts                 map[string]int     `json:"tls_version_counts,omitempty"`
-	TLSVersionRatePct                map[string]float64 `json:"tls_version_rate_pct,omitempty"`
-	ALPNCounts                       map[string]int     `json:"alpn_counts,omitempty"`
-	ALPNRatePct                      map[string]float64 `json:"alpn_rate_pct,omitempty"`
-	ChunkedRatePct                   float64            `json:"chunked_rate_pct,omitempty"`
+	// Forced HTTP versions (site http_version, monitor --protocol-experiment): lines that forced a
+	// version, and those forced to HTTP/2 that the server still answered in HTTP/1.x. Forced lines
+	// without a protocol (failed before a response) count under the forced version above.
+	ForcedProtocolLines     int `json:"forced_protocol_lines,omitempty"`
+	ForcedProtocolFallbacks int `json:"forced_protocol_fallbacks,omitempty"`
+	// Per request method (site "method": GET, HEAD, POST), only for lines that record http_method
+	HTTPMethodCounts         map[string]int     `json:"http_method_counts,omitempty"`
+	AvgSpeedByHTTPMethodKbps map[string]float64 `json:"avg_speed_by_http_method_kbps,omitempty"`
+	AvgTTFBByHTTPMethodMs    map[string]float64 `json:"avg_ttfb_by_http_method_ms,omitempty"`
+	ErrorRateByHTTPMethodPct map[string]float64 `json:"error_rate_by_http_method_pct,omitempty"`
+	TLSVersionCounts         map[string]int     `json:"tls_version_counts,omitempty"`
+	TLSVersionRatePct        map[string]float64 `json:"tls_version_rate_pct,omitempty"`
+	ALPNCounts               map[string]int     `json:"alpn_counts,omitempty"`
+	ALPNRatePct              map[string]float64 `json:"alpn_rate_pct,omitempty"`
+	ChunkedRatePct           float64            `json:"chunked_rate_pct,omitempty"`
 	// Error type breakdowns
 	// ErrorRateByTypePct is the percentage of all requests in the batch that failed for a given error type.
 	// Keys use short labels: dns, tcp, tls, head, http, range
@@ -188,6 +427,15 @@ type BatchSummary struct {
 	ErrorLinesByURL map[string]int `json:"error_lines_by_url,omitempty"`
 }
 
+// BindLabel names the batch's source binding: the interface, else the source address ("" when
+// unbound).
+func (b BatchSummary) BindLabel() string {
+	if b.BindInterface != "" {
+		return b.BindInterface
+	}
+	return b.BindSourceIP
+}
+
 // FamilySummary mirrors BatchSummary's metric fields for a single IP family subset.
 type FamilySummary struct {
 	Lines       int     `json:"lines"`
@@ -219,6 +467,14 @@ type FamilySummary struct {
 	AvgP90Speed    float64 `json:"avg_p90_kbps,omitempty"`
 	AvgP95Speed    float64 `json:"avg_p95_kbps,omitempty"`
 	AvgP99Speed    float64 `json:"avg_p99_kbps,omitempty"`
+	// Pooled Speed percentiles over every transfer speed sample of the batch (true batch-level
+	// percentiles). AvgP50Speed..AvgP99Speed average each line's own percentile instead; both are
+	// kept so the two methods can be compared. Zero when no line carries samples.
+	PooledP50Speed     float64 `json:"pooled_p50_kbps,omitempty"`
+	PooledP90Speed     float64 `json:"pooled_p90_kbps,omitempty"`
+	PooledP95Speed     float64 `json:"pooled_p95_kbps,omitempty"`
+	PooledP99Speed     float64 `json:"pooled_p99_kbps,omitempty"`
+	PooledSpeedSamples int     `json:"pooled_speed_samples,omitempty"`
 	// Cross-line Speed percentiles
 	AvgP25Speed           float64 `json:"avg_p25_kbps,omitempty"`
 	AvgP75Speed           float64 `json:"avg_p75_kbps,omitempty"`
@@ -251,6 +507,16 @@ type FamilySummary struct {
 	AvgP90TTFBMs float64 `json:"avg_ttfb_p90_ms,omitempty"`
 	AvgP95TTFBMs float64 `json:"avg_ttfb_p95_ms,omitempty"`
 	AvgP99TTFBMs float64 `json:"avg_ttfb_p99_ms,omitempty"`
+	// QUIC/UDP reachability within this family (h3-advertising sites only)
+	QUICProbeLines    int     `json:"quic_probe_lines,omitempty"`
+	UDPBlockedRatePct float64 `json:"udp_blocked_rate_pct,omitempty"`
+	// Cold vs warm connection TTFB within this family
+	AvgColdTTFBMs float64 `json:"avg_cold_ttfb_ms,omitempty"`
+	AvgWarmTTFBMs float64 `json:"avg_warm_ttfb_ms,omitempty"`
+	// Content integrity within this family
+	IntegrityCheckedLines    int     `json:"integrity_checked_lines,omitempty"`
+	ContentCorruptionRatePct float64 `json:"content_corruption_rate_pct,omitempty"`
+	RateLimitedRatePct       float64 `json:"rate_limited_rate_pct,omitempty"`
 }
 
 // AnalyzeRecentResults parses the results file and returns the most recent up to MaxBatches batch summaries.
@@ -268,8 +534,19 @@ type AnalyzeOptions struct {
 	// If >0, detect short transfer pauses ("micro-stalls") using TransferSpeedSamples.
 	// Micro‑stalls are brief pauses where transfer resumes later (distinct from hard stall timeouts/aborts).
 	// Definition: contiguous gap where cumulative bytes do not increase for at least this many milliseconds.
-	// Recommended default: 500 ms.
+	// Recommended default: DefaultMicroStallGapMs (500 ms).
 	MicroStallMinGapMs int64
+	// If non-nil, filled with per-line read counts (how many lines were skipped as corrupt etc.).
+	Stats *LoadStats
+	// Number of goroutines decoding lines in parallel; 0 uses one per CPU, 1 decodes sequentially.
+	ParseWorkers int
+	// If non-nil, filled with the lines upgraded from older schema versions and the fields changed.
+	Migration *MigrationReport
+	// Where the "[analysis] ..." progress notes go; nil writes them to stdout.
+	Log io.Writer
+	// If non-nil, called with the bytes and lines read so far, every 100 ms and at the end of the
+	// file, from the goroutine reading it.
+	Progress func(LoadProgress)
 }
 
 // normalizeErrorReason maps a free-form error string to a compact normalized reason label.
@@ -323,6 +600,13 @@ func normalizeErrorReason(err string) string {
 	if strings.Contains(e, "partial_body") || strings.Contains(e, "unexpected eof") {
 		return "partial_body"
 	}
+	// Complete body whose sha256 differs from the site's expected digest
+	if strings.Contains(e, "content_mismatch") {
+		return "content_mismatch"
+	}
+	if strings.Contains(e, "rate_limited") {
+		return "rate_limited"
+	}
 	// Fallback
 	return "other"
 }
@@ -336,6 +620,12 @@ func normalizeHTTPReason(err string, headStatus int) string {
 	if strings.Contains(e, "partial_body") {
 		return "partial_body"
 	}
+	if strings.Contains(e, "content_mismatch") {
+		return "content_mismatch"
+	}
+	if strings.Contains(e, "rate_limited") {
+		return "rate_limited"
+	}
 	if strings.Contains(e, "stall_pre_ttfb") {
 		return "stall_pre_ttfb"
 	}
@@ -362,6 +652,12 @@ func normalizeErrorReasonDetailed(err string, headStatus int, typed string) stri
 	if strings.Contains(e, "partial_body") {
 		return "partial_body"
 	}
+	if strings.Contains(e, "content_mismatch") {
+		return "content_mismatch"
+	}
+	if strings.Contains(e, "rate_limited") {
+		return "rate_limited"
+	}
 	if strings.Contains(e, "stall_pre_ttfb") {
 		return "stall_pre_ttfb"
 	}
@@ -627,25 +923,45 @@ func normalizeErrorReasonDetailed(err string, headStatus int, typed string) stri
 }
 
 // AnalyzeRecentResultsFullWithOptions parses results and computes extended batch metrics with options.
+// Memory: the file is streamed and only the decoded lines of the MaxBatches most recent batches
+// are retained, plus the parse read-ahead (4*ParseWorkers chunks of 1 MiB), whatever the file size.
 func AnalyzeRecentResultsFullWithOptions(path string, schemaVersion, MaxBatches int, opts AnalyzeOptions) ([]BatchSummary, error) {
-	f, err := os.Open(path)
+	return analyzeResults(context.Background(), path, schemaVersion, MaxBatches, opts)
+}
+
+// analyzeResults is AnalyzeRecentResultsFullWithOptions returning ctx.Err() once ctx is done:
+// reads of the file fail, and the aggregation checks ctx between batches.
+func analyzeResults(ctx context.Context, path string, schemaVersion, MaxBatches int, opts AnalyzeOptions) ([]BatchSummary, error) {
+	rc, err := OpenResults(path)
 	if err != nil {
 		return nil, err
 	}
-	defer f.Close()
+	defer rc.Close()
+	f := &contextReader{ctx: ctx, r: rc, progress: opts.Progress}
+	logw := opts.Log
+	if logw == nil {
+		logw = os.Stdout
+	}
 	if opts.SituationFilter != "" {
-		fmt.Printf("[analysis] reading results from %s (schema_version=%d, max_batches=%d, situation=\"%s\")\n", path, schemaVersion, MaxBatches, opts.SituationFilter)
+		fmt.Fprintf(logw, "[analysis] reading results from %s (schema_version=%d, max_batches=%d, situation=\"%s\")\n", path, schemaVersion, MaxBatches, opts.SituationFilter)
 	} else {
-		fmt.Printf("[analysis] reading results from %s (schema_version=%d, max_batches=%d, situation=ALL)\n", path, schemaVersion, MaxBatches)
+		fmt.Fprintf(logw, "[analysis] reading results from %s (schema_version=%d, max_batches=%d, situation=ALL)\n", path, schemaVersion, MaxBatches)
 	}
-	// Use a dynamic reader to handle long JSONL lines without a fixed max token size.
 	// Defensive cap per-line to avoid pathological memory spikes.
-	reader := bufio.NewReader(f)
 	const MaxLineBytes = 200 * 1024 * 1024 // 200MB; increase here if you truly need larger lines
 	type rec struct {
 		runTag             string
+		lineHash           uint64 // FNV-1a of the trimmed line, for dropping re-appended duplicates
+		derivedTag         bool   // runTag derived during a v1 upgrade; regrouped in file order below
 		situation          string
+		agent              string
+		tags               map[string]string
+		vpnActive          bool
+		vpnName            string
 		ipFamily           string
+		group              string
+		site               string     // site name (URL when unnamed), for per-target SLOs
+		slo                *types.SLO // site "slo" recorded on the line
 		proxyName          string
 		usingEnvProxy      bool
 		timestamp          time.Time
@@ -653,6 +969,7 @@ func AnalyzeRecentResultsFullWithOptions(path string, schemaVersion, MaxBatches
 		firstRTT           float64
 		url                string
 		p50, p90, p95, p99 float64
+		speedSketch        *monitor.Sketch // transfer speed samples (for pooled percentiles)
 		plateauCount       float64
 		longestPlateau     float64
 		jitterPct          float64
@@ -670,6 +987,40 @@ func AnalyzeRecentResultsFullWithOptions(path string, schemaVersion, MaxBatches
 		plateauStable      bool
 		hasError           bool
 		partialBody        bool
+		// route comparison inputs
+		resolvedIP           string
+		asn                  uint
+		asnOrg               string
+		routePath            *monitor.RoutePath
+		responseTTL          *monitor.ResponseTTL
+		compression          *monitor.ResponseCompression
+		serverTiming         *monitor.ServerTiming
+		tcpStats             *monitor.TCPStats
+		respHeaders          map[string]string
+		egressV4, egressV6   uint
+		egressV4O, egressV6O string
+		// non-HTTP probe line (nil for http)
+		probe *probeLine
+		// NXDOMAIN rewriting check of the batch (meta.dns_hijack)
+		dnsHijack   *monitor.DNSHijackInfo
+		hooks       *monitor.BatchHooks
+		runConfig   *monitor.RunConfig
+		healthCheck *monitor.HealthCheckInfo
+		// --interface/--source-ip binding (meta.bind_*)
+		bindInterface, bindSourceIP string
+		// monitor's local offset/zone when the line was written
+		utcOffset, timeZone string
+		// metered network state / reduced mode
+		metered        bool
+		meteredSource  string
+		reducedMode    bool
+		transferCapped bool
+		// meta.sampling_profile ("lite")
+		samplingProfile string
+		// data usage
+		wireRx, wireTx int64
+		usage          *monitor.DataUsage
+		canceled       bool
 		// meta
 		localSelfKbps float64
 		hostname      string
@@ -687,10 +1038,12 @@ func AnalyzeRecentResultsFullWithOptions(path string, schemaVersion, MaxBatches
 		calibErrPct   []float64
 		calibSamples  []int
 		// protocol/tls/encoding
-		httpProto string
-		tlsVer    string
-		alpn      string
-		chunked   bool
+		httpProto  string
+		forcedHTTP string
+		httpMethod string
+		tlsVer     string
+		alpn       string
+		chunked    bool
 		// stability
 		stalled        bool
 		stallElapsedMs int64
@@ -701,11 +1054,23 @@ func AnalyzeRecentResultsFullWithOptions(path string, schemaVersion, MaxBatches
 		microStallCount   int
 		microStallTotalMs int64
 		microStallPresent bool
+		stallEvents       []stallEvent
+		sampleSpanMs      int64 // last sample time; the stall timeline's 100%
 		// connection setup timings (ms)
 		dnsMs       float64
 		dnsLegacyMs float64 // raw legacy dns_time_ms if present
 		connMs      float64
 		tlsMs       float64
+		// per-family lookups (dns_family)
+		dnsFamily  bool
+		dnsAMs     float64
+		dnsAAAAMs  float64
+		dnsAErr    bool
+		dnsAAAAErr bool
+		dnsCache   *monitor.DNSCacheInfo
+		dnsLookMs  float64 // dns_time_ms, the lookup dns_cache classifies
+		aaaaKnown  bool    // whether the line says if the host has AAAA records
+		hasAAAA    bool
 		// network diagnostics
 		// normalized error reason
 		errorReason         string
@@ -716,63 +1081,88 @@ func AnalyzeRecentResultsFullWithOptions(path string, schemaVersion, MaxBatches
 		mqReqN10Pct   int
 		mqGood        bool
 		// error classification (single primary type per line)
-		errorType string // dns|tcp|tls|head|http|range|""
+		errorType string // dns|tcp|tls|head|http|rate_limit|range|""
 		// network diagnostics
 		dnsServer  string
 		dnsNet     string
 		nextHop    string
 		nextHopSrc string
+		// Wi-Fi link (meta)
+		wifiRSSI    float64
+		wifiPHY     float64
+		wifiSSID    string
+		wifiBSSID   string
+		wifiChannel int
+		// happy-eyeballs race
+		heRace     bool
+		heKey      string // site and race outcome: the per-IP lines of a site share one race
+		heV6Lost   bool
+		heWinnerMs float64
+		heV6LoseMs float64
+		// QUIC/UDP probe
+		quicProbed bool
+		udpBlocked bool
+		altSvcH3   bool
+		// cold vs warm connection experiment (ms); reuseOK = both requests succeeded on the intended connection kind
+		reuseOK   bool
+		coldTTFB  float64
+		warmTTFB  float64
+		setupCost float64
+		// body integrity (content_sha256 present = checked)
+		integrityChecked bool
+		contentMismatch  bool
+		// throttling (rate_limited); retryAfterSec < 0 when no positive Retry-After was sent
+		rateLimited   bool
+		retryAfterSec float64
 	}
 	// Phase 1: scan the JSONL results file and extract only the typed envelope lines
 	// matching the requested schemaVersion. Each valid line becomes a lightweight
 	// 'rec' containing only the numeric fields needed for aggregation. We avoid
 	// retaining full structs / raw maps to keep memory usage low when the file is large.
-	var records []rec
-readLoop:
-	for {
-		// Accumulate one logical line (may span multiple internal buffers)
-		var line []byte
-		for {
-			part, rerr := reader.ReadBytes('\n')
-			if len(part) > 0 {
-				if len(line)+len(part) > MaxLineBytes {
-					return nil, fmt.Errorf("line too large: %d bytes exceeds limit %d in %s (bump MaxLineBytes in src/analysis/analysis.go if needed)", len(line)+len(part), MaxLineBytes, path)
-				}
-				line = append(line, part...)
-			}
-			if rerr == nil {
-				break // finished one line with newline
-			}
-			if errors.Is(rerr, io.EOF) {
-				// Handle final line without newline
-				if len(line) == 0 {
-					break readLoop
-				}
-				break
-			}
-			if errors.Is(rerr, bufio.ErrBufferFull) {
-				// continue accumulating
-				continue
-			}
-			// Other I/O error: warn and stop processing
-			fmt.Printf("[analysis] read warning: %v (file=%s)\n", rerr, path)
-			if len(line) == 0 {
-				break readLoop
-			}
-			break
-		}
+	// JSON decoding dominates load time, so the file is read in newline-aligned chunks that
+	// are decoded on parallel workers and merged back in file order.
+	stats := opts.Stats
+	if stats == nil {
+		stats = &LoadStats{}
+	}
+	*stats = LoadStats{}
+	// Lines of older schema versions are upgraded through the registered migrations (schema.go).
+	migrator := newSchemaMigrator(schemaVersion)
+	// decode turns one line into a rec; it runs concurrently on the parse workers, so it only
+	// reads shared state (opts, schemaVersion) and counts skips in its own per-chunk stats.
+	decode := func(line []byte, stats *LoadStats) (rec, bool) {
 		var env monitor.ResultEnvelope
+		derivedTag := false
 		if err := json.Unmarshal(line, &env); err != nil || env.Meta == nil || env.SiteResult == nil {
-			continue
-		}
-		if env.Meta.SchemaVersion != schemaVersion {
-			continue
+			// an older schema may not decode into today's types; upgrade it when a path exists
+			v, ok := peekSchemaVersion(line)
+			if !ok || v == schemaVersion {
+				stats.Corrupt++
+				return rec{}, false
+			}
+			up, derived, ok := migrator.upgrade(line, v)
+			if !ok {
+				stats.SchemaMismatch++
+				return rec{}, false
+			}
+			env, derivedTag = *up, derived
+			stats.Migrated++
+		} else if env.Meta.SchemaVersion != schemaVersion {
+			up, derived, ok := migrator.upgrade(line, env.Meta.SchemaVersion)
+			if !ok {
+				stats.SchemaMismatch++
+				return rec{}, false
+			}
+			env, derivedTag = *up, derived
+			stats.Migrated++
 		}
 		if env.Meta.RunTag == "" { // require explicit run_tag; skip otherwise
-			continue
+			stats.MissingRunTag++
+			return rec{}, false
 		}
+		stats.Parsed++
 		if opts.SituationFilter != "" && !strings.EqualFold(env.Meta.Situation, opts.SituationFilter) {
-			continue
+			return rec{}, false
 		}
 		sr := env.SiteResult
 		var ts time.Time
@@ -781,7 +1171,9 @@ readLoop:
 				ts = parsed
 			}
 		}
-		bs := rec{runTag: env.Meta.RunTag, situation: env.Meta.Situation, ipFamily: sr.IPFamily, proxyName: sr.ProxyName, usingEnvProxy: sr.UsingEnvProxy, timestamp: ts, speed: sr.TransferSpeedKbps, ttfb: float64(sr.TraceTTFBMs), bytes: float64(sr.TransferSizeBytes), firstRTT: sr.FirstRTTGoodputKbps, url: sr.URL}
+		bs := rec{runTag: env.Meta.RunTag, situation: env.Meta.Situation, agent: env.Meta.Agent, vpnActive: env.Meta.VPNActive, vpnName: env.Meta.VPNName, ipFamily: sr.IPFamily, group: strings.TrimSpace(sr.Group), proxyName: sr.ProxyName, usingEnvProxy: sr.UsingEnvProxy, timestamp: ts, speed: sr.TransferSpeedKbps, ttfb: float64(sr.TraceTTFBMs), bytes: float64(sr.TransferSizeBytes), firstRTT: sr.FirstRTTGoodputKbps, url: sr.URL}
+		bs.lineHash = hashLine(line)
+		bs.derivedTag = derivedTag
 		// capture meta self-test baseline if present
 		if env.Meta.LocalSelfTestKbps > 0 {
 			bs.localSelfKbps = env.Meta.LocalSelfTestKbps
@@ -810,6 +1202,13 @@ readLoop:
 		if env.Meta.DiskRootFreeBytes > 0 {
 			bs.diskFree = float64(env.Meta.DiskRootFreeBytes)
 		}
+		if w := env.Meta.WiFi; w != nil {
+			bs.wifiRSSI = float64(w.RSSIDBm)
+			bs.wifiPHY = w.PHYRateMbps
+			bs.wifiSSID = w.SSID
+			bs.wifiBSSID = w.BSSID
+			bs.wifiChannel = w.Channel
+		}
 		// capture calibration if present
 		if env.Meta.Calibration != nil {
 			if env.Meta.Calibration.MaxKbps > 0 {
@@ -852,6 +1251,9 @@ readLoop:
 				bs.errorReasonDetailed = normalizeErrorReasonDetailed(sr.HeadError, sr.HeadStatus, et)
 			} else if sr.HTTPError != "" {
 				et = "http"
+				if sr.RateLimited != nil && sr.RateLimited.Request == "get" {
+					et = "rate_limit" // server throttling, not a transport failure
+				}
 				bs.errorReason = normalizeHTTPReason(sr.HTTPError, sr.HeadStatus)
 				bs.errorReasonDetailed = normalizeErrorReasonDetailed(sr.HTTPError, sr.HeadStatus, et)
 			} else if sr.SecondGetError != "" {
@@ -875,6 +1277,12 @@ readLoop:
 		}
 		if sa := sr.SpeedAnalysis; sa != nil {
 			bs.p50 = sa.P50Kbps
+			// same sample population the monitor used for this line's own percentiles; lines
+			// written before speed_sketch existed are sketched from their raw samples
+			bs.speedSketch = sr.SpeedSketch
+			if bs.speedSketch == nil {
+				bs.speedSketch = monitor.SketchOf(sr.TransferSpeedSamples)
+			}
 			if sa.P99Kbps > 0 {
 				bs.p99 = sa.P99Kbps
 			}
@@ -983,11 +1391,13 @@ readLoop:
 							if dur >= opts.MicroStallMinGapMs {
 								microCnt++
 								microTotal += dur
+								bs.stallEvents = append(bs.stallEvents, stallEvent{offsetMs: startMs, durMs: dur})
 							}
 							runStartIdx = -1
 						}
 					}
 				}
+				bs.sampleSpanMs = sr.TransferSpeedSamples[len(sr.TransferSpeedSamples)-1].TimeMs
 				if microCnt > 0 {
 					bs.microStallCount = microCnt
 					bs.microStallTotalMs = microTotal
@@ -1013,37 +1423,176 @@ readLoop:
 		bs.headGetRatio = sr.HeadGetTimeRatio
 		// protocol/tls/encoding telemetry
 		bs.httpProto = sr.HTTPProtocol
+		bs.forcedHTTP = sr.ForcedHTTPVersion
+		bs.httpMethod = sr.HTTPMethod
 		bs.tlsVer = sr.TLSVersion
 		bs.alpn = sr.ALPN
 		bs.chunked = sr.Chunked
+		if he := sr.HappyEyeballs; he != nil && he.IPv6Attempted {
+			bs.heRace = true
+			bs.heKey = fmt.Sprintf("%s|%s|%+v", sr.Name, sr.URL, *he)
+			bs.heV6Lost = he.IPv6Lost()
+			bs.heWinnerMs = float64(he.WinnerConnectMs)
+			if bs.heV6Lost && he.LoserFamily == "ipv6" {
+				bs.heV6LoseMs = float64(he.LoserConnectMs)
+			}
+		}
+		if qp := sr.QUICProbe; qp != nil && qp.Attempts > 0 {
+			bs.quicProbed = true
+			bs.udpBlocked = qp.UDPBlocked
+		}
+		bs.altSvcH3 = sr.AltSvcH3
+		if ft := sr.DNSFamily; ft != nil {
+			bs.dnsFamily = true
+			bs.dnsAMs = float64(ft.AMs)
+			bs.dnsAAAAMs = float64(ft.AAAAMs)
+			bs.dnsAErr = ft.AError != ""
+			bs.dnsAAAAErr = ft.AAAAError != ""
+		}
+		bs.dnsCache, bs.dnsLookMs = sr.DNSCache, float64(sr.DNSTimeMs)
+		if ft := sr.DNSFamily; ft != nil && ft.AAAAError == "" {
+			bs.aaaaKnown, bs.hasAAAA = true, ft.AAAACount > 0
+		} else if len(sr.DNSIPs) > 0 {
+			bs.aaaaKnown = true
+			for _, ip := range sr.DNSIPs {
+				if strings.Contains(ip, ":") {
+					bs.hasAAAA = true
+					break
+				}
+			}
+		}
+		if ex := sr.ReuseExperiment; ex != nil && ex.ColdError == "" && ex.WarmError == "" && ex.WarmReused && ex.ColdTTFBMs > 0 {
+			bs.reuseOK = true
+			bs.coldTTFB = float64(ex.ColdTTFBMs)
+			bs.warmTTFB = float64(ex.WarmTTFBMs)
+			bs.setupCost = float64(ex.SetupCostMs)
+		}
+		bs.tags = env.Meta.Tags
+		bs.dnsHijack = env.Meta.DNSHijack
+		bs.hooks = env.Meta.Hooks
+		bs.runConfig = env.Meta.Config
+		bs.healthCheck = env.Meta.HealthCheck
+		bs.bindInterface, bs.bindSourceIP = env.Meta.BindInterface, env.Meta.BindSourceIP
+		bs.utcOffset, bs.timeZone = env.Meta.UTCOffset, env.Meta.TimeZone
+		if mi := env.Meta.Metered; mi != nil {
+			bs.metered, bs.meteredSource = mi.Metered, mi.Source
+		}
+		bs.reducedMode = env.Meta.ReducedMode
+		bs.samplingProfile = env.Meta.SamplingProfile
+		bs.transferCapped = sr.TransferCapped
+		bs.wireRx, bs.wireTx = sr.WireRxBytes, sr.WireTxBytes
+		bs.usage = env.Meta.DataUsage
+		bs.canceled = env.Meta.Canceled
+		bs.probe = probeLineOf(sr)
+		bs.resolvedIP = sr.ResolvedIP
+		if bs.resolvedIP == "" {
+			bs.resolvedIP = sr.RemoteIP
+		}
+		bs.asn, bs.asnOrg = sr.ASNNumber, sr.ASNOrg
+		bs.routePath = sr.RoutePath
+		bs.responseTTL = sr.ResponseTTL
+		bs.compression = sr.Compression
+		bs.serverTiming = sr.ServerTiming
+		bs.tcpStats = sr.TCPStats
+		bs.site, bs.slo = sr.Name, sr.SLO
+		if bs.site == "" {
+			bs.site = sr.URL
+		}
+		bs.respHeaders = sr.ResponseHeaders
+		bs.egressV4, bs.egressV4O = env.Meta.PublicIPv4ASNNumber, env.Meta.PublicIPv4ASNOrg
+		bs.egressV6, bs.egressV6O = env.Meta.PublicIPv6ASNNumber, env.Meta.PublicIPv6ASNOrg
+		bs.integrityChecked = sr.ContentSHA256 != ""
+		bs.contentMismatch = sr.ContentMismatch
+		if rl := sr.RateLimited; rl != nil {
+			bs.rateLimited = true
+			bs.retryAfterSec = -1
+			if rl.RetryAfterSec > 0 {
+				bs.retryAfterSec = float64(rl.RetryAfterSec)
+			}
+		}
 		// network diagnostics
 		bs.dnsServer = strings.TrimSpace(sr.DNSServer)
 		bs.dnsNet = strings.TrimSpace(sr.DNSServerNetwork)
 		bs.nextHop = strings.TrimSpace(sr.NextHop)
 		bs.nextHopSrc = strings.TrimSpace(sr.NextHopSource)
-		records = append(records, bs)
+		return bs, true
 	}
-	if len(records) == 0 {
-		return nil, fmt.Errorf("no records")
+	// Phase 2: group records by run_tag while streaming. Only the last MaxBatches batches are
+	// kept (run tags are timestamps, so they sort chronologically); lines of older batches are
+	// dropped as soon as newer ones arrive and never held all at once.
+	if MaxBatches <= 0 {
+		MaxBatches = 10
 	}
-	// Phase 2: group records by run_tag. We preserve the first-seen order of unique
-	// run_tags in 'order' then later sort it lexicographically (timestamps sort
-	// correctly) so we can truncate to the last MaxBatches batches deterministically.
-	batches := map[string][]rec{}
-	var order []string
+	window := newBatchWindow[rec](MaxBatches)
 	debugOn := os.Getenv("ANALYSIS_DEBUG") != ""
-	for _, r := range records {
+	// Line hashes per batch in the window: a line seen before in its batch is a re-appended copy.
+	// Batches leaving the window take their hashes with them, so this stays bounded like window.
+	seenLines := map[string]map[uint64]bool{}
+	duplicates := 0
+	// v1 lines have no run_tag: each got its own timestamp as tag, and a run of them less than
+	// derivedBatchGap apart is one batch under the tag of its first line.
+	var derivedTag string
+	var prevDerived time.Time
+	err = streamLinesParallel(f, opts.ParseWorkers, MaxLineBytes, decode, stats, func(r rec) {
 		if r.runTag == "" { // should not happen (filtered earlier) but guard regardless
-			continue
+			return
+		}
+		if r.derivedTag {
+			if prevDerived.IsZero() || r.timestamp.Sub(prevDerived).Abs() >= derivedBatchGap {
+				derivedTag = r.runTag
+			}
+			prevDerived = r.timestamp
+			r.runTag = derivedTag
+		} else {
+			prevDerived = time.Time{}
 		}
-		if _, ok := batches[r.runTag]; !ok {
-			order = append(order, r.runTag)
+		if seenLines[r.runTag][r.lineHash] {
+			duplicates++
+			return
+		}
+		opened := window.add(r.runTag, r)
+		if _, kept := window.batches[r.runTag]; !kept {
+			return
+		}
+		if opened {
+			for tag := range seenLines {
+				if _, ok := window.batches[tag]; !ok {
+					delete(seenLines, tag)
+				}
+			}
+			seenLines[r.runTag] = map[uint64]bool{}
 			if debugOn {
 				fmt.Printf("[analysis debug] discovered new batch tag: %s\n", r.runTag)
 			}
 		}
-		batches[r.runTag] = append(batches[r.runTag], r)
+		seenLines[r.runTag][r.lineHash] = true
+	})
+	stats.OlderBatchLines = window.dropped
+	stats.Duplicates = duplicates
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w in %s (bump MaxLineBytes in src/analysis/analysis.go if needed)", err, path)
+	}
+	if stats.Corrupt > 0 {
+		fmt.Fprintf(logw, "[analysis] skipped %d corrupt/truncated line(s) in %s (run the monitor with --fsck for details)\n", stats.Corrupt, path)
+	}
+	if stats.Duplicates > 0 {
+		fmt.Fprintf(logw, "[analysis] ignored %d duplicate line(s) in %s (file appended twice?; --fsck --fsck-repair writes a clean copy)\n", stats.Duplicates, path)
+	}
+	migration := migrator.report()
+	if opts.Migration != nil {
+		*opts.Migration = migration
+	}
+	if migration.Lines > 0 {
+		fmt.Fprintf(logw, "[analysis] %s: %s\n", path, migration.Summary())
+	}
+	if len(window.batches) == 0 {
+		return nil, fmt.Errorf("no records")
 	}
+	batches := window.batches
+	order := window.tags()
 	if debugOn {
 		for _, tag := range order {
 			fmt.Printf("[analysis debug] batch %s raw line count=%d\n", tag, len(batches[tag]))
@@ -1052,15 +1601,6 @@ readLoop:
 	if len(order) == 0 {
 		return nil, fmt.Errorf("no batches")
 	}
-	// Sort batch tags so chronological order (timestamp-based tags) is guaranteed,
-	// then trim to the last MaxBatches requested batches (keeping most recent activity).
-	sort.Strings(order)
-	if MaxBatches <= 0 {
-		MaxBatches = 10
-	}
-	if len(order) > MaxBatches {
-		order = order[len(order)-MaxBatches:]
-	}
 	avg := func(a []float64) float64 {
 		if len(a) == 0 {
 			return 0
@@ -1127,28 +1667,91 @@ readLoop:
 	}
 	// Phase 3: aggregate each batch.
 	var summaries []BatchSummary
+	postHooks := map[string]*monitor.HookResult{} // run tag -> its post hook, from the next batch
 	for _, tag := range order {
-		recs := batches[tag]
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		// probe lines (ping, dns, ...) are summarized apart from the HTTP lines (probes.go)
+		var recs, probeRecs []rec
+		for _, r := range batches[tag] {
+			if r.probe != nil {
+				probeRecs = append(probeRecs, r)
+			} else {
+				recs = append(recs, r)
+			}
+		}
 		proxyNameCounts := map[string]int{}
 		proxyUsingEnv := 0
 		proxyClassified := 0
 		// capture situation for this batch (prefer first non-empty)
 		batchSituation := ""
+		batchAgent := ""
+		batchVPN, batchVPNName := false, ""
+		var reducedLines, cappedLines int
+		batchMetered, batchMeteredSource := false, ""
+		batchSamplingProfile := ""
+		var wireRx, wireTx int64
+		var lastUsage *monitor.DataUsage
+		batchCanceled := false
+		var batchDNSHijack *monitor.DNSHijackInfo
+		var batchHooks monitor.BatchHooks
+		var batchConfig *monitor.RunConfig
+		var batchHealth *monitor.HealthCheckInfo
+		var bindInterface, bindSourceIP string
+		for _, r := range batches[tag] { // probe lines too: any line may be the one in flight
+			batchCanceled = batchCanceled || r.canceled
+			if bindInterface == "" && bindSourceIP == "" {
+				bindInterface, bindSourceIP = r.bindInterface, r.bindSourceIP
+			}
+			if batchDNSHijack == nil {
+				batchDNSHijack = r.dnsHijack
+			}
+			if batchConfig == nil {
+				batchConfig = r.runConfig
+			}
+			if batchHealth == nil {
+				batchHealth = r.healthCheck
+			}
+			if h := r.hooks; h != nil {
+				if batchHooks.Pre == nil {
+					batchHooks.Pre = h.Pre
+				}
+				if batchHooks.PostPrev == nil {
+					batchHooks.PostPrev = h.PostPrev
+				}
+			}
+		}
+		if p := batchHooks.PostPrev; p != nil {
+			postHooks[p.RunTag] = p
+		}
+		var batchTags map[string]string
 
 		// protocol/tls/encoding aggregators
 		protoCounts := map[string]int{}
+		var forcedLines, forcedFallbacks int
 		protoSpeedSum := map[string]float64{}
 		protoSpeedCnt := map[string]int{}
+		protoTTFBs := map[string][]float64{}
 		protoStallCnt := map[string]int{}
 		protoErrorCnt := map[string]int{}
 		protoPartialCnt := map[string]int{}
+		methodCounts := map[string]int{}
+		methodSpeedSum := map[string]float64{}
+		methodSpeedCnt := map[string]int{}
+		methodTTFBSum := map[string]float64{}
+		methodTTFBCnt := map[string]int{}
+		methodErrorCnt := map[string]int{}
 		tlsCounts := map[string]int{}
 		alpnCounts := map[string]int{}
 		chunkedTrue := 0
 
-		buildFamily := func(filter string) *FamilySummary {
+		// buildSubset summarizes the lines keep accepts (all lines when keep is nil), e.g. one IP
+		// family or one target group
+		buildSubset := func(keep func(rec) bool) *FamilySummary {
 			var speeds, ttfbs, bytesVals, firsts, p50s, p90s, p95s, p99s, ratios, plateauCounts, longest, jitters []float64
 			var slopes, coefVars, headGetRatios []float64
+			pooled := monitor.NewSketch(monitor.DefaultSketchAlpha)
 			var dnsTimes, dnsLegacyTimes, connTimes, tlsTimes []float64
 			var cacheCnt, proxyCnt, entProxyCnt, srvProxyCnt, ipMismatchCnt, prefetchCnt, warmCacheCnt, reuseCnt, plateauStableCnt int
 			var errorLines int
@@ -1163,7 +1766,7 @@ readLoop:
 			var microMsSum int64
 			var minTS, maxTS time.Time
 			for _, r := range recs {
-				if filter != "" && r.ipFamily != filter { // skip if filtering by family
+				if keep != nil && !keep(r) {
 					continue
 				}
 				if !r.timestamp.IsZero() {
@@ -1189,6 +1792,7 @@ readLoop:
 				if r.p50 > 0 {
 					p50s = append(p50s, r.p50)
 				}
+				pooled.Merge(r.speedSketch)
 				if r.p90 > 0 {
 					p90s = append(p90s, r.p90)
 				}
@@ -1295,7 +1899,7 @@ readLoop:
 			// Count lines that passed filter
 			lineCount := 0
 			for _, r := range recs {
-				if filter == "" || r.ipFamily == filter {
+				if keep == nil || keep(r) {
 					lineCount++
 				}
 			}
@@ -1378,13 +1982,19 @@ readLoop:
 			// Speed percentiles per family
 			fs.AvgP25Speed = percentile(speeds, 25)
 			fs.AvgP75Speed = percentile(speeds, 75)
+			fs.PooledP50Speed, fs.PooledP90Speed, fs.PooledP95Speed, fs.PooledP99Speed = pooled.Quantile(50), pooled.Quantile(90), pooled.Quantile(95), pooled.Quantile(99)
+			fs.PooledSpeedSamples = int(pooled.Count)
 			// Min/Max TTFB
 			fs.MinTTFBMs = minVal(ttfbs)
 			fs.MaxTTFBMs = maxVal(ttfbs)
 			return fs
 		}
+		buildFamily := func(family string) *FamilySummary {
+			return buildSubset(func(r rec) bool { return r.ipFamily == family })
+		}
 		var speeds, ttfbs, bytesVals, firsts, p50s, p90s, p95s, p99s, ratios, plateauCounts, longest, jitters []float64
 		var slopes, coefVars, headGetRatios []float64
+		pooled := monitor.NewSketch(monitor.DefaultSketchAlpha)
 		var dnsTimesAll, dnsLegacyTimesAll, connTimesAll, tlsTimesAll []float64
 		var cacheCnt, proxyCnt, entProxyCntAll, srvProxyCntAll, ipMismatchCnt, prefetchCnt, warmCacheCnt, reuseCnt, plateauStableCnt int
 		var errorLines int
@@ -1406,13 +2016,51 @@ readLoop:
 		var microCountSumAll int
 		var microMsSumAll int64
 		var minTS, maxTS time.Time
+		var startOffset, startZone string
 		for _, r := range recs {
 			if batchSituation == "" && r.situation != "" {
 				batchSituation = r.situation
 			}
+			if batchAgent == "" && r.agent != "" {
+				batchAgent = r.agent
+			}
+			for k, v := range r.tags {
+				if batchTags == nil {
+					batchTags = map[string]string{}
+				}
+				if _, ok := batchTags[k]; !ok {
+					batchTags[k] = v
+				}
+			}
+			if r.vpnActive {
+				batchVPN = true
+				if batchVPNName == "" {
+					batchVPNName = r.vpnName
+				}
+			}
+			if r.reducedMode {
+				reducedLines++
+			}
+			if r.transferCapped {
+				cappedLines++
+			}
+			if r.metered && !batchMetered {
+				batchMetered, batchMeteredSource = true, r.meteredSource
+			}
+			if batchSamplingProfile == "" {
+				batchSamplingProfile = r.samplingProfile
+			}
+			wireRx += r.wireRx
+			wireTx += r.wireTx
+			// running totals only grow within a day/cycle, so the largest is the latest (lines of a
+			// parallel batch are not written in order)
+			if u := r.usage; u != nil && (lastUsage == nil || u.CycleRxBytes+u.CycleTxBytes >= lastUsage.CycleRxBytes+lastUsage.CycleTxBytes) {
+				lastUsage = u
+			}
 			if !r.timestamp.IsZero() {
 				if minTS.IsZero() || r.timestamp.Before(minTS) {
 					minTS = r.timestamp
+					startOffset, startZone = r.utcOffset, r.timeZone
 				}
 				if maxTS.IsZero() || r.timestamp.After(maxTS) {
 					maxTS = r.timestamp
@@ -1425,14 +2073,28 @@ readLoop:
 			// Count missing protocol explicitly as "(unknown)" so mix charts can account for 100% without a synthetic remainder.
 			{
 				key := r.httpProto
-				if key == "" {
+				switch {
+				case key == "" && r.forcedHTTP == monitor.HTTPVersion11:
+					key = "HTTP/1.1"
+				case key == "" && r.forcedHTTP == monitor.HTTPVersion2:
+					key = "HTTP/2.0"
+				case key == "":
 					key = "(unknown)"
 				}
+				if r.forcedHTTP != "" {
+					forcedLines++
+					if r.forcedHTTP == monitor.HTTPVersion2 && strings.HasPrefix(r.httpProto, "HTTP/1") {
+						forcedFallbacks++
+					}
+				}
 				protoCounts[key]++
 				if r.speed > 0 {
 					protoSpeedSum[key] += r.speed
 					protoSpeedCnt[key]++
 				}
+				if r.ttfb > 0 {
+					protoTTFBs[key] = append(protoTTFBs[key], r.ttfb)
+				}
 				if r.stalled {
 					protoStallCnt[key]++
 				}
@@ -1443,6 +2105,20 @@ readLoop:
 					protoPartialCnt[key]++
 				}
 			}
+			if m := r.httpMethod; m != "" {
+				methodCounts[m]++
+				if r.speed > 0 {
+					methodSpeedSum[m] += r.speed
+					methodSpeedCnt[m]++
+				}
+				if r.ttfb > 0 {
+					methodTTFBSum[m] += r.ttfb
+					methodTTFBCnt[m]++
+				}
+				if r.hasError {
+					methodErrorCnt[m]++
+				}
+			}
 			if r.tlsVer != "" {
 				tlsCounts[r.tlsVer]++
 			}
@@ -1471,6 +2147,7 @@ readLoop:
 			if r.p50 > 0 {
 				p50s = append(p50s, r.p50)
 			}
+			pooled.Merge(r.speedSketch)
 			if r.p90 > 0 {
 				p90s = append(p90s, r.p90)
 			}
@@ -1596,6 +2273,10 @@ readLoop:
 		if !minTS.IsZero() && !maxTS.IsZero() && maxTS.After(minTS) {
 			durationMs = maxTS.Sub(minTS).Milliseconds()
 		}
+		startedUTC := ""
+		if !minTS.IsZero() {
+			startedUTC = minTS.UTC().Format(time.RFC3339Nano)
+		}
 		// Capture most recent non-empty diagnostics across the batch
 		latestDNS, latestDNSNet := "", ""
 		latestHop, latestHopSrc := "", ""
@@ -1632,6 +2313,9 @@ readLoop:
 			AvgP90Speed: avg(p90s), AvgP95Speed: avg(p95s), AvgP99Speed: avg(p99s), AvgSlopeKbpsPerSec: avg(slopes), AvgCoefVariationPct: avg(coefVars),
 			CacheHitRatePct: pct(cacheCnt), ProxySuspectedRatePct: pct(proxyCnt), IPMismatchRatePct: pct(ipMismatchCnt), PrefetchSuspectedRatePct: pct(prefetchCnt), WarmCacheSuspectedRatePct: pct(warmCacheCnt), ConnReuseRatePct: pct(reuseCnt), PlateauStableRatePct: pct(plateauStableCnt), AvgHeadGetTimeRatio: avg(headGetRatios),
 			BatchDurationMs: durationMs,
+			StartedUTC:      startedUTC,
+			UTCOffset:       startOffset,
+			TimeZone:        startZone,
 			AvgDNSMs:        avg(dnsTimesAll),
 			AvgDNSLegacyMs:  avg(dnsLegacyTimesAll),
 			AvgConnectMs:    avg(connTimesAll),
@@ -1728,6 +2412,291 @@ readLoop:
 		summary.NextHop = latestHop
 		summary.NextHopSource = latestHopSrc
 		summary.SampleURL = latestURL
+		// Wi-Fi link rollup
+		{
+			var rssiSum, phySum float64
+			var rssiN, phyN int
+			for _, r := range recs {
+				if r.wifiRSSI != 0 {
+					rssiSum += r.wifiRSSI
+					rssiN++
+				}
+				if r.wifiPHY > 0 {
+					phySum += r.wifiPHY
+					phyN++
+				}
+			}
+			if rssiN > 0 {
+				summary.AvgWiFiRSSIDBm = rssiSum / float64(rssiN)
+			}
+			if phyN > 0 {
+				summary.AvgWiFiPHYRateMbps = phySum / float64(phyN)
+			}
+			for i := len(recs) - 1; i >= 0; i-- {
+				if recs[i].wifiSSID != "" || recs[i].wifiBSSID != "" {
+					summary.WiFiSSID = recs[i].wifiSSID
+					summary.WiFiBSSID = recs[i].wifiBSSID
+					summary.WiFiChannel = recs[i].wifiChannel
+					break
+				}
+			}
+		}
+		// Happy-eyeballs rollup
+		{
+			var races, lost, winN, loseN int
+			var winSum, loseSum float64
+			seen := map[string]bool{}
+			for _, r := range recs {
+				if !r.heRace || seen[r.heKey] {
+					continue
+				}
+				seen[r.heKey] = true
+				races++
+				if r.heV6Lost {
+					lost++
+				}
+				if r.heWinnerMs > 0 {
+					winSum += r.heWinnerMs
+					winN++
+				}
+				if r.heV6LoseMs > 0 {
+					loseSum += r.heV6LoseMs
+					loseN++
+				}
+			}
+			if races > 0 {
+				summary.HappyEyeballsRaces = races
+				summary.HappyEyeballsIPv6LostPct = float64(lost) / float64(races) * 100
+			}
+			if winN > 0 {
+				summary.AvgHappyEyeballsWinnerMs = winSum / float64(winN)
+			}
+			if loseN > 0 {
+				summary.AvgHappyEyeballsIPv6LoserMs = loseSum / float64(loseN)
+			}
+		}
+		// QUIC/UDP reachability rollup (overall and per family)
+		quicRollup := func(filter string) (probed, blocked, noListener int) {
+			for _, r := range recs {
+				if !r.quicProbed || (filter != "" && r.ipFamily != filter) {
+					continue
+				}
+				if !r.altSvcH3 {
+					if r.udpBlocked {
+						noListener++
+					}
+					continue
+				}
+				probed++
+				if r.udpBlocked {
+					blocked++
+				}
+			}
+			return
+		}
+		probed, blocked, noListener := quicRollup("")
+		if probed > 0 {
+			summary.QUICProbeLines = probed
+			summary.UDPBlockedLines = blocked
+			summary.UDPBlockedRatePct = float64(blocked) / float64(probed) * 100
+		}
+		summary.QUICNoListenerLines = noListener
+		// Cold vs warm connection rollup (overall and per family)
+		reuseRollup := func(filter string) (cold, warm, setup []float64) {
+			for _, r := range recs {
+				if !r.reuseOK || (filter != "" && r.ipFamily != filter) {
+					continue
+				}
+				cold = append(cold, r.coldTTFB)
+				warm = append(warm, r.warmTTFB)
+				setup = append(setup, r.setupCost)
+			}
+			return
+		}
+		if cold, warm, setup := reuseRollup(""); len(cold) > 0 {
+			summary.ReuseExperimentLines = len(cold)
+			summary.AvgColdTTFBMs = avg(cold)
+			summary.AvgWarmTTFBMs = avg(warm)
+			summary.AvgSetupCostMs = avg(setup)
+			summary.P50SetupCostMs = percentile(setup, 50)
+		}
+		integrityRollup := func(filter string) (checked, mismatched int) {
+			for _, r := range recs {
+				if !r.integrityChecked || (filter != "" && r.ipFamily != filter) {
+					continue
+				}
+				checked++
+				if r.contentMismatch {
+					mismatched++
+				}
+			}
+			return
+		}
+		if checked, mismatched := integrityRollup(""); checked > 0 {
+			summary.IntegrityCheckedLines = checked
+			summary.ContentCorruptionRatePct = float64(mismatched) / float64(checked) * 100
+		}
+		rateLimitRollup := func(filter string) (lines, limited int, retryAfter []float64) {
+			for _, r := range recs {
+				if filter != "" && r.ipFamily != filter {
+					continue
+				}
+				lines++
+				if r.rateLimited {
+					limited++
+					if r.retryAfterSec >= 0 {
+						retryAfter = append(retryAfter, r.retryAfterSec)
+					}
+				}
+			}
+			return
+		}
+		if lines, limited, retryAfter := rateLimitRollup(""); limited > 0 {
+			summary.RateLimitedLines = limited
+			summary.RateLimitedRatePct = float64(limited) / float64(lines) * 100
+			summary.AvgRetryAfterSec = avg(retryAfter)
+		}
+		{
+			routeLines := make([]routeLine, 0, len(recs))
+			for _, r := range recs {
+				routeLines = append(routeLines, routeLine{url: r.url, family: r.ipFamily, ip: r.resolvedIP, asn: r.asn, asnOrg: r.asnOrg, path: r.routePath})
+				if summary.EgressIPv4ASN == 0 && r.egressV4 != 0 {
+					summary.EgressIPv4ASN, summary.EgressIPv4ASNOrg = r.egressV4, r.egressV4O
+				}
+				if summary.EgressIPv6ASN == 0 && r.egressV6 != 0 {
+					summary.EgressIPv6ASN, summary.EgressIPv6ASNOrg = r.egressV6, r.egressV6O
+				}
+			}
+			summary.RouteComparisons = compareRoutes(routeLines)
+			var hopLines []returnHopLine
+			for _, r := range recs {
+				if r.responseTTL != nil {
+					hopLines = append(hopLines, returnHopLine{url: r.url, family: r.ipFamily, ttl: r.responseTTL})
+				}
+			}
+			summary.ReturnHops = returnHops(hopLines)
+			var samples []*monitor.ResponseCompression
+			for _, r := range recs {
+				if r.compression != nil {
+					samples = append(samples, r.compression)
+				}
+			}
+			summary.Compression = compressionStats(samples)
+			var timings []*monitor.ServerTiming
+			var timingTTFBs []float64
+			for _, r := range recs {
+				if r.serverTiming != nil {
+					timings, timingTTFBs = append(timings, r.serverTiming), append(timingTTFBs, r.ttfb)
+				}
+			}
+			summary.ServerTiming = serverTimingStats(timings, timingTTFBs)
+			var tcpLines []tcpLine
+			for _, r := range recs {
+				if r.tcpStats != nil {
+					tcpLines = append(tcpLines, tcpLine{stats: r.tcpStats, stalled: r.stalled || r.microStallPresent})
+				}
+			}
+			summary.TCPStats = tcpRetransStats(tcpLines)
+			for _, c := range summary.RouteComparisons {
+				if c.Differs {
+					summary.RouteDifferSites++
+				}
+			}
+		}
+		{
+			var headerLines []headerLine
+			for _, r := range recs {
+				if len(r.respHeaders) > 0 {
+					headerLines = append(headerLines, headerLine{url: r.url, headers: r.respHeaders})
+				}
+			}
+			if len(headerLines) > 0 {
+				summary.HeaderFingerprints = headerFingerprints(headerLines)
+			}
+		}
+		{
+			var sloLines []sloLine
+			for _, r := range recs {
+				if r.slo != nil {
+					sloLines = append(sloLines, sloLine{target: r.site, group: r.group, slo: r.slo, ttfb: r.ttfb, speed: r.speed, failed: r.hasError})
+				}
+			}
+			summary.TargetSLOs = targetSLOCompliance(sloLines)
+		}
+		if len(probeRecs) > 0 {
+			probes := make([]*probeLine, len(probeRecs))
+			for i, r := range probeRecs {
+				probes[i] = r.probe
+			}
+			applyProbeRollup(&summary, probes, recCount)
+		}
+		{
+			var aMs, aaaaMs []float64
+			aErr, aaaaErr := 0, 0
+			for _, r := range recs {
+				if !r.dnsFamily {
+					continue
+				}
+				// failed lookups are timed too (a timeout is the slow case), but kept out of the
+				// latency averages so they read as error rates instead
+				if r.dnsAErr {
+					aErr++
+				} else {
+					aMs = append(aMs, r.dnsAMs)
+				}
+				if r.dnsAAAAErr {
+					aaaaErr++
+				} else {
+					aaaaMs = append(aaaaMs, r.dnsAAAAMs)
+				}
+			}
+			if n := len(aMs) + aErr; n > 0 {
+				summary.DNSFamilyLines = n
+				summary.AvgDNSAMs = avg(aMs)
+				summary.AvgDNSAAAAMs = avg(aaaaMs)
+				summary.P95DNSAMs = percentile(aMs, 95)
+				summary.P95DNSAAAAMs = percentile(aaaaMs, 95)
+				summary.DNSAErrorRatePct = float64(aErr) / float64(n) * 100
+				summary.DNSAAAAErrorRatePct = float64(aaaaErr) / float64(n) * 100
+			}
+		}
+		{
+			var hitMs, requeryMs, ttls []float64
+			for _, r := range recs {
+				c := r.dnsCache
+				if c == nil {
+					continue
+				}
+				summary.DNSCacheLines++
+				switch {
+				case c.CacheHit:
+					hitMs = append(hitMs, r.dnsLookMs)
+				case c.WithinTTL:
+					requeryMs = append(requeryMs, r.dnsLookMs)
+				}
+				if c.Error != "" {
+					summary.DNSTTLQueryErrorLines++
+				} else if c.TTLSeconds > 0 {
+					ttls = append(ttls, float64(c.TTLSeconds))
+				}
+			}
+			if n := len(hitMs) + len(requeryMs); n > 0 {
+				summary.DNSWithinTTLLookups = n
+				summary.DNSCacheHitRatePct = float64(len(hitMs)) / float64(n) * 100
+				summary.AvgDNSCacheHitMs = avg(hitMs)
+				summary.AvgDNSRequeryMs = avg(requeryMs)
+			}
+			if len(ttls) > 0 {
+				summary.MedianDNSTTLSeconds = medianOf(ttls)
+			}
+		}
+		{
+			var tl stallTimelineAccum
+			for _, r := range recs {
+				tl.add(r.sampleSpanMs, r.stallEvents)
+			}
+			tl.apply(&summary)
+		}
 		// Set LocalSelfTestKbps from the most recent non-zero value in this batch
 		for i := len(recs) - 1; i >= 0; i-- {
 			if recs[i].localSelfKbps > 0 {
@@ -1790,6 +2759,16 @@ readLoop:
 					if n := protoSpeedCnt[k]; n > 0 {
 						summary.AvgSpeedByHTTPProtocolKbps[k] = protoSpeedSum[k] / float64(n)
 					}
+					if vals := protoTTFBs[k]; len(vals) > 0 {
+						if summary.AvgTTFBByHTTPProtocolMs == nil {
+							summary.AvgTTFBByHTTPProtocolMs = map[string]float64{}
+							summary.P50TTFBByHTTPProtocolMs = map[string]float64{}
+							summary.P95TTFBByHTTPProtocolMs = map[string]float64{}
+						}
+						summary.AvgTTFBByHTTPProtocolMs[k] = avg(vals)
+						summary.P50TTFBByHTTPProtocolMs[k] = percentile(vals, 50)
+						summary.P95TTFBByHTTPProtocolMs[k] = percentile(vals, 95)
+					}
 					if c > 0 {
 						summary.StallRateByHTTPProtocolPct[k] = float64(protoStallCnt[k]) / float64(c) * 100
 						summary.ErrorRateByHTTPProtocolPct[k] = float64(protoErrorCnt[k]) / float64(c) * 100
@@ -1819,6 +2798,21 @@ readLoop:
 					}
 				}
 			}
+			if len(methodCounts) > 0 {
+				summary.HTTPMethodCounts = methodCounts
+				summary.AvgSpeedByHTTPMethodKbps = map[string]float64{}
+				summary.AvgTTFBByHTTPMethodMs = map[string]float64{}
+				summary.ErrorRateByHTTPMethodPct = map[string]float64{}
+				for m, c := range methodCounts {
+					if n := methodSpeedCnt[m]; n > 0 {
+						summary.AvgSpeedByHTTPMethodKbps[m] = methodSpeedSum[m] / float64(n)
+					}
+					if n := methodTTFBCnt[m]; n > 0 {
+						summary.AvgTTFBByHTTPMethodMs[m] = methodTTFBSum[m] / float64(n)
+					}
+					summary.ErrorRateByHTTPMethodPct[m] = float64(methodErrorCnt[m]) / float64(c) * 100
+				}
+			}
 			if len(tlsCounts) > 0 {
 				summary.TLSVersionCounts = tlsCounts
 				summary.TLSVersionRatePct = map[string]float64{}
@@ -1845,14 +2839,70 @@ readLoop:
 		// Speed percentiles overall
 		summary.AvgP25Speed = percentile(speeds, 25)
 		summary.AvgP75Speed = percentile(speeds, 75)
+		summary.PooledP50Speed, summary.PooledP90Speed, summary.PooledP95Speed, summary.PooledP99Speed = pooled.Quantile(50), pooled.Quantile(90), pooled.Quantile(95), pooled.Quantile(99)
+		summary.PooledSpeedSamples = int(pooled.Count)
+		summary.Stability = throughputStability(speeds)
+		if pooled.Count > 0 {
+			summary.SpeedSketch = pooled
+			summary.SpeedHistogram = pooled.Histogram(monitor.DefaultHistogramPerDecade)
+			if modes := HistogramModes(summary.SpeedHistogram); len(modes) > 1 {
+				summary.SpeedModesKbps = modes
+			}
+		}
+		if len(ttfbs) > 0 {
+			summary.TTFBSketch = monitor.NewSketch(monitor.DefaultSketchAlpha)
+			for _, v := range ttfbs {
+				summary.TTFBSketch.Add(v)
+			}
+		}
 		// Set split proxy rates
 		if recCount > 0 {
 			summary.EnterpriseProxyRatePct = float64(entProxyCntAll) / float64(recCount) * 100
 			summary.ServerProxyRatePct = float64(srvProxyCntAll) / float64(recCount) * 100
 		}
+		// a batch of probe lines only still carries its situation/agent/tags
+		for _, r := range probeRecs {
+			if batchSituation == "" {
+				batchSituation = r.situation
+			}
+			if batchAgent == "" {
+				batchAgent = r.agent
+			}
+			if batchTags == nil {
+				batchTags = r.tags
+			}
+		}
 		if batchSituation != "" {
 			summary.Situation = batchSituation
 		}
+		summary.Agent = batchAgent
+		summary.Tags = batchTags
+		summary.VPNActive, summary.VPNName = batchVPN, batchVPNName
+		summary.ReducedMode, summary.ReducedModeLines, summary.CappedTransferLines = reducedLines > 0, reducedLines, cappedLines
+		summary.Metered, summary.MeteredSource = batchMetered, batchMeteredSource
+		summary.SamplingProfile = batchSamplingProfile
+		summary.WireRxBytes, summary.WireTxBytes = wireRx, wireTx
+		summary.Canceled = batchCanceled
+		summary.PreHook = batchHooks.Pre
+		summary.ForcedProtocolLines, summary.ForcedProtocolFallbacks = forcedLines, forcedFallbacks
+		summary.Thresholds = &StallThresholds{MicroStallGapMs: opts.MicroStallMinGapMs, LowSpeedThresholdKbps: opts.LowSpeedThresholdKbps}
+		if c := batchConfig; c != nil {
+			summary.Config, summary.ConfigChanges = c, c.Changed
+			summary.Thresholds.StallTimeoutMs = c.StallTimeoutMs
+		}
+		if h := batchHealth; h != nil {
+			summary.HealthCheck, summary.ExcludedUnhealthy = h, h.Excluded
+		}
+		summary.BindInterface, summary.BindSourceIP = bindInterface, bindSourceIP
+		if h := batchDNSHijack; h != nil {
+			summary.DNSHijackChecked, summary.DNSHijackSuspected = true, h.Suspected
+			summary.DNSHijackAnswers, summary.DNSHijackResolver = h.Answers, h.Resolver
+		}
+		if u := lastUsage; u != nil {
+			summary.UsageDay, summary.DayRxBytes, summary.DayTxBytes = u.Day, u.DayRxBytes, u.DayTxBytes
+			summary.CycleRxBytes, summary.CycleTxBytes = u.CycleRxBytes, u.CycleTxBytes
+			summary.BudgetBytes, summary.BudgetAction = u.BudgetBytes, u.BudgetAction
+		}
 		// Situation is expected to be provided by upstream logic populating BatchSummary
 		// Fill proxy aggregation
 		if len(proxyNameCounts) > 0 {
@@ -1878,6 +2928,76 @@ readLoop:
 		if fam := buildFamily("ipv6"); fam != nil {
 			summary.IPv6 = fam
 		}
+		// Per target group subsets, only when some site of the batch has a group
+		for _, r := range recs {
+			if r.group == "" {
+				continue
+			}
+			if _, done := summary.Groups[r.group]; done {
+				continue
+			}
+			if summary.Groups == nil {
+				summary.Groups = map[string]*FamilySummary{}
+			}
+			g := r.group
+			summary.Groups[g] = buildSubset(func(r rec) bool { return r.group == g })
+		}
+		{
+			var v4Speeds, v6Speeds, v4TTFBs, v6TTFBs, h1Speeds, h2Speeds []float64
+			for _, r := range recs {
+				switch r.ipFamily {
+				case "ipv4":
+					v4Speeds, v4TTFBs = append(v4Speeds, r.speed), append(v4TTFBs, r.ttfb)
+				case "ipv6":
+					v6Speeds, v6TTFBs = append(v6Speeds, r.speed), append(v6TTFBs, r.ttfb)
+				}
+				switch r.httpProto {
+				case "HTTP/1.1":
+					h1Speeds = append(h1Speeds, r.speed)
+				case "HTTP/2.0":
+					h2Speeds = append(h2Speeds, r.speed)
+				}
+			}
+			summary.FamilySpeedTest = CompareGroups("IPv4", v4Speeds, "IPv6", v6Speeds)
+			summary.FamilyTTFBTest = CompareGroups("IPv4", v4TTFBs, "IPv6", v6TTFBs)
+			summary.ProtocolSpeedTest = CompareGroups("HTTP/1.1", h1Speeds, "HTTP/2.0", h2Speeds)
+		}
+		for _, f := range []struct {
+			name string
+			fam  *FamilySummary
+		}{{"ipv4", summary.IPv4}, {"ipv6", summary.IPv6}} {
+			if f.fam == nil {
+				continue
+			}
+			if probed, blocked, _ := quicRollup(f.name); probed > 0 {
+				f.fam.QUICProbeLines = probed
+				f.fam.UDPBlockedRatePct = float64(blocked) / float64(probed) * 100
+			}
+			if cold, warm, _ := reuseRollup(f.name); len(cold) > 0 {
+				f.fam.AvgColdTTFBMs = avg(cold)
+				f.fam.AvgWarmTTFBMs = avg(warm)
+			}
+			if checked, mismatched := integrityRollup(f.name); checked > 0 {
+				f.fam.IntegrityCheckedLines = checked
+				f.fam.ContentCorruptionRatePct = float64(mismatched) / float64(checked) * 100
+			}
+			if lines, limited, _ := rateLimitRollup(f.name); limited > 0 {
+				f.fam.RateLimitedRatePct = float64(limited) / float64(lines) * 100
+			}
+		}
+		{
+			known, withAAAA := 0, 0
+			for _, r := range recs {
+				if r.aaaaKnown {
+					known++
+					if r.hasAAAA {
+						withAAAA++
+					}
+				}
+			}
+			summary.IPv6Readiness = ipv6Readiness(known, withAAAA, summary.IPv4, summary.IPv6)
+		}
+		summary.QualityScore = qualityScore(&summary, qualityScoreConfig)
 		summaries = append(summaries, summary)
 		if debugOn {
 			// Compose protocol mix string if available
@@ -1939,13 +3059,45 @@ readLoop:
 			)
 		}
 	}
+	markHopChanges(summaries)
+	for i := range summaries {
+		summaries[i].PostHook = postHooks[summaries[i].RunTag]
+		if i > 0 && len(summaries[i].ConfigChanges) == 0 {
+			prev, cur := summaries[i-1].Config, summaries[i].Config
+			if prev != nil && cur != nil && prev.Hash != cur.Hash {
+				summaries[i].ConfigChanges = monitor.DiffRunConfig(prev, cur)
+			}
+		}
+	}
 	return summaries, nil
 }
 
 // Backwards-compatible wrapper for callers without options
 func AnalyzeRecentResultsFull(path string, schemaVersion, MaxBatches int, situationFilter string) ([]BatchSummary, error) {
-	// Choose a sensible default threshold for low-speed share (1,000 kbps) without breaking callers.
-	return AnalyzeRecentResultsFullWithOptions(path, schemaVersion, MaxBatches, AnalyzeOptions{SituationFilter: situationFilter, LowSpeedThresholdKbps: 1000, MicroStallMinGapMs: 500})
+	// The thresholds default to 1,000 kbps and 500 ms (SetDefaultStallThresholds).
+	t := currentStallThresholds()
+	return AnalyzeRecentResultsFullWithOptions(path, schemaVersion, MaxBatches, AnalyzeOptions{SituationFilter: situationFilter, LowSpeedThresholdKbps: t.LowSpeedThresholdKbps, MicroStallMinGapMs: t.MicroStallGapMs})
+}
+
+// MergeBatchSketches merges the speed and TTFB sketches of several batches, e.g. to read
+// percentiles over a whole day without re-reading raw samples. Either result is nil when no
+// batch carries that sketch.
+func MergeBatchSketches(summaries []BatchSummary) (speed, ttfb *monitor.Sketch) {
+	for _, s := range summaries {
+		if s.SpeedSketch != nil {
+			if speed == nil {
+				speed = monitor.NewSketch(s.SpeedSketch.Alpha)
+			}
+			speed.Merge(s.SpeedSketch)
+		}
+		if s.TTFBSketch != nil {
+			if ttfb == nil {
+				ttfb = monitor.NewSketch(s.TTFBSketch.Alpha)
+			}
+			ttfb.Merge(s.TTFBSketch)
+		}
+	}
+	return speed, ttfb
 }
 
 // CompareLastVsPrevious returns delta percentages for speed and TTFB of last batch vs previous average.
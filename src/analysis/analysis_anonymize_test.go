@@ -0,0 +1,123 @@
+package analysis
+
+import (
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iafilius/InternetQualityMonitor/src/monitor"
+)
+
+func TestAnonymizeResultsFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "results.jsonl")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	write := func(tag, ip string, speed float64, tcpErr string) {
+		meta := &monitor.Meta{TimestampUTC: time.Now().UTC().Format(time.RFC3339Nano), RunTag: tag, SchemaVersion: monitor.SchemaVersion,
+			Hostname: "laptop-jdoe", LocalIP: "192.168.1.23", WiFi: &monitor.WiFiInfo{SSID: "CorpNet", BSSID: "aa:bb:cc:dd:ee:ff", RSSIDBm: -60}}
+		sr := &monitor.SiteResult{Name: "intranet", URL: "https://files.corp.internal/big/file.bin?token=secret", IP: ip,
+			DNSIPs: []string{"10.1.2.3", "fd12::7"}, TransferSpeedKbps: speed, TCPError: tcpErr, EnvProxyURL: "http://proxy.corp.internal:3128"}
+		if strings.Contains(ip, ":") {
+			sr.IPFamily = "ipv6"
+		} else {
+			sr.IPFamily = "ipv4"
+		}
+		b, _ := json.Marshal(&monitor.ResultEnvelope{Meta: meta, SiteResult: sr})
+		f.Write(append(b, '\n'))
+	}
+	write("b1", "10.1.2.3", 1000, "")
+	write("b1", "fd12::7", 3000, "")
+	write("b2", "10.1.2.3", 2000, "dial tcp 10.1.2.3:443: connect to files.corp.internal refused")
+	f.WriteString("{not json\n")
+	f.Close()
+
+	out := filepath.Join(dir, "anon.jsonl")
+	rep, err := AnonymizeResultsFile(path, out, "s3cret")
+	if err != nil {
+		t.Fatalf("anonymize: %v", err)
+	}
+	if rep.Lines != 4 || rep.Written != 3 || rep.Dropped != 1 {
+		t.Fatalf("report: %+v", rep)
+	}
+	if rep.Replaced["ip"] != 3 || rep.Replaced["ssid"] != 1 {
+		t.Fatalf("replaced counts: %v", rep.Replaced)
+	}
+	data, _ := os.ReadFile(out)
+	for _, leak := range []string{"corp.internal", "10.1.2.3", "fd12::7", "192.168.1.23", "CorpNet", "laptop-jdoe", "intranet", "secret", "big/file"} {
+		if strings.Contains(string(data), leak) {
+			t.Errorf("output still contains %q:\n%s", leak, data)
+		}
+	}
+	if !strings.Contains(string(data), "connect to h") {
+		t.Errorf("error text lost its structure:\n%s", data)
+	}
+
+	// the metrics come through unchanged and pseudonyms are stable, so analysis still works
+	orig, err := AnalyzeRecentResultsFull(path, monitor.SchemaVersion, 5, "")
+	if err != nil {
+		t.Fatalf("analyze original: %v", err)
+	}
+	anon, err := AnalyzeRecentResultsFull(out, monitor.SchemaVersion, 5, "")
+	if err != nil || len(anon) != len(orig) {
+		t.Fatalf("analyze anonymized: %v (n=%d want %d)", err, len(anon), len(orig))
+	}
+	for i := range orig {
+		if anon[i].AvgSpeed != orig[i].AvgSpeed || anon[i].Lines != orig[i].Lines || anon[i].ErrorLines != orig[i].ErrorLines {
+			t.Errorf("batch %s: anonymized avg=%.1f lines=%d errors=%d, original avg=%.1f lines=%d errors=%d",
+				orig[i].RunTag, anon[i].AvgSpeed, anon[i].Lines, anon[i].ErrorLines, orig[i].AvgSpeed, orig[i].Lines, orig[i].ErrorLines)
+		}
+	}
+
+	a, b, other := newTestAnonymizer(t, "s3cret"), newTestAnonymizer(t, "s3cret"), newTestAnonymizer(t, "other")
+	if a.IP("10.1.2.3") != b.IP("10.1.2.3") || a.IP("10.1.2.3") == other.IP("10.1.2.3") {
+		t.Fatalf("pseudonyms must depend on the salt only")
+	}
+	if ip := net.ParseIP(a.IP("2001:db8::1")); ip == nil || ip.To4() != nil {
+		t.Fatalf("IPv6 pseudonym lost its family: %v", ip)
+	}
+	if got := a.IP("[2001:db8::1]:443"); !strings.HasPrefix(got, "[fd") || !strings.HasSuffix(got, "]:443") {
+		t.Fatalf("host:port pseudonym: %s", got)
+	}
+	if got := a.IP("iperf.corp.internal:5201"); got != a.Host("iperf.corp.internal")+":5201" {
+		t.Fatalf("iperf3 server pseudonym: %s", got)
+	}
+
+	// fields the anonymizer knows nothing about are scrubbed, not copied
+	line := []byte(`{"meta":{"run_tag":"b3","bind_source_ip":"192.168.1.23","bind_interface":"eth-corp","tags":{"isp":"AcmeFiber","location":"Amsterdam HQ"},` +
+		`"dns_hijack":{"resolver":"10.9.9.9:53","answers":["10.9.9.10","block.corp.internal"]},` +
+		`"hooks":{"pre":{"phase":"pre","command":"/opt/corp/vpn-up --gw gw.corp.internal","output":"tunnel to 10.9.9.1 up"}}},` +
+		`"site_result":{"probe_type":"iperf3","iperf3":{"server":"iperf.corp.internal:5201","duration_ms":10000},"future_field":"corp-secret","tls_version":"TLS1.3"}}`)
+	anonLine, err := newTestAnonymizer(t, "s3cret").Line(line)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, leak := range []string{"192.168.1.23", "eth-corp", "10.9.9", "corp", "vpn-up", "tunnel", "AcmeFiber", "Amsterdam"} {
+		if strings.Contains(string(anonLine), leak) {
+			t.Errorf("anonymized line still contains %q:\n%s", leak, anonLine)
+		}
+	}
+	for _, keep := range []string{`"run_tag":"b3"`, `"phase":"pre"`, `"probe_type":"iperf3"`, `"tls_version":"TLS1.3"`, `"duration_ms":10000`, `"future_field":"future_field-`, `:5201"`, `"isp":"tag-`} {
+		if !strings.Contains(string(anonLine), keep) {
+			t.Errorf("anonymized line lacks %s:\n%s", keep, anonLine)
+		}
+	}
+	if _, err := AnonymizeResultsFile(path, path, ""); err == nil {
+		t.Fatalf("anonymizing in place must be refused")
+	}
+}
+
+func newTestAnonymizer(t *testing.T, salt string) *Anonymizer {
+	t.Helper()
+	a, err := NewAnonymizer(salt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
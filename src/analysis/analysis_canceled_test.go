@@ -0,0 +1,47 @@
+package analysis
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/iafilius/InternetQualityMonitor/src/monitor"
+)
+
+func TestBatchCanceled(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.jsonl")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	write := func(tag string, canceled bool, probe string) {
+		meta := &monitor.Meta{TimestampUTC: time.Now().UTC().Format(time.RFC3339Nano), RunTag: tag, SchemaVersion: monitor.SchemaVersion, Canceled: canceled}
+		env := monitor.ResultEnvelope{Meta: meta, SiteResult: &monitor.SiteResult{TransferSpeedKbps: 1000, ProbeType: probe}}
+		b, _ := json.Marshal(&env)
+		f.Write(append(b, '\n'))
+	}
+	write("full", false, "")
+	write("full", false, "")
+	// lines finished before the signal have no flag; the in-flight one marks the batch
+	write("stopped", false, "")
+	write("stopped", true, "")
+	// the in-flight line may be a non-HTTP probe
+	write("stopped_probe", false, "")
+	write("stopped_probe", true, monitor.ProbePing)
+	f.Close()
+
+	sums, err := AnalyzeRecentResultsFull(path, monitor.SchemaVersion, 5, "")
+	if err != nil || len(sums) != 3 {
+		t.Fatalf("analyze: %v (n=%d)", err, len(sums))
+	}
+	for _, s := range sums {
+		if want := s.RunTag != "full"; s.Canceled != want {
+			t.Fatalf("%s: canceled=%v want %v", s.RunTag, s.Canceled, want)
+		}
+		if s.ErrorLines != 0 {
+			t.Fatalf("%s: canceled lines counted as errors: %d", s.RunTag, s.ErrorLines)
+		}
+	}
+}
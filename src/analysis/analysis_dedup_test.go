@@ -0,0 +1,54 @@
+package analysis
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/iafilius/InternetQualityMonitor/src/monitor"
+)
+
+func TestDuplicateLinesIgnored(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.jsonl")
+	var data []byte
+	line := func(tag, ts string, speed float64) []byte {
+		env := monitor.ResultEnvelope{
+			Meta:       &monitor.Meta{TimestampUTC: ts, RunTag: tag, SchemaVersion: monitor.SchemaVersion},
+			SiteResult: &monitor.SiteResult{Name: "s", URL: "https://a.example/", TransferSpeedKbps: speed},
+		}
+		b, _ := json.Marshal(&env)
+		return append(b, '\n')
+	}
+	first := append(line("20260301_100000", "2026-03-01T10:00:01Z", 1000), line("20260301_100000", "2026-03-01T10:00:02Z", 3000)...)
+	second := line("20260301_110000", "2026-03-01T11:00:01Z", 5000)
+	data = append(data, first...)
+	data = append(data, second...)
+	// the whole file appended again, plus a re-sent first line with trailing whitespace
+	data = append(data, first...)
+	data = append(data, second...)
+	resent := line("20260301_100000", "2026-03-01T10:00:01Z", 1000)
+	data = append(data, resent[:len(resent)-1]...)
+	data = append(data, " \t\n"...)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var st LoadStats
+	sums, err := AnalyzeRecentResultsFullWithOptions(path, monitor.SchemaVersion, 5, AnalyzeOptions{Stats: &st})
+	if err != nil || len(sums) != 2 {
+		t.Fatalf("analyze: %v (n=%d)", err, len(sums))
+	}
+	if sums[0].Lines != 2 || sums[0].AvgSpeed != 2000 || sums[1].Lines != 1 {
+		t.Fatalf("duplicates counted: %+v / %+v", sums[0].Lines, sums[1].Lines)
+	}
+	if st.Duplicates != 4 || st.Parsed != 7 {
+		t.Fatalf("stats: %+v", st)
+	}
+
+	// an identical line in a different batch is not a duplicate
+	data = append(line("a", "2026-03-01T10:00:01Z", 1000), line("b", "2026-03-01T10:00:01Z", 1000)...)
+	os.WriteFile(path, data, 0o644)
+	if sums, err = AnalyzeRecentResultsFullWithOptions(path, monitor.SchemaVersion, 5, AnalyzeOptions{Stats: &st}); err != nil || len(sums) != 2 || st.Duplicates != 0 {
+		t.Fatalf("cross-batch: %v n=%d dup=%d", err, len(sums), st.Duplicates)
+	}
+}
@@ -0,0 +1,50 @@
+package analysis
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/iafilius/InternetQualityMonitor/src/monitor"
+)
+
+func TestBatchDNSHijack(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.jsonl")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	write := func(tag string, h *monitor.DNSHijackInfo) {
+		env := monitor.ResultEnvelope{Meta: &monitor.Meta{TimestampUTC: time.Now().UTC().Format(time.RFC3339Nano), RunTag: tag, SchemaVersion: monitor.SchemaVersion, DNSHijack: h}, SiteResult: &monitor.SiteResult{TransferSpeedKbps: 1000}}
+		b, _ := json.Marshal(&env)
+		f.Write(append(b, '\n'))
+	}
+	clean := &monitor.DNSHijackInfo{Checked: 3, NXDomain: 3, Resolver: "192.168.1.1:53"}
+	rewritten := &monitor.DNSHijackInfo{Suspected: true, Checked: 3, NXDomain: 1, Answered: 2, Answers: []string{"92.242.132.24"}, Resolver: "192.168.1.1:53"}
+	write("A", clean)
+	write("A", clean)
+	write("B", rewritten)
+	write("B", rewritten)
+	write("C", nil) // monitor without the check
+	f.Close()
+
+	sums, err := AnalyzeRecentResultsFull(path, monitor.SchemaVersion, 5, "")
+	if err != nil || len(sums) != 3 {
+		t.Fatalf("analyze: %v (n=%d)", err, len(sums))
+	}
+	byTag := map[string]BatchSummary{}
+	for _, s := range sums {
+		byTag[s.RunTag] = s
+	}
+	if a := byTag["A"]; !a.DNSHijackChecked || a.DNSHijackSuspected || a.DNSHijackResolver != "192.168.1.1:53" {
+		t.Fatalf("batch A: %+v", a)
+	}
+	if b := byTag["B"]; !b.DNSHijackSuspected || len(b.DNSHijackAnswers) != 1 || b.DNSHijackAnswers[0] != "92.242.132.24" {
+		t.Fatalf("batch B: suspected=%v answers=%v", b.DNSHijackSuspected, b.DNSHijackAnswers)
+	}
+	if c := byTag["C"]; c.DNSHijackChecked || c.DNSHijackSuspected {
+		t.Fatalf("batch C was not checked")
+	}
+}
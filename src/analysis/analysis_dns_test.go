@@ -64,3 +64,73 @@ func TestDNSAggregation_TracePrecedenceAndLegacyOverlay(t *testing.T) {
 		t.Fatalf("avg legacy dns got %.3f want 30.000", b.AvgDNSLegacyMs)
 	}
 }
+
+func TestDNSAggregation_PerFamilySplit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.jsonl")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	lines := []*monitor.DNSFamilyTiming{
+		{AMs: 10, ACount: 1, AAAAMs: 200, AAAACount: 1},
+		{AMs: 20, ACount: 1, AAAAMs: 400},
+		{AMs: 30, ACount: 1, AAAAMs: 5000, AAAAError: "i/o timeout"},
+		nil, // IP-literal host: no split
+	}
+	for _, ft := range lines {
+		env := monitor.ResultEnvelope{Meta: &monitor.Meta{TimestampUTC: time.Now().UTC().Format(time.RFC3339Nano), RunTag: "F1", SchemaVersion: monitor.SchemaVersion},
+			SiteResult: &monitor.SiteResult{DNSTimeMs: 50, DNSFamily: ft}}
+		b, _ := json.Marshal(&env)
+		f.Write(append(b, '\n'))
+	}
+	f.Close()
+	sums, err := AnalyzeRecentResultsFull(path, monitor.SchemaVersion, 5, "")
+	if err != nil || len(sums) != 1 {
+		t.Fatalf("analyze: %v (%d batches)", err, len(sums))
+	}
+	b := sums[0]
+	if b.DNSFamilyLines != 3 || b.AvgDNSAMs != 20 || b.P95DNSAMs != 30 {
+		t.Fatalf("A: lines=%d avg=%.1f p95=%.1f", b.DNSFamilyLines, b.AvgDNSAMs, b.P95DNSAMs)
+	}
+	// the timed-out AAAA lookup counts as an error, not as latency
+	if b.AvgDNSAAAAMs != 300 || b.P95DNSAAAAMs != 400 || abs(b.DNSAAAAErrorRatePct-100.0/3) > 1e-9 || b.DNSAErrorRatePct != 0 {
+		t.Fatalf("AAAA: avg=%.1f p95=%.1f err=%.2f%% aErr=%.2f%%", b.AvgDNSAAAAMs, b.P95DNSAAAAMs, b.DNSAAAAErrorRatePct, b.DNSAErrorRatePct)
+	}
+}
+
+func TestDNSAggregation_CacheHitRate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.jsonl")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	lines := []struct {
+		ms int64
+		c  *monitor.DNSCacheInfo
+	}{
+		{90, &monitor.DNSCacheInfo{TTLSeconds: 60}},
+		{2, &monitor.DNSCacheInfo{TTLSeconds: 60, WithinTTL: true, CacheHit: true}},
+		{4, &monitor.DNSCacheInfo{TTLSeconds: 300, WithinTTL: true, CacheHit: true}},
+		{120, &monitor.DNSCacheInfo{TTLSeconds: 300, WithinTTL: true}},
+		{3000, &monitor.DNSCacheInfo{Error: "i/o timeout"}},
+		{5, nil},
+	}
+	for _, l := range lines {
+		sr := &monitor.SiteResult{DNSTimeMs: l.ms, DNSCache: l.c}
+		env := monitor.ResultEnvelope{Meta: &monitor.Meta{TimestampUTC: time.Now().UTC().Format(time.RFC3339Nano), RunTag: "C1", SchemaVersion: monitor.SchemaVersion}, SiteResult: sr}
+		b, _ := json.Marshal(&env)
+		f.Write(append(b, '\n'))
+	}
+	f.Close()
+	sums, err := AnalyzeRecentResultsFull(path, monitor.SchemaVersion, 5, "")
+	if err != nil || len(sums) != 1 {
+		t.Fatalf("analyze: %v (%d batches)", err, len(sums))
+	}
+	b := sums[0]
+	if b.DNSCacheLines != 5 || b.DNSWithinTTLLookups != 3 || b.DNSTTLQueryErrorLines != 1 {
+		t.Fatalf("counts: lines=%d within=%d errors=%d", b.DNSCacheLines, b.DNSWithinTTLLookups, b.DNSTTLQueryErrorLines)
+	}
+	if abs(b.DNSCacheHitRatePct-200.0/3) > 1e-6 || b.AvgDNSCacheHitMs != 3 || b.AvgDNSRequeryMs != 120 || b.MedianDNSTTLSeconds != 180 {
+		t.Fatalf("rates: hit=%.2f%% hit_ms=%.1f requery_ms=%.1f ttl=%.0f", b.DNSCacheHitRatePct, b.AvgDNSCacheHitMs, b.AvgDNSRequeryMs, b.MedianDNSTTLSeconds)
+	}
+}
@@ -0,0 +1,44 @@
+package analysis
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/iafilius/InternetQualityMonitor/src/monitor"
+)
+
+func TestForcedProtocolLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.jsonl")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	meta := &monitor.Meta{TimestampUTC: time.Now().UTC().Format(time.RFC3339Nano), RunTag: "20250101_000000", SchemaVersion: monitor.SchemaVersion}
+	for _, sr := range []*monitor.SiteResult{
+		{Name: "a", TransferSpeedKbps: 1000, HTTPProtocol: "HTTP/1.1", ForcedHTTPVersion: "1.1"},
+		{Name: "a", TransferSpeedKbps: 1200, HTTPProtocol: "HTTP/2.0", ForcedHTTPVersion: "2"},
+		{Name: "b", TransferSpeedKbps: 800, HTTPProtocol: "HTTP/1.1", ForcedHTTPVersion: "2"}, // no h2 on the server
+		{Name: "c", HTTPError: "connection reset", ForcedHTTPVersion: "2"},
+		{Name: "d", HTTPError: "timeout"},
+	} {
+		writeEnvLine(t, f, monitor.ResultEnvelope{Meta: meta, SiteResult: sr})
+	}
+	f.Close()
+	sums, err := AnalyzeRecentResultsFull(path, monitor.SchemaVersion, 5, "")
+	if err != nil || len(sums) != 1 {
+		t.Fatalf("analyze: %v (%d batches)", err, len(sums))
+	}
+	b := sums[0]
+	if b.ForcedProtocolLines != 4 || b.ForcedProtocolFallbacks != 1 {
+		t.Fatalf("forced=%d fallbacks=%d", b.ForcedProtocolLines, b.ForcedProtocolFallbacks)
+	}
+	// the failed forced line counts under its forced version, the unforced one stays unknown
+	if b.HTTPProtocolCounts["HTTP/2.0"] != 2 || b.HTTPProtocolCounts["HTTP/1.1"] != 2 || b.HTTPProtocolCounts["(unknown)"] != 1 {
+		t.Fatalf("counts %v", b.HTTPProtocolCounts)
+	}
+	if b.ErrorRateByHTTPProtocolPct["HTTP/2.0"] != 50 {
+		t.Fatalf("h2 error rate %v", b.ErrorRateByHTTPProtocolPct)
+	}
+}
@@ -0,0 +1,53 @@
+package analysis
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/iafilius/InternetQualityMonitor/src/monitor"
+)
+
+func TestTargetGroups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.jsonl")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	write := func(tag, group string, kbps float64, ttfb int64) {
+		env := monitor.ResultEnvelope{Meta: &monitor.Meta{TimestampUTC: time.Now().UTC().Format(time.RFC3339Nano), RunTag: tag, SchemaVersion: monitor.SchemaVersion}, SiteResult: &monitor.SiteResult{Group: group, TransferSpeedKbps: kbps, TraceTTFBMs: ttfb}}
+		b, _ := json.Marshal(&env)
+		f.Write(append(b, '\n'))
+	}
+	write("A", "Intranet", 50000, 5)
+	write("A", "Intranet", 70000, 7)
+	write("A", "CDN", 10000, 40)
+	write("A", "", 2000, 100) // ungrouped: batch totals only
+	write("B", "", 1000, 50)
+	f.Close()
+
+	sums, err := AnalyzeRecentResultsFull(path, monitor.SchemaVersion, 5, "")
+	if err != nil || len(sums) != 2 {
+		t.Fatalf("analyze: %v (n=%d)", err, len(sums))
+	}
+	byTag := map[string]BatchSummary{}
+	for _, s := range sums {
+		byTag[s.RunTag] = s
+	}
+	a := byTag["A"]
+	if a.Lines != 4 || len(a.Groups) != 2 {
+		t.Fatalf("batch A: lines=%d groups=%v", a.Lines, a.Groups)
+	}
+	in, cdn := a.Groups["Intranet"], a.Groups["CDN"]
+	if in == nil || in.Lines != 2 || in.AvgSpeed != 60000 || in.AvgTTFB != 6 {
+		t.Fatalf("Intranet group: %+v", in)
+	}
+	if cdn == nil || cdn.Lines != 1 || cdn.AvgSpeed != 10000 {
+		t.Fatalf("CDN group: %+v", cdn)
+	}
+	if byTag["B"].Groups != nil {
+		t.Fatalf("batch B should have no groups: %v", byTag["B"].Groups)
+	}
+}
@@ -0,0 +1,44 @@
+package analysis
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/iafilius/InternetQualityMonitor/src/monitor"
+)
+
+func TestHappyEyeballsRollup_IPv6LostPct(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "results.jsonl")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	write := func(site string, he *monitor.HappyEyeballs) {
+		env := monitor.ResultEnvelope{Meta: &monitor.Meta{TimestampUTC: time.Now().UTC().Format(time.RFC3339Nano), RunTag: "H1", SchemaVersion: monitor.SchemaVersion}, SiteResult: &monitor.SiteResult{Name: site, TransferSpeedKbps: 1000, HappyEyeballs: he}}
+		b, _ := json.Marshal(&env)
+		f.Write(append(b, '\n'))
+	}
+	write("s1", &monitor.HappyEyeballs{Winner: "ipv6", IPv6Attempted: true, WinnerConnectMs: 20})
+	write("s2", &monitor.HappyEyeballs{Winner: "ipv4", IPv6Attempted: true, IPv4Attempted: true, WinnerConnectMs: 340, LoserFamily: "ipv6", LoserConnectMs: 900, LoserOutcome: "aborted"})
+	write("s2", &monitor.HappyEyeballs{Winner: "ipv4", IPv6Attempted: true, IPv4Attempted: true, WinnerConnectMs: 340, LoserFamily: "ipv6", LoserConnectMs: 900, LoserOutcome: "aborted"}) // second IP of s2: same race
+	write("s3", &monitor.HappyEyeballs{Winner: "ipv6", IPv6Attempted: true, WinnerConnectMs: 20})
+	write("s4", &monitor.HappyEyeballs{Winner: "ipv4", IPv6Attempted: true, IPv4Attempted: true, WinnerConnectMs: 20, LoserFamily: "ipv6", LoserConnectMs: 100, LoserOutcome: "failed"})
+	write("s5", nil) // single-family site: no race
+	f.Close()
+
+	sums, err := AnalyzeRecentResultsFull(path, monitor.SchemaVersion, 5, "")
+	if err != nil || len(sums) != 1 {
+		t.Fatalf("analyze: %v (n=%d)", err, len(sums))
+	}
+	b := sums[0]
+	if b.HappyEyeballsRaces != 4 || b.HappyEyeballsIPv6LostPct != 50 {
+		t.Fatalf("races=%d lost=%.1f%% want 4/50%%", b.HappyEyeballsRaces, b.HappyEyeballsIPv6LostPct)
+	}
+	if b.AvgHappyEyeballsWinnerMs != 100 || b.AvgHappyEyeballsIPv6LoserMs != 500 {
+		t.Fatalf("winner=%.1f loser=%.1f want 100/500", b.AvgHappyEyeballsWinnerMs, b.AvgHappyEyeballsIPv6LoserMs)
+	}
+}
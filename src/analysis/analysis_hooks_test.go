@@ -0,0 +1,71 @@
+package analysis
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/iafilius/InternetQualityMonitor/src/monitor"
+)
+
+// The post hook of a batch is written on the next batch's lines and must land on the batch it ran after.
+func TestBatchHooksLinkedToTheirBatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.jsonl")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	write := func(tag string, hooks *monitor.BatchHooks) {
+		env := monitor.ResultEnvelope{Meta: &monitor.Meta{TimestampUTC: "2026-10-01T10:00:00Z", RunTag: tag, Hooks: hooks, SchemaVersion: monitor.SchemaVersion}, SiteResult: &monitor.SiteResult{TransferSpeedKbps: 1000}}
+		b, _ := json.Marshal(&env)
+		f.Write(append(b, '\n'))
+	}
+	pre1 := &monitor.HookResult{Phase: monitor.HookPre, RunTag: "20261001_100000_i1", Command: "vpn up"}
+	post1 := &monitor.HookResult{Phase: monitor.HookPost, RunTag: "20261001_100000_i1", Command: "vpn down", ExitCode: 1}
+	pre2 := &monitor.HookResult{Phase: monitor.HookPre, RunTag: "20261001_100000_i2", Command: "vpn up"}
+	write("20261001_100000_i1", &monitor.BatchHooks{Pre: pre1})
+	write("20261001_100000_i2", &monitor.BatchHooks{Pre: pre2, PostPrev: post1})
+	f.Close()
+	sums, err := AnalyzeRecentResultsFull(path, monitor.SchemaVersion, 5, "")
+	if err != nil || len(sums) != 2 {
+		t.Fatalf("analyze: %v (n=%d)", err, len(sums))
+	}
+	if s := sums[0]; s.PreHook == nil || s.PreHook.RunTag != pre1.RunTag || s.PostHook == nil || s.PostHook.OK() {
+		t.Fatalf("batch 1 hooks: pre=%+v post=%+v", s.PreHook, s.PostHook)
+	}
+	if s := sums[1]; s.PreHook == nil || s.PreHook.RunTag != pre2.RunTag || s.PostHook != nil {
+		t.Fatalf("batch 2 hooks: pre=%+v post=%+v", s.PreHook, s.PostHook)
+	}
+}
+
+// Config changes come from meta.config.changed, or from comparing with the preceding batch when the
+// monitor restarted with other settings.
+func TestBatchConfigChanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.jsonl")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	write := func(tag string, cfg monitor.RunConfig) {
+		env := monitor.ResultEnvelope{Meta: &monitor.Meta{TimestampUTC: "2026-10-01T10:00:00Z", RunTag: tag, Config: &cfg, SchemaVersion: monitor.SchemaVersion}, SiteResult: &monitor.SiteResult{TransferSpeedKbps: 1000}}
+		b, _ := json.Marshal(&env)
+		f.Write(append(b, '\n'))
+	}
+	write("20261001_100000_i1", monitor.RunConfig{Hash: "a", Sites: 3, Parallel: 1})
+	write("20261001_100000_i2", monitor.RunConfig{Hash: "b", Sites: 3, Parallel: 2, Changed: []string{"parallel 1→2"}})
+	write("20261001_120000", monitor.RunConfig{Hash: "c", Sites: 4, Parallel: 2})
+	write("20261001_121500", monitor.RunConfig{Hash: "c", Sites: 4, Parallel: 2})
+	f.Close()
+	sums, err := AnalyzeRecentResultsFull(path, monitor.SchemaVersion, 10, "")
+	if err != nil || len(sums) != 4 {
+		t.Fatalf("analyze: %v (n=%d)", err, len(sums))
+	}
+	var got [][]string
+	for _, s := range sums {
+		got = append(got, s.ConfigChanges)
+	}
+	if len(got[0]) != 0 || len(got[1]) != 1 || got[1][0] != "parallel 1→2" || len(got[2]) != 1 || got[2][0] != "sites 3→4" || len(got[3]) != 0 {
+		t.Fatalf("config changes = %q", got)
+	}
+}
@@ -0,0 +1,48 @@
+package analysis
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/iafilius/InternetQualityMonitor/src/monitor"
+)
+
+func TestContentCorruptionRate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.jsonl")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	write := func(family, sha string, mismatch bool) {
+		sr := &monitor.SiteResult{IPFamily: family, TransferSpeedKbps: 1000, TransferSizeBytes: 1024, ContentSHA256: sha, ContentMismatch: mismatch}
+		if mismatch {
+			sr.HTTPError = "content_mismatch: sha256=bb expected=aa"
+		}
+		env := monitor.ResultEnvelope{Meta: &monitor.Meta{TimestampUTC: time.Now().UTC().Format(time.RFC3339Nano), RunTag: "IC1", SchemaVersion: monitor.SchemaVersion}, SiteResult: sr}
+		b, _ := json.Marshal(env)
+		f.Write(append(b, '\n'))
+	}
+	write("ipv4", "aa", false)
+	write("ipv4", "bb", true) // rewritten in transit
+	write("ipv6", "aa", false)
+	write("ipv6", "", false) // site without an expected digest: not part of the rate
+	f.Close()
+
+	sums, err := AnalyzeRecentResultsFull(path, monitor.SchemaVersion, 5, "")
+	if err != nil || len(sums) != 1 {
+		t.Fatalf("analyze: %v (%d batches)", err, len(sums))
+	}
+	s := sums[0]
+	if s.IntegrityCheckedLines != 3 || abs(s.ContentCorruptionRatePct-100.0/3) > 1e-9 {
+		t.Fatalf("overall: checked=%d rate=%.3f, want 3 and 33.333", s.IntegrityCheckedLines, s.ContentCorruptionRatePct)
+	}
+	if s.IPv4 == nil || s.IPv4.ContentCorruptionRatePct != 50 || s.IPv6 == nil || s.IPv6.IntegrityCheckedLines != 1 || s.IPv6.ContentCorruptionRatePct != 0 {
+		t.Fatalf("per family: %+v / %+v", s.IPv4, s.IPv6)
+	}
+	if s.ErrorShareByReasonPct["content_mismatch"] != 100 {
+		t.Fatalf("content_mismatch should be its own error reason: %v", s.ErrorShareByReasonPct)
+	}
+}
@@ -0,0 +1,76 @@
+package analysis
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/iafilius/InternetQualityMonitor/src/monitor"
+)
+
+func TestIPv6ReadinessScore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.jsonl")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	write := func(tag, fam string, speed float64, ttfb int64, dnsIPs []string, httpErr string) {
+		env := monitor.ResultEnvelope{Meta: &monitor.Meta{TimestampUTC: time.Now().UTC().Format(time.RFC3339Nano), RunTag: tag, SchemaVersion: monitor.SchemaVersion},
+			SiteResult: &monitor.SiteResult{IPFamily: fam, TransferSpeedKbps: speed, TraceTTFBMs: ttfb, DNSIPs: dnsIPs, HTTPError: httpErr}}
+		b, _ := json.Marshal(&env)
+		f.Write(append(b, '\n'))
+	}
+	dual := []string{"192.0.2.1", "2001:db8::1"}
+	// v4-only batch: no IPv6 lines, one of two hosts has AAAA
+	write("b1", "ipv4", 1000, 100, []string{"192.0.2.1"}, "")
+	write("b1", "ipv4", 1000, 100, dual, "")
+	// dual-stack batch: IPv6 half as fast, half the IPv6 lines fail
+	write("b2", "ipv4", 1000, 100, dual, "")
+	write("b2", "ipv6", 500, 200, dual, "")
+	write("b2", "ipv6", 500, 200, dual, "connection reset")
+	// no DNS information and no IPv6 at all
+	write("b3", "ipv4", 1000, 100, nil, "")
+	f.Close()
+
+	sums, err := AnalyzeRecentResultsFull(path, monitor.SchemaVersion, 5, "")
+	if err != nil || len(sums) != 3 {
+		t.Fatalf("analyze: %v (n=%d)", err, len(sums))
+	}
+	byTag := map[string]BatchSummary{}
+	for _, s := range sums {
+		byTag[s.RunTag] = s
+	}
+	r := byTag["b1"].IPv6Readiness
+	if r == nil || r.AAAAPct != 50 || r.SuccessPct != 0 || r.UDPPct != -1 {
+		t.Fatalf("v4-only readiness: %+v", r)
+	}
+	// only AAAA (25) counts toward the score among the components with data: 25*50/85
+	if want := 25.0 * 50 / 85; math.Abs(r.Score-want) > 0.01 {
+		t.Fatalf("v4-only score %.2f want %.2f", r.Score, want)
+	}
+	r = byTag["b2"].IPv6Readiness
+	if r == nil || r.AAAAPct != 100 || r.SuccessPct != 50 || r.SpeedPct != 50 || r.TTFBPct != 50 {
+		t.Fatalf("dual-stack readiness: %+v", r)
+	}
+	if want := (25*100 + 30*50 + 15*50 + 15*50) / 85.0; math.Abs(r.Score-want) > 0.01 {
+		t.Fatalf("dual-stack score %.2f want %.2f", r.Score, want)
+	}
+	if byTag["b3"].IPv6Readiness != nil {
+		t.Fatalf("batch without IPv6 information should have no score: %+v", byTag["b3"].IPv6Readiness)
+	}
+}
+
+func TestIPv6ReadinessUDPComponent(t *testing.T) {
+	v4 := &FamilySummary{Lines: 2, AvgSpeed: 1000, AvgTTFB: 100}
+	v6 := &FamilySummary{Lines: 2, AvgSpeed: 2000, AvgTTFB: 50, QUICProbeLines: 2, UDPBlockedRatePct: 100}
+	r := ipv6Readiness(2, 2, v4, v6)
+	if r.SpeedPct != 100 || r.TTFBPct != 100 || r.UDPPct != 0 {
+		t.Fatalf("components: %+v", r)
+	}
+	if want := 85.0; math.Abs(r.Score-want) > 0.01 {
+		t.Fatalf("score %.2f want %.2f (UDP blocked costs its 15%%)", r.Score, want)
+	}
+}
@@ -0,0 +1,79 @@
+package analysis
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/iafilius/InternetQualityMonitor/src/monitor"
+)
+
+func TestBatchReducedMode(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.jsonl")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	write := func(tag string, mi *monitor.MeteredInfo, capped bool) {
+		meta := &monitor.Meta{TimestampUTC: time.Now().UTC().Format(time.RFC3339Nano), RunTag: tag, SchemaVersion: monitor.SchemaVersion, Metered: mi}
+		meta.ReducedMode = mi != nil && mi.Action == monitor.MeteredPolicyReduce
+		env := monitor.ResultEnvelope{Meta: meta, SiteResult: &monitor.SiteResult{TransferSpeedKbps: 1000, TransferCapped: capped}}
+		b, _ := json.Marshal(&env)
+		f.Write(append(b, '\n'))
+	}
+	write("full", nil, false)
+	write("full", nil, false)
+	hotspot := &monitor.MeteredInfo{Metered: true, Source: "heuristic", Action: monitor.MeteredPolicyReduce}
+	write("reduced", hotspot, true)
+	write("reduced", hotspot, false) // body smaller than the cap
+	write("recorded", &monitor.MeteredInfo{Metered: true, Source: "nmcli"}, false)
+	f.Close()
+
+	sums, err := AnalyzeRecentResultsFull(path, monitor.SchemaVersion, 5, "")
+	if err != nil || len(sums) != 3 {
+		t.Fatalf("analyze: %v (n=%d)", err, len(sums))
+	}
+	byTag := map[string]BatchSummary{}
+	for _, s := range sums {
+		byTag[s.RunTag] = s
+	}
+	if s := byTag["full"]; s.ReducedMode || s.Metered || s.CappedTransferLines != 0 {
+		t.Fatalf("full batch: %+v", s.ReducedMode)
+	}
+	if s := byTag["reduced"]; !s.ReducedMode || s.ReducedModeLines != 2 || s.CappedTransferLines != 1 || !s.Metered || s.MeteredSource != "heuristic" {
+		t.Fatalf("reduced batch: reduced=%v lines=%d capped=%d metered=%v source=%q", s.ReducedMode, s.ReducedModeLines, s.CappedTransferLines, s.Metered, s.MeteredSource)
+	}
+	// record policy: metered is known but transfers ran in full
+	if s := byTag["recorded"]; s.ReducedMode || !s.Metered || s.MeteredSource != "nmcli" {
+		t.Fatalf("recorded batch: reduced=%v metered=%v source=%q", s.ReducedMode, s.Metered, s.MeteredSource)
+	}
+}
+
+func TestBatchSamplingProfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.jsonl")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	for _, l := range []struct{ tag, profile string }{{"full", ""}, {"lite", "lite"}, {"lite", "lite"}} {
+		meta := &monitor.Meta{TimestampUTC: time.Now().UTC().Format(time.RFC3339Nano), RunTag: l.tag, SchemaVersion: monitor.SchemaVersion, SamplingProfile: l.profile}
+		b, _ := json.Marshal(&monitor.ResultEnvelope{Meta: meta, SiteResult: &monitor.SiteResult{TransferSpeedKbps: 1000}})
+		f.Write(append(b, '\n'))
+	}
+	f.Close()
+	sums, err := AnalyzeRecentResultsFull(path, monitor.SchemaVersion, 5, "")
+	if err != nil || len(sums) != 2 {
+		t.Fatalf("analyze: %v (n=%d)", err, len(sums))
+	}
+	for _, s := range sums {
+		want := ""
+		if s.RunTag == "lite" {
+			want = "lite"
+		}
+		if s.SamplingProfile != want {
+			t.Fatalf("%s: sampling profile %q, want %q", s.RunTag, s.SamplingProfile, want)
+		}
+	}
+}
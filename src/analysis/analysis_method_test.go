@@ -0,0 +1,44 @@
+package analysis
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/iafilius/InternetQualityMonitor/src/monitor"
+)
+
+func TestHTTPMethodSegmentation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.jsonl")
+	var data []byte
+	add := func(method string, speed float64, ttfb int64, httpErr string) {
+		env := monitor.ResultEnvelope{Meta: &monitor.Meta{TimestampUTC: time.Now().UTC().Format(time.RFC3339Nano), RunTag: "m1", SchemaVersion: monitor.SchemaVersion},
+			SiteResult: &monitor.SiteResult{URL: "http://x/", HTTPMethod: method, TransferSpeedKbps: speed, TraceTTFBMs: ttfb, TransferSizeBytes: 1000, HTTPError: httpErr}}
+		b, _ := json.Marshal(&env)
+		data = append(append(data, b...), '\n')
+	}
+	add("GET", 1000, 100, "")
+	add("GET", 3000, 300, "")
+	add("POST", 500, 50, "")
+	add("POST", 0, 0, "http_500")
+	add("", 2000, 200, "") // older line without http_method: not segmented
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	sums, err := AnalyzeRecentResultsFull(path, monitor.SchemaVersion, 5, "")
+	if err != nil || len(sums) != 1 {
+		t.Fatalf("analyze: %v %d", err, len(sums))
+	}
+	s := sums[0]
+	if s.HTTPMethodCounts["GET"] != 2 || s.HTTPMethodCounts["POST"] != 2 || len(s.HTTPMethodCounts) != 2 {
+		t.Fatalf("counts: %v", s.HTTPMethodCounts)
+	}
+	if s.AvgSpeedByHTTPMethodKbps["GET"] != 2000 || s.AvgSpeedByHTTPMethodKbps["POST"] != 500 {
+		t.Fatalf("speed by method: %v", s.AvgSpeedByHTTPMethodKbps)
+	}
+	if s.AvgTTFBByHTTPMethodMs["GET"] != 200 || s.ErrorRateByHTTPMethodPct["POST"] != 50 || s.ErrorRateByHTTPMethodPct["GET"] != 0 {
+		t.Fatalf("ttfb/error by method: %v %v", s.AvgTTFBByHTTPMethodMs, s.ErrorRateByHTTPMethodPct)
+	}
+}
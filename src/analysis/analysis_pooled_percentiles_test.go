@@ -0,0 +1,87 @@
+package analysis
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/iafilius/InternetQualityMonitor/src/monitor"
+)
+
+func TestPooledSpeedPercentiles_DifferFromAveragedPerLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.jsonl")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	// A long slow transfer (90 samples) and a short fast one (10 samples): averaging the two
+	// per-line P50s gives 5000, while the pooled P50 over all 100 samples is 1000.
+	write := func(family string, n int, kbps float64) {
+		samples := make([]monitor.SpeedSample, n)
+		for i := range samples {
+			samples[i] = monitor.SpeedSample{TimeMs: int64(i * 100), Bytes: int64(i+1) * 1000, Speed: kbps}
+		}
+		sa := &monitor.SpeedAnalysis{AverageKbps: kbps, P50Kbps: kbps, P90Kbps: kbps, P95Kbps: kbps, P99Kbps: kbps, SampleCount: n}
+		sr := &monitor.SiteResult{IPFamily: family, TransferSpeedKbps: kbps, TransferSizeBytes: int64(n) * 1000, TransferSpeedSamples: samples, SpeedAnalysis: sa}
+		env := monitor.ResultEnvelope{Meta: &monitor.Meta{TimestampUTC: time.Now().UTC().Format(time.RFC3339Nano), RunTag: "P1", SchemaVersion: monitor.SchemaVersion}, SiteResult: sr}
+		b, _ := json.Marshal(&env)
+		f.Write(append(b, '\n'))
+	}
+	write("ipv4", 90, 1000)
+	write("ipv6", 10, 9000)
+	f.Close()
+
+	sums, err := AnalyzeRecentResultsFull(path, monitor.SchemaVersion, 5, "")
+	if err != nil || len(sums) != 1 {
+		t.Fatalf("analyze: %v (n=%d)", err, len(sums))
+	}
+	s := sums[0]
+	if s.AvgP50Speed != 5000 {
+		t.Fatalf("averaged per-line P50=%v want 5000", s.AvgP50Speed)
+	}
+	// pooled values come from sketches: within the sketch's 1% relative accuracy
+	near := func(got, want float64) bool { return math.Abs(got-want) <= want*monitor.DefaultSketchAlpha }
+	if s.PooledSpeedSamples != 100 || !near(s.PooledP50Speed, 1000) || !near(s.PooledP90Speed, 1000) || !near(s.PooledP95Speed, 9000) || !near(s.PooledP99Speed, 9000) {
+		t.Fatalf("pooled n=%d p50=%v p90=%v p95=%v p99=%v", s.PooledSpeedSamples, s.PooledP50Speed, s.PooledP90Speed, s.PooledP95Speed, s.PooledP99Speed)
+	}
+	if s.IPv4 == nil || !near(s.IPv4.PooledP99Speed, 1000) || s.IPv6 == nil || !near(s.IPv6.PooledP50Speed, 9000) {
+		t.Fatalf("per-family pooled percentiles wrong: v4=%+v v6=%+v", s.IPv4, s.IPv6)
+	}
+}
+
+func TestMergeBatchSketches_MatchesPooledOverBothBatches(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.jsonl")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	// Lines carry the monitor's own speed_sketch (no raw samples needed).
+	for i, tag := range []string{"B1", "B2"} {
+		sk := monitor.NewSketch(monitor.DefaultSketchAlpha)
+		for j := 0; j < 50; j++ {
+			sk.Add(float64(1000 * (i + 1)))
+		}
+		sr := &monitor.SiteResult{IPFamily: "ipv4", TransferSpeedKbps: float64(1000 * (i + 1)), TraceTTFBMs: int64(20 * (i + 1)), SpeedAnalysis: &monitor.SpeedAnalysis{P50Kbps: float64(1000 * (i + 1))}, SpeedSketch: sk}
+		env := monitor.ResultEnvelope{Meta: &monitor.Meta{TimestampUTC: time.Now().Add(time.Duration(i) * time.Minute).UTC().Format(time.RFC3339Nano), RunTag: tag, SchemaVersion: monitor.SchemaVersion}, SiteResult: sr}
+		b, _ := json.Marshal(&env)
+		f.Write(append(b, '\n'))
+	}
+	f.Close()
+	sums, err := AnalyzeRecentResultsFull(path, monitor.SchemaVersion, 5, "")
+	if err != nil || len(sums) != 2 {
+		t.Fatalf("analyze: %v (n=%d)", err, len(sums))
+	}
+	speed, ttfb := MergeBatchSketches(sums)
+	if speed == nil || speed.Count != 100 || ttfb == nil || ttfb.Count != 2 {
+		t.Fatalf("merged sketches: speed=%+v ttfb=%+v", speed, ttfb)
+	}
+	if p := speed.Quantile(25); math.Abs(p-1000) > 10 {
+		t.Fatalf("merged P25=%v want ~1000", p)
+	}
+	if p := speed.Quantile(75); math.Abs(p-2000) > 20 {
+		t.Fatalf("merged P75=%v want ~2000", p)
+	}
+}
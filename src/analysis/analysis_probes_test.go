@@ -0,0 +1,156 @@
+package analysis
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/iafilius/InternetQualityMonitor/src/monitor"
+)
+
+func TestProbeLinesSummarizedSeparately(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.jsonl")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	write := func(tag string, sr *monitor.SiteResult) {
+		env := monitor.ResultEnvelope{
+			Meta:       &monitor.Meta{TimestampUTC: time.Now().UTC().Format(time.RFC3339Nano), RunTag: tag, SchemaVersion: monitor.SchemaVersion, Situation: "Home"},
+			SiteResult: sr,
+		}
+		b, _ := json.Marshal(&env)
+		f.Write(append(b, '\n'))
+	}
+	// pre-probe HTTP line (no probe_type) and a tagged one
+	write("A", &monitor.SiteResult{URL: "https://a/", IPFamily: "ipv4", TransferSpeedKbps: 1000})
+	write("A", &monitor.SiteResult{URL: "https://b/", IPFamily: "ipv4", TransferSpeedKbps: 3000, ProbeType: "http"})
+	write("A", &monitor.SiteResult{URL: "tcp://gw:22", IPFamily: "ipv4", ProbeType: "ping", Ping: &monitor.PingResult{Sent: 4, Received: 3, RTTsMs: []float64{10, 20, 30}}})
+	write("A", &monitor.SiteResult{URL: "tcp://gw6:22", IPFamily: "ipv6", ProbeType: "ping", ProbeError: "connection refused", Ping: &monitor.PingResult{Sent: 4}})
+	write("A", &monitor.SiteResult{URL: "https://a/", ProbeType: "dns", DNSTimeMs: 12})
+	write("A", &monitor.SiteResult{URL: "https://c/", ProbeType: "dns", ProbeError: "no such host"})
+	write("A", &monitor.SiteResult{URL: "https://a/", ProbeType: "custom"})
+	// a batch of ping lines only
+	write("B", &monitor.SiteResult{URL: "tcp://gw:22", IPFamily: "ipv4", ProbeType: "ping", Ping: &monitor.PingResult{Sent: 2, Received: 2, RTTsMs: []float64{5, 7}}})
+	f.Close()
+
+	sums, err := AnalyzeRecentResultsFull(path, monitor.SchemaVersion, 5, "")
+	if err != nil || len(sums) != 2 {
+		t.Fatalf("analyze: %v (n=%d)", err, len(sums))
+	}
+	a, b := sums[0], sums[1]
+	if a.Lines != 2 || a.AvgSpeed != 2000 || a.ErrorLines != 0 {
+		t.Fatalf("HTTP aggregates include probe lines: lines=%d avg=%.0f errors=%d", a.Lines, a.AvgSpeed, a.ErrorLines)
+	}
+	want := map[string]int{"http": 2, "ping": 2, "dns": 2, "custom": 1}
+	for k, v := range want {
+		if a.ProbeLines[k] != v {
+			t.Fatalf("probe_lines %v, want %v", a.ProbeLines, want)
+		}
+	}
+	if a.PingLines != 2 || a.AvgPingRTTMs != 20 || a.P95PingRTTMs != 30 || a.PingLossPct != 62.5 {
+		t.Fatalf("ping: lines=%d avg=%.1f p95=%.1f loss=%.1f", a.PingLines, a.AvgPingRTTMs, a.P95PingRTTMs, a.PingLossPct)
+	}
+	// jitter of 10,20,30 is seeded with |D| = 10 and stays there; the failed line has no RTTs and
+	// is left out
+	if wantJ := 10.0; a.PingJitterLines != 1 || a.AvgPingJitterMs != wantJ || a.P95PingJitterMs != wantJ {
+		t.Fatalf("ping jitter: lines=%d avg=%.4f p95=%.4f want %.4f", a.PingJitterLines, a.AvgPingJitterMs, a.P95PingJitterMs, wantJ)
+	}
+	if a.DNSProbeLines != 2 || a.AvgDNSProbeMs != 12 || a.DNSProbeErrorRatePct != 50 {
+		t.Fatalf("dns: lines=%d avg=%.1f err=%.1f", a.DNSProbeLines, a.AvgDNSProbeMs, a.DNSProbeErrorRatePct)
+	}
+	if b.Lines != 0 || b.PingLines != 1 || b.AvgPingRTTMs != 6 || b.PingLossPct != 0 || b.Situation != "Home" || b.ProbeLines["http"] != 0 {
+		t.Fatalf("ping-only batch: %+v", b)
+	}
+}
+
+func TestSoakDropsAndStability(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.jsonl")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	var kbps []float64
+	add := func(n int, v float64) {
+		for i := 0; i < n; i++ {
+			kbps = append(kbps, v)
+		}
+	}
+	// outage of 3s, one slow second (too short to count), then 2s at 30%
+	add(10, 1000)
+	add(3, 0)
+	add(5, 1000)
+	add(1, 100)
+	add(5, 1000)
+	add(2, 300)
+	env := monitor.ResultEnvelope{
+		Meta: &monitor.Meta{TimestampUTC: time.Now().UTC().Format(time.RFC3339Nano), RunTag: "S", SchemaVersion: monitor.SchemaVersion},
+		SiteResult: &monitor.SiteResult{URL: "https://big/file", ProbeType: "soak", Soak: &monitor.SoakResult{
+			IntervalMs: 1000, SamplesKbps: kbps, Requests: 2, Interruptions: 1,
+		}},
+	}
+	b, _ := json.Marshal(&env)
+	f.Write(append(b, '\n'))
+	f.Close()
+
+	sums, err := AnalyzeRecentResultsFull(path, monitor.SchemaVersion, 5, "")
+	if err != nil || len(sums) != 1 {
+		t.Fatalf("analyze: %v (n=%d)", err, len(sums))
+	}
+	s := sums[0]
+	if s.Lines != 0 || s.SoakLines != 1 || s.SoakSeconds != 26 || s.MedianSoakKbps != 1000 || s.P5SoakKbps != 0 || s.SoakInterruptions != 1 {
+		t.Fatalf("soak: %+v", s)
+	}
+	if s.SoakDropEvents != 2 || s.SoakLongestDropS != 3 || math.Abs(s.SoakTimeInDropPct-5.0/26*100) > 1e-9 || math.Abs(s.SoakDropsPerHour-2/(26.0/3600)) > 1e-9 {
+		t.Fatalf("drops: events=%d longest=%.1f in-drop=%.2f%% per-hour=%.1f", s.SoakDropEvents, s.SoakLongestDropS, s.SoakTimeInDropPct, s.SoakDropsPerHour)
+	}
+	if s.SoakCoVPct <= 0 || s.AvgSoakKbps >= 1000 {
+		t.Fatalf("stability: cov=%.1f avg=%.1f", s.SoakCoVPct, s.AvgSoakKbps)
+	}
+}
+
+func TestIPerf3Rollup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.jsonl")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	write := func(sr *monitor.SiteResult) {
+		env := monitor.ResultEnvelope{
+			Meta:       &monitor.Meta{TimestampUTC: time.Now().UTC().Format(time.RFC3339Nano), RunTag: "I", SchemaVersion: monitor.SchemaVersion},
+			SiteResult: sr,
+		}
+		b, _ := json.Marshal(&env)
+		f.Write(append(b, '\n'))
+	}
+	write(&monitor.SiteResult{URL: "https://a/", IPFamily: "ipv4", TransferSpeedKbps: 45000})
+	write(&monitor.SiteResult{URL: "iperf.example.net", ProbeType: "iperf3", IPerf3: &monitor.IPerf3Result{
+		TCP: &monitor.IPerf3TCP{ReceivedKbps: 100000, Retransmits: 30},
+		UDP: &monitor.IPerf3UDP{Kbps: 49000, JitterMs: 0.5, LostPackets: 10, Packets: 1000},
+	}})
+	// UDP filtered on the second server: its TCP result counts, its UDP test does not
+	write(&monitor.SiteResult{URL: "iperf2.example.net", ProbeType: "iperf3", IPerf3: &monitor.IPerf3Result{
+		TCP: &monitor.IPerf3TCP{ReceivedKbps: 80000, Retransmits: 2},
+		UDP: &monitor.IPerf3UDP{Error: "iperf3: unable to receive control message"},
+	}})
+	write(&monitor.SiteResult{URL: "down.example.net", ProbeType: "iperf3", ProbeError: "iperf3: unable to connect to server", IPerf3: &monitor.IPerf3Result{}})
+	f.Close()
+
+	sums, err := AnalyzeRecentResultsFull(path, monitor.SchemaVersion, 5, "")
+	if err != nil || len(sums) != 1 {
+		t.Fatalf("analyze: %v (n=%d)", err, len(sums))
+	}
+	s := sums[0]
+	if s.Lines != 1 || s.IPerf3Lines != 3 || s.ProbeLines["iperf3"] != 3 || math.Abs(s.IPerf3ErrorRatePct-100.0/3) > 1e-9 {
+		t.Fatalf("lines: http=%d iperf3=%d err=%.1f", s.Lines, s.IPerf3Lines, s.IPerf3ErrorRatePct)
+	}
+	if s.AvgIPerf3TCPKbps != 90000 || s.MedianIPerf3TCPKbps != 80000 || s.IPerf3Retransmits != 32 || s.IPerf3HTTPSpeedPct != 50 {
+		t.Fatalf("tcp: avg=%.0f median=%.0f retrans=%d http=%.1f%%", s.AvgIPerf3TCPKbps, s.MedianIPerf3TCPKbps, s.IPerf3Retransmits, s.IPerf3HTTPSpeedPct)
+	}
+	if s.IPerf3UDPLines != 1 || s.AvgIPerf3UDPKbps != 49000 || s.AvgIPerf3UDPJitterMs != 0.5 || s.IPerf3UDPLossPct != 1 {
+		t.Fatalf("udp: lines=%d kbps=%.0f jitter=%.2f loss=%.2f", s.IPerf3UDPLines, s.AvgIPerf3UDPKbps, s.AvgIPerf3UDPJitterMs, s.IPerf3UDPLossPct)
+	}
+}
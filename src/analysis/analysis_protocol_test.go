@@ -141,3 +141,44 @@ func TestProtocolTlsAlpnChunkedAggregations(t *testing.T) {
 		t.Fatalf("chunked rate got %.3f want ~66.667", b.ChunkedRatePct)
 	}
 }
+
+func TestTTFBByHTTPProtocol(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "results.jsonl")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	defer f.Close()
+	ts := time.Now().UTC().Format(time.RFC3339Nano)
+	write := func(proto string, ttfb int64) {
+		sr := &monitor.SiteResult{Name: "a", TransferSpeedKbps: 1000, HTTPProtocol: proto, TraceTTFBMs: ttfb}
+		writeEnvLine(t, f, monitor.ResultEnvelope{Meta: &monitor.Meta{TimestampUTC: ts, RunTag: "P1", SchemaVersion: monitor.SchemaVersion}, SiteResult: sr})
+	}
+	for _, v := range []int64{10, 20, 30, 40, 200} {
+		write("HTTP/2.0", v)
+	}
+	write("HTTP/1.1", 100)
+	write("HTTP/1.1", 300)
+	write("HTTP/1.1", 0) // no TTFB: excluded from latency stats
+	sums, err := AnalyzeRecentResultsFull(path, monitor.SchemaVersion, 5, "")
+	if err != nil || len(sums) != 1 {
+		t.Fatalf("analyze: %v (n=%d)", err, len(sums))
+	}
+	b := sums[0]
+	if v := b.AvgTTFBByHTTPProtocolMs["HTTP/2.0"]; math.Abs(v-60) > 1e-9 {
+		t.Fatalf("avg ttfb h2 got %.2f want 60", v)
+	}
+	if v := b.P50TTFBByHTTPProtocolMs["HTTP/2.0"]; v != 30 {
+		t.Fatalf("p50 ttfb h2 got %.2f want 30", v)
+	}
+	if v := b.P95TTFBByHTTPProtocolMs["HTTP/2.0"]; v != 200 {
+		t.Fatalf("p95 ttfb h2 got %.2f want 200", v)
+	}
+	if v := b.AvgTTFBByHTTPProtocolMs["HTTP/1.1"]; math.Abs(v-200) > 1e-9 {
+		t.Fatalf("avg ttfb h1.1 got %.2f want 200", v)
+	}
+	if v := b.P50TTFBByHTTPProtocolMs["HTTP/1.1"]; v != 100 {
+		t.Fatalf("p50 ttfb h1.1 got %.2f want 100", v)
+	}
+}
@@ -0,0 +1,46 @@
+package analysis
+
+import (
+	"math"
+	"testing"
+)
+
+func TestQualityScore(t *testing.T) {
+	c := DefaultQualityScoreConfig
+	// 12.5 Mbps median, 400 ms TTFB, 4% jitter, 10% stalls, 1 of 20 lines failed
+	s := &BatchSummary{Lines: 20, ErrorLines: 1, AvgSpeed: 14000, MedianSpeed: 12500, AvgTTFB: 400, AvgJitterPct: 4, StallRatePct: 10}
+	q := qualityScore(s, c)
+	if q.SpeedPct != 50 || q.TTFBPct != 50 || q.JitterPct != 80 || q.StallPct != 80 || q.ErrorPct != 90 {
+		t.Fatalf("components: %+v", q)
+	}
+	if want := (30*50 + 25*50 + 15*80 + 15*80 + 15*90) / 100.0; math.Abs(q.Score-want) > 0.01 {
+		t.Fatalf("score %.2f want %.2f", q.Score, want)
+	}
+	// fast, clean batch scores 100
+	if q := qualityScore(&BatchSummary{Lines: 5, MedianSpeed: 100000, AvgTTFB: 50}, c); q.Score != 100 {
+		t.Fatalf("perfect batch: %+v", q)
+	}
+	// total outage: no jitter component, speed and TTFB count as 0
+	q = qualityScore(&BatchSummary{Lines: 4, ErrorLines: 4}, c)
+	if want := 15 * 100 / 85.0; q.SpeedPct != 0 || q.ErrorPct != 0 || math.Abs(q.Score-want) > 0.01 {
+		t.Fatalf("outage: %+v want score %.2f", q, want)
+	}
+	if qualityScore(&BatchSummary{}, c) != nil {
+		t.Fatalf("probe-only batch must have no score")
+	}
+}
+
+func TestParseQualityScoreConfig(t *testing.T) {
+	c, err := ParseQualityScoreConfig(" speed=50, errors=0 ,speed_ref_kbps=100000")
+	if err != nil || c.SpeedWeight != 50 || c.ErrorWeight != 0 || c.SpeedRefKbps != 100000 || c.TTFBWeight != DefaultQualityScoreConfig.TTFBWeight {
+		t.Fatalf("parse: %+v %v", c, err)
+	}
+	if back, err := ParseQualityScoreConfig(c.String()); err != nil || back != c {
+		t.Fatalf("round trip: %+v %v", back, err)
+	}
+	for _, bad := range []string{"speed", "speed=-1", "latency=3", "ttfb_ref_ms=0", "speed=0,ttfb=0,jitter=0,stall=0,errors=0"} {
+		if _, err := ParseQualityScoreConfig(bad); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
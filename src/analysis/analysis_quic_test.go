@@ -0,0 +1,47 @@
+package analysis
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/iafilius/InternetQualityMonitor/src/monitor"
+)
+
+func TestQUICRollup_UDPBlockedRate(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "results.jsonl")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	write := func(fam string, qp *monitor.QUICProbe, h3 bool) {
+		env := monitor.ResultEnvelope{Meta: &monitor.Meta{TimestampUTC: time.Now().UTC().Format(time.RFC3339Nano), RunTag: "Q1", SchemaVersion: monitor.SchemaVersion}, SiteResult: &monitor.SiteResult{IPFamily: fam, TransferSpeedKbps: 1000, QUICProbe: qp, AltSvcH3: h3}}
+		b, _ := json.Marshal(&env)
+		f.Write(append(b, '\n'))
+	}
+	write("ipv4", &monitor.QUICProbe{Port: 443, Attempts: 2, UDPBlocked: true}, true)
+	write("ipv4", &monitor.QUICProbe{Port: 443, Attempts: 1, Responded: true, Versions: []string{"v1"}}, true)
+	write("ipv6", &monitor.QUICProbe{Port: 443, Attempts: 2, UDPBlocked: true}, false)
+	write("ipv6", &monitor.QUICProbe{Port: 443, Attempts: 2, UDPBlocked: true}, true)
+	write("ipv4", nil, false) // probe disabled / plain http
+	f.Close()
+
+	sums, err := AnalyzeRecentResultsFull(path, monitor.SchemaVersion, 5, "")
+	if err != nil || len(sums) != 1 {
+		t.Fatalf("analyze: %v (n=%d)", err, len(sums))
+	}
+	b := sums[0]
+	// the ipv6 line of a site without h3 is no evidence of blocking
+	if b.QUICProbeLines != 3 || b.UDPBlockedLines != 2 || b.UDPBlockedRatePct < 66.6 || b.UDPBlockedRatePct > 66.7 || b.QUICNoListenerLines != 1 {
+		t.Fatalf("probed=%d blocked=%d rate=%.1f%% no-listener=%d want 3/2/66.7%%/1", b.QUICProbeLines, b.UDPBlockedLines, b.UDPBlockedRatePct, b.QUICNoListenerLines)
+	}
+	if b.IPv4 == nil || b.IPv4.QUICProbeLines != 2 || b.IPv4.UDPBlockedRatePct != 50 {
+		t.Fatalf("ipv4 family: %+v", b.IPv4)
+	}
+	if b.IPv6 == nil || b.IPv6.QUICProbeLines != 1 || b.IPv6.UDPBlockedRatePct != 100 {
+		t.Fatalf("ipv6 family: %+v", b.IPv6)
+	}
+}
@@ -0,0 +1,52 @@
+package analysis
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/iafilius/InternetQualityMonitor/src/monitor"
+)
+
+func TestRateLimitedRate_SeparateFromNetworkErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.jsonl")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	write := func(sr *monitor.SiteResult) {
+		env := monitor.ResultEnvelope{Meta: &monitor.Meta{TimestampUTC: time.Now().UTC().Format(time.RFC3339Nano), RunTag: "RL1", SchemaVersion: monitor.SchemaVersion}, SiteResult: sr}
+		b, _ := json.Marshal(env)
+		f.Write(append(b, '\n'))
+	}
+	write(&monitor.SiteResult{IPFamily: "ipv4", HTTPError: `rate_limited: status=429 retry_after="60"`,
+		RateLimited: &monitor.RateLimit{Status: 429, Request: "get", RetryAfter: "60", RetryAfterSec: 60}})
+	write(&monitor.SiteResult{IPFamily: "ipv4", HTTPError: `rate_limited: status=503 retry_after="20"`,
+		RateLimited: &monitor.RateLimit{Status: 503, Request: "get", RetryAfter: "20", RetryAfterSec: 20}})
+	// throttled HEAD but the measured GET went through: counted as rate limited, not as an error
+	write(&monitor.SiteResult{IPFamily: "ipv6", TransferSpeedKbps: 900, TransferSizeBytes: 1024, HeadStatus: 429,
+		RateLimited: &monitor.RateLimit{Status: 429, Request: "head"}})
+	write(&monitor.SiteResult{IPFamily: "ipv6", HTTPError: "read: connection reset by peer"})
+	f.Close()
+
+	sums, err := AnalyzeRecentResultsFull(path, monitor.SchemaVersion, 5, "")
+	if err != nil || len(sums) != 1 {
+		t.Fatalf("analyze: %v (%d batches)", err, len(sums))
+	}
+	s := sums[0]
+	if s.RateLimitedLines != 3 || s.RateLimitedRatePct != 75 || s.AvgRetryAfterSec != 40 {
+		t.Fatalf("lines=%d rate=%.1f retry=%.1f; want 3, 75, 40", s.RateLimitedLines, s.RateLimitedRatePct, s.AvgRetryAfterSec)
+	}
+	if s.IPv4 == nil || s.IPv4.RateLimitedRatePct != 100 || s.IPv6 == nil || s.IPv6.RateLimitedRatePct != 50 {
+		t.Fatalf("per family: %+v / %+v", s.IPv4, s.IPv6)
+	}
+	// two throttled GETs and one reset out of three errors
+	if abs(s.ErrorShareByTypePct["rate_limit"]-200.0/3) > 1e-9 || abs(s.ErrorShareByTypePct["http"]-100.0/3) > 1e-9 {
+		t.Fatalf("error types: %v", s.ErrorShareByTypePct)
+	}
+	if abs(s.ErrorShareByReasonPct["rate_limited"]-200.0/3) > 1e-9 {
+		t.Fatalf("error reasons: %v", s.ErrorShareByReasonPct)
+	}
+}
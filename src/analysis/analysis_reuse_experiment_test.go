@@ -0,0 +1,41 @@
+package analysis
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/iafilius/InternetQualityMonitor/src/monitor"
+)
+
+func TestReuseExperimentRollup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.jsonl")
+	var data []byte
+	add := func(fam string, ex *monitor.ReuseExperiment) {
+		env := monitor.ResultEnvelope{Meta: &monitor.Meta{TimestampUTC: time.Now().UTC().Format(time.RFC3339Nano), RunTag: "r1", SchemaVersion: monitor.SchemaVersion},
+			SiteResult: &monitor.SiteResult{URL: "http://x/", IPFamily: fam, TransferSpeedKbps: 1000, TransferSizeBytes: 1000, ReuseExperiment: ex}}
+		b, _ := json.Marshal(&env)
+		data = append(append(data, b...), '\n')
+	}
+	add("ipv4", &monitor.ReuseExperiment{ColdTTFBMs: 120, WarmTTFBMs: 40, WarmReused: true, SetupCostMs: 80})
+	add("ipv6", &monitor.ReuseExperiment{ColdTTFBMs: 200, WarmTTFBMs: 60, WarmReused: true, SetupCostMs: 140})
+	add("ipv4", &monitor.ReuseExperiment{ColdTTFBMs: 500, WarmTTFBMs: 50, WarmReused: false}) // warm dialled anew: not a fair pair
+	add("ipv4", &monitor.ReuseExperiment{ColdError: "timeout"})
+	add("ipv4", nil)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	sums, err := AnalyzeRecentResultsFull(path, monitor.SchemaVersion, 5, "")
+	if err != nil || len(sums) != 1 {
+		t.Fatalf("analyze: %v %d", err, len(sums))
+	}
+	s := sums[0]
+	if s.ReuseExperimentLines != 2 || s.AvgColdTTFBMs != 160 || s.AvgWarmTTFBMs != 50 || s.AvgSetupCostMs != 110 || s.P50SetupCostMs != 80 {
+		t.Fatalf("rollup: lines=%d cold=%v warm=%v setup=%v p50=%v", s.ReuseExperimentLines, s.AvgColdTTFBMs, s.AvgWarmTTFBMs, s.AvgSetupCostMs, s.P50SetupCostMs)
+	}
+	if s.IPv4 == nil || s.IPv4.AvgColdTTFBMs != 120 || s.IPv6 == nil || s.IPv6.AvgWarmTTFBMs != 60 {
+		t.Fatalf("family rollup: %+v %+v", s.IPv4, s.IPv6)
+	}
+}
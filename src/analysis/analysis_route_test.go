@@ -0,0 +1,109 @@
+package analysis
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/iafilius/InternetQualityMonitor/src/monitor"
+)
+
+func TestRouteComparisons(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.jsonl")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	write := func(url, fam, ip string, asn uint, rp *monitor.RoutePath) {
+		env := monitor.ResultEnvelope{
+			Meta:       &monitor.Meta{TimestampUTC: time.Now().UTC().Format(time.RFC3339Nano), RunTag: "A", SchemaVersion: monitor.SchemaVersion, PublicIPv4ASNNumber: 64496, PublicIPv4ASNOrg: "Acme", PublicIPv6ASNNumber: 64497, PublicIPv6ASNOrg: "Tunnel Broker"},
+			SiteResult: &monitor.SiteResult{URL: url, IPFamily: fam, ResolvedIP: ip, ASNNumber: asn, TransferSpeedKbps: 1000, RoutePath: rp},
+		}
+		b, _ := json.Marshal(&env)
+		f.Write(append(b, '\n'))
+	}
+	hops := func(n int) []monitor.RouteHop {
+		out := make([]monitor.RouteHop, n)
+		for i := range out {
+			out[i].TTL = i + 1
+		}
+		return out
+	}
+	// traced site: the IPv6 path transits a different AS
+	write("https://a.example/", "ipv4", "192.0.2.1", 64510, nil)
+	write("https://a.example/", "ipv4", "192.0.2.1", 64510, &monitor.RoutePath{Target: "192.0.2.1", Hops: hops(8), ASPath: []uint{64500, 64510}})
+	write("https://a.example/", "ipv6", "2001:db8::1", 64510, &monitor.RoutePath{Target: "2001:db8::1", Hops: hops(12), ASPath: []uint{64501, 64510}})
+	// untraced site: destination ASNs only, same on both families
+	write("https://b.example/", "ipv4", "198.51.100.1", 64520, nil)
+	write("https://b.example/", "ipv6", "2001:db8:b::1", 64520, nil)
+	// single-family site is not compared
+	write("https://c.example/", "ipv4", "203.0.113.1", 64530, nil)
+	f.Close()
+
+	sums, err := AnalyzeRecentResultsFull(path, monitor.SchemaVersion, 5, "")
+	if err != nil || len(sums) != 1 {
+		t.Fatalf("analyze: %v (n=%d)", err, len(sums))
+	}
+	s := sums[0]
+	if len(s.RouteComparisons) != 2 || s.RouteDifferSites != 1 {
+		t.Fatalf("comparisons %+v, differ=%d", s.RouteComparisons, s.RouteDifferSites)
+	}
+	a, b := s.RouteComparisons[0], s.RouteComparisons[1]
+	if a.URL != "https://a.example/" || a.Basis != "as_path" || !a.Differs || a.IPv4Hops != 8 || a.IPv6Hops != 12 || a.IPv6 != "2001:db8::1" {
+		t.Fatalf("site a: %+v", a)
+	}
+	if b.Basis != "dest_asn" || b.Differs || b.IPv4ASN != 64520 {
+		t.Fatalf("site b: %+v", b)
+	}
+	if s.EgressIPv4ASNOrg != "Acme" || s.EgressIPv6ASN != 64497 {
+		t.Fatalf("egress: v4 %d %q, v6 %d", s.EgressIPv4ASN, s.EgressIPv4ASNOrg, s.EgressIPv6ASN)
+	}
+}
+
+func TestReturnHopsAndChanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.jsonl")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	write := func(tag, url, fam string, rt *monitor.ResponseTTL) {
+		env := monitor.ResultEnvelope{
+			Meta:       &monitor.Meta{TimestampUTC: time.Now().UTC().Format(time.RFC3339Nano), RunTag: tag, SchemaVersion: monitor.SchemaVersion},
+			SiteResult: &monitor.SiteResult{URL: url, IPFamily: fam, TransferSpeedKbps: 1000, ResponseTTL: rt},
+		}
+		b, _ := json.Marshal(&env)
+		f.Write(append(b, '\n'))
+	}
+	ttl := func(hops int, asym bool) *monitor.ResponseTTL {
+		return &monitor.ResponseTTL{TTL: 64 - hops, InitialTTL: 64, Hops: hops, Asymmetric: asym}
+	}
+	write("20260101_000000", "https://a.example/", "ipv4", ttl(10, false))
+	write("20260101_000000", "https://a.example/", "ipv6", ttl(14, true))
+	write("20260101_000000", "https://b.example/", "ipv4", nil)
+	write("20260101_001000", "https://a.example/", "ipv4", ttl(11, false)) // ±1: equal-cost paths
+	write("20260101_001000", "https://a.example/", "ipv6", ttl(9, false))
+	write("20260101_002000", "https://b.example/", "ipv4", &monitor.ResponseTTL{Error: "no echo reply"})
+	write("20260101_003000", "https://a.example/", "ipv6", ttl(9, false))
+	f.Close()
+
+	sums, err := AnalyzeRecentResultsFull(path, monitor.SchemaVersion, 10, "")
+	if err != nil || len(sums) != 4 {
+		t.Fatalf("analyze: %v (n=%d)", err, len(sums))
+	}
+	first := sums[0].ReturnHops
+	if first == nil || first.Lines != 2 || first.AvgHops != 12 || first.AvgHopsIPv4 != 10 || first.AvgHopsIPv6 != 14 || first.AsymmetricLines != 1 || len(first.Changes) != 0 {
+		t.Fatalf("first batch: %+v", first)
+	}
+	second := sums[1].ReturnHops
+	if second == nil || len(second.Changes) != 1 || second.Changes[0] != (HopChange{Target: "https://a.example/ ipv6", From: 14, To: 9}) {
+		t.Fatalf("second batch: %+v", second)
+	}
+	if sums[2].ReturnHops != nil {
+		t.Fatalf("a failed echo is no measurement: %+v", sums[2].ReturnHops)
+	}
+	if rh := sums[3].ReturnHops; rh == nil || len(rh.Changes) != 0 {
+		t.Fatalf("unchanged target: %+v", rh)
+	}
+}
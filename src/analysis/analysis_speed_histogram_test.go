@@ -0,0 +1,33 @@
+package analysis
+
+import (
+	"math"
+	"testing"
+
+	"github.com/iafilius/InternetQualityMonitor/src/monitor"
+)
+
+func TestHistogramModes(t *testing.T) {
+	bimodal := monitor.NewSketch(0)
+	unimodal := monitor.NewSketch(0)
+	for i := 0; i < 200; i++ {
+		// slow path around 3 Mbps, fast path around 60 Mbps, ±10%
+		jitter := 0.9 + 0.2*float64(i%10)/9
+		if i%3 == 0 {
+			bimodal.Add(3000 * jitter)
+		} else {
+			bimodal.Add(60000 * jitter)
+		}
+		unimodal.Add(20000 * jitter)
+	}
+	modes := HistogramModes(bimodal.Histogram(0))
+	if len(modes) != 2 || math.Abs(modes[0]-3000)/3000 > 0.2 || math.Abs(modes[1]-60000)/60000 > 0.2 {
+		t.Fatalf("bimodal modes: %v", modes)
+	}
+	if modes := HistogramModes(unimodal.Histogram(0)); len(modes) != 1 {
+		t.Fatalf("unimodal modes: %v", modes)
+	}
+	if HistogramModes(nil) != nil {
+		t.Fatalf("nil histogram has no modes")
+	}
+}
@@ -0,0 +1,44 @@
+package analysis
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/iafilius/InternetQualityMonitor/src/monitor"
+)
+
+func TestBatchTags(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.jsonl")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	write := func(tag string, tags map[string]string) {
+		env := monitor.ResultEnvelope{Meta: &monitor.Meta{TimestampUTC: time.Now().UTC().Format(time.RFC3339Nano), RunTag: tag, SchemaVersion: monitor.SchemaVersion, Tags: tags}, SiteResult: &monitor.SiteResult{TransferSpeedKbps: 1000}}
+		b, _ := json.Marshal(&env)
+		f.Write(append(b, '\n'))
+	}
+	write("A", map[string]string{"isp": "Acme", "router_fw": "1.2.3"})
+	write("A", map[string]string{"isp": "Other", "site": "attic"}) // first value per key wins
+	write("B", nil)
+	f.Close()
+
+	sums, err := AnalyzeRecentResultsFull(path, monitor.SchemaVersion, 5, "")
+	if err != nil || len(sums) != 2 {
+		t.Fatalf("analyze: %v (n=%d)", err, len(sums))
+	}
+	byTag := map[string]BatchSummary{}
+	for _, s := range sums {
+		byTag[s.RunTag] = s
+	}
+	a := byTag["A"].Tags
+	if len(a) != 3 || a["isp"] != "Acme" || a["router_fw"] != "1.2.3" || a["site"] != "attic" {
+		t.Fatalf("batch A tags = %v", a)
+	}
+	if byTag["B"].Tags != nil {
+		t.Fatalf("batch B should have no tags: %v", byTag["B"].Tags)
+	}
+}
@@ -0,0 +1,50 @@
+package analysis
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/iafilius/InternetQualityMonitor/src/monitor"
+)
+
+func TestStartTimeRunTagIsUTC(t *testing.T) {
+	got := BatchSummary{RunTag: "20260329_003000_i2"}.StartTime()
+	if want := time.Date(2026, 3, 29, 0, 30, 0, 0, time.UTC); !got.Equal(want) {
+		t.Fatalf("StartTime = %v, want %v", got, want)
+	}
+	if (BatchSummary{RunTag: "x"}).RecordedLocation() != nil {
+		t.Fatalf("no offset recorded means no location")
+	}
+	loc := BatchSummary{UTCOffset: "+02:00", TimeZone: "CEST"}.RecordedLocation()
+	if name, off := got.In(loc).Zone(); name != "CEST" || off != 7200 {
+		t.Fatalf("zone = %s %d", name, off)
+	}
+}
+
+// A batch running across the spring-forward change keeps the offset of its first line.
+func TestBatchUTCOffsetFromEarliestLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.jsonl")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	write := func(ts time.Time, off, tz string) {
+		env := monitor.ResultEnvelope{Meta: &monitor.Meta{TimestampUTC: ts.Format(time.RFC3339Nano), UTCOffset: off, TimeZone: tz, RunTag: "20260329_005900", SchemaVersion: monitor.SchemaVersion}, SiteResult: &monitor.SiteResult{TransferSpeedKbps: 1000}}
+		b, _ := json.Marshal(&env)
+		f.Write(append(b, '\n'))
+	}
+	start := time.Date(2026, 3, 29, 0, 59, 0, 0, time.UTC) // 01:59 CET, a minute before 03:00 CEST
+	write(start.Add(2*time.Minute), "+02:00", "CEST")
+	write(start, "+01:00", "CET")
+	f.Close()
+	sums, err := AnalyzeRecentResultsFull(path, monitor.SchemaVersion, 5, "")
+	if err != nil || len(sums) != 1 {
+		t.Fatalf("analyze: %v (n=%d)", err, len(sums))
+	}
+	if s := sums[0]; s.UTCOffset != "+01:00" || s.TimeZone != "CET" || !s.StartTime().Equal(start) {
+		t.Fatalf("batch: offset=%q tz=%q start=%v", s.UTCOffset, s.TimeZone, s.StartTime())
+	}
+}
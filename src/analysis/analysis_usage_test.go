@@ -0,0 +1,49 @@
+package analysis
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/iafilius/InternetQualityMonitor/src/monitor"
+)
+
+func TestBatchDataUsage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.jsonl")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	write := func(tag string, rx, tx int64, du *monitor.DataUsage) {
+		meta := &monitor.Meta{TimestampUTC: time.Now().UTC().Format(time.RFC3339Nano), RunTag: tag, SchemaVersion: monitor.SchemaVersion, DataUsage: du}
+		env := monitor.ResultEnvelope{Meta: meta, SiteResult: &monitor.SiteResult{TransferSpeedKbps: 1000, WireRxBytes: rx, WireTxBytes: tx}}
+		b, _ := json.Marshal(&env)
+		f.Write(append(b, '\n'))
+	}
+	write("old", 0, 0, nil)
+	// parallel lines land out of order: the largest running total is the batch's last
+	write("b1", 3000, 100, &monitor.DataUsage{Day: "2026-05-20", DayRxBytes: 5000, DayTxBytes: 200, CycleRxBytes: 9000, CycleTxBytes: 300, BudgetBytes: 10000, BudgetAction: monitor.BudgetPolicyReduce})
+	write("b1", 2000, 100, &monitor.DataUsage{Day: "2026-05-20", DayRxBytes: 2000, DayTxBytes: 100, CycleRxBytes: 6000, CycleTxBytes: 200, BudgetBytes: 10000, BudgetAction: monitor.BudgetPolicyReduce})
+	f.Close()
+
+	sums, err := AnalyzeRecentResultsFull(path, monitor.SchemaVersion, 5, "")
+	if err != nil || len(sums) != 2 {
+		t.Fatalf("analyze: %v (n=%d)", err, len(sums))
+	}
+	byTag := map[string]BatchSummary{}
+	for _, s := range sums {
+		byTag[s.RunTag] = s
+	}
+	if s := byTag["old"]; s.WireRxBytes != 0 || s.UsageDay != "" || s.BudgetBytes != 0 {
+		t.Fatalf("batch without usage: rx=%d day=%q budget=%d", s.WireRxBytes, s.UsageDay, s.BudgetBytes)
+	}
+	s := byTag["b1"]
+	if s.WireRxBytes != 5000 || s.WireTxBytes != 200 {
+		t.Fatalf("batch bytes: rx=%d tx=%d", s.WireRxBytes, s.WireTxBytes)
+	}
+	if s.UsageDay != "2026-05-20" || s.DayRxBytes != 5000 || s.CycleRxBytes != 9000 || s.BudgetBytes != 10000 || s.BudgetAction != monitor.BudgetPolicyReduce {
+		t.Fatalf("usage totals: day=%q dayRx=%d cycleRx=%d budget=%d action=%q", s.UsageDay, s.DayRxBytes, s.CycleRxBytes, s.BudgetBytes, s.BudgetAction)
+	}
+}
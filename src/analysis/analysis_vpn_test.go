@@ -0,0 +1,44 @@
+package analysis
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/iafilius/InternetQualityMonitor/src/monitor"
+)
+
+func TestBatchVPNState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.jsonl")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	write := func(tag string, vpn bool, name string) {
+		env := monitor.ResultEnvelope{Meta: &monitor.Meta{TimestampUTC: time.Now().UTC().Format(time.RFC3339Nano), RunTag: tag, SchemaVersion: monitor.SchemaVersion, VPNActive: vpn, VPNName: name}, SiteResult: &monitor.SiteResult{TransferSpeedKbps: 1000}}
+		b, _ := json.Marshal(&env)
+		f.Write(append(b, '\n'))
+	}
+	write("A", false, "")
+	write("A", false, "")
+	write("B", false, "")
+	write("B", true, "WireGuard") // tunnel came up mid-batch
+	f.Close()
+
+	sums, err := AnalyzeRecentResultsFull(path, monitor.SchemaVersion, 5, "")
+	if err != nil || len(sums) != 2 {
+		t.Fatalf("analyze: %v (n=%d)", err, len(sums))
+	}
+	byTag := map[string]BatchSummary{}
+	for _, s := range sums {
+		byTag[s.RunTag] = s
+	}
+	if byTag["A"].VPNActive || byTag["A"].VPNName != "" {
+		t.Fatalf("batch A should be off VPN: %+v", byTag["A"].VPNActive)
+	}
+	if !byTag["B"].VPNActive || byTag["B"].VPNName != "WireGuard" {
+		t.Fatalf("batch B: active=%v name=%q", byTag["B"].VPNActive, byTag["B"].VPNName)
+	}
+}
@@ -0,0 +1,41 @@
+package analysis
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/iafilius/InternetQualityMonitor/src/monitor"
+)
+
+func TestWiFiRollup_AveragesAndLatestIdentity(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "results.jsonl")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	write := func(wi *monitor.WiFiInfo) {
+		env := monitor.ResultEnvelope{Meta: &monitor.Meta{TimestampUTC: time.Now().UTC().Format(time.RFC3339Nano), RunTag: "W1", SchemaVersion: monitor.SchemaVersion, WiFi: wi}, SiteResult: &monitor.SiteResult{TransferSpeedKbps: 1000}}
+		b, _ := json.Marshal(&env)
+		f.Write(append(b, '\n'))
+	}
+	write(&monitor.WiFiInfo{SSID: "A", BSSID: "aa", RSSIDBm: -50, PHYRateMbps: 400, Channel: 36})
+	write(nil) // wired line (or probe failed): ignored for averages
+	write(&monitor.WiFiInfo{SSID: "B", BSSID: "bb", RSSIDBm: -70, PHYRateMbps: 200, Channel: 6})
+	f.Close()
+
+	sums, err := AnalyzeRecentResultsFull(path, monitor.SchemaVersion, 5, "")
+	if err != nil || len(sums) != 1 {
+		t.Fatalf("analyze: %v (n=%d)", err, len(sums))
+	}
+	b := sums[0]
+	if b.AvgWiFiRSSIDBm != -60 || b.AvgWiFiPHYRateMbps != 300 {
+		t.Fatalf("avg rssi=%.1f phy=%.1f want -60/300", b.AvgWiFiRSSIDBm, b.AvgWiFiPHYRateMbps)
+	}
+	if b.WiFiSSID != "B" || b.WiFiBSSID != "bb" || b.WiFiChannel != 6 {
+		t.Fatalf("latest identity ssid=%q bssid=%q ch=%d", b.WiFiSSID, b.WiFiBSSID, b.WiFiChannel)
+	}
+}
@@ -0,0 +1,379 @@
+package analysis
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Result line keys by how their string values are anonymized. The anonymizer walks every line
+// generically, so these apply wherever the key appears (site_result, meta, nested route hops,
+// Wi-Fi, VPN, hook and DNS hijack details). Numbers and booleans, i.e. the metrics, are copied
+// as is; a string under any key not listed here is replaced by an opaque token, so a field
+// added later is scrubbed until it is known to be safe.
+var (
+	anonIPKeys = map[string]bool{
+		"ip": true, "remote_ip": true, "resolved_ip": true, "winner_ip": true, "origin_ip_candidate": true,
+		"proxy_remote_ip": true, "local_ip": true, "next_hop": true, "dns_server": true, "target": true,
+		"dns_ips": true, "public_ipv4_candidates": true, "public_ipv6_candidates": true,
+		"public_ipv4_consensus": true, "public_ipv6_consensus": true, "server": true,
+		"bind_source_ip": true, "resolver": true, "answers": true,
+	}
+	anonURLKeys  = map[string]bool{"url": true, "env_proxy_url": true}
+	anonHostKeys = map[string]bool{"dns_suffix": true}
+	// opaque values: replaced by kind-hash tokens so grouping by them still works
+	anonNameKeys = map[string]string{
+		"name": "site", "hostname": "host", "user": "user", "agent": "agent", "ssid": "ssid", "bssid": "bssid",
+		"vpn_name": "vpn", "proxy_name": "proxy", "tls_cert_subject": "cert", "tls_cert_issuer": "cert",
+		"interface": "iface", "bind_interface": "iface", "default_iface": "iface", "prev_default_iface": "iface",
+		"asn_org": "asn", "public_ipv4_asn_org": "asn", "public_ipv6_asn_org": "asn",
+		"tag": "tag", // meta.tags values: free-form labels (isp, location, router names)
+	}
+	// free text that may quote addresses or host names (errors, Via, captured headers); keys
+	// ending in "error" are free text too
+	anonTextKeys = map[string]bool{
+		"header_via": true, "header_server": true, "header_x_cache": true, "second_get_x_cache": true,
+		"response_headers": true, "signals": true, "reason": true, "desc": true, "changed": true,
+		"insights": true,
+	}
+	// enums, timestamps, versions and the labels analysis groups by: copied as is
+	anonKeepKeys = map[string]bool{
+		"run_tag": true, "timestamp_utc": true, "situation": true, "tz": true, "utc_offset": true,
+		"os": true, "arch": true, "kernel_version": true, "connection_type": true, "home_office_estimate": true,
+		"sampling_profile": true, "protocol_experiment": true, "hash": true, "sites_hash": true,
+		"calibrated_utc": true, "day": true, "cycle": true, "budget_action": true, "action": true, "source": true,
+		"mode": true, "status": true, "phase": true, "outcome": true, "probe_type": true, "ip_family": true,
+		"country_configured": true, "country_geoip": true, "dns_server_network": true, "http_method": true,
+		"http_protocol": true, "forced_http_version": true, "alpn": true, "tls_version": true, "tls_cipher": true,
+		"content_type": true, "encoding": true, "transfer_encoding": true, "header_age": true,
+		"second_get_header_age": true, "second_get_content_range": true, "retry_after": true, "request": true,
+		"proxy_source": true, "proxy_config_source": true, "proxy_indicators": true, "next_hop_source": true,
+		"winner": true, "loser_family": true, "loser_outcome": true, "patterns": true, "tool": true,
+		"version": true, "versions": true, "target_bitrate": true,
+	}
+)
+
+var (
+	anonIPv4Re = regexp.MustCompile(`\b\d{1,3}(?:\.\d{1,3}){3}\b`)
+	anonIPv6Re = regexp.MustCompile(`[0-9A-Fa-f]{0,4}(?::[0-9A-Fa-f]{0,4}){2,7}`)
+)
+
+// Anonymizer replaces URLs, host names, IP addresses, SSIDs, proxy and VPN names in result
+// lines with stable pseudonyms while keeping every metric and the line structure, so a file can
+// be shared without revealing internal hosts. Strings it has no rule for (hook commands and
+// output, fields newer than its key lists) become opaque tokens. Pseudonyms are keyed HMACs of
+// the original value: the same input maps to the same pseudonym within one salt, and without
+// the salt they cannot be confirmed by hashing guessed names. IPv4 addresses map into
+// 10.0.0.0/8 and IPv6 into fd00::/8 so the family survives; hosts become h<hash>.example.
+//
+// This is pseudonymization, not differential privacy: the measurements themselves are exact.
+type Anonymizer struct {
+	key    []byte
+	hosts  map[string]string // original host -> pseudonym, for scrubbing free text
+	counts map[string]int    // kind -> distinct values replaced
+	seen   map[string]bool
+}
+
+// NewAnonymizer returns an anonymizer keyed by salt; an empty salt picks a random one, so the
+// pseudonyms of two exports only match when they share an explicit salt.
+func NewAnonymizer(salt string) (*Anonymizer, error) {
+	key := []byte(salt)
+	if salt == "" {
+		key = make([]byte, 16)
+		if _, err := rand.Read(key); err != nil {
+			return nil, fmt.Errorf("anonymize: random salt: %v", err)
+		}
+	}
+	return &Anonymizer{key: key, hosts: map[string]string{}, counts: map[string]int{}, seen: map[string]bool{}}, nil
+}
+
+func (a *Anonymizer) sum(kind, v string) []byte {
+	m := hmac.New(sha256.New, a.key)
+	m.Write([]byte(kind + "\x00" + v))
+	if k := kind + "\x00" + v; !a.seen[k] {
+		a.seen[k] = true
+		a.counts[kind]++
+	}
+	return m.Sum(nil)
+}
+
+func (a *Anonymizer) token(kind, v string) string {
+	if v == "" {
+		return ""
+	}
+	return kind + "-" + hex.EncodeToString(a.sum(kind, v)[:4])
+}
+
+// IP pseudonymizes an address, keeping its family and an optional :port; host names (an
+// iperf3 server's "host:port") go through Host.
+func (a *Anonymizer) IP(v string) string {
+	if v == "" {
+		return ""
+	}
+	if h, p, err := net.SplitHostPort(v); err == nil && h != "" {
+		return net.JoinHostPort(a.IP(h), p)
+	}
+	ip := net.ParseIP(strings.Trim(v, "[]"))
+	if ip == nil {
+		return a.Host(v)
+	}
+	s := a.sum("ip", ip.String())
+	if ip4 := ip.To4(); ip4 != nil {
+		return net.IPv4(10, s[0], s[1], s[2]).String()
+	}
+	out := make(net.IP, net.IPv6len)
+	out[0] = 0xfd
+	copy(out[1:8], s[:7])
+	binary.BigEndian.PutUint16(out[14:], 1)
+	return out.String()
+}
+
+// Host pseudonymizes a DNS name (IP literals go through IP).
+func (a *Anonymizer) Host(v string) string {
+	v = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(v)), ".")
+	if v == "" {
+		return ""
+	}
+	if net.ParseIP(v) != nil {
+		return a.IP(v)
+	}
+	h := "h" + hex.EncodeToString(a.sum("host", v)[:4]) + ".example"
+	a.hosts[v] = h
+	return h
+}
+
+// URL keeps the scheme and port, pseudonymizes the host and path and drops credentials, query
+// and fragment.
+func (a *Anonymizer) URL(v string) string {
+	if v == "" {
+		return ""
+	}
+	raw := v
+	if !strings.Contains(raw, "://") {
+		raw = "tcp://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Hostname() == "" {
+		return a.token("url", v)
+	}
+	host := a.Host(u.Hostname())
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	if p := u.Port(); p != "" {
+		host += ":" + p
+	}
+	out := &url.URL{Scheme: u.Scheme, Host: host}
+	if p := strings.Trim(u.Path, "/"); p != "" {
+		out.Path = "/" + a.token("p", u.Path)
+	} else {
+		out.Path = u.Path
+	}
+	if !strings.Contains(v, "://") {
+		return out.Host
+	}
+	return out.String()
+}
+
+// Text replaces host names already seen by the anonymizer and any IP address inside free text.
+func (a *Anonymizer) Text(v string) string {
+	if v == "" {
+		return ""
+	}
+	hosts := make([]string, 0, len(a.hosts))
+	for h := range a.hosts {
+		if strings.Contains(v, h) {
+			hosts = append(hosts, h)
+		}
+	}
+	sort.Slice(hosts, func(i, j int) bool { return len(hosts[i]) > len(hosts[j]) })
+	for _, h := range hosts {
+		v = strings.ReplaceAll(v, h, a.hosts[h])
+	}
+	v = anonIPv4Re.ReplaceAllStringFunc(v, func(m string) string {
+		if net.ParseIP(m) == nil {
+			return m
+		}
+		return a.IP(m)
+	})
+	return anonIPv6Re.ReplaceAllStringFunc(v, func(m string) string {
+		if strings.Count(m, ":") < 2 || net.ParseIP(m) == nil {
+			return m
+		}
+		return a.IP(m)
+	})
+}
+
+// Line anonymizes one JSONL result line. Keys come out sorted; numbers are kept verbatim.
+func (a *Anonymizer) Line(line []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+	var doc map[string]any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	// register the line's hosts first so errors quoting them are scrubbed regardless of key order
+	a.collectHosts("", doc)
+	return json.Marshal(a.walk("", doc))
+}
+
+func (a *Anonymizer) collectHosts(key string, v any) {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, c := range t {
+			a.collectHosts(k, c)
+		}
+	case []any:
+		for _, c := range t {
+			a.collectHosts(key, c)
+		}
+	case string:
+		switch {
+		case anonIPKeys[key]:
+			a.IP(t)
+		case anonURLKeys[key]:
+			a.URL(t)
+		case anonHostKeys[key]:
+			a.Host(t)
+		}
+	}
+}
+
+func (a *Anonymizer) walk(key string, v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, c := range t {
+			ck := k
+			switch {
+			case key == "response_headers": // header names are fixed, their values are free text
+				ck = key
+			case key == "tags": // tag names are kept, their values are tokens
+				ck = "tag"
+			case key == "vpn" && k == "name":
+				ck = "vpn_name"
+			}
+			t[k] = a.walk(ck, c)
+		}
+		return t
+	case []any:
+		for i, c := range t {
+			t[i] = a.walk(key, c)
+		}
+		return t
+	case string:
+		switch {
+		case anonIPKeys[key]:
+			return a.IP(t)
+		case anonURLKeys[key]:
+			return a.URL(t)
+		case anonHostKeys[key]:
+			return a.Host(t)
+		case anonNameKeys[key] != "":
+			return a.token(anonNameKeys[key], t)
+		case anonTextKeys[key], key == "error", strings.HasSuffix(key, "_error"):
+			return a.Text(t)
+		case anonKeepKeys[key]:
+			return t
+		}
+		return a.token(key, t)
+	}
+	return v
+}
+
+// AnonymizeReport summarizes AnonymizeResultsFile.
+type AnonymizeReport struct {
+	Path     string         `json:"path"`
+	OutPath  string         `json:"out_path"`
+	Lines    int            `json:"lines"`
+	Written  int            `json:"written"`
+	Dropped  int            `json:"dropped"`  // lines that did not parse and were left out rather than copied unscrubbed
+	Replaced map[string]int `json:"replaced"` // distinct values replaced per kind (ip, host, site, ssid, …)
+}
+
+func (r *AnonymizeReport) String() string {
+	kinds := make([]string, 0, len(r.Replaced))
+	for k := range r.Replaced {
+		kinds = append(kinds, k)
+	}
+	sort.Strings(kinds)
+	parts := make([]string, 0, len(kinds))
+	for _, k := range kinds {
+		parts = append(parts, fmt.Sprintf("%s=%d", k, r.Replaced[k]))
+	}
+	return fmt.Sprintf("[anonymize] %s -> %s: lines=%d written=%d dropped=%d replaced: %s\n", r.Path, r.OutPath, r.Lines, r.Written, r.Dropped, strings.Join(parts, " "))
+}
+
+// AnonymizeResultsFile writes an anonymized copy of the results file at path to outPath (see
+// Anonymizer). Lines that are not valid JSON are dropped; the input is never modified.
+func AnonymizeResultsFile(path, outPath, salt string) (*AnonymizeReport, error) {
+	if outPath == "" {
+		return nil, errors.New("anonymize: output path is required")
+	}
+	if sameFile(path, outPath) {
+		return nil, fmt.Errorf("anonymize: output %s must differ from input", outPath)
+	}
+	f, err := OpenResults(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	out, err := os.Create(outPath)
+	if err != nil {
+		return nil, err
+	}
+	defer out.Close()
+	rep, err := AnonymizeResults(f, out, salt)
+	if rep != nil {
+		rep.Path, rep.OutPath = path, outPath
+	}
+	return rep, err
+}
+
+// AnonymizeResults copies the JSONL results in r to w through an Anonymizer keyed by salt.
+func AnonymizeResults(r io.Reader, w io.Writer, salt string) (*AnonymizeReport, error) {
+	a, err := NewAnonymizer(salt)
+	if err != nil {
+		return nil, err
+	}
+	rep := &AnonymizeReport{}
+	bw := bufio.NewWriter(w)
+	reader := bufio.NewReader(r)
+	for {
+		line, rerr := reader.ReadBytes('\n')
+		if trimmed := bytes.TrimSpace(line); len(trimmed) > 0 {
+			rep.Lines++
+			if b, err := a.Line(trimmed); err != nil {
+				rep.Dropped++
+			} else {
+				bw.Write(b)
+				bw.WriteByte('\n')
+				rep.Written++
+			}
+		}
+		if rerr != nil {
+			if !errors.Is(rerr, io.EOF) {
+				return rep, rerr
+			}
+			break
+		}
+	}
+	rep.Replaced = map[string]int{}
+	for k, n := range a.counts {
+		rep.Replaced[k] = n
+	}
+	return rep, bw.Flush()
+}
@@ -0,0 +1,160 @@
+package analysis
+
+import (
+	"context"
+	"io"
+
+	"github.com/iafilius/InternetQualityMonitor/src/monitor"
+)
+
+// APIVersion is the semantic version of the stable API described in the package documentation.
+const APIVersion = "1.1.0"
+
+// DefaultMaxBatches is the number of most recent batches Analyze summarizes when
+// Options.MaxBatches is 0.
+const DefaultMaxBatches = 10
+
+// Options controls Analyze and Batches. The zero value is usable: every zero field selects its
+// default.
+type Options struct {
+	// Most recent batches to summarize; 0 means DefaultMaxBatches. Memory grows with it, not
+	// with the size of the file.
+	MaxBatches int
+	// Only batches measured in this situation (the monitor's --situation); "" means all.
+	Situation string
+	// Schema version lines are upgraded to; 0 means the monitor's current monitor.SchemaVersion.
+	SchemaVersion int
+	// Speed below which transfer time counts as low-speed time (LowSpeedTimeSharePct); 0 means
+	// DefaultLowSpeedThresholdKbps, negative disables it.
+	LowSpeedThresholdKbps float64
+	// Smallest pause in a transfer counted as a transient stall, in ms; 0 means
+	// DefaultMicroStallGapMs, negative disables the detection.
+	MicroStallMinGapMs int64
+	// Goroutines decoding lines in parallel; 0 uses one per CPU.
+	ParseWorkers int
+	// Progress notes ("[analysis] reading results from ..."); nil discards them.
+	Log io.Writer
+	// Called with the bytes and lines read so far while the file is read (see
+	// AnalyzeOptions.Progress); nil reports nothing.
+	Progress func(LoadProgress)
+}
+
+// DefaultOptions are the options the IQM viewer and reports start with.
+func DefaultOptions() Options {
+	return Options{MaxBatches: DefaultMaxBatches}
+}
+
+// Result is the outcome of Analyze.
+type Result struct {
+	// Batches oldest first, at most Options.MaxBatches.
+	Batches []BatchSummary
+	// Line counts of the read: lines skipped as corrupt, duplicates, lines of older batches.
+	Stats LoadStats
+	// Lines upgraded from older schema versions.
+	Migration MigrationReport
+}
+
+// analyzeOptions maps o onto the options of the internal entry point.
+func (o Options) analyzeOptions(res *Result) (schemaVersion, maxBatches int, ao AnalyzeOptions) {
+	schemaVersion, maxBatches = o.SchemaVersion, o.MaxBatches
+	if schemaVersion == 0 {
+		schemaVersion = monitor.SchemaVersion
+	}
+	if maxBatches <= 0 {
+		maxBatches = DefaultMaxBatches
+	}
+	ao = AnalyzeOptions{SituationFilter: o.Situation, LowSpeedThresholdKbps: DefaultLowSpeedThresholdKbps, MicroStallMinGapMs: DefaultMicroStallGapMs,
+		ParseWorkers: o.ParseWorkers, Stats: &res.Stats, Migration: &res.Migration, Log: o.Log, Progress: o.Progress}
+	switch {
+	case o.LowSpeedThresholdKbps < 0:
+		ao.LowSpeedThresholdKbps = 0
+	case o.LowSpeedThresholdKbps > 0:
+		ao.LowSpeedThresholdKbps = o.LowSpeedThresholdKbps
+	}
+	switch {
+	case o.MicroStallMinGapMs < 0:
+		ao.MicroStallMinGapMs = 0
+	case o.MicroStallMinGapMs > 0:
+		ao.MicroStallMinGapMs = o.MicroStallMinGapMs
+	}
+	if ao.Log == nil {
+		ao.Log = io.Discard
+	}
+	return schemaVersion, maxBatches, ao
+}
+
+// Analyze reads the results file at path and summarizes its most recent batches. It returns
+// ctx.Err() when ctx is canceled before the summaries are complete.
+func Analyze(ctx context.Context, path string, opts Options) (*Result, error) {
+	res := &Result{}
+	schemaVersion, maxBatches, ao := opts.analyzeOptions(res)
+	batches, err := analyzeResults(ctx, path, schemaVersion, maxBatches, ao)
+	if err != nil {
+		return nil, err
+	}
+	res.Batches = batches
+	return res, nil
+}
+
+// BatchIterator walks the batches of a results file oldest first; see Batches. It is a
+// convenience over Analyze, not a stream: the first Next summarizes the whole file.
+type BatchIterator struct {
+	ctx     context.Context
+	path    string
+	opts    Options
+	res     *Result
+	i       int
+	err     error
+	started bool
+}
+
+// Batches returns an iterator over the most recent batches of the results file at path. The
+// first call to Next runs Analyze, so memory is that of the Options.MaxBatches summaries either
+// way; a canceled ctx ends the iteration with ctx.Err().
+func Batches(ctx context.Context, path string, opts Options) *BatchIterator {
+	return &BatchIterator{ctx: ctx, path: path, opts: opts}
+}
+
+// Next advances to the next batch and reports whether there is one. After false, Err tells
+// whether the iteration ended because of an error.
+func (it *BatchIterator) Next() bool {
+	if !it.started {
+		it.started = true
+		it.res, it.err = Analyze(it.ctx, it.path, it.opts)
+		if it.err != nil {
+			return false
+		}
+		it.i = -1
+	}
+	if it.err != nil || it.res == nil {
+		return false
+	}
+	if err := it.ctx.Err(); err != nil {
+		it.err = err
+		return false
+	}
+	if it.i+1 >= len(it.res.Batches) {
+		return false
+	}
+	it.i++
+	return true
+}
+
+// Batch is the current batch; valid after Next returned true.
+func (it *BatchIterator) Batch() BatchSummary {
+	if it.res == nil || it.i < 0 || it.i >= len(it.res.Batches) {
+		return BatchSummary{}
+	}
+	return it.res.Batches[it.i]
+}
+
+// Err is the error that ended the iteration, nil when all batches were visited.
+func (it *BatchIterator) Err() error { return it.err }
+
+// Stats are the line counts of the read; zero before the first Next.
+func (it *BatchIterator) Stats() LoadStats {
+	if it.res == nil {
+		return LoadStats{}
+	}
+	return it.res.Stats
+}
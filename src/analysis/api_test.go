@@ -0,0 +1,73 @@
+package analysis
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/iafilius/InternetQualityMonitor/src/monitor"
+)
+
+func writeAPIFixture(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "results.jsonl")
+	var buf bytes.Buffer
+	for i, tag := range []string{"20260101_000000", "20260101_001000", "20260101_002000"} {
+		for _, sit := range []string{"Home", "Office"} {
+			env := monitor.ResultEnvelope{
+				Meta:       &monitor.Meta{TimestampUTC: time.Now().UTC().Format(time.RFC3339Nano), RunTag: tag, Situation: sit, SchemaVersion: monitor.SchemaVersion},
+				SiteResult: &monitor.SiteResult{URL: "https://a.example/", TransferSpeedKbps: float64(1000 * (i + 1)), TraceTTFBMs: 50},
+			}
+			b, _ := json.Marshal(&env)
+			buf.Write(append(b, '\n'))
+		}
+	}
+	buf.WriteString("{not json\n")
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestAnalyzeAPI(t *testing.T) {
+	path := writeAPIFixture(t)
+	var log bytes.Buffer
+	var last LoadProgress
+	res, err := Analyze(context.Background(), path, Options{MaxBatches: 2, Situation: "Home", Log: &log, Progress: func(p LoadProgress) { last = p }})
+	if err != nil {
+		t.Fatalf("analyze: %v", err)
+	}
+	if fi, _ := os.Stat(path); last.Bytes != fi.Size() || last.Lines != 7 {
+		t.Fatalf("final progress %+v of %d bytes", last, fi.Size())
+	}
+	if len(res.Batches) != 2 || res.Batches[0].RunTag != "20260101_001000" || res.Batches[1].Lines != 1 {
+		t.Fatalf("batches: %+v", res.Batches)
+	}
+	if res.Stats.Corrupt != 1 || log.Len() == 0 {
+		t.Fatalf("stats %+v, log %q", res.Stats, log.String())
+	}
+
+	it := Batches(context.Background(), path, DefaultOptions())
+	var tags []string
+	for it.Next() {
+		tags = append(tags, it.Batch().RunTag)
+	}
+	if it.Err() != nil || len(tags) != 3 || tags[0] != "20260101_000000" || it.Stats().Lines == 0 {
+		t.Fatalf("iterator: %v %v", tags, it.Err())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := Analyze(ctx, path, Options{}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("canceled analyze: %v", err)
+	}
+	it = Batches(ctx, path, Options{})
+	if it.Next() || !errors.Is(it.Err(), context.Canceled) {
+		t.Fatalf("canceled iterator: %v", it.Err())
+	}
+}
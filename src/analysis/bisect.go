@@ -0,0 +1,406 @@
+package analysis
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Regression bisect (monitor --bisect): finds the batch where a sustained regression of one
+// metric began and lists what changed at the same time. The batch series is split into segments
+// of constant level by PELT changepoint detection (L2 cost, penalty 2σ²·ln n with the noise σ
+// estimated from the batch-to-batch differences), so single bad batches do not count. The first
+// changepoint whose following levels are all worse than the preceding one by the threshold, up
+// to the newest batch, is the start; a dip that recovered is not a regression. Suspects compare the batches before and
+// after it: protocol mix, proxy rates, next hop, DNS server, egress ASN, Wi-Fi, VPN and the
+// monitor's configuration.
+
+// RegressionMinBatches is the shortest segment: a level must hold this many batches.
+const RegressionMinBatches = 3
+
+// regressionSuspectMinPoints is the smallest change of a share (percentage points) reported as
+// a suspect.
+const regressionSuspectMinPoints = 10
+
+// regressionMetric is a metric --bisect can follow.
+type regressionMetric struct {
+	get          func(BatchSummary) (float64, bool)
+	higherBetter bool
+	unit         string
+	points       bool // a rate: the threshold is in percentage points, not relative
+}
+
+var regressionMetrics = map[string]regressionMetric{
+	"speed":         {get: positiveMetric(BatchP50Speed), higherBetter: true, unit: "kbps"},
+	"ttfb":          {get: positiveMetric(func(b BatchSummary) float64 { return b.AvgTTFB }), unit: "ms"},
+	"ttfb_p95":      {get: positiveMetric(func(b BatchSummary) float64 { return b.AvgP95TTFBMs }), unit: "ms"},
+	"dns":           {get: positiveMetric(func(b BatchSummary) float64 { return b.AvgDNSMs }), unit: "ms"},
+	"connect":       {get: positiveMetric(func(b BatchSummary) float64 { return b.AvgConnectMs }), unit: "ms"},
+	"tls":           {get: positiveMetric(func(b BatchSummary) float64 { return b.AvgTLSHandshake }), unit: "ms"},
+	"jitter":        {get: positiveMetric(func(b BatchSummary) float64 { return b.AvgJitterPct }), unit: "%"},
+	"error_rate":    {get: ratePct(func(b BatchSummary) float64 { return float64(b.ErrorLines) / float64(b.Lines) * 100 }), unit: "%", points: true},
+	"stall_rate":    {get: ratePct(func(b BatchSummary) float64 { return b.StallRatePct }), unit: "%", points: true},
+	"quality_score": {get: qualityScoreOf, higherBetter: true, unit: "points", points: true},
+}
+
+func positiveMetric(f func(BatchSummary) float64) func(BatchSummary) (float64, bool) {
+	return func(b BatchSummary) (float64, bool) {
+		v := f(b)
+		return v, v > 0
+	}
+}
+
+func ratePct(f func(BatchSummary) float64) func(BatchSummary) (float64, bool) {
+	return func(b BatchSummary) (float64, bool) {
+		if b.Lines == 0 {
+			return 0, false
+		}
+		return f(b), true
+	}
+}
+
+func qualityScoreOf(b BatchSummary) (float64, bool) {
+	if b.QualityScore == nil {
+		return 0, false
+	}
+	return b.QualityScore.Score, true
+}
+
+// RegressionMetrics lists the metric names FindRegression accepts.
+func RegressionMetrics() []string {
+	names := make([]string, 0, len(regressionMetrics))
+	for n := range regressionMetrics {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Regression is the start of a sustained regression of Metric.
+type Regression struct {
+	Metric string `json:"metric"`
+	Unit   string `json:"unit"`
+	// RunTag is the first regressed batch, LastGoodRunTag the batch before it.
+	RunTag         string `json:"run_tag"`
+	Situation      string `json:"situation,omitempty"`
+	StartedUTC     string `json:"started_utc,omitempty"`
+	LastGoodRunTag string `json:"last_good_run_tag,omitempty"`
+	// Before is the mean level of the segment up to the changepoint, After that of the segment
+	// from it; Sustained is the mean from the changepoint to the newest batch.
+	Before    float64 `json:"before"`
+	After     float64 `json:"after"`
+	Sustained float64 `json:"sustained"`
+	// Change is the degradation of After against Before: percent, or percentage points for rates.
+	Change           float64             `json:"change"`
+	ChangeInPoints   bool                `json:"change_in_points,omitempty"`
+	BaselineBatches  int                 `json:"baseline_batches"`
+	RegressedBatches int                 `json:"regressed_batches"` // up to the newest batch
+	Suspects         []RegressionSuspect `json:"suspects,omitempty"`
+}
+
+// RegressionSuspect is a signal that changed together with the regression. Strength is 0–100:
+// the shift in percentage points of a share, or of the batches carrying the new value; a
+// configuration change at the first regressed batch counts 100.
+type RegressionSuspect struct {
+	Signal   string  `json:"signal"`
+	Before   string  `json:"before"`
+	After    string  `json:"after"`
+	Strength float64 `json:"strength"`
+}
+
+// FindRegression looks for the start of a sustained regression of metric (see
+// RegressionMetrics) by at least threshold: percent for levels, percentage points for rates and
+// the quality score. Summaries are ordered by start time first; batches without the metric are
+// skipped. nil without a regression; an error for an unknown metric.
+func FindRegression(summaries []BatchSummary, metric string, threshold float64) (*Regression, error) {
+	m, ok := regressionMetrics[metric]
+	if !ok {
+		return nil, fmt.Errorf("unknown metric %q (use %s)", metric, strings.Join(RegressionMetrics(), ", "))
+	}
+	if threshold <= 0 {
+		return nil, fmt.Errorf("threshold must be > 0, got %g", threshold)
+	}
+	rows := append([]BatchSummary(nil), summaries...)
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].StartTime().Before(rows[j].StartTime()) })
+	var batches []BatchSummary
+	var xs []float64
+	for _, b := range rows {
+		if v, ok := m.get(b); ok && !math.IsNaN(v) && !math.IsInf(v, 0) {
+			batches = append(batches, b)
+			xs = append(xs, v)
+		}
+	}
+	cps := peltChangepoints(xs, peltPenalty(xs), RegressionMinBatches)
+	bounds := append(append([]int{0}, cps...), len(xs))
+	// degradation of level b against level a, positive when worse
+	worse := func(a, b float64) float64 {
+		d := b - a
+		if m.higherBetter {
+			d = -d
+		}
+		if m.points {
+			return d
+		}
+		if a == 0 {
+			return 0
+		}
+		return d / math.Abs(a) * 100
+	}
+	for k := 1; k+1 < len(bounds); k++ {
+		cp := bounds[k]
+		before := meanOf(xs[bounds[k-1]:cp])
+		after := meanOf(xs[cp:bounds[k+1]])
+		// sustained: no later segment recovers to within the threshold of the baseline
+		held := true
+		for j := k; j+1 < len(bounds) && held; j++ {
+			held = worse(before, meanOf(xs[bounds[j]:bounds[j+1]])) >= threshold
+		}
+		if !held {
+			continue
+		}
+		sustained := meanOf(xs[cp:])
+		first := batches[cp]
+		r := &Regression{Metric: metric, Unit: m.unit, RunTag: first.RunTag, Situation: first.Situation, StartedUTC: first.StartedUTC,
+			LastGoodRunTag: batches[cp-1].RunTag, Before: before, After: after, Sustained: sustained, Change: worse(before, after),
+			ChangeInPoints: m.points, BaselineBatches: cp - bounds[k-1], RegressedBatches: len(xs) - cp}
+		r.Suspects = regressionSuspects(batches[bounds[k-1]:cp], batches[cp:bounds[k+1]])
+		return r, nil
+	}
+	return nil, nil
+}
+
+// peltPenalty is 2σ²·ln n, σ estimated robustly (MAD) from the differences of neighbouring
+// batches so that level shifts do not inflate it.
+func peltPenalty(xs []float64) float64 {
+	if len(xs) < 2 {
+		return 1
+	}
+	d := make([]float64, len(xs)-1)
+	for i := 1; i < len(xs); i++ {
+		d[i-1] = xs[i] - xs[i-1]
+	}
+	med := medianOf(d)
+	for i := range d {
+		d[i] = math.Abs(d[i] - med)
+	}
+	sigma := 1.4826 * medianOf(d) / math.Sqrt2
+	beta := 2 * sigma * sigma * math.Log(float64(len(xs)))
+	// constant stretches give σ = 0; a tiny penalty still prefers fewer segments on ties
+	return math.Max(beta, 1e-9)
+}
+
+// peltChangepoints returns the segment starts (excluding 0) of the optimal partition of xs into
+// segments of at least minSeg values under the L2 cost plus beta per segment (Killick et al.,
+// 2012, "Optimal detection of changepoints with a linear computational cost").
+func peltChangepoints(xs []float64, beta float64, minSeg int) []int {
+	n := len(xs)
+	if n < 2*minSeg {
+		return nil
+	}
+	s1, s2 := make([]float64, n+1), make([]float64, n+1)
+	for i, x := range xs {
+		s1[i+1], s2[i+1] = s1[i]+x, s2[i]+x*x
+	}
+	cost := func(a, b int) float64 {
+		s := s1[b] - s1[a]
+		return s2[b] - s2[a] - s*s/float64(b-a)
+	}
+	f := make([]float64, n+1)
+	last := make([]int, n+1)
+	for t := 1; t <= n; t++ {
+		f[t] = math.Inf(1)
+	}
+	f[0] = -beta
+	cands := []int{0}
+	for t := minSeg; t <= n; t++ {
+		for _, s := range cands {
+			if t-s < minSeg {
+				continue
+			}
+			if v := f[s] + cost(s, t) + beta; v < f[t] {
+				f[t], last[t] = v, s
+			}
+		}
+		// prune starts that can never be optimal again; too-young ones stay
+		keep := cands[:0]
+		for _, s := range cands {
+			if t-s < minSeg || f[s]+cost(s, t) <= f[t] {
+				keep = append(keep, s)
+			}
+		}
+		cands = keep
+		if !math.IsInf(f[t], 1) {
+			cands = append(cands, t)
+		}
+	}
+	var cps []int
+	for t := last[n]; t > 0; t = last[t] {
+		cps = append([]int{t}, cps...)
+	}
+	return cps
+}
+
+// regressionSuspects compares the batches before and after the changepoint, strongest first.
+func regressionSuspects(before, after []BatchSummary) []RegressionSuspect {
+	var out []RegressionSuspect
+	shares := []struct {
+		signal string
+		get    func(BatchSummary) float64
+	}{
+		{"HTTP/2 share", func(b BatchSummary) float64 { return b.HTTPProtocolRatePct["HTTP/2.0"] }},
+		{"HTTP/1.1 share", func(b BatchSummary) float64 { return b.HTTPProtocolRatePct["HTTP/1.1"] }},
+		{"enterprise proxy rate", func(b BatchSummary) float64 { return b.EnterpriseProxyRatePct }},
+		{"server proxy rate", func(b BatchSummary) float64 { return b.ServerProxyRatePct }},
+		{"env proxy usage", func(b BatchSummary) float64 { return b.EnvProxyUsageRatePct }},
+		{"IPv6 share", func(b BatchSummary) float64 {
+			if b.IPv6 == nil || b.Lines == 0 {
+				return 0
+			}
+			return float64(b.IPv6.Lines) / float64(b.Lines) * 100
+		}},
+		{"connection reuse", func(b BatchSummary) float64 { return b.ConnReuseRatePct }},
+		{"cache hit rate", func(b BatchSummary) float64 { return b.CacheHitRatePct }},
+		{"UDP blocked rate", func(b BatchSummary) float64 { return b.UDPBlockedRatePct }},
+		{"VPN active", func(b BatchSummary) float64 { return boolPct(b.VPNActive) }},
+	}
+	for _, s := range shares {
+		var vb, va []float64
+		for _, b := range before {
+			vb = append(vb, s.get(b))
+		}
+		for _, b := range after {
+			va = append(va, s.get(b))
+		}
+		mb, ma := meanOf(vb), meanOf(va)
+		if d := math.Abs(ma - mb); d >= regressionSuspectMinPoints {
+			out = append(out, RegressionSuspect{Signal: s.signal, Before: fmt.Sprintf("%.0f%%", mb), After: fmt.Sprintf("%.0f%%", ma), Strength: d})
+		}
+	}
+	values := []struct {
+		signal string
+		get    func(BatchSummary) string
+	}{
+		{"next hop", func(b BatchSummary) string { return b.NextHop }},
+		{"DNS server", func(b BatchSummary) string { return b.DNSServer }},
+		{"egress IPv4 ASN", func(b BatchSummary) string { return asnLabel(b.EgressIPv4ASN, b.EgressIPv4ASNOrg) }},
+		{"egress IPv6 ASN", func(b BatchSummary) string { return asnLabel(b.EgressIPv6ASN, b.EgressIPv6ASNOrg) }},
+		{"Wi-Fi access point", func(b BatchSummary) string { return b.WiFiBSSID }},
+		{"Wi-Fi network", func(b BatchSummary) string { return b.WiFiSSID }},
+		{"VPN", func(b BatchSummary) string { return b.VPNName }},
+		{"interface", func(b BatchSummary) string { return b.BindLabel() }},
+		{"host", func(b BatchSummary) string { return b.Hostname }},
+	}
+	for _, v := range values {
+		mb, _ := modeOf(before, v.get)
+		ma, shareA := modeOf(after, v.get)
+		if ma == mb || ma == "" {
+			continue
+		}
+		// how much more often the new value shows up after the changepoint than before it
+		strength := (shareA - shareOf(before, v.get, ma)) * 100
+		if strength < regressionSuspectMinPoints {
+			continue
+		}
+		if mb == "" {
+			mb = "(none)"
+		}
+		out = append(out, RegressionSuspect{Signal: v.signal, Before: mb, After: ma, Strength: strength})
+	}
+	// configuration changes recorded at the first regressed batch
+	if len(after) > 0 {
+		for _, c := range after[0].ConfigChanges {
+			out = append(out, RegressionSuspect{Signal: "config", Before: "", After: c, Strength: 100})
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Strength > out[j].Strength })
+	return out
+}
+
+func boolPct(b bool) float64 {
+	if b {
+		return 100
+	}
+	return 0
+}
+
+func asnLabel(asn uint, org string) string {
+	if asn == 0 {
+		return ""
+	}
+	if org == "" {
+		return fmt.Sprintf("AS%d", asn)
+	}
+	return fmt.Sprintf("AS%d %s", asn, org)
+}
+
+// modeOf is the most frequent non-empty value over the batches (ties: the earliest seen) and
+// the share of batches carrying it.
+func modeOf(rows []BatchSummary, get func(BatchSummary) string) (string, float64) {
+	counts := map[string]int{}
+	var order []string
+	for _, b := range rows {
+		v := get(b)
+		if v == "" {
+			continue
+		}
+		if counts[v] == 0 {
+			order = append(order, v)
+		}
+		counts[v]++
+	}
+	best := ""
+	for _, v := range order {
+		if counts[v] > counts[best] {
+			best = v
+		}
+	}
+	if best == "" || len(rows) == 0 {
+		return "", 0
+	}
+	return best, float64(counts[best]) / float64(len(rows))
+}
+
+func shareOf(rows []BatchSummary, get func(BatchSummary) string, want string) float64 {
+	if len(rows) == 0 {
+		return 0
+	}
+	n := 0
+	for _, b := range rows {
+		if get(b) == want {
+			n++
+		}
+	}
+	return float64(n) / float64(len(rows))
+}
+
+// String renders the regression for the console.
+func (r *Regression) String() string {
+	var b strings.Builder
+	unit := "%"
+	if r.ChangeInPoints {
+		unit = " points"
+	}
+	fmt.Fprintf(&b, "[bisect] %s regressed by %.1f%s starting at batch %s", r.Metric, r.Change, unit, r.RunTag)
+	if r.StartedUTC != "" {
+		fmt.Fprintf(&b, " (%s)", r.StartedUTC)
+	}
+	if r.LastGoodRunTag != "" {
+		fmt.Fprintf(&b, "; last good batch %s", r.LastGoodRunTag)
+	}
+	fmt.Fprintf(&b, "\n[bisect] level %.1f → %.1f %s (%d batches before, %d from the start to the newest, mean since %.1f %s)\n",
+		r.Before, r.After, r.Unit, r.BaselineBatches, r.RegressedBatches, r.Sustained, r.Unit)
+	if len(r.Suspects) == 0 {
+		b.WriteString("[bisect] no correlated changes found\n")
+		return b.String()
+	}
+	b.WriteString("[bisect] changed at the same time (strongest first):\n")
+	for _, s := range r.Suspects {
+		if s.Before == "" {
+			fmt.Fprintf(&b, "  %-22s %s\n", s.Signal, s.After)
+			continue
+		}
+		fmt.Fprintf(&b, "  %-22s %s → %s\n", s.Signal, s.Before, s.After)
+	}
+	return b.String()
+}
@@ -0,0 +1,102 @@
+package analysis
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// bisectSeries builds hourly batches with the given P50 speeds; setup tweaks batch i.
+func bisectSeries(speeds []float64, setup func(i int, b *BatchSummary)) []BatchSummary {
+	t0 := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	var out []BatchSummary
+	for i, v := range speeds {
+		b := BatchSummary{RunTag: fmt.Sprintf("b%02d", i), Lines: 10, AvgP50Speed: v, StartedUTC: t0.Add(time.Duration(i) * time.Hour).Format(time.RFC3339Nano)}
+		if setup != nil {
+			setup(i, &b)
+		}
+		out = append(out, b)
+	}
+	return out
+}
+
+func TestFindRegressionSpeedDrop(t *testing.T) {
+	speeds := []float64{100, 104, 98, 101, 99, 103, 100, 30, 102, 97, 101, // one bad batch is noise
+		60, 62, 58, 61, 59, 60, 63}
+	rows := bisectSeries(speeds, func(i int, b *BatchSummary) {
+		b.NextHop = "192.168.1.1"
+		b.HTTPProtocolRatePct = map[string]float64{"HTTP/2.0": 90, "HTTP/1.1": 10}
+		if i >= 11 {
+			b.NextHop = "10.0.0.1"
+			b.HTTPProtocolRatePct = map[string]float64{"HTTP/2.0": 20, "HTTP/1.1": 80}
+		}
+		if i == 11 {
+			b.ConfigChanges = []string{"parallel 4→8"}
+		}
+	})
+	// newest first, as the file may list them in any order
+	rev := make([]BatchSummary, len(rows))
+	for i := range rows {
+		rev[len(rows)-1-i] = rows[i]
+	}
+	r, err := FindRegression(rev, "speed", 20)
+	if err != nil || r == nil {
+		t.Fatalf("no regression found: %v", err)
+	}
+	if r.RunTag != "b11" || r.LastGoodRunTag != "b10" || r.Change < 35 || r.Change > 45 {
+		t.Fatalf("regression: %+v", r)
+	}
+	signals := map[string]RegressionSuspect{}
+	for _, s := range r.Suspects {
+		signals[s.Signal] = s
+	}
+	if s := signals["next hop"]; s.Before != "192.168.1.1" || s.After != "10.0.0.1" || s.Strength != 100 {
+		t.Fatalf("next hop suspect: %+v", r.Suspects)
+	}
+	if s := signals["HTTP/2 share"]; s.Before != "90%" || s.After != "20%" {
+		t.Fatalf("protocol suspect: %+v", r.Suspects)
+	}
+	if r.Suspects[len(r.Suspects)-1].Strength < regressionSuspectMinPoints {
+		t.Fatalf("weak suspect listed: %+v", r.Suspects)
+	}
+	if _, ok := signals["config"]; !ok || !strings.Contains(r.String(), "parallel 4→8") {
+		t.Fatalf("config change missing:\n%s", r.String())
+	}
+	// the level must hold: a threshold above the drop finds nothing
+	if r, err := FindRegression(rows, "speed", 50); err != nil || r != nil {
+		t.Fatalf("threshold 50: %+v %v", r, err)
+	}
+}
+
+func TestFindRegressionRecoveredAndRates(t *testing.T) {
+	// a dip that recovered is not a sustained regression
+	speeds := []float64{100, 101, 99, 100, 50, 52, 49, 51, 100, 99, 101, 100, 102, 98}
+	if r, err := FindRegression(bisectSeries(speeds, nil), "speed", 20); err != nil || r != nil {
+		t.Fatalf("recovered dip reported: %+v %v", r, err)
+	}
+	// error rate rises by 30 points: the threshold is in points
+	rows := bisectSeries(make([]float64, 12), func(i int, b *BatchSummary) {
+		if i >= 6 {
+			b.ErrorLines = 3
+		}
+	})
+	r, err := FindRegression(rows, "error_rate", 20)
+	if err != nil || r == nil || r.RunTag != "b06" || r.Change != 30 || !r.ChangeInPoints {
+		t.Fatalf("error rate: %+v %v", r, err)
+	}
+	if _, err := FindRegression(rows, "bogus", 20); err == nil || !strings.Contains(err.Error(), "stall_rate") {
+		t.Fatalf("unknown metric: %v", err)
+	}
+}
+
+func TestPeltChangepoints(t *testing.T) {
+	xs := []float64{1, 1, 1, 1, 5, 5, 5, 5, 5, 2, 2, 2}
+	cps := peltChangepoints(xs, peltPenalty(xs), 3)
+	if len(cps) != 2 || cps[0] != 4 || cps[1] != 9 {
+		t.Fatalf("changepoints: %v", cps)
+	}
+	if cps := peltChangepoints([]float64{3, 3, 3, 3, 3, 3, 3}, 1e-9, 3); len(cps) != 0 {
+		t.Fatalf("constant series split: %v", cps)
+	}
+}
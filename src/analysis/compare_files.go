@@ -0,0 +1,204 @@
+package analysis
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// File comparison (the viewer's File → Compare With…): the batches of two results files, e.g.
+// before and after a router swap captured in separate files, are paired batch by batch and
+// compared per metric. Batch A is always from the file on screen, B from the compared file.
+
+// Pairing modes for PairBatches.
+const (
+	PairByIndex     = "index"       // the n-th oldest batch of each file
+	PairByTime      = "time"        // the nearest start time, within the tolerance
+	PairByTimeOfDay = "time_of_day" // the nearest clock time on any day, within the tolerance
+)
+
+// BatchPair is one batch of each file. Offset is B's start minus A's start (by clock time only for
+// PairByTimeOfDay), zero when either start time is unknown.
+type BatchPair struct {
+	A, B   BatchSummary
+	Offset time.Duration
+}
+
+// PairBatches pairs the batches of a and b, oldest batch of a first. PairByIndex pairs them in
+// start order and stops at the shorter file. The time modes give each batch of a the nearest
+// still unpaired batch of b within tolerance (<= 0: any distance); batches without a start time
+// are left out.
+func PairBatches(a, b []BatchSummary, mode string, tolerance time.Duration) ([]BatchPair, error) {
+	sa, sb := byStartTime(a), byStartTime(b)
+	var dist func(x, y time.Time) time.Duration
+	switch mode {
+	case PairByIndex, "":
+		n := min(len(sa), len(sb))
+		out := make([]BatchPair, 0, n)
+		for i := 0; i < n; i++ {
+			p := BatchPair{A: sa[i], B: sb[i]}
+			if ta, tb := sa[i].StartTime(), sb[i].StartTime(); !ta.IsZero() && !tb.IsZero() {
+				p.Offset = tb.Sub(ta)
+			}
+			out = append(out, p)
+		}
+		return out, nil
+	case PairByTime:
+		dist = func(x, y time.Time) time.Duration { return y.Sub(x) }
+	case PairByTimeOfDay:
+		dist = clockOffset
+	default:
+		return nil, fmt.Errorf("pairing %q: want %s, %s or %s", mode, PairByIndex, PairByTime, PairByTimeOfDay)
+	}
+	used := make([]bool, len(sb))
+	var out []BatchPair
+	for _, x := range sa {
+		ta := x.StartTime()
+		if ta.IsZero() {
+			continue
+		}
+		best, bestOff := -1, time.Duration(0)
+		for j, y := range sb {
+			tb := y.StartTime()
+			if used[j] || tb.IsZero() {
+				continue
+			}
+			off := dist(ta, tb)
+			if tolerance > 0 && absDuration(off) > tolerance {
+				continue
+			}
+			if best < 0 || absDuration(off) < absDuration(bestOff) {
+				best, bestOff = j, off
+			}
+		}
+		if best >= 0 {
+			used[best] = true
+			out = append(out, BatchPair{A: x, B: sb[best], Offset: bestOff})
+		}
+	}
+	return out, nil
+}
+
+// byStartTime is a copy of rows sorted oldest first; batches without a start time keep their
+// order at the front.
+func byStartTime(rows []BatchSummary) []BatchSummary {
+	out := append([]BatchSummary(nil), rows...)
+	sort.SliceStable(out, func(i, j int) bool { return out[i].StartTime().Before(out[j].StartTime()) })
+	return out
+}
+
+// clockOffset is y's clock time minus x's, wrapped to ±12h, ignoring the date.
+func clockOffset(x, y time.Time) time.Duration {
+	day := 24 * time.Hour
+	sinceMidnight := func(t time.Time) time.Duration {
+		h, m, s := t.Clock()
+		return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute + time.Duration(s)*time.Second + time.Duration(t.Nanosecond())
+	}
+	d := (sinceMidnight(y) - sinceMidnight(x)) % day
+	switch {
+	case d > day/2:
+		d -= day
+	case d < -day/2:
+		d += day
+	}
+	return d
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+// CompareMetric is one batch metric compared between the files.
+type CompareMetric struct {
+	Key            string
+	Label          string
+	Unit           string // "kbps", "ms", "%" or "" (score)
+	HigherIsBetter bool
+	// Tested metrics are strictly positive, so ComparePaired can test them; rates, which are
+	// often 0, are only averaged.
+	Tested bool
+	Get    func(BatchSummary) (float64, bool)
+}
+
+// CompareMetrics are the metrics of File → Compare With…, in display order.
+var CompareMetrics = []CompareMetric{
+	{Key: "avg_speed", Label: "Avg speed", Unit: "kbps", HigherIsBetter: true, Tested: true, Get: func(s BatchSummary) (float64, bool) { return s.AvgSpeed, s.AvgSpeed > 0 }},
+	{Key: "median_speed", Label: "Median speed", Unit: "kbps", HigherIsBetter: true, Tested: true, Get: func(s BatchSummary) (float64, bool) { return s.MedianSpeed, s.MedianSpeed > 0 }},
+	{Key: "avg_ttfb", Label: "Avg TTFB", Unit: "ms", Tested: true, Get: func(s BatchSummary) (float64, bool) { return s.AvgTTFB, s.AvgTTFB > 0 }},
+	{Key: "p95_ttfb", Label: "P95 TTFB", Unit: "ms", Tested: true, Get: func(s BatchSummary) (float64, bool) { return s.AvgP95TTFBMs, s.AvgP95TTFBMs > 0 }},
+	{Key: "error_rate", Label: "Error rate", Unit: "%", Get: func(s BatchSummary) (float64, bool) {
+		if s.Lines <= 0 {
+			return 0, false
+		}
+		return float64(s.ErrorLines) / float64(s.Lines) * 100, true
+	}},
+	{Key: "jitter", Label: "Jitter", Unit: "%", Get: func(s BatchSummary) (float64, bool) { return s.AvgJitterPct, s.Lines > 0 }},
+	{Key: "stall_rate", Label: "Stall rate", Unit: "%", Get: func(s BatchSummary) (float64, bool) { return s.StallRatePct, s.Lines > 0 }},
+	{Key: "quality_score", Label: "Quality score", HigherIsBetter: true, Tested: true, Get: func(s BatchSummary) (float64, bool) {
+		if s.QualityScore == nil {
+			return 0, false
+		}
+		return s.QualityScore.Score, true
+	}},
+}
+
+// CompareMetricByKey returns the CompareMetrics entry with key.
+func CompareMetricByKey(key string) (CompareMetric, bool) {
+	for _, m := range CompareMetrics {
+		if m.Key == key {
+			return m, true
+		}
+	}
+	return CompareMetric{}, false
+}
+
+// MetricComparison is one metric over the pairs where both batches have it. Delta is MeanB −
+// MeanA; Improved tells whether that is the better direction for the metric (false when equal).
+type MetricComparison struct {
+	Key      string            `json:"key"`
+	Label    string            `json:"label"`
+	Unit     string            `json:"unit,omitempty"`
+	Pairs    int               `json:"pairs"`
+	MeanA    float64           `json:"mean_a"`
+	MeanB    float64           `json:"mean_b"`
+	Delta    float64           `json:"delta"`
+	DeltaPct float64           `json:"delta_pct"` // of MeanA; 0 when MeanA is 0
+	Improved bool              `json:"improved"`
+	Test     *SignificanceTest `json:"test,omitempty"` // Tested metrics with enough pairs
+}
+
+// ComparePairs compares every CompareMetrics entry over pairs; metrics no pair has in both
+// batches are left out. nameA and nameB name the files in the significance tests, which are
+// paired: each pair's B − A difference is tested, so slots that are slow in both files do not
+// hide a consistent change.
+func ComparePairs(pairs []BatchPair, nameA, nameB string) []MetricComparison {
+	var out []MetricComparison
+	for _, m := range CompareMetrics {
+		var va, vb []float64
+		for _, p := range pairs {
+			a, okA := m.Get(p.A)
+			b, okB := m.Get(p.B)
+			if okA && okB && !math.IsNaN(a) && !math.IsNaN(b) {
+				va, vb = append(va, a), append(vb, b)
+			}
+		}
+		if len(va) == 0 {
+			continue
+		}
+		c := MetricComparison{Key: m.Key, Label: m.Label, Unit: m.Unit, Pairs: len(va), MeanA: meanOf(va), MeanB: meanOf(vb)}
+		c.Delta = c.MeanB - c.MeanA
+		if c.MeanA != 0 {
+			c.DeltaPct = c.Delta / c.MeanA * 100
+		}
+		c.Improved = (m.HigherIsBetter && c.Delta > 0) || (!m.HigherIsBetter && c.Delta < 0)
+		if m.Tested {
+			c.Test = ComparePaired(nameA, va, nameB, vb)
+		}
+		out = append(out, c)
+	}
+	return out
+}
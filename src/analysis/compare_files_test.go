@@ -0,0 +1,52 @@
+package analysis
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPairBatches(t *testing.T) {
+	before := time.Date(2025, 6, 2, 8, 0, 0, 0, time.UTC)
+	after := before.AddDate(0, 0, 7)
+	var a, b []BatchSummary
+	for i := 0; i < 4; i++ {
+		a = append(a, reportBatch("a", "old router", before.Add(time.Duration(i)*time.Hour), 40000, 200, 10, 1))
+	}
+	for i := 0; i < 6; i++ {
+		// the new router's batches start 10 minutes later in the hour
+		b = append(b, reportBatch("b", "new router", after.Add(time.Duration(i)*time.Hour+10*time.Minute), 80000, 100, 10, 0))
+	}
+	pairs, err := PairBatches(a, b, PairByIndex, 0)
+	if err != nil || len(pairs) != 4 || pairs[0].Offset != 7*24*time.Hour+10*time.Minute {
+		t.Fatalf("index: %v %+v", err, pairs)
+	}
+	if pairs, _ := PairBatches(a, b, PairByTime, time.Hour); len(pairs) != 0 {
+		t.Fatalf("time: a week apart should not pair within an hour: %d", len(pairs))
+	}
+	pairs, _ = PairBatches(a, b, PairByTimeOfDay, 15*time.Minute)
+	if len(pairs) != 4 || pairs[3].Offset != 10*time.Minute || !pairs[3].B.StartTime().Equal(after.Add(3*time.Hour+10*time.Minute)) {
+		t.Fatalf("time of day: %+v", pairs)
+	}
+	if _, err := PairBatches(a, b, "weekday", 0); err == nil {
+		t.Fatal("unknown mode accepted")
+	}
+	if d := clockOffset(time.Date(2025, 1, 1, 23, 50, 0, 0, time.UTC), time.Date(2025, 1, 9, 0, 5, 0, 0, time.UTC)); d != 15*time.Minute {
+		t.Fatalf("offset across midnight: %v", d)
+	}
+
+	cmp := ComparePairs(pairs, "old router", "new router")
+	byKey := map[string]MetricComparison{}
+	for _, c := range cmp {
+		byKey[c.Key] = c
+	}
+	speed := byKey["median_speed"]
+	if speed.Pairs != 4 || speed.Delta != 40000 || speed.DeltaPct != 100 || !speed.Improved || speed.Test != nil {
+		t.Fatalf("speed (4 pairs, too few to test): %+v", speed)
+	}
+	if e := byKey["error_rate"]; e.MeanA != 10 || e.MeanB != 0 || !e.Improved {
+		t.Fatalf("error rate: %+v", e)
+	}
+	if _, ok := byKey["quality_score"]; ok {
+		t.Fatal("metric without values listed")
+	}
+}
@@ -0,0 +1,65 @@
+package analysis
+
+import (
+	"github.com/iafilius/InternetQualityMonitor/src/monitor"
+)
+
+// CompressionStats summarizes the sampled response bodies of a batch. EffectiveRatio is all
+// decoded over all encoded bytes of the decodable lines, so it drops towards 1 when a proxy
+// starts stripping gzip: the same content then takes ratio times the bytes to transfer.
+type CompressionStats struct {
+	Lines           int     `json:"lines"`
+	CompressedLines int     `json:"compressed_lines,omitempty"`
+	CompressedPct   float64 `json:"compressed_pct"`
+	EffectiveRatio  float64 `json:"effective_ratio,omitempty"`
+	// mean Ratio of the compressed lines that could be decoded
+	AvgCompressedRatio float64 `json:"avg_compressed_ratio,omitempty"`
+	// decoded minus encoded bytes: what compression saved on the wire
+	SavedBytes int64 `json:"saved_bytes,omitempty"`
+	// compressible content types that arrived without encoding although compression was offered
+	UncompressedCompressible int `json:"uncompressed_compressible,omitempty"`
+	// lines per Content-Encoding, "identity" for none
+	Encodings map[string]int `json:"encodings"`
+}
+
+// compressionStats rolls up the lines' body samples; nil when there are none.
+func compressionStats(samples []*monitor.ResponseCompression) *CompressionStats {
+	cs := &CompressionStats{Encodings: map[string]int{}}
+	var encoded, decoded int64
+	var ratios []float64
+	for _, c := range samples {
+		if c == nil || c.EncodedBytes <= 0 {
+			continue
+		}
+		cs.Lines++
+		enc := c.Encoding
+		if enc == "" {
+			enc = "identity"
+		} else {
+			cs.CompressedLines++
+			if c.Ratio > 0 {
+				ratios = append(ratios, c.Ratio)
+			}
+		}
+		cs.Encodings[enc]++
+		if c.Uncompressed {
+			cs.UncompressedCompressible++
+		}
+		if c.DecodedBytes > 0 {
+			encoded += c.EncodedBytes
+			decoded += c.DecodedBytes
+		}
+	}
+	if cs.Lines == 0 {
+		return nil
+	}
+	cs.CompressedPct = float64(cs.CompressedLines) / float64(cs.Lines) * 100
+	cs.AvgCompressedRatio = meanOf(ratios)
+	if encoded > 0 {
+		cs.EffectiveRatio = float64(decoded) / float64(encoded)
+		if decoded > encoded {
+			cs.SavedBytes = decoded - encoded
+		}
+	}
+	return cs
+}
@@ -0,0 +1,52 @@
+package analysis
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/iafilius/InternetQualityMonitor/src/monitor"
+)
+
+func TestCompressionStats(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.jsonl")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	write := func(tag string, c *monitor.ResponseCompression) {
+		env := monitor.ResultEnvelope{
+			Meta:       &monitor.Meta{TimestampUTC: time.Now().UTC().Format(time.RFC3339Nano), RunTag: tag, SchemaVersion: monitor.SchemaVersion},
+			SiteResult: &monitor.SiteResult{URL: "https://a.example/", TransferSpeedKbps: 1000, Compression: c},
+		}
+		b, _ := json.Marshal(&env)
+		f.Write(append(b, '\n'))
+	}
+	write("20260101_000000", &monitor.ResponseCompression{Encoding: "gzip", EncodedBytes: 1000, DecodedBytes: 4000, Ratio: 4})
+	write("20260101_000000", &monitor.ResponseCompression{Encoding: "gzip", EncodedBytes: 1000, DecodedBytes: 2000, Ratio: 2})
+	write("20260101_000000", &monitor.ResponseCompression{Encoding: "br", EncodedBytes: 500}) // not decodable
+	write("20260101_000000", &monitor.ResponseCompression{EncodedBytes: 2000, DecodedBytes: 2000, Ratio: 1, Uncompressed: true})
+	write("20260101_001000", nil)
+	f.Close()
+
+	sums, err := AnalyzeRecentResultsFull(path, monitor.SchemaVersion, 10, "")
+	if err != nil || len(sums) != 2 {
+		t.Fatalf("analyze: %v (n=%d)", err, len(sums))
+	}
+	c := sums[0].Compression
+	if c == nil || c.Lines != 4 || c.CompressedLines != 3 || c.CompressedPct != 75 || c.UncompressedCompressible != 1 {
+		t.Fatalf("compression: %+v", c)
+	}
+	// (4000+2000+2000) / (1000+1000+2000); the undecodable br line stays out
+	if c.EffectiveRatio != 2 || c.AvgCompressedRatio != 3 || c.SavedBytes != 4000 {
+		t.Fatalf("ratios: %+v", c)
+	}
+	if c.Encodings["gzip"] != 2 || c.Encodings["br"] != 1 || c.Encodings["identity"] != 1 {
+		t.Fatalf("encodings: %v", c.Encodings)
+	}
+	if sums[1].Compression != nil {
+		t.Fatalf("no samples: %+v", sums[1].Compression)
+	}
+}
@@ -0,0 +1,40 @@
+// Package analysis turns IQM results files (one JSON line per measured site and IP, as written
+// by the monitor) into per-batch summaries: speeds, TTFB, percentiles, errors, stalls, protocol
+// and proxy indicators, and the many derived views the viewer and reports chart.
+//
+// # Embedding
+//
+// Other Go programs use the stable API in api.go:
+//
+//	res, err := analysis.Analyze(ctx, "monitor_results.jsonl", analysis.Options{MaxBatches: 50})
+//	if err != nil {
+//		return err
+//	}
+//	for _, b := range res.Batches {
+//		fmt.Println(b.RunTag, b.AvgSpeed, b.AvgTTFB)
+//	}
+//
+// or walk the batches one at a time, oldest first:
+//
+//	it := analysis.Batches(ctx, path, analysis.DefaultOptions())
+//	for it.Next() {
+//		b := it.Batch()
+//		...
+//	}
+//	if err := it.Err(); err != nil { ... }
+//
+// Files may be plain, gzip or zstd compressed (see OpenResults). Canceling ctx stops a load
+// within one read chunk and returns ctx.Err().
+//
+// # Stability
+//
+// The stable API follows semantic versioning, reported by APIVersion. Within a major version,
+// Analyze, Batches, BatchIterator, Options, DefaultOptions, Result, LoadProgress and APIVersion
+// keep their signatures and meaning, and BatchSummary (with the types of its fields) and
+// LoadStats only gain fields: existing fields keep their name, JSON key, unit and meaning. A
+// minor version adds API or fields, a patch version fixes results without changing what they
+// mean.
+//
+// Every other exported identifier (AnalyzeRecentResultsFullWithOptions, the Build* and Render*
+// helpers, report formats, ...) serves the IQM commands and may change with any release.
+package analysis
@@ -0,0 +1,271 @@
+package analysis
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Batch explanation: a rules-based summary of what is unusual about one batch, for example
+// "TTFB spike driven by TLS handshake (+180ms) on IPv4 only; enterprise proxy rate rose to 90%".
+// Each metric of the batch is compared with its median over the preceding batches of the same
+// situation; a headline finding (TTFB, speed, errors, stalls) names the setup phase and the
+// address family that carry it, and the context signals of the regression bisect (protocol mix,
+// proxy rates, next hop, DNS server, ASN, Wi-Fi, VPN, configuration) are added when they moved.
+
+// ExplainBaselineBatches is how many earlier batches form the baseline of an explanation.
+const ExplainBaselineBatches = 20
+
+const (
+	explainMinRelPct = 30 // relative change of a level (TTFB, speed)
+	explainMinMs     = 20 // and its absolute change in milliseconds
+	explainMinPoints = 5  // change of a rate in percentage points
+)
+
+// BatchExplanation is the heuristic summary of one batch against its baseline.
+type BatchExplanation struct {
+	RunTag          string `json:"run_tag"`
+	BaselineBatches int    `json:"baseline_batches"`
+	// Findings are short phrases, headline metrics first, then the context that changed.
+	Findings []string `json:"findings,omitempty"`
+}
+
+// String joins the findings into one sentence.
+func (e *BatchExplanation) String() string {
+	if e == nil {
+		return ""
+	}
+	if e.BaselineBatches == 0 {
+		return "No earlier batches of this situation to compare with."
+	}
+	if len(e.Findings) == 0 {
+		return fmt.Sprintf("Nothing unusual against the previous %d batches.", e.BaselineBatches)
+	}
+	s := strings.Join(e.Findings, "; ")
+	return strings.ToUpper(s[:1]) + s[1:] + "."
+}
+
+// ExplainBatch explains the batch runTag of summaries against up to ExplainBaselineBatches
+// earlier batches of its situation; nil when runTag is not among them.
+func ExplainBatch(summaries []BatchSummary, runTag string) *BatchExplanation {
+	rows := append([]BatchSummary(nil), summaries...)
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].StartTime().Before(rows[j].StartTime()) })
+	at := -1
+	for i := range rows {
+		if rows[i].RunTag == runTag {
+			at = i
+		}
+	}
+	if at < 0 {
+		return nil
+	}
+	b := rows[at]
+	var base []BatchSummary
+	for i := at - 1; i >= 0 && len(base) < ExplainBaselineBatches; i-- {
+		if rows[i].Situation == b.Situation {
+			base = append(base, rows[i])
+		}
+	}
+	e := &BatchExplanation{RunTag: b.RunTag, BaselineBatches: len(base)}
+	if len(base) == 0 {
+		return e
+	}
+	// base is newest first; the suspects compare lists in time order
+	for i, j := 0, len(base)-1; i < j; i, j = i+1, j-1 {
+		base[i], base[j] = base[j], base[i]
+	}
+	if b.Canceled {
+		e.Findings = append(e.Findings, "canceled batch (partial)")
+	}
+	e.Findings = append(e.Findings, explainTTFB(b, base)...)
+	e.Findings = append(e.Findings, explainSpeed(b, base)...)
+	e.Findings = append(e.Findings, explainRates(b, base)...)
+	if b.DNSHijackSuspected {
+		e.Findings = append(e.Findings, "resolver rewrites nonexistent names (DNS hijacking suspected)")
+	}
+	if rh := b.ReturnHops; rh != nil && len(rh.Changes) > 0 {
+		e.Findings = append(e.Findings, fmt.Sprintf("return path changed for %d target(s)", len(rh.Changes)))
+	}
+	for _, s := range regressionSuspects(base, []BatchSummary{b}) {
+		e.Findings = append(e.Findings, explainSuspect(s))
+	}
+	return e
+}
+
+// baselineOf is the median of get over the baseline batches where ok; ok false without any.
+func baselineOf(base []BatchSummary, get func(BatchSummary) (float64, bool)) (float64, bool) {
+	var vs []float64
+	for _, b := range base {
+		if v, ok := get(b); ok {
+			vs = append(vs, v)
+		}
+	}
+	if len(vs) == 0 {
+		return 0, false
+	}
+	return medianOf(vs), true
+}
+
+// levelChanged reports whether v moved from the baseline m by explainMinRelPct and, for times,
+// explainMinMs; up selects the direction.
+func levelChanged(m, v float64, up, ms bool) bool {
+	if m <= 0 || v <= 0 {
+		return false
+	}
+	d := v - m
+	if !up {
+		d = -d
+	}
+	if ms && d < explainMinMs {
+		return false
+	}
+	return d/m*100 >= explainMinRelPct
+}
+
+// familyNote is " on IPv4 only" / " on IPv6 only" when just one family matches changed.
+func familyNote(b BatchSummary, base []BatchSummary, get func(*FamilySummary) float64, changed func(m, v float64) bool) string {
+	fam := func(pick func(BatchSummary) *FamilySummary) (bool, bool) {
+		fs := pick(b)
+		if fs == nil || fs.Lines == 0 {
+			return false, false
+		}
+		m, ok := baselineOf(base, func(x BatchSummary) (float64, bool) {
+			f := pick(x)
+			if f == nil || f.Lines == 0 {
+				return 0, false
+			}
+			v := get(f)
+			return v, v > 0
+		})
+		return ok, ok && changed(m, get(fs))
+	}
+	has4, v4 := fam(func(x BatchSummary) *FamilySummary { return x.IPv4 })
+	has6, v6 := fam(func(x BatchSummary) *FamilySummary { return x.IPv6 })
+	switch {
+	case has4 && has6 && v4 && !v6:
+		return " on IPv4 only"
+	case has4 && has6 && v6 && !v4:
+		return " on IPv6 only"
+	}
+	return ""
+}
+
+func explainTTFB(b BatchSummary, base []BatchSummary) []string {
+	m, ok := baselineOf(base, positiveMetric(func(x BatchSummary) float64 { return x.AvgTTFB }))
+	if !ok || !levelChanged(m, b.AvgTTFB, true, true) {
+		return nil
+	}
+	s := fmt.Sprintf("TTFB spike (%.0f → %.0fms)", m, b.AvgTTFB)
+	// the setup phase with the largest rise carries the spike when it makes up half of it
+	phases := []struct {
+		name string
+		get  func(BatchSummary) float64
+	}{
+		{"DNS lookup", func(x BatchSummary) float64 { return x.AvgDNSMs }},
+		{"TCP connect", func(x BatchSummary) float64 { return x.AvgConnectMs }},
+		{"TLS handshake", func(x BatchSummary) float64 { return x.AvgTLSHandshake }},
+	}
+	driver, rise := "", 0.0
+	for _, p := range phases {
+		pm, ok := baselineOf(base, positiveMetric(p.get))
+		if v := p.get(b); ok && v > 0 && v-pm > rise {
+			driver, rise = p.name, v-pm
+		}
+	}
+	if rise >= (b.AvgTTFB-m)/2 {
+		s += fmt.Sprintf(" driven by %s (+%.0fms)", driver, rise)
+	} else {
+		s += " after connection setup (server or path)"
+	}
+	s += familyNote(b, base, func(f *FamilySummary) float64 { return f.AvgTTFB }, func(m, v float64) bool { return levelChanged(m, v, true, true) })
+	return []string{s}
+}
+
+func explainSpeed(b BatchSummary, base []BatchSummary) []string {
+	get := positiveMetric(BatchP50Speed)
+	m, ok := baselineOf(base, get)
+	v, vok := get(b)
+	if !ok || !vok || !levelChanged(m, v, false, false) {
+		return nil
+	}
+	s := fmt.Sprintf("speed down %.0f%% (%.1f → %.1f Mbps)", (m-v)/m*100, m/1000, v/1000)
+	famP50 := func(f *FamilySummary) float64 {
+		if f.PooledP50Speed > 0 {
+			return f.PooledP50Speed
+		}
+		if f.AvgP50Speed > 0 {
+			return f.AvgP50Speed
+		}
+		return f.MedianSpeed
+	}
+	s += familyNote(b, base, famP50, func(m, v float64) bool { return levelChanged(m, v, false, false) })
+	// a monitor host that is itself slow explains the drop better than the network
+	if lm, ok := baselineOf(base, positiveMetric(func(x BatchSummary) float64 { return x.LocalSelfTestKbps })); ok && levelChanged(lm, b.LocalSelfTestKbps, false, false) {
+		s += "; the local self-test dropped too (monitor host limited)"
+	} else if b.NumCPU > 0 && b.LoadAvg1 > float64(b.NumCPU) {
+		s += fmt.Sprintf("; host load %.1f on %d CPUs", b.LoadAvg1, b.NumCPU)
+	}
+	return []string{s}
+}
+
+func explainRates(b BatchSummary, base []BatchSummary) []string {
+	if b.Lines == 0 {
+		return nil
+	}
+	var out []string
+	errRate := func(x BatchSummary) (float64, bool) {
+		if x.Lines == 0 {
+			return 0, false
+		}
+		return float64(x.ErrorLines) / float64(x.Lines) * 100, true
+	}
+	if m, ok := baselineOf(base, errRate); ok {
+		if v, _ := errRate(b); v-m >= explainMinPoints {
+			s := fmt.Sprintf("errors in %.0f%% of lines (usually %.0f%%)", v, m)
+			reason, share := "", 0.0
+			for k, p := range b.ErrorShareByReasonPct {
+				if p > share || (p == share && k < reason) {
+					reason, share = k, p
+				}
+			}
+			if reason != "" {
+				s += fmt.Sprintf(", mostly %s (%.0f%%)", reason, share)
+			}
+			out = append(out, s)
+		}
+	}
+	rates := []struct {
+		name string
+		get  func(BatchSummary) float64
+	}{
+		{"stall rate", func(x BatchSummary) float64 { return x.StallRatePct }},
+		{"transient stall rate", func(x BatchSummary) float64 { return x.MicroStallRatePct }},
+		{"pre-TTFB stall rate", func(x BatchSummary) float64 { return x.PreTTFBStallRatePct }},
+		{"partial body rate", func(x BatchSummary) float64 { return x.PartialBodyRatePct }},
+	}
+	for _, r := range rates {
+		m, _ := baselineOf(base, ratePct(r.get))
+		if v := r.get(b); v-m >= explainMinPoints {
+			out = append(out, fmt.Sprintf("%s rose to %.0f%% (usually %.0f%%)", r.name, v, m))
+		}
+	}
+	return out
+}
+
+// explainSuspect phrases a context change found by regressionSuspects.
+func explainSuspect(s RegressionSuspect) string {
+	switch {
+	case s.Signal == "config":
+		return "monitor config changed: " + s.After
+	case strings.HasSuffix(s.Before, "%"):
+		var vb, va float64
+		fmt.Sscanf(s.Before, "%f%%", &vb)
+		fmt.Sscanf(s.After, "%f%%", &va)
+		if math.Round(va) > math.Round(vb) {
+			return fmt.Sprintf("%s rose to %s", s.Signal, s.After)
+		}
+		return fmt.Sprintf("%s fell to %s", s.Signal, s.After)
+	}
+	return fmt.Sprintf("%s changed from %s to %s", s.Signal, s.Before, s.After)
+}
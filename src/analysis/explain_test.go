@@ -0,0 +1,78 @@
+package analysis
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func explainFixture(n int) []BatchSummary {
+	t0 := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	var rows []BatchSummary
+	for i := 0; i < n; i++ {
+		rows = append(rows, BatchSummary{
+			RunTag: fmt.Sprintf("b%02d", i), Situation: "home", StartedUTC: t0.Add(time.Duration(i) * time.Hour).Format(time.RFC3339Nano),
+			Lines: 20, ErrorLines: 0, AvgTTFB: 120, AvgDNSMs: 10, AvgConnectMs: 20, AvgTLSHandshake: 40, MedianSpeed: 50000,
+			EnterpriseProxyRatePct: 0, NextHop: "192.168.1.1",
+			IPv4: &FamilySummary{Lines: 10, AvgTTFB: 120, MedianSpeed: 50000},
+			IPv6: &FamilySummary{Lines: 10, AvgTTFB: 120, MedianSpeed: 50000},
+		})
+	}
+	return rows
+}
+
+func TestExplainBatch_TTFBSpikeDrivenByTLSOnIPv4(t *testing.T) {
+	rows := explainFixture(6)
+	last := &rows[5]
+	last.AvgTTFB, last.AvgTLSHandshake = 300, 220
+	last.IPv4.AvgTTFB = 480
+	last.EnterpriseProxyRatePct = 90
+	// a batch of another situation must not form the baseline
+	rows = append(rows, BatchSummary{RunTag: "office", Situation: "office", StartedUTC: rows[4].StartedUTC, Lines: 20, AvgTTFB: 900})
+	e := ExplainBatch(rows, "b05")
+	if e == nil || e.BaselineBatches != 5 {
+		t.Fatalf("explanation %+v, want 5 baseline batches", e)
+	}
+	got := e.String()
+	for _, want := range []string{"TTFB spike (120 → 300ms) driven by TLS handshake (+180ms) on IPv4 only", "enterprise proxy rate rose to 90%"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("explanation %q lacks %q", got, want)
+		}
+	}
+	if strings.Contains(got, "speed") {
+		t.Errorf("unexpected speed finding: %q", got)
+	}
+}
+
+func TestExplainBatch_SpeedErrorsAndContext(t *testing.T) {
+	rows := explainFixture(4)
+	last := &rows[3]
+	last.MedianSpeed, last.IPv4.MedianSpeed, last.IPv6.MedianSpeed = 20000, 20000, 20000
+	last.ErrorLines = 4
+	last.ErrorShareByReasonPct = map[string]float64{"timeout": 75, "dns": 25}
+	last.NextHop = "10.0.0.1"
+	last.ConfigChanges = []string{"parallel 1→4"}
+	got := ExplainBatch(rows, "b03").String()
+	for _, want := range []string{"Speed down 60% (50.0 → 20.0 Mbps)", "errors in 20% of lines (usually 0%), mostly timeout (75%)", "next hop changed from 192.168.1.1 to 10.0.0.1", "monitor config changed: parallel 1→4"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("explanation %q lacks %q", got, want)
+		}
+	}
+	if strings.Contains(got, "IPv") {
+		t.Errorf("both families dropped, no family note expected: %q", got)
+	}
+}
+
+func TestExplainBatch_QuietAndFirstBatch(t *testing.T) {
+	rows := explainFixture(3)
+	if got := ExplainBatch(rows, "b02").String(); got != "Nothing unusual against the previous 2 batches." {
+		t.Fatalf("quiet batch: %q", got)
+	}
+	if got := ExplainBatch(rows, "b00").String(); !strings.HasPrefix(got, "No earlier batches") {
+		t.Fatalf("first batch: %q", got)
+	}
+	if ExplainBatch(rows, "missing") != nil {
+		t.Fatalf("unknown run tag should give nil")
+	}
+}
@@ -0,0 +1,254 @@
+package analysis
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/iafilius/InternetQualityMonitor/src/monitor"
+)
+
+// LoadStats counts what the analysis read loop did with each line of the results file.
+// Pass a non-nil pointer in AnalyzeOptions.Stats to have it filled.
+type LoadStats struct {
+	Lines          int // non-blank lines read
+	Parsed         int // valid envelopes with the requested schema version and a run_tag
+	Migrated       int // of Parsed: lines upgraded from an older schema version
+	Corrupt        int // lines that are not valid JSON envelopes (includes a truncated final line)
+	SchemaMismatch int // versions without a migration path (e.g. newer than this build)
+	MissingRunTag  int
+	// of Parsed: lines of batches older than the requested MaxBatches window, released while
+	// streaming (always 0 for CheckResultsFile, which keeps no records)
+	OlderBatchLines int
+	// of Parsed: byte-identical repeats of a line already seen in the same batch (a file or batch
+	// appended twice), ignored so they do not count double
+	Duplicates int
+}
+
+// Skipped returns the number of lines ignored because they could not be used.
+func (s LoadStats) Skipped() int { return s.Corrupt + s.SchemaMismatch + s.MissingRunTag }
+
+func (s *LoadStats) add(o LoadStats) {
+	s.Lines += o.Lines
+	s.Parsed += o.Parsed
+	s.Migrated += o.Migrated
+	s.Corrupt += o.Corrupt
+	s.SchemaMismatch += o.SchemaMismatch
+	s.MissingRunTag += o.MissingRunTag
+}
+
+// FsckIssue describes one problem line found by CheckResultsFile.
+type FsckIssue struct {
+	Line   int    `json:"line"`
+	Kind   string `json:"kind"` // corrupt|truncated|missing_meta|schema_mismatch|missing_run_tag|duplicate_line|duplicate_run_tag
+	Detail string `json:"detail,omitempty"`
+}
+
+// FsckReport summarizes the integrity of a results JSONL file.
+type FsckReport struct {
+	Path             string      `json:"path"`
+	TotalLines       int         `json:"total_lines"`
+	Valid            int         `json:"valid"`
+	Blank            int         `json:"blank"`
+	Corrupt          int         `json:"corrupt"`
+	Truncated        int         `json:"truncated"` // final line without newline that does not parse (interrupted write)
+	MissingMeta      int         `json:"missing_meta"`
+	SchemaMismatch   int         `json:"schema_mismatch"`
+	SchemaVersions   map[int]int `json:"schema_versions,omitempty"`
+	MissingRunTag    int         `json:"missing_run_tag"`
+	DuplicateLines   int         `json:"duplicate_lines"`    // byte-identical repeats of an earlier line
+	DuplicateRunTags []string    `json:"duplicate_run_tags"` // run_tags that reappear after another batch started
+	Issues           []FsckIssue `json:"issues,omitempty"`
+	RepairedPath     string      `json:"repaired_path,omitempty"`
+	RepairedLines    int         `json:"repaired_lines,omitempty"`
+}
+
+// maxFsckIssues caps the per-line issue list; the counters stay exact.
+const maxFsckIssues = 50
+
+// OK reports whether the file has no problems that would make analysis skip or double count lines.
+func (r *FsckReport) OK() bool {
+	return r.Corrupt == 0 && r.Truncated == 0 && r.MissingMeta == 0 && r.SchemaMismatch == 0 &&
+		r.MissingRunTag == 0 && r.DuplicateLines == 0 && len(r.DuplicateRunTags) == 0
+}
+
+// hashLine identifies a line for duplicate detection (surrounding whitespace ignored).
+func hashLine(line []byte) uint64 {
+	h := fnv.New64a()
+	h.Write(bytes.TrimSpace(line))
+	return h.Sum64()
+}
+
+func (r *FsckReport) addIssue(line int, kind, detail string) {
+	if len(r.Issues) < maxFsckIssues {
+		r.Issues = append(r.Issues, FsckIssue{Line: line, Kind: kind, Detail: detail})
+	}
+}
+
+// String renders the report as a short multi-line summary for the CLI.
+func (r *FsckReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[fsck] %s: lines=%d valid=%d blank=%d corrupt=%d truncated=%d missing_meta=%d schema_mismatch=%d missing_run_tag=%d duplicate_lines=%d duplicate_run_tags=%d\n",
+		r.Path, r.TotalLines, r.Valid, r.Blank, r.Corrupt, r.Truncated, r.MissingMeta, r.SchemaMismatch, r.MissingRunTag, r.DuplicateLines, len(r.DuplicateRunTags))
+	if len(r.SchemaVersions) > 1 {
+		var vs []int
+		for v := range r.SchemaVersions {
+			vs = append(vs, v)
+		}
+		sort.Ints(vs)
+		var parts []string
+		for _, v := range vs {
+			parts = append(parts, fmt.Sprintf("v%d:%d", v, r.SchemaVersions[v]))
+		}
+		fmt.Fprintf(&b, "[fsck] schema versions: %s\n", strings.Join(parts, " "))
+	}
+	if len(r.DuplicateRunTags) > 0 {
+		fmt.Fprintf(&b, "[fsck] run_tags split across the file: %s\n", strings.Join(r.DuplicateRunTags, ", "))
+	}
+	for _, is := range r.Issues {
+		if is.Detail != "" {
+			fmt.Fprintf(&b, "[fsck]   line %d: %s (%s)\n", is.Line, is.Kind, is.Detail)
+		} else {
+			fmt.Fprintf(&b, "[fsck]   line %d: %s\n", is.Line, is.Kind)
+		}
+	}
+	if n := r.Corrupt + r.Truncated + r.MissingMeta + r.SchemaMismatch + r.MissingRunTag + r.DuplicateLines; n > len(r.Issues) && len(r.Issues) == maxFsckIssues {
+		fmt.Fprintf(&b, "[fsck]   … %d more issues not listed\n", n-len(r.Issues))
+	}
+	if r.RepairedPath != "" {
+		fmt.Fprintf(&b, "[fsck] wrote repaired copy %s (%d lines)\n", r.RepairedPath, r.RepairedLines)
+	}
+	return b.String()
+}
+
+// CheckResultsFile scans a results JSONL file for truncated/corrupt lines, schema-version
+// mismatches and duplicate run_tags without modifying it.
+func CheckResultsFile(path string, schemaVersion int) (*FsckReport, error) {
+	return fsckResultsFile(path, "", schemaVersion)
+}
+
+// RepairResultsFile runs CheckResultsFile and writes a repaired copy to outPath that keeps every
+// valid envelope (including other schema versions, which the analysis filters on its own) and
+// drops corrupt, truncated, meta-less and byte-identical duplicate lines. The input is untouched.
+func RepairResultsFile(path, outPath string, schemaVersion int) (*FsckReport, error) {
+	if outPath == "" {
+		return nil, errors.New("repair: output path is required")
+	}
+	if sameFile(path, outPath) {
+		return nil, fmt.Errorf("repair: output %s must differ from input", outPath)
+	}
+	return fsckResultsFile(path, outPath, schemaVersion)
+}
+
+func sameFile(a, b string) bool {
+	sa, err1 := os.Stat(a)
+	sb, err2 := os.Stat(b)
+	return err1 == nil && err2 == nil && os.SameFile(sa, sb)
+}
+
+func fsckResultsFile(path, outPath string, schemaVersion int) (*FsckReport, error) {
+	f, err := OpenResults(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var w *bufio.Writer
+	var out *os.File
+	if outPath != "" {
+		out, err = os.Create(outPath)
+		if err != nil {
+			return nil, err
+		}
+		defer out.Close()
+		w = bufio.NewWriter(out)
+	}
+	rep := &FsckReport{Path: path, SchemaVersions: map[int]int{}}
+	seen := map[uint64]int{} // line hash -> first line number
+	closedTags := map[string]bool{}
+	dupTags := map[string]bool{}
+	currentTag := ""
+	reader := bufio.NewReader(f)
+	lineNo := 0
+	for {
+		line, rerr := reader.ReadBytes('\n')
+		if len(line) == 0 && rerr != nil {
+			if !errors.Is(rerr, io.EOF) {
+				return rep, rerr
+			}
+			break
+		}
+		lineNo++
+		rep.TotalLines++
+		final := rerr != nil // no trailing newline
+		trimmed := bytes.TrimSpace(line)
+		if len(trimmed) == 0 {
+			rep.Blank++
+			continue
+		}
+		var env monitor.ResultEnvelope
+		if uerr := json.Unmarshal(trimmed, &env); uerr != nil {
+			if final {
+				rep.Truncated++
+				rep.addIssue(lineNo, "truncated", fmt.Sprintf("%d bytes, no trailing newline", len(line)))
+			} else {
+				rep.Corrupt++
+				rep.addIssue(lineNo, "corrupt", uerr.Error())
+			}
+			continue
+		}
+		if env.Meta == nil || env.SiteResult == nil {
+			rep.MissingMeta++
+			rep.addIssue(lineNo, "missing_meta", "")
+			continue
+		}
+		sum := hashLine(trimmed)
+		if first, dup := seen[sum]; dup {
+			rep.DuplicateLines++
+			rep.addIssue(lineNo, "duplicate_line", fmt.Sprintf("same as line %d", first))
+			continue
+		}
+		seen[sum] = lineNo
+		rep.SchemaVersions[env.Meta.SchemaVersion]++
+		switch {
+		case env.Meta.SchemaVersion != schemaVersion:
+			rep.SchemaMismatch++
+			rep.addIssue(lineNo, "schema_mismatch", fmt.Sprintf("schema_version=%d want %d", env.Meta.SchemaVersion, schemaVersion))
+		case env.Meta.RunTag == "":
+			rep.MissingRunTag++
+			rep.addIssue(lineNo, "missing_run_tag", "")
+		default:
+			rep.Valid++
+			tag := env.Meta.RunTag
+			if tag != currentTag {
+				if closedTags[tag] && !dupTags[tag] {
+					dupTags[tag] = true
+					rep.DuplicateRunTags = append(rep.DuplicateRunTags, tag)
+					rep.addIssue(lineNo, "duplicate_run_tag", tag)
+				}
+				if currentTag != "" {
+					closedTags[currentTag] = true
+				}
+				currentTag = tag
+			}
+		}
+		if w != nil {
+			w.Write(trimmed)
+			w.WriteByte('\n')
+			rep.RepairedLines++
+		}
+	}
+	if w != nil {
+		if err := w.Flush(); err != nil {
+			return rep, err
+		}
+		rep.RepairedPath = outPath
+	}
+	return rep, nil
+}
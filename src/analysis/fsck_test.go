@@ -0,0 +1,92 @@
+package analysis
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/iafilius/InternetQualityMonitor/src/monitor"
+)
+
+// writeFsckFixture writes batches A, B, A (A split), one corrupt line, one exact duplicate,
+// one older-schema line and a truncated final line without newline.
+func writeFsckFixture(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "results.jsonl")
+	var data []byte
+	line := func(tag string, schema int, url string) []byte {
+		env := monitor.ResultEnvelope{Meta: &monitor.Meta{TimestampUTC: time.Now().UTC().Format(time.RFC3339Nano), RunTag: tag, SchemaVersion: schema}, SiteResult: &monitor.SiteResult{URL: url, TransferSpeedKbps: 1000}}
+		b, _ := json.Marshal(&env)
+		return append(b, '\n')
+	}
+	a1 := line("A", monitor.SchemaVersion, "http://a/1")
+	data = append(data, a1...)
+	data = append(data, a1...) // duplicate
+	data = append(data, []byte("{\"meta\":{\"run_tag\":\"A\"\n")...)
+	data = append(data, '\n')
+	data = append(data, line("B", monitor.SchemaVersion, "http://b/1")...)
+	data = append(data, line("A", monitor.SchemaVersion, "http://a/2")...)
+	data = append(data, line("old", monitor.SchemaVersion-1, "http://o/1")...)
+	partial := line("B", monitor.SchemaVersion, "http://b/2")
+	data = append(data, partial[:len(partial)/2]...)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	return path
+}
+
+func TestCheckResultsFile_CountsProblems(t *testing.T) {
+	path := writeFsckFixture(t)
+	rep, err := CheckResultsFile(path, monitor.SchemaVersion)
+	if err != nil {
+		t.Fatalf("fsck: %v", err)
+	}
+	if rep.TotalLines != 8 || rep.Valid != 3 || rep.Blank != 1 {
+		t.Fatalf("lines=%d valid=%d blank=%d want 8/3/1", rep.TotalLines, rep.Valid, rep.Blank)
+	}
+	if rep.Corrupt != 1 || rep.Truncated != 1 || rep.DuplicateLines != 1 || rep.SchemaMismatch != 1 {
+		t.Fatalf("corrupt=%d truncated=%d dup=%d schema=%d want 1 each", rep.Corrupt, rep.Truncated, rep.DuplicateLines, rep.SchemaMismatch)
+	}
+	if len(rep.DuplicateRunTags) != 1 || rep.DuplicateRunTags[0] != "A" {
+		t.Fatalf("duplicate run_tags=%v want [A]", rep.DuplicateRunTags)
+	}
+	if rep.OK() {
+		t.Fatalf("report should not be OK")
+	}
+}
+
+func TestRepairResultsFile_WritesCleanCopy(t *testing.T) {
+	path := writeFsckFixture(t)
+	out := filepath.Join(filepath.Dir(path), "repaired.jsonl")
+	rep, err := RepairResultsFile(path, out, monitor.SchemaVersion)
+	if err != nil {
+		t.Fatalf("repair: %v", err)
+	}
+	if rep.RepairedLines != 4 { // A, B, A, old-schema line
+		t.Fatalf("repaired lines=%d want 4", rep.RepairedLines)
+	}
+	again, err := CheckResultsFile(out, monitor.SchemaVersion)
+	if err != nil {
+		t.Fatalf("re-check: %v", err)
+	}
+	if again.Corrupt != 0 || again.Truncated != 0 || again.DuplicateLines != 0 || again.Valid != 3 {
+		t.Fatalf("repaired copy not clean: %+v", again)
+	}
+	if _, err := RepairResultsFile(path, path, monitor.SchemaVersion); err == nil {
+		t.Fatalf("repair onto the input must be refused")
+	}
+}
+
+func TestAnalyzeOptionsStats_ReportsSkippedLines(t *testing.T) {
+	path := writeFsckFixture(t)
+	var st LoadStats
+	if _, err := AnalyzeRecentResultsFullWithOptions(path, monitor.SchemaVersion, 5, AnalyzeOptions{Stats: &st}); err != nil {
+		t.Fatalf("analyze: %v", err)
+	}
+	// the older-schema line is upgraded via the registered migrations rather than skipped
+	if st.Lines != 7 || st.Corrupt != 2 || st.SchemaMismatch != 0 || st.Parsed != 5 || st.Migrated != 1 {
+		t.Fatalf("stats=%+v want lines=7 corrupt=2 schema=0 parsed=5 migrated=1", st)
+	}
+}
@@ -0,0 +1,168 @@
+package analysis
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// SiteHeaderFingerprint is the captured response headers of one target in a batch (monitor
+// --capture-headers). Headers holds the normalized value seen on most of the target's lines per
+// header; Variants counts the distinct fingerprints among those lines (more than one usually
+// means requests landed on different edges or backends).
+type SiteHeaderFingerprint struct {
+	URL         string            `json:"url"`
+	Headers     map[string]string `json:"headers"`
+	Fingerprint string            `json:"fingerprint"`
+	Lines       int               `json:"lines"`
+	Variants    int               `json:"variants,omitempty"`
+}
+
+// HeaderChange is one header whose normalized value differs between two fingerprints; From or
+// To is empty when the header appeared or disappeared.
+type HeaderChange struct {
+	Header string `json:"header"`
+	From   string `json:"from,omitempty"`
+	To     string `json:"to,omitempty"`
+}
+
+// HeaderTimelineEntry is a target's fingerprint in one batch with the changes against the
+// previous batch that had the target.
+type HeaderTimelineEntry struct {
+	RunTag  string
+	Site    SiteHeaderFingerprint
+	Changes []HeaderChange
+}
+
+// normalizeHeaderValue drops the per-request parts of a header so that only provider or
+// configuration changes alter the fingerprint: Age varies with every request and only its
+// presence says something (a cache answered), and of CF-Ray only the data-center suffix is kept.
+func normalizeHeaderValue(name, v string) string {
+	v = strings.TrimSpace(v)
+	switch http.CanonicalHeaderKey(name) {
+	case "Age":
+		if v == "" {
+			return ""
+		}
+		return "present"
+	case "Cf-Ray":
+		if i := strings.LastIndexByte(v, '-'); i >= 0 {
+			return strings.ToUpper(v[i+1:])
+		}
+	}
+	return v
+}
+
+// HeaderFingerprint renders normalized headers as a stable "Name: value; …" string sorted by
+// name.
+func HeaderFingerprint(h map[string]string) string {
+	names := make([]string, 0, len(h))
+	for k := range h {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	parts := make([]string, 0, len(names))
+	for _, k := range names {
+		parts = append(parts, k+": "+h[k])
+	}
+	return strings.Join(parts, "; ")
+}
+
+// DiffHeaders lists the headers whose values differ between prev and cur, sorted by name.
+func DiffHeaders(prev, cur map[string]string) []HeaderChange {
+	var out []HeaderChange
+	for k, v := range cur {
+		if prev[k] != v {
+			out = append(out, HeaderChange{Header: k, From: prev[k], To: v})
+		}
+	}
+	for k, v := range prev {
+		if _, ok := cur[k]; !ok {
+			out = append(out, HeaderChange{Header: k, From: v})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Header < out[j].Header })
+	return out
+}
+
+// headerLine is the per-line input of headerFingerprints.
+type headerLine struct {
+	url     string
+	headers map[string]string
+}
+
+// headerFingerprints builds one fingerprint per target that captured headers, sorted by URL.
+func headerFingerprints(lines []headerLine) []SiteHeaderFingerprint {
+	type acc struct {
+		lines    int
+		values   map[string]map[string]int
+		variants map[string]bool
+	}
+	bySite := map[string]*acc{}
+	for _, l := range lines {
+		if l.url == "" || len(l.headers) == 0 {
+			continue
+		}
+		a := bySite[l.url]
+		if a == nil {
+			a = &acc{values: map[string]map[string]int{}, variants: map[string]bool{}}
+			bySite[l.url] = a
+		}
+		a.lines++
+		norm := make(map[string]string, len(l.headers))
+		for k, v := range l.headers {
+			if nv := normalizeHeaderValue(k, v); nv != "" {
+				norm[k] = nv
+			}
+		}
+		for k, v := range norm {
+			if a.values[k] == nil {
+				a.values[k] = map[string]int{}
+			}
+			a.values[k][v]++
+		}
+		a.variants[HeaderFingerprint(norm)] = true
+	}
+	out := make([]SiteHeaderFingerprint, 0, len(bySite))
+	for url, a := range bySite {
+		h := map[string]string{}
+		for k, counts := range a.values {
+			best, bestN := "", 0
+			for v, n := range counts {
+				if n > bestN || (n == bestN && v < best) {
+					best, bestN = v, n
+				}
+			}
+			h[k] = best
+		}
+		fp := SiteHeaderFingerprint{URL: url, Headers: h, Fingerprint: HeaderFingerprint(h), Lines: a.lines}
+		if len(a.variants) > 1 {
+			fp.Variants = len(a.variants)
+		}
+		out = append(out, fp)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].URL < out[j].URL })
+	return out
+}
+
+// HeaderTimeline follows url's header fingerprint through summaries (oldest first) and reports,
+// per batch that has it, what changed since the previous one.
+func HeaderTimeline(summaries []BatchSummary, url string) []HeaderTimelineEntry {
+	var out []HeaderTimelineEntry
+	var prev map[string]string
+	for _, s := range summaries {
+		for _, fp := range s.HeaderFingerprints {
+			if fp.URL != url {
+				continue
+			}
+			e := HeaderTimelineEntry{RunTag: s.RunTag, Site: fp}
+			if prev != nil {
+				e.Changes = DiffHeaders(prev, fp.Headers)
+			}
+			prev = fp.Headers
+			out = append(out, e)
+			break
+		}
+	}
+	return out
+}
@@ -0,0 +1,59 @@
+package analysis
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/iafilius/InternetQualityMonitor/src/monitor"
+)
+
+func TestHeaderFingerprints_TimelineShowsProviderChange(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.jsonl")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	write := func(tag, url string, h map[string]string) {
+		meta := &monitor.Meta{TimestampUTC: time.Now().UTC().Format(time.RFC3339Nano), RunTag: tag, SchemaVersion: monitor.SchemaVersion}
+		env := monitor.ResultEnvelope{Meta: meta, SiteResult: &monitor.SiteResult{URL: url, TransferSpeedKbps: 1000, ResponseHeaders: h}}
+		b, _ := json.Marshal(&env)
+		f.Write(append(b, '\n'))
+	}
+	// Age and the CF-Ray request id vary per request and must not look like a change
+	write("b1", "https://a/", map[string]string{"Server": "cloudflare", "Cf-Ray": "8a1b2c3d4e5f6071-AMS", "Age": "12"})
+	write("b1", "https://a/", map[string]string{"Server": "cloudflare", "Cf-Ray": "8a1b2c3d4e5f6072-AMS", "Age": "40"})
+	write("b1", "https://b/", nil)
+	write("b2", "https://a/", map[string]string{"Server": "cloudflare", "Cf-Ray": "9f00000000000001-AMS", "Age": "3"})
+	write("b3", "https://a/", map[string]string{"Server": "AkamaiGHost", "X-Cache": "TCP_HIT"})
+	write("b3", "https://a/", map[string]string{"Server": "AkamaiGHost", "X-Cache": "TCP_MISS"})
+	write("b3", "https://a/", map[string]string{"Server": "AkamaiGHost", "X-Cache": "TCP_HIT"})
+	f.Close()
+
+	sums, err := AnalyzeRecentResultsFull(path, monitor.SchemaVersion, 5, "")
+	if err != nil || len(sums) != 3 {
+		t.Fatalf("analyze: %v (n=%d)", err, len(sums))
+	}
+	if fps := sums[0].HeaderFingerprints; len(fps) != 1 || fps[0].Lines != 2 || fps[0].Variants != 0 || fps[0].Fingerprint != "Age: present; Cf-Ray: AMS; Server: cloudflare" {
+		t.Fatalf("b1 fingerprints: %+v", fps)
+	}
+	tl := HeaderTimeline(sums, "https://a/")
+	if len(tl) != 3 || tl[0].Changes != nil || len(tl[1].Changes) != 0 {
+		t.Fatalf("timeline: %+v", tl)
+	}
+	got := map[string]HeaderChange{}
+	for _, c := range tl[2].Changes {
+		got[c.Header] = c
+	}
+	if len(got) != 4 || got["Server"].From != "cloudflare" || got["Server"].To != "AkamaiGHost" || got["Cf-Ray"].To != "" || got["X-Cache"].From != "" {
+		t.Fatalf("b3 changes: %+v", tl[2].Changes)
+	}
+	if s := tl[2].Site; s.Headers["X-Cache"] != "TCP_HIT" || s.Variants != 2 {
+		t.Fatalf("b3 majority/variants: %+v", s)
+	}
+	if HeaderTimeline(sums, "https://b/") != nil {
+		t.Fatal("target without headers has a timeline")
+	}
+}
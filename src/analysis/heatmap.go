@@ -0,0 +1,150 @@
+package analysis
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Metrics of BuildTimeHeatmap.
+const (
+	HeatmapMedianSpeed = "median_speed" // batch median speed (kbps); higher is better
+	HeatmapP95TTFB     = "p95_ttfb"     // batch P95 TTFB (ms); lower is better
+)
+
+// maxHeatmapDays caps the day axis so a file spanning years stays drawable; the newest days win.
+const maxHeatmapDays = 366
+
+// HeatmapCell is one day/hour slot: the median of the metric over the batches that started in
+// it. Batches is 0 for slots without data.
+type HeatmapCell struct {
+	Value   float64 `json:"value"`
+	Batches int     `json:"batches"`
+}
+
+// TimeHeatmap lays the batches out by local calendar day (columns) and hour of day (rows), so
+// recurring patterns such as evening congestion line up instead of scrolling past on a linear
+// batch axis.
+type TimeHeatmap struct {
+	Metric string            `json:"metric"`
+	Days   []time.Time       `json:"days"`  // local midnights, consecutive, oldest first
+	Cells  [][24]HeatmapCell `json:"cells"` // Cells[day][hour]
+	// Hours is the per-hour median over all batches regardless of day (the typical day).
+	Hours    [24]HeatmapCell `json:"hours"`
+	Min, Max float64         `json:"-"` // range of the filled cells
+	Batches  int             `json:"batches"`
+}
+
+// heatmapValue picks the metric of one batch; ok is false when the batch has no such data.
+func heatmapValue(b BatchSummary, metric string) (float64, bool) {
+	switch metric {
+	case HeatmapP95TTFB:
+		return b.AvgP95TTFBMs, b.AvgP95TTFBMs > 0
+	default:
+		return b.MedianSpeed, b.MedianSpeed > 0
+	}
+}
+
+// BuildTimeHeatmap places summaries by their start time in loc (time.Local when nil). Batches
+// without a start time or without the metric are ignored; days between the first and last batch
+// are kept as empty columns so the calendar stays regular.
+func BuildTimeHeatmap(summaries []BatchSummary, metric string, loc *time.Location) TimeHeatmap {
+	if loc == nil {
+		loc = time.Local
+	}
+	if metric != HeatmapP95TTFB {
+		metric = HeatmapMedianSpeed
+	}
+	out := TimeHeatmap{Metric: metric}
+	type sample struct {
+		day  time.Time
+		hour int
+		v    float64
+	}
+	var samples []sample
+	var first, last time.Time
+	for _, s := range summaries {
+		v, ok := heatmapValue(s, metric)
+		t := s.StartTime()
+		if !ok || t.IsZero() {
+			continue
+		}
+		t = t.In(loc)
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+		samples = append(samples, sample{day, t.Hour(), v})
+		if first.IsZero() || day.Before(first) {
+			first = day
+		}
+		if day.After(last) {
+			last = day
+		}
+	}
+	if len(samples) == 0 {
+		return out
+	}
+	// step by calendar date, not 24h, so DST changes do not skip or repeat a day
+	for d := first; !d.After(last); d = time.Date(d.Year(), d.Month(), d.Day()+1, 0, 0, 0, 0, loc) {
+		out.Days = append(out.Days, d)
+	}
+	if len(out.Days) > maxHeatmapDays {
+		out.Days = out.Days[len(out.Days)-maxHeatmapDays:]
+	}
+	index := make(map[time.Time]int, len(out.Days))
+	for i, d := range out.Days {
+		index[d] = i
+	}
+	vals := make([][24][]float64, len(out.Days))
+	var hours [24][]float64
+	for _, s := range samples {
+		i, ok := index[s.day]
+		if !ok {
+			continue
+		}
+		vals[i][s.hour] = append(vals[i][s.hour], s.v)
+		hours[s.hour] = append(hours[s.hour], s.v)
+		out.Batches++
+	}
+	out.Cells = make([][24]HeatmapCell, len(out.Days))
+	out.Min, out.Max = math.Inf(1), math.Inf(-1)
+	for i := range vals {
+		for h, vs := range vals[i] {
+			if len(vs) == 0 {
+				continue
+			}
+			m := medianOf(vs)
+			out.Cells[i][h] = HeatmapCell{Value: m, Batches: len(vs)}
+			out.Min, out.Max = math.Min(out.Min, m), math.Max(out.Max, m)
+		}
+	}
+	for h, vs := range hours {
+		if len(vs) > 0 {
+			out.Hours[h] = HeatmapCell{Value: medianOf(vs), Batches: len(vs)}
+		}
+	}
+	return out
+}
+
+// WorstHour is the hour of day with the lowest median speed (or highest P95 TTFB) in Hours,
+// -1 when no hour has data.
+func (m TimeHeatmap) WorstHour() int {
+	worst := -1
+	for h, c := range m.Hours {
+		if c.Batches == 0 {
+			continue
+		}
+		if worst < 0 || (m.Metric == HeatmapP95TTFB && c.Value > m.Hours[worst].Value) || (m.Metric != HeatmapP95TTFB && c.Value < m.Hours[worst].Value) {
+			worst = h
+		}
+	}
+	return worst
+}
+
+func medianOf(vs []float64) float64 {
+	s := append([]float64(nil), vs...)
+	sort.Float64s(s)
+	n := len(s)
+	if n%2 == 1 {
+		return s[n/2]
+	}
+	return (s[n/2-1] + s[n/2]) / 2
+}
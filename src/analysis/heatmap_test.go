@@ -0,0 +1,54 @@
+package analysis
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBuildTimeHeatmap(t *testing.T) {
+	loc := time.FixedZone("CET", 3600)
+	at := func(day, hour, min int) string {
+		return time.Date(2026, 3, day, hour, min, 0, 0, loc).UTC().Format(time.RFC3339Nano)
+	}
+	rows := []BatchSummary{
+		{RunTag: "a", StartedUTC: at(2, 20, 0), MedianSpeed: 1000, AvgP95TTFBMs: 300},
+		{RunTag: "b", StartedUTC: at(2, 20, 30), MedianSpeed: 3000, AvgP95TTFBMs: 100},
+		{RunTag: "c", StartedUTC: at(2, 9, 0), MedianSpeed: 8000, AvgP95TTFBMs: 50},
+		{RunTag: "d", StartedUTC: at(4, 20, 0), MedianSpeed: 1500, AvgP95TTFBMs: 250},
+		{RunTag: "nodata", StartedUTC: at(3, 12, 0)},
+		{RunTag: "notime", MedianSpeed: 9999},
+	}
+	m := BuildTimeHeatmap(rows, HeatmapMedianSpeed, loc)
+	if len(m.Days) != 3 || !m.Days[0].Equal(time.Date(2026, 3, 2, 0, 0, 0, 0, loc)) {
+		t.Fatalf("days: %v", m.Days)
+	}
+	if m.Batches != 4 {
+		t.Fatalf("batches: %d", m.Batches)
+	}
+	if c := m.Cells[0][20]; c.Batches != 2 || c.Value != 2000 {
+		t.Fatalf("day 0 20h: %+v", c)
+	}
+	if m.Cells[1][12].Batches != 0 {
+		t.Fatalf("the gap day must stay empty: %+v", m.Cells[1])
+	}
+	if m.Min != 1500 || m.Max != 8000 {
+		t.Fatalf("range: %v..%v", m.Min, m.Max)
+	}
+	if c := m.Hours[20]; c.Batches != 3 || c.Value != 1500 {
+		t.Fatalf("hour profile 20h: %+v", c)
+	}
+	if h := m.WorstHour(); h != 20 {
+		t.Fatalf("worst speed hour: %d", h)
+	}
+
+	ttfb := BuildTimeHeatmap(rows, HeatmapP95TTFB, loc)
+	if c := ttfb.Cells[0][20]; c.Value != 200 {
+		t.Fatalf("ttfb cell: %+v", c)
+	}
+	if h := ttfb.WorstHour(); h != 20 {
+		t.Fatalf("worst ttfb hour: %d", h)
+	}
+	if empty := BuildTimeHeatmap(nil, HeatmapP95TTFB, loc); len(empty.Days) != 0 || empty.WorstHour() != -1 {
+		t.Fatalf("empty heatmap: %+v", empty)
+	}
+}
@@ -0,0 +1,94 @@
+package analysis
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/iafilius/InternetQualityMonitor/src/monitor"
+)
+
+// influxBatchFields are the BatchSummary/FamilySummary metrics (by JSON name, which both share)
+// written as fields of a batch point. Metrics a batch does not have are left out, except the
+// rates below, which are 0 rather than unknown when omitted.
+var influxBatchFields = []string{
+	"lines", "error_lines", "avg_speed_kbps", "median_speed_kbps", "avg_p95_kbps", "avg_ttfb_ms",
+	"avg_ttfb_p75_ms", "avg_dns_ms", "avg_connect_ms", "avg_tls_handshake_ms", "avg_bytes",
+	"avg_jitter_mean_abs_pct", "avg_p99_p50_ratio", "batch_duration_ms", "stall_rate_pct",
+	"pretffb_stall_rate_pct", "micro_stall_rate_pct",
+}
+
+var influxZeroRates = map[string]bool{"stall_rate_pct": true, "pretffb_stall_rate_pct": true, "micro_stall_rate_pct": true}
+
+// InfluxBatchPoints returns the line protocol points of one batch for monitor.WriteInfluxPoints:
+// the whole batch, then one point per target group (tag group), all at the batch start. Tags are
+// the situation and agent; the run tag, quality score and error rate are fields.
+func InfluxBatchPoints(s BatchSummary, measurement string) []monitor.InfluxPoint {
+	tags := map[string]string{"situation": s.Situation, "agent": s.Agent}
+	pt := influxSummaryPoint(s, measurement, tags, s.RunTag, s.StartTime())
+	if s.QualityScore != nil {
+		pt.Fields["quality_score"] = s.QualityScore.Score
+	}
+	if s.SamplingProfile != "" {
+		pt.Fields["sampling_profile"] = s.SamplingProfile
+	}
+	points := []monitor.InfluxPoint{pt}
+	groups := make([]string, 0, len(s.Groups))
+	for g := range s.Groups {
+		groups = append(groups, g)
+	}
+	sort.Strings(groups)
+	for _, g := range groups {
+		gtags := map[string]string{"situation": s.Situation, "agent": s.Agent, "group": g}
+		points = append(points, influxSummaryPoint(s.Groups[g], measurement, gtags, s.RunTag, s.StartTime()))
+	}
+	return points
+}
+
+// WriteInfluxLines writes the batch points of sums as line protocol, oldest batch first, and
+// returns the number of points written.
+func WriteInfluxLines(w io.Writer, sums []BatchSummary, measurement string) (int, error) {
+	rows := append([]BatchSummary(nil), sums...)
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].StartTime().Before(rows[j].StartTime()) })
+	n := 0
+	for _, s := range rows {
+		for _, p := range InfluxBatchPoints(s, measurement) {
+			l := p.Line()
+			if l == "" {
+				continue
+			}
+			if _, err := io.WriteString(w, l+"\n"); err != nil {
+				return n, err
+			}
+			n++
+		}
+	}
+	return n, nil
+}
+
+// influxSummaryPoint picks influxBatchFields from v (a BatchSummary or *FamilySummary) through
+// its JSON encoding.
+func influxSummaryPoint(v any, measurement string, tags map[string]string, runTag string, ts time.Time) monitor.InfluxPoint {
+	var m map[string]any
+	if b, err := json.Marshal(v); err == nil {
+		_ = json.Unmarshal(b, &m)
+	}
+	fields := map[string]any{"run_tag": runTag}
+	for _, k := range influxBatchFields {
+		f, ok := m[k].(float64)
+		switch {
+		case !ok && !influxZeroRates[k]:
+			continue
+		case k == "lines" || k == "error_lines" || k == "batch_duration_ms":
+			fields[k] = int64(f)
+		default:
+			fields[k] = f
+		}
+	}
+	if lines, _ := m["lines"].(float64); lines > 0 {
+		errLines, _ := m["error_lines"].(float64)
+		fields["error_rate_pct"] = errLines / lines * 100
+	}
+	return monitor.InfluxPoint{Measurement: measurement, Tags: tags, Fields: fields, Time: ts}
+}
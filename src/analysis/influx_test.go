@@ -0,0 +1,40 @@
+package analysis
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestInfluxBatchPoints(t *testing.T) {
+	s := BatchSummary{RunTag: "20240102_030405", Situation: "Home", StartedUTC: "2024-01-02T03:04:05Z", Lines: 4, ErrorLines: 1,
+		AvgSpeed: 1000, AvgTTFB: 50, QualityScore: &QualityScore{Score: 81},
+		Groups: map[string]*FamilySummary{"SaaS": {Lines: 2, AvgSpeed: 600}, "CDN": {Lines: 2, ErrorLines: 1, AvgSpeed: 1400}}}
+	pts := InfluxBatchPoints(s, "iqm_batch")
+	if len(pts) != 3 || pts[1].Tags["group"] != "CDN" || pts[2].Tags["group"] != "SaaS" {
+		t.Fatalf("points: %+v", pts)
+	}
+	l := pts[0].Line()
+	for _, want := range []string{"iqm_batch,situation=Home ", "lines=4i", "error_lines=1i", "error_rate_pct=25", "avg_speed_kbps=1000", "quality_score=81", `run_tag="20240102_030405"`, "stall_rate_pct=0", " 1704164645000000000"} {
+		if !strings.Contains(l, want) {
+			t.Fatalf("batch line lacks %q:\n%s", want, l)
+		}
+	}
+	if strings.Contains(l, "avg_dns_ms") {
+		t.Fatalf("metric the batch has no data for: %s", l)
+	}
+	if g := pts[1].Line(); !strings.Contains(g, "group=CDN") || !strings.Contains(g, "error_rate_pct=50") || !strings.Contains(g, "avg_speed_kbps=1400") {
+		t.Fatalf("group line: %s", g)
+	}
+}
+
+func TestWriteInfluxLinesOldestFirst(t *testing.T) {
+	var b strings.Builder
+	n, err := WriteInfluxLines(&b, []BatchSummary{
+		{RunTag: "new", StartedUTC: "2024-01-02T00:00:00Z", Lines: 1},
+		{RunTag: "old", StartedUTC: "2024-01-01T00:00:00Z", Lines: 1},
+	}, "m")
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if err != nil || n != 2 || len(lines) != 2 || !strings.Contains(lines[0], `"old"`) {
+		t.Fatalf("n=%d err=%v\n%s", n, err, b.String())
+	}
+}
@@ -0,0 +1,67 @@
+package analysis
+
+import "math"
+
+// IPv6Readiness is a composite 0–100 score of how usable IPv6 is from this network in a batch,
+// with the components it was built from (each 0–100, higher is better):
+//   - AAAAPct: share of lines whose host published AAAA records (dns_family, else dns_ips)
+//   - SuccessPct: share of IPv6 lines without an error (0 when nothing went over IPv6)
+//   - SpeedPct: IPv6 average speed relative to IPv4, capped at 100
+//   - TTFBPct: IPv4 average TTFB relative to IPv6 (faster IPv6 first bytes cap at 100)
+//   - UDPPct: share of IPv6 QUIC probes that got a reply; -1 when none ran (left out of Score)
+//
+// Score weights them 25/30/15/15/15; components without data are left out and the rest
+// re-weighted. A network without any IPv6 connectivity scores at most the AAAA share.
+type IPv6Readiness struct {
+	Score      float64 `json:"score"`
+	AAAAPct    float64 `json:"aaaa_pct"`
+	SuccessPct float64 `json:"success_pct"`
+	SpeedPct   float64 `json:"speed_pct"`
+	TTFBPct    float64 `json:"ttfb_pct"`
+	UDPPct     float64 `json:"udp_pct"`
+}
+
+// ipv6ReadinessWeights matches the component order of IPv6Readiness.
+var ipv6ReadinessWeights = [5]float64{25, 30, 15, 15, 15}
+
+// ipv6Readiness scores a batch from its AAAA counts and family subsets; nil when the batch
+// carries no IPv6 evidence at all (no AAAA information and no IPv6 lines).
+func ipv6Readiness(aaaaKnown, aaaaLines int, v4, v6 *FamilySummary) *IPv6Readiness {
+	if aaaaKnown == 0 && (v6 == nil || v6.Lines == 0) {
+		return nil
+	}
+	r := &IPv6Readiness{UDPPct: -1}
+	comps := [5]float64{-1, 0, 0, 0, -1}
+	if aaaaKnown > 0 {
+		r.AAAAPct = float64(aaaaLines) / float64(aaaaKnown) * 100
+		comps[0] = r.AAAAPct
+	}
+	if v6 != nil && v6.Lines > 0 {
+		r.SuccessPct = float64(v6.Lines-v6.ErrorLines) / float64(v6.Lines) * 100
+		r.SpeedPct = 100
+		r.TTFBPct = 100
+		if v4 != nil && v4.AvgSpeed > 0 {
+			r.SpeedPct = math.Min(100, v6.AvgSpeed/v4.AvgSpeed*100)
+		}
+		if v4 != nil && v4.AvgTTFB > 0 && v6.AvgTTFB > 0 {
+			r.TTFBPct = math.Min(100, v4.AvgTTFB/v6.AvgTTFB*100)
+		} else if v6.AvgTTFB <= 0 {
+			r.TTFBPct = 0
+		}
+		if v6.QUICProbeLines > 0 {
+			r.UDPPct = 100 - v6.UDPBlockedRatePct
+			comps[4] = r.UDPPct
+		}
+	}
+	comps[1], comps[2], comps[3] = r.SuccessPct, r.SpeedPct, r.TTFBPct
+	sum, wsum := 0.0, 0.0
+	for i, c := range comps {
+		if c < 0 {
+			continue
+		}
+		sum += c * ipv6ReadinessWeights[i]
+		wsum += ipv6ReadinessWeights[i]
+	}
+	r.Score = sum / wsum
+	return r
+}
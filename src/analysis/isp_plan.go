@@ -0,0 +1,111 @@
+package analysis
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// ISPPlan is the subscribed internet plan batches are benchmarked against. MinDownKbps is the
+// contractual minimum download rate when the plan states one (0 = none). UpKbps is kept for the
+// report; the monitor measures downloads only, so no upload attainment is derived from it.
+type ISPPlan struct {
+	DownKbps    float64 `json:"down_kbps"`
+	UpKbps      float64 `json:"up_kbps,omitempty"`
+	MinDownKbps float64 `json:"min_down_kbps,omitempty"`
+}
+
+// PlanReachedPct is the attainment a batch needs to count as reaching the plan in the monthly
+// summary: 90% of the subscribed rate, the share regulators commonly treat as "normally
+// available".
+const PlanReachedPct = 90.0
+
+// Enabled reports whether a download rate was entered.
+func (p ISPPlan) Enabled() bool { return p.DownKbps > 0 }
+
+// PlanAttainment is the batch's median speed as a percentage of the subscribed download rate;
+// ok is false when the plan is not set or the batch has no successful transfer.
+func PlanAttainment(b BatchSummary, p ISPPlan) (pct float64, ok bool) {
+	v := b.MedianSpeed
+	if v <= 0 {
+		v = b.AvgSpeed
+	}
+	if !p.Enabled() || v <= 0 {
+		return 0, false
+	}
+	return v / p.DownKbps * 100, true
+}
+
+// PlanMonth summarizes the plan attainment of one local calendar month.
+type PlanMonth struct {
+	Month      time.Time `json:"month"` // local midnight of the first day
+	Batches    int       `json:"batches"`
+	MedianPct  float64   `json:"median_pct"`
+	P10Pct     float64   `json:"p10_pct"` // the worst tenth of the batches stayed below this
+	WorstPct   float64   `json:"worst_pct"`
+	ReachedPct float64   `json:"reached_pct"` // share of batches at or above PlanReachedPct
+	// BelowMin counts batches under the contractual minimum; only set when the plan has one.
+	BelowMin    int     `json:"below_min,omitempty"`
+	BelowMinPct float64 `json:"below_min_pct,omitempty"`
+	// First and Last are the start times of the month's first and last batch.
+	First time.Time `json:"first"`
+	Last  time.Time `json:"last"`
+}
+
+// BuildPlanMonthly groups the batches by their start month in loc (time.Local when nil) and
+// summarizes their plan attainment, oldest month first. Batches without a start time or without
+// a speed are ignored.
+func BuildPlanMonthly(summaries []BatchSummary, p ISPPlan, loc *time.Location) []PlanMonth {
+	if !p.Enabled() {
+		return nil
+	}
+	if loc == nil {
+		loc = time.Local
+	}
+	byMonth := map[time.Time]*PlanMonth{}
+	vals := map[time.Time][]float64{}
+	for _, s := range summaries {
+		pct, ok := PlanAttainment(s, p)
+		t := s.StartTime()
+		if !ok || t.IsZero() {
+			continue
+		}
+		t = t.In(loc)
+		m := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, loc)
+		pm := byMonth[m]
+		if pm == nil {
+			pm = &PlanMonth{Month: m, First: t, Last: t}
+			byMonth[m] = pm
+		}
+		if t.Before(pm.First) {
+			pm.First = t
+		}
+		if t.After(pm.Last) {
+			pm.Last = t
+		}
+		pm.Batches++
+		if pct >= PlanReachedPct {
+			pm.ReachedPct++
+		}
+		if p.MinDownKbps > 0 && pct < p.MinDownKbps/p.DownKbps*100 {
+			pm.BelowMin++
+		}
+		vals[m] = append(vals[m], pct)
+	}
+	out := make([]PlanMonth, 0, len(byMonth))
+	for m, pm := range byMonth {
+		vs := vals[m]
+		sort.Float64s(vs)
+		pm.MedianPct = medianOf(vs)
+		// nearest rank, as the pooled percentiles
+		pm.P10Pct = vs[int(math.Max(0, math.Ceil(0.10*float64(len(vs)))-1))]
+		pm.WorstPct = vs[0]
+		pm.ReachedPct = pm.ReachedPct / float64(pm.Batches) * 100
+		if pm.BelowMin > 0 {
+			pm.BelowMinPct = float64(pm.BelowMin) / float64(pm.Batches) * 100
+		}
+		out = append(out, *pm)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Month.Before(out[j].Month) })
+	return out
+}
@@ -0,0 +1,46 @@
+package analysis
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBuildPlanMonthly(t *testing.T) {
+	loc := time.FixedZone("CET", 3600)
+	at := func(month time.Month, day int) string {
+		return time.Date(2026, month, day, 20, 0, 0, 0, loc).UTC().Format(time.RFC3339Nano)
+	}
+	plan := ISPPlan{DownKbps: 100000, UpKbps: 20000, MinDownKbps: 50000}
+	rows := []BatchSummary{
+		{RunTag: "a", StartedUTC: at(3, 2), MedianSpeed: 95000},
+		{RunTag: "b", StartedUTC: at(3, 9), MedianSpeed: 40000},
+		{RunTag: "c", StartedUTC: at(3, 30), MedianSpeed: 80000},
+		{RunTag: "d", StartedUTC: at(3, 31), AvgSpeed: 92000},
+		{RunTag: "e", StartedUTC: at(4, 1), MedianSpeed: 60000},
+		{RunTag: "nodata", StartedUTC: at(4, 2)},
+		{RunTag: "notime", MedianSpeed: 100000},
+	}
+	if pct, ok := PlanAttainment(rows[0], plan); !ok || pct != 95 {
+		t.Fatalf("attainment: %v %v", pct, ok)
+	}
+	if _, ok := PlanAttainment(rows[0], ISPPlan{}); ok {
+		t.Fatalf("no plan, no attainment")
+	}
+	ms := BuildPlanMonthly(rows, plan, loc)
+	if len(ms) != 2 || !ms[0].Month.Equal(time.Date(2026, 3, 1, 0, 0, 0, 0, loc)) {
+		t.Fatalf("months: %+v", ms)
+	}
+	m := ms[0]
+	if m.Batches != 4 || m.MedianPct != 86 || m.WorstPct != 40 || m.P10Pct != 40 {
+		t.Fatalf("March: %+v", m)
+	}
+	if m.ReachedPct != 50 || m.BelowMin != 1 || m.BelowMinPct != 25 {
+		t.Fatalf("March reached/below: %+v", m)
+	}
+	if ms[1].Batches != 1 || ms[1].BelowMin != 0 || ms[1].ReachedPct != 0 {
+		t.Fatalf("April: %+v", ms[1])
+	}
+	if BuildPlanMonthly(rows, ISPPlan{}, loc) != nil {
+		t.Fatalf("no plan, no months")
+	}
+}
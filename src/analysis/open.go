@@ -0,0 +1,138 @@
+package analysis
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"time"
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// zstdCommand is the external decompressor used for .zst files (the standard library has no
+// zstd reader); it must write the decompressed stream to stdout.
+var zstdCommand = []string{"zstd", "-dcq"}
+
+// OpenResults opens a results file for reading, transparently decompressing gzip (.jsonl.gz)
+// and zstd (.jsonl.zst) archives as a stream. The format is detected from the leading magic
+// bytes, so renamed files work too; anything else is returned as-is.
+func OpenResults(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	br := bufio.NewReaderSize(f, 256*1024)
+	head, _ := br.Peek(4)
+	switch {
+	case bytes.HasPrefix(head, gzipMagic):
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		zr.Multistream(true) // concatenated members (e.g. appended rotations) read as one file
+		return &resultsReader{Reader: zr, closers: []io.Closer{zr, f}}, nil
+	case bytes.HasPrefix(head, zstdMagic):
+		cmd := exec.Command(zstdCommand[0], zstdCommand[1:]...)
+		cmd.Stdin = br
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		out, err := cmd.StdoutPipe()
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		if err := cmd.Start(); err != nil {
+			f.Close()
+			if errors.Is(err, exec.ErrNotFound) {
+				return nil, fmt.Errorf("%s is zstd-compressed: install the zstd tool or decompress it first", path)
+			}
+			return nil, fmt.Errorf("%s: starting zstd: %w", path, err)
+		}
+		return &resultsReader{Reader: out, closers: []io.Closer{f}, cmd: cmd, stderr: &stderr}, nil
+	}
+	return &resultsReader{Reader: br, closers: []io.Closer{f}}, nil
+}
+
+// resultsReader closes the decompressor and the file; for zstd it also reaps the process and
+// reports a decompression failure at EOF (a truncated archive must not look like a short file).
+type resultsReader struct {
+	io.Reader
+	closers []io.Closer
+	cmd     *exec.Cmd
+	stderr  *bytes.Buffer
+	waited  bool
+}
+
+func (r *resultsReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if err == io.EOF && r.cmd != nil && !r.waited {
+		r.waited = true
+		if werr := r.cmd.Wait(); werr != nil {
+			return n, fmt.Errorf("zstd: %v: %s", werr, bytes.TrimSpace(r.stderr.Bytes()))
+		}
+	}
+	return n, err
+}
+
+func (r *resultsReader) Close() error {
+	var first error
+	if r.cmd != nil && !r.waited {
+		r.waited = true
+		if r.cmd.Process != nil {
+			_ = r.cmd.Process.Kill() // closed before EOF: stop decompressing
+		}
+		_ = r.cmd.Wait()
+	}
+	for _, c := range r.closers {
+		if err := c.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// LoadProgress is how far a load got: bytes read from the (decompressed) file and the lines
+// they contained.
+type LoadProgress struct {
+	Bytes int64
+	Lines int
+}
+
+// loadProgressInterval throttles AnalyzeOptions.Progress callbacks.
+const loadProgressInterval = 100 * time.Millisecond
+
+// contextReader fails reads with ctx.Err() once ctx is done, so a long parse stops at the next
+// chunk, and reports the bytes and lines read to progress (nil: not reported).
+type contextReader struct {
+	ctx      context.Context
+	r        io.Reader
+	progress func(LoadProgress)
+	p        LoadProgress
+	last     time.Time
+}
+
+func (c *contextReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	n, err := c.r.Read(p)
+	if c.progress != nil {
+		c.p.Bytes += int64(n)
+		c.p.Lines += bytes.Count(p[:n], []byte{'\n'})
+		if now := time.Now(); err != nil || now.Sub(c.last) >= loadProgressInterval {
+			c.last = now
+			c.progress(c.p)
+		}
+	}
+	return n, err
+}
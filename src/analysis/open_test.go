@@ -0,0 +1,86 @@
+package analysis
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iafilius/InternetQualityMonitor/src/monitor"
+)
+
+func compressedFixture(t *testing.T) []byte {
+	t.Helper()
+	var data []byte
+	for i, tag := range []string{"20250101_100000", "20250101_100000", "20250101_110000"} {
+		env := monitor.ResultEnvelope{Meta: &monitor.Meta{TimestampUTC: time.Date(2025, 1, 1, 10+i, 0, 0, 0, time.UTC).Format(time.RFC3339Nano), RunTag: tag, SchemaVersion: monitor.SchemaVersion},
+			SiteResult: &monitor.SiteResult{URL: "http://x/", TransferSpeedKbps: 1000, TransferSizeBytes: 100000}}
+		b, _ := json.Marshal(&env)
+		data = append(append(data, b...), '\n')
+	}
+	return data
+}
+
+func TestOpenResults_Gzip(t *testing.T) {
+	plain := compressedFixture(t)
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write(plain)
+	zw.Close()
+	path := filepath.Join(t.TempDir(), "results.jsonl.gz")
+	if err := os.WriteFile(path, gz.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	sums, err := AnalyzeRecentResultsFull(path, monitor.SchemaVersion, 5, "")
+	if err != nil {
+		t.Fatalf("analyze gz: %v", err)
+	}
+	if len(sums) != 2 || sums[0].Lines != 2 {
+		t.Fatalf("want 2 batches (2+1 lines), got %+v", sums)
+	}
+	// uncompressed files pass through untouched
+	raw := filepath.Join(t.TempDir(), "results.jsonl")
+	os.WriteFile(raw, plain, 0o644)
+	rc, err := OpenResults(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got bytes.Buffer
+	got.ReadFrom(rc)
+	rc.Close()
+	if !bytes.Equal(got.Bytes(), plain) {
+		t.Fatalf("plain file altered")
+	}
+}
+
+func TestOpenResults_Zstd(t *testing.T) {
+	if _, err := exec.LookPath("zstd"); err != nil {
+		t.Skip("zstd tool not installed")
+	}
+	dir := t.TempDir()
+	plain := filepath.Join(dir, "results.jsonl")
+	os.WriteFile(plain, compressedFixture(t), 0o644)
+	if out, err := exec.Command("zstd", "-q", plain, "-o", plain+".zst").CombinedOutput(); err != nil {
+		t.Fatalf("zstd: %v %s", err, out)
+	}
+	sums, err := AnalyzeRecentResultsFull(plain+".zst", monitor.SchemaVersion, 5, "")
+	if err != nil || len(sums) != 2 {
+		t.Fatalf("analyze zst: %v %d", err, len(sums))
+	}
+}
+
+func TestOpenResults_ZstdToolMissing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.jsonl.zst")
+	os.WriteFile(path, append([]byte{0x28, 0xb5, 0x2f, 0xfd}, make([]byte, 16)...), 0o644)
+	old := zstdCommand
+	zstdCommand = []string{"iqm-no-such-zstd-binary"}
+	defer func() { zstdCommand = old }()
+	if _, err := OpenResults(path); err == nil || !strings.Contains(err.Error(), "zstd") {
+		t.Fatalf("want a clear zstd error, got %v", err)
+	}
+}
@@ -0,0 +1,173 @@
+package analysis
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"sync"
+)
+
+// parseChunkBytes is the size of the blocks read from the results file; each block is cut at
+// its last newline so workers only ever see whole lines. A variable so tests can force lines
+// to straddle block boundaries.
+var parseChunkBytes = 1 << 20
+
+// errLineTooLarge reports a single line above the per-line cap.
+type errLineTooLarge struct {
+	size, limit int
+}
+
+func (e errLineTooLarge) Error() string {
+	return fmt.Sprintf("line too large: %d bytes exceeds limit %d", e.size, e.limit)
+}
+
+// parseWorkerCount resolves AnalyzeOptions.ParseWorkers: 0 means one worker per CPU.
+func parseWorkerCount(n int) int {
+	if n <= 0 {
+		n = runtime.GOMAXPROCS(0)
+	}
+	return n
+}
+
+type parseChunk[T any] struct {
+	seq   int
+	data  []byte
+	out   []T
+	stats LoadStats
+}
+
+// parseLinesParallel splits r into newline-aligned chunks, decodes the non-blank lines of each
+// chunk on up to workers goroutines and returns the decoded values in file order. decode
+// reports whether the line produced a value and counts skipped lines in the stats it is given;
+// the per-chunk stats are summed into stats. Lines above maxLine bytes abort the parse. A read
+// error other than EOF is warned about and ends the input, like a truncated file.
+func parseLinesParallel[T any](r io.Reader, workers, maxLine int, decode func(line []byte, st *LoadStats) (T, bool), stats *LoadStats) ([]T, error) {
+	var out []T
+	if err := streamLinesParallel(r, workers, maxLine, decode, stats, func(v T) { out = append(out, v) }); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// streamLinesParallel is parseLinesParallel without the result slice: each decoded value is
+// handed to emit, in file order, as soon as its chunk is next in sequence. Only the chunks in
+// flight (at most 4*workers blocks of parseChunkBytes plus their decoded values) are held, so
+// the caller decides how much of the file stays in memory.
+func streamLinesParallel[T any](r io.Reader, workers, maxLine int, decode func(line []byte, st *LoadStats) (T, bool), stats *LoadStats, emit func(T)) error {
+	workers = parseWorkerCount(workers)
+	jobs := make(chan *parseChunk[T], workers)
+	done := make(chan *parseChunk[T], workers)
+	// bounds the chunks read but not yet merged, so one slow chunk cannot buffer the whole file
+	inflight := make(chan struct{}, 4*workers)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for c := range jobs {
+				for _, line := range bytes.Split(c.data, []byte{'\n'}) {
+					if len(bytes.TrimSpace(line)) == 0 {
+						continue
+					}
+					c.stats.Lines++
+					if v, ok := decode(line, &c.stats); ok {
+						c.out = append(c.out, v)
+					}
+				}
+				c.data = nil
+				done <- c
+			}
+		}()
+	}
+	readErr := make(chan error, 1)
+	go func() {
+		defer close(jobs)
+		readErr <- splitChunks(r, maxLine, func(seq int, data []byte) {
+			inflight <- struct{}{}
+			jobs <- &parseChunk[T]{seq: seq, data: data}
+		})
+	}()
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	// Merge in sequence order; chunks finishing early wait in pending.
+	pending := map[int]*parseChunk[T]{}
+	next := 0
+	for c := range done {
+		pending[c.seq] = c
+		for p, ok := pending[next]; ok; p, ok = pending[next] {
+			for _, v := range p.out {
+				emit(v)
+			}
+			stats.add(p.stats)
+			delete(pending, next)
+			next++
+			<-inflight
+		}
+	}
+	return <-readErr
+}
+
+// splitChunks reads r in parseChunkBytes blocks and hands emit each block up to and including
+// its last newline; the remainder is carried into the next block (a line longer than a block
+// accumulates until its newline). The final line may lack a newline.
+func splitChunks(r io.Reader, maxLine int, emit func(seq int, data []byte)) error {
+	seq := 0
+	var carry []byte
+	for {
+		block := make([]byte, parseChunkBytes)
+		n, err := io.ReadFull(r, block)
+		block = block[:n]
+		eof := errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+		if err != nil && !eof {
+			fmt.Printf("[analysis] read warning: %v\n", err)
+			eof = true
+		}
+		cut := bytes.LastIndexByte(block, '\n') + 1
+		if eof {
+			cut = len(block)
+		}
+		if cut == 0 && !eof { // no line ends in this block
+			if len(carry)+len(block) > maxLine {
+				return errLineTooLarge{size: len(carry) + len(block), limit: maxLine}
+			}
+			carry = append(carry, block...)
+			continue
+		}
+		data := block[:cut]
+		if len(carry) > 0 {
+			data = append(carry, data...)
+		}
+		if longest := longestLine(data); longest > maxLine {
+			return errLineTooLarge{size: longest, limit: maxLine}
+		}
+		if len(data) > 0 {
+			emit(seq, data)
+			seq++
+		}
+		if eof {
+			return nil
+		}
+		carry = append([]byte(nil), block[cut:]...)
+	}
+}
+
+func longestLine(b []byte) int {
+	longest := 0
+	for len(b) > 0 {
+		i := bytes.IndexByte(b, '\n')
+		if i < 0 {
+			i = len(b)
+		} else {
+			i++
+		}
+		if i > longest {
+			longest = i
+		}
+		b = b[i:]
+	}
+	return longest
+}
@@ -0,0 +1,131 @@
+package analysis
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iafilius/InternetQualityMonitor/src/monitor"
+)
+
+// parseFixture writes n lines spread over batches of 50, with a corrupt line, a blank line and
+// a wrong-schema line mixed in, and returns the file path.
+func parseFixture(tb testing.TB, n int) string {
+	tb.Helper()
+	var buf bytes.Buffer
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < n; i++ {
+		ts := base.Add(time.Duration(i) * time.Second)
+		env := monitor.ResultEnvelope{
+			Meta: &monitor.Meta{TimestampUTC: ts.Format(time.RFC3339Nano), RunTag: fmt.Sprintf("20250101_%06d", i/50), SchemaVersion: monitor.SchemaVersion},
+			SiteResult: &monitor.SiteResult{URL: fmt.Sprintf("https://site%d.example/", i%7), IPFamily: []string{"ipv4", "ipv6"}[i%2],
+				TransferSpeedKbps: float64(1000 + i%300), TraceTTFBMs: int64(20 + i%90), TransferSizeBytes: 1 << 20,
+				SpeedAnalysis: &monitor.SpeedAnalysis{P50Kbps: float64(1000 + i%300), P90Kbps: 1400, P95Kbps: 1500, P99Kbps: 1600}},
+		}
+		b, _ := json.Marshal(&env)
+		buf.Write(b)
+		buf.WriteByte('\n')
+		switch i % 97 {
+		case 13:
+			buf.WriteString("{\"meta\": truncated\n")
+		case 29:
+			buf.WriteString("\n")
+		case 41:
+			buf.WriteString(`{"meta":{"schema_version":99,"run_tag":"x"},"site_result":{}}` + "\n")
+		}
+	}
+	path := filepath.Join(tb.TempDir(), "results.jsonl")
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		tb.Fatal(err)
+	}
+	return path
+}
+
+func TestParallelParse_MatchesSequential(t *testing.T) {
+	path := parseFixture(t, 1200)
+	old := parseChunkBytes
+	parseChunkBytes = 1500 // far smaller than a batch, so lines straddle chunk boundaries
+	defer func() { parseChunkBytes = old }()
+	run := func(workers int) ([]BatchSummary, LoadStats) {
+		var st LoadStats
+		sums, err := AnalyzeRecentResultsFullWithOptions(path, monitor.SchemaVersion, 100, AnalyzeOptions{ParseWorkers: workers, Stats: &st})
+		if err != nil {
+			t.Fatalf("workers=%d: %v", workers, err)
+		}
+		return sums, st
+	}
+	seq, seqStats := run(1)
+	if len(seq) != 24 || seqStats.Parsed != 1200 || seqStats.Corrupt == 0 || seqStats.SchemaMismatch == 0 {
+		t.Fatalf("unexpected sequential result: %d batches, stats %+v", len(seq), seqStats)
+	}
+	for _, w := range []int{2, 8} {
+		par, parStats := run(w)
+		if parStats != seqStats {
+			t.Fatalf("workers=%d stats %+v, want %+v", w, parStats, seqStats)
+		}
+		if !reflect.DeepEqual(par, seq) {
+			t.Fatalf("workers=%d summaries differ from sequential parse", w)
+		}
+	}
+}
+
+func TestParseLinesParallel_OrderAndFinalLine(t *testing.T) {
+	old := parseChunkBytes
+	parseChunkBytes = 7
+	defer func() { parseChunkBytes = old }()
+	var in strings.Builder
+	for i := 0; i < 500; i++ {
+		fmt.Fprintf(&in, "%d\n", i)
+	}
+	in.WriteString("500") // no trailing newline
+	var st LoadStats
+	got, err := parseLinesParallel(strings.NewReader(in.String()), 4, 1024, func(line []byte, _ *LoadStats) (string, bool) {
+		return string(line), true
+	}, &st)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 501 || st.Lines != 501 {
+		t.Fatalf("got %d values, %d lines", len(got), st.Lines)
+	}
+	for i, v := range got {
+		if v != fmt.Sprint(i) {
+			t.Fatalf("value %d = %q (order not preserved)", i, v)
+		}
+	}
+}
+
+func TestParseLinesParallel_LineTooLarge(t *testing.T) {
+	old := parseChunkBytes
+	parseChunkBytes = 16
+	defer func() { parseChunkBytes = old }()
+	in := "short\n" + strings.Repeat("x", 100) + "\nshort\n"
+	_, err := parseLinesParallel(strings.NewReader(in), 2, 64, func(line []byte, _ *LoadStats) (int, bool) { return 0, true }, &LoadStats{})
+	if err == nil || !strings.Contains(err.Error(), "line too large") {
+		t.Fatalf("want line too large error, got %v", err)
+	}
+}
+
+// Run with: go test ./src/analysis -run '^$' -bench Parse -benchmem
+func benchmarkAnalyzeParse(b *testing.B, workers int) {
+	path := parseFixture(b, 20000)
+	fi, _ := os.Stat(path)
+	b.SetBytes(fi.Size())
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := AnalyzeRecentResultsFullWithOptions(path, monitor.SchemaVersion, 1000, AnalyzeOptions{ParseWorkers: workers}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkAnalyzeParse_Sequential(b *testing.B) { benchmarkAnalyzeParse(b, 1) }
+func BenchmarkAnalyzeParse_Workers4(b *testing.B)   { benchmarkAnalyzeParse(b, 4) }
+func BenchmarkAnalyzeParse_Workers8(b *testing.B)   { benchmarkAnalyzeParse(b, 8) }
+func BenchmarkAnalyzeParse_AllCPUs(b *testing.B)    { benchmarkAnalyzeParse(b, 0) }
@@ -0,0 +1,237 @@
+package analysis
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/iafilius/InternetQualityMonitor/src/monitor"
+)
+
+// probeLine is what analysis keeps of a line written by a non-HTTP probe (monitor.Measurer).
+// Such lines have no transfer, so they are kept out of the HTTP aggregates and summarized per
+// probe type by applyProbeRollup.
+type probeLine struct {
+	probeType string
+	failed    bool // probe_error set
+	// ping
+	pingSent, pingRecv int
+	pingRTTs           []float64
+	pingJitter         float64
+	// dns
+	dnsMs float64
+	// soak
+	soakKbps          []float64
+	soakIntervalMs    int64
+	soakInterruptions int
+	// iperf3
+	iperf3TCP *monitor.IPerf3TCP
+	iperf3UDP *monitor.IPerf3UDP
+}
+
+// probeLineOf returns nil for HTTP lines, including lines from before probe_type existed.
+func probeLineOf(sr *monitor.SiteResult) *probeLine {
+	pt := strings.ToLower(strings.TrimSpace(sr.ProbeType))
+	if pt == "" || pt == monitor.ProbeHTTP {
+		return nil
+	}
+	p := &probeLine{probeType: pt, failed: sr.ProbeError != ""}
+	switch pt {
+	case monitor.ProbePing:
+		if pr := sr.Ping; pr != nil {
+			p.pingSent, p.pingRecv, p.pingRTTs = pr.Sent, pr.Received, pr.RTTsMs
+			// recomputed from the RTTs so lines from before jitter_ms get it too
+			p.pingJitter = monitor.InterarrivalJitter(pr.RTTsMs)
+		}
+	case monitor.ProbeDNS:
+		p.dnsMs = float64(sr.DNSTimeMs)
+	case monitor.ProbeSoak:
+		if sk := sr.Soak; sk != nil && sk.IntervalMs > 0 {
+			p.soakKbps, p.soakIntervalMs, p.soakInterruptions = sk.SamplesKbps, sk.IntervalMs, sk.Interruptions
+		}
+	case monitor.ProbeIPerf3:
+		if ip := sr.IPerf3; ip != nil {
+			p.iperf3TCP = ip.TCP
+			if u := ip.UDP; u != nil && u.Error == "" {
+				p.iperf3UDP = u
+			}
+		}
+	}
+	return p
+}
+
+// applyProbeRollup fills the probe fields of s from a batch's probe lines; httpLines is the
+// number of HTTP lines, counted as probe_lines["http"] so mixed batches show the split.
+func applyProbeRollup(s *BatchSummary, probes []*probeLine, httpLines int) {
+	if len(probes) == 0 {
+		return
+	}
+	s.ProbeLines = map[string]int{}
+	if httpLines > 0 {
+		s.ProbeLines[monitor.ProbeHTTP] = httpLines
+	}
+	var rtts, jitters []float64
+	var sent, recv, dnsFailed int
+	var dnsMs []float64
+	var soakKbps, soakCoVs []float64
+	var soakDropS float64
+	var iperf3Kbps, udpKbps, udpJitters []float64
+	var iperf3Failed int
+	var udpLost, udpPackets int64
+	for _, p := range probes {
+		s.ProbeLines[p.probeType]++
+		switch p.probeType {
+		case monitor.ProbePing:
+			s.PingLines++
+			sent += p.pingSent
+			recv += p.pingRecv
+			rtts = append(rtts, p.pingRTTs...)
+			if len(p.pingRTTs) >= 2 {
+				jitters = append(jitters, p.pingJitter)
+			}
+		case monitor.ProbeDNS:
+			s.DNSProbeLines++
+			if p.failed {
+				dnsFailed++
+			} else {
+				dnsMs = append(dnsMs, p.dnsMs)
+			}
+		case monitor.ProbeSoak:
+			s.SoakLines++
+			if len(p.soakKbps) == 0 {
+				continue
+			}
+			secs := float64(len(p.soakKbps)) * float64(p.soakIntervalMs) / 1000
+			s.SoakSeconds += secs
+			s.SoakInterruptions += p.soakInterruptions
+			soakKbps = append(soakKbps, p.soakKbps...)
+			mean := meanOf(p.soakKbps)
+			if len(p.soakKbps) >= 2 && mean > 0 {
+				soakCoVs = append(soakCoVs, stddevOf(p.soakKbps, mean)/mean*100)
+			}
+			events, longest, dropS := soakDrops(p.soakKbps, p.soakIntervalMs)
+			s.SoakDropEvents += events
+			soakDropS += dropS
+			s.SoakLongestDropS = max(s.SoakLongestDropS, longest)
+		case monitor.ProbeIPerf3:
+			s.IPerf3Lines++
+			if p.failed || p.iperf3TCP == nil {
+				iperf3Failed++
+				continue
+			}
+			iperf3Kbps = append(iperf3Kbps, p.iperf3TCP.ReceivedKbps)
+			s.IPerf3Retransmits += p.iperf3TCP.Retransmits
+			if u := p.iperf3UDP; u != nil {
+				s.IPerf3UDPLines++
+				udpKbps, udpJitters = append(udpKbps, u.Kbps), append(udpJitters, u.JitterMs)
+				udpLost += u.LostPackets
+				udpPackets += u.Packets
+			}
+		}
+	}
+	if sent > 0 {
+		s.PingLossPct = float64(sent-recv) / float64(sent) * 100
+	}
+	if len(rtts) > 0 {
+		sort.Float64s(rtts)
+		s.AvgPingRTTMs = meanOf(rtts)
+		s.P50PingRTTMs = nearestRank(rtts, 50)
+		s.P95PingRTTMs = nearestRank(rtts, 95)
+	}
+	if len(jitters) > 0 {
+		sort.Float64s(jitters)
+		s.PingJitterLines = len(jitters)
+		s.AvgPingJitterMs = meanOf(jitters)
+		s.P95PingJitterMs = nearestRank(jitters, 95)
+	}
+	if s.DNSProbeLines > 0 {
+		s.DNSProbeErrorRatePct = float64(dnsFailed) / float64(s.DNSProbeLines) * 100
+		s.AvgDNSProbeMs = meanOf(dnsMs)
+	}
+	if len(soakKbps) > 0 {
+		sort.Float64s(soakKbps)
+		s.AvgSoakKbps = meanOf(soakKbps)
+		s.MedianSoakKbps = nearestRank(soakKbps, 50)
+		s.P5SoakKbps = nearestRank(soakKbps, 5)
+		s.SoakCoVPct = meanOf(soakCoVs)
+		s.SoakTimeInDropPct = soakDropS / s.SoakSeconds * 100
+		s.SoakDropsPerHour = float64(s.SoakDropEvents) / (s.SoakSeconds / 3600)
+	}
+	if s.IPerf3Lines > 0 {
+		s.IPerf3ErrorRatePct = float64(iperf3Failed) / float64(s.IPerf3Lines) * 100
+	}
+	if len(iperf3Kbps) > 0 {
+		sort.Float64s(iperf3Kbps)
+		s.AvgIPerf3TCPKbps = meanOf(iperf3Kbps)
+		s.MedianIPerf3TCPKbps = nearestRank(iperf3Kbps, 50)
+		if s.AvgSpeed > 0 && s.AvgIPerf3TCPKbps > 0 {
+			s.IPerf3HTTPSpeedPct = s.AvgSpeed / s.AvgIPerf3TCPKbps * 100
+		}
+	}
+	if s.IPerf3UDPLines > 0 {
+		s.AvgIPerf3UDPKbps, s.AvgIPerf3UDPJitterMs = meanOf(udpKbps), meanOf(udpJitters)
+		if udpPackets > 0 {
+			s.IPerf3UDPLossPct = float64(udpLost) / float64(udpPackets) * 100
+		}
+	}
+}
+
+// Soak drop detection: a drop is a run of samples below soakDropFrac of the line's own median
+// speed that lasts at least soakMinDropMs, so single slow seconds (sampling jitter, a GC pause
+// on the server) are not counted. Outage seconds are 0 kbps and always qualify.
+const (
+	soakDropFrac  = 0.5
+	soakMinDropMs = 2000
+)
+
+// soakDrops returns the drop events of one soak series, the longest in seconds and the total
+// seconds spent in drops.
+func soakDrops(kbps []float64, intervalMs int64) (events int, longestS, totalS float64) {
+	sorted := append([]float64(nil), kbps...)
+	sort.Float64s(sorted)
+	limit := nearestRank(sorted, 50) * soakDropFrac
+	run := 0
+	flush := func() {
+		if ms := int64(run) * intervalMs; run > 0 && ms >= soakMinDropMs {
+			events++
+			longestS = max(longestS, float64(ms)/1000)
+			totalS += float64(ms) / 1000
+		}
+		run = 0
+	}
+	for _, v := range kbps {
+		if v < limit {
+			run++
+			continue
+		}
+		flush()
+	}
+	flush()
+	return events, longestS, totalS
+}
+
+func meanOf(a []float64) float64 {
+	if len(a) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, v := range a {
+		sum += v
+	}
+	return sum / float64(len(a))
+}
+
+// stddevOf is the population standard deviation of a around mean.
+func stddevOf(a []float64, mean float64) float64 {
+	sum := 0.0
+	for _, v := range a {
+		sum += (v - mean) * (v - mean)
+	}
+	return math.Sqrt(sum / float64(len(a)))
+}
+
+// nearestRank is the p-th percentile of sorted a (nearest-rank, like the batch percentiles).
+func nearestRank(sorted []float64, p float64) float64 {
+	idx := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	return sorted[min(max(idx, 0), len(sorted)-1)]
+}
@@ -0,0 +1,326 @@
+package analysis
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/iafilius/InternetQualityMonitor/src/monitor"
+)
+
+// ParseRetention parses a retention period: days ("90d"), weeks ("12w") or any Go duration
+// ("36h"). It must be positive.
+func ParseRetention(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	var d time.Duration
+	if n, ok := strings.CutSuffix(s, "d"); ok {
+		v, err := strconv.Atoi(n)
+		if err != nil {
+			return 0, fmt.Errorf("retention %q: want e.g. 90d, 12w or 36h", s)
+		}
+		d = time.Duration(v) * 24 * time.Hour
+	} else if n, ok := strings.CutSuffix(s, "w"); ok {
+		v, err := strconv.Atoi(n)
+		if err != nil {
+			return 0, fmt.Errorf("retention %q: want e.g. 90d, 12w or 36h", s)
+		}
+		d = time.Duration(v) * 7 * 24 * time.Hour
+	} else {
+		var err error
+		if d, err = time.ParseDuration(s); err != nil {
+			return 0, fmt.Errorf("retention %q: want e.g. 90d, 12w or 36h", s)
+		}
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("retention %q must be positive", s)
+	}
+	return d, nil
+}
+
+// PruneOptions controls PruneResultsFile.
+type PruneOptions struct {
+	// DryRun only reports what would be removed; nothing is written.
+	DryRun bool
+	// BackupPath receives the pruned lines, gzip-compressed; empty picks
+	// <file>.pruned-<UTC time>.jsonl.gz next to the results file.
+	BackupPath string
+}
+
+// PruneReport describes a prune: the lines and batches kept and removed and where the removed
+// ones went. Batches are kept or removed whole.
+type PruneReport struct {
+	Path          string    `json:"path"`
+	Cutoff        time.Time `json:"cutoff"`
+	DryRun        bool      `json:"dry_run,omitempty"`
+	Lines         int       `json:"lines"`
+	KeptLines     int       `json:"kept_lines"`
+	PrunedLines   int       `json:"pruned_lines"`
+	KeptBatches   int       `json:"kept_batches"`
+	PrunedBatches int       `json:"pruned_batches"`
+	// run tags of the oldest and newest pruned batch
+	OldestPruned string `json:"oldest_pruned,omitempty"`
+	NewestPruned string `json:"newest_pruned,omitempty"`
+	BackupPath   string `json:"backup_path,omitempty"`
+}
+
+func (r *PruneReport) String() string {
+	var b strings.Builder
+	verb := "removed"
+	if r.DryRun {
+		verb = "would remove"
+	}
+	fmt.Fprintf(&b, "[prune] %s: batches before %s\n", r.Path, r.Cutoff.UTC().Format(time.RFC3339))
+	fmt.Fprintf(&b, "[prune] %s %d batches (%d lines), keeping %d batches (%d lines)\n", verb, r.PrunedBatches, r.PrunedLines, r.KeptBatches, r.KeptLines)
+	if r.PrunedBatches > 0 {
+		fmt.Fprintf(&b, "[prune] pruned run tags %s … %s\n", r.OldestPruned, r.NewestPruned)
+	}
+	if r.BackupPath != "" {
+		fmt.Fprintf(&b, "[prune] pruned lines backed up to %s\n", r.BackupPath)
+	}
+	return b.String()
+}
+
+// pruneLine is the part of an envelope a prune looks at.
+type pruneLine struct {
+	Meta *struct {
+		RunTag       string `json:"run_tag"`
+		TimestampUTC string `json:"timestamp_utc"`
+	} `json:"meta"`
+}
+
+// PruneResultsFile removes the batches of the results file at path that started before cutoff
+// (the earliest line timestamp of a run tag, else the time in the tag). Lines without a run tag
+// or timestamp and unparsable lines are kept; fsck handles those. Unless DryRun, the pruned
+// lines are written to a gzip backup first and the file is then replaced atomically by the kept
+// lines (gzip-compressed again when it was). It refuses while a monitor has the file open (the
+// writer's file lock, see monitor.LockResultsFile) and holds the lock until the file is
+// replaced, so a monitor loses nothing: stop it or retry. Without file locks it fails when the
+// file changes while pruning instead. zstd files must be decompressed first.
+func PruneResultsFile(path string, cutoff time.Time, opts PruneOptions) (*PruneReport, error) {
+	rep := &PruneReport{Path: path, Cutoff: cutoff, DryRun: opts.DryRun}
+	if !opts.DryRun {
+		lock, err := monitor.LockResultsFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("prune: %w", err)
+		}
+		defer lock.Close()
+	}
+	before, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	gz, err := isGzipFile(path)
+	if err != nil {
+		return nil, err
+	}
+	// pass 1: batch start times
+	starts := map[string]time.Time{}
+	if err := scanPruneLines(path, func(_ []byte, tag string, ts time.Time) error {
+		if tag == "" {
+			return nil
+		}
+		if s, ok := starts[tag]; !ok || (!ts.IsZero() && (s.IsZero() || ts.Before(s))) {
+			starts[tag] = ts
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	pruned := map[string]bool{}
+	for tag, ts := range starts {
+		if ts.IsZero() {
+			ts = BatchSummary{RunTag: tag}.StartTime()
+		}
+		if !ts.IsZero() && ts.Before(cutoff) {
+			pruned[tag] = true
+			if rep.OldestPruned == "" || tag < rep.OldestPruned {
+				rep.OldestPruned = tag
+			}
+			if tag > rep.NewestPruned {
+				rep.NewestPruned = tag
+			}
+		}
+	}
+	rep.PrunedBatches, rep.KeptBatches = len(pruned), len(starts)-len(pruned)
+	if opts.DryRun || len(pruned) == 0 {
+		err := scanPruneLines(path, func(_ []byte, tag string, _ time.Time) error {
+			rep.Lines++
+			if pruned[tag] {
+				rep.PrunedLines++
+			} else {
+				rep.KeptLines++
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return rep, nil
+	}
+	// pass 2: pruned lines to the backup, kept lines to a temporary file next to path
+	rep.BackupPath = opts.BackupPath
+	if rep.BackupPath == "" {
+		rep.BackupPath = fmt.Sprintf("%s.pruned-%s.jsonl.gz", strings.TrimSuffix(path, ".gz"), time.Now().UTC().Format("20060102T150405Z"))
+	}
+	backup, err := newGzipFile(rep.BackupPath)
+	if err != nil {
+		return nil, err
+	}
+	tmpPath := filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".prune.tmp")
+	kept, err := os.Create(tmpPath)
+	if err != nil {
+		backup.Close()
+		return nil, err
+	}
+	var keptW io.Writer = kept
+	var keptGz *gzip.Writer
+	if gz {
+		keptGz = gzip.NewWriter(kept)
+		keptW = keptGz
+	}
+	keptBuf := bufio.NewWriter(keptW)
+	// on failure the original is untouched, so the partial copies go
+	fail := func(err error) (*PruneReport, error) {
+		kept.Close()
+		os.Remove(tmpPath)
+		backup.Close()
+		os.Remove(rep.BackupPath)
+		return nil, err
+	}
+	err = scanPruneLines(path, func(line []byte, tag string, _ time.Time) error {
+		rep.Lines++
+		w := keptBuf
+		if pruned[tag] {
+			rep.PrunedLines++
+			w = backup.w
+		} else {
+			rep.KeptLines++
+		}
+		if _, err := w.Write(line); err != nil {
+			return err
+		}
+		return w.WriteByte('\n')
+	})
+	if err != nil {
+		return fail(err)
+	}
+	// the backup must be complete before anything is removed from the original
+	if err := backup.Close(); err != nil {
+		return fail(err)
+	}
+	if err := keptBuf.Flush(); err != nil {
+		return fail(err)
+	}
+	if keptGz != nil {
+		if err := keptGz.Close(); err != nil {
+			return fail(err)
+		}
+	}
+	if err := kept.Sync(); err != nil {
+		return fail(err)
+	}
+	if err := kept.Close(); err != nil {
+		return fail(err)
+	}
+	if after, err := os.Stat(path); err != nil || after.Size() != before.Size() || !after.ModTime().Equal(before.ModTime()) {
+		return fail(fmt.Errorf("prune: %s changed while pruning (is the monitor still writing to it?); nothing was removed", path))
+	}
+	if err := os.Chmod(tmpPath, before.Mode().Perm()); err != nil {
+		return fail(err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fail(err)
+	}
+	return rep, nil
+}
+
+// scanPruneLines calls fn with every non-empty line of the results file (trimmed) and its run
+// tag and timestamp; both are empty for lines that do not parse.
+func scanPruneLines(path string, fn func(line []byte, tag string, ts time.Time) error) error {
+	f, err := OpenResults(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	r := bufio.NewReaderSize(f, 256*1024)
+	for {
+		line, rerr := r.ReadBytes('\n')
+		if trimmed := bytes.TrimSpace(line); len(trimmed) > 0 {
+			var pl pruneLine
+			var tag string
+			var ts time.Time
+			if json.Unmarshal(trimmed, &pl) == nil && pl.Meta != nil {
+				tag = pl.Meta.RunTag
+				ts, _ = time.Parse(time.RFC3339Nano, pl.Meta.TimestampUTC)
+			}
+			if err := fn(trimmed, tag, ts); err != nil {
+				return err
+			}
+		}
+		if rerr != nil {
+			if errors.Is(rerr, io.EOF) {
+				return nil
+			}
+			return rerr
+		}
+	}
+}
+
+func isGzipFile(path string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	head := make([]byte, 4)
+	n, _ := io.ReadFull(f, head)
+	if bytes.HasPrefix(head[:n], zstdMagic) {
+		return false, fmt.Errorf("prune: %s is zstd-compressed; decompress it first", path)
+	}
+	return bytes.HasPrefix(head[:n], gzipMagic), nil
+}
+
+// gzipFile is a buffered gzip writer on a new file.
+type gzipFile struct {
+	f *os.File
+	z *gzip.Writer
+	w *bufio.Writer
+}
+
+func newGzipFile(path string) (*gzipFile, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	z := gzip.NewWriter(f)
+	return &gzipFile{f: f, z: z, w: bufio.NewWriter(z)}, nil
+}
+
+// Close flushes and syncs the file; calling it again is a no-op.
+func (g *gzipFile) Close() error {
+	if g.f == nil {
+		return nil
+	}
+	f := g.f
+	g.f = nil
+	err := g.w.Flush()
+	if zerr := g.z.Close(); err == nil {
+		err = zerr
+	}
+	if serr := f.Sync(); err == nil {
+		err = serr
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,175 @@
+package analysis
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iafilius/InternetQualityMonitor/src/monitor"
+)
+
+// writePruneFixture writes an old batch (two lines), a recent one and an unparsable line,
+// gzip-compressed when gz is set.
+func writePruneFixture(t *testing.T, now time.Time, gz bool) string {
+	t.Helper()
+	name := "results.jsonl"
+	if gz {
+		name += ".gz"
+	}
+	path := filepath.Join(t.TempDir(), name)
+	var data bytes.Buffer
+	line := func(tag string, ts time.Time, url string) {
+		env := monitor.ResultEnvelope{Meta: &monitor.Meta{TimestampUTC: ts.UTC().Format(time.RFC3339Nano), RunTag: tag, SchemaVersion: monitor.SchemaVersion}, SiteResult: &monitor.SiteResult{URL: url}}
+		b, _ := json.Marshal(&env)
+		data.Write(append(b, '\n'))
+	}
+	old := now.Add(-100 * 24 * time.Hour)
+	line("old", old, "http://a/1")
+	line("recent", now.Add(-time.Hour), "http://a/1")
+	line("old", old.Add(time.Minute), "http://a/2")
+	data.WriteString("not json\n")
+	out := data.Bytes()
+	if gz {
+		var zb bytes.Buffer
+		zw := gzip.NewWriter(&zb)
+		zw.Write(out)
+		zw.Close()
+		out = zb.Bytes()
+	}
+	if err := os.WriteFile(path, out, 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	return path
+}
+
+func readMaybeGzip(t *testing.T, path string) string {
+	t.Helper()
+	f, err := OpenResults(path)
+	if err != nil {
+		t.Fatalf("open %s: %v", path, err)
+	}
+	defer f.Close()
+	b, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatalf("read %s: %v", path, err)
+	}
+	return string(b)
+}
+
+func TestPruneResultsFile_DryRunWritesNothing(t *testing.T) {
+	now := time.Now()
+	path := writePruneFixture(t, now, false)
+	before, _ := os.ReadFile(path)
+	rep, err := PruneResultsFile(path, now.Add(-90*24*time.Hour), PruneOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("prune: %v", err)
+	}
+	if rep.PrunedBatches != 1 || rep.KeptBatches != 1 || rep.PrunedLines != 2 || rep.KeptLines != 2 || rep.BackupPath != "" {
+		t.Fatalf("report %+v", rep)
+	}
+	if !strings.Contains(rep.String(), "would remove 1 batches") {
+		t.Fatalf("report text: %q", rep.String())
+	}
+	after, _ := os.ReadFile(path)
+	if !bytes.Equal(before, after) {
+		t.Fatalf("dry run modified the file")
+	}
+	if m, _ := filepath.Glob(filepath.Join(filepath.Dir(path), "*pruned*")); len(m) != 0 {
+		t.Fatalf("dry run wrote a backup: %v", m)
+	}
+}
+
+func TestPruneResultsFile_BacksUpAndRewrites(t *testing.T) {
+	for _, gz := range []bool{false, true} {
+		now := time.Now()
+		path := writePruneFixture(t, now, gz)
+		rep, err := PruneResultsFile(path, now.Add(-90*24*time.Hour), PruneOptions{})
+		if err != nil {
+			t.Fatalf("gz=%v prune: %v", gz, err)
+		}
+		kept := readMaybeGzip(t, path)
+		if strings.Contains(kept, `"run_tag":"old"`) || !strings.Contains(kept, `"run_tag":"recent"`) || !strings.Contains(kept, "not json") {
+			t.Fatalf("gz=%v kept lines:\n%s", gz, kept)
+		}
+		if isGz, _ := isGzipFile(path); isGz != gz {
+			t.Fatalf("gz=%v rewritten file compressed=%v", gz, isGz)
+		}
+		if fi, _ := os.Stat(path); fi.Mode().Perm() != 0o600 {
+			t.Fatalf("gz=%v mode %v want 0600", gz, fi.Mode().Perm())
+		}
+		backup := readMaybeGzip(t, rep.BackupPath)
+		if strings.Count(backup, `"run_tag":"old"`) != 2 || strings.Contains(backup, "recent") {
+			t.Fatalf("gz=%v backup:\n%s", gz, backup)
+		}
+		// nothing left to prune: the file stays as it is
+		again, err := PruneResultsFile(path, now.Add(-90*24*time.Hour), PruneOptions{})
+		if err != nil || again.PrunedBatches != 0 || again.BackupPath != "" {
+			t.Fatalf("gz=%v second prune: %+v %v", gz, again, err)
+		}
+	}
+}
+
+func TestPruneResultsFile_RefusesWhileInUse(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no file locks")
+	}
+	now := time.Now()
+	path := writePruneFixture(t, now, false)
+	held, err := monitor.LockResultsFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	before, _ := os.ReadFile(path)
+	if _, err := PruneResultsFile(path, now.Add(-90*24*time.Hour), PruneOptions{}); !errors.Is(err, monitor.ErrResultsFileInUse) {
+		t.Fatalf("prune of a file in use: %v", err)
+	}
+	if after, _ := os.ReadFile(path); !bytes.Equal(before, after) {
+		t.Fatalf("file modified while in use")
+	}
+	if rep, err := PruneResultsFile(path, now.Add(-90*24*time.Hour), PruneOptions{DryRun: true}); err != nil || rep.PrunedBatches != 1 {
+		t.Fatalf("dry run of a file in use: %+v %v", rep, err)
+	}
+	held.Close()
+	if _, err := PruneResultsFile(path, now.Add(-90*24*time.Hour), PruneOptions{}); err != nil {
+		t.Fatalf("prune after the writer closed: %v", err)
+	}
+}
+
+func TestPruneResultsFile_KeepsExistingBackup(t *testing.T) {
+	now := time.Now()
+	path := writePruneFixture(t, now, false)
+	backup := filepath.Join(filepath.Dir(path), "backup.jsonl.gz")
+	os.WriteFile(backup, []byte("keep me"), 0o644)
+	before, _ := os.ReadFile(path)
+	if _, err := PruneResultsFile(path, now.Add(-90*24*time.Hour), PruneOptions{BackupPath: backup}); err == nil {
+		t.Fatalf("expected an error for an existing backup")
+	}
+	if b, _ := os.ReadFile(backup); string(b) != "keep me" {
+		t.Fatalf("existing backup overwritten")
+	}
+	if after, _ := os.ReadFile(path); !bytes.Equal(before, after) {
+		t.Fatalf("file modified after a failed prune")
+	}
+}
+
+func TestParseRetention(t *testing.T) {
+	cases := map[string]time.Duration{"90d": 90 * 24 * time.Hour, "12w": 84 * 24 * time.Hour, "36h": 36 * time.Hour, " 1d ": 24 * time.Hour}
+	for in, want := range cases {
+		if got, err := ParseRetention(in); err != nil || got != want {
+			t.Errorf("ParseRetention(%q) = %v, %v want %v", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "0d", "-5d", "d", "90 days", "1y"} {
+		if _, err := ParseRetention(in); err == nil {
+			t.Errorf("ParseRetention(%q): expected an error", in)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package analysis
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// QualityScore is the network "weather" of a batch: one 0–100 number for day-to-day comparison,
+// with the components it was built from (each 0–100, higher is better):
+//   - SpeedPct: median speed (average when no median) relative to the reference speed, capped at 100
+//   - TTFBPct: reference TTFB relative to the average TTFB, capped at 100
+//   - JitterPct: 100 minus JitterPenalty points per percent of throughput jitter
+//   - StallPct: 100 minus StallPenalty points per percent of stalled transfers
+//   - ErrorPct: 100 minus ErrorPenalty points per percent of failed lines
+//
+// Score is their weighted mean (QualityScoreConfig weights). A batch without any successful
+// transfer has speed and TTFB 0 and no jitter component (left out, the rest re-weighted).
+type QualityScore struct {
+	Score     float64 `json:"score"`
+	SpeedPct  float64 `json:"speed_pct"`
+	TTFBPct   float64 `json:"ttfb_pct"`
+	JitterPct float64 `json:"jitter_pct"`
+	StallPct  float64 `json:"stall_pct"`
+	ErrorPct  float64 `json:"error_pct"`
+}
+
+// QualityScoreConfig holds the weights and references of the quality score.
+type QualityScoreConfig struct {
+	SpeedWeight, TTFBWeight, JitterWeight, StallWeight, ErrorWeight float64
+	SpeedRefKbps                                                    float64 // speed that scores 100
+	TTFBRefMs                                                       float64 // TTFB that scores 100
+	JitterPenalty, StallPenalty, ErrorPenalty                       float64 // points lost per percent
+}
+
+// DefaultQualityScoreConfig weighs speed 30, TTFB 25 and jitter, stalls and errors 15 each;
+// 25 Mbps and 200 ms score full marks, and 2% jitter, 5% stalls or 5% errors cost 10 points.
+var DefaultQualityScoreConfig = QualityScoreConfig{
+	SpeedWeight: 30, TTFBWeight: 25, JitterWeight: 15, StallWeight: 15, ErrorWeight: 15,
+	SpeedRefKbps: 25000, TTFBRefMs: 200,
+	JitterPenalty: 5, StallPenalty: 2, ErrorPenalty: 2,
+}
+
+var qualityScoreConfig = DefaultQualityScoreConfig
+
+// SetQualityScoreConfig sets the weights and references used for BatchSummary.QualityScore by
+// later analyses.
+func SetQualityScoreConfig(c QualityScoreConfig) { qualityScoreConfig = c }
+
+// qualityScoreKeys maps the spec keys of ParseQualityScoreConfig to the config fields.
+var qualityScoreKeys = []struct {
+	key string
+	ptr func(*QualityScoreConfig) *float64
+}{
+	{"speed", func(c *QualityScoreConfig) *float64 { return &c.SpeedWeight }},
+	{"ttfb", func(c *QualityScoreConfig) *float64 { return &c.TTFBWeight }},
+	{"jitter", func(c *QualityScoreConfig) *float64 { return &c.JitterWeight }},
+	{"stall", func(c *QualityScoreConfig) *float64 { return &c.StallWeight }},
+	{"errors", func(c *QualityScoreConfig) *float64 { return &c.ErrorWeight }},
+	{"speed_ref_kbps", func(c *QualityScoreConfig) *float64 { return &c.SpeedRefKbps }},
+	{"ttfb_ref_ms", func(c *QualityScoreConfig) *float64 { return &c.TTFBRefMs }},
+	{"jitter_penalty", func(c *QualityScoreConfig) *float64 { return &c.JitterPenalty }},
+	{"stall_penalty", func(c *QualityScoreConfig) *float64 { return &c.StallPenalty }},
+	{"error_penalty", func(c *QualityScoreConfig) *float64 { return &c.ErrorPenalty }},
+}
+
+// ParseQualityScoreConfig reads "key=value,..." over the defaults, e.g.
+// "speed=40,ttfb=20,speed_ref_kbps=100000". Keys: speed, ttfb, jitter, stall, errors (weights),
+// speed_ref_kbps, ttfb_ref_ms, jitter_penalty, stall_penalty, error_penalty.
+func ParseQualityScoreConfig(spec string) (QualityScoreConfig, error) {
+	c := DefaultQualityScoreConfig
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(part, "=")
+		if !ok {
+			return c, fmt.Errorf("quality score: %q is not key=value", part)
+		}
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil || f < 0 || math.IsInf(f, 0) {
+			return c, fmt.Errorf("quality score: %s needs a non-negative number, got %q", k, v)
+		}
+		found := false
+		for _, qk := range qualityScoreKeys {
+			if qk.key == strings.ToLower(strings.TrimSpace(k)) {
+				*qk.ptr(&c) = f
+				found = true
+				break
+			}
+		}
+		if !found {
+			return c, fmt.Errorf("quality score: unknown key %q", k)
+		}
+	}
+	if c.SpeedWeight+c.TTFBWeight+c.JitterWeight+c.StallWeight+c.ErrorWeight == 0 {
+		return c, fmt.Errorf("quality score: all weights are 0")
+	}
+	if c.SpeedRefKbps == 0 || c.TTFBRefMs == 0 {
+		return c, fmt.Errorf("quality score: speed_ref_kbps and ttfb_ref_ms must be > 0")
+	}
+	return c, nil
+}
+
+// String formats c as a spec ParseQualityScoreConfig accepts.
+func (c QualityScoreConfig) String() string {
+	parts := make([]string, 0, len(qualityScoreKeys))
+	for _, qk := range qualityScoreKeys {
+		parts = append(parts, qk.key+"="+strconv.FormatFloat(*qk.ptr(&c), 'g', -1, 64))
+	}
+	return strings.Join(parts, ",")
+}
+
+// qualityScore scores an HTTP batch; nil when it has no HTTP lines.
+func qualityScore(s *BatchSummary, c QualityScoreConfig) *QualityScore {
+	if s.Lines == 0 {
+		return nil
+	}
+	clamp := func(v float64) float64 { return math.Max(0, math.Min(100, v)) }
+	q := &QualityScore{
+		StallPct: clamp(100 - c.StallPenalty*s.StallRatePct),
+		ErrorPct: clamp(100 - c.ErrorPenalty*float64(s.ErrorLines)/float64(s.Lines)*100),
+	}
+	// a batch where nothing was transferred scores 0 for speed and TTFB; jitter needs transfers
+	jitter := -1.0
+	if s.ErrorLines < s.Lines && (s.AvgSpeed > 0 || s.MedianSpeed > 0) {
+		speed := s.MedianSpeed
+		if speed <= 0 {
+			speed = s.AvgSpeed
+		}
+		q.SpeedPct = clamp(speed / c.SpeedRefKbps * 100)
+		q.TTFBPct = 100
+		if s.AvgTTFB > 0 {
+			q.TTFBPct = clamp(c.TTFBRefMs / s.AvgTTFB * 100)
+		}
+		q.JitterPct = clamp(100 - c.JitterPenalty*s.AvgJitterPct)
+		jitter = q.JitterPct
+	}
+	comps := [5]float64{q.SpeedPct, q.TTFBPct, jitter, q.StallPct, q.ErrorPct}
+	weights := [5]float64{c.SpeedWeight, c.TTFBWeight, c.JitterWeight, c.StallWeight, c.ErrorWeight}
+	sum, wsum := 0.0, 0.0
+	for i, v := range comps {
+		if v < 0 {
+			continue
+		}
+		sum += v * weights[i]
+		wsum += weights[i]
+	}
+	if wsum > 0 {
+		q.Score = sum / wsum
+	}
+	return q
+}
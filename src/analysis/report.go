@@ -0,0 +1,450 @@
+package analysis
+
+import (
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Period report (monitor --report): the batches of the last days condensed into one page for a
+// weekly status mail — per-situation uptime, speed and TTFB, SLA attainment, the worst batches
+// and notable events. Markdown and HTML render the same content.
+
+// Report limits and anomaly thresholds.
+const (
+	ReportWorstBatches = 5
+	ReportMaxAnomalies = 20
+	// A batch is a speed drop below this share of its situation's median P50 speed over the
+	// period, and a TTFB spike above this multiple of the situation's median P95 TTFB.
+	reportSpeedDropPct  = 50.0
+	reportTTFBSpikeMult = 2.0
+)
+
+// Anomaly kinds.
+const (
+	AnomalyOutage       = "outage"        // every line of the batch failed
+	AnomalySpeedDrop    = "speed_drop"    // P50 speed under half the situation's median
+	AnomalyTTFBSpike    = "ttfb_spike"    // P95 TTFB over twice the situation's median
+	AnomalyDNSHijack    = "dns_hijack"    // resolver answered nonexistent names
+	AnomalyConfigChange = "config_change" // monitor settings or sites changed
+)
+
+// PeriodReport summarizes the batches started in (From, To].
+type PeriodReport struct {
+	From       time.Time       `json:"from"`
+	To         time.Time       `json:"to"`
+	SLA        SLAThresholds   `json:"sla"`
+	Total      ReportGroup     `json:"total"`
+	Situations []ReportGroup   `json:"situations,omitempty"` // by name; only with more than one situation
+	Worst      []ReportBatch   `json:"worst,omitempty"`
+	Anomalies  []ReportAnomaly `json:"anomalies,omitempty"` // oldest first, at most ReportMaxAnomalies
+	// MoreAnomalies counts the anomalies left out of Anomalies.
+	MoreAnomalies int `json:"more_anomalies,omitempty"`
+	// Differences test each pair of situations (CompareSituations), so a gap between them can be
+	// told apart from batch-to-batch noise; pairs with too few batches are left out.
+	Differences []ReportDifference `json:"differences,omitempty"`
+}
+
+// ReportGroup is the period aggregate of one situation (or of all batches).
+type ReportGroup struct {
+	Situation string `json:"situation,omitempty"`
+	RollingPoint
+	// UptimePct is the share of batches with at least one successful line.
+	UptimePct    float64 `json:"uptime_pct"`
+	P50SpeedKbps float64 `json:"p50_speed_kbps,omitempty"` // median of the batch P50 speeds
+	AvgTTFBMs    float64 `json:"avg_ttfb_ms,omitempty"`    // line-weighted
+}
+
+// ReportDifference is a situation comparison of one metric ("speed": batch P50 speed in kbps,
+// "ttfb": average TTFB in ms).
+type ReportDifference struct {
+	Metric string `json:"metric"`
+	SignificanceTest
+}
+
+// ReportBatch is one of the worst batches of the period.
+type ReportBatch struct {
+	RunTag       string    `json:"run_tag"`
+	Situation    string    `json:"situation,omitempty"`
+	Start        time.Time `json:"start"`
+	P50SpeedKbps float64   `json:"p50_speed_kbps"`
+	P95TTFBMs    float64   `json:"p95_ttfb_ms,omitempty"`
+	ErrorRatePct float64   `json:"error_rate_pct"`
+	Score        float64   `json:"score,omitempty"` // quality score, when the batches carry one
+}
+
+// ReportAnomaly is a notable batch.
+type ReportAnomaly struct {
+	RunTag    string    `json:"run_tag"`
+	Situation string    `json:"situation,omitempty"`
+	Start     time.Time `json:"start"`
+	Kind      string    `json:"kind"`
+	Detail    string    `json:"detail"`
+}
+
+// BuildPeriodReport aggregates the batches that started after from and not after to. Batches
+// without a start time are ignored. The worst batches are ranked by quality score when the
+// batches carry one, else by P50 speed.
+func BuildPeriodReport(summaries []BatchSummary, from, to time.Time, sla SLAThresholds) *PeriodReport {
+	r := &PeriodReport{From: from, To: to, SLA: sla}
+	var in []BatchSummary
+	bySit := map[string][]BatchSummary{}
+	for _, s := range summaries {
+		t := s.StartTime()
+		if t.IsZero() || !t.After(from) || t.After(to) {
+			continue
+		}
+		in = append(in, s)
+		bySit[s.Situation] = append(bySit[s.Situation], s)
+	}
+	sort.SliceStable(in, func(i, j int) bool { return in[i].StartTime().Before(in[j].StartTime()) })
+	r.Total = reportGroup(in, sla)
+	if len(bySit) > 1 {
+		for name, rows := range bySit {
+			g := reportGroup(rows, sla)
+			g.Situation = name
+			r.Situations = append(r.Situations, g)
+		}
+		sort.Slice(r.Situations, func(i, j int) bool { return r.Situations[i].Situation < r.Situations[j].Situation })
+		for i, a := range r.Situations {
+			for _, b := range r.Situations[i+1:] {
+				speed, ttfb := CompareSituations(in, a.Situation, b.Situation)
+				if speed != nil {
+					r.Differences = append(r.Differences, ReportDifference{Metric: "speed", SignificanceTest: *speed})
+				}
+				if ttfb != nil {
+					r.Differences = append(r.Differences, ReportDifference{Metric: "ttfb", SignificanceTest: *ttfb})
+				}
+			}
+		}
+	}
+	r.Worst = worstBatches(in)
+	bases := map[string]situationBase{}
+	for name, rows := range bySit {
+		bases[name] = newSituationBase(rows)
+	}
+	for _, s := range in {
+		for _, a := range batchAnomalies(s, bases[s.Situation]) {
+			if len(r.Anomalies) < ReportMaxAnomalies {
+				r.Anomalies = append(r.Anomalies, a)
+			} else {
+				r.MoreAnomalies++
+			}
+		}
+	}
+	return r
+}
+
+func reportGroup(rows []BatchSummary, sla SLAThresholds) ReportGroup {
+	g := ReportGroup{RollingPoint: rollingPoint(rows, sla)}
+	var up int
+	var p50s []float64
+	var ttfbSum float64
+	var ttfbW int
+	for _, b := range rows {
+		if b.Lines > b.ErrorLines {
+			up++
+		}
+		if v := BatchP50Speed(b); v > 0 {
+			p50s = append(p50s, v)
+		}
+		if b.AvgTTFB > 0 {
+			ttfbSum += b.AvgTTFB * float64(b.Lines)
+			ttfbW += b.Lines
+		}
+	}
+	if len(rows) > 0 {
+		g.UptimePct = float64(up) / float64(len(rows)) * 100
+	}
+	if len(p50s) > 0 {
+		g.P50SpeedKbps = medianOf(p50s)
+	}
+	if ttfbW > 0 {
+		g.AvgTTFBMs = ttfbSum / float64(ttfbW)
+	}
+	return g
+}
+
+func worstBatches(rows []BatchSummary) []ReportBatch {
+	scored := false
+	for _, b := range rows {
+		if b.QualityScore != nil {
+			scored = true
+			break
+		}
+	}
+	var out []ReportBatch
+	for _, b := range rows {
+		rb := ReportBatch{RunTag: b.RunTag, Situation: b.Situation, Start: b.StartTime(), P50SpeedKbps: BatchP50Speed(b), P95TTFBMs: b.AvgP95TTFBMs}
+		if b.Lines > 0 {
+			rb.ErrorRatePct = float64(b.ErrorLines) / float64(b.Lines) * 100
+		}
+		if scored {
+			if b.QualityScore == nil {
+				continue
+			}
+			rb.Score = b.QualityScore.Score
+		}
+		out = append(out, rb)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if scored {
+			return out[i].Score < out[j].Score
+		}
+		return out[i].P50SpeedKbps < out[j].P50SpeedKbps
+	})
+	if len(out) > ReportWorstBatches {
+		out = out[:ReportWorstBatches]
+	}
+	return out
+}
+
+// situationBase is what a situation's batches are compared against: the medians over the period
+// of the batch P50 speeds and P95 TTFBs (0 with fewer than three batches to go by).
+type situationBase struct {
+	p50SpeedKbps, p95TTFBMs float64
+}
+
+func newSituationBase(rows []BatchSummary) situationBase {
+	var p50s, p95s []float64
+	for _, s := range rows {
+		if v := BatchP50Speed(s); v > 0 {
+			p50s = append(p50s, v)
+		}
+		if s.AvgP95TTFBMs > 0 {
+			p95s = append(p95s, s.AvgP95TTFBMs)
+		}
+	}
+	var base situationBase
+	if len(p50s) > 2 {
+		base.p50SpeedKbps = medianOf(p50s)
+	}
+	if len(p95s) > 2 {
+		base.p95TTFBMs = medianOf(p95s)
+	}
+	return base
+}
+
+// batchAnomalies lists what is notable about b against its situation's base.
+func batchAnomalies(b BatchSummary, base situationBase) []ReportAnomaly {
+	mk := func(kind, detail string) ReportAnomaly {
+		return ReportAnomaly{RunTag: b.RunTag, Situation: b.Situation, Start: b.StartTime(), Kind: kind, Detail: detail}
+	}
+	var out []ReportAnomaly
+	if b.Lines > 0 && b.ErrorLines == b.Lines {
+		out = append(out, mk(AnomalyOutage, fmt.Sprintf("all %d lines failed", b.Lines)))
+	} else {
+		if v, med := BatchP50Speed(b), base.p50SpeedKbps; v > 0 && v < med*reportSpeedDropPct/100 {
+			out = append(out, mk(AnomalySpeedDrop, fmt.Sprintf("P50 speed %.1f Mbps vs period median %.1f Mbps", v/1000, med/1000)))
+		}
+		if v, med := b.AvgP95TTFBMs, base.p95TTFBMs; med > 0 && v > med*reportTTFBSpikeMult {
+			out = append(out, mk(AnomalyTTFBSpike, fmt.Sprintf("P95 TTFB %.0f ms vs period median %.0f ms", v, med)))
+		}
+	}
+	if b.DNSHijackSuspected {
+		out = append(out, mk(AnomalyDNSHijack, "resolver answered nonexistent names"))
+	}
+	if len(b.ConfigChanges) > 0 {
+		out = append(out, mk(AnomalyConfigChange, strings.Join(b.ConfigChanges, ", ")))
+	}
+	return out
+}
+
+// reportTable is a rendered table: a header row and data rows of preformatted cells.
+type reportTable struct {
+	head []string
+	rows [][]string
+}
+
+func (r *PeriodReport) groupTable() reportTable {
+	t := reportTable{head: []string{"Situation", "Batches", "Uptime", "Avg speed", "P50 speed", "Avg TTFB", "P95 TTFB", "Stalls", "Errors", "SLA met"}}
+	groups := r.Situations
+	total := r.Total
+	total.Situation = "All"
+	groups = append(append([]ReportGroup(nil), groups...), total)
+	for _, g := range groups {
+		name := g.Situation
+		if name == "" {
+			name = "(none)"
+		}
+		sla := "—"
+		if g.SLABatches > 0 {
+			sla = fmt.Sprintf("%.1f%%", g.SLABothPct)
+		}
+		t.rows = append(t.rows, []string{name, fmt.Sprint(g.Batches), fmt.Sprintf("%.1f%%", g.UptimePct),
+			mbps(g.MeanSpeedKbps), mbps(g.P50SpeedKbps), millis(g.AvgTTFBMs), millis(g.P95TTFBMs),
+			fmt.Sprintf("%.1f%%", g.StallRatePct), fmt.Sprintf("%.1f%%", g.ErrorRatePct), sla})
+	}
+	return t
+}
+
+func (r *PeriodReport) worstTable(loc *time.Location) reportTable {
+	t := reportTable{head: []string{"Batch", "Started", "Situation", "P50 speed", "P95 TTFB", "Errors"}}
+	scored := len(r.Worst) > 0 && r.Worst[0].Score > 0
+	if scored {
+		t.head = append(t.head, "Score")
+	}
+	for _, b := range r.Worst {
+		row := []string{b.RunTag, b.Start.In(loc).Format("2006-01-02 15:04"), b.Situation, mbps(b.P50SpeedKbps), millis(b.P95TTFBMs), fmt.Sprintf("%.1f%%", b.ErrorRatePct)}
+		if scored {
+			row = append(row, fmt.Sprintf("%.0f", b.Score))
+		}
+		t.rows = append(t.rows, row)
+	}
+	return t
+}
+
+func (r *PeriodReport) anomalyTable(loc *time.Location) reportTable {
+	t := reportTable{head: []string{"Started", "Batch", "Situation", "Event", "Detail"}}
+	for _, a := range r.Anomalies {
+		t.rows = append(t.rows, []string{a.Start.In(loc).Format("2006-01-02 15:04"), a.RunTag, a.Situation, a.Kind, a.Detail})
+	}
+	return t
+}
+
+func (r *PeriodReport) differenceTable() reportTable {
+	t := reportTable{head: []string{"Situations", "Metric", "Medians", "Difference (95% CI)", "p", "Verdict"}}
+	for _, d := range r.Differences {
+		format, metric := mbps, "P50 speed"
+		if d.Metric == "ttfb" {
+			format, metric = millis, "Avg TTFB"
+		}
+		verdict := "not significant"
+		if d.Significant {
+			verdict = "significant"
+		}
+		signed := func(v float64) string {
+			if d.Metric == "ttfb" {
+				return fmt.Sprintf("%+.0f ms", v)
+			}
+			return fmt.Sprintf("%+.1f Mbps", v/1000)
+		}
+		t.rows = append(t.rows, []string{d.A + " → " + d.B, metric, format(d.MedianA) + " → " + format(d.MedianB),
+			fmt.Sprintf("%s (%s … %s)", signed(d.Delta), signed(d.CILow), signed(d.CIHigh)), fmt.Sprintf("%.3f", d.PValue), verdict})
+	}
+	return t
+}
+
+func (r *PeriodReport) slaLine() string {
+	var parts []string
+	if r.SLA.SpeedKbps > 0 {
+		parts = append(parts, "P50 speed ≥ "+mbps(r.SLA.SpeedKbps))
+	}
+	if r.SLA.TTFBMs > 0 {
+		parts = append(parts, "P95 TTFB ≤ "+millis(r.SLA.TTFBMs))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "SLA per batch: " + strings.Join(parts, " and ")
+}
+
+// Title is the report heading, also used as mail subject.
+func (r *PeriodReport) Title(loc *time.Location) string {
+	return fmt.Sprintf("Internet quality %s – %s", r.From.In(loc).Format("2006-01-02"), r.To.In(loc).Format("2006-01-02"))
+}
+
+// Markdown renders the report with times in loc.
+func (r *PeriodReport) Markdown(loc *time.Location) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", r.Title(loc))
+	if r.Total.Batches == 0 {
+		b.WriteString("No batches in this period.\n")
+		return b.String()
+	}
+	fmt.Fprintf(&b, "%d batches, %d lines. Uptime %.1f%%, average speed %s, P95 TTFB %s.\n\n", r.Total.Batches, r.Total.Lines, r.Total.UptimePct, mbps(r.Total.MeanSpeedKbps), millis(r.Total.P95TTFBMs))
+	if s := r.slaLine(); s != "" {
+		fmt.Fprintf(&b, "%s.\n\n", s)
+	}
+	section := func(title string, t reportTable) {
+		fmt.Fprintf(&b, "## %s\n\n", title)
+		if len(t.rows) == 0 {
+			b.WriteString("None.\n\n")
+			return
+		}
+		b.WriteString("| " + strings.Join(t.head, " | ") + " |\n")
+		b.WriteString(strings.Repeat("|---", len(t.head)) + "|\n")
+		for _, row := range t.rows {
+			cells := make([]string, len(row))
+			for i, c := range row {
+				cells[i] = strings.ReplaceAll(c, "|", `\|`)
+			}
+			b.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+		}
+		b.WriteString("\n")
+	}
+	section("Per situation", r.groupTable())
+	if len(r.Differences) > 0 {
+		section("Situation differences", r.differenceTable())
+	}
+	section("Worst batches", r.worstTable(loc))
+	section("Notable events", r.anomalyTable(loc))
+	if r.MoreAnomalies > 0 {
+		fmt.Fprintf(&b, "…and %d more.\n", r.MoreAnomalies)
+	}
+	return b.String()
+}
+
+// HTML renders the report as a standalone page with times in loc.
+func (r *PeriodReport) HTML(loc *time.Location) string {
+	var b strings.Builder
+	title := html.EscapeString(r.Title(loc))
+	fmt.Fprintf(&b, "<!DOCTYPE html>\n<html><head><meta charset=\"utf-8\"><title>%s</title>\n", title)
+	b.WriteString("<style>body{font-family:sans-serif}table{border-collapse:collapse}td,th{border:1px solid #ccc;padding:3px 8px;text-align:left}th{background:#f0f0f0}</style>\n")
+	fmt.Fprintf(&b, "</head><body>\n<h1>%s</h1>\n", title)
+	if r.Total.Batches == 0 {
+		b.WriteString("<p>No batches in this period.</p>\n</body></html>\n")
+		return b.String()
+	}
+	fmt.Fprintf(&b, "<p>%d batches, %d lines. Uptime %.1f%%, average speed %s, P95 TTFB %s.</p>\n", r.Total.Batches, r.Total.Lines, r.Total.UptimePct, html.EscapeString(mbps(r.Total.MeanSpeedKbps)), html.EscapeString(millis(r.Total.P95TTFBMs)))
+	if s := r.slaLine(); s != "" {
+		fmt.Fprintf(&b, "<p>%s.</p>\n", html.EscapeString(s))
+	}
+	section := func(title string, t reportTable) {
+		fmt.Fprintf(&b, "<h2>%s</h2>\n", title)
+		if len(t.rows) == 0 {
+			b.WriteString("<p>None.</p>\n")
+			return
+		}
+		b.WriteString("<table>\n<tr>")
+		for _, h := range t.head {
+			fmt.Fprintf(&b, "<th>%s</th>", html.EscapeString(h))
+		}
+		b.WriteString("</tr>\n")
+		for _, row := range t.rows {
+			b.WriteString("<tr>")
+			for _, c := range row {
+				fmt.Fprintf(&b, "<td>%s</td>", html.EscapeString(c))
+			}
+			b.WriteString("</tr>\n")
+		}
+		b.WriteString("</table>\n")
+	}
+	section("Per situation", r.groupTable())
+	if len(r.Differences) > 0 {
+		section("Situation differences", r.differenceTable())
+	}
+	section("Worst batches", r.worstTable(loc))
+	section("Notable events", r.anomalyTable(loc))
+	if r.MoreAnomalies > 0 {
+		fmt.Fprintf(&b, "<p>…and %d more.</p>\n", r.MoreAnomalies)
+	}
+	b.WriteString("</body></html>\n")
+	return b.String()
+}
+
+func mbps(kbps float64) string {
+	if kbps <= 0 {
+		return "—"
+	}
+	return fmt.Sprintf("%.1f Mbps", kbps/1000)
+}
+
+func millis(ms float64) string {
+	if ms <= 0 {
+		return "—"
+	}
+	return fmt.Sprintf("%.0f ms", ms)
+}
@@ -0,0 +1,66 @@
+package analysis
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func reportBatch(tag, situation string, start time.Time, p50, p95 float64, lines, errs int) BatchSummary {
+	return BatchSummary{RunTag: tag, Situation: situation, StartedUTC: start.Format(time.RFC3339Nano), Lines: lines, ErrorLines: errs,
+		AvgSpeed: p50, MedianSpeed: p50, AvgTTFB: p95 / 2, AvgP95TTFBMs: p95}
+}
+
+func TestBuildPeriodReport(t *testing.T) {
+	to := time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC)
+	from := to.AddDate(0, 0, -7)
+	day := func(d int) time.Time { return from.Add(time.Duration(d)*24*time.Hour + time.Hour) }
+	rows := []BatchSummary{
+		reportBatch("old", "home", from.Add(-time.Hour), 1, 9999, 4, 4), // before the period
+		reportBatch("h1", "home", day(0), 50000, 100, 10, 0),
+		reportBatch("h2", "home", day(1), 52000, 120, 10, 1),
+		reportBatch("h3", "home", day(2), 10000, 500, 10, 0), // speed drop and TTFB spike
+		reportBatch("h4", "home", day(3), 0, 0, 10, 10),      // outage
+		reportBatch("o1", "office", day(4), 90000, 80, 10, 0),
+	}
+	rows[2].ConfigChanges = []string{"parallel 2→4"}
+	r := BuildPeriodReport(rows, from, to, SLAThresholds{SpeedKbps: 20000, TTFBMs: 200})
+	if r.Total.Batches != 5 || r.Total.Lines != 50 || r.Total.UptimePct != 80 {
+		t.Fatalf("total: %+v", r.Total)
+	}
+	if len(r.Situations) != 2 || r.Situations[0].Situation != "home" || r.Situations[0].Batches != 4 || r.Situations[1].P50SpeedKbps != 90000 {
+		t.Fatalf("situations: %+v", r.Situations)
+	}
+	// home: h1, h2 meet both targets, h3 misses them, h4 has nothing to judge; office meets them
+	if r.Total.SLABatches != 4 || r.Total.SLABothPct != 75 {
+		t.Fatalf("sla: batches=%d both=%.1f", r.Total.SLABatches, r.Total.SLABothPct)
+	}
+	if len(r.Worst) != 5 || r.Worst[0].RunTag != "h4" || r.Worst[1].RunTag != "h3" {
+		t.Fatalf("worst: %+v", r.Worst)
+	}
+	kinds := map[string]string{}
+	for _, a := range r.Anomalies {
+		kinds[a.RunTag+" "+a.Kind] = a.Detail
+	}
+	for _, want := range []string{"h2 " + AnomalyConfigChange, "h3 " + AnomalySpeedDrop, "h3 " + AnomalyTTFBSpike, "h4 " + AnomalyOutage} {
+		if _, ok := kinds[want]; !ok {
+			t.Fatalf("missing %s in %v", want, kinds)
+		}
+	}
+	if len(r.Anomalies) != 4 {
+		t.Fatalf("anomalies: %+v", r.Anomalies)
+	}
+	md := r.Markdown(time.UTC)
+	for _, want := range []string{"# Internet quality 2025-03-03 – 2025-03-10", "| home | 4 | 75.0% |", "| All | 5 | 80.0% |", "SLA per batch: P50 speed ≥ 20.0 Mbps and P95 TTFB ≤ 200 ms", "all 10 lines failed"} {
+		if !strings.Contains(md, want) {
+			t.Fatalf("markdown lacks %q:\n%s", want, md)
+		}
+	}
+	rows[5].Situation = "<office>"
+	if h := BuildPeriodReport(rows, from, to, SLAThresholds{}).HTML(time.UTC); !strings.Contains(h, "<td>&lt;office&gt;</td>") || strings.Contains(h, "SLA per batch") {
+		t.Fatalf("html:\n%s", h)
+	}
+	if md := BuildPeriodReport(rows, to, to.Add(time.Hour), SLAThresholds{}).Markdown(time.UTC); !strings.Contains(md, "No batches in this period.") {
+		t.Fatalf("empty period:\n%s", md)
+	}
+}
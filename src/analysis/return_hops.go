@@ -0,0 +1,97 @@
+package analysis
+
+import (
+	"sort"
+
+	"github.com/iafilius/InternetQualityMonitor/src/monitor"
+)
+
+// ReturnHops summarizes a batch's response TTLs (monitor --response-ttl): the estimated routers
+// on the way back from each target. It complements the forward view of next hop and route
+// traces: a hop count that jumps between batches is a route change even without --route-trace,
+// and AsymmetricLines counts lines whose return path differs from their traced forward path by
+// monitor.AsymmetricHopDelta or more.
+type ReturnHops struct {
+	Lines           int     `json:"lines"`
+	AvgHops         float64 `json:"avg_hops"`
+	AvgHopsIPv4     float64 `json:"avg_hops_ipv4,omitempty"`
+	AvgHopsIPv6     float64 `json:"avg_hops_ipv6,omitempty"`
+	AsymmetricLines int     `json:"asymmetric_lines,omitempty"`
+	// median hop count per target, keyed "<url> <family>"
+	Targets map[string]int `json:"targets"`
+	// targets whose hop count moved by HopChangeMin or more since the previous batch that
+	// measured them, sorted by target
+	Changes []HopChange `json:"changes,omitempty"`
+}
+
+// HopChange is a target whose return hop count changed between batches.
+type HopChange struct {
+	Target string `json:"target"`
+	From   int    `json:"from"`
+	To     int    `json:"to"`
+}
+
+// HopChangeMin is the smallest hop count change reported as a route change; a single hop comes
+// and goes with equal-cost paths.
+const HopChangeMin = 2
+
+type returnHopLine struct {
+	url, family string
+	ttl         *monitor.ResponseTTL
+}
+
+// returnHops rolls up the lines with a response TTL; nil when none has one.
+func returnHops(lines []returnHopLine) *ReturnHops {
+	rh := &ReturnHops{Targets: map[string]int{}}
+	perTarget := map[string][]int{}
+	var all, v4, v6 []float64
+	for _, l := range lines {
+		t := l.ttl
+		if t == nil || t.TTL <= 0 {
+			continue
+		}
+		rh.Lines++
+		h := float64(t.Hops)
+		all = append(all, h)
+		switch l.family {
+		case "ipv4":
+			v4 = append(v4, h)
+		case "ipv6":
+			v6 = append(v6, h)
+		}
+		if t.Asymmetric {
+			rh.AsymmetricLines++
+		}
+		key := l.url + " " + l.family
+		perTarget[key] = append(perTarget[key], t.Hops)
+	}
+	if rh.Lines == 0 {
+		return nil
+	}
+	rh.AvgHops, rh.AvgHopsIPv4, rh.AvgHopsIPv6 = meanOf(all), meanOf(v4), meanOf(v6)
+	for k, hs := range perTarget {
+		sort.Ints(hs)
+		rh.Targets[k] = hs[len(hs)/2]
+	}
+	return rh
+}
+
+// markHopChanges fills ReturnHops.Changes of summaries (oldest first), comparing each target
+// with the last batch before that measured it.
+func markHopChanges(summaries []BatchSummary) {
+	last := map[string]int{}
+	for i := range summaries {
+		rh := summaries[i].ReturnHops
+		if rh == nil {
+			continue
+		}
+		rh.Changes = nil
+		for k, h := range rh.Targets {
+			if prev, ok := last[k]; ok && (h-prev >= HopChangeMin || prev-h >= HopChangeMin) {
+				rh.Changes = append(rh.Changes, HopChange{Target: k, From: prev, To: h})
+			}
+			last[k] = h
+		}
+		sort.Slice(rh.Changes, func(a, b int) bool { return rh.Changes[a].Target < rh.Changes[b].Target })
+	}
+}
@@ -0,0 +1,232 @@
+package analysis
+
+import (
+	"strings"
+	"time"
+
+	"github.com/iafilius/InternetQualityMonitor/src/monitor"
+)
+
+// StartTime returns when the batch started: StartedUTC when present, otherwise the time encoded
+// in a RunTag of the form 20060102_150405[...] (the monitor writes run tags in UTC), else the
+// zero time.
+func (b BatchSummary) StartTime() time.Time {
+	if b.StartedUTC != "" {
+		if t, err := time.Parse(time.RFC3339Nano, b.StartedUTC); err == nil {
+			return t
+		}
+	}
+	parts := strings.Split(b.RunTag, "_")
+	if len(parts) >= 2 && len(parts[0]) == 8 && len(parts[1]) >= 6 {
+		if t, err := time.ParseInLocation("20060102_150405", parts[0]+"_"+parts[1][:6], time.UTC); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// RecordedLocation is the monitor's own zone at the batch start: a fixed zone from UTCOffset
+// named after TimeZone, nil when the batch predates meta.utc_offset.
+func (b BatchSummary) RecordedLocation() *time.Location {
+	if b.UTCOffset == "" {
+		return nil
+	}
+	t, err := time.Parse("-07:00", b.UTCOffset)
+	if err != nil {
+		return nil
+	}
+	_, off := t.Zone()
+	name := b.TimeZone
+	if name == "" {
+		name = "UTC" + b.UTCOffset
+	}
+	return time.FixedZone(name, off)
+}
+
+// SLAThresholds are the per-batch targets used for compliance: batch P50 speed at or above
+// SpeedKbps and batch P95 TTFB at or below TTFBMs. A zero threshold disables that check.
+type SLAThresholds struct {
+	SpeedKbps float64
+	TTFBMs    float64
+}
+
+// RollingPoint aggregates the batches of one trend bucket (or of the whole window).
+type RollingPoint struct {
+	Start         time.Time `json:"start"`
+	Batches       int       `json:"batches"`
+	Lines         int       `json:"lines"`
+	MeanSpeedKbps float64   `json:"mean_speed_kbps"` // line-weighted mean of batch average speeds
+	P95TTFBMs     float64   `json:"p95_ttfb_ms"`     // from the merged TTFB sketches (batch P95s line-weighted for old data)
+	StallRatePct  float64   `json:"stall_rate_pct"`  // line-weighted
+	ErrorRatePct  float64   `json:"error_rate_pct"`  // error lines over all lines
+	SLASpeedPct   float64   `json:"sla_speed_pct"`   // batches meeting the speed target
+	SLATTFBPct    float64   `json:"sla_ttfb_pct"`    // batches meeting the TTFB target
+	SLABothPct    float64   `json:"sla_both_pct"`    // batches meeting every enabled target
+	SLABatches    int       `json:"sla_batches"`     // batches that could be judged
+	HasData       bool      `json:"has_data"`        // false for empty trend buckets
+}
+
+// RollingAggregate is the summary of the batches started within Window before the newest batch,
+// plus Buckets equal-width trend points (oldest first) for sparklines.
+type RollingAggregate struct {
+	Window time.Duration  `json:"window_ns"`
+	From   time.Time      `json:"from"` // exclusive
+	To     time.Time      `json:"to"`   // newest batch start
+	Total  RollingPoint   `json:"total"`
+	Trend  []RollingPoint `json:"trend"`
+}
+
+// RollingSummary aggregates the batches of the last window (anchored at the newest batch start,
+// so files that stopped growing still summarise their final period). Batches without a start
+// time are ignored. buckets < 1 yields no trend.
+func RollingSummary(summaries []BatchSummary, window time.Duration, buckets int, sla SLAThresholds) RollingAggregate {
+	type tb struct {
+		b BatchSummary
+		t time.Time
+	}
+	var all []tb
+	var end time.Time
+	for _, s := range summaries {
+		t := s.StartTime()
+		if t.IsZero() {
+			continue
+		}
+		all = append(all, tb{s, t})
+		if t.After(end) {
+			end = t
+		}
+	}
+	var out RollingAggregate
+	if len(all) == 0 || window <= 0 {
+		return out
+	}
+	// include the newest batch itself: the window is (end-window, end]
+	start := end.Add(-window)
+	var in []BatchSummary
+	var inT []time.Time
+	for _, x := range all {
+		if x.t.After(start) {
+			in = append(in, x.b)
+			inT = append(inT, x.t)
+		}
+	}
+	out.Total = rollingPoint(in, sla)
+	out.Total.Start = start
+	out.Window, out.From, out.To = window, start, end
+	if buckets < 1 {
+		return out
+	}
+	width := window / time.Duration(buckets)
+	groups := make([][]BatchSummary, buckets)
+	for i, b := range in {
+		k := int(inT[i].Sub(start) / width)
+		if k >= buckets {
+			k = buckets - 1
+		}
+		if k < 0 {
+			k = 0
+		}
+		groups[k] = append(groups[k], b)
+	}
+	out.Trend = make([]RollingPoint, buckets)
+	for k := range groups {
+		out.Trend[k] = rollingPoint(groups[k], sla)
+		out.Trend[k].Start = start.Add(time.Duration(k) * width)
+	}
+	return out
+}
+
+func rollingPoint(rows []BatchSummary, sla SLAThresholds) RollingPoint {
+	var p RollingPoint
+	var speedSum, stallSum, ttfbSum float64
+	var speedW, stallW, ttfbW, errLines int
+	var slaSpeedOK, slaTTFBOK, slaBothOK, slaSpeedN, slaTTFBN int
+	var ttfb *monitor.Sketch
+	fallbackTTFB := false
+	for _, b := range rows {
+		p.Batches++
+		p.Lines += b.Lines
+		errLines += b.ErrorLines
+		if b.AvgSpeed > 0 {
+			speedSum += b.AvgSpeed * float64(b.Lines)
+			speedW += b.Lines
+		}
+		stallSum += b.StallRatePct * float64(b.Lines)
+		stallW += b.Lines
+		if b.TTFBSketch != nil && b.TTFBSketch.Count > 0 {
+			if ttfb == nil {
+				ttfb = monitor.NewSketch(b.TTFBSketch.Alpha)
+			}
+			ttfb.Merge(b.TTFBSketch)
+		} else if b.AvgP95TTFBMs > 0 {
+			fallbackTTFB = true
+		}
+		if b.AvgP95TTFBMs > 0 {
+			ttfbSum += b.AvgP95TTFBMs * float64(b.Lines)
+			ttfbW += b.Lines
+		}
+		// per-batch SLA verdicts
+		speedOK, ttfbOK, judged := true, true, false
+		if sla.SpeedKbps > 0 {
+			if p50 := BatchP50Speed(b); p50 > 0 {
+				judged = true
+				slaSpeedN++
+				if speedOK = p50 >= sla.SpeedKbps; speedOK {
+					slaSpeedOK++
+				}
+			}
+		}
+		if sla.TTFBMs > 0 && b.AvgP95TTFBMs > 0 {
+			judged = true
+			slaTTFBN++
+			if ttfbOK = b.AvgP95TTFBMs <= sla.TTFBMs; ttfbOK {
+				slaTTFBOK++
+			}
+		}
+		if judged {
+			p.SLABatches++
+			if speedOK && ttfbOK {
+				slaBothOK++
+			}
+		}
+	}
+	p.HasData = p.Batches > 0
+	if speedW > 0 {
+		p.MeanSpeedKbps = speedSum / float64(speedW)
+	}
+	if stallW > 0 {
+		p.StallRatePct = stallSum / float64(stallW)
+	}
+	if p.Lines > 0 {
+		p.ErrorRatePct = float64(errLines) / float64(p.Lines) * 100
+	}
+	// Sketches give the true pooled P95; mixing in batches without sketches would skew it, so
+	// fall back to the line-weighted batch P95s then.
+	if ttfb != nil && !fallbackTTFB {
+		p.P95TTFBMs = ttfb.Quantile(95)
+	} else if ttfbW > 0 {
+		p.P95TTFBMs = ttfbSum / float64(ttfbW)
+	}
+	if slaSpeedN > 0 {
+		p.SLASpeedPct = float64(slaSpeedOK) / float64(slaSpeedN) * 100
+	}
+	if slaTTFBN > 0 {
+		p.SLATTFBPct = float64(slaTTFBOK) / float64(slaTTFBN) * 100
+	}
+	if p.SLABatches > 0 {
+		p.SLABothPct = float64(slaBothOK) / float64(p.SLABatches) * 100
+	}
+	return p
+}
+
+// BatchP50Speed is a batch's typical (P50) speed in kbps: the pooled median, else the averaged
+// per-line P50, else the median of line averages.
+func BatchP50Speed(b BatchSummary) float64 {
+	switch {
+	case b.PooledP50Speed > 0:
+		return b.PooledP50Speed
+	case b.AvgP50Speed > 0:
+		return b.AvgP50Speed
+	}
+	return b.MedianSpeed
+}
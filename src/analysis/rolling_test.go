@@ -0,0 +1,55 @@
+package analysis
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/iafilius/InternetQualityMonitor/src/monitor"
+)
+
+func TestRollingSummary_WindowAndTrend(t *testing.T) {
+	end := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	at := func(h int) string { return end.Add(-time.Duration(h) * time.Hour).Format(time.RFC3339Nano) }
+	rows := []BatchSummary{
+		{RunTag: "old", StartedUTC: at(30), Lines: 10, AvgSpeed: 1, AvgP50Speed: 1, AvgP95TTFBMs: 5000}, // outside 24h
+		{RunTag: "a", StartedUTC: at(20), Lines: 10, AvgSpeed: 10000, AvgP50Speed: 12000, AvgP95TTFBMs: 150, StallRatePct: 0, ErrorLines: 0},
+		{RunTag: "b", StartedUTC: at(10), Lines: 30, AvgSpeed: 2000, AvgP50Speed: 2500, AvgP95TTFBMs: 400, StallRatePct: 20, ErrorLines: 3},
+		{RunTag: "c", StartedUTC: at(0), Lines: 10, AvgSpeed: 6000, PooledP50Speed: 11000, AvgP95TTFBMs: 180, ErrorLines: 1},
+		{RunTag: "no-time", Lines: 100, AvgSpeed: 99999},
+	}
+	agg := RollingSummary(rows, 24*time.Hour, 4, SLAThresholds{SpeedKbps: 10000, TTFBMs: 200})
+	tot := agg.Total
+	if !agg.To.Equal(end) || tot.Batches != 3 || tot.Lines != 50 {
+		t.Fatalf("window: to=%s batches=%d lines=%d", agg.To, tot.Batches, tot.Lines)
+	}
+	// (10*10000 + 30*2000 + 10*6000) / 50
+	if tot.MeanSpeedKbps != 4400 || tot.ErrorRatePct != 8 || tot.StallRatePct != 12 {
+		t.Fatalf("mean=%.1f err=%.1f stall=%.1f", tot.MeanSpeedKbps, tot.ErrorRatePct, tot.StallRatePct)
+	}
+	if math.Abs(tot.SLABothPct-200.0/3) > 1e-9 || math.Abs(tot.SLASpeedPct-200.0/3) > 1e-9 || tot.SLABatches != 3 {
+		t.Fatalf("sla both=%.2f speed=%.2f n=%d", tot.SLABothPct, tot.SLASpeedPct, tot.SLABatches)
+	}
+	if len(agg.Trend) != 4 || agg.Trend[0].Batches != 1 || agg.Trend[1].HasData || agg.Trend[2].Batches != 1 || agg.Trend[3].Batches != 1 {
+		t.Fatalf("trend buckets: %+v", agg.Trend)
+	}
+}
+
+func TestRollingSummary_P95FromSketches(t *testing.T) {
+	mk := func(vals ...float64) *monitor.Sketch {
+		s := monitor.NewSketch(monitor.DefaultSketchAlpha)
+		for _, v := range vals {
+			s.Add(v)
+		}
+		return s
+	}
+	now := time.Now().UTC()
+	rows := []BatchSummary{
+		{StartedUTC: now.Add(-time.Hour).Format(time.RFC3339Nano), Lines: 10, AvgP95TTFBMs: 100, TTFBSketch: mk(10, 20, 30, 40, 50, 60, 70, 80, 90, 100)},
+		{StartedUTC: now.Format(time.RFC3339Nano), Lines: 10, AvgP95TTFBMs: 1000, TTFBSketch: mk(100, 200, 300, 400, 500, 600, 700, 800, 900, 1000)},
+	}
+	got := RollingSummary(rows, 7*24*time.Hour, 0, SLAThresholds{}).Total.P95TTFBMs
+	if math.Abs(got-900)/900 > 0.01 || RollingSummary(rows, time.Hour, 0, SLAThresholds{}).Trend != nil {
+		t.Fatalf("pooled P95=%.1f want ~900 (nearest rank 19 of 20)", got)
+	}
+}
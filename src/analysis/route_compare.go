@@ -0,0 +1,106 @@
+package analysis
+
+import (
+	"sort"
+
+	"github.com/iafilius/InternetQualityMonitor/src/monitor"
+)
+
+// SiteRouteComparison contrasts how one dual-stack site was reached over IPv4 and IPv6 in a
+// batch. Basis says what Differs is based on: "as_path" when both families were traced
+// (monitor --route-trace), "dest_asn" when only the destination ASNs are known (GeoLite2 ASN
+// database), "" when neither is available and only the resolved IPs can be shown.
+type SiteRouteComparison struct {
+	URL        string `json:"url"`
+	IPv4       string `json:"ipv4,omitempty"`
+	IPv6       string `json:"ipv6,omitempty"`
+	IPv4ASN    uint   `json:"ipv4_asn,omitempty"`
+	IPv6ASN    uint   `json:"ipv6_asn,omitempty"`
+	IPv4ASNOrg string `json:"ipv4_asn_org,omitempty"`
+	IPv6ASNOrg string `json:"ipv6_asn_org,omitempty"`
+	IPv4ASPath []uint `json:"ipv4_as_path,omitempty"`
+	IPv6ASPath []uint `json:"ipv6_as_path,omitempty"`
+	IPv4Hops   int    `json:"ipv4_hops,omitempty"` // TTL of the last hop of the trace
+	IPv6Hops   int    `json:"ipv6_hops,omitempty"`
+	Basis      string `json:"basis,omitempty"`
+	Differs    bool   `json:"differs,omitempty"`
+}
+
+// routeLine is the per-line input of compareRoutes.
+type routeLine struct {
+	url, family, ip string
+	asn             uint
+	asnOrg          string
+	path            *monitor.RoutePath
+}
+
+// compareRoutes builds one comparison per site that has lines of both families, sorted by
+// URL. Per family the first traced line is used, else the first line.
+func compareRoutes(lines []routeLine) []SiteRouteComparison {
+	type pick struct{ v4, v6 *routeLine }
+	bySite := map[string]*pick{}
+	for i := range lines {
+		l := &lines[i]
+		if l.url == "" {
+			continue
+		}
+		p := bySite[l.url]
+		if p == nil {
+			p = &pick{}
+			bySite[l.url] = p
+		}
+		slot := &p.v4
+		switch l.family {
+		case "ipv4":
+		case "ipv6":
+			slot = &p.v6
+		default:
+			continue
+		}
+		if *slot == nil || ((*slot).path == nil && l.path != nil) {
+			*slot = l
+		}
+	}
+	var out []SiteRouteComparison
+	for url, p := range bySite {
+		if p.v4 == nil || p.v6 == nil {
+			continue
+		}
+		c := SiteRouteComparison{URL: url, IPv4: p.v4.ip, IPv6: p.v6.ip, IPv4ASN: p.v4.asn, IPv6ASN: p.v6.asn, IPv4ASNOrg: p.v4.asnOrg, IPv6ASNOrg: p.v6.asnOrg}
+		if rp := p.v4.path; rp != nil {
+			c.IPv4ASPath = rp.ASPath
+			if n := len(rp.Hops); n > 0 {
+				c.IPv4Hops = rp.Hops[n-1].TTL
+			}
+		}
+		if rp := p.v6.path; rp != nil {
+			c.IPv6ASPath = rp.ASPath
+			if n := len(rp.Hops); n > 0 {
+				c.IPv6Hops = rp.Hops[n-1].TTL
+			}
+		}
+		switch {
+		case len(c.IPv4ASPath) > 0 && len(c.IPv6ASPath) > 0:
+			c.Basis = "as_path"
+			c.Differs = !sameASPath(c.IPv4ASPath, c.IPv6ASPath)
+		case c.IPv4ASN != 0 && c.IPv6ASN != 0:
+			c.Basis = "dest_asn"
+			c.Differs = c.IPv4ASN != c.IPv6ASN
+		}
+		out = append(out, c)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].URL < out[j].URL })
+	return out
+}
+
+func sameASPath(a, b []uint) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
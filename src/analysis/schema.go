@@ -0,0 +1,283 @@
+package analysis
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/iafilius/InternetQualityMonitor/src/monitor"
+)
+
+// SchemaMigration upgrades one line from schema version From to From+1. Apply edits the raw
+// meta and site_result objects in place and calls note for every field it changed, e.g.
+// note("meta.run_tag", "derived from timestamp_utc").
+type SchemaMigration struct {
+	From        int
+	Description string
+	Apply       func(meta, site map[string]any, note func(field, change string))
+}
+
+var schemaMigrations = map[int]SchemaMigration{}
+
+// derivedRunTagNote marks v1 lines whose run_tag was derived from their own timestamp. The load
+// then gives consecutive such lines less than derivedBatchGap apart the tag of the first one,
+// so a v1 batch stays one batch (a monitor line follows the previous one within a site timeout).
+const (
+	derivedRunTagNote = "meta.run_tag: derived from timestamp_utc"
+	derivedBatchGap   = 3 * time.Minute
+)
+
+// RegisterSchemaMigration adds the step from m.From to m.From+1 (replacing an earlier one).
+// Call it from init: the registry is read concurrently while a file loads.
+func RegisterSchemaMigration(m SchemaMigration) { schemaMigrations[m.From] = m }
+
+func init() {
+	RegisterSchemaMigration(SchemaMigration{
+		From:        1,
+		Description: "v2 typed site_result; the v1 legacy map output mixed dynamic types and may lack run_tag",
+		Apply: func(meta, site map[string]any, note func(field, change string)) {
+			coerceToStruct(site, reflect.TypeOf(monitor.SiteResult{}), "site_result.", note)
+			if tag, _ := meta["run_tag"].(string); tag != "" {
+				return
+			}
+			ts, _ := meta["timestamp_utc"].(string)
+			if t, err := time.Parse(time.RFC3339Nano, ts); err == nil {
+				meta["run_tag"] = t.UTC().Format("20060102_150405")
+				note("meta.run_tag", "derived from timestamp_utc")
+			}
+		},
+	})
+	RegisterSchemaMigration(SchemaMigration{
+		From:        2,
+		Description: "v3 made meta strongly typed and removed the legacy duplicate map fields from site_result; the v2 generic meta map could carry numbers and booleans as strings",
+		Apply: func(meta, site map[string]any, note func(field, change string)) {
+			coerceToStruct(meta, reflect.TypeOf(monitor.Meta{}), "meta.", note)
+		},
+	})
+}
+
+// MigratableSchemaVersions lists the older schema versions that can be upgraded to target, in
+// ascending order (target itself excluded).
+func MigratableSchemaVersions(target int) []int {
+	var out []int
+	for v := target - 1; v >= 0; v-- {
+		if _, ok := schemaMigrations[v]; !ok {
+			break
+		}
+		out = append([]int{v}, out...)
+	}
+	return out
+}
+
+// migrationPath returns the steps from version from to target, or false if a step is missing
+// (or from is newer than target: lines from a newer monitor cannot be downgraded).
+func migrationPath(from, target int) ([]SchemaMigration, bool) {
+	if from >= target || from < 0 {
+		return nil, false
+	}
+	var steps []SchemaMigration
+	for v := from; v < target; v++ {
+		m, ok := schemaMigrations[v]
+		if !ok {
+			return nil, false
+		}
+		steps = append(steps, m)
+	}
+	return steps, true
+}
+
+// MigrationReport summarizes the lines of a load that were written with an older schema
+// version and upgraded to the requested one.
+type MigrationReport struct {
+	Lines        int            `json:"lines"`                   // lines upgraded
+	FromVersions map[int]int    `json:"from_versions,omitempty"` // upgraded lines per original schema_version
+	Upgraded     map[string]int `json:"upgraded,omitempty"`      // "field: change" -> lines
+	Dropped      map[string]int `json:"dropped,omitempty"`       // fields unknown to the current schema -> lines
+}
+
+// Summary is a one-line description for logs and the viewer banner ("" for no migrations).
+func (r MigrationReport) Summary() string {
+	if r.Lines == 0 {
+		return ""
+	}
+	var from []string
+	for _, v := range sortedIntKeys(r.FromVersions) {
+		from = append(from, fmt.Sprintf("v%d: %d", v, r.FromVersions[v]))
+	}
+	s := fmt.Sprintf("upgraded %d line(s) from older schema versions (%s)", r.Lines, strings.Join(from, ", "))
+	if len(r.Upgraded) > 0 {
+		s += "; upgraded fields: " + countList(r.Upgraded)
+	}
+	if len(r.Dropped) > 0 {
+		s += "; dropped fields: " + countList(r.Dropped)
+	}
+	return s
+}
+
+func sortedIntKeys(m map[int]int) []int {
+	keys := make([]int, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	return keys
+}
+
+func countList(m map[string]int) string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for i, k := range keys {
+		keys[i] = fmt.Sprintf("%s (%d)", k, m[k])
+	}
+	return strings.Join(keys, ", ")
+}
+
+// schemaMigrator upgrades lines for one load; parse workers share it.
+type schemaMigrator struct {
+	target int
+	mu     sync.Mutex
+	rep    MigrationReport
+}
+
+func newSchemaMigrator(target int) *schemaMigrator {
+	return &schemaMigrator{target: target, rep: MigrationReport{FromVersions: map[int]int{}, Upgraded: map[string]int{}, Dropped: map[string]int{}}}
+}
+
+// upgrade decodes a line of schema version from into the current envelope via the registered
+// migrations; ok is false when there is no path or the raw line does not have the expected
+// shape. derivedTag reports a run_tag derived from the line's timestamp (derivedRunTagNote).
+func (m *schemaMigrator) upgrade(line []byte, from int) (env *monitor.ResultEnvelope, derivedTag, ok bool) {
+	steps, ok := migrationPath(from, m.target)
+	if !ok {
+		return nil, false, false
+	}
+	var raw struct {
+		Meta       map[string]any `json:"meta"`
+		SiteResult map[string]any `json:"site_result"`
+	}
+	if err := json.Unmarshal(line, &raw); err != nil || raw.Meta == nil || raw.SiteResult == nil {
+		return nil, false, false
+	}
+	upgraded := map[string]bool{}
+	note := func(field, change string) { upgraded[field+": "+change] = true }
+	for _, st := range steps {
+		if st.Apply != nil {
+			st.Apply(raw.Meta, raw.SiteResult, note)
+		}
+	}
+	raw.Meta["schema_version"] = m.target
+	dropped := append(unknownFields(raw.Meta, reflect.TypeOf(monitor.Meta{}), "meta."), unknownFields(raw.SiteResult, reflect.TypeOf(monitor.SiteResult{}), "site_result.")...)
+	b, err := json.Marshal(raw)
+	if err != nil {
+		return nil, false, false
+	}
+	var out monitor.ResultEnvelope
+	if err := json.Unmarshal(b, &out); err != nil || out.Meta == nil || out.SiteResult == nil {
+		return nil, false, false
+	}
+	m.mu.Lock()
+	m.rep.Lines++
+	m.rep.FromVersions[from]++
+	for k := range upgraded {
+		m.rep.Upgraded[k]++
+	}
+	for _, k := range dropped {
+		m.rep.Dropped[k]++
+	}
+	m.mu.Unlock()
+	return &out, upgraded[derivedRunTagNote], true
+}
+
+func (m *schemaMigrator) report() MigrationReport {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.rep
+}
+
+// jsonFields maps the JSON names of t's fields to their types.
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	out := map[string]reflect.Type{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" || !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		out[name] = f.Type
+	}
+	return out
+}
+
+func unknownFields(obj map[string]any, t reflect.Type, prefix string) []string {
+	known := jsonFields(t)
+	var out []string
+	for k := range obj {
+		if _, ok := known[k]; !ok {
+			out = append(out, prefix+k)
+		}
+	}
+	return out
+}
+
+// coerceToStruct converts string values in obj to the number or boolean the matching field
+// of t expects; values that do not parse are removed (they would fail the typed decode).
+func coerceToStruct(obj map[string]any, t reflect.Type, prefix string, note func(field, change string)) {
+	fields := jsonFields(t)
+	for k, v := range obj {
+		s, isStr := v.(string)
+		ft, ok := fields[k]
+		if !isStr || !ok {
+			continue
+		}
+		switch ft.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64:
+			if f, err := strconv.ParseFloat(strings.TrimSpace(s), 64); err == nil {
+				obj[k] = f
+				note(prefix+k, "string to number")
+			} else {
+				delete(obj, k)
+				note(prefix+k, "unparsable number removed")
+			}
+		case reflect.Bool:
+			if b, err := strconv.ParseBool(strings.TrimSpace(s)); err == nil {
+				obj[k] = b
+				note(prefix+k, "string to bool")
+			} else {
+				delete(obj, k)
+				note(prefix+k, "unparsable bool removed")
+			}
+		}
+	}
+}
+
+// peekSchemaVersion reads meta.schema_version from a line that may not match today's types
+// (the v2 generic meta map could carry it as a string).
+func peekSchemaVersion(line []byte) (int, bool) {
+	var p struct {
+		Meta map[string]any `json:"meta"`
+	}
+	if err := json.Unmarshal(line, &p); err != nil || p.Meta == nil {
+		return 0, false
+	}
+	switch v := p.Meta["schema_version"].(type) {
+	case float64:
+		return int(v), true
+	case string:
+		n, err := strconv.Atoi(strings.TrimSpace(v))
+		return n, err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,68 @@
+package analysis
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/iafilius/InternetQualityMonitor/src/monitor"
+)
+
+func TestSchemaMigration_UpgradesOlderLines(t *testing.T) {
+	lines := []string{
+		// current schema
+		`{"meta":{"timestamp_utc":"2025-01-01T10:00:00Z","run_tag":"20250101_100000","schema_version":3,"num_cpu":8},"site_result":{"url":"https://a/","transfer_speed_kbps":1000}}`,
+		// v2: generic meta map with numbers as strings (fails the typed decode as-is)
+		`{"meta":{"timestamp_utc":"2025-01-01T10:00:01Z","run_tag":"20250101_100000","schema_version":"2","num_cpu":"4","vpn_active":"false"},"site_result":{"url":"https://b/","transfer_speed_kbps":3000}}`,
+		// v1: no run_tag, a legacy duplicate field in site_result
+		`{"meta":{"timestamp_utc":"2025-01-01T11:30:00Z","schema_version":1},"site_result":{"url":"https://c/","transfer_speed_kbps":500,"speed_kbps_legacy":500}}`,
+		// v1, same batch 40 s later, with a number encoded as a string
+		`{"meta":{"timestamp_utc":"2025-01-01T11:30:40Z","schema_version":1},"site_result":{"url":"https://e/","transfer_speed_kbps":"1500"}}`,
+		// newer monitor: no downgrade path
+		`{"meta":{"timestamp_utc":"2025-01-01T12:00:00Z","run_tag":"20250101_120000","schema_version":9},"site_result":{"url":"https://d/"}}`,
+	}
+	path := filepath.Join(t.TempDir(), "results.jsonl")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var st LoadStats
+	var mig MigrationReport
+	sums, err := AnalyzeRecentResultsFullWithOptions(path, monitor.SchemaVersion, 5, AnalyzeOptions{Stats: &st, Migration: &mig})
+	if err != nil {
+		t.Fatalf("analyze: %v", err)
+	}
+	if st.Parsed != 4 || st.Migrated != 3 || st.SchemaMismatch != 1 || st.Corrupt != 0 {
+		t.Fatalf("stats=%+v want parsed=4 migrated=3 schema=1", st)
+	}
+	if len(sums) != 2 || sums[0].RunTag != "20250101_100000" || sums[0].Lines != 2 || sums[0].AvgSpeed != 2000 {
+		t.Fatalf("want v2 line merged into the first batch: %+v", sums)
+	}
+	if sums[1].RunTag != "20250101_113000" || sums[1].Lines != 2 || sums[1].AvgSpeed != 1000 {
+		t.Fatalf("want both v1 lines in one batch tagged by the first, got %+v", sums[1])
+	}
+	if !reflect.DeepEqual(mig.FromVersions, map[int]int{1: 2, 2: 1}) {
+		t.Fatalf("from versions %+v", mig.FromVersions)
+	}
+	for k, n := range map[string]int{"meta.run_tag: derived from timestamp_utc": 2, "site_result.transfer_speed_kbps: string to number": 1, "meta.num_cpu: string to number": 1, "meta.vpn_active: string to bool": 1} {
+		if mig.Upgraded[k] != n {
+			t.Fatalf("upgrade %q: want %d lines in %+v", k, n, mig.Upgraded)
+		}
+	}
+	if mig.Dropped["site_result.speed_kbps_legacy"] != 1 || len(mig.Dropped) != 1 {
+		t.Fatalf("dropped %+v", mig.Dropped)
+	}
+	if s := mig.Summary(); !strings.Contains(s, "upgraded 3 line(s)") || !strings.Contains(s, "v1: 2, v2: 1") {
+		t.Fatalf("summary %q", s)
+	}
+}
+
+func TestMigratableSchemaVersions(t *testing.T) {
+	if got := MigratableSchemaVersions(monitor.SchemaVersion); !reflect.DeepEqual(got, []int{1, 2}) {
+		t.Fatalf("got %v", got)
+	}
+	if _, ok := migrationPath(monitor.SchemaVersion+1, monitor.SchemaVersion); ok {
+		t.Fatalf("newer versions must not migrate")
+	}
+}
@@ -0,0 +1,82 @@
+package analysis
+
+import (
+	"sort"
+
+	"github.com/iafilius/InternetQualityMonitor/src/monitor"
+)
+
+// ServerTimingStats splits the TTFB of the lines whose origin sent Server-Timing into the
+// server-reported processing time and the rest (network, TLS and queueing in front of the
+// server): a large NetworkMs with a small ServerMs puts slowness on the path, the reverse on the
+// backend. Server time is capped at the line's TTFB, clocks and rounding differ.
+type ServerTimingStats struct {
+	Lines          int     `json:"lines"`
+	AvgTTFBMs      float64 `json:"avg_ttfb_ms"`
+	AvgServerMs    float64 `json:"avg_server_ms"`
+	AvgNetworkMs   float64 `json:"avg_network_ms"`
+	ServerSharePct float64 `json:"server_share_pct"` // AvgServerMs of AvgTTFBMs
+	// the most frequently reported metrics, most lines first
+	Metrics []ServerTimingMetricStats `json:"metrics,omitempty"`
+}
+
+// ServerTimingMetricStats is one Server-Timing metric name across the batch.
+type ServerTimingMetricStats struct {
+	Name     string  `json:"name"`
+	Lines    int     `json:"lines"`
+	AvgDurMs float64 `json:"avg_dur_ms,omitempty"` // over the lines that sent a dur
+}
+
+// maxServerTimingMetricStats bounds the metric list in a batch summary.
+const maxServerTimingMetricStats = 8
+
+// serverTimingStats rolls up the lines' Server-Timing headers against their TTFB; nil when no
+// line with a TTFB has one.
+func serverTimingStats(timings []*monitor.ServerTiming, ttfbs []float64) *ServerTimingStats {
+	st := &ServerTimingStats{}
+	var ttfb, server []float64
+	type acc struct {
+		lines int
+		durs  []float64
+	}
+	byName := map[string]*acc{}
+	for i, t := range timings {
+		if t == nil || ttfbs[i] <= 0 {
+			continue
+		}
+		st.Lines++
+		ttfb = append(ttfb, ttfbs[i])
+		server = append(server, min(t.ServerMs, ttfbs[i]))
+		for _, m := range t.Metrics {
+			a := byName[m.Name]
+			if a == nil {
+				a = &acc{}
+				byName[m.Name] = a
+			}
+			a.lines++
+			if m.DurMs > 0 {
+				a.durs = append(a.durs, m.DurMs)
+			}
+		}
+	}
+	if st.Lines == 0 {
+		return nil
+	}
+	st.AvgTTFBMs = meanOf(ttfb)
+	st.AvgServerMs = meanOf(server)
+	st.AvgNetworkMs = st.AvgTTFBMs - st.AvgServerMs
+	st.ServerSharePct = st.AvgServerMs / st.AvgTTFBMs * 100
+	for name, a := range byName {
+		st.Metrics = append(st.Metrics, ServerTimingMetricStats{Name: name, Lines: a.lines, AvgDurMs: meanOf(a.durs)})
+	}
+	sort.Slice(st.Metrics, func(i, j int) bool {
+		if st.Metrics[i].Lines != st.Metrics[j].Lines {
+			return st.Metrics[i].Lines > st.Metrics[j].Lines
+		}
+		return st.Metrics[i].Name < st.Metrics[j].Name
+	})
+	if len(st.Metrics) > maxServerTimingMetricStats {
+		st.Metrics = st.Metrics[:maxServerTimingMetricStats]
+	}
+	return st
+}
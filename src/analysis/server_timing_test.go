@@ -0,0 +1,48 @@
+package analysis
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/iafilius/InternetQualityMonitor/src/monitor"
+)
+
+func TestServerTimingStats(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.jsonl")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	write := func(tag string, ttfb int64, st *monitor.ServerTiming) {
+		env := monitor.ResultEnvelope{
+			Meta:       &monitor.Meta{TimestampUTC: time.Now().UTC().Format(time.RFC3339Nano), RunTag: tag, SchemaVersion: monitor.SchemaVersion},
+			SiteResult: &monitor.SiteResult{URL: "https://a.example/", TransferSpeedKbps: 1000, TraceTTFBMs: ttfb, ServerTiming: st},
+		}
+		b, _ := json.Marshal(&env)
+		f.Write(append(b, '\n'))
+	}
+	write("20260101_000000", 100, &monitor.ServerTiming{Metrics: []monitor.ServerTimingMetric{{Name: "db", DurMs: 30}, {Name: "app", DurMs: 60}}, ServerMs: 60})
+	// server time is capped at the TTFB
+	write("20260101_000000", 50, &monitor.ServerTiming{Metrics: []monitor.ServerTimingMetric{{Name: "app", DurMs: 80}}, ServerMs: 80})
+	write("20260101_000000", 400, nil) // no header: not in the split
+	write("20260101_001000", 100, nil)
+	f.Close()
+
+	sums, err := AnalyzeRecentResultsFull(path, monitor.SchemaVersion, 10, "")
+	if err != nil || len(sums) != 2 {
+		t.Fatalf("analyze: %v (n=%d)", err, len(sums))
+	}
+	st := sums[0].ServerTiming
+	if st == nil || st.Lines != 2 || st.AvgTTFBMs != 75 || st.AvgServerMs != 55 || st.AvgNetworkMs != 20 {
+		t.Fatalf("server timing: %+v", st)
+	}
+	if len(st.Metrics) != 2 || st.Metrics[0].Name != "app" || st.Metrics[0].Lines != 2 || st.Metrics[0].AvgDurMs != 70 {
+		t.Fatalf("metrics: %+v", st.Metrics)
+	}
+	if sums[1].ServerTiming != nil {
+		t.Fatalf("no header: %+v", sums[1].ServerTiming)
+	}
+}
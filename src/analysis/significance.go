@@ -0,0 +1,270 @@
+package analysis
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// Significance tests: a difference between two groups (IPv4 and IPv6 lines of a batch, HTTP/1.1
+// and HTTP/2 lines, the batches of two situations) is only worth acting on when it is larger
+// than the spread within the groups. Speeds and TTFBs are skewed and heavy-tailed, so the tests
+// make no normality assumption: a two-sided Mann–Whitney U test gives the p-value (normal
+// approximation with tie and continuity correction), and a percentile bootstrap of the
+// difference of medians gives the confidence interval. The bootstrap uses a fixed seed, so the
+// same data always gives the same interval. Paired values (the same slot measured before and
+// after a change) use the Wilcoxon signed-rank test on the per-pair differences instead, with a
+// bootstrap of the median difference.
+
+// Significance settings.
+const (
+	SignificanceAlpha      = 0.05 // p below this is significant; the interval is the matching 95%
+	SignificanceMinSamples = 5    // per group; fewer give no test
+	significanceResamples  = 1000
+)
+
+// SignificanceTest compares the values of group B with those of group A.
+type SignificanceTest struct {
+	A       string  `json:"a"`
+	B       string  `json:"b"`
+	NA      int     `json:"n_a"`
+	NB      int     `json:"n_b"`
+	MedianA float64 `json:"median_a"`
+	MedianB float64 `json:"median_b"`
+	// Delta is MedianB − MedianA, with its 95% bootstrap confidence interval.
+	Delta       float64 `json:"delta"`
+	CILow       float64 `json:"ci_low"`
+	CIHigh      float64 `json:"ci_high"`
+	PValue      float64 `json:"p_value"` // two-sided Mann–Whitney U; Wilcoxon signed-rank when Paired
+	Significant bool    `json:"significant"`
+	// Paired: from ComparePaired, Delta is the median of the per-pair differences B − A.
+	Paired bool `json:"paired,omitempty"`
+}
+
+// CompareGroups tests the values b (group nameB) against a (group nameA); values <= 0 or NaN
+// are ignored. nil when a group has fewer than SignificanceMinSamples values.
+func CompareGroups(nameA string, a []float64, nameB string, b []float64) *SignificanceTest {
+	a, b = positiveValues(a), positiveValues(b)
+	if len(a) < SignificanceMinSamples || len(b) < SignificanceMinSamples {
+		return nil
+	}
+	sort.Float64s(a)
+	sort.Float64s(b)
+	t := &SignificanceTest{A: nameA, B: nameB, NA: len(a), NB: len(b), MedianA: medianSorted(a), MedianB: medianSorted(b)}
+	t.Delta = t.MedianB - t.MedianA
+	t.PValue = mannWhitneyP(a, b)
+	t.CILow, t.CIHigh = bootstrapMedianDiffCI(a, b)
+	t.Significant = t.PValue < SignificanceAlpha
+	return t
+}
+
+// ComparePaired tests b against a where b[i] and a[i] are one pair; pairs with a value <= 0 or
+// NaN are ignored. nil when fewer than SignificanceMinSamples pairs remain.
+func ComparePaired(nameA string, a []float64, nameB string, b []float64) *SignificanceTest {
+	var pa, pb, diffs []float64
+	for i := range a {
+		if i >= len(b) || !(a[i] > 0) || !(b[i] > 0) || math.IsInf(a[i], 0) || math.IsInf(b[i], 0) {
+			continue
+		}
+		pa, pb, diffs = append(pa, a[i]), append(pb, b[i]), append(diffs, b[i]-a[i])
+	}
+	if len(diffs) < SignificanceMinSamples {
+		return nil
+	}
+	sort.Float64s(pa)
+	sort.Float64s(pb)
+	t := &SignificanceTest{A: nameA, B: nameB, NA: len(pa), NB: len(pb), MedianA: medianSorted(pa), MedianB: medianSorted(pb), Paired: true}
+	t.PValue = wilcoxonSignedRankP(diffs)
+	sort.Float64s(diffs)
+	t.Delta = medianSorted(diffs)
+	t.CILow, t.CIHigh = bootstrapMedianCI(diffs)
+	t.Significant = t.PValue < SignificanceAlpha
+	return t
+}
+
+// wilcoxonSignedRankP is the two-sided p-value of the signed-rank test for the differences d:
+// zero differences are dropped, tied magnitudes get their average rank and shrink the variance,
+// and the normal approximation uses a continuity correction.
+func wilcoxonSignedRankP(d []float64) float64 {
+	var mags []float64
+	var pos []bool
+	for _, x := range d {
+		if x != 0 {
+			mags, pos = append(mags, math.Abs(x)), append(pos, x > 0)
+		}
+	}
+	n := len(mags)
+	if n == 0 {
+		return 1 // no differences at all
+	}
+	idx := make([]int, n)
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.Slice(idx, func(i, j int) bool { return mags[idx[i]] < mags[idx[j]] })
+	var wPlus, tieTerm float64
+	for i := 0; i < n; {
+		j := i
+		for j < n && mags[idx[j]] == mags[idx[i]] {
+			j++
+		}
+		rank := float64(i+j+1) / 2 // ranks i+1..j
+		for k := i; k < j; k++ {
+			if pos[idx[k]] {
+				wPlus += rank
+			}
+		}
+		if t := float64(j - i); t > 1 {
+			tieTerm += t*t*t - t
+		}
+		i = j
+	}
+	fn := float64(n)
+	mean := fn * (fn + 1) / 4
+	variance := fn*(fn+1)*(2*fn+1)/24 - tieTerm/48
+	if variance <= 0 {
+		return 1
+	}
+	z := (math.Abs(wPlus-mean) - 0.5) / math.Sqrt(variance)
+	if z < 0 {
+		z = 0
+	}
+	return math.Erfc(z / math.Sqrt2)
+}
+
+// bootstrapMedianCI is the 95% percentile interval of the median over resamples with
+// replacement of the sorted s.
+func bootstrapMedianCI(s []float64) (lo, hi float64) {
+	rng := rand.New(rand.NewSource(1))
+	counts := make([]int, len(s))
+	meds := make([]float64, significanceResamples)
+	for i := range meds {
+		meds[i] = bootstrapMedian(rng, s, counts)
+	}
+	sort.Float64s(meds)
+	tail := SignificanceAlpha / 2 * float64(len(meds))
+	return meds[int(tail)], meds[len(meds)-1-int(tail)]
+}
+
+func positiveValues(v []float64) []float64 {
+	out := make([]float64, 0, len(v))
+	for _, x := range v {
+		if x > 0 && !math.IsInf(x, 0) {
+			out = append(out, x)
+		}
+	}
+	return out
+}
+
+// mannWhitneyP is the two-sided p-value of the U test for sorted samples a and b.
+func mannWhitneyP(a, b []float64) float64 {
+	type obs struct {
+		v   float64
+		inA bool
+	}
+	all := make([]obs, 0, len(a)+len(b))
+	for _, v := range a {
+		all = append(all, obs{v, true})
+	}
+	for _, v := range b {
+		all = append(all, obs{v, false})
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].v < all[j].v })
+	// average ranks over ties; ties also shrink the variance
+	var rankSumA, tieTerm float64
+	for i := 0; i < len(all); {
+		j := i
+		for j < len(all) && all[j].v == all[i].v {
+			j++
+		}
+		rank := float64(i+j+1) / 2 // ranks i+1..j
+		for k := i; k < j; k++ {
+			if all[k].inA {
+				rankSumA += rank
+			}
+		}
+		if t := float64(j - i); t > 1 {
+			tieTerm += t*t*t - t
+		}
+		i = j
+	}
+	n1, n2 := float64(len(a)), float64(len(b))
+	n := n1 + n2
+	u := rankSumA - n1*(n1+1)/2
+	mean := n1 * n2 / 2
+	variance := n1 * n2 / 12 * ((n + 1) - tieTerm/(n*(n-1)))
+	if variance <= 0 {
+		return 1 // all values equal
+	}
+	z := (math.Abs(u-mean) - 0.5) / math.Sqrt(variance)
+	if z < 0 {
+		z = 0
+	}
+	return math.Erfc(z / math.Sqrt2)
+}
+
+// bootstrapMedianDiffCI is the 95% percentile interval of median(b*) − median(a*) over
+// resamples with replacement of the sorted a and b.
+func bootstrapMedianDiffCI(a, b []float64) (lo, hi float64) {
+	rng := rand.New(rand.NewSource(1))
+	ca, cb := make([]int, len(a)), make([]int, len(b))
+	diffs := make([]float64, significanceResamples)
+	for i := range diffs {
+		diffs[i] = bootstrapMedian(rng, b, cb) - bootstrapMedian(rng, a, ca)
+	}
+	sort.Float64s(diffs)
+	tail := SignificanceAlpha / 2 * float64(len(diffs))
+	return diffs[int(tail)], diffs[len(diffs)-1-int(tail)]
+}
+
+// medianSorted is medianOf for an already sorted, non-empty slice, without the copy.
+func medianSorted(s []float64) float64 {
+	n := len(s)
+	if n%2 == 1 {
+		return s[n/2]
+	}
+	return (s[n/2-1] + s[n/2]) / 2
+}
+
+// bootstrapMedian returns the median of a resample of the sorted s. The order statistics of a
+// resample of sorted values are those of the drawn indices, so counting the draws per index
+// finds the median in linear time without sorting; counts is scratch space of len(s).
+func bootstrapMedian(rng *rand.Rand, s []float64, counts []int) float64 {
+	n := len(s)
+	for i := range counts {
+		counts[i] = 0
+	}
+	for range s {
+		counts[rng.Intn(n)]++
+	}
+	// the values at ranks (n-1)/2 and n/2 (the same for odd n)
+	lo, hi := -1, -1
+	seen := 0
+	for i, c := range counts {
+		seen += c
+		if lo < 0 && seen > (n-1)/2 {
+			lo = i
+		}
+		if seen > n/2 {
+			hi = i
+			break
+		}
+	}
+	return (s[lo] + s[hi]) / 2
+}
+
+// CompareSituations tests the batches of situation b against those of situation a, one value per
+// batch: the P50 speed and the average TTFB. Unlike the per-batch tests, each batch counts once,
+// so a situation measured with more targets does not look more certain.
+func CompareSituations(summaries []BatchSummary, a, b string) (speed, ttfb *SignificanceTest) {
+	var sa, sb, ta, tb []float64
+	for _, s := range summaries {
+		switch s.Situation {
+		case a:
+			sa, ta = append(sa, BatchP50Speed(s)), append(ta, s.AvgTTFB)
+		case b:
+			sb, tb = append(sb, BatchP50Speed(s)), append(tb, s.AvgTTFB)
+		}
+	}
+	return CompareGroups(a, sa, b, sb), CompareGroups(a, ta, b, tb)
+}
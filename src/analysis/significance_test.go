@@ -0,0 +1,101 @@
+package analysis
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestCompareGroups(t *testing.T) {
+	// fully separated groups of five: U = 0, z = 12/sqrt(275/12), p ≈ 0.0122
+	a := []float64{1, 2, 3, 4, 5}
+	b := []float64{6, 7, 8, 9, 10}
+	r := CompareGroups("a", a, "b", b)
+	if r == nil || r.NA != 5 || r.NB != 5 || r.MedianA != 3 || r.MedianB != 8 || r.Delta != 5 {
+		t.Fatalf("separated: %+v", r)
+	}
+	if math.Abs(r.PValue-0.0122) > 0.0005 || !r.Significant {
+		t.Fatalf("separated p = %.4f significant=%v", r.PValue, r.Significant)
+	}
+	if r.CILow > r.Delta || r.CIHigh < r.Delta || r.CILow <= 0 {
+		t.Fatalf("separated CI %.1f … %.1f", r.CILow, r.CIHigh)
+	}
+	// interleaved groups: no difference to speak of
+	r = CompareGroups("a", []float64{10, 12, 14, 16, 18, 20}, "b", []float64{11, 13, 15, 17, 19, 21})
+	if r == nil || r.Significant || r.PValue < 0.5 || r.CILow > 0 || r.CIHigh < 0 {
+		t.Fatalf("interleaved: %+v", r)
+	}
+	// all equal, and too few values once the zeros (failed lines) are dropped
+	if r := CompareGroups("a", []float64{5, 5, 5, 5, 5}, "b", []float64{5, 5, 5, 5, 5}); r == nil || r.PValue != 1 || r.Significant {
+		t.Fatalf("equal: %+v", r)
+	}
+	if r := CompareGroups("a", []float64{1, 2, 3, 4, 0}, "b", b); r != nil {
+		t.Fatalf("too few: %+v", r)
+	}
+}
+
+func TestComparePaired(t *testing.T) {
+	// every pair 1 faster: interleaved as groups, but a consistent change per pair. Six tied
+	// ranks of 3.5: W+ = 21, variance 22.75 − 210/48, z = 10/sqrt(18.375), p ≈ 0.0196
+	a := []float64{10, 12, 14, 16, 18, 20}
+	b := []float64{11, 13, 15, 17, 19, 21}
+	r := ComparePaired("a", a, "b", b)
+	if r == nil || !r.Paired || r.NA != 6 || r.Delta != 1 || r.CILow != 1 || r.CIHigh != 1 {
+		t.Fatalf("paired: %+v", r)
+	}
+	if math.Abs(r.PValue-0.0196) > 0.0005 || !r.Significant {
+		t.Fatalf("paired p = %.4f significant=%v", r.PValue, r.Significant)
+	}
+	// changes in both directions, and pairs dropped for a failed side
+	r = ComparePaired("a", a, "b", []float64{11, 11, 15, 15, 19, 19})
+	if r == nil || r.Significant || r.PValue < 0.5 {
+		t.Fatalf("mixed: %+v", r)
+	}
+	if r := ComparePaired("a", a, "b", []float64{11, 0, 15, 17, 19, 21}); r == nil || r.NA != 5 {
+		t.Fatalf("failed pair kept: %+v", r)
+	}
+	if r := ComparePaired("a", a, "b", []float64{11, 0, 15, 0, 19, 21}); r != nil {
+		t.Fatalf("too few pairs: %+v", r)
+	}
+}
+
+func TestBootstrapMedianMatchesSortedResample(t *testing.T) {
+	s := []float64{1, 2, 2, 3, 5, 8, 13, 21}
+	counts := make([]int, len(s))
+	for _, seed := range []int64{1, 2, 3} {
+		got := bootstrapMedian(rand.New(rand.NewSource(seed)), s, counts)
+		// the same draws, sorted the slow way
+		rng := rand.New(rand.NewSource(seed))
+		res := make([]float64, len(s))
+		for i := range res {
+			res[i] = s[rng.Intn(len(s))]
+		}
+		sort.Float64s(res)
+		if want := medianSorted(res); got != want {
+			t.Fatalf("seed %d: median %v, want %v", seed, got, want)
+		}
+	}
+}
+
+func TestCompareSituations(t *testing.T) {
+	start := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	var rows []BatchSummary
+	for i := 0; i < 6; i++ {
+		rows = append(rows,
+			reportBatch("h", "home", start.Add(time.Duration(i)*time.Hour), 50000+float64(i)*500, 100, 10, 0),
+			reportBatch("o", "office", start.Add(time.Duration(i)*time.Hour), 90000+float64(i)*500, 100, 10, 0))
+	}
+	speed, ttfb := CompareSituations(rows, "home", "office")
+	if speed == nil || !speed.Significant || speed.NA != 6 || speed.Delta != 40000 {
+		t.Fatalf("speed: %+v", speed)
+	}
+	if ttfb == nil || ttfb.Significant {
+		t.Fatalf("ttfb: %+v", ttfb)
+	}
+	r := BuildPeriodReport(rows, start.Add(-time.Hour), start.Add(24*time.Hour), SLAThresholds{})
+	if len(r.Differences) != 2 || r.Differences[0].Metric != "speed" || !r.Differences[0].Significant {
+		t.Fatalf("report differences: %+v", r.Differences)
+	}
+}
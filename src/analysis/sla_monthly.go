@@ -0,0 +1,169 @@
+package analysis
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// BusinessHours limits SLA compliance to working time: batches starting on Days between
+// StartHour and EndHour (local wall clock, end exclusive). The zero value means all hours.
+type BusinessHours struct {
+	StartHour int            `json:"start_hour"`
+	EndHour   int            `json:"end_hour"`
+	Days      []time.Weekday `json:"days,omitempty"`
+}
+
+// DefaultBusinessHours is Monday to Friday, 08:00–18:00.
+func DefaultBusinessHours() BusinessHours {
+	return BusinessHours{StartHour: 8, EndHour: 18, Days: []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}}
+}
+
+// Enabled reports whether the hours restrict anything.
+func (h BusinessHours) Enabled() bool { return h.EndHour > h.StartHour }
+
+// Contains reports whether the local time t falls within the hours.
+func (h BusinessHours) Contains(t time.Time) bool {
+	if !h.Enabled() {
+		return true
+	}
+	if hr := t.Hour(); hr < h.StartHour || hr >= h.EndHour {
+		return false
+	}
+	if len(h.Days) == 0 {
+		return true
+	}
+	for _, d := range h.Days {
+		if t.Weekday() == d {
+			return true
+		}
+	}
+	return false
+}
+
+// String is e.g. "Mon–Fri 08:00–18:00".
+func (h BusinessHours) String() string {
+	if !h.Enabled() {
+		return "all hours"
+	}
+	days := "every day"
+	if len(h.Days) > 0 {
+		var names []string
+		for _, d := range h.Days {
+			names = append(names, d.String()[:3])
+		}
+		days = strings.Join(names, ",")
+		if days == "Mon,Tue,Wed,Thu,Fri" {
+			days = "Mon–Fri"
+		}
+	}
+	return fmt.Sprintf("%s %02d:00–%02d:00", days, h.StartHour, h.EndHour)
+}
+
+// windowHours counts the hours of [from, to) in loc that lie within h.
+func (h BusinessHours) windowHours(from, to time.Time, loc *time.Location) int {
+	n := 0
+	for t := from.In(loc); t.Before(to); t = t.Add(time.Hour) {
+		if h.Contains(t) {
+			n++
+		}
+	}
+	return n
+}
+
+// localHour is the start of t's hour on t's own clock. Truncate works on absolute time, which
+// lands on the half hour in zones like India (UTC+5:30).
+func localHour(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location())
+}
+
+// SLAMonth is the SLA compliance of one local calendar month: the share of its judged batches
+// meeting the SLAThresholds (within BusinessHours when set), so "did we hit 99% this month?" has
+// an answer. Coverage tells how much of the month's eligible time was measured at all: the hours
+// with at least one batch over the hours up to the month's end or the newest batch.
+type SLAMonth struct {
+	Month         time.Time `json:"month"` // local midnight of the first day
+	Batches       int       `json:"batches"`
+	SpeedPct      float64   `json:"speed_pct"`
+	TTFBPct       float64   `json:"ttfb_pct"`
+	CompliancePct float64   `json:"compliance_pct"` // batches meeting every enabled target
+	FailedBatches int       `json:"failed_batches"`
+	// the day with the lowest compliance (its local midnight) and that compliance
+	WorstDay     time.Time `json:"worst_day"`
+	WorstDayPct  float64   `json:"worst_day_pct"`
+	CoveredHours int       `json:"covered_hours"`
+	WindowHours  int       `json:"window_hours"`
+	Met          bool      `json:"met"` // CompliancePct at or above the target
+}
+
+// CoveragePct is CoveredHours over WindowHours.
+func (m SLAMonth) CoveragePct() float64 {
+	if m.WindowHours == 0 {
+		return 0
+	}
+	return float64(m.CoveredHours) / float64(m.WindowHours) * 100
+}
+
+// BuildSLAMonthly groups the batches by their start month in loc (time.Local when nil), keeps
+// those within hours, and rolls up their compliance with sla against targetPct, oldest month
+// first. Batches without a start time or that cannot be judged (no speed or TTFB for the enabled
+// targets) are ignored; nil when no target is enabled.
+func BuildSLAMonthly(summaries []BatchSummary, sla SLAThresholds, hours BusinessHours, targetPct float64, loc *time.Location) []SLAMonth {
+	if sla.SpeedKbps <= 0 && sla.TTFBMs <= 0 {
+		return nil
+	}
+	if loc == nil {
+		loc = time.Local
+	}
+	byMonth := map[time.Time][]BatchSummary{}
+	byDay := map[time.Time][]BatchSummary{}
+	hoursSeen := map[time.Time]map[time.Time]bool{}
+	var newest time.Time
+	for _, b := range summaries {
+		t := b.StartTime()
+		if t.IsZero() {
+			continue
+		}
+		t = t.In(loc)
+		if !hours.Contains(t) || rollingPoint([]BatchSummary{b}, sla).SLABatches == 0 {
+			continue
+		}
+		if t.After(newest) {
+			newest = t
+		}
+		m := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, loc)
+		d := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+		byMonth[m] = append(byMonth[m], b)
+		byDay[d] = append(byDay[d], b)
+		if hoursSeen[m] == nil {
+			hoursSeen[m] = map[time.Time]bool{}
+		}
+		hoursSeen[m][localHour(t)] = true
+	}
+	out := make([]SLAMonth, 0, len(byMonth))
+	for m, rows := range byMonth {
+		p := rollingPoint(rows, sla)
+		sm := SLAMonth{Month: m, Batches: p.SLABatches, SpeedPct: p.SLASpeedPct, TTFBPct: p.SLATTFBPct, CompliancePct: p.SLABothPct,
+			CoveredHours: len(hoursSeen[m]), WorstDayPct: 101}
+		sm.FailedBatches = sm.Batches - int(p.SLABothPct*float64(p.SLABatches)/100+0.5)
+		sm.Met = sm.CompliancePct >= targetPct
+		for d, drows := range byDay {
+			if d.Year() != m.Year() || d.Month() != m.Month() {
+				continue
+			}
+			if dp := rollingPoint(drows, sla).SLABothPct; dp < sm.WorstDayPct || (dp == sm.WorstDayPct && d.Before(sm.WorstDay)) {
+				sm.WorstDay, sm.WorstDayPct = d, dp
+			}
+		}
+		// the newest batch ends the window of its (current) month
+		end := m.AddDate(0, 1, 0)
+		if newest.Before(end) {
+			end = localHour(newest).Add(time.Hour)
+		}
+		sm.WindowHours = hours.windowHours(m, end, loc)
+		out = append(out, sm)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Month.Before(out[j].Month) })
+	return out
+}
@@ -0,0 +1,70 @@
+package analysis
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBuildSLAMonthly(t *testing.T) {
+	loc := time.FixedZone("CET", 3600)
+	at := func(month time.Month, day, hour int) string {
+		return time.Date(2026, month, day, hour, 0, 0, 0, loc).UTC().Format(time.RFC3339Nano)
+	}
+	sla := SLAThresholds{SpeedKbps: 10000, TTFBMs: 200}
+	rows := []BatchSummary{
+		// Monday 2 March: one failing batch at night, three good ones in office hours
+		{RunTag: "night", StartedUTC: at(3, 2, 2), MedianSpeed: 5000, AvgP95TTFBMs: 100},
+		{RunTag: "a", StartedUTC: at(3, 2, 9), MedianSpeed: 20000, AvgP95TTFBMs: 100},
+		{RunTag: "b", StartedUTC: at(3, 2, 10), MedianSpeed: 20000, AvgP95TTFBMs: 150},
+		{RunTag: "c", StartedUTC: at(3, 3, 9), MedianSpeed: 20000, AvgP95TTFBMs: 300},
+		// Saturday
+		{RunTag: "weekend", StartedUTC: at(3, 7, 11), MedianSpeed: 1000, AvgP95TTFBMs: 900},
+		{RunTag: "april", StartedUTC: at(4, 1, 9), MedianSpeed: 20000, AvgP95TTFBMs: 100},
+		{RunTag: "nodata", StartedUTC: at(4, 1, 10)},
+	}
+	all := BuildSLAMonthly(rows, sla, BusinessHours{}, 99, loc)
+	if len(all) != 2 || !all[0].Month.Equal(time.Date(2026, 3, 1, 0, 0, 0, 0, loc)) {
+		t.Fatalf("months: %+v", all)
+	}
+	m := all[0]
+	if m.Batches != 5 || m.CompliancePct != 40 || m.FailedBatches != 3 || m.Met {
+		t.Fatalf("March, all hours: %+v", m)
+	}
+	if m.SpeedPct != 60 || m.TTFBPct != 60 {
+		t.Fatalf("March per target: %+v", m)
+	}
+	if !m.WorstDay.Equal(time.Date(2026, 3, 3, 0, 0, 0, 0, loc)) || m.WorstDayPct != 0 {
+		t.Fatalf("worst day %v %.0f", m.WorstDay, m.WorstDayPct)
+	}
+	if m.WindowHours != 31*24 || m.CoveredHours != 5 {
+		t.Fatalf("March coverage %d/%d", m.CoveredHours, m.WindowHours)
+	}
+	// April ends with the newest batch: midnight to 10:00
+	if a := all[1]; a.Batches != 1 || !a.Met || a.WindowHours != 10 {
+		t.Fatalf("April: %+v", a)
+	}
+
+	biz := BuildSLAMonthly(rows, sla, DefaultBusinessHours(), 60, loc)
+	if m := biz[0]; m.Batches != 3 || m.FailedBatches != 1 || !m.Met {
+		t.Fatalf("March, business hours: %+v", m)
+	}
+	// 22 weekdays of 10 hours in March 2026
+	if biz[0].WindowHours != 220 || biz[1].WindowHours != 2 {
+		t.Fatalf("business hours windows %d, %d", biz[0].WindowHours, biz[1].WindowHours)
+	}
+	if BuildSLAMonthly(rows, SLAThresholds{}, BusinessHours{}, 99, loc) != nil {
+		t.Fatalf("no thresholds, no months")
+	}
+	// a half-hour zone: 09:10 and 09:50 are one covered hour, and the window ends at 10:00
+	ist := time.FixedZone("IST", 5*3600+1800)
+	half := []BatchSummary{
+		{RunTag: "x", StartedUTC: time.Date(2026, 5, 4, 9, 10, 0, 0, ist).UTC().Format(time.RFC3339Nano), MedianSpeed: 20000, AvgP95TTFBMs: 100},
+		{RunTag: "y", StartedUTC: time.Date(2026, 5, 4, 9, 50, 0, 0, ist).UTC().Format(time.RFC3339Nano), MedianSpeed: 20000, AvgP95TTFBMs: 100},
+	}
+	if m := BuildSLAMonthly(half, sla, BusinessHours{}, 99, ist); len(m) != 1 || m[0].CoveredHours != 1 || m[0].WindowHours != 3*24+10 {
+		t.Fatalf("half-hour zone: %+v", m)
+	}
+	if s := DefaultBusinessHours().String(); s != "Mon–Fri 08:00–18:00" {
+		t.Fatalf("business hours label %q", s)
+	}
+}
@@ -0,0 +1,84 @@
+package analysis
+
+import (
+	"math"
+
+	"github.com/iafilius/InternetQualityMonitor/src/monitor"
+)
+
+// Mode detection on an ExpHistogram of speed samples. A batch that alternates between a fast
+// path and a slow one (Wi-Fi roaming, a congested peering link for some targets) shows two
+// peaks whose average and percentiles look like one mediocre connection.
+const (
+	modeMinShare  = 0.05 // a peak needs at least this share of the samples
+	modeValleyMax = 0.6  // the dip between two peaks must fall to this fraction of the lower one
+)
+
+// HistogramModes returns the centers (geometric bucket middles) of the modes of h, ascending:
+// local maxima of the lightly smoothed counts holding at least 5% of the samples, where
+// neighbouring peaks count separately only when the counts between them dip below 60% of the
+// lower peak. It returns nil for an empty histogram and one mode for a unimodal one.
+func HistogramModes(h *monitor.ExpHistogram) []float64 {
+	if h == nil || len(h.Counts) == 0 {
+		return nil
+	}
+	n := len(h.Counts)
+	sm := make([]float64, n)
+	var total float64
+	for i := range h.Counts {
+		v := 2 * float64(h.Counts[i])
+		w := 2.0
+		if i > 0 {
+			v, w = v+float64(h.Counts[i-1]), w+1
+		}
+		if i < n-1 {
+			v, w = v+float64(h.Counts[i+1]), w+1
+		}
+		sm[i] = v / w
+		total += float64(h.Counts[i])
+	}
+	var peaks []int
+	for i := range sm {
+		left := i == 0 || sm[i] >= sm[i-1]
+		right := i == n-1 || sm[i] > sm[i+1]
+		if left && right && sm[i] >= modeMinShare*total {
+			peaks = append(peaks, i)
+		}
+	}
+	if len(peaks) == 0 {
+		// flat or spiky data: the fullest bucket is the mode
+		best := 0
+		for i := range sm {
+			if sm[i] > sm[best] {
+				best = i
+			}
+		}
+		peaks = []int{best}
+	}
+	// merge neighbours without a real valley between them, keeping the higher peak
+	for merged := true; merged && len(peaks) > 1; {
+		merged = false
+		for j := 0; j+1 < len(peaks); j++ {
+			a, b := peaks[j], peaks[j+1]
+			valley := math.Inf(1)
+			for k := a; k <= b; k++ {
+				valley = math.Min(valley, sm[k])
+			}
+			if valley > modeValleyMax*math.Min(sm[a], sm[b]) {
+				drop := j
+				if sm[a] > sm[b] {
+					drop = j + 1
+				}
+				peaks = append(peaks[:drop], peaks[drop+1:]...)
+				merged = true
+				break
+			}
+		}
+	}
+	out := make([]float64, len(peaks))
+	for j, i := range peaks {
+		lo, hi := h.Bounds(i)
+		out[j] = math.Sqrt(lo * hi)
+	}
+	return out
+}
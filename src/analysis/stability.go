@@ -0,0 +1,63 @@
+package analysis
+
+import (
+	"math"
+	"sort"
+)
+
+// ThroughputStability describes how consistent a batch's transfer speeds were, in the terms
+// regulators use for "normally available" and "consistent" speeds. Each successful line is one
+// test; the metrics compare the slow tail and the spread with the batch median:
+//   - P10MedianPct: 10th percentile speed as a percentage of the median, the speed 90% of the
+//     tests reached (the "normally available" share of BEREC's guidelines on Regulation (EU)
+//     2015/2120, art. 4(1)(d); ETSI EG 202 057-4 likewise reports test percentiles, not just means)
+//   - P20MedianPct: 20th percentile speed as a percentage of the median, the speed of the FCC
+//     Measuring Broadband America "80/80 consistent speed" (reached in at least 80% of tests)
+//   - Within20Pct: share of tests within ±20% of the median
+//
+// Index is the mean of P10MedianPct and Within20Pct: 100 when every test ran at the median
+// speed, lower the more often and the further transfers fell away from it.
+type ThroughputStability struct {
+	Tests        int     `json:"tests"`
+	MedianKbps   float64 `json:"median_kbps"`
+	P10Kbps      float64 `json:"p10_kbps"`
+	P20Kbps      float64 `json:"p20_kbps"`
+	P10MedianPct float64 `json:"p10_median_pct"`
+	P20MedianPct float64 `json:"p20_median_pct"`
+	Within20Pct  float64 `json:"within_20pct"`
+	Index        float64 `json:"index"`
+}
+
+// StabilityMinTests is the number of successful transfers a batch needs before its percentiles
+// say anything about consistency.
+const StabilityMinTests = 5
+
+// StabilityBandPct is the ±band around the median that Within20Pct counts.
+const StabilityBandPct = 20.0
+
+// throughputStability computes the stability of the transfer speeds (kbps, successful tests
+// only); nil below StabilityMinTests.
+func throughputStability(speeds []float64) *ThroughputStability {
+	if len(speeds) < StabilityMinTests {
+		return nil
+	}
+	sorted := append([]float64(nil), speeds...)
+	sort.Float64s(sorted)
+	med := medianOf(sorted)
+	if med <= 0 {
+		return nil
+	}
+	// nearest rank, as the other per-batch percentiles
+	s := &ThroughputStability{Tests: len(sorted), MedianKbps: med, P10Kbps: nearestRank(sorted, 10), P20Kbps: nearestRank(sorted, 20)}
+	s.P10MedianPct = s.P10Kbps / med * 100
+	s.P20MedianPct = s.P20Kbps / med * 100
+	within := 0
+	for _, v := range speeds {
+		if math.Abs(v-med) <= med*StabilityBandPct/100 {
+			within++
+		}
+	}
+	s.Within20Pct = float64(within) / float64(len(speeds)) * 100
+	s.Index = (math.Min(s.P10MedianPct, 100) + s.Within20Pct) / 2
+	return s
+}
@@ -0,0 +1,22 @@
+package analysis
+
+import "testing"
+
+func TestThroughputStability(t *testing.T) {
+	if throughputStability([]float64{100, 100, 100, 100}) != nil {
+		t.Fatalf("four tests are too few")
+	}
+	steady := throughputStability([]float64{100, 100, 100, 100, 100, 100})
+	if steady == nil || steady.Index != 100 || steady.P10MedianPct != 100 || steady.Within20Pct != 100 {
+		t.Fatalf("steady: %+v", steady)
+	}
+	// ten tests: the median is 100, P10 the slowest and P20 the second slowest; 130, 60 and 40 fall
+	// outside ±20%
+	s := throughputStability([]float64{100, 40, 110, 90, 100, 130, 100, 60, 100, 95})
+	if s.Tests != 10 || s.MedianKbps != 100 || s.P10Kbps != 40 || s.P20Kbps != 60 {
+		t.Fatalf("percentiles: %+v", s)
+	}
+	if s.P10MedianPct != 40 || s.P20MedianPct != 60 || s.Within20Pct != 70 || s.Index != 55 {
+		t.Fatalf("stability: %+v", s)
+	}
+}
@@ -0,0 +1,49 @@
+package analysis
+
+import "sync"
+
+// Stall thresholds: the stall rate, transient (micro) stall metrics and Low-Speed Time Share are
+// only comparable between batches analyzed with the same thresholds. The stall timeout is the
+// monitor's --stall-timeout (meta.config); the micro-stall gap and the low-speed threshold are
+// analysis settings (AnalyzeOptions, monitor --micro-stall-gap/--low-speed-threshold-kbps, the
+// viewer's Thresholds menu). Every BatchSummary carries the values its numbers were derived with.
+
+// Defaults of the analysis thresholds.
+const (
+	DefaultMicroStallGapMs       = 500
+	DefaultLowSpeedThresholdKbps = 1000
+)
+
+// StallThresholds are the thresholds behind a batch's stall and low-speed metrics.
+type StallThresholds struct {
+	StallTimeoutMs        int64   `json:"stall_timeout_ms,omitempty"`         // monitor --stall-timeout; 0 when the batch did not record it
+	MicroStallGapMs       int64   `json:"micro_stall_gap_ms,omitempty"`       // 0: micro-stalls not computed
+	LowSpeedThresholdKbps float64 `json:"low_speed_threshold_kbps,omitempty"` // 0: low-speed share not computed
+}
+
+var (
+	stallThresholdsMu      sync.RWMutex
+	defaultStallThresholds = StallThresholds{MicroStallGapMs: DefaultMicroStallGapMs, LowSpeedThresholdKbps: DefaultLowSpeedThresholdKbps}
+)
+
+// SetDefaultStallThresholds sets the micro-stall gap and low-speed threshold used by
+// AnalyzeRecentResultsFull; values <= 0 restore the defaults. AnalyzeOptions and Analyze callers
+// pass their own (Analyze's zero Options fields select the package defaults, not these).
+func SetDefaultStallThresholds(microStallGapMs int64, lowSpeedKbps float64) {
+	if microStallGapMs <= 0 {
+		microStallGapMs = DefaultMicroStallGapMs
+	}
+	if lowSpeedKbps <= 0 {
+		lowSpeedKbps = DefaultLowSpeedThresholdKbps
+	}
+	stallThresholdsMu.Lock()
+	defaultStallThresholds = StallThresholds{MicroStallGapMs: microStallGapMs, LowSpeedThresholdKbps: lowSpeedKbps}
+	stallThresholdsMu.Unlock()
+}
+
+// currentStallThresholds is the last value set with SetDefaultStallThresholds.
+func currentStallThresholds() StallThresholds {
+	stallThresholdsMu.RLock()
+	defer stallThresholdsMu.RUnlock()
+	return defaultStallThresholds
+}
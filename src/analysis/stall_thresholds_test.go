@@ -0,0 +1,52 @@
+package analysis
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/iafilius/InternetQualityMonitor/src/monitor"
+)
+
+func TestStallThresholds_RecordedAndConfigurable(t *testing.T) {
+	defer SetDefaultStallThresholds(0, 0)
+	path := filepath.Join(t.TempDir(), "results.jsonl")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	// one line with a 600 ms pause
+	writeLineWithMicroStall(t, f, "TH1", "ipv4", 5, 6, 5, monitor.SpeedSampleInterval)
+	f.Close()
+
+	sums, err := AnalyzeRecentResultsFull(path, monitor.SchemaVersion, 5, "")
+	if err != nil || len(sums) != 1 {
+		t.Fatalf("analyze: %v (%d batches)", err, len(sums))
+	}
+	want := StallThresholds{MicroStallGapMs: 500, LowSpeedThresholdKbps: 1000}
+	if th := sums[0].Thresholds; th == nil || *th != want || sums[0].MicroStallRatePct == 0 {
+		t.Fatalf("defaults: thresholds=%+v micro=%.1f", th, sums[0].MicroStallRatePct)
+	}
+
+	// a 1 s gap no longer counts the 600 ms pause
+	SetDefaultStallThresholds(1000, 2000)
+	sums, err = AnalyzeRecentResultsFull(path, monitor.SchemaVersion, 5, "")
+	if err != nil || len(sums) != 1 {
+		t.Fatalf("analyze: %v (%d batches)", err, len(sums))
+	}
+	want = StallThresholds{MicroStallGapMs: 1000, LowSpeedThresholdKbps: 2000}
+	if th := sums[0].Thresholds; th == nil || *th != want || sums[0].MicroStallRatePct != 0 {
+		t.Fatalf("configured: thresholds=%+v micro=%.1f", th, sums[0].MicroStallRatePct)
+	}
+
+	// the stable API keeps the package defaults whatever the process set
+	res, err := Analyze(context.Background(), path, Options{})
+	if err != nil || len(res.Batches) != 1 {
+		t.Fatalf("Analyze: %v", err)
+	}
+	want = StallThresholds{MicroStallGapMs: DefaultMicroStallGapMs, LowSpeedThresholdKbps: DefaultLowSpeedThresholdKbps}
+	if th := res.Batches[0].Thresholds; th == nil || *th != want {
+		t.Fatalf("Analyze thresholds: %+v", th)
+	}
+}
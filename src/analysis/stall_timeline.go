@@ -0,0 +1,67 @@
+package analysis
+
+// StallTimelineBins is the number of equal slices each transfer is cut into for
+// BatchSummary.StallTimelinePct (10 = one bin per tenth of the transfer).
+const StallTimelineBins = 10
+
+// stallEvent is one no-progress span within a transfer; offsetMs is the sample time it began at.
+type stallEvent struct {
+	offsetMs int64
+	durMs    int64
+}
+
+// stallTimelineAccum collects stall events from many transfers on a common 0–100% time axis,
+// so a 2 s stall at the start of a short transfer and one at the start of a long transfer land
+// in the same bin.
+type stallTimelineAccum struct {
+	stallMs  [StallTimelineBins]float64
+	totalMs  [StallTimelineBins]float64
+	events   int
+	early    int // events starting in the first bin
+	offsetMs int64
+	durMs    int64
+}
+
+// add records one transfer whose samples cover spanMs (the last sample time) and its stall events.
+func (a *stallTimelineAccum) add(spanMs int64, events []stallEvent) {
+	if spanMs <= 0 {
+		return
+	}
+	binMs := float64(spanMs) / StallTimelineBins
+	for i := range a.totalMs {
+		a.totalMs[i] += binMs
+	}
+	for _, e := range events {
+		a.events++
+		a.offsetMs += e.offsetMs
+		a.durMs += e.durMs
+		if float64(e.offsetMs) < binMs {
+			a.early++
+		}
+		// spread the stall over the bins it overlaps
+		start, end := float64(e.offsetMs), float64(e.offsetMs+e.durMs)
+		for i := range a.stallMs {
+			lo, hi := float64(i)*binMs, float64(i+1)*binMs
+			if ov := min(end, hi) - max(start, lo); ov > 0 {
+				a.stallMs[i] += ov
+			}
+		}
+	}
+}
+
+// apply fills the stall timeline fields of s; it leaves them empty when no transfer had samples.
+func (a *stallTimelineAccum) apply(s *BatchSummary) {
+	if a.totalMs[0] == 0 {
+		return
+	}
+	s.StallTimelinePct = make([]float64, StallTimelineBins)
+	for i := range a.stallMs {
+		s.StallTimelinePct[i] = a.stallMs[i] / a.totalMs[i] * 100
+	}
+	if a.events > 0 {
+		s.StallEvents = a.events
+		s.StallEventsEarlyPct = float64(a.early) / float64(a.events) * 100
+		s.AvgStallEventOffsetMs = float64(a.offsetMs) / float64(a.events)
+		s.AvgStallEventMs = float64(a.durMs) / float64(a.events)
+	}
+}
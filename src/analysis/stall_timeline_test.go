@@ -0,0 +1,55 @@
+package analysis
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/iafilius/InternetQualityMonitor/src/monitor"
+)
+
+func TestStallTimeline_StartVsMidTransfer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.jsonl")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	interval := monitor.SpeedSampleInterval // 100ms
+	// Both transfers span 0–1900 ms: one stalls right at the start (0–500 ms), one mid-way (700–1300 ms).
+	writeLineWithMicroStall(t, f, "TL1", "ipv4", 0, 6, 14, interval)
+	writeLineWithMicroStall(t, f, "TL1", "ipv6", 8, 6, 6, interval)
+	f.Close()
+
+	sums, err := AnalyzeRecentResultsFullWithOptions(path, monitor.SchemaVersion, 5, AnalyzeOptions{MicroStallMinGapMs: 500})
+	if err != nil || len(sums) != 1 {
+		t.Fatalf("analyze: %v (%d batches)", err, len(sums))
+	}
+	s := sums[0]
+	if s.StallEvents != 2 || s.StallEventsEarlyPct != 50 {
+		t.Fatalf("events=%d early=%.1f%%, want 2 and 50%%", s.StallEvents, s.StallEventsEarlyPct)
+	}
+	if s.AvgStallEventOffsetMs != 350 || s.AvgStallEventMs != 550 {
+		t.Fatalf("avg offset %.0f ms, avg duration %.0f ms; want 350 and 550", s.AvgStallEventOffsetMs, s.AvgStallEventMs)
+	}
+	if len(s.StallTimelinePct) != StallTimelineBins {
+		t.Fatalf("timeline has %d bins, want %d", len(s.StallTimelinePct), StallTimelineBins)
+	}
+	// 190 ms bins, two transfers: 380 ms of transfer time per bin.
+	want := []float64{50, 50, 120.0 / 380 * 100, 60.0 / 380 * 100, 50, 50, 160.0 / 380 * 100, 0, 0, 0}
+	for i, w := range want {
+		if math.Abs(s.StallTimelinePct[i]-w) > 1e-9 {
+			t.Fatalf("bin %d = %.3f%%, want %.3f%% (timeline %v)", i, s.StallTimelinePct[i], w, s.StallTimelinePct)
+		}
+	}
+}
+
+func TestStallTimeline_NoSamples(t *testing.T) {
+	var tl stallTimelineAccum
+	tl.add(0, []stallEvent{{offsetMs: 10, durMs: 600}})
+	var s BatchSummary
+	tl.apply(&s)
+	if s.StallTimelinePct != nil || s.StallEvents != 0 {
+		t.Fatalf("expected no timeline without sampled transfers, got %+v", s.StallTimelinePct)
+	}
+}
@@ -0,0 +1,154 @@
+package analysis
+
+import (
+	"sort"
+
+	"github.com/iafilius/InternetQualityMonitor/src/types"
+)
+
+// TargetSLOCompliance is one target's compliance with its own SLO (sites file "slo") in a batch,
+// or over several batches (AggregateTargetSLOs). A line complies when it succeeded, its TTFB is
+// at most TTFBMs and its transfer speed at least SpeedKbps; each line is judged by the SLO it
+// recorded, and TTFBMs/SpeedKbps are those of the newest line. Targets without an SLO are not
+// listed.
+type TargetSLOCompliance struct {
+	Target         string  `json:"target"` // site name, URL when unnamed
+	Group          string  `json:"group,omitempty"`
+	TTFBMs         float64 `json:"slo_ttfb_ms,omitempty"`
+	SpeedKbps      float64 `json:"slo_speed_kbps,omitempty"`
+	Lines          int     `json:"lines"`
+	CompliantLines int     `json:"compliant_lines"`
+	CompliancePct  float64 `json:"compliance_pct"`
+	TTFBBreaches   int     `json:"ttfb_breaches,omitempty"`
+	SpeedBreaches  int     `json:"speed_breaches,omitempty"`
+	ErrorLines     int     `json:"error_lines,omitempty"`
+	AvgTTFBMs      float64 `json:"avg_ttfb_ms,omitempty"`
+	P95TTFBMs      float64 `json:"p95_ttfb_ms,omitempty"`
+	AvgSpeedKbps   float64 `json:"avg_speed_kbps,omitempty"`
+	Batches        int     `json:"batches,omitempty"` // AggregateTargetSLOs only
+}
+
+// sloLine is what the per-target compliance needs of one HTTP line.
+type sloLine struct {
+	target, group string
+	slo           *types.SLO
+	ttfb, speed   float64
+	failed        bool
+}
+
+// targetSLOCompliance judges the lines that carry an SLO, one entry per target sorted by name;
+// nil when no line has an SLO.
+func targetSLOCompliance(lines []sloLine) []TargetSLOCompliance {
+	type acc struct {
+		c             TargetSLOCompliance
+		ttfbs, speeds []float64
+	}
+	byTarget := map[string]*acc{}
+	for _, l := range lines {
+		if l.slo == nil || (l.slo.TTFBMs <= 0 && l.slo.SpeedKbps <= 0) {
+			continue
+		}
+		a := byTarget[l.target]
+		if a == nil {
+			a = &acc{c: TargetSLOCompliance{Target: l.target}}
+			byTarget[l.target] = a
+		}
+		c := &a.c
+		c.Group, c.TTFBMs, c.SpeedKbps = l.group, l.slo.TTFBMs, l.slo.SpeedKbps
+		c.Lines++
+		if l.ttfb > 0 {
+			a.ttfbs = append(a.ttfbs, l.ttfb)
+		}
+		if l.speed > 0 {
+			a.speeds = append(a.speeds, l.speed)
+		}
+		ok := !l.failed
+		if l.failed {
+			c.ErrorLines++
+		}
+		// phases the line did not reach (no TTFB, no transfer) are not judged; its error already counts
+		if l.slo.TTFBMs > 0 && l.ttfb > l.slo.TTFBMs {
+			c.TTFBBreaches++
+			ok = false
+		}
+		if l.slo.SpeedKbps > 0 && l.speed > 0 && l.speed < l.slo.SpeedKbps {
+			c.SpeedBreaches++
+			ok = false
+		}
+		if ok {
+			c.CompliantLines++
+		}
+	}
+	if len(byTarget) == 0 {
+		return nil
+	}
+	out := make([]TargetSLOCompliance, 0, len(byTarget))
+	for _, a := range byTarget {
+		c := a.c
+		c.CompliancePct = float64(c.CompliantLines) / float64(c.Lines) * 100
+		c.AvgTTFBMs, c.AvgSpeedKbps = meanOf(a.ttfbs), meanOf(a.speeds)
+		if len(a.ttfbs) > 0 {
+			sort.Float64s(a.ttfbs)
+			c.P95TTFBMs = nearestRank(a.ttfbs, 95)
+		}
+		out = append(out, c)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Target < out[j].Target })
+	return out
+}
+
+// AggregateTargetSLOs combines the per-batch TargetSLOs of summaries per target: line counts are
+// summed, averages are line-weighted, P95TTFBMs is the highest batch P95 and the SLO thresholds
+// are those of the newest batch. Batches counts the batches that measured the target.
+func AggregateTargetSLOs(summaries []BatchSummary) []TargetSLOCompliance {
+	rows := append([]BatchSummary(nil), summaries...)
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].StartTime().Before(rows[j].StartTime()) })
+	type acc struct {
+		c                   TargetSLOCompliance
+		ttfbLines, spdLines int
+		ttfbSum, speedSum   float64
+	}
+	byTarget := map[string]*acc{}
+	for _, s := range rows {
+		for _, t := range s.TargetSLOs {
+			a := byTarget[t.Target]
+			if a == nil {
+				a = &acc{c: TargetSLOCompliance{Target: t.Target}}
+				byTarget[t.Target] = a
+			}
+			c := &a.c
+			c.Group, c.TTFBMs, c.SpeedKbps = t.Group, t.TTFBMs, t.SpeedKbps
+			c.Batches++
+			c.Lines += t.Lines
+			c.CompliantLines += t.CompliantLines
+			c.TTFBBreaches += t.TTFBBreaches
+			c.SpeedBreaches += t.SpeedBreaches
+			c.ErrorLines += t.ErrorLines
+			c.P95TTFBMs = max(c.P95TTFBMs, t.P95TTFBMs)
+			if t.AvgTTFBMs > 0 {
+				a.ttfbSum += t.AvgTTFBMs * float64(t.Lines)
+				a.ttfbLines += t.Lines
+			}
+			if t.AvgSpeedKbps > 0 {
+				a.speedSum += t.AvgSpeedKbps * float64(t.Lines)
+				a.spdLines += t.Lines
+			}
+		}
+	}
+	out := make([]TargetSLOCompliance, 0, len(byTarget))
+	for _, a := range byTarget {
+		c := a.c
+		if c.Lines > 0 {
+			c.CompliancePct = float64(c.CompliantLines) / float64(c.Lines) * 100
+		}
+		if a.ttfbLines > 0 {
+			c.AvgTTFBMs = a.ttfbSum / float64(a.ttfbLines)
+		}
+		if a.spdLines > 0 {
+			c.AvgSpeedKbps = a.speedSum / float64(a.spdLines)
+		}
+		out = append(out, c)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Target < out[j].Target })
+	return out
+}
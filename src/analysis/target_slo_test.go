@@ -0,0 +1,65 @@
+package analysis
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/iafilius/InternetQualityMonitor/src/monitor"
+	"github.com/iafilius/InternetQualityMonitor/src/types"
+)
+
+func TestBatchTargetSLOs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.jsonl")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	intranet := &types.SLO{TTFBMs: 50}
+	cdn := &types.SLO{TTFBMs: 200, SpeedKbps: 5000}
+	write := func(tag string, sr *monitor.SiteResult) {
+		meta := &monitor.Meta{TimestampUTC: time.Now().UTC().Format(time.RFC3339Nano), RunTag: tag, SchemaVersion: monitor.SchemaVersion}
+		b, _ := json.Marshal(&monitor.ResultEnvelope{Meta: meta, SiteResult: sr})
+		f.Write(append(b, '\n'))
+	}
+	write("b1", &monitor.SiteResult{Name: "intranet", Group: "Intranet", SLO: intranet, TraceTTFBMs: 30, TransferSpeedKbps: 1000})
+	write("b1", &monitor.SiteResult{Name: "intranet", Group: "Intranet", SLO: intranet, TraceTTFBMs: 80, TransferSpeedKbps: 1000}) // slow
+	write("b1", &monitor.SiteResult{Name: "cdn", SLO: cdn, TraceTTFBMs: 150, TransferSpeedKbps: 8000})
+	write("b1", &monitor.SiteResult{Name: "cdn", SLO: cdn, TraceTTFBMs: 150, TransferSpeedKbps: 2000}) // below speed
+	write("b1", &monitor.SiteResult{Name: "cdn", SLO: cdn, TCPError: "refused"})
+	write("b1", &monitor.SiteResult{Name: "no-slo", TraceTTFBMs: 900, TransferSpeedKbps: 10})
+	write("b2", &monitor.SiteResult{Name: "intranet", Group: "Intranet", SLO: intranet, TraceTTFBMs: 20, TransferSpeedKbps: 1000})
+	f.Close()
+
+	sums, err := AnalyzeRecentResultsFull(path, monitor.SchemaVersion, 5, "")
+	if err != nil || len(sums) != 2 {
+		t.Fatalf("analyze: %v (n=%d)", err, len(sums))
+	}
+	var b1 BatchSummary
+	for _, s := range sums {
+		if s.RunTag == "b1" {
+			b1 = s
+		}
+	}
+	if len(b1.TargetSLOs) != 2 || b1.TargetSLOs[0].Target != "cdn" || b1.TargetSLOs[1].Target != "intranet" {
+		t.Fatalf("targets: %+v", b1.TargetSLOs)
+	}
+	c := b1.TargetSLOs[0]
+	if c.Lines != 3 || c.CompliantLines != 1 || c.SpeedBreaches != 1 || c.TTFBBreaches != 0 || c.ErrorLines != 1 || c.SpeedKbps != 5000 {
+		t.Fatalf("cdn: %+v", c)
+	}
+	in := b1.TargetSLOs[1]
+	if in.Lines != 2 || in.CompliantLines != 1 || in.TTFBBreaches != 1 || in.CompliancePct != 50 || in.Group != "Intranet" || in.P95TTFBMs != 80 {
+		t.Fatalf("intranet: %+v", in)
+	}
+
+	agg := AggregateTargetSLOs(sums)
+	if len(agg) != 2 || agg[1].Target != "intranet" || agg[1].Batches != 2 || agg[1].Lines != 3 || agg[1].CompliantLines != 2 || agg[1].P95TTFBMs != 80 {
+		t.Fatalf("aggregate: %+v", agg)
+	}
+	if want := (30.0+80)/2*2/3 + 20.0/3; agg[1].AvgTTFBMs < want-0.01 || agg[1].AvgTTFBMs > want+0.01 {
+		t.Fatalf("aggregate avg TTFB %.2f, want %.2f", agg[1].AvgTTFBMs, want)
+	}
+}
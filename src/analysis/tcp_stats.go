@@ -0,0 +1,74 @@
+package analysis
+
+import "github.com/iafilius/InternetQualityMonitor/src/monitor"
+
+// TCPRetransStats rolls up the lines' kernel TCP counters (monitor tcp_stats): the share of the
+// segments the client retransmitted, the share of the received segments that arrived out of
+// order (on downloads mostly the server's retransmissions after a loss), the RTT variation, and
+// how many of the stalled lines (a stall or micro-stall) saw either, which is how loss shows up
+// as a stall or a plateau.
+type TCPRetransStats struct {
+	Lines          int     `json:"lines"`
+	Conns          int     `json:"conns"`
+	SegsOut        int64   `json:"segs_out"`
+	SegsIn         int64   `json:"segs_in"`
+	Retransmits    int64   `json:"retransmits"`
+	RecvOutOfOrder int64   `json:"recv_out_of_order"`
+	RetransRatePct float64 `json:"retrans_rate_pct"`  // Retransmits of SegsOut
+	RecvOOORatePct float64 `json:"recv_ooo_rate_pct"` // RecvOutOfOrder of SegsIn
+	AvgRTTMs       float64 `json:"avg_rtt_ms,omitempty"`
+	AvgRTTVarMs    float64 `json:"avg_rtt_var_ms,omitempty"`
+	// lines with at least one retransmitted or out-of-order segment
+	LossLines int `json:"loss_lines"`
+	// stalled lines, and those of them with loss
+	StalledLines         int `json:"stalled_lines,omitempty"`
+	StalledWithLossLines int `json:"stalled_with_loss_lines,omitempty"`
+}
+
+// tcpLine is what the TCP rollup needs of one HTTP line.
+type tcpLine struct {
+	stats   *monitor.TCPStats
+	stalled bool
+}
+
+// tcpRetransStats sums the lines' tcp_stats; nil when no line has them.
+func tcpRetransStats(lines []tcpLine) *TCPRetransStats {
+	st := &TCPRetransStats{}
+	var rtts, rttVars []float64
+	for _, l := range lines {
+		t := l.stats
+		if t == nil || t.Conns == 0 {
+			continue
+		}
+		st.Lines++
+		st.Conns += t.Conns
+		st.SegsOut += t.SegsOut
+		st.SegsIn += t.SegsIn
+		st.Retransmits += t.Retransmits
+		st.RecvOutOfOrder += t.RecvOutOfOrder
+		if t.RTTMs > 0 {
+			rtts, rttVars = append(rtts, t.RTTMs), append(rttVars, t.RTTVarMs)
+		}
+		loss := t.Retransmits > 0 || t.RecvOutOfOrder > 0
+		if loss {
+			st.LossLines++
+		}
+		if l.stalled {
+			st.StalledLines++
+			if loss {
+				st.StalledWithLossLines++
+			}
+		}
+	}
+	if st.Lines == 0 {
+		return nil
+	}
+	if st.SegsOut > 0 {
+		st.RetransRatePct = float64(st.Retransmits) / float64(st.SegsOut) * 100
+	}
+	if st.SegsIn > 0 {
+		st.RecvOOORatePct = float64(st.RecvOutOfOrder) / float64(st.SegsIn) * 100
+	}
+	st.AvgRTTMs, st.AvgRTTVarMs = meanOf(rtts), meanOf(rttVars)
+	return st
+}
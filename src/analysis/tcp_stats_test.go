@@ -0,0 +1,47 @@
+package analysis
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/iafilius/InternetQualityMonitor/src/monitor"
+)
+
+func TestTCPRetransStats(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.jsonl")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	write := func(tag string, stalled bool, st *monitor.TCPStats) {
+		env := monitor.ResultEnvelope{
+			Meta:       &monitor.Meta{TimestampUTC: time.Now().UTC().Format(time.RFC3339Nano), RunTag: tag, SchemaVersion: monitor.SchemaVersion},
+			SiteResult: &monitor.SiteResult{URL: "https://a.example/", TransferSpeedKbps: 1000, TraceTTFBMs: 50, TransferStalled: stalled, TCPStats: st},
+		}
+		b, _ := json.Marshal(&env)
+		f.Write(append(b, '\n'))
+	}
+	write("20260101_000000", true, &monitor.TCPStats{Conns: 1, SegsOut: 100, SegsIn: 1000, Retransmits: 2, RecvOutOfOrder: 30, RTTMs: 20, RTTVarMs: 5})
+	write("20260101_000000", false, &monitor.TCPStats{Conns: 2, SegsOut: 100, SegsIn: 1000, RTTMs: 10, RTTVarMs: 1})
+	write("20260101_000000", true, nil) // no counters (other OS): not in the rollup
+	write("20260101_001000", false, nil)
+	f.Close()
+
+	sums, err := AnalyzeRecentResultsFull(path, monitor.SchemaVersion, 10, "")
+	if err != nil || len(sums) != 2 {
+		t.Fatalf("analyze: %v (n=%d)", err, len(sums))
+	}
+	st := sums[0].TCPStats
+	if st == nil || st.Lines != 2 || st.Conns != 3 || st.RetransRatePct != 1 || st.RecvOOORatePct != 1.5 || st.AvgRTTMs != 15 || st.AvgRTTVarMs != 3 {
+		t.Fatalf("tcp stats: %+v", st)
+	}
+	if st.LossLines != 1 || st.StalledLines != 1 || st.StalledWithLossLines != 1 {
+		t.Fatalf("loss and stalls: %+v", st)
+	}
+	if sums[1].TCPStats != nil {
+		t.Fatalf("no counters: %+v", sums[1].TCPStats)
+	}
+}
@@ -0,0 +1,173 @@
+package analysis
+
+import (
+	"math"
+	"sort"
+)
+
+// Trend methods accepted by FitTrend.
+const (
+	TrendLinear = "linear"
+	TrendLoess  = "loess"
+)
+
+// loessSpan is the share of the points each LOESS estimate uses.
+const loessSpan = 0.5
+
+// Trend is a fitted trend of one metric series, e.g. a chart's per-batch values over time.
+// Fitted holds the trend at each input x (NaN where it cannot be estimated). Slope and
+// Intercept are the least-squares line over all valid points for either method; the forecast
+// always extrapolates that line, since a LOESS curve has no meaningful continuation.
+type Trend struct {
+	Method    string
+	N         int // valid points used
+	Fitted    []float64
+	Slope     float64 // per x unit
+	Intercept float64
+	// ResidualStd is the standard deviation of the points around the line; it sets the width
+	// of the forecast band.
+	ResidualStd float64
+	Forecast    []ForecastPoint
+}
+
+// ForecastPoint is the linear trend at a future x with its approximate 95% prediction band.
+type ForecastPoint struct {
+	X, Y, Lo, Hi float64
+}
+
+// SlopePct is the line's change per x unit relative to its value at the last valid x, in
+// percent; 0 when that value is not positive. Multiply by the x units per day (or per batch)
+// to read it as e.g. "-1%/day".
+func (t Trend) SlopePct(lastX float64) float64 {
+	y := t.Intercept + t.Slope*lastX
+	if y <= 0 {
+		return 0
+	}
+	return t.Slope / y * 100
+}
+
+// FitTrend fits ys over xs (NaN or Inf ys are skipped) with method linear or loess, and
+// forecasts horizon further points step apart after the last valid x (step <= 0 uses the
+// median spacing of the points). ok is false with fewer than 3 valid points or an unknown
+// method.
+func FitTrend(xs, ys []float64, method string, horizon int, step float64) (Trend, bool) {
+	var vx, vy []float64
+	for i := range xs {
+		if i < len(ys) && !math.IsNaN(ys[i]) && !math.IsInf(ys[i], 0) {
+			vx = append(vx, xs[i])
+			vy = append(vy, ys[i])
+		}
+	}
+	t := Trend{Method: method, N: len(vx)}
+	if len(vx) < 3 || (method != TrendLinear && method != TrendLoess) {
+		return t, false
+	}
+	n := float64(len(vx))
+	var mx, my float64
+	for i := range vx {
+		mx += vx[i]
+		my += vy[i]
+	}
+	mx /= n
+	my /= n
+	var sxx, sxy float64
+	for i := range vx {
+		sxx += (vx[i] - mx) * (vx[i] - mx)
+		sxy += (vx[i] - mx) * (vy[i] - my)
+	}
+	if sxx == 0 {
+		return t, false
+	}
+	t.Slope = sxy / sxx
+	t.Intercept = my - t.Slope*mx
+	var sse float64
+	for i := range vx {
+		r := vy[i] - (t.Intercept + t.Slope*vx[i])
+		sse += r * r
+	}
+	t.ResidualStd = math.Sqrt(sse / (n - 2))
+
+	t.Fitted = make([]float64, len(xs))
+	for i, x := range xs {
+		if method == TrendLoess {
+			t.Fitted[i] = loessAt(vx, vy, x)
+		} else {
+			t.Fitted[i] = t.Intercept + t.Slope*x
+		}
+	}
+
+	if horizon > 0 {
+		if step <= 0 {
+			step = medianSpacing(vx)
+		}
+		last := vx[len(vx)-1]
+		for _, x := range vx {
+			last = math.Max(last, x)
+		}
+		for k := 1; k <= horizon && step > 0; k++ {
+			x := last + float64(k)*step
+			y := t.Intercept + t.Slope*x
+			// prediction interval of a new observation (normal approximation)
+			half := 1.96 * t.ResidualStd * math.Sqrt(1+1/n+(x-mx)*(x-mx)/sxx)
+			t.Forecast = append(t.Forecast, ForecastPoint{X: x, Y: y, Lo: y - half, Hi: y + half})
+		}
+	}
+	return t, true
+}
+
+// loessAt is a tricube-weighted local linear fit at x over the nearest loessSpan of the points
+// (at least 3).
+func loessAt(xs, ys []float64, x float64) float64 {
+	q := int(math.Ceil(loessSpan * float64(len(xs))))
+	q = min(max(q, 3), len(xs))
+	dist := make([]float64, len(xs))
+	for i := range xs {
+		dist[i] = math.Abs(xs[i] - x)
+	}
+	sorted := append([]float64(nil), dist...)
+	sort.Float64s(sorted)
+	h := sorted[q-1]
+	if h == 0 {
+		h = 1
+	}
+	// centred on x so large x values (UnixNano times) keep their precision
+	var sw, swx, swy, swxx, swxy float64
+	for i := range xs {
+		u := dist[i] / h
+		if u >= 1 {
+			continue
+		}
+		w := math.Pow(1-u*u*u, 3)
+		dx := xs[i] - x
+		sw += w
+		swx += w * dx
+		swy += w * ys[i]
+		swxx += w * dx * dx
+		swxy += w * dx * ys[i]
+	}
+	if sw == 0 {
+		return math.NaN()
+	}
+	den := sw*swxx - swx*swx
+	if den <= 1e-12*sw*swxx {
+		return swy / sw // all weight on one x: local mean
+	}
+	b := (sw*swxy - swx*swy) / den
+	return (swy - b*swx) / sw
+}
+
+func medianSpacing(xs []float64) float64 {
+	s := append([]float64(nil), xs...)
+	sort.Float64s(s)
+	var gaps []float64
+	for i := 1; i < len(s); i++ {
+		if g := s[i] - s[i-1]; g > 0 {
+			gaps = append(gaps, g)
+		}
+	}
+	if len(gaps) == 0 {
+		return 0
+	}
+	sort.Float64s(gaps)
+	return gaps[len(gaps)/2]
+}
@@ -0,0 +1,76 @@
+package analysis
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestFitTrend_LinearAndForecast(t *testing.T) {
+	// speed declining 1% of the start value per step, with alternating noise
+	xs := make([]float64, 20)
+	ys := make([]float64, 20)
+	for i := range xs {
+		xs[i] = float64(i + 1)
+		ys[i] = 1000 - 10*float64(i)
+		if i%2 == 0 {
+			ys[i] += 5
+		} else {
+			ys[i] -= 5
+		}
+	}
+	ys[3] = math.NaN() // gaps are skipped
+	tr, ok := FitTrend(xs, ys, TrendLinear, 3, 0)
+	if !ok || tr.N != 19 {
+		t.Fatalf("fit: ok=%v n=%d", ok, tr.N)
+	}
+	if math.Abs(tr.Slope+10) > 0.5 {
+		t.Fatalf("slope %.3f, want about -10", tr.Slope)
+	}
+	if len(tr.Forecast) != 3 || tr.Forecast[0].X != 21 || tr.Forecast[2].X != 23 {
+		t.Fatalf("forecast xs: %+v", tr.Forecast)
+	}
+	for i, f := range tr.Forecast {
+		if !(f.Lo < f.Y && f.Y < f.Hi) || (i > 0 && f.Hi-f.Lo <= tr.Forecast[i-1].Hi-tr.Forecast[i-1].Lo) {
+			t.Fatalf("band should contain the line and widen: %+v", tr.Forecast)
+		}
+	}
+	// -10 per step against a fitted 810 at the last point
+	if pct := tr.SlopePct(20); math.Abs(pct+10.0/810*100) > 0.1 {
+		t.Fatalf("relative slope %.2f%%/step, want about -1.23", pct)
+	}
+	if _, ok := FitTrend(xs[:2], ys[:2], TrendLinear, 1, 0); ok {
+		t.Fatal("two points should not fit")
+	}
+	if _, ok := FitTrend(xs, ys, "spline", 1, 0); ok {
+		t.Fatal("unknown method accepted")
+	}
+}
+
+func TestFitTrend_LoessFollowsCurveOnTimeAxis(t *testing.T) {
+	// a step change halfway: LOESS bends with it, the line cannot; x in UnixNano like time charts
+	start := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	var xs, ys []float64
+	for i := 0; i < 24; i++ {
+		xs = append(xs, float64(start.Add(time.Duration(i)*time.Hour).UnixNano()))
+		y := 100.0
+		if i >= 12 {
+			y = 50
+		}
+		ys = append(ys, y)
+	}
+	lo, ok := FitTrend(xs, ys, TrendLoess, 2, 0)
+	if !ok {
+		t.Fatal("loess fit failed")
+	}
+	if math.Abs(lo.Fitted[0]-100) > 1 || math.Abs(lo.Fitted[23]-50) > 1 {
+		t.Fatalf("loess ends: %.2f .. %.2f", lo.Fitted[0], lo.Fitted[23])
+	}
+	lin, _ := FitTrend(xs, ys, TrendLinear, 2, 0)
+	if math.Abs(lin.Fitted[0]-100) < 5 {
+		t.Fatalf("line should not follow the step: %.2f", lin.Fitted[0])
+	}
+	if step := lo.Forecast[0].X - xs[23]; step != float64(time.Hour) {
+		t.Fatalf("forecast step %v, want the median spacing of 1h", time.Duration(step))
+	}
+}
@@ -0,0 +1,52 @@
+package analysis
+
+import "sort"
+
+// batchWindow keeps the records of the maxBatches highest run_tags seen so far. Run tags are
+// timestamps, so these are the most recent batches; records of older batches are counted in
+// dropped and released as soon as a newer batch pushes them out. A batch that ends up in the
+// window is never evicted on the way there, so the outcome matches grouping the whole file
+// and keeping the last maxBatches tags.
+type batchWindow[T any] struct {
+	maxBatches int
+	batches    map[string][]T
+	dropped    int
+}
+
+func newBatchWindow[T any](maxBatches int) *batchWindow[T] {
+	return &batchWindow[T]{maxBatches: maxBatches, batches: map[string][]T{}}
+}
+
+// add files v under tag and reports whether tag opened a new batch in the window.
+func (w *batchWindow[T]) add(tag string, v T) bool {
+	if recs, ok := w.batches[tag]; ok {
+		w.batches[tag] = append(recs, v)
+		return false
+	}
+	if len(w.batches) >= w.maxBatches {
+		oldest := ""
+		for t := range w.batches {
+			if oldest == "" || t < oldest {
+				oldest = t
+			}
+		}
+		if tag < oldest {
+			w.dropped++
+			return false
+		}
+		w.dropped += len(w.batches[oldest])
+		delete(w.batches, oldest)
+	}
+	w.batches[tag] = []T{v}
+	return true
+}
+
+// tags returns the run tags in the window in ascending (chronological) order.
+func (w *batchWindow[T]) tags() []string {
+	out := make([]string, 0, len(w.batches))
+	for t := range w.batches {
+		out = append(out, t)
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,72 @@
+package analysis
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/iafilius/InternetQualityMonitor/src/monitor"
+)
+
+func TestBatchWindow_MatchesGroupAndTrim(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	tags := []string{"20250101_000000", "20250101_010000", "20250101_020000", "20250101_030000", "20250101_040000", "20250101_050000"}
+	type line struct {
+		tag string
+		n   int
+	}
+	var lines []line
+	for i := 0; i < 300; i++ {
+		// mostly chronological with stragglers from earlier batches (collector files, clock skew)
+		idx := i * len(tags) / 300
+		if rng.Intn(5) == 0 {
+			idx = rng.Intn(len(tags))
+		}
+		lines = append(lines, line{tags[idx], i})
+	}
+	for _, maxBatches := range []int{1, 3, len(tags), 10} {
+		w := newBatchWindow[int](maxBatches)
+		want := map[string][]int{}
+		for _, l := range lines {
+			w.add(l.tag, l.n)
+			want[l.tag] = append(want[l.tag], l.n)
+		}
+		var order []string
+		for tag := range want {
+			order = append(order, tag)
+		}
+		sort.Strings(order)
+		kept := 0
+		if len(order) > maxBatches {
+			order = order[len(order)-maxBatches:]
+		}
+		for _, tag := range order {
+			if !reflect.DeepEqual(w.batches[tag], want[tag]) {
+				t.Fatalf("max=%d: batch %s lines differ", maxBatches, tag)
+			}
+			kept += len(want[tag])
+		}
+		if !reflect.DeepEqual(w.tags(), order) {
+			t.Fatalf("max=%d: tags %v, want %v", maxBatches, w.tags(), order)
+		}
+		if w.dropped != len(lines)-kept {
+			t.Fatalf("max=%d: dropped %d, want %d", maxBatches, w.dropped, len(lines)-kept)
+		}
+	}
+}
+
+func TestAnalyze_OlderBatchLinesStat(t *testing.T) {
+	path := parseFixture(t, 1200) // 24 batches of 50 lines
+	var st LoadStats
+	sums, err := AnalyzeRecentResultsFullWithOptions(path, monitor.SchemaVersion, 5, AnalyzeOptions{Stats: &st})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sums) != 5 || sums[4].RunTag != "20250101_000023" {
+		t.Fatalf("got %d batches, last %q", len(sums), sums[len(sums)-1].RunTag)
+	}
+	if st.OlderBatchLines != st.Parsed-5*50 {
+		t.Fatalf("OlderBatchLines=%d, want %d", st.OlderBatchLines, st.Parsed-5*50)
+	}
+}
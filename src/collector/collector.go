@@ -0,0 +1,330 @@
+// Package collector receives result lines pushed by remote monitor agents (see agent mode in
+// src/monitor/agent.go) and appends them to one JSONL file per agent, plus an optional combined
+// file with every agent's lines. All files are regular results files: the viewer and
+// --analyze-only read them unchanged and can filter by meta.agent.
+//
+// Protocol: POST /v1/results with a JSONL body (optionally Content-Encoding: gzip), headers
+// Authorization: Bearer <token>, X-IQM-Agent: <name> and X-IQM-Batch-Id: <id>. A batch id that
+// was already stored for the agent is acknowledged without writing it again, so agents can retry
+// safely. GET /healthz reports liveness.
+package collector
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const (
+	// DefaultMaxBodyBytes bounds one decompressed push request.
+	DefaultMaxBodyBytes = 64 << 20
+	// CombinedFileName is the file in Dir that receives every agent's lines.
+	CombinedFileName = "all_agents.jsonl"
+	// recentBatchIDs is how many batch ids per agent are remembered for duplicate detection.
+	recentBatchIDs = 512
+)
+
+// Server is an http.Handler that stores pushed result lines.
+type Server struct {
+	Dir          string
+	Token        string
+	Combined     bool  // also append to Dir/all_agents.jsonl
+	MaxBodyBytes int64 // 0 -> DefaultMaxBodyBytes
+	Logf         func(format string, args ...any)
+
+	mu    sync.Mutex
+	files map[string]*os.File
+	seen  map[string]*batchRing
+	// partial holds, per agent and batch id, the files a failed push was already written to,
+	// so its retry appends to the remaining ones only
+	partial map[string]map[string]bool
+}
+
+// New returns a collector writing below dir. token must be non-empty.
+func New(dir, token string) (*Server, error) {
+	if strings.TrimSpace(token) == "" {
+		return nil, errors.New("collector: an auth token is required")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &Server{Dir: dir, Token: token, Combined: true, files: map[string]*os.File{}, seen: map[string]*batchRing{}, partial: map[string]map[string]bool{}}, nil
+}
+
+// PushResponse is the JSON body returned for a push.
+type PushResponse struct {
+	Accepted  int    `json:"accepted"`
+	Rejected  int    `json:"rejected"`
+	Duplicate bool   `json:"duplicate,omitempty"`
+	File      string `json:"file,omitempty"`
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/healthz":
+		w.Write([]byte("ok\n"))
+	case "/v1/results":
+		s.handlePush(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) logf(format string, args ...any) {
+	if s.Logf != nil {
+		s.Logf(format, args...)
+	}
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	got := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return subtle.ConstantTimeCompare([]byte(got), []byte(s.Token)) == 1
+}
+
+func (s *Server) handlePush(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "POST only", http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.authorized(r) {
+		http.Error(w, "invalid or missing bearer token", http.StatusUnauthorized)
+		return
+	}
+	agent := SanitizeAgentName(r.Header.Get("X-IQM-Agent"))
+	if agent == "" {
+		http.Error(w, "X-IQM-Agent header is required", http.StatusBadRequest)
+		return
+	}
+	batchID := strings.TrimSpace(r.Header.Get("X-IQM-Batch-Id"))
+	if batchID != "" && !s.claimBatch(agent, batchID) {
+		writeJSON(w, PushResponse{Duplicate: true, File: agent + ".jsonl"})
+		return
+	}
+	stored := false
+	defer func() {
+		if batchID != "" && !stored {
+			s.releaseBatch(agent, batchID)
+		}
+	}()
+	limit := s.MaxBodyBytes
+	if limit <= 0 {
+		limit = DefaultMaxBodyBytes
+	}
+	var body io.Reader = http.MaxBytesReader(w, r.Body, limit)
+	if strings.EqualFold(r.Header.Get("Content-Encoding"), "gzip") {
+		zr, err := gzip.NewReader(body)
+		if err != nil {
+			http.Error(w, "bad gzip body: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer zr.Close()
+		body = io.LimitReader(zr, limit+1)
+	}
+	data, err := io.ReadAll(body)
+	if err != nil || int64(len(data)) > limit {
+		http.Error(w, "request body too large or unreadable", http.StatusRequestEntityTooLarge)
+		return
+	}
+	lines, rejected := normalizeLines(data, agent)
+	if err := s.store(agent, batchID, lines); err != nil {
+		s.logf("[collector] write %s: %v", agent, err)
+		http.Error(w, "store failed", http.StatusInternalServerError)
+		return
+	}
+	stored = true
+	if rejected > 0 {
+		s.logf("[collector] %s: batch %s accepted=%d rejected=%d (invalid JSON or missing meta)", agent, batchID, len(lines), rejected)
+	}
+	writeJSON(w, PushResponse{Accepted: len(lines), Rejected: rejected, File: agent + ".jsonl"})
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// normalizeLines validates each JSONL line as a result envelope and stamps meta.agent with the
+// authenticated agent name, so lines from different agents stay distinguishable in merged files.
+func normalizeLines(data []byte, agent string) (out [][]byte, rejected int) {
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+	for sc.Scan() {
+		raw := bytes.TrimSpace(sc.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+		var env map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &env); err != nil {
+			rejected++
+			continue
+		}
+		var meta map[string]any
+		if err := json.Unmarshal(env["meta"], &meta); err != nil || meta == nil {
+			rejected++
+			continue
+		}
+		if got, _ := meta["agent"].(string); got != agent {
+			meta["agent"] = agent
+			mb, err := json.Marshal(meta)
+			if err != nil {
+				rejected++
+				continue
+			}
+			env["meta"] = mb
+			if raw, err = json.Marshal(env); err != nil {
+				rejected++
+				continue
+			}
+		}
+		out = append(out, append([]byte(nil), raw...))
+	}
+	return out, rejected
+}
+
+// store appends lines to the agent's file and the combined file. When one write fails, the
+// files already written are remembered under the batch id (when there is one), so the agent's
+// retry does not append the same lines to them again.
+func (s *Server) store(agent, batchID string, lines [][]byte) error {
+	if len(lines) == 0 {
+		return nil
+	}
+	var buf bytes.Buffer
+	for _, l := range lines {
+		buf.Write(l)
+		buf.WriteByte('\n')
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	targets := []string{agent + ".jsonl"}
+	if s.Combined {
+		targets = append(targets, CombinedFileName)
+	}
+	key := agent + "\x00" + batchID
+	done := s.partial[key]
+	for _, name := range targets {
+		if done[name] {
+			continue
+		}
+		f, err := s.fileLocked(name)
+		if err == nil {
+			_, err = f.Write(buf.Bytes())
+		}
+		if err != nil {
+			return err
+		}
+		if batchID != "" {
+			if done == nil {
+				done = map[string]bool{}
+				s.partial[key] = done
+			}
+			done[name] = true
+		}
+	}
+	delete(s.partial, key)
+	return nil
+}
+
+func (s *Server) fileLocked(name string) (*os.File, error) {
+	if f := s.files[name]; f != nil {
+		return f, nil
+	}
+	f, err := os.OpenFile(filepath.Join(s.Dir, name), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	s.files[name] = f
+	return f, nil
+}
+
+// Close closes all open result files.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var first error
+	for name, f := range s.files {
+		if err := f.Close(); err != nil && first == nil {
+			first = fmt.Errorf("close %s: %w", name, err)
+		}
+		delete(s.files, name)
+	}
+	return first
+}
+
+// batchRing remembers the most recent batch ids of one agent.
+type batchRing struct {
+	ids  []string
+	set  map[string]bool
+	next int
+}
+
+// claimBatch records id as received from agent and reports whether it was new. The test and
+// the insert share one lock, so two concurrent pushes of the same batch store it only once.
+func (s *Server) claimBatch(agent, id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r := s.seen[agent]
+	if r == nil {
+		r = &batchRing{ids: make([]string, recentBatchIDs), set: map[string]bool{}}
+		s.seen[agent] = r
+	}
+	if r.set[id] {
+		return false
+	}
+	if old := r.ids[r.next]; old != "" {
+		delete(r.set, old)
+	}
+	r.ids[r.next] = id
+	r.set[id] = true
+	r.next = (r.next + 1) % len(r.ids)
+	return true
+}
+
+// releaseBatch forgets a claimed id whose push failed, so the agent's retry is stored.
+func (s *Server) releaseBatch(agent, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r := s.seen[agent]
+	if r == nil || !r.set[id] {
+		return
+	}
+	delete(r.set, id)
+	for i, v := range r.ids {
+		if v == id {
+			r.ids[i] = ""
+		}
+	}
+}
+
+// SanitizeAgentName maps an agent name to a safe file stem: lowercase letters, digits, '-', '_'
+// and '.', other characters become '-'. Leading dots are trimmed so names cannot escape Dir or
+// create hidden files.
+func SanitizeAgentName(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	var b strings.Builder
+	for _, r := range name {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '-' || r == '_' || r == '.' {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('-')
+		}
+	}
+	out := strings.TrimLeft(b.String(), ".")
+	if len(out) > 64 {
+		out = out[:64]
+	}
+	if out == strings.TrimSuffix(CombinedFileName, ".jsonl") {
+		out += "-agent"
+	}
+	return out
+}
@@ -0,0 +1,184 @@
+package collector
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func push(t *testing.T, h http.Handler, token, agent, batch string, body string, gz bool) *httptest.ResponseRecorder {
+	t.Helper()
+	var buf bytes.Buffer
+	if gz {
+		zw := gzip.NewWriter(&buf)
+		zw.Write([]byte(body))
+		zw.Close()
+	} else {
+		buf.WriteString(body)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/v1/results", &buf)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	req.Header.Set("X-IQM-Agent", agent)
+	req.Header.Set("X-IQM-Batch-Id", batch)
+	if gz {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestServer_StoresPerAgentAndStampsAgent(t *testing.T) {
+	dir := t.TempDir()
+	srv, err := New(dir, "s3cret")
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	defer srv.Close()
+	body := `{"meta":{"run_tag":"R1","schema_version":3},"site_result":{"name":"a"}}` + "\n" +
+		"not json\n" +
+		`{"meta":{"run_tag":"R1","agent":"laptop","schema_version":3},"site_result":{"name":"b"}}` + "\n"
+	rr := push(t, srv, "s3cret", "Laptop", "n-1", body, true)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp PushResponse
+	json.Unmarshal(rr.Body.Bytes(), &resp)
+	if resp.Accepted != 2 || resp.Rejected != 1 || resp.File != "laptop.jsonl" {
+		t.Fatalf("resp=%+v", resp)
+	}
+	b, err := os.ReadFile(filepath.Join(dir, "laptop.jsonl"))
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("want 2 stored lines, got %d", len(lines))
+	}
+	for _, l := range lines {
+		var env struct {
+			Meta struct {
+				Agent string `json:"agent"`
+			} `json:"meta"`
+		}
+		if err := json.Unmarshal([]byte(l), &env); err != nil || env.Meta.Agent != "laptop" {
+			t.Fatalf("line not stamped with agent: %s", l)
+		}
+	}
+	if comb, _ := os.ReadFile(filepath.Join(dir, CombinedFileName)); !bytes.Equal(comb, b) {
+		t.Fatalf("combined file should mirror the single agent's lines")
+	}
+	// A retried batch (same id) is acknowledged but not written again.
+	rr = push(t, srv, "s3cret", "Laptop", "n-1", body, true)
+	json.Unmarshal(rr.Body.Bytes(), &resp)
+	if rr.Code != http.StatusOK || !resp.Duplicate {
+		t.Fatalf("duplicate not detected: %d %s", rr.Code, rr.Body.String())
+	}
+	if again, _ := os.ReadFile(filepath.Join(dir, "laptop.jsonl")); len(again) != len(b) {
+		t.Fatalf("duplicate batch was written again")
+	}
+}
+
+func TestServer_ConcurrentDuplicatesStoredOnce(t *testing.T) {
+	dir := t.TempDir()
+	srv, err := New(dir, "s3cret")
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	defer srv.Close()
+	body := `{"meta":{"run_tag":"R1","schema_version":3},"site_result":{"name":"a"}}` + "\n"
+	// An oversized push releases its id, so the agent's retry is stored.
+	srv.MaxBodyBytes = 8
+	if rr := push(t, srv, "s3cret", "laptop", "n-1", body, false); rr.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("oversized: %d", rr.Code)
+	}
+	srv.MaxBodyBytes = 0
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			push(t, srv, "s3cret", "laptop", "n-1", body, false)
+		}()
+	}
+	wg.Wait()
+	b, _ := os.ReadFile(filepath.Join(dir, "laptop.jsonl"))
+	if n := strings.Count(string(b), "\n"); n != 1 {
+		t.Fatalf("batch stored %d times", n)
+	}
+}
+
+func TestServer_RetryAfterFailedCombinedWrite(t *testing.T) {
+	dir := t.TempDir()
+	srv, err := New(dir, "s3cret")
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	defer srv.Close()
+	// a directory in place of the combined file makes its write fail
+	combined := filepath.Join(dir, CombinedFileName)
+	if err := os.Mkdir(combined, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	body := `{"meta":{"run_tag":"R1","schema_version":3},"site_result":{"name":"a"}}` + "\n"
+	if rr := push(t, srv, "s3cret", "laptop", "p-1", body, false); rr.Code != http.StatusInternalServerError {
+		t.Fatalf("failed combined write: %d", rr.Code)
+	}
+	os.Remove(combined)
+	if rr := push(t, srv, "s3cret", "laptop", "p-1", body, false); rr.Code != http.StatusOK {
+		t.Fatalf("retry: %d %s", rr.Code, rr.Body)
+	}
+	for _, name := range []string{"laptop.jsonl", CombinedFileName} {
+		b, _ := os.ReadFile(filepath.Join(dir, name))
+		if n := strings.Count(string(b), "\n"); n != 1 {
+			t.Fatalf("%s holds the batch %d times", name, n)
+		}
+	}
+	if len(srv.partial) != 0 {
+		t.Fatalf("partial writes left: %v", srv.partial)
+	}
+}
+
+func TestServer_RejectsBadTokenAndMissingAgent(t *testing.T) {
+	srv, err := New(t.TempDir(), "s3cret")
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	defer srv.Close()
+	if rr := push(t, srv, "wrong", "a", "1", "{}\n", false); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("bad token: status %d", rr.Code)
+	}
+	if rr := push(t, srv, "", "a", "1", "{}\n", false); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("missing token: status %d", rr.Code)
+	}
+	if rr := push(t, srv, "s3cret", "", "1", "{}\n", false); rr.Code != http.StatusBadRequest {
+		t.Fatalf("missing agent: status %d", rr.Code)
+	}
+	if _, err := New(t.TempDir(), " "); err == nil {
+		t.Fatalf("empty token must be refused")
+	}
+}
+
+func TestSanitizeAgentName(t *testing.T) {
+	cases := map[string]string{
+		"Office Mac":     "office-mac",
+		"../../etc":      "-..-etc",
+		"..hidden":       "hidden",
+		"all_agents":     "all_agents-agent",
+		"host.example.c": "host.example.c",
+	}
+	for in, want := range cases {
+		if got := SanitizeAgentName(in); got != want {
+			t.Errorf("SanitizeAgentName(%q)=%q want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,233 @@
+// Package config loads the monitor's YAML config file.
+//
+// The file mirrors the command-line flags: every key is a flag name (without dashes) and its
+// value is what you would pass on the command line. Settings under `defaults` always apply;
+// settings under `profiles.<name>` apply on top when that profile is selected (via --profile or
+// the top-level `profile` key). Flags given on the command line override both.
+//
+//	profile: home            # optional default profile
+//	defaults:
+//	  parallel: 2
+//	  out: monitor_results_{host}.jsonl
+//	profiles:
+//	  home:
+//	    situation: Home
+//	  office-vpn:
+//	    situation: Office-VPN
+//	    http-timeout: 60s
+//	    otlp-endpoint: ${OTLP_ENDPOINT:-http://localhost:4318}
+//
+// String values may reference environment variables as ${VAR} or ${VAR:-fallback}; referencing
+// an unset variable without a fallback is an error. Substitution happens after YAML parsing, so
+// a variable cannot inject YAML structure.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Setting is one resolved key/value pair from the config file.
+type Setting struct {
+	Key    string
+	Value  string
+	Source string // "defaults" or "profile <name>"
+	Line   int
+}
+
+// Config is a parsed config file with the selected profile already merged over the defaults.
+type Config struct {
+	Path     string
+	Profile  string   // selected profile ("" when none)
+	Profiles []string // all profile names, sorted
+	Settings []Setting
+	// keys of profiles that were not selected; validated by Apply but never applied
+	otherKeys []Setting
+}
+
+// reservedKeys are flags that select the config itself and cannot be set from inside it.
+var reservedKeys = map[string]bool{"config": true, "profile": true}
+
+type fileLayout struct {
+	Profile  string               `yaml:"profile"`
+	Defaults yaml.Node            `yaml:"defaults"`
+	Profiles map[string]yaml.Node `yaml:"profiles"`
+}
+
+// Load reads path and resolves profile (empty = the file's `profile` key, if any). It does not
+// check keys against the flag set; Apply does that.
+func Load(path, profile string) (*Config, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(path, b, profile, os.LookupEnv)
+}
+
+// Parse is Load for in-memory data; lookupEnv resolves ${VAR} references.
+func Parse(path string, data []byte, profile string, lookupEnv func(string) (string, bool)) (*Config, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	var lay fileLayout
+	if err := dec.Decode(&lay); err != nil && !errors.Is(err, io.EOF) { // io.EOF: empty file
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	cfg := &Config{Path: path}
+	for name := range lay.Profiles {
+		cfg.Profiles = append(cfg.Profiles, name)
+	}
+	sort.Strings(cfg.Profiles)
+	if profile == "" {
+		profile = strings.TrimSpace(lay.Profile)
+	}
+	defaults, err := settingsFrom(path, "defaults", &lay.Defaults, lookupEnv)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Settings = defaults
+	if profile != "" {
+		node, ok := lay.Profiles[profile]
+		if !ok {
+			if len(cfg.Profiles) == 0 {
+				return nil, fmt.Errorf("%s: profile %q not found (the file defines no profiles)", path, profile)
+			}
+			return nil, fmt.Errorf("%s: profile %q not found (available: %s)", path, profile, strings.Join(cfg.Profiles, ", "))
+		}
+		prof, err := settingsFrom(path, "profile "+profile, &node, lookupEnv)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Settings = merge(cfg.Settings, prof)
+		cfg.Profile = profile
+	}
+	for _, name := range cfg.Profiles {
+		if name == profile {
+			continue
+		}
+		node := lay.Profiles[name]
+		if node.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("%s:%d: profile %s must be a mapping of flag names to values", path, node.Line, name)
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			k := node.Content[i]
+			cfg.otherKeys = append(cfg.otherKeys, Setting{Key: strings.TrimLeft(strings.TrimSpace(k.Value), "-"), Source: "profile " + name, Line: k.Line})
+		}
+	}
+	return cfg, nil
+}
+
+func settingsFrom(path, source string, node *yaml.Node, lookupEnv func(string) (string, bool)) ([]Setting, error) {
+	if node.Kind == 0 {
+		return nil, nil
+	}
+	if node.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%s:%d: %s must be a mapping of flag names to values", path, node.Line, source)
+	}
+	var out []Setting
+	seen := map[string]bool{}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		k, v := node.Content[i], node.Content[i+1]
+		key := strings.TrimLeft(strings.TrimSpace(k.Value), "-")
+		if reservedKeys[key] {
+			return nil, fmt.Errorf("%s:%d: %s: %q cannot be set inside the config file", path, k.Line, source, key)
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("%s:%d: %s: duplicate key %q", path, k.Line, source, key)
+		}
+		seen[key] = true
+		if v.Kind != yaml.ScalarNode {
+			return nil, fmt.Errorf("%s:%d: %s: %s must be a single value (lists and mappings are not supported)", path, v.Line, source, key)
+		}
+		val, err := expandEnv(v.Value, lookupEnv)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %s: %s: %w", path, v.Line, source, key, err)
+		}
+		out = append(out, Setting{Key: key, Value: val, Source: source, Line: v.Line})
+	}
+	return out, nil
+}
+
+// merge returns base with over applied: overridden keys keep their position, new keys append.
+func merge(base, over []Setting) []Setting {
+	idx := map[string]int{}
+	out := append([]Setting(nil), base...)
+	for i, s := range out {
+		idx[s.Key] = i
+	}
+	for _, s := range over {
+		if i, ok := idx[s.Key]; ok {
+			out[i] = s
+			continue
+		}
+		idx[s.Key] = len(out)
+		out = append(out, s)
+	}
+	return out
+}
+
+var envRef = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)((:?)-([^}]*))?\}`)
+
+// expandEnv substitutes ${VAR}, ${VAR-fallback} and ${VAR:-fallback} like the shell: ${VAR}
+// takes the value even when empty, the fallback replaces an unset variable, and with ":-" also
+// an empty one. Bare $VAR is left alone because the output path placeholders ($HOST) use that
+// form.
+func expandEnv(s string, lookupEnv func(string) (string, bool)) (string, error) {
+	var missing []string
+	out := envRef.ReplaceAllStringFunc(s, func(m string) string {
+		sub := envRef.FindStringSubmatch(m)
+		v, ok := lookupEnv(sub[1])
+		if ok && (v != "" || sub[3] == "") {
+			return v
+		}
+		if sub[2] != "" {
+			return sub[4]
+		}
+		missing = append(missing, sub[1])
+		return m
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("environment variable %s is not set (use ${%s:-default} for a fallback)", strings.Join(missing, ", "), missing[0])
+	}
+	return out, nil
+}
+
+// Apply sets each config value on fs unless the flag was given explicitly on the command line
+// (explicit holds flag names as seen by flag.Visit). Unknown keys and values the flag rejects
+// are reported together with their file position. It returns the keys that were applied.
+func (c *Config) Apply(fs *flag.FlagSet, explicit map[string]bool) ([]string, error) {
+	var errs []string
+	var applied []string
+	for _, s := range c.otherKeys {
+		if fs.Lookup(s.Key) == nil {
+			errs = append(errs, fmt.Sprintf("%s:%d: %s: unknown setting %q (keys are monitor flag names, see --help)", c.Path, s.Line, s.Source, s.Key))
+		}
+	}
+	for _, s := range c.Settings {
+		f := fs.Lookup(s.Key)
+		if f == nil {
+			errs = append(errs, fmt.Sprintf("%s:%d: %s: unknown setting %q (keys are monitor flag names, see --help)", c.Path, s.Line, s.Source, s.Key))
+			continue
+		}
+		if explicit[s.Key] {
+			continue
+		}
+		if err := fs.Set(s.Key, s.Value); err != nil {
+			errs = append(errs, fmt.Sprintf("%s:%d: %s: %s: invalid value %q: %v", c.Path, s.Line, s.Source, s.Key, s.Value, err))
+			continue
+		}
+		applied = append(applied, s.Key)
+	}
+	if len(errs) > 0 {
+		return applied, errors.New(strings.Join(errs, "\n"))
+	}
+	return applied, nil
+}
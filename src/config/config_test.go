@@ -0,0 +1,146 @@
+package config
+
+import (
+	"flag"
+	"strings"
+	"testing"
+	"time"
+)
+
+const sample = `
+profile: home
+defaults:
+  parallel: 2
+  http-timeout: 90s
+  out: results_{host}.jsonl
+profiles:
+  home:
+    situation: Home
+  office-vpn:
+    situation: Office-VPN
+    http-timeout: 60s
+    otlp-endpoint: ${OTLP_ENDPOINT:-http://localhost:4318}
+  hotspot:
+    situation: ${HOTSPOT_NAME}
+`
+
+func testFlags() (*flag.FlagSet, *int, *time.Duration, *string, *string, *string) {
+	fs := flag.NewFlagSet("t", flag.ContinueOnError)
+	parallel := fs.Int("parallel", 1, "")
+	httpTimeout := fs.Duration("http-timeout", 120*time.Second, "")
+	out := fs.String("out", "monitor_results.jsonl", "")
+	situation := fs.String("situation", "Unknown", "")
+	otlp := fs.String("otlp-endpoint", "", "")
+	return fs, parallel, httpTimeout, out, situation, otlp
+}
+
+func noEnv(string) (string, bool) { return "", false }
+
+func TestParse_ProfileOverDefaultsAndFlagsOverride(t *testing.T) {
+	cfg, err := Parse("iqm.yaml", []byte(sample), "office-vpn", noEnv)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	fs, parallel, httpTimeout, out, situation, otlp := testFlags()
+	if err := fs.Parse([]string{"--parallel", "8"}); err != nil {
+		t.Fatalf("flags: %v", err)
+	}
+	explicit := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+	if _, err := cfg.Apply(fs, explicit); err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if *parallel != 8 {
+		t.Fatalf("command-line flag must win: parallel=%d", *parallel)
+	}
+	if *httpTimeout != 60*time.Second || *situation != "Office-VPN" || *out != "results_{host}.jsonl" {
+		t.Fatalf("got http-timeout=%s situation=%q out=%q", *httpTimeout, *situation, *out)
+	}
+	if *otlp != "http://localhost:4318" {
+		t.Fatalf("env fallback not applied: %q", *otlp)
+	}
+}
+
+func TestParse_DefaultProfileAndEnvSubstitution(t *testing.T) {
+	env := func(k string) (string, bool) {
+		if k == "HOTSPOT_NAME" {
+			return "Phone", true
+		}
+		return "", false
+	}
+	cfg, err := Parse("iqm.yaml", []byte(sample), "", env)
+	if err != nil || cfg.Profile != "home" {
+		t.Fatalf("default profile: %v (profile=%q)", err, cfg.Profile)
+	}
+	cfg, err = Parse("iqm.yaml", []byte(sample), "hotspot", env)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	fs, _, _, _, situation, _ := testFlags()
+	if _, err := cfg.Apply(fs, nil); err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if *situation != "Phone" {
+		t.Fatalf("situation=%q want Phone", *situation)
+	}
+}
+
+func TestExpandEnv_UnsetVersusEmpty(t *testing.T) {
+	env := func(k string) (string, bool) {
+		if k == "EMPTY" {
+			return "", true
+		}
+		return "", false
+	}
+	for in, want := range map[string]string{
+		"${EMPTY}":      "",
+		"${EMPTY:-d}":   "d",
+		"${EMPTY-d}":    "",
+		"${UNSET-d}":    "d",
+		"${UNSET:-}":    "",
+		"a${UNSET:-b}c": "abc",
+	} {
+		if got, err := expandEnv(in, env); err != nil || got != want {
+			t.Fatalf("%s: got %q (%v), want %q", in, got, err, want)
+		}
+	}
+	if _, err := expandEnv("${UNSET}", env); err == nil {
+		t.Fatal("unset variable without fallback accepted")
+	}
+}
+
+func TestParse_ClearErrors(t *testing.T) {
+	cases := []struct {
+		name, data, profile, want string
+	}{
+		{"missing env", sample, "hotspot", "iqm.yaml:15: profile hotspot: situation: environment variable HOTSPOT_NAME is not set"},
+		{"unknown profile", sample, "travel", `profile "travel" not found (available: home, hotspot, office-vpn)`},
+		{"unknown top-level", "defaultz:\n  parallel: 2\n", "", "field defaultz not found"},
+		{"reserved key", "defaults:\n  profile: home\n", "", `iqm.yaml:2: defaults: "profile" cannot be set inside the config file`},
+		{"list value", "defaults:\n  parallel: [1, 2]\n", "", "parallel must be a single value"},
+	}
+	for _, c := range cases {
+		_, err := Parse("iqm.yaml", []byte(c.data), c.profile, noEnv)
+		if err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("%s: got %v, want error containing %q", c.name, err, c.want)
+		}
+	}
+}
+
+func TestApply_ReportsUnknownKeysAndBadValues(t *testing.T) {
+	data := "defaults:\n  http-timeout: 9x\n  paralel: 3\nprofiles:\n  other:\n    bogus: 1\n"
+	cfg, err := Parse("iqm.yaml", []byte(data), "", noEnv)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	fs, _, _, _, _, _ := testFlags()
+	_, err = cfg.Apply(fs, nil)
+	if err == nil {
+		t.Fatalf("expected validation errors")
+	}
+	for _, want := range []string{`iqm.yaml:2: defaults: http-timeout: invalid value "9x"`, `iqm.yaml:3: defaults: unknown setting "paralel"`, `iqm.yaml:6: profile other: unknown setting "bogus"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q missing %q", err, want)
+		}
+	}
+}
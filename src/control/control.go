@@ -0,0 +1,439 @@
+// Package control is the local control API of a long-running monitor (--control-listen). It
+// lets the viewer or a script steer the collector without restarting it: trigger a batch now,
+// change the batch interval, reload the sites file, and read the status and the newest batch
+// summary. The monitor's batch loop drives a Controller (BatchStarted, BatchDone, Wait,
+// TakeSites); the Controller is also an http.Handler serving the API.
+//
+// Endpoints (JSON): GET /v1/status, GET /v1/summary, POST /v1/batch, PUT or POST /v1/interval
+// with {"interval":"15m"}, POST /v1/reload. Every request needs Authorization: Bearer <token>
+// (see LoadOrCreateToken), a Host and Origin that name this machine or an IP address, and POST
+// and PUT requests need Content-Type: application/json; GET /healthz is always open.
+package control
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/iafilius/InternetQualityMonitor/src/analysis"
+	"github.com/iafilius/InternetQualityMonitor/src/types"
+)
+
+// Monitor states reported in Status.State.
+const (
+	StateMeasuring = "measuring"
+	StateWaiting   = "waiting"
+	StateStopping  = "stopping"
+)
+
+// Status is the body of GET /v1/status.
+type Status struct {
+	State      string `json:"state"`
+	PID        int    `json:"pid"`
+	Iteration  int    `json:"iteration"`  // batches started so far
+	Iterations int    `json:"iterations"` // --iterations; 0 = until stopped
+	RunTag     string `json:"run_tag,omitempty"`
+	// times are RFC 3339 UTC; NextBatchUTC is empty while measuring and when no batch follows
+	StartedUTC     string `json:"started_utc"`
+	BatchStartUTC  string `json:"batch_start_utc,omitempty"`
+	LastBatchUTC   string `json:"last_batch_done_utc,omitempty"`
+	NextBatchUTC   string `json:"next_batch_utc,omitempty"`
+	Interval       string `json:"interval"`
+	TriggerPending bool   `json:"trigger_pending,omitempty"`
+	Sites          int    `json:"sites"`
+	SitesPath      string `json:"sites_path"`
+	ReloadPending  bool   `json:"reload_pending,omitempty"`
+	OutFile        string `json:"out_file"`
+	LastRunTag     string `json:"last_run_tag,omitempty"` // newest analyzed batch
+}
+
+// Controller holds the state shared between the batch loop and the API handlers.
+type Controller struct {
+	// Token every API request must present; an empty token refuses them all.
+	Token string
+	// LoadSites reads and validates the sites file for POST /v1/reload; the result is applied
+	// at the start of the next batch (TakeSites).
+	LoadSites func() ([]types.Site, error)
+
+	listenHost  string // host of the ListenAndServe address, accepted in Host and Origin
+	mu          sync.Mutex
+	status      Status
+	interval    time.Duration
+	batchStart  time.Time
+	last        *analysis.BatchSummary
+	pending     []types.Site
+	trigger     chan struct{} // buffered 1: a request made while measuring runs right after
+	changed     chan struct{} // wakes Wait when the interval changes
+	moreBatches bool
+}
+
+// New returns a controller; interval is the pause between batch starts (0 = back to back).
+func New(sitesPath, outFile string, sites, iterations int, interval time.Duration) *Controller {
+	return &Controller{
+		status: Status{
+			State: StateWaiting, PID: os.Getpid(), Iterations: iterations, StartedUTC: utc(time.Now()),
+			Sites: sites, SitesPath: sitesPath, OutFile: outFile,
+		},
+		interval:    interval,
+		trigger:     make(chan struct{}, 1),
+		changed:     make(chan struct{}, 1),
+		moreBatches: true,
+	}
+}
+
+// ListenAndServe serves the API on addr until the returned server is shut down. It refuses to
+// serve without a token.
+func (c *Controller) ListenAndServe(addr string) (*http.Server, net.Addr, error) {
+	if strings.TrimSpace(c.Token) == "" {
+		return nil, nil, errors.New("control: no token set")
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, nil, err
+	}
+	c.listenHost, _, _ = net.SplitHostPort(addr)
+	hs := &http.Server{Handler: c, ReadHeaderTimeout: 10 * time.Second}
+	go hs.Serve(ln)
+	return hs, ln.Addr(), nil
+}
+
+// LoopbackAddr reports whether the listen address addr only accepts local connections.
+func LoopbackAddr(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// DefaultTokenFile is where a monitor keeps the control token it generated and where the viewer
+// looks for it: control-token in the user's config directory (e.g. ~/.config/iqm).
+func DefaultTokenFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "iqm", "control-token")
+}
+
+// ReadTokenFile returns the token stored in path.
+func ReadTokenFile(path string) (string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	tok := strings.TrimSpace(string(b))
+	if tok == "" {
+		return "", fmt.Errorf("control: %s is empty", path)
+	}
+	return tok, nil
+}
+
+// LoadOrCreateToken returns the token in path or, when the file does not exist, generates a
+// random one and writes it there readable by the current user only, so local clients can use it.
+func LoadOrCreateToken(path string) (string, error) {
+	if path == "" {
+		return "", errors.New("control: no token file")
+	}
+	tok, err := ReadTokenFile(path)
+	if err == nil || !errors.Is(err, os.ErrNotExist) {
+		return tok, err
+	}
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	tok = hex.EncodeToString(b)
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, []byte(tok+"\n"), 0o600); err != nil {
+		return "", err
+	}
+	return tok, nil
+}
+
+// BatchStarted records the start of a batch; the next one is due interval after this.
+func (c *Controller) BatchStarted(iteration int, runTag string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.batchStart = time.Now()
+	c.status.State, c.status.Iteration, c.status.RunTag = StateMeasuring, iteration, runTag
+	c.status.BatchStartUTC, c.status.NextBatchUTC = utc(c.batchStart), ""
+}
+
+// BatchDone records the end of the running batch and its newest summary (nil when the analysis
+// failed); more reports whether another batch follows.
+func (c *Controller) BatchDone(sum *analysis.BatchSummary, more bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if sum != nil {
+		c.last = sum
+		c.status.LastRunTag = sum.RunTag
+	}
+	c.status.LastBatchUTC = utc(time.Now())
+	c.moreBatches = more
+	if c.status.State != StateStopping {
+		c.status.State = StateWaiting
+	}
+}
+
+// Stopping marks the monitor as shutting down.
+func (c *Controller) Stopping() {
+	c.mu.Lock()
+	c.status.State, c.status.NextBatchUTC = StateStopping, ""
+	c.mu.Unlock()
+}
+
+// Trigger asks for a batch now; if one is running, the next starts as soon as it ends.
+func (c *Controller) Trigger() {
+	select {
+	case c.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// SetInterval changes the pause between batch starts; a running Wait picks it up at once.
+func (c *Controller) SetInterval(d time.Duration) {
+	c.mu.Lock()
+	c.interval = d
+	c.mu.Unlock()
+	select {
+	case c.changed <- struct{}{}:
+	default:
+	}
+}
+
+// Interval returns the current pause between batch starts.
+func (c *Controller) Interval() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.interval
+}
+
+// Wait blocks until the next batch is due (interval after the previous start), a batch is
+// triggered, or stop is closed. It reports whether a batch should run.
+func (c *Controller) Wait(stop <-chan struct{}) bool {
+	for {
+		c.mu.Lock()
+		due := c.batchStart.Add(c.interval)
+		if c.status.State != StateStopping {
+			c.status.NextBatchUTC = utc(due)
+		}
+		c.mu.Unlock()
+		timer := time.NewTimer(time.Until(due))
+		select {
+		case <-stop:
+			timer.Stop()
+			return false
+		case <-c.trigger:
+			timer.Stop()
+			return true
+		case <-timer.C:
+			// a trigger that raced with the timer is used up by this batch
+			select {
+			case <-c.trigger:
+			default:
+			}
+			return true
+		case <-c.changed:
+			timer.Stop()
+		}
+	}
+}
+
+// TakeSites returns the sites loaded by the last successful reload, once.
+func (c *Controller) TakeSites() ([]types.Site, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.pending == nil {
+		return nil, false
+	}
+	s := c.pending
+	c.pending = nil
+	c.status.Sites, c.status.ReloadPending = len(s), false
+	return s, true
+}
+
+// Status returns a snapshot of the monitor state.
+func (c *Controller) Status() Status {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	st := c.status
+	st.Interval = c.interval.String()
+	st.TriggerPending = len(c.trigger) > 0
+	if !c.moreBatches {
+		st.NextBatchUTC = ""
+	}
+	return st
+}
+
+func (c *Controller) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/healthz" {
+		w.Write([]byte("ok\n"))
+		return
+	}
+	if !c.localRequest(r) {
+		http.Error(w, "foreign Host or Origin", http.StatusForbidden)
+		return
+	}
+	if !c.authorized(r) {
+		http.Error(w, "invalid or missing bearer token", http.StatusUnauthorized)
+		return
+	}
+	if r.Method == http.MethodPost || r.Method == http.MethodPut {
+		if mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mt != "application/json" {
+			http.Error(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
+			return
+		}
+	}
+	switch r.URL.Path {
+	case "/v1/status":
+		if allow(w, r, http.MethodGet) {
+			writeJSON(w, http.StatusOK, c.Status())
+		}
+	case "/v1/summary":
+		if !allow(w, r, http.MethodGet) {
+			return
+		}
+		c.mu.Lock()
+		last := c.last
+		c.mu.Unlock()
+		if last == nil {
+			http.Error(w, "no batch analyzed yet", http.StatusNotFound)
+			return
+		}
+		writeJSON(w, http.StatusOK, last)
+	case "/v1/batch":
+		if allow(w, r, http.MethodPost) {
+			c.Trigger()
+			writeJSON(w, http.StatusAccepted, c.Status())
+		}
+	case "/v1/interval":
+		if allow(w, r, http.MethodPut, http.MethodPost) {
+			c.handleInterval(w, r)
+		}
+	case "/v1/reload":
+		if allow(w, r, http.MethodPost) {
+			c.handleReload(w)
+		}
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (c *Controller) authorized(r *http.Request) bool {
+	if c.Token == "" {
+		return false
+	}
+	got := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return subtle.ConstantTimeCompare([]byte(got), []byte(c.Token)) == 1
+}
+
+// localRequest rejects requests a web page could make through the user's browser: a Host that is
+// a DNS name other than localhost or the listen host (DNS rebinding), or an Origin other than
+// this machine.
+func (c *Controller) localRequest(r *http.Request) bool {
+	host := hostOnly(r.Host)
+	if host == "" || (net.ParseIP(host) == nil && !c.ownHost(host)) {
+		return false
+	}
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	host = hostOnly(u.Host)
+	ip := net.ParseIP(host)
+	return (ip != nil && ip.IsLoopback()) || c.ownHost(host)
+}
+
+// ownHost reports whether host is localhost or the host the API listens on.
+func (c *Controller) ownHost(host string) bool {
+	return host != "" && (strings.EqualFold(host, "localhost") || strings.EqualFold(host, c.listenHost))
+}
+
+func hostOnly(hostport string) string {
+	if h, _, err := net.SplitHostPort(hostport); err == nil {
+		hostport = h
+	}
+	return strings.TrimSuffix(strings.Trim(hostport, "[]"), ".")
+}
+
+func (c *Controller) handleInterval(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Interval string `json:"interval"`
+	}
+	if err := json.NewDecoder(io.LimitReader(r.Body, 4096)).Decode(&req); err != nil {
+		http.Error(w, "body must be {\"interval\":\"<duration>\"}", http.StatusBadRequest)
+		return
+	}
+	d, err := time.ParseDuration(strings.TrimSpace(req.Interval))
+	if err != nil || d < 0 {
+		http.Error(w, fmt.Sprintf("invalid interval %q", req.Interval), http.StatusBadRequest)
+		return
+	}
+	c.SetInterval(d)
+	writeJSON(w, http.StatusOK, c.Status())
+}
+
+func (c *Controller) handleReload(w http.ResponseWriter) {
+	if c.LoadSites == nil {
+		http.Error(w, "reload not supported", http.StatusNotImplemented)
+		return
+	}
+	sites, err := c.LoadSites()
+	if err == nil && len(sites) == 0 {
+		err = errors.New("no sites loaded")
+	}
+	if err != nil {
+		http.Error(w, "reload: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	c.mu.Lock()
+	c.pending = sites
+	c.status.ReloadPending = true
+	c.mu.Unlock()
+	writeJSON(w, http.StatusOK, map[string]any{"sites": len(sites), "applies": "next batch"})
+}
+
+func allow(w http.ResponseWriter, r *http.Request, methods ...string) bool {
+	for _, m := range methods {
+		if r.Method == m {
+			return true
+		}
+	}
+	w.Header().Set("Allow", strings.Join(methods, ", "))
+	http.Error(w, strings.Join(methods, " or ")+" only", http.StatusMethodNotAllowed)
+	return false
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+func utc(t time.Time) string { return t.UTC().Format(time.RFC3339) }
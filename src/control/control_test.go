@@ -0,0 +1,190 @@
+package control
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iafilius/InternetQualityMonitor/src/analysis"
+	"github.com/iafilius/InternetQualityMonitor/src/types"
+)
+
+func call(t *testing.T, h http.Handler, method, path, token, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Host = "127.0.0.1:8098"
+	if method == http.MethodPost || method == http.MethodPut {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestAPI(t *testing.T) {
+	c := New("sites.jsonc", "out.jsonl", 3, 0, time.Hour)
+	c.Token = "s3cret"
+	reloadErr := errors.New("bad json")
+	c.LoadSites = func() ([]types.Site, error) {
+		if reloadErr != nil {
+			return nil, reloadErr
+		}
+		return []types.Site{{Name: "a"}, {Name: "b"}}, nil
+	}
+	if rr := call(t, c, http.MethodGet, "/v1/status", "", ""); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("no token: %d", rr.Code)
+	}
+	if rr := call(t, c, http.MethodGet, "/healthz", "", ""); rr.Code != http.StatusOK {
+		t.Fatalf("healthz: %d", rr.Code)
+	}
+	if rr := call(t, c, http.MethodGet, "/v1/summary", "s3cret", ""); rr.Code != http.StatusNotFound {
+		t.Fatalf("summary before any batch: %d", rr.Code)
+	}
+	if rr := call(t, c, http.MethodGet, "/v1/batch", "s3cret", ""); rr.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("GET batch: %d", rr.Code)
+	}
+
+	c.BatchStarted(1, "R1")
+	var st Status
+	json.Unmarshal(call(t, c, http.MethodGet, "/v1/status", "s3cret", "").Body.Bytes(), &st)
+	if st.State != StateMeasuring || st.RunTag != "R1" || st.Iteration != 1 || st.Sites != 3 || st.Interval != "1h0m0s" || st.NextBatchUTC != "" {
+		t.Fatalf("status while measuring: %+v", st)
+	}
+	c.BatchDone(&analysis.BatchSummary{RunTag: "R1", Lines: 7}, true)
+	var sum analysis.BatchSummary
+	if rr := call(t, c, http.MethodGet, "/v1/summary", "s3cret", ""); rr.Code != http.StatusOK || json.Unmarshal(rr.Body.Bytes(), &sum) != nil || sum.Lines != 7 {
+		t.Fatalf("summary: %d %s", rr.Code, rr.Body.String())
+	}
+
+	if rr := call(t, c, http.MethodPut, "/v1/interval", "s3cret", `{"interval":"soon"}`); rr.Code != http.StatusBadRequest {
+		t.Fatalf("bad interval: %d", rr.Code)
+	}
+	if rr := call(t, c, http.MethodPut, "/v1/interval", "s3cret", `{"interval":"90s"}`); rr.Code != http.StatusOK || c.Interval() != 90*time.Second {
+		t.Fatalf("interval: %d %v", rr.Code, c.Interval())
+	}
+
+	if rr := call(t, c, http.MethodPost, "/v1/reload", "s3cret", ""); rr.Code != http.StatusBadRequest {
+		t.Fatalf("failed reload: %d", rr.Code)
+	}
+	if _, ok := c.TakeSites(); ok {
+		t.Fatalf("failed reload must not replace the sites")
+	}
+	reloadErr = nil
+	if rr := call(t, c, http.MethodPost, "/v1/reload", "s3cret", ""); rr.Code != http.StatusOK || !c.Status().ReloadPending {
+		t.Fatalf("reload: %d", rr.Code)
+	}
+	if s, ok := c.TakeSites(); !ok || len(s) != 2 || c.Status().Sites != 2 {
+		t.Fatalf("take sites: %v %v", s, ok)
+	}
+	if _, ok := c.TakeSites(); ok {
+		t.Fatalf("reloaded sites are taken once")
+	}
+
+	if rr := call(t, c, http.MethodPost, "/v1/batch", "s3cret", ""); rr.Code != http.StatusAccepted || !c.Status().TriggerPending {
+		t.Fatalf("trigger: %d", rr.Code)
+	}
+}
+
+func TestRequestChecks(t *testing.T) {
+	c := New("", "", 1, 0, time.Hour)
+	if rr := call(t, c, http.MethodGet, "/v1/status", "", ""); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("no token configured must refuse requests: %d", rr.Code)
+	}
+	c.Token = "s3cret"
+	req := func(host, origin, contentType string) int {
+		r := httptest.NewRequest(http.MethodPost, "/v1/batch", nil)
+		r.Host = host
+		r.Header.Set("Authorization", "Bearer s3cret")
+		if origin != "" {
+			r.Header.Set("Origin", origin)
+		}
+		if contentType != "" {
+			r.Header.Set("Content-Type", contentType)
+		}
+		rr := httptest.NewRecorder()
+		c.ServeHTTP(rr, r)
+		return rr.Code
+	}
+	for _, tc := range []struct {
+		host, origin, contentType string
+		want                      int
+	}{
+		{"localhost:8098", "", "application/json", http.StatusAccepted},
+		{"[::1]:8098", "http://127.0.0.1:8098", "application/json; charset=utf-8", http.StatusAccepted},
+		{"rebind.example.com:8098", "", "application/json", http.StatusForbidden},
+		{"127.0.0.1:8098", "https://evil.example.com", "application/json", http.StatusForbidden},
+		{"127.0.0.1:8098", "null", "application/json", http.StatusForbidden},
+		{"127.0.0.1:8098", "", "", http.StatusUnsupportedMediaType},
+		{"127.0.0.1:8098", "", "text/plain", http.StatusUnsupportedMediaType},
+	} {
+		if got := req(tc.host, tc.origin, tc.contentType); got != tc.want {
+			t.Errorf("host %q origin %q type %q: %d, want %d", tc.host, tc.origin, tc.contentType, got, tc.want)
+		}
+	}
+}
+
+func TestLoadOrCreateToken(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "iqm", "control-token")
+	tok, err := LoadOrCreateToken(path)
+	if err != nil || len(tok) != 48 {
+		t.Fatalf("generated token %q: %v", tok, err)
+	}
+	if fi, err := os.Stat(path); err != nil || fi.Mode().Perm() != 0o600 {
+		t.Fatalf("token file: %v %v", fi, err)
+	}
+	if again, err := LoadOrCreateToken(path); err != nil || again != tok {
+		t.Fatalf("existing token not reused: %q %v", again, err)
+	}
+	if got, err := ReadTokenFile(path); err != nil || got != tok {
+		t.Fatalf("read token: %q %v", got, err)
+	}
+}
+
+func TestWait(t *testing.T) {
+	c := New("", "", 1, 0, time.Hour)
+	c.BatchStarted(1, "R1")
+	c.BatchDone(nil, true)
+
+	// a trigger made while measuring runs the next batch at once
+	c.Trigger()
+	if !c.Wait(nil) {
+		t.Fatalf("triggered wait must run a batch")
+	}
+
+	done := make(chan bool, 1)
+	go func() { done <- c.Wait(nil) }()
+	time.Sleep(20 * time.Millisecond)
+	if st := c.Status(); st.NextBatchUTC == "" || st.State != StateWaiting {
+		t.Fatalf("waiting status: %+v", st)
+	}
+	// shortening the interval makes the batch due now
+	c.SetInterval(time.Millisecond)
+	select {
+	case ok := <-done:
+		if !ok {
+			t.Fatalf("interval wait must run a batch")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("interval change did not wake Wait")
+	}
+
+	stop := make(chan struct{})
+	close(stop)
+	c.SetInterval(time.Hour)
+	if c.Wait(stop) {
+		t.Fatalf("stopped wait must not run a batch")
+	}
+
+	if _, _, err := c.ListenAndServe("0.0.0.0:0"); err == nil {
+		t.Fatalf("non-loopback listen without a token must fail")
+	}
+}
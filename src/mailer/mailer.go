@@ -0,0 +1,117 @@
+// Package mailer sends the monitor's period report (--report-email) by SMTP as a
+// multipart/alternative message: the plain-text (Markdown) and the HTML rendering of the
+// report built by the analysis package.
+package mailer
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// Config is where the report goes. Port 465 uses implicit TLS; any other port upgrades with
+// STARTTLS when the server offers it. Credentials are only sent over TLS (or to localhost), as
+// net/smtp enforces.
+type Config struct {
+	Addr     string // host:port
+	From     string
+	To       []string
+	Username string // empty: no authentication
+	Password string
+}
+
+// deliver is replaceable in tests.
+var deliver = deliverSMTP
+
+// Send mails subject with text and htmlBody as the alternatives of one message.
+func Send(cfg Config, subject, text, htmlBody string) error {
+	if cfg.Addr == "" || cfg.From == "" || len(cfg.To) == 0 {
+		return errors.New("report mail needs an SMTP server, a sender and at least one recipient")
+	}
+	msg, err := buildMessage(cfg, subject, text, htmlBody, time.Now())
+	if err != nil {
+		return err
+	}
+	return deliver(cfg, msg)
+}
+
+func buildMessage(cfg Config, subject, text, htmlBody string, now time.Time) ([]byte, error) {
+	rnd := make([]byte, 12)
+	if _, err := rand.Read(rnd); err != nil {
+		return nil, fmt.Errorf("mime boundary: %v", err)
+	}
+	boundary := "iqm-" + hex.EncodeToString(rnd)
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", cfg.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(cfg.To, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&b, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", boundary)
+	for _, part := range []struct{ typ, body string }{{"text/plain", text}, {"text/html", htmlBody}} {
+		fmt.Fprintf(&b, "--%s\r\nContent-Type: %s; charset=utf-8\r\nContent-Transfer-Encoding: quoted-printable\r\n\r\n", boundary, part.typ)
+		qp := quotedprintable.NewWriter(&b)
+		qp.Write([]byte(strings.ReplaceAll(part.body, "\n", "\r\n")))
+		qp.Close()
+		b.WriteString("\r\n")
+	}
+	fmt.Fprintf(&b, "--%s--\r\n", boundary)
+	return b.Bytes(), nil
+}
+
+func deliverSMTP(cfg Config, msg []byte) error {
+	host, port, err := net.SplitHostPort(cfg.Addr)
+	if err != nil {
+		return fmt.Errorf("smtp server %q: %v", cfg.Addr, err)
+	}
+	var auth smtp.Auth
+	if cfg.Username != "" {
+		auth = smtp.PlainAuth("", cfg.Username, cfg.Password, host)
+	}
+	if port != "465" {
+		return smtp.SendMail(cfg.Addr, auth, cfg.From, cfg.To, msg)
+	}
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: 30 * time.Second}, "tcp", cfg.Addr, &tls.Config{ServerName: host})
+	if err != nil {
+		return err
+	}
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+	if auth != nil {
+		if err := c.Auth(auth); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(cfg.From); err != nil {
+		return err
+	}
+	for _, to := range cfg.To {
+		if err := c.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
@@ -0,0 +1,26 @@
+package mailer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSend(t *testing.T) {
+	prev := deliver
+	defer func() { deliver = prev }()
+	var got []byte
+	deliver = func(cfg Config, msg []byte) error { got = msg; return nil }
+	if err := Send(Config{Addr: "mail.example:587"}, "s", "t", "h"); err == nil {
+		t.Fatalf("mail without sender and recipients accepted")
+	}
+	cfg := Config{Addr: "mail.example:587", From: "iqm@example.com", To: []string{"me@example.com", "boss@example.com"}}
+	if err := Send(cfg, "Internet quality 2025-03-03 – 2025-03-10", "# Report\nline", "<h1>Report</h1>"); err != nil {
+		t.Fatal(err)
+	}
+	msg := string(got)
+	for _, want := range []string{"To: me@example.com, boss@example.com\r\n", "Subject: =?utf-8?q?Internet_quality_2025-03-03_", "multipart/alternative", "Content-Type: text/plain; charset=utf-8", "# Report\r\nline", "Content-Type: text/html; charset=utf-8"} {
+		if !strings.Contains(msg, want) {
+			t.Fatalf("message lacks %q:\n%s", want, msg)
+		}
+	}
+}
@@ -17,25 +17,53 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"math/rand"
 	"net"
+	"net/http"
 	"net/url"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
+	"syscall"
 	"time"
 
 	"github.com/iafilius/InternetQualityMonitor/src/analysis"
+	"github.com/iafilius/InternetQualityMonitor/src/collector"
+	"github.com/iafilius/InternetQualityMonitor/src/config"
+	"github.com/iafilius/InternetQualityMonitor/src/control"
+	"github.com/iafilius/InternetQualityMonitor/src/mailer"
 	"github.com/iafilius/InternetQualityMonitor/src/monitor"
 	"github.com/iafilius/InternetQualityMonitor/src/types"
 )
 
+// reportMaxBatches bounds the batches read for --report: a month of 5-minute batches.
+const reportMaxBatches = 10000
+
+// Process exit codes, for orchestration tooling (also the run_done event of --progress-json).
+// A run stopped by SIGINT/SIGTERM after flushing its partial batch counts as completed.
+const (
+	exitOK          = 0 // completed
+	exitFailure     = 1 // could not run (no sites, unreadable input) or a check/report mode found a problem
+	exitConfigError = 2 // invalid flags or --config
+	exitErrorRate   = 3 // completed, but a batch's error rate exceeded --fail-error-rate
+)
+
+// exitRun emits the run_done progress event and exits with code.
+func exitRun(code int) {
+	monitor.EmitProgress(monitor.ProgressEvent{Event: monitor.ProgressRunDone, ExitCode: &code})
+	monitor.CloseProgressJSON()
+	os.Exit(code)
+}
+
 // repeatValue returns a slice containing v repeated n times (used to weight per-batch averages
 // back into a line-weighted overall average when constructing an overall aggregate across batches).
 func repeatValue(v float64, n int) []float64 {
@@ -81,9 +109,117 @@ func loadSites(path string) ([]types.Site, error) {
 	if err := json.Unmarshal(b, &sites); err != nil {
 		return nil, err
 	}
+	for _, s := range sites {
+		if err := monitor.ValidateSite(s); err != nil {
+			return nil, err
+		}
+	}
 	return sites, nil
 }
 
+// runCollector serves the agent push endpoint until SIGINT/SIGTERM.
+func runCollector(addr, dir, token string, combined bool) error {
+	srv, err := collector.New(dir, token)
+	if err != nil {
+		return err
+	}
+	defer srv.Close()
+	srv.Combined = combined
+	srv.Logf = func(format string, args ...any) { fmt.Printf(format+"\n", args...) }
+	hs := &http.Server{Addr: addr, Handler: srv, ReadHeaderTimeout: 10 * time.Second}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		hs.Shutdown(shutCtx)
+	}()
+	fmt.Printf("[collector] listening on %s, writing per-agent files to %s (combined=%v)\n", addr, dir, combined)
+	if err := hs.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	fmt.Println("[collector] stopped")
+	return nil
+}
+
+// applyConfigFile loads the --config file and sets every value whose flag was not given on the
+// command line. The selected profile is merged over the file's defaults.
+func applyConfigFile(path, profile string) error {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		if profile != "" {
+			return fmt.Errorf("--profile %q requires --config <file>", profile)
+		}
+		return nil
+	}
+	explicit := map[string]bool{}
+	flag.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+	cfg, err := config.Load(path, profile)
+	if err != nil {
+		return err
+	}
+	applied, err := cfg.Apply(flag.CommandLine, explicit)
+	if err != nil {
+		return err
+	}
+	name := cfg.Profile
+	if name == "" {
+		name = "(none)"
+	}
+	overridden := len(cfg.Settings) - len(applied)
+	fmt.Printf("[config] %s profile=%s: %d setting(s) applied, %d overridden by command-line flags\n", path, name, len(applied), overridden)
+	return nil
+}
+
+// applySamplingProfileDefaults selects the sampling profile and, for lite, sets the flags in
+// monitor.LiteFlagDefaults that neither the command line nor the config file set. It runs after
+// applyConfigFile so flag.Visit sees both sources.
+func applySamplingProfileDefaults(profile string) error {
+	if err := monitor.SetSamplingProfile(profile); err != nil {
+		return err
+	}
+	if monitor.SamplingProfile() != monitor.SamplingProfileLite {
+		return nil
+	}
+	set := map[string]bool{}
+	flag.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	for name, v := range monitor.LiteFlagDefaults {
+		if set[name] {
+			continue
+		}
+		if err := flag.Set(name, v); err != nil {
+			return fmt.Errorf("lite default %s=%s: %w", name, v, err)
+		}
+	}
+	return nil
+}
+
+// validateFlagValues rejects values that parse but make no sense, whether they came from the
+// command line or the config file.
+func validateFlagValues(logLevel string, durations map[string]time.Duration) error {
+	var errs []string
+	switch strings.ToLower(strings.TrimSpace(logLevel)) {
+	case "debug", "info", "warn", "warning", "error":
+	default:
+		errs = append(errs, fmt.Sprintf("log-level must be one of debug|info|warn|error (got %q)", logLevel))
+	}
+	names := make([]string, 0, len(durations))
+	for k := range durations {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		if durations[k] < 0 {
+			errs = append(errs, fmt.Sprintf("%s must not be negative (got %s)", k, durations[k]))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid settings:\n  %s", strings.Join(errs, "\n  "))
+	}
+	return nil
+}
+
 func main() {
 	// Normalize boolean flags of the form `--flag true|false` to `--flag=true|false`
 	// to avoid Go's flag parsing stopping at the first non-flag argument.
@@ -116,22 +252,42 @@ func main() {
 	}
 
 	sitesPath := flag.String("sites", "./sites.jsonc", "Path to sites JSONC file")
-	iterations := flag.Int("iterations", 1, "Number of passes over the sites list")
+	iterations := flag.Int("iterations", 1, "Number of passes over the sites list (0 = keep running until stopped)")
+	batchInterval := flag.Duration("batch-interval", 0, "Time from one batch start to the next (0 = back to back); e.g. --iterations 0 --batch-interval 15m runs as a daemon")
+	preBatchHook := flag.String("pre-batch-hook", "", "Shell command run before each batch (e.g. bring a VPN up); exit status, duration and output tail go into meta.hooks. Gets IQM_HOOK_PHASE, IQM_RUN_TAG, IQM_ITERATION, IQM_OUT_FILE")
+	postBatchHook := flag.String("post-batch-hook", "", "Shell command run after each batch, also when it was skipped or canceled (IQM_BATCH_CANCELED=1); recorded in the next batch's meta.hooks.post_prev")
+	hookTimeout := flag.Duration("hook-timeout", time.Minute, "Maximum run time of --pre-batch-hook/--post-batch-hook before the command is killed")
+	healthCheck := flag.String("health-check", monitor.HealthCheckOff, "HEAD each target before a batch: off, report (record hard-down targets in meta.health_check) or exclude (also leave targets with NXDOMAIN or connection refused out of the batch as excluded_unhealthy)")
+	speedSeries := flag.Bool("speed-series", false, "Persist each transfer's per-second throughput in speed_series (for drilling into single bad transfers); long transfers use coarser intervals to stay within --speed-series-max points")
+	speedSeriesMax := flag.Int("speed-series-max", monitor.DefaultSpeedSeriesMax, "Maximum number of speed_series points per line (--speed-series)")
+	healthCheckTimeout := flag.Duration("health-check-timeout", 5*time.Second, "Per-target timeout of the --health-check HEAD request")
+	protocolExperiment := flag.String("protocol-experiment", "", "Fetch every https target once per listed HTTP version in each batch, e.g. 1.1,2 (lines carry forced_http_version); empty = use whatever ALPN negotiates")
+	controlListen := flag.String("control-listen", "", "Serve the local control API on this address (e.g. 127.0.0.1:8098): status, last summary, run a batch now, change the interval, reload sites")
+	controlToken := flag.String("control-token", "", "Bearer token for --control-listen; required for a non-loopback address (default: $IQM_CONTROL_TOKEN, else the --control-token-file token)")
+	controlTokenFile := flag.String("control-token-file", "", "File holding the control token, generated on first use when --control-token and $IQM_CONTROL_TOKEN are unset (default: control-token in the user config directory, e.g. ~/.config/iqm)")
 	parallel := flag.Int("parallel", 1, "Maximum concurrent site monitors")
 	outFile := flag.String("out", monitor.DefaultResultsFile, "Output JSONL file for collection results (ignored in analyze-only; use --input)")
-	logLevel := flag.String("log-level", "info", "Log level (debug|info|warn|error)")
+	logLevel := flag.String("log-level", monitor.LogEnvDefault("IQM_LOG_LEVEL", "info"), "Log level (debug|info|warn|error; default: $IQM_LOG_LEVEL or info)")
+	logFormat := flag.String("log-format", monitor.LogEnvDefault("IQM_LOG_FORMAT", "text"), "Log output: text ([LEVEL] lines) or json (one JSON object per line; default: $IQM_LOG_FORMAT or text)")
 	httpTimeout := flag.Duration("http-timeout", 120*time.Second, "Per-request total timeout (including body transfer)")
 	stallTimeout := flag.Duration("stall-timeout", 20*time.Second, "Abort transfer if no progress for this long")
+	microStallGap := flag.Duration("micro-stall-gap", analysis.DefaultMicroStallGapMs*time.Millisecond, "Analysis: a pause in a transfer of at least this long counts as a transient (micro) stall; recorded in meta.config")
+	lowSpeedThreshold := flag.Float64("low-speed-threshold-kbps", analysis.DefaultLowSpeedThresholdKbps, "Analysis: transfer time below this speed counts toward the Low-Speed Time Share; recorded in meta.config")
 	siteTimeout := flag.Duration("site-timeout", 120*time.Second, "Optional overall timeout per site (DNS + all IP probes). 0 disables.")
 	dnsTimeout := flag.Duration("dns-timeout", 5*time.Second, "Default DNS timeout when no site-timeout is set; also used as upper bound for fanout DNS")
+	ifaceFlag := flag.String("interface", "", "Bind all measurement connections to this network interface (e.g. en0); a comma-separated list runs each batch once per interface, one after the other, with the interface in the run tag and meta.bind_interface")
+	sourceIPFlag := flag.String("source-ip", "", "Bind all measurement connections to this local address; a comma-separated list (also combined with --interface) runs one batch per address")
 	maxIPsPerSite := flag.Int("max-ips-per-site", 0, "If >0 limit number of IPs probed per site (e.g. 2 for first v4+v6). 0 = all")
 	situation := flag.String("situation", "Unknown", "Label describing current network/context situation (e.g. Office, Home, VPN, Travel). Added to meta for later comparative analysis")
+	tags := flag.String("tags", "", "Comma-separated key=value tags stored in every line's meta.tags (e.g. router_fw=1.2.3,isp=Acme) to compare runs by hardware, firmware or provider")
 	speedDropAlert := flag.Float64("speed-drop-alert", 30, "Speed drop alert threshold percent")
 	ttfbIncreaseAlert := flag.Float64("ttfb-increase-alert", 50, "TTFB increase alert threshold percent")
 	errorRateAlert := flag.Float64("error-rate-alert", 20, "Error rate alert threshold percent")
 	jitterAlert := flag.Float64("jitter-alert", 25, "Jitter alert threshold percent")
 	p99p50RatioAlert := flag.Float64("p99p50-ratio-alert", 2.0, "p99/p50 ratio alert threshold")
 	progressInterval := flag.Duration("progress-interval", 5*time.Second, "Interval for progress logging of worker pool (0 disables)")
+	progressJSON := flag.String("progress-json", "", "Write machine-readable progress events (run_start, batch_start, result, batch_done, run_done) as JSON lines to this file, or - for stderr. Empty disables")
+	failErrorRate := flag.Float64("fail-error-rate", 0, "Exit with code 3 after the run when a batch's error rate exceeded this percent (0 disables)")
 	progressSites := flag.Bool("progress-sites", true, "Include currently active site names in progress log (may increase verbosity)")
 	progressResolveIP := flag.Bool("progress-resolve-ip", true, "Resolve and append first IP(s) for active sites in progress output")
 	ipFanout := flag.Bool("ip-fanout", true, "If true, pre-resolve all site IPs and randomize site/IP tasks to spread load")
@@ -139,7 +295,27 @@ func main() {
 	preTTFBStall := flag.Bool("pre-ttfb-stall", false, "Cancel primary GET if no first byte within stall-timeout; marks http_error=stall_pre_ttfb")
 	analyzeOnly := flag.Bool("analyze-only", false, "If true, analyze existing results and exit (no new collection)")
 	inputFile := flag.String("input", monitor.DefaultResultsFile, "Input JSONL file to analyze when --analyze-only is set")
+	fsck := flag.Bool("fsck", false, "Check the --input JSONL file for corrupt/truncated lines, schema-version mismatches and duplicate run_tags, then exit (non-zero when problems are found)")
+	fsckRepair := flag.String("fsck-repair", "", "With --fsck: write a repaired copy (corrupt, truncated and duplicate lines dropped) to this path; the input is never modified")
+	anonymizeOut := flag.String("anonymize", "", "Write a copy of the --input file with URLs, host names, IPs, SSIDs and proxy/VPN names replaced by stable pseudonyms (metrics unchanged) to this path, then exit")
+	anonymizeSalt := flag.String("anonymize-salt", "", "Secret keying the --anonymize pseudonyms; reuse it to keep them consistent across exports (default: random per run)")
+	pruneKeep := flag.String("prune-keep", "", "Rewrite the --input file keeping only batches from this retention period (e.g. 90d, 12w, 36h), then exit; removed lines are backed up to a gzip file first")
+	pruneDryRun := flag.Bool("prune-dry-run", false, "With --prune-keep: only report what would be removed; nothing is written")
+	pruneBackup := flag.String("prune-backup", "", "With --prune-keep: backup path for the removed lines (default: <input>.pruned-<UTC time>.jsonl.gz)")
+	reportOut := flag.String("report", "", "Write a summary of the last --report-days of the --input file (uptime, speed/TTFB per situation, SLA attainment, worst batches, notable events) to this path, then exit: .html/.htm for HTML, else Markdown; - for stdout")
+	reportDays := flag.Int("report-days", 7, "Period of --report/--report-email in days, ending now")
+	reportEmail := flag.String("report-email", "", "Comma-separated recipients: mail the --report-days summary through --smtp-server, then exit (with --report also write it)")
+	reportSLASpeed := flag.Float64("report-sla-speed-kbps", 10000, "SLA target of the report: batch P50 speed at or above this (0 disables)")
+	reportSLATTFB := flag.Float64("report-sla-ttfb-ms", 200, "SLA target of the report: batch P95 TTFB at or below this (0 disables)")
+	smtpServer := flag.String("smtp-server", "", "SMTP server host:port for --report-email (465 = implicit TLS, else STARTTLS when offered)")
+	smtpFrom := flag.String("smtp-from", "", "Sender address of --report-email")
+	smtpUser := flag.String("smtp-user", "", "SMTP user name (empty: no authentication)")
+	smtpPassword := flag.String("smtp-password", "", "SMTP password (empty: $IQM_SMTP_PASSWORD; in a --config file use ${VAR})")
+	bisectMetric := flag.String("bisect", "", "Find the batch of the --input file where a sustained regression of this metric began and list what changed with it (protocol mix, proxy rate, next hop, ...), then exit (non-zero when one is found); metrics: "+strings.Join(analysis.RegressionMetrics(), ", "))
+	bisectThreshold := flag.Float64("bisect-threshold", 20, "Smallest degradation --bisect reports: percent for levels, percentage points for error_rate, stall_rate and quality_score")
+	validate := flag.Bool("validate", false, "Check the sites file, name resolution, proxy settings, output path, push endpoints and required tools/permissions, print a readiness report and exit without measuring (non-zero when a check fails)")
 	analysisBatches := flag.Int("analysis-batches", 10, "Max number of recent batches to analyze when --analyze-only is set")
+	qualityScoreSpec := flag.String("quality-score", "", "Quality score weights and references as key=value list, e.g. speed=40,ttfb=20,speed_ref_kbps=100000 (keys: speed, ttfb, jitter, stall, errors, speed_ref_kbps, ttfb_ref_ms, jitter_penalty, stall_penalty, error_penalty)")
 	finalAnalysisBatches := flag.Int("final-analysis-batches", 0, "If >0 in collection mode, after all iterations perform a final full analysis over last N batches")
 	// Self-test flags (default-on)
 	selfTest := flag.Bool("selftest-speed", true, "Run a quick local throughput self-test on startup (loopback)")
@@ -149,10 +325,108 @@ func main() {
 	calibTargetsCSV := flag.String("calibrate-targets", "", "Comma-separated speed targets in kbps (empty = auto: 10,30,100,300,1000,… up to local max; 0 means skip a value). Max is always measured.")
 	calibDur := flag.Duration("calibrate-duration", 500*time.Millisecond, "Duration per calibration target")
 	calibTolPct := flag.Int("calibrate-tolerance", 10, "Calibration tolerance percent for target checks (info only)")
+	// Optional OpenTelemetry export: one trace per measurement with DNS/connect/TLS/TTFB/transfer child spans
+	otlpEndpoint := flag.String("otlp-endpoint", "", "OTLP/HTTP collector base URL for trace export (e.g. http://localhost:4318). Empty disables")
+	otlpService := flag.String("otlp-service-name", monitor.DefaultOTLPServiceName, "service.name resource attribute for exported traces")
+	// Optional InfluxDB line protocol sink: batch summaries (and per-line points) to a file and/or a write endpoint
+	influxFile := flag.String("influx-file", "", "Append InfluxDB line protocol points (one per batch and target group) to this file. Empty disables")
+	influxURL := flag.String("influx-url", "", "InfluxDB/VictoriaMetrics write URL for the same points, e.g. http://localhost:8086/api/v2/write?org=home&bucket=iqm, http://influx:8086/write?db=iqm or http://vm:8428/write. Empty disables")
+	influxToken := flag.String("influx-token", "", "Token for --influx-url, sent as \"Authorization: Token ...\" (default: $IQM_INFLUX_TOKEN)")
+	influxLines := flag.Bool("influx-lines", false, "Also write one point per result line (measurement <influx-measurement>_line) to --influx-file/--influx-url")
+	influxMeasurement := flag.String("influx-measurement", monitor.DefaultInfluxMeasurement, "Measurement name prefix: <prefix>_batch and <prefix>_line")
+	influxExport := flag.String("influx-export", "", "Write the batches of the --input file as InfluxDB line protocol to this file (- for stdout), then exit")
+	// Proxy for HTTP sites: explicit URL or PAC file, with a direct-connect bypass list
+	proxyFlag := flag.String("proxy", "", "Explicit proxy for HTTP sites instead of HTTP(S)_PROXY: http://, https://, socks5:// or socks5h:// URL, optionally with user:password@")
+	proxyPAC := flag.String("proxy-pac", "", "PAC file (path or http(s) URL) deciding the proxy per target; takes precedence over --proxy. Supports only a JavaScript subset: functions, var, if/else, return, ! && || == != === !== +, toLowerCase/toUpperCase/indexOf and the standard PAC helpers except dateRange (no < > -, arrays, ternary, switch, loops or regex); anything else fails at startup with its line")
+	proxyBypass := flag.String("proxy-bypass", "", "Comma-separated targets that always connect direct: host, .domain, *.pattern, CIDR or *")
+	// Dual-stack happy-eyeballs race per site (IPv6 first, IPv4 after the fallback delay)
+	happyEyeballs := flag.Bool("happy-eyeballs", true, "Race IPv6 vs IPv4 connects once per dual-stack site per batch and record the winner/loser timings")
+	happyEyeballsDelay := flag.Duration("happy-eyeballs-delay", 300*time.Millisecond, "IPv4 fallback delay used in the happy-eyeballs race")
+	// QUIC/UDP reachability probe per IP (separate from the TCP measurement)
+	quicProbe := flag.Bool("quic-probe", false, "Send a QUIC version-negotiation probe to UDP/443 of each https target IP and record whether UDP is blocked")
+	quicProbeTimeout := flag.Duration("quic-probe-timeout", time.Second, "Wait per QUIC probe attempt (2 attempts) before counting UDP as blocked")
+	reuseExperiment := flag.Bool("reuse-experiment", false, "Per target IP, time one small request on a fresh connection and one on the warm connection to measure pure setup cost")
+	// IPv4 vs IPv6 route comparison for dual-stack sites (shells out to traceroute/tracert)
+	routeTrace := flag.Bool("route-trace", false, "Traceroute the first IPv4 and IPv6 address of each dual-stack site once per batch to compare the per-family paths and ASNs")
+	routeTraceMaxHops := flag.Int("route-trace-max-hops", 20, "Maximum TTL for --route-trace")
+	// Return-path hop count from the TTL of an echo reply (shells out to ping)
+	responseTTL := flag.Bool("response-ttl", false, "Ping each target IP once per line and record the reply TTL and estimated return hop count (compared with --route-trace's forward path to spot asymmetric routing)")
+	// Kernel TCP counters (TCP_INFO) of the measured connections, read when they close
+	tcpStats := flag.Bool("tcp-stats", true, "Record retransmissions, out-of-order segments and RTT variation of each line's TCP connections in tcp_stats (Linux and macOS)")
+	// Accept-Encoding of the measured GET; the body is decoded by the monitor so its compression ratio is recorded
+	acceptEncoding := flag.String("accept-encoding", "gzip, deflate", "Accept-Encoding offered on the measured GET; the encoded and decoded body sizes are recorded per line (\"identity\" asks for uncompressed bodies, \"\" leaves decoding to Go's HTTP client and records nothing)")
+	pingCount := flag.Int("ping-count", 5, "TCP connects per address for sites with \"probe\": \"ping\"")
+	pingInterval := flag.Duration("ping-interval", 200*time.Millisecond, "Pause between the connects of the ping probe")
+	soakDuration := flag.Duration("soak-duration", 10*time.Minute, "How long sites with \"probe\": \"soak\" keep one download streaming (independent of --site-timeout)")
+	soakInterval := flag.Duration("soak-interval", time.Second, "Speed sampling interval of the soak probe")
+	iperf3Duration := flag.Duration("iperf3-duration", 10*time.Second, "Length of each iperf3 test of sites with \"probe\": \"iperf3\" (runs the system iperf3 client, independent of --site-timeout)")
+	iperf3Reverse := flag.Bool("iperf3-reverse", true, "Let the iperf3 server send (download direction, like the HTTP transfers); false measures the upload")
+	iperf3Parallel := flag.Int("iperf3-parallel", 1, "Parallel TCP streams of the iperf3 test")
+	iperf3UDPBitrate := flag.String("iperf3-udp-bitrate", "", "Also run a UDP iperf3 test at this offered rate (iperf3 notation, e.g. 50M) and record its received rate, jitter and loss")
+	dnsFamilyTiming := flag.Bool("dns-family-timing", true, "Also time the A (IPv4) and AAAA (IPv6) lookups of each site separately, in parallel with the normal lookup")
+	dnsCacheCheck := flag.Bool("dns-cache-check", false, "Record each site lookup's answer TTL (one direct query to the resolver per TTL, up to 2s before the site is measured) and whether later lookups within it were cache hits (dns_cache)")
+	dnsCacheHitMs := flag.Int64("dns-cache-hit-ms", 20, "With --dns-cache-check: slowest lookup within the TTL still counted as a cache hit; raise it for a resolver that is far away")
+	dnsHijackCheck := flag.Bool("dns-hijack-check", true, "Resolve a few random names that cannot exist once per batch and record meta.dns_hijack when the resolver answers instead of NXDOMAIN (ISP NXDOMAIN rewriting)")
+	// VPN detection: extra resolver search domains that mean "on VPN" (interfaces/default route are always checked)
+	vpnDNSSuffixes := flag.String("vpn-dns-suffixes", "", "Comma-separated resolver search domains that indicate an active VPN (e.g. corp.example.com); built-in: ts.net, tailscale.net, zerotier.net")
+	// Response header capture for the viewer's header history (CDN/provider changes)
+	captureHeaders := flag.String("capture-headers", "", "Comma-separated response headers to store per line in response_headers (e.g. Server,Via,X-Cache,CF-Ray,Age); \"default\" selects exactly those; empty disables")
+	// Metered/captive networks: detect once per batch and optionally cap (reduce) or skip the batch
+	meteredPolicy := flag.String("metered-policy", monitor.MeteredPolicyRecord, "On metered or captive-portal networks: off (no detection), record (meta.metered only), reduce (cap each transfer at --metered-max-bytes; skip captive batches) or skip (no measurements)")
+	meteredSSIDs := flag.String("metered-ssids", "", "Comma-separated Wi-Fi SSIDs (globs allowed, e.g. Hotel*,MyPhone) to treat as metered in addition to OS and hotspot detection")
+	meteredMaxBytes := flag.Int64("metered-max-bytes", monitor.DefaultMeteredMaxBytes, "Per-transfer byte cap in --metered-policy=reduce mode")
+	captiveCheckURL := flag.String("captive-check-url", monitor.DefaultCaptiveCheckURL, "URL expected to answer 204 unless a captive portal intercepts it (reduce/skip policies only; empty disables)")
+	// Low-overhead sampling for small devices: fewer targets, smaller transfers, no per-sample retention
+	samplingProfile := flag.String("sampling-profile", monitor.SamplingProfileFull, "full (default) or lite: measure only the first --lite-max-sites sites, cap each transfer at --lite-max-bytes, drop per-sample speed data and default to one IP per site, parallel 1, batch-interval 30m and no speed self-test")
+	liteMaxSites := flag.Int("lite-max-sites", monitor.DefaultLiteMaxSites, "Sites measured per batch with --sampling-profile=lite")
+	liteMaxBytes := flag.Int64("lite-max-bytes", monitor.DefaultLiteMaxBytes, "Per-transfer byte cap with --sampling-profile=lite")
+	// Data usage: wire bytes are always counted (meta.data_usage); a monthly budget throttles or skips batches near the cap
+	monthlyBudget := flag.String("monthly-budget", "", "Byte budget per billing cycle (e.g. 20GB, 500MB, 1.5GiB); empty disables the guard. Totals persist in <out>.usage.json")
+	budgetNearPct := flag.Float64("budget-near-pct", 10, "Apply --budget-policy once less than this percent of --monthly-budget is left (past the budget batches are skipped)")
+	budgetPolicy := flag.String("budget-policy", monitor.BudgetPolicyReduce, "Near the monthly budget: reduce (cap transfers at --metered-max-bytes) or skip batches")
+	budgetCycleDay := flag.Int("budget-cycle-day", 1, "Day of month (1-28) the billing cycle of --monthly-budget starts")
+	// Remote agent mode: also push result lines to a central collector (the local file is still written)
+	agentPush := flag.String("agent-push", "", "Collector base URL to push result lines to (e.g. http://collector:8099; /v1/results is appended). Empty disables")
+	agentToken := flag.String("agent-token", "", "Bearer token for --agent-push and --collector-listen (default: $IQM_AGENT_TOKEN)")
+	agentName := flag.String("agent-name", "", "Agent name stored as meta.agent and used for the collector's per-agent file (default: hostname when pushing)")
+	agentBatchSize := flag.Int("agent-batch-size", 200, "Result lines per push request")
+	agentFlushInterval := flag.Duration("agent-flush-interval", 10*time.Second, "Push a partial batch after this long")
+	// Central collector mode: receive pushes from agents instead of measuring
+	collectorListen := flag.String("collector-listen", "", "Run as the central collector on this address (e.g. :8099) and write pushed lines to --collector-dir; no measurements are taken")
+	collectorDir := flag.String("collector-dir", "./agents", "Directory for the collector's per-agent result files (<agent>.jsonl)")
+	collectorCombined := flag.Bool("collector-combined", true, "Collector also appends every agent's lines to <collector-dir>/all_agents.jsonl for cross-agent viewing")
+	// YAML config file with named profiles; command-line flags override its values
+	configPath := flag.String("config", "", "YAML config file whose keys are flag names (defaults + named profiles); flags on the command line override it")
+	profile := flag.String("profile", "", "Profile from --config to apply on top of its defaults (e.g. home, office-vpn, hotspot)")
 	flag.Parse()
 
+	if err := applyConfigFile(*configPath, *profile); err != nil {
+		fmt.Printf("[config] %v\n", err)
+		exitRun(exitConfigError)
+	}
+	if err := monitor.SetProgressJSON(*progressJSON); err != nil {
+		fmt.Printf("[init] --progress-json: %v\n", err)
+		exitRun(exitConfigError)
+	}
+	if err := applySamplingProfileDefaults(*samplingProfile); err != nil {
+		fmt.Printf("[init] --sampling-profile: %v\n", err)
+		exitRun(exitConfigError)
+	}
+	if err := validateFlagValues(*logLevel, map[string]time.Duration{
+		"http-timeout": *httpTimeout, "stall-timeout": *stallTimeout, "site-timeout": *siteTimeout, "dns-timeout": *dnsTimeout,
+		"progress-interval": *progressInterval, "happy-eyeballs-delay": *happyEyeballsDelay,
+		"quic-probe-timeout": *quicProbeTimeout,
+	}); err != nil {
+		fmt.Printf("[config] %v\n", err)
+		exitRun(exitConfigError)
+	}
+	if err := monitor.SetLogFormat(*logFormat); err != nil {
+		fmt.Printf("[config] %v\n", err)
+		exitRun(exitConfigError)
+	}
+
 	var selfTestKbps float64
-	if *selfTest {
+	if *selfTest && !*fsck && !*validate && *anonymizeOut == "" && *pruneKeep == "" && *reportOut == "" && *reportEmail == "" && *bisectMetric == "" && *influxExport == "" && *collectorListen == "" {
 		if kbps, err := monitor.LocalMaxSpeedProbe(*selfTestDur); err == nil {
 			selfTestKbps = kbps
 			fmt.Printf("[selftest] local throughput: %.1f Mbps (%.0f kbps)\n", kbps/1000.0, kbps)
@@ -163,7 +437,7 @@ func main() {
 	}
 
 	// Only run calibration for collection sessions (embed into emitted metadata)
-	if *calib && !*analyzeOnly {
+	if *calib && !*analyzeOnly && !*fsck && !*validate && *anonymizeOut == "" && *pruneKeep == "" && *reportOut == "" && *reportEmail == "" && *bisectMetric == "" && *influxExport == "" && *collectorListen == "" {
 		// build targets: if CSV provided use it; otherwise auto-generate 10,30 per decade up to local max
 		var targets []float64
 		if strings.TrimSpace(*calibTargetsCSV) != "" {
@@ -265,12 +539,303 @@ func main() {
 	monitor.SetLogLevel(*logLevel)
 	monitor.SetHTTPTimeout(*httpTimeout)
 	monitor.SetStallTimeout(*stallTimeout)
+	if *microStallGap < time.Millisecond || *lowSpeedThreshold <= 0 {
+		fmt.Println("[init] --micro-stall-gap must be at least 1ms and --low-speed-threshold-kbps above 0")
+		exitRun(exitConfigError)
+	}
+	analysis.SetDefaultStallThresholds(microStallGap.Milliseconds(), *lowSpeedThreshold)
 	monitor.SetSiteTimeout(*siteTimeout)
 	monitor.SetDNSTimeout(*dnsTimeout)
 	monitor.SetMaxIPsPerSite(*maxIPsPerSite)
 	monitor.SetSituation(*situation)
+	if tagMap, err := monitor.ParseTags(*tags); err != nil {
+		fmt.Printf("[init] --tags: %v\n", err)
+		exitRun(exitConfigError)
+	} else {
+		monitor.SetTags(tagMap)
+	}
+	if *vpnDNSSuffixes != "" {
+		monitor.SetVPNDNSSuffixes(strings.Split(*vpnDNSSuffixes, ","))
+	}
+	if *captureHeaders != "" {
+		monitor.SetCaptureHeaders(strings.Split(*captureHeaders, ","))
+	}
+	if *qualityScoreSpec != "" {
+		qc, err := analysis.ParseQualityScoreConfig(*qualityScoreSpec)
+		if err != nil {
+			fmt.Printf("[init] --quality-score: %v\n", err)
+			exitRun(exitConfigError)
+		}
+		analysis.SetQualityScoreConfig(qc)
+	}
+	if err := monitor.SetMeteredPolicy(*meteredPolicy); err != nil {
+		fmt.Printf("[init] --metered-policy: %v\n", err)
+		exitRun(exitConfigError)
+	}
+	if *meteredSSIDs != "" {
+		monitor.SetMeteredSSIDs(strings.Split(*meteredSSIDs, ","))
+	}
+	var bypassRules []string
+	if *proxyBypass != "" {
+		bypassRules = strings.Split(*proxyBypass, ",")
+	}
+	if err := monitor.SetProxyConfig(*proxyFlag, *proxyPAC, bypassRules); err != nil {
+		fmt.Printf("[init] %v\n", err)
+		exitRun(exitConfigError)
+	}
+	monitor.SetMeteredMaxBytes(*meteredMaxBytes)
+	monitor.SetLiteLimits(*liteMaxSites, *liteMaxBytes)
+	monitor.SetCaptiveCheckURL(*captiveCheckURL)
+	budgetBytes, err := monitor.ParseByteSize(*monthlyBudget)
+	if err == nil {
+		err = monitor.SetMonthlyBudget(budgetBytes, *budgetNearPct, *budgetPolicy)
+	}
+	if err != nil {
+		fmt.Printf("[init] --monthly-budget: %v\n", err)
+		exitRun(exitConfigError)
+	}
+	monitor.SetBudgetCycleDay(*budgetCycleDay)
 	// Pre‑TTFB stall watchdog toggle
 	monitor.SetPreTTFBStall(*preTTFBStall)
+	monitor.SetOTLPServiceName(*otlpService)
+	monitor.SetOTLPEndpoint(*otlpEndpoint)
+	monitor.SetHappyEyeballs(*happyEyeballs)
+	monitor.SetHappyEyeballsDelay(*happyEyeballsDelay)
+	monitor.SetQUICProbe(*quicProbe)
+	monitor.SetQUICProbeTimeout(*quicProbeTimeout)
+	monitor.SetReuseExperiment(*reuseExperiment)
+	monitor.SetDNSFamilyTiming(*dnsFamilyTiming)
+	monitor.SetDNSHijackCheck(*dnsHijackCheck)
+	monitor.SetDNSCacheCheck(*dnsCacheCheck, *dnsCacheHitMs)
+	monitor.SetBatchHooks(*preBatchHook, *postBatchHook, *hookTimeout)
+	monitor.SetSpeedSeries(*speedSeries, *speedSeriesMax)
+	if err := monitor.SetHealthCheck(*healthCheck, *healthCheckTimeout); err != nil {
+		fmt.Printf("[init] --health-check: %v\n", err)
+		exitRun(exitConfigError)
+	}
+	protoVersions, err := monitor.ParseProtocolExperiment(*protocolExperiment)
+	if err != nil {
+		fmt.Printf("[init] --protocol-experiment: %v\n", err)
+		exitRun(exitConfigError)
+	}
+	monitor.SetRouteTrace(*routeTrace)
+	monitor.SetRouteTraceMaxHops(*routeTraceMaxHops)
+	monitor.SetResponseTTL(*responseTTL)
+	monitor.SetTCPStats(*tcpStats)
+	monitor.SetAcceptEncoding(*acceptEncoding)
+	monitor.SetPingCount(*pingCount)
+	monitor.SetPingInterval(*pingInterval)
+	monitor.SetSoakDuration(*soakDuration)
+	monitor.SetSoakInterval(*soakInterval)
+	monitor.SetIPerf3Duration(*iperf3Duration)
+	monitor.SetIPerf3Reverse(*iperf3Reverse)
+	monitor.SetIPerf3Parallel(*iperf3Parallel)
+	monitor.SetIPerf3UDPBitrate(*iperf3UDPBitrate)
+	if strings.TrimSpace(*agentToken) == "" {
+		*agentToken = os.Getenv("IQM_AGENT_TOKEN")
+	}
+	if strings.TrimSpace(*influxToken) == "" {
+		*influxToken = os.Getenv("IQM_INFLUX_TOKEN")
+	}
+	monitor.SetInfluxMeasurement(*influxMeasurement)
+	if !*analyzeOnly {
+		monitor.SetInfluxSink(*influxFile, *influxURL, *influxToken, *influxLines)
+	}
+	monitor.SetAgentName(*agentName)
+	monitor.SetAgentBatch(*agentBatchSize, *agentFlushInterval)
+	if *agentPush != "" && !*analyzeOnly {
+		if *agentToken == "" {
+			fmt.Println("[agent] --agent-push requires --agent-token (or IQM_AGENT_TOKEN)")
+			exitRun(exitConfigError)
+		}
+		monitor.SetAgentPush(*agentPush, *agentToken)
+	}
+
+	// COLLECTOR MODE: receive result lines from remote agents
+	if *collectorListen != "" {
+		if err := runCollector(*collectorListen, *collectorDir, *agentToken, *collectorCombined); err != nil {
+			fmt.Printf("[collector] %v\n", err)
+			exitRun(exitFailure)
+		}
+		return
+	}
+
+	// FSCK MODE: integrity check (and optional repair) of an existing results file
+	if *fsck {
+		inPath := strings.TrimSpace(*inputFile)
+		var rep *analysis.FsckReport
+		var err error
+		if *fsckRepair != "" {
+			rep, err = analysis.RepairResultsFile(inPath, *fsckRepair, monitor.SchemaVersion)
+		} else {
+			rep, err = analysis.CheckResultsFile(inPath, monitor.SchemaVersion)
+		}
+		if err != nil {
+			fmt.Printf("[fsck] %v\n", err)
+			exitRun(exitConfigError)
+		}
+		fmt.Print(rep.String())
+		if !rep.OK() {
+			exitRun(exitFailure)
+		}
+		fmt.Println("[fsck] ok")
+		return
+	}
+
+	// ANONYMIZE MODE: shareable copy of an existing results file
+	if *anonymizeOut != "" {
+		rep, err := analysis.AnonymizeResultsFile(strings.TrimSpace(*inputFile), *anonymizeOut, *anonymizeSalt)
+		if err != nil {
+			fmt.Printf("[anonymize] %v\n", err)
+			exitRun(exitConfigError)
+		}
+		fmt.Print(rep.String())
+		return
+	}
+
+	// PRUNE MODE: retention applied to an existing results file
+	if *pruneKeep != "" {
+		keep, err := analysis.ParseRetention(*pruneKeep)
+		if err != nil {
+			fmt.Printf("[prune] %v\n", err)
+			exitRun(exitConfigError)
+		}
+		rep, err := analysis.PruneResultsFile(strings.TrimSpace(*inputFile), time.Now().Add(-keep), analysis.PruneOptions{DryRun: *pruneDryRun, BackupPath: *pruneBackup})
+		if err != nil {
+			fmt.Printf("[prune] %v\n", err)
+			exitRun(exitConfigError)
+		}
+		fmt.Print(rep.String())
+		return
+	}
+
+	// REPORT MODE: period summary written to a file and/or mailed
+	if *reportOut != "" || *reportEmail != "" {
+		days := *reportDays
+		if days < 1 {
+			days = 1
+		}
+		// every situation, each with its own row, unless --situation picks one
+		sitFilter := ""
+		flag.Visit(func(f *flag.Flag) {
+			if f.Name == "situation" {
+				sitFilter = *situation
+			}
+		})
+		summaries, err := analysis.AnalyzeRecentResultsFull(strings.TrimSpace(*inputFile), monitor.SchemaVersion, reportMaxBatches, sitFilter)
+		if err != nil {
+			fmt.Printf("[report] %v\n", err)
+			exitRun(exitFailure)
+		}
+		now := time.Now()
+		rep := analysis.BuildPeriodReport(summaries, now.AddDate(0, 0, -days), now, analysis.SLAThresholds{SpeedKbps: *reportSLASpeed, TTFBMs: *reportSLATTFB})
+		if *reportOut != "" {
+			body := rep.Markdown(time.Local)
+			if ext := strings.ToLower(filepath.Ext(*reportOut)); ext == ".html" || ext == ".htm" {
+				body = rep.HTML(time.Local)
+			}
+			if *reportOut == "-" {
+				fmt.Print(body)
+			} else if err := os.WriteFile(*reportOut, []byte(body), 0o644); err != nil {
+				fmt.Printf("[report] %v\n", err)
+				exitRun(exitFailure)
+			} else {
+				fmt.Printf("[report] %d batches written to %s\n", rep.Total.Batches, *reportOut)
+			}
+		}
+		if *reportEmail != "" {
+			var to []string
+			for _, a := range strings.Split(*reportEmail, ",") {
+				if a = strings.TrimSpace(a); a != "" {
+					to = append(to, a)
+				}
+			}
+			// read here rather than as the flag default, so the password never shows in -h
+			password := *smtpPassword
+			if password == "" {
+				password = os.Getenv("IQM_SMTP_PASSWORD")
+			}
+			cfg := mailer.Config{Addr: *smtpServer, From: *smtpFrom, To: to, Username: *smtpUser, Password: password}
+			if err := mailer.Send(cfg, rep.Title(time.Local), rep.Markdown(time.Local), rep.HTML(time.Local)); err != nil {
+				fmt.Printf("[report] mail: %v\n", err)
+				exitRun(exitFailure)
+			}
+			fmt.Printf("[report] %d batches mailed to %s\n", rep.Total.Batches, strings.Join(to, ", "))
+		}
+		return
+	}
+
+	// BISECT MODE: start of a sustained regression and its likely suspects
+	if *bisectMetric != "" {
+		// with several situations in the file, --situation keeps their levels apart
+		sitFilter := ""
+		flag.Visit(func(f *flag.Flag) {
+			if f.Name == "situation" {
+				sitFilter = *situation
+			}
+		})
+		summaries, err := analysis.AnalyzeRecentResultsFull(strings.TrimSpace(*inputFile), monitor.SchemaVersion, reportMaxBatches, sitFilter)
+		if err != nil {
+			fmt.Printf("[bisect] %v\n", err)
+			exitRun(exitConfigError)
+		}
+		r, err := analysis.FindRegression(summaries, strings.TrimSpace(*bisectMetric), *bisectThreshold)
+		if err != nil {
+			fmt.Printf("[bisect] %v\n", err)
+			exitRun(exitConfigError)
+		}
+		if r == nil {
+			fmt.Printf("[bisect] no sustained regression of %s by %g found in %d batches\n", *bisectMetric, *bisectThreshold, len(summaries))
+			return
+		}
+		fmt.Print(r.String())
+		exitRun(exitFailure)
+	}
+
+	// INFLUX EXPORT MODE: batches of an existing results file as line protocol (backfill)
+	if *influxExport != "" {
+		summaries, err := analysis.AnalyzeRecentResultsFull(strings.TrimSpace(*inputFile), monitor.SchemaVersion, reportMaxBatches, "")
+		if err != nil {
+			fmt.Printf("[influx] %v\n", err)
+			exitRun(exitConfigError)
+		}
+		w := os.Stdout
+		if *influxExport != "-" {
+			f, err := os.Create(*influxExport)
+			if err != nil {
+				fmt.Printf("[influx] %v\n", err)
+				exitRun(exitConfigError)
+			}
+			defer f.Close()
+			w = f
+		}
+		n, err := analysis.WriteInfluxLines(w, summaries, monitor.InfluxMeasurement("batch"))
+		if err != nil {
+			fmt.Printf("[influx] %v\n", err)
+			exitRun(exitConfigError)
+		}
+		if w != os.Stdout {
+			fmt.Printf("[influx] %d point(s) from %d batch(es) written to %s\n", n, len(summaries), *influxExport)
+		}
+		return
+	}
+
+	// VALIDATE MODE: readiness report for a headless deployment, no transfers
+	if *validate {
+		sites, err := loadSites(*sitesPath)
+		if err != nil {
+			fmt.Printf("[validate] FAIL sites: %s: %v\n", *sitesPath, err)
+			exitRun(exitFailure)
+		}
+		fmt.Printf("[validate] sites file %s\n", *sitesPath)
+		rep := monitor.ValidateSetup(context.Background(), sites, *outFile)
+		fmt.Print(rep.String())
+		if !rep.OK() {
+			exitRun(exitFailure)
+		}
+		fmt.Println("[validate] ready")
+		return
+	}
 
 	// Only load sites if we are going to collect (not in analyze-only mode)
 	var sites []types.Site
@@ -279,11 +844,15 @@ func main() {
 		sites, err = loadSites(*sitesPath)
 		if err != nil {
 			fmt.Printf("load sites: %v\n", err)
-			os.Exit(1)
+			exitRun(exitFailure)
 		}
 		if len(sites) == 0 {
 			fmt.Println("no sites loaded")
-			os.Exit(1)
+			exitRun(exitFailure)
+		}
+		if lite := monitor.LiteSites(sites); len(lite) < len(sites) {
+			fmt.Printf("[init] sampling profile lite: measuring the first %d of %d sites\n", len(lite), len(sites))
+			sites = lite
 		}
 	}
 
@@ -303,7 +872,7 @@ func main() {
 		summaries, err := analysis.AnalyzeRecentResultsFull(inPath, monitor.SchemaVersion, batches, *situation)
 		if err != nil {
 			fmt.Printf("[analysis] %v\n", err)
-			os.Exit(1)
+			exitRun(exitFailure)
 		}
 		for _, s := range summaries {
 			line := fmt.Sprintf("[batch %s] (per-batch) lines=%d dur=%dms avg_speed=%.1fkbps median=%.1fkbps ttfb=%.0fms bytes=%.0fB errors=%d first_rtt_goodput=%.1fkbps p50=%.1fkbps p99/p50=%.2f jitter=%.1f%% slope=%.2fkbps/s cov%%=%.1f cache_hit=%.1f%% reuse=%.1f%% plateaus=%.1f longest_ms=%.0f", s.RunTag, s.Lines, s.BatchDurationMs, s.AvgSpeed, s.MedianSpeed, s.AvgTTFB, s.AvgBytes, s.ErrorLines, s.AvgFirstRTTGoodput, s.AvgP50Speed, s.AvgP99P50Ratio, s.AvgJitterPct, s.AvgSlopeKbpsPerSec, s.AvgCoefVariationPct, s.CacheHitRatePct, s.ConnReuseRatePct, s.AvgPlateauCount, s.AvgLongestPlateau)
@@ -324,6 +893,15 @@ func main() {
 					line += " [" + strings.Join(top, ",") + "]"
 				}
 			}
+			if s.ReducedMode {
+				line += fmt.Sprintf(" reduced_mode(capped=%d)", s.CappedTransferLines)
+			}
+			if s.Canceled {
+				line += " canceled(partial)"
+			}
+			if s.WireRxBytes+s.WireTxBytes > 0 {
+				line += fmt.Sprintf(" data(rx=%.1fMB tx=%.1fMB)", float64(s.WireRxBytes)/1e6, float64(s.WireTxBytes)/1e6)
+			}
 			if s.IPv4 != nil {
 				line += fmt.Sprintf(" v4(lines=%d spd=%.1fkbps ttfb=%.0fms p50=%.1fkbps)", s.IPv4.Lines, s.IPv4.AvgSpeed, s.IPv4.AvgTTFB, s.IPv4.AvgP50Speed)
 			}
@@ -439,13 +1017,16 @@ func main() {
 		// compute aggregates vs previous batches
 		last := summaries[len(summaries)-1]
 		var prevAggAvgSpeed, prevAggAvgTTFB float64
-		for i := 0; i < len(summaries)-1; i++ {
-			prevAggAvgSpeed += summaries[i].AvgSpeed
-			prevAggAvgTTFB += summaries[i].AvgTTFB
+		baseline := baselineBatches(summaries)
+		for _, b := range baseline {
+			prevAggAvgSpeed += b.AvgSpeed
+			prevAggAvgTTFB += b.AvgTTFB
+		}
+		prevCount := float64(len(baseline))
+		if prevCount > 0 {
+			prevAggAvgSpeed /= prevCount
+			prevAggAvgTTFB /= prevCount
 		}
-		prevCount := float64(len(summaries) - 1)
-		prevAggAvgSpeed /= prevCount
-		prevAggAvgTTFB /= prevCount
 		speedDeltaPct := 0.0
 		if prevAggAvgSpeed > 0 {
 			speedDeltaPct = (last.AvgSpeed - prevAggAvgSpeed) / prevAggAvgSpeed * 100
@@ -509,7 +1090,30 @@ func main() {
 
 	// Init async writer for collection mode so results go to the requested --out file
 	monitor.InitResultWriter(*outFile)
+	if err := monitor.LoadUsageState(*outFile); err != nil {
+		fmt.Printf("[init] data usage state: %v (starting from zero)\n", err)
+	}
 	defer monitor.CloseResultWriter()
+	// SIGINT/SIGTERM stop handing out sites: requests in flight finish and are written with
+	// meta.canceled, and the deferred CloseResultWriter flushes the file. A second signal exits at once.
+	stopCh := make(chan struct{})
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		sig := <-sigCh
+		monitor.SetRunCanceled(true)
+		fmt.Printf("[signal] %v: finishing in-flight requests, then flushing results (repeat to exit immediately)\n", sig)
+		close(stopCh)
+		signal.Reset(os.Interrupt, syscall.SIGTERM)
+	}()
+	stopping := func() bool {
+		select {
+		case <-stopCh:
+			return true
+		default:
+			return false
+		}
+	}
 	defaultAlerts := false
 	if *alertsJSON == "" { // user did not supply a path; enable automatic alerts JSON per iteration (repo root preferred)
 		defaultAlerts = true
@@ -517,13 +1121,132 @@ func main() {
 	}
 	fmt.Printf("[init] sites=%d iterations=%d parallel=%d out=%s run_tag_base=%s situation=%s go=%s/%s\n", len(sites), *iterations, *parallel, *outFile, baseRunTag, *situation, runtime.GOOS, runtime.GOARCH)
 
-	for it := 0; it < *iterations; it++ {
-		iterTag := baseRunTag
-		if *iterations > 1 {
-			iterTag = fmt.Sprintf("%s_i%d", baseRunTag, it+1)
+	// Appending to a file that already has this run_tag (clock reset after a reboot, two runs in
+	// the same second) would merge two batches; such a batch gets a _r2, _r3, … suffix instead.
+	usedTags, err := monitor.ExistingRunTags(*outFile)
+	if err != nil {
+		fmt.Printf("[init] scanning %s for run_tags: %v (collision check incomplete)\n", *outFile, err)
+		usedTags = map[string]bool{}
+	}
+	// The controller schedules batches (--batch-interval) and, with --control-listen, takes
+	// run-now / interval / reload requests from the viewer or scripts while the monitor runs.
+	ctl := control.New(*sitesPath, *outFile, len(sites), *iterations, *batchInterval)
+	ctl.LoadSites = func() ([]types.Site, error) { return loadSites(*sitesPath) }
+	moreBatches := func(it int) bool { return *iterations == 0 || it+1 < *iterations }
+	if *controlListen != "" {
+		if *controlToken == "" {
+			*controlToken = os.Getenv("IQM_CONTROL_TOKEN")
+		}
+		tokenSource := "--control-token"
+		if *controlToken == "" {
+			// a generated token is kept in a local file: fine for loopback clients only
+			if !control.LoopbackAddr(*controlListen) {
+				fmt.Printf("[init] --control-listen: %s is not a loopback address; set --control-token\n", *controlListen)
+				exitRun(exitConfigError)
+			}
+			tokenSource = *controlTokenFile
+			if tokenSource == "" {
+				tokenSource = control.DefaultTokenFile()
+			}
+			tok, err := control.LoadOrCreateToken(tokenSource)
+			if err != nil {
+				fmt.Printf("[init] --control-token-file: %v\n", err)
+				exitRun(exitConfigError)
+			}
+			*controlToken = tok
+		}
+		ctl.Token = *controlToken
+		hs, addr, err := ctl.ListenAndServe(*controlListen)
+		if err != nil {
+			fmt.Printf("[init] --control-listen: %v\n", err)
+			exitRun(exitConfigError)
+		}
+		defer hs.Close()
+		fmt.Printf("[control] API on http://%s/v1/status (token from %s)\n", addr, tokenSource)
+	}
+	go func() {
+		<-stopCh
+		ctl.Stopping()
+	}()
+	bindPasses := []string{""} // "" = the system's choice
+	if specs := bindingSpecs(*ifaceFlag, *sourceIPFlag); len(specs) > 0 {
+		for _, spec := range specs {
+			b, err := monitor.ResolveSourceBinding(spec)
+			if err != nil {
+				fmt.Printf("[init] --interface/--source-ip: %v\n", err)
+				exitRun(exitConfigError)
+			}
+			if err := b.DeviceBindError(); err != nil && b.Interface != "" {
+				fmt.Printf("[init] %s: cannot bind to the device (%v); binding its source address only, so routes still follow the routing table\n", b.Interface, err)
+			}
+		}
+		bindPasses = specs
+	}
+	exitCode := exitOK
+	// runBatch runs one batch of iteration it through the binding spec ("" = none) under iterTag;
+	// more tells the control API whether another batch follows. false: the run was canceled.
+	runBatch := func(it int, spec, iterTag string, more bool) bool {
+		if tag := monitor.UniqueRunTag(iterTag, usedTags); tag != iterTag {
+			fmt.Printf("[iteration %d] run_tag %s already exists in %s (clock reset?); using %s\n", it+1, iterTag, *outFile, tag)
+			iterTag = tag
 		}
 		monitor.SetRunTag(iterTag)
+		ctl.BatchStarted(it+1, iterTag)
 		fmt.Printf("[iteration %d/%d] run_tag=%s\n", it+1, *iterations, iterTag)
+		monitor.EmitProgress(monitor.ProgressEvent{Event: monitor.ProgressBatchStart, RunTag: iterTag, Iteration: it + 1, Sites: len(sites)})
+		runCfg := monitor.SetRunConfig(iterTag, monitor.RunConfig{
+			SitesHash:          monitor.SitesHash(sites),
+			Sites:              len(sites),
+			Parallel:           *parallel,
+			HTTPTimeoutMs:      httpTimeout.Milliseconds(),
+			StallTimeoutMs:     stallTimeout.Milliseconds(),
+			SiteTimeoutMs:      siteTimeout.Milliseconds(),
+			DNSTimeoutMs:       dnsTimeout.Milliseconds(),
+			IntervalMs:         ctl.Interval().Milliseconds(),
+			MaxIPsPerSite:      *maxIPsPerSite,
+			ProtocolExperiment: protoVersions,
+
+			MicroStallGapMs:       microStallGap.Milliseconds(),
+			LowSpeedThresholdKbps: *lowSpeedThreshold,
+			SamplingProfile:       monitor.RecordedSamplingProfile(),
+		})
+		if len(runCfg.Changed) > 0 {
+			fmt.Printf("[iteration %d] config changed since the previous batch: %s\n", it+1, strings.Join(runCfg.Changed, ", "))
+		}
+		// the pre hook runs before the metered probe so the batch sees the environment it set up
+		hookEnv := []string{fmt.Sprintf("IQM_ITERATION=%d", it+1), "IQM_OUT_FILE=" + *outFile, "IQM_BIND=" + spec}
+		logHook(it+1, monitor.RunBatchHook(monitor.HookPre, iterTag, hookEnv...))
+		postHook := func() {
+			canceled := "0"
+			if stopping() {
+				canceled = "1"
+			}
+			logHook(it+1, monitor.RunBatchHook(monitor.HookPost, iterTag, append(hookEnv, "IQM_BATCH_CANCELED="+canceled)...))
+		}
+		switch action, mi := monitor.MeteredAction(iterTag); action {
+		case monitor.MeteredPolicySkip:
+			fmt.Printf("[iteration %d] skipped: metered=%v captive=%v source=%s signals=%v\n", it+1, mi.Metered, mi.Captive, mi.Source, mi.Signals)
+			postHook()
+			monitor.EmitProgress(monitor.ProgressEvent{Event: monitor.ProgressBatchDone, RunTag: iterTag, Iteration: it + 1, Skipped: "metered"})
+			ctl.BatchDone(nil, more)
+			return true
+		case monitor.MeteredPolicyReduce:
+			fmt.Printf("[iteration %d] reduced mode: metered network (%s %v); transfers capped at %d bytes\n", it+1, mi.Source, mi.Signals, *meteredMaxBytes)
+		}
+		switch action, du := monitor.BudgetAction(iterTag); action {
+		case monitor.BudgetPolicySkip:
+			fmt.Printf("[iteration %d] skipped: data budget cycle=%s used=%d of %d bytes\n", it+1, du.Cycle, du.CycleRxBytes+du.CycleTxBytes, du.BudgetBytes)
+			postHook()
+			monitor.EmitProgress(monitor.ProgressEvent{Event: monitor.ProgressBatchDone, RunTag: iterTag, Iteration: it + 1, Skipped: "budget"})
+			ctl.BatchDone(nil, more)
+			return true
+		case monitor.BudgetPolicyReduce:
+			fmt.Printf("[iteration %d] reduced mode: data budget nearly used (%d of %d bytes); transfers capped at %d bytes\n", it+1, du.CycleRxBytes+du.CycleTxBytes, du.BudgetBytes, *meteredMaxBytes)
+		}
+		batchSites, hc := monitor.RunHealthCheck(iterTag, sites, *parallel)
+		logHealthCheck(it+1, hc)
+		batchSites = monitor.ExpandProtocolExperiment(batchSites, protoVersions)
+		batchSites, iperf3Sites := monitor.SplitIPerf3Sites(batchSites)
 
 		if *ipFanout {
 			// --- IP fanout mode ---
@@ -535,7 +1258,7 @@ func main() {
 				fallback  bool
 			}
 			var tasks []ipTask
-			for _, s := range sites {
+			for _, s := range batchSites {
 				u, err := url.Parse(s.URL)
 				if err != nil {
 					fmt.Printf("[dns %s] parse error: %v\n", s.Name, err)
@@ -549,12 +1272,13 @@ func main() {
 					perLookupTimeout = *siteTimeout
 				}
 				dnsCtx, dnsCancel := context.WithTimeout(context.Background(), perLookupTimeout)
-				addrs, derr := net.DefaultResolver.LookupIPAddr(dnsCtx, host)
+				addrs, derr := monitor.BoundResolver().LookupIPAddr(dnsCtx, host)
 				dnsCancel()
 				var ips []net.IP
 				for _, a := range addrs {
 					ips = append(ips, a.IP)
 				}
+				ips = monitor.BindableIPs(ips)
 				dnsDur := time.Since(startDNS)
 				if derr != nil || len(ips) == 0 {
 					fmt.Printf("[dns %s] failed: %v\n", s.Name, derr)
@@ -728,8 +1452,16 @@ func main() {
 					}
 				}(w)
 			}
+		dispatchTasks:
 			for _, t := range tasks {
-				workCh <- t
+				if stopping() {
+					break
+				}
+				select {
+				case workCh <- t:
+				case <-stopCh:
+					break dispatchTasks
+				}
 			}
 			close(workCh)
 			wg.Wait()
@@ -747,7 +1479,7 @@ func main() {
 			}
 			var inFlight int32
 			var completed int32
-			totalSites := len(sites)
+			totalSites := len(batchSites)
 			activeSites := make([]string, workerCount)
 			var activeMu sync.Mutex
 			stopProgress := make(chan struct{})
@@ -830,8 +1562,16 @@ func main() {
 					}
 				}(w)
 			}
-			for _, s := range sites {
-				workCh <- s
+		dispatchSites:
+			for _, s := range batchSites {
+				if stopping() {
+					break
+				}
+				select {
+				case workCh <- s:
+				case <-stopCh:
+					break dispatchSites
+				}
 			}
 			close(workCh)
 			wg.Wait()
@@ -840,10 +1580,27 @@ func main() {
 			}
 			fmt.Printf("[iteration %d] complete\n", it+1)
 		}
+		// iperf3 saturates the path: its sites run alone, one after the other, once the rest is done
+		for _, s := range iperf3Sites {
+			if stopping() {
+				break
+			}
+			monitor.MonitorSite(s)
+		}
+
+		if err := monitor.SaveUsageState(); err != nil {
+			fmt.Printf("[iteration %d] saving data usage state: %v\n", it+1, err)
+		}
+		postHook()
+		if stopping() {
+			fmt.Printf("[iteration %d] canceled: partial batch %s kept with meta.canceled; skipping analysis and remaining iterations\n", it+1, iterTag)
+			monitor.EmitProgress(monitor.ProgressEvent{Event: monitor.ProgressBatchDone, RunTag: iterTag, Iteration: it + 1, Canceled: true})
+			return false
+		}
 
 		// Run analysis after each iteration (consider last N batches up to iterations so far, capped at 10)
 		batchesToParse := *iterations
-		if batchesToParse > 10 {
+		if batchesToParse > 10 || batchesToParse == 0 {
 			batchesToParse = 10
 		}
 		fmt.Printf("[iteration %d analysis] performing rolling analysis over last %d batch(es) including current iteration\n", it+1, batchesToParse)
@@ -851,21 +1608,166 @@ func main() {
 		if defaultAlerts { // derive unique filename incorporating the iteration tag, prefer repo root if running inside src
 			alertsPath = deriveDefaultAlertsPath(iterTag)
 		}
-		performAnalysis(*outFile, monitor.SchemaVersion, batchesToParse, *speedDropAlert, *ttfbIncreaseAlert, *errorRateAlert, *jitterAlert, *p99p50RatioAlert, alertsPath, *situation)
+		newest := performAnalysis(*outFile, monitor.SchemaVersion, batchesToParse, *speedDropAlert, *ttfbIncreaseAlert, *errorRateAlert, *jitterAlert, *p99p50RatioAlert, alertsPath, *situation)
+		if newest != nil && monitor.InfluxEnabled() {
+			monitor.WriteInfluxPoints(analysis.InfluxBatchPoints(*newest, monitor.InfluxMeasurement("batch")))
+		}
+		done := monitor.ProgressEvent{Event: monitor.ProgressBatchDone, RunTag: iterTag, Iteration: it + 1}
+		if newest != nil && newest.Lines > 0 {
+			done.Lines, done.ErrorLines = newest.Lines, newest.ErrorLines
+			done.ErrorRatePct = float64(newest.ErrorLines) / float64(newest.Lines) * 100
+			if *failErrorRate > 0 && done.ErrorRatePct > *failErrorRate {
+				fmt.Printf("[iteration %d] error rate %.1f%% above --fail-error-rate %.1f%%; the run will exit with code %d\n", it+1, done.ErrorRatePct, *failErrorRate, exitErrorRate)
+				exitCode = exitErrorRate
+			}
+		}
+		monitor.EmitProgress(done)
+		ctl.BatchDone(newest, more)
+		return true
+	}
+	monitor.EmitProgress(monitor.ProgressEvent{Event: monitor.ProgressRunStart, Iterations: iterations})
+	for it := 0; (*iterations == 0 || it < *iterations) && !stopping(); it++ {
+		if it > 0 {
+			if !ctl.Wait(stopCh) {
+				break
+			}
+		}
+		if reloaded, ok := ctl.TakeSites(); ok {
+			fmt.Printf("[iteration %d] sites reloaded from %s: %d (was %d)\n", it+1, *sitesPath, len(reloaded), len(sites))
+			sites = monitor.LiteSites(reloaded)
+		}
+		// one batch per binding (--interface/--source-ip), run one after the other on the same
+		// sites; with several, each run tag carries the binding's label
+		passTag := baseRunTag
+		if *iterations == 0 {
+			// open-ended runs get a fresh timestamp per batch
+			passTag = time.Now().UTC().Format("20060102_150405")
+		} else if *iterations > 1 {
+			passTag = fmt.Sprintf("%s_i%d", baseRunTag, it+1)
+		}
+		for pi, spec := range bindPasses {
+			more := moreBatches(it) || pi+1 < len(bindPasses)
+			iterTag := passTag
+			if spec != "" {
+				b, err := monitor.ResolveSourceBinding(spec)
+				if err != nil {
+					fmt.Printf("[iteration %d] %v; skipping this binding\n", it+1, err)
+					continue
+				}
+				monitor.SetSourceBinding(b)
+				if len(bindPasses) > 1 {
+					iterTag += "_" + runTagLabel(b.Label())
+				}
+				fmt.Printf("[iteration %d] bound to %s (source %s, device=%v)\n", it+1, b.Label(), b.SourceIP(), b.Device)
+			}
+			if !runBatch(it, spec, iterTag, more) {
+				break
+			}
+		}
 	}
 
 	// Optional final full analysis after all iterations if requested
-	if *finalAnalysisBatches > 0 {
+	if *finalAnalysisBatches > 0 && !stopping() {
 		fmt.Printf("[final analysis] requested --final-analysis-batches=%d; performing analysis over last %d batch(es)\n", *finalAnalysisBatches, *finalAnalysisBatches)
 		performAnalysis(*outFile, monitor.SchemaVersion, *finalAnalysisBatches, *speedDropAlert, *ttfbIncreaseAlert, *errorRateAlert, *jitterAlert, *p99p50RatioAlert, *alertsJSON, *situation)
 	}
+	// flush the results before exiting: os.Exit skips the deferred CloseResultWriter
+	monitor.CloseResultWriter()
+	exitRun(exitCode)
+}
+
+// bindingSpecs splits --interface and --source-ip into the bindings to run, interfaces first.
+func bindingSpecs(ifaces, sourceIPs string) []string {
+	var out []string
+	for _, list := range []string{ifaces, sourceIPs} {
+		for _, v := range strings.Split(list, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				out = append(out, v)
+			}
+		}
+	}
+	return out
+}
+
+// runTagLabel makes a binding label safe inside a run tag ("Wi-Fi 2" -> "Wi-Fi-2", IPv6 colons
+// become dashes).
+func runTagLabel(label string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '-' {
+			return r
+		}
+		return '-'
+	}, label)
+}
+
+// logHealthCheck prints the hard-down targets the pre-check found (nil: check off).
+func logHealthCheck(iter int, hc *monitor.HealthCheckInfo) {
+	if hc == nil || hc.Unhealthy == 0 {
+		return
+	}
+	var names []string
+	for _, t := range hc.Targets {
+		names = append(names, t.Name+"("+t.Reason+")")
+	}
+	switch {
+	case hc.AllUnhealthy:
+		fmt.Printf("[iteration %d health] all %d targets down (%s); measuring them anyway\n", iter, hc.Checked, strings.Join(names, ","))
+	case hc.Excluded > 0:
+		fmt.Printf("[iteration %d health] excluded %d of %d target(s) as unhealthy: %s\n", iter, hc.Excluded, hc.Checked, strings.Join(names, ","))
+	default:
+		fmt.Printf("[iteration %d health] %d of %d target(s) down: %s\n", iter, hc.Unhealthy, hc.Checked, strings.Join(names, ","))
+	}
+}
+
+// logHook prints the outcome of a batch hook (nil: none configured).
+func logHook(iter int, h *monitor.HookResult) {
+	if h == nil {
+		return
+	}
+	status := fmt.Sprintf("exit=%d", h.ExitCode)
+	if h.Error != "" {
+		status += " error=" + h.Error
+	}
+	fmt.Printf("[iteration %d hook] %s-batch %s dur=%dms\n", iter, h.Phase, status, h.DurationMs)
+	if h.Output != "" && (!h.OK() || monitor.GetLogLevel() == monitor.LevelDebug) {
+		fmt.Printf("[iteration %d hook] output: %s\n", iter, h.Output)
+	}
+}
 
+// baselineBatches returns the batches before the newest one that were measured in the same mode:
+// capped reduced-mode transfers (--metered-policy=reduce) are slower by construction, so full and
+// reduced batches are never averaged into each other's comparison baseline. Batches bound to
+// another interface or source address (--interface/--source-ip) measured another path and are
+// skipped too. Canceled (partial) batches are left out entirely.
+func baselineBatches(summaries []analysis.BatchSummary) []analysis.BatchSummary {
+	if len(summaries) < 2 {
+		return nil
+	}
+	last := summaries[len(summaries)-1]
+	out := make([]analysis.BatchSummary, 0, len(summaries)-1)
+	canceled := 0
+	for _, s := range summaries[:len(summaries)-1] {
+		if s.Canceled {
+			canceled++
+			continue
+		}
+		if s.ReducedMode == last.ReducedMode && s.BindLabel() == last.BindLabel() {
+			out = append(out, s)
+		}
+	}
+	if n := len(summaries) - 1 - len(out) - canceled; n > 0 {
+		fmt.Printf("[batch-compare] %d of %d earlier batch(es) ran in a different mode or binding and are left out of the baseline (newest: reduced=%v bind=%q)\n", n, len(summaries)-1, last.ReducedMode, last.BindLabel())
+	}
+	if canceled > 0 {
+		fmt.Printf("[batch-compare] %d canceled (partial) batch(es) left out of the baseline\n", canceled)
+	}
+	return out
 }
 
 // performAnalysis uses the analysis package and prints summaries & alerts.
 // performAnalysis loads up to n recent batches from path and evaluates alert conditions comparing newest vs aggregate of previous.
-// Used in collection mode after each iteration.
-func performAnalysis(path string, schemaVersion, n int, speedDropThresh, ttfbIncreaseThresh, errorRateThresh, jitterThresh, ratioThresh float64, alertsJSONPath string, situationFilter string) {
+// Used in collection mode after each iteration; returns the newest batch (nil when none).
+func performAnalysis(path string, schemaVersion, n int, speedDropThresh, ttfbIncreaseThresh, errorRateThresh, jitterThresh, ratioThresh float64, alertsJSONPath string, situationFilter string) (newest *analysis.BatchSummary) {
 	fmt.Printf("[analysis start] evaluating up to last %d batch(es) from %s\n", n, path)
 	summaries, err := analysis.AnalyzeRecentResultsFull(path, schemaVersion, n, situationFilter)
 	if err != nil {
@@ -875,6 +1777,36 @@ func performAnalysis(path string, schemaVersion, n int, speedDropThresh, ttfbInc
 	for _, s := range summaries {
 		line := fmt.Sprintf("[batch %s] (per-batch) lines=%d dur=%dms avg_speed=%.1fkbps median=%.1fkbps ttfb=%.0fms bytes=%.0fB errors=%d first_rtt_goodput=%.1fkbps p50=%.1fkbps p99/p50=%.2f plateaus=%.1f longest_ms=%.0f jitter=%.1f%%",
 			s.RunTag, s.Lines, s.BatchDurationMs, s.AvgSpeed, s.MedianSpeed, s.AvgTTFB, s.AvgBytes, s.ErrorLines, s.AvgFirstRTTGoodput, s.AvgP50Speed, s.AvgP99P50Ratio, s.AvgPlateauCount, s.AvgLongestPlateau, s.AvgJitterPct)
+		if s.QualityScore != nil {
+			line += fmt.Sprintf(" score=%.0f", s.QualityScore.Score)
+		}
+		if s.ReducedMode {
+			line += fmt.Sprintf(" reduced_mode(capped=%d)", s.CappedTransferLines)
+		}
+		if s.Canceled {
+			line += " canceled(partial)"
+		}
+		if s.DNSHijackSuspected {
+			line += fmt.Sprintf(" dns_hijack(answers=%s)", strings.Join(s.DNSHijackAnswers, ","))
+		}
+		if s.DNSWithinTTLLookups > 0 {
+			line += fmt.Sprintf(" dns_cache_hits=%.0f%%(%d)", s.DNSCacheHitRatePct, s.DNSWithinTTLLookups)
+		}
+		if l := s.BindLabel(); l != "" {
+			line += " bind=" + l
+		}
+		if h := s.PreHook; h != nil && !h.OK() {
+			line += fmt.Sprintf(" pre_hook_failed(exit=%d)", h.ExitCode)
+		}
+		if h := s.PostHook; h != nil && !h.OK() {
+			line += fmt.Sprintf(" post_hook_failed(exit=%d)", h.ExitCode)
+		}
+		if s.ExcludedUnhealthy > 0 {
+			line += fmt.Sprintf(" excluded_unhealthy=%d", s.ExcludedUnhealthy)
+		}
+		if s.WireRxBytes+s.WireTxBytes > 0 {
+			line += fmt.Sprintf(" data(rx=%.1fMB tx=%.1fMB)", float64(s.WireRxBytes)/1e6, float64(s.WireTxBytes)/1e6)
+		}
 		if s.IPv4 != nil {
 			line += fmt.Sprintf(" v4(lines=%d spd=%.1fkbps ttfb=%.0fms p50=%.1fkbps)", s.IPv4.Lines, s.IPv4.AvgSpeed, s.IPv4.AvgTTFB, s.IPv4.AvgP50Speed)
 		}
@@ -886,6 +1818,7 @@ func performAnalysis(path string, schemaVersion, n int, speedDropThresh, ttfbInc
 	if len(summaries) == 0 {
 		return
 	}
+	newest = &summaries[len(summaries)-1]
 	// Overall multi-batch aggregation (line-weighted) for context in collection mode analysis
 	if len(summaries) > 1 {
 		var totalLines int
@@ -981,13 +1914,16 @@ func performAnalysis(path string, schemaVersion, n int, speedDropThresh, ttfbInc
 	}
 	last := summaries[len(summaries)-1]
 	var prevAggAvgSpeed, prevAggAvgTTFB float64
-	for i := 0; i < len(summaries)-1; i++ {
-		prevAggAvgSpeed += summaries[i].AvgSpeed
-		prevAggAvgTTFB += summaries[i].AvgTTFB
+	baseline := baselineBatches(summaries)
+	for _, b := range baseline {
+		prevAggAvgSpeed += b.AvgSpeed
+		prevAggAvgTTFB += b.AvgTTFB
+	}
+	prevCount := float64(len(baseline))
+	if prevCount > 0 {
+		prevAggAvgSpeed /= prevCount
+		prevAggAvgTTFB /= prevCount
 	}
-	prevCount := float64(len(summaries) - 1)
-	prevAggAvgSpeed /= prevCount
-	prevAggAvgTTFB /= prevCount
 	speedDeltaPct := 0.0
 	if prevAggAvgSpeed > 0 {
 		speedDeltaPct = (last.AvgSpeed - prevAggAvgSpeed) / prevAggAvgSpeed * 100
@@ -1040,6 +1976,7 @@ func performAnalysis(path string, schemaVersion, n int, speedDropThresh, ttfbInc
 	if alertsJSONPath != "" {
 		writeAlertJSON(alertsJSONPath, schemaVersion, last, &struct{ PrevSpeed, PrevTTFB, SpeedDelta, TTFBDelta, ErrorRate float64 }{prevAggAvgSpeed, prevAggAvgTTFB, speedDeltaPct, ttfbDeltaPct, errorRate}, alerts, speedDropThresh, ttfbIncreaseThresh, errorRateThresh, jitterThresh, ratioThresh, len(summaries))
 	}
+	return
 }
 
 // writeAlertJSON persists a structured alert report capturing the latest batch summary, optional comparison, thresholds & alerts.
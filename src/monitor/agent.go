@@ -0,0 +1,285 @@
+package monitor
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Remote agent mode.
+//
+// When a collector URL is configured every result line is also pushed to a central collector
+// (see src/collector) in addition to the local results file. Lines are batched, gzip-compressed
+// and POSTed as JSONL with a bearer token. Failed batches are retried with exponential backoff
+// and keep their batch id, so the collector can drop a batch it already stored when only the
+// response was lost. The local file stays the source of truth: pushing never blocks measurements.
+
+const (
+	defaultAgentBatchSize     = 200
+	defaultAgentFlushInterval = 10 * time.Second
+	agentMaxPendingLines      = 20000 // oldest lines are dropped beyond this while the collector is unreachable
+	agentMaxBackoff           = 2 * time.Minute
+	agentCloseBudget          = 15 * time.Second
+)
+
+var (
+	agentName          string
+	agentPushURL       string
+	agentToken         string
+	agentBatchSize     = defaultAgentBatchSize
+	agentFlushInterval = defaultAgentFlushInterval
+	agentOnce          sync.Once
+	agent              *agentPusher
+)
+
+// SetAgentName labels every result line with meta.agent (used by the collector and viewer to
+// tell machines apart). Empty leaves lines unlabelled unless pushing is enabled, in which case
+// the hostname is used.
+func SetAgentName(name string) { agentName = strings.TrimSpace(name) }
+
+// SetAgentPush enables pushing result lines to a collector base URL (e.g. http://collector:8099).
+// When the URL has no path, /v1/results is appended. Empty disables.
+func SetAgentPush(url, token string) {
+	url = strings.TrimSpace(url)
+	if url == "" {
+		return
+	}
+	if !strings.Contains(strings.TrimPrefix(strings.TrimPrefix(url, "http://"), "https://"), "/") {
+		url = strings.TrimRight(url, "/") + "/v1/results"
+	}
+	agentPushURL = url
+	agentToken = strings.TrimSpace(token)
+}
+
+// SetAgentBatch sets how many lines are sent per request and how often a partial batch is flushed.
+func SetAgentBatch(size int, interval time.Duration) {
+	if size > 0 {
+		agentBatchSize = size
+	}
+	if interval > 0 {
+		agentFlushInterval = interval
+	}
+}
+
+// effectiveAgentName is the meta.agent value for this process.
+func effectiveAgentName() string {
+	if agentName != "" {
+		return agentName
+	}
+	if agentPushURL != "" {
+		if h := gatherBaseMeta().Hostname; h != "" {
+			return h
+		}
+		return "agent"
+	}
+	return ""
+}
+
+type agentBatch struct {
+	id    string
+	lines [][]byte
+}
+
+type agentPusher struct {
+	url, token, name string
+	client           *http.Client
+	in               chan []byte
+	done             chan struct{}
+	pending          [][]byte
+	inflight         *agentBatch
+	nonce            string
+	seq              int
+	dropped          int
+	backoff          time.Duration
+	nextTry          time.Time
+	sent             int
+}
+
+// pushResult queues one line for the collector. It never blocks: when the queue is full the
+// line is dropped (it is still in the local results file).
+func pushResult(env *ResultEnvelope) {
+	if agentPushURL == "" || env == nil {
+		return
+	}
+	agentOnce.Do(startAgentPusher)
+	b, err := json.Marshal(env)
+	if err != nil {
+		return
+	}
+	select {
+	case agent.in <- b:
+	default:
+		Debugf("[agent] push queue full; line kept locally only")
+	}
+}
+
+func startAgentPusher() {
+	nonce := make([]byte, 6)
+	_, _ = rand.Read(nonce)
+	agent = &agentPusher{
+		url:    agentPushURL,
+		token:  agentToken,
+		name:   effectiveAgentName(),
+		client: &http.Client{Timeout: 30 * time.Second},
+		in:     make(chan []byte, 1024),
+		done:   make(chan struct{}),
+		nonce:  hex.EncodeToString(nonce),
+	}
+	Infof("[agent] pushing results as %q to %s (batch=%d, flush=%s)", agent.name, agent.url, agentBatchSize, agentFlushInterval)
+	go agent.run(agentBatchSize, agentFlushInterval)
+}
+
+func (p *agentPusher) run(batchSize int, interval time.Duration) {
+	defer close(p.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case line, ok := <-p.in:
+			if !ok {
+				p.drain(batchSize)
+				return
+			}
+			p.add(line)
+			if len(p.pending) >= batchSize {
+				p.flush(batchSize, false)
+			}
+		case <-ticker.C:
+			p.flush(batchSize, false)
+		}
+	}
+}
+
+func (p *agentPusher) add(line []byte) {
+	if len(p.pending) >= agentMaxPendingLines {
+		p.pending = p.pending[1:]
+		p.dropped++
+	}
+	p.pending = append(p.pending, line)
+}
+
+// flush sends the in-flight batch (retries keep its id) and then new batches until the queue is
+// empty or a send fails. force ignores the backoff window (used on shutdown).
+func (p *agentPusher) flush(batchSize int, force bool) error {
+	if !force && time.Now().Before(p.nextTry) {
+		return nil
+	}
+	for {
+		if p.inflight == nil {
+			if len(p.pending) == 0 {
+				return nil
+			}
+			n := batchSize
+			if n > len(p.pending) {
+				n = len(p.pending)
+			}
+			p.seq++
+			p.inflight = &agentBatch{id: p.nonce + "-" + strconv.Itoa(p.seq), lines: p.pending[:n:n]}
+			p.pending = p.pending[n:]
+		}
+		err := p.post(p.inflight)
+		if err != nil {
+			if pe, ok := err.(permanentPushError); ok {
+				Warnf("[agent] collector rejected batch %s (%d lines): %v; dropping it", p.inflight.id, len(p.inflight.lines), pe)
+				p.inflight = nil
+				continue
+			}
+			if p.backoff == 0 {
+				p.backoff = time.Second
+			} else if p.backoff *= 2; p.backoff > agentMaxBackoff {
+				p.backoff = agentMaxBackoff
+			}
+			p.nextTry = time.Now().Add(p.backoff)
+			Warnf("[agent] push failed (%d lines queued, retry in %s): %v", len(p.inflight.lines)+len(p.pending), p.backoff, err)
+			return err
+		}
+		p.sent += len(p.inflight.lines)
+		p.inflight = nil
+		p.backoff, p.nextTry = 0, time.Time{}
+	}
+}
+
+// drain flushes everything left on shutdown within agentCloseBudget.
+func (p *agentPusher) drain(batchSize int) {
+	deadline := time.Now().Add(agentCloseBudget)
+	for attempt := 0; ; attempt++ {
+		if p.flush(batchSize, true) == nil {
+			break
+		}
+		wait := time.Duration(attempt+1) * time.Second
+		if time.Now().Add(wait).After(deadline) {
+			break
+		}
+		time.Sleep(wait)
+	}
+	left := len(p.pending)
+	if p.inflight != nil {
+		left += len(p.inflight.lines)
+	}
+	if left > 0 || p.dropped > 0 {
+		Warnf("[agent] shutdown: %d lines not delivered, %d dropped while queued (all remain in the local results file)", left, p.dropped)
+	} else {
+		Debugf("[agent] shutdown: %d lines delivered", p.sent)
+	}
+}
+
+// permanentPushError marks a response that retrying cannot fix (malformed request, too large).
+type permanentPushError struct{ msg string }
+
+func (e permanentPushError) Error() string { return e.msg }
+
+func (p *agentPusher) post(b *agentBatch) error {
+	var body bytes.Buffer
+	zw := gzip.NewWriter(&body)
+	for _, l := range b.lines {
+		zw.Write(l)
+		zw.Write([]byte{'\n'})
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, p.url, &body)
+	if err != nil {
+		return permanentPushError{err.Error()}
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	req.Header.Set("Content-Encoding", "gzip")
+	req.Header.Set("X-IQM-Agent", p.name)
+	req.Header.Set("X-IQM-Batch-Id", b.id)
+	if p.token != "" {
+		req.Header.Set("Authorization", "Bearer "+p.token)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	switch {
+	case resp.StatusCode/100 == 2:
+		return nil
+	case resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusRequestEntityTooLarge:
+		return permanentPushError{fmt.Sprintf("%s: %s", resp.Status, strings.TrimSpace(string(msg)))}
+	default: // 401/403 (token), 5xx, proxies: keep retrying, the operator can fix it without losing data
+		return fmt.Errorf("collector responded %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+}
+
+// closeAgentPusher flushes queued lines; called from CloseResultWriter.
+func closeAgentPusher() {
+	if agent != nil {
+		close(agent.in)
+		<-agent.done
+		agent = nil
+		agentOnce = sync.Once{}
+	}
+}
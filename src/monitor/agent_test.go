@@ -0,0 +1,93 @@
+package monitor
+
+import (
+	"bufio"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAgentPusher_RetryKeepsBatchID(t *testing.T) {
+	var mu sync.Mutex
+	var ids []string
+	var lines []int
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		if r.Header.Get("Authorization") != "Bearer tok" || r.Header.Get("X-IQM-Agent") != "lab" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		n := 0
+		for sc := bufio.NewScanner(zr); sc.Scan(); n++ {
+		}
+		ids = append(ids, r.Header.Get("X-IQM-Batch-Id"))
+		lines = append(lines, n)
+		if calls == 1 { // first attempt fails: the retry must reuse the id
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	p := &agentPusher{url: srv.URL, token: "tok", name: "lab", client: srv.Client(), nonce: "n"}
+	for i := 0; i < 5; i++ {
+		p.add([]byte(`{"meta":{},"site_result":{}}`))
+	}
+	if err := p.flush(3, false); err == nil {
+		t.Fatalf("first flush should fail")
+	}
+	if p.backoff != time.Second || p.inflight == nil {
+		t.Fatalf("expected backoff and an in-flight batch, got %s %v", p.backoff, p.inflight)
+	}
+	if err := p.flush(3, false); err != nil || calls != 1 {
+		t.Fatalf("flush inside the backoff window must not send (calls=%d, err=%v)", calls, err)
+	}
+	if err := p.flush(3, true); err != nil {
+		t.Fatalf("forced flush: %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(ids) != 3 || ids[0] != ids[1] || ids[1] == ids[2] {
+		t.Fatalf("batch ids %v: retry must reuse the id, the next batch must get a new one", ids)
+	}
+	if lines[0] != 3 || lines[1] != 3 || lines[2] != 2 {
+		t.Fatalf("lines per request %v want [3 3 2]", lines)
+	}
+	if p.sent != 5 || len(p.pending) != 0 || p.inflight != nil {
+		t.Fatalf("sent=%d pending=%d inflight=%v", p.sent, len(p.pending), p.inflight)
+	}
+}
+
+func TestAgentPusher_DropsOldestWhenFull(t *testing.T) {
+	p := &agentPusher{}
+	for i := 0; i < agentMaxPendingLines+3; i++ {
+		p.add([]byte{byte(i)})
+	}
+	if len(p.pending) != agentMaxPendingLines || p.dropped != 3 {
+		t.Fatalf("pending=%d dropped=%d", len(p.pending), p.dropped)
+	}
+}
+
+func TestSetAgentPush_AppendsDefaultPath(t *testing.T) {
+	defer func() { agentPushURL, agentToken = "", "" }()
+	SetAgentPush("http://collector:8099", "t")
+	if agentPushURL != "http://collector:8099/v1/results" {
+		t.Fatalf("url=%s", agentPushURL)
+	}
+	SetAgentPush("https://c.example/ingest", "t")
+	if agentPushURL != "https://c.example/ingest" {
+		t.Fatalf("explicit path changed: %s", agentPushURL)
+	}
+}
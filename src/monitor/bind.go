@@ -0,0 +1,235 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Source binding (--interface, --source-ip): every connection a measurement opens — HTTP, the
+// ALPN pre-dial, DNS, ping, QUIC and soak — uses a local address of the chosen interface, so a
+// multi-homed host (Ethernet + Wi-Fi, LTE stick, second uplink) can be measured per path. On
+// Linux a named interface is also bound with SO_BINDTODEVICE when the process may do so, which
+// keeps the route on that interface even without source-based routing rules.
+
+// SourceBinding is a resolved --interface or --source-ip value.
+type SourceBinding struct {
+	Interface string // empty for a plain --source-ip
+	IPv4      net.IP
+	IPv6      net.IP
+	// Device is set when connections are bound to Interface itself (Linux SO_BINDTODEVICE).
+	Device    bool
+	deviceErr error
+}
+
+// DeviceBindError is why a Linux interface binding fell back to its source address (nil when
+// bound to the device, or off Linux where only the address is bound).
+func (b *SourceBinding) DeviceBindError() error { return b.deviceErr }
+
+// Label names the binding in logs, run tags and meta (interface name, else the source IP).
+func (b *SourceBinding) Label() string {
+	if b == nil {
+		return ""
+	}
+	if b.Interface != "" {
+		return b.Interface
+	}
+	if b.IPv4 != nil {
+		return b.IPv4.String()
+	}
+	return b.IPv6.String()
+}
+
+// SourceIP is the address lines report as their source: IPv4 when the binding has one.
+func (b *SourceBinding) SourceIP() string {
+	switch {
+	case b == nil:
+		return ""
+	case b.IPv4 != nil:
+		return b.IPv4.String()
+	case b.IPv6 != nil:
+		return b.IPv6.String()
+	}
+	return ""
+}
+
+var (
+	bindMu        sync.RWMutex
+	sourceBinding *SourceBinding
+)
+
+// ResolveSourceBinding turns an interface name or a local IP address into a binding. An
+// interface needs at least one usable (non link-local) address; IPv6 link-local addresses would
+// need a zone on every dial and are skipped.
+func ResolveSourceBinding(spec string) (*SourceBinding, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, fmt.Errorf("empty interface/source address")
+	}
+	if ip := net.ParseIP(spec); ip != nil {
+		if !isLocalAddress(ip) {
+			return nil, fmt.Errorf("source address %s is not assigned to any interface", ip)
+		}
+		b := &SourceBinding{}
+		if ip.To4() != nil {
+			b.IPv4 = ip.To4()
+		} else {
+			b.IPv6 = ip
+		}
+		return b, nil
+	}
+	ifi, err := net.InterfaceByName(spec)
+	if err != nil {
+		return nil, fmt.Errorf("interface %q: %v", spec, err)
+	}
+	if ifi.Flags&net.FlagUp == 0 {
+		return nil, fmt.Errorf("interface %q is down", spec)
+	}
+	addrs, err := ifi.Addrs()
+	if err != nil {
+		return nil, fmt.Errorf("interface %q addresses: %v", spec, err)
+	}
+	b := &SourceBinding{Interface: ifi.Name}
+	for _, a := range addrs {
+		ipn, ok := a.(*net.IPNet)
+		if !ok || ipn.IP.IsLinkLocalUnicast() {
+			continue
+		}
+		if v4 := ipn.IP.To4(); v4 != nil {
+			if b.IPv4 == nil {
+				b.IPv4 = v4
+			}
+		} else if b.IPv6 == nil {
+			b.IPv6 = ipn.IP
+		}
+	}
+	if b.IPv4 == nil && b.IPv6 == nil {
+		return nil, fmt.Errorf("interface %q has no usable address", spec)
+	}
+	b.deviceErr = deviceBindCheck(b.Interface)
+	b.Device = deviceBindSupported && b.deviceErr == nil
+	return b, nil
+}
+
+func isLocalAddress(ip net.IP) bool {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return false
+	}
+	for _, a := range addrs {
+		if ipn, ok := a.(*net.IPNet); ok && ipn.IP.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetSourceBinding makes all further connections use b (nil: the system's choice).
+func SetSourceBinding(b *SourceBinding) {
+	bindMu.Lock()
+	defer bindMu.Unlock()
+	sourceBinding = b
+}
+
+func currentBinding() *SourceBinding {
+	bindMu.RLock()
+	defer bindMu.RUnlock()
+	return sourceBinding
+}
+
+// boundDial returns d.DialContext with the binding's local address (and device) applied per
+// dial. The family of the local address follows the network ("tcp4", "udp6") or a literal
+// target IP; for a host name the IPv4 address is used when there is one, which makes the dialer
+// try only the name's IPv4 addresses.
+func boundDial(d *net.Dialer) func(ctx context.Context, network, address string) (net.Conn, error) {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		b := currentBinding()
+		if b == nil {
+			return d.DialContext(ctx, network, address)
+		}
+		dd := *d
+		ip := b.localIPFor(network, address)
+		switch {
+		case ip == nil && !b.Device:
+			// dialing unbound would measure the default route instead of the chosen one
+			return nil, fmt.Errorf("bind %s: no address of the family needed for %s", b.Label(), address)
+		case ip != nil && strings.HasPrefix(network, "udp"):
+			dd.LocalAddr = &net.UDPAddr{IP: ip}
+		case ip != nil:
+			dd.LocalAddr = &net.TCPAddr{IP: ip}
+		}
+		if b.Device {
+			dd.Control = bindToDevice(b.Interface)
+		}
+		return dd.DialContext(ctx, network, address)
+	}
+}
+
+func (b *SourceBinding) localIPFor(network, address string) net.IP {
+	want6 := strings.HasSuffix(network, "6")
+	if !want6 && !strings.HasSuffix(network, "4") {
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			host = address
+		}
+		if ip := net.ParseIP(host); ip != nil {
+			want6 = ip.To4() == nil
+		} else {
+			want6 = b.IPv4 == nil
+		}
+	}
+	if want6 {
+		return b.IPv6
+	}
+	return b.IPv4
+}
+
+// BindableIPs drops the addresses of a family the binding has no address of, so an IPv4-only
+// interface does not turn every AAAA record into a failed line. All of ips are kept when none
+// would remain.
+func BindableIPs(ips []net.IP) []net.IP {
+	b := currentBinding()
+	if b == nil || (b.IPv4 != nil && b.IPv6 != nil) {
+		return ips
+	}
+	out := make([]net.IP, 0, len(ips))
+	for _, ip := range ips {
+		if (ip.To4() != nil) == (b.IPv4 != nil) {
+			out = append(out, ip)
+		}
+	}
+	if len(out) == 0 {
+		return ips
+	}
+	return out
+}
+
+// BoundResolver is the resolver for site names: net.DefaultResolver, or with a binding one whose
+// queries leave through the bound interface (its DNS server may only be reachable there).
+func BoundResolver() *net.Resolver {
+	if currentBinding() == nil {
+		return net.DefaultResolver
+	}
+	return &net.Resolver{PreferGo: true, Dial: resolverDial(&net.Dialer{Timeout: 2 * time.Second})}
+}
+
+// resolverDial is boundDial for DNS servers, except that a loopback server (the systemd-resolved
+// stub at 127.0.0.53, a local dnsmasq) is dialed unbound: SO_BINDTODEVICE or a non-loopback
+// source address cannot reach it. That resolver forwards upstream over its own routes.
+func resolverDial(d *net.Dialer) func(ctx context.Context, network, address string) (net.Conn, error) {
+	bound := boundDial(d)
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		host, _, err := net.SplitHostPort(address)
+		if ip := net.ParseIP(host); err == nil && ip != nil && ip.IsLoopback() {
+			return d.DialContext(ctx, network, address)
+		}
+		return bound(ctx, network, address)
+	}
+}
+
+// controlFunc is the net.Dialer.Control signature.
+type controlFunc = func(network, address string, c syscall.RawConn) error
@@ -0,0 +1,30 @@
+//go:build linux
+
+package monitor
+
+import "syscall"
+
+const deviceBindSupported = true
+
+// deviceBindCheck tries SO_BINDTODEVICE on a throwaway socket; it needs CAP_NET_RAW on older
+// kernels and fails for unprivileged users there.
+func deviceBindCheck(name string) error {
+	fd, err := syscall.Socket(syscall.AF_INET, syscall.SOCK_DGRAM, 0)
+	if err != nil {
+		return err
+	}
+	defer syscall.Close(fd)
+	return syscall.SetsockoptString(fd, syscall.SOL_SOCKET, syscall.SO_BINDTODEVICE, name)
+}
+
+func bindToDevice(name string) controlFunc {
+	return func(_, _ string, c syscall.RawConn) error {
+		var serr error
+		if err := c.Control(func(fd uintptr) {
+			serr = syscall.SetsockoptString(int(fd), syscall.SOL_SOCKET, syscall.SO_BINDTODEVICE, name)
+		}); err != nil {
+			return err
+		}
+		return serr
+	}
+}
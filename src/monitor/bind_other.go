@@ -0,0 +1,10 @@
+//go:build !linux
+
+package monitor
+
+// Non-Linux: connections are bound by source address only.
+const deviceBindSupported = false
+
+func deviceBindCheck(string) error { return nil }
+
+func bindToDevice(string) controlFunc { return nil }
@@ -0,0 +1,81 @@
+package monitor
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestSourceBindingLocalIPFor(t *testing.T) {
+	b := &SourceBinding{Interface: "eth0", IPv4: net.ParseIP("192.0.2.10").To4(), IPv6: net.ParseIP("2001:db8::10")}
+	cases := []struct{ network, addr, want string }{
+		{"tcp", "198.51.100.1:443", "192.0.2.10"},
+		{"tcp", "[2001:db8::1]:443", "2001:db8::10"},
+		{"udp6", "example.com:53", "2001:db8::10"},
+		{"tcp", "example.com:443", "192.0.2.10"},
+	}
+	for _, c := range cases {
+		if got := b.localIPFor(c.network, c.addr); got.String() != c.want {
+			t.Errorf("localIPFor(%s, %s) = %v, want %s", c.network, c.addr, got, c.want)
+		}
+	}
+	v6only := &SourceBinding{IPv6: net.ParseIP("2001:db8::10")}
+	if got := v6only.localIPFor("tcp", "example.com:443"); got.String() != "2001:db8::10" {
+		t.Errorf("a v6-only binding dials names over IPv6, got %v", got)
+	}
+	if v6only.Label() != "2001:db8::10" || b.Label() != "eth0" || b.SourceIP() != "192.0.2.10" {
+		t.Errorf("labels: %q %q %q", v6only.Label(), b.Label(), b.SourceIP())
+	}
+}
+
+func TestBindableIPsAndBoundDial(t *testing.T) {
+	defer SetSourceBinding(nil)
+	ips := []net.IP{net.ParseIP("2001:db8::1"), net.ParseIP("198.51.100.1")}
+	if len(BindableIPs(ips)) != 2 {
+		t.Fatalf("no binding keeps all addresses")
+	}
+	b, err := ResolveSourceBinding("127.0.0.1")
+	if err != nil {
+		t.Fatalf("resolve loopback: %v", err)
+	}
+	SetSourceBinding(b)
+	if got := BindableIPs(ips); len(got) != 1 || got[0].String() != "198.51.100.1" {
+		t.Fatalf("IPv4 binding: %v", got)
+	}
+	if _, err := ResolveSourceBinding("192.0.2.77"); err == nil {
+		t.Fatalf("an address of no interface must be rejected")
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+	go func() {
+		if c, err := ln.Accept(); err == nil {
+			c.Close()
+		}
+	}()
+	c, err := boundDial(&net.Dialer{Timeout: time.Second})(context.Background(), "tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("bound dial: %v", err)
+	}
+	defer c.Close()
+	if ip := c.LocalAddr().(*net.TCPAddr).IP.String(); ip != "127.0.0.1" {
+		t.Fatalf("local address %s", ip)
+	}
+	if _, err := boundDial(&net.Dialer{Timeout: time.Second})(context.Background(), "tcp", "[::1]:9"); err == nil {
+		t.Fatalf("an IPv6 target must not be dialed unbound from an IPv4 binding")
+	}
+	// a loopback resolver is dialed unbound, so even an IPv4 binding reaches one on ::1
+	pc, err := net.ListenPacket("udp6", "[::1]:0")
+	if err != nil {
+		t.Skipf("no IPv6 loopback: %v", err)
+	}
+	defer pc.Close()
+	rc, err := resolverDial(&net.Dialer{Timeout: time.Second})(context.Background(), "udp", pc.LocalAddr().String())
+	if err != nil {
+		t.Fatalf("loopback resolver: %v", err)
+	}
+	rc.Close()
+}
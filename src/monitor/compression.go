@@ -0,0 +1,165 @@
+package monitor
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// ResponseCompression samples the body of the measured GET: its Content-Encoding and the bytes
+// as they crossed the network (encoded) and after decoding. Ratio is decoded over encoded, 1 for
+// an uncompressed body. A proxy that strips gzip or brotli keeps the content intact but inflates
+// the transfer; Uncompressed flags such bodies: a content type that compresses well arriving
+// without encoding although compression was offered.
+type ResponseCompression struct {
+	Encoding     string  `json:"encoding,omitempty"` // lower-cased Content-Encoding, "" for identity
+	ContentType  string  `json:"content_type,omitempty"`
+	EncodedBytes int64   `json:"encoded_bytes"`
+	DecodedBytes int64   `json:"decoded_bytes,omitempty"` // 0 when the encoding cannot be decoded (br, zstd)
+	Ratio        float64 `json:"ratio,omitempty"`
+	Uncompressed bool    `json:"uncompressed_compressible,omitempty"`
+}
+
+// CompressibleMinBytes is the smallest body for which a missing encoding is flagged; servers
+// rightly skip compression for tiny responses.
+const CompressibleMinBytes = 1024
+
+// acceptEncoding is offered on the measured GET (--accept-encoding). Setting it ourselves keeps
+// net/http from decoding gzip transparently, so the encoded size stays visible.
+var acceptEncoding = "gzip, deflate"
+
+// SetAcceptEncoding sets the Accept-Encoding of the measured GET; "identity" asks for
+// uncompressed bodies, "" leaves it to net/http (transparent gzip, nothing sampled).
+func SetAcceptEncoding(v string) { acceptEncoding = strings.TrimSpace(v) }
+
+// applyAcceptEncoding offers acceptEncoding on req unless the site sets its own header; HEAD has
+// no body to compress. It reports whether the body will arrive undecoded.
+func applyAcceptEncoding(req *http.Request) bool {
+	if req.Method == http.MethodHead {
+		return false
+	}
+	if req.Header.Get("Accept-Encoding") != "" {
+		return true
+	}
+	if acceptEncoding == "" {
+		return false
+	}
+	req.Header.Set("Accept-Encoding", acceptEncoding)
+	return true
+}
+
+// compressionOffered reports whether ae asks for any compressed encoding.
+func compressionOffered(ae string) bool {
+	for _, part := range strings.Split(ae, ",") {
+		coding := strings.ToLower(strings.TrimSpace(strings.SplitN(part, ";", 2)[0]))
+		if coding != "" && coding != "identity" && !strings.HasSuffix(strings.TrimSpace(part), "q=0") {
+			return true
+		}
+	}
+	return false
+}
+
+// compressibleType reports whether bodies of the media type usually shrink with gzip.
+func compressibleType(contentType string) bool {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	switch {
+	case strings.HasPrefix(mt, "text/"):
+		return true
+	case strings.HasSuffix(mt, "+json"), strings.HasSuffix(mt, "+xml"):
+		return true
+	}
+	switch mt {
+	case "application/json", "application/javascript", "application/x-javascript", "application/xml",
+		"application/wasm", "application/x-ndjson", "image/svg+xml", "application/manifest+json":
+		return true
+	}
+	return false
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// bodySampler reads a response body undecoded by net/http: it counts the encoded bytes and
+// decodes gzip and deflate itself, passing other encodings through as they are.
+type bodySampler struct {
+	raw     *countingReader
+	decoder io.Reader
+	res     *ResponseCompression
+	offered bool
+}
+
+func newBodySampler(resp *http.Response, offered bool) *bodySampler {
+	enc := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding")))
+	if enc == "identity" {
+		enc = ""
+	}
+	return &bodySampler{
+		raw:     &countingReader{r: resp.Body},
+		res:     &ResponseCompression{Encoding: enc, ContentType: resp.Header.Get("Content-Type")},
+		offered: offered,
+	}
+}
+
+// decodes reports whether Read yields decoded bytes; false means they are the encoded ones.
+func (s *bodySampler) decodes() bool {
+	return s.res.Encoding == "" || s.res.Encoding == "gzip" || s.res.Encoding == "x-gzip" || s.res.Encoding == "deflate"
+}
+
+func (s *bodySampler) Read(p []byte) (int, error) {
+	if s.decoder == nil {
+		switch s.res.Encoding {
+		case "gzip", "x-gzip":
+			zr, err := gzip.NewReader(s.raw)
+			if err != nil {
+				return 0, err
+			}
+			s.decoder = zr
+		case "deflate":
+			// RFC 9110 deflate is zlib-wrapped, but some servers send a raw stream
+			br := bufio.NewReader(s.raw)
+			if h, err := br.Peek(2); err == nil && h[0]&0x0f == 8 && (int(h[0])<<8|int(h[1]))%31 == 0 {
+				zr, err := zlib.NewReader(br)
+				if err != nil {
+					return 0, err
+				}
+				s.decoder = zr
+			} else {
+				s.decoder = flate.NewReader(br)
+			}
+		default:
+			s.decoder = s.raw
+		}
+	}
+	return s.decoder.Read(p)
+}
+
+// result completes the sample after bodyBytes were read through s; nil for an empty body.
+func (s *bodySampler) result(bodyBytes int64) *ResponseCompression {
+	r := s.res
+	r.EncodedBytes = s.raw.n
+	if r.EncodedBytes == 0 {
+		return nil
+	}
+	if s.decodes() {
+		r.DecodedBytes = bodyBytes
+		r.Ratio = float64(r.DecodedBytes) / float64(r.EncodedBytes)
+	}
+	r.Uncompressed = r.Encoding == "" && s.offered && r.EncodedBytes >= CompressibleMinBytes && compressibleType(r.ContentType)
+	return r
+}
@@ -0,0 +1,101 @@
+package monitor
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	typespkg "github.com/iafilius/InternetQualityMonitor/src/types"
+)
+
+func TestMonitorSiteIP_ResponseCompression(t *testing.T) {
+	body := strings.Repeat("compressible text ", 4096)
+	var gz, raw bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write([]byte(body))
+	zw.Close()
+	fw, _ := flate.NewWriter(&raw, flate.BestCompression)
+	fw.Write([]byte(body))
+	fw.Close()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		if r.Header.Get("Range") != "" || !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			w.Write([]byte(body))
+			return
+		}
+		switch r.URL.Path {
+		case "/gzip":
+			w.Header().Set("Content-Encoding", "gzip")
+			w.Write(gz.Bytes())
+		case "/deflate":
+			w.Header().Set("Content-Encoding", "deflate")
+			w.Write(raw.Bytes())
+		default: // a proxy that strips the encoding
+			w.Write([]byte(body))
+		}
+	}))
+	defer srv.Close()
+	u, _ := url.Parse(srv.URL)
+	for _, k := range []string{"HTTP_PROXY", "HTTPS_PROXY", "ALL_PROXY", "NO_PROXY"} {
+		if v, ok := os.LookupEnv(k); ok {
+			t.Setenv(k, v)
+			os.Unsetenv(k)
+		}
+	}
+	defer SetAcceptEncoding("gzip, deflate")
+	run := func(path string) *SiteResult {
+		t.Helper()
+		tmp := t.TempDir() + "/res.jsonl"
+		resultChan = nil
+		resultPath = tmp
+		MonitorSiteIP(typespkg.Site{Name: "enc", URL: srv.URL + path}, u.Hostname(), []string{u.Hostname()}, 0)
+		data, _ := os.ReadFile(tmp)
+		var env ResultEnvelope
+		if err := json.Unmarshal([]byte(strings.TrimSpace(string(data))), &env); err != nil || env.SiteResult == nil {
+			t.Fatalf("decode result: %v", err)
+		}
+		return env.SiteResult
+	}
+	g := run("/gzip")
+	c := g.Compression
+	if c == nil || c.Encoding != "gzip" || c.EncodedBytes != int64(gz.Len()) || c.DecodedBytes != int64(len(body)) || c.Ratio < 10 || c.Uncompressed {
+		t.Fatalf("gzip body: %+v", c)
+	}
+	if g.TransferSizeBytes != int64(len(body)) || g.ContentLengthMismatch || g.HTTPError != "" {
+		t.Fatalf("gzip transfer: size=%d mismatch=%v err=%q", g.TransferSizeBytes, g.ContentLengthMismatch, g.HTTPError)
+	}
+	if c := run("/deflate").Compression; c == nil || c.Encoding != "deflate" || c.DecodedBytes != int64(len(body)) {
+		t.Fatalf("raw deflate body: %+v", c)
+	}
+	if c := run("/stripped").Compression; c == nil || c.Encoding != "" || c.Ratio != 1 || !c.Uncompressed {
+		t.Fatalf("stripped body: %+v", c)
+	}
+	SetAcceptEncoding("identity")
+	if c := run("/gzip").Compression; c == nil || c.Uncompressed {
+		t.Fatalf("uncompressed on request is no stripped encoding: %+v", c)
+	}
+	SetAcceptEncoding("")
+	if c := run("/gzip").Compression; c != nil {
+		t.Fatalf("sampling off: %+v", c)
+	}
+}
+
+func TestCompressionOfferedAndTypes(t *testing.T) {
+	for ae, want := range map[string]bool{"gzip, deflate": true, "identity": false, "": false, "br;q=1.0": true, "gzip;q=0, identity": false} {
+		if got := compressionOffered(ae); got != want {
+			t.Errorf("compressionOffered(%q) = %v", ae, got)
+		}
+	}
+	for ct, want := range map[string]bool{"text/html; charset=utf-8": true, "application/json": true, "application/ld+json": true, "image/svg+xml": true, "image/jpeg": false, "application/octet-stream": false, "": false} {
+		if got := compressibleType(ct); got != want {
+			t.Errorf("compressibleType(%q) = %v", ct, got)
+		}
+	}
+}
@@ -0,0 +1,258 @@
+package monitor
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DNSCacheInfo records whether a site's lookup was answered from the resolver cache. The first
+// lookup of a host (and the first after its TTL ran out) also asks the resolver directly for the
+// answer's TTL; later lookups within that TTL should be cache hits. A lookup within the TTL that
+// still takes longer than the cache-hit limit went upstream again: a resolver that does not
+// cache, evicts early or caps TTLs, the usual cause of sporadic 100 ms+ DNS times.
+type DNSCacheInfo struct {
+	TTLSeconds int64 `json:"ttl_s"` // TTL of the host's answer (smallest of its records) at the last direct query
+	// WithinTTL is set when that answer was still valid at this lookup; TTLLeftSeconds is how much
+	// of it remained.
+	WithinTTL      bool  `json:"within_ttl"`
+	TTLLeftSeconds int64 `json:"ttl_left_s,omitempty"`
+	// CacheHit: within the TTL and answered in at most HitLimitMs; within the TTL without it the
+	// resolver re-queried.
+	CacheHit   bool   `json:"cache_hit,omitempty"`
+	HitLimitMs int64  `json:"hit_limit_ms,omitempty"`
+	QueryMs    int64  `json:"ttl_query_ms,omitempty"` // the direct TTL query
+	Error      string `json:"ttl_error,omitempty"`
+}
+
+// Requeried reports a lookup within the TTL that was not a cache hit.
+func (c *DNSCacheInfo) Requeried() bool { return c != nil && c.WithinTTL && !c.CacheHit }
+
+const dnsCacheQueryTimeout = 2 * time.Second
+
+type dnsCacheEntry struct {
+	ttl     int64
+	expires time.Time
+}
+
+var (
+	dnsCacheMu      sync.Mutex
+	dnsCacheEnabled bool
+	dnsCacheHitMs   int64 = 20
+	dnsCacheSeen          = map[string]dnsCacheEntry{} // by dnsCacheKey
+	dnsTTLQuery           = queryDNSTTL                // replaceable in tests
+)
+
+// SetDNSCacheCheck enables the TTL and cache-hit tracking of site lookups (--dns-cache-check;
+// off by default, as the direct TTL query runs before the site is measured and can add up to
+// dnsCacheQueryTimeout). hitMs is the slowest lookup still counted as answered from cache
+// (--dns-cache-hit-ms, <= 0 keeps the default). A resolver in the LAN or on the host answers
+// from cache in a few ms; one at the ISP needs its round trip, so raise it for those.
+func SetDNSCacheCheck(enabled bool, hitMs int64) {
+	dnsCacheMu.Lock()
+	defer dnsCacheMu.Unlock()
+	dnsCacheEnabled = enabled
+	if hitMs > 0 {
+		dnsCacheHitMs = hitMs
+	}
+}
+
+const ctxDNSCacheKey ctxKey = "dns_cache"
+
+// dnsCacheKey keys a known answer by the resolver and the host: each resolver has its own cache,
+// so a TTL learned from one says nothing about lookups through another.
+func dnsCacheKey(server, host string) string { return server + "|" + strings.ToLower(host) }
+
+// observeDNSCache classifies a finished lookup of host through server (host:port as dialed)
+// that took the given time; nil when the check is off, for IP literals and when no server was
+// dialed (hosts file). The direct TTL query runs only when no valid answer is known.
+func observeDNSCache(ctx context.Context, host, server string, took time.Duration) *DNSCacheInfo {
+	dnsCacheMu.Lock()
+	enabled, hitMs := dnsCacheEnabled, dnsCacheHitMs
+	e, known := dnsCacheSeen[dnsCacheKey(server, host)]
+	dnsCacheMu.Unlock()
+	if !enabled || server == "" || net.ParseIP(host) != nil {
+		return nil
+	}
+	now := time.Now()
+	if known && now.Before(e.expires) {
+		info := &DNSCacheInfo{TTLSeconds: e.ttl, WithinTTL: true, TTLLeftSeconds: int64(e.expires.Sub(now) / time.Second), HitLimitMs: hitMs}
+		info.CacheHit = took.Milliseconds() <= hitMs
+		return info
+	}
+	qctx, cancel := context.WithTimeout(ctx, dnsCacheQueryTimeout)
+	defer cancel()
+	start := time.Now()
+	ttl, err := dnsTTLQuery(qctx, server, host)
+	info := &DNSCacheInfo{TTLSeconds: ttl, QueryMs: time.Since(start).Milliseconds()}
+	if err != nil {
+		info.Error = err.Error()
+		return info
+	}
+	dnsCacheMu.Lock()
+	// drop expired answers, so a long run over changing hosts and resolvers does not grow the map
+	for k, old := range dnsCacheSeen {
+		if !now.Before(old.expires) {
+			delete(dnsCacheSeen, k)
+		}
+	}
+	// TTL 0 forbids caching: nothing to hold later lookups to
+	if ttl > 0 {
+		dnsCacheSeen[dnsCacheKey(server, host)] = dnsCacheEntry{ttl: ttl, expires: now.Add(time.Duration(ttl) * time.Second)}
+	}
+	dnsCacheMu.Unlock()
+	return info
+}
+
+// DNS record types and header bits used by the TTL query.
+const (
+	dnsTypeA     = 1
+	dnsTypeCNAME = 5
+	dnsTypeAAAA  = 28
+	dnsFlagRD    = 0x0100
+	dnsRcodeMask = 0x000f
+)
+
+var errDNSNoAnswer = errors.New("no A/AAAA answer")
+
+// queryDNSTTL asks server over UDP for the A records of host, then AAAA when there are none,
+// and returns the smallest TTL of the answer chain (CNAMEs included: the chain is cached no
+// longer than its shortest link).
+func queryDNSTTL(ctx context.Context, server, host string) (int64, error) {
+	var lastErr error
+	for _, qtype := range []uint16{dnsTypeA, dnsTypeAAAA} {
+		ttl, err := queryDNSTTLType(ctx, server, host, qtype)
+		if err == nil {
+			return ttl, nil
+		}
+		lastErr = err
+		if !errors.Is(err, errDNSNoAnswer) {
+			break
+		}
+	}
+	return 0, lastErr
+}
+
+func queryDNSTTLType(ctx context.Context, server, host string, qtype uint16) (int64, error) {
+	query, id, err := buildDNSQuery(host, qtype)
+	if err != nil {
+		return 0, err
+	}
+	conn, err := resolverDial(&net.Dialer{Timeout: dnsCacheQueryTimeout})(ctx, "udp", server)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+	if dl, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(dl)
+	}
+	if _, err := conn.Write(query); err != nil {
+		return 0, err
+	}
+	buf := make([]byte, 4096)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return 0, err
+		}
+		// skip stray datagrams from earlier queries
+		if n >= 2 && binary.BigEndian.Uint16(buf) == id {
+			return parseDNSAnswerTTL(buf[:n], qtype)
+		}
+	}
+}
+
+// buildDNSQuery encodes a recursive query for host and returns it with its ID.
+func buildDNSQuery(host string, qtype uint16) ([]byte, uint16, error) {
+	var idb [2]byte
+	if _, err := rand.Read(idb[:]); err != nil {
+		return nil, 0, err
+	}
+	id := binary.BigEndian.Uint16(idb[:])
+	msg := make([]byte, 12, 12+len(host)+6)
+	binary.BigEndian.PutUint16(msg[0:], id)
+	binary.BigEndian.PutUint16(msg[2:], dnsFlagRD)
+	binary.BigEndian.PutUint16(msg[4:], 1) // QDCOUNT
+	for _, label := range strings.Split(strings.TrimSuffix(host, "."), ".") {
+		if label == "" || len(label) > 63 {
+			return nil, 0, errors.New("invalid host name " + host)
+		}
+		msg = append(msg, byte(len(label)))
+		msg = append(msg, label...)
+	}
+	msg = append(msg, 0, byte(qtype>>8), byte(qtype), 0, 1) // root, QTYPE, QCLASS IN
+	return msg, id, nil
+}
+
+// parseDNSAnswerTTL returns the smallest TTL over the answer's records of qtype and CNAME;
+// errDNSNoAnswer when there is no record of qtype.
+func parseDNSAnswerTTL(msg []byte, qtype uint16) (int64, error) {
+	errShort := errors.New("short DNS response")
+	if len(msg) < 12 {
+		return 0, errShort
+	}
+	if rc := binary.BigEndian.Uint16(msg[2:]) & dnsRcodeMask; rc != 0 {
+		if rc == 3 {
+			return 0, errors.New("NXDOMAIN")
+		}
+		return 0, fmt.Errorf("DNS rcode %d", rc)
+	}
+	qd, an := int(binary.BigEndian.Uint16(msg[4:])), int(binary.BigEndian.Uint16(msg[6:]))
+	off := 12
+	for i := 0; i < qd; i++ {
+		var ok bool
+		if off, ok = skipDNSName(msg, off); !ok || off+4 > len(msg) {
+			return 0, errShort
+		}
+		off += 4
+	}
+	var minTTL int64 = -1
+	found := false
+	for i := 0; i < an; i++ {
+		var ok bool
+		if off, ok = skipDNSName(msg, off); !ok || off+10 > len(msg) {
+			return 0, errShort
+		}
+		typ := binary.BigEndian.Uint16(msg[off:])
+		ttl := int64(binary.BigEndian.Uint32(msg[off+4:]))
+		rdlen := int(binary.BigEndian.Uint16(msg[off+8:]))
+		off += 10 + rdlen
+		if off > len(msg) {
+			return 0, errShort
+		}
+		if typ != qtype && typ != dnsTypeCNAME {
+			continue
+		}
+		found = found || typ == qtype
+		if minTTL < 0 || ttl < minTTL {
+			minTTL = ttl
+		}
+	}
+	if !found {
+		return 0, errDNSNoAnswer
+	}
+	return minTTL, nil
+}
+
+// skipDNSName returns the offset after the (possibly compressed) name at off.
+func skipDNSName(msg []byte, off int) (int, bool) {
+	for off < len(msg) {
+		l := int(msg[off])
+		switch {
+		case l == 0:
+			return off + 1, true
+		case l&0xc0 == 0xc0:
+			// a pointer ends the name
+			return off + 2, off+2 <= len(msg)
+		default:
+			off += 1 + l
+		}
+	}
+	return 0, false
+}
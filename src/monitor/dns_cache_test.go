@@ -0,0 +1,132 @@
+package monitor
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+// dnsTestResponse answers query with a CNAME (name compressed to the question) and an A record.
+func dnsTestResponse(query []byte, cnameTTL, aTTL uint32) []byte {
+	resp := append([]byte(nil), query...)
+	binary.BigEndian.PutUint16(resp[2:], 0x8180) // response, RD, RA
+	binary.BigEndian.PutUint16(resp[6:], 2)      // ANCOUNT
+	rr := func(typ uint16, ttl uint32, rdata []byte) {
+		resp = append(resp, 0xc0, 12) // pointer to the question name
+		var h [10]byte
+		binary.BigEndian.PutUint16(h[0:], typ)
+		binary.BigEndian.PutUint16(h[2:], 1)
+		binary.BigEndian.PutUint32(h[4:], ttl)
+		binary.BigEndian.PutUint16(h[8:], uint16(len(rdata)))
+		resp = append(append(resp, h[:]...), rdata...)
+	}
+	rr(dnsTypeCNAME, cnameTTL, []byte{0xc0, 12})
+	rr(dnsTypeA, aTTL, []byte{192, 0, 2, 1})
+	return resp
+}
+
+func TestQueryDNSTTL_SmallestTTLOfChain(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("udp listen: %v", err)
+	}
+	defer pc.Close()
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if binary.BigEndian.Uint16(buf[n-4:]) != dnsTypeA {
+				continue
+			}
+			pc.WriteTo(dnsTestResponse(buf[:n], 300, 42), addr)
+		}
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	ttl, err := queryDNSTTL(ctx, pc.LocalAddr().String(), "www.example.com")
+	if err != nil || ttl != 42 {
+		t.Fatalf("ttl=%d err=%v want 42", ttl, err)
+	}
+}
+
+func TestParseDNSAnswerTTL(t *testing.T) {
+	q, _, err := buildDNSQuery("example.com.", dnsTypeAAAA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// an A answer to an AAAA question has no record of the asked type
+	if _, err := parseDNSAnswerTTL(dnsTestResponse(q, 60, 60), dnsTypeAAAA); !errors.Is(err, errDNSNoAnswer) {
+		t.Fatalf("err=%v want no answer", err)
+	}
+	nx := append([]byte(nil), q...)
+	binary.BigEndian.PutUint16(nx[2:], 0x8183)
+	if _, err := parseDNSAnswerTTL(nx, dnsTypeAAAA); err == nil || err.Error() != "NXDOMAIN" {
+		t.Fatalf("err=%v want NXDOMAIN", err)
+	}
+	if _, err := parseDNSAnswerTTL(dnsTestResponse(q, 60, 60)[:30], dnsTypeA); err == nil {
+		t.Fatalf("truncated response parsed")
+	}
+	if _, _, err := buildDNSQuery("a..com", dnsTypeA); err == nil {
+		t.Fatalf("empty label accepted")
+	}
+}
+
+func TestObserveDNSCache_HitsAndRequeries(t *testing.T) {
+	prev := dnsTTLQuery
+	defer func() {
+		dnsTTLQuery = prev
+		SetDNSCacheCheck(false, 20)
+		dnsCacheSeen = map[string]dnsCacheEntry{}
+	}()
+	queries := 0
+	dnsTTLQuery = func(ctx context.Context, server, host string) (int64, error) {
+		queries++
+		if host == "nocache.example" {
+			return 0, nil
+		}
+		return 300, nil
+	}
+	if observeDNSCache(context.Background(), "a.example", "127.0.0.53:53", time.Millisecond) != nil {
+		t.Fatalf("the check is off by default")
+	}
+	SetDNSCacheCheck(true, 20)
+	first := observeDNSCache(context.Background(), "a.example", "127.0.0.53:53", 80*time.Millisecond)
+	if first == nil || first.WithinTTL || first.TTLSeconds != 300 || queries != 1 {
+		t.Fatalf("first lookup: %+v queries=%d", first, queries)
+	}
+	hit := observeDNSCache(context.Background(), "a.example", "127.0.0.53:53", 2*time.Millisecond)
+	if !hit.WithinTTL || !hit.CacheHit || hit.Requeried() || hit.TTLLeftSeconds < 299 || queries != 1 {
+		t.Fatalf("cached lookup: %+v queries=%d", hit, queries)
+	}
+	slow := observeDNSCache(context.Background(), "a.example", "127.0.0.53:53", 150*time.Millisecond)
+	if !slow.Requeried() || slow.HitLimitMs != 20 {
+		t.Fatalf("slow lookup within the TTL: %+v", slow)
+	}
+	// TTL 0 answers may not be cached, so every lookup asks again
+	observeDNSCache(context.Background(), "nocache.example", "127.0.0.53:53", time.Millisecond)
+	if c := observeDNSCache(context.Background(), "nocache.example", "127.0.0.53:53", time.Millisecond); c.WithinTTL || queries != 3 {
+		t.Fatalf("ttl 0: %+v queries=%d", c, queries)
+	}
+	// another resolver has its own cache: its first lookup asks for the TTL again
+	if c := observeDNSCache(context.Background(), "a.example", "192.0.2.53:53", 2*time.Millisecond); c.WithinTTL || queries != 4 {
+		t.Fatalf("second resolver: %+v queries=%d", c, queries)
+	}
+	// expired answers are dropped on the next insert
+	dnsCacheMu.Lock()
+	dnsCacheSeen[dnsCacheKey("127.0.0.53:53", "old.example")] = dnsCacheEntry{ttl: 1, expires: time.Now().Add(-time.Second)}
+	dnsCacheMu.Unlock()
+	observeDNSCache(context.Background(), "c.example", "127.0.0.53:53", time.Millisecond)
+	if _, ok := dnsCacheSeen[dnsCacheKey("127.0.0.53:53", "old.example")]; ok || len(dnsCacheSeen) != 3 {
+		t.Fatalf("expired entry kept: %v", dnsCacheSeen)
+	}
+	if observeDNSCache(context.Background(), "192.0.2.1", "127.0.0.53:53", time.Millisecond) != nil ||
+		observeDNSCache(context.Background(), "b.example", "", time.Millisecond) != nil {
+		t.Fatalf("IP literals and hosts-file answers are not classified")
+	}
+}
@@ -0,0 +1,65 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+)
+
+// DNSFamilyTiming records the A (IPv4) and AAAA (IPv6) lookups of a site's host timed
+// separately. The combined lookup only shows the slower of the two, so a resolver that
+// stalls on AAAA silently inflates setup time for every client ("broken IPv6 DNS").
+type DNSFamilyTiming struct {
+	AMs       int64  `json:"a_ms"`
+	ACount    int    `json:"a_count,omitempty"`
+	AError    string `json:"a_error,omitempty"`
+	AAAAMs    int64  `json:"aaaa_ms"`
+	AAAACount int    `json:"aaaa_count,omitempty"`
+	AAAAError string `json:"aaaa_error,omitempty"` // an empty answer (no AAAA records) is not an error
+}
+
+var dnsFamilyTimingEnabled = true
+
+// SetDNSFamilyTiming enables the separately timed A/AAAA lookups (default on).
+func SetDNSFamilyTiming(enabled bool) { dnsFamilyTimingEnabled = enabled }
+
+const ctxDNSFamilyKey ctxKey = "dns_family"
+
+// lookupFamilies resolves host once per family in parallel; nil for IP literals. It uses its
+// own resolver so the site's combined lookup (and the DNS server it records) is unaffected.
+func lookupFamilies(ctx context.Context, host string) *DNSFamilyTiming {
+	if net.ParseIP(host) != nil {
+		return nil
+	}
+	r := &net.Resolver{PreferGo: true}
+	one := func(network string) (time.Duration, int, string) {
+		start := time.Now()
+		ips, err := r.LookupIP(ctx, network, host)
+		d := time.Since(start)
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			return d, 0, "" // NXDOMAIN/NODATA for this family: answered, just empty
+		}
+		if err != nil {
+			return d, 0, err.Error()
+		}
+		return d, len(ips), ""
+	}
+	var ft DNSFamilyTiming
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		d, n, e := one("ip4")
+		ft.AMs, ft.ACount, ft.AError = d.Milliseconds(), n, e
+	}()
+	go func() {
+		defer wg.Done()
+		d, n, e := one("ip6")
+		ft.AAAAMs, ft.AAAACount, ft.AAAAError = d.Milliseconds(), n, e
+	}()
+	wg.Wait()
+	return &ft
+}
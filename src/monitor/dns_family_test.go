@@ -0,0 +1,20 @@
+package monitor
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLookupFamilies(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if ft := lookupFamilies(ctx, "192.0.2.1"); ft != nil {
+		t.Fatalf("IP literal must not be looked up: %+v", ft)
+	}
+	// localhost resolves from the hosts file without network access
+	ft := lookupFamilies(ctx, "localhost")
+	if ft == nil || ft.AError != "" || ft.ACount < 1 {
+		t.Fatalf("localhost A lookup: %+v", ft)
+	}
+}
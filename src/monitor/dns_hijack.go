@@ -0,0 +1,174 @@
+package monitor
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DNSHijackInfo is the per-batch result of resolving names that cannot exist. A resolver that
+// follows the protocol returns NXDOMAIN for all of them; one that answers with addresses rewrites
+// NXDOMAIN (ISP "search assist" pages, captive portals, some filtering resolvers), which also skews
+// DNS timings and breaks the expectation that a typo fails. Suspected is set when any name got an
+// answer; lookups that failed otherwise (timeout, SERVFAIL) are inconclusive and counted in Errors.
+type DNSHijackInfo struct {
+	Suspected bool   `json:"suspected"`
+	Resolver  string `json:"resolver,omitempty"` // DNS server the probe dialed
+	Checked   int    `json:"checked"`
+	NXDomain  int    `json:"nxdomain"`
+	Answered  int    `json:"answered,omitempty"`
+	Errors    int    `json:"errors,omitempty"`
+	// Answers are the distinct addresses returned for the nonexistent names (the rewrite target).
+	Answers []string         `json:"answers,omitempty"`
+	Probes  []DNSHijackProbe `json:"probes,omitempty"`
+}
+
+// DNSHijackProbe is the lookup of one nonexistent name.
+type DNSHijackProbe struct {
+	Name    string   `json:"name"`
+	Outcome string   `json:"outcome"` // nxdomain, answered or error
+	Answers []string `json:"answers,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// Outcomes of a DNSHijackProbe.
+const (
+	DNSHijackNXDomain = "nxdomain"
+	DNSHijackAnswered = "answered"
+	DNSHijackError    = "error"
+)
+
+// dnsHijackTLDs get one random label each: rewriting resolvers act on names under real TLDs, so
+// RFC 6761 names such as .invalid (often answered locally by the stub resolver) would miss them.
+var dnsHijackTLDs = []string{"com", "net", "org"}
+
+const dnsHijackTimeout = 3 * time.Second
+
+var (
+	dnsHijackMu      sync.Mutex
+	dnsHijackEnabled bool
+	dnsHijackProbed  bool
+	dnsHijackRunTag  string
+	dnsHijackCached  *DNSHijackInfo
+	dnsHijackProbe   = probeDNSHijack // replaceable in tests
+)
+
+// SetDNSHijackCheck enables the once-per-batch NXDOMAIN check (--dns-hijack-check).
+func SetDNSHijackCheck(enabled bool) {
+	dnsHijackMu.Lock()
+	defer dnsHijackMu.Unlock()
+	dnsHijackEnabled = enabled
+}
+
+// dnsHijackInfoForRun returns the check result captured once per run tag (batch), nil when the
+// check is off.
+func dnsHijackInfoForRun(tag string) *DNSHijackInfo {
+	dnsHijackMu.Lock()
+	defer dnsHijackMu.Unlock()
+	if !dnsHijackEnabled {
+		return nil
+	}
+	if dnsHijackProbed && dnsHijackRunTag == tag {
+		return dnsHijackCached
+	}
+	dnsHijackProbed = true
+	dnsHijackRunTag = tag
+	dnsHijackCached = dnsHijackProbe()
+	return dnsHijackCached
+}
+
+// probeDNSHijack resolves one random name per dnsHijackTLDs in parallel through the resolver the
+// sites use.
+func probeDNSHijack() *DNSHijackInfo {
+	ctx, cancel := context.WithTimeout(context.Background(), dnsHijackTimeout)
+	defer cancel()
+	var mu sync.Mutex
+	var server string
+	resolver := &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			mu.Lock()
+			server = address
+			mu.Unlock()
+			return resolverDial(&net.Dialer{Timeout: 2 * time.Second})(ctx, network, address)
+		},
+	}
+	probes := make([]DNSHijackProbe, len(dnsHijackTLDs))
+	var wg sync.WaitGroup
+	for i, tld := range dnsHijackTLDs {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			addrs, err := resolver.LookupHost(ctx, name)
+			probes[i] = classifyNXProbe(name, addrs, err)
+		}(i, randomDNSLabel()+"."+tld+".") // rooted, so no search domain is appended
+	}
+	wg.Wait()
+	info := summarizeDNSHijack(probes)
+	info.Resolver = server
+	if info.Suspected {
+		Warnf("[dns-hijack] resolver %s answered %d of %d nonexistent name(s) with %v (NXDOMAIN rewriting?)", server, info.Answered, info.Checked, info.Answers)
+	}
+	return info
+}
+
+// randomDNSLabel is a 24-character label nobody registers.
+func randomDNSLabel() string {
+	b := make([]byte, 10)
+	if _, err := rand.Read(b); err != nil {
+		// never expected; the clock still makes the label unique enough
+		return "iqm-nx-" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return "iqm-nx-" + hex.EncodeToString(b)[:17]
+}
+
+// classifyNXProbe turns one lookup result into a probe outcome.
+func classifyNXProbe(name string, addrs []string, err error) DNSHijackProbe {
+	p := DNSHijackProbe{Name: name}
+	var de *net.DNSError
+	switch {
+	case err == nil && len(addrs) > 0:
+		p.Outcome = DNSHijackAnswered
+		p.Answers = addrs
+	case errors.As(err, &de) && de.IsNotFound:
+		p.Outcome = DNSHijackNXDomain
+	case err == nil:
+		// an empty answer is NODATA, not a rewrite
+		p.Outcome = DNSHijackNXDomain
+	default:
+		p.Outcome = DNSHijackError
+		p.Error = err.Error()
+	}
+	return p
+}
+
+// summarizeDNSHijack counts the outcomes and collects the distinct rewrite addresses.
+func summarizeDNSHijack(probes []DNSHijackProbe) *DNSHijackInfo {
+	info := &DNSHijackInfo{Checked: len(probes), Probes: probes}
+	seen := map[string]bool{}
+	for _, p := range probes {
+		switch p.Outcome {
+		case DNSHijackNXDomain:
+			info.NXDomain++
+		case DNSHijackAnswered:
+			info.Answered++
+			for _, a := range p.Answers {
+				if !seen[a] {
+					seen[a] = true
+					info.Answers = append(info.Answers, a)
+				}
+			}
+		default:
+			info.Errors++
+		}
+	}
+	sort.Strings(info.Answers)
+	info.Suspected = info.Answered > 0
+	return info
+}
@@ -0,0 +1,52 @@
+package monitor
+
+import (
+	"errors"
+	"net"
+	"testing"
+)
+
+func TestSummarizeDNSHijack(t *testing.T) {
+	probes := []DNSHijackProbe{
+		classifyNXProbe("a.com.", nil, &net.DNSError{Err: "no such host", Name: "a.com.", IsNotFound: true}),
+		classifyNXProbe("b.net.", []string{"92.242.132.24"}, nil),
+		classifyNXProbe("c.org.", nil, errors.New("i/o timeout")),
+	}
+	if probes[0].Outcome != DNSHijackNXDomain || probes[1].Outcome != DNSHijackAnswered || probes[2].Outcome != DNSHijackError {
+		t.Fatalf("outcomes: %+v", probes)
+	}
+	info := summarizeDNSHijack(probes)
+	if !info.Suspected || info.Checked != 3 || info.NXDomain != 1 || info.Answered != 1 || info.Errors != 1 {
+		t.Fatalf("summary: %+v", info)
+	}
+	if len(info.Answers) != 1 || info.Answers[0] != "92.242.132.24" {
+		t.Fatalf("answers: %v", info.Answers)
+	}
+	clean := summarizeDNSHijack([]DNSHijackProbe{probes[0], probes[2]})
+	if clean.Suspected {
+		t.Fatalf("NXDOMAIN and timeouts are not a hijack: %+v", clean)
+	}
+}
+
+func TestDNSHijackInfoForRun_OncePerBatchWhenEnabled(t *testing.T) {
+	prev := dnsHijackProbe
+	defer func() {
+		dnsHijackProbe = prev
+		SetDNSHijackCheck(false)
+		dnsHijackProbed, dnsHijackCached, dnsHijackRunTag = false, nil, ""
+	}()
+	calls := 0
+	dnsHijackProbe = func() *DNSHijackInfo { calls++; return &DNSHijackInfo{Checked: 3, NXDomain: 3} }
+	if dnsHijackInfoForRun("r1") != nil || calls != 0 {
+		t.Fatalf("the check is off by default")
+	}
+	SetDNSHijackCheck(true)
+	dnsHijackInfoForRun("r1")
+	dnsHijackInfoForRun("r1")
+	if calls != 1 {
+		t.Fatalf("calls=%d want 1", calls)
+	}
+	if info := dnsHijackInfoForRun("r2"); calls != 2 || info == nil || info.Suspected {
+		t.Fatalf("calls=%d info=%+v after new batch", calls, info)
+	}
+}
@@ -0,0 +1,72 @@
+package monitor
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/iafilius/InternetQualityMonitor/src/types"
+)
+
+// DNSMeasurer times the resolution of the site's host only, one line per site: dns_time_ms,
+// dns_ips, the resolver dialed, with --dns-family-timing the separate A/AAAA queries and with
+// --dns-cache-check the answer TTL and whether the resolver answered from cache. A
+// failed lookup is recorded as probe_error.
+type DNSMeasurer struct{}
+
+func (DNSMeasurer) ProbeType() string { return ProbeDNS }
+
+func (DNSMeasurer) MeasureSite(ctx context.Context, site types.Site) {
+	sr := &SiteResult{Name: site.Name, URL: site.URL, CountryConfigured: site.Country, Group: SiteGroup(site), ProbeType: ProbeDNS, started: time.Now(), usage: &wireUsage{}}
+	host, err := siteHost(site)
+	if err != nil {
+		sr.ProbeError = err.Error()
+		WriteSiteResult(sr)
+		return
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, dnsTimeoutDefault)
+		defer cancel()
+	}
+	resolver := &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			sr.DNSServerNetwork = network
+			sr.DNSServer = address
+			return sr.usage.dial(resolverDial(&net.Dialer{Timeout: 2 * time.Second}))(ctx, network, address)
+		},
+	}
+	familyDone := make(chan struct{})
+	if dnsFamilyTimingEnabled {
+		go func() {
+			defer close(familyDone)
+			sr.DNSFamily = lookupFamilies(ctx, host)
+		}()
+	} else {
+		close(familyDone)
+	}
+	start := time.Now()
+	addrs, err := resolver.LookupIPAddr(ctx, host)
+	sr.DNSTimeMs = time.Since(start).Milliseconds()
+	<-familyDone
+	for _, a := range addrs {
+		sr.DNSIPs = append(sr.DNSIPs, a.IP.String())
+	}
+	if err != nil {
+		sr.ProbeError = err.Error()
+		Warnf("[%s] dns probe: %v", site.Name, err)
+	} else {
+		sr.DNSCache = observeDNSCache(ctx, host, sr.DNSServer, time.Duration(sr.DNSTimeMs)*time.Millisecond)
+		Infof("[%s] dns %dms %d address(es)", site.Name, sr.DNSTimeMs, len(sr.DNSIPs))
+	}
+	WriteSiteResult(sr)
+}
+
+// MeasureSiteIP runs the lookup once per site: only for the first fanned-out address.
+func (d DNSMeasurer) MeasureSiteIP(ctx context.Context, site types.Site, ip net.IP, dnsIPs []string, dnsTime time.Duration) {
+	if len(dnsIPs) > 0 && dnsIPs[0] != ip.String() {
+		return
+	}
+	d.MeasureSite(ctx, site)
+}
@@ -0,0 +1,225 @@
+package monitor
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HappyEyeballs records a dual-stack connection race (RFC 8305 style) to the site's origin:
+// IPv6 is dialed first and IPv4 joins after FallbackDelayMs (or as soon as IPv6 fails).
+// It explains family delta oddities: a broken or slow IPv6 path is masked by the race in
+// browsers, while IPv6-pinned measurements show it in full.
+type HappyEyeballs struct {
+	Winner          string `json:"winner,omitempty"` // ipv6, ipv4, or none when both attempts failed
+	WinnerIP        string `json:"winner_ip,omitempty"`
+	WinnerConnectMs int64  `json:"winner_connect_ms,omitempty"` // race start to winning connect (includes the fallback delay when IPv4 won)
+	IPv6Attempted   bool   `json:"ipv6_attempted,omitempty"`
+	IPv4Attempted   bool   `json:"ipv4_attempted,omitempty"`
+	FallbackDelayMs int64  `json:"fallback_delay_ms,omitempty"`
+	LoserFamily     string `json:"loser_family,omitempty"`
+	LoserConnectMs  int64  `json:"loser_connect_ms,omitempty"` // losing attempt from its own start to connect, failure, or abort
+	LoserOutcome    string `json:"loser_outcome,omitempty"`    // connected_later, failed, aborted, not_started
+	LoserError      string `json:"loser_error,omitempty"`
+}
+
+// IPv6Lost reports whether IPv6 was tried but did not win the race.
+func (h *HappyEyeballs) IPv6Lost() bool {
+	return h != nil && h.IPv6Attempted && h.Winner != "ipv6"
+}
+
+const (
+	defaultHappyEyeballsDelay = 300 * time.Millisecond // same as net.Dialer's default FallbackDelay
+	happyEyeballsDialTimeout  = 3 * time.Second
+	// happyEyeballsLoserGrace bounds how long we keep the losing attempt running after the
+	// winner connected, so its own connect time can be recorded.
+	happyEyeballsLoserGrace = 1 * time.Second
+)
+
+var (
+	happyEyeballsEnabled = true
+	happyEyeballsDelay   = defaultHappyEyeballsDelay
+
+	heMu     sync.Mutex
+	heRunTag string
+	heRaces  map[string]*heRace
+)
+
+type heRace struct {
+	done chan struct{}
+	res  *HappyEyeballs
+}
+
+// SetHappyEyeballs enables or disables the per-site dual-stack race measurement.
+func SetHappyEyeballs(enabled bool) { happyEyeballsEnabled = enabled }
+
+// SetHappyEyeballsDelay sets the IPv4 fallback delay used in the race (default 300ms).
+func SetHappyEyeballsDelay(d time.Duration) {
+	if d > 0 {
+		happyEyeballsDelay = d
+	}
+}
+
+// happyEyeballsForSite runs the race once per site, port and batch; concurrent per-IP workers of
+// the same site share the result. Returns nil when disabled or the site is not dual-stack.
+func happyEyeballsForSite(ctx context.Context, host, port string, dnsIPs []string) *HappyEyeballs {
+	if !happyEyeballsEnabled {
+		return nil
+	}
+	v6, v4 := firstIPPerFamily(dnsIPs)
+	if v6 == "" || v4 == "" {
+		return nil
+	}
+	key := strings.ToLower(host) + "|" + port
+	heMu.Lock()
+	if heRaces == nil || heRunTag != runTag {
+		heRaces = map[string]*heRace{}
+		heRunTag = runTag
+	}
+	if r, ok := heRaces[key]; ok {
+		heMu.Unlock()
+		select {
+		case <-r.done:
+			return r.res
+		case <-ctx.Done():
+			return nil
+		}
+	}
+	r := &heRace{done: make(chan struct{})}
+	heRaces[key] = r
+	heMu.Unlock()
+	r.res = raceHappyEyeballs(ctx, boundDial(&net.Dialer{Timeout: happyEyeballsDialTimeout}), v6, v4, port, happyEyeballsDelay)
+	close(r.done)
+	if r.res != nil {
+		Debugf("[%s] happy-eyeballs winner=%s in %dms (loser %s %s after %dms)", host, r.res.Winner, r.res.WinnerConnectMs, r.res.LoserFamily, r.res.LoserOutcome, r.res.LoserConnectMs)
+	}
+	return r.res
+}
+
+func firstIPPerFamily(ips []string) (v6, v4 string) {
+	for _, s := range ips {
+		ip := net.ParseIP(s)
+		if ip == nil {
+			continue
+		}
+		if ip.To4() != nil {
+			if v4 == "" {
+				v4 = s
+			}
+		} else if v6 == "" {
+			v6 = s
+		}
+	}
+	return v6, v4
+}
+
+type dialFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+type heAttempt struct {
+	family  string
+	ip      string
+	started time.Time
+	elapsed time.Duration
+	err     error
+}
+
+// raceHappyEyeballs dials v6 first and v4 after delay (or on IPv6 failure); the first connect wins.
+func raceHappyEyeballs(ctx context.Context, dial dialFunc, v6, v4, port string, delay time.Duration) *HappyEyeballs {
+	res := &HappyEyeballs{FallbackDelayMs: delay.Milliseconds()}
+	raceCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	start := time.Now()
+	results := make(chan heAttempt, 2)
+	launch := func(family, ip string) {
+		a := heAttempt{family: family, ip: ip, started: time.Now()}
+		go func() {
+			c, err := dial(raceCtx, "tcp", net.JoinHostPort(ip, port))
+			a.elapsed = time.Since(a.started)
+			a.err = err
+			if c != nil {
+				c.Close()
+			}
+			results <- a
+		}()
+	}
+	launch("ipv6", v6)
+	res.IPv6Attempted = true
+	v4Started := time.Time{}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	pending := 1
+	var failures []heAttempt
+	startV4 := func() {
+		if v4Started.IsZero() {
+			v4Started = time.Now()
+			launch("ipv4", v4)
+			res.IPv4Attempted = true
+			pending++
+		}
+	}
+	var winner *heAttempt
+	for winner == nil && pending > 0 {
+		select {
+		case <-timer.C:
+			startV4()
+		case a := <-results:
+			pending--
+			if a.err == nil {
+				winner = &a
+				continue
+			}
+			failures = append(failures, a)
+			startV4() // IPv6 failed fast: fall back immediately
+		case <-ctx.Done():
+			res.Winner = "none"
+			return res
+		}
+	}
+	if winner == nil {
+		res.Winner = "none"
+		for _, f := range failures {
+			if f.family == "ipv6" {
+				res.LoserFamily, res.LoserOutcome = "ipv6", "failed"
+				res.LoserConnectMs, res.LoserError = f.elapsed.Milliseconds(), f.err.Error()
+			}
+		}
+		return res
+	}
+	res.Winner, res.WinnerIP = winner.family, winner.ip
+	res.WinnerConnectMs = time.Since(start).Milliseconds()
+	res.LoserFamily = "ipv4"
+	if winner.family == "ipv4" {
+		res.LoserFamily = "ipv6"
+	}
+	for _, f := range failures {
+		if f.family == res.LoserFamily {
+			res.LoserOutcome, res.LoserConnectMs, res.LoserError = "failed", f.elapsed.Milliseconds(), f.err.Error()
+			return res
+		}
+	}
+	if res.LoserFamily == "ipv4" && v4Started.IsZero() {
+		res.LoserOutcome = "not_started"
+		return res
+	}
+	// Loser still dialing: give it a short grace period to learn its own connect time.
+	grace := time.NewTimer(happyEyeballsLoserGrace)
+	defer grace.Stop()
+	loserStart := start
+	if res.LoserFamily == "ipv4" {
+		loserStart = v4Started
+	}
+	select {
+	case a := <-results:
+		res.LoserConnectMs = a.elapsed.Milliseconds()
+		if a.err == nil {
+			res.LoserOutcome = "connected_later"
+		} else {
+			res.LoserOutcome, res.LoserError = "failed", a.err.Error()
+		}
+	case <-grace.C:
+		res.LoserOutcome, res.LoserConnectMs = "aborted", time.Since(loserStart).Milliseconds()
+	}
+	return res
+}
@@ -0,0 +1,69 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeDial returns a dialer whose per-family behaviour is scripted: a delay before success, or an error.
+func fakeDial(delays map[string]time.Duration, fail map[string]bool) dialFunc {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		fam := "ipv4"
+		if strings.HasPrefix(address, "[") {
+			fam = "ipv6"
+		}
+		select {
+		case <-time.After(delays[fam]):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if fail[fam] {
+			return nil, errors.New("connect: network is unreachable")
+		}
+		c1, c2 := net.Pipe()
+		c2.Close()
+		return c1, nil
+	}
+}
+
+func TestRaceHappyEyeballs_IPv6Wins(t *testing.T) {
+	res := raceHappyEyeballs(context.Background(), fakeDial(map[string]time.Duration{"ipv6": 5 * time.Millisecond}, nil), "2001:db8::1", "192.0.2.1", "443", 50*time.Millisecond)
+	if res.Winner != "ipv6" || res.IPv4Attempted || res.LoserOutcome != "not_started" || res.IPv6Lost() {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+}
+
+func TestRaceHappyEyeballs_IPv6FailsFast(t *testing.T) {
+	res := raceHappyEyeballs(context.Background(), fakeDial(nil, map[string]bool{"ipv6": true}), "2001:db8::1", "192.0.2.1", "443", time.Second)
+	if res.Winner != "ipv4" || res.LoserFamily != "ipv6" || res.LoserOutcome != "failed" || res.LoserError == "" || !res.IPv6Lost() {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	// Fallback must not wait for the full delay when IPv6 fails immediately.
+	if res.WinnerConnectMs >= 500 {
+		t.Fatalf("expected immediate fallback, winner after %dms", res.WinnerConnectMs)
+	}
+}
+
+func TestRaceHappyEyeballs_SlowIPv6LosesAfterDelay(t *testing.T) {
+	delays := map[string]time.Duration{"ipv6": 120 * time.Millisecond, "ipv4": 5 * time.Millisecond}
+	res := raceHappyEyeballs(context.Background(), fakeDial(delays, nil), "2001:db8::1", "192.0.2.1", "443", 30*time.Millisecond)
+	if res.Winner != "ipv4" || !res.IPv4Attempted || !res.IPv6Attempted {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	if res.WinnerConnectMs < 30 {
+		t.Fatalf("IPv4 should only start after the fallback delay; won after %dms", res.WinnerConnectMs)
+	}
+	if res.LoserOutcome != "connected_later" || res.LoserConnectMs < 100 {
+		t.Fatalf("expected the IPv6 attempt to complete later (~120ms), got %s after %dms", res.LoserOutcome, res.LoserConnectMs)
+	}
+}
+
+func TestHappyEyeballsForSite_SkipsSingleFamily(t *testing.T) {
+	if he := happyEyeballsForSite(context.Background(), "example.com", "443", []string{"192.0.2.1", "192.0.2.2"}); he != nil {
+		t.Fatalf("expected nil for IPv4-only site, got %+v", he)
+	}
+}
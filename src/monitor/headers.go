@@ -0,0 +1,59 @@
+package monitor
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// DefaultCaptureHeaders is the set --capture-headers=default stores: the headers that identify
+// the server, CDN and cache layer answering a request.
+var DefaultCaptureHeaders = []string{"Server", "Via", "X-Cache", "CF-Ray", "Age"}
+
+var (
+	captureHeadersMu sync.RWMutex
+	captureHeaders   []string
+)
+
+// SetCaptureHeaders sets the response headers stored per line in response_headers (names are
+// case-insensitive; "default" expands to DefaultCaptureHeaders). An empty list disables capture.
+func SetCaptureHeaders(names []string) {
+	captureHeadersMu.Lock()
+	defer captureHeadersMu.Unlock()
+	captureHeaders = captureHeaders[:0]
+	seen := map[string]bool{}
+	add := func(n string) {
+		if n = http.CanonicalHeaderKey(strings.TrimSpace(n)); n != "" && !seen[n] {
+			seen[n] = true
+			captureHeaders = append(captureHeaders, n)
+		}
+	}
+	for _, n := range names {
+		if strings.EqualFold(strings.TrimSpace(n), "default") {
+			for _, d := range DefaultCaptureHeaders {
+				add(d)
+			}
+			continue
+		}
+		add(n)
+	}
+}
+
+// captureResponseHeaders returns the configured headers present in h (repeated values joined
+// with ", "), nil when capture is off or none were sent.
+func captureResponseHeaders(h http.Header) map[string]string {
+	captureHeadersMu.RLock()
+	defer captureHeadersMu.RUnlock()
+	var out map[string]string
+	for _, n := range captureHeaders {
+		vs := h.Values(n)
+		if len(vs) == 0 {
+			continue
+		}
+		if out == nil {
+			out = map[string]string{}
+		}
+		out[n] = strings.Join(vs, ", ")
+	}
+	return out
+}
@@ -0,0 +1,24 @@
+package monitor
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestCaptureResponseHeaders(t *testing.T) {
+	defer SetCaptureHeaders(nil)
+	h := http.Header{}
+	h.Set("Server", "nginx")
+	h.Add("Via", "1.1 varnish")
+	h.Add("Via", "1.1 edge")
+	h.Set("Cf-Ray", "8a1b2c3d4e5f6071-AMS")
+	h.Set("X-Custom", "v")
+	if got := captureResponseHeaders(h); got != nil {
+		t.Fatalf("capture off: %v", got)
+	}
+	SetCaptureHeaders([]string{"default", " x-custom ", "SERVER"})
+	got := captureResponseHeaders(h)
+	if len(got) != 4 || got["Server"] != "nginx" || got["Via"] != "1.1 varnish, 1.1 edge" || got["Cf-Ray"] == "" || got["X-Custom"] != "v" {
+		t.Fatalf("captured: %v", got)
+	}
+}
@@ -0,0 +1,195 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/iafilius/InternetQualityMonitor/src/types"
+)
+
+// Target health pre-check (--health-check): before a batch every target gets one HEAD request.
+// A target that is hard down — its name does not exist (NXDOMAIN) or its server refuses the
+// connection — says nothing about the network, yet would count as a failed line in every error
+// rate. In exclude mode such targets are left out of the batch and listed in meta.health_check
+// as excluded_unhealthy; in report mode they are only listed. Anything softer (timeouts, TLS
+// errors, any HTTP status) keeps the target in: that is what the measurements are for.
+
+// Health check modes.
+const (
+	HealthCheckOff     = "off"
+	HealthCheckReport  = "report"
+	HealthCheckExclude = "exclude"
+)
+
+// Reasons a target is hard down, and the status of a target left out of the batch.
+const (
+	HealthNXDomain          = "nxdomain"
+	HealthRefused           = "refused"
+	HealthExcludedUnhealthy = "excluded_unhealthy"
+)
+
+// HealthCheckInfo is meta.health_check.
+type HealthCheckInfo struct {
+	Mode       string `json:"mode"`
+	Checked    int    `json:"checked"`
+	Unhealthy  int    `json:"unhealthy"`
+	Excluded   int    `json:"excluded"`
+	DurationMs int64  `json:"duration_ms"`
+	// AllUnhealthy: every target was down, so none was excluded — a batch without targets would
+	// hide what is most likely a local outage.
+	AllUnhealthy bool                `json:"all_unhealthy,omitempty"`
+	Targets      []HealthCheckTarget `json:"targets,omitempty"` // the unhealthy targets only
+}
+
+// HealthCheckTarget is one hard-down target.
+type HealthCheckTarget struct {
+	Name   string `json:"name"`
+	URL    string `json:"url"`
+	Reason string `json:"reason"`           // nxdomain or refused
+	Status string `json:"status,omitempty"` // excluded_unhealthy when left out of the batch
+	Error  string `json:"error,omitempty"`
+}
+
+var (
+	healthMu      sync.Mutex
+	healthMode    = HealthCheckOff
+	healthTimeout = 5 * time.Second
+	healthRunTag  string
+	healthInfo    *HealthCheckInfo
+	healthProbe   = probeTargetHealth // replaceable in tests
+)
+
+// SetHealthCheck sets the pre-check mode (off, report or exclude) and the per-target timeout.
+func SetHealthCheck(mode string, timeout time.Duration) error {
+	mode = strings.ToLower(strings.TrimSpace(mode))
+	switch mode {
+	case HealthCheckOff, HealthCheckReport, HealthCheckExclude:
+	default:
+		return fmt.Errorf("health check %q: want off, report or exclude", mode)
+	}
+	healthMu.Lock()
+	defer healthMu.Unlock()
+	healthMode = mode
+	if timeout > 0 {
+		healthTimeout = timeout
+	}
+	healthRunTag, healthInfo = "", nil
+	return nil
+}
+
+// RunHealthCheck checks sites for batch tag with up to parallel requests at a time and returns
+// the sites to measure: all of them unless the mode is exclude and some, but not all, are hard
+// down. The info (nil when the check is off) is recorded on the batch's lines.
+func RunHealthCheck(tag string, sites []types.Site, parallel int) ([]types.Site, *HealthCheckInfo) {
+	healthMu.Lock()
+	mode, timeout := healthMode, healthTimeout
+	healthMu.Unlock()
+	if mode == HealthCheckOff || len(sites) == 0 {
+		return sites, nil
+	}
+	if parallel < 1 {
+		parallel = 1
+	}
+	start := time.Now()
+	reasons := make([]string, len(sites))
+	errs := make([]error, len(sites))
+	sem := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+	for i, s := range sites {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, s types.Site) {
+			defer func() { <-sem; wg.Done() }()
+			reasons[i], errs[i] = healthProbe(s, timeout)
+		}(i, s)
+	}
+	wg.Wait()
+	info := &HealthCheckInfo{Mode: mode, Checked: len(sites), DurationMs: time.Since(start).Milliseconds()}
+	keep := make([]types.Site, 0, len(sites))
+	for i, s := range sites {
+		if reasons[i] == "" {
+			keep = append(keep, s)
+			continue
+		}
+		t := HealthCheckTarget{Name: s.Name, URL: s.URL, Reason: reasons[i]}
+		if errs[i] != nil {
+			t.Error = errs[i].Error()
+		}
+		info.Targets = append(info.Targets, t)
+	}
+	info.Unhealthy = len(info.Targets)
+	switch {
+	case info.Unhealthy == len(sites):
+		info.AllUnhealthy = true
+		keep = sites
+	case mode == HealthCheckExclude:
+		for i := range info.Targets {
+			info.Targets[i].Status = HealthExcludedUnhealthy
+		}
+		info.Excluded = info.Unhealthy
+	default:
+		keep = sites
+	}
+	healthMu.Lock()
+	healthRunTag, healthInfo = tag, info
+	healthMu.Unlock()
+	return keep, info
+}
+
+// healthCheckForRun returns meta.health_check for a line of batch tag, nil when none ran.
+func healthCheckForRun(tag string) *HealthCheckInfo {
+	healthMu.Lock()
+	defer healthMu.Unlock()
+	if healthInfo == nil || healthRunTag != tag {
+		return nil
+	}
+	return healthInfo
+}
+
+// probeTargetHealth sends one HEAD request to the site, through its proxy and the source binding
+// like the measurement, and returns the hard-down reason ("" = healthy) with the error seen.
+func probeTargetHealth(site types.Site, timeout time.Duration) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	req, err := newSiteRequest(ctx, http.MethodHead, site)
+	if err != nil {
+		return "", err
+	}
+	tr := &http.Transport{
+		DialContext: boundDial(&net.Dialer{Timeout: timeout, Resolver: BoundResolver()}),
+		Proxy: func(r *http.Request) (*url.URL, error) {
+			p, _, _, err := effectiveProxy(site, r.URL, nil)
+			return p, err
+		},
+		DisableKeepAlives: true,
+	}
+	defer tr.CloseIdleConnections()
+	client := &http.Client{Transport: tr, CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Do(req)
+	if err != nil {
+		return classifyHealthError(err), err
+	}
+	resp.Body.Close()
+	return "", nil
+}
+
+// classifyHealthError returns the hard-down reason for a request error, "" for anything that
+// may be the network's doing.
+func classifyHealthError(err error) string {
+	var de *net.DNSError
+	switch {
+	case errors.As(err, &de) && de.IsNotFound:
+		return HealthNXDomain
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return HealthRefused
+	}
+	return ""
+}
@@ -0,0 +1,80 @@
+package monitor
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/iafilius/InternetQualityMonitor/src/types"
+)
+
+func TestRunHealthCheck_ExcludesHardDownTargets(t *testing.T) {
+	prev := healthProbe
+	defer func() {
+		healthProbe = prev
+		SetHealthCheck(HealthCheckOff, 0)
+	}()
+	down := map[string]string{"gone": HealthNXDomain, "closed": HealthRefused}
+	healthProbe = func(s types.Site, _ time.Duration) (string, error) {
+		if r := down[s.Name]; r != "" {
+			return r, fmt.Errorf("%s down", s.Name)
+		}
+		return "", nil
+	}
+	sites := []types.Site{{Name: "ok"}, {Name: "gone"}, {Name: "closed"}}
+	if keep, info := RunHealthCheck("r1", sites, 2); len(keep) != 3 || info != nil || healthCheckForRun("r1") != nil {
+		t.Fatalf("off: keep=%d info=%+v", len(keep), info)
+	}
+	if err := SetHealthCheck("sometimes", 0); err == nil {
+		t.Fatalf("unknown mode accepted")
+	}
+	SetHealthCheck(HealthCheckReport, 0)
+	keep, info := RunHealthCheck("r1", sites, 2)
+	if len(keep) != 3 || info.Unhealthy != 2 || info.Excluded != 0 || info.Targets[0].Status != "" {
+		t.Fatalf("report: keep=%d info=%+v", len(keep), info)
+	}
+	SetHealthCheck(HealthCheckExclude, 0)
+	keep, info = RunHealthCheck("r2", sites, 2)
+	if len(keep) != 1 || keep[0].Name != "ok" || info.Excluded != 2 || info.Targets[1].Reason != HealthRefused || info.Targets[1].Status != HealthExcludedUnhealthy {
+		t.Fatalf("exclude: keep=%v info=%+v", keep, info)
+	}
+	if healthCheckForRun("r2") != info || healthCheckForRun("r1") != nil {
+		t.Fatalf("info recorded for the wrong batch")
+	}
+	// all down: nothing is excluded, the failures are the measurement
+	keep, info = RunHealthCheck("r3", sites[1:], 2)
+	if len(keep) != 2 || !info.AllUnhealthy || info.Excluded != 0 {
+		t.Fatalf("all down: keep=%d info=%+v", len(keep), info)
+	}
+}
+
+func TestProbeTargetHealth(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodHead {
+			t.Errorf("method %s", r.Method)
+		}
+		w.WriteHeader(http.StatusServiceUnavailable) // any answer means the target is up
+	}))
+	defer srv.Close()
+	if reason, err := probeTargetHealth(types.Site{Name: "up", URL: srv.URL}, 2*time.Second); reason != "" || err != nil {
+		t.Fatalf("up: %q %v", reason, err)
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+	if reason, _ := probeTargetHealth(types.Site{Name: "closed", URL: "http://" + addr + "/"}, 2*time.Second); reason != HealthRefused {
+		t.Fatalf("closed port: %q", reason)
+	}
+	if got := classifyHealthError(&net.DNSError{Err: "no such host", Name: "x.invalid", IsNotFound: true}); got != HealthNXDomain {
+		t.Fatalf("nxdomain: %q", got)
+	}
+	if got := classifyHealthError(&net.DNSError{Err: "i/o timeout", IsTimeout: true}); got != "" {
+		t.Fatalf("resolver timeout is not hard down: %q", got)
+	}
+}
@@ -0,0 +1,153 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Batch hooks (--pre-batch-hook, --post-batch-hook) are shell commands run before and after each
+// batch, e.g. to bring a VPN up, switch Wi-Fi bands or notify another tool. Their outcome goes into
+// meta.hooks so a batch measured after an environment change can be told apart in analysis: the
+// pre hook is recorded on the lines of its own batch, the post hook (which runs after the last
+// line is written) on the lines of the next batch as post_prev, keyed by the run tag it ran after.
+
+// HookResult is one run of a batch hook.
+type HookResult struct {
+	Phase      string `json:"phase"`   // pre or post
+	RunTag     string `json:"run_tag"` // batch the hook ran before/after
+	Command    string `json:"command"`
+	ExitCode   int    `json:"exit_code"` // -1 when the command could not be started or was killed
+	DurationMs int64  `json:"duration_ms"`
+	TimedOut   bool   `json:"timed_out,omitempty"`
+	Error      string `json:"error,omitempty"`
+	// Output is the tail of the combined stdout/stderr (hookOutputMax bytes).
+	Output string `json:"output,omitempty"`
+}
+
+// OK reports whether the hook ran and exited 0.
+func (h *HookResult) OK() bool { return h != nil && h.ExitCode == 0 && h.Error == "" }
+
+// BatchHooks is meta.hooks.
+type BatchHooks struct {
+	Pre      *HookResult `json:"pre,omitempty"`
+	PostPrev *HookResult `json:"post_prev,omitempty"` // post hook of the previous batch
+}
+
+// Hook phases.
+const (
+	HookPre  = "pre"
+	HookPost = "post"
+)
+
+const hookOutputMax = 512
+
+var (
+	hookMu      sync.Mutex
+	hookPreCmd  string
+	hookPostCmd string
+	hookTimeout = time.Minute
+	hookPre     *HookResult
+	hookPost    *HookResult
+	hookExec    = execHook // replaceable in tests
+)
+
+// SetBatchHooks sets the pre/post batch commands and their timeout; an empty command disables the
+// hook.
+func SetBatchHooks(pre, post string, timeout time.Duration) {
+	hookMu.Lock()
+	defer hookMu.Unlock()
+	hookPreCmd, hookPostCmd = strings.TrimSpace(pre), strings.TrimSpace(post)
+	if timeout > 0 {
+		hookTimeout = timeout
+	}
+	hookPre, hookPost = nil, nil
+}
+
+// RunBatchHook runs the phase's hook for the batch tag, nil when none is configured. env is added
+// to the hook's environment on top of IQM_HOOK_PHASE and IQM_RUN_TAG.
+func RunBatchHook(phase, tag string, env ...string) *HookResult {
+	hookMu.Lock()
+	cmd, timeout := hookPreCmd, hookTimeout
+	if phase == HookPost {
+		cmd = hookPostCmd
+	}
+	hookMu.Unlock()
+	if cmd == "" {
+		return nil
+	}
+	env = append([]string{"IQM_HOOK_PHASE=" + phase, "IQM_RUN_TAG=" + tag}, env...)
+	res := hookExec(cmd, env, timeout)
+	res.Phase, res.RunTag, res.Command = phase, tag, cmd
+	hookMu.Lock()
+	if phase == HookPost {
+		hookPost = res
+	} else {
+		hookPre = res
+	}
+	hookMu.Unlock()
+	return res
+}
+
+// batchHooksForRun returns meta.hooks for a line of batch tag, nil when no hook has run.
+func batchHooksForRun(tag string) *BatchHooks {
+	hookMu.Lock()
+	defer hookMu.Unlock()
+	var bh BatchHooks
+	if hookPre != nil && hookPre.RunTag == tag {
+		bh.Pre = hookPre
+	}
+	if hookPost != nil && hookPost.RunTag != tag {
+		bh.PostPrev = hookPost
+	}
+	if bh.Pre == nil && bh.PostPrev == nil {
+		return nil
+	}
+	return &bh
+}
+
+// execHook runs cmd through the platform shell with a timeout.
+func execHook(cmd string, env []string, timeout time.Duration) *HookResult {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	var c *exec.Cmd
+	if runtime.GOOS == "windows" {
+		c = exec.CommandContext(ctx, "cmd", "/C", cmd)
+	} else {
+		c = exec.CommandContext(ctx, "/bin/sh", "-c", cmd)
+	}
+	c.Env = append(os.Environ(), env...)
+	c.WaitDelay = time.Second // a backgrounded child keeping the pipes open must not hang the batch
+	start := time.Now()
+	out, err := c.CombinedOutput()
+	res := &HookResult{DurationMs: time.Since(start).Milliseconds(), Output: tailString(strings.TrimSpace(string(out)), hookOutputMax)}
+	var exitErr *exec.ExitError
+	switch {
+	case ctx.Err() == context.DeadlineExceeded:
+		res.ExitCode, res.TimedOut, res.Error = -1, true, "timed out after "+timeout.String()
+	case errors.As(err, &exitErr):
+		res.ExitCode = exitErr.ExitCode()
+	case err != nil:
+		res.ExitCode, res.Error = -1, err.Error()
+	}
+	return res
+}
+
+// tailString returns the last max bytes of s, cut forward to a rune start so no UTF-8 sequence
+// is split.
+func tailString(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	cut := len(s) - max
+	for cut < len(s) && !utf8.RuneStart(s[cut]) {
+		cut++
+	}
+	return "…" + s[cut:]
+}
@@ -0,0 +1,70 @@
+package monitor
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestBatchHooksForRun_PreAndPreviousPost(t *testing.T) {
+	prev := hookExec
+	defer func() {
+		hookExec = prev
+		SetBatchHooks("", "", 0)
+	}()
+	var env []string
+	hookExec = func(cmd string, e []string, _ time.Duration) *HookResult {
+		env = e
+		if strings.Contains(cmd, "fail") {
+			return &HookResult{ExitCode: 3, DurationMs: 5}
+		}
+		return &HookResult{DurationMs: 5}
+	}
+	if RunBatchHook(HookPre, "r1") != nil || batchHooksForRun("r1") != nil {
+		t.Fatalf("no hooks configured: nothing runs or is recorded")
+	}
+	SetBatchHooks("vpn up", "vpn down fail", 0)
+	pre := RunBatchHook(HookPre, "r1", "IQM_ITERATION=1")
+	if !pre.OK() || pre.Command != "vpn up" || pre.RunTag != "r1" || len(env) != 3 || env[0] != "IQM_HOOK_PHASE=pre" {
+		t.Fatalf("pre: %+v env=%v", pre, env)
+	}
+	if bh := batchHooksForRun("r1"); bh == nil || bh.Pre != pre || bh.PostPrev != nil {
+		t.Fatalf("r1 hooks: %+v", bh)
+	}
+	RunBatchHook(HookPost, "r1")
+	if bh := batchHooksForRun("r1"); bh.PostPrev != nil {
+		t.Fatalf("a batch's own post hook is not its post_prev")
+	}
+	RunBatchHook(HookPre, "r2")
+	bh := batchHooksForRun("r2")
+	if bh == nil || bh.Pre.RunTag != "r2" || bh.PostPrev == nil || bh.PostPrev.RunTag != "r1" || bh.PostPrev.OK() {
+		t.Fatalf("r2 hooks: %+v", bh)
+	}
+}
+
+func TestExecHookExitCodeAndTimeout(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses /bin/sh")
+	}
+	res := execHook("echo $IQM_RUN_TAG; exit 4", []string{"IQM_RUN_TAG=r9"}, 5*time.Second)
+	if res.ExitCode != 4 || res.Output != "r9" || res.TimedOut {
+		t.Fatalf("exit: %+v", res)
+	}
+	res = execHook("sleep 5", nil, 100*time.Millisecond)
+	if !res.TimedOut || res.ExitCode != -1 || res.DurationMs > 3000 {
+		t.Fatalf("timeout: %+v", res)
+	}
+}
+
+func TestTailStringKeepsRunes(t *testing.T) {
+	if got := tailString("abc", 5); got != "abc" {
+		t.Fatalf("short: %q", got)
+	}
+	// the last 4 bytes start inside "é" (2 bytes): the cut moves to the next rune
+	got := tailString("xéabc", 4)
+	if got != "…abc" || !utf8.ValidString(got) {
+		t.Fatalf("tail %q", got)
+	}
+}
@@ -0,0 +1,67 @@
+package monitor
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/iafilius/InternetQualityMonitor/src/types"
+)
+
+func TestForceHTTPVersion(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	defer srv.Close()
+	for _, tc := range []struct{ version, want string }{{HTTPVersion11, "HTTP/1.1"}, {HTTPVersion2, "HTTP/2.0"}} {
+		tr := &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true, NextProtos: []string{"h2", "http/1.1"}}}
+		forceHTTPVersion(tr, tc.version)
+		resp, err := (&http.Client{Transport: tr}).Get(srv.URL)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.version, err)
+		}
+		resp.Body.Close()
+		tr.CloseIdleConnections()
+		if resp.Proto != tc.want {
+			t.Fatalf("forced %s: got %s", tc.version, resp.Proto)
+		}
+	}
+}
+
+func TestParseProtocolExperiment(t *testing.T) {
+	got, err := ParseProtocolExperiment(" h1, 2 ,http/1.1,")
+	if err != nil || len(got) != 2 || got[0] != HTTPVersion11 || got[1] != HTTPVersion2 {
+		t.Fatalf("got %v %v", got, err)
+	}
+	if got, err := ParseProtocolExperiment(""); got != nil || err != nil {
+		t.Fatalf("empty: %v %v", got, err)
+	}
+	for _, bad := range []string{"1.1,h3", "spdy"} {
+		if _, err := ParseProtocolExperiment(bad); err == nil {
+			t.Fatalf("%q accepted", bad)
+		}
+	}
+	if ValidateSite(types.Site{Name: "x", HTTPVersion: "3"}) == nil {
+		t.Fatalf("site http_version 3 accepted")
+	}
+}
+
+func TestExpandProtocolExperiment(t *testing.T) {
+	sites := []types.Site{
+		{Name: "tls", URL: "https://a.example/"},
+		{Name: "plain", URL: "http://b.example/"},
+		{Name: "pinned", URL: "https://c.example/", HTTPVersion: "1.1"},
+		{Name: "ping", URL: "https://d.example/", Probe: ProbePing},
+	}
+	if got := ExpandProtocolExperiment(sites, nil); len(got) != len(sites) {
+		t.Fatalf("off: %d sites", len(got))
+	}
+	got := ExpandProtocolExperiment(sites, []string{HTTPVersion11, HTTPVersion2})
+	if len(got) != 5 || got[0].HTTPVersion != HTTPVersion11 || got[1].HTTPVersion != HTTPVersion2 || got[1].Name != "tls" || got[2].HTTPVersion != "" {
+		t.Fatalf("expanded: %+v", got)
+	}
+	if sites[0].HTTPVersion != "" {
+		t.Fatalf("input modified")
+	}
+}
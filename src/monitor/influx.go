@@ -0,0 +1,332 @@
+package monitor
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// InfluxDB line protocol sink.
+//
+// With --influx-file and/or --influx-url the monitor writes points in InfluxDB line protocol:
+// one <measurement>_batch point per analysed batch (plus one per target group, built by
+// analysis.InfluxBatchPoints), and with --influx-lines one <measurement>_line point per result
+// line. The URL is any write endpoint that takes nanosecond line protocol in the request body:
+// InfluxDB 2 (/api/v2/write?org=..&bucket=..), InfluxDB 1 (/write?db=..) or VictoriaMetrics
+// (/write). The token is sent as "Authorization: Token ..."; user:password in the URL is sent as
+// basic auth. Run tags are fields, not tags, to keep series cardinality bounded. Like the OTLP
+// exporter the sink never blocks measurements; failed pushes are retried with the next write.
+
+// DefaultInfluxMeasurement is the measurement name prefix used when none is configured.
+const DefaultInfluxMeasurement = "iqm"
+
+const influxMaxPending = 10000 // oldest points are dropped beyond this while the endpoint is unreachable
+
+var (
+	influxFile        string
+	influxURL         string
+	influxToken       string
+	influxPerLine     bool
+	influxMeasurement = DefaultInfluxMeasurement
+	influxMu          sync.Mutex // guards influxChan: queueing and closing take it, so no send hits a closed channel
+	influxChan        chan []string
+	influxWG          sync.WaitGroup
+	influxClient      = &http.Client{Timeout: 15 * time.Second}
+)
+
+// SetInfluxSink enables the line protocol sink: points are appended to file and/or POSTed to url
+// (either may be empty). perLine adds one point per result line to the batch points.
+func SetInfluxSink(file, url, token string, perLine bool) {
+	influxFile = strings.TrimSpace(file)
+	influxURL = strings.TrimSpace(url)
+	influxToken = strings.TrimSpace(token)
+	influxPerLine = perLine
+}
+
+// SetInfluxMeasurement overrides the measurement name prefix (default "iqm").
+func SetInfluxMeasurement(prefix string) {
+	if strings.TrimSpace(prefix) != "" {
+		influxMeasurement = strings.TrimSpace(prefix)
+	}
+}
+
+// InfluxEnabled reports whether a file or URL is configured for the sink.
+func InfluxEnabled() bool { return influxFile != "" || influxURL != "" }
+
+// InfluxMeasurement returns the measurement name for kind ("batch", "line"): <prefix>_<kind>.
+func InfluxMeasurement(kind string) string { return influxMeasurement + "_" + kind }
+
+// InfluxPoint is one line protocol point. Fields hold float64, int, int64, bool or string values;
+// empty tag values are left out, as line protocol has no empty tags.
+type InfluxPoint struct {
+	Measurement string
+	Tags        map[string]string
+	Fields      map[string]any
+	Time        time.Time
+}
+
+// Line encodes the point with sorted tags and fields and a nanosecond timestamp (none when Time
+// is zero, so the server assigns its own). It returns "" for a point without fields; NaN and ±Inf
+// fields are dropped because line protocol cannot carry them.
+func (p InfluxPoint) Line() string {
+	var fields []string
+	for _, k := range sortedKeys(p.Fields) {
+		var v string
+		switch x := p.Fields[k].(type) {
+		case float64:
+			if math.IsNaN(x) || math.IsInf(x, 0) {
+				continue
+			}
+			v = strconv.FormatFloat(x, 'f', -1, 64)
+		case int:
+			v = strconv.Itoa(x) + "i"
+		case int64:
+			v = strconv.FormatInt(x, 10) + "i"
+		case bool:
+			v = strconv.FormatBool(x)
+		case string:
+			v = `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(x) + `"`
+		default:
+			continue
+		}
+		fields = append(fields, influxEscape(k, ",= ")+"="+v)
+	}
+	if len(fields) == 0 || p.Measurement == "" {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString(influxEscape(p.Measurement, ", "))
+	for _, k := range sortedKeys(p.Tags) {
+		if v := p.Tags[k]; v != "" && k != "" {
+			b.WriteString("," + influxEscape(k, ",= ") + "=" + influxEscape(v, ",= "))
+		}
+	}
+	b.WriteString(" " + strings.Join(fields, ","))
+	if !p.Time.IsZero() {
+		b.WriteString(" " + strconv.FormatInt(p.Time.UnixNano(), 10))
+	}
+	return b.String()
+}
+
+// influxEscape backslash-escapes the characters in special; newlines become spaces (line
+// protocol cannot escape them outside string fields).
+func influxEscape(s, special string) string {
+	s = strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+	if !strings.ContainsAny(s, special) {
+		return s
+	}
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(special, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// influxLinePoint is the --influx-lines point of one result line.
+func influxLinePoint(env *ResultEnvelope) InfluxPoint {
+	sr := env.SiteResult
+	p := InfluxPoint{Measurement: InfluxMeasurement("line"), Time: time.Now(),
+		Tags: map[string]string{"site": sr.Name, "group": sr.Group, "ip_family": sr.IPFamily, "http_protocol": sr.HTTPProtocol},
+		Fields: map[string]any{
+			"dns_ms": sr.DNSTimeMs, "connect_ms": sr.TCPTimeMs, "tls_ms": sr.SSLHandshakeTimeMs, "ttfb_ms": sr.TraceTTFBMs,
+			"transfer_ms": sr.TransferTimeMs, "bytes": sr.TransferSizeBytes, "speed_kbps": sr.TransferSpeedKbps,
+			"stalled": sr.TransferStalled, "error": lineError(sr) != "",
+		}}
+	if e := lineError(sr); e != "" {
+		p.Fields["error_message"] = e
+	}
+	if m := env.Meta; m != nil {
+		p.Tags["situation"], p.Tags["agent"] = m.Situation, m.Agent
+		p.Fields["run_tag"] = m.RunTag
+		if t, err := time.Parse(time.RFC3339Nano, m.TimestampUTC); err == nil {
+			p.Time = t
+		}
+	}
+	return p
+}
+
+// lineError is the first failure recorded on the line, "" when it succeeded.
+func lineError(sr *SiteResult) string {
+	for _, e := range []string{sr.TCPError, sr.SSLError, sr.HTTPError, sr.ProbeError} {
+		if e != "" {
+			return e
+		}
+	}
+	return ""
+}
+
+// emitInfluxLine queues the --influx-lines point of one result line.
+func emitInfluxLine(env *ResultEnvelope) {
+	if !influxPerLine || env == nil || env.SiteResult == nil {
+		return
+	}
+	WriteInfluxPoints([]InfluxPoint{influxLinePoint(env)})
+}
+
+// WriteInfluxPoints queues points for the sink; a no-op unless SetInfluxSink configured a file or
+// URL. It never blocks: when the sink falls behind, points are dropped with a debug log.
+func WriteInfluxPoints(points []InfluxPoint) {
+	if !InfluxEnabled() {
+		return
+	}
+	lines := make([]string, 0, len(points))
+	for _, p := range points {
+		if l := p.Line(); l != "" {
+			lines = append(lines, l)
+		}
+	}
+	if len(lines) == 0 {
+		return
+	}
+	influxMu.Lock()
+	defer influxMu.Unlock()
+	if influxChan == nil {
+		startInfluxSink()
+	}
+	select {
+	case influxChan <- lines:
+	default:
+		Debugf("[influx] queue full; dropping %d point(s)", len(lines))
+	}
+}
+
+// startInfluxSink opens influxChan and its writer goroutine; influxMu must be held.
+func startInfluxSink() {
+	ch := make(chan []string, 256)
+	influxChan = ch
+	influxWG.Add(1)
+	go func() {
+		defer influxWG.Done()
+		var pending []string
+		for lines := range ch {
+			// Coalesce anything already queued into one write.
+		drain:
+			for len(lines) < 1000 {
+				select {
+				case more, ok := <-ch:
+					if !ok {
+						break drain
+					}
+					lines = append(lines, more...)
+				default:
+					break drain
+				}
+			}
+			if influxFile != "" {
+				if err := appendInfluxFile(influxFile, lines); err != nil {
+					Warnf("[influx] writing %s: %v", influxFile, err)
+				}
+			}
+			if influxURL == "" {
+				continue
+			}
+			pending = append(pending, lines...)
+			if n := len(pending) - influxMaxPending; n > 0 {
+				Warnf("[influx] endpoint unreachable; dropping %d oldest point(s)", n)
+				pending = pending[n:]
+			}
+			unsent, dropped, err := pushInfluxLines(influxURL, influxToken, pending)
+			if dropped > 0 {
+				Warnf("[influx] endpoint rejected %d point(s); dropping them", dropped)
+			}
+			pending = unsent
+			if err != nil {
+				Warnf("[influx] push failed (%d point(s) kept for the next write): %v", len(pending), err)
+			}
+		}
+	}()
+}
+
+// pushInfluxLines posts lines. When the endpoint rejects them as malformed or too large, it
+// posts the halves in turn, so only points rejected on their own are dropped (counted in
+// dropped). Any other error stops the push; unsent are the lines still to send, in order.
+func pushInfluxLines(url, token string, lines []string) (unsent []string, dropped int, err error) {
+	err = postInfluxLines(url, token, lines)
+	if err == nil {
+		return nil, 0, nil
+	}
+	if _, ok := err.(permanentPushError); !ok {
+		return lines, 0, err
+	}
+	if len(lines) == 1 {
+		Debugf("[influx] endpoint rejected %q: %v", lines[0], err)
+		return nil, 1, nil
+	}
+	mid := len(lines) / 2
+	unsent, dropped, err = pushInfluxLines(url, token, lines[:mid])
+	if err != nil {
+		return append(append([]string(nil), unsent...), lines[mid:]...), dropped, err
+	}
+	unsent, n, err := pushInfluxLines(url, token, lines[mid:])
+	return unsent, dropped + n, err
+}
+
+func appendInfluxFile(path string, lines []string) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	_, werr := f.WriteString(strings.Join(lines, "\n") + "\n")
+	if cerr := f.Close(); werr == nil {
+		werr = cerr
+	}
+	return werr
+}
+
+func postInfluxLines(url, token string, lines []string) error {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader([]byte(strings.Join(lines, "\n")+"\n")))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if token != "" {
+		req.Header.Set("Authorization", "Token "+token)
+	}
+	resp, err := influxClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	switch {
+	case resp.StatusCode/100 == 2:
+		return nil
+	case resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusRequestEntityTooLarge:
+		// malformed points or a body over the server's limit: retrying sends the same again
+		return permanentPushError{fmt.Sprintf("%s: %s", resp.Status, strings.TrimSpace(string(msg)))}
+	default:
+		return fmt.Errorf("endpoint responded %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+}
+
+// closeInfluxSink flushes queued points; called from CloseResultWriter.
+func closeInfluxSink() {
+	influxMu.Lock()
+	ch := influxChan
+	influxChan = nil
+	influxMu.Unlock()
+	if ch != nil {
+		close(ch)
+		influxWG.Wait()
+	}
+}
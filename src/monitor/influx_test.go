@@ -0,0 +1,130 @@
+package monitor
+
+import (
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestInfluxPointLine(t *testing.T) {
+	p := InfluxPoint{
+		Measurement: "iqm batch",
+		Tags:        map[string]string{"situation": "Home, office", "agent": "", "k=v": "x"},
+		Fields: map[string]any{"lines": 12, "bytes": int64(3), "speed": 1.5, "ok": true, "run_tag": `a "b"`,
+			"nan": math.NaN(), "inf": math.Inf(1), "other": struct{}{}},
+		Time: time.Unix(1700000000, 5),
+	}
+	want := `iqm\ batch,k\=v=x,situation=Home\,\ office bytes=3i,lines=12i,ok=true,run_tag="a \"b\"",speed=1.5 1700000000000000005`
+	if got := p.Line(); got != want {
+		t.Fatalf("line:\n got %s\nwant %s", got, want)
+	}
+	if got := (InfluxPoint{Measurement: "m", Fields: map[string]any{"x": math.NaN()}}).Line(); got != "" {
+		t.Fatalf("point without valid fields: %q", got)
+	}
+	if got := (InfluxPoint{Measurement: "m", Fields: map[string]any{"x": 1.0}}).Line(); got != "m x=1" {
+		t.Fatalf("point without time: %q", got)
+	}
+}
+
+func TestInfluxSinkFileAndPush(t *testing.T) {
+	var mu sync.Mutex
+	var auth, body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		auth, body = r.Header.Get("Authorization"), string(b)
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+	path := filepath.Join(t.TempDir(), "iqm.lp")
+	SetInfluxSink(path, srv.URL+"/api/v2/write?org=o&bucket=b", "secret", true)
+	defer SetInfluxSink("", "", "", false)
+
+	emitInfluxLine(&ResultEnvelope{Meta: &Meta{RunTag: "r1", Situation: "Home", TimestampUTC: "2024-01-02T03:04:05Z"},
+		SiteResult: &SiteResult{Name: "example", IPFamily: "ipv4", TransferSpeedKbps: 800, TCPError: "refused"}})
+	WriteInfluxPoints([]InfluxPoint{{Measurement: InfluxMeasurement("batch"), Fields: map[string]any{"lines": 1}}})
+	closeInfluxSink()
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "iqm_line,ip_family=ipv4,site=example,situation=Home ") || lines[1] != "iqm_batch lines=1i" {
+		t.Fatalf("file:\n%s", b)
+	}
+	if !strings.Contains(lines[0], `error=true,error_message="refused"`) || !strings.Contains(lines[0], `run_tag="r1"`) || !strings.HasSuffix(lines[0], " 1704164645000000000") {
+		t.Fatalf("line point: %s", lines[0])
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if auth != "Token secret" {
+		t.Fatalf("authorization %q", auth)
+	}
+	if body != string(b) {
+		t.Fatalf("pushed body differs from file:\n%s", body)
+	}
+}
+
+func TestInfluxSinkDisabledDropsPoints(t *testing.T) {
+	SetInfluxSink("", "", "", true)
+	WriteInfluxPoints([]InfluxPoint{{Measurement: "m", Fields: map[string]any{"x": 1}}})
+	if influxChan != nil {
+		t.Fatal("sink started without a file or URL")
+	}
+}
+
+func TestInfluxSinkSplitsRejectedBatch(t *testing.T) {
+	var mu sync.Mutex
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		if strings.Contains(string(b), "bad") {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		got = append(got, strings.Split(strings.TrimSpace(string(b)), "\n")...)
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+	lines := []string{"m a=1", "m a=2", "bad x=1", "m a=3", "m a=4"}
+	unsent, dropped, err := pushInfluxLines(srv.URL, "", lines)
+	if err != nil || dropped != 1 || len(unsent) != 0 {
+		t.Fatalf("unsent=%q dropped=%d err=%v", unsent, dropped, err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if strings.Join(got, ";") != "m a=1;m a=2;m a=3;m a=4" {
+		t.Fatalf("delivered %q", got)
+	}
+}
+
+func TestInfluxSinkConcurrentWriteAndClose(t *testing.T) {
+	SetInfluxSink(filepath.Join(t.TempDir(), "iqm.lp"), "", "", true)
+	defer SetInfluxSink("", "", "", false)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				WriteInfluxPoints([]InfluxPoint{{Measurement: "m", Fields: map[string]any{"x": j}}})
+			}
+		}()
+	}
+	for i := 0; i < 20; i++ {
+		closeInfluxSink()
+	}
+	wg.Wait()
+	closeInfluxSink()
+}
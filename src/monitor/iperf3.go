@@ -0,0 +1,354 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/iafilius/InternetQualityMonitor/src/types"
+)
+
+// IPerf3Result is one run of the system iperf3 client against the site's iperf3 server: a TCP
+// test, and with --iperf3-udp-bitrate a UDP test after it. It measures the raw capacity of the
+// path without HTTP, TLS, proxies or the origin, so HTTP speeds well below it point at those
+// layers, and speeds close to it at the path itself.
+type IPerf3Result struct {
+	Server  string `json:"server"` // host:port as dialed
+	Version string `json:"version,omitempty"`
+	// Reverse: the server sent (the download direction, like the HTTP probe's transfers)
+	Reverse    bool       `json:"reverse,omitempty"`
+	Streams    int        `json:"streams,omitempty"`
+	DurationMs int64      `json:"duration_ms"` // --iperf3-duration per test
+	TCP        *IPerf3TCP `json:"tcp,omitempty"`
+	UDP        *IPerf3UDP `json:"udp,omitempty"`
+}
+
+// IPerf3TCP is the TCP test. ReceivedKbps is what arrived, the capacity; Retransmits and
+// MeanRTTMs are the sender's, so with Reverse the retransmits are the server's and the RTT is
+// not reported.
+type IPerf3TCP struct {
+	SentKbps     float64   `json:"sent_kbps"`
+	ReceivedKbps float64   `json:"received_kbps"`
+	Retransmits  int64     `json:"retransmits,omitempty"`
+	MeanRTTMs    float64   `json:"mean_rtt_ms,omitempty"`
+	SamplesKbps  []float64 `json:"samples_kbps,omitempty"` // per 1s interval, omitted ones left out
+}
+
+// IPerf3UDP is the UDP test at a fixed offered rate: the received rate, jitter and loss as the
+// receiver counted them. Error is set when only the UDP test failed (e.g. UDP filtered).
+type IPerf3UDP struct {
+	TargetBitrate string  `json:"target_bitrate"` // --iperf3-udp-bitrate, iperf3 notation
+	Kbps          float64 `json:"kbps,omitempty"`
+	JitterMs      float64 `json:"jitter_ms,omitempty"`
+	LostPackets   int64   `json:"lost_packets,omitempty"`
+	Packets       int64   `json:"packets,omitempty"`
+	LostPct       float64 `json:"lost_pct,omitempty"`
+	Error         string  `json:"error,omitempty"`
+}
+
+const (
+	defaultIPerf3Duration = 10 * time.Second
+	defaultIPerf3Port     = 5201
+	// connection setup and the result exchange on top of the test duration
+	iperf3Overhead = 20 * time.Second
+	// an iperf3 server runs one test at a time; a busy one is asked again after a pause
+	iperf3BusyRetries = 3
+	iperf3BusyPause   = 5 * time.Second
+)
+
+var (
+	iperf3Duration   = defaultIPerf3Duration
+	iperf3Reverse    = true
+	iperf3Parallel   = 1
+	iperf3UDPBitrate = ""
+
+	// seams for tests
+	runIPerf3       = execIPerf3
+	iperf3BusyDelay = iperf3BusyPause
+)
+
+// SetIPerf3Duration sets the length of each iperf3 test (default 10s).
+func SetIPerf3Duration(d time.Duration) {
+	if d >= time.Second {
+		iperf3Duration = d
+	}
+}
+
+// SetIPerf3Reverse selects the direction: true (default) lets the server send, false uploads.
+func SetIPerf3Reverse(reverse bool) { iperf3Reverse = reverse }
+
+// SetIPerf3Parallel sets the parallel TCP streams of the iperf3 test (default 1).
+func SetIPerf3Parallel(n int) {
+	if n > 0 {
+		iperf3Parallel = n
+	}
+}
+
+// SetIPerf3UDPBitrate enables the UDP test at an iperf3 bitrate such as "50M" ("": TCP only).
+func SetIPerf3UDPBitrate(rate string) { iperf3UDPBitrate = strings.TrimSpace(rate) }
+
+// IPerf3Measurer runs the system iperf3 client (-J, JSON output) against the server in the
+// site's URL ("host", "host:port" or "iperf3://host:port", default port 5201), one line per
+// site. Like the soak probe it runs for its own duration regardless of --site-timeout. The
+// source binding applies as iperf3 -B; proxies do not, iperf3 always connects directly. A
+// reduced batch (or the lite profile) limits the TCP test to the transfer cap and skips the UDP
+// test; once the monthly budget is used up the test is skipped.
+type IPerf3Measurer struct{}
+
+func (IPerf3Measurer) ProbeType() string { return ProbeIPerf3 }
+
+func (IPerf3Measurer) MeasureSite(ctx context.Context, site types.Site) {
+	sr := &SiteResult{Name: site.Name, URL: site.URL, CountryConfigured: site.Country, Group: SiteGroup(site), ProbeType: ProbeIPerf3, started: time.Now(), usage: &wireUsage{}}
+	host, port, err := iperf3Server(site)
+	if err != nil {
+		sr.ProbeError = err.Error()
+		WriteSiteResult(sr)
+		return
+	}
+	res := &IPerf3Result{Server: net.JoinHostPort(host, strconv.Itoa(port)), Reverse: iperf3Reverse, Streams: iperf3Parallel, DurationMs: iperf3Duration.Milliseconds()}
+	sr.IPerf3 = res
+	if budgetUsedUp() {
+		sr.ProbeError = "iperf3: skipped, monthly data budget used up"
+		Warnf("[%s] %s", site.Name, sr.ProbeError)
+		WriteSiteResult(sr)
+		return
+	}
+	capBytes := transferCap()
+	ctx, cancel := withoutSiteTimeout(ctx)
+	defer cancel()
+	out, err := runIPerf3Test(ctx, iperf3Args(host, port, "", capBytes))
+	if err != nil {
+		sr.ProbeError = err.Error()
+		Warnf("[%s] iperf3 %s: %v", site.Name, res.Server, err)
+		WriteSiteResult(sr)
+		return
+	}
+	res.Version = out.Start.Version
+	if c := out.Start.Connected; len(c) > 0 && c[0].RemoteHost != "" {
+		if ip := net.ParseIP(c[0].RemoteHost); ip != nil {
+			sr.IP, sr.IPFamily = ip.String(), ipFamilyOf(ip)
+		}
+	}
+	res.TCP = out.tcp()
+	out.addWireBytes(sr.usage)
+	sr.TransferCapped = capBytes > 0 && sr.usage.rx.Load()+sr.usage.tx.Load() >= capBytes
+	if iperf3UDPBitrate != "" && capBytes == 0 {
+		res.UDP = &IPerf3UDP{TargetBitrate: iperf3UDPBitrate}
+		if uout, err := runIPerf3Test(ctx, iperf3Args(host, port, iperf3UDPBitrate, 0)); err != nil {
+			res.UDP.Error = err.Error()
+		} else {
+			uout.fillUDP(res.UDP)
+			uout.addWireBytes(sr.usage)
+		}
+	}
+	Infof("[%s] iperf3 %s tcp=%.0fkbps retrans=%d%s", site.Name, res.Server, res.TCP.ReceivedKbps, res.TCP.Retransmits, iperf3UDPLog(res.UDP))
+	WriteSiteResult(sr)
+}
+
+// MeasureSiteIP tests once per site: only for the first fanned-out address, since the server
+// runs one test at a time.
+func (m IPerf3Measurer) MeasureSiteIP(ctx context.Context, site types.Site, ip net.IP, dnsIPs []string, dnsTime time.Duration) {
+	if len(dnsIPs) > 0 && dnsIPs[0] != ip.String() {
+		return
+	}
+	m.MeasureSite(ctx, site)
+}
+
+// SplitIPerf3Sites separates a batch's iperf3 sites from the others. An iperf3 test saturates
+// the path, so the batch loop runs these one at a time after the other sites instead of in the
+// --parallel pool, where they would measure each other's traffic.
+func SplitIPerf3Sites(sites []types.Site) (others, iperf3 []types.Site) {
+	for _, s := range sites {
+		if strings.ToLower(strings.TrimSpace(s.Probe)) == ProbeIPerf3 {
+			iperf3 = append(iperf3, s)
+		} else {
+			others = append(others, s)
+		}
+	}
+	return others, iperf3
+}
+
+func iperf3UDPLog(u *IPerf3UDP) string {
+	switch {
+	case u == nil:
+		return ""
+	case u.Error != "":
+		return " udp error: " + u.Error
+	}
+	return fmt.Sprintf(" udp=%.0fkbps jitter=%.2fms loss=%.2f%%", u.Kbps, u.JitterMs, u.LostPct)
+}
+
+// iperf3Server is the host and port of the site's iperf3 server.
+func iperf3Server(site types.Site) (string, int, error) {
+	u, err := siteURL(site)
+	if err != nil {
+		return "", 0, err
+	}
+	port := defaultIPerf3Port
+	if p := u.Port(); p != "" {
+		if port, err = strconv.Atoi(p); err != nil {
+			return "", 0, fmt.Errorf("%s: bad port %q", site.URL, p)
+		}
+	}
+	return u.Hostname(), port, nil
+}
+
+// iperf3Args are the client arguments of one test; udpBitrate "" is the TCP test. capBytes > 0
+// ends the test after that many bytes (-n) if the duration has not ended it first.
+func iperf3Args(host string, port int, udpBitrate string, capBytes int64) []string {
+	args := []string{"-c", host, "-p", strconv.Itoa(port), "-J", "-t", strconv.Itoa(int(iperf3Duration / time.Second))}
+	if iperf3Reverse {
+		args = append(args, "-R")
+	}
+	if udpBitrate != "" {
+		args = append(args, "-u", "-b", udpBitrate)
+	} else if iperf3Parallel > 1 {
+		args = append(args, "-P", strconv.Itoa(iperf3Parallel))
+	}
+	if capBytes > 0 {
+		args = append(args, "-n", strconv.FormatInt(capBytes, 10))
+	}
+	if src := currentBinding().SourceIP(); src != "" {
+		args = append(args, "-B", src)
+	}
+	return args
+}
+
+// runIPerf3Test runs one test, asking a busy server again up to iperf3BusyRetries times.
+func runIPerf3Test(ctx context.Context, args []string) (*iperf3Output, error) {
+	for attempt := 0; ; attempt++ {
+		tctx, cancel := context.WithTimeout(ctx, iperf3Duration+iperf3Overhead)
+		raw, runErr := runIPerf3(tctx, args)
+		cancel()
+		out, err := parseIPerf3(raw, runErr)
+		if err == nil || !strings.Contains(err.Error(), "busy") || attempt == iperf3BusyRetries {
+			return out, err
+		}
+		time.Sleep(iperf3BusyDelay)
+	}
+}
+
+// execIPerf3 runs the iperf3 client; it prints JSON even when the test fails.
+func execIPerf3(ctx context.Context, args []string) ([]byte, error) {
+	return exec.CommandContext(ctx, "iperf3", args...).Output()
+}
+
+// iperf3Output is the part of iperf3's -J output the probe reads.
+type iperf3Output struct {
+	Start struct {
+		Version   string `json:"version"`
+		Connected []struct {
+			RemoteHost string `json:"remote_host"`
+		} `json:"connected"`
+	} `json:"start"`
+	Intervals []struct {
+		Sum struct {
+			BitsPerSecond float64 `json:"bits_per_second"`
+			Omitted       bool    `json:"omitted"`
+		} `json:"sum"`
+	} `json:"intervals"`
+	End struct {
+		SumSent     iperf3Sum `json:"sum_sent"`
+		SumReceived iperf3Sum `json:"sum_received"`
+		Sum         iperf3Sum `json:"sum"` // UDP
+		Streams     []struct {
+			Sender struct {
+				MeanRTT float64 `json:"mean_rtt"` // µs
+			} `json:"sender"`
+		} `json:"streams"`
+	} `json:"end"`
+	Error string `json:"error"`
+}
+
+type iperf3Sum struct {
+	Bytes         int64   `json:"bytes"`
+	BitsPerSecond float64 `json:"bits_per_second"`
+	Retransmits   int64   `json:"retransmits"`
+	JitterMs      float64 `json:"jitter_ms"`
+	LostPackets   int64   `json:"lost_packets"`
+	Packets       int64   `json:"packets"`
+	LostPercent   float64 `json:"lost_percent"`
+}
+
+// parseIPerf3 decodes a test's output; iperf3's own error, else runErr, fails it.
+func parseIPerf3(raw []byte, runErr error) (*iperf3Output, error) {
+	out := &iperf3Output{}
+	if err := json.Unmarshal(raw, out); err != nil {
+		if runErr != nil {
+			var ee *exec.ExitError
+			if errors.As(runErr, &ee) && len(ee.Stderr) > 0 {
+				return nil, fmt.Errorf("iperf3: %s", strings.TrimSpace(string(ee.Stderr)))
+			}
+			return nil, fmt.Errorf("iperf3: %w", runErr)
+		}
+		return nil, fmt.Errorf("iperf3 output: %v", err)
+	}
+	if out.Error != "" {
+		return nil, fmt.Errorf("iperf3: %s", out.Error)
+	}
+	if runErr != nil {
+		return nil, fmt.Errorf("iperf3: %w", runErr)
+	}
+	return out, nil
+}
+
+func (o *iperf3Output) tcp() *IPerf3TCP {
+	t := &IPerf3TCP{
+		SentKbps:     o.End.SumSent.BitsPerSecond / 1000,
+		ReceivedKbps: o.End.SumReceived.BitsPerSecond / 1000,
+		Retransmits:  o.End.SumSent.Retransmits,
+	}
+	var rtt float64
+	n := 0
+	for _, s := range o.End.Streams {
+		if s.Sender.MeanRTT > 0 {
+			rtt += s.Sender.MeanRTT
+			n++
+		}
+	}
+	if n > 0 {
+		t.MeanRTTMs = rtt / float64(n) / 1000
+	}
+	for _, iv := range o.Intervals {
+		if !iv.Sum.Omitted {
+			t.SamplesKbps = append(t.SamplesKbps, iv.Sum.BitsPerSecond/1000)
+		}
+	}
+	return t
+}
+
+// addWireBytes counts the test's payload into u, the iperf3 client's own connections being
+// outside the monitor's dialers: received bytes when the server sent (Reverse), else sent bytes.
+// UDP results of iperf3 before 3.13 only carry the combined sum.
+func (o *iperf3Output) addWireBytes(u *wireUsage) {
+	if iperf3Reverse {
+		n := o.End.SumReceived.Bytes
+		if n == 0 {
+			n = o.End.Sum.Bytes
+		}
+		u.rx.Add(n)
+		return
+	}
+	n := o.End.SumSent.Bytes
+	if n == 0 {
+		n = o.End.Sum.Bytes
+	}
+	u.tx.Add(n)
+}
+
+// fillUDP copies the UDP result; iperf3 3.13 and later report the receiver's side as
+// sum_received, older versions only the combined sum.
+func (o *iperf3Output) fillUDP(u *IPerf3UDP) {
+	s := o.End.Sum
+	if o.End.SumReceived.Packets > 0 {
+		s = o.End.SumReceived
+	}
+	u.Kbps, u.JitterMs = s.BitsPerSecond/1000, s.JitterMs
+	u.LostPackets, u.Packets, u.LostPct = s.LostPackets, s.Packets, s.LostPercent
+}
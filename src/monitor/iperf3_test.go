@@ -0,0 +1,119 @@
+package monitor
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	typespkg "github.com/iafilius/InternetQualityMonitor/src/types"
+)
+
+const iperf3TCPJSON = `{"start":{"version":"iperf 3.12","connected":[{"remote_host":"192.0.2.10","remote_port":5201}]},
+"intervals":[{"sum":{"bits_per_second":9.1e7,"omitted":false}},{"sum":{"bits_per_second":9.3e7,"omitted":false}}],
+"end":{"sum_sent":{"bytes":117500000,"bits_per_second":9.4e7,"retransmits":12},"sum_received":{"bytes":115000000,"bits_per_second":9.2e7},
+"streams":[{"sender":{"mean_rtt":14000}}]}}`
+
+const iperf3UDPJSON = `{"start":{"version":"iperf 3.12"},"end":{"sum":{"bytes":61250000,"bits_per_second":4.9e7,"jitter_ms":0.35,"lost_packets":21,"packets":4200,"lost_percent":0.5}}}`
+
+func TestIPerf3Measurer(t *testing.T) {
+	prevRun, prevDelay, prevUDP, prevPar := runIPerf3, iperf3BusyDelay, iperf3UDPBitrate, iperf3Parallel
+	defer func() {
+		runIPerf3, iperf3BusyDelay, iperf3UDPBitrate, iperf3Parallel = prevRun, prevDelay, prevUDP, prevPar
+	}()
+	iperf3BusyDelay = time.Millisecond
+	SetIPerf3UDPBitrate("50M")
+	SetIPerf3Parallel(4)
+	var calls [][]string
+	runIPerf3 = func(ctx context.Context, args []string) ([]byte, error) {
+		calls = append(calls, args)
+		if len(calls) == 1 {
+			return []byte(`{"error":"the server is busy running a test. try again later"}`), nil
+		}
+		if strings.Contains(strings.Join(args, " "), "-u") {
+			return []byte(iperf3UDPJSON), nil
+		}
+		return []byte(iperf3TCPJSON), nil
+	}
+	site := typespkg.Site{Name: "capacity", URL: "iperf3://iperf.example.net", Probe: "iperf3"}
+	res := readResults(t, func() { MonitorSite(site) })
+	if len(res) != 1 || len(calls) != 3 {
+		t.Fatalf("lines=%d calls=%q", len(res), calls)
+	}
+	if got := strings.Join(calls[1], " "); got != "-c iperf.example.net -p 5201 -J -t 10 -R -P 4" {
+		t.Fatalf("tcp args: %s", got)
+	}
+	if got := strings.Join(calls[2], " "); got != "-c iperf.example.net -p 5201 -J -t 10 -R -u -b 50M" {
+		t.Fatalf("udp args: %s", got)
+	}
+	sr := res[0]
+	ip := sr.IPerf3
+	if sr.ProbeType != ProbeIPerf3 || sr.ProbeError != "" || sr.IP != "192.0.2.10" || ip == nil || ip.Version != "iperf 3.12" || !ip.Reverse || ip.Streams != 4 {
+		t.Fatalf("line: %+v %+v", sr, ip)
+	}
+	if tcp := ip.TCP; tcp == nil || tcp.ReceivedKbps != 92000 || tcp.SentKbps != 94000 || tcp.Retransmits != 12 || tcp.MeanRTTMs != 14 || len(tcp.SamplesKbps) != 2 {
+		t.Fatalf("tcp: %+v", ip.TCP)
+	}
+	if udp := ip.UDP; udp == nil || udp.Kbps != 49000 || udp.JitterMs != 0.35 || udp.LostPackets != 21 || udp.LostPct != 0.5 || udp.TargetBitrate != "50M" {
+		t.Fatalf("udp: %+v", ip.UDP)
+	}
+	if sr.WireRxBytes != 115000000+61250000 || sr.WireTxBytes != 0 || sr.TransferCapped {
+		t.Fatalf("wire bytes rx=%d tx=%d capped=%v", sr.WireRxBytes, sr.WireTxBytes, sr.TransferCapped)
+	}
+
+	// a reduced batch caps the TCP test and skips the UDP one
+	SetSamplingProfile(SamplingProfileLite)
+	SetLiteLimits(0, 1000000)
+	calls = nil
+	runIPerf3 = func(ctx context.Context, args []string) ([]byte, error) {
+		calls = append(calls, args)
+		return []byte(strings.Replace(iperf3TCPJSON, `"bytes":115000000`, `"bytes":1000000`, 1)), nil
+	}
+	res = readResults(t, func() { MonitorSite(site) })
+	SetSamplingProfile(SamplingProfileFull)
+	SetLiteLimits(0, 0)
+	if len(calls) != 1 || !strings.HasSuffix(strings.Join(calls[0], " "), "-R -P 4 -n 1000000") || len(res) != 1 || !res[0].TransferCapped || res[0].IPerf3.UDP != nil || res[0].WireRxBytes != 1000000 {
+		t.Fatalf("capped: calls=%q line=%+v", calls, res)
+	}
+
+	// canceling the run ends a test in progress; the site timeout does not
+	runIPerf3 = func(ctx context.Context, args []string) ([]byte, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	defer SetRunCanceled(false)
+	time.AfterFunc(50*time.Millisecond, func() { SetRunCanceled(true) })
+	res = readResults(t, func() { MonitorSite(site) })
+	SetRunCanceled(false)
+	if len(res) != 1 || !strings.Contains(res[0].ProbeError, "canceled") {
+		t.Fatalf("canceled run: %+v", res)
+	}
+
+	// a used-up budget skips the test
+	SetMonthlyBudget(1, 10, BudgetPolicyReduce)
+	res = readResults(t, func() { MonitorSite(site) })
+	SetMonthlyBudget(0, 10, BudgetPolicyReduce)
+	if len(res) != 1 || res[0].ProbeError != "iperf3: skipped, monthly data budget used up" {
+		t.Fatalf("over budget: %+v", res)
+	}
+
+	// a refused connection fails the line with iperf3's own message
+	SetIPerf3UDPBitrate("")
+	runIPerf3 = func(ctx context.Context, args []string) ([]byte, error) {
+		return []byte(`{"start":{},"end":{},"error":"unable to connect to server: Connection refused"}`), context.DeadlineExceeded
+	}
+	res = readResults(t, func() { MonitorSite(typespkg.Site{Name: "down", URL: "192.0.2.11:5202", Probe: "iperf3"}) })
+	if len(res) != 1 || res[0].ProbeError != "iperf3: unable to connect to server: Connection refused" || res[0].IPerf3.Server != "192.0.2.11:5202" || res[0].IPerf3.TCP != nil {
+		t.Fatalf("failed line: %+v", res)
+	}
+	if _, err := parseIPerf3([]byte("iperf3: parameter error"), nil); err == nil {
+		t.Fatal("non-JSON output accepted")
+	}
+}
+
+func TestSplitIPerf3Sites(t *testing.T) {
+	others, ip := SplitIPerf3Sites([]typespkg.Site{{Name: "a"}, {Name: "b", Probe: " IPerf3"}, {Name: "c", Probe: "soak"}})
+	if len(others) != 2 || others[0].Name != "a" || others[1].Name != "c" || len(ip) != 1 || ip[0].Name != "b" {
+		t.Fatalf("others=%v iperf3=%v", others, ip)
+	}
+}
@@ -1,8 +1,10 @@
 package monitor
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"log/slog"
 	"os"
 	"strings"
 	"sync/atomic"
@@ -31,6 +33,48 @@ var currentLevel int32 = int32(LevelInfo)
 
 var baseLogger = log.New(os.Stderr, "", log.Ldate|log.Ltime|log.Lmicroseconds)
 
+// logJSON switches the output to one slog JSON object per line (--log-format json), written to
+// baseLogger's writer so redirected output keeps working.
+var logJSON atomic.Bool
+
+// SetLogFormat selects "text" (the default "[LEVEL] message" lines) or "json".
+func SetLogFormat(s string) error {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "text":
+		logJSON.Store(false)
+	case "json":
+		logJSON.Store(true)
+	default:
+		return fmt.Errorf("log format must be text or json (got %q)", s)
+	}
+	return nil
+}
+
+// LogEnvDefault returns the environment variable's value (IQM_LOG_LEVEL, IQM_LOG_FORMAT) as the
+// default of the matching flag, or def when it is unset; an explicit flag still wins.
+func LogEnvDefault(name, def string) string {
+	if v := strings.TrimSpace(os.Getenv(name)); v != "" {
+		return v
+	}
+	return def
+}
+
+var slogLevels = map[LogLevel]slog.Level{LevelDebug: slog.LevelDebug, LevelInfo: slog.LevelInfo, LevelWarn: slog.LevelWarn, LevelError: slog.LevelError}
+
+// logJSONLine writes msg as a JSON record; a leading "[component]" tag such as "[dns-hijack]"
+// becomes a component attribute.
+func logJSONLine(l LogLevel, msg string) {
+	var attrs []slog.Attr
+	if strings.HasPrefix(msg, "[") {
+		if i := strings.Index(msg, "] "); i > 1 && !strings.ContainsAny(msg[1:i], " ") {
+			attrs = append(attrs, slog.String("component", msg[1:i]))
+			msg = msg[i+2:]
+		}
+	}
+	h := slog.NewJSONHandler(baseLogger.Writer(), &slog.HandlerOptions{Level: slog.LevelDebug})
+	slog.New(h).LogAttrs(context.Background(), slogLevels[l], msg, attrs...)
+}
+
 // SetLogLevel parses and sets global log level.
 func SetLogLevel(s string) {
 	l, ok := levelNames[strings.ToLower(strings.TrimSpace(s))]
@@ -60,11 +104,15 @@ func logf(l LogLevel, format string, args ...interface{}) {
 	}
 	// Only format when there are args; otherwise treat the input as a plain message to avoid
 	// fmt parsing literal % characters in already formatted strings (which would yield %!x(MISSING)).
-	if len(args) == 0 {
-		baseLogger.Printf("[%s] %s", prefix, format)
+	msg := format
+	if len(args) > 0 {
+		msg = fmt.Sprintf(format, args...)
+	}
+	if logJSON.Load() {
+		logJSONLine(l, msg)
 		return
 	}
-	baseLogger.Printf("[%s] %s", prefix, fmt.Sprintf(format, args...))
+	baseLogger.Printf("[%s] %s", prefix, msg)
 }
 
 // Public helpers
@@ -2,6 +2,7 @@ package monitor
 
 import (
 	"bytes"
+	"encoding/json"
 	"log"
 	"strings"
 	"testing"
@@ -27,3 +28,26 @@ func TestInfof_NoDoubleFormattingWithPercent(t *testing.T) {
 		t.Fatalf("log output still shows fmt artifact: %s", out)
 	}
 }
+
+func TestLogFormatJSON(t *testing.T) {
+	var buf bytes.Buffer
+	saved := baseLogger
+	baseLogger = log.New(&buf, "", 0)
+	defer func() { baseLogger = saved; SetLogFormat("text") }()
+	SetLogLevel("info")
+	if err := SetLogFormat("yaml"); err == nil {
+		t.Fatalf("unknown format accepted")
+	}
+	if err := SetLogFormat("json"); err != nil {
+		t.Fatalf("json: %v", err)
+	}
+	Warnf("[dns-hijack] resolver %s answered %d name(s)", "192.168.1.1:53", 2)
+	Debugf("dropped below the level")
+	var rec map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatalf("not one JSON line: %q (%v)", buf.String(), err)
+	}
+	if rec["level"] != "WARN" || rec["component"] != "dns-hijack" || rec["msg"] != "resolver 192.168.1.1:53 answered 2 name(s)" {
+		t.Fatalf("record: %v", rec)
+	}
+}
@@ -0,0 +1,182 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/iafilius/InternetQualityMonitor/src/types"
+)
+
+// Probe types built into the monitor. Each result line records its probe as probe_type; lines
+// written before probes existed have none and are HTTP measurements.
+const (
+	ProbeHTTP = "http"
+	ProbePing = "ping"
+	ProbeDNS  = "dns"
+	ProbeSoak = "soak" // one long-lived download sampled every --soak-interval
+	// raw TCP (and UDP) capacity with the system iperf3 client, see IPerf3Measurer
+	ProbeIPerf3 = "iperf3"
+)
+
+// Measurer is one kind of probe. A site selects it with "probe" in the sites file (default
+// http); MonitorSite and MonitorSiteIP look it up and hand over the site, so the batch loop,
+// IP fan-out, retries and progress reporting stay the same for every probe.
+//
+// Implementations write their own result lines with WriteSiteResult, one per measured IP or one
+// per site, and set SiteResult.ProbeType to their ProbeType and SiteResult.Group to
+// SiteGroup(site). ctx carries the site timeout.
+type Measurer interface {
+	ProbeType() string
+	// MeasureSite resolves the site itself and measures it.
+	MeasureSite(ctx context.Context, site types.Site)
+	// MeasureSiteIP measures one pre-resolved address (ip fan-out mode). dnsIPs are all
+	// addresses of the site and dnsTime is the lookup time main already spent.
+	MeasureSiteIP(ctx context.Context, site types.Site, ip net.IP, dnsIPs []string, dnsTime time.Duration)
+}
+
+var (
+	measurersMu sync.RWMutex
+	measurers   = map[string]Measurer{}
+)
+
+func init() {
+	for _, m := range []Measurer{HTTPMeasurer{}, PingMeasurer{}, DNSMeasurer{}, SoakMeasurer{}, IPerf3Measurer{}} {
+		if err := RegisterMeasurer(m); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// RegisterMeasurer adds a probe; call it from an init function before the sites are loaded.
+// Probe types are case-insensitive and must be unique.
+func RegisterMeasurer(m Measurer) error {
+	name := strings.ToLower(strings.TrimSpace(m.ProbeType()))
+	if name == "" {
+		return fmt.Errorf("measurer has an empty probe type")
+	}
+	measurersMu.Lock()
+	defer measurersMu.Unlock()
+	if _, dup := measurers[name]; dup {
+		return fmt.Errorf("probe type %q already registered", name)
+	}
+	measurers[name] = m
+	return nil
+}
+
+// LookupMeasurer returns the measurer for a probe type; "" means http.
+func LookupMeasurer(probe string) (Measurer, bool) {
+	name := strings.ToLower(strings.TrimSpace(probe))
+	if name == "" {
+		name = ProbeHTTP
+	}
+	measurersMu.RLock()
+	defer measurersMu.RUnlock()
+	m, ok := measurers[name]
+	return m, ok
+}
+
+// ProbeTypes lists the registered probe types, sorted.
+func ProbeTypes() []string {
+	measurersMu.RLock()
+	defer measurersMu.RUnlock()
+	out := make([]string, 0, len(measurers))
+	for name := range measurers {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// WriteSiteResult wraps sr in the batch meta and queues it for the results file; for use by
+// measurers outside this package.
+func WriteSiteResult(sr *SiteResult) { writeResult(wrapRoot(sr)) }
+
+// SiteGroup is the group a site's lines are recorded under: its "group", trimmed.
+func SiteGroup(site types.Site) string { return strings.TrimSpace(site.Group) }
+
+// siteContext bounds a site's measurement by --site-timeout (no bound when 0).
+func siteContext() (context.Context, context.CancelFunc) {
+	if siteTimeout > 0 {
+		return context.WithTimeout(context.Background(), siteTimeout)
+	}
+	return context.WithCancel(context.Background())
+}
+
+// withoutSiteTimeout drops ctx's --site-timeout but keeps the run's cancellation: the returned
+// context ends once the run is canceled (SetRunCanceled), for probes with their own duration.
+func withoutSiteTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	runCancelMu.Lock()
+	canceled := runCancelCh
+	runCancelMu.Unlock()
+	go func() {
+		select {
+		case <-canceled:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
+
+// HTTPMeasurer is the full HTTP measurement (HEAD, GET with speed samples, Range GET and the
+// optional experiments); it is the default probe.
+type HTTPMeasurer struct{}
+
+func (HTTPMeasurer) ProbeType() string { return ProbeHTTP }
+
+func (HTTPMeasurer) MeasureSite(ctx context.Context, site types.Site) { monitorHTTPSite(ctx, site) }
+
+func (HTTPMeasurer) MeasureSiteIP(ctx context.Context, site types.Site, ip net.IP, dnsIPs []string, dnsTime time.Duration) {
+	monitorOneIP(ctx, site, ip, ipIndex(ip, dnsIPs), dnsIPs, dnsTime)
+}
+
+// ipIndex is ip's position in dnsIPs (best effort, 0 when absent).
+func ipIndex(ip net.IP, dnsIPs []string) int {
+	s := ip.String()
+	for i, v := range dnsIPs {
+		if v == s {
+			return i
+		}
+	}
+	return 0
+}
+
+func ipFamilyOf(ip net.IP) string {
+	if ip.To4() != nil {
+		return "ipv4"
+	}
+	return "ipv6"
+}
+
+// resolveSite looks up the site's host for the non-HTTP probes, bounded like the HTTP probe's
+// lookup (--dns-timeout when there is no site timeout).
+func resolveSite(ctx context.Context, site types.Site) (host string, ips []string, took time.Duration, err error) {
+	host, err = siteHost(site)
+	if err != nil {
+		return "", nil, 0, err
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return host, []string{ip.String()}, 0, nil
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, dnsTimeoutDefault)
+		defer cancel()
+	}
+	start := time.Now()
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	took = time.Since(start)
+	for _, a := range addrs {
+		ips = append(ips, a.IP.String())
+	}
+	if err == nil && len(ips) == 0 {
+		err = fmt.Errorf("no addresses for %s", host)
+	}
+	return host, ips, took, err
+}
@@ -0,0 +1,228 @@
+package monitor
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	typespkg "github.com/iafilius/InternetQualityMonitor/src/types"
+)
+
+type fakeMeasurer struct{ calls *int }
+
+func (fakeMeasurer) ProbeType() string { return "fake" }
+
+func (f fakeMeasurer) MeasureSite(ctx context.Context, site typespkg.Site) {
+	*f.calls++
+	WriteSiteResult(&SiteResult{Name: site.Name, URL: site.URL, ProbeType: "fake"})
+}
+
+func (f fakeMeasurer) MeasureSiteIP(ctx context.Context, site typespkg.Site, ip net.IP, dnsIPs []string, dnsTime time.Duration) {
+	f.MeasureSite(ctx, site)
+}
+
+// readResults points the writer at a temp file and returns the lines run wrote.
+func readResults(t *testing.T, run func()) []*SiteResult {
+	t.Helper()
+	prevChan, prevPath := resultChan, resultPath
+	defer func() { resultChan, resultPath = prevChan, prevPath }()
+	resultChan = nil
+	resultPath = t.TempDir() + "/res.jsonl"
+	run()
+	f, err := os.Open(resultPath)
+	if err != nil {
+		t.Fatalf("open results: %v", err)
+	}
+	defer f.Close()
+	var out []*SiteResult
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var env ResultEnvelope
+		if err := json.Unmarshal(sc.Bytes(), &env); err != nil || env.SiteResult == nil {
+			t.Fatalf("decode result: %v", err)
+		}
+		out = append(out, env.SiteResult)
+	}
+	return out
+}
+
+func TestRegisterMeasurer(t *testing.T) {
+	calls := 0
+	if err := RegisterMeasurer(fakeMeasurer{&calls}); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	defer func() {
+		measurersMu.Lock()
+		delete(measurers, "fake")
+		measurersMu.Unlock()
+	}()
+	if RegisterMeasurer(fakeMeasurer{&calls}) == nil {
+		t.Fatal("duplicate probe type accepted")
+	}
+	if m, ok := LookupMeasurer(""); !ok || m.ProbeType() != ProbeHTTP {
+		t.Fatalf("default probe: %v %v", m, ok)
+	}
+	site := typespkg.Site{Name: "custom", URL: "https://example.invalid/", Probe: "Fake"}
+	if err := ValidateSite(site); err != nil {
+		t.Fatalf("registered probe rejected: %v", err)
+	}
+	res := readResults(t, func() { MonitorSite(site) })
+	if calls != 1 || len(res) != 1 || res[0].ProbeType != "fake" {
+		t.Fatalf("dispatch: calls=%d results=%+v", calls, res)
+	}
+	if ValidateSite(typespkg.Site{Name: "x", Probe: "smoke-signal"}) == nil {
+		t.Fatal("unknown probe accepted")
+	}
+	if ValidateSite(typespkg.Site{Name: "x", Probe: "ping", Method: "POST"}) == nil {
+		t.Fatal("http options accepted on a ping site")
+	}
+}
+
+func TestPingMeasurer(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			c.Close()
+		}
+	}()
+	port := ln.Addr().(*net.TCPAddr).Port
+	prevCount, prevInterval := pingCount, pingInterval
+	defer func() { pingCount, pingInterval = prevCount, prevInterval }()
+	SetPingCount(3)
+	SetPingInterval(0)
+	res := readResults(t, func() {
+		MonitorSite(typespkg.Site{Name: "ping", URL: "tcp://127.0.0.1:" + strconv.Itoa(port), Probe: "ping"})
+	})
+	if len(res) != 1 {
+		t.Fatalf("want one line for the single address, got %d", len(res))
+	}
+	sr := res[0]
+	if sr.ProbeType != ProbePing || sr.IPFamily != "ipv4" || sr.Ping == nil || sr.ProbeError != "" {
+		t.Fatalf("ping line: %+v", sr)
+	}
+	if p := sr.Ping; p.Port != port || p.Sent != 3 || p.Received != 3 || p.LossPct != 0 || len(p.RTTsMs) != 3 || p.MinMs > p.AvgMs || p.AvgMs > p.MaxMs {
+		t.Fatalf("ping stats: %+v", p)
+	}
+
+	// a closed port loses every connect
+	ln.Close()
+	res = readResults(t, func() {
+		MonitorSiteIP(typespkg.Site{Name: "ping", URL: "tcp://127.0.0.1:" + strconv.Itoa(port), Probe: "ping"}, "127.0.0.1", []string{"127.0.0.1"}, 0)
+	})
+	if len(res) != 1 || res[0].Ping.Received != 0 || res[0].Ping.LossPct != 100 || res[0].ProbeError == "" {
+		t.Fatalf("closed port: %+v", res)
+	}
+}
+
+func TestDNSMeasurer(t *testing.T) {
+	prev := dnsFamilyTimingEnabled
+	defer func() { dnsFamilyTimingEnabled = prev }()
+	dnsFamilyTimingEnabled = false
+	site := typespkg.Site{Name: "dns", URL: "https://localhost/", Probe: "dns", Group: " Intranet "}
+	res := readResults(t, func() {
+		MonitorSite(site)
+		// fanned out: only the first address triggers the lookup
+		MonitorSiteIP(site, "::1", []string{"127.0.0.1", "::1"}, 0)
+	})
+	if len(res) != 1 {
+		t.Fatalf("want one line per site, got %d", len(res))
+	}
+	if sr := res[0]; sr.ProbeType != ProbeDNS || len(sr.DNSIPs) == 0 || sr.ProbeError != "" || sr.IP != "" || sr.Group != "Intranet" {
+		t.Fatalf("dns line: %+v", sr)
+	}
+}
+
+func TestSoakMeasurer(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) == 2 {
+			// the second body breaks off mid-transfer
+			w.Header().Set("Content-Length", "100000")
+			w.Write(make([]byte, 1000))
+			return
+		}
+		w.Write(make([]byte, 20000))
+	}))
+	defer srv.Close()
+	prevDur, prevInt := soakDuration, soakInterval
+	defer func() { soakDuration, soakInterval = prevDur, prevInt }()
+	SetSoakDuration(500 * time.Millisecond)
+	SetSoakInterval(100 * time.Millisecond)
+	site := typespkg.Site{Name: "soak", URL: srv.URL + "/stream", Probe: "soak", Proxy: "direct"}
+	res := readResults(t, func() {
+		MonitorSiteIP(site, "127.0.0.1", []string{"127.0.0.1"}, 0)
+		MonitorSiteIP(site, "::1", []string{"127.0.0.1", "::1"}, 0) // not the first address
+	})
+	if len(res) != 1 {
+		t.Fatalf("want one line per site, got %d", len(res))
+	}
+	sr := res[0]
+	if sr.ProbeType != ProbeSoak || sr.Soak == nil || sr.ProbeError != "" {
+		t.Fatalf("soak line: %+v", sr)
+	}
+	s := sr.Soak
+	if n := len(s.SamplesKbps); n < 3 || n > 5 || s.IntervalMs != 100 || s.TargetMs != 500 {
+		t.Fatalf("samples: %+v", s)
+	}
+	if s.Requests < 2 || s.Interruptions != 1 || s.Bytes < 21000 || sr.TransferSizeBytes != s.Bytes {
+		t.Fatalf("stream accounting: %+v", s)
+	}
+	if sr.WireRxBytes <= s.Bytes || sr.WireTxBytes == 0 || sr.TransferCapped {
+		t.Fatalf("wire bytes rx=%d tx=%d for %d body bytes, capped=%v", sr.WireRxBytes, sr.WireTxBytes, s.Bytes, sr.TransferCapped)
+	}
+
+	// the lite cap ends the soak early; the bytes of the unfinished interval still count
+	SetSamplingProfile(SamplingProfileLite)
+	SetLiteLimits(0, 30000)
+	defer func() {
+		SetSamplingProfile(SamplingProfileFull)
+		SetLiteLimits(0, 0)
+	}()
+	SetSoakDuration(time.Minute)
+	res = readResults(t, func() { MonitorSite(site) })
+	if len(res) != 1 || !res[0].TransferCapped || res[0].Soak.Bytes != 30000 || res[0].Soak.DurationMs > 5000 {
+		t.Fatalf("capped soak: %+v %+v", res, res[0].Soak)
+	}
+	SetSamplingProfile(SamplingProfileFull)
+	SetSoakDuration(500 * time.Millisecond)
+
+	// nothing received is a probe error
+	srv.Close()
+	res = readResults(t, func() { MonitorSite(site) })
+	if len(res) != 1 || res[0].ProbeError == "" || res[0].Soak.Bytes != 0 {
+		t.Fatalf("closed server: %+v", res)
+	}
+}
+
+func TestInterarrivalJitter(t *testing.T) {
+	if j := InterarrivalJitter([]float64{20}); j != 0 {
+		t.Fatalf("single RTT jitter %.3f, want 0", j)
+	}
+	if j := InterarrivalJitter([]float64{20, 20, 20}); j != 0 {
+		t.Fatalf("steady RTTs jitter %.3f, want 0", j)
+	}
+	// seeded with the first |D| = 16, so a steady 16 ms swing reads 16 even in a short series
+	if j := InterarrivalJitter([]float64{10, 26, 10}); j != 16 {
+		t.Fatalf("jitter %.4f, want 16", j)
+	}
+	// 16, then 0: 16 + (0-16)/16 = 15
+	if j := InterarrivalJitter([]float64{10, 26, 26}); j != 15 {
+		t.Fatalf("jitter %.4f, want 15", j)
+	}
+}
@@ -0,0 +1,311 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os/exec"
+	"path"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MeteredInfo is the per-batch metered/captive network state. Metered comes from the OS where
+// it reports connection cost (Windows cost API, NetworkManager, which also honours the Android
+// ANDROID_METERED DHCP hint), a configured SSID, or phone-hotspot heuristics (hotspot SSID
+// names, the iOS/Android tethering subnets). Captive is set when the captive-portal check did
+// not get its expected 204, e.g. a hotel or train login page.
+type MeteredInfo struct {
+	Metered bool     `json:"metered"`
+	Captive bool     `json:"captive,omitempty"`
+	Source  string   `json:"source,omitempty"` // windows-cost, nmcli, ssid, heuristic, captive-check
+	SSID    string   `json:"ssid,omitempty"`
+	Signals []string `json:"signals,omitempty"`
+	// Action is what --metered-policy did with the batch: reduce (transfers capped) or skip.
+	Action string `json:"action,omitempty"`
+}
+
+// Metered policies for --metered-policy.
+const (
+	MeteredPolicyOff    = "off"    // no detection
+	MeteredPolicyRecord = "record" // detect and record meta.metered only
+	MeteredPolicyReduce = "reduce" // cap transfers at the metered byte budget; skip captive batches
+	MeteredPolicySkip   = "skip"   // skip metered and captive batches entirely
+)
+
+// DefaultMeteredMaxBytes is the per-transfer cap in reduce mode.
+const DefaultMeteredMaxBytes int64 = 1 << 20
+
+// DefaultCaptiveCheckURL answers 204 No Content unless a portal intercepts the request.
+const DefaultCaptiveCheckURL = "http://connectivitycheck.gstatic.com/generate_204"
+
+// hotspotSSIDHints are substrings of the default SSIDs phones use when tethering.
+var hotspotSSIDHints = []string{"iphone", "ipad", "android", "galaxy", "pixel", "hotspot", "mifi", "tether"}
+
+var (
+	meteredMu       sync.Mutex
+	meteredPolicy   = MeteredPolicyRecord
+	meteredSSIDs    []string
+	meteredMaxBytes = DefaultMeteredMaxBytes
+	captiveCheckURL = DefaultCaptiveCheckURL
+	meteredProbed   bool
+	meteredRunTag   string
+	meteredCached   *MeteredInfo
+	meteredProbe    = probeMetered // replaceable in tests
+	captiveProbe    = probeCaptive // replaceable in tests
+)
+
+// SetMeteredPolicy selects what happens on metered or captive networks (off, record, reduce, skip).
+func SetMeteredPolicy(p string) error {
+	p = strings.ToLower(strings.TrimSpace(p))
+	switch p {
+	case MeteredPolicyOff, MeteredPolicyRecord, MeteredPolicyReduce, MeteredPolicySkip:
+	default:
+		return fmt.Errorf("metered policy %q: want off, record, reduce or skip", p)
+	}
+	meteredMu.Lock()
+	defer meteredMu.Unlock()
+	meteredPolicy = p
+	return nil
+}
+
+// SetMeteredSSIDs marks Wi-Fi networks as metered by SSID (case-insensitive; path.Match globs
+// such as "Hotel*" are allowed).
+func SetMeteredSSIDs(ssids []string) {
+	meteredMu.Lock()
+	defer meteredMu.Unlock()
+	meteredSSIDs = meteredSSIDs[:0]
+	for _, s := range ssids {
+		if s = strings.ToLower(strings.TrimSpace(s)); s != "" {
+			meteredSSIDs = append(meteredSSIDs, s)
+		}
+	}
+}
+
+// SetMeteredMaxBytes sets the per-transfer byte cap of reduce mode (<= 0 restores the default).
+func SetMeteredMaxBytes(n int64) {
+	if n <= 0 {
+		n = DefaultMeteredMaxBytes
+	}
+	meteredMu.Lock()
+	defer meteredMu.Unlock()
+	meteredMaxBytes = n
+}
+
+// SetCaptiveCheckURL sets the 204 endpoint of the captive-portal check ("" disables the check).
+func SetCaptiveCheckURL(u string) {
+	meteredMu.Lock()
+	defer meteredMu.Unlock()
+	captiveCheckURL = strings.TrimSpace(u)
+}
+
+// MeteredAction returns the policy decision for batch tag: MeteredPolicySkip when the batch
+// should not be measured, MeteredPolicyReduce when transfers are capped, "" otherwise. The
+// detection runs once per tag, like the Wi-Fi and VPN probes.
+func MeteredAction(tag string) (string, *MeteredInfo) {
+	mi := meteredInfoForRun(tag)
+	if mi == nil {
+		return "", nil
+	}
+	return mi.Action, mi
+}
+
+// meteredInfoForRun returns the batch's metered state, nil when the policy is off or the network
+// is neither metered nor captive.
+func meteredInfoForRun(tag string) *MeteredInfo {
+	meteredMu.Lock()
+	defer meteredMu.Unlock()
+	if meteredPolicy == MeteredPolicyOff {
+		return nil
+	}
+	if meteredProbed && meteredRunTag == tag {
+		return meteredCached
+	}
+	meteredProbed = true
+	meteredRunTag = tag
+	var ssid string
+	if wi := wifiInfoForRun(tag); wi != nil {
+		ssid = wi.SSID
+	}
+	mi := meteredProbe(ssid, meteredSSIDs)
+	// only the policies that act on it pay for the network round trip
+	if (meteredPolicy == MeteredPolicyReduce || meteredPolicy == MeteredPolicySkip) && captiveCheckURL != "" {
+		if captive, signal := captiveProbe(captiveCheckURL); captive {
+			if mi == nil {
+				mi = &MeteredInfo{Source: "captive-check", SSID: ssid}
+			}
+			mi.Captive = true
+			mi.Signals = append(mi.Signals, signal)
+		}
+	}
+	if mi != nil {
+		switch {
+		case meteredPolicy == MeteredPolicySkip && (mi.Metered || mi.Captive):
+			mi.Action = MeteredPolicySkip
+		case meteredPolicy == MeteredPolicyReduce && mi.Captive:
+			// a portal answers every request with its login page; nothing useful to measure
+			mi.Action = MeteredPolicySkip
+		case meteredPolicy == MeteredPolicyReduce && mi.Metered:
+			mi.Action = MeteredPolicyReduce
+		}
+	}
+	meteredCached = mi
+	return mi
+}
+
+// reducedTransferCap returns the byte cap for transfers of the current batch, 0 when uncapped.
+// The batch is reduced by the metered policy or by the monthly budget guard (usage.go).
+func reducedTransferCap() int64 {
+	mi := meteredInfoForRun(runTag)
+	if (mi == nil || mi.Action != MeteredPolicyReduce) && !budgetReduced() {
+		return 0
+	}
+	meteredMu.Lock()
+	defer meteredMu.Unlock()
+	return meteredMaxBytes
+}
+
+// transferCap is the tighter of the reduced-mode and the lite byte caps, 0 when uncapped.
+func transferCap() int64 {
+	capBytes := reducedTransferCap()
+	if lc := liteTransferCap(); lc > 0 && (capBytes == 0 || lc < capBytes) {
+		capBytes = lc
+	}
+	return capBytes
+}
+
+// probeMetered asks the OS for the connection cost, then falls back to the configured SSIDs and
+// the hotspot heuristics. Returns nil when nothing indicates a metered link.
+func probeMetered(ssid string, configured []string) *MeteredInfo {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	switch runtime.GOOS {
+	case "windows":
+		ps := `[void][Windows.Networking.Connectivity.NetworkInformation,Windows.Networking.Connectivity,ContentType=WindowsRuntime];` +
+			`$p=[Windows.Networking.Connectivity.NetworkInformation]::GetInternetConnectionProfile();` +
+			`if($p){$c=$p.GetConnectionCost();"$($c.NetworkCostType) $($c.Roaming) $($c.OverDataLimit)"}`
+		if out, err := exec.CommandContext(ctx, "powershell", "-NoProfile", "-Command", ps).Output(); err == nil {
+			if metered, signals, ok := parseWindowsConnectionCost(string(out)); ok && metered {
+				return &MeteredInfo{Metered: true, Source: "windows-cost", SSID: ssid, Signals: signals}
+			}
+		}
+	case "linux":
+		if iface, _ := getDefaultInterface(); iface != "" {
+			if out, err := exec.CommandContext(ctx, "nmcli", "-t", "-f", "GENERAL.METERED", "dev", "show", iface).Output(); err == nil {
+				if metered, signal, ok := parseNMCLIMetered(string(out)); ok && metered {
+					return &MeteredInfo{Metered: true, Source: "nmcli", SSID: ssid, Signals: []string{signal}}
+				}
+			}
+		}
+	}
+	return classifyMetered(ssid, configured, getLocalOutboundIP())
+}
+
+// classifyMetered applies the platform-independent rules: a configured SSID first, then the
+// hotspot SSID names and the tethering subnets handed out by phones.
+func classifyMetered(ssid string, configured []string, localIP string) *MeteredInfo {
+	lower := strings.ToLower(ssid)
+	if lower != "" {
+		for _, pat := range configured {
+			if ok, _ := path.Match(pat, lower); ok || pat == lower {
+				return &MeteredInfo{Metered: true, Source: "ssid", SSID: ssid, Signals: []string{"configured ssid " + pat}}
+			}
+		}
+	}
+	var signals []string
+	for _, h := range hotspotSSIDHints {
+		if lower != "" && strings.Contains(lower, h) {
+			signals = append(signals, "hotspot ssid ("+h+")")
+			break
+		}
+	}
+	if ip := net.ParseIP(localIP).To4(); ip != nil {
+		switch {
+		case ip[0] == 172 && ip[1] == 20 && ip[2] == 10 && ip[3] < 16:
+			signals = append(signals, "iOS hotspot subnet 172.20.10.0/28")
+		case ip[0] == 192 && ip[1] == 168 && ip[2] == 43:
+			signals = append(signals, "Android tethering subnet 192.168.43.0/24")
+		}
+	}
+	if len(signals) == 0 {
+		return nil
+	}
+	return &MeteredInfo{Metered: true, Source: "heuristic", SSID: ssid, Signals: signals}
+}
+
+// parseWindowsConnectionCost parses "<NetworkCostType> <Roaming> <OverDataLimit>" as printed
+// by the PowerShell query. Fixed and Variable cost types are metered, as are roaming and over
+// the data limit.
+func parseWindowsConnectionCost(out string) (metered bool, signals []string, ok bool) {
+	f := strings.Fields(out)
+	if len(f) == 0 {
+		return false, nil, false
+	}
+	switch strings.ToLower(f[0]) {
+	case "fixed", "variable":
+		metered = true
+		signals = append(signals, "cost type "+f[0])
+	case "unrestricted", "unknown":
+	default:
+		return false, nil, false
+	}
+	if len(f) > 1 && strings.EqualFold(f[1], "true") {
+		metered = true
+		signals = append(signals, "roaming")
+	}
+	if len(f) > 2 && strings.EqualFold(f[2], "true") {
+		metered = true
+		signals = append(signals, "over data limit")
+	}
+	return metered, signals, true
+}
+
+// parseNMCLIMetered parses `nmcli -t -f GENERAL.METERED dev show` ("GENERAL.METERED:yes
+// (guessed)"). NetworkManager guesses "yes" from the ANDROID_METERED DHCP option of phones.
+func parseNMCLIMetered(out string) (metered bool, signal string, ok bool) {
+	for _, ln := range strings.Split(out, "\n") {
+		k, v, found := strings.Cut(strings.TrimSpace(ln), ":")
+		if !found || k != "GENERAL.METERED" {
+			continue
+		}
+		v = strings.ToLower(strings.TrimSpace(v))
+		switch {
+		case strings.HasPrefix(v, "yes"):
+			return true, "NetworkManager metered: " + v, true
+		case strings.HasPrefix(v, "no"):
+			return false, "", true
+		}
+		return false, "", false
+	}
+	return false, "", false
+}
+
+// probeCaptive fetches the 204 endpoint without following redirects. Anything but a 204 (a
+// redirect to a login page, a 200 with HTML) counts as captive; a network error does not, since a
+// link that is down is reported by the measurements themselves.
+func probeCaptive(u string) (bool, string) {
+	client := &http.Client{
+		Timeout: 5 * time.Second,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	resp, err := client.Get(u)
+	if err != nil {
+		return false, ""
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode == http.StatusNoContent {
+		return false, ""
+	}
+	signal := fmt.Sprintf("captive check status %d", resp.StatusCode)
+	if loc := resp.Header.Get("Location"); loc != "" {
+		signal += " -> " + loc
+	}
+	return true, signal
+}
@@ -0,0 +1,118 @@
+package monitor
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	typespkg "github.com/iafilius/InternetQualityMonitor/src/types"
+)
+
+func TestParseMeteredSources(t *testing.T) {
+	if m, sig, ok := parseWindowsConnectionCost("Variable False False\r\n"); !ok || !m || len(sig) != 1 {
+		t.Fatalf("variable cost: %v %v %v", m, sig, ok)
+	}
+	if m, sig, ok := parseWindowsConnectionCost("Unrestricted True False"); !ok || !m || sig[0] != "roaming" {
+		t.Fatalf("roaming: %v %v %v", m, sig, ok)
+	}
+	if m, _, ok := parseWindowsConnectionCost("Unrestricted False False"); !ok || m {
+		t.Fatalf("unrestricted reported metered=%v ok=%v", m, ok)
+	}
+	if _, _, ok := parseWindowsConnectionCost("Get-Thing : error"); ok {
+		t.Fatal("error output parsed")
+	}
+	if m, sig, ok := parseNMCLIMetered("GENERAL.METERED:yes (guessed)\n"); !ok || !m || !strings.Contains(sig, "guessed") {
+		t.Fatalf("nmcli guessed: %v %q %v", m, sig, ok)
+	}
+	if m, _, ok := parseNMCLIMetered("GENERAL.METERED:no (guessed)\n"); !ok || m {
+		t.Fatalf("nmcli no: %v %v", m, ok)
+	}
+	if _, _, ok := parseNMCLIMetered("GENERAL.METERED:unknown\n"); ok {
+		t.Fatal("unknown treated as known")
+	}
+}
+
+func TestClassifyMetered(t *testing.T) {
+	if mi := classifyMetered("Hotel Lobby", []string{"hotel*"}, "10.0.0.5"); mi == nil || mi.Source != "ssid" {
+		t.Fatalf("configured glob: %+v", mi)
+	}
+	if mi := classifyMetered("Bob's iPhone", nil, "172.20.10.3"); mi == nil || mi.Source != "heuristic" || len(mi.Signals) != 2 {
+		t.Fatalf("iPhone hotspot: %+v", mi)
+	}
+	if mi := classifyMetered("", nil, "192.168.43.20"); mi == nil || !mi.Metered {
+		t.Fatalf("android tethering subnet: %+v", mi)
+	}
+	if mi := classifyMetered("HomeNet", []string{"office"}, "192.168.1.20"); mi != nil {
+		t.Fatalf("home network flagged: %+v", mi)
+	}
+}
+
+func TestMeteredPolicy_ReduceCapsTransfers(t *testing.T) {
+	body := strings.Repeat("x", 512*1024)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	}))
+	defer srv.Close()
+	u, _ := url.Parse(srv.URL)
+	for _, k := range []string{"HTTP_PROXY", "HTTPS_PROXY", "ALL_PROXY", "NO_PROXY"} {
+		if v, ok := os.LookupEnv(k); ok {
+			t.Setenv(k, v)
+			os.Unsetenv(k)
+		}
+	}
+	prevProbe, prevCaptive, prevPolicy, prevMax, prevTag := meteredProbe, captiveProbe, meteredPolicy, meteredMaxBytes, runTag
+	defer func() {
+		meteredProbe, captiveProbe, meteredPolicy, meteredMaxBytes, runTag = prevProbe, prevCaptive, prevPolicy, prevMax, prevTag
+		meteredProbed, meteredCached = false, nil
+	}()
+	meteredProbe = func(string, []string) *MeteredInfo { return &MeteredInfo{Metered: true, Source: "ssid"} }
+	captive := false
+	captiveProbe = func(string) (bool, string) { return captive, "captive check status 302" }
+	if err := SetMeteredPolicy("Reduce"); err != nil {
+		t.Fatalf("policy: %v", err)
+	}
+	SetMeteredMaxBytes(64 * 1024)
+	SetRunTag("metered_1")
+	if action, mi := MeteredAction("metered_1"); action != MeteredPolicyReduce || mi.Source != "ssid" {
+		t.Fatalf("action=%q info=%+v", action, mi)
+	}
+
+	tmp := t.TempDir() + "/res.jsonl"
+	resultChan = nil
+	resultPath = tmp
+	MonitorSiteIP(typespkg.Site{Name: "big", URL: srv.URL + "/big"}, u.Hostname(), []string{u.Hostname()}, 0)
+	data, _ := os.ReadFile(tmp)
+	var env ResultEnvelope
+	if err := json.Unmarshal([]byte(strings.TrimSpace(string(data))), &env); err != nil || env.SiteResult == nil {
+		t.Fatalf("decode result: %v", err)
+	}
+	sr := env.SiteResult
+	if !sr.TransferCapped || sr.TransferSizeBytes >= int64(len(body)) || sr.HTTPError != "" || sr.ContentLengthMismatch {
+		t.Fatalf("capped transfer: capped=%v bytes=%d err=%q mismatch=%v", sr.TransferCapped, sr.TransferSizeBytes, sr.HTTPError, sr.ContentLengthMismatch)
+	}
+	if !env.Meta.ReducedMode || env.Meta.Metered == nil || env.Meta.Metered.Action != MeteredPolicyReduce {
+		t.Fatalf("meta: reduced=%v metered=%+v", env.Meta.ReducedMode, env.Meta.Metered)
+	}
+
+	// a captive portal skips the batch even in reduce mode; skip mode skips any metered batch
+	captive = true
+	if action, mi := MeteredAction("metered_2"); action != MeteredPolicySkip || !mi.Captive {
+		t.Fatalf("captive: action=%q info=%+v", action, mi)
+	}
+	captive = false
+	SetMeteredPolicy(MeteredPolicySkip)
+	if action, _ := MeteredAction("metered_3"); action != MeteredPolicySkip {
+		t.Fatalf("skip policy: action=%q", action)
+	}
+	SetMeteredPolicy(MeteredPolicyOff)
+	if action, mi := MeteredAction("metered_4"); action != "" || mi != nil {
+		t.Fatalf("off policy still detects: %q %+v", action, mi)
+	}
+	if SetMeteredPolicy("sometimes") == nil {
+		t.Fatal("unknown policy accepted")
+	}
+}
@@ -3,11 +3,13 @@ package monitor
 import (
 	"context"
 	"crypto/rand"
+	"crypto/sha256"
 	"crypto/tls"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"hash"
 	"io"
 	"math"
 	"net"
@@ -48,6 +50,18 @@ type SiteResult struct {
 	Name string `json:"name,omitempty"`
 	URL  string `json:"url,omitempty"`
 	IP   string `json:"ip,omitempty"`
+	// Target group of the site (types.Site.Group), trimmed; empty for ungrouped sites.
+	Group string `json:"group,omitempty"`
+	// Expected service level of the site (types.Site.SLO), copied so the analysis judges each
+	// line against the thresholds in force when it was measured; HTTP lines only.
+	SLO *types.SLO `json:"slo,omitempty"`
+	// Probe that produced the line (http, ping, dns or a registered Measurer); empty on lines
+	// written before probes existed, which are http. ProbeError is the failure of a non-HTTP probe.
+	ProbeType  string        `json:"probe_type,omitempty"`
+	ProbeError string        `json:"probe_error,omitempty"`
+	Ping       *PingResult   `json:"ping,omitempty"`
+	Soak       *SoakResult   `json:"soak,omitempty"`
+	IPerf3     *IPerf3Result `json:"iperf3,omitempty"`
 	// Migrated scalar timing / status fields
 	TCPTimeMs          int64  `json:"tcp_time_ms,omitempty"`
 	TCPError           string `json:"tcp_error,omitempty"`
@@ -64,6 +78,11 @@ type SiteResult struct {
 	TransferSpeedKbps float64 `json:"transfer_speed_kbps,omitempty"`
 	TransferStalled   bool    `json:"transfer_stalled,omitempty"`
 	StallElapsedMs    int64   `json:"stall_elapsed_ms,omitempty"`
+	// TransferCapped: the body read stopped at the reduced-mode byte cap (--metered-policy=reduce)
+	TransferCapped bool `json:"transfer_capped,omitempty"`
+	// Bytes read/written on this line's HTTP connections (headers, TLS and proxy overhead included)
+	WireRxBytes int64 `json:"wire_rx_bytes,omitempty"`
+	WireTxBytes int64 `json:"wire_tx_bytes,omitempty"`
 	// Secondary (range) GET
 	SecondGetStatus       int    `json:"second_get_status,omitempty"`
 	SecondGetTimeMs       int64  `json:"second_get_time_ms,omitempty"`
@@ -80,11 +99,13 @@ type SiteResult struct {
 	ConnectionReusedSecond bool  `json:"connection_reused_second_get,omitempty"`
 	// Protocol/TLS/encoding telemetry (for diagnostics, esp. with proxies)
 	HTTPProtocol      string   `json:"http_protocol,omitempty"`     // e.g., HTTP/1.1, HTTP/2.0
+	HTTPMethod        string   `json:"http_method,omitempty"`       // method of the measured request (GET, HEAD, POST)
 	TLSVersion        string   `json:"tls_version,omitempty"`       // e.g., TLS1.2, TLS1.3
 	TLSCipher         string   `json:"tls_cipher,omitempty"`        // e.g., TLS_AES_128_GCM_SHA256
 	ALPN              string   `json:"alpn,omitempty"`              // e.g., h2, http/1.1
 	TransferEncoding  string   `json:"transfer_encoding,omitempty"` // joined list, e.g., chunked
 	Chunked           bool     `json:"chunked,omitempty"`
+	ForcedHTTPVersion string   `json:"forced_http_version,omitempty"`
 	CountryConfigured string   `json:"country_configured,omitempty"`
 	CountryGeoIP      string   `json:"country_geoip,omitempty"`
 	DNSIPs            []string `json:"dns_ips,omitempty"`
@@ -115,11 +136,36 @@ type SiteResult struct {
 	TraceTLSMs        int64 `json:"trace_tls_ms,omitempty"`
 	TraceTimeToConnMs int64 `json:"trace_time_to_conn_ms,omitempty"`
 	HTTPConnectTimeMs int64 `json:"http_connect_time_ms,omitempty"`
+	// Dual-stack happy-eyeballs race to the origin (nil when disabled or the site is single-family)
+	HappyEyeballs *HappyEyeballs `json:"happy_eyeballs,omitempty"`
+	// A and AAAA lookups of the host timed separately (nil for IP-literal hosts or when disabled)
+	DNSFamily *DNSFamilyTiming `json:"dns_family,omitempty"`
+	// TTL and resolver cache hit of the site lookup (--dns-cache-check)
+	DNSCache *DNSCacheInfo `json:"dns_cache,omitempty"`
+	// Content-Encoding and encoded vs decoded body bytes of the GET (nil when the body was empty or
+	// --accept-encoding is "")
+	Compression *ResponseCompression `json:"compression,omitempty"`
+	// Backend durations the origin reported in Server-Timing headers (nil when it sent none)
+	ServerTiming *ServerTiming `json:"server_timing,omitempty"`
+	// Retransmissions, reordering and RTT variation of the line's TCP connections (tcp_stats.go)
+	TCPStats *TCPStats `json:"tcp_stats,omitempty"`
+	// Traceroute towards this IP (nil unless --route-trace; only the first IP per family of dual-stack sites)
+	RoutePath *RoutePath `json:"route_path,omitempty"`
+	// TTL of an echo reply from this IP and the estimated return hop count (nil unless --response-ttl)
+	ResponseTTL *ResponseTTL `json:"response_ttl,omitempty"`
+	// QUIC/UDP reachability of this IP (nil unless --quic-probe and an https target)
+	QUICProbe *QUICProbe `json:"quic_probe,omitempty"`
+	// Cold vs warm connection comparison (nil unless --reuse-experiment)
+	ReuseExperiment *ReuseExperiment `json:"reuse_experiment,omitempty"`
+	// AltSvcH3: the primary GET advertised HTTP/3 via Alt-Svc (a browser would try QUIC next)
+	AltSvcH3 bool `json:"alt_svc_h3,omitempty"`
 	// Headers (primary GET / HEAD)
 	HeaderVia    string `json:"header_via,omitempty"`
 	HeaderXCache string `json:"header_x_cache,omitempty"`
 	HeaderAge    string `json:"header_age,omitempty"`
 	HeaderServer string `json:"header_server,omitempty"`
+	// Selected response headers of the primary GET (--capture-headers), keyed by canonical name
+	ResponseHeaders map[string]string `json:"response_headers,omitempty"`
 	// Proxy identification (heuristic). proxy_suspected remains a broader flag; these fields
 	// attempt to classify the proxy/CDN if discernible from headers.
 	ProxyName   string `json:"proxy_name,omitempty"`
@@ -128,12 +174,14 @@ type SiteResult struct {
 	// contributed to proxy/CDN detection (e.g. cf-ray, x-akamai-request-id, x-zscaler-*) to aid
 	// downstream auditing & new heuristic refinement.
 	ProxyIndicators []string `json:"proxy_indicators,omitempty"`
-	// Go's proxy resolution (respecting environment variables like HTTPS_PROXY / NO_PROXY) for the target URL.
-	// This records what proxy (if any) the standard library would use before any custom transport overrides.
+	// The effective proxy of the request (credentials redacted): from the environment (HTTPS_PROXY /
+	// NO_PROXY) or, when configured, the site's proxy field, --proxy-bypass, --proxy-pac or --proxy.
+	// The field names predate the explicit configuration; ProxyConfigSource says which one decided.
 	EnvProxyURL string `json:"env_proxy_url,omitempty"`
-	// Whether NO_PROXY excluded the host (explicit bypass) when EnvProxyURL is empty.
-	EnvProxyBypassed bool `json:"env_proxy_bypassed,omitempty"`
-	UsingEnvProxy    bool `json:"using_env_proxy,omitempty"`
+	// Whether NO_PROXY, a bypass rule or a site "direct" override excluded the host when EnvProxyURL is empty.
+	EnvProxyBypassed  bool   `json:"env_proxy_bypassed,omitempty"`
+	UsingEnvProxy     bool   `json:"using_env_proxy,omitempty"`
+	ProxyConfigSource string `json:"proxy_config_source,omitempty"` // env|flag|pac|site|bypass
 	// When using a proxy, remote_ip reflects the proxy endpoint. These fields explicitly label and duplicate it
 	// so downstream processors need not infer from header heuristics.
 	ProxyRemoteIP      string `json:"proxy_remote_ip,omitempty"`
@@ -156,10 +204,29 @@ type SiteResult struct {
 	FirstRTTGoodputKbps   float64 `json:"first_rtt_goodput_kbps,omitempty"`
 	ContentLengthMismatch bool    `json:"content_length_mismatch,omitempty"`
 	ContentLengthHeader   int64   `json:"content_length_header,omitempty"`
+	// Body integrity against the site's expected sha256; only complete bodies are checked
+	ContentSHA256   string `json:"content_sha256,omitempty"`
+	ContentMismatch bool   `json:"content_mismatch,omitempty"`
+	// Throttling (429, or 503 with Retry-After) seen on any request to the target
+	RateLimited *RateLimit `json:"rate_limited,omitempty"`
 	// Samples & analysis
 	TransferSpeedSamples []SpeedSample  `json:"transfer_speed_samples,omitempty"`
 	SpeedAnalysis        *SpeedAnalysis `json:"speed_analysis,omitempty"`
+	// SpeedSketch summarizes TransferSpeedSamples as a mergeable quantile sketch (see sketch.go) so
+	// analysis can pool percentiles across lines and batches without holding every sample.
+	SpeedSketch *Sketch `json:"speed_sketch,omitempty"`
+	// SpeedSeries is the per-interval throughput of the transfer (--speed-series, speed_series.go)
+	SpeedSeries *SpeedSeries `json:"speed_series,omitempty"`
 	// Additional fields will be added progressively.
+
+	// started is the wall-clock start of the measurement (including DNS); not persisted, used for trace spans.
+	started time.Time
+	// usage counts the bytes of the line's connections into WireRxBytes/WireTxBytes.
+	usage *wireUsage
+	// tcp reads the TCP_INFO of the line's connections into TCPStats (nil when off).
+	tcp *tcpTracker
+	// pathProbes waits for the background route trace and response TTL probe (nil when off).
+	pathProbes func()
 }
 
 // SpeedSample represents one periodic throughput sample.
@@ -340,8 +407,11 @@ type SpeedAnalysis struct {
 // Meta holds environment & run metadata (strongly typed in schema v3+).
 type Meta struct {
 	TimestampUTC         string   `json:"timestamp_utc"`
-	Situation            string   `json:"situation,omitempty"` // Situation on front of json (struct keeps ordering)
-	RunTag               string   `json:"run_tag,omitempty"`   // RunTag also in front of json (struct keeps ordering)
+	UTCOffset            string   `json:"utc_offset,omitempty"` // monitor's local offset at TimestampUTC, e.g. "+02:00"
+	TimeZone             string   `json:"tz,omitempty"`         // local zone abbreviation at TimestampUTC, e.g. "CEST"
+	Situation            string   `json:"situation,omitempty"`  // Situation on front of json (struct keeps ordering)
+	RunTag               string   `json:"run_tag,omitempty"`    // RunTag also in front of json (struct keeps ordering)
+	Agent                string   `json:"agent,omitempty"`      // remote agent name (--agent-name / agent push mode)
 	Hostname             string   `json:"hostname,omitempty"`
 	OS                   string   `json:"os,omitempty"`
 	Arch                 string   `json:"arch,omitempty"`
@@ -375,7 +445,38 @@ type Meta struct {
 	MemFreeOrAvailable uint64 `json:"mem_free_or_available_bytes,omitempty"`
 	DiskRootTotalBytes uint64 `json:"disk_root_total_bytes,omitempty"`
 	DiskRootFreeBytes  uint64 `json:"disk_root_free_bytes,omitempty"`
-	SchemaVersion      int    `json:"schema_version"`
+	// Optional: local Wi-Fi link details (captured once per batch; absent on wired links)
+	WiFi *WiFiInfo `json:"wifi,omitempty"`
+	// Free-form batch tags from --tags (e.g. router_fw=1.2.3, isp=Acme)
+	Tags map[string]string `json:"tags,omitempty"`
+	// Metered/captive network state (--metered-policy); ReducedMode marks batches whose transfers
+	// were capped so they are never compared with full batches unnoticed.
+	Metered     *MeteredInfo `json:"metered,omitempty"`
+	ReducedMode bool         `json:"reduced_mode,omitempty"`
+	// SamplingProfile is "lite" for batches measured with --sampling-profile lite (fewer targets,
+	// capped transfers, no speed samples); empty for full batches
+	SamplingProfile string `json:"sampling_profile,omitempty"`
+	// Data usage totals at this line and the monthly budget state (--monthly-budget)
+	DataUsage *DataUsage `json:"data_usage,omitempty"`
+	// Canceled: the batch was being stopped (SIGINT/SIGTERM) when this line was written
+	Canceled bool `json:"canceled,omitempty"`
+	// NXDOMAIN rewriting check of the resolver, once per batch (--dns-hijack-check)
+	DNSHijack *DNSHijackInfo `json:"dns_hijack,omitempty"`
+	// Source binding of the batch (--interface/--source-ip); LocalIP is then the bound address
+	BindInterface string `json:"bind_interface,omitempty"`
+	BindSourceIP  string `json:"bind_source_ip,omitempty"`
+	// Outcome of --pre-batch-hook for this batch and --post-batch-hook of the previous one
+	Hooks *BatchHooks `json:"hooks,omitempty"`
+	// Settings the batch ran with and what changed since the previous batch (sites, parallel,
+	// timeouts)
+	Config *RunConfig `json:"config,omitempty"`
+	// Target pre-check of the batch (--health-check): hard-down targets, excluded or only reported
+	HealthCheck *HealthCheckInfo `json:"health_check,omitempty"`
+	// VPN/tunnel state detected once per batch (interfaces, default route, resolver search domains)
+	VPNActive     bool     `json:"vpn_active"`
+	VPNName       string   `json:"vpn_name,omitempty"`
+	VPN           *VPNInfo `json:"vpn,omitempty"`
+	SchemaVersion int      `json:"schema_version"`
 }
 
 type ResultEnvelope struct {
@@ -398,6 +499,14 @@ var (
 	maxIPsPerSite     int               // if >0 limit IPs processed per site (e.g. first v4 + first v6)
 )
 
+// runCanceled is set once the monitor is stopping mid-batch (see SetRunCanceled); runCancelCh
+// is closed at the same time, for probes that outlive their site context.
+var (
+	runCanceled atomic.Bool
+	runCancelMu sync.Mutex
+	runCancelCh = make(chan struct{})
+)
+
 // preTTFBStall holds whether pre-first-byte stall cancellation is enabled.
 // Configure via SetPreTTFBStall from callers (e.g., main). Default: disabled.
 var preTTFBStall atomic.Bool
@@ -486,7 +595,7 @@ func InitResultWriter(path string) {
 		writerWG.Add(1)
 		go func() {
 			defer writerWG.Done()
-			f, err := os.OpenFile(resultPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+			f, err := openResultsAppend(resultPath)
 			if err != nil {
 				fmt.Println("open results file:", err)
 				return
@@ -505,24 +614,66 @@ func InitResultWriter(path string) {
 	})
 }
 
-// CloseResultWriter flushes and closes the async writer.
+// CloseResultWriter flushes and closes the async writer; calling it again is a no-op.
 func CloseResultWriter() {
 	if resultChan != nil {
 		close(resultChan)
 		writerWG.Wait()
+		resultChan = nil
 	}
+	closeTraceExporter()
+	closeAgentPusher()
+	closeInfluxSink()
 }
 
 // context keys used to propagate ancillary info like DNS server used during resolution.
 type ctxKey string
 
 const (
-	ctxDNSAddrKey ctxKey = "dns_addr"
-	ctxDNSNetKey  ctxKey = "dns_net"
+	ctxDNSAddrKey  ctxKey = "dns_addr"
+	ctxDNSNetKey   ctxKey = "dns_net"
+	ctxDNSUsageKey ctxKey = "dns_usage" // bytes of the site's lookup, carried by its first IP's line
 )
 
-// MonitorSite performs the measurement and writes a JSONL line via writeResult.
+// MonitorSite measures site with its probe (see Measurer) and writes its JSONL line(s).
 func MonitorSite(site types.Site) {
+	m, ok := measurerFor(site)
+	if !ok {
+		return
+	}
+	ctx, cancel := siteContext()
+	defer cancel()
+	m.MeasureSite(ctx, site)
+}
+
+// MonitorSiteIP measures a single site & specific IP (pre-resolved) with the site's probe.
+// dnsIPs is the full list of resolved IPs for the site (both families) and dnsTimeMs
+// represents the DNS lookup duration in milliseconds for context.
+func MonitorSiteIP(site types.Site, ipStr string, dnsIPs []string, dnsTimeMs int64) {
+	ipAddr := net.ParseIP(ipStr)
+	if ipAddr == nil {
+		Errorf("[%s %s] invalid ip", site.Name, ipStr)
+		return
+	}
+	m, ok := measurerFor(site)
+	if !ok {
+		return
+	}
+	ctx, cancel := siteContext()
+	defer cancel()
+	m.MeasureSiteIP(ctx, site, ipAddr, dnsIPs, time.Duration(dnsTimeMs)*time.Millisecond)
+}
+
+func measurerFor(site types.Site) (Measurer, bool) {
+	m, ok := LookupMeasurer(site.Probe)
+	if !ok {
+		Errorf("[%s] unknown probe %q (registered: %s)", site.Name, site.Probe, strings.Join(ProbeTypes(), ", "))
+	}
+	return m, ok
+}
+
+// monitorHTTPSite is the HTTP probe for a whole site: resolve, then measure each selected IP.
+func monitorHTTPSite(ctx context.Context, site types.Site) {
 	parsed, err := url.Parse(site.URL)
 	if err != nil {
 		Errorf("parse url %s: %v", site.URL, err)
@@ -535,15 +686,7 @@ func MonitorSite(site types.Site) {
 	}
 	host := parsed.Hostname()
 
-	// Prepare context for entire site operation
 	startSite := time.Now()
-	ctx := context.Background()
-	if siteTimeout > 0 {
-		var cancel context.CancelFunc
-		ctx, cancel = context.WithTimeout(ctx, siteTimeout)
-		defer cancel()
-	}
-
 	// DNS resolve once (always context-aware). If no siteTimeout is set, bound DNS to 5s.
 	Debugf("[%s] DNS lookup %s", site.Name, host)
 	start := time.Now()
@@ -555,16 +698,28 @@ func MonitorSite(site types.Site) {
 		defer dnsCancel()
 	}
 	// Use a custom resolver to capture which DNS server was dialed (best-effort).
+	// Its bytes go to the first IP's line (or the DNS failure line).
 	var usedDNSServer, usedDNSServerNet string
+	dnsUsage := &wireUsage{}
 	resolver := &net.Resolver{
 		PreferGo: true,
 		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
 			usedDNSServerNet = network
 			usedDNSServer = address
-			d := &net.Dialer{Timeout: 2 * time.Second}
-			return d.DialContext(ctx, network, address)
+			return dnsUsage.dial(resolverDial(&net.Dialer{Timeout: 2 * time.Second}))(ctx, network, address)
 		},
 	}
+	// The per-family lookups run alongside the combined one, so they add no wall time.
+	var dnsFamily *DNSFamilyTiming
+	familyDone := make(chan struct{})
+	if dnsFamilyTimingEnabled {
+		go func() {
+			defer close(familyDone)
+			dnsFamily = lookupFamilies(dnsCtx, host)
+		}()
+	} else {
+		close(familyDone)
+	}
 	addrs, derr := resolver.LookupIPAddr(dnsCtx, host)
 	if derr != nil {
 		err = derr
@@ -574,8 +729,13 @@ func MonitorSite(site types.Site) {
 		}
 	}
 	dnsTime := time.Since(start)
+	<-familyDone
+	var dnsCache *DNSCacheInfo
+	if err == nil {
+		dnsCache = observeDNSCache(ctx, host, usedDNSServer, dnsTime)
+	}
 	if err != nil || len(ips) == 0 {
-		res := &SiteResult{Name: site.Name, URL: site.URL, CountryConfigured: site.Country, DNSTimeMs: dnsTime.Milliseconds()}
+		res := &SiteResult{Name: site.Name, URL: site.URL, CountryConfigured: site.Country, Group: SiteGroup(site), SLO: site.SLO, ProbeType: ProbeHTTP, DNSTimeMs: dnsTime.Milliseconds(), DNSFamily: dnsFamily, DNSCache: dnsCache, started: start, usage: dnsUsage}
 		// dns_error no longer persisted in v2; tcp_error/ssl_error/http_error fields retained.
 		writeResult(wrapRoot(res))
 		Warnf("[%s] DNS failed: %v", site.Name, err)
@@ -586,6 +746,7 @@ func MonitorSite(site types.Site) {
 		dnsIPs = append(dnsIPs, ipr.String())
 	}
 
+	ips = BindableIPs(ips)
 	// Optionally limit IPs processed (e.g. first v4 + first v6) to avoid long sequential work per site.
 	if maxIPsPerSite > 0 && len(ips) > maxIPsPerSite {
 		var selected []net.IP
@@ -623,36 +784,13 @@ func MonitorSite(site types.Site) {
 		// attach DNS server info into context for downstream recording
 		ctxWithDNS := context.WithValue(ctx, ctxDNSAddrKey, usedDNSServer)
 		ctxWithDNS = context.WithValue(ctxWithDNS, ctxDNSNetKey, usedDNSServerNet)
-		monitorOneIP(ctxWithDNS, site, ipAddr, idx, dnsIPs, dnsTime)
-	}
-}
-
-// MonitorSiteIP performs monitoring for a single site & specific IP (pre-resolved).
-// dnsIPs is the full list of resolved IPs for the site (both families) and dnsTimeMs
-// represents the DNS lookup duration in milliseconds for context.
-func MonitorSiteIP(site types.Site, ipStr string, dnsIPs []string, dnsTimeMs int64) {
-	ipAddr := net.ParseIP(ipStr)
-	if ipAddr == nil {
-		Errorf("[%s %s] invalid ip", site.Name, ipStr)
-		return
-	}
-	// Determine index in slice (best effort)
-	idx := 0
-	for i, v := range dnsIPs {
-		if v == ipStr {
-			idx = i
-			break
+		ctxWithDNS = context.WithValue(ctxWithDNS, ctxDNSFamilyKey, dnsFamily)
+		ctxWithDNS = context.WithValue(ctxWithDNS, ctxDNSCacheKey, dnsCache)
+		if idx == 0 {
+			ctxWithDNS = context.WithValue(ctxWithDNS, ctxDNSUsageKey, dnsUsage)
 		}
+		monitorOneIP(ctxWithDNS, site, ipAddr, idx, dnsIPs, dnsTime)
 	}
-	ctx := context.Background()
-	startSite := time.Now()
-	if siteTimeout > 0 {
-		var cancel context.CancelFunc
-		ctx, cancel = context.WithTimeout(ctx, siteTimeout)
-		defer cancel()
-	}
-	monitorOneIP(ctx, site, ipAddr, idx, dnsIPs, time.Duration(dnsTimeMs)*time.Millisecond)
-	_ = startSite // reserved for potential future site-level metrics
 }
 
 // monitorOneIP encapsulates the original per-IP logic from MonitorSite, allowing reuse by MonitorSiteIP.
@@ -665,25 +803,15 @@ func monitorOneIP(ctx context.Context, site types.Site, ipAddr net.IP, idx int,
 	}
 	// Info-level per-IP start marker so sessions show a clear begin line even without debug logging.
 	Infof("[%s %s] start", site.Name, ipStr)
-	// Determine environment proxy (standard library resolution) for transparency
-	var envProxyURL string
-	var envBypass bool
-	reqURL := &url.URL{Scheme: parsed.Scheme, Host: parsed.Host}
-	if pu, perr := http.ProxyFromEnvironment(&http.Request{URL: reqURL}); perr == nil {
-		if pu != nil {
-			envProxyURL = pu.String()
-		}
-	} else {
+	// Resolve the effective proxy (site override, bypass rules, PAC, --proxy, environment)
+	proxyURL, proxyConfigSource, proxyBypassed, perr := effectiveProxy(site, parsed, ipAddr)
+	if perr != nil {
 		Debugf("[%s %s] proxy resolution error: %v", site.Name, ipStr, perr)
-	}
-	if envProxyURL == "" { // check if NO_PROXY caused bypass
-		if os.Getenv("HTTPS_PROXY") != "" || os.Getenv("HTTP_PROXY") != "" || os.Getenv("ALL_PROXY") != "" {
-			envBypass = true
-		}
+		proxyURL = nil
 	}
 	var start time.Time
 	// Begin migration to typed SiteResult: maintain legacy map for rich metrics while introducing sr.
-	sr := &SiteResult{Name: site.Name, URL: site.URL, IP: ipStr, CountryConfigured: site.Country, DNSIPs: dnsIPs, DNSTimeMs: dnsTime.Milliseconds(), ResolvedIP: ipStr, IPIndex: idx}
+	sr := &SiteResult{Name: site.Name, URL: site.URL, IP: ipStr, CountryConfigured: site.Country, Group: SiteGroup(site), SLO: site.SLO, ProbeType: ProbeHTTP, DNSIPs: dnsIPs, DNSTimeMs: dnsTime.Milliseconds(), ResolvedIP: ipStr, IPIndex: idx, started: time.Now().Add(-dnsTime), usage: &wireUsage{}, tcp: newTCPTracker()}
 	// Populate DNS server info from context (best-effort)
 	if v := ctx.Value(ctxDNSAddrKey); v != nil {
 		if s, ok := v.(string); ok {
@@ -695,16 +823,45 @@ func monitorOneIP(ctx context.Context, site types.Site, ipAddr net.IP, idx int,
 			sr.DNSServerNetwork = s
 		}
 	}
-	if envProxyURL != "" {
-		sr.EnvProxyURL = envProxyURL
-	} else if envBypass {
+	if ft, ok := ctx.Value(ctxDNSFamilyKey).(*DNSFamilyTiming); ok {
+		sr.DNSFamily = ft
+	}
+	if dc, ok := ctx.Value(ctxDNSCacheKey).(*DNSCacheInfo); ok {
+		sr.DNSCache = dc
+	}
+	if du, ok := ctx.Value(ctxDNSUsageKey).(*wireUsage); ok {
+		sr.usage.rx.Add(du.rx.Load())
+		sr.usage.tx.Add(du.tx.Load())
+	}
+	if proxyURL != nil {
+		sr.EnvProxyURL = proxyURL.Redacted()
+	} else if proxyBypassed {
 		sr.EnvProxyBypassed = true
 	}
+	sr.ProxyConfigSource = proxyConfigSource
 	if ipAddr.To4() != nil {
 		sr.IPFamily = "ipv4"
 	} else {
 		sr.IPFamily = "ipv6"
 	}
+	// Dual-stack race (shared by all IPs of this site in the batch)
+	hePort := parsed.Port()
+	if hePort == "" {
+		hePort = "80"
+		if strings.EqualFold(parsed.Scheme, "https") {
+			hePort = "443"
+		}
+	}
+	sr.HappyEyeballs = happyEyeballsForSite(ctx, parsed.Hostname(), hePort, dnsIPs)
+	sr.pathProbes = startPathProbes(ctx, sr, ipStr, dnsIPs)
+	if strings.EqualFold(parsed.Scheme, "https") {
+		if p, err := strconv.Atoi(hePort); err == nil {
+			sr.QUICProbe = quicProbeForIP(ctx, sr.usage, ipStr, p)
+			if q := sr.QUICProbe; q != nil {
+				Debugf("[%s %s] quic probe udp/%d responded=%v blocked=%v rtt=%dms versions=%v", site.Name, ipStr, q.Port, q.Responded, q.UDPBlocked, q.RTTMs, q.Versions)
+			}
+		}
+	}
 
 	// GeoIP per IP (prefer GeoIP2 mmdb; fall back to legacy database on Linux only via build tag helper)
 	if country, ok := lookupGeoIP2Country(ipAddr); ok {
@@ -731,7 +888,7 @@ func monitorOneIP(ctx context.Context, site types.Site, ipAddr net.IP, idx int,
 	}
 	Debugf("[%s %s] TCP connect %s", site.Name, ipStr, target)
 	start = time.Now()
-	conn, cerr := net.DialTimeout("tcp", target, 10*time.Second)
+	conn, cerr := sr.usage.dial(boundDial(&net.Dialer{Timeout: 10 * time.Second}))(context.Background(), "tcp", target)
 	tcpTime := time.Since(start)
 	sr.TCPTimeMs = tcpTime.Milliseconds()
 	if cerr != nil {
@@ -747,7 +904,7 @@ func monitorOneIP(ctx context.Context, site types.Site, ipAddr net.IP, idx int,
 		cfg := &tls.Config{
 			ServerName: parsed.Hostname(),
 			// Advertise ALPN to learn negotiated protocol (h2 vs http/1.1) from this handshake.
-			NextProtos: []string{"h2", "http/1.1"},
+			NextProtos: alpnFor(siteHTTPVersion(site)),
 		}
 		tlsConn := tls.Client(conn, cfg)
 		// Ensure the manual handshake cannot block indefinitely. Use a bounded deadline
@@ -854,8 +1011,7 @@ func monitorOneIP(ctx context.Context, site types.Site, ipAddr net.IP, idx int,
 	var remoteIP string
 	dialCount := 0
 	var transport *http.Transport
-	if sr.EnvProxyURL != "" { // use proxy-aware transport; still wrap DialContext to record proxy connect timing & remoteIP
-		proxyURL, _ := url.Parse(sr.EnvProxyURL)
+	if proxyURL != nil { // use proxy-aware transport; still wrap DialContext to record proxy connect timing & remoteIP
 		transport = &http.Transport{
 			Proxy: http.ProxyURL(proxyURL),
 			TLSClientConfig: &tls.Config{
@@ -863,8 +1019,8 @@ func monitorOneIP(ctx context.Context, site types.Site, ipAddr net.IP, idx int,
 				NextProtos: []string{"h2", "http/1.1"},
 			},
 			DialContext: func(ctx context.Context, network, address string) (net.Conn, error) {
-				d := &net.Dialer{Timeout: 10 * time.Second, KeepAlive: 30 * time.Second}
-				c, e := d.DialContext(ctx, network, address)
+				c, e := boundDial(&net.Dialer{Timeout: 10 * time.Second, KeepAlive: 30 * time.Second})(ctx, network, address)
+				c = sr.usage.wrap(sr.tcp.wrap(c))
 				if e == nil && remoteIP == "" {
 					if ta, ok := c.RemoteAddr().(*net.TCPAddr); ok {
 						remoteIP = ta.IP.String()
@@ -899,8 +1055,8 @@ func monitorOneIP(ctx context.Context, site types.Site, ipAddr net.IP, idx int,
 			ServerName: parsed.Hostname(),
 			NextProtos: []string{"h2", "http/1.1"},
 		}, DialContext: func(ctx context.Context, network, address string) (net.Conn, error) {
-			d := &net.Dialer{Timeout: 10 * time.Second}
-			c, e := d.DialContext(ctx, network, target)
+			c, e := boundDial(&net.Dialer{Timeout: 10 * time.Second})(ctx, network, target)
+			c = sr.usage.wrap(sr.tcp.wrap(c))
 			if e == nil && remoteIP == "" {
 				if ta, ok := c.RemoteAddr().(*net.TCPAddr); ok {
 					remoteIP = ta.IP.String()
@@ -926,12 +1082,17 @@ func monitorOneIP(ctx context.Context, site types.Site, ipAddr net.IP, idx int,
 			ExpectContinueTimeout: 2 * time.Second,
 		}
 	}
+	if v := siteHTTPVersion(site); v != "" {
+		forceHTTPVersion(transport, v)
+		sr.ForcedHTTPVersion = v
+	}
 	client := &http.Client{Transport: transport, Timeout: httpTimeout}
 
 	// HEAD (with one-shot transient retry)
 	Debugf("[%s %s] HEAD %s", site.Name, ipStr, site.URL)
 	doHEAD := func() (*http.Response, time.Duration, error) {
 		req, _ := http.NewRequestWithContext(ctx, "HEAD", site.URL, nil)
+		applySiteRequest(req, site)
 		req.Header.Set("X-Probe", probeVal)
 		st := time.Now()
 		r, e := client.Do(req)
@@ -951,13 +1112,17 @@ func monitorOneIP(ctx context.Context, site types.Site, ipAddr net.IP, idx int,
 		fillProtocolTLSAndEncoding(sr, headResp)
 		headResp.Body.Close()
 		sr.HeadStatus = headResp.StatusCode
+		sr.RateLimited = rateLimitFrom(headResp, "head")
 	} else if headErr != nil {
 		sr.HeadError = headErr.Error()
 	}
 
 	// GET with trace (with one-shot retry on transient errors like EOF/reset)
 	var dnsStartT, dnsDoneT, connStartT, connDoneT, tlsStartT, tlsDoneT, gotConnT, gotFirstByteT time.Time
-	Debugf("[%s %s] GET %s", site.Name, ipStr, site.URL)
+	method := siteMethod(site)
+	sr.HTTPMethod = method
+	var sampleBody, compressionAsked bool
+	Debugf("[%s %s] %s %s", site.Name, ipStr, method, site.URL)
 	doGET := func() (*http.Response, error) {
 		dnsStartT, dnsDoneT, connStartT, connDoneT, tlsStartT, tlsDoneT, gotConnT, gotFirstByteT = time.Time{}, time.Time{}, time.Time{}, time.Time{}, time.Time{}, time.Time{}, time.Time{}, time.Time{}
 		// If pre-TTFB stall cancellation is enabled, use a child context to allow targeted cancel.
@@ -967,8 +1132,13 @@ func monitorOneIP(ctx context.Context, site types.Site, ipAddr net.IP, idx int,
 			reqBaseCtx, reqCancel = context.WithCancel(ctx)
 			defer reqCancel()
 		}
-		req, _ := http.NewRequestWithContext(reqBaseCtx, "GET", site.URL, nil)
+		req, err := newSiteRequest(reqBaseCtx, method, site)
+		if err != nil {
+			return nil, err
+		}
 		req.Header.Set("X-Probe", probeVal)
+		sampleBody = applyAcceptEncoding(req)
+		compressionAsked = compressionOffered(req.Header.Get("Accept-Encoding"))
 		trace := &httptrace.ClientTrace{DNSStart: func(info httptrace.DNSStartInfo) { dnsStartT = time.Now() }, DNSDone: func(info httptrace.DNSDoneInfo) { dnsDoneT = time.Now() }, ConnectStart: func(network, addr string) { connStartT = time.Now() }, ConnectDone: func(network, addr string, err error) { connDoneT = time.Now() }, TLSHandshakeStart: func() { tlsStartT = time.Now() }, TLSHandshakeDone: func(cs tls.ConnectionState, err error) { tlsDoneT = time.Now() }, GotConn: func(info httptrace.GotConnInfo) { gotConnT = time.Now() }, GotFirstResponseByte: func() { gotFirstByteT = time.Now() }}
 		req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
 		start = time.Now()
@@ -1051,11 +1221,26 @@ func monitorOneIP(ctx context.Context, site types.Site, ipAddr net.IP, idx int,
 	}
 	// Populate protocol/TLS/encoding from the response so http_protocol/alpn/tls_ver are not left unknown
 	fillProtocolTLSAndEncoding(sr, resp)
+	// A throttled GET carries an error page, not the measured body: record it and skip the
+	// transfer so the line reads as rate limited instead of a slow or broken download.
+	if rl := rateLimitFrom(resp, "get"); rl != nil {
+		resp.Body.Close()
+		sr.RateLimited = rl
+		if sr.HTTPError == "" {
+			sr.HTTPError = fmt.Sprintf("rate_limited: status=%d retry_after=%q", rl.Status, rl.RetryAfter)
+		}
+		Warnf("[%s %s] rate limited: GET status %d, Retry-After %q", site.Name, ipStr, rl.Status, rl.RetryAfter)
+		writeResult(wrapRoot(sr))
+		return
+	}
 	// content length header handled later into sr.ContentLengthHeader
 	via := resp.Header.Get("Via")
 	xcache := resp.Header.Get("X-Cache")
 	ageHeader := resp.Header.Get("Age")
 	serverHeader := resp.Header.Get("Server")
+	sr.AltSvcH3 = altSvcAdvertisesH3(resp.Header.Get("Alt-Svc"))
+	sr.ResponseHeaders = captureResponseHeaders(resp.Header)
+	sr.ServerTiming = parseServerTiming(resp.Header)
 	sr.HeaderVia = via
 	sr.HeaderXCache = xcache
 	if ageHeader != "" {
@@ -1247,6 +1432,12 @@ func monitorOneIP(ctx context.Context, site types.Site, ipAddr net.IP, idx int,
 	buf := make([]byte, 32*1024)
 	var speedSamples []SpeedSample
 	nextSample := transferStart.Add(SpeedSampleInterval)
+	var bodyHash hash.Hash
+	if strings.TrimSpace(site.SHA256) != "" && method != http.MethodHead {
+		bodyHash = sha256.New()
+	}
+	bodyComplete := false
+	capBytes := transferCap()
 	lastProgressLog := time.Now()
 	lastProgress := time.Now()
 	// Make the first visible progress explicit at info level even if Content-Length is unknown
@@ -1259,6 +1450,15 @@ func monitorOneIP(ctx context.Context, site types.Site, ipAddr net.IP, idx int,
 			expectedBytes = v
 		}
 	}
+	var body io.Reader = resp.Body
+	var sampler *bodySampler
+	if sampleBody {
+		sampler = newBodySampler(resp, compressionAsked)
+		body = sampler
+		if sampler.res.Encoding != "" && sampler.decodes() {
+			expectedBytes = 0 // Content-Length counts the encoded bytes
+		}
+	}
 	// Watchdog goroutine: logs if no additional bytes for half stallTimeout (but does not abort; abort handled inline)
 	watchdogQuit := make(chan struct{})
 	var lastBytesLogged int64
@@ -1295,10 +1495,13 @@ func monitorOneIP(ctx context.Context, site types.Site, ipAddr net.IP, idx int,
 		}(site.Name, ipStr, expectedBytes)
 	}
 	for {
-		n, er := resp.Body.Read(buf)
+		n, er := body.Read(buf)
 		bytesRead += int64(n)
 		if n > 0 {
 			lastProgress = time.Now()
+			if bodyHash != nil {
+				bodyHash.Write(buf[:n])
+			}
 		}
 		progressInterval := 3 * time.Second
 		if getLevel() == LevelInfo {
@@ -1349,6 +1552,7 @@ func monitorOneIP(ctx context.Context, site types.Site, ipAddr net.IP, idx int,
 		if er != nil {
 			// Normal end of stream or early termination
 			if errors.Is(er, io.EOF) {
+				bodyComplete = true
 				if expectedBytes > 0 && bytesRead < expectedBytes {
 					// Early EOF; the mismatch flag will be set below. Log a concise debug for diagnostics.
 					Debugf("[%s %s] early EOF at %d/%d bytes (%.1f%%)", site.Name, ipStr, bytesRead, expectedBytes, (float64(bytesRead)*100.0)/float64(expectedBytes))
@@ -1356,6 +1560,11 @@ func monitorOneIP(ctx context.Context, site types.Site, ipAddr net.IP, idx int,
 			}
 			break
 		}
+		if capBytes > 0 && bytesRead >= capBytes {
+			Debugf("[%s %s] reduced mode: transfer capped at %d bytes", site.Name, ipStr, bytesRead)
+			sr.TransferCapped = true
+			break
+		}
 		// Stall detection
 		if stallTimeout > 0 && time.Since(lastProgress) > stallTimeout {
 			if expectedBytes > 0 {
@@ -1393,6 +1602,14 @@ func monitorOneIP(ctx context.Context, site types.Site, ipAddr net.IP, idx int,
 	sr.TransferSizeBytes = bytesRead
 	sr.TransferSpeedKbps = speed
 	sr.TransferSpeedSamples = speedSamples
+	sr.SpeedSeries = speedSeriesFor(speedSamples, sr.TransferTimeMs, bytesRead)
+	wireBodyBytes := bytesRead
+	if sampler != nil {
+		sr.Compression = sampler.result(bytesRead)
+		if sr.Compression != nil && sr.Compression.Encoding != "" {
+			wireBodyBytes = sr.Compression.EncodedBytes
+		}
+	}
 	if rawRTTms > 0 {
 		firstGoodput := float64(firstRTTBytes) / (float64(rawRTTms) / 1000) / 1024
 		sr.FirstRTTBytes = firstRTTBytes
@@ -1401,24 +1618,39 @@ func monitorOneIP(ctx context.Context, site types.Site, ipAddr net.IP, idx int,
 	if clHeader != "" {
 		if clVal, e := strconv.ParseInt(clHeader, 10, 64); e == nil {
 			sr.ContentLengthHeader = clVal
-			sr.ContentLengthMismatch = (clVal != bytesRead)
+			sr.ContentLengthMismatch = (clVal != wireBodyBytes) && !sr.TransferCapped
 			// If server closed the connection before delivering the advertised Content-Length,
 			// treat this as an incomplete transfer. Surface it as an HTTPError so analysis counts it.
 			if sr.ContentLengthMismatch && sr.HTTPError == "" {
-				sr.HTTPError = fmt.Sprintf("partial_body: expected=%d read=%d", clVal, bytesRead)
-				Warnf("[%s %s] content-length mismatch: expected=%d read=%d", site.Name, ipStr, clVal, bytesRead)
+				sr.HTTPError = fmt.Sprintf("partial_body: expected=%d read=%d", clVal, wireBodyBytes)
+				Warnf("[%s %s] content-length mismatch: expected=%d read=%d", site.Name, ipStr, clVal, wireBodyBytes)
+			}
+		}
+	}
+	// A truncated body would always mismatch; that case is already reported as partial_body.
+	if bodyHash != nil && bodyComplete && !sr.ContentLengthMismatch {
+		sr.ContentSHA256 = hex.EncodeToString(bodyHash.Sum(nil))
+		if want := strings.ToLower(strings.TrimSpace(site.SHA256)); sr.ContentSHA256 != want {
+			sr.ContentMismatch = true
+			if sr.HTTPError == "" {
+				sr.HTTPError = fmt.Sprintf("content_mismatch: sha256=%s expected=%s", sr.ContentSHA256, want)
 			}
+			Warnf("[%s %s] content integrity mismatch: sha256=%s expected=%s (%d bytes)", site.Name, ipStr, sr.ContentSHA256, want, bytesRead)
 		}
 	}
 
 	// Secondary Range GET (with one-shot transient retry)
 	var rangeProgressCh chan struct{}
 	doSecondGET := func() (*http.Response, time.Duration, error) {
+		if method != http.MethodGet {
+			return nil, 0, nil // the cache probe re-fetches a GET resource; not meaningful for HEAD/POST targets
+		}
 		st := time.Now()
 		// Child context to allow mid-body stall cancellation
 		rCtx, rCancel := context.WithCancel(ctx)
 		defer rCancel()
 		req, _ := http.NewRequestWithContext(rCtx, "GET", site.URL, nil)
+		applySiteRequest(req, site)
 		req.Header.Set("X-Probe", probeVal)
 		req.Header.Set("Range", "bytes=0-65535")
 		// Start a watchdog that will cancel if no progress is made beyond stallTimeout once body starts
@@ -1466,6 +1698,9 @@ func monitorOneIP(ctx context.Context, site types.Site, ipAddr net.IP, idx int,
 		// Also capture protocol/TLS/encoding from the Range response (usually same connection)
 		fillProtocolTLSAndEncoding(sr, secondResp)
 		sr.SecondGetStatus = secondResp.StatusCode
+		if sr.RateLimited == nil {
+			sr.RateLimited = rateLimitFrom(secondResp, "range")
+		}
 		sr.SecondGetTimeMs = secondGetTime.Milliseconds()
 		sr.SecondGetHeaderAge = secondResp.Header.Get("Age")
 		sr.SecondGetXCache = secondResp.Header.Get("X-Cache")
@@ -1574,6 +1809,7 @@ func monitorOneIP(ctx context.Context, site types.Site, ipAddr net.IP, idx int,
 	// Warm HEAD
 	warmHeadStart := time.Now()
 	warmHeadReq, _ := http.NewRequestWithContext(ctx, "HEAD", site.URL, nil)
+	applySiteRequest(warmHeadReq, site)
 	warmHeadReq.Header.Set("X-Probe", probeVal)
 	warmHeadResp, warmHeadErr := client.Do(warmHeadReq)
 	warmHeadTime := time.Since(warmHeadStart)
@@ -1586,6 +1822,12 @@ func monitorOneIP(ctx context.Context, site types.Site, ipAddr net.IP, idx int,
 	sr.WarmCacheSuspected = (warmHeadSpeedup && cachePresent)
 	sr.DialCount = dialCount
 	sr.ConnectionReusedSecond = (dialCount == 1)
+	if reuseExperimentEnabled {
+		sr.ReuseExperiment = runReuseExperiment(ctx, client, transport, site, probeVal)
+		if ex := sr.ReuseExperiment; ex != nil {
+			Debugf("[%s %s] reuse experiment cold_ttfb=%dms warm_ttfb=%dms reused=%v setup_cost=%dms", site.Name, ipStr, ex.ColdTTFBMs, ex.WarmTTFBMs, ex.WarmReused, ex.SetupCostMs)
+		}
+	}
 
 	// Speed / stats analysis (reusing existing logic)
 	var avgSpeed, stddevSpeed float64
@@ -1832,6 +2074,11 @@ func monitorOneIP(ctx context.Context, site types.Site, ipAddr net.IP, idx int,
 		}
 	}
 	sr.SpeedAnalysis = analysis
+	sr.SpeedSketch = SketchOf(speedSamples)
+	if liteSampling() {
+		// lite keeps the summaries derived above (speed_analysis, speed_sketch), not the samples
+		sr.TransferSpeedSamples = nil
+	}
 
 	writeResult(wrapRoot(sr))
 	headStatus := sr.HeadStatus
@@ -1862,6 +2109,8 @@ func monitorOneIP(ctx context.Context, site types.Site, ipAddr net.IP, idx int,
 		statusLabel = "aborted"
 	} else if sr.ContentLengthMismatch {
 		statusLabel = "incomplete"
+	} else if sr.ContentMismatch {
+		statusLabel = "corrupt"
 	}
 	extra := formatPercentOf(transferBytes, sr.ContentLengthHeader)
 	finalLine := fmt.Sprintf("[%s %s] %s head=%d sec_get=%d bytes=%d%s time=%dms speed=%.1fkbps dns=%dms tcp=%dms tls=%dms ttfb=%dms proto=%s alpn=%s tls_ver=%s", site.Name, ipStr, statusLabel, headStatus, secStatus, transferBytes, extra, transferTime, transferSpeed, dnsMs, tcpMs, sslMs, ttfbMs, proto, alpn, tlsv)
@@ -2202,6 +2451,34 @@ func wrapRoot(sr *SiteResult) *ResultEnvelope {
 	if meta.ConnectionType == "" {
 		meta.ConnectionType = detectConnectionType()
 	}
+	meta.WiFi = wifiInfoForRun(runTag)
+	if vi := vpnInfoForRun(runTag); vi != nil {
+		meta.VPN = vi
+		meta.VPNActive = vi.Active
+		meta.VPNName = vi.Name
+	}
+	meta.DNSHijack = dnsHijackInfoForRun(runTag)
+	meta.Hooks = batchHooksForRun(runTag)
+	meta.Config = runConfigForRun(runTag)
+	meta.HealthCheck = healthCheckForRun(runTag)
+	if mi := meteredInfoForRun(runTag); mi != nil {
+		meta.Metered = mi
+		meta.ReducedMode = mi.Action == MeteredPolicyReduce
+	}
+	if sr != nil && sr.tcp != nil {
+		sr.TCPStats = sr.tcp.stats()
+	}
+	if sr != nil && sr.pathProbes != nil {
+		sr.pathProbes()
+		sr.pathProbes = nil
+	}
+	meta.DataUsage = accountUsage(runTag, sr)
+	if meta.DataUsage.BudgetAction == BudgetPolicyReduce {
+		meta.ReducedMode = true
+	}
+	meta.SamplingProfile = RecordedSamplingProfile()
+	meta.Canceled = runCanceled.Load()
+	meta.Agent = effectiveAgentName()
 	meta.HomeOfficeEstimate = classifyClientEnvironment(meta)
 	return &ResultEnvelope{Meta: meta, SiteResult: sr}
 }
@@ -2209,6 +2486,24 @@ func wrapRoot(sr *SiteResult) *ResultEnvelope {
 // SetRunTag sets the batch/run tag added into meta for each result line.
 func SetRunTag(tag string) { runTag = tag }
 
+// SetRunCanceled marks the running batch as canceled: the lines still in flight are written
+// with meta.canceled, which is how analysis tells a partial batch from a failing one.
+func SetRunCanceled(c bool) {
+	runCanceled.Store(c)
+	runCancelMu.Lock()
+	defer runCancelMu.Unlock()
+	select {
+	case <-runCancelCh:
+		if !c {
+			runCancelCh = make(chan struct{})
+		}
+	default:
+		if c {
+			close(runCancelCh)
+		}
+	}
+}
+
 // SetSituation sets the situation label (e.g., Home, Office, VPN) embedded in meta for each result.
 func SetSituation(s string) { currentSituation = s }
 func gatherBaseMeta() *Meta {
@@ -2288,6 +2583,7 @@ func gatherBaseMeta() *Meta {
 		}
 		m.SchemaVersion = SchemaVersion
 		m.Situation = currentSituation
+		m.Tags = batchTags
 		if localSelfTestKbps > 0 {
 			m.LocalSelfTestKbps = localSelfTestKbps
 		}
@@ -2298,7 +2594,15 @@ func gatherBaseMeta() *Meta {
 	})
 	// Shallow copy with updated timestamp
 	cp := *cachedBaseMeta
-	cp.TimestampUTC = time.Now().UTC().Format(time.RFC3339Nano)
+	now := time.Now()
+	cp.TimestampUTC = now.UTC().Format(time.RFC3339Nano)
+	// per line, not cached: a batch can run across a DST change
+	cp.TimeZone, _ = now.Zone()
+	cp.UTCOffset = now.Format("-07:00")
+	if b := currentBinding(); b != nil {
+		cp.BindInterface, cp.BindSourceIP = b.Interface, b.SourceIP()
+		cp.LocalIP = cp.BindSourceIP
+	}
 	// Ensure the latest self-test value is reflected even if set after base init.
 	if localSelfTestKbps > 0 {
 		cp.LocalSelfTestKbps = localSelfTestKbps
@@ -2571,6 +2875,10 @@ func classifyClientEnvironment(meta *Meta) string {
 	return "unknown"
 }
 func writeResult(env *ResultEnvelope) {
+	emitTrace(env)
+	pushResult(env)
+	emitInfluxLine(env)
+	emitProgressResult(env)
 	if resultChan != nil {
 		resultChan <- env
 		return
@@ -2580,7 +2888,7 @@ func writeResult(env *ResultEnvelope) {
 		path = DefaultResultsFile
 	}
 	fallbackWriteOnce.Do(func() { fmt.Printf("[writer fallback] results file (append): %s\n", path) })
-	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	f, err := openResultsAppend(path)
 	if err != nil {
 		fmt.Println("write result:", err)
 		return
@@ -0,0 +1,72 @@
+package monitor
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	typespkg "github.com/iafilius/InternetQualityMonitor/src/types"
+)
+
+func TestMonitorSiteIP_ContentIntegrity(t *testing.T) {
+	body := strings.Repeat("integrity payload ", 2048)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/rewritten" && r.Method == http.MethodGet && r.Header.Get("Range") == "" {
+			w.Write([]byte(strings.Replace(body, "payload", "PAYLOAD", 1))) // same length, different bytes
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	defer srv.Close()
+	u, _ := url.Parse(srv.URL)
+	for _, k := range []string{"HTTP_PROXY", "HTTPS_PROXY", "ALL_PROXY", "NO_PROXY"} {
+		if v, ok := os.LookupEnv(k); ok {
+			t.Setenv(k, v)
+			os.Unsetenv(k)
+		}
+	}
+	sum := sha256.Sum256([]byte(body))
+	want := hex.EncodeToString(sum[:])
+	run := func(path, digest string) *SiteResult {
+		t.Helper()
+		tmp := t.TempDir() + "/res.jsonl"
+		resultChan = nil
+		resultPath = tmp
+		MonitorSiteIP(typespkg.Site{Name: "sha", URL: srv.URL + path, SHA256: digest}, u.Hostname(), []string{u.Hostname()}, 0)
+		data, _ := os.ReadFile(tmp)
+		var env ResultEnvelope
+		if err := json.Unmarshal([]byte(strings.TrimSpace(string(data))), &env); err != nil || env.SiteResult == nil {
+			t.Fatalf("decode result: %v", err)
+		}
+		return env.SiteResult
+	}
+	ok := run("/same", strings.ToUpper(want)) // digests compare case-insensitively
+	if ok.ContentSHA256 != want || ok.ContentMismatch || ok.HTTPError != "" {
+		t.Fatalf("matching body: sha=%s mismatch=%v err=%q", ok.ContentSHA256, ok.ContentMismatch, ok.HTTPError)
+	}
+	bad := run("/rewritten", want)
+	if !bad.ContentMismatch || !strings.HasPrefix(bad.HTTPError, "content_mismatch:") {
+		t.Fatalf("rewritten body not flagged: mismatch=%v err=%q", bad.ContentMismatch, bad.HTTPError)
+	}
+	if unchecked := run("/same", ""); unchecked.ContentSHA256 != "" || unchecked.ContentMismatch {
+		t.Fatalf("body hashed without an expected digest: %+v", unchecked.ContentSHA256)
+	}
+}
+
+func TestValidateSite_SHA256(t *testing.T) {
+	if ValidateSite(typespkg.Site{Name: "short", SHA256: "abc"}) == nil {
+		t.Fatal("short digest accepted")
+	}
+	if ValidateSite(typespkg.Site{Name: "head", Method: "HEAD", SHA256: strings.Repeat("a", 64)}) == nil {
+		t.Fatal("digest on a HEAD target accepted")
+	}
+	if err := ValidateSite(typespkg.Site{Name: "ok", SHA256: strings.Repeat("A", 64)}); err != nil {
+		t.Fatalf("valid digest rejected: %v", err)
+	}
+}
@@ -66,6 +66,17 @@ func TestResultEnvelopeMarshalUnmarshal(t *testing.T) {
 	}
 }
 
+func TestRunCanceledMarksMeta(t *testing.T) {
+	defer SetRunCanceled(false)
+	if wrapRoot(&SiteResult{Name: "a"}).Meta.Canceled {
+		t.Fatal("canceled before SetRunCanceled")
+	}
+	SetRunCanceled(true)
+	if !wrapRoot(&SiteResult{Name: "b"}).Meta.Canceled {
+		t.Fatal("in-flight line after cancel not marked")
+	}
+}
+
 func TestSmallHTTPTimeoutTriggersDeadline(t *testing.T) {
 	// Slow server: sleep beyond small timeout to force deadline
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
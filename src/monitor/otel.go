@@ -0,0 +1,290 @@
+package monitor
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Optional OpenTelemetry trace emission.
+//
+// Each measurement (one SiteResult line) becomes a root span "iqm.measurement" with child spans
+// for the phases we time: dns, connect, tls, http.head, http.ttfb, http.transfer and http.range.
+// Spans are exported as OTLP/HTTP JSON (POST <endpoint>/v1/traces), which Jaeger, Tempo and the
+// OpenTelemetry Collector accept natively; no SDK dependency is required.
+//
+// The monitor records durations rather than absolute phase timestamps, so child spans are laid
+// out sequentially starting at the measurement start. That is exact for dns/connect/tls and a
+// close approximation for the HTTP phases.
+
+// DefaultOTLPServiceName is the service.name resource attribute used when none is configured.
+const DefaultOTLPServiceName = "internet-quality-monitor"
+
+var (
+	otlpEndpoint    string
+	otlpServiceName = DefaultOTLPServiceName
+	otlpMu          sync.Mutex // guards otlpChan: queueing and closing take it, so no send hits a closed channel
+	otlpChan        chan []otlpSpan
+	otlpWG          sync.WaitGroup
+	otlpClient      = &http.Client{Timeout: 10 * time.Second}
+)
+
+// SetOTLPEndpoint enables OTLP trace export to the given collector base URL
+// (e.g. http://localhost:4318). When the URL has no path, /v1/traces is appended; a trailing
+// slash is dropped first. Empty disables.
+func SetOTLPEndpoint(endpoint string) {
+	endpoint = strings.TrimRight(strings.TrimSpace(endpoint), "/")
+	if endpoint == "" {
+		return
+	}
+	if !strings.Contains(strings.TrimPrefix(strings.TrimPrefix(endpoint, "http://"), "https://"), "/") {
+		endpoint += "/v1/traces"
+	}
+	otlpEndpoint = endpoint
+}
+
+// SetOTLPServiceName overrides the service.name resource attribute on exported spans.
+func SetOTLPServiceName(name string) {
+	if strings.TrimSpace(name) != "" {
+		otlpServiceName = strings.TrimSpace(name)
+	}
+}
+
+// otlpSpan is a minimal OTLP JSON span (see opentelemetry-proto trace/v1).
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            otlpStatus      `json:"status"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"` // OTLP JSON encodes int64 as string
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code,omitempty"` // 0 unset, 1 ok, 2 error
+	Message string `json:"message,omitempty"`
+}
+
+const (
+	otlpSpanKindInternal = 1
+	otlpSpanKindClient   = 3
+	otlpStatusOK         = 1
+	otlpStatusError      = 2
+)
+
+func strAttr(k, v string) otlpAttribute {
+	return otlpAttribute{Key: k, Value: otlpValue{StringValue: &v}}
+}
+func intAttr(k string, v int64) otlpAttribute {
+	s := strconv.FormatInt(v, 10)
+	return otlpAttribute{Key: k, Value: otlpValue{IntValue: &s}}
+}
+func floatAttr(k string, v float64) otlpAttribute {
+	return otlpAttribute{Key: k, Value: otlpValue{DoubleValue: &v}}
+}
+func boolAttr(k string, v bool) otlpAttribute {
+	return otlpAttribute{Key: k, Value: otlpValue{BoolValue: &v}}
+}
+
+func randomHexID(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func unixNano(t time.Time) string { return strconv.FormatInt(t.UnixNano(), 10) }
+
+// buildMeasurementSpans converts one result envelope into a root span plus per-phase child spans.
+// end is the time the measurement finished (normally when the line is written).
+func buildMeasurementSpans(env *ResultEnvelope, end time.Time) []otlpSpan {
+	if env == nil || env.SiteResult == nil {
+		return nil
+	}
+	sr := env.SiteResult
+	start := sr.started
+	if start.IsZero() {
+		start = end.Add(-time.Duration(sr.DNSTimeMs) * time.Millisecond)
+	}
+	traceID := randomHexID(16)
+	rootID := randomHexID(8)
+
+	var spans []otlpSpan
+	cursor := start
+	child := func(name string, ms int64, errMsg string, attrs ...otlpAttribute) {
+		d := time.Duration(ms) * time.Millisecond
+		sp := otlpSpan{TraceID: traceID, SpanID: randomHexID(8), ParentSpanID: rootID, Name: name, Kind: otlpSpanKindClient,
+			StartTimeUnixNano: unixNano(cursor), EndTimeUnixNano: unixNano(cursor.Add(d)), Attributes: attrs, Status: otlpStatus{Code: otlpStatusOK}}
+		if errMsg != "" {
+			sp.Status = otlpStatus{Code: otlpStatusError, Message: errMsg}
+		}
+		spans = append(spans, sp)
+		cursor = cursor.Add(d)
+	}
+
+	if sr.DNSTimeMs > 0 || sr.IP == "" {
+		dnsErr := ""
+		if sr.IP == "" && len(sr.DNSIPs) == 0 {
+			dnsErr = "dns_failed"
+		}
+		child("dns", sr.DNSTimeMs, dnsErr, strAttr("dns.server", sr.DNSServer), intAttr("dns.answers", int64(len(sr.DNSIPs))))
+	}
+	if sr.IP != "" {
+		child("connect", sr.TCPTimeMs, sr.TCPError, strAttr("net.peer.ip", sr.IP), strAttr("net.ip_family", sr.IPFamily))
+	}
+	if sr.SSLHandshakeTimeMs > 0 || sr.SSLError != "" {
+		child("tls", sr.SSLHandshakeTimeMs, sr.SSLError, strAttr("tls.version", sr.TLSVersion), strAttr("tls.alpn", sr.ALPN))
+	}
+	if sr.HeadTimeMs > 0 || sr.HeadError != "" {
+		child("http.head", sr.HeadTimeMs, sr.HeadError, intAttr("http.status_code", int64(sr.HeadStatus)))
+	}
+	if sr.TraceTTFBMs > 0 || (sr.HTTPError != "" && sr.TransferTimeMs == 0) {
+		errMsg := ""
+		if sr.TransferTimeMs == 0 {
+			errMsg = sr.HTTPError
+		}
+		// TraceTTFBMs counts from the request start, so the connect and tls spans before it are taken out
+		ttfb := max(sr.TraceTTFBMs-sr.TCPTimeMs-sr.SSLHandshakeTimeMs, 0)
+		child("http.ttfb", ttfb, errMsg, strAttr("http.protocol", sr.HTTPProtocol))
+	}
+	if sr.TransferTimeMs > 0 {
+		errMsg := ""
+		if sr.TransferStalled {
+			errMsg = "transfer_stalled"
+		} else if sr.HTTPError != "" {
+			errMsg = sr.HTTPError
+		} else if sr.ContentLengthMismatch {
+			errMsg = "partial_body"
+		}
+		child("http.transfer", sr.TransferTimeMs, errMsg, intAttr("http.response.body.size", sr.TransferSizeBytes), floatAttr("iqm.speed_kbps", sr.TransferSpeedKbps))
+	}
+	if sr.SecondGetTimeMs > 0 || sr.SecondGetError != "" {
+		child("http.range", sr.SecondGetTimeMs, sr.SecondGetError, intAttr("http.status_code", int64(sr.SecondGetStatus)), boolAttr("iqm.cache_present", sr.SecondGetCachePresent))
+	}
+	if cursor.After(end) {
+		end = cursor
+	}
+
+	root := otlpSpan{TraceID: traceID, SpanID: rootID, Name: "iqm.measurement", Kind: otlpSpanKindInternal,
+		StartTimeUnixNano: unixNano(start), EndTimeUnixNano: unixNano(end), Status: otlpStatus{Code: otlpStatusOK}}
+	root.Attributes = []otlpAttribute{strAttr("iqm.site", sr.Name), strAttr("url.full", sr.URL), strAttr("net.peer.ip", sr.IP), strAttr("net.ip_family", sr.IPFamily)}
+	if env.Meta != nil {
+		root.Attributes = append(root.Attributes, strAttr("iqm.run_tag", env.Meta.RunTag), strAttr("iqm.situation", env.Meta.Situation))
+	}
+	// Root carries the first failing phase so error traces are easy to filter on in the backend.
+	for _, sp := range spans {
+		if sp.Status.Code == otlpStatusError {
+			root.Status = otlpStatus{Code: otlpStatusError, Message: sp.Name + ": " + sp.Status.Message}
+			break
+		}
+	}
+	return append([]otlpSpan{root}, spans...)
+}
+
+// emitTrace queues spans for one result line. It never blocks measurements: when the exporter
+// falls behind, spans are dropped with a debug log.
+func emitTrace(env *ResultEnvelope) {
+	if otlpEndpoint == "" {
+		return
+	}
+	spans := buildMeasurementSpans(env, time.Now())
+	if len(spans) == 0 {
+		return
+	}
+	otlpMu.Lock()
+	defer otlpMu.Unlock()
+	if otlpChan == nil {
+		startOTLPExporter()
+	}
+	select {
+	case otlpChan <- spans:
+	default:
+		Debugf("[otlp] export queue full; dropping %d spans", len(spans))
+	}
+}
+
+// startOTLPExporter opens otlpChan and its exporter goroutine; otlpMu must be held.
+func startOTLPExporter() {
+	ch := make(chan []otlpSpan, 256)
+	otlpChan = ch
+	otlpWG.Add(1)
+	go func() {
+		defer otlpWG.Done()
+		for spans := range ch {
+			// Coalesce anything already queued into one request.
+		drain:
+			for len(spans) < 512 {
+				select {
+				case more, ok := <-ch:
+					if !ok {
+						break drain
+					}
+					spans = append(spans, more...)
+				default:
+					break drain
+				}
+			}
+			if err := postOTLPSpans(otlpEndpoint, spans); err != nil {
+				Warnf("[otlp] export failed: %v", err)
+			}
+		}
+	}()
+}
+
+func postOTLPSpans(endpoint string, spans []otlpSpan) error {
+	payload := map[string]any{
+		"resourceSpans": []any{map[string]any{
+			"resource": map[string]any{"attributes": []otlpAttribute{strAttr("service.name", otlpServiceName)}},
+			"scopeSpans": []any{map[string]any{
+				"scope": map[string]any{"name": "github.com/iafilius/InternetQualityMonitor/src/monitor"},
+				"spans": spans,
+			}},
+		}},
+	}
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	resp, err := otlpClient.Post(endpoint, "application/json", bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("collector responded %s", resp.Status)
+	}
+	return nil
+}
+
+// closeTraceExporter flushes queued spans; called from CloseResultWriter.
+func closeTraceExporter() {
+	otlpMu.Lock()
+	ch := otlpChan
+	otlpChan = nil
+	otlpMu.Unlock()
+	if ch != nil {
+		close(ch)
+		otlpWG.Wait()
+	}
+}
@@ -0,0 +1,115 @@
+package monitor
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBuildMeasurementSpans_PhasesAndErrorStatus(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	sr := &SiteResult{Name: "s", URL: "https://example.com/x", IP: "192.0.2.1", IPFamily: "ipv4", DNSTimeMs: 10, TCPTimeMs: 20, SSLHandshakeTimeMs: 30,
+		HeadTimeMs: 5, TraceTTFBMs: 80, TransferTimeMs: 100, TransferStalled: true, started: start}
+	spans := buildMeasurementSpans(&ResultEnvelope{Meta: &Meta{RunTag: "r1"}, SiteResult: sr}, start.Add(time.Second))
+	want := []string{"iqm.measurement", "dns", "connect", "tls", "http.head", "http.ttfb", "http.transfer"}
+	if len(spans) != len(want) {
+		t.Fatalf("got %d spans, want %d", len(spans), len(want))
+	}
+	for i, n := range want {
+		if spans[i].Name != n {
+			t.Fatalf("span[%d]=%s want %s", i, spans[i].Name, n)
+		}
+		if i > 0 && (spans[i].ParentSpanID != spans[0].SpanID || spans[i].TraceID != spans[0].TraceID) {
+			t.Fatalf("span %s not parented to root", n)
+		}
+	}
+	// connect starts where dns ends (sequential layout)
+	if spans[2].StartTimeUnixNano != spans[1].EndTimeUnixNano {
+		t.Fatalf("connect start %s != dns end %s", spans[2].StartTimeUnixNano, spans[1].EndTimeUnixNano)
+	}
+	// the ttfb span is what is left of the 80 ms TTFB after connect (20) and tls (30)
+	ttfbStart, _ := strconv.ParseInt(spans[5].StartTimeUnixNano, 10, 64)
+	ttfbEnd, _ := strconv.ParseInt(spans[5].EndTimeUnixNano, 10, 64)
+	if d := time.Duration(ttfbEnd - ttfbStart); d != 30*time.Millisecond {
+		t.Fatalf("ttfb span %s, want 30ms", d)
+	}
+	if spans[6].Status.Code != otlpStatusError || spans[0].Status.Code != otlpStatusError {
+		t.Fatalf("expected error status on transfer and root, got %+v / %+v", spans[6].Status, spans[0].Status)
+	}
+	if spans[1].Status.Code != otlpStatusOK {
+		t.Fatalf("dns should be ok, got %+v", spans[1].Status)
+	}
+}
+
+func TestBuildMeasurementSpans_TCPFailureStopsAtConnect(t *testing.T) {
+	sr := &SiteResult{Name: "s", IP: "192.0.2.1", DNSTimeMs: 3, TCPTimeMs: 10000, TCPError: "i/o timeout"}
+	spans := buildMeasurementSpans(&ResultEnvelope{SiteResult: sr}, time.Now())
+	if len(spans) != 3 || spans[2].Name != "connect" || spans[2].Status.Message != "i/o timeout" {
+		t.Fatalf("unexpected spans: %+v", spans)
+	}
+	if spans[0].Status.Message != "connect: i/o timeout" {
+		t.Fatalf("root status=%q", spans[0].Status.Message)
+	}
+}
+
+func TestEmitTrace_PostsOTLPJSON(t *testing.T) {
+	var mu sync.Mutex
+	var gotPath string
+	var body map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		gotPath = r.URL.Path
+		_ = json.Unmarshal(b, &body)
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+	SetOTLPEndpoint(srv.URL + "/") // a trailing slash must not give //v1/traces
+	defer func() { otlpEndpoint = "" }()
+
+	emitTrace(&ResultEnvelope{SiteResult: &SiteResult{Name: "s", IP: "192.0.2.1", DNSTimeMs: 1, TCPTimeMs: 2}})
+	closeTraceExporter()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if gotPath != "/v1/traces" {
+		t.Fatalf("path=%q want /v1/traces", gotPath)
+	}
+	rs, _ := body["resourceSpans"].([]any)
+	if len(rs) != 1 {
+		t.Fatalf("resourceSpans=%v", body)
+	}
+	ss := rs[0].(map[string]any)["scopeSpans"].([]any)
+	spans := ss[0].(map[string]any)["spans"].([]any)
+	if len(spans) != 3 {
+		t.Fatalf("got %d spans, want 3 (root, dns, connect)", len(spans))
+	}
+}
+
+func TestEmitTrace_ConcurrentWithClose(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	SetOTLPEndpoint(srv.URL)
+	defer func() { otlpEndpoint = "" }()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				emitTrace(&ResultEnvelope{SiteResult: &SiteResult{Name: "s", DNSTimeMs: 1}})
+			}
+		}()
+	}
+	for i := 0; i < 5; i++ {
+		closeTraceExporter() // must not make a concurrent emitTrace send on a closed channel
+	}
+	wg.Wait()
+	closeTraceExporter()
+}
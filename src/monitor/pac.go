@@ -0,0 +1,906 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// pacScript is a parsed proxy auto-config file. The monitor has no JavaScript engine, so PAC
+// files are run by a small interpreter that covers what PAC files are made of in practice:
+// function declarations, var/assignment, if/else, return, the operators ! && || == != === !==
+// and +, string methods toLowerCase/toUpperCase/indexOf and the standard PAC helpers
+// (isPlainHostName, dnsDomainIs, localHostOrDomainIs, isResolvable, isInNet, dnsResolve,
+// myIpAddress, dnsDomainLevels, shExpMatch, weekdayRange, timeRange). There are no < > - or
+// other arithmetic, arrays, ternaries, loops, switch or regular expressions. Anything outside
+// the subset is rejected when the PAC is loaded, with the offending token and its line.
+type pacScript struct {
+	funcs   map[string]*pacFunc
+	globals []pacStmt
+}
+
+type pacFunc struct {
+	params []string
+	body   []pacStmt
+}
+
+type (
+	pacStmt interface{}
+	pacExpr interface{}
+
+	pacIf struct {
+		cond      pacExpr
+		then, els []pacStmt
+	}
+	pacReturn struct{ x pacExpr }
+	pacAssign struct {
+		name string
+		x    pacExpr
+	}
+	pacExprStmt struct{ x pacExpr }
+
+	pacLit   struct{ v any }
+	pacIdent struct{ name string }
+	pacCall  struct {
+		name string
+		recv pacExpr // method receiver, nil for plain calls
+		args []pacExpr
+	}
+	pacUnary  struct{ x pacExpr }
+	pacBinary struct {
+		op   string
+		l, r pacExpr
+	}
+)
+
+// pacLookup resolves host names for the PAC DNS helpers (seam for tests).
+var pacLookup = func(host string) []net.IP {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil
+	}
+	out := make([]net.IP, 0, len(addrs))
+	for _, a := range addrs {
+		out = append(out, a.IP)
+	}
+	return out
+}
+
+// pacNow is the clock of weekdayRange/timeRange (seam for tests).
+var pacNow = time.Now
+
+type pacToken struct {
+	kind string // ident, str, num, op, eof
+	val  string
+	line int
+}
+
+// pacUnsupported are JavaScript keywords outside the interpreter's subset; they fail the parse
+// instead of being read as variables or calls.
+var pacUnsupported = map[string]bool{
+	"switch": true, "case": true, "for": true, "while": true, "do": true, "break": true, "continue": true,
+	"let": true, "const": true, "new": true, "try": true, "catch": true, "throw": true, "typeof": true,
+	"instanceof": true, "in": true, "delete": true, "this": true,
+}
+
+// pacMethods are the string methods pacMethod implements.
+var pacMethods = map[string]bool{"toLowerCase": true, "toUpperCase": true, "indexOf": true}
+
+// pacBuiltins are the PAC helpers pacBuiltin implements.
+var pacBuiltins = map[string]bool{
+	"isPlainHostName": true, "dnsDomainIs": true, "localHostOrDomainIs": true, "isResolvable": true,
+	"dnsResolve": true, "myIpAddress": true, "dnsDomainLevels": true, "isInNet": true,
+	"shExpMatch": true, "weekdayRange": true, "timeRange": true, "alert": true,
+}
+
+func lexPAC(src string) ([]pacToken, error) {
+	var toks []pacToken
+	line, seen := 1, 0
+	lineAt := func(i int) int {
+		line += strings.Count(src[seen:i], "\n")
+		seen = i
+		return line
+	}
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case strings.HasPrefix(src[i:], "//"):
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated comment", lineAt(i))
+			}
+			i += end + 4
+		case c == '"' || c == '\'':
+			var b strings.Builder
+			j := i + 1
+			for ; j < len(src) && src[j] != c; j++ {
+				if src[j] == '\\' && j+1 < len(src) {
+					j++
+				}
+				b.WriteByte(src[j])
+			}
+			if j >= len(src) {
+				return nil, fmt.Errorf("line %d: unterminated string", lineAt(i))
+			}
+			toks = append(toks, pacToken{"str", b.String(), lineAt(i)})
+			i = j + 1
+		case c == '_' || c == '$' || unicode.IsLetter(rune(c)):
+			j := i
+			for j < len(src) && (src[j] == '_' || src[j] == '$' || unicode.IsLetter(rune(src[j])) || unicode.IsDigit(rune(src[j]))) {
+				j++
+			}
+			toks = append(toks, pacToken{"ident", src[i:j], lineAt(i)})
+			i = j
+		case unicode.IsDigit(rune(c)):
+			j := i
+			for j < len(src) && (unicode.IsDigit(rune(src[j])) || src[j] == '.') {
+				j++
+			}
+			toks = append(toks, pacToken{"num", src[i:j], lineAt(i)})
+			i = j
+		default:
+			op := ""
+			for _, o := range []string{"===", "!==", "&&", "||", "==", "!=", "(", ")", "{", "}", ";", ",", "!", "=", "+", "."} {
+				if strings.HasPrefix(src[i:], o) {
+					op = o
+					break
+				}
+			}
+			if op == "" {
+				r, _ := utf8.DecodeRuneInString(src[i:])
+				return nil, fmt.Errorf("line %d: unsupported %q", lineAt(i), r)
+			}
+			toks = append(toks, pacToken{"op", op, lineAt(i)})
+			i += len(op)
+		}
+	}
+	return append(toks, pacToken{kind: "eof", val: "end of file", line: lineAt(len(src))}), nil
+}
+
+type pacParser struct {
+	toks  []pacToken
+	pos   int
+	calls []pacToken // plain calls, checked against the functions once all are declared
+}
+
+func (p *pacParser) errorf(t pacToken, format string, args ...any) error {
+	return fmt.Errorf("line %d: "+format, append([]any{t.line}, args...)...)
+}
+
+func (p *pacParser) peek() pacToken { return p.toks[p.pos] }
+
+func (p *pacParser) next() pacToken {
+	t := p.toks[p.pos]
+	if t.kind != "eof" {
+		p.pos++
+	}
+	return t
+}
+
+func (p *pacParser) is(kind, val string) bool {
+	t := p.peek()
+	return t.kind == kind && t.val == val
+}
+
+func (p *pacParser) expect(val string) error {
+	if t := p.next(); t.kind != "op" || t.val != val {
+		return p.errorf(t, "expected %q, got %q", val, t.val)
+	}
+	return nil
+}
+
+func (p *pacParser) ident() (string, error) {
+	t := p.next()
+	if t.kind != "ident" {
+		return "", p.errorf(t, "expected a name, got %q", t.val)
+	}
+	return t.val, nil
+}
+
+// parsePAC parses a PAC file; it must declare FindProxyForURL(url, host).
+func parsePAC(src string) (*pacScript, error) {
+	toks, err := lexPAC(src)
+	if err != nil {
+		return nil, fmt.Errorf("pac: %w", err)
+	}
+	p := &pacParser{toks: toks}
+	s := &pacScript{funcs: map[string]*pacFunc{}}
+	for p.peek().kind != "eof" {
+		if p.is("ident", "function") {
+			p.next()
+			name, err := p.ident()
+			if err != nil {
+				return nil, fmt.Errorf("pac: %w", err)
+			}
+			fn := &pacFunc{}
+			if err := p.expect("("); err != nil {
+				return nil, fmt.Errorf("pac: function %s: %w", name, err)
+			}
+			for !p.is("op", ")") {
+				param, err := p.ident()
+				if err != nil {
+					return nil, fmt.Errorf("pac: function %s: %w", name, err)
+				}
+				fn.params = append(fn.params, param)
+				if p.is("op", ",") {
+					p.next()
+				}
+			}
+			p.next()
+			if fn.body, err = p.block(); err != nil {
+				return nil, fmt.Errorf("pac: function %s: %w", name, err)
+			}
+			s.funcs[name] = fn
+			continue
+		}
+		st, err := p.stmt()
+		if err != nil {
+			return nil, fmt.Errorf("pac: %w", err)
+		}
+		s.globals = append(s.globals, st)
+	}
+	if fn := s.funcs["FindProxyForURL"]; fn == nil || len(fn.params) != 2 {
+		return nil, fmt.Errorf("pac: no FindProxyForURL(url, host) function")
+	}
+	for _, c := range p.calls {
+		if s.funcs[c.val] == nil && !pacBuiltins[c.val] {
+			return nil, fmt.Errorf("pac: %w", p.errorf(c, "unsupported function %s", c.val))
+		}
+	}
+	return s, nil
+}
+
+func (p *pacParser) block() ([]pacStmt, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	var out []pacStmt
+	for !p.is("op", "}") {
+		if t := p.peek(); t.kind == "eof" {
+			return nil, p.errorf(t, "unterminated block")
+		}
+		st, err := p.stmt()
+		if err != nil {
+			return nil, err
+		}
+		if st != nil {
+			out = append(out, st)
+		}
+	}
+	p.next()
+	return out, nil
+}
+
+// body is a block or a single statement (if/else branches).
+func (p *pacParser) body() ([]pacStmt, error) {
+	if p.is("op", "{") {
+		return p.block()
+	}
+	st, err := p.stmt()
+	if err != nil || st == nil {
+		return nil, err
+	}
+	return []pacStmt{st}, nil
+}
+
+func (p *pacParser) stmt() (pacStmt, error) {
+	switch t := p.peek(); {
+	case t.kind == "ident" && pacUnsupported[t.val]:
+		return nil, p.errorf(t, "unsupported %q", t.val)
+	case p.is("op", ";"):
+		p.next()
+		return nil, nil
+	case p.is("ident", "if"):
+		p.next()
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		cond, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		st := &pacIf{cond: cond}
+		if st.then, err = p.body(); err != nil {
+			return nil, err
+		}
+		if p.is("ident", "else") {
+			p.next()
+			if st.els, err = p.body(); err != nil {
+				return nil, err
+			}
+		}
+		return st, nil
+	case p.is("ident", "return"):
+		p.next()
+		var x pacExpr = pacLit{}
+		if !p.is("op", ";") && !p.is("op", "}") {
+			var err error
+			if x, err = p.expr(); err != nil {
+				return nil, err
+			}
+		}
+		p.semi()
+		return &pacReturn{x: x}, nil
+	case p.is("ident", "var"):
+		p.next()
+		name, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+		var x pacExpr = pacLit{}
+		if p.is("op", "=") {
+			p.next()
+			if x, err = p.expr(); err != nil {
+				return nil, err
+			}
+		}
+		p.semi()
+		return &pacAssign{name: name, x: x}, nil
+	case p.peek().kind == "ident" && p.toks[p.pos+1].kind == "op" && p.toks[p.pos+1].val == "=":
+		name := p.next().val
+		p.next()
+		x, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		p.semi()
+		return &pacAssign{name: name, x: x}, nil
+	}
+	x, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	p.semi()
+	return &pacExprStmt{x: x}, nil
+}
+
+func (p *pacParser) semi() {
+	if p.is("op", ";") {
+		p.next()
+	}
+}
+
+func (p *pacParser) expr() (pacExpr, error) { return p.binary(0) }
+
+var pacPrecedence = [][]string{{"||"}, {"&&"}, {"==", "!=", "===", "!=="}, {"+"}}
+
+func (p *pacParser) binary(level int) (pacExpr, error) {
+	if level == len(pacPrecedence) {
+		return p.unary()
+	}
+	l, err := p.binary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		matched := false
+		for _, op := range pacPrecedence[level] {
+			if t.kind == "op" && t.val == op {
+				matched = true
+			}
+		}
+		if !matched {
+			return l, nil
+		}
+		p.next()
+		r, err := p.binary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		l = &pacBinary{op: t.val, l: l, r: r}
+	}
+}
+
+func (p *pacParser) unary() (pacExpr, error) {
+	if p.is("op", "!") {
+		p.next()
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &pacUnary{x: x}, nil
+	}
+	x, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+	for p.is("op", ".") {
+		p.next()
+		t := p.peek()
+		name, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+		if !pacMethods[name] {
+			return nil, p.errorf(t, "unsupported method or property %s", name)
+		}
+		args, err := p.args()
+		if err != nil {
+			return nil, err
+		}
+		x = &pacCall{name: name, recv: x, args: args}
+	}
+	return x, nil
+}
+
+func (p *pacParser) args() ([]pacExpr, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	var out []pacExpr
+	for !p.is("op", ")") {
+		a, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+		if p.is("op", ",") {
+			p.next()
+		} else if !p.is("op", ")") {
+			return nil, p.errorf(p.peek(), "expected ',' or ')' in call, got %q", p.peek().val)
+		}
+	}
+	p.next()
+	return out, nil
+}
+
+func (p *pacParser) primary() (pacExpr, error) {
+	t := p.next()
+	switch t.kind {
+	case "str":
+		return pacLit{v: t.val}, nil
+	case "num":
+		f, err := strconv.ParseFloat(t.val, 64)
+		if err != nil {
+			return nil, err
+		}
+		return pacLit{v: f}, nil
+	case "ident":
+		if pacUnsupported[t.val] {
+			return nil, p.errorf(t, "unsupported %q", t.val)
+		}
+		switch t.val {
+		case "true":
+			return pacLit{v: true}, nil
+		case "false":
+			return pacLit{v: false}, nil
+		case "null", "undefined":
+			return pacLit{}, nil
+		}
+		if p.is("op", "(") {
+			args, err := p.args()
+			if err != nil {
+				return nil, err
+			}
+			p.calls = append(p.calls, t)
+			return &pacCall{name: t.val, args: args}, nil
+		}
+		return pacIdent{name: t.val}, nil
+	case "op":
+		if t.val == "(" {
+			x, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			return x, p.expect(")")
+		}
+	}
+	return nil, p.errorf(t, "unsupported %q", t.val)
+}
+
+// pacEnv is one function activation; lookups fall back to the globals.
+type pacEnv struct {
+	vars   map[string]any
+	global map[string]any
+	script *pacScript
+	depth  int
+}
+
+type pacReturned struct{ v any }
+
+// FindProxyForURL runs the PAC for a target and returns its raw result ("PROXY a:8080; DIRECT").
+func (s *pacScript) FindProxyForURL(rawURL, host string) (string, error) {
+	global := map[string]any{}
+	genv := &pacEnv{vars: global, global: global, script: s}
+	if _, err := genv.run(s.globals); err != nil {
+		return "", err
+	}
+	v, err := genv.call(s.funcs["FindProxyForURL"], []any{rawURL, host})
+	if err != nil {
+		return "", err
+	}
+	return pacString(v), nil
+}
+
+func (e *pacEnv) call(fn *pacFunc, args []any) (any, error) {
+	if e.depth > 32 {
+		return nil, fmt.Errorf("pac: call depth exceeded")
+	}
+	env := &pacEnv{vars: map[string]any{}, global: e.global, script: e.script, depth: e.depth + 1}
+	for i, p := range fn.params {
+		if i < len(args) {
+			env.vars[p] = args[i]
+		} else {
+			env.vars[p] = nil
+		}
+	}
+	ret, err := env.run(fn.body)
+	if err != nil {
+		return nil, err
+	}
+	if ret != nil {
+		return ret.v, nil
+	}
+	return nil, nil
+}
+
+func (e *pacEnv) run(stmts []pacStmt) (*pacReturned, error) {
+	for _, st := range stmts {
+		switch t := st.(type) {
+		case *pacIf:
+			c, err := e.eval(t.cond)
+			if err != nil {
+				return nil, err
+			}
+			branch := t.els
+			if pacTruthy(c) {
+				branch = t.then
+			}
+			if ret, err := e.run(branch); ret != nil || err != nil {
+				return ret, err
+			}
+		case *pacReturn:
+			v, err := e.eval(t.x)
+			if err != nil {
+				return nil, err
+			}
+			return &pacReturned{v}, nil
+		case *pacAssign:
+			v, err := e.eval(t.x)
+			if err != nil {
+				return nil, err
+			}
+			if _, local := e.vars[t.name]; !local {
+				if _, g := e.global[t.name]; g {
+					e.global[t.name] = v
+					continue
+				}
+			}
+			e.vars[t.name] = v
+		case *pacExprStmt:
+			if _, err := e.eval(t.x); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return nil, nil
+}
+
+func (e *pacEnv) eval(x pacExpr) (any, error) {
+	switch t := x.(type) {
+	case pacLit:
+		return t.v, nil
+	case pacIdent:
+		if v, ok := e.vars[t.name]; ok {
+			return v, nil
+		}
+		if v, ok := e.global[t.name]; ok {
+			return v, nil
+		}
+		return nil, fmt.Errorf("pac: %s is not defined", t.name)
+	case *pacUnary:
+		v, err := e.eval(t.x)
+		return !pacTruthy(v), err
+	case *pacBinary:
+		l, err := e.eval(t.l)
+		if err != nil {
+			return nil, err
+		}
+		switch t.op {
+		case "||":
+			if pacTruthy(l) {
+				return l, nil
+			}
+			return e.eval(t.r)
+		case "&&":
+			if !pacTruthy(l) {
+				return l, nil
+			}
+			return e.eval(t.r)
+		}
+		r, err := e.eval(t.r)
+		if err != nil {
+			return nil, err
+		}
+		switch t.op {
+		case "==", "===":
+			return pacEqual(l, r), nil
+		case "!=", "!==":
+			return !pacEqual(l, r), nil
+		default: // +
+			lf, lok := l.(float64)
+			rf, rok := r.(float64)
+			if lok && rok {
+				return lf + rf, nil
+			}
+			return pacString(l) + pacString(r), nil
+		}
+	case *pacCall:
+		args := make([]any, 0, len(t.args))
+		for _, a := range t.args {
+			v, err := e.eval(a)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, v)
+		}
+		if t.recv != nil {
+			recv, err := e.eval(t.recv)
+			if err != nil {
+				return nil, err
+			}
+			return pacMethod(pacString(recv), t.name, args)
+		}
+		if fn := e.script.funcs[t.name]; fn != nil {
+			return e.call(fn, args)
+		}
+		return pacBuiltin(t.name, args)
+	}
+	return nil, fmt.Errorf("pac: bad expression")
+}
+
+func pacTruthy(v any) bool {
+	switch t := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return t
+	case string:
+		return t != ""
+	case float64:
+		return t != 0
+	}
+	return true
+}
+
+func pacString(v any) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case bool:
+		return strconv.FormatBool(t)
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
+
+func pacEqual(l, r any) bool {
+	if l == nil || r == nil {
+		return l == nil && r == nil
+	}
+	return pacString(l) == pacString(r)
+}
+
+func pacMethod(s, name string, args []any) (any, error) {
+	switch name {
+	case "toLowerCase":
+		return strings.ToLower(s), nil
+	case "toUpperCase":
+		return strings.ToUpper(s), nil
+	case "indexOf":
+		if len(args) < 1 {
+			return nil, fmt.Errorf("pac: indexOf needs an argument")
+		}
+		return float64(strings.Index(s, pacString(args[0]))), nil
+	}
+	return nil, fmt.Errorf("pac: unsupported method %s", name)
+}
+
+func pacBuiltin(name string, args []any) (any, error) {
+	arg := func(i int) string {
+		if i < len(args) {
+			return pacString(args[i])
+		}
+		return ""
+	}
+	switch name {
+	case "isPlainHostName":
+		return !strings.Contains(arg(0), "."), nil
+	case "dnsDomainIs":
+		return strings.HasSuffix(strings.ToLower(arg(0)), strings.ToLower(arg(1))), nil
+	case "localHostOrDomainIs":
+		h, hd := strings.ToLower(arg(0)), strings.ToLower(arg(1))
+		return h == hd || (!strings.Contains(h, ".") && strings.HasPrefix(hd, h+".")), nil
+	case "isResolvable":
+		return len(pacLookup(arg(0))) > 0, nil
+	case "dnsResolve":
+		if ip := pacResolve4(arg(0)); ip != nil {
+			return ip.String(), nil
+		}
+		return nil, nil
+	case "myIpAddress":
+		return pacMyIP(), nil
+	case "dnsDomainLevels":
+		return float64(strings.Count(arg(0), ".")), nil
+	case "isInNet":
+		ip := pacResolve4(arg(0))
+		pat, mask := net.ParseIP(arg(1)).To4(), net.ParseIP(arg(2)).To4()
+		if ip == nil || pat == nil || mask == nil {
+			return false, nil
+		}
+		m := net.IPMask(mask)
+		return ip.Mask(m).Equal(pat.Mask(m)), nil
+	case "shExpMatch":
+		return pacGlob(arg(1)).MatchString(arg(0)), nil
+	case "weekdayRange":
+		return pacWeekdayRange(args)
+	case "timeRange":
+		return pacTimeRange(args)
+	case "alert":
+		return nil, nil
+	}
+	return nil, fmt.Errorf("pac: unsupported function %s", name)
+}
+
+func pacResolve4(host string) net.IP {
+	if ip := net.ParseIP(host); ip != nil {
+		return ip.To4()
+	}
+	for _, ip := range pacLookup(host) {
+		if v4 := ip.To4(); v4 != nil {
+			return v4
+		}
+	}
+	return nil
+}
+
+// pacMyIP is the source address of the default route (no packet is sent).
+func pacMyIP() string {
+	c, err := net.Dial("udp", "192.0.2.1:9")
+	if err != nil {
+		return "127.0.0.1"
+	}
+	defer c.Close()
+	if a, ok := c.LocalAddr().(*net.UDPAddr); ok {
+		return a.IP.String()
+	}
+	return "127.0.0.1"
+}
+
+// pacGlob turns a shell expression (* and ?) into an anchored regexp; unlike path.Match the
+// wildcards also match '/', as PAC requires for URLs.
+func pacGlob(pat string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("^")
+	for _, r := range pat {
+		switch r {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
+
+var pacDays = []string{"SUN", "MON", "TUE", "WED", "THU", "FRI", "SAT"}
+
+func pacWeekdayRange(args []any) (any, error) {
+	now := pacNow()
+	var days []int
+	for _, a := range args {
+		s := strings.ToUpper(pacString(a))
+		if s == "GMT" {
+			now = now.UTC()
+			continue
+		}
+		found := false
+		for i, d := range pacDays {
+			if d == s {
+				days, found = append(days, i), true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("pac: weekdayRange: bad day %q", s)
+		}
+	}
+	wd := int(now.Weekday())
+	switch len(days) {
+	case 1:
+		return wd == days[0], nil
+	case 2:
+		if days[0] <= days[1] {
+			return wd >= days[0] && wd <= days[1], nil
+		}
+		return wd >= days[0] || wd <= days[1], nil
+	}
+	return nil, fmt.Errorf("pac: weekdayRange needs one or two days")
+}
+
+// pacTimeRange supports the hour forms timeRange(h) and timeRange(h1, h2) (optionally "GMT").
+func pacTimeRange(args []any) (any, error) {
+	now := pacNow()
+	var hours []int
+	for _, a := range args {
+		if s, ok := a.(string); ok && strings.EqualFold(s, "GMT") {
+			now = now.UTC()
+			continue
+		}
+		hours = append(hours, int(pacNumber(a)))
+	}
+	h := now.Hour()
+	switch len(hours) {
+	case 1:
+		return h == hours[0], nil
+	case 2:
+		if hours[0] <= hours[1] {
+			return h >= hours[0] && h < hours[1], nil
+		}
+		return h >= hours[0] || h < hours[1], nil
+	}
+	return nil, fmt.Errorf("pac: timeRange supports only timeRange(hour) and timeRange(hour1, hour2)")
+}
+
+func pacNumber(v any) float64 {
+	if f, ok := v.(float64); ok {
+		return f
+	}
+	f, _ := strconv.ParseFloat(pacString(v), 64)
+	return f
+}
+
+// parsePACResult picks the first entry of a PAC result: nil for DIRECT, else the proxy URL
+// (PROXY → http, HTTPS → https, SOCKS/SOCKS5 → socks5).
+func parsePACResult(res string) (*url.URL, error) {
+	for _, entry := range strings.Split(res, ";") {
+		f := strings.Fields(entry)
+		if len(f) == 0 {
+			continue
+		}
+		kind := strings.ToUpper(f[0])
+		if kind == "DIRECT" {
+			return nil, nil
+		}
+		if len(f) < 2 {
+			return nil, fmt.Errorf("pac: %q has no host:port", strings.TrimSpace(entry))
+		}
+		scheme := ""
+		switch kind {
+		case "PROXY", "HTTP":
+			scheme = "http"
+		case "HTTPS":
+			scheme = "https"
+		case "SOCKS", "SOCKS5":
+			scheme = "socks5"
+		default:
+			return nil, fmt.Errorf("pac: unsupported proxy type %q", f[0])
+		}
+		return &url.URL{Scheme: scheme, Host: f[1]}, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,203 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/iafilius/InternetQualityMonitor/src/types"
+)
+
+// PingResult is a series of TCP connects ("tcping") to one address. Unlike ICMP it needs no
+// privileges and follows the same path and port as the site's traffic; the RTT is the time to
+// complete the handshake.
+type PingResult struct {
+	Port     int       `json:"port"`
+	Sent     int       `json:"sent"`
+	Received int       `json:"received"`
+	LossPct  float64   `json:"loss_pct"`
+	RTTsMs   []float64 `json:"rtts_ms,omitempty"` // successful connects, in send order
+	MinMs    float64   `json:"min_ms,omitempty"`
+	AvgMs    float64   `json:"avg_ms,omitempty"`
+	MaxMs    float64   `json:"max_ms,omitempty"`
+	JitterMs float64   `json:"jitter_ms,omitempty"` // RFC 3550 interarrival jitter of RTTsMs
+}
+
+const (
+	defaultPingCount    = 5
+	defaultPingInterval = 200 * time.Millisecond
+	pingConnectTimeout  = 2 * time.Second
+)
+
+var (
+	pingCount    = defaultPingCount
+	pingInterval = defaultPingInterval
+
+	// seam for tests
+	pingDial = func(ctx context.Context, addr string) (net.Conn, error) {
+		return boundDial(&net.Dialer{Timeout: pingConnectTimeout})(ctx, "tcp", addr)
+	}
+)
+
+// SetPingCount sets the connects per address of the ping probe (default 5).
+func SetPingCount(n int) {
+	if n > 0 {
+		pingCount = n
+	}
+}
+
+// SetPingInterval sets the pause between connects of the ping probe (default 200ms).
+func SetPingInterval(d time.Duration) {
+	if d >= 0 {
+		pingInterval = d
+	}
+}
+
+// PingMeasurer times TCP connects to the first IPv4 and first IPv6 address of a site, one line
+// per address. The port comes from the URL (https 443, http 80, or an explicit :port, e.g.
+// tcp://host:22).
+type PingMeasurer struct{}
+
+func (PingMeasurer) ProbeType() string { return ProbePing }
+
+func (p PingMeasurer) MeasureSite(ctx context.Context, site types.Site) {
+	_, ips, took, err := resolveSite(ctx, site)
+	if err != nil {
+		WriteSiteResult(&SiteResult{Name: site.Name, URL: site.URL, CountryConfigured: site.Country, Group: SiteGroup(site), ProbeType: ProbePing, DNSTimeMs: took.Milliseconds(), ProbeError: err.Error(), started: time.Now().Add(-took)})
+		Warnf("[%s] ping: %v", site.Name, err)
+		return
+	}
+	v6, v4 := firstIPPerFamily(ips)
+	for _, s := range []string{v4, v6} {
+		if s != "" {
+			p.MeasureSiteIP(ctx, site, net.ParseIP(s), ips, took)
+		}
+	}
+}
+
+func (PingMeasurer) MeasureSiteIP(ctx context.Context, site types.Site, ip net.IP, dnsIPs []string, dnsTime time.Duration) {
+	ipStr := ip.String()
+	sr := &SiteResult{Name: site.Name, URL: site.URL, IP: ipStr, CountryConfigured: site.Country, Group: SiteGroup(site), ProbeType: ProbePing, DNSIPs: dnsIPs, DNSTimeMs: dnsTime.Milliseconds(), ResolvedIP: ipStr, IPIndex: ipIndex(ip, dnsIPs), IPFamily: ipFamilyOf(ip), started: time.Now().Add(-dnsTime)}
+	port, err := sitePort(site)
+	if err != nil {
+		sr.ProbeError = err.Error()
+		WriteSiteResult(sr)
+		return
+	}
+	res, lastErr := tcpPing(ctx, net.JoinHostPort(ipStr, strconv.Itoa(port)), pingCount, pingInterval)
+	res.Port = port
+	sr.Ping = res
+	if res.Received == 0 && lastErr != nil {
+		sr.ProbeError = lastErr.Error()
+	}
+	Infof("[%s %s] ping %d/%d avg=%.1fms loss=%.0f%%", site.Name, ipStr, res.Received, res.Sent, res.AvgMs, res.LossPct)
+	WriteSiteResult(sr)
+}
+
+// tcpPing connects to addr count times, interval apart, and returns the RTT statistics with
+// the last connect error. It stops early when ctx ends; unsent connects are not counted.
+func tcpPing(ctx context.Context, addr string, count int, interval time.Duration) (*PingResult, error) {
+	res := &PingResult{}
+	var lastErr error
+	sum := 0.0
+	for i := 0; i < count; i++ {
+		if i > 0 && interval > 0 {
+			select {
+			case <-ctx.Done():
+			case <-time.After(interval):
+			}
+		}
+		if ctx.Err() != nil {
+			if lastErr == nil {
+				lastErr = ctx.Err()
+			}
+			break
+		}
+		res.Sent++
+		start := time.Now()
+		conn, err := pingDial(ctx, addr)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		ms := float64(time.Since(start).Microseconds()) / 1000
+		conn.Close()
+		res.Received++
+		res.RTTsMs = append(res.RTTsMs, ms)
+		sum += ms
+		if res.Received == 1 || ms < res.MinMs {
+			res.MinMs = ms
+		}
+		res.MaxMs = math.Max(res.MaxMs, ms)
+	}
+	if res.Received > 0 {
+		res.AvgMs = sum / float64(res.Received)
+	}
+	res.JitterMs = InterarrivalJitter(res.RTTsMs)
+	if res.Sent > 0 {
+		res.LossPct = float64(res.Sent-res.Received) / float64(res.Sent) * 100
+	}
+	return res, lastErr
+}
+
+// InterarrivalJitter is the RFC 3550 (section 6.4.1) jitter estimate over consecutive RTTs:
+// J += (|D| - J) / 16 per pair, with D the RTT difference (for round trips the RTT change is the
+// difference in transit time). The 1/16 gain smooths out single spikes. J is seeded with the
+// first |D| rather than 0, so a short series (the default 5 pings) is not biased low. Zero for
+// fewer than two RTTs.
+func InterarrivalJitter(rttsMs []float64) float64 {
+	if len(rttsMs) < 2 {
+		return 0
+	}
+	j := math.Abs(rttsMs[1] - rttsMs[0])
+	for i := 2; i < len(rttsMs); i++ {
+		j += (math.Abs(rttsMs[i]-rttsMs[i-1]) - j) / 16
+	}
+	return j
+}
+
+// siteHost is the host of a site's URL; a bare "host" or "host:port" is accepted too.
+func siteHost(site types.Site) (string, error) {
+	u, err := siteURL(site)
+	if err != nil {
+		return "", err
+	}
+	return u.Hostname(), nil
+}
+
+func sitePort(site types.Site) (int, error) {
+	u, err := siteURL(site)
+	if err != nil {
+		return 0, err
+	}
+	if p := u.Port(); p != "" {
+		return strconv.Atoi(p)
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "https":
+		return 443, nil
+	case "http":
+		return 80, nil
+	}
+	return 0, fmt.Errorf("%s: no port (add :port to the url)", site.URL)
+}
+
+func siteURL(site types.Site) (*url.URL, error) {
+	raw := site.URL
+	if !strings.Contains(raw, "://") {
+		raw = "tcp://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("%s: no host", site.URL)
+	}
+	return u, nil
+}
@@ -0,0 +1,144 @@
+package monitor
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Machine-readable progress (--progress-json).
+//
+// Orchestration tooling gets one JSON object per line on stderr or in a file instead of parsing
+// the human log on stdout: run_start, batch_start, one result per measured site/IP (when its
+// line is written), batch_done with the batch's error rate and run_done with the process exit
+// code. Events are written synchronously and in order; the file is appended to.
+
+// Progress event names.
+const (
+	ProgressRunStart   = "run_start"
+	ProgressBatchStart = "batch_start"
+	ProgressResult     = "result"
+	ProgressBatchDone  = "batch_done"
+	ProgressRunDone    = "run_done"
+)
+
+// ProgressEvent is one line of --progress-json. Which fields are set depends on Event.
+type ProgressEvent struct {
+	Event     string `json:"event"`
+	TimeUTC   string `json:"time_utc"`
+	RunTag    string `json:"run_tag,omitempty"`
+	Iteration int    `json:"iteration,omitempty"`
+	// batch_start: sites to measure; run_start: iterations requested (0 = until stopped)
+	Sites      int  `json:"sites,omitempty"`
+	Iterations *int `json:"iterations,omitempty"`
+	// result: one measured site/IP; Done counts the result lines of the batch so far
+	Site      string  `json:"site,omitempty"`
+	IP        string  `json:"ip,omitempty"`
+	IPFamily  string  `json:"ip_family,omitempty"`
+	OK        *bool   `json:"ok,omitempty"`
+	Error     string  `json:"error,omitempty"`
+	SpeedKbps float64 `json:"speed_kbps,omitempty"`
+	TTFBMs    int64   `json:"ttfb_ms,omitempty"`
+	Done      int     `json:"done,omitempty"`
+	// batch_done
+	Lines        int     `json:"lines,omitempty"`
+	ErrorLines   int     `json:"error_lines,omitempty"`
+	ErrorRatePct float64 `json:"error_rate_pct,omitempty"`
+	Canceled     bool    `json:"canceled,omitempty"`
+	Skipped      string  `json:"skipped,omitempty"` // "metered" or "budget": the batch took no measurements
+	// run_done
+	ExitCode *int `json:"exit_code,omitempty"`
+}
+
+var (
+	progressMu   sync.Mutex
+	progressOut  io.Writer
+	progressFile *os.File
+	progressDone atomic.Int64 // result events of the current batch
+)
+
+// SetProgressJSON directs progress events to path: "-" or "stderr" for stderr, otherwise a file
+// that is appended to. Empty disables.
+func SetProgressJSON(path string) error {
+	path = strings.TrimSpace(path)
+	progressMu.Lock()
+	defer progressMu.Unlock()
+	closeProgressLocked()
+	switch path {
+	case "":
+		return nil
+	case "-", "stderr":
+		progressOut = os.Stderr
+		return nil
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	progressFile, progressOut = f, f
+	return nil
+}
+
+// CloseProgressJSON closes a --progress-json file; later events are dropped.
+func CloseProgressJSON() {
+	progressMu.Lock()
+	defer progressMu.Unlock()
+	closeProgressLocked()
+}
+
+func closeProgressLocked() {
+	if progressFile != nil {
+		progressFile.Close()
+	}
+	progressFile, progressOut = nil, nil
+}
+
+// EmitProgress writes one event, stamping TimeUTC when empty; a no-op without --progress-json.
+// batch_start resets the per-batch result count.
+func EmitProgress(ev ProgressEvent) {
+	if ev.Event == ProgressBatchStart {
+		progressDone.Store(0)
+	}
+	progressMu.Lock()
+	defer progressMu.Unlock()
+	if progressOut == nil {
+		return
+	}
+	if ev.TimeUTC == "" {
+		ev.TimeUTC = time.Now().UTC().Format(time.RFC3339Nano)
+	}
+	b, err := json.Marshal(ev)
+	if err != nil {
+		return
+	}
+	progressOut.Write(append(b, '\n'))
+}
+
+// emitProgressResult is the result event of one written line.
+func emitProgressResult(env *ResultEnvelope) {
+	if env == nil || env.SiteResult == nil {
+		return
+	}
+	progressMu.Lock()
+	enabled := progressOut != nil
+	progressMu.Unlock()
+	if !enabled {
+		return
+	}
+	sr := env.SiteResult
+	errMsg := lineError(sr)
+	ok := errMsg == "" && !sr.TransferStalled
+	if errMsg == "" && sr.TransferStalled {
+		errMsg = "transfer_stalled"
+	}
+	ev := ProgressEvent{Event: ProgressResult, Site: sr.Name, IP: sr.IP, IPFamily: sr.IPFamily, OK: &ok, Error: errMsg,
+		SpeedKbps: sr.TransferSpeedKbps, TTFBMs: sr.TraceTTFBMs, Done: int(progressDone.Add(1))}
+	if env.Meta != nil {
+		ev.RunTag = env.Meta.RunTag
+	}
+	EmitProgress(ev)
+}
@@ -0,0 +1,71 @@
+package monitor
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestProgressJSONEvents(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "progress.jsonl")
+	if err := SetProgressJSON(path); err != nil {
+		t.Fatal(err)
+	}
+	defer CloseProgressJSON()
+	EmitProgress(ProgressEvent{Event: ProgressBatchStart, RunTag: "r1", Iteration: 1, Sites: 2})
+	emitProgressResult(&ResultEnvelope{Meta: &Meta{RunTag: "r1"}, SiteResult: &SiteResult{Name: "a", IP: "192.0.2.1", IPFamily: "ipv4", TransferSpeedKbps: 900, TraceTTFBMs: 40}})
+	emitProgressResult(&ResultEnvelope{Meta: &Meta{RunTag: "r1"}, SiteResult: &SiteResult{Name: "b", HTTPError: "timeout"}})
+	emitProgressResult(&ResultEnvelope{Meta: &Meta{RunTag: "r1"}, SiteResult: &SiteResult{Name: "c", TransferStalled: true}})
+	code := 3
+	EmitProgress(ProgressEvent{Event: ProgressRunDone, ExitCode: &code})
+	CloseProgressJSON()
+	EmitProgress(ProgressEvent{Event: ProgressRunDone}) // dropped after close
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var evs []ProgressEvent
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var ev ProgressEvent
+		if err := json.Unmarshal(sc.Bytes(), &ev); err != nil {
+			t.Fatalf("line %q: %v", sc.Text(), err)
+		}
+		evs = append(evs, ev)
+	}
+	if len(evs) != 5 {
+		t.Fatalf("got %d events: %+v", len(evs), evs)
+	}
+	if evs[0].Event != ProgressBatchStart || evs[0].Sites != 2 || evs[0].TimeUTC == "" {
+		t.Fatalf("batch_start: %+v", evs[0])
+	}
+	if r := evs[1]; r.Event != ProgressResult || r.OK == nil || !*r.OK || r.Done != 1 || r.RunTag != "r1" || r.SpeedKbps != 900 || r.TTFBMs != 40 {
+		t.Fatalf("ok result: %+v", r)
+	}
+	if r := evs[2]; r.OK == nil || *r.OK || r.Error != "timeout" || r.Done != 2 {
+		t.Fatalf("failed result: %+v", r)
+	}
+	if r := evs[3]; r.OK == nil || *r.OK || r.Error != "transfer_stalled" {
+		t.Fatalf("stalled result: %+v", r)
+	}
+	if r := evs[4]; r.Event != ProgressRunDone || r.ExitCode == nil || *r.ExitCode != 3 {
+		t.Fatalf("run_done: %+v", r)
+	}
+}
+
+func TestSetProgressJSONErrors(t *testing.T) {
+	if err := SetProgressJSON(filepath.Join(t.TempDir(), "missing", "p.jsonl")); err == nil {
+		t.Fatal("unwritable path accepted")
+	}
+	if err := SetProgressJSON("-"); err != nil || progressOut != os.Stderr {
+		t.Fatalf("stderr: %v", err)
+	}
+	SetProgressJSON("")
+	if progressOut != nil {
+		t.Fatal("empty path did not disable")
+	}
+}
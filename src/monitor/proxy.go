@@ -0,0 +1,212 @@
+package monitor
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/iafilius/InternetQualityMonitor/src/types"
+)
+
+// Where the effective proxy of a request came from (SiteResult.ProxyConfigSource).
+const (
+	ProxyFromEnv    = "env"    // HTTP(S)_PROXY / ALL_PROXY / NO_PROXY
+	ProxyFromFlag   = "flag"   // --proxy
+	ProxyFromPAC    = "pac"    // --proxy-pac
+	ProxyFromSite   = "site"   // the site's "proxy" field
+	ProxyFromBypass = "bypass" // a --proxy-bypass rule sent the target direct
+)
+
+// Explicit proxy configuration (flags / per-profile config). Without any of it the monitor
+// keeps using the environment proxy as before.
+var (
+	proxyMu      sync.RWMutex
+	proxyFlagURL *url.URL // --proxy
+	proxyPAC     *pacScript
+	proxyBypass  []proxyBypassRule
+)
+
+// envProxyFor is the environment proxy resolution (seam for tests: the standard library caches
+// the environment on first use).
+var envProxyFor = http.ProxyFromEnvironment
+
+type proxyBypassRule struct {
+	all    bool
+	host   string // exact host or domain suffix (".example.com" also matches example.com)
+	suffix bool
+	glob   string // shell pattern with * and ?
+	cidr   *net.IPNet
+}
+
+// SetProxyConfig sets the explicit proxy used for HTTP sites. proxy is an http://, https://,
+// socks5:// or socks5h:// URL, optionally with user:password; pac is a PAC file path or an
+// http(s) URL, fetched and parsed once here; bypass lists targets that always connect direct
+// (host, .domain, *.domain pattern, CIDR or "*"). Per site, a "proxy" field ("direct" or a
+// proxy URL) overrides all of these. Precedence: site, bypass, PAC, proxy, environment.
+func SetProxyConfig(proxy, pac string, bypass []string) error {
+	var pu *url.URL
+	if p := strings.TrimSpace(proxy); p != "" {
+		var err error
+		if pu, err = parseProxyURL(p); err != nil {
+			return fmt.Errorf("--proxy: %w", err)
+		}
+	}
+	var script *pacScript
+	if p := strings.TrimSpace(pac); p != "" {
+		src, err := loadPAC(p)
+		if err != nil {
+			return fmt.Errorf("--proxy-pac: %w", err)
+		}
+		if script, err = parsePAC(src); err != nil {
+			return fmt.Errorf("--proxy-pac %s: %w", p, err)
+		}
+	}
+	var rules []proxyBypassRule
+	for _, b := range bypass {
+		rule, ok, err := parseBypassRule(b)
+		if err != nil {
+			return fmt.Errorf("--proxy-bypass: %w", err)
+		}
+		if ok {
+			rules = append(rules, rule)
+		}
+	}
+	proxyMu.Lock()
+	proxyFlagURL, proxyPAC, proxyBypass = pu, script, rules
+	proxyMu.Unlock()
+	return nil
+}
+
+// parseProxyURL accepts the proxy schemes net/http can dial; a bare host:port means http.
+func parseProxyURL(s string) (*url.URL, error) {
+	if !strings.Contains(s, "://") {
+		s = "http://" + s
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme %q (want http, https, socks5 or socks5h)", u.Scheme)
+	}
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("proxy %q has no host", s)
+	}
+	return u, nil
+}
+
+func loadPAC(src string) (string, error) {
+	if strings.HasPrefix(src, "http://") || strings.HasPrefix(src, "https://") {
+		// fetched direct: the PAC decides about proxies, it cannot sit behind one
+		c := &http.Client{Timeout: 15 * time.Second, Transport: &http.Transport{Proxy: nil}}
+		resp, err := c.Get(src)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return "", fmt.Errorf("fetch %s: %s", src, resp.Status)
+		}
+		b, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		return string(b), err
+	}
+	b, err := os.ReadFile(src)
+	return string(b), err
+}
+
+func parseBypassRule(s string) (proxyBypassRule, bool, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	switch {
+	case s == "":
+		return proxyBypassRule{}, false, nil
+	case s == "*":
+		return proxyBypassRule{all: true}, true, nil
+	case strings.Contains(s, "/"):
+		_, n, err := net.ParseCIDR(s)
+		if err != nil {
+			return proxyBypassRule{}, false, err
+		}
+		return proxyBypassRule{cidr: n}, true, nil
+	case strings.ContainsAny(s, "*?"):
+		return proxyBypassRule{glob: s}, true, nil
+	case strings.HasPrefix(s, "."):
+		return proxyBypassRule{host: strings.TrimPrefix(s, "."), suffix: true}, true, nil
+	}
+	if ip := net.ParseIP(s); ip != nil {
+		bits := 8 * len(ip.To16())
+		if ip.To4() != nil {
+			ip, bits = ip.To4(), 32
+		}
+		return proxyBypassRule{cidr: &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}}, true, nil
+	}
+	return proxyBypassRule{host: s, suffix: true}, true, nil
+}
+
+// match reports whether the rule exempts host (or the IP about to be dialed).
+func (b proxyBypassRule) match(host string, ip net.IP) bool {
+	switch {
+	case b.all:
+		return true
+	case b.cidr != nil:
+		if hip := net.ParseIP(host); hip != nil {
+			return b.cidr.Contains(hip)
+		}
+		return ip != nil && b.cidr.Contains(ip)
+	case b.glob != "":
+		return pacGlob(b.glob).MatchString(host)
+	}
+	return host == b.host || (b.suffix && strings.HasSuffix(host, "."+b.host))
+}
+
+// effectiveProxy decides how a request to u (dialed at ip, which may be nil) leaves the host:
+// the proxy URL (nil = direct, with credentials, for the transport only), where that decision
+// came from, and whether an explicit exemption (NO_PROXY, bypass rule, site "direct") made it
+// direct. An empty source with a nil proxy is a plain direct connection.
+func effectiveProxy(site types.Site, u *url.URL, ip net.IP) (proxy *url.URL, source string, bypassed bool, err error) {
+	host := strings.ToLower(u.Hostname())
+	if p := strings.TrimSpace(site.Proxy); p != "" {
+		if strings.EqualFold(p, "direct") {
+			return nil, ProxyFromSite, true, nil
+		}
+		pu, err := parseProxyURL(p)
+		if err != nil {
+			return nil, ProxyFromSite, false, fmt.Errorf("site %s proxy: %w", site.Name, err)
+		}
+		return pu, ProxyFromSite, false, nil
+	}
+	proxyMu.RLock()
+	flagURL, pac, rules := proxyFlagURL, proxyPAC, proxyBypass
+	proxyMu.RUnlock()
+	for _, b := range rules {
+		if b.match(host, ip) {
+			return nil, ProxyFromBypass, true, nil
+		}
+	}
+	if pac != nil {
+		res, err := pac.FindProxyForURL(u.String(), host)
+		if err != nil {
+			return nil, ProxyFromPAC, false, err
+		}
+		pu, err := parsePACResult(res)
+		return pu, ProxyFromPAC, false, err
+	}
+	if flagURL != nil {
+		return flagURL, ProxyFromFlag, false, nil
+	}
+	pu, err := envProxyFor(&http.Request{URL: &url.URL{Scheme: u.Scheme, Host: u.Host}})
+	if err != nil || pu != nil {
+		return pu, ProxyFromEnv, false, err
+	}
+	if os.Getenv("HTTPS_PROXY") != "" || os.Getenv("HTTP_PROXY") != "" || os.Getenv("ALL_PROXY") != "" {
+		return nil, ProxyFromEnv, true, nil // NO_PROXY exempted the host
+	}
+	return nil, "", false, nil
+}
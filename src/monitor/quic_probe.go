@@ -0,0 +1,182 @@
+package monitor
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// QUICProbe records a UDP reachability check towards the target IP, separate from the TCP
+// measurement: one QUIC long-header packet with a reserved (greased) version is sent to UDP/443
+// and any QUIC server must answer with a Version Negotiation packet (RFC 9000 §6). No answer
+// after all attempts from a target that advertises HTTP/3 (SiteResult.AltSvcH3) means UDP to it
+// is dropped somewhere on the path — common on corporate networks — which explains failing
+// HTTP/3 while TCP-based HTTP works; other targets may simply run no QUIC server.
+type QUICProbe struct {
+	Port       int      `json:"port"`
+	Attempts   int      `json:"attempts"`
+	Responded  bool     `json:"responded,omitempty"`   // a Version Negotiation reply arrived
+	UDPBlocked bool     `json:"udp_blocked,omitempty"` // every attempt timed out (see AltSvcH3)
+	RTTMs      int64    `json:"rtt_ms,omitempty"`      // send to reply of the answered attempt
+	Versions   []string `json:"versions,omitempty"`    // versions offered by the server (v1, v2, draft-29, ...)
+	Error      string   `json:"error,omitempty"`       // e.g. ICMP port unreachable (reachable, but no QUIC listener)
+}
+
+const (
+	defaultQUICProbeTimeout  = 1 * time.Second
+	defaultQUICProbeAttempts = 2
+	quicProbePacketSize      = 1200 // minimum datagram size a server answers (RFC 9000 §14.1)
+	quicGreaseVersion        = 0x1a2a3a4a
+)
+
+var (
+	quicProbeEnabled  = false
+	quicProbeTimeout  = defaultQUICProbeTimeout
+	quicProbeAttempts = defaultQUICProbeAttempts
+)
+
+// SetQUICProbe enables the per-IP QUIC/UDP reachability probe for https targets.
+func SetQUICProbe(enabled bool) { quicProbeEnabled = enabled }
+
+// SetQUICProbeTimeout sets how long each probe attempt waits for a reply (default 1s).
+func SetQUICProbeTimeout(d time.Duration) {
+	if d > 0 {
+		quicProbeTimeout = d
+	}
+}
+
+// quicProbeForIP probes ip:port when enabled, counting the datagrams into u; nil otherwise.
+func quicProbeForIP(ctx context.Context, u *wireUsage, ip string, port int) *QUICProbe {
+	if !quicProbeEnabled {
+		return nil
+	}
+	return probeQUIC(ctx, u.dial(boundDial(&net.Dialer{})), ip, port, quicProbeAttempts, quicProbeTimeout)
+}
+
+func probeQUIC(ctx context.Context, dial dialFunc, ip string, port, attempts int, timeout time.Duration) *QUICProbe {
+	res := &QUICProbe{Port: port}
+	conn, err := dial(ctx, "udp", net.JoinHostPort(ip, strconv.Itoa(port)))
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	defer conn.Close()
+	buf := make([]byte, 2048)
+	for res.Attempts < attempts {
+		res.Attempts++
+		pkt, scid := quicVersionProbePacket()
+		start := time.Now()
+		if _, err := conn.Write(pkt); err != nil {
+			res.Error = err.Error()
+			return res
+		}
+		deadline := start.Add(timeout)
+		if dl, ok := ctx.Deadline(); ok && dl.Before(deadline) {
+			deadline = dl
+		}
+		conn.SetReadDeadline(deadline)
+		for {
+			n, err := conn.Read(buf)
+			if err != nil {
+				var ne net.Error
+				if errors.As(err, &ne) && ne.Timeout() {
+					break // next attempt
+				}
+				if errors.Is(err, syscall.ECONNREFUSED) {
+					res.Error = "udp port unreachable (ICMP): path is open but nothing listens for QUIC"
+				} else {
+					res.Error = err.Error()
+				}
+				return res
+			}
+			if versions, ok := parseVersionNegotiation(buf[:n], scid); ok {
+				res.Responded = true
+				res.RTTMs = time.Since(start).Milliseconds()
+				res.Versions = versions
+				return res
+			}
+			// unrelated datagram: keep waiting until the deadline
+		}
+		if ctx.Err() != nil {
+			res.Error = ctx.Err().Error()
+			return res
+		}
+	}
+	res.UDPBlocked = true
+	return res
+}
+
+// quicVersionProbePacket builds a padded long-header packet with a greased version and random
+// connection ids; it returns the packet and the source connection id the reply must echo.
+func quicVersionProbePacket() ([]byte, []byte) {
+	ids := make([]byte, 16)
+	_, _ = rand.Read(ids)
+	dcid, scid := ids[:8], ids[8:]
+	pkt := make([]byte, 0, quicProbePacketSize)
+	pkt = append(pkt, 0xc0) // long header, fixed bit set, Initial type
+	pkt = binary.BigEndian.AppendUint32(pkt, quicGreaseVersion)
+	pkt = append(pkt, byte(len(dcid)))
+	pkt = append(pkt, dcid...)
+	pkt = append(pkt, byte(len(scid)))
+	pkt = append(pkt, scid...)
+	return pkt[:quicProbePacketSize], scid
+}
+
+// parseVersionNegotiation accepts a Version Negotiation packet (long header, version 0) whose
+// destination connection id echoes scid, and names the versions it lists.
+func parseVersionNegotiation(b, scid []byte) ([]string, bool) {
+	if len(b) < 7 || b[0]&0x80 == 0 || binary.BigEndian.Uint32(b[1:5]) != 0 {
+		return nil, false
+	}
+	p := 5
+	dl := int(b[p])
+	p++
+	if p+dl > len(b) || string(b[p:p+dl]) != string(scid) {
+		return nil, false
+	}
+	p += dl
+	if p >= len(b) {
+		return nil, false
+	}
+	p += 1 + int(b[p])
+	var out []string
+	for ; p+4 <= len(b); p += 4 {
+		if name := quicVersionName(binary.BigEndian.Uint32(b[p : p+4])); name != "" {
+			out = append(out, name)
+		}
+	}
+	return out, true
+}
+
+func quicVersionName(v uint32) string {
+	switch {
+	case v&0x0f0f0f0f == 0x0a0a0a0a:
+		return "" // greased placeholder
+	case v == 0x00000001:
+		return "v1"
+	case v == 0x6b3343cf:
+		return "v2"
+	case v>>8 == 0xff0000:
+		return fmt.Sprintf("draft-%d", v&0xff)
+	default:
+		return fmt.Sprintf("0x%08x", v)
+	}
+}
+
+// altSvcAdvertisesH3 reports whether an Alt-Svc header offers HTTP/3 (h3 or an h3-NN draft).
+func altSvcAdvertisesH3(v string) bool {
+	for _, alt := range strings.Split(v, ",") {
+		proto, _, _ := strings.Cut(strings.TrimSpace(alt), "=")
+		if proto == "h3" || strings.HasPrefix(proto, "h3-") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,89 @@
+package monitor
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+)
+
+// fakeQUICServer answers every long-header packet with a Version Negotiation offering v1 and
+// draft-29 (plus a greased entry), like a real QUIC server does for an unknown version.
+func fakeQUICServer(t *testing.T) (port int, stop func()) {
+	t.Helper()
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	go func() {
+		buf := make([]byte, 2048)
+		for {
+			n, addr, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if n < quicProbePacketSize || buf[0]&0x80 == 0 {
+				continue
+			}
+			dcid := buf[6 : 6+int(buf[5])]
+			sOff := 6 + len(dcid)
+			scid := buf[sOff+1 : sOff+1+int(buf[sOff])]
+			resp := []byte{0x80, 0, 0, 0, 0, byte(len(scid))}
+			resp = append(resp, scid...)
+			resp = append(resp, byte(len(dcid)))
+			resp = append(resp, dcid...)
+			for _, v := range []uint32{0x00000001, 0xff00001d, 0x3a4a5a6a} {
+				resp = binary.BigEndian.AppendUint32(resp, v)
+			}
+			pc.WriteTo(resp, addr)
+		}
+	}()
+	return pc.LocalAddr().(*net.UDPAddr).Port, func() { pc.Close() }
+}
+
+func TestProbeQUIC_VersionNegotiation(t *testing.T) {
+	port, stop := fakeQUICServer(t)
+	defer stop()
+	d := &net.Dialer{}
+	res := probeQUIC(context.Background(), d.DialContext, "127.0.0.1", port, 2, time.Second)
+	if !res.Responded || res.UDPBlocked || res.Attempts != 1 {
+		t.Fatalf("expected a reply on the first attempt: %+v", res)
+	}
+	if len(res.Versions) != 2 || res.Versions[0] != "v1" || res.Versions[1] != "draft-29" {
+		t.Fatalf("versions=%v", res.Versions)
+	}
+}
+
+func TestProbeQUIC_SilentDropIsBlocked(t *testing.T) {
+	// A bound socket that never answers behaves like a firewall silently dropping UDP.
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer pc.Close()
+	d := &net.Dialer{}
+	u := &wireUsage{}
+	res := probeQUIC(context.Background(), u.dial(d.DialContext), "127.0.0.1", pc.LocalAddr().(*net.UDPAddr).Port, 2, 50*time.Millisecond)
+	if res.Responded || !res.UDPBlocked || res.Attempts != 2 {
+		t.Fatalf("expected blocked after 2 attempts: %+v", res)
+	}
+	if got := u.tx.Load(); got != 2*quicProbePacketSize {
+		t.Fatalf("counted %d bytes sent, want %d", got, 2*quicProbePacketSize)
+	}
+}
+
+func TestAltSvcAdvertisesH3(t *testing.T) {
+	cases := map[string]bool{
+		`h3=":443"; ma=86400, h3-29=":443"`: true,
+		`h3-29=":443"`:                      true,
+		`h2=":443"`:                         false,
+		``:                                  false,
+		`clear`:                             false,
+	}
+	for in, want := range cases {
+		if got := altSvcAdvertisesH3(in); got != want {
+			t.Errorf("altSvcAdvertisesH3(%q)=%v want %v", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package monitor
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RateLimit records a throttling response from the target: any 429, or a 503 carrying
+// Retry-After (a 503 without it is treated as an ordinary server error). Kept apart from the
+// generic errors so a server shedding load is not read as a network problem.
+type RateLimit struct {
+	Status        int    `json:"status"`
+	Request       string `json:"request"`                 // head, get or range: the first request that was throttled
+	RetryAfter    string `json:"retry_after,omitempty"`   // raw Retry-After header
+	RetryAfterSec int64  `json:"retry_after_s,omitempty"` // parsed delay (delta-seconds or HTTP-date relative to the response)
+}
+
+// rateLimitFrom returns the throttling details of resp, or nil when it is not rate limited.
+func rateLimitFrom(resp *http.Response, request string) *RateLimit {
+	if resp == nil {
+		return nil
+	}
+	ra := strings.TrimSpace(resp.Header.Get("Retry-After"))
+	if resp.StatusCode != http.StatusTooManyRequests && (resp.StatusCode != http.StatusServiceUnavailable || ra == "") {
+		return nil
+	}
+	rl := &RateLimit{Status: resp.StatusCode, Request: request, RetryAfter: ra}
+	now := time.Now()
+	if d, err := http.ParseTime(resp.Header.Get("Date")); err == nil {
+		now = d
+	}
+	if sec, ok := parseRetryAfter(ra, now); ok {
+		rl.RetryAfterSec = sec
+	}
+	return rl
+}
+
+// parseRetryAfter reads a Retry-After value (RFC 9110 §10.2.3): delay-seconds or an HTTP-date,
+// which is converted to seconds after now (never negative).
+func parseRetryAfter(v string, now time.Time) (int64, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return max(n, 0), n >= 0
+	}
+	t, err := http.ParseTime(v)
+	if err != nil {
+		return 0, false
+	}
+	return max(int64(t.Sub(now).Round(time.Second)/time.Second), 0), true
+}
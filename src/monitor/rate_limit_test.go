@@ -0,0 +1,95 @@
+package monitor
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	typespkg "github.com/iafilius/InternetQualityMonitor/src/types"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	cases := []struct {
+		in   string
+		want int64
+		ok   bool
+	}{
+		{"120", 120, true},
+		{"0", 0, true},
+		{now.Add(90 * time.Second).Format(http.TimeFormat), 90, true},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0, true}, // date in the past: retry now
+		{"-5", 0, false},
+		{"soon", 0, false},
+		{"", 0, false},
+	}
+	for _, c := range cases {
+		got, ok := parseRetryAfter(c.in, now)
+		if got != c.want || ok != c.ok {
+			t.Errorf("parseRetryAfter(%q) = %d, %v; want %d, %v", c.in, got, ok, c.want, c.ok)
+		}
+	}
+}
+
+func TestRateLimitFrom(t *testing.T) {
+	mk := func(code int, ra string) *http.Response {
+		h := http.Header{}
+		if ra != "" {
+			h.Set("Retry-After", ra)
+		}
+		return &http.Response{StatusCode: code, Header: h}
+	}
+	if rl := rateLimitFrom(mk(429, ""), "get"); rl == nil || rl.Status != 429 || rl.RetryAfterSec != 0 {
+		t.Fatalf("429 without Retry-After: %+v", rl)
+	}
+	if rl := rateLimitFrom(mk(503, "30"), "head"); rl == nil || rl.RetryAfterSec != 30 || rl.Request != "head" {
+		t.Fatalf("503 with Retry-After: %+v", rl)
+	}
+	if rl := rateLimitFrom(mk(503, ""), "get"); rl != nil {
+		t.Fatalf("plain 503 is a server error, not throttling: %+v", rl)
+	}
+	if rl := rateLimitFrom(mk(200, "30"), "get"); rl != nil {
+		t.Fatalf("200 is not throttled: %+v", rl)
+	}
+}
+
+func TestMonitorSiteIP_RateLimitedGET(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			w.Header().Set("Retry-After", "45")
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(strings.Repeat("slow down ", 1000)))
+			return
+		}
+		w.WriteHeader(200)
+	}))
+	defer srv.Close()
+	u, _ := url.Parse(srv.URL)
+	for _, k := range []string{"HTTP_PROXY", "HTTPS_PROXY", "ALL_PROXY", "NO_PROXY"} {
+		if v, ok := os.LookupEnv(k); ok {
+			t.Setenv(k, v)
+			os.Unsetenv(k)
+		}
+	}
+	tmp := t.TempDir() + "/res.jsonl"
+	resultChan = nil
+	resultPath = tmp
+	MonitorSiteIP(typespkg.Site{Name: "throttled", URL: srv.URL}, u.Hostname(), []string{u.Hostname()}, 0)
+	data, _ := os.ReadFile(tmp)
+	var env ResultEnvelope
+	if err := json.Unmarshal([]byte(strings.TrimSpace(string(data))), &env); err != nil || env.SiteResult == nil {
+		t.Fatalf("decode result: %v", err)
+	}
+	sr := env.SiteResult
+	if sr.RateLimited == nil || sr.RateLimited.Status != 429 || sr.RateLimited.Request != "get" || sr.RateLimited.RetryAfterSec != 45 {
+		t.Fatalf("rate limit not recorded: %+v", sr.RateLimited)
+	}
+	if !strings.HasPrefix(sr.HTTPError, "rate_limited:") || sr.TransferSizeBytes != 0 {
+		t.Fatalf("throttled GET should be an error without a measured transfer: err=%q bytes=%d", sr.HTTPError, sr.TransferSizeBytes)
+	}
+}
@@ -0,0 +1,108 @@
+package monitor
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/iafilius/InternetQualityMonitor/src/types"
+)
+
+// siteMethod returns the measured request's method for site: GET unless the config asks for
+// HEAD or POST.
+func siteMethod(site types.Site) string {
+	switch m := strings.ToUpper(strings.TrimSpace(site.Method)); m {
+	case http.MethodHead, http.MethodPost:
+		return m
+	}
+	return http.MethodGet
+}
+
+// ValidateSite reports configuration errors in a site's request template.
+func ValidateSite(site types.Site) error {
+	if _, ok := LookupMeasurer(site.Probe); !ok {
+		return fmt.Errorf("site %q: unknown probe %q (use %s)", site.Name, site.Probe, strings.Join(ProbeTypes(), ", "))
+	}
+	if p := strings.ToLower(strings.TrimSpace(site.Probe)); p == ProbeSoak {
+		if site.Method != "" || site.Body != "" || site.SHA256 != "" {
+			return fmt.Errorf("site %q: method, body and sha256 only apply to the http probe", site.Name)
+		}
+	} else if p != "" && p != ProbeHTTP {
+		if site.Method != "" || site.Body != "" || len(site.Headers) > 0 || site.Auth != nil || site.SHA256 != "" {
+			return fmt.Errorf("site %q: method, body, headers, auth and sha256 only apply to the http probe", site.Name)
+		}
+	}
+	if m := strings.ToUpper(strings.TrimSpace(site.Method)); m != "" && m != http.MethodGet && m != http.MethodHead && m != http.MethodPost {
+		return fmt.Errorf("site %q: unsupported method %q (use GET, HEAD or POST)", site.Name, site.Method)
+	}
+	if site.Body != "" && siteMethod(site) != http.MethodPost {
+		return fmt.Errorf("site %q: body is only sent with method POST", site.Name)
+	}
+	if a := site.Auth; a != nil {
+		switch strings.ToLower(a.Type) {
+		case "basic":
+			if a.Username == "" {
+				return fmt.Errorf("site %q: basic auth needs a username", site.Name)
+			}
+		case "bearer":
+			if a.Token == "" {
+				return fmt.Errorf("site %q: bearer auth needs a token", site.Name)
+			}
+		default:
+			return fmt.Errorf("site %q: unknown auth type %q (use basic or bearer)", site.Name, a.Type)
+		}
+	}
+	if _, err := NormalizeHTTPVersion(site.HTTPVersion); err != nil {
+		return fmt.Errorf("site %q: http_version: %v", site.Name, err)
+	}
+	if d := strings.TrimSpace(site.SHA256); d != "" {
+		if b, err := hex.DecodeString(d); err != nil || len(b) != sha256.Size {
+			return fmt.Errorf("site %q: sha256 must be 64 hex characters", site.Name)
+		}
+		if siteMethod(site) == http.MethodHead {
+			return fmt.Errorf("site %q: sha256 needs a response body (method HEAD has none)", site.Name)
+		}
+	}
+	return nil
+}
+
+// newSiteRequest builds a request to site.URL with the site's headers and auth applied; POST
+// requests carry the configured body (rebuilt per call so retries resend it).
+func newSiteRequest(ctx context.Context, method string, site types.Site) (*http.Request, error) {
+	var body io.Reader
+	if method == http.MethodPost {
+		body = strings.NewReader(os.ExpandEnv(site.Body))
+	}
+	req, err := http.NewRequestWithContext(ctx, method, site.URL, body)
+	if err != nil {
+		return nil, err
+	}
+	applySiteRequest(req, site)
+	return req, nil
+}
+
+// applySiteRequest sets the configured headers and Authorization on req. A "Host" header
+// overrides the request host (virtual-host testing against a fixed URL).
+func applySiteRequest(req *http.Request, site types.Site) {
+	for k, v := range site.Headers {
+		v = os.ExpandEnv(v)
+		if strings.EqualFold(k, "Host") {
+			req.Host = v
+			continue
+		}
+		req.Header.Set(k, v)
+	}
+	if a := site.Auth; a != nil {
+		switch strings.ToLower(a.Type) {
+		case "basic":
+			req.SetBasicAuth(os.ExpandEnv(a.Username), os.ExpandEnv(a.Password))
+		case "bearer":
+			req.Header.Set("Authorization", "Bearer "+os.ExpandEnv(a.Token))
+		}
+	}
+}
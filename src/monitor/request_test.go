@@ -0,0 +1,109 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	typespkg "github.com/iafilius/InternetQualityMonitor/src/types"
+)
+
+func TestNewSiteRequest_HeadersAuthBody(t *testing.T) {
+	t.Setenv("IQM_TEST_TOKEN", "s3cret")
+	site := typespkg.Site{Name: "api", URL: "http://example.test/api", Method: "post", Body: `{"q":1}`,
+		Headers: map[string]string{"User-Agent": "iqm-test/1", "Cookie": "a=b", "Host": "vhost.test"},
+		Auth:    &typespkg.Auth{Type: "bearer", Token: "${IQM_TEST_TOKEN}"}}
+	if m := siteMethod(site); m != http.MethodPost {
+		t.Fatalf("method: %s", m)
+	}
+	req, err := newSiteRequest(context.Background(), siteMethod(site), site)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Header.Get("User-Agent") != "iqm-test/1" || req.Header.Get("Cookie") != "a=b" || req.Host != "vhost.test" {
+		t.Fatalf("headers not applied: %v host=%s", req.Header, req.Host)
+	}
+	if req.Header.Get("Authorization") != "Bearer s3cret" {
+		t.Fatalf("bearer token not expanded: %q", req.Header.Get("Authorization"))
+	}
+	if b, _ := io.ReadAll(req.Body); string(b) != `{"q":1}` {
+		t.Fatalf("body: %q", b)
+	}
+	basic := typespkg.Site{URL: "http://example.test/", Auth: &typespkg.Auth{Type: "basic", Username: "u", Password: "p"}}
+	req, _ = newSiteRequest(context.Background(), siteMethod(basic), basic)
+	if u, p, ok := req.BasicAuth(); !ok || u != "u" || p != "p" || req.Method != http.MethodGet {
+		t.Fatalf("basic auth/default method: %v %s %s %s", ok, u, p, req.Method)
+	}
+}
+
+func TestValidateSite(t *testing.T) {
+	bad := []typespkg.Site{
+		{Name: "m", Method: "DELETE"},
+		{Name: "b", Body: "x"},
+		{Name: "a", Auth: &typespkg.Auth{Type: "digest"}},
+		{Name: "t", Auth: &typespkg.Auth{Type: "bearer"}},
+	}
+	for _, s := range bad {
+		if ValidateSite(s) == nil {
+			t.Fatalf("site %q should be rejected", s.Name)
+		}
+	}
+	if err := ValidateSite(typespkg.Site{Name: "ok", Method: "HEAD", Headers: map[string]string{"X": "y"}}); err != nil {
+		t.Fatalf("valid site rejected: %v", err)
+	}
+}
+
+func TestMonitorSiteIP_RecordsMethodAndSendsTemplate(t *testing.T) {
+	var mu sync.Mutex
+	seen := map[string]http.Header{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		seen[r.Method] = r.Header.Clone()
+		mu.Unlock()
+		if r.Method == http.MethodPost {
+			b, _ := io.ReadAll(r.Body)
+			w.Write(append([]byte("echo:"), b...))
+			return
+		}
+		w.WriteHeader(200)
+	}))
+	defer srv.Close()
+	u, _ := url.Parse(srv.URL)
+	for _, k := range []string{"HTTP_PROXY", "HTTPS_PROXY", "ALL_PROXY", "NO_PROXY"} {
+		if v, ok := os.LookupEnv(k); ok {
+			t.Setenv(k, v)
+			os.Unsetenv(k)
+		}
+	}
+	tmp := t.TempDir() + "/res.jsonl"
+	resultChan = nil
+	resultPath = tmp
+	site := typespkg.Site{Name: "post", URL: srv.URL, Method: "POST", Body: "hello", Headers: map[string]string{"User-Agent": "iqm-template"}}
+	MonitorSiteIP(site, u.Hostname(), []string{u.Hostname()}, 0)
+	data, _ := os.ReadFile(tmp)
+	var env ResultEnvelope
+	if err := json.Unmarshal([]byte(strings.TrimSpace(string(data))), &env); err != nil {
+		t.Fatal(err)
+	}
+	if env.SiteResult == nil || env.SiteResult.HTTPMethod != "POST" {
+		t.Fatalf("http_method not recorded: %+v", env.SiteResult)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if seen[http.MethodPost] == nil || seen[http.MethodPost].Get("User-Agent") != "iqm-template" {
+		t.Fatalf("POST did not carry the configured headers: %v", seen)
+	}
+	if seen[http.MethodHead] == nil || seen[http.MethodHead].Get("User-Agent") != "iqm-template" {
+		t.Fatalf("HEAD did not carry the configured headers: %v", seen)
+	}
+	if _, ok := seen[http.MethodGet]; ok {
+		t.Fatalf("POST targets must not get the Range GET cache probe")
+	}
+}
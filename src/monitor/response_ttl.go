@@ -0,0 +1,154 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strconv"
+	"time"
+)
+
+// ResponseTTL is the TTL (IPv6: hop limit) of an echo reply from the site's address. Hosts send
+// with one of a few initial TTLs (64 Linux/macOS, 128 Windows, 255 routers and some CDNs), so the
+// distance up to the next of them estimates the routers on the return path. A return path that
+// differs clearly from the traced forward path means asymmetric routing; a target whose hop count
+// changes between batches was rerouted. The IP ID is not read: that needs raw sockets.
+type ResponseTTL struct {
+	TTL        int `json:"ttl,omitempty"`
+	InitialTTL int `json:"initial_ttl,omitempty"`
+	Hops       int `json:"hops"` // InitialTTL - TTL
+	// routers before the target in the batch's route trace (--route-trace), 0 when not traced
+	// or not reached; Asymmetric when Hops differs from it by AsymmetricHopDelta or more
+	ForwardHops int    `json:"forward_hops,omitempty"`
+	Asymmetric  bool   `json:"asymmetric,omitempty"`
+	Tool        string `json:"tool,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// AsymmetricHopDelta is the difference between return and forward hop counts from which a path
+// counts as asymmetric; one or two hops are within the noise of middleboxes and load balancers.
+const AsymmetricHopDelta = 3
+
+const responseTTLTimeout = 3 * time.Second
+
+var (
+	responseTTLEnabled = false
+
+	// seam for tests
+	runEchoPing = execEchoPing
+)
+
+// SetResponseTTL enables the echo probe that records each address's response TTL (--response-ttl).
+func SetResponseTTL(enabled bool) { responseTTLEnabled = enabled }
+
+// responseTTLForIP pings ip once and derives its return hop count, compared with the forward
+// path when route is a trace that reached ip; nil when disabled. It runs in the background of the
+// measurement (startPathProbes), so its up to responseTTLTimeout is not on the measured path.
+func responseTTLForIP(ctx context.Context, ip string, v6 bool, route *RoutePath) *ResponseTTL {
+	if !responseTTLEnabled {
+		return nil
+	}
+	pctx, cancel := context.WithTimeout(ctx, responseTTLTimeout)
+	defer cancel()
+	tool, out, err := runEchoPing(pctx, ip, v6)
+	res := &ResponseTTL{Tool: tool}
+	ttl, ok := parseEchoTTL(out)
+	if !ok {
+		if err == nil {
+			err = errors.New("no echo reply")
+		}
+		res.Error = err.Error()
+		return res
+	}
+	res.TTL, res.InitialTTL = ttl, InitialTTL(ttl)
+	res.Hops = res.InitialTTL - ttl
+	if route != nil && route.Reached && len(route.Hops) > 0 {
+		res.ForwardHops = route.Hops[len(route.Hops)-1].TTL - 1
+		d := res.Hops - res.ForwardHops
+		res.Asymmetric = d >= AsymmetricHopDelta || d <= -AsymmetricHopDelta
+	}
+	return res
+}
+
+// InitialTTL is the smallest common initial TTL (32, 64, 128, 255) at or above an observed one.
+func InitialTTL(ttl int) int {
+	for _, t := range []int{32, 64, 128} {
+		if ttl <= t {
+			return t
+		}
+	}
+	return 255
+}
+
+// execEchoPing sends one ICMP echo with the system ping, which needs no privileges of its own,
+// from the --interface/--source-ip binding when there is one.
+func execEchoPing(ctx context.Context, ip string, v6 bool) (string, []byte, error) {
+	tool, args := echoPingCommand(v6)
+	args = append(args, echoBindArgs(currentBinding(), ip, v6)...)
+	out, err := exec.CommandContext(ctx, tool, append(args, ip)...).Output()
+	return tool, out, err
+}
+
+// echoBindArgs are the ping options that send the echo from binding b: iputils ping -I takes the
+// interface when connections are bound to the device, else the source address of the target's
+// family; macOS and Windows ping take the source address with -S.
+func echoBindArgs(b *SourceBinding, ip string, v6 bool) []string {
+	if b == nil {
+		return nil
+	}
+	network := "ip4"
+	if v6 {
+		network = "ip6"
+	}
+	src := b.localIPFor(network, ip)
+	switch {
+	case runtime.GOOS == "windows" || runtime.GOOS == "darwin":
+		if src != nil {
+			return []string{"-S", src.String()}
+		}
+	case b.Device:
+		return []string{"-I", b.Interface}
+	case src != nil:
+		return []string{"-I", src.String()}
+	}
+	return nil
+}
+
+// echoPingCommand is the ping binary and its arguments (without the target) for one echo.
+func echoPingCommand(v6 bool) (string, []string) {
+	switch runtime.GOOS {
+	case "windows":
+		if v6 {
+			return "ping", []string{"-6", "-n", "1", "-w", "2000"}
+		}
+		return "ping", []string{"-4", "-n", "1", "-w", "2000"}
+	case "darwin":
+		if v6 {
+			return "ping6", []string{"-n", "-c", "1"}
+		}
+		return "ping", []string{"-n", "-c", "1", "-t", "2"}
+	default:
+		if v6 {
+			return "ping", []string{"-6", "-n", "-c", "1", "-W", "2"}
+		}
+		return "ping", []string{"-n", "-c", "1", "-W", "2"}
+	}
+}
+
+// echoTTLRe matches the reply TTL of iputils/BSD ping ("ttl=57"), Windows ("TTL=57") and the hop
+// limit of macOS ping6 ("hlim=57").
+var echoTTLRe = regexp.MustCompile(`(?i)\b(?:ttl|hlim)=(\d+)`)
+
+func parseEchoTTL(out []byte) (int, bool) {
+	m := echoTTLRe.FindSubmatch(out)
+	if m == nil {
+		return 0, false
+	}
+	ttl, err := strconv.Atoi(string(m[1]))
+	if err != nil || ttl <= 0 || ttl > 255 {
+		return 0, false
+	}
+	return ttl, true
+}
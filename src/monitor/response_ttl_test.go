@@ -0,0 +1,74 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"net"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestParseEchoTTL(t *testing.T) {
+	for out, want := range map[string]int{
+		"64 bytes from 192.0.2.1: icmp_seq=1 ttl=57 time=9.81 ms":                   57,
+		"Reply from 192.0.2.1: bytes=32 time=12ms TTL=116":                          116,
+		"16 bytes from 2001:db8::1, icmp_seq=0 hlim=53 time=20.1 ms":                53,
+		"PING 192.0.2.1 (192.0.2.1) 56(84) bytes of data.\n\n1 packets transmitted": 0,
+	} {
+		got, ok := parseEchoTTL([]byte(out))
+		if got != want || ok != (want > 0) {
+			t.Errorf("%q: got %d %v, want %d", out, got, ok, want)
+		}
+	}
+	for ttl, want := range map[int]int{1: 32, 57: 64, 64: 64, 65: 128, 116: 128, 240: 255} {
+		if got := InitialTTL(ttl); got != want {
+			t.Errorf("InitialTTL(%d) = %d, want %d", ttl, got, want)
+		}
+	}
+}
+
+func TestResponseTTLForIP(t *testing.T) {
+	prevRun, prevEnabled := runEchoPing, responseTTLEnabled
+	defer func() { runEchoPing, responseTTLEnabled = prevRun, prevEnabled }()
+	if responseTTLForIP(context.Background(), "192.0.2.1", false, nil) != nil {
+		t.Fatalf("disabled probe must return nil")
+	}
+	SetResponseTTL(true)
+	runEchoPing = func(ctx context.Context, ip string, v6 bool) (string, []byte, error) {
+		return "ping", []byte("64 bytes from " + ip + ": icmp_seq=1 ttl=52 time=9 ms"), nil
+	}
+	// forward: the target answers at TTL 5, so 4 routers; back: 64-52 = 12
+	route := &RoutePath{Reached: true, Hops: []RouteHop{{TTL: 1}, {TTL: 2}, {TTL: 3}, {TTL: 4}, {TTL: 5, IP: "192.0.2.1"}}}
+	got := responseTTLForIP(context.Background(), "192.0.2.1", false, route)
+	if got == nil || got.TTL != 52 || got.InitialTTL != 64 || got.Hops != 12 || got.ForwardHops != 4 || !got.Asymmetric {
+		t.Fatalf("got %+v", got)
+	}
+	if got := responseTTLForIP(context.Background(), "192.0.2.1", false, nil); got.Asymmetric || got.ForwardHops != 0 {
+		t.Fatalf("without a trace: %+v", got)
+	}
+	runEchoPing = func(ctx context.Context, ip string, v6 bool) (string, []byte, error) {
+		return "ping", []byte("1 packets transmitted, 0 received"), errors.New("exit status 1")
+	}
+	if got := responseTTLForIP(context.Background(), "192.0.2.1", false, nil); got.TTL != 0 || got.Error != "exit status 1" {
+		t.Fatalf("no reply: %+v", got)
+	}
+}
+
+func TestEchoBindArgs(t *testing.T) {
+	if echoBindArgs(nil, "192.0.2.1", false) != nil {
+		t.Fatal("no binding, no arguments")
+	}
+	src := &SourceBinding{IPv4: net.ParseIP("192.0.2.77").To4(), IPv6: net.ParseIP("2001:db8::77")}
+	dev := &SourceBinding{Interface: "eth1", IPv4: src.IPv4, Device: true}
+	want := map[string][2]string{"windows": {"-S 2001:db8::77", "-S 192.0.2.77"}, "darwin": {"-S 2001:db8::77", "-S 192.0.2.77"}}[runtime.GOOS]
+	if want[0] == "" {
+		want = [2]string{"-I 2001:db8::77", "-I eth1"}
+	}
+	if got := strings.Join(echoBindArgs(src, "2001:db8::1", true), " "); got != want[0] {
+		t.Fatalf("source address: %q, want %q", got, want[0])
+	}
+	if got := strings.Join(echoBindArgs(dev, "192.0.2.1", false), " "); got != want[1] {
+		t.Fatalf("device: %q, want %q", got, want[1])
+	}
+}
@@ -0,0 +1,55 @@
+package monitor
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ErrResultsFileInUse is returned by LockResultsFile while a monitor is writing the file.
+var ErrResultsFileInUse = errors.New("results file is in use by a running monitor")
+
+// The results writer holds a shared lock on its results file for as long as it has it open;
+// tools that replace the file (prune) take an exclusive one. On platforms without file locks
+// both are no-ops and those tools fall back to checking the file did not change.
+
+// LockResultsFile locks the results file at path for a rewrite; it fails with
+// ErrResultsFileInUse while a monitor has the file open. Close the returned file to unlock.
+func LockResultsFile(path string) (*os.File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(f, true); err != nil {
+		f.Close()
+		if errors.Is(err, errLockHeld) {
+			return nil, fmt.Errorf("%s: %w", path, ErrResultsFileInUse)
+		}
+		return nil, err
+	}
+	return f, nil
+}
+
+// openResultsAppend opens path for appending under the writer's shared lock. A rewrite that
+// held the lock meanwhile has replaced the file, so the lock is taken on the new one instead.
+func openResultsAppend(path string) (*os.File, error) {
+	for {
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return nil, err
+		}
+		if err := lockFile(f, false); err != nil {
+			f.Close()
+			return nil, err
+		}
+		held, err := f.Stat()
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		if cur, err := os.Stat(path); err == nil && os.SameFile(held, cur) {
+			return f, nil
+		}
+		f.Close()
+	}
+}
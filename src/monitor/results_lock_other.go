@@ -0,0 +1,13 @@
+//go:build !unix
+
+package monitor
+
+import (
+	"errors"
+	"os"
+)
+
+var errLockHeld = errors.New("lock held")
+
+// lockFile is a no-op without flock.
+func lockFile(*os.File, bool) error { return nil }
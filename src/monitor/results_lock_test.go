@@ -0,0 +1,55 @@
+//go:build unix
+
+package monitor
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestResultsFileLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "res.jsonl")
+	w, err := openResultsAppend(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LockResultsFile(path); !errors.Is(err, ErrResultsFileInUse) {
+		t.Fatalf("lock while the writer has the file: %v", err)
+	}
+	w.Close()
+	lock, err := LockResultsFile(path)
+	if err != nil {
+		t.Fatalf("lock after the writer closed: %v", err)
+	}
+
+	// a writer opening during a rewrite waits, then appends to the replaced file
+	opened := make(chan *os.File, 1)
+	go func() {
+		f, err := openResultsAppend(path)
+		if err != nil {
+			t.Error(err)
+		}
+		opened <- f
+	}()
+	select {
+	case <-opened:
+		t.Fatal("writer opened the file while it was locked for a rewrite")
+	case <-time.After(50 * time.Millisecond):
+	}
+	tmp := path + ".tmp"
+	os.WriteFile(tmp, []byte("kept\n"), 0o644)
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatal(err)
+	}
+	lock.Close()
+	f := <-opened
+	defer f.Close()
+	held, _ := f.Stat()
+	cur, _ := os.Stat(path)
+	if !os.SameFile(held, cur) {
+		t.Fatal("writer kept the replaced file open")
+	}
+}
@@ -0,0 +1,109 @@
+package monitor
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"time"
+
+	"github.com/iafilius/InternetQualityMonitor/src/types"
+)
+
+// ReuseExperiment compares the same small request on a fresh connection (new TCP and TLS
+// handshake) and on the warm connection left by the measurement. The TTFB difference is the
+// pure setup cost a client pays when it cannot reuse connections.
+type ReuseExperiment struct {
+	ColdTTFBMs    int64  `json:"cold_ttfb_ms,omitempty"`    // request start to first byte on a new connection
+	ColdConnectMs int64  `json:"cold_connect_ms,omitempty"` // TCP connect of the fresh connection
+	ColdTLSMs     int64  `json:"cold_tls_ms,omitempty"`     // TLS handshake of the fresh connection
+	WarmTTFBMs    int64  `json:"warm_ttfb_ms,omitempty"`    // request start to first byte on the reused connection
+	WarmReused    bool   `json:"warm_reused"`               // the warm request really got a pooled connection
+	SetupCostMs   int64  `json:"setup_cost_ms,omitempty"`   // ColdTTFBMs - WarmTTFBMs (only when both succeeded and WarmReused)
+	ColdError     string `json:"cold_error,omitempty"`
+	WarmError     string `json:"warm_error,omitempty"`
+}
+
+var reuseExperimentEnabled = false
+
+// SetReuseExperiment enables the cold vs warm connection experiment per target IP.
+func SetReuseExperiment(enabled bool) { reuseExperimentEnabled = enabled }
+
+// reuseProbeResult is one timed request of the experiment.
+type reuseProbeResult struct {
+	ttfb, connect, tlsDur time.Duration
+	reused                bool
+	err                   error
+}
+
+// runReuseExperiment issues the cold request on a clone of transport with keep-alives off (so
+// it can neither take nor leave a pooled connection) and then the warm one through client.
+// GET targets ask for a single byte so neither request transfers the body again.
+func runReuseExperiment(ctx context.Context, client *http.Client, transport *http.Transport, site types.Site, probeVal string) *ReuseExperiment {
+	cold := transport.Clone()
+	cold.DisableKeepAlives = true
+	defer cold.CloseIdleConnections()
+	coldRes := timedReuseProbe(ctx, &http.Client{Transport: cold, Timeout: client.Timeout}, site, probeVal)
+	warmRes := timedReuseProbe(ctx, client, site, probeVal)
+	ex := &ReuseExperiment{
+		ColdTTFBMs:    coldRes.ttfb.Milliseconds(),
+		ColdConnectMs: coldRes.connect.Milliseconds(),
+		ColdTLSMs:     coldRes.tlsDur.Milliseconds(),
+		WarmTTFBMs:    warmRes.ttfb.Milliseconds(),
+		WarmReused:    warmRes.reused,
+	}
+	if coldRes.err != nil {
+		ex.ColdError = coldRes.err.Error()
+	}
+	if warmRes.err != nil {
+		ex.WarmError = warmRes.err.Error()
+	}
+	if coldRes.err == nil && warmRes.err == nil && warmRes.reused {
+		ex.SetupCostMs = ex.ColdTTFBMs - ex.WarmTTFBMs
+	}
+	return ex
+}
+
+func timedReuseProbe(ctx context.Context, client *http.Client, site types.Site, probeVal string) reuseProbeResult {
+	var res reuseProbeResult
+	method := siteMethod(site)
+	req, err := newSiteRequest(ctx, method, site)
+	if err != nil {
+		res.err = err
+		return res
+	}
+	req.Header.Set("X-Probe", probeVal)
+	if method == http.MethodGet {
+		req.Header.Set("Range", "bytes=0-0")
+	}
+	var connStart, connDone, tlsStart, tlsDone, firstByte time.Time
+	trace := &httptrace.ClientTrace{
+		ConnectStart:         func(string, string) { connStart = time.Now() },
+		ConnectDone:          func(string, string, error) { connDone = time.Now() },
+		TLSHandshakeStart:    func() { tlsStart = time.Now() },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { tlsDone = time.Now() },
+		GotConn:              func(info httptrace.GotConnInfo) { res.reused = info.Reused },
+		GotFirstResponseByte: func() { firstByte = time.Now() },
+	}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		res.err = err
+		return res
+	}
+	// drain the (at most one byte) body so the warm connection stays reusable
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	resp.Body.Close()
+	if !firstByte.IsZero() {
+		res.ttfb = firstByte.Sub(start)
+	}
+	if !connStart.IsZero() && !connDone.IsZero() {
+		res.connect = connDone.Sub(connStart)
+	}
+	if !tlsStart.IsZero() && !tlsDone.IsZero() {
+		res.tlsDur = tlsDone.Sub(tlsStart)
+	}
+	return res
+}
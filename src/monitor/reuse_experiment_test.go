@@ -0,0 +1,49 @@
+package monitor
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	typespkg "github.com/iafilius/InternetQualityMonitor/src/types"
+)
+
+func TestRunReuseExperiment_ColdDialsWarmReuses(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Range") != "bytes=0-0" && r.Header.Get("X-Warmup") == "" {
+			t.Errorf("GET probe should ask for one byte, got Range=%q", r.Header.Get("Range"))
+		}
+		w.Write([]byte("x"))
+	}))
+	defer srv.Close()
+	var dials int32
+	tr := &http.Transport{Proxy: nil, DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+		atomic.AddInt32(&dials, 1)
+		return (&net.Dialer{}).DialContext(ctx, network, addr)
+	}}
+	client := &http.Client{Transport: tr}
+	// the measurement leaves one idle connection in the pool
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	req.Header.Set("X-Warmup", "1")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	ex := runReuseExperiment(context.Background(), client, tr, typespkg.Site{Name: "t", URL: srv.URL}, "p")
+	if ex.ColdError != "" || ex.WarmError != "" {
+		t.Fatalf("errors: %+v", ex)
+	}
+	if !ex.WarmReused {
+		t.Fatalf("warm request should reuse the pooled connection: %+v", ex)
+	}
+	if n := atomic.LoadInt32(&dials); n != 2 {
+		t.Fatalf("want exactly one extra dial for the cold request, got %d dials", n)
+	}
+	if ex.ColdConnectMs < 0 || ex.SetupCostMs != ex.ColdTTFBMs-ex.WarmTTFBMs {
+		t.Fatalf("setup cost inconsistent: %+v", ex)
+	}
+}
@@ -0,0 +1,253 @@
+package monitor
+
+import (
+	"context"
+	"net"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RoutePath is a traceroute towards one of the site's IPs. For dual-stack sites both families
+// are traced, so the IPv4 and IPv6 paths can be compared: different transit ASNs or a much
+// longer path on one family usually explain a persistent IPv4/IPv6 speed or TTFB delta. The
+// trace runs alongside the site's measurement (see startPathProbes), so hop RTTs include the
+// load of that transfer.
+type RoutePath struct {
+	Target  string     `json:"target"`
+	Tool    string     `json:"tool,omitempty"` // traceroute, traceroute6, tracert
+	Hops    []RouteHop `json:"hops,omitempty"`
+	Reached bool       `json:"reached,omitempty"` // the last responding hop is the target
+	// Origin ASNs of the responding hops in order, consecutive repeats collapsed (needs the
+	// GeoLite2 ASN database; private and unknown hops are skipped)
+	ASPath []uint `json:"as_path,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// RouteHop is one TTL of a trace; IP is empty when the hop did not answer.
+type RouteHop struct {
+	TTL    int     `json:"ttl"`
+	IP     string  `json:"ip,omitempty"`
+	RTTMs  float64 `json:"rtt_ms,omitempty"`
+	ASN    uint    `json:"asn,omitempty"`
+	ASNOrg string  `json:"asn_org,omitempty"`
+}
+
+const (
+	defaultRouteTraceMaxHops = 20
+	routeTraceTimeout        = 30 * time.Second
+)
+
+var (
+	routeTraceEnabled = false
+	routeTraceMaxHops = defaultRouteTraceMaxHops
+
+	rtMu     sync.Mutex
+	rtRunTag string
+	rtTraces map[string]*routeTrace
+
+	// seams for tests
+	runTraceroute = execTraceroute
+	hopASNLookup  = lookupGeoIP2ASN
+)
+
+type routeTrace struct {
+	done chan struct{}
+	res  *RoutePath
+}
+
+// SetRouteTrace enables tracing the first IPv4 and IPv6 address of dual-stack sites once per batch.
+func SetRouteTrace(enabled bool) { routeTraceEnabled = enabled }
+
+// SetRouteTraceMaxHops caps the TTL of route traces (default 20).
+func SetRouteTraceMaxHops(n int) {
+	if n > 0 {
+		routeTraceMaxHops = n
+	}
+}
+
+// routePathForIP returns the batch's trace towards ip when it is the first address of its
+// family for a dual-stack site; the per-IP workers of a site share one trace per family.
+// nil when disabled, for single-family sites and for the other IPs of a family.
+func routePathForIP(ctx context.Context, ip string, dnsIPs []string) *RoutePath {
+	if !routeTraceEnabled {
+		return nil
+	}
+	v6, v4 := firstIPPerFamily(dnsIPs)
+	if v6 == "" || v4 == "" || (ip != v6 && ip != v4) {
+		return nil
+	}
+	rtMu.Lock()
+	if rtTraces == nil || rtRunTag != runTag {
+		rtTraces = map[string]*routeTrace{}
+		rtRunTag = runTag
+	}
+	if t, ok := rtTraces[ip]; ok {
+		rtMu.Unlock()
+		select {
+		case <-t.done:
+			return t.res
+		case <-ctx.Done():
+			return nil
+		}
+	}
+	t := &routeTrace{done: make(chan struct{})}
+	rtTraces[ip] = t
+	rtMu.Unlock()
+	tctx, cancel := context.WithTimeout(ctx, routeTraceTimeout)
+	t.res = traceRoute(tctx, ip, ip == v6, routeTraceMaxHops)
+	cancel()
+	close(t.done)
+	Debugf("[%s] route trace via %s: %d hops, reached=%v, as_path=%v %s", ip, t.res.Tool, len(t.res.Hops), t.res.Reached, t.res.ASPath, t.res.Error)
+	return t.res
+}
+
+// startPathProbes starts the route trace and then the response TTL probe of ip (which compares
+// with the trace) in the background, so their up to routeTraceTimeout and responseTTLTimeout do
+// not delay the measurement. The returned func waits for both and sets sr.RoutePath and
+// sr.ResponseTTL; wrapRoot calls it before the line is written. nil when both are off.
+func startPathProbes(ctx context.Context, sr *SiteResult, ip string, dnsIPs []string) func() {
+	if !routeTraceEnabled && !responseTTLEnabled {
+		return nil
+	}
+	v6 := sr.IPFamily == "ipv6"
+	var rp *RoutePath
+	var rt *ResponseTTL
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		rp = routePathForIP(ctx, ip, dnsIPs)
+		rt = responseTTLForIP(ctx, ip, v6, rp)
+	}()
+	return func() {
+		<-done
+		sr.RoutePath, sr.ResponseTTL = rp, rt
+	}
+}
+
+func traceRoute(ctx context.Context, ip string, v6 bool, maxHops int) *RoutePath {
+	rp := &RoutePath{Target: ip}
+	tool, out, err := runTraceroute(ctx, ip, v6, maxHops)
+	rp.Tool = tool
+	rp.Hops = parseTraceroute(out)
+	if err != nil && len(rp.Hops) == 0 {
+		rp.Error = err.Error()
+		return rp
+	}
+	for i := range rp.Hops {
+		h := &rp.Hops[i]
+		if h.IP == "" {
+			continue
+		}
+		if asn, org, ok := hopASNLookup(h.IP); ok && asn != 0 {
+			h.ASN, h.ASNOrg = asn, org
+			if n := len(rp.ASPath); n == 0 || rp.ASPath[n-1] != asn {
+				rp.ASPath = append(rp.ASPath, asn)
+			}
+		}
+	}
+	for i := len(rp.Hops) - 1; i >= 0; i-- {
+		if rp.Hops[i].IP != "" {
+			rp.Reached = net.ParseIP(rp.Hops[i].IP).Equal(net.ParseIP(ip))
+			break
+		}
+	}
+	return rp
+}
+
+// execTraceroute runs the platform tracer with one probe per hop and no name resolution, from
+// the --interface/--source-ip binding when there is one.
+func execTraceroute(ctx context.Context, ip string, v6 bool, maxHops int) (string, []byte, error) {
+	tool, args := traceCommand(v6, maxHops)
+	args = append(args, traceBindArgs(currentBinding(), ip, v6)...)
+	out, err := exec.CommandContext(ctx, tool, append(args, ip)...).Output()
+	return tool, out, err
+}
+
+// traceBindArgs are the tracer options that send its probes from binding b: the source address
+// of the target's family (-s; tracert -S, which only takes IPv6) and, when connections are bound
+// to the device (Linux), the interface (-i).
+func traceBindArgs(b *SourceBinding, ip string, v6 bool) []string {
+	if b == nil {
+		return nil
+	}
+	network := "udp4"
+	if v6 {
+		network = "udp6"
+	}
+	src := b.localIPFor(network, ip)
+	var args []string
+	if runtime.GOOS == "windows" {
+		if v6 && src != nil {
+			args = append(args, "-S", src.String())
+		}
+		return args
+	}
+	if b.Device {
+		args = append(args, "-i", b.Interface)
+	}
+	if src != nil {
+		args = append(args, "-s", src.String())
+	}
+	return args
+}
+
+// traceCommand is the tracer binary and its arguments (without the target) for this platform.
+func traceCommand(v6 bool, maxHops int) (string, []string) {
+	hops := strconv.Itoa(maxHops)
+	var tool string
+	var args []string
+	switch runtime.GOOS {
+	case "windows":
+		tool, args = "tracert", []string{"-d", "-h", hops, "-w", "1000"}
+		if v6 {
+			args = append(args, "-6")
+		}
+	case "darwin":
+		tool, args = "traceroute", []string{"-n", "-q", "1", "-w", "1", "-m", hops}
+		if v6 {
+			tool, args = "traceroute6", []string{"-n", "-q", "1", "-w", "1", "-m", hops}
+		}
+	default:
+		tool, args = "traceroute", []string{"-n", "-q", "1", "-w", "1", "-m", hops}
+		if v6 {
+			args = append([]string{"-6"}, args...)
+		}
+	}
+	return tool, args
+}
+
+// parseTraceroute reads traceroute/traceroute6/tracert output: each hop line starts with its
+// TTL, followed by RTTs ("1.234 ms", "<1 ms") and the responding address, or "*" when the
+// hop timed out. Header and trailer lines are ignored.
+func parseTraceroute(out []byte) []RouteHop {
+	var hops []RouteHop
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		ttl, err := strconv.Atoi(fields[0])
+		if err != nil || ttl <= 0 {
+			continue
+		}
+		h := RouteHop{TTL: ttl}
+		for i, f := range fields[1:] {
+			f = strings.Trim(f, "()[]")
+			if h.IP == "" && net.ParseIP(f) != nil {
+				h.IP = f
+				continue
+			}
+			if h.RTTMs == 0 && i+2 < len(fields) && fields[i+2] == "ms" {
+				if v, err := strconv.ParseFloat(strings.TrimPrefix(f, "<"), 64); err == nil {
+					h.RTTMs = v
+				}
+			}
+		}
+		hops = append(hops, h)
+	}
+	return hops
+}
@@ -0,0 +1,129 @@
+package monitor
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestParseTraceroute(t *testing.T) {
+	linux := `traceroute to 192.0.2.10 (192.0.2.10), 20 hops max, 60 byte packets
+ 1  192.168.1.1  0.512 ms
+ 2  *
+ 3  198.51.100.1  8.204 ms
+ 4  192.0.2.10  12.9 ms
+`
+	got := parseTraceroute([]byte(linux))
+	want := []RouteHop{{TTL: 1, IP: "192.168.1.1", RTTMs: 0.512}, {TTL: 2}, {TTL: 3, IP: "198.51.100.1", RTTMs: 8.204}, {TTL: 4, IP: "192.0.2.10", RTTMs: 12.9}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("linux: got %+v", got)
+	}
+	windows := `
+Tracing route to 2001:db8::10 over a maximum of 20 hops
+
+  1    <1 ms    fe80::1
+  2     *        Request timed out.
+  3    14 ms    2001:db8::10
+
+Trace complete.
+`
+	got = parseTraceroute([]byte(windows))
+	want = []RouteHop{{TTL: 1, IP: "fe80::1", RTTMs: 1}, {TTL: 2}, {TTL: 3, IP: "2001:db8::10", RTTMs: 14}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("tracert: got %+v", got)
+	}
+}
+
+func TestRoutePathForIP_OncePerFamilyPerBatch(t *testing.T) {
+	prevRun, prevASN, prevEnabled, prevTag := runTraceroute, hopASNLookup, routeTraceEnabled, runTag
+	defer func() {
+		runTraceroute, hopASNLookup, routeTraceEnabled, runTag = prevRun, prevASN, prevEnabled, prevTag
+		rtTraces = nil
+	}()
+	var calls int32
+	runTraceroute = func(ctx context.Context, ip string, v6 bool, maxHops int) (string, []byte, error) {
+		atomic.AddInt32(&calls, 1)
+		if v6 {
+			return "traceroute", []byte(" 1  fe80::1  1 ms\n 2  2001:db8:1::1  9 ms\n 3  " + ip + "  20 ms\n"), nil
+		}
+		return "traceroute", []byte(" 1  192.168.1.1  1 ms\n 2  198.51.100.1  5 ms\n 3  203.0.113.1  6 ms\n 4  " + ip + "  10 ms\n"), nil
+	}
+	asns := map[string]uint{"198.51.100.1": 64500, "203.0.113.1": 64500, "192.0.2.10": 64510, "2001:db8:1::1": 64501, "2001:db8::10": 64510}
+	hopASNLookup = func(ip string) (uint, string, bool) {
+		n, ok := asns[ip]
+		return n, "", ok
+	}
+	SetRouteTrace(true)
+	SetRunTag("20250101_000000")
+	ips := []string{"2001:db8::10", "2001:db8::11", "192.0.2.10", "192.0.2.11"}
+	ctx := context.Background()
+	v4 := routePathForIP(ctx, "192.0.2.10", ips)
+	if v4 == nil || !v4.Reached || !reflect.DeepEqual(v4.ASPath, []uint{64500, 64510}) || len(v4.Hops) != 4 {
+		t.Fatalf("v4 path %+v", v4)
+	}
+	v6 := routePathForIP(ctx, "2001:db8::10", ips)
+	if v6 == nil || !reflect.DeepEqual(v6.ASPath, []uint{64501, 64510}) {
+		t.Fatalf("v6 path %+v", v6)
+	}
+	if routePathForIP(ctx, "192.0.2.11", ips) != nil {
+		t.Fatal("second IPv4 address should not be traced")
+	}
+	if routePathForIP(ctx, "192.0.2.10", ips) != v4 || calls != 2 {
+		t.Fatalf("trace not shared within the batch (calls=%d)", calls)
+	}
+	if routePathForIP(ctx, "192.0.2.10", []string{"192.0.2.10"}) != nil {
+		t.Fatal("single-family site should not be traced")
+	}
+	SetRunTag("20250101_010000")
+	routePathForIP(ctx, "192.0.2.10", ips)
+	if calls != 3 {
+		t.Fatalf("new batch should trace again (calls=%d)", calls)
+	}
+}
+
+func TestStartPathProbes_RunsAlongsideTheMeasurement(t *testing.T) {
+	prevRun, prevEnabled, prevTTL, prevTag := runTraceroute, routeTraceEnabled, responseTTLEnabled, runTag
+	defer func() {
+		runTraceroute, routeTraceEnabled, responseTTLEnabled, runTag = prevRun, prevEnabled, prevTTL, prevTag
+		rtTraces = nil
+	}()
+	if startPathProbes(context.Background(), &SiteResult{}, "192.0.2.10", nil) != nil {
+		t.Fatal("probes started while both are off")
+	}
+	release := make(chan struct{})
+	runTraceroute = func(ctx context.Context, ip string, v6 bool, maxHops int) (string, []byte, error) {
+		<-release
+		return "traceroute", []byte(" 1  " + ip + "  3 ms\n"), nil
+	}
+	SetRouteTrace(true)
+	SetRunTag("20250101_020000")
+	sr := &SiteResult{IPFamily: "ipv4"}
+	wait := startPathProbes(context.Background(), sr, "192.0.2.10", []string{"2001:db8::10", "192.0.2.10"})
+	if wait == nil || sr.RoutePath != nil {
+		t.Fatalf("the trace must not block the caller: %+v", sr.RoutePath)
+	}
+	close(release)
+	wait()
+	if sr.RoutePath == nil || !sr.RoutePath.Reached {
+		t.Fatalf("route path after wait: %+v", sr.RoutePath)
+	}
+}
+
+func TestTraceBindArgs(t *testing.T) {
+	if traceBindArgs(nil, "192.0.2.10", false) != nil {
+		t.Fatal("no binding, no arguments")
+	}
+	b := &SourceBinding{Interface: "eth1", IPv4: net.ParseIP("192.0.2.77").To4(), Device: true}
+	got := strings.Join(traceBindArgs(b, "198.51.100.1", false), " ")
+	want := "-i eth1 -s 192.0.2.77"
+	if runtime.GOOS == "windows" {
+		want = ""
+	}
+	if got != want {
+		t.Fatalf("bind args %q, want %q", got, want)
+	}
+}
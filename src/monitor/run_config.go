@@ -0,0 +1,160 @@
+package monitor
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/iafilius/InternetQualityMonitor/src/types"
+)
+
+// Run configuration markers: every line carries meta.config, the settings that shape a batch's
+// numbers (sites list, --parallel, the timeouts, the batch interval). When they differ from the
+// previous batch of the same process, config.changed lists what changed, so a step in the charts
+// right after e.g. a sites reload or a --parallel change is not mistaken for a network change.
+// Analysis also compares consecutive batches across monitor restarts (DiffRunConfig).
+
+// RunConfig is meta.config.
+type RunConfig struct {
+	Hash           string `json:"hash"`       // over all settings below
+	SitesHash      string `json:"sites_hash"` // over the sites list as loaded
+	Sites          int    `json:"sites"`
+	Parallel       int    `json:"parallel"`
+	HTTPTimeoutMs  int64  `json:"http_timeout_ms"`
+	StallTimeoutMs int64  `json:"stall_timeout_ms"`
+	SiteTimeoutMs  int64  `json:"site_timeout_ms"`
+	DNSTimeoutMs   int64  `json:"dns_timeout_ms"`
+	IntervalMs     int64  `json:"batch_interval_ms,omitempty"`
+	MaxIPsPerSite  int    `json:"max_ips_per_site,omitempty"`
+	// The analysis thresholds of the monitor's own summaries (--micro-stall-gap,
+	// --low-speed-threshold-kbps), so later readers know what the transient stall and low-speed
+	// numbers meant.
+	MicroStallGapMs       int64   `json:"micro_stall_gap_ms,omitempty"`
+	LowSpeedThresholdKbps float64 `json:"low_speed_threshold_kbps,omitempty"`
+	// ProtocolExperiment lists the HTTP versions every https target is fetched with.
+	ProtocolExperiment []string `json:"protocol_experiment,omitempty"`
+	// SamplingProfile is --sampling-profile when not full ("lite")
+	SamplingProfile string `json:"sampling_profile,omitempty"`
+	// Changed describes the differences to the previous batch ("parallel 2→4"); empty for the
+	// first batch of a process and when nothing changed.
+	Changed []string `json:"changed,omitempty"`
+}
+
+var (
+	runConfigMu   sync.Mutex
+	runConfigTag  string
+	runConfigCur  *RunConfig
+	runConfigPrev *RunConfig
+)
+
+// SitesHash returns a short hash of the sites list; any change to a target (URL, name, probe
+// settings) or their order changes it.
+func SitesHash(sites []types.Site) string {
+	b, _ := json.Marshal(sites)
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:6])
+}
+
+// SetRunConfig records cfg for the lines of batch tag and returns it with Hash set and Changed
+// filled in against the previous batch.
+func SetRunConfig(tag string, cfg RunConfig) *RunConfig {
+	cfg.Changed = nil
+	cfg.Hash = runConfigHash(cfg)
+	runConfigMu.Lock()
+	defer runConfigMu.Unlock()
+	if runConfigCur != nil && runConfigTag != tag {
+		runConfigPrev = runConfigCur
+	}
+	if runConfigPrev != nil && runConfigPrev.Hash != cfg.Hash {
+		cfg.Changed = DiffRunConfig(runConfigPrev, &cfg)
+	}
+	runConfigTag, runConfigCur = tag, &cfg
+	return &cfg
+}
+
+// runConfigForRun returns meta.config for a line of batch tag, nil when none was set.
+func runConfigForRun(tag string) *RunConfig {
+	runConfigMu.Lock()
+	defer runConfigMu.Unlock()
+	if runConfigCur == nil || runConfigTag != tag {
+		return nil
+	}
+	return runConfigCur
+}
+
+func runConfigHash(cfg RunConfig) string {
+	cfg.Hash, cfg.Changed = "", nil
+	b, _ := json.Marshal(cfg)
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:6])
+}
+
+// DiffRunConfig describes what differs from prev to cur, one entry per setting; nil when either
+// is nil or nothing differs.
+func DiffRunConfig(prev, cur *RunConfig) []string {
+	if prev == nil || cur == nil {
+		return nil
+	}
+	var out []string
+	switch {
+	case prev.Sites != cur.Sites:
+		out = append(out, fmt.Sprintf("sites %d→%d", prev.Sites, cur.Sites))
+	case prev.SitesHash != cur.SitesHash:
+		out = append(out, "sites list edited")
+	}
+	if prev.Parallel != cur.Parallel {
+		out = append(out, fmt.Sprintf("parallel %d→%d", prev.Parallel, cur.Parallel))
+	}
+	for _, d := range []struct {
+		name     string
+		from, to int64
+	}{
+		{"http_timeout", prev.HTTPTimeoutMs, cur.HTTPTimeoutMs},
+		{"stall_timeout", prev.StallTimeoutMs, cur.StallTimeoutMs},
+		{"site_timeout", prev.SiteTimeoutMs, cur.SiteTimeoutMs},
+		{"dns_timeout", prev.DNSTimeoutMs, cur.DNSTimeoutMs},
+		{"batch_interval", prev.IntervalMs, cur.IntervalMs},
+	} {
+		if d.from != d.to {
+			out = append(out, fmt.Sprintf("%s %s→%s", d.name, msDuration(d.from), msDuration(d.to)))
+		}
+	}
+	if prev.MaxIPsPerSite != cur.MaxIPsPerSite {
+		out = append(out, fmt.Sprintf("max_ips_per_site %d→%d", prev.MaxIPsPerSite, cur.MaxIPsPerSite))
+	}
+	// older lines carry no analysis thresholds: their absence is not a change
+	if prev.MicroStallGapMs != 0 && prev.MicroStallGapMs != cur.MicroStallGapMs {
+		out = append(out, fmt.Sprintf("micro_stall_gap %s→%s", msDuration(prev.MicroStallGapMs), msDuration(cur.MicroStallGapMs)))
+	}
+	if prev.LowSpeedThresholdKbps != 0 && prev.LowSpeedThresholdKbps != cur.LowSpeedThresholdKbps {
+		out = append(out, fmt.Sprintf("low_speed_threshold %g→%g kbps", prev.LowSpeedThresholdKbps, cur.LowSpeedThresholdKbps))
+	}
+	if from, to := strings.Join(prev.ProtocolExperiment, ","), strings.Join(cur.ProtocolExperiment, ","); from != to {
+		if from == "" {
+			from = "off"
+		}
+		if to == "" {
+			to = "off"
+		}
+		out = append(out, fmt.Sprintf("protocol_experiment %s→%s", from, to))
+	}
+	if prev.SamplingProfile != cur.SamplingProfile {
+		from, to := prev.SamplingProfile, cur.SamplingProfile
+		if from == "" {
+			from = SamplingProfileFull
+		}
+		if to == "" {
+			to = SamplingProfileFull
+		}
+		out = append(out, fmt.Sprintf("sampling_profile %s→%s", from, to))
+	}
+	return out
+}
+
+func msDuration(ms int64) string {
+	return (time.Duration(ms) * time.Millisecond).String()
+}
@@ -0,0 +1,53 @@
+package monitor
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/iafilius/InternetQualityMonitor/src/types"
+)
+
+func TestSetRunConfigRecordsChanges(t *testing.T) {
+	defer func() { runConfigTag, runConfigCur, runConfigPrev = "", nil, nil }()
+	sites := []types.Site{{Name: "a", URL: "https://a.example/"}}
+	base := RunConfig{SitesHash: SitesHash(sites), Sites: 1, Parallel: 2, HTTPTimeoutMs: 120000}
+	if c := SetRunConfig("t1", base); c.Hash == "" || len(c.Changed) != 0 {
+		t.Fatalf("first batch: %+v", c)
+	}
+	// the next binding pass of the same settings is no change
+	if c := SetRunConfig("t1_eth0", base); len(c.Changed) != 0 {
+		t.Fatalf("same settings: %v", c.Changed)
+	}
+	next := base
+	next.Parallel, next.HTTPTimeoutMs = 4, 60000
+	next.SitesHash = SitesHash(append(sites, types.Site{Name: "b", URL: "https://b.example/"}))
+	next.Sites = 2
+	c := SetRunConfig("t2", next)
+	want := []string{"sites 1→2", "parallel 2→4", "http_timeout 2m0s→1m0s"}
+	if !reflect.DeepEqual(c.Changed, want) {
+		t.Fatalf("changed = %v, want %v", c.Changed, want)
+	}
+	if got := runConfigForRun("t2"); got != c || runConfigForRun("t1") != nil {
+		t.Fatalf("config for run: %+v", got)
+	}
+	edited := next
+	edited.SitesHash = SitesHash([]types.Site{{Name: "a", URL: "https://a.example/x"}, {Name: "b", URL: "https://b.example/"}})
+	if c := SetRunConfig("t3", edited); !reflect.DeepEqual(c.Changed, []string{"sites list edited"}) {
+		t.Fatalf("edited sites: %v", c.Changed)
+	}
+	forced := edited
+	forced.ProtocolExperiment = []string{HTTPVersion11, HTTPVersion2}
+	if c := SetRunConfig("t4", forced); !reflect.DeepEqual(c.Changed, []string{"protocol_experiment off→1.1,2"}) {
+		t.Fatalf("protocol experiment: %v", c.Changed)
+	}
+	// analysis thresholds appearing on upgrade are no change, a later edit is
+	thresholds := forced
+	thresholds.MicroStallGapMs, thresholds.LowSpeedThresholdKbps = 500, 1000
+	if c := SetRunConfig("t5", thresholds); len(c.Changed) != 0 {
+		t.Fatalf("thresholds added: %v", c.Changed)
+	}
+	thresholds.MicroStallGapMs = 250
+	if c := SetRunConfig("t6", thresholds); !reflect.DeepEqual(c.Changed, []string{"micro_stall_gap 500ms→250ms"}) {
+		t.Fatalf("thresholds changed: %v", c.Changed)
+	}
+}
@@ -0,0 +1,72 @@
+package monitor
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// ExistingRunTags returns the run_tags already present in a results file (empty when the file
+// does not exist yet). Only the meta.run_tag field is looked at, so scanning a large file at
+// startup stays cheap.
+func ExistingRunTags(path string) (map[string]bool, error) {
+	tags := map[string]bool{}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return tags, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	key := []byte(`"run_tag":"`)
+	r := bufio.NewReaderSize(f, 1<<20)
+	for {
+		line, rerr := r.ReadSlice('\n')
+		if errors.Is(rerr, bufio.ErrBufferFull) {
+			// long line: the tag sits in meta at the front, skip the rest
+			if tag := runTagIn(line, key); tag != "" {
+				tags[tag] = true
+			}
+			for errors.Is(rerr, bufio.ErrBufferFull) {
+				_, rerr = r.ReadSlice('\n')
+			}
+		} else if tag := runTagIn(line, key); tag != "" {
+			tags[tag] = true
+		}
+		if rerr != nil {
+			if errors.Is(rerr, io.EOF) {
+				return tags, nil
+			}
+			return tags, rerr
+		}
+	}
+}
+
+func runTagIn(line, key []byte) string {
+	i := bytes.Index(line, key)
+	if i < 0 {
+		return ""
+	}
+	rest := line[i+len(key):]
+	j := bytes.IndexByte(rest, '"')
+	if j <= 0 {
+		return ""
+	}
+	return string(rest[:j])
+}
+
+// UniqueRunTag returns tag, or tag with the first free "_r2", "_r3", … suffix when a batch of
+// that name is already in the file (the clock went back, e.g. after a reboot without RTC, or
+// two runs started in the same second). The result is marked as used.
+func UniqueRunTag(tag string, used map[string]bool) string {
+	out := tag
+	for n := 2; used[out]; n++ {
+		out = fmt.Sprintf("%s_r%d", tag, n)
+	}
+	used[out] = true
+	return out
+}
@@ -0,0 +1,35 @@
+package monitor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunTagCollision(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.jsonl")
+	tags, err := ExistingRunTags(path)
+	if err != nil || len(tags) != 0 {
+		t.Fatalf("missing file: %v %v", tags, err)
+	}
+	long := `{"meta":{"timestamp_utc":"x","run_tag":"20260301_100000_i2","schema_version":3},"site_result":{"name":"` + strings.Repeat("x", 3<<20) + `"}}`
+	content := `{"meta":{"timestamp_utc":"x","run_tag":"20260301_100000"},"site_result":{}}` + "\n" +
+		"not json\n" + long + "\n" +
+		`{"meta":{"run_tag":"20260301_100000_r2"},"site_result":{}}`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if tags, err = ExistingRunTags(path); err != nil || len(tags) != 3 || !tags["20260301_100000_i2"] {
+		t.Fatalf("tags: %v %v", tags, err)
+	}
+	if got := UniqueRunTag("20260301_100000", tags); got != "20260301_100000_r3" {
+		t.Fatalf("collision suffix: %s", got)
+	}
+	if got := UniqueRunTag("20260301_100000", tags); got != "20260301_100000_r4" {
+		t.Fatalf("a renamed tag must be marked used: %s", got)
+	}
+	if got := UniqueRunTag("20260301_120000", tags); got != "20260301_120000" {
+		t.Fatalf("free tag renamed: %s", got)
+	}
+}
@@ -0,0 +1,109 @@
+package monitor
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/iafilius/InternetQualityMonitor/src/types"
+)
+
+// Sampling profiles for --sampling-profile. Lite keeps a Raspberry Pi or similar small device from
+// measuring its own limits instead of the network's: fewer targets, smaller transfers, no
+// per-sample retention, and (through LiteFlagDefaults) one IP per site, one site at a time and a
+// longer batch interval. Lines record meta.sampling_profile, and the profile is part of
+// meta.config, so lite batches are never compared with full batches unnoticed.
+const (
+	SamplingProfileFull = "full"
+	SamplingProfileLite = "lite"
+)
+
+// Lite profile limits; --lite-max-sites and --lite-max-bytes override them.
+const (
+	DefaultLiteMaxSites       = 5
+	DefaultLiteMaxBytes int64 = 2 << 20
+)
+
+// LiteFlagDefaults are the monitor flags the lite profile changes, applied only where neither the
+// command line nor the config file sets them.
+var LiteFlagDefaults = map[string]string{
+	"max-ips-per-site": "1",
+	"parallel":         "1",
+	"batch-interval":   "30m",
+	"speed-series":     "false",
+	"selftest-speed":   "false",
+}
+
+var (
+	samplingMu      sync.RWMutex
+	samplingProfile = SamplingProfileFull
+	liteMaxSites    = DefaultLiteMaxSites
+	liteMaxBytes    = DefaultLiteMaxBytes
+)
+
+// SetSamplingProfile selects full (default) or lite sampling.
+func SetSamplingProfile(p string) error {
+	p = strings.ToLower(strings.TrimSpace(p))
+	if p == "" {
+		p = SamplingProfileFull
+	}
+	if p != SamplingProfileFull && p != SamplingProfileLite {
+		return fmt.Errorf("sampling profile %q: want full or lite", p)
+	}
+	samplingMu.Lock()
+	defer samplingMu.Unlock()
+	samplingProfile = p
+	return nil
+}
+
+// SetLiteLimits sets the lite profile's site and per-transfer byte caps (<= 0 restores a default).
+func SetLiteLimits(maxSites int, maxBytes int64) {
+	if maxSites <= 0 {
+		maxSites = DefaultLiteMaxSites
+	}
+	if maxBytes <= 0 {
+		maxBytes = DefaultLiteMaxBytes
+	}
+	samplingMu.Lock()
+	defer samplingMu.Unlock()
+	liteMaxSites, liteMaxBytes = maxSites, maxBytes
+}
+
+// SamplingProfile returns the selected profile: SamplingProfileFull or SamplingProfileLite.
+func SamplingProfile() string {
+	samplingMu.RLock()
+	defer samplingMu.RUnlock()
+	return samplingProfile
+}
+
+func liteSampling() bool { return SamplingProfile() == SamplingProfileLite }
+
+// LiteSites returns the sites a batch measures under the selected profile: all of them for full,
+// the first --lite-max-sites for lite.
+func LiteSites(sites []types.Site) []types.Site {
+	samplingMu.RLock()
+	defer samplingMu.RUnlock()
+	if samplingProfile != SamplingProfileLite || len(sites) <= liteMaxSites {
+		return sites
+	}
+	return sites[:liteMaxSites]
+}
+
+// liteTransferCap returns the lite per-transfer byte cap, 0 under the full profile.
+func liteTransferCap() int64 {
+	samplingMu.RLock()
+	defer samplingMu.RUnlock()
+	if samplingProfile != SamplingProfileLite {
+		return 0
+	}
+	return liteMaxBytes
+}
+
+// RecordedSamplingProfile is the profile as stored in meta.sampling_profile and the run config:
+// empty for full, so full lines stay unchanged.
+func RecordedSamplingProfile() string {
+	if p := SamplingProfile(); p != SamplingProfileFull {
+		return p
+	}
+	return ""
+}
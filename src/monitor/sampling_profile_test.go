@@ -0,0 +1,42 @@
+package monitor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/iafilius/InternetQualityMonitor/src/types"
+)
+
+func TestSamplingProfileLite(t *testing.T) {
+	t.Cleanup(func() {
+		SetSamplingProfile(SamplingProfileFull)
+		SetLiteLimits(0, 0)
+	})
+	if err := SetSamplingProfile("tiny"); err == nil {
+		t.Fatal("unknown profile accepted")
+	}
+	sites := make([]types.Site, 8)
+	if got := LiteSites(sites); len(got) != 8 || liteTransferCap() != 0 || RecordedSamplingProfile() != "" {
+		t.Fatalf("full: %d sites, cap %d, recorded %q", len(got), liteTransferCap(), RecordedSamplingProfile())
+	}
+	if err := SetSamplingProfile(" Lite "); err != nil {
+		t.Fatal(err)
+	}
+	if got := LiteSites(sites); len(got) != DefaultLiteMaxSites || liteTransferCap() != DefaultLiteMaxBytes {
+		t.Fatalf("lite defaults: %d sites, cap %d", len(got), liteTransferCap())
+	}
+	SetLiteLimits(3, 1<<20)
+	if got := LiteSites(sites); len(got) != 3 || liteTransferCap() != 1<<20 || RecordedSamplingProfile() != SamplingProfileLite {
+		t.Fatalf("lite limits: %d sites, cap %d, recorded %q", len(got), liteTransferCap(), RecordedSamplingProfile())
+	}
+	if got := LiteSites(sites[:2]); len(got) != 2 {
+		t.Fatalf("fewer sites than the limit: %d", len(got))
+	}
+}
+
+func TestDiffRunConfigSamplingProfile(t *testing.T) {
+	got := DiffRunConfig(&RunConfig{}, &RunConfig{SamplingProfile: SamplingProfileLite})
+	if len(got) != 1 || !strings.Contains(got[0], "sampling_profile full→lite") {
+		t.Fatalf("changes: %v", got)
+	}
+}
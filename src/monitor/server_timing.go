@@ -0,0 +1,87 @@
+package monitor
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ServerTiming is what the origin reported about its own processing in Server-Timing response
+// headers (W3C Server Timing), e.g. `db;dur=53, app;dur=47.2;desc="render"`. ServerMs is the
+// server's share of the TTFB: the "total" metric when sent, otherwise the longest duration
+// (metrics may nest, so they are not summed). Trailers are not read: the line is written after
+// the body, but most servers that send the header send it with the response head.
+type ServerTiming struct {
+	Metrics  []ServerTimingMetric `json:"metrics"`
+	ServerMs float64              `json:"server_ms,omitempty"`
+}
+
+// ServerTimingMetric is one entry of a Server-Timing header; DurMs is 0 for metrics without dur.
+type ServerTimingMetric struct {
+	Name  string  `json:"name"`
+	DurMs float64 `json:"dur_ms,omitempty"`
+	Desc  string  `json:"desc,omitempty"`
+}
+
+// maxServerTimingMetrics bounds what is stored per line; some frameworks emit dozens of entries.
+const maxServerTimingMetrics = 16
+
+// parseServerTiming returns the metrics of the Server-Timing headers in h, nil when there are none.
+func parseServerTiming(h http.Header) *ServerTiming {
+	var st ServerTiming
+	total := -1.0
+	for _, v := range h.Values("Server-Timing") {
+		for _, entry := range splitUnquoted(v, ',') {
+			params := splitUnquoted(entry, ';')
+			name := strings.TrimSpace(params[0])
+			if name == "" {
+				continue
+			}
+			m := ServerTimingMetric{Name: name}
+			for _, p := range params[1:] {
+				k, val, _ := strings.Cut(p, "=")
+				val = strings.Trim(strings.TrimSpace(val), `"`)
+				switch strings.ToLower(strings.TrimSpace(k)) {
+				case "dur":
+					if d, err := strconv.ParseFloat(val, 64); err == nil && d >= 0 {
+						m.DurMs = d
+					}
+				case "desc":
+					m.Desc = val
+				}
+			}
+			if strings.EqualFold(name, "total") {
+				total = m.DurMs
+			}
+			if len(st.Metrics) < maxServerTimingMetrics {
+				st.Metrics = append(st.Metrics, m)
+			}
+			st.ServerMs = max(st.ServerMs, m.DurMs)
+		}
+	}
+	if len(st.Metrics) == 0 {
+		return nil
+	}
+	if total >= 0 {
+		st.ServerMs = total
+	}
+	return &st
+}
+
+// splitUnquoted splits s at sep outside double-quoted strings.
+func splitUnquoted(s string, sep byte) []string {
+	var out []string
+	quoted, start := false, 0
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && quoted:
+			i++
+		case s[i] == '"':
+			quoted = !quoted
+		case s[i] == sep && !quoted:
+			out = append(out, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(out, s[start:])
+}
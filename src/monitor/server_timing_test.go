@@ -0,0 +1,32 @@
+package monitor
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestParseServerTiming(t *testing.T) {
+	if st := parseServerTiming(http.Header{}); st != nil {
+		t.Fatalf("no header: %+v", st)
+	}
+	h := http.Header{}
+	h.Add("Server-Timing", `db;dur=53.5;desc="query, cached", cache;desc=hit`)
+	h.Add("Server-Timing", `app;dur=47.2`)
+	st := parseServerTiming(h)
+	if st == nil || len(st.Metrics) != 3 {
+		t.Fatalf("metrics: %+v", st)
+	}
+	if m := st.Metrics[0]; m.Name != "db" || m.DurMs != 53.5 || m.Desc != "query, cached" {
+		t.Fatalf("quoted desc: %+v", m)
+	}
+	if m := st.Metrics[1]; m.Name != "cache" || m.DurMs != 0 || m.Desc != "hit" {
+		t.Fatalf("metric without dur: %+v", m)
+	}
+	if st.ServerMs != 53.5 {
+		t.Fatalf("server ms is the longest metric without total: %v", st.ServerMs)
+	}
+	h.Add("Server-Timing", "total;dur=120")
+	if st := parseServerTiming(h); st.ServerMs != 120 {
+		t.Fatalf("total wins: %v", st.ServerMs)
+	}
+}
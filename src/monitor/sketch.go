@@ -0,0 +1,214 @@
+package monitor
+
+import (
+	"math"
+)
+
+// DefaultSketchAlpha is the relative accuracy of sketches written by the monitor: any quantile
+// read back is within ±1% of a value that was actually observed at that rank.
+const DefaultSketchAlpha = 0.01
+
+// Sketch is a compact, mergeable quantile sketch (logarithmic buckets as in DDSketch). Values
+// v > 0 fall into bucket ceil(log_gamma(v)) with gamma = (1+alpha)/(1-alpha); counts are kept
+// for a contiguous index range starting at Offset. Values <= 0 (e.g. stalled speed samples) are
+// counted in Zero. Merging two sketches with the same Alpha is exact, so batch- and
+// multi-batch percentiles can be built from per-line sketches without keeping raw samples.
+type Sketch struct {
+	Alpha  float64 `json:"alpha"`
+	Count  int64   `json:"count"`
+	Zero   int64   `json:"zero,omitempty"`
+	Min    float64 `json:"min"`
+	Max    float64 `json:"max"`
+	Offset int     `json:"offset,omitempty"`
+	Bins   []int64 `json:"bins,omitempty"`
+}
+
+// NewSketch returns an empty sketch with relative accuracy alpha (DefaultSketchAlpha if alpha
+// is not in (0,1)).
+func NewSketch(alpha float64) *Sketch {
+	if alpha <= 0 || alpha >= 1 {
+		alpha = DefaultSketchAlpha
+	}
+	return &Sketch{Alpha: alpha}
+}
+
+func (s *Sketch) logGamma() float64 { return math.Log((1 + s.Alpha) / (1 - s.Alpha)) }
+
+// Add records one value.
+func (s *Sketch) Add(v float64) { s.addN(v, 1) }
+
+func (s *Sketch) addN(v float64, n int64) {
+	if n <= 0 || math.IsNaN(v) || math.IsInf(v, 0) {
+		return
+	}
+	if s.Count == 0 || v < s.Min {
+		s.Min = v
+	}
+	if s.Count == 0 || v > s.Max {
+		s.Max = v
+	}
+	s.Count += n
+	if v <= 0 {
+		s.Zero += n
+		return
+	}
+	idx := int(math.Ceil(math.Log(v) / s.logGamma()))
+	s.grow(idx)
+	s.Bins[idx-s.Offset] += n
+}
+
+// grow extends Bins so that idx is addressable.
+func (s *Sketch) grow(idx int) {
+	if len(s.Bins) == 0 {
+		s.Offset = idx
+		s.Bins = []int64{0}
+		return
+	}
+	if idx < s.Offset {
+		s.Bins = append(make([]int64, s.Offset-idx), s.Bins...)
+		s.Offset = idx
+	} else if end := s.Offset + len(s.Bins); idx >= end {
+		s.Bins = append(s.Bins, make([]int64, idx-end+1)...)
+	}
+}
+
+// Merge adds all values of o into s. When the accuracies differ, o's bucket representatives are
+// re-inserted (the result then carries the error of both sketches).
+func (s *Sketch) Merge(o *Sketch) {
+	if o == nil || o.Count == 0 {
+		return
+	}
+	if s.Alpha == 0 {
+		s.Alpha = o.Alpha
+	}
+	if o.Alpha != s.Alpha {
+		s.addN(0, o.Zero)
+		for i, c := range o.Bins {
+			s.addN(o.bucketValue(o.Offset+i), c)
+		}
+	} else {
+		s.Zero += o.Zero
+		s.Count += o.Count
+		for i, c := range o.Bins {
+			if c == 0 {
+				continue
+			}
+			s.grow(o.Offset + i)
+			s.Bins[o.Offset+i-s.Offset] += c
+		}
+	}
+	if s.Count == o.Count || o.Min < s.Min {
+		s.Min = o.Min
+	}
+	if s.Count == o.Count || o.Max > s.Max {
+		s.Max = o.Max
+	}
+}
+
+// bucketValue is the representative of bucket idx: within ±alpha of every value in it.
+func (s *Sketch) bucketValue(idx int) float64 {
+	g := (1 + s.Alpha) / (1 - s.Alpha)
+	return 2 * math.Pow(g, float64(idx)) / (g + 1)
+}
+
+// Quantile returns the p-th percentile (0..100) using the nearest-rank rule, clamped to the
+// observed Min/Max. It returns 0 for an empty sketch.
+func (s *Sketch) Quantile(p float64) float64 {
+	if s == nil || s.Count == 0 {
+		return 0
+	}
+	if p <= 0 {
+		return s.Min
+	}
+	if p >= 100 {
+		return s.Max
+	}
+	rank := int64(math.Ceil(p/100*float64(s.Count))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank < s.Zero {
+		return math.Min(0, s.Max)
+	}
+	seen := s.Zero
+	v := s.Max
+	for i, c := range s.Bins {
+		seen += c
+		if seen > rank {
+			v = s.bucketValue(s.Offset + i)
+			break
+		}
+	}
+	return math.Max(s.Min, math.Min(s.Max, v))
+}
+
+// SketchOf builds a sketch of the speed samples of one transfer.
+func SketchOf(samples []SpeedSample) *Sketch {
+	if len(samples) == 0 {
+		return nil
+	}
+	s := NewSketch(DefaultSketchAlpha)
+	for _, smp := range samples {
+		s.Add(smp.Speed)
+	}
+	return s
+}
+
+// DefaultHistogramPerDecade is the resolution of ExpHistogram summaries: 10 buckets per decade,
+// each about 26% wide — coarse enough to stay small, fine enough to separate two speed modes.
+const DefaultHistogramPerDecade = 10
+
+// ExpHistogram is a compact exponential (log-bucketed) histogram. Counts[i] holds the values in
+// [10^(k/PerDecade), 10^((k+1)/PerDecade)) with k = Offset+i; values <= 0 are counted in Zero.
+type ExpHistogram struct {
+	PerDecade int     `json:"per_decade"`
+	Zero      int64   `json:"zero,omitempty"`
+	Offset    int     `json:"offset,omitempty"`
+	Counts    []int64 `json:"counts,omitempty"`
+}
+
+// Histogram coarsens s into an ExpHistogram with perDecade buckets per decade
+// (DefaultHistogramPerDecade if perDecade <= 0); nil for an empty sketch. Each sketch bucket is
+// placed by its representative, so a bucket edge is accurate to the sketch's alpha.
+func (s *Sketch) Histogram(perDecade int) *ExpHistogram {
+	if s == nil || s.Count == 0 {
+		return nil
+	}
+	if perDecade <= 0 {
+		perDecade = DefaultHistogramPerDecade
+	}
+	h := &ExpHistogram{PerDecade: perDecade, Zero: s.Zero}
+	for i, c := range s.Bins {
+		if c == 0 {
+			continue
+		}
+		k := int(math.Floor(math.Log10(s.bucketValue(s.Offset+i)) * float64(perDecade)))
+		if len(h.Counts) == 0 {
+			h.Offset, h.Counts = k, []int64{0}
+		} else if end := h.Offset + len(h.Counts); k >= end {
+			h.Counts = append(h.Counts, make([]int64, k-end+1)...)
+		}
+		// sketch bins are visited in ascending order, so k never falls below Offset
+		h.Counts[k-h.Offset] += c
+	}
+	return h
+}
+
+// Bounds returns the value range [lo, hi) of Counts[i].
+func (h *ExpHistogram) Bounds(i int) (lo, hi float64) {
+	k := float64(h.Offset + i)
+	d := float64(h.PerDecade)
+	return math.Pow(10, k/d), math.Pow(10, (k+1)/d)
+}
+
+// Total is the number of values in h, including Zero.
+func (h *ExpHistogram) Total() int64 {
+	if h == nil {
+		return 0
+	}
+	n := h.Zero
+	for _, c := range h.Counts {
+		n += c
+	}
+	return n
+}
@@ -0,0 +1,93 @@
+package monitor
+
+import (
+	"encoding/json"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestSketch_QuantilesWithinAlpha(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	var vals []float64
+	s := NewSketch(DefaultSketchAlpha)
+	for i := 0; i < 5000; i++ {
+		v := math.Exp(r.Float64()*8) * 100 // 100 .. ~300000 kbps, heavy tail
+		vals = append(vals, v)
+		s.Add(v)
+	}
+	sort.Float64s(vals)
+	for _, p := range []float64{1, 25, 50, 90, 95, 99} {
+		want := vals[int(math.Ceil(p/100*float64(len(vals))))-1]
+		if got := s.Quantile(p); math.Abs(got-want) > want*DefaultSketchAlpha {
+			t.Errorf("P%v=%v want %v ±1%%", p, got, want)
+		}
+	}
+	if s.Quantile(0) != vals[0] || s.Quantile(100) != vals[len(vals)-1] {
+		t.Errorf("min/max not exact")
+	}
+}
+
+func TestSketch_MergeEqualsSingleSketchAndRoundTrips(t *testing.T) {
+	all := NewSketch(0)
+	a, b := NewSketch(0), NewSketch(0)
+	for i := 0; i < 300; i++ {
+		v := float64(i * 37 % 1000)
+		all.Add(v)
+		if i%2 == 0 {
+			a.Add(v)
+		} else {
+			b.Add(v)
+		}
+	}
+	raw, err := json.Marshal(a)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var back Sketch
+	if err := json.Unmarshal(raw, &back); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	back.Merge(b)
+	for _, p := range []float64{0, 10, 50, 90, 99, 100} {
+		if got, want := back.Quantile(p), all.Quantile(p); got != want {
+			t.Errorf("P%v merged=%v single=%v", p, got, want)
+		}
+	}
+	if back.Count != 300 || back.Zero != all.Zero {
+		t.Errorf("count=%d zero=%d want 300/%d", back.Count, back.Zero, all.Zero)
+	}
+}
+
+func TestSketch_Histogram(t *testing.T) {
+	s := NewSketch(0)
+	for i := 0; i < 40; i++ {
+		s.Add(2000) // 2 Mbps
+	}
+	for i := 0; i < 60; i++ {
+		s.Add(50000) // 50 Mbps
+	}
+	s.Add(0)
+	h := s.Histogram(0)
+	if h.PerDecade != DefaultHistogramPerDecade || h.Zero != 1 || h.Total() != 101 {
+		t.Fatalf("histogram: %+v", h)
+	}
+	var peaks []float64
+	for i, c := range h.Counts {
+		if c == 0 {
+			continue
+		}
+		lo, hi := h.Bounds(i)
+		peaks = append(peaks, lo)
+		if (c == 40 && (lo > 2000 || hi <= 2000)) || (c == 60 && (lo > 50000 || hi <= 50000)) || (c != 40 && c != 60) {
+			t.Fatalf("bucket [%v,%v) holds %d", lo, hi, c)
+		}
+	}
+	if len(peaks) != 2 {
+		t.Fatalf("want two filled buckets, got %v", peaks)
+	}
+	if (*Sketch)(nil).Histogram(10) != nil || (*ExpHistogram)(nil).Total() != 0 {
+		t.Fatalf("nil handling")
+	}
+}
@@ -0,0 +1,244 @@
+package monitor
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"time"
+
+	"github.com/iafilius/InternetQualityMonitor/src/types"
+)
+
+// SoakResult is the full speed series of a soak (continuous stream) probe. When the body ends
+// before the duration is over the URL is requested again; failed requests are retried after a
+// short pause and the samples in between are 0, so an outage shows up as a drop.
+type SoakResult struct {
+	TargetMs    int64     `json:"target_ms"`    // --soak-duration
+	DurationMs  int64     `json:"duration_ms"`  // time actually sampled
+	IntervalMs  int64     `json:"interval_ms"`  // --soak-interval
+	Bytes       int64     `json:"bytes"`        // body bytes over all requests
+	SamplesKbps []float64 `json:"samples_kbps"` // one per interval, in order
+	Requests    int       `json:"requests"`     // GETs issued (1 + restarts after the body ended or failed)
+	// Interruptions counts requests that failed or broke off mid-body (connection reset, stall)
+	Interruptions int `json:"interruptions,omitempty"`
+}
+
+const (
+	defaultSoakDuration = 10 * time.Minute
+	defaultSoakInterval = time.Second
+	soakRetryPause      = time.Second
+	// a body without progress for this long is abandoned and re-requested
+	soakStallTimeout = 15 * time.Second
+)
+
+var (
+	soakDuration = defaultSoakDuration
+	soakInterval = defaultSoakInterval
+
+	// seam for tests; the dials count into u
+	soakTransport = func(proxy *url.URL, u *wireUsage) http.RoundTripper {
+		return &http.Transport{
+			Proxy:                 http.ProxyURL(proxy),
+			DialContext:           u.dial(boundDial(&net.Dialer{Timeout: 10 * time.Second, KeepAlive: 30 * time.Second})),
+			TLSClientConfig:       &tls.Config{NextProtos: []string{"h2", "http/1.1"}},
+			ForceAttemptHTTP2:     true,
+			TLSHandshakeTimeout:   20 * time.Second,
+			ResponseHeaderTimeout: 30 * time.Second,
+		}
+	}
+)
+
+// SetSoakDuration sets how long the soak probe streams per site (default 10m).
+func SetSoakDuration(d time.Duration) {
+	if d > 0 {
+		soakDuration = d
+	}
+}
+
+// SetSoakInterval sets the soak probe's sampling interval (default 1s).
+func SetSoakInterval(d time.Duration) {
+	if d > 0 {
+		soakInterval = d
+	}
+}
+
+// SoakMeasurer streams the site's URL (a large file or an endless stream) for the soak duration,
+// one line per site. It runs for the whole --soak-duration regardless of --site-timeout, so use
+// it for long-horizon stability, next to the regular short per-URL transfers. Headers and auth
+// of the site apply; the proxy is the site's effective proxy, as for the http probe. A reduced
+// batch or the lite profile ends the soak at its transfer cap (transfer_capped).
+type SoakMeasurer struct{}
+
+func (SoakMeasurer) ProbeType() string { return ProbeSoak }
+
+func (SoakMeasurer) MeasureSite(ctx context.Context, site types.Site) {
+	sr := &SiteResult{Name: site.Name, URL: site.URL, CountryConfigured: site.Country, Group: SiteGroup(site), ProbeType: ProbeSoak, started: time.Now(), usage: &wireUsage{}}
+	u, err := url.Parse(site.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		sr.ProbeError = fmt.Sprintf("soak needs an http(s) URL: %s", site.URL)
+		WriteSiteResult(sr)
+		return
+	}
+	proxy, source, bypassed, err := effectiveProxy(site, u, nil)
+	if err != nil {
+		Debugf("[%s] soak proxy resolution error: %v", site.Name, err)
+		proxy = nil
+	}
+	if proxy != nil {
+		sr.EnvProxyURL, sr.UsingEnvProxy = proxy.Redacted(), true
+	} else if bypassed {
+		sr.EnvProxyBypassed = true
+	}
+	sr.ProxyConfigSource = source
+	// the site timeout is meant for short transfers; the soak has its own horizon
+	ctx, stop := withoutSiteTimeout(ctx)
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, soakDuration+soakStallTimeout)
+	defer cancel()
+	capBytes := transferCap()
+	res, lastErr := soakStream(ctx, site, &http.Client{Transport: soakTransport(proxy, sr.usage)}, soakDuration, soakInterval, capBytes)
+	sr.Soak = res
+	sr.TransferSizeBytes = res.Bytes
+	sr.TransferCapped = capBytes > 0 && res.Bytes >= capBytes
+	if res.Bytes == 0 && lastErr != nil {
+		sr.ProbeError = lastErr.Error()
+	}
+	avg := 0.0
+	if res.DurationMs > 0 {
+		avg = float64(res.Bytes) * 8 / float64(res.DurationMs)
+	}
+	Infof("[%s] soak %.0fs avg=%.0fkbps requests=%d interruptions=%d", site.Name, float64(res.DurationMs)/1000, avg, res.Requests, res.Interruptions)
+	WriteSiteResult(sr)
+}
+
+// MeasureSiteIP soaks once per site: only for the first fanned-out address.
+func (s SoakMeasurer) MeasureSiteIP(ctx context.Context, site types.Site, ip net.IP, dnsIPs []string, dnsTime time.Duration) {
+	if len(dnsIPs) > 0 && dnsIPs[0] != ip.String() {
+		return
+	}
+	s.MeasureSite(ctx, site)
+}
+
+// soakStream downloads site.URL for duration (re-requesting whenever a body ends or fails) and
+// samples the bytes read every interval. It stops early when ctx ends, the run is canceled or
+// capBytes (0: no cap) have been read. The last, partial interval counts towards Bytes and
+// DurationMs, and gets its own sample when it spans at least half an interval.
+func soakStream(ctx context.Context, site types.Site, client *http.Client, duration, interval time.Duration, capBytes int64) (*SoakResult, error) {
+	res := &SoakResult{TargetMs: duration.Milliseconds(), IntervalMs: interval.Milliseconds()}
+	var read atomic.Int64
+	var lastErr atomic.Value
+	streamCtx, stop := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		buf := make([]byte, 64<<10)
+		for streamCtx.Err() == nil && (capBytes == 0 || read.Load() < capBytes) {
+			res.Requests++
+			err := soakOnce(streamCtx, site, client, buf, &read, capBytes)
+			if streamCtx.Err() != nil || errors.Is(err, errSoakCapped) {
+				return
+			}
+			if err != nil {
+				res.Interruptions++
+				lastErr.Store(err)
+				select {
+				case <-streamCtx.Done():
+				case <-time.After(soakRetryPause):
+				}
+			}
+		}
+	}()
+	start := time.Now()
+	tick := time.NewTicker(interval)
+	defer tick.Stop()
+	var prev int64
+	prevAt := start
+sampling:
+	for time.Since(start) < duration {
+		select {
+		case <-ctx.Done():
+			break sampling
+		case <-done:
+			break sampling
+		case now := <-tick.C:
+			cur := read.Load()
+			secs := now.Sub(prevAt).Seconds()
+			if secs > 0 {
+				res.SamplesKbps = append(res.SamplesKbps, float64(cur-prev)*8/1000/secs)
+			}
+			prev, prevAt = cur, now
+			if runCanceled.Load() {
+				break sampling
+			}
+		}
+	}
+	stop()
+	<-done
+	end := time.Now()
+	res.Bytes = read.Load()
+	if last := end.Sub(prevAt); last >= interval/2 {
+		res.SamplesKbps = append(res.SamplesKbps, float64(res.Bytes-prev)*8/1000/last.Seconds())
+	}
+	res.DurationMs = end.Sub(start).Milliseconds()
+	if err, ok := lastErr.Load().(error); ok {
+		return res, err
+	}
+	return res, ctx.Err()
+}
+
+// errSoakCapped ends the stream once the transfer cap has been read.
+var errSoakCapped = errors.New("soak: transfer cap reached")
+
+// soakOnce streams one response body into read; a body that stalls for soakStallTimeout is
+// abandoned with an error. It stops with errSoakCapped once read reaches capBytes (0: no cap).
+func soakOnce(ctx context.Context, site types.Site, client *http.Client, buf []byte, read *atomic.Int64, capBytes int64) error {
+	reqCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	req, err := newSiteRequest(reqCtx, http.MethodGet, site)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+		return fmt.Errorf("http status %d", resp.StatusCode)
+	}
+	watchdog := time.AfterFunc(soakStallTimeout, cancel)
+	defer watchdog.Stop()
+	for {
+		b := buf
+		if capBytes > 0 {
+			left := capBytes - read.Load()
+			if left <= 0 {
+				return errSoakCapped
+			}
+			if left < int64(len(b)) {
+				b = b[:left]
+			}
+		}
+		n, err := resp.Body.Read(b)
+		if n > 0 {
+			read.Add(int64(n))
+			watchdog.Reset(soakStallTimeout)
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			if ctx.Err() == nil && reqCtx.Err() != nil {
+				return fmt.Errorf("soak: no data for %s", soakStallTimeout)
+			}
+			return err
+		}
+	}
+}
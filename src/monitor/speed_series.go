@@ -0,0 +1,85 @@
+package monitor
+
+import "sync"
+
+// Speed series (--speed-series): the per-second throughput of each transfer, persisted so a single
+// bad transfer can be examined afterwards (a stall in the middle, a slow start, a collapse at the
+// end). transfer_speed_samples holds running averages since the transfer start, which smooth such
+// events away; the series holds the rate within each interval. Long transfers are bounded by
+// --speed-series-max points: the interval doubles until the whole transfer fits.
+
+// SpeedSeries is the throughput per IntervalMs of one transfer, in the same kbps as
+// TransferSpeedKbps; the last interval may be shorter.
+type SpeedSeries struct {
+	IntervalMs int64     `json:"interval_ms"`
+	Kbps       []float64 `json:"kbps"`
+}
+
+// DefaultSpeedSeriesMax bounds a line's series to about 3 KB of JSON.
+const DefaultSpeedSeriesMax = 300
+
+var (
+	speedSeriesMu      sync.RWMutex
+	speedSeriesEnabled bool
+	speedSeriesMax     = DefaultSpeedSeriesMax
+)
+
+// SetSpeedSeries enables persisting speed_series with at most maxPoints intervals (<= 0: the
+// default).
+func SetSpeedSeries(enabled bool, maxPoints int) {
+	speedSeriesMu.Lock()
+	defer speedSeriesMu.Unlock()
+	speedSeriesEnabled = enabled
+	speedSeriesMax = maxPoints
+	if speedSeriesMax <= 0 {
+		speedSeriesMax = DefaultSpeedSeriesMax
+	}
+}
+
+// speedSeriesFor returns the series for a finished transfer when --speed-series is on.
+func speedSeriesFor(samples []SpeedSample, durationMs, totalBytes int64) *SpeedSeries {
+	speedSeriesMu.RLock()
+	on, max := speedSeriesEnabled, speedSeriesMax
+	speedSeriesMu.RUnlock()
+	if !on {
+		return nil
+	}
+	all := append(append([]SpeedSample(nil), samples...), SpeedSample{TimeMs: durationMs, Bytes: totalBytes})
+	return BuildSpeedSeries(all, max)
+}
+
+// BuildSpeedSeries derives the per-second series from cumulative samples (byte counts at
+// increasing times since the transfer start), with at most maxPoints intervals. The byte count at
+// an interval boundary is that of the last sample at or before it. nil with less than one
+// interval of samples.
+func BuildSpeedSeries(samples []SpeedSample, maxPoints int) *SpeedSeries {
+	if len(samples) == 0 || maxPoints <= 0 {
+		return nil
+	}
+	endMs := samples[len(samples)-1].TimeMs
+	if endMs <= 0 {
+		return nil
+	}
+	interval := int64(1000)
+	for (endMs+interval-1)/interval > int64(maxPoints) {
+		interval *= 2
+	}
+	s := &SpeedSeries{IntervalMs: interval}
+	var prevBytes, prevMs int64
+	i := 0
+	for t := interval; prevMs < endMs; t += interval {
+		if t > endMs {
+			t = endMs
+		}
+		for i+1 < len(samples) && samples[i+1].TimeMs <= t {
+			i++
+		}
+		b := prevBytes
+		if samples[i].TimeMs <= t {
+			b = samples[i].Bytes
+		}
+		s.Kbps = append(s.Kbps, float64(b-prevBytes)/(float64(t-prevMs)/1000)/1024)
+		prevBytes, prevMs = b, t
+	}
+	return s
+}
@@ -0,0 +1,39 @@
+package monitor
+
+import "testing"
+
+func TestBuildSpeedSeries(t *testing.T) {
+	// 1024 B/s for 2 s, a 1 s stall, then 4096 B/s for the final half second
+	samples := []SpeedSample{{TimeMs: 500, Bytes: 512}, {TimeMs: 1000, Bytes: 1024}, {TimeMs: 2000, Bytes: 2048}, {TimeMs: 3000, Bytes: 2048}, {TimeMs: 3500, Bytes: 4096}}
+	s := BuildSpeedSeries(samples, 10)
+	want := []float64{1, 1, 0, 4}
+	if s == nil || s.IntervalMs != 1000 || len(s.Kbps) != len(want) {
+		t.Fatalf("series: %+v", s)
+	}
+	for i := range want {
+		if s.Kbps[i] != want[i] {
+			t.Fatalf("interval %d: %.2f kbps, want %.2f (%v)", i, s.Kbps[i], want[i], s.Kbps)
+		}
+	}
+	// bounded: 4 intervals of 1s do not fit into 2 points, 2 of 2s do
+	if s := BuildSpeedSeries(samples, 2); s.IntervalMs != 2000 || len(s.Kbps) != 2 || s.Kbps[0] != 1 {
+		t.Fatalf("bounded series: %+v", s)
+	}
+	if BuildSpeedSeries(nil, 10) != nil {
+		t.Fatalf("no samples: no series")
+	}
+}
+
+func TestSpeedSeriesFor(t *testing.T) {
+	defer SetSpeedSeries(false, 0)
+	samples := []SpeedSample{{TimeMs: 900, Bytes: 900}}
+	if speedSeriesFor(samples, 1500, 2048) != nil {
+		t.Fatalf("off by default")
+	}
+	SetSpeedSeries(true, 0)
+	s := speedSeriesFor(samples, 1500, 2048)
+	// the transfer end closes the last, shorter interval
+	if s == nil || len(s.Kbps) != 2 || s.Kbps[1] != float64(2048-900)/0.5/1024 || len(samples) != 1 {
+		t.Fatalf("series: %+v", s)
+	}
+}
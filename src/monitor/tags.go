@@ -0,0 +1,40 @@
+package monitor
+
+import (
+	"fmt"
+	"strings"
+)
+
+// batchTags are attached to every line's meta (--tags), e.g. router_fw=1.2.3, isp=Acme.
+var batchTags map[string]string
+
+// SetTags sets the tags written to meta.tags; nil or empty clears them.
+func SetTags(tags map[string]string) {
+	if len(tags) == 0 {
+		batchTags = nil
+		return
+	}
+	batchTags = tags
+}
+
+// ParseTags parses "key=value,key2=value2". Keys and values are trimmed; a value may contain
+// '=' (only the first one splits), but keys must be non-empty and unique.
+func ParseTags(s string) (map[string]string, error) {
+	out := map[string]string{}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(part, "=")
+		k, v = strings.TrimSpace(k), strings.TrimSpace(v)
+		if !ok || k == "" {
+			return nil, fmt.Errorf("tag %q: want key=value", part)
+		}
+		if _, dup := out[k]; dup {
+			return nil, fmt.Errorf("tag %q given twice", k)
+		}
+		out[k] = v
+	}
+	return out, nil
+}
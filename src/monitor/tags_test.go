@@ -0,0 +1,25 @@
+package monitor
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTags(t *testing.T) {
+	got, err := ParseTags(" router_fw=1.2.3, isp = Acme ,,note=a=b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"router_fw": "1.2.3", "isp": "Acme", "note": "a=b"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for _, bad := range []string{"novalue", "=x", "a=1,a=2"} {
+		if _, err := ParseTags(bad); err == nil {
+			t.Errorf("ParseTags(%q) accepted", bad)
+		}
+	}
+	if got, err := ParseTags(""); err != nil || len(got) != 0 {
+		t.Fatalf("empty: %v %v", got, err)
+	}
+}
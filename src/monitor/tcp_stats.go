@@ -0,0 +1,217 @@
+package monitor
+
+import (
+	"encoding/binary"
+	"net"
+	"sync"
+	"syscall"
+)
+
+// TCPStats is tcp_stats: the kernel's TCP counters of the line's connections, read with TCP_INFO
+// (Linux) or TCP_CONNECTION_INFO (macOS) when a connection closes, or when the line is written
+// for connections the transport still keeps open. Retransmissions explain the stalls and
+// plateaus a speed chart only shows: Retransmits are the segments this side sent again,
+// RecvOutOfOrder the segments that arrived out of order, which on a download are mostly the
+// server's retransmissions after a loss. With a proxy the counters are those of the connection
+// to the proxy. Not recorded on other systems or with --tcp-stats=false.
+type TCPStats struct {
+	Conns          int     `json:"conns"`
+	SegsOut        int64   `json:"segs_out,omitempty"`
+	SegsIn         int64   `json:"segs_in,omitempty"`
+	Retransmits    int64   `json:"retransmits"`
+	RetransRatePct float64 `json:"retrans_rate_pct,omitempty"` // Retransmits of SegsOut
+	// Linux 5.4 and later count packets; macOS counts bytes, converted with the MSS
+	RecvOutOfOrder int64   `json:"recv_out_of_order,omitempty"`
+	RecvOOORatePct float64 `json:"recv_ooo_rate_pct,omitempty"` // RecvOutOfOrder of SegsIn
+	// smoothed RTT and its variation as the kernel keeps them, mean over the connections
+	RTTMs    float64 `json:"rtt_ms,omitempty"`
+	RTTVarMs float64 `json:"rtt_var_ms,omitempty"`
+	Source   string  `json:"source"` // tcp_info or tcp_connection_info
+}
+
+// tcpConnInfo is one connection's counters.
+type tcpConnInfo struct {
+	segsOut, segsIn, retrans, recvOOO int64
+	rttMs, rttVarMs                   float64
+}
+
+var tcpStatsEnabled = true
+
+// SetTCPStats enables or disables tcp_stats (--tcp-stats, on by default where supported).
+func SetTCPStats(enabled bool) { tcpStatsEnabled = enabled }
+
+// tcpTracker collects the counters of one line's connections; the transport closes connections
+// on its own goroutines, hence the mutex.
+type tcpTracker struct {
+	mu     sync.Mutex
+	open   map[*tcpStatsConn]struct{}
+	closed []tcpConnInfo
+}
+
+// newTCPTracker returns nil when tcp_stats is off or the OS has no TCP_INFO.
+func newTCPTracker() *tcpTracker {
+	if !tcpStatsEnabled || tcpInfoSource == "" {
+		return nil
+	}
+	return &tcpTracker{open: map[*tcpStatsConn]struct{}{}}
+}
+
+type tcpStatsConn struct {
+	net.Conn
+	raw  syscall.RawConn
+	t    *tcpTracker
+	once sync.Once
+}
+
+// Close records the connection's counters before closing it.
+func (c *tcpStatsConn) Close() error {
+	c.once.Do(func() {
+		info, ok := c.snapshot()
+		c.t.mu.Lock()
+		delete(c.t.open, c)
+		if ok {
+			c.t.closed = append(c.t.closed, info)
+		}
+		c.t.mu.Unlock()
+	})
+	return c.Conn.Close()
+}
+
+func (c *tcpStatsConn) snapshot() (tcpConnInfo, bool) {
+	var info tcpConnInfo
+	var ok bool
+	if err := c.raw.Control(func(fd uintptr) { info, ok = readTCPInfo(fd) }); err != nil {
+		return tcpConnInfo{}, false
+	}
+	return info, ok
+}
+
+// wrap returns c recording into t; c unchanged when t is nil or c is not a TCP connection.
+func (t *tcpTracker) wrap(c net.Conn) net.Conn {
+	tc, ok := c.(*net.TCPConn)
+	if t == nil || !ok {
+		return c
+	}
+	raw, err := tc.SyscallConn()
+	if err != nil {
+		return c
+	}
+	sc := &tcpStatsConn{Conn: c, raw: raw, t: t}
+	t.mu.Lock()
+	t.open[sc] = struct{}{}
+	t.mu.Unlock()
+	return sc
+}
+
+// stats sums the closed connections and the ones still open; nil without any.
+func (t *tcpTracker) stats() *TCPStats {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	infos := append([]tcpConnInfo(nil), t.closed...)
+	open := make([]*tcpStatsConn, 0, len(t.open))
+	for c := range t.open {
+		open = append(open, c)
+	}
+	t.mu.Unlock()
+	for _, c := range open {
+		if info, ok := c.snapshot(); ok {
+			infos = append(infos, info)
+		}
+	}
+	return sumTCPInfo(infos, tcpInfoSource)
+}
+
+func sumTCPInfo(infos []tcpConnInfo, source string) *TCPStats {
+	if len(infos) == 0 {
+		return nil
+	}
+	s := &TCPStats{Conns: len(infos), Source: source}
+	var rtt, rttVar float64
+	rttConns := 0
+	for _, in := range infos {
+		s.SegsOut += in.segsOut
+		s.SegsIn += in.segsIn
+		s.Retransmits += in.retrans
+		s.RecvOutOfOrder += in.recvOOO
+		if in.rttMs > 0 {
+			rtt += in.rttMs
+			rttVar += in.rttVarMs
+			rttConns++
+		}
+	}
+	if s.SegsOut > 0 {
+		s.RetransRatePct = float64(s.Retransmits) / float64(s.SegsOut) * 100
+	}
+	if s.SegsIn > 0 {
+		s.RecvOOORatePct = float64(s.RecvOutOfOrder) / float64(s.SegsIn) * 100
+	}
+	if rttConns > 0 {
+		s.RTTMs, s.RTTVarMs = rtt/float64(rttConns), rttVar/float64(rttConns)
+	}
+	return s
+}
+
+// Offsets in Linux's struct tcp_info, which only ever grows; older kernels return a shorter one.
+const (
+	linuxTCPInfoRTT          = 68 // u32 µs
+	linuxTCPInfoRTTVar       = 72 // u32 µs
+	linuxTCPInfoTotalRetrans = 100
+	linuxTCPInfoSegsOut      = 136 // since 4.2
+	linuxTCPInfoSegsIn       = 140
+	linuxTCPInfoRcvOOOPack   = 224 // since 5.4
+	linuxTCPInfoMaxLen       = 232
+)
+
+// decodeLinuxTCPInfo reads the counters from the bytes getsockopt(TCP_INFO) returned.
+func decodeLinuxTCPInfo(b []byte) (tcpConnInfo, bool) {
+	if len(b) < linuxTCPInfoTotalRetrans+4 {
+		return tcpConnInfo{}, false
+	}
+	u32 := func(off int) int64 { return int64(binary.NativeEndian.Uint32(b[off:])) }
+	info := tcpConnInfo{
+		retrans:  u32(linuxTCPInfoTotalRetrans),
+		rttMs:    float64(u32(linuxTCPInfoRTT)) / 1000,
+		rttVarMs: float64(u32(linuxTCPInfoRTTVar)) / 1000,
+	}
+	if len(b) >= linuxTCPInfoSegsIn+4 {
+		info.segsOut, info.segsIn = u32(linuxTCPInfoSegsOut), u32(linuxTCPInfoSegsIn)
+	}
+	if len(b) >= linuxTCPInfoRcvOOOPack+4 {
+		info.recvOOO = u32(linuxTCPInfoRcvOOOPack)
+	}
+	return info, true
+}
+
+// Offsets in macOS's struct tcp_connection_info.
+const (
+	darwinTCPInfoMaxSeg       = 16  // u32 bytes
+	darwinTCPInfoSRTT         = 44  // u32 ms
+	darwinTCPInfoRTTVar       = 48  // u32 ms
+	darwinTCPInfoTxPackets    = 56  // u64
+	darwinTCPInfoRxPackets    = 80  // u64
+	darwinTCPInfoRxOOOBytes   = 96  // u64
+	darwinTCPInfoTxRetransPkt = 104 // u64
+	darwinTCPInfoLen          = 112
+)
+
+// decodeDarwinTCPInfo reads the counters from the bytes getsockopt(TCP_CONNECTION_INFO) returned.
+func decodeDarwinTCPInfo(b []byte) (tcpConnInfo, bool) {
+	if len(b) < darwinTCPInfoLen {
+		return tcpConnInfo{}, false
+	}
+	u32 := func(off int) int64 { return int64(binary.NativeEndian.Uint32(b[off:])) }
+	u64 := func(off int) int64 { return int64(binary.NativeEndian.Uint64(b[off:])) }
+	info := tcpConnInfo{
+		segsOut:  u64(darwinTCPInfoTxPackets),
+		segsIn:   u64(darwinTCPInfoRxPackets),
+		retrans:  u64(darwinTCPInfoTxRetransPkt),
+		rttMs:    float64(u32(darwinTCPInfoSRTT)),
+		rttVarMs: float64(u32(darwinTCPInfoRTTVar)),
+	}
+	if mss := u32(darwinTCPInfoMaxSeg); mss > 0 {
+		info.recvOOO = (u64(darwinTCPInfoRxOOOBytes) + mss - 1) / mss
+	}
+	return info, true
+}
@@ -0,0 +1,24 @@
+//go:build darwin
+
+package monitor
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+const tcpInfoSource = "tcp_connection_info"
+
+// sysTCPConnectionInfo is TCP_CONNECTION_INFO from <netinet/tcp.h>; package syscall lacks it.
+const sysTCPConnectionInfo = 0x106
+
+// readTCPInfo reads TCP_CONNECTION_INFO of the socket fd.
+func readTCPInfo(fd uintptr) (tcpConnInfo, bool) {
+	var buf [darwinTCPInfoLen]byte
+	n := uint32(len(buf))
+	if _, _, errno := syscall.Syscall6(syscall.SYS_GETSOCKOPT, fd, syscall.IPPROTO_TCP, sysTCPConnectionInfo,
+		uintptr(unsafe.Pointer(&buf[0])), uintptr(unsafe.Pointer(&n)), 0); errno != 0 {
+		return tcpConnInfo{}, false
+	}
+	return decodeDarwinTCPInfo(buf[:min(int(n), len(buf))])
+}
@@ -0,0 +1,21 @@
+//go:build linux
+
+package monitor
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+const tcpInfoSource = "tcp_info"
+
+// readTCPInfo reads TCP_INFO of the socket fd.
+func readTCPInfo(fd uintptr) (tcpConnInfo, bool) {
+	var buf [linuxTCPInfoMaxLen]byte
+	n := uint32(len(buf))
+	if _, _, errno := syscall.Syscall6(syscall.SYS_GETSOCKOPT, fd, syscall.IPPROTO_TCP, syscall.TCP_INFO,
+		uintptr(unsafe.Pointer(&buf[0])), uintptr(unsafe.Pointer(&n)), 0); errno != 0 {
+		return tcpConnInfo{}, false
+	}
+	return decodeLinuxTCPInfo(buf[:min(int(n), len(buf))])
+}
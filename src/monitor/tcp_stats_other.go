@@ -0,0 +1,8 @@
+//go:build !linux && !darwin
+
+package monitor
+
+// Other systems: no TCP_INFO, so lines carry no tcp_stats.
+const tcpInfoSource = ""
+
+func readTCPInfo(uintptr) (tcpConnInfo, bool) { return tcpConnInfo{}, false }
@@ -0,0 +1,98 @@
+package monitor
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDecodeTCPInfo(t *testing.T) {
+	b := make([]byte, linuxTCPInfoMaxLen)
+	put := func(off int, v uint32) { binary.NativeEndian.PutUint32(b[off:], v) }
+	put(linuxTCPInfoRTT, 12500)
+	put(linuxTCPInfoRTTVar, 3000)
+	put(linuxTCPInfoTotalRetrans, 4)
+	put(linuxTCPInfoSegsOut, 200)
+	put(linuxTCPInfoSegsIn, 1000)
+	put(linuxTCPInfoRcvOOOPack, 20)
+	info, ok := decodeLinuxTCPInfo(b)
+	if !ok || info.retrans != 4 || info.segsOut != 200 || info.segsIn != 1000 || info.recvOOO != 20 || info.rttMs != 12.5 || info.rttVarMs != 3 {
+		t.Fatalf("linux: %+v", info)
+	}
+	// a pre-4.2 kernel has no segment counters
+	if old, ok := decodeLinuxTCPInfo(b[:104]); !ok || old.retrans != 4 || old.segsOut != 0 || old.recvOOO != 0 {
+		t.Fatalf("linux short: %+v", old)
+	}
+	if _, ok := decodeLinuxTCPInfo(b[:40]); ok {
+		t.Fatal("truncated tcp_info decoded")
+	}
+
+	d := make([]byte, darwinTCPInfoLen)
+	binary.NativeEndian.PutUint32(d[darwinTCPInfoMaxSeg:], 1448)
+	binary.NativeEndian.PutUint32(d[darwinTCPInfoSRTT:], 30)
+	binary.NativeEndian.PutUint32(d[darwinTCPInfoRTTVar:], 8)
+	binary.NativeEndian.PutUint64(d[darwinTCPInfoTxPackets:], 50)
+	binary.NativeEndian.PutUint64(d[darwinTCPInfoRxPackets:], 700)
+	binary.NativeEndian.PutUint64(d[darwinTCPInfoRxOOOBytes:], 1448*7)
+	binary.NativeEndian.PutUint64(d[darwinTCPInfoTxRetransPkt:], 2)
+	info, ok = decodeDarwinTCPInfo(d)
+	if !ok || info.retrans != 2 || info.segsOut != 50 || info.segsIn != 700 || info.recvOOO != 7 || info.rttMs != 30 || info.rttVarMs != 8 {
+		t.Fatalf("darwin: %+v", info)
+	}
+
+	s := sumTCPInfo([]tcpConnInfo{{segsOut: 100, segsIn: 400, retrans: 2, recvOOO: 8, rttMs: 10, rttVarMs: 2}, {segsOut: 100, segsIn: 400, rttMs: 20, rttVarMs: 4}}, "tcp_info")
+	if s.Conns != 2 || s.Retransmits != 2 || s.RetransRatePct != 1 || s.RecvOOORatePct != 1 || s.RTTMs != 15 || s.RTTVarMs != 3 {
+		t.Fatalf("sum: %+v", s)
+	}
+	if sumTCPInfo(nil, "tcp_info") != nil {
+		t.Fatal("stats without connections")
+	}
+}
+
+func TestTCPTrackerRecordsOnClose(t *testing.T) {
+	tr := newTCPTracker()
+	if tr == nil {
+		t.Skip("no TCP_INFO on this system")
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		io.WriteString(w, strings.Repeat("x", 256<<10))
+	}))
+	defer srv.Close()
+	c, err := net.Dial("tcp", srv.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	wc := tr.wrap(c)
+	if _, err := io.WriteString(wc, "GET / HTTP/1.1\r\nHost: x\r\nConnection: close\r\n\r\n"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.Copy(io.Discard, wc); err != nil {
+		t.Fatal(err)
+	}
+	// open connections are read when the line is written
+	if s := tr.stats(); s == nil || s.Conns != 1 || s.RTTMs <= 0 && s.SegsIn == 0 {
+		t.Fatalf("open connection: %+v", s)
+	}
+	wc.Close()
+	wc.Close()
+	s := tr.stats()
+	if s == nil || s.Conns != 1 || len(tr.open) != 0 {
+		t.Fatalf("closed connection: %+v", s)
+	}
+	if tcpInfoSource == "tcp_info" && s.SegsIn == 0 {
+		t.Fatalf("linux: no segments counted: %+v", s)
+	}
+	SetTCPStats(false)
+	defer SetTCPStats(true)
+	if newTCPTracker() != nil {
+		t.Fatal("tracker with --tcp-stats=false")
+	}
+	var none *tcpTracker
+	if none.wrap(c) != c || none.stats() != nil {
+		t.Fatal("nil tracker")
+	}
+}
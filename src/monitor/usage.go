@@ -0,0 +1,306 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DataUsage is meta.data_usage: the bytes this monitor moved on the wire, counted on the
+// connections of each line's measurement (HTTP requests with TLS records and headers, the timed
+// TCP/TLS connect, the reuse experiment, QUIC and DNS probes, the site's DNS lookup), as running
+// totals when the line was written.
+// Day and Cycle are local dates (the cycle starts on --budget-cycle-day) and survive restarts
+// through the <out>.usage.json state file. BudgetBytes and BudgetAction are set when a monthly
+// budget is configured.
+type DataUsage struct {
+	BatchRxBytes int64  `json:"batch_rx_bytes"`
+	BatchTxBytes int64  `json:"batch_tx_bytes"`
+	Day          string `json:"day"`
+	DayRxBytes   int64  `json:"day_rx_bytes"`
+	DayTxBytes   int64  `json:"day_tx_bytes"`
+	Cycle        string `json:"cycle"`
+	CycleRxBytes int64  `json:"cycle_rx_bytes"`
+	CycleTxBytes int64  `json:"cycle_tx_bytes"`
+	BudgetBytes  int64  `json:"budget_bytes,omitempty"`
+	BudgetAction string `json:"budget_action,omitempty"` // reduce or skip, decided at the batch start
+}
+
+// Budget policies for --budget-policy, applied once the cycle's usage passes the near threshold.
+// Past the budget itself batches are always skipped.
+const (
+	BudgetPolicyReduce = "reduce" // cap transfers like --metered-policy=reduce
+	BudgetPolicySkip   = "skip"
+)
+
+// wireUsage counts the bytes of one line's connections; the transport may read on its own
+// goroutines (HTTP/2), hence atomics.
+type wireUsage struct{ rx, tx atomic.Int64 }
+
+type countingConn struct {
+	net.Conn
+	u *wireUsage
+}
+
+func (c countingConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	c.u.rx.Add(int64(n))
+	return n, err
+}
+
+func (c countingConn) Write(p []byte) (int, error) {
+	n, err := c.Conn.Write(p)
+	c.u.tx.Add(int64(n))
+	return n, err
+}
+
+// countingPacketConn keeps a UDP connection a net.PacketConn: the Go resolver only uses
+// datagram framing on connections that implement it.
+type countingPacketConn struct {
+	countingConn
+	pc net.PacketConn
+}
+
+func (c countingPacketConn) ReadFrom(p []byte) (int, net.Addr, error) {
+	n, addr, err := c.pc.ReadFrom(p)
+	c.u.rx.Add(int64(n))
+	return n, addr, err
+}
+
+func (c countingPacketConn) WriteTo(p []byte, addr net.Addr) (int, error) {
+	n, err := c.pc.WriteTo(p, addr)
+	c.u.tx.Add(int64(n))
+	return n, err
+}
+
+// wrap returns c counting into u (c unchanged when u is nil).
+func (u *wireUsage) wrap(c net.Conn) net.Conn {
+	if u == nil || c == nil {
+		return c
+	}
+	if pc, ok := c.(net.PacketConn); ok {
+		return countingPacketConn{countingConn: countingConn{Conn: c, u: u}, pc: pc}
+	}
+	return countingConn{Conn: c, u: u}
+}
+
+// dial returns d with every connection it makes counted into u.
+func (u *wireUsage) dial(d dialFunc) dialFunc {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		c, err := d(ctx, network, address)
+		return u.wrap(c), err
+	}
+}
+
+// usageState is the persisted part of the accounting (<out>.usage.json).
+type usageState struct {
+	Day          string `json:"day"`
+	DayRxBytes   int64  `json:"day_rx_bytes"`
+	DayTxBytes   int64  `json:"day_tx_bytes"`
+	Cycle        string `json:"cycle"`
+	CycleRxBytes int64  `json:"cycle_rx_bytes"`
+	CycleTxBytes int64  `json:"cycle_tx_bytes"`
+}
+
+var (
+	usageMu                    sync.Mutex
+	usageTotals                usageState
+	usageBatchTag              string
+	usageBatchRx, usageBatchTx int64
+	usageStatePath             string
+	monthlyBudgetBytes         int64
+	budgetNearPct              = 10.0
+	budgetPolicy               = BudgetPolicyReduce
+	budgetCycleDay             = 1
+	budgetRunTag               string
+	budgetDecided              bool
+	budgetAction               string
+	usageNow                   = time.Now // replaceable in tests
+)
+
+// SetMonthlyBudget sets the byte budget per billing cycle (0 disables the guard), the share of
+// it (percent) left at which policy starts to apply, and the policy (reduce or skip).
+func SetMonthlyBudget(bytes int64, nearPct float64, policy string) error {
+	policy = strings.ToLower(strings.TrimSpace(policy))
+	if policy != BudgetPolicyReduce && policy != BudgetPolicySkip {
+		return fmt.Errorf("budget policy %q: want reduce or skip", policy)
+	}
+	if bytes < 0 || nearPct < 0 || nearPct > 100 {
+		return fmt.Errorf("budget %d bytes / near %.0f%%: want bytes >= 0 and 0-100%%", bytes, nearPct)
+	}
+	usageMu.Lock()
+	defer usageMu.Unlock()
+	monthlyBudgetBytes, budgetNearPct, budgetPolicy = bytes, nearPct, policy
+	budgetDecided = false
+	return nil
+}
+
+// SetBudgetCycleDay sets the day of month (1-28) the billing cycle starts on.
+func SetBudgetCycleDay(day int) {
+	usageMu.Lock()
+	defer usageMu.Unlock()
+	budgetCycleDay = min(max(day, 1), 28)
+}
+
+// ParseByteSize parses sizes like 500MB, 20GB, 1.5GiB or plain bytes. Decimal units are powers
+// of 1000 like a carrier's plan, the *iB units powers of 1024.
+func ParseByteSize(s string) (int64, error) {
+	t := strings.ToUpper(strings.TrimSpace(s))
+	if t == "" || t == "0" {
+		return 0, nil
+	}
+	units := []struct {
+		suffix string
+		mult   float64
+	}{
+		{"TIB", 1 << 40}, {"GIB", 1 << 30}, {"MIB", 1 << 20}, {"KIB", 1 << 10},
+		{"TB", 1e12}, {"GB", 1e9}, {"MB", 1e6}, {"KB", 1e3}, {"B", 1},
+	}
+	mult := 1.0
+	for _, u := range units {
+		if strings.HasSuffix(t, u.suffix) {
+			t, mult = strings.TrimSpace(strings.TrimSuffix(t, u.suffix)), u.mult
+			break
+		}
+	}
+	v, err := strconv.ParseFloat(t, 64)
+	if err != nil || v < 0 {
+		return 0, fmt.Errorf("byte size %q: want e.g. 500MB or 20GB", s)
+	}
+	return int64(v * mult), nil
+}
+
+// LoadUsageState reads the persisted day/cycle totals kept next to the results file and
+// remembers the path for SaveUsageState. A missing file starts from zero.
+func LoadUsageState(resultsPath string) error {
+	usageMu.Lock()
+	defer usageMu.Unlock()
+	usageStatePath = resultsPath + ".usage.json"
+	b, err := os.ReadFile(usageStatePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var st usageState
+	if err := json.Unmarshal(b, &st); err != nil {
+		return fmt.Errorf("%s: %w", usageStatePath, err)
+	}
+	usageTotals = st
+	return nil
+}
+
+// SaveUsageState persists the day/cycle totals (after each batch).
+func SaveUsageState() error {
+	usageMu.Lock()
+	st, path := usageTotals, usageStatePath
+	usageMu.Unlock()
+	if path == "" {
+		return nil
+	}
+	b, _ := json.MarshalIndent(st, "", "  ")
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// cycleStart returns the local date the billing cycle containing t started.
+func cycleStart(t time.Time, day int) time.Time {
+	start := time.Date(t.Year(), t.Month(), day, 0, 0, 0, 0, t.Location())
+	if t.Day() < day {
+		start = start.AddDate(0, -1, 0)
+	}
+	return start
+}
+
+// rollUsageLocked resets the day, cycle and batch totals when they changed. Callers hold usageMu.
+func rollUsageLocked(tag string) {
+	now := usageNow()
+	if d := now.Format("2006-01-02"); usageTotals.Day != d {
+		usageTotals.Day, usageTotals.DayRxBytes, usageTotals.DayTxBytes = d, 0, 0
+	}
+	if c := cycleStart(now, budgetCycleDay).Format("2006-01-02"); usageTotals.Cycle != c {
+		usageTotals.Cycle, usageTotals.CycleRxBytes, usageTotals.CycleTxBytes = c, 0, 0
+	}
+	if usageBatchTag != tag {
+		usageBatchTag, usageBatchRx, usageBatchTx = tag, 0, 0
+	}
+}
+
+// BudgetAction decides once per batch, from the cycle usage at its start, whether the budget
+// guard skips it (budget used up, or near it with the skip policy) or caps its transfers.
+func BudgetAction(tag string) (string, DataUsage) {
+	usageMu.Lock()
+	defer usageMu.Unlock()
+	rollUsageLocked(tag)
+	if !budgetDecided || budgetRunTag != tag {
+		budgetDecided, budgetRunTag, budgetAction = true, tag, ""
+		if monthlyBudgetBytes > 0 {
+			used := usageTotals.CycleRxBytes + usageTotals.CycleTxBytes
+			switch {
+			case used >= monthlyBudgetBytes:
+				budgetAction = BudgetPolicySkip
+			case float64(monthlyBudgetBytes-used) <= float64(monthlyBudgetBytes)*budgetNearPct/100:
+				budgetAction = budgetPolicy
+			}
+		}
+	}
+	return budgetAction, usageSnapshotLocked()
+}
+
+func usageSnapshotLocked() DataUsage {
+	return DataUsage{
+		BatchRxBytes: usageBatchRx, BatchTxBytes: usageBatchTx,
+		Day: usageTotals.Day, DayRxBytes: usageTotals.DayRxBytes, DayTxBytes: usageTotals.DayTxBytes,
+		Cycle: usageTotals.Cycle, CycleRxBytes: usageTotals.CycleRxBytes, CycleTxBytes: usageTotals.CycleTxBytes,
+		BudgetBytes: monthlyBudgetBytes, BudgetAction: budgetAction,
+	}
+}
+
+// budgetReduced reports whether the current batch runs with capped transfers for the budget.
+func budgetReduced() bool {
+	action, _ := BudgetAction(runTag)
+	return action == BudgetPolicyReduce
+}
+
+// budgetUsedUp reports whether the cycle's usage so far, including this batch, has reached the
+// monthly budget; BudgetAction only looks at the batch start.
+func budgetUsedUp() bool {
+	usageMu.Lock()
+	defer usageMu.Unlock()
+	return monthlyBudgetBytes > 0 && usageTotals.CycleRxBytes+usageTotals.CycleTxBytes >= monthlyBudgetBytes
+}
+
+// accountUsage moves sr's connection counters into wire_rx/tx_bytes and adds them to the
+// totals; the returned snapshot goes into the line's meta.
+func accountUsage(tag string, sr *SiteResult) *DataUsage {
+	if sr != nil && sr.usage != nil {
+		sr.WireRxBytes, sr.WireTxBytes = sr.usage.rx.Load(), sr.usage.tx.Load()
+	}
+	usageMu.Lock()
+	defer usageMu.Unlock()
+	rollUsageLocked(tag)
+	if sr != nil {
+		usageBatchRx += sr.WireRxBytes
+		usageBatchTx += sr.WireTxBytes
+		usageTotals.DayRxBytes += sr.WireRxBytes
+		usageTotals.DayTxBytes += sr.WireTxBytes
+		usageTotals.CycleRxBytes += sr.WireRxBytes
+		usageTotals.CycleTxBytes += sr.WireTxBytes
+	}
+	if budgetDecided && budgetRunTag != tag {
+		budgetAction = "" // not decided for this batch (callers outside the main loop)
+	}
+	u := usageSnapshotLocked()
+	return &u
+}
@@ -0,0 +1,149 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	typespkg "github.com/iafilius/InternetQualityMonitor/src/types"
+)
+
+func TestParseByteSize(t *testing.T) {
+	cases := map[string]int64{"": 0, "0": 0, "1234": 1234, "500MB": 500e6, "20 gb": 20e9, "1.5GiB": 3 << 29, "2KiB": 2048}
+	for in, want := range cases {
+		if got, err := ParseByteSize(in); err != nil || got != want {
+			t.Fatalf("ParseByteSize(%q) = %d, %v; want %d", in, got, err, want)
+		}
+	}
+	for _, bad := range []string{"lots", "-5MB", "GB"} {
+		if _, err := ParseByteSize(bad); err == nil {
+			t.Fatalf("ParseByteSize(%q) accepted", bad)
+		}
+	}
+}
+
+func TestCycleStart(t *testing.T) {
+	day := func(s string) time.Time { d, _ := time.Parse("2006-01-02", s); return d }
+	if got := cycleStart(day("2026-03-20"), 15).Format("2006-01-02"); got != "2026-03-15" {
+		t.Fatalf("after cycle day: %s", got)
+	}
+	if got := cycleStart(day("2026-01-10"), 15).Format("2006-01-02"); got != "2025-12-15" {
+		t.Fatalf("before cycle day: %s", got)
+	}
+}
+
+func resetUsage(t *testing.T) {
+	prevNow, prevTag := usageNow, runTag
+	t.Cleanup(func() {
+		usageNow, runTag = prevNow, prevTag
+		usageTotals, usageBatchTag, usageBatchRx, usageBatchTx, usageStatePath = usageState{}, "", 0, 0, ""
+		monthlyBudgetBytes, budgetNearPct, budgetPolicy, budgetCycleDay = 0, 10, BudgetPolicyReduce, 1
+		budgetDecided, budgetRunTag, budgetAction = false, "", ""
+	})
+	usageTotals, usageBatchTag, usageBatchRx, usageBatchTx = usageState{}, "", 0, 0
+	budgetDecided = false
+}
+
+func TestBudgetAction(t *testing.T) {
+	resetUsage(t)
+	usageNow = func() time.Time { return time.Date(2026, 5, 20, 12, 0, 0, 0, time.Local) }
+	if err := SetMonthlyBudget(1000, 10, BudgetPolicyReduce); err != nil {
+		t.Fatalf("budget: %v", err)
+	}
+	if a, _ := BudgetAction("b1"); a != "" {
+		t.Fatalf("fresh cycle: %q", a)
+	}
+	accountUsage("b1", &SiteResult{WireRxBytes: 850, WireTxBytes: 50})
+	if a, _ := BudgetAction("b1"); a != "" {
+		t.Fatalf("decision changed within the batch: %q", a)
+	}
+	if a, du := BudgetAction("b2"); a != BudgetPolicyReduce || du.CycleRxBytes != 850 || du.BatchRxBytes != 0 {
+		t.Fatalf("near budget: %q %+v", a, du)
+	}
+	accountUsage("b2", &SiteResult{WireRxBytes: 200})
+	if a, _ := BudgetAction("b3"); a != BudgetPolicySkip {
+		t.Fatalf("over budget: %q", a)
+	}
+	// a new billing cycle starts from zero, the day total as well
+	usageNow = func() time.Time { return time.Date(2026, 6, 2, 8, 0, 0, 0, time.Local) }
+	if a, du := BudgetAction("b4"); a != "" || du.CycleRxBytes != 0 || du.DayRxBytes != 0 || du.Cycle != "2026-06-01" {
+		t.Fatalf("new cycle: %q %+v", a, du)
+	}
+	if SetMonthlyBudget(1000, 10, "throttle") == nil {
+		t.Fatal("unknown policy accepted")
+	}
+}
+
+func TestDataUsage_CountsWireBytesAndPersists(t *testing.T) {
+	resetUsage(t)
+	body := strings.Repeat("x", 200*1024)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	}))
+	defer srv.Close()
+	u, _ := url.Parse(srv.URL)
+	for _, k := range []string{"HTTP_PROXY", "HTTPS_PROXY", "ALL_PROXY", "NO_PROXY"} {
+		if v, ok := os.LookupEnv(k); ok {
+			t.Setenv(k, v)
+			os.Unsetenv(k)
+		}
+	}
+	tmp := t.TempDir() + "/res.jsonl"
+	resultChan = nil
+	resultPath = tmp
+	if err := LoadUsageState(tmp); err != nil {
+		t.Fatalf("load missing state: %v", err)
+	}
+	SetRunTag("usage_1")
+	MonitorSiteIP(typespkg.Site{Name: "dl", URL: srv.URL + "/dl"}, u.Hostname(), []string{u.Hostname()}, 0)
+	data, _ := os.ReadFile(tmp)
+	var env ResultEnvelope
+	if err := json.Unmarshal([]byte(strings.TrimSpace(string(data))), &env); err != nil || env.SiteResult == nil {
+		t.Fatalf("decode result: %v", err)
+	}
+	sr := env.SiteResult
+	if sr.WireRxBytes < int64(len(body)) || sr.WireTxBytes == 0 {
+		t.Fatalf("wire bytes: rx=%d tx=%d (body %d)", sr.WireRxBytes, sr.WireTxBytes, len(body))
+	}
+	du := env.Meta.DataUsage
+	if du == nil || du.BatchRxBytes != sr.WireRxBytes || du.DayRxBytes != sr.WireRxBytes || du.BudgetBytes != 0 {
+		t.Fatalf("meta.data_usage: %+v", du)
+	}
+
+	if err := SaveUsageState(); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	usageTotals = usageState{}
+	if err := LoadUsageState(tmp); err != nil || usageTotals.CycleRxBytes != sr.WireRxBytes {
+		t.Fatalf("reload: %v %+v", err, usageTotals)
+	}
+}
+
+func TestWireUsage_KeepsPacketConn(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer pc.Close()
+	u := &wireUsage{}
+	c, err := u.dial((&net.Dialer{}).DialContext)(context.Background(), "udp", pc.LocalAddr().String())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer c.Close()
+	// the Go resolver picks datagram framing by this assertion
+	if _, ok := c.(net.PacketConn); !ok {
+		t.Fatal("counted UDP connection is no longer a net.PacketConn")
+	}
+	c.Write([]byte("query"))
+	if u.tx.Load() != 5 {
+		t.Fatalf("tx=%d", u.tx.Load())
+	}
+}
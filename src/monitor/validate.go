@@ -0,0 +1,311 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/iafilius/InternetQualityMonitor/src/types"
+)
+
+// Readiness check outcomes. Only a fail makes --validate exit non-zero; a warn is something
+// worth knowing that does not stop collection.
+const (
+	ReadinessOK   = "ok"
+	ReadinessWarn = "warn"
+	ReadinessFail = "fail"
+)
+
+// ReadinessCheck is one line of the --validate report.
+type ReadinessCheck struct {
+	Name   string
+	Status string
+	Detail string
+}
+
+// ReadinessReport is what --validate found: the checks in the order they ran.
+type ReadinessReport struct {
+	Checks []ReadinessCheck
+}
+
+func (r *ReadinessReport) add(name, status, format string, args ...any) {
+	r.Checks = append(r.Checks, ReadinessCheck{Name: name, Status: status, Detail: fmt.Sprintf(format, args...)})
+}
+
+// Count returns the number of checks with status.
+func (r *ReadinessReport) Count(status string) int {
+	n := 0
+	for _, c := range r.Checks {
+		if c.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// OK reports whether no check failed.
+func (r *ReadinessReport) OK() bool { return r.Count(ReadinessFail) == 0 }
+
+func (r *ReadinessReport) String() string {
+	var b strings.Builder
+	for _, c := range r.Checks {
+		fmt.Fprintf(&b, "[validate] %-4s %s: %s\n", strings.ToUpper(c.Status), c.Name, c.Detail)
+	}
+	fmt.Fprintf(&b, "[validate] %d checks: %d ok, %d warn, %d fail\n", len(r.Checks), r.Count(ReadinessOK), r.Count(ReadinessWarn), r.Count(ReadinessFail))
+	return b.String()
+}
+
+const validateDialTimeout = 3 * time.Second
+
+var (
+	// seams for tests
+	validateDial = func(ctx context.Context, addr string) error {
+		d := &net.Dialer{Timeout: validateDialTimeout}
+		c, err := d.DialContext(ctx, "tcp", addr)
+		if err == nil {
+			c.Close()
+		}
+		return err
+	}
+	validateLookPath   = exec.LookPath
+	validateListenICMP = func() error {
+		c, err := net.ListenPacket("ip4:icmp", "0.0.0.0")
+		if err == nil {
+			c.Close()
+		}
+		return err
+	}
+)
+
+// ValidateSetup checks what a collection run over sites needs without transferring anything:
+// every host resolves, the effective proxies (if any) parse and accepts connections, outPath
+// is writable, the collector, OTLP and InfluxDB endpoints are reachable, and the external tools
+// and privileges the enabled options rely on are present.
+func ValidateSetup(ctx context.Context, sites []types.Site, outPath string) *ReadinessReport {
+	r := &ReadinessReport{}
+	validateSites(ctx, r, sites)
+	validateProxies(ctx, r, sites)
+	validateOutput(r, outPath)
+	validateEndpoint(ctx, r, "agent push", agentPushURL)
+	validateEndpoint(ctx, r, "otlp", otlpEndpoint)
+	validateEndpoint(ctx, r, "influx", influxURL)
+	validateTools(r, sites)
+	return r
+}
+
+func validateSites(ctx context.Context, r *ReadinessReport, sites []types.Site) {
+	if len(sites) == 0 {
+		r.add("sites", ReadinessFail, "no sites configured")
+		return
+	}
+	byProbe := map[string]int{}
+	for _, s := range sites {
+		probe := strings.ToLower(strings.TrimSpace(s.Probe))
+		if probe == "" {
+			probe = ProbeHTTP
+		}
+		byProbe[probe]++
+	}
+	var parts []string
+	for _, p := range ProbeTypes() {
+		if n := byProbe[p]; n > 0 {
+			parts = append(parts, fmt.Sprintf("%s=%d", p, n))
+		}
+	}
+	r.add("sites", ReadinessOK, "%d configured (%s)", len(sites), strings.Join(parts, " "))
+	for _, s := range sites {
+		name := "resolve " + s.Name
+		if _, ok := LookupMeasurer(s.Probe); !ok {
+			r.add(name, ReadinessFail, "unknown probe %q", s.Probe)
+			continue
+		}
+		host, ips, took, err := resolveSite(ctx, s)
+		if err != nil {
+			r.add(name, ReadinessFail, "%v", err)
+			continue
+		}
+		if net.ParseIP(host) != nil {
+			r.add(name, ReadinessOK, "%s is an IP literal", host)
+			continue
+		}
+		v4, v6 := 0, 0
+		for _, ip := range ips {
+			if strings.Contains(ip, ":") {
+				v6++
+			} else {
+				v4++
+			}
+		}
+		r.add(name, ReadinessOK, "%s: %d IPv4, %d IPv6 in %dms", host, v4, v6, took.Milliseconds())
+	}
+}
+
+// validateProxies reports the proxy the HTTP sites would use, once per proxy. Site overrides,
+// bypass rules, the PAC and NO_PROXY are honoured the same way the HTTP probe honours them.
+func validateProxies(ctx context.Context, r *ReadinessReport, sites []types.Site) {
+	users := map[string]int{}
+	var order []string
+	direct := 0
+	for _, s := range sites {
+		if probe := strings.ToLower(strings.TrimSpace(s.Probe)); probe != "" && probe != ProbeHTTP {
+			continue
+		}
+		u, err := url.Parse(s.URL)
+		if err != nil || u.Host == "" {
+			continue
+		}
+		pu, _, _, err := effectiveProxy(s, u, nil)
+		if err != nil {
+			r.add("proxy", ReadinessFail, "invalid proxy setting for %s: %v", s.Name, err)
+			continue
+		}
+		if pu == nil {
+			direct++
+			continue
+		}
+		key := pu.Redacted()
+		if users[key] == 0 {
+			order = append(order, key)
+		}
+		users[key]++
+	}
+	if len(order) == 0 {
+		r.add("proxy", ReadinessOK, "none (direct connections)")
+		return
+	}
+	for _, p := range order {
+		pu, _ := url.Parse(p)
+		addr := pu.Host
+		if pu.Port() == "" {
+			addr = net.JoinHostPort(pu.Hostname(), defaultProxyPort(pu.Scheme))
+		}
+		if err := validateDial(ctx, addr); err != nil {
+			r.add("proxy", ReadinessFail, "%s (used by %d sites) unreachable: %v", p, users[p], err)
+			continue
+		}
+		r.add("proxy", ReadinessOK, "%s used by %d sites, %d direct", p, users[p], direct)
+	}
+}
+
+func defaultProxyPort(scheme string) string {
+	switch strings.ToLower(scheme) {
+	case "https":
+		return "443"
+	case "socks5", "socks5h":
+		return "1080"
+	}
+	return "80"
+}
+
+// validateOutput checks that outPath can be appended to, without truncating an existing file
+// and without leaving a new empty one behind.
+func validateOutput(r *ReadinessReport, outPath string) {
+	if strings.TrimSpace(outPath) == "" {
+		r.add("output", ReadinessFail, "no output file")
+		return
+	}
+	_, statErr := os.Stat(outPath)
+	existed := statErr == nil
+	if err := os.MkdirAll(filepath.Dir(outPath), 0o755); err != nil {
+		r.add("output", ReadinessFail, "%v", err)
+		return
+	}
+	f, err := os.OpenFile(outPath, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		r.add("output", ReadinessFail, "%v", err)
+		return
+	}
+	f.Close()
+	if !existed {
+		os.Remove(outPath)
+		r.add("output", ReadinessOK, "%s writable (will be created)", outPath)
+		return
+	}
+	r.add("output", ReadinessOK, "%s writable (appending)", outPath)
+}
+
+// validateEndpoint checks that a configured push endpoint accepts TCP connections.
+func validateEndpoint(ctx context.Context, r *ReadinessReport, name, raw string) {
+	if raw == "" {
+		return
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Hostname() == "" {
+		r.add(name, ReadinessFail, "invalid URL %q", raw)
+		return
+	}
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if strings.EqualFold(u.Scheme, "https") {
+			port = "443"
+		}
+	}
+	if err := validateDial(ctx, net.JoinHostPort(u.Hostname(), port)); err != nil {
+		r.add(name, ReadinessFail, "%s unreachable: %v", u.Redacted(), err)
+		return
+	}
+	r.add(name, ReadinessOK, "%s reachable", u.Redacted())
+}
+
+// validateTools checks the binaries and privileges behind the optional measurements and the
+// iperf3 probe. The ping probe times TCP connects, so raw ICMP sockets are reported for
+// information only.
+func validateTools(r *ReadinessReport, sites []types.Site) {
+	if routeTraceEnabled {
+		v4Tool, _ := traceCommand(false, routeTraceMaxHops)
+		v6Tool, _ := traceCommand(true, routeTraceMaxHops)
+		tools := []string{v4Tool}
+		if v6Tool != v4Tool {
+			tools = append(tools, v6Tool)
+		}
+		for _, tool := range tools {
+			if p, err := validateLookPath(tool); err != nil {
+				r.add("route trace", ReadinessFail, "%s not found in PATH", tool)
+			} else {
+				r.add("route trace", ReadinessOK, "%s at %s", tool, p)
+			}
+		}
+	}
+	if responseTTLEnabled {
+		v4Tool, _ := echoPingCommand(false)
+		v6Tool, _ := echoPingCommand(true)
+		tools := []string{v4Tool}
+		if v6Tool != v4Tool {
+			tools = append(tools, v6Tool)
+		}
+		for _, tool := range tools {
+			if p, err := validateLookPath(tool); err != nil {
+				r.add("response ttl", ReadinessFail, "%s not found in PATH", tool)
+			} else {
+				r.add("response ttl", ReadinessOK, "%s at %s", tool, p)
+			}
+		}
+	}
+	for _, s := range sites {
+		if strings.EqualFold(strings.TrimSpace(s.Probe), ProbeIPerf3) {
+			if p, err := validateLookPath("iperf3"); err != nil {
+				r.add("iperf3", ReadinessFail, "iperf3 not found in PATH (needed by %s)", s.Name)
+			} else {
+				r.add("iperf3", ReadinessOK, "iperf3 at %s", p)
+			}
+			break
+		}
+	}
+	if err := validateListenICMP(); err != nil {
+		detail := err.Error()
+		if errors.Is(err, os.ErrPermission) {
+			detail = "not permitted"
+		}
+		r.add("icmp", ReadinessWarn, "raw ICMP sockets unavailable (%s); not needed, the ping probe uses TCP connects", detail)
+		return
+	}
+	r.add("icmp", ReadinessOK, "raw ICMP sockets permitted")
+}
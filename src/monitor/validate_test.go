@@ -0,0 +1,63 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/iafilius/InternetQualityMonitor/src/types"
+)
+
+func TestValidateSetup(t *testing.T) {
+	oldDial, oldProxy, oldLook, oldICMP, oldTrace := validateDial, envProxyFor, validateLookPath, validateListenICMP, routeTraceEnabled
+	defer func() {
+		validateDial, envProxyFor, validateLookPath, validateListenICMP, routeTraceEnabled = oldDial, oldProxy, oldLook, oldICMP, oldTrace
+	}()
+	envProxyFor = func(*http.Request) (*url.URL, error) { return nil, nil }
+	validateDial = func(ctx context.Context, addr string) error { return nil }
+	validateLookPath = func(string) (string, error) { return "", errors.New("not found") }
+	validateListenICMP = func() error { return os.ErrPermission }
+	routeTraceEnabled = false
+
+	out := filepath.Join(t.TempDir(), "sub", "results.jsonl")
+	sites := []types.Site{
+		{Name: "lit", URL: "https://127.0.0.1/file"},
+		{Name: "ping", URL: "tcp://[::1]:22", Probe: "ping"},
+	}
+	rep := ValidateSetup(context.Background(), sites, out)
+	if !rep.OK() {
+		t.Fatalf("expected ready, got:\n%s", rep)
+	}
+	if _, err := os.Stat(out); !os.IsNotExist(err) {
+		t.Fatalf("validate left the output file behind: %v", err)
+	}
+	if rep.Count(ReadinessWarn) != 1 || !strings.Contains(rep.String(), "WARN icmp") {
+		t.Fatalf("raw ICMP should only warn:\n%s", rep)
+	}
+
+	// unknown probe, missing tracer and iperf3, and an unreachable proxy fail
+	routeTraceEnabled = true
+	envProxyFor = func(*http.Request) (*url.URL, error) { return url.Parse("http://proxy.invalid:3128") }
+	validateDial = func(ctx context.Context, addr string) error {
+		if addr != "proxy.invalid:3128" {
+			t.Errorf("dialed %s", addr)
+		}
+		return errors.New("connection refused")
+	}
+	sites = append(sites, types.Site{Name: "bad", URL: "https://127.0.0.1/", Probe: "nope"}, types.Site{Name: "capacity", URL: "127.0.0.1:5201", Probe: "iperf3"})
+	rep = ValidateSetup(context.Background(), sites, out)
+	if rep.OK() {
+		t.Fatalf("expected failures, got:\n%s", rep)
+	}
+	text := rep.String()
+	for _, want := range []string{"FAIL resolve bad: unknown probe", "FAIL proxy: http://proxy.invalid:3128 (used by 1 sites) unreachable", "FAIL route trace", "FAIL iperf3: iperf3 not found in PATH (needed by capacity)"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("report lacks %q:\n%s", want, text)
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package monitor
+
+import (
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// VPNInfo is the per-batch VPN/tunnel detection result. Active is set when a tunnel interface
+// carries the default route, when an up tunnel interface has a routable address (split tunnel),
+// or when the resolver search domains match a known/configured VPN DNS suffix. Signals lists the
+// evidence so false positives (e.g. idle macOS utun interfaces) can be judged afterwards.
+type VPNInfo struct {
+	Active              bool     `json:"active"`
+	Name                string   `json:"name,omitempty"`      // e.g. WireGuard, Tailscale, Cisco AnyConnect, "tunnel (utun4)"
+	Interface           string   `json:"interface,omitempty"` // tunnel interface that decided Name
+	DefaultRouteTunnel  bool     `json:"default_route_tunnel,omitempty"`
+	DefaultRouteChanged bool     `json:"default_route_changed,omitempty"` // default interface differs from the previous batch
+	PrevDefaultIface    string   `json:"prev_default_iface,omitempty"`
+	DNSSuffix           string   `json:"dns_suffix,omitempty"` // matching resolver search domain
+	Signals             []string `json:"signals,omitempty"`
+}
+
+// vpnIface is the subset of interface state the detection needs (kept separate for tests).
+type vpnIface struct {
+	Name     string
+	Up       bool
+	Routable bool // has an IPv4 or global IPv6 address (not only link-local)
+	CGNAT    bool // has an address in 100.64.0.0/10 (Tailscale and other overlay networks)
+}
+
+// vpnIfacePrefixes maps interface name prefixes to a VPN name ("" = generic tunnel).
+var vpnIfacePrefixes = []struct{ prefix, name string }{
+	{"tailscale", "Tailscale"},
+	{"wg", "WireGuard"},
+	{"nordlynx", "NordVPN"},
+	{"proton", "ProtonVPN"},
+	{"cscotun", "Cisco AnyConnect"},
+	{"gpd", "GlobalProtect"},
+	{"zt", "ZeroTier"},
+	{"ipsec", "IPsec"},
+	{"ppp", "PPP"},
+	{"utun", ""},
+	{"tun", ""},
+	{"tap", ""},
+}
+
+// knownVPNDNSSuffixes are resolver search domains that VPN clients install.
+var knownVPNDNSSuffixes = map[string]string{
+	"ts.net":        "Tailscale",
+	"tailscale.net": "Tailscale",
+	"zerotier.net":  "ZeroTier",
+}
+
+var (
+	vpnMu          sync.Mutex
+	vpnProbed      bool
+	vpnRunTag      string
+	vpnCached      *VPNInfo
+	vpnLastDefault string
+	vpnDNSSuffixes []string
+	vpnProbe       = probeVPNInfo // replaceable in tests
+)
+
+// SetVPNDNSSuffixes adds resolver search domains (e.g. corp.example.com) that indicate a VPN.
+func SetVPNDNSSuffixes(suffixes []string) {
+	vpnMu.Lock()
+	defer vpnMu.Unlock()
+	vpnDNSSuffixes = vpnDNSSuffixes[:0]
+	for _, s := range suffixes {
+		if s = strings.Trim(strings.ToLower(strings.TrimSpace(s)), "."); s != "" {
+			vpnDNSSuffixes = append(vpnDNSSuffixes, s)
+		}
+	}
+}
+
+// vpnInfoForRun returns the VPN state captured once per run tag (batch), like wifiInfoForRun.
+func vpnInfoForRun(tag string) *VPNInfo {
+	vpnMu.Lock()
+	defer vpnMu.Unlock()
+	if vpnProbed && vpnRunTag == tag {
+		return vpnCached
+	}
+	vpnProbed = true
+	vpnRunTag = tag
+	vpnCached = vpnProbe()
+	return vpnCached
+}
+
+// probeVPNInfo gathers interfaces, the default interface and resolver search domains, then
+// classifies them. Callers hold vpnMu.
+func probeVPNInfo() *VPNInfo {
+	var ifs []vpnIface
+	if list, err := net.Interfaces(); err == nil {
+		for _, ifc := range list {
+			vi := vpnIface{Name: ifc.Name, Up: ifc.Flags&net.FlagUp != 0}
+			addrs, _ := ifc.Addrs()
+			for _, a := range addrs {
+				ipn, ok := a.(*net.IPNet)
+				if !ok {
+					continue
+				}
+				if ip4 := ipn.IP.To4(); ip4 != nil {
+					vi.Routable = true
+					if ip4[0] == 100 && ip4[1]&0xc0 == 64 {
+						vi.CGNAT = true
+					}
+				} else if ipn.IP.IsGlobalUnicast() {
+					vi.Routable = true
+				}
+			}
+			ifs = append(ifs, vi)
+		}
+	}
+	def, _ := getDefaultInterface()
+	info := detectVPN(ifs, def, vpnLastDefault, resolverSearchDomains(), vpnDNSSuffixes)
+	if def != "" {
+		vpnLastDefault = def
+	}
+	return info
+}
+
+// detectVPN classifies interface/route/DNS state. It always returns a value so default-route
+// changes are recorded even when no VPN is seen.
+func detectVPN(ifs []vpnIface, defIface, prevDefault string, searchDomains, extraSuffixes []string) *VPNInfo {
+	info := &VPNInfo{}
+	if prevDefault != "" && defIface != "" && prevDefault != defIface {
+		info.DefaultRouteChanged = true
+		info.PrevDefaultIface = prevDefault
+		info.Signals = append(info.Signals, "default route moved "+prevDefault+" -> "+defIface)
+	}
+	type cand struct {
+		iface, name string
+		score       int
+	}
+	var best *cand
+	for _, ifc := range ifs {
+		if !ifc.Up {
+			continue
+		}
+		name, ok := tunnelName(ifc)
+		if !ok {
+			continue
+		}
+		score := 0
+		switch {
+		case ifc.Name == defIface:
+			score = 3
+			info.DefaultRouteTunnel = true
+			info.Signals = append(info.Signals, "default route via "+ifc.Name)
+		case ifc.Routable:
+			score = 2
+			info.Signals = append(info.Signals, "tunnel up: "+ifc.Name)
+		default:
+			continue // e.g. macOS system utun interfaces with only link-local addresses
+		}
+		if name != "" {
+			score++ // a recognised client beats a generic tunnel
+		} else {
+			name = "tunnel (" + ifc.Name + ")"
+		}
+		if best == nil || score > best.score {
+			best = &cand{iface: ifc.Name, name: name, score: score}
+		}
+	}
+	if best != nil {
+		info.Active = true
+		info.Name = best.name
+		info.Interface = best.iface
+	}
+	suffixes := map[string]string{}
+	for k, v := range knownVPNDNSSuffixes {
+		suffixes[k] = v
+	}
+	for _, s := range extraSuffixes {
+		suffixes[s] = ""
+	}
+	keys := make([]string, 0, len(suffixes))
+	for k := range suffixes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, d := range searchDomains {
+		d = strings.Trim(strings.ToLower(d), ".")
+		for _, s := range keys {
+			if d != s && !strings.HasSuffix(d, "."+s) {
+				continue
+			}
+			info.DNSSuffix = d
+			info.Signals = append(info.Signals, "dns search domain "+d)
+			info.Active = true
+			if info.Name == "" || (strings.HasPrefix(info.Name, "tunnel (") && suffixes[s] != "") {
+				if info.Name = suffixes[s]; info.Name == "" {
+					info.Name = "VPN (" + d + ")"
+				}
+			}
+			break
+		}
+		if info.DNSSuffix != "" {
+			break
+		}
+	}
+	return info
+}
+
+// tunnelName reports whether ifc looks like a VPN tunnel and the client name when recognisable.
+func tunnelName(ifc vpnIface) (string, bool) {
+	n := strings.ToLower(ifc.Name)
+	for _, p := range vpnIfacePrefixes {
+		if strings.HasPrefix(n, p.prefix) {
+			if p.name == "" && ifc.CGNAT {
+				return "Tailscale", true // Tailscale on macOS uses a utun interface with a 100.64/10 address
+			}
+			return p.name, true
+		}
+	}
+	if strings.Contains(n, "vpn") {
+		return "", true
+	}
+	return "", false
+}
+
+// resolverSearchDomains reads search/domain entries from /etc/resolv.conf (Linux, macOS).
+func resolverSearchDomains() []string {
+	b, err := os.ReadFile("/etc/resolv.conf")
+	if err != nil {
+		return nil
+	}
+	return parseResolvSearch(string(b))
+}
+
+func parseResolvSearch(conf string) []string {
+	var out []string
+	for _, ln := range strings.Split(conf, "\n") {
+		f := strings.Fields(ln)
+		if len(f) < 2 || (f[0] != "search" && f[0] != "domain") {
+			continue
+		}
+		out = append(out, f[1:]...)
+	}
+	return out
+}
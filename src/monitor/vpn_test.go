@@ -0,0 +1,45 @@
+package monitor
+
+import "testing"
+
+func TestDetectVPN_DefaultRouteTunnel(t *testing.T) {
+	ifs := []vpnIface{
+		{Name: "en0", Up: true, Routable: true},
+		{Name: "utun0", Up: true},                 // macOS system tunnel: link-local only
+		{Name: "utun4", Up: true, Routable: true}, // split tunnel of some client
+		{Name: "wg0", Up: true, Routable: true},
+	}
+	vi := detectVPN(ifs, "wg0", "en0", nil, nil)
+	if !vi.Active || vi.Name != "WireGuard" || vi.Interface != "wg0" || !vi.DefaultRouteTunnel {
+		t.Fatalf("unexpected: %+v", vi)
+	}
+	if !vi.DefaultRouteChanged || vi.PrevDefaultIface != "en0" {
+		t.Fatalf("default route change not recorded: %+v", vi)
+	}
+	if len(vi.Signals) != 3 {
+		t.Fatalf("signals=%v", vi.Signals)
+	}
+}
+
+func TestDetectVPN_IdleSystemTunnelsAndCGNAT(t *testing.T) {
+	idle := detectVPN([]vpnIface{{Name: "en0", Up: true, Routable: true}, {Name: "utun0", Up: true}, {Name: "utun1", Up: true}}, "en0", "en0", nil, nil)
+	if idle.Active || len(idle.Signals) != 0 {
+		t.Fatalf("link-local utun must not count: %+v", idle)
+	}
+	ts := detectVPN([]vpnIface{{Name: "en0", Up: true, Routable: true}, {Name: "utun3", Up: true, Routable: true, CGNAT: true}}, "en0", "", nil, nil)
+	if !ts.Active || ts.Name != "Tailscale" || ts.DefaultRouteTunnel {
+		t.Fatalf("expected split-tunnel Tailscale: %+v", ts)
+	}
+}
+
+func TestDetectVPN_DNSSuffix(t *testing.T) {
+	search := parseResolvSearch("# generated\nnameserver 10.0.0.1\nsearch vpn.corp.example.com. home.lan\n")
+	vi := detectVPN([]vpnIface{{Name: "eth0", Up: true, Routable: true}}, "eth0", "", search, []string{"corp.example.com"})
+	if !vi.Active || vi.DNSSuffix != "vpn.corp.example.com" || vi.Name != "VPN (vpn.corp.example.com)" {
+		t.Fatalf("unexpected: %+v", vi)
+	}
+	ts := detectVPN(nil, "", "", []string{"tail1234.ts.net"}, nil)
+	if !ts.Active || ts.Name != "Tailscale" {
+		t.Fatalf("known suffix: %+v", ts)
+	}
+}
@@ -0,0 +1,225 @@
+package monitor
+
+import (
+	"context"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WiFiInfo captures best-effort local Wi-Fi link details for the batch, so throughput dips can be
+// attributed to the radio link (weak signal, low PHY rate, roaming) rather than the ISP.
+type WiFiInfo struct {
+	SSID         string  `json:"ssid,omitempty"`
+	BSSID        string  `json:"bssid,omitempty"`
+	RSSIDBm      int     `json:"rssi_dbm,omitempty"`
+	NoiseDBm     int     `json:"noise_dbm,omitempty"`
+	Channel      int     `json:"channel,omitempty"`
+	FrequencyMHz int     `json:"frequency_mhz,omitempty"`
+	PHYRateMbps  float64 `json:"phy_rate_mbps,omitempty"` // negotiated tx rate when available, else rx rate
+	Interface    string  `json:"interface,omitempty"`
+	Source       string  `json:"source,omitempty"` // airport, iw, netsh
+}
+
+var (
+	wifiMu     sync.Mutex
+	wifiProbed bool
+	wifiRunTag string
+	wifiCached *WiFiInfo
+	wifiProbe  = probeWiFiInfo // replaceable in tests
+)
+
+// wifiInfoForRun returns Wi-Fi details captured once per run tag (batch). The probe shells out to
+// platform tools, so we avoid repeating it for every line of the same batch.
+func wifiInfoForRun(tag string) *WiFiInfo {
+	wifiMu.Lock()
+	defer wifiMu.Unlock()
+	if wifiProbed && wifiRunTag == tag {
+		return wifiCached
+	}
+	wifiProbed = true
+	wifiRunTag = tag
+	wifiCached = wifiProbe()
+	return wifiCached
+}
+
+// probeWiFiInfo queries the platform Wi-Fi tooling. Returns nil when no Wi-Fi link is detected.
+func probeWiFiInfo() *WiFiInfo {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	switch runtime.GOOS {
+	case "darwin":
+		out, err := exec.CommandContext(ctx, "/System/Library/PrivateFrameworks/Apple80211.framework/Versions/Current/Resources/airport", "-I").CombinedOutput()
+		if err != nil {
+			return nil
+		}
+		return parseAirportInfo(string(out))
+	case "linux":
+		iface, _ := getDefaultInterface()
+		if iface == "" {
+			return nil
+		}
+		out, err := exec.CommandContext(ctx, "iw", "dev", iface, "link").CombinedOutput()
+		if err != nil {
+			return nil
+		}
+		wi := parseIWLink(string(out))
+		if wi != nil {
+			wi.Interface = iface
+		}
+		return wi
+	case "windows":
+		out, err := exec.CommandContext(ctx, "netsh", "wlan", "show", "interfaces").CombinedOutput()
+		if err != nil {
+			return nil
+		}
+		return parseNetshWLAN(string(out))
+	default:
+		return nil
+	}
+}
+
+// parseAirportInfo parses `airport -I` output (key: value lines).
+func parseAirportInfo(out string) *WiFiInfo {
+	wi := &WiFiInfo{Source: "airport"}
+	for _, ln := range strings.Split(out, "\n") {
+		k, v, ok := strings.Cut(strings.TrimSpace(ln), ":")
+		if !ok {
+			continue
+		}
+		v = strings.TrimSpace(v)
+		switch strings.TrimSpace(k) {
+		case "agrCtlRSSI":
+			wi.RSSIDBm, _ = strconv.Atoi(v)
+		case "agrCtlNoise":
+			wi.NoiseDBm, _ = strconv.Atoi(v)
+		case "lastTxRate":
+			wi.PHYRateMbps, _ = strconv.ParseFloat(v, 64)
+		case "BSSID":
+			wi.BSSID = v
+		case "SSID":
+			wi.SSID = v
+		case "channel":
+			// e.g. "149,80" (primary channel, width)
+			ch, _, _ := strings.Cut(v, ",")
+			wi.Channel, _ = strconv.Atoi(strings.TrimSpace(ch))
+		}
+	}
+	if wi.SSID == "" && wi.RSSIDBm == 0 {
+		return nil
+	}
+	return wi
+}
+
+// parseIWLink parses `iw dev <iface> link` output.
+func parseIWLink(out string) *WiFiInfo {
+	if strings.HasPrefix(strings.TrimSpace(out), "Not connected") {
+		return nil
+	}
+	wi := &WiFiInfo{Source: "iw"}
+	var rxRate float64
+	for _, ln := range strings.Split(out, "\n") {
+		ln = strings.TrimSpace(ln)
+		if strings.HasPrefix(ln, "Connected to ") {
+			if f := strings.Fields(ln); len(f) >= 3 {
+				wi.BSSID = f[2]
+			}
+			continue
+		}
+		k, v, ok := strings.Cut(ln, ":")
+		if !ok {
+			continue
+		}
+		v = strings.TrimSpace(v)
+		switch k {
+		case "SSID":
+			wi.SSID = v
+		case "freq":
+			f, _ := strconv.ParseFloat(strings.Fields(v + " ")[0], 64)
+			wi.FrequencyMHz = int(f)
+			wi.Channel = channelFromFrequency(wi.FrequencyMHz)
+		case "signal":
+			// "-52 dBm"
+			if f := strings.Fields(v); len(f) > 0 {
+				wi.RSSIDBm, _ = strconv.Atoi(f[0])
+			}
+		case "tx bitrate":
+			if f := strings.Fields(v); len(f) > 0 {
+				wi.PHYRateMbps, _ = strconv.ParseFloat(f[0], 64)
+			}
+		case "rx bitrate":
+			if f := strings.Fields(v); len(f) > 0 {
+				rxRate, _ = strconv.ParseFloat(f[0], 64)
+			}
+		}
+	}
+	if wi.PHYRateMbps == 0 {
+		wi.PHYRateMbps = rxRate
+	}
+	if wi.BSSID == "" && wi.SSID == "" {
+		return nil
+	}
+	return wi
+}
+
+// parseNetshWLAN parses `netsh wlan show interfaces` output. Windows reports signal quality as a
+// percentage; it is converted to an approximate dBm (quality/2 - 100) for comparability.
+func parseNetshWLAN(out string) *WiFiInfo {
+	wi := &WiFiInfo{Source: "netsh"}
+	var rxRate float64
+	connected := false
+	for _, ln := range strings.Split(out, "\n") {
+		k, v, ok := strings.Cut(strings.TrimSpace(ln), ":")
+		if !ok {
+			continue
+		}
+		k = strings.TrimSpace(k)
+		v = strings.TrimSpace(v)
+		switch k {
+		case "Name":
+			wi.Interface = v
+		case "State":
+			connected = strings.EqualFold(v, "connected")
+		case "SSID":
+			wi.SSID = v
+		case "BSSID", "AP BSSID":
+			wi.BSSID = v
+		case "Signal":
+			if pct, err := strconv.Atoi(strings.TrimSuffix(v, "%")); err == nil {
+				wi.RSSIDBm = pct/2 - 100
+			}
+		case "Channel":
+			wi.Channel, _ = strconv.Atoi(v)
+		case "Transmit rate (Mbps)":
+			wi.PHYRateMbps, _ = strconv.ParseFloat(v, 64)
+		case "Receive rate (Mbps)":
+			rxRate, _ = strconv.ParseFloat(v, 64)
+		}
+	}
+	if !connected {
+		return nil
+	}
+	if wi.PHYRateMbps == 0 {
+		wi.PHYRateMbps = rxRate
+	}
+	return wi
+}
+
+// channelFromFrequency maps a 2.4/5/6 GHz centre frequency (MHz) to its IEEE channel number.
+func channelFromFrequency(mhz int) int {
+	switch {
+	case mhz == 2484:
+		return 14
+	case mhz >= 2412 && mhz < 2484:
+		return (mhz - 2407) / 5
+	case mhz >= 5955 && mhz <= 7115: // 6 GHz
+		return (mhz - 5950) / 5
+	case mhz >= 5000 && mhz < 5955:
+		return (mhz - 5000) / 5
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,68 @@
+package monitor
+
+import "testing"
+
+func TestParseIWLink(t *testing.T) {
+	out := `Connected to aa:bb:cc:dd:ee:ff (on wlan0)
+	SSID: HomeNet
+	freq: 5180
+	RX: 123456 bytes (789 packets)
+	signal: -52 dBm
+	rx bitrate: 433.3 MBit/s VHT-MCS 9 80MHz VHT-NSS 1
+	tx bitrate: 390.0 MBit/s VHT-MCS 8 80MHz VHT-NSS 1
+`
+	wi := parseIWLink(out)
+	if wi == nil {
+		t.Fatalf("expected info")
+	}
+	if wi.SSID != "HomeNet" || wi.BSSID != "aa:bb:cc:dd:ee:ff" || wi.RSSIDBm != -52 || wi.Channel != 36 || wi.PHYRateMbps != 390 {
+		t.Fatalf("unexpected parse: %+v", wi)
+	}
+	if parseIWLink("Not connected.\n") != nil {
+		t.Fatalf("not connected should yield nil")
+	}
+}
+
+func TestParseAirportInfo(t *testing.T) {
+	out := `     agrCtlRSSI: -61
+     agrExtRSSI: 0
+    agrCtlNoise: -92
+          state: running
+     lastTxRate: 585
+          BSSID: 11:22:33:44:55:66
+           SSID: Office
+        channel: 149,80
+`
+	wi := parseAirportInfo(out)
+	if wi == nil || wi.RSSIDBm != -61 || wi.NoiseDBm != -92 || wi.PHYRateMbps != 585 || wi.Channel != 149 || wi.SSID != "Office" || wi.BSSID != "11:22:33:44:55:66" {
+		t.Fatalf("unexpected parse: %+v", wi)
+	}
+}
+
+func TestParseNetshWLAN(t *testing.T) {
+	out := "    Name                   : Wi-Fi\r\n    State                  : connected\r\n    SSID                   : Cafe\r\n    BSSID                  : de:ad:be:ef:00:01\r\n    Channel                : 6\r\n    Receive rate (Mbps)    : 144.4\r\n    Transmit rate (Mbps)   : 130\r\n    Signal                 : 80%\r\n"
+	wi := parseNetshWLAN(out)
+	if wi == nil || wi.SSID != "Cafe" || wi.Channel != 6 || wi.PHYRateMbps != 130 || wi.RSSIDBm != -60 || wi.Interface != "Wi-Fi" {
+		t.Fatalf("unexpected parse: %+v", wi)
+	}
+	if parseNetshWLAN("    State : disconnected\r\n") != nil {
+		t.Fatalf("disconnected should yield nil")
+	}
+}
+
+func TestWiFiInfoForRun_ProbesOncePerBatch(t *testing.T) {
+	prev := wifiProbe
+	defer func() { wifiProbe = prev; wifiProbed = false; wifiCached = nil; wifiRunTag = "" }()
+	calls := 0
+	wifiProbe = func() *WiFiInfo { calls++; return &WiFiInfo{SSID: "x"} }
+	wifiProbed = false
+	wifiInfoForRun("r1")
+	wifiInfoForRun("r1")
+	if calls != 1 {
+		t.Fatalf("calls=%d want 1", calls)
+	}
+	wifiInfoForRun("r2")
+	if calls != 2 {
+		t.Fatalf("calls=%d want 2 after new batch", calls)
+	}
+}
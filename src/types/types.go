@@ -4,4 +4,47 @@ type Site struct {
 	Name    string `json:"name"`
 	URL     string `json:"url"`
 	Country string `json:"country"`
+	// Probe selects the measurement: http (default), ping (TCP connect RTT/loss), dns (lookup
+	// time only), soak (long-lived download), iperf3 (raw capacity against the iperf3 server in
+	// URL) or a probe registered with monitor.RegisterMeasurer.
+	Probe string `json:"probe,omitempty"`
+	// Optional request templating. Method is GET (default), HEAD or POST and applies to the
+	// measured request; Headers (e.g. User-Agent, Cookie) and Auth are sent on every request to
+	// the site. Header, body and credential values expand ${VAR} from the environment so
+	// secrets need not live in the sites file.
+	Method  string            `json:"method,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body,omitempty"` // POST body
+	Auth    *Auth             `json:"auth,omitempty"`
+	// Optional expected SHA-256 (hex) of the full response body. When set, the monitor hashes
+	// complete transfers and records a mismatch as a content_mismatch error.
+	SHA256 string `json:"sha256,omitempty"`
+	// Optional proxy override for this target: "direct", or an http://, https://, socks5:// or
+	// socks5h:// URL (user:password allowed, ${VAR} is not expanded). Takes precedence over
+	// --proxy, --proxy-pac, --proxy-bypass and the environment.
+	Proxy string `json:"proxy,omitempty"`
+	// Optional HTTP version to force for https targets: "1.1" or "2" (default: whatever ALPN
+	// negotiates). --protocol-experiment sets it on per-version copies of each target.
+	HTTPVersion string `json:"http_version,omitempty"`
+	// Optional target group, e.g. "CDN", "Intranet" or "SaaS". Lines record it as group and the
+	// analysis summarizes each group separately, so internal and internet paths can be compared.
+	Group string `json:"group,omitempty"`
+	// Optional expected service level of this target, e.g. {"ttfb_ms": 50} for an intranet host
+	// and {"ttfb_ms": 200} for a CDN. Lines record it as slo and the analysis reports each
+	// target's compliance with it.
+	SLO *SLO `json:"slo,omitempty"`
+}
+
+// SLO is a target's expected service level per request; zero fields are not checked.
+type SLO struct {
+	TTFBMs    float64 `json:"ttfb_ms,omitempty"`    // highest acceptable TTFB
+	SpeedKbps float64 `json:"speed_kbps,omitempty"` // lowest acceptable transfer speed
+}
+
+// Auth adds an Authorization header: Type "basic" uses Username/Password, "bearer" uses Token.
+type Auth struct {
+	Type     string `json:"type"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	Token    string `json:"token,omitempty"`
 }